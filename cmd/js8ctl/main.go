@@ -1,17 +1,39 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/dougsko/js8d/pkg/client"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// Exit codes, so scripts can tell failures apart
+const (
+	exitOK           = 0
+	exitUsage        = 1 // Bad flags or an error talking to the daemon
+	exitUnreachable  = 2 // Daemon socket can't be reached
+	exitFailed       = 3 // Daemon returned an error
+	exitRadioError   = 4 // Radio rejected or failed the command
+	exitQueueFull    = 5 // Transmit queue is full
+	exitNotConnected = 6 // No radio connected
+	exitDenied       = 7 // Token missing, unknown or without the role needed
 )
 
 var (
 	socketPath = flag.String("socket", "/tmp/js8d.sock", "Unix socket path")
 	command    = flag.String("cmd", "", "Command to send (e.g., 'STATUS', 'SEND:N0CALL Hello')")
+	protoFlag  = flag.Int("protocol", 0, "Socket protocol version: 1, 2, or 0 to negotiate")
+	jsonOutput = flag.Bool("json", false, "Print the full JSON response")
+	quiet      = flag.Bool("quiet", false, "Print nothing; only the exit code reports the result")
+	token      = flag.String("token", os.Getenv("JS8D_TOKEN"), "Access token, if the daemon requires one (default $JS8D_TOKEN)")
+	timeout    = flag.Duration("timeout", 5*time.Second, "How long to wait for the daemon to answer")
 )
 
 func main() {
@@ -19,7 +41,12 @@ func main() {
 
 	if *socketPath == "" {
 		fmt.Fprintf(os.Stderr, "Socket path is required\n")
-		os.Exit(1)
+		os.Exit(exitUsage)
+	}
+
+	if *protoFlag < 0 || *protoFlag > 2 {
+		fmt.Fprintf(os.Stderr, "Protocol version must be 1 or 2\n")
+		os.Exit(exitUsage)
 	}
 
 	// If no command specified, show interactive help
@@ -33,17 +60,72 @@ func main() {
 	}
 
 	// Create socket client
-	client := client.NewSocketClient(*socketPath)
+	socketClient := client.NewSocketClient(*socketPath).WithTimeout(*timeout)
+	socketClient.SetProtocolVersion(*protoFlag)
+	socketClient.SetToken(*token)
 
 	// Send command
-	response, err := client.SendCommand(*command)
+	response, err := socketClient.SendCommand(*command)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		if !*quiet {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		if errors.Is(err, client.ErrNotConnected) {
+			os.Exit(exitUnreachable)
+		}
+		os.Exit(exitUsage)
 	}
 
 	// Print response
-	fmt.Printf("%s\n", response.String())
+	switch {
+	case *quiet:
+	case *jsonOutput:
+		fmt.Printf("%s\n", response.String())
+	case !response.Success:
+		fmt.Fprintf(os.Stderr, "Error: %s\n", response.Error)
+	default:
+		printData(response.Data)
+	}
+
+	os.Exit(exitCode(response))
+}
+
+// exitCode maps a daemon response to the process exit code
+func exitCode(response *protocol.Response) int {
+	if response.Success {
+		return exitOK
+	}
+	switch response.Code {
+	case protocol.ErrCodeRadio:
+		return exitRadioError
+	case protocol.ErrCodeQueueFull:
+		return exitQueueFull
+	case protocol.ErrCodeNotConnected:
+		return exitNotConnected
+	case protocol.ErrCodeUnauthorized, protocol.ErrCodeForbidden:
+		return exitDenied
+	default:
+		return exitFailed
+	}
+}
+
+// printData prints response data as "key: value" lines, nested values as JSON
+func printData(data map[string]interface{}) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch value := data[key].(type) {
+		case string, bool, float64, nil:
+			fmt.Printf("%s: %v\n", key, value)
+		default:
+			encoded, _ := json.Marshal(value)
+			fmt.Printf("%s: %s\n", key, encoded)
+		}
+	}
 }
 
 func showHelp() {
@@ -55,6 +137,11 @@ func showHelp() {
 	fmt.Println("Options:")
 	fmt.Println("  -socket <path>    Unix socket path (default: /tmp/js8d.sock)")
 	fmt.Println("  -cmd <command>    Command to send")
+	fmt.Println("  -protocol <n>     Socket protocol version 1 or 2 (default: negotiate)")
+	fmt.Println("  -json             Print the full JSON response")
+	fmt.Println("  -quiet            Print nothing, check the exit code")
+	fmt.Println("  -token <token>    Access token (default: $JS8D_TOKEN)")
+	fmt.Println("  -timeout <d>      How long to wait for an answer (default: 5s)")
 	fmt.Println()
 	fmt.Println("Commands:")
 	fmt.Println("  STATUS                    Get daemon status")
@@ -62,13 +149,72 @@ func showHelp() {
 	fmt.Println("  MESSAGES:10               Get last 10 messages")
 	fmt.Println("  SEND:<to> <message>       Send a message")
 	fmt.Println("  SEND:<message>            Send broadcast message")
+	fmt.Println("  SEND:PWR=<n> <to> <msg>   Send a message at n% power")
+	fmt.Println("  RAW:<id>                  Show the bits and tones a message was decoded from")
+	fmt.Println("  REDECODE                  Show the last re-decode of band recordings")
+	fmt.Println("  REDECODE:<from>/<to>      Decode the recordings between two times again, RFC 3339")
+	fmt.Println("  REDECODE:<duration>       Decode the last duration of recordings again, such as 2h")
+	fmt.Println("  REDECODE:CANCEL           Stop a re-decode")
+	fmt.Println("  FILTER                    List the filters dropping decodes")
+	fmt.Println("  FILTER:add:callsign:<c>   Drop every decode from a station")
+	fmt.Println("  FILTER:add:offset:<hz>[:<width>]")
+	fmt.Println("                            Drop decodes near an audio offset, such as a birdie")
+	fmt.Println("  FILTER:delete:<id>        Remove a filter")
+	fmt.Println("  AUTOLIST                  List the callsigns automatic replies skip or are kept to")
+	fmt.Println("  AUTOLIST:block:<c>        Never answer a station, or a prefix such as VK*, automatically")
+	fmt.Println("  AUTOLIST:allow:<c>        Answer only allowed stations automatically")
+	fmt.Println("  AUTOLIST:delete:<id>      Remove an entry")
+	fmt.Println("  BEACON                    Show when the next beacon and station ID are due")
+	fmt.Println("  BEACON ON|OFF|NOW         Turn beacons on or off until restart, or send one now")
+	fmt.Println("  TELEMETRY                 Show when telemetry is next due and the reading it would send")
+	fmt.Println("  TELEMETRY NOW|STATIONS    Send telemetry now, or list the latest heard from each station")
+	fmt.Println("  GET_TELEMETRY <c> <from> <to>")
+	fmt.Println("                            A station's telemetry between two Unix times")
+	fmt.Println("  NET                       Show the net running and its check-ins")
+	fmt.Println("  NET OPEN [@GROUP]         Open a net and send the preamble, on the net group by default")
+	fmt.Println("  NET CLOSE                 Close the net and send the closing")
+	fmt.Println("  NET LIST [n]              List the last n nets")
+	fmt.Println("  NET GET|EXPORT <id>       Show a net's check-ins, or print them as CSV")
+	fmt.Println("  SESSION                   List sessions with other js8d stations")
+	fmt.Println("  SESSION:connect:<c>       Open a session to a station")
+	fmt.Println("  SESSION:send:<id>:<msg>   Write a message to a session, resent until acknowledged")
+	fmt.Println("  SESSION:get:<id>          Show a session and its messages")
+	fmt.Println("  SESSION:disconnect:<id>   Close a session")
 	fmt.Println("  FREQUENCY:<freq>          Set radio frequency")
 	fmt.Println("  RADIO                     Get radio status")
+	fmt.Println("  TUNE [carrier|cat] [PWR=n]")
+	fmt.Println("                            Run the antenna tuner on the current band")
+	fmt.Println("  FIND_OFFSET               Move the TX offset to the quietest spot of the last minute")
+	fmt.Println("  BANDPLAN [freq]           Check TX at a dial frequency against the band plan and license class")
+	fmt.Println("  ANTENNA [port|AUTO]       Show or select the antenna, AUTO to follow the band")
+	fmt.Println("  SENSORS                   Show battery and temperature readings")
+	fmt.Println("  POWER [LOW|NORMAL|AUTO]   Show or hold the power mode, AUTO to follow the schedule")
+	fmt.Println("  PASSBAND [low high|RESET] Show or narrow the audio offsets decoded, RESET for the configured ones")
+	fmt.Println("  PROFILE                   List configuration profiles")
+	fmt.Println("  PROFILE:<name>            Switch to a configuration profile")
+	fmt.Println("  RELOAD                    Reload the configuration file")
+	fmt.Println("  LOGLEVEL                  Show the log level of each component")
+	fmt.Println("  LOGLEVEL:[component:]<l>  Set the log level of one or every component")
 	fmt.Println("  PING                      Test connection")
+	fmt.Println("  SELFTEST [SWEEP] [snr] [message]")
+	fmt.Println("                            Encode and decode a message in software")
+	fmt.Println()
+	fmt.Println("Exit codes:")
+	fmt.Println("  0  Success")
+	fmt.Println("  1  Usage or communication error")
+	fmt.Println("  2  Daemon not reachable")
+	fmt.Println("  3  Command failed")
+	fmt.Println("  4  Radio error")
+	fmt.Println("  5  Transmit queue full")
+	fmt.Println("  6  Radio not connected")
+	fmt.Println("  7  Token missing, unknown or not allowed")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Printf("  %s STATUS\n", os.Args[0])
 	fmt.Printf("  %s 'SEND:N0CALL Hello from js8ctl'\n", os.Args[0])
 	fmt.Printf("  %s MESSAGES:5\n", os.Args[0])
+	fmt.Printf("  %s -json STATUS | jq .data.status.frequency\n", os.Args[0])
+	fmt.Printf("  %s -quiet PING || echo 'js8d is down'\n", os.Args[0])
+	fmt.Printf("  %s -timeout 2m SELFTEST SWEEP\n", os.Args[0])
 	fmt.Printf("  echo 'STATUS' | nc -U /tmp/js8d.sock\n")
 }
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestLogger logs each request through the web logger. Successful ones
+// are logged at debug so the UI's polling stays out of the log unless
+// logging.levels.web is debug; failures are warnings.
+func requestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		fields := map[string]interface{}{
+			"method":  c.Request.Method,
+			"path":    c.Request.URL.Path,
+			"status":  c.Writer.Status(),
+			"latency": time.Since(start).String(),
+			"client":  c.ClientIP(),
+		}
+		if c.Writer.Status() >= http.StatusBadRequest {
+			webLogger.Warn("Request failed", fields)
+			return
+		}
+		webLogger.Debug("Request", fields)
+	}
+}
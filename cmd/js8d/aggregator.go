@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// aggregatorMessages is how many recent messages are kept from each node
+const aggregatorMessages = 100
+
+// remoteNode is another js8d daemon polled over its REST API
+type remoteNode struct {
+	name  string
+	url   *url.URL
+	token string // Sent when polling nodes that require a token
+	proxy *httputil.ReverseProxy
+
+	mutex     sync.RWMutex
+	status    map[string]interface{}
+	messages  []protocol.Message
+	lastPoll  time.Time
+	lastError string
+}
+
+// nodeMessage is a message tagged with the node that heard it
+type nodeMessage struct {
+	protocol.Message
+	Node string `json:"node"`
+}
+
+// Aggregator serves one dashboard for several js8d daemons, such as the
+// nodes of a club station. It has no engine or radio of its own.
+type Aggregator struct {
+	config *config.Config
+	nodes  []*remoteNode
+	client *http.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	webServer *http.Server
+}
+
+// NewAggregator creates an aggregator for the configured nodes
+func NewAggregator(cfg *config.Config) (*Aggregator, error) {
+	if len(cfg.Aggregator.Nodes) == 0 {
+		return nil, fmt.Errorf("aggregator mode needs at least one node in aggregator.nodes")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &Aggregator{
+		config: cfg,
+		client: &http.Client{Timeout: 5 * time.Second},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	for _, node := range cfg.Aggregator.Nodes {
+		u, err := url.Parse(node.URL)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("aggregator node %s: %w", node.Name, err)
+		}
+		a.nodes = append(a.nodes, &remoteNode{
+			name:  node.Name,
+			url:   u,
+			token: node.Token.Value(),
+			proxy: newNodeProxy(u),
+		})
+	}
+
+	if err := a.setupWebServer(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// newNodeProxy forwards /api/v1/nodes/<name>/<path> to <url>/api/v1/<path>
+func newNodeProxy(target *url.URL) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
+			req.URL.Path = target.Path + "/api/v1" + req.URL.Path
+			req.Host = target.Host
+		},
+	}
+}
+
+// Start starts polling the nodes and serving the dashboard
+func (a *Aggregator) Start() error {
+	logger.Infof("Starting js8d aggregator for %d nodes...", len(a.nodes))
+
+	interval := time.Duration(a.config.Aggregator.PollInterval) * time.Second
+	for _, node := range a.nodes {
+		a.wg.Add(1)
+		go func(node *remoteNode) {
+			defer a.wg.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				a.poll(node)
+				select {
+				case <-a.ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(node)
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		webLogger.Infof("Starting aggregator web server on %s", a.webServer.Addr)
+		if err := a.webServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			webLogger.Errorf("Web server error: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops polling and shuts the web server down
+func (a *Aggregator) Stop() error {
+	logger.Infof("Stopping aggregator...")
+
+	a.cancel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.webServer.Shutdown(ctx); err != nil {
+		webLogger.Errorf("Web server shutdown error: %v", err)
+	}
+
+	a.wg.Wait()
+	logger.Infof("Aggregator stopped")
+	return nil
+}
+
+// poll refreshes the status and recent messages of one node
+func (a *Aggregator) poll(node *remoteNode) {
+	var status map[string]interface{}
+	err := a.getJSON(node, "/api/v1/status", &status)
+
+	var messages struct {
+		Messages []protocol.Message `json:"messages"`
+	}
+	if err == nil {
+		err = a.getJSON(node, "/api/v1/messages?limit="+strconv.Itoa(aggregatorMessages), &messages)
+	}
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	node.lastPoll = time.Now()
+	if err != nil {
+		if node.lastError == "" {
+			logger.Warnf("Aggregator: node %s unreachable: %v", node.name, err)
+		}
+		node.lastError = err.Error()
+		return
+	}
+	if node.lastError != "" {
+		logger.Infof("Aggregator: node %s is back", node.name)
+	}
+	node.lastError = ""
+	node.status = status
+	node.messages = messages.Messages
+}
+
+// getJSON fetches a path from a node and decodes the JSON response
+func (a *Aggregator) getJSON(node *remoteNode, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(a.ctx, http.MethodGet, node.url.String()+path, nil)
+	if err != nil {
+		return err
+	}
+	if node.token != "" {
+		req.Header.Set("Authorization", "Bearer "+node.token)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (a *Aggregator) findNode(name string) *remoteNode {
+	for _, node := range a.nodes {
+		if node.name == name {
+			return node
+		}
+	}
+	return nil
+}
+
+// setupWebServer initializes the dashboard and API routes
+func (a *Aggregator) setupWebServer() error {
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(requestLogger(), gin.Recovery(), localize(func() *config.Config { return a.config }))
+
+	if err := loadWebAssets(router, a.config.Web.AssetsDir); err != nil {
+		return err
+	}
+
+	// The aggregator checks its own tokens; proxied requests pass theirs on
+	// to the node, which checks them again
+	auth := authenticate(func() *config.Config { return a.config })
+	router.GET("/", auth, a.handleDashboard)
+
+	api := router.Group("/api/v1", auth)
+	{
+		api.GET("/status", a.handleGetStatus)
+		api.GET("/messages", a.handleGetMessages)
+		api.GET("/nodes", a.handleGetNodes)
+		api.Any("/nodes/:node/*path", a.handleNodeProxy)
+	}
+
+	a.webServer = &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", a.config.Web.BindAddress, a.config.Web.Port),
+		Handler: router,
+	}
+	return nil
+}
+
+// handleDashboard serves the combined dashboard
+func (a *Aggregator) handleDashboard(c *gin.Context) {
+	names := make([]string, 0, len(a.nodes))
+	for _, node := range a.nodes {
+		names = append(names, node.name)
+	}
+	c.HTML(http.StatusOK, "dashboard.html", gin.H{
+		"callsign": a.config.Station.Callsign,
+		"version":  Version,
+		"nodes":    names,
+		"theme":    themeFor(c),
+		"lang":     langFor(c),
+	})
+}
+
+// handleGetNodes returns the last polled status of every node
+func (a *Aggregator) handleGetNodes(c *gin.Context) {
+	nodes := make([]gin.H, 0, len(a.nodes))
+	for _, node := range a.nodes {
+		node.mutex.RLock()
+		entry := gin.H{
+			"name":     node.name,
+			"url":      node.url.String(),
+			"online":   !node.lastPoll.IsZero() && node.lastError == "",
+			"status":   node.status,
+			"messages": len(node.messages),
+		}
+		if !node.lastPoll.IsZero() {
+			entry["last_poll"] = node.lastPoll
+		}
+		if node.lastError != "" {
+			entry["error"] = node.lastError
+		}
+		node.mutex.RUnlock()
+		nodes = append(nodes, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"nodes": nodes,
+		"count": len(nodes),
+	})
+}
+
+// handleGetStatus summarizes the nodes in the shape of a daemon status
+func (a *Aggregator) handleGetStatus(c *gin.Context) {
+	online := 0
+	for _, node := range a.nodes {
+		node.mutex.RLock()
+		if !node.lastPoll.IsZero() && node.lastError == "" {
+			online++
+		}
+		node.mutex.RUnlock()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "aggregating",
+		"version":  Version,
+		"callsign": a.config.Station.Callsign,
+		"nodes":    len(a.nodes),
+		"online":   online,
+	})
+}
+
+// handleGetMessages merges the recent messages of all nodes, newest first
+func (a *Aggregator) handleGetMessages(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	only := c.Query("node")
+
+	var messages []nodeMessage
+	for _, node := range a.nodes {
+		if only != "" && node.name != only {
+			continue
+		}
+		node.mutex.RLock()
+		for _, msg := range node.messages {
+			messages = append(messages, nodeMessage{Message: msg, Node: node.name})
+		}
+		node.mutex.RUnlock()
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Timestamp.After(messages[j].Timestamp)
+	})
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"messages": messages,
+		"count":    len(messages),
+	})
+}
+
+// handleNodeProxy passes a request through to one node's API, so the
+// dashboard can send messages or change frequency on any node
+func (a *Aggregator) handleNodeProxy(c *gin.Context) {
+	node := a.findNode(c.Param("node"))
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "error.unknown_node", c.Param("node"))})
+		return
+	}
+
+	c.Request.URL.Path = c.Param("path")
+	c.Request.URL.RawPath = ""
+	node.proxy.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+
+	"github.com/dougsko/js8d/web"
+	"github.com/gin-gonic/gin"
+)
+
+// loadWebAssets loads the page templates and serves the static files of the
+// web interface, from the copy built into js8d unless dir is set
+func loadWebAssets(router *gin.Engine, dir string) error {
+	files := web.Files(dir)
+
+	templates, err := template.New("").Funcs(templateFuncs).ParseFS(files, "templates/*.html")
+	if err != nil {
+		return fmt.Errorf("failed to load web templates: %w", err)
+	}
+	router.SetHTMLTemplate(templates)
+
+	static, err := fs.Sub(files, "static")
+	if err != nil {
+		return fmt.Errorf("failed to load static files: %w", err)
+	}
+	router.StaticFS("/static", http.FS(static))
+
+	if dir != "" {
+		webLogger.Infof("Serving the web interface from %s", dir)
+	}
+	return nil
+}
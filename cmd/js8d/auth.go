@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// tokenCookie keeps the token a browser logged in with, so the web UI and
+// its WebSockets work without sending headers
+const tokenCookie = "js8d_token"
+
+// authenticate picks the role of a request from its bearer token, the token
+// cookie or a ?token= query parameter, which also sets the cookie so a
+// bookmarked link logs the browser in. Without configured tokens every
+// request is admin.
+func authenticate(getConfig func() *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := getConfig()
+		if !cfg.AuthEnabled() {
+			c.Set("role", protocol.RoleAdmin)
+			c.Next()
+			return
+		}
+
+		token, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" {
+			if token = c.Query("token"); token != "" {
+				c.SetSameSite(http.SameSiteStrictMode)
+				c.SetCookie(tokenCookie, token, 0, "/", "", c.Request.TLS != nil, true)
+			}
+		}
+		if token == "" {
+			token, _ = c.Cookie(tokenCookie)
+		}
+
+		if token == "" {
+			if cfg.Auth.GuestAccess {
+				c.Set("role", protocol.RoleGuest)
+				c.Next()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": tr(c, "error.missing_token"),
+				"code":  protocol.ErrCodeUnauthorized,
+			})
+			return
+		}
+
+		_, role, ok := cfg.TokenRole(token)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": tr(c, "error.unknown_token"),
+				"code":  protocol.ErrCodeUnauthorized,
+			})
+			return
+		}
+		c.Set("role", role)
+		c.Next()
+	}
+}
+
+// requireRole refuses requests authenticated with a lower role
+func requireRole(required string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if !protocol.RoleAllows(role, required) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": tr(c, "error.forbidden", required, role),
+				"code":  protocol.ErrCodeForbidden,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// currentConfig returns the configuration the web server checks tokens
+// against, which the settings page may replace
+func (d *JS8Daemon) currentConfig() *config.Config {
+	d.configMutex.RLock()
+	defer d.configMutex.RUnlock()
+	return d.config
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/dougsko/js8d/pkg/backup"
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/storage"
+)
+
+// maxRestoreSize is the most a restored backup may unpack to
+const maxRestoreSize = 1 << 30
+
+// backupDatabaseName is the name an instance's message database has in a
+// backup
+func backupDatabaseName(cfg *config.Config) string {
+	name := cfg.Instance
+	if name == "" {
+		name = "default"
+	}
+	return path.Join(backup.DatabaseDir, name+".db")
+}
+
+// backupLogFiles lists the log file and its rotated backups
+func backupLogFiles(cfg *config.Config) []backup.File {
+	if cfg.Logging.File == "" {
+		return nil
+	}
+	var files []backup.File
+	if _, err := os.Stat(cfg.Logging.File); err == nil {
+		files = append(files, backup.File{
+			Name: path.Join(backup.LogDir, filepath.Base(cfg.Logging.File)),
+			Path: cfg.Logging.File,
+		})
+	}
+	// Rotated logs are named <name>-<timestamp><ext>, gzipped with compress
+	ext := filepath.Ext(cfg.Logging.File)
+	prefix := strings.TrimSuffix(cfg.Logging.File, ext)
+	rotated, _ := filepath.Glob(prefix + "-*" + ext + "*")
+	for _, file := range rotated {
+		files = append(files, backup.File{
+			Name: path.Join(backup.LogDir, filepath.Base(file)),
+			Path: file,
+		})
+	}
+	return files
+}
+
+// handleBackup downloads a tarball of the configuration file, a snapshot
+// of each instance's message database and the logs. The secrets file is
+// left out.
+func (d *JS8Daemon) handleBackup(c *gin.Context) {
+	if d.configPath == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "error.no_config_file")})
+		return
+	}
+
+	tmp, err := os.MkdirTemp("", "js8d-backup")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "error.backup", err)})
+		return
+	}
+	defer os.RemoveAll(tmp)
+
+	files := []backup.File{{Name: backup.ConfigName, Path: d.configPath}}
+	for _, inst := range d.instances {
+		name := backupDatabaseName(inst.config)
+		snapshot := filepath.Join(tmp, filepath.Base(name))
+		ok, err := inst.coreEngine.SnapshotDatabase(snapshot)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "error.backup", err)})
+			return
+		}
+		if ok {
+			files = append(files, backup.File{Name: name, Path: snapshot})
+		}
+	}
+	files = append(files, backupLogFiles(d.config)...)
+
+	now := time.Now().UTC()
+	manifest := backup.Manifest{
+		Version:  Version,
+		Created:  now,
+		Callsign: d.config.Station.Callsign,
+	}
+	name := fmt.Sprintf("js8d-%s-%s.tar.gz", strings.ToLower(d.config.Station.Callsign), now.Format("20060102-150405"))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	c.Header("Content-Type", "application/gzip")
+	c.Status(http.StatusOK)
+
+	// The headers are gone by the time writing fails, so the download is
+	// cut short and the error only logged
+	if err := backup.Write(c.Writer, manifest, files); err != nil {
+		webLogger.Errorf("Backup failed: %v", err)
+		return
+	}
+	webLogger.Infof("Backup of %d files downloaded", len(files))
+}
+
+// handleRestore restores a backup from handleBackup, uploaded as multipart
+// form data in "backup" or as the request body. The configuration and every
+// database are checked before anything is replaced; the databases are
+// swapped in as js8d restarts. Logs are not restored.
+func (d *JS8Daemon) handleRestore(c *gin.Context) {
+	if d.configPath == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "error.no_config_file")})
+		return
+	}
+
+	var body io.Reader = http.MaxBytesReader(c.Writer, c.Request.Body, maxRestoreSize)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("backup")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	tmp, err := os.MkdirTemp("", "js8d-restore")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "error.restore", err)})
+		return
+	}
+	defer os.RemoveAll(tmp)
+
+	manifest, err := backup.Extract(body, tmp, maxRestoreSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "error.restore_invalid", err)})
+		return
+	}
+
+	// The restored configuration decides where the databases go
+	configFile := filepath.Join(tmp, backup.ConfigName)
+	restored, err := config.LoadConfig(configFile)
+	if err == nil {
+		err = restored.Validate()
+	}
+	var instances []*config.Config
+	if err == nil {
+		instances, err = restored.InstanceConfigs()
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "error.restore_invalid", err)})
+		return
+	}
+
+	databases := map[string]string{}
+	for _, inst := range instances {
+		// Only SQLite files are restored
+		if inst.Storage.Backend != "" && !strings.EqualFold(inst.Storage.Backend, storage.BackendSQLite) {
+			continue
+		}
+		src := filepath.Join(tmp, filepath.FromSlash(backupDatabaseName(inst)))
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		if err := storage.ValidateDatabase(src); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "error.restore_invalid", err)})
+			return
+		}
+		databases[src] = inst.Storage.DatabasePath
+	}
+
+	data, err := os.ReadFile(configFile)
+	if err == nil {
+		d.configMutex.Lock()
+		err = os.WriteFile(d.configPath, data, 0644)
+		d.configMutex.Unlock()
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "error.write_config", err)})
+		return
+	}
+	for src, dbPath := range databases {
+		if err := storage.StageRestore(src, dbPath); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "error.restore", err)})
+			return
+		}
+	}
+	webLogger.Infof("Restored backup of %s from %s", manifest.Callsign, manifest.Created.Format(time.RFC3339))
+
+	resp, err := d.clientFor(c).SendCommand("RESTART")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "error.restart", err)})
+		return
+	}
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": resp.Error})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "restored",
+		"manifest":  manifest,
+		"databases": len(databases),
+	})
+}
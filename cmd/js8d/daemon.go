@@ -3,15 +3,15 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/dougsko/js8d/pkg/client"
 	"github.com/dougsko/js8d/pkg/config"
 	"github.com/dougsko/js8d/pkg/engine"
+	"github.com/dougsko/js8d/pkg/hardware"
+	"github.com/dougsko/js8d/pkg/protocol"
 )
 
 // JS8Daemon represents the main daemon with Unix socket architecture
@@ -23,36 +23,40 @@ type JS8Daemon struct {
 	wg         sync.WaitGroup
 	verbose    bool
 
-	// Core components
-	coreEngine   *engine.CoreEngine
-	socketClient *client.SocketClient
-	webServer    *http.Server
+	// configMutex guards config while the settings page saves changes
+	configMutex sync.RWMutex
 
-	// Socket path
-	socketPath string
+	// Core components, one engine per configured instance
+	instances []*engineInstance
+	webServer *http.Server
 }
 
-// NewJS8Daemon creates a new daemon instance with config path for reloading
-func NewJS8Daemon(cfg *config.Config, configPath string, verbose bool) (*JS8Daemon, error) {
+// NewJS8Daemon creates a new daemon instance with config path for reloading.
+// Its engines ask lifecycle for restarts and reboots.
+func NewJS8Daemon(cfg *config.Config, configPath string, verbose bool, lifecycle *lifecycle) (*JS8Daemon, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	socketPath := cfg.API.UnixSocket
-	if socketPath == "" {
-		socketPath = "/tmp/js8d.sock"
+	configs, err := cfg.InstanceConfigs()
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("invalid instances: %w", err)
 	}
 
 	daemon := &JS8Daemon{
-		config:       cfg,
-		configPath:   configPath,
-		ctx:          ctx,
-		cancel:       cancel,
-		verbose:      verbose,
-		socketPath:   socketPath,
-		socketClient: client.NewSocketClient(socketPath),
+		config:     cfg,
+		configPath: configPath,
+		ctx:        ctx,
+		cancel:     cancel,
+		verbose:    verbose,
 	}
 
-	// Create core engine with config path for reloading
-	daemon.coreEngine = engine.NewCoreEngine(cfg, socketPath, configPath)
+	// Create core engines with config path for reloading
+	for _, instanceConfig := range configs {
+		if instanceConfig.API.UnixSocket == "" {
+			instanceConfig.API.UnixSocket = config.DefaultUnixSocket
+		}
+		daemon.instances = append(daemon.instances, newEngineInstance(ctx, instanceConfig, configPath, lifecycle))
+	}
 
 	// Initialize web server
 	if err := daemon.setupWebServer(); err != nil {
@@ -64,19 +68,26 @@ func NewJS8Daemon(cfg *config.Config, configPath string, verbose bool) (*JS8Daem
 
 // Start starts the daemon
 func (d *JS8Daemon) Start() error {
-	log.Printf("Starting js8d daemon...")
+	logger.Infof("Starting js8d daemon...")
 
-	// Start core engine first
-	if err := d.coreEngine.Start(); err != nil {
-		return fmt.Errorf("failed to start core engine: %w", err)
+	// Start core engines first
+	for _, inst := range d.instances {
+		if len(d.instances) > 1 {
+			logger.Infof("Starting instance %s (%s on %s)", inst.name, hardware.RigModelName(inst.config.Radio.Model), inst.config.API.UnixSocket)
+		}
+		if err := inst.coreEngine.Start(); err != nil {
+			return fmt.Errorf("failed to start core engine %s: %w", inst.name, err)
+		}
 	}
 
-	// Wait a moment for socket to be ready
+	// Wait a moment for sockets to be ready
 	time.Sleep(100 * time.Millisecond)
 
-	// Test socket connection
-	if !d.socketClient.IsConnected() {
-		return fmt.Errorf("failed to connect to core engine socket")
+	// Test socket connections
+	for _, inst := range d.instances {
+		if !inst.socketClient.IsConnected() {
+			return fmt.Errorf("failed to connect to core engine socket %s", inst.config.API.UnixSocket)
+		}
 	}
 
 	// Start web server
@@ -84,9 +95,9 @@ func (d *JS8Daemon) Start() error {
 	go func() {
 		defer d.wg.Done()
 		addr := fmt.Sprintf("%s:%d", d.config.Web.BindAddress, d.config.Web.Port)
-		log.Printf("Starting web server on %s", addr)
+		webLogger.Infof("Starting web server on %s", addr)
 		if err := d.webServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("Web server error: %v", err)
+			webLogger.Errorf("Web server error: %v", err)
 		}
 	}()
 
@@ -97,7 +108,7 @@ func (d *JS8Daemon) Start() error {
 
 // Stop stops the daemon gracefully
 func (d *JS8Daemon) Stop() error {
-	log.Printf("Stopping daemon...")
+	logger.Infof("Stopping daemon...")
 
 	d.cancel()
 
@@ -106,21 +117,30 @@ func (d *JS8Daemon) Stop() error {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		if err := d.webServer.Shutdown(ctx); err != nil {
-			log.Printf("Web server shutdown error: %v", err)
+			webLogger.Errorf("Web server shutdown error: %v", err)
 		}
 	}
 
-	// Stop core engine
-	if d.coreEngine != nil {
-		if err := d.coreEngine.Stop(); err != nil {
-			log.Printf("Core engine shutdown error: %v", err)
-		}
+	// Stop core engines together so every rig gets the same deadline to
+	// finish its transmission
+	ctx, cancel := context.WithTimeout(context.Background(), engine.ShutdownTimeout)
+	defer cancel()
+	var engines sync.WaitGroup
+	for _, inst := range d.instances {
+		engines.Add(1)
+		go func(inst *engineInstance) {
+			defer engines.Done()
+			if err := inst.coreEngine.Shutdown(ctx); err != nil {
+				logger.Errorf("Core engine %s shutdown error: %v", inst.name, err)
+			}
+		}(inst)
 	}
+	engines.Wait()
 
 	// Wait for goroutines to finish
 	d.wg.Wait()
 
-	log.Printf("Daemon stopped")
+	logger.Infof("Daemon stopped")
 	return nil
 }
 
@@ -128,46 +148,159 @@ func (d *JS8Daemon) Stop() error {
 func (d *JS8Daemon) setupWebServer() error {
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
-	router.Use(gin.Logger(), gin.Recovery())
+	router.Use(requestLogger(), gin.Recovery(), localize(d.currentConfig))
+
+	// Serve the web interface
+	if err := loadWebAssets(router, d.config.Web.AssetsDir); err != nil {
+		return err
+	}
 
-	// Serve static files
-	router.Static("/static", "./web/static")
-	router.LoadHTMLGlob("web/templates/*")
+	// Every page and API call needs a token once auth.tokens is set
+	auth := authenticate(d.currentConfig)
+	operator := requireRole(protocol.RoleOperator)
+	admin := requireRole(protocol.RoleAdmin)
 
 	// Main web interface
-	router.GET("/", d.handleHome)
-	router.GET("/settings", d.handleSettings)
-
-	// API routes
-	api := router.Group("/api/v1")
+	router.GET("/", auth, d.selectInstance, d.handleHome)
+	router.GET("/settings", auth, admin, d.handleSettings)
+	router.GET("/map", auth, d.selectInstance, d.handleMap)
+	router.GET("/awards", auth, d.selectInstance, d.handleAwards)
+	router.GET("/forms", auth, d.selectInstance, d.handleForms)
+	router.GET("/files", auth, d.selectInstance, d.handleFiles)
+	router.GET("/m", auth, d.selectInstance, d.handleMobile)
+
+	// Audio health for Prometheus, every instance at once
+	router.GET("/metrics", auth, d.handleMetrics)
+
+	// API routes, each for the instance picked by selectInstance. Viewing
+	// needs any role, transmitting and tuning needs operator, and changing
+	// the configuration or testing hardware needs admin.
+	api := router.Group("/api/v1", auth, d.selectInstance)
 	{
+		api.GET("/instances", d.handleGetInstances)
+		api.POST("/instances/select", d.handleSelectInstance)
 		api.GET("/status", d.handleGetStatus)
 		api.GET("/messages", d.handleGetMessages)
-		api.POST("/messages", d.handleSendMessage)
+		api.POST("/messages", operator, d.handleSendMessage)
 		api.GET("/messages/history", d.handleGetMessageHistory)
 		api.GET("/messages/conversations", d.handleGetConversations)
-		api.POST("/messages/mark-read", d.handleMarkMessagesRead)
+		api.POST("/messages/mark-read", operator, d.handleMarkMessagesRead)
 		api.GET("/messages/search", d.handleSearchMessages)
 		api.GET("/messages/stats", d.handleGetMessageStats)
-		api.POST("/messages/cleanup", d.handleCleanupMessages)
+		api.GET("/messages/:id/raw", d.handleGetRawFrame)
+		api.GET("/stats/summary", d.handleGetStatsSummary)
+		api.GET("/stats/timeseries", d.handleGetStatsTimeseries)
+		api.GET("/stats/noise", d.handleGetBandNoise)
+		api.GET("/messages/queue", d.handleGetTXQueue)
+		api.POST("/messages/cleanup", admin, d.handleCleanupMessages)
+		api.GET("/stations", d.handleGetStations)
+		api.GET("/stations/:callsign", d.handleGetStation)
+		api.GET("/stations/:callsign/snr-history", d.handleGetSNRHistory)
+		api.PUT("/stations/:callsign", operator, d.handleUpdateStation)
+		api.GET("/macros", d.handleGetMacros)
+		api.POST("/macros/expand", d.handleExpandMacros)
+		api.GET("/macros/:name", d.handleGetMacro)
+		api.PUT("/macros/:name", operator, d.handleSaveMacro)
+		api.DELETE("/macros/:name", operator, d.handleDeleteMacro)
+		api.GET("/filters", d.handleGetFilters)
+		api.POST("/filters", operator, d.handleAddFilter)
+		api.DELETE("/filters/:id", operator, d.handleDeleteFilter)
+		api.GET("/auto-list", d.handleGetAutoList)
+		api.POST("/auto-list", operator, d.handleAddAutoList)
+		api.DELETE("/auto-list/:id", operator, d.handleDeleteAutoList)
+		api.GET("/forms", d.handleGetForms)
+		api.POST("/forms", operator, d.handleSendForm)
+		api.GET("/forms/templates", d.handleGetFormTemplates)
+		api.GET("/files", d.handleGetFiles)
+		api.POST("/files", operator, d.handleSendFile)
+		api.GET("/files/:id", d.handleDownloadFile)
+		api.POST("/files/:id/cancel", operator, d.handleCancelFile)
+		api.GET("/sessions", d.handleGetSessions)
+		api.POST("/sessions", operator, d.handleConnectSession)
+		api.GET("/sessions/:id", d.handleGetSession)
+		api.POST("/sessions/:id/messages", operator, d.handleSessionMessage)
+		api.POST("/sessions/:id/disconnect", operator, d.handleDisconnectSession)
+		api.GET("/map", d.handleGetMap)
+		api.GET("/propagation", d.handleGetPropagation)
 		api.GET("/radio", d.handleGetRadio)
-		api.PUT("/radio/frequency", d.handleSetFrequency)
-		api.POST("/abort", d.handleAbortTransmission)
-		api.GET("/config", d.handleGetConfig)
-		api.POST("/config", d.handleSaveConfig)
-		api.POST("/config/reload", d.handleReloadConfig)
-		api.POST("/radio/retry-connection", d.handleRetryRadioConnection)
-		api.POST("/radio/test-cat", d.handleTestCAT)
-		api.POST("/radio/test-ptt", d.handleTestPTT)
-		api.POST("/radio/test-ptt-off", d.handleTestPTTOff)
+		api.PUT("/radio/frequency", operator, d.handleSetFrequency)
+		api.POST("/abort", operator, d.handleAbortTransmission)
+		api.PUT("/auto", operator, d.handleSetAuto)
+		api.GET("/qso", d.handleGetQSO)
+		api.PUT("/qso", operator, d.handleSetQSO)
+		api.POST("/qso/stop", operator, d.handleStopQSO)
+		api.POST("/qso/next", operator, d.handleNextQSO)
+		api.GET("/beacon", d.handleGetBeacon)
+		api.PUT("/beacon", operator, d.handleSetBeacon)
+		api.POST("/beacon/now", operator, d.handleSendBeacon)
+		api.GET("/telemetry", d.handleGetTelemetry)
+		api.POST("/telemetry/now", operator, d.handleSendTelemetry)
+		api.GET("/telemetry/stations", d.handleGetTelemetryStations)
+		api.GET("/telemetry/stations/:callsign", d.handleGetTelemetryHistory)
+		api.GET("/net", d.handleGetNet)
+		api.POST("/net/open", operator, d.handleOpenNet)
+		api.POST("/net/close", operator, d.handleCloseNet)
+		api.GET("/nets", d.handleGetNets)
+		api.GET("/nets/:id", d.handleGetNetCheckins)
+		api.GET("/nets/:id/export", d.handleExportNet)
+		api.GET("/answers", d.handleGetAnswers)
+		api.GET("/rate-limits", d.handleGetRateLimits)
+		api.GET("/log", d.handleGetLog)
+		api.GET("/log/adif", d.handleExportADIF)
+		api.GET("/awards", d.handleGetAwards)
+		api.GET("/qsl", d.handleGetQSL)
+		api.POST("/qsl/:service/upload", operator, d.handleUploadQSL)
+		api.POST("/qsl/:service/mark", operator, d.handleMarkQSL)
+		api.GET("/dxcc", d.handleGetDXCC)
+		api.GET("/dxcc/:callsign", d.handleLookupDXCC)
+		api.POST("/dxcc/refresh", admin, d.handleRefreshDXCC)
+		api.GET("/config", admin, d.handleGetConfig)
+		api.POST("/config", admin, d.handleSaveConfig)
+		api.POST("/config/reload", admin, d.handleReloadConfig)
+		api.GET("/config/:section", admin, d.handleGetConfigSection)
+		api.PUT("/config/:section", admin, d.handleUpdateConfigSection)
+		api.GET("/profiles", d.handleGetProfiles)
+		api.POST("/profiles/select", operator, d.handleSelectProfile)
+		api.POST("/radio/retry-connection", operator, d.handleRetryRadioConnection)
+		api.POST("/radio/tune", operator, d.handleTune)
+		api.POST("/radio/find-offset", operator, d.handleFindOffset)
+		api.GET("/radio/bandplan", d.handleGetBandPlan)
+		api.GET("/antenna", d.handleGetAntenna)
+		api.PUT("/antenna", operator, d.handleSetAntenna)
+		api.GET("/sensors", d.handleGetSensors)
+		api.GET("/power", d.handleGetPower)
+		api.PUT("/power", operator, d.handleSetPower)
+		api.GET("/dsp/passband", d.handleGetPassband)
+		api.PUT("/dsp/passband", operator, d.handleSetPassband)
+		api.POST("/radio/test-cat", admin, d.handleTestCAT)
+		api.GET("/radio/models", admin, d.handleGetRigModels)
+		api.GET("/radio/detect", admin, d.handleDetectRigs)
+		api.POST("/radio/test-ptt", admin, d.handleTestPTT)
+		api.POST("/radio/test-ptt-off", admin, d.handleTestPTTOff)
 		api.GET("/audio/stats", d.handleGetAudioStats)
+		api.GET("/snippets/:name", d.handleGetSnippet)
+		api.GET("/recordings", d.handleGetRecordings)
+		api.GET("/recordings/:name", d.handleGetRecording)
+		api.GET("/recordings/:name/decodes", d.handleGetRecordingDecodes)
+		api.GET("/redecode", d.handleGetRedecode)
+		api.POST("/redecode", operator, d.handleStartRedecode)
+		api.DELETE("/redecode", operator, d.handleCancelRedecode)
 		api.GET("/audio/test", d.handleTestAudioData)
-		api.GET("/audio/devices", d.handleGetAudioDevices)
-		api.GET("/serial/devices", d.handleGetSerialDevices)
+		api.GET("/audio/devices", admin, d.handleGetAudioDevices)
+		api.GET("/serial/devices", admin, d.handleGetSerialDevices)
+		api.GET("/system/update", admin, d.handleCheckUpdate)
+		api.POST("/system/selftest", admin, d.handleSelfTest)
+		api.POST("/system/restart", admin, d.handleRestart)
+		api.POST("/system/reboot", admin, d.handleReboot)
+		api.GET("/system/backup", admin, d.handleBackup)
+		api.POST("/system/restore", admin, d.handleRestore)
 	}
 
 	// WebSocket endpoints
-	router.GET("/ws/audio", d.handleAudioWebSocket)
+	ws := router.Group("/ws", auth, d.selectInstance)
+	ws.GET("/audio", d.handleAudioWebSocket)
+	ws.GET("/audio-pcm", d.handleAudioPCMWebSocket)
+	ws.GET("/messages", d.handleMessagesWebSocket)
 
 	addr := fmt.Sprintf("%s:%d", d.config.Web.BindAddress, d.config.Web.Port)
 	d.webServer = &http.Server{
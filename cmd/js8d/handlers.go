@@ -1,12 +1,17 @@
 package main
 
 import (
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"mime"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,21 +20,93 @@ import (
 	"github.com/gorilla/websocket"
 	"gopkg.in/yaml.v2"
 
+	"github.com/dougsko/js8d/pkg/audio"
+	"github.com/dougsko/js8d/pkg/config"
 	"github.com/dougsko/js8d/pkg/hardware"
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/storage"
 )
 
 // handleHome serves the main web interface
 func (d *JS8Daemon) handleHome(c *gin.Context) {
+	inst := d.instanceFor(c)
 	c.HTML(http.StatusOK, "index.html", gin.H{
-		"callsign": d.config.Station.Callsign,
-		"grid":     d.config.Station.Grid,
+		"callsign":  inst.config.Station.Callsign,
+		"grid":      inst.config.Station.Grid,
+		"version":   Version,
+		"instance":  inst.name,
+		"instances": d.instanceNames(),
+		"profiles":  inst.config.ProfileNames(),
+		"theme":     themeFor(c),
+		"lang":      langFor(c),
+	})
+}
+
+// handleMap serves the map of heard stations
+func (d *JS8Daemon) handleMap(c *gin.Context) {
+	inst := d.instanceFor(c)
+	c.HTML(http.StatusOK, "map.html", gin.H{
+		"callsign": inst.config.Station.Callsign,
+		"grid":     inst.config.Station.Grid,
+		"version":  Version,
+		"theme":    themeFor(c),
+		"lang":     langFor(c),
+	})
+}
+
+// handleAwards serves the award progress page
+func (d *JS8Daemon) handleAwards(c *gin.Context) {
+	inst := d.instanceFor(c)
+	c.HTML(http.StatusOK, "awards.html", gin.H{
+		"callsign": inst.config.Station.Callsign,
+		"grid":     inst.config.Station.Grid,
+		"version":  Version,
+		"theme":    themeFor(c),
+		"lang":     langFor(c),
+	})
+}
+
+// handleForms serves the page for sending and reading forms
+func (d *JS8Daemon) handleForms(c *gin.Context) {
+	inst := d.instanceFor(c)
+	c.HTML(http.StatusOK, "forms.html", gin.H{
+		"callsign": inst.config.Station.Callsign,
+		"grid":     inst.config.Station.Grid,
+		"version":  Version,
+		"theme":    themeFor(c),
+		"lang":     langFor(c),
+	})
+}
+
+// handleFiles serves the page for sending files and following transfers
+func (d *JS8Daemon) handleFiles(c *gin.Context) {
+	inst := d.instanceFor(c)
+	c.HTML(http.StatusOK, "files.html", gin.H{
+		"callsign": inst.config.Station.Callsign,
+		"grid":     inst.config.Station.Grid,
 		"version":  Version,
+		"theme":    themeFor(c),
+		"lang":     langFor(c),
+		"enabled":  inst.config.Files.Enabled,
+		"max_size": inst.config.Files.MaxSize,
+	})
+}
+
+// handleMobile serves the compact layout for phones
+func (d *JS8Daemon) handleMobile(c *gin.Context) {
+	inst := d.instanceFor(c)
+	c.HTML(http.StatusOK, "mobile.html", gin.H{
+		"callsign": inst.config.Station.Callsign,
+		"grid":     inst.config.Station.Grid,
+		"version":  Version,
+		"theme":    themeFor(c),
+		"lang":     langFor(c),
 	})
 }
 
 // handleGetStatus returns daemon status via socket
 func (d *JS8Daemon) handleGetStatus(c *gin.Context) {
-	status, err := d.socketClient.GetStatus()
+	status, err := d.clientFor(c).GetStatus()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -38,15 +115,26 @@ func (d *JS8Daemon) handleGetStatus(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status":    "running",
-		"version":   Version,
-		"callsign":  status.Callsign,
-		"grid":      status.Grid,
-		"uptime":    status.Uptime,
-		"frequency": status.Frequency,
-		"mode":      status.Mode,
-		"ptt":       status.PTT,
-		"connected": status.Connected,
+		"status":     "running",
+		"instance":   d.instanceFor(c).name,
+		"version":    Version,
+		"callsign":   status.Callsign,
+		"grid":       status.Grid,
+		"uptime":     status.Uptime,
+		"started":    status.StartTime,
+		"frequency":  status.Frequency,
+		"mode":       status.Mode,
+		"ptt":        status.PTT,
+		"connected":  status.Connected,
+		"auto":       status.Auto,
+		"qso_script": status.QSOScript,
+		"qso":        status.QSO,
+		"restarts":   status.Restarts,
+		"clock":      status.Clock,
+		"gps":        status.GPS,
+		"sensors":    status.Sensors,
+		"low_power":  status.LowPower,
+		"band_plan":  status.BandPlan,
 	})
 }
 
@@ -59,7 +147,7 @@ func (d *JS8Daemon) handleGetMessages(c *gin.Context) {
 		limit = 50
 	}
 
-	messages, err := d.socketClient.GetMessages(limit)
+	messages, err := d.clientFor(c).GetMessages(limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -78,6 +166,7 @@ func (d *JS8Daemon) handleSendMessage(c *gin.Context) {
 	var req struct {
 		To      string `json:"to"`
 		Message string `json:"message" binding:"required"`
+		Power   int    `json:"power"` // Percent of full power, 0 for the rig's setting
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -85,7 +174,7 @@ func (d *JS8Daemon) handleSendMessage(c *gin.Context) {
 		return
 	}
 
-	message, err := d.socketClient.SendMessage(req.To, req.Message)
+	message, err := d.clientFor(c).SendMessageAtPower(req.To, req.Message, req.Power)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -101,7 +190,7 @@ func (d *JS8Daemon) handleSendMessage(c *gin.Context) {
 
 // handleGetRadio returns radio status via socket
 func (d *JS8Daemon) handleGetRadio(c *gin.Context) {
-	radioStatus, err := d.socketClient.GetRadioStatus()
+	radioStatus, err := d.clientFor(c).GetRadioStatus()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -123,7 +212,7 @@ func (d *JS8Daemon) handleSetFrequency(c *gin.Context) {
 		return
 	}
 
-	if err := d.socketClient.SetFrequency(req.Frequency); err != nil {
+	if err := d.clientFor(c).SetFrequency(req.Frequency); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
@@ -138,7 +227,7 @@ func (d *JS8Daemon) handleSetFrequency(c *gin.Context) {
 
 // handleAbortTransmission aborts any ongoing transmission and turns off PTT
 func (d *JS8Daemon) handleAbortTransmission(c *gin.Context) {
-	if err := d.socketClient.AbortTransmission(); err != nil {
+	if err := d.clientFor(c).AbortTransmission(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
@@ -150,499 +239,599 @@ func (d *JS8Daemon) handleAbortTransmission(c *gin.Context) {
 	})
 }
 
-// handleSettings serves the settings page
-func (d *JS8Daemon) handleSettings(c *gin.Context) {
-	c.HTML(http.StatusOK, "settings.html", gin.H{
-		"version": Version,
-	})
-}
+// handleSetAuto turns automatic replies to queries like SNR? on or off
+func (d *JS8Daemon) handleSetAuto(c *gin.Context) {
+	var req struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-// handleGetConfig returns the current configuration
-func (d *JS8Daemon) handleGetConfig(c *gin.Context) {
-	// Marshal to YAML then unmarshal to JSON via map to ensure
-	// field names match the YAML structure and JSON compatibility
-	yamlData, err := yaml.Marshal(d.config)
+	setting := "OFF"
+	if *req.Enabled {
+		setting = "ON"
+	}
+	resp, err := d.clientFor(c).SendCommand("AUTO " + setting)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to marshal config: %v", err),
+			"error": tr(c, "error.set_auto", err),
 		})
 		return
 	}
 
-	// Unmarshal YAML to interface{} then convert to JSON-compatible map
-	var yamlConfig interface{}
-	if err := yaml.Unmarshal(yamlData, &yamlConfig); err != nil {
+	if !resp.Success {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to unmarshal config: %v", err),
+			"error": resp.Error,
 		})
 		return
 	}
 
-	// Convert map[interface{}]interface{} to map[string]interface{} recursively
-	configMap := convertYamlToJson(yamlConfig)
+	c.JSON(http.StatusOK, resp.Data)
+}
 
-	c.JSON(http.StatusOK, configMap)
+// handleGetQSO reports whether stations answering a CQ get a scripted QSO,
+// and the one running
+func (d *JS8Daemon) handleGetQSO(c *gin.Context) {
+	d.sendQSOCommand(c, "QSO", http.StatusBadRequest)
 }
 
-// convertYamlToJson converts YAML map[interface{}]interface{} to JSON-compatible map[string]interface{}
-func convertYamlToJson(i interface{}) interface{} {
-	switch x := i.(type) {
-	case map[interface{}]interface{}:
-		m2 := map[string]interface{}{}
-		for k, v := range x {
-			m2[k.(string)] = convertYamlToJson(v)
-		}
-		return m2
-	case []interface{}:
-		for i, v := range x {
-			x[i] = convertYamlToJson(v)
-		}
+// handleSetQSO turns scripted QSOs on or off
+func (d *JS8Daemon) handleSetQSO(c *gin.Context) {
+	var req struct {
+		Enabled *bool `json:"enabled" binding:"required"`
 	}
-	return i
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	setting := "OFF"
+	if *req.Enabled {
+		setting = "ON"
+	}
+	d.sendQSOCommand(c, "QSO "+setting, http.StatusBadRequest)
+}
+
+// handleStopQSO ends the scripted QSO running, leaving the station to the
+// operator
+func (d *JS8Daemon) handleStopQSO(c *gin.Context) {
+	d.sendQSOCommand(c, "QSO STOP", http.StatusConflict)
 }
 
-// deepMerge recursively merges source map into destination map
-func deepMerge(dst, src map[string]interface{}) map[string]interface{} {
-	result := make(map[string]interface{})
+// handleNextQSO sends the next step of the scripted QSO without waiting for
+// the reply
+func (d *JS8Daemon) handleNextQSO(c *gin.Context) {
+	d.sendQSOCommand(c, "QSO NEXT", http.StatusConflict)
+}
 
-	// First copy all dst values
-	for k, v := range dst {
-		result[k] = v
+// sendQSOCommand sends a QSO command and returns its result, answering
+// invalidStatus when the engine rejects the request
+func (d *JS8Daemon) sendQSOCommand(c *gin.Context, command string, invalidStatus int) {
+	resp, err := d.clientFor(c).SendCommand(command)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.qso_command", err),
+		})
+		return
 	}
 
-	// Then merge src values
-	for k, v := range src {
-		if srcMap, srcOk := v.(map[string]interface{}); srcOk {
-			if dstMap, dstOk := result[k].(map[string]interface{}); dstOk {
-				// Both are maps, merge recursively
-				result[k] = deepMerge(dstMap, srcMap)
-			} else {
-				// Destination is not a map, replace with source
-				result[k] = v
-			}
-		} else {
-			// Source is not a map, replace destination
-			result[k] = v
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = invalidStatus
 		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
 	}
 
-	return result
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetBeacon reports whether beacons go out, when the next is due and
+// the station ID timer
+func (d *JS8Daemon) handleGetBeacon(c *gin.Context) {
+	d.sendBeaconCommand(c, "BEACON")
 }
 
-// handleSaveConfig saves the configuration to file
-func (d *JS8Daemon) handleSaveConfig(c *gin.Context) {
-	var newConfig map[string]interface{}
-	if err := c.ShouldBindJSON(&newConfig); err != nil {
+// handleSetBeacon turns beacons on or off until js8d restarts
+func (d *JS8Daemon) handleSetBeacon(c *gin.Context) {
+	var req struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Get current configuration and convert to map format
-	yamlData, err := yaml.Marshal(d.config)
+	setting := "OFF"
+	if *req.Enabled {
+		setting = "ON"
+	}
+	d.sendBeaconCommand(c, "BEACON "+setting)
+}
+
+// handleSendBeacon sends a beacon now
+func (d *JS8Daemon) handleSendBeacon(c *gin.Context) {
+	d.sendBeaconCommand(c, "BEACON NOW")
+}
+
+// sendBeaconCommand sends a BEACON command and returns its result
+func (d *JS8Daemon) sendBeaconCommand(c *gin.Context, command string) {
+	resp, err := d.clientFor(c).SendCommand(command)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to marshal current config: %v", err),
+			"error": tr(c, "error.beacon_command", err),
 		})
 		return
 	}
 
-	var currentConfig interface{}
-	if err := yaml.Unmarshal(yamlData, &currentConfig); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to unmarshal current config: %v", err),
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
 		})
 		return
 	}
 
-	// Convert to JSON-compatible format
-	currentConfigMap := convertYamlToJson(currentConfig).(map[string]interface{})
-
-	// Merge new configuration into current configuration
-	mergedConfig := deepMerge(currentConfigMap, newConfig)
+	c.JSON(http.StatusOK, resp.Data)
+}
 
-	// Validate audio device configuration if present
-	if audioConfig, exists := newConfig["Audio"]; exists {
-		if audioMap, ok := audioConfig.(map[string]interface{}); ok {
-			warnings := []string{}
+// handleGetTelemetry reports the telemetry schedule and the reading this
+// station would send now
+func (d *JS8Daemon) handleGetTelemetry(c *gin.Context) {
+	d.sendTelemetryCommand(c, "TELEMETRY")
+}
 
-			// Validate input device if specified
-			if inputDevice, exists := audioMap["InputDevice"]; exists {
-				if deviceStr, ok := inputDevice.(string); ok && deviceStr != "" && deviceStr != "default" {
-					if err := validateAudioDevice(deviceStr, "input"); err != nil {
-						warning := fmt.Sprintf("Input device '%s' validation failed: %v", deviceStr, err)
-						log.Printf("Audio validation warning: %s", warning)
-						warnings = append(warnings, warning)
-					}
-				}
-			}
+// handleSendTelemetry sends this station's telemetry now
+func (d *JS8Daemon) handleSendTelemetry(c *gin.Context) {
+	d.sendTelemetryCommand(c, "TELEMETRY NOW")
+}
 
-			// Validate output device if specified
-			if outputDevice, exists := audioMap["OutputDevice"]; exists {
-				if deviceStr, ok := outputDevice.(string); ok && deviceStr != "" && deviceStr != "default" {
-					if err := validateAudioDevice(deviceStr, "output"); err != nil {
-						warning := fmt.Sprintf("Output device '%s' validation failed: %v", deviceStr, err)
-						log.Printf("Audio validation warning: %s", warning)
-						warnings = append(warnings, warning)
-					}
-				}
-			}
+// handleGetTelemetryStations lists the latest telemetry heard from each
+// station
+func (d *JS8Daemon) handleGetTelemetryStations(c *gin.Context) {
+	d.sendTelemetryCommand(c, "TELEMETRY STATIONS")
+}
 
-			// Return warnings if any validation failed
-			if len(warnings) > 0 {
-				c.JSON(http.StatusOK, gin.H{
-					"status":   "saved_with_warnings",
-					"warnings": warnings,
-				})
-				// Continue with save despite warnings
-			}
-		}
+// handleGetTelemetryHistory returns a station's telemetry over a time
+// range, oldest first, for charting
+func (d *JS8Daemon) handleGetTelemetryHistory(c *gin.Context) {
+	from, to, err := parseRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	d.sendTelemetryCommand(c, fmt.Sprintf("GET_TELEMETRY %s %d %d", c.Param("callsign"), from.Unix(), to.Unix()))
+}
 
-	// Convert merged config to YAML and save to file
-	yamlData, err = yaml.Marshal(mergedConfig)
+// sendTelemetryCommand sends a telemetry command and returns its result
+func (d *JS8Daemon) sendTelemetryCommand(c *gin.Context, command string) {
+	resp, err := d.clientFor(c).SendCommand(command)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to marshal config: %v", err),
+			"error": tr(c, "error.telemetry_command", err),
 		})
 		return
 	}
 
-	// Determine config file path from daemon's loaded config
-	configPath := d.configPath
-	if configPath == "" {
-		// Fallback if config path not available
-		if len(os.Args) > 2 && os.Args[1] == "-config" {
-			configPath = os.Args[2]
-		} else {
-			configPath = "/tmp/claude/config.yaml"
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = http.StatusBadRequest
 		}
-	}
-
-	// Write to file
-	if err := os.WriteFile(configPath, yamlData, 0644); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to write config file: %v", err),
+		c.JSON(status, gin.H{
+			"error": resp.Error,
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status": "saved",
-		"path":   configPath,
-	})
+	c.JSON(http.StatusOK, resp.Data)
 }
 
-// handleReloadConfig triggers daemon to reload configuration
-func (d *JS8Daemon) handleReloadConfig(c *gin.Context) {
-	// Send reload command to core engine via socket
-	resp, err := d.socketClient.SendCommand("RELOAD")
+// handleGetNet reports the net settings and the net running, with its
+// check-ins so far
+func (d *JS8Daemon) handleGetNet(c *gin.Context) {
+	d.sendNetCommand(c, "NET", http.StatusBadRequest)
+}
+
+// handleOpenNet opens a net on the group given, or net group, sending the
+// preamble
+func (d *JS8Daemon) handleOpenNet(c *gin.Context) {
+	var req struct {
+		Group string `json:"group"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	command := "NET OPEN"
+	if group := strings.TrimSpace(req.Group); group != "" {
+		if strings.ContainsAny(group, " \t") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid net group %q", group)})
+			return
+		}
+		command += " " + group
+	}
+	d.sendNetCommand(c, command, http.StatusConflict)
+}
+
+// handleCloseNet closes the net running, sending the closing message
+func (d *JS8Daemon) handleCloseNet(c *gin.Context) {
+	d.sendNetCommand(c, "NET CLOSE", http.StatusConflict)
+}
+
+// handleGetNets lists the latest nets, newest first
+func (d *JS8Daemon) handleGetNets(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	d.sendNetCommand(c, fmt.Sprintf("NET LIST %d", limit), http.StatusBadRequest)
+}
+
+// handleGetNetCheckins returns a net with its check-ins
+func (d *JS8Daemon) handleGetNetCheckins(c *gin.Context) {
+	d.sendNetCommand(c, "NET GET "+c.Param("id"), http.StatusNotFound)
+}
+
+// handleExportNet downloads a net's check-ins as a CSV file
+func (d *JS8Daemon) handleExportNet(c *gin.Context) {
+	resp, err := d.clientFor(c).SendCommand("NET EXPORT " + c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to send reload command: %v", err),
+			"error": tr(c, "error.net_command", err),
 		})
 		return
 	}
 
 	if !resp.Success {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
 			"error": resp.Error,
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status": "reloaded",
-	})
+	data, _ := resp.Data["csv"].(string)
+	filename, _ := resp.Data["filename"].(string)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", []byte(data))
 }
 
-// handleRetryRadioConnection attempts to reconnect the radio after configuration changes
-func (d *JS8Daemon) handleRetryRadioConnection(c *gin.Context) {
-	// Send retry radio connection command to core engine via socket
-	resp, err := d.socketClient.SendCommand("RETRY_RADIO")
+// sendNetCommand sends a NET command and returns its result, answering
+// invalidStatus when the engine rejects the request
+func (d *JS8Daemon) sendNetCommand(c *gin.Context, command string, invalidStatus int) {
+	resp, err := d.clientFor(c).SendCommand(command)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to send retry radio command: %v", err),
+			"error": tr(c, "error.net_command", err),
 		})
 		return
 	}
 
 	if !resp.Success {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = invalidStatus
+		}
+		c.JSON(status, gin.H{
 			"error": resp.Error,
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Radio connection retry completed",
-		"details": resp.Data,
-	})
+	c.JSON(http.StatusOK, resp.Data)
 }
 
-// handleGetAudioDevices returns available audio devices
-func (d *JS8Daemon) handleGetAudioDevices(c *gin.Context) {
-	// Try to get real audio devices on macOS and Linux
-	if runtime.GOOS == "darwin" || runtime.GOOS == "linux" {
-		devices, err := getAvailableAudioDevices()
-		if err != nil {
-			log.Printf("Warning: Failed to enumerate audio devices: %v", err)
-		} else {
-			// Separate input and output devices with deduplication
-			inputDeviceSet := make(map[string]bool)
-			outputDeviceSet := make(map[string]bool)
-
-			for _, device := range devices {
-				// Trim any trailing spaces from device names
-				deviceName := strings.TrimSpace(device.Name)
-				log.Printf("Device: %s (input:%v, output:%v)", deviceName, device.IsInput, device.IsOutput)
-
-				if device.IsInput {
-					inputDeviceSet[deviceName] = true
-				}
-				if device.IsOutput {
-					outputDeviceSet[deviceName] = true
-				}
-			}
-
-			// Convert sets to slices
-			inputDevices := make([]string, 0, len(inputDeviceSet))
-			for deviceName := range inputDeviceSet {
-				inputDevices = append(inputDevices, deviceName)
-			}
-
-			outputDevices := make([]string, 0, len(outputDeviceSet))
-			for deviceName := range outputDeviceSet {
-				outputDevices = append(outputDevices, deviceName)
-			}
-
-			log.Printf("Final lists - Input devices: %d, Output devices: %d", len(inputDevices), len(outputDevices))
-
-			c.JSON(http.StatusOK, gin.H{
-				"input_devices":  inputDevices,
-				"output_devices": outputDevices,
-			})
-			return
-		}
-	}
+// handleSettings serves the settings page
+func (d *JS8Daemon) handleSettings(c *gin.Context) {
+	c.HTML(http.StatusOK, "settings.html", gin.H{
+		"version": Version,
+		"theme":   themeFor(c),
+		"lang":    langFor(c),
+	})
+}
 
-	// Fallback to static device list if enumeration fails or on other platforms
-	devices := gin.H{
-		"input_devices": []string{
-			"Built-in Microphone",
-			"USB Audio Device",
-			"IC-7300",
-			"External Microphone",
-		},
-		"output_devices": []string{
-			"Built-in Output",
-			"USB Audio Device",
-			"IC-7300",
-			"External Speakers",
-		},
+// handleGetConfig returns the current configuration
+func (d *JS8Daemon) handleGetConfig(c *gin.Context) {
+	configMap, err := d.redactedConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
-
-	c.JSON(http.StatusOK, devices)
+	c.JSON(http.StatusOK, configMap)
 }
 
-// getAvailableAudioDevices wraps the audio device enumeration
-func getAvailableAudioDevices() ([]AudioDeviceInfo, error) {
-	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
-		return nil, fmt.Errorf("audio device enumeration only supported on macOS and Linux")
+// handleGetConfigSection returns one section of the current configuration
+func (d *JS8Daemon) handleGetConfigSection(c *gin.Context) {
+	section := c.Param("section")
+	if !config.IsSection(section) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":    tr(c, "error.unknown_section", section),
+			"sections": config.SectionNames(),
+		})
+		return
 	}
 
-	// Call the audio device enumeration from the hardware package
-	log.Printf("Attempting to enumerate audio devices...")
-	devices, err := hardware.GetAudioDevices()
+	configMap, err := d.redactedConfig()
 	if err != nil {
-		log.Printf("Audio device enumeration failed: %v", err)
-		return nil, fmt.Errorf("failed to enumerate audio devices: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
+	values, ok := configMap[section]
+	if !ok {
+		values = map[string]interface{}{}
+	}
+	c.JSON(http.StatusOK, values)
+}
 
-	log.Printf("Found %d audio devices", len(devices))
+// redactedConfig returns the current configuration keyed as in the file,
+// with passwords and keys never sent back to the browser
+func (d *JS8Daemon) redactedConfig() (map[string]interface{}, error) {
+	d.configMutex.RLock()
+	yamlData, err := d.config.RedactedYAML()
+	d.configMutex.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %v", err)
+	}
 
-	// Convert hardware.AudioDevice to AudioDeviceInfo
-	result := make([]AudioDeviceInfo, len(devices))
-	for i, device := range devices {
-		result[i] = AudioDeviceInfo{
-			ID:       device.ID,
-			Name:     device.Name,
-			IsInput:  device.IsInput,
-			IsOutput: device.IsOutput,
-		}
+	// Unmarshal YAML to interface{} then convert to JSON-compatible map
+	var yamlConfig interface{}
+	if err := yaml.Unmarshal(yamlData, &yamlConfig); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %v", err)
 	}
 
-	return result, nil
+	// Convert map[interface{}]interface{} to map[string]interface{} recursively
+	configMap, _ := convertYamlToJson(yamlConfig).(map[string]interface{})
+	return configMap, nil
 }
 
-// AudioDeviceInfo represents an audio device for the handler
-type AudioDeviceInfo struct {
-	ID       uint32 `json:"id"`
-	Name     string `json:"name"`
-	IsInput  bool   `json:"is_input"`
-	IsOutput bool   `json:"is_output"`
+// convertYamlToJson converts YAML map[interface{}]interface{} to JSON-compatible map[string]interface{}
+func convertYamlToJson(i interface{}) interface{} {
+	switch x := i.(type) {
+	case map[interface{}]interface{}:
+		m2 := map[string]interface{}{}
+		for k, v := range x {
+			m2[k.(string)] = convertYamlToJson(v)
+		}
+		return m2
+	case []interface{}:
+		for i, v := range x {
+			x[i] = convertYamlToJson(v)
+		}
+	}
+	return i
 }
 
-// validateAudioDevice validates that an audio device can be opened and used
-func validateAudioDevice(deviceName, deviceType string) error {
-	// For now, we'll do basic validation - check if device file exists on Linux
-	if runtime.GOOS == "linux" {
-		// ALSA device names like "hw:0,0" or "plughw:0,0"
-		if strings.HasPrefix(deviceName, "hw:") || strings.HasPrefix(deviceName, "plughw:") {
-			// Parse ALSA device name format hw:card,device
-			parts := strings.Split(strings.TrimPrefix(strings.TrimPrefix(deviceName, "plughw:"), "hw:"), ",")
-			if len(parts) >= 1 {
-				cardNum := parts[0]
-				// Check if the ALSA card exists
-				cardPath := fmt.Sprintf("/proc/asound/card%s", cardNum)
-				if _, err := os.Stat(cardPath); err != nil {
-					return fmt.Errorf("ALSA card %s not found", cardNum)
-				}
-
-				// Check if device node exists
-				devicePath := fmt.Sprintf("/dev/snd/controlC%s", cardNum)
-				if _, err := os.Stat(devicePath); err != nil {
-					return fmt.Errorf("ALSA device node %s not accessible", devicePath)
-				}
+// handleSaveConfig saves changes to several sections of the configuration
+func (d *JS8Daemon) handleSaveConfig(c *gin.Context) {
+	var values map[string]interface{}
+	if err := c.ShouldBindJSON(&values); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	d.saveConfig(c, func(cfg *config.Config) (*config.Config, error) {
+		return cfg.Update(values)
+	})
+}
 
-				log.Printf("Audio device validation: %s device '%s' appears valid", deviceType, deviceName)
-				return nil
-			}
-		}
+// handleUpdateConfigSection saves changes to one section of the configuration
+func (d *JS8Daemon) handleUpdateConfigSection(c *gin.Context) {
+	section := c.Param("section")
+	if !config.IsSection(section) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":    tr(c, "error.unknown_section", section),
+			"sections": config.SectionNames(),
+		})
+		return
+	}
 
-		// For other device names, just log a warning
-		log.Printf("Audio device validation: Cannot validate non-standard device name '%s'", deviceName)
-		return nil
+	var values map[string]interface{}
+	if err := c.ShouldBindJSON(&values); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	d.saveConfig(c, func(cfg *config.Config) (*config.Config, error) {
+		return cfg.UpdateSection(section, values)
+	})
+}
 
-	// On macOS, we could validate against the available devices list
-	if runtime.GOOS == "darwin" {
-		devices, err := getAvailableAudioDevices()
-		if err != nil {
-			log.Printf("Audio device validation: Cannot enumerate devices for validation: %v", err)
-			return nil // Don't fail validation if we can't enumerate
+// saveConfig applies an update to the configuration and writes it to the
+// config file. Settings that are unknown, of the wrong type or that would
+// fail validation are rejected with the fields at fault, and nothing is
+// written. The running engines pick the file up on reload.
+func (d *JS8Daemon) saveConfig(c *gin.Context, update func(*config.Config) (*config.Config, error)) {
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+
+	updated, err := update(d.config)
+	if err != nil {
+		var fieldErrors config.FieldErrors
+		if errors.As(err, &fieldErrors) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":  fieldErrors.Error(),
+				"fields": fieldErrors,
+			})
+			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-		// Check if the device name exists in the available devices
-		for _, device := range devices {
-			if strings.TrimSpace(device.Name) == deviceName {
-				// Check if device supports the required direction
-				if deviceType == "input" && !device.IsInput {
-					return fmt.Errorf("device '%s' does not support input", deviceName)
-				}
-				if deviceType == "output" && !device.IsOutput {
-					return fmt.Errorf("device '%s' does not support output", deviceName)
-				}
-				log.Printf("Audio device validation: %s device '%s' validated successfully", deviceType, deviceName)
-				return nil
-			}
+	// Devices that don't exist are saved anyway; they may be plugged in later
+	var warnings []string
+	if updated.Audio.InputDevice != d.config.Audio.InputDevice && updated.Audio.InputDevice != "default" {
+		if err := validateAudioDevice(updated.Audio.InputDevice, "input"); err != nil {
+			warnings = append(warnings, fmt.Sprintf("Input device '%s' validation failed: %v", updated.Audio.InputDevice, err))
+		}
+	}
+	if updated.Audio.OutputDevice != d.config.Audio.OutputDevice && updated.Audio.OutputDevice != "default" {
+		if err := validateAudioDevice(updated.Audio.OutputDevice, "output"); err != nil {
+			warnings = append(warnings, fmt.Sprintf("Output device '%s' validation failed: %v", updated.Audio.OutputDevice, err))
 		}
+	}
+	for _, warning := range warnings {
+		webLogger.Warnf("Audio validation warning: %s", warning)
+	}
 
-		return fmt.Errorf("device '%s' not found in available devices", deviceName)
+	yamlData, err := yaml.Marshal(updated)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.marshal_config", err),
+		})
+		return
 	}
 
-	// For other platforms, just log and accept
-	log.Printf("Audio device validation: Platform-specific validation not implemented for %s", runtime.GOOS)
-	return nil
-}
+	if d.configPath == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "error.no_config_file")})
+		return
+	}
+	if err := os.WriteFile(d.configPath, yamlData, 0644); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.write_config", err),
+		})
+		return
+	}
+	d.config = updated
 
-// handleGetSerialDevices returns available serial/USB devices
-func (d *JS8Daemon) handleGetSerialDevices(c *gin.Context) {
-	devices := []string{}
+	if len(warnings) > 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"status":   "saved_with_warnings",
+			"path":     d.configPath,
+			"warnings": warnings,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "saved",
+		"path":   d.configPath,
+	})
+}
 
-	// Detect serial devices based on operating system
-	switch runtime.GOOS {
-	case "linux":
-		// Check common Linux USB serial device patterns
-		patterns := []string{
-			"/dev/ttyUSB*",
-			"/dev/ttyACM*",
-			"/dev/ttyAMA*",
-			"/dev/serial/by-id/*",
+// handleReloadConfig triggers daemon to reload configuration
+func (d *JS8Daemon) handleReloadConfig(c *gin.Context) {
+	// The config file is shared, so every instance reloads its part of it
+	result := gin.H{}
+	restarted := []string{}
+	var warnings []string
+	for _, inst := range d.instances {
+		resp, err := inst.socketClient.SendCommand("RELOAD")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": tr(c, "error.reload", inst.name, err),
+			})
+			return
 		}
 
-		for _, pattern := range patterns {
-			matches, err := filepath.Glob(pattern)
-			if err == nil {
-				devices = append(devices, matches...)
-			}
+		if !resp.Success {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("%s: %s", inst.name, resp.Error),
+			})
+			return
 		}
 
-	case "darwin":
-		// Check common macOS USB serial device patterns
-		patterns := []string{
-			"/dev/tty.usb*",
-			"/dev/tty.SLAB_*", // Silicon Labs CP210x
-			"/dev/tty.wchusbserial*", // WCH CH340
-			"/dev/tty.usbmodem*", // USB CDC ACM devices
+		// Name the instance when several share the file
+		prefix := ""
+		if len(d.instances) > 1 {
+			prefix = inst.name + " "
 		}
-
-		for _, pattern := range patterns {
-			matches, err := filepath.Glob(pattern)
-			if err == nil {
-				devices = append(devices, matches...)
+		for key, value := range resp.Data {
+			result[key] = value
+		}
+		if subsystems, ok := resp.Data["restarted"].([]interface{}); ok {
+			for _, subsystem := range subsystems {
+				restarted = append(restarted, prefix+fmt.Sprint(subsystem))
 			}
 		}
-
-	case "windows":
-		// On Windows, we would typically enumerate COM ports
-		// For now, provide common examples
-		for i := 1; i <= 20; i++ {
-			comPort := fmt.Sprintf("COM%d", i)
-			devices = append(devices, comPort)
+		if warning, ok := resp.Data["warning"].(string); ok && warning != "" {
+			warnings = append(warnings, prefix+warning)
 		}
 	}
 
-	// Add some common defaults if no devices found
-	if len(devices) == 0 {
-		devices = []string{
-			"/dev/ttyUSBmodem14201", // Common IC-7300 device
-			"/dev/ttyUSB0",
-			"/dev/ttyUSB1",
-			"/dev/ttyACM0",
-			"/dev/ttyACM1",
-		}
+	result["status"] = "reloaded"
+	result["restarted"] = restarted
+	delete(result, "warning")
+	if len(warnings) > 0 {
+		result["warning"] = strings.Join(warnings, "; ")
 	}
+	c.JSON(http.StatusOK, result)
+}
 
-	// Filter out non-existent devices on Unix systems
-	if runtime.GOOS != "windows" {
-		filteredDevices := []string{}
-		for _, device := range devices {
-			if _, err := os.Stat(device); err == nil {
-				filteredDevices = append(filteredDevices, device)
-			}
-		}
+// handleGetProfiles lists the configuration profiles and the active one
+func (d *JS8Daemon) handleGetProfiles(c *gin.Context) {
+	resp, err := d.clientFor(c).SendCommand("PROFILE")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.profile", err),
+		})
+		return
+	}
 
-		// If filtering removed everything, keep the defaults
-		if len(filteredDevices) > 0 {
-			devices = filteredDevices
-		}
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"serial_devices": devices,
-	})
+	c.JSON(http.StatusOK, resp.Data)
 }
 
-// handleTestCAT tests the CAT (Computer Aided Transceiver) connection
-func (d *JS8Daemon) handleTestCAT(c *gin.Context) {
+// handleSelectProfile switches the engine to another configuration profile
+func (d *JS8Daemon) handleSelectProfile(c *gin.Context) {
 	var req struct {
-		Device   string `json:"device"`
-		Model    string `json:"model"`
-		BaudRate int    `json:"baud_rate"`
+		Name string `json:"name" binding:"required"`
 	}
-
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Send CAT test command to daemon via socket
-	cmd := fmt.Sprintf("TEST_CAT %s %s %d", req.Device, req.Model, req.BaudRate)
-	resp, err := d.socketClient.SendCommand(cmd)
+	resp, err := d.clientFor(c).Do(&protocol.Command{
+		Type: protocol.CmdProfile,
+		Args: map[string]interface{}{"name": req.Name},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.profile", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleRetryRadioConnection attempts to reconnect the radio after configuration changes
+func (d *JS8Daemon) handleRetryRadioConnection(c *gin.Context) {
+	// Send retry radio connection command to core engine via socket
+	resp, err := d.clientFor(c).SendCommand("RETRY_RADIO")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to test CAT: %v", err),
+			"error": tr(c, "error.retry_radio", err),
 		})
 		return
 	}
@@ -656,107 +845,1884 @@ func (d *JS8Daemon) handleTestCAT(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
-		"message": "CAT connection test successful",
+		"message": "Radio connection retry completed",
 		"details": resp.Data,
 	})
 }
 
-// handleTestPTT tests the PTT (Push To Talk) functionality
-func (d *JS8Daemon) handleTestPTT(c *gin.Context) {
+// tuneTimeout is how long the web server waits for the antenna tuner, which
+// may key a carrier for up to 30 seconds
+const tuneTimeout = 45 * time.Second
+
+// handleTune runs the antenna tuner on the current band, by the configured
+// method or the one given
+func (d *JS8Daemon) handleTune(c *gin.Context) {
 	var req struct {
-		Method  string  `json:"method"`
-		Port    string  `json:"port"`
-		TxDelay float64 `json:"tx_delay"`
+		Method string `json:"method"`
+		Power  int    `json:"power"`
 	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Send PTT test command to daemon via socket
-	cmd := fmt.Sprintf("TEST_PTT %s %s %.1f", req.Method, req.Port, req.TxDelay)
-	resp, err := d.socketClient.SendCommand(cmd)
+	cmd := "TUNE"
+	if req.Method != "" {
+		cmd += " " + req.Method
+	}
+	if req.Power != 0 {
+		cmd += fmt.Sprintf(" PWR=%d", req.Power)
+	}
+
+	resp, err := d.clientFor(c).WithTimeout(tuneTimeout).SendCommand(cmd)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to test PTT: %v", err),
+			"error": tr(c, "error.tune", err),
 		})
 		return
 	}
 
 	if !resp.Success {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusBadRequest, gin.H{
 			"error": resp.Error,
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "PTT test successful",
-		"details": resp.Data,
-	})
+	c.JSON(http.StatusOK, resp.Data)
 }
 
-// handleTestPTTOff turns off PTT for toggle mode
-func (d *JS8Daemon) handleTestPTTOff(c *gin.Context) {
-	// Send PTT off command to daemon via socket
-	resp, err := d.socketClient.SendCommand("TEST_PTT_OFF")
+// handleFindOffset moves the TX offset to the quietest slot of the last
+// minute
+func (d *JS8Daemon) handleFindOffset(c *gin.Context) {
+	resp, err := d.clientFor(c).SendCommand("FIND_OFFSET")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to turn off PTT: %v", err),
+			"error": tr(c, "error.find_offset", err),
 		})
 		return
 	}
 
 	if !resp.Success {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusConflict, gin.H{
 			"error": resp.Error,
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "PTT turned off successfully",
-		"details": resp.Data,
-	})
+	c.JSON(http.StatusOK, resp.Data)
 }
 
-// handleGetMessageHistory returns stored message history
-func (d *JS8Daemon) handleGetMessageHistory(c *gin.Context) {
-	// Parse query parameters
-	limitStr := c.DefaultQuery("limit", "50")
-	offsetStr := c.DefaultQuery("offset", "0")
-	callsign := c.Query("callsign")
-	direction := c.Query("direction") // RX, TX, or empty for both
-	messageType := c.Query("type")
+// handleGetAntenna returns the antenna switch's ports and the one selected
+func (d *JS8Daemon) handleGetAntenna(c *gin.Context) {
+	d.sendAntennaCommand(c, "ANTENNA")
+}
+
+// handleSetAntenna selects an antenna port by hand, or "auto" to follow the
+// band again
+func (d *JS8Daemon) handleSetAntenna(c *gin.Context) {
+	var req struct {
+		Port string `json:"port" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	d.sendAntennaCommand(c, "ANTENNA "+req.Port)
+}
+
+// sendAntennaCommand sends an ANTENNA command and returns the antenna
+// switch status
+func (d *JS8Daemon) sendAntennaCommand(c *gin.Context, command string) {
+	resp, err := d.clientFor(c).SendCommand(command)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.antenna", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		switch resp.Code {
+		case protocol.ErrCodeInvalid:
+			status = http.StatusBadRequest
+		case protocol.ErrCodeNotConnected:
+			status = http.StatusNotFound
+		case protocol.ErrCodeRadio:
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetSensors returns the latest battery and temperature reading and
+// the last day's readings for the dashboard graph
+func (d *JS8Daemon) handleGetSensors(c *gin.Context) {
+	resp, err := d.clientFor(c).SendCommand("SENSORS")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.sensors", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeNotConnected {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetPower returns the power mode and what low power mode changes
+func (d *JS8Daemon) handleGetPower(c *gin.Context) {
+	d.sendPowerCommand(c, "POWER")
+}
+
+// handleSetPower switches to low power or normal mode and holds it, or
+// "auto" to follow the schedule again
+func (d *JS8Daemon) handleSetPower(c *gin.Context) {
+	var req struct {
+		Mode string `json:"mode" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	d.sendPowerCommand(c, "POWER "+req.Mode)
+}
+
+// sendPowerCommand sends a POWER command and returns the power mode
+func (d *JS8Daemon) sendPowerCommand(c *gin.Context, command string) {
+	resp, err := d.clientFor(c).SendCommand(command)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.power", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetBandPlan checks TX at a dial frequency, the current one without
+// ?frequency=, against the station region's band plan and license class
+func (d *JS8Daemon) handleGetBandPlan(c *gin.Context) {
+	command := "BANDPLAN"
+	if frequency := c.Query("frequency"); frequency != "" {
+		command += " " + frequency
+	}
+	resp, err := d.clientFor(c).SendCommand(command)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.band_plan", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetPassband returns the audio offsets decoded
+func (d *JS8Daemon) handleGetPassband(c *gin.Context) {
+	d.sendPassbandCommand(c, "PASSBAND")
+}
+
+// handleSetPassband narrows decoding to the audio offsets from low to high
+// Hz, or with reset goes back to the configured passband
+func (d *JS8Daemon) handleSetPassband(c *gin.Context) {
+	var req struct {
+		Low   int  `json:"low"`
+		High  int  `json:"high"`
+		Reset bool `json:"reset"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Reset {
+		d.sendPassbandCommand(c, "PASSBAND RESET")
+		return
+	}
+	d.sendPassbandCommand(c, fmt.Sprintf("PASSBAND %d %d", req.Low, req.High))
+}
+
+// sendPassbandCommand sends a PASSBAND command and returns the passband
+func (d *JS8Daemon) sendPassbandCommand(c *gin.Context, command string) {
+	resp, err := d.clientFor(c).SendCommand(command)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.passband", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetAudioDevices returns available audio devices
+func (d *JS8Daemon) handleGetAudioDevices(c *gin.Context) {
+	// Try to get real audio devices on macOS and Linux
+	if runtime.GOOS == "darwin" || runtime.GOOS == "linux" {
+		devices, err := getAvailableAudioDevices()
+		if err != nil {
+			webLogger.Warnf("Failed to enumerate audio devices: %v", err)
+		} else {
+			// Separate input and output devices with deduplication
+			inputDeviceSet := make(map[string]bool)
+			outputDeviceSet := make(map[string]bool)
+
+			for _, device := range devices {
+				// Trim any trailing spaces from device names
+				deviceName := strings.TrimSpace(device.Name)
+				webLogger.Debugf("Device: %s (input:%v, output:%v)", deviceName, device.IsInput, device.IsOutput)
+
+				if device.IsInput {
+					inputDeviceSet[deviceName] = true
+				}
+				if device.IsOutput {
+					outputDeviceSet[deviceName] = true
+				}
+			}
+
+			// Convert sets to slices
+			inputDevices := make([]string, 0, len(inputDeviceSet))
+			for deviceName := range inputDeviceSet {
+				inputDevices = append(inputDevices, deviceName)
+			}
+
+			outputDevices := make([]string, 0, len(outputDeviceSet))
+			for deviceName := range outputDeviceSet {
+				outputDevices = append(outputDevices, deviceName)
+			}
+
+			webLogger.Debugf("Final lists - Input devices: %d, Output devices: %d", len(inputDevices), len(outputDevices))
+
+			c.JSON(http.StatusOK, gin.H{
+				"input_devices":  inputDevices,
+				"output_devices": outputDevices,
+			})
+			return
+		}
+	}
+
+	// Fallback to static device list if enumeration fails or on other platforms
+	devices := gin.H{
+		"input_devices": []string{
+			"Built-in Microphone",
+			"USB Audio Device",
+			"IC-7300",
+			"External Microphone",
+		},
+		"output_devices": []string{
+			"Built-in Output",
+			"USB Audio Device",
+			"IC-7300",
+			"External Speakers",
+		},
+	}
+
+	c.JSON(http.StatusOK, devices)
+}
+
+// getAvailableAudioDevices wraps the audio device enumeration
+func getAvailableAudioDevices() ([]AudioDeviceInfo, error) {
+	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("audio device enumeration only supported on macOS and Linux")
+	}
+
+	// Call the audio device enumeration from the hardware package
+	webLogger.Debugf("Attempting to enumerate audio devices...")
+	devices, err := hardware.GetAudioDevices()
+	if err != nil {
+		webLogger.Errorf("Audio device enumeration failed: %v", err)
+		return nil, fmt.Errorf("failed to enumerate audio devices: %v", err)
+	}
+
+	webLogger.Debugf("Found %d audio devices", len(devices))
+
+	// Convert hardware.AudioDevice to AudioDeviceInfo
+	result := make([]AudioDeviceInfo, len(devices))
+	for i, device := range devices {
+		result[i] = AudioDeviceInfo{
+			ID:       device.ID,
+			Name:     device.Name,
+			IsInput:  device.IsInput,
+			IsOutput: device.IsOutput,
+		}
+	}
+
+	return result, nil
+}
+
+// AudioDeviceInfo represents an audio device for the handler
+type AudioDeviceInfo struct {
+	ID       uint32 `json:"id"`
+	Name     string `json:"name"`
+	IsInput  bool   `json:"is_input"`
+	IsOutput bool   `json:"is_output"`
+}
+
+// validateAudioDevice validates that an audio device can be opened and used
+func validateAudioDevice(deviceName, deviceType string) error {
+	// For now, we'll do basic validation - check if device file exists on Linux
+	if runtime.GOOS == "linux" {
+		// ALSA device names like "hw:0,0" or "plughw:0,0"
+		if strings.HasPrefix(deviceName, "hw:") || strings.HasPrefix(deviceName, "plughw:") {
+			// Parse ALSA device name format hw:card,device
+			parts := strings.Split(strings.TrimPrefix(strings.TrimPrefix(deviceName, "plughw:"), "hw:"), ",")
+			if len(parts) >= 1 {
+				cardNum := parts[0]
+				// Check if the ALSA card exists
+				cardPath := fmt.Sprintf("/proc/asound/card%s", cardNum)
+				if _, err := os.Stat(cardPath); err != nil {
+					return fmt.Errorf("ALSA card %s not found", cardNum)
+				}
+
+				// Check if device node exists
+				devicePath := fmt.Sprintf("/dev/snd/controlC%s", cardNum)
+				if _, err := os.Stat(devicePath); err != nil {
+					return fmt.Errorf("ALSA device node %s not accessible", devicePath)
+				}
+
+				webLogger.Debugf("Audio device validation: %s device '%s' appears valid", deviceType, deviceName)
+				return nil
+			}
+		}
+
+		// For other device names, just log a warning
+		webLogger.Warnf("Audio device validation: Cannot validate non-standard device name '%s'", deviceName)
+		return nil
+	}
+
+	// On macOS, we could validate against the available devices list
+	if runtime.GOOS == "darwin" {
+		devices, err := getAvailableAudioDevices()
+		if err != nil {
+			webLogger.Warnf("Audio device validation: Cannot enumerate devices for validation: %v", err)
+			return nil // Don't fail validation if we can't enumerate
+		}
+
+		// Check if the device name exists in the available devices
+		for _, device := range devices {
+			if strings.TrimSpace(device.Name) == deviceName {
+				// Check if device supports the required direction
+				if deviceType == "input" && !device.IsInput {
+					return fmt.Errorf("device '%s' does not support input", deviceName)
+				}
+				if deviceType == "output" && !device.IsOutput {
+					return fmt.Errorf("device '%s' does not support output", deviceName)
+				}
+				webLogger.Debugf("Audio device validation: %s device '%s' validated successfully", deviceType, deviceName)
+				return nil
+			}
+		}
+
+		return fmt.Errorf("device '%s' not found in available devices", deviceName)
+	}
+
+	// For other platforms, just log and accept
+	webLogger.Infof("Audio device validation: Platform-specific validation not implemented for %s", runtime.GOOS)
+	return nil
+}
+
+// handleGetSerialDevices returns available serial/USB devices
+func (d *JS8Daemon) handleGetSerialDevices(c *gin.Context) {
+	devices := []string{}
+
+	// Detect serial devices based on operating system
+	switch runtime.GOOS {
+	case "linux":
+		// Check common Linux USB serial device patterns
+		patterns := []string{
+			"/dev/ttyUSB*",
+			"/dev/ttyACM*",
+			"/dev/ttyAMA*",
+			"/dev/serial/by-id/*",
+		}
+
+		for _, pattern := range patterns {
+			matches, err := filepath.Glob(pattern)
+			if err == nil {
+				devices = append(devices, matches...)
+			}
+		}
+
+	case "darwin":
+		// Check common macOS USB serial device patterns
+		patterns := []string{
+			"/dev/tty.usb*",
+			"/dev/tty.SLAB_*", // Silicon Labs CP210x
+			"/dev/tty.wchusbserial*", // WCH CH340
+			"/dev/tty.usbmodem*", // USB CDC ACM devices
+		}
+
+		for _, pattern := range patterns {
+			matches, err := filepath.Glob(pattern)
+			if err == nil {
+				devices = append(devices, matches...)
+			}
+		}
+
+	case "windows":
+		// On Windows, we would typically enumerate COM ports
+		// For now, provide common examples
+		for i := 1; i <= 20; i++ {
+			comPort := fmt.Sprintf("COM%d", i)
+			devices = append(devices, comPort)
+		}
+	}
+
+	// Add some common defaults if no devices found
+	if len(devices) == 0 {
+		devices = []string{
+			"/dev/ttyUSBmodem14201", // Common IC-7300 device
+			"/dev/ttyUSB0",
+			"/dev/ttyUSB1",
+			"/dev/ttyACM0",
+			"/dev/ttyACM1",
+		}
+	}
+
+	// Filter out non-existent devices on Unix systems
+	if runtime.GOOS != "windows" {
+		filteredDevices := []string{}
+		for _, device := range devices {
+			if _, err := os.Stat(device); err == nil {
+				filteredDevices = append(filteredDevices, device)
+			}
+		}
+
+		// If filtering removed everything, keep the defaults
+		if len(filteredDevices) > 0 {
+			devices = filteredDevices
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"serial_devices": devices,
+	})
+}
+
+// handleGetRigModels lists the radio models Hamlib can control, narrowed by
+// the search, manufacturer and status parameters
+func (d *JS8Daemon) handleGetRigModels(c *gin.Context) {
+	models, err := hardware.RigModels()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.rig_models", err),
+		})
+		return
+	}
+
+	seen := map[string]bool{}
+	manufacturers := []string{}
+	for _, model := range models {
+		if !seen[model.Manufacturer] {
+			seen[model.Manufacturer] = true
+			manufacturers = append(manufacturers, model.Manufacturer)
+		}
+	}
+	sort.Strings(manufacturers)
+
+	matches := hardware.FilterRigModels(models, c.Query("search"), c.Query("manufacturer"), c.Query("status"))
+	c.JSON(http.StatusOK, gin.H{
+		"models":        matches,
+		"count":         len(matches),
+		"manufacturers": manufacturers,
+	})
+}
+
+// handleDetectRigs finds QRP Labs rigs on USB with the settings each needs,
+// for one-click setup
+func (d *JS8Daemon) handleDetectRigs(c *gin.Context) {
+	rigs, err := hardware.DetectUSBRigs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.detect_rigs", err),
+		})
+		return
+	}
+	if rigs == nil {
+		rigs = []hardware.DetectedRig{}
+	}
+	c.JSON(http.StatusOK, gin.H{"rigs": rigs})
+}
+
+// handleTestCAT tests the CAT (Computer Aided Transceiver) connection
+func (d *JS8Daemon) handleTestCAT(c *gin.Context) {
+	var req struct {
+		Device   string `json:"device"`
+		Model    string `json:"model"`
+		BaudRate int    `json:"baud_rate"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Send CAT test command to daemon via socket
+	cmd := fmt.Sprintf("TEST_CAT %s %s %d", req.Device, req.Model, req.BaudRate)
+	resp, err := d.clientFor(c).SendCommand(cmd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.test_cat", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "CAT connection test successful",
+		"details": resp.Data,
+	})
+}
+
+// handleTestPTT tests the PTT (Push To Talk) functionality
+func (d *JS8Daemon) handleTestPTT(c *gin.Context) {
+	var req struct {
+		Method  string  `json:"method"`
+		Port    string  `json:"port"`
+		TxDelay float64 `json:"tx_delay"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Send PTT test command to daemon via socket
+	cmd := fmt.Sprintf("TEST_PTT %s %s %.1f", req.Method, req.Port, req.TxDelay)
+	resp, err := d.clientFor(c).SendCommand(cmd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.test_ptt", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "PTT test successful",
+		"details": resp.Data,
+	})
+}
+
+// handleTestPTTOff turns off PTT for toggle mode
+func (d *JS8Daemon) handleTestPTTOff(c *gin.Context) {
+	// Send PTT off command to daemon via socket
+	resp, err := d.clientFor(c).SendCommand("TEST_PTT_OFF")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.ptt_off", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "PTT turned off successfully",
+		"details": resp.Data,
+	})
+}
+
+// handleGetMessageHistory returns stored message history
+func (d *JS8Daemon) handleGetMessageHistory(c *gin.Context) {
+	// Parse query parameters
+	limitStr := c.DefaultQuery("limit", "50")
+	offsetStr := c.DefaultQuery("offset", "0")
+	callsign := c.Query("callsign")
+	direction := c.Query("direction") // RX, TX, or empty for both
+	messageType := c.Query("type")
 	unreadOnly := c.Query("unread") == "true"
 
-	limit, err := strconv.Atoi(limitStr)
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		limit = 50
+	}
+
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil {
+		offset = 0
+	}
+
+	// Send message history request to core engine
+	cmd := fmt.Sprintf("GET_MESSAGE_HISTORY %d %d %s %s %s %t",
+		limit, offset, callsign, direction, messageType, unreadOnly)
+
+	resp, err := d.clientFor(c).SendCommand(cmd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.history", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetConversations returns conversation summaries
+func (d *JS8Daemon) handleGetConversations(c *gin.Context) {
+	limitStr := c.DefaultQuery("limit", "20")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		limit = 20
+	}
+
+	// Send conversations request to core engine
+	cmd := fmt.Sprintf("GET_CONVERSATIONS %d", limit)
+	resp, err := d.clientFor(c).SendCommand(cmd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.conversations", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleMarkMessagesRead marks messages as read for a callsign
+func (d *JS8Daemon) handleMarkMessagesRead(c *gin.Context) {
+	var req struct {
+		Callsign string `json:"callsign" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Send mark read command to core engine
+	cmd := fmt.Sprintf("MARK_MESSAGES_READ %s", req.Callsign)
+	resp, err := d.clientFor(c).SendCommand(cmd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.mark_read", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"message": fmt.Sprintf("Messages marked as read for %s", req.Callsign),
+	})
+}
+
+// handleSearchMessages searches through message history
+func (d *JS8Daemon) handleSearchMessages(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "error.search_required")})
+		return
+	}
+
+	limitStr := c.DefaultQuery("limit", "50")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		limit = 50
+	}
+
+	// Send search command to core engine
+	cmd := fmt.Sprintf("SEARCH_MESSAGES %s %d", query, limit)
+	resp, err := d.clientFor(c).SendCommand(cmd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.search", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetMessageStats returns database statistics
+func (d *JS8Daemon) handleGetMessageStats(c *gin.Context) {
+	// Send stats request to core engine
+	resp, err := d.clientFor(c).SendCommand("GET_MESSAGE_STATS")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.message_stats", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetRawFrame returns the payload bits and received tones a message
+// was decoded from, or 404 when they were not stored
+func (d *JS8Daemon) handleGetRawFrame(c *gin.Context) {
+	resp, err := d.clientFor(c).Do(&protocol.Command{
+		Type: protocol.CmdRaw,
+		Args: map[string]interface{}{"id": c.Param("id")},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.raw_frame", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetStatsSummary returns decode activity over a window, 7d unless
+// given, for the dashboard charts
+func (d *JS8Daemon) handleGetStatsSummary(c *gin.Context) {
+	window, err := parseWindow(c.DefaultQuery("window", "7d"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := d.clientFor(c).SendCommand(fmt.Sprintf("GET_STATS_SUMMARY %d", int(window.Seconds())))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.stats_summary", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetStatsTimeseries returns the stats history for trend graphs,
+// between from and to or over the window before now
+func (d *JS8Daemon) handleGetStatsTimeseries(c *gin.Context) {
+	from, to, err := parseRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cmd := fmt.Sprintf("GET_STATS_SERIES %s %d %d", c.DefaultQuery("resolution", "auto"), from.Unix(), to.Unix())
+	resp, err := d.clientFor(c).SendCommand(cmd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.stats_history", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetBandNoise returns the noise floor of each band between from and
+// to or over the window before now, for spotting local noise sources
+func (d *JS8Daemon) handleGetBandNoise(c *gin.Context) {
+	from, to, err := parseRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := d.clientFor(c).SendCommand(fmt.Sprintf("GET_BAND_NOISE %d %d", from.Unix(), to.Unix()))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.band_noise", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetSNRHistory returns every decode of a station between from and to
+// or over the window before now, for plotting how the path to it opens and
+// closes
+func (d *JS8Daemon) handleGetSNRHistory(c *gin.Context) {
+	from, to, err := parseRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cmd := fmt.Sprintf("GET_SNR_HISTORY %s %d %d", c.Param("callsign"), from.Unix(), to.Unix())
+	resp, err := d.clientFor(c).SendCommand(cmd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.snr_history", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// parseRange reads a time range from the from and to query parameters,
+// ending now and starting the window parameter (default 24h) before the end
+// when they are left out
+func parseRange(c *gin.Context) (from, to time.Time, err error) {
+	to = time.Now()
+	if value := c.Query("to"); value != "" {
+		if to, err = parseTime(value); err != nil {
+			return from, to, err
+		}
+	}
+
+	if value := c.Query("from"); value != "" {
+		from, err = parseTime(value)
+		return from, to, err
+	}
+	window, err := parseWindow(c.DefaultQuery("window", "24h"))
+	if err != nil {
+		return from, to, err
+	}
+	return to.Add(-window), to, nil
+}
+
+// parseTime parses an RFC 3339 time or Unix seconds
+func parseTime(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, want RFC 3339 or Unix seconds", value)
+	}
+	return t, nil
+}
+
+// handleGetTXQueue returns the messages waiting to be sent or on the air
+func (d *JS8Daemon) handleGetTXQueue(c *gin.Context) {
+	resp, err := d.clientFor(c).SendCommand("GET_TX_QUEUE")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.tx_queue", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetStations lists the station database, most recently heard first
+func (d *JS8Daemon) handleGetStations(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil {
+		limit = 50
+	}
+
+	cmd := strings.TrimSpace(fmt.Sprintf("GET_STATIONS %d %s", limit, c.Query("q")))
+	resp, err := d.clientFor(c).SendCommand(cmd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.stations", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetMap returns the stations heard in a time window as GeoJSON for
+// the map. window is a duration like 1h or 7d, and band limits it to one
+// band.
+func (d *JS8Daemon) handleGetMap(c *gin.Context) {
+	window, err := parseWindow(c.DefaultQuery("window", "24h"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cmd := strings.TrimSpace(fmt.Sprintf("GET_MAP %d %s", int(window.Seconds()), c.Query("band")))
+	resp, err := d.clientFor(c).SendCommand(cmd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.map", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// parseWindow parses a Go duration, also accepting whole days like 7d
+func parseWindow(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid window %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	window, err := time.ParseDuration(value)
+	if err != nil || window < time.Second {
+		return 0, fmt.Errorf("invalid window %q", value)
+	}
+	return window, nil
+}
+
+// handleGetAnswers lists the latest decisions on answering CQs, newest
+// first
+func (d *JS8Daemon) handleGetAnswers(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil {
+		limit = 50
+	}
+
+	resp, err := d.clientFor(c).SendCommand(fmt.Sprintf("GET_ANSWERS %d", limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.answers", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetAutoList lists the block and allow lists of automatic replies
+// and what they held back
+func (d *JS8Daemon) handleGetAutoList(c *gin.Context) {
+	d.sendAutoListCommand(c, map[string]interface{}{"action": protocol.AutoListList}, http.StatusBadRequest)
+}
+
+// handleAddAutoList puts a callsign or prefix on the block or allow list
+func (d *JS8Daemon) handleAddAutoList(c *gin.Context) {
+	var req struct {
+		List     string `json:"list" binding:"required"`
+		Callsign string `json:"callsign" binding:"required"`
+		Note     string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list := strings.ToLower(req.List)
+	if list != protocol.AutoListBlock && list != protocol.AutoListAllow {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown list %q, use %s or %s", req.List, protocol.AutoListBlock, protocol.AutoListAllow)})
+		return
+	}
+	d.sendAutoListCommand(c, map[string]interface{}{
+		"action":   list,
+		"callsign": req.Callsign,
+		"note":     req.Note,
+	}, http.StatusBadRequest)
+}
+
+// handleDeleteAutoList removes a block or allow list entry
+func (d *JS8Daemon) handleDeleteAutoList(c *gin.Context) {
+	d.sendAutoListCommand(c, map[string]interface{}{
+		"action": protocol.AutoListDelete,
+		"id":     c.Param("id"),
+	}, http.StatusNotFound)
+}
+
+// sendAutoListCommand sends an AUTOLIST command and returns its result,
+// answering invalidStatus when the engine rejects the request
+func (d *JS8Daemon) sendAutoListCommand(c *gin.Context, args map[string]interface{}, invalidStatus int) {
+	resp, err := d.clientFor(c).Do(&protocol.Command{
+		Type: protocol.CmdAutoList,
+		Args: args,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.auto_list_command", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = invalidStatus
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetRateLimits returns the rate limits on automatic replies and
+// their current counters
+func (d *JS8Daemon) handleGetRateLimits(c *gin.Context) {
+	resp, err := d.clientFor(c).SendCommand("GET_RATE_LIMITS")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.rate_limits", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetLog lists the logged QSOs, newest first, optionally with one
+// station
+func (d *JS8Daemon) handleGetLog(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil {
+		limit = 100
+	}
+	command := fmt.Sprintf("GET_LOG %d", limit)
+	if callsign := strings.TrimSpace(c.Query("callsign")); callsign != "" {
+		command += " " + callsign
+	}
+
+	resp, err := d.clientFor(c).SendCommand(command)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.log", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleExportADIF downloads the log as an ADIF file, or with ?service=
+// only the QSOs not yet uploaded to lotw or eqsl. The log comes from the
+// engine a page at a time and is written out as each arrives.
+func (d *JS8Daemon) handleExportADIF(c *gin.Context) {
+	command := "EXPORT_ADIF"
+	if service := strings.TrimSpace(c.Query("service")); service != "" {
+		command += " " + service
+	}
+
+	client := d.clientFor(c)
+	resp, err := client.SendCommand(command)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.log", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="js8d.adi"`)
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	for {
+		data, _ := resp.Data["adif"].(string)
+		if _, err := c.Writer.WriteString(data); err != nil {
+			return
+		}
+		next, ok := resp.Data["next"].(float64)
+		if !ok {
+			return
+		}
+
+		// The download has started, so a failure can only cut it short
+		resp, err = client.SendCommand(fmt.Sprintf("%s %d", command, int(next)))
+		if err == nil && !resp.Success {
+			err = errors.New(resp.Error)
+		}
+		if err != nil {
+			webLogger.Errorf("ADIF export cut short: %v", err)
+			return
+		}
+	}
+}
+
+// handleGetQSL reports how many QSOs wait for upload to LoTW and eQSL
+func (d *JS8Daemon) handleGetQSL(c *gin.Context) {
+	d.sendQSLCommand(c, "QSL")
+}
+
+// handleUploadQSL uploads the QSOs not yet sent to a service
+func (d *JS8Daemon) handleUploadQSL(c *gin.Context) {
+	d.sendQSLCommand(c, "QSL UPLOAD "+c.Param("service"))
+}
+
+// handleMarkQSL records QSOs as uploaded to a service by hand
+func (d *JS8Daemon) handleMarkQSL(c *gin.Context) {
+	var req struct {
+		IDs []int64 `json:"ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	command := "QSL MARK " + c.Param("service")
+	for _, id := range req.IDs {
+		command += fmt.Sprintf(" %d", id)
+	}
+	d.sendQSLCommand(c, command)
+}
+
+// sendQSLCommand sends a QSL command and relays its result
+func (d *JS8Daemon) sendQSLCommand(c *gin.Context, command string) {
+	resp, err := d.clientFor(c).SendCommand(command)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.qsl", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetAwards reports the progress toward the grid, DXCC and WAS
+// awards, on the band given or all of them
+func (d *JS8Daemon) handleGetAwards(c *gin.Context) {
+	command := "GET_AWARDS"
+	if band := strings.TrimSpace(c.Query("band")); band != "" {
+		command += " " + band
+	}
+
+	resp, err := d.clientFor(c).SendCommand(command)
 	if err != nil {
-		limit = 50
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.awards", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetDXCC reports the country file used to tag stations with their
+// DXCC entity
+func (d *JS8Daemon) handleGetDXCC(c *gin.Context) {
+	d.sendDXCCCommand(c, "DXCC")
+}
+
+// handleLookupDXCC returns the DXCC entity of a callsign
+func (d *JS8Daemon) handleLookupDXCC(c *gin.Context) {
+	d.sendDXCCCommand(c, "DXCC LOOKUP "+strings.ToUpper(c.Param("callsign")))
+}
+
+// handleRefreshDXCC downloads the current country file
+func (d *JS8Daemon) handleRefreshDXCC(c *gin.Context) {
+	d.sendDXCCCommand(c, "DXCC REFRESH")
+}
+
+// sendDXCCCommand sends a DXCC command and returns its result
+func (d *JS8Daemon) sendDXCCCommand(c *gin.Context, cmd string) {
+	resp, err := d.clientFor(c).SendCommand(cmd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.dxcc", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetPropagation returns the solar indices the daemon last fetched
+func (d *JS8Daemon) handleGetPropagation(c *gin.Context) {
+	resp, err := d.clientFor(c).SendCommand("GET_PROPAGATION")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.propagation", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetStation returns one station from the station database
+func (d *JS8Daemon) handleGetStation(c *gin.Context) {
+	d.sendStationCommand(c, map[string]interface{}{"callsign": c.Param("callsign")}, http.StatusNotFound)
+}
+
+// handleUpdateStation sets the name, QTH, notes or QSL status of a station
+func (d *JS8Daemon) handleUpdateStation(c *gin.Context) {
+	var req storage.StationUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	args := map[string]interface{}{"callsign": c.Param("callsign")}
+	for field, value := range map[string]*string{
+		"name":       req.Name,
+		"qth":        req.QTH,
+		"notes":      req.Notes,
+		"qsl_status": req.QSLStatus,
+	} {
+		if value != nil {
+			args[field] = *value
+		}
+	}
+	d.sendStationCommand(c, args, http.StatusBadRequest)
+}
+
+// sendStationCommand sends a STATION command and returns the station,
+// answering invalidStatus when the engine rejects the request
+func (d *JS8Daemon) sendStationCommand(c *gin.Context, args map[string]interface{}, invalidStatus int) {
+	resp, err := d.clientFor(c).Do(&protocol.Command{
+		Type: protocol.CmdStation,
+		Args: args,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.station_command", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = invalidStatus
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data["station"])
+}
+
+// handleGetMacros lists the saved message macros
+func (d *JS8Daemon) handleGetMacros(c *gin.Context) {
+	d.sendMacroCommand(c, map[string]interface{}{"action": protocol.MacroList}, http.StatusBadRequest)
+}
+
+// handleGetMacro returns one saved macro
+func (d *JS8Daemon) handleGetMacro(c *gin.Context) {
+	d.sendMacroCommand(c, map[string]interface{}{
+		"action": protocol.MacroGet,
+		"name":   c.Param("name"),
+	}, http.StatusNotFound)
+}
+
+// handleSaveMacro adds a macro or replaces its text
+func (d *JS8Daemon) handleSaveMacro(c *gin.Context) {
+	var req struct {
+		Text string `json:"text" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	d.sendMacroCommand(c, map[string]interface{}{
+		"action": protocol.MacroSet,
+		"name":   c.Param("name"),
+		"text":   req.Text,
+	}, http.StatusBadRequest)
+}
+
+// handleDeleteMacro removes a saved macro
+func (d *JS8Daemon) handleDeleteMacro(c *gin.Context) {
+	d.sendMacroCommand(c, map[string]interface{}{
+		"action": protocol.MacroDelete,
+		"name":   c.Param("name"),
+	}, http.StatusNotFound)
+}
+
+// handleExpandMacros fills in the macros and tokens of a message without
+// sending it, so the composer can show what will go out
+func (d *JS8Daemon) handleExpandMacros(c *gin.Context) {
+	var req struct {
+		To   string `json:"to"`
+		Text string `json:"text" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	d.sendMacroCommand(c, map[string]interface{}{
+		"action": protocol.MacroExpand,
+		"to":     req.To,
+		"text":   req.Text,
+	}, http.StatusBadRequest)
+}
+
+// sendMacroCommand sends a MACRO command and returns its result, answering
+// invalidStatus when the engine rejects the request
+func (d *JS8Daemon) sendMacroCommand(c *gin.Context, args map[string]interface{}, invalidStatus int) {
+	resp, err := d.clientFor(c).Do(&protocol.Command{
+		Type: protocol.CmdMacro,
+		Args: args,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.macro_command", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = invalidStatus
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetFilters lists the decode filters and what they dropped
+func (d *JS8Daemon) handleGetFilters(c *gin.Context) {
+	d.sendFilterCommand(c, map[string]interface{}{"action": protocol.FilterList}, http.StatusBadRequest)
+}
+
+// handleAddFilter adds a filter dropping the decodes from a callsign or near
+// an audio offset
+func (d *JS8Daemon) handleAddFilter(c *gin.Context) {
+	var req struct {
+		Type     string `json:"type" binding:"required"`
+		Callsign string `json:"callsign"`
+		Offset   int    `json:"offset"`
+		Width    *int   `json:"width"`
+		Note     string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	args := map[string]interface{}{
+		"action":   protocol.FilterAdd,
+		"type":     req.Type,
+		"callsign": req.Callsign,
+		"offset":   req.Offset,
+		"note":     req.Note,
+	}
+	if req.Width != nil {
+		args["width"] = *req.Width
+	}
+	d.sendFilterCommand(c, args, http.StatusBadRequest)
+}
+
+// handleDeleteFilter removes a decode filter
+func (d *JS8Daemon) handleDeleteFilter(c *gin.Context) {
+	d.sendFilterCommand(c, map[string]interface{}{
+		"action": protocol.FilterDelete,
+		"id":     c.Param("id"),
+	}, http.StatusNotFound)
+}
+
+// sendFilterCommand sends a FILTER command and returns its result, answering
+// invalidStatus when the engine rejects the request
+func (d *JS8Daemon) sendFilterCommand(c *gin.Context, args map[string]interface{}, invalidStatus int) {
+	resp, err := d.clientFor(c).Do(&protocol.Command{
+		Type: protocol.CmdFilter,
+		Args: args,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.filter_command", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = invalidStatus
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// handleGetForms lists the forms sent and received, newest first,
+// optionally only those in one direction
+func (d *JS8Daemon) handleGetForms(c *gin.Context) {
+	d.sendFormCommand(c, map[string]interface{}{
+		"action":    protocol.FormList,
+		"direction": c.Query("direction"),
+	})
+}
+
+// handleGetFormTemplates lists the templates forms can be sent with
+func (d *JS8Daemon) handleGetFormTemplates(c *gin.Context) {
+	d.sendFormCommand(c, map[string]interface{}{"action": protocol.FormTemplates})
+}
+
+// handleSendForm fills in a template and queues it for transmission
+func (d *JS8Daemon) handleSendForm(c *gin.Context) {
+	var req struct {
+		Template string            `json:"template" binding:"required"`
+		To       string            `json:"to"`
+		Values   map[string]string `json:"values"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	values := make(map[string]interface{}, len(req.Values))
+	for name, text := range req.Values {
+		values[name] = text
+	}
+	d.sendFormCommand(c, map[string]interface{}{
+		"action":   protocol.FormSend,
+		"template": req.Template,
+		"to":       req.To,
+		"values":   values,
+	})
+}
+
+// sendFormCommand sends a FORM command and relays its result
+func (d *JS8Daemon) sendFormCommand(c *gin.Context, args map[string]interface{}) {
+	resp, err := d.clientFor(c).Do(&protocol.Command{
+		Type: protocol.CmdForm,
+		Args: args,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.form_command", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		switch resp.Code {
+		case protocol.ErrCodeInvalid:
+			status = http.StatusBadRequest
+		case protocol.ErrCodeQueueFull:
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
+// maxFileUpload is the most of an uploaded file read before the engine
+// checks it against files.max_size
+const maxFileUpload = 64 << 10
+
+// handleGetFiles lists the file transfers, newest first
+func (d *JS8Daemon) handleGetFiles(c *gin.Context) {
+	d.sendFileCommand(c, map[string]interface{}{"action": protocol.FileList})
+}
+
+// handleDownloadFile serves the content of a transfer as a download
+func (d *JS8Daemon) handleDownloadFile(c *gin.Context) {
+	resp, ok := d.fileCommand(c, map[string]interface{}{
+		"action": protocol.FileGet,
+		"id":     c.Param("id"),
+	})
+	if !ok {
+		return
+	}
+
+	content, err := base64.StdEncoding.DecodeString(fmt.Sprint(resp.Data["content"]))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	name := "file"
+	if transfer, ok := resp.Data["transfer"].(map[string]interface{}); ok && transfer["name"] != "" {
+		name = fmt.Sprint(transfer["name"])
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	c.Data(http.StatusOK, "application/octet-stream", content)
+}
+
+// handleSendFile starts sending a file, uploaded as multipart form data
+// with the station in "to", or as JSON with the content in base64
+func (d *JS8Daemon) handleSendFile(c *gin.Context) {
+	var req struct {
+		To      string `json:"to" binding:"required"`
+		Name    string `json:"name"`
+		Content string `json:"content" binding:"required"`
+	}
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer file.Close()
+		content, err := io.ReadAll(io.LimitReader(file, maxFileUpload))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.To, req.Name, req.Content = c.PostForm("to"), header.Filename, base64.StdEncoding.EncodeToString(content)
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	d.sendFileCommand(c, map[string]interface{}{
+		"action":  protocol.FileSend,
+		"to":      req.To,
+		"name":    req.Name,
+		"content": req.Content,
+	})
+}
+
+// handleCancelFile gives up on a transfer in progress
+func (d *JS8Daemon) handleCancelFile(c *gin.Context) {
+	d.sendFileCommand(c, map[string]interface{}{
+		"action": protocol.FileCancel,
+		"id":     c.Param("id"),
+	})
+}
+
+// sendFileCommand sends a FILE command and relays its result
+func (d *JS8Daemon) sendFileCommand(c *gin.Context, args map[string]interface{}) {
+	if resp, ok := d.fileCommand(c, args); ok {
+		c.JSON(http.StatusOK, resp.Data)
+	}
+}
+
+// fileCommand sends a FILE command, answering the request itself and
+// reporting false when it fails
+func (d *JS8Daemon) fileCommand(c *gin.Context, args map[string]interface{}) (*protocol.Response, bool) {
+	resp, err := d.clientFor(c).Do(&protocol.Command{
+		Type: protocol.CmdFile,
+		Args: args,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.file_command", err),
+		})
+		return nil, false
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		switch resp.Code {
+		case protocol.ErrCodeInvalid:
+			status = http.StatusBadRequest
+		case protocol.ErrCodeQueueFull:
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return nil, false
+	}
+	return resp, true
+}
+
+// handleGetSessions lists the sessions open and recently closed
+func (d *JS8Daemon) handleGetSessions(c *gin.Context) {
+	d.sendSessionCommand(c, map[string]interface{}{"action": protocol.SessionList})
+}
+
+// handleGetSession returns a session and its messages
+func (d *JS8Daemon) handleGetSession(c *gin.Context) {
+	d.sendSessionCommand(c, map[string]interface{}{
+		"action": protocol.SessionGet,
+		"id":     c.Param("id"),
+	})
+}
+
+// handleConnectSession opens a session to the station in "to"
+func (d *JS8Daemon) handleConnectSession(c *gin.Context) {
+	var req struct {
+		To string `json:"to" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil {
-		offset = 0
+	d.sendSessionCommand(c, map[string]interface{}{
+		"action": protocol.SessionConnect,
+		"to":     req.To,
+	})
+}
+
+// handleSessionMessage writes a message to a session
+func (d *JS8Daemon) handleSessionMessage(c *gin.Context) {
+	var req struct {
+		Message string `json:"message" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Send message history request to core engine
-	cmd := fmt.Sprintf("GET_MESSAGE_HISTORY %d %d %s %s %s %t",
-		limit, offset, callsign, direction, messageType, unreadOnly)
+	d.sendSessionCommand(c, map[string]interface{}{
+		"action":  protocol.SessionSend,
+		"id":      c.Param("id"),
+		"message": req.Message,
+	})
+}
 
-	resp, err := d.socketClient.SendCommand(cmd)
+// handleDisconnectSession closes a session
+func (d *JS8Daemon) handleDisconnectSession(c *gin.Context) {
+	d.sendSessionCommand(c, map[string]interface{}{
+		"action": protocol.SessionDisconnect,
+		"id":     c.Param("id"),
+	})
+}
+
+// sendSessionCommand sends a SESSION command and relays its result
+func (d *JS8Daemon) sendSessionCommand(c *gin.Context, args map[string]interface{}) {
+	resp, err := d.clientFor(c).Do(&protocol.Command{
+		Type: protocol.CmdSession,
+		Args: args,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to get message history: %v", err),
+			"error": tr(c, "error.session_command", err),
 		})
 		return
 	}
 
 	if !resp.Success {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		status := http.StatusInternalServerError
+		switch resp.Code {
+		case protocol.ErrCodeInvalid:
+			status = http.StatusBadRequest
+		case protocol.ErrCodeQueueFull:
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, gin.H{
 			"error": resp.Error,
 		})
 		return
@@ -765,20 +2731,13 @@ func (d *JS8Daemon) handleGetMessageHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, resp.Data)
 }
 
-// handleGetConversations returns conversation summaries
-func (d *JS8Daemon) handleGetConversations(c *gin.Context) {
-	limitStr := c.DefaultQuery("limit", "20")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		limit = 20
-	}
-
-	// Send conversations request to core engine
-	cmd := fmt.Sprintf("GET_CONVERSATIONS %d", limit)
-	resp, err := d.socketClient.SendCommand(cmd)
+// handleCleanupMessages triggers manual cleanup of old messages
+func (d *JS8Daemon) handleCleanupMessages(c *gin.Context) {
+	// Send cleanup command to core engine
+	resp, err := d.clientFor(c).SendCommand("CLEANUP_MESSAGES")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to get conversations: %v", err),
+			"error": tr(c, "error.cleanup", err),
 		})
 		return
 	}
@@ -793,23 +2752,12 @@ func (d *JS8Daemon) handleGetConversations(c *gin.Context) {
 	c.JSON(http.StatusOK, resp.Data)
 }
 
-// handleMarkMessagesRead marks messages as read for a callsign
-func (d *JS8Daemon) handleMarkMessagesRead(c *gin.Context) {
-	var req struct {
-		Callsign string `json:"callsign" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Send mark read command to core engine
-	cmd := fmt.Sprintf("MARK_MESSAGES_READ %s", req.Callsign)
-	resp, err := d.socketClient.SendCommand(cmd)
+// handleRestart stops js8d gracefully and starts it again
+func (d *JS8Daemon) handleRestart(c *gin.Context) {
+	resp, err := d.clientFor(c).SendCommand("RESTART")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to mark messages as read: %v", err),
+			"error": tr(c, "error.restart", err),
 		})
 		return
 	}
@@ -821,32 +2769,16 @@ func (d *JS8Daemon) handleMarkMessagesRead(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"message": fmt.Sprintf("Messages marked as read for %s", req.Callsign),
-	})
+	c.JSON(http.StatusOK, resp.Data)
 }
 
-// handleSearchMessages searches through message history
-func (d *JS8Daemon) handleSearchMessages(c *gin.Context) {
-	query := c.Query("q")
-	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "search query required"})
-		return
-	}
-
-	limitStr := c.DefaultQuery("limit", "50")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		limit = 50
-	}
-
-	// Send search command to core engine
-	cmd := fmt.Sprintf("SEARCH_MESSAGES %s %d", query, limit)
-	resp, err := d.socketClient.SendCommand(cmd)
+// handleReboot stops js8d gracefully and reboots the host, if js8d
+// is allowed to
+func (d *JS8Daemon) handleReboot(c *gin.Context) {
+	resp, err := d.clientFor(c).SendCommand("REBOOT")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to search messages: %v", err),
+			"error": tr(c, "error.reboot", err),
 		})
 		return
 	}
@@ -861,13 +2793,12 @@ func (d *JS8Daemon) handleSearchMessages(c *gin.Context) {
 	c.JSON(http.StatusOK, resp.Data)
 }
 
-// handleGetMessageStats returns database statistics
-func (d *JS8Daemon) handleGetMessageStats(c *gin.Context) {
-	// Send stats request to core engine
-	resp, err := d.socketClient.SendCommand("GET_MESSAGE_STATS")
+// handleCheckUpdate compares the running version with the latest release
+func (d *JS8Daemon) handleCheckUpdate(c *gin.Context) {
+	resp, err := d.clientFor(c).SendCommand("CHECK_UPDATE")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to get message stats: %v", err),
+			"error": tr(c, "error.check_update", err),
 		})
 		return
 	}
@@ -882,20 +2813,44 @@ func (d *JS8Daemon) handleGetMessageStats(c *gin.Context) {
 	c.JSON(http.StatusOK, resp.Data)
 }
 
+// selfTestTimeout is how long the web server waits for a DSP self-test,
+// which decodes several cycles of audio when sweeping
+const selfTestTimeout = 2 * time.Minute
 
-// handleCleanupMessages triggers manual cleanup of old messages
-func (d *JS8Daemon) handleCleanupMessages(c *gin.Context) {
-	// Send cleanup command to core engine
-	resp, err := d.socketClient.SendCommand("CLEANUP_MESSAGES")
+// handleSelfTest encodes a message and decodes it again in software,
+// optionally at an SNR or sweeping for the decode threshold
+func (d *JS8Daemon) handleSelfTest(c *gin.Context) {
+	var req struct {
+		Message string   `json:"message"`
+		SNR     *float64 `json:"snr"`
+		Sweep   bool     `json:"sweep"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cmd := "SELFTEST"
+	if req.Sweep {
+		cmd += " SWEEP"
+	}
+	if req.SNR != nil {
+		cmd += " " + strconv.FormatFloat(*req.SNR, 'f', -1, 64)
+	}
+	if req.Message != "" {
+		cmd += " " + req.Message
+	}
+
+	resp, err := d.clientFor(c).WithTimeout(selfTestTimeout).SendCommand(cmd)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to cleanup messages: %v", err),
+			"error": tr(c, "error.selftest", err),
 		})
 		return
 	}
 
 	if !resp.Success {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusBadRequest, gin.H{
 			"error": resp.Error,
 		})
 		return
@@ -915,19 +2870,19 @@ var upgrader = websocket.Upgrader{
 func (d *JS8Daemon) handleAudioWebSocket(c *gin.Context) {
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		log.Printf("WebSocket upgrade failed: %v", err)
+		webLogger.Errorf("WebSocket upgrade failed: %v", err)
 		return
 	}
 	defer conn.Close()
 
-	log.Printf("Audio WebSocket client connected")
+	webLogger.Infof("Audio WebSocket client connected")
 
 	// Get audio monitor from core engine
-	audioMonitor := d.coreEngine.GetAudioMonitor()
+	audioMonitor := d.engineFor(c).GetAudioMonitor()
 	if audioMonitor == nil {
-		log.Printf("Audio monitor not available")
+		webLogger.Warnf("Audio monitor not available")
 		conn.WriteJSON(map[string]string{
-			"error": "audio monitor not available",
+			"error": tr(c, "error.no_audio_monitor"),
 		})
 		return
 	}
@@ -936,16 +2891,20 @@ func (d *JS8Daemon) handleAudioWebSocket(c *gin.Context) {
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
+	// Push RX level alarm changes as they happen
+	alarms, unsubscribe := d.engineFor(c).SubscribeAlarms()
+	defer unsubscribe()
+
 	// Handle client messages (for configuration)
 	go func() {
 		for {
 			var msg map[string]interface{}
 			if err := conn.ReadJSON(&msg); err != nil {
-				log.Printf("WebSocket read error: %v", err)
+				webLogger.Errorf("WebSocket read error: %v", err)
 				return
 			}
 			// Handle client configuration messages if needed
-			log.Printf("WebSocket message received: %v", msg)
+			webLogger.Debugf("WebSocket message received: %v", msg)
 		}
 	}()
 
@@ -953,6 +2912,22 @@ func (d *JS8Daemon) handleAudioWebSocket(c *gin.Context) {
 	for {
 		select {
 		case <-ticker.C:
+			// Levels keep coming while low power mode has the waterfall off
+			if !d.engineFor(c).WaterfallEnabled() {
+				levels := audioMonitor.GetCurrentLevels()
+				if err := conn.WriteJSON(map[string]interface{}{
+					"type":      "audio_data",
+					"low_power": true,
+					"rms":       levels.RMSLevel,
+					"peak":      levels.PeakLevel,
+					"clipping":  levels.Clipping,
+					"alarms":    audioMonitor.GetActiveAlarms(),
+				}); err != nil {
+					webLogger.Errorf("WebSocket write error: %v", err)
+					return
+				}
+				continue
+			}
 			vizData := audioMonitor.GetVisualizationData()
 
 			// Convert to format expected by JavaScript client
@@ -964,6 +2939,7 @@ func (d *JS8Daemon) handleAudioWebSocket(c *gin.Context) {
 				"rms": vizData.AudioLevelData.RMSLevel,
 				"peak": vizData.AudioLevelData.PeakLevel,
 				"clipping": vizData.AudioLevelData.Clipping,
+				"alarms": audioMonitor.GetActiveAlarms(),
 				// Spectrum data
 				"spectrum": map[string]interface{}{
 					"bins": vizData.SpectrumData.Spectrum,
@@ -972,23 +2948,303 @@ func (d *JS8Daemon) handleAudioWebSocket(c *gin.Context) {
 			}
 
 			if err := conn.WriteJSON(data); err != nil {
-				log.Printf("WebSocket write error: %v", err)
+				webLogger.Errorf("WebSocket write error: %v", err)
+				return
+			}
+
+		case alarm := <-alarms:
+			event := map[string]interface{}{
+				"type":  "audio_alarm",
+				"alarm": alarm,
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				webLogger.Errorf("WebSocket write error: %v", err)
+				return
+			}
+
+		case <-d.ctx.Done():
+			webLogger.Infof("Audio WebSocket client disconnected (context cancelled)")
+			return
+		}
+	}
+}
+
+// handleAudioPCMWebSocket streams RX audio so a remote operator can listen to
+// the band. A JSON audio_format message describes the binary frames that follow.
+func (d *JS8Daemon) handleAudioPCMWebSocket(c *gin.Context) {
+	encoding := c.DefaultQuery("encoding", audio.StreamEncodingMuLaw)
+	if encoding != audio.StreamEncodingMuLaw && encoding != audio.StreamEncodingPCM16 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": tr(c, "error.encoding", audio.StreamEncodingMuLaw, audio.StreamEncodingPCM16),
+		})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		webLogger.Errorf("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	stream := d.engineFor(c).GetPCMStream()
+	if err := conn.WriteJSON(map[string]interface{}{
+		"type":        "audio_format",
+		"encoding":    encoding,
+		"sample_rate": stream.SampleRate(),
+		"channels":    1,
+	}); err != nil {
+		return
+	}
+
+	chunks, unsubscribe := stream.Subscribe()
+	defer unsubscribe()
+	webLogger.Infof("Audio stream listener connected (%s, %d listeners)", encoding, stream.Listeners())
+
+	// The client only sends close frames, reading is how we notice them
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case chunk := <-chunks:
+			payload := audio.EncodeMuLaw(chunk)
+			if encoding == audio.StreamEncodingPCM16 {
+				payload = audio.EncodePCM16(chunk)
+			}
+			if err := conn.WriteMessage(websocket.BinaryMessage, payload); err != nil {
+				webLogger.Errorf("Audio stream write error: %v", err)
 				return
 			}
 
+		case <-closed:
+			webLogger.Infof("Audio stream listener disconnected")
+			return
+
 		case <-d.ctx.Done():
-			log.Printf("Audio WebSocket client disconnected (context cancelled)")
 			return
 		}
 	}
 }
 
+// handleGetSnippet plays back the audio of a decode, named by the snippet
+// field of its message. The gzipped WAV is sent as it is stored to clients
+// that accept gzip and unpacked for the rest.
+func (d *JS8Daemon) handleGetSnippet(c *gin.Context) {
+	snippets := d.engineFor(c).GetSnippets()
+	if snippets == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": tr(c, "error.snippets_off"),
+		})
+		return
+	}
+
+	name := c.Param("name")
+	path, err := snippets.Path(name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": tr(c, "error.snippet_not_found", name),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{
+		"filename": strings.TrimSuffix(name, ".gz"),
+	}))
+	c.Header("Vary", "Accept-Encoding")
+	if strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+		info, err := file.Stat()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.DataFromReader(http.StatusOK, info.Size(), "audio/wav", file, map[string]string{
+			"Content-Encoding": "gzip",
+		})
+		return
+	}
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.DataFromReader(http.StatusOK, -1, "audio/wav", zr, nil)
+}
+
+// handleGetRecordings lists the band recorder's recordings, oldest first,
+// with its usage against the quota
+func (d *JS8Daemon) handleGetRecordings(c *gin.Context) {
+	eng := d.engineFor(c)
+	recorder := eng.GetRecorder()
+	if recorder == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": tr(c, "error.recordings_off"),
+		})
+		return
+	}
+
+	recordings, err := recorder.Recordings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"recordings": recordings,
+		"usage":      eng.RecorderStats(),
+	})
+}
+
+// recordingPath finds a recording by name, answering the request itself
+// and returning false when there is none
+func (d *JS8Daemon) recordingPath(c *gin.Context) (*audio.Recorder, string, bool) {
+	recorder := d.engineFor(c).GetRecorder()
+	if recorder == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": tr(c, "error.recordings_off"),
+		})
+		return nil, "", false
+	}
+
+	name := c.Param("name")
+	path, err := recorder.Path(name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, "", false
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": tr(c, "error.recording_not_found", name),
+		})
+		return nil, "", false
+	}
+	return recorder, path, true
+}
+
+// handleGetRecording plays back or downloads a band recording, with range
+// requests so players can seek to a decode
+func (d *JS8Daemon) handleGetRecording(c *gin.Context) {
+	_, path, ok := d.recordingPath(c)
+	if !ok {
+		return
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := "audio/wav"
+	if strings.HasSuffix(path, ".opus") {
+		contentType = "audio/ogg"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{
+		"filename": filepath.Base(path),
+	}))
+	http.ServeContent(c.Writer, c.Request, filepath.Base(path), info.ModTime(), file)
+}
+
+// handleGetRecordingDecodes returns the decodes made while a band recording
+// was written, with how far into it each was
+func (d *JS8Daemon) handleGetRecordingDecodes(c *gin.Context) {
+	recorder, path, ok := d.recordingPath(c)
+	if !ok {
+		return
+	}
+	decodes, err := recorder.Decodes(filepath.Base(path))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"name":    filepath.Base(path),
+		"decodes": decodes,
+	})
+}
+
+// handleGetRedecode returns the last re-decode of band recordings, its
+// progress and the messages it found
+func (d *JS8Daemon) handleGetRedecode(c *gin.Context) {
+	d.sendRedecodeCommand(c, protocol.CmdRedecode)
+}
+
+// handleStartRedecode decodes the band recordings of a span again with the
+// current decoder, from and to as RFC 3339 times, to now when to is left out
+func (d *JS8Daemon) handleStartRedecode(c *gin.Context) {
+	var req struct {
+		From time.Time  `json:"from" binding:"required"`
+		To   *time.Time `json:"to"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	span := req.From.UTC().Format(time.RFC3339)
+	if req.To != nil {
+		span += "/" + req.To.UTC().Format(time.RFC3339)
+	}
+	d.sendRedecodeCommand(c, protocol.CmdRedecode+":"+span)
+}
+
+// handleCancelRedecode stops a re-decode in progress
+func (d *JS8Daemon) handleCancelRedecode(c *gin.Context) {
+	d.sendRedecodeCommand(c, protocol.CmdRedecode+":"+protocol.RedecodeCancel)
+}
+
+// sendRedecodeCommand sends a REDECODE command and returns the re-decode
+func (d *JS8Daemon) sendRedecodeCommand(c *gin.Context, command string) {
+	resp, err := d.clientFor(c).SendCommand(command)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": tr(c, "error.redecode", err),
+		})
+		return
+	}
+
+	if !resp.Success {
+		status := http.StatusInternalServerError
+		if resp.Code == protocol.ErrCodeInvalid {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error": resp.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Data)
+}
+
 // handleTestAudioData returns raw audio data for debugging
 func (d *JS8Daemon) handleTestAudioData(c *gin.Context) {
-	audioMonitor := d.coreEngine.GetAudioMonitor()
+	audioMonitor := d.engineFor(c).GetAudioMonitor()
 	if audioMonitor == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "audio monitor not available",
+			"error": tr(c, "error.no_audio_monitor"),
 		})
 		return
 	}
@@ -1038,10 +3294,10 @@ func (d *JS8Daemon) handleTestAudioData(c *gin.Context) {
 // handleGetAudioStats returns audio monitoring statistics
 func (d *JS8Daemon) handleGetAudioStats(c *gin.Context) {
 	// Get audio monitor from core engine
-	audioMonitor := d.coreEngine.GetAudioMonitor()
+	audioMonitor := d.engineFor(c).GetAudioMonitor()
 	if audioMonitor == nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "audio monitor not available",
+			"error": tr(c, "error.no_audio_monitor"),
 		})
 		return
 	}
@@ -1049,12 +3305,75 @@ func (d *JS8Daemon) handleGetAudioStats(c *gin.Context) {
 	stats := audioMonitor.GetStatistics()
 	levels := audioMonitor.GetCurrentLevels()
 
+	// Each RX channel has its own level monitor and alarms
+	alarms := []audio.AudioAlarm{}
+	channels := []gin.H{}
+	for _, monitor := range d.engineFor(c).GetAudioMonitors() {
+		alarms = append(alarms, monitor.GetActiveAlarms()...)
+		channels = append(channels, gin.H{
+			"statistics":     monitor.GetStatistics(),
+			"current_levels": monitor.GetCurrentLevels(),
+			"alarms":         monitor.GetActiveAlarms(),
+		})
+	}
+
 	response := gin.H{
 		"status":     "ok",
 		"statistics": stats,
 		"current_levels": levels,
+		"alarms":     alarms,
+		"channels":   channels,
 		"monitoring": audioMonitor.IsRunning(),
+		"prefilter":  d.engineFor(c).GetPreFilterStatistics(),
+		"stream":     d.engineFor(c).GetPCMStream().GetStatistics(),
+		"health":     d.engineFor(c).GetAudioHealthStatistics(),
+	}
+	if governor := d.engineFor(c).GetDecodeGovernorStatistics(); governor != nil {
+		response["decode_governor"] = governor
 	}
 
 	c.JSON(http.StatusOK, response)
 }
+
+// handleMessagesWebSocket pushes message store events so every open client
+// sees new messages, TX progress and read-state changes at once
+func (d *JS8Daemon) handleMessagesWebSocket(c *gin.Context) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		webLogger.Errorf("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	events, unsubscribe := d.engineFor(c).SubscribeEvents()
+	defer unsubscribe()
+	webLogger.Infof("Messages WebSocket client connected")
+
+	// The client only sends close frames, reading is how we notice them
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case event := <-events:
+			if err := conn.WriteJSON(event); err != nil {
+				webLogger.Errorf("Messages WebSocket write error: %v", err)
+				return
+			}
+
+		case <-closed:
+			webLogger.Infof("Messages WebSocket client disconnected")
+			return
+
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/i18n"
+)
+
+// languageCookie remembers the language picked in the web UI
+const languageCookie = "js8d_lang"
+
+// localize picks the language of the pages and API errors of a request: a
+// ?lang= query parameter, which the cookie then remembers, the cookie, the
+// browser's Accept-Language, and last web.language
+func localize(getConfig func() *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := strings.ToLower(c.Query("lang"))
+		if i18n.Supported(lang) {
+			c.SetSameSite(http.SameSiteLaxMode)
+			c.SetCookie(languageCookie, lang, 365*24*3600, "/", "", false, false)
+		} else if cookie, err := c.Cookie(languageCookie); err == nil && i18n.Supported(cookie) {
+			lang = cookie
+		} else {
+			lang = i18n.Match(c.GetHeader("Accept-Language"), strings.ToLower(getConfig().Web.Language))
+		}
+		c.Set("lang", lang)
+		c.Next()
+	}
+}
+
+// langFor returns the language localize picked for a request
+func langFor(c *gin.Context) string {
+	if lang := c.GetString("lang"); lang != "" {
+		return lang
+	}
+	return i18n.Default
+}
+
+// templateFuncs lets page templates translate with {{t .lang "key"}} and
+// list the languages for the picker
+var templateFuncs = template.FuncMap{
+	"t":            i18n.T,
+	"languages":    func() []string { return i18n.Languages },
+	"languageName": func(lang string) string { return i18n.Names[lang] },
+}
+
+// tr translates a message for a request, formatting it with args
+func tr(c *gin.Context, key string, args ...interface{}) string {
+	return i18n.T(langFor(c), key, args...)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/dougsko/js8d/pkg/client"
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/engine"
+	"github.com/dougsko/js8d/pkg/hardware"
+)
+
+// instanceCookie remembers the instance picked in the web UI
+const instanceCookie = "js8d_instance"
+
+// engineInstance is one rig: its engine, the socket it listens on and a
+// client for that socket
+type engineInstance struct {
+	name         string
+	config       *config.Config
+	coreEngine   *engine.CoreEngine
+	socketClient *client.SocketClient
+}
+
+func newEngineInstance(ctx context.Context, cfg *config.Config, configPath string, lifecycle *lifecycle) *engineInstance {
+	coreEngine := engine.NewCoreEngine(cfg, cfg.API.UnixSocket, configPath)
+
+	// Panics in the audio, decode and socket goroutines restart them
+	coreEngine.SetSupervisor(newSupervisor(ctx, cfg.Instance))
+
+	// Admins may restart the whole daemon, or reboot the host, from any instance
+	coreEngine.SetLifecycle(lifecycle)
+	coreEngine.SetVersion(Version)
+
+	// The web server checks roles itself and acts as admin on the socket
+	socketClient := client.NewSocketClient(cfg.API.UnixSocket)
+	socketClient.SetToken(coreEngine.SessionToken())
+
+	return &engineInstance{
+		name:         cfg.Instance,
+		config:       cfg,
+		coreEngine:   coreEngine,
+		socketClient: socketClient,
+	}
+}
+
+// findInstance returns the named instance, or the first one for an empty name
+func (d *JS8Daemon) findInstance(name string) *engineInstance {
+	if name == "" {
+		return d.instances[0]
+	}
+	for _, inst := range d.instances {
+		if inst.name == name {
+			return inst
+		}
+	}
+	return nil
+}
+
+// instanceNames lists the instance names in configuration order
+func (d *JS8Daemon) instanceNames() []string {
+	names := make([]string, len(d.instances))
+	for i, inst := range d.instances {
+		names[i] = inst.name
+	}
+	return names
+}
+
+// selectInstance picks the instance a request is for, from the instance
+// query parameter, the X-JS8D-Instance header or the web UI cookie
+func (d *JS8Daemon) selectInstance(c *gin.Context) {
+	name := c.Query("instance")
+	if name == "" {
+		name = c.GetHeader("X-JS8D-Instance")
+	}
+	if name == "" {
+		if cookie, err := c.Cookie(instanceCookie); err == nil && d.findInstance(cookie) != nil {
+			name = cookie
+		}
+	}
+
+	inst := d.findInstance(name)
+	if inst == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+			"error": tr(c, "error.unknown_instance", name),
+		})
+		return
+	}
+	c.Set("instance", inst)
+	c.Next()
+}
+
+// instanceFor returns the instance chosen by selectInstance
+func (d *JS8Daemon) instanceFor(c *gin.Context) *engineInstance {
+	if inst, ok := c.Get("instance"); ok {
+		return inst.(*engineInstance)
+	}
+	return d.instances[0]
+}
+
+func (d *JS8Daemon) engineFor(c *gin.Context) *engine.CoreEngine {
+	return d.instanceFor(c).coreEngine
+}
+
+func (d *JS8Daemon) clientFor(c *gin.Context) *client.SocketClient {
+	return d.instanceFor(c).socketClient
+}
+
+// handleGetInstances lists the engine instances hosted by this daemon
+func (d *JS8Daemon) handleGetInstances(c *gin.Context) {
+	selected := d.instanceFor(c)
+
+	instances := make([]gin.H, 0, len(d.instances))
+	for _, inst := range d.instances {
+		instances = append(instances, gin.H{
+			"name":     inst.name,
+			"callsign": inst.config.Station.Callsign,
+			"grid":     inst.config.Station.Grid,
+			"radio":    hardware.RigModelName(inst.config.Radio.Model),
+			"device":   inst.config.Radio.Device,
+			"audio":    inst.config.Audio.InputDevice,
+			"socket":   inst.config.API.UnixSocket,
+			"selected": inst == selected,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"instances": instances,
+		"count":     len(instances),
+	})
+}
+
+// handleSelectInstance makes an instance the default for this browser
+func (d *JS8Daemon) handleSelectInstance(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if d.findInstance(req.Name) == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "error.unknown_instance", req.Name)})
+		return
+	}
+
+	c.SetCookie(instanceCookie, req.Name, 365*24*3600, "/", "", false, false)
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "selected",
+		"instance": req.Name,
+	})
+}
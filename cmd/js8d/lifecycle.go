@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// Actions the engines can ask the daemon for
+const (
+	actionRestart = "restart"
+	actionReboot  = "reboot"
+)
+
+// lifecycle carries restart and reboot requests from the engines to main,
+// which stops the daemon before acting on them. It implements
+// engine.Lifecycle.
+type lifecycle struct {
+	actions chan string
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{actions: make(chan string, 1)}
+}
+
+// Restart asks main to stop the daemon and start it again
+func (l *lifecycle) Restart() {
+	l.request(actionRestart)
+}
+
+// Reboot asks main to stop the daemon and reboot the host
+func (l *lifecycle) Reboot() {
+	l.request(actionReboot)
+}
+
+// request queues an action; once one is queued the others are ignored
+func (l *lifecycle) request(action string) {
+	select {
+	case l.actions <- action:
+	default:
+	}
+}
+
+// restartProcess replaces the process with a new copy of js8d run with the
+// same arguments and environment. The PID stays the same, so a service
+// manager sees no exit.
+func restartProcess() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(executable, os.Args, os.Environ())
+}
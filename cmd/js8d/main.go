@@ -3,27 +3,45 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
 
 	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/hardware"
 	"github.com/dougsko/js8d/pkg/logging"
-	"github.com/dougsko/js8d/pkg/verbose"
+	"gopkg.in/yaml.v2"
 )
 
 var (
 	configPath  = flag.String("config", "config.yaml", "Configuration file path")
 	pidFilePath = flag.String("pidfile", "", "PID file path (default: /var/run/js8d.pid or ./js8d.pid)")
 	version     = flag.Bool("version", false, "Show version information")
-	verboseFlag = flag.Bool("verbose", false, "Enable verbose logging")
+	verboseFlag = flag.Bool("verbose", false, "Log every component at debug level")
+	aggregate   = flag.Bool("aggregate", false, "Run a dashboard for the js8d nodes in aggregator.nodes instead of a radio")
+	checkConfig = flag.Bool("check-config", false, "Validate the configuration file and exit")
+	dumpConfig  = flag.Bool("dump-default-config", false, "Print a commented configuration with every default and exit")
+	autodetect  = flag.Bool("autodetect", false, "Find a QRP Labs QDX or QMX on USB, write its settings to the configuration file and exit")
+
+	setSecret    = flag.String("set-secret", "", "Store the value read from stdin as the named secret and exit")
+	deleteSecret = flag.String("delete-secret", "", "Remove the named secret and exit")
+	listSecrets  = flag.Bool("list-secrets", false, "List the names in the secrets file and exit")
 )
 
-const (
+// Loggers for startup and for the web server
+var (
+	logger    = logging.Component("main")
+	webLogger = logging.Component("web")
+)
+
+// Version and Build are set at link time with -X
+var (
 	Version = "0.1.0-dev"
 	Build   = "development"
 )
@@ -122,17 +140,116 @@ func isProcessRunning(pid int) bool {
 func removePidFile(pidFile string) {
 	if pidFile != "" {
 		if err := os.Remove(pidFile); err != nil && !os.IsNotExist(err) {
-			log.Printf("Warning: failed to remove PID file %s: %v", pidFile, err)
+			logger.Warnf("Failed to remove PID file %s: %v", pidFile, err)
+		}
+	}
+}
+
+// runCheckConfig loads and validates the configuration, including every
+// instance, and reports the result
+func runCheckConfig(path string) error {
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	instances, err := cfg.InstanceConfigs()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: OK (%s, %s)\n", path, cfg.Station.Callsign, cfg.Station.Grid)
+	if len(cfg.Instances) > 0 {
+		for _, instance := range instances {
+			fmt.Printf("  instance %s: %s on %s, socket %s\n", instance.Instance,
+				hardware.RigModelName(instance.Radio.Model), instance.Radio.Device, instance.API.UnixSocket)
+		}
+	}
+	return nil
+}
+
+// runAutodetect finds QRP Labs rigs on USB and writes the recommended
+// settings of the first into the configuration file
+func runAutodetect(path string) error {
+	rigs, err := hardware.DetectUSBRigs()
+	if err != nil {
+		return err
+	}
+	if len(rigs) == 0 {
+		return fmt.Errorf("no QRP Labs QDX or QMX found on USB")
+	}
+	for _, rig := range rigs {
+		fmt.Printf("%s on %s", rig.Name, rig.SerialDevice)
+		if rig.AudioDevice != "" {
+			fmt.Printf(", audio %s", rig.AudioDevice)
+		}
+		fmt.Println()
+	}
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	updated, err := cfg.Update(rigs[0].Settings)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(updated)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	fmt.Printf("%s: set up for %s\n", path, rigs[0].Name)
+	return nil
+}
+
+// runSecrets adds, removes or lists entries of the encrypted secrets file
+func runSecrets(path string) error {
+	store, err := config.OpenSecretsStore(path)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *listSecrets:
+		names := make([]string, 0, len(store.Secrets))
+		for name := range store.Secrets {
+			names = append(names, name)
 		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+
+	case *deleteSecret != "":
+		if _, ok := store.Secrets[*deleteSecret]; !ok {
+			return fmt.Errorf("no secret named %q", *deleteSecret)
+		}
+		delete(store.Secrets, *deleteSecret)
+
+	default:
+		value, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read secret: %w", err)
+		}
+		store.Secrets[*setSecret] = strings.TrimRight(string(value), "\r\n")
+	}
+
+	if err := store.Save(); err != nil {
+		return err
 	}
+	fmt.Printf("%s: %d secret(s)\n", store.Path, len(store.Secrets))
+	return nil
 }
 
 func main() {
 	flag.Parse()
 
-	// Set verbose logging flag
-	verbose.SetEnabled(*verboseFlag)
-
 	// Set hamlib debug level early based on verbose flag
 	if *verboseFlag {
 		os.Setenv("HAMLIB_DEBUG_LEVEL", "3") // Verbose hamlib debugging
@@ -145,6 +262,35 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *dumpConfig {
+		os.Stdout.Write(config.DefaultYAML)
+		os.Exit(0)
+	}
+
+	if *setSecret != "" || *deleteSecret != "" || *listSecrets {
+		if err := runSecrets(*configPath); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", *configPath, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if *autodetect {
+		if err := runAutodetect(*configPath); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", *configPath, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if *checkConfig {
+		if err := runCheckConfig(*configPath); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", *configPath, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	// Determine PID file path
 	var actualPidFile string
 	if *pidFilePath != "" {
@@ -176,18 +322,34 @@ func main() {
 		log.Fatalf("Failed to initialize logging: %v", err)
 	}
 	defer logging.CloseGlobalLogger()
+	if *verboseFlag {
+		logging.GetGlobalLogger().SetLevel("", logging.LevelDebug)
+	}
 
 	// Switch to using the new logger
-	logging.Info("main", fmt.Sprintf("js8d version %s starting...", Version))
-	logging.Info("main", fmt.Sprintf("PID: %d, PID file: %s", os.Getpid(), actualPidFile))
-	logging.Info("main", fmt.Sprintf("Station: %s (%s)", cfg.Station.Callsign, cfg.Station.Grid))
-	logging.Info("main", fmt.Sprintf("Radio: %s on %s", cfg.GetRadioName(), cfg.Radio.Device))
-	logging.Info("main", fmt.Sprintf("Web interface: http://%s:%d", cfg.Web.BindAddress, cfg.Web.Port))
-
-	// Create the daemon with config path for reloading
-	daemon, err := NewJS8Daemon(cfg, *configPath, *verboseFlag)
+	logger.Infof("js8d version %s starting...", Version)
+	logger.Infof("PID: %d, PID file: %s", os.Getpid(), actualPidFile)
+	logger.Infof("Station: %s (%s)", cfg.Station.Callsign, cfg.Station.Grid)
+	if *aggregate {
+		logger.Infof("Aggregating %d nodes", len(cfg.Aggregator.Nodes))
+	} else {
+		logger.Infof("Radio: %s on %s", hardware.RigModelName(cfg.Radio.Model), cfg.Radio.Device)
+	}
+	logger.Infof("Web interface: http://%s:%d", cfg.Web.BindAddress, cfg.Web.Port)
+
+	// Create the daemon with config path for reloading, or the aggregator
+	var daemon interface {
+		Start() error
+		Stop() error
+	}
+	lifecycle := newLifecycle()
+	if *aggregate {
+		daemon, err = NewAggregator(cfg)
+	} else {
+		daemon, err = NewJS8Daemon(cfg, *configPath, *verboseFlag, lifecycle)
+	}
 	if err != nil {
-		logging.Error("main", fmt.Sprintf("Failed to create daemon: %v", err))
+		logger.Errorf("Failed to create daemon: %v", err)
 		os.Exit(1)
 	}
 
@@ -197,20 +359,43 @@ func main() {
 
 	// Start the daemon
 	if err := daemon.Start(); err != nil {
-		logging.Error("main", fmt.Sprintf("Failed to start daemon: %v", err))
+		logger.Errorf("Failed to start daemon: %v", err)
 		os.Exit(1)
 	}
 
-	logging.Info("main", "js8d started successfully")
+	logger.Infof("js8d started successfully")
 
-	// Wait for shutdown signal
-	<-sigChan
-	logging.Info("main", "Shutting down...")
+	// Wait for a shutdown signal, or an admin asking for a restart or reboot
+	action := ""
+	select {
+	case <-sigChan:
+	case action = <-lifecycle.actions:
+	}
+	logger.Infof("Shutting down...")
 
 	// Graceful shutdown
 	if err := daemon.Stop(); err != nil {
-		logging.Error("main", fmt.Sprintf("Error during shutdown: %v", err))
+		logger.Errorf("Error during shutdown: %v", err)
 	}
 
-	logging.Info("main", "js8d stopped")
+	logger.Infof("js8d stopped")
+
+	switch action {
+	case actionRestart:
+		// The new process writes its own PID file and opens its own logs
+		logger.Infof("Restarting js8d")
+		removePidFile(actualPidFile)
+		logging.CloseGlobalLogger()
+		if err := restartProcess(); err != nil {
+			log.Fatalf("Failed to restart: %v", err)
+		}
+
+	case actionReboot:
+		logger.Warnf("Rebooting the host")
+		removePidFile(actualPidFile)
+		logging.CloseGlobalLogger()
+		if err := rebootHost(); err != nil {
+			log.Fatalf("Failed to reboot: %v", err)
+		}
+	}
 }
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// audioMetric is one audio health series, read from an instance's engine
+type audioMetric struct {
+	name, kind, help string
+	value            func(h audioHealthValues) float64
+}
+
+// audioHealthValues are an engine's audio health as plain numbers
+type audioHealthValues struct {
+	inputLatency, outputLatency          float64
+	inputFill, outputFill, decodeFill    float64
+	overruns, underruns, inputDropped    float64
+	decodeLag, decodeTime, decodeDropped float64
+	bufferSize                           float64
+}
+
+// audioMetrics are exported in the Prometheus text format at /metrics
+var audioMetrics = []audioMetric{
+	{"js8d_audio_input_latency_seconds", "gauge", "Age of captured audio when read from the sound card",
+		func(h audioHealthValues) float64 { return h.inputLatency }},
+	{"js8d_audio_output_latency_seconds", "gauge", "Audio written to the sound card not yet played",
+		func(h audioHealthValues) float64 { return h.outputLatency }},
+	{"js8d_audio_input_buffer_fill_ratio", "gauge", "Fill of the queue of captured blocks waiting for the engine",
+		func(h audioHealthValues) float64 { return h.inputFill }},
+	{"js8d_audio_output_buffer_fill_ratio", "gauge", "Fill of the queue of blocks waiting to be played",
+		func(h audioHealthValues) float64 { return h.outputFill }},
+	{"js8d_audio_decode_queue_fill_ratio", "gauge", "Fill of the queue of blocks waiting for the decoder",
+		func(h audioHealthValues) float64 { return h.decodeFill }},
+	{"js8d_audio_overruns_total", "counter", "Capture overruns, the sound card's buffer filling before it was read",
+		func(h audioHealthValues) float64 { return h.overruns }},
+	{"js8d_audio_underruns_total", "counter", "Playback underruns, the sound card's buffer running dry",
+		func(h audioHealthValues) float64 { return h.underruns }},
+	{"js8d_audio_input_dropped_blocks_total", "counter", "Captured blocks dropped with the engine not reading",
+		func(h audioHealthValues) float64 { return h.inputDropped }},
+	{"js8d_audio_decode_dropped_blocks_total", "counter", "Captured blocks dropped with the decoder behind",
+		func(h audioHealthValues) float64 { return h.decodeDropped }},
+	{"js8d_audio_decode_lag_seconds", "gauge", "How long the last captured block waited for the decoder",
+		func(h audioHealthValues) float64 { return h.decodeLag }},
+	{"js8d_audio_decode_duration_seconds", "gauge", "How long the last decode pass took",
+		func(h audioHealthValues) float64 { return h.decodeTime }},
+	{"js8d_audio_buffer_size_frames", "gauge", "Frames per capture block, audio buffer_size",
+		func(h audioHealthValues) float64 { return h.bufferSize }},
+}
+
+// instanceAudioHealth reads an instance's audio health as plain numbers
+func instanceAudioHealth(inst *engineInstance) audioHealthValues {
+	health := inst.coreEngine.AudioHealth()
+	fill := func(queued, size int) float64 {
+		if size == 0 {
+			return 0
+		}
+		return float64(queued) / float64(size)
+	}
+	card := health.Card
+	return audioHealthValues{
+		inputLatency:  card.InputLatency.Seconds(),
+		outputLatency: card.OutputLatency.Seconds(),
+		inputFill:     fill(card.InputQueued, card.InputQueueSize),
+		outputFill:    fill(card.OutputQueued, card.OutputQueueSize),
+		decodeFill:    fill(health.DecodeQueued, health.DecodeQueueSize),
+		overruns:      float64(card.Overruns),
+		underruns:     float64(card.Underruns),
+		inputDropped:  float64(card.InputDropped),
+		decodeLag:     health.DecodeLag.Seconds(),
+		decodeTime:    health.DecodeTime.Seconds(),
+		decodeDropped: float64(health.DecodeDropped),
+		bufferSize:    float64(health.BufferSize),
+	}
+}
+
+// handleMetrics exports every instance's audio health for Prometheus. The
+// label is js8d_instance, as Prometheus sets instance to the scrape target.
+func (d *JS8Daemon) handleMetrics(c *gin.Context) {
+	values := make([]audioHealthValues, len(d.instances))
+	for i, inst := range d.instances {
+		values[i] = instanceAudioHealth(inst)
+	}
+
+	var out strings.Builder
+	for _, metric := range audioMetrics {
+		fmt.Fprintf(&out, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind)
+		for i, inst := range d.instances {
+			fmt.Fprintf(&out, "%s{js8d_instance=%q} %g\n", metric.name, inst.name, metric.value(values[i]))
+		}
+	}
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(out.String()))
+}
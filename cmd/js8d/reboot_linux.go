@@ -0,0 +1,51 @@
+//go:build linux
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// capSysBoot is the capability that allows reboot(2)
+const capSysBoot = 22
+
+// CanReboot checks that js8d has CAP_SYS_BOOT, which it has when run as
+// root or given AmbientCapabilities=CAP_SYS_BOOT by systemd
+func (l *lifecycle) CanReboot() error {
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "CapEff:")
+		if !ok {
+			continue
+		}
+		caps, err := strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+		if err != nil {
+			return fmt.Errorf("failed to read capabilities: %w", err)
+		}
+		if caps&(1<<capSysBoot) == 0 {
+			return fmt.Errorf("js8d does not have CAP_SYS_BOOT")
+		}
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("failed to read capabilities")
+}
+
+// rebootHost flushes the filesystems and reboots
+func rebootHost() error {
+	syscall.Sync()
+	return syscall.Reboot(syscall.LINUX_REBOOT_CMD_RESTART)
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+var errRebootUnsupported = errors.New("rebooting the host is only supported on Linux")
+
+// CanReboot reports that the host can't be rebooted from js8d
+func (l *lifecycle) CanReboot() error {
+	return errRebootUnsupported
+}
+
+// rebootHost is never reached, CanReboot refuses first
+func rebootHost() error {
+	return errRebootUnsupported
+}
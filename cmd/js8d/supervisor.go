@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Restart backoff: the first restart waits minBackoff, each panic after it
+// doubles the wait up to maxBackoff, and a goroutine that ran for
+// stableAfter before panicking starts again from minBackoff
+const (
+	minBackoff  = time.Second
+	maxBackoff  = time.Minute
+	stableAfter = 5 * time.Minute
+)
+
+// supervisor recovers panics in an engine's goroutines and restarts them
+// with exponential backoff. It implements engine.Supervisor.
+type supervisor struct {
+	ctx      context.Context
+	instance string
+
+	mutex    sync.Mutex
+	restarts map[string]int
+}
+
+func newSupervisor(ctx context.Context, instance string) *supervisor {
+	return &supervisor{
+		ctx:      ctx,
+		instance: instance,
+		restarts: make(map[string]int),
+	}
+}
+
+// Run calls run until it returns without panicking. After a panic it waits
+// out the backoff and calls it again, unless stop is closed or the daemon
+// is shutting down.
+func (s *supervisor) Run(name string, stop <-chan struct{}, run func()) {
+	backoff := minBackoff
+	for {
+		started := time.Now()
+		if !s.runOnce(name, run) {
+			return
+		}
+
+		if time.Since(started) >= stableAfter {
+			backoff = minBackoff
+		}
+		logger.Warnf("Restarting %s in %s", s.label(name), backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-stop:
+			return
+		case <-s.ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// runOnce calls run and reports whether it panicked
+func (s *supervisor) runOnce(name string, run func()) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.Recovered(name, r)
+			panicked = true
+		}
+	}()
+	run()
+	return false
+}
+
+// Recovered counts and logs a panic, with the stack of the goroutine that
+// raised it
+func (s *supervisor) Recovered(name string, value interface{}) {
+	s.mutex.Lock()
+	s.restarts[name]++
+	s.mutex.Unlock()
+
+	logger.Errorf("%s panicked: %v\n%s", s.label(name), value, debug.Stack())
+}
+
+// Restarts returns the number of panics recovered for each goroutine
+func (s *supervisor) Restarts() map[string]int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	restarts := make(map[string]int, len(s.restarts))
+	for name, count := range s.restarts {
+		restarts[name] = count
+	}
+	return restarts
+}
+
+// label names a goroutine in the log, with its instance in a multi-rig
+// daemon
+func (s *supervisor) label(name string) string {
+	if s.instance == "" {
+		return name
+	}
+	return s.instance + " " + name
+}
@@ -0,0 +1,22 @@
+package main
+
+import "github.com/gin-gonic/gin"
+
+// themeCookie keeps the web UI theme picked in the browser, which theme.js
+// sets, so pages are rendered in it from the first paint
+const themeCookie = "js8d_theme"
+
+// themes are the web UI themes main.css defines, the default first
+var themes = []string{"dark", "light", "high-contrast", "night"}
+
+// themeFor returns the theme the browser picked, or the default
+func themeFor(c *gin.Context) string {
+	if cookie, err := c.Cookie(themeCookie); err == nil {
+		for _, theme := range themes {
+			if cookie == theme {
+				return theme
+			}
+		}
+	}
+	return themes[0]
+}
@@ -4,7 +4,10 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/dougsko/js8d/pkg/audio"
 	"github.com/dougsko/js8d/pkg/dsp"
 )
 
@@ -14,7 +17,10 @@ func main() {
 		sampleRate = flag.Int("rate", 12000, "Audio sample rate")
 		fillChar   = flag.String("fill", "-", "Character to pad short messages")
 		frameType  = flag.Int("type", 0, "JS8 frame type (0-7)")
-		output     = flag.String("output", "", "Output audio file (raw 16-bit samples)")
+		output     = flag.String("output", "", "Output audio file")
+		format     = flag.String("format", "", "Output format, raw (16-bit samples) or wav (default from the file extension)")
+		offset     = flag.Float64("offset", 1500, "Audio frequency of the lowest tone in Hz")
+		submode    = flag.String("submode", "normal", "JS8 submode (normal, fast, turbo, slow, ultra)")
 		showTones  = flag.Bool("tones", false, "Show tone sequence")
 	)
 	flag.Parse()
@@ -36,6 +42,24 @@ func main() {
 		os.Exit(1)
 	}
 
+	mode, err := dsp.ParseJS8Mode(*submode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid submode: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Raw unless asked for or writing a .wav file
+	if *format == "" {
+		*format = "raw"
+		if strings.EqualFold(filepath.Ext(*output), ".wav") {
+			*format = "wav"
+		}
+	}
+	if *format != "raw" && *format != "wav" {
+		fmt.Fprintf(os.Stderr, "Format must be raw or wav\n")
+		os.Exit(1)
+	}
+
 	// Pad message to 12 characters
 	paddedMsg, err := dsp.PadMessage(*message, (*fillChar)[0])
 	if err != nil {
@@ -49,6 +73,8 @@ func main() {
 	fmt.Printf("Padded:   %q\n", paddedMsg)
 	fmt.Printf("Length:   %d characters\n", len(paddedMsg))
 	fmt.Printf("Type:     %d\n", *frameType)
+	fmt.Printf("Submode:  %s\n", mode)
+	fmt.Printf("Offset:   %.1f Hz\n", *offset)
 	fmt.Printf("Rate:     %d Hz\n", *sampleRate)
 	fmt.Printf("\n")
 
@@ -82,15 +108,19 @@ func main() {
 	}
 
 	// Generate audio
-	audio := encoder.GenerateAudio(tones, *sampleRate)
-	duration := float64(len(audio)) / float64(*sampleRate)
+	samples, err := encoder.GenerateSubmodeAudio(tones, mode, *offset, *sampleRate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Audio generation failed: %v\n", err)
+		os.Exit(1)
+	}
+	duration := float64(len(samples)) / float64(*sampleRate)
 
-	fmt.Printf("✓ Generated %d audio samples (%.2f seconds)\n", len(audio), duration)
+	fmt.Printf("✓ Generated %d audio samples (%.2f seconds)\n", len(samples), duration)
 
 	// Calculate some statistics
 	var minSample, maxSample int16 = 32767, -32768
 	var avgSample float64
-	for _, sample := range audio {
+	for _, sample := range samples {
 		if sample < minSample {
 			minSample = sample
 		}
@@ -99,7 +129,7 @@ func main() {
 		}
 		avgSample += float64(sample)
 	}
-	avgSample /= float64(len(audio))
+	avgSample /= float64(len(samples))
 
 	fmt.Printf("Audio Stats:\n")
 	fmt.Printf("  Range:    %d to %d\n", minSample, maxSample)
@@ -107,7 +137,15 @@ func main() {
 	fmt.Printf("  Peak:     %.1f%% of full scale\n", float64(maxSample)/32767.0*100)
 
 	// Output to file if requested
-	if *output != "" {
+	if *output != "" && *format == "wav" {
+		if err := audio.WriteWAVFile(*output, samples, *sampleRate); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write output file: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✓ Wrote WAV audio to %s\n", *output)
+		fmt.Printf("  Play with: sox %s -t alsa\n", *output)
+	} else if *output != "" {
 		file, err := os.Create(*output)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to create output file: %v\n", err)
@@ -116,7 +154,7 @@ func main() {
 		defer file.Close()
 
 		// Write raw 16-bit samples (little endian)
-		for _, sample := range audio {
+		for _, sample := range samples {
 			file.Write([]byte{byte(sample), byte(sample >> 8)})
 		}
 
@@ -134,14 +172,14 @@ func main() {
 	fmt.Printf("  Message data: 29 tones (your message)\n")
 	fmt.Printf("  End Costas:    7 tones (sync)\n")
 	fmt.Printf("\nFrequency plan:\n")
-	fmt.Printf("  Base freq:    1000 Hz\n")
-	fmt.Printf("  Tone spacing: %.2f Hz\n", float64(*sampleRate)/2048.0)
-	fmt.Printf("  Bandwidth:    ~%.0f Hz (8 tones)\n", 8*float64(*sampleRate)/2048.0)
+	fmt.Printf("  Base freq:    %.1f Hz\n", *offset)
+	fmt.Printf("  Tone spacing: %.2f Hz\n", dsp.ToneSpacing(mode))
+	fmt.Printf("  Bandwidth:    ~%.0f Hz (8 tones)\n", 8*dsp.ToneSpacing(mode))
 
 	// Show first few frequency values
 	fmt.Printf("\nFirst few tone frequencies:\n")
-	baseFreq := 1000.0
-	freqSpacing := float64(*sampleRate) / 2048.0
+	baseFreq := *offset
+	freqSpacing := dsp.ToneSpacing(mode)
 	for i := 0; i < 8 && i < len(tones); i++ {
 		freq := baseFreq + float64(tones[i])*freqSpacing
 		fmt.Printf("  Tone %d: %.2f Hz\n", tones[i], freq)
@@ -0,0 +1,214 @@
+// js8sim generates audio of several JS8 signals at once, each at its own
+// offset, SNR, drift and submode, for measuring the decoder's sensitivity and
+// false decodes against signals whose content is known.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/audio"
+	"github.com/dougsko/js8d/pkg/dsp"
+)
+
+// signalFlags collects repeated -signal flags
+type signalFlags []dsp.SimSignal
+
+func (s *signalFlags) String() string {
+	return fmt.Sprintf("%d signals", len(*s))
+}
+
+func (s *signalFlags) Set(spec string) error {
+	signal, err := parseSignal(spec)
+	if err != nil {
+		return err
+	}
+	*s = append(*s, signal)
+	return nil
+}
+
+// parseSignal reads a signal from comma separated key=value pairs, such as
+// msg=K3DEP-W1AW,offset=1000,snr=-12,drift=0.05,mode=fast,dt=0.2,type=0
+func parseSignal(spec string) (dsp.SimSignal, error) {
+	signal := dsp.SimSignal{Offset: 1000}
+	for _, field := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return signal, fmt.Errorf("signal field %q is not key=value", field)
+		}
+
+		var err error
+		switch strings.ToLower(key) {
+		case "msg", "message":
+			signal.Message = value
+		case "offset":
+			signal.Offset, err = strconv.ParseFloat(value, 64)
+		case "snr":
+			signal.SNR, err = strconv.ParseFloat(value, 64)
+		case "drift":
+			signal.Drift, err = strconv.ParseFloat(value, 64)
+		case "dt":
+			signal.DT, err = strconv.ParseFloat(value, 64)
+		case "mode":
+			signal.Mode, err = dsp.ParseJS8Mode(value)
+		case "type":
+			signal.Type, err = strconv.Atoi(value)
+		default:
+			return signal, fmt.Errorf("unknown signal field %q", key)
+		}
+		if err != nil {
+			return signal, fmt.Errorf("signal field %s: %v", key, err)
+		}
+	}
+	if signal.Message == "" {
+		return signal, fmt.Errorf("signal %q has no msg", spec)
+	}
+	if _, err := signal.Frame(); err != nil {
+		return signal, err
+	}
+	return signal, nil
+}
+
+// randomSignals spreads count signals evenly from low to high Hz, each moved
+// about within its slot, with SNRs between snrLow and snrHigh and drifts of
+// up to drift Hz per second either way
+func randomSignals(rng *rand.Rand, count int, mode dsp.JS8Mode, low, high, snrLow, snrHigh, drift float64) []dsp.SimSignal {
+	slot := (high - low) / float64(count)
+	signals := make([]dsp.SimSignal, count)
+	for i := range signals {
+		signals[i] = dsp.SimSignal{
+			Message: fmt.Sprintf("SIM%03d", i+1),
+			Mode:    mode,
+			Offset:  low + (float64(i)+0.25+rng.Float64()/2)*slot,
+			SNR:     snrLow + rng.Float64()*(snrHigh-snrLow),
+			Drift:   (2*rng.Float64() - 1) * drift,
+			DT:      (2*rng.Float64() - 1) * 0.5,
+		}
+	}
+	return signals
+}
+
+func main() {
+	var signals signalFlags
+	flag.Var(&signals, "signal", "Signal as msg=TEXT,offset=HZ,snr=DB,drift=HZ/S,mode=NAME,dt=S,type=N (repeatable)")
+	var (
+		random     = flag.Int("random", 0, "Number of random signals to add")
+		modeName   = flag.String("mode", "normal", "Submode of random signals (normal, fast, turbo, slow, ultra)")
+		lowHz      = flag.Float64("low", 300, "Lowest offset of random signals in Hz")
+		highHz     = flag.Float64("high", 2700, "Highest offset of random signals in Hz")
+		snrLow     = flag.Float64("snr-min", -20, "Lowest SNR of random signals in dB")
+		snrHigh    = flag.Float64("snr-max", 0, "Highest SNR of random signals in dB")
+		maxDrift   = flag.Float64("drift", 0, "Largest drift of random signals in Hz per second")
+		sampleRate = flag.Int("rate", 12000, "Audio sample rate")
+		seconds    = flag.Float64("seconds", 0, "Seconds per cycle (0 for the longest submode's cycle)")
+		cycles     = flag.Int("cycles", 1, "Receive cycles to generate, each with fresh noise")
+		seed       = flag.Int64("seed", 1, "Random seed for the noise and random signals")
+		output     = flag.String("output", "", "Output WAV file (16-bit mono)")
+		decode     = flag.Bool("decode", false, "Decode each cycle and score the decoder")
+		decoder    = flag.String("decoder", dsp.DecoderGo, "Decoder to score (go or native)")
+	)
+	flag.Parse()
+
+	mode, err := dsp.ParseJS8Mode(*modeName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid mode: %v\n", err)
+		os.Exit(1)
+	}
+	if *random < 0 || *cycles < 1 || *highHz <= *lowHz {
+		fmt.Fprintf(os.Stderr, "Invalid -random, -cycles or offset range\n")
+		os.Exit(1)
+	}
+	rng := rand.New(rand.NewSource(*seed))
+	if *random > 0 {
+		signals = append(signals, randomSignals(rng, *random, mode, *lowHz, *highHz, *snrLow, *snrHigh, *maxDrift)...)
+	}
+	if len(signals) == 0 && !*decode {
+		fmt.Fprintf(os.Stderr, "Usage: %s -signal msg=CQ-N0CALL,offset=1000,snr=-10 [-random N] [options]\n", os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	fmt.Printf("JS8 Signal Simulation\n")
+	fmt.Printf("=====================\n")
+	fmt.Printf("%-3s %-12s %-6s %8s %6s %7s %6s\n", "#", "Frame", "Mode", "Offset", "SNR", "Drift", "DT")
+	for i, s := range signals {
+		frame, _ := s.Frame()
+		fmt.Printf("%-3d %-12s %-6s %8.1f %6.1f %7.3f %6.2f\n", i+1, frame, s.Mode, s.Offset, s.SNR, s.Drift, s.DT)
+	}
+	fmt.Printf("\n")
+
+	var engine dsp.DSPEngine
+	if *decode {
+		engine, err = dsp.NewEngine(*decoder)
+		if err == nil {
+			err = engine.Initialize()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Decoder failed: %v\n", err)
+			os.Exit(1)
+		}
+		defer engine.Close()
+		engine.SetSampleRate(*sampleRate)
+	}
+
+	var samplesOut []int16
+	found := make([]int, len(signals))
+	falseDecodes := 0
+	for cycle := 0; cycle < *cycles; cycle++ {
+		samples, err := dsp.Simulate(signals, *sampleRate, *seconds, *seed+int64(cycle))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Simulation failed: %v\n", err)
+			os.Exit(1)
+		}
+		if *output != "" {
+			samplesOut = append(samplesOut, samples...)
+		}
+		if engine == nil {
+			continue
+		}
+
+		var decodes []dsp.DecodeResult
+		if _, err := engine.DecodeBuffer(samples, func(result *dsp.DecodeResult) {
+			decodes = append(decodes, *result)
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Decode failed: %v\n", err)
+			os.Exit(1)
+		}
+		score := dsp.ScoreSim(signals, decodes)
+		for i, ok := range score.Found {
+			if ok {
+				found[i]++
+			}
+		}
+		for _, result := range score.False {
+			fmt.Printf("Cycle %d false decode: %q at %.1f Hz, %d dB\n", cycle+1, result.Message, result.Frequency, result.SNR)
+		}
+		falseDecodes += len(score.False)
+	}
+
+	if *output != "" {
+		if err := audio.WriteWAVFile(*output, samplesOut, *sampleRate); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", *output, err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Wrote %d cycles (%.1f seconds) to %s\n", *cycles, float64(len(samplesOut))/float64(*sampleRate), *output)
+	}
+
+	if engine != nil {
+		decoded := 0
+		fmt.Printf("\nDecoder Score (%s, %d cycles)\n", *decoder, *cycles)
+		fmt.Printf("=============\n")
+		for i, s := range signals {
+			fmt.Printf("%-3d %6.1f dB  %d/%d decoded\n", i+1, s.SNR, found[i], *cycles)
+			decoded += found[i]
+		}
+		if total := len(signals) * *cycles; total > 0 {
+			fmt.Printf("Decoded:        %d of %d (%.1f%%)\n", decoded, total, 100*float64(decoded)/float64(total))
+		}
+		fmt.Printf("False decodes:  %d (%.2f per cycle)\n", falseDecodes, float64(falseDecodes)/float64(*cycles))
+	}
+}
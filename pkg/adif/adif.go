@@ -0,0 +1,89 @@
+// Package adif writes logs in the ADIF text (.adi) format that logging
+// programs, LoTW's TQSL and eQSL read
+package adif
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Version is the ADIF specification the files follow
+const Version = "3.1.4"
+
+// Field is one named value of a record or the header
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Record is the fields of one QSO, in the order they are written
+type Record []Field
+
+// Add appends a field, leaving out empty values
+func (r *Record) Add(name, value string) {
+	if value != "" {
+		*r = append(*r, Field{Name: strings.ToUpper(name), Value: value})
+	}
+}
+
+// Date formats the UTC date of t as QSO_DATE wants it
+func Date(t time.Time) string {
+	return t.UTC().Format("20060102")
+}
+
+// Time formats the UTC time of t as TIME_ON wants it
+func Time(t time.Time) string {
+	return t.UTC().Format("150405")
+}
+
+// Frequency formats a frequency in Hz as FREQ wants it, in MHz
+func Frequency(hz int) string {
+	if hz <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.6f", float64(hz)/1e6)
+}
+
+// Write writes a file: a header line of text and the header fields, which
+// need not include ADIF_VER, then one line per record
+func Write(w io.Writer, program string, header Record, records []Record) error {
+	if err := WriteHeader(w, program, header); err != nil {
+		return err
+	}
+	return WriteRecords(w, records)
+}
+
+// WriteHeader writes the start of a file up to its first record, for a
+// file written a part at a time
+func WriteHeader(w io.Writer, program string, header Record) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s ADIF export\n", program)
+	writeField(bw, Field{Name: "ADIF_VER", Value: Version})
+	writeField(bw, Field{Name: "PROGRAMID", Value: program})
+	for _, f := range header {
+		writeField(bw, f)
+	}
+	bw.WriteString("<EOH>\n")
+	return bw.Flush()
+}
+
+// WriteRecords writes records one per line, following WriteHeader
+func WriteRecords(w io.Writer, records []Record) error {
+	bw := bufio.NewWriter(w)
+	for _, record := range records {
+		for _, f := range record {
+			writeField(bw, f)
+		}
+		bw.WriteString("<EOR>\n")
+	}
+	return bw.Flush()
+}
+
+// writeField writes a field as <NAME:length>value, the length counting
+// bytes
+func writeField(w *bufio.Writer, f Field) {
+	fmt.Fprintf(w, "<%s:%d>%s ", f.Name, len(f.Value), f.Value)
+}
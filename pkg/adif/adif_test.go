@@ -0,0 +1,39 @@
+package adif
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	start := time.Date(2024, 1, 15, 11, 12, 30, 0, time.FixedZone("EST", -5*3600))
+
+	var record Record
+	record.Add("call", "DL1ABC")
+	record.Add("qso_date", Date(start))
+	record.Add("time_on", Time(start))
+	record.Add("freq", Frequency(14079500))
+	record.Add("gridsquare", "")
+
+	var b strings.Builder
+	if err := Write(&b, "js8d", Record{{Name: "STATION_CALLSIGN", Value: "K1ABC"}}, []Record{record}); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+
+	want := "js8d ADIF export\n" +
+		"<ADIF_VER:5>3.1.4 <PROGRAMID:4>js8d <STATION_CALLSIGN:5>K1ABC <EOH>\n" +
+		"<CALL:6>DL1ABC <QSO_DATE:8>20240115 <TIME_ON:6>161230 <FREQ:9>14.079500 <EOR>\n"
+	if b.String() != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, b.String())
+	}
+}
+
+func TestFrequency(t *testing.T) {
+	if f := Frequency(0); f != "" {
+		t.Errorf("Expected no frequency for 0, got %q", f)
+	}
+	if f := Frequency(7078000); f != "7.078000" {
+		t.Errorf("Expected 7.078000, got %q", f)
+	}
+}
@@ -0,0 +1,230 @@
+// Package arq carries connected-mode sessions between two js8d stations as
+// JS8 session frames. Each side writes messages into a stream that is cut
+// into numbered frames 5 bytes at a time. The sender sends a window of
+// frames and polls; the receiver answers with the next frame it needs in
+// order and a mask of the frames after that it already has, so only the
+// frames lost are sent again.
+package arq
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+)
+
+// MaxWindow is the most frames in flight, the most an ACK can account for
+const MaxWindow = dsp.SessionMaskFrames
+
+// MaxMessageLength is the longest message written to a session, in bytes
+const MaxMessageLength = 500
+
+// Control packs a control frame of session id between the stations from
+// and to
+func Control(id, op, seq uint8, mask uint16, from, to string) string {
+	frame, _ := dsp.PackSessionFrame(dsp.SessionFrame{
+		ID:       id,
+		Control:  true,
+		Op:       op,
+		Seq:      seq,
+		Mask:     mask,
+		FromHash: dsp.TransferCallHash(strings.ToUpper(from)),
+		ToHash:   dsp.TransferCallHash(strings.ToUpper(to)),
+	})
+	return frame
+}
+
+// Reply packs a control frame answering f, a control frame from the other
+// station, back to the station that sent it
+func Reply(f dsp.SessionFrame, op, seq uint8, mask uint16) string {
+	frame, _ := dsp.PackSessionFrame(dsp.SessionFrame{
+		ID:       f.ID,
+		Control:  true,
+		Op:       op,
+		Seq:      seq,
+		Mask:     mask,
+		FromHash: f.ToHash,
+		ToHash:   f.FromHash,
+	})
+	return frame
+}
+
+// Sender cuts the messages written to a session into numbered data frames
+// and keeps each until it is acknowledged
+type Sender struct {
+	ID      uint8
+	stream  []byte           // Written and not yet cut into frames
+	unacked map[uint8][]byte // Frames cut and not yet acknowledged, by seq
+	base    uint8            // Oldest frame not acknowledged
+	next    uint8            // Seq of the next frame cut
+}
+
+// NewSender starts the outgoing stream of session id
+func NewSender(id uint8) *Sender {
+	return &Sender{ID: id, unacked: make(map[uint8][]byte)}
+}
+
+// Write adds a message to the stream, ended by a NUL
+func (s *Sender) Write(message string) error {
+	if message == "" {
+		return fmt.Errorf("message is empty")
+	}
+	if strings.ContainsRune(message, 0) {
+		return fmt.Errorf("message contains a NUL")
+	}
+	if len(message) > MaxMessageLength {
+		return fmt.Errorf("message is %d bytes, over the %d byte limit", len(message), MaxMessageLength)
+	}
+	s.stream = append(append(s.stream, message...), 0)
+	return nil
+}
+
+// Pending reports whether anything written is not yet acknowledged
+func (s *Sender) Pending() bool {
+	return len(s.stream) > 0 || len(s.unacked) > 0
+}
+
+// InFlight is how many frames are cut and not yet acknowledged
+func (s *Sender) InFlight() int {
+	return len(s.unacked)
+}
+
+// Queued is how many bytes written are not yet cut into frames
+func (s *Sender) Queued() int {
+	return len(s.stream)
+}
+
+// Next is the seq of the next frame cut, which a poll names
+func (s *Sender) Next() uint8 {
+	return s.next
+}
+
+// Round returns the data frames to send next, oldest first: those not yet
+// acknowledged, then new ones cut from the stream until window frames are
+// in flight. The last frame of the stream is padded with NULs, which end
+// no message.
+func (s *Sender) Round(window int) []string {
+	if window < 1 || window > MaxWindow {
+		window = MaxWindow
+	}
+	for len(s.stream) > 0 && int(s.next-s.base) < window {
+		data := make([]byte, dsp.SessionFrameBytes)
+		n := copy(data, s.stream)
+		s.stream = s.stream[n:]
+		s.unacked[s.next] = data
+		s.next++
+	}
+
+	frames := make([]string, 0, len(s.unacked))
+	for seq := s.base; seq != s.next; seq++ {
+		data, ok := s.unacked[seq]
+		if !ok {
+			continue
+		}
+		frame, _ := dsp.PackSessionFrame(dsp.SessionFrame{ID: s.ID, Seq: seq, Data: data})
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// Ack takes the receiver's answer to a poll: every frame before seq is in,
+// and those after it in mask. It returns how many frames it acknowledged;
+// an ACK naming frames not in flight is stale and acknowledges none.
+func (s *Sender) Ack(seq uint8, mask uint16) int {
+	if seq-s.base > s.next-s.base {
+		return 0
+	}
+
+	acked := 0
+	for ; s.base != seq; s.base++ {
+		if _, ok := s.unacked[s.base]; ok {
+			delete(s.unacked, s.base)
+			acked++
+		}
+	}
+	for i := 0; i < dsp.SessionMaskFrames; i++ {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		if _, ok := s.unacked[seq+1+uint8(i)]; ok {
+			delete(s.unacked, seq+1+uint8(i))
+			acked++
+		}
+	}
+	for s.base != s.next {
+		if _, ok := s.unacked[s.base]; ok {
+			break
+		}
+		s.base++
+	}
+	return acked
+}
+
+// Receiver puts a session's incoming data frames back in order and splits
+// the stream into messages
+type Receiver struct {
+	ID      uint8
+	next    uint8            // Next frame expected in order
+	frames  map[uint8][]byte // Frames in after next, by seq
+	partial []byte           // Start of a message not yet ended
+}
+
+// NewReceiver starts the incoming stream of session id
+func NewReceiver(id uint8) *Receiver {
+	return &Receiver{ID: id, frames: make(map[uint8][]byte)}
+}
+
+// Add keeps a data frame and returns the messages it completes, in order,
+// and whether the frame was new. Frames already passed, or too far ahead
+// of the next one expected for an ACK to name, are dropped.
+func (r *Receiver) Add(f dsp.SessionFrame) ([]string, bool) {
+	ahead := f.Seq - r.next
+	if int(ahead) > dsp.SessionMaskFrames {
+		return nil, false
+	}
+	if _, ok := r.frames[f.Seq]; ok {
+		return nil, false
+	}
+	r.frames[f.Seq] = f.Data
+
+	for {
+		data, ok := r.frames[r.next]
+		if !ok {
+			break
+		}
+		delete(r.frames, r.next)
+		r.partial = append(r.partial, data...)
+		r.next++
+	}
+
+	var messages []string
+	for {
+		end := -1
+		for i, b := range r.partial {
+			if b == 0 {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			break
+		}
+		if end > 0 {
+			messages = append(messages, string(r.partial[:end]))
+		}
+		r.partial = r.partial[end+1:]
+	}
+	return messages, true
+}
+
+// Ack returns the answer to a poll: the next frame needed in order and the
+// mask of frames after it already in
+func (r *Receiver) Ack() (uint8, uint16) {
+	var mask uint16
+	for seq := range r.frames {
+		if ahead := seq - r.next; ahead >= 1 && int(ahead) <= dsp.SessionMaskFrames {
+			mask |= 1 << (ahead - 1)
+		}
+	}
+	return r.next, mask
+}
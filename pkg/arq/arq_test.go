@@ -0,0 +1,128 @@
+package arq
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+)
+
+func TestSessionStream(t *testing.T) {
+	sender := NewSender(9)
+	receiver := NewReceiver(9)
+	want := []string{"K1ABC", "Good morning, the net meets at 1900Z", strings.Repeat("x", 300)}
+	for _, message := range want {
+		if err := sender.Write(message); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	var got []string
+	rounds := 0
+	for sender.Pending() {
+		rounds++
+		if rounds > 50 {
+			t.Fatal("Expected the stream through within 50 rounds")
+		}
+		frames := sender.Round(8)
+		if len(frames) == 0 || len(frames) > 8 {
+			t.Fatalf("Round %d: expected 1 to 8 frames, got %d", rounds, len(frames))
+		}
+		for i, frame := range frames {
+			// Every third frame of the first rounds is lost
+			if rounds < 5 && i%3 == 1 {
+				continue
+			}
+			f, err := dsp.UnpackSessionFrame(frame)
+			if err != nil || f.ID != 9 || f.Control {
+				t.Fatalf("Expected a data frame of session 9, got %+v (%v)", f, err)
+			}
+			messages, _ := receiver.Add(f)
+			got = append(got, messages...)
+		}
+		sender.Ack(receiver.Ack())
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d messages, got %d: %q", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Message %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+	// 341 bytes and 3 NULs take 69 frames
+	if sender.Next() != 69 {
+		t.Errorf("Expected 69 frames cut, got %d", sender.Next())
+	}
+}
+
+func TestSessionSelectiveAck(t *testing.T) {
+	sender := NewSender(1)
+	receiver := NewReceiver(1)
+	sender.Write(strings.Repeat("a", 39)) // 8 frames with its NUL
+
+	frames := sender.Round(16)
+	if len(frames) != 8 {
+		t.Fatalf("Expected 8 frames, got %d", len(frames))
+	}
+	for _, i := range []int{0, 1, 3, 6} {
+		f, _ := dsp.UnpackSessionFrame(frames[i])
+		receiver.Add(f)
+	}
+	seq, mask := receiver.Ack()
+	if seq != 2 || mask != 1<<0|1<<3 {
+		t.Fatalf("Expected frame 2 needed and 3 and 6 in, got %d %016b", seq, mask)
+	}
+	if acked := sender.Ack(seq, mask); acked != 4 {
+		t.Errorf("Expected 4 frames acknowledged, got %d", acked)
+	}
+
+	// Only the four lost frames go again
+	resend := sender.Round(16)
+	if len(resend) != 4 {
+		t.Fatalf("Expected the 4 lost frames sent again, got %d", len(resend))
+	}
+	for i, wantSeq := range []uint8{2, 4, 5, 7} {
+		if f, _ := dsp.UnpackSessionFrame(resend[i]); f.Seq != wantSeq {
+			t.Errorf("Expected frame %d sent again, got %d", wantSeq, f.Seq)
+		}
+	}
+
+	// A stale ACK from before acknowledges nothing
+	if acked := sender.Ack(20, 0); acked != 0 {
+		t.Errorf("Expected an ACK past the frames sent ignored, got %d", acked)
+	}
+}
+
+func TestSessionSequenceWrap(t *testing.T) {
+	sender := NewSender(2)
+	receiver := NewReceiver(2)
+	var got []string
+	for i := 0; i < 60; i++ {
+		sender.Write(strings.Repeat(string(rune('a'+i%26)), 24)) // 5 frames each
+		for sender.Pending() {
+			for _, frame := range sender.Round(MaxWindow) {
+				f, _ := dsp.UnpackSessionFrame(frame)
+				messages, _ := receiver.Add(f)
+				got = append(got, messages...)
+			}
+			sender.Ack(receiver.Ack())
+		}
+	}
+	if len(got) != 60 || got[59] != strings.Repeat("h", 24) {
+		t.Errorf("Expected 60 messages through 300 frames, got %d", len(got))
+	}
+}
+
+func TestSessionWriteErrors(t *testing.T) {
+	sender := NewSender(0)
+	for _, message := range []string{"", "a\x00b", strings.Repeat("x", MaxMessageLength+1)} {
+		if err := sender.Write(message); err == nil {
+			t.Errorf("Expected an error writing %q", message)
+		}
+	}
+	if sender.Pending() {
+		t.Error("Expected nothing pending after failed writes")
+	}
+}
@@ -2,11 +2,14 @@ package audio
 
 import (
 	"fmt"
-	"log"
 	"sync"
 	"time"
+
+	"github.com/dougsko/js8d/pkg/logging"
 )
 
+var logger = logging.Component("audio")
+
 // AudioConfig represents audio system configuration
 type AudioConfig struct {
 	InputDevice  string
@@ -50,21 +53,21 @@ func NewAudioSystem(config AudioConfig) *AudioSystem {
 
 // Initialize initializes the audio system
 func (a *AudioSystem) Initialize() error {
-	log.Printf("Initializing audio system...")
-	log.Printf("Input device: %s", a.config.InputDevice)
-	log.Printf("Output device: %s", a.config.OutputDevice)
-	log.Printf("Sample rate: %d Hz", a.sampleRate)
-	log.Printf("Buffer size: %d samples", a.bufferSize)
+	logger.Infof("Initializing audio system...")
+	logger.Infof("Input device: %s", a.config.InputDevice)
+	logger.Infof("Output device: %s", a.config.OutputDevice)
+	logger.Infof("Sample rate: %d Hz", a.sampleRate)
+	logger.Infof("Buffer size: %d samples", a.bufferSize)
 
 	// TODO: Initialize ALSA or other audio system
 	// For now, use mock implementation
 
 	if a.mockInput {
-		log.Printf("Using mock audio input")
+		logger.Infof("Using mock audio input")
 	}
 
 	if a.mockOutput {
-		log.Printf("Using mock audio output")
+		logger.Infof("Using mock audio output")
 	}
 
 	return nil
@@ -88,7 +91,7 @@ func (a *AudioSystem) StartInput() error {
 		return fmt.Errorf("real audio input not implemented yet")
 	}
 
-	log.Printf("Audio input started")
+	logger.Infof("Audio input started")
 	return nil
 }
 
@@ -98,7 +101,7 @@ func (a *AudioSystem) StopInput() error {
 	defer a.mutex.Unlock()
 
 	a.recording = false
-	log.Printf("Audio input stopped")
+	logger.Infof("Audio input stopped")
 	return nil
 }
 
@@ -120,7 +123,7 @@ func (a *AudioSystem) StartOutput() error {
 		return fmt.Errorf("real audio output not implemented yet")
 	}
 
-	log.Printf("Audio output started")
+	logger.Infof("Audio output started")
 	return nil
 }
 
@@ -130,7 +133,7 @@ func (a *AudioSystem) StopOutput() error {
 	defer a.mutex.Unlock()
 
 	a.playing = false
-	log.Printf("Audio output stopped")
+	logger.Infof("Audio output stopped")
 	return nil
 }
 
@@ -162,7 +165,7 @@ func (a *AudioSystem) Close() error {
 	close(a.inputSamples)
 	close(a.outputSamples)
 
-	log.Printf("Audio system closed")
+	logger.Infof("Audio system closed")
 	return nil
 }
 
@@ -197,7 +200,7 @@ func (a *AudioSystem) mockOutputWorker() {
 		case samples := <-a.outputSamples:
 			// Simulate playing audio by consuming samples
 			duration := time.Duration(len(samples)*1000/a.sampleRate) * time.Millisecond
-			log.Printf("Mock audio: Playing %d samples (%.1fms)", len(samples), float64(duration)/float64(time.Millisecond))
+			logger.Debugf("Mock audio: Playing %d samples (%.1fms)", len(samples), float64(duration)/float64(time.Millisecond))
 			time.Sleep(duration)
 
 		case <-time.After(100 * time.Millisecond):
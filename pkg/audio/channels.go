@@ -0,0 +1,44 @@
+package audio
+
+// The channel of a stereo device carrying RX or TX audio, for rig
+// interfaces that only wire one
+const (
+	ChannelLeft  = "left"
+	ChannelRight = "right"
+	ChannelSum   = "sum"  // RX: the two channels averaged
+	ChannelBoth  = "both" // TX: the same audio on each channel
+)
+
+// FromStereo reduces interleaved stereo to mono in place, taking the left
+// or right channel or averaging the two, and returns the mono samples
+func FromStereo(samples []int16, channel string) []int16 {
+	frames := len(samples) / 2
+	mono := samples[:frames]
+	for i := 0; i < frames; i++ {
+		left, right := samples[2*i], samples[2*i+1]
+		switch channel {
+		case ChannelRight:
+			mono[i] = right
+		case ChannelSum:
+			mono[i] = int16((int32(left) + int32(right)) / 2)
+		default:
+			mono[i] = left
+		}
+	}
+	return mono
+}
+
+// ToStereo interleaves mono audio onto the left or right channel of a
+// stereo device, leaving the other silent, or onto both
+func ToStereo(samples []int16, channel string) []int16 {
+	stereo := make([]int16, 2*len(samples))
+	for i, s := range samples {
+		if channel != ChannelRight {
+			stereo[2*i] = s
+		}
+		if channel != ChannelLeft {
+			stereo[2*i+1] = s
+		}
+	}
+	return stereo
+}
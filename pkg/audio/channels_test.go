@@ -0,0 +1,39 @@
+package audio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFromStereo(t *testing.T) {
+	tests := []struct {
+		channel string
+		want    []int16
+	}{
+		{ChannelLeft, []int16{100, 32767}},
+		{ChannelRight, []int16{-100, 32767}},
+		{ChannelSum, []int16{0, 32767}},
+	}
+	for _, tt := range tests {
+		samples := []int16{100, -100, 32767, 32767}
+		if got := FromStereo(samples, tt.channel); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FromStereo(%s) = %v, want %v", tt.channel, got, tt.want)
+		}
+	}
+}
+
+func TestToStereo(t *testing.T) {
+	tests := []struct {
+		channel string
+		want    []int16
+	}{
+		{ChannelLeft, []int16{5, 0, -7, 0}},
+		{ChannelRight, []int16{0, 5, 0, -7}},
+		{ChannelBoth, []int16{5, 5, -7, -7}},
+	}
+	for _, tt := range tests {
+		if got := ToStereo([]int16{5, -7}, tt.channel); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ToStereo(%s) = %v, want %v", tt.channel, got, tt.want)
+		}
+	}
+}
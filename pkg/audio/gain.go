@@ -0,0 +1,23 @@
+package audio
+
+import "math"
+
+// ApplyGain scales samples in place by db decibels, clipping at full
+// scale. A gain of 0 dB leaves them untouched.
+func ApplyGain(samples []int16, db float64) {
+	if db == 0 {
+		return
+	}
+	factor := math.Pow(10, db/20)
+	for i, s := range samples {
+		scaled := math.Round(float64(s) * factor)
+		switch {
+		case scaled > math.MaxInt16:
+			samples[i] = math.MaxInt16
+		case scaled < math.MinInt16:
+			samples[i] = math.MinInt16
+		default:
+			samples[i] = int16(scaled)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package audio
+
+import "testing"
+
+func TestApplyGain(t *testing.T) {
+	samples := []int16{1000, -1000, 20000, -20000}
+	ApplyGain(samples, 0)
+	if samples[0] != 1000 {
+		t.Errorf("Expected 0 dB to leave samples alone, got %v", samples)
+	}
+
+	ApplyGain(samples, -6.0206)
+	if samples[0] != 500 || samples[1] != -500 {
+		t.Errorf("Expected -6 dB to halve samples, got %v", samples)
+	}
+
+	// Full scale clips rather than wrapping around
+	ApplyGain(samples, 12)
+	if samples[2] != 32767 || samples[3] != -32768 {
+		t.Errorf("Expected clipping at full scale, got %v", samples)
+	}
+}
@@ -1,12 +1,13 @@
 package audio
 
 import (
-	"log"
+	"fmt"
 	"math"
+	"sort"
 	"sync"
 	"time"
 
-	"github.com/mjibson/go-dsp/fft"
+	"github.com/dougsko/js8d/pkg/fft"
 )
 
 // AudioLevelData represents real-time audio level measurements
@@ -31,6 +32,40 @@ type AudioVisualizationData struct {
 	SpectrumData
 }
 
+// Audio alarm types
+const (
+	AlarmClipping  = "clipping"
+	AlarmDeadInput = "dead_input"
+)
+
+// AudioAlarm represents a change in an RX level alarm condition
+type AudioAlarm struct {
+	Type      string    `json:"type"`
+	Channel   string    `json:"channel,omitempty"` // RX channel label, empty for mono input
+	Active    bool      `json:"active"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// AlarmConfig controls when RX level alarms are raised
+type AlarmConfig struct {
+	ClipDuration      time.Duration // Sustained clipping before alarm (0 disables)
+	DeadInputDuration time.Duration // Silence or missing samples before alarm (0 disables)
+	DeadInputLevel    float32       // RMS level in dB below which input is considered dead
+}
+
+// DefaultAlarmConfig returns the default alarm thresholds
+func DefaultAlarmConfig() AlarmConfig {
+	return AlarmConfig{
+		ClipDuration:      5 * time.Second,
+		DeadInputDuration: 5 * time.Minute,
+		DeadInputLevel:    -80.0,
+	}
+}
+
+// historySeconds is how far back the spectrum history reaches
+const historySeconds = 60
+
 // AudioLevelMonitor processes audio samples for real-time visualization
 type AudioLevelMonitor struct {
 	mutex sync.RWMutex
@@ -39,6 +74,7 @@ type AudioLevelMonitor struct {
 	sampleRate   int
 	fftSize      int
 	updateRate   time.Duration
+	channel      string // RX channel label reported with alarms
 
 	// Current measurements
 	currentRMS   float32
@@ -48,8 +84,14 @@ type AudioLevelMonitor struct {
 	isClipping   bool
 
 	// Spectrum analysis
-	spectrum     []float32
-	spectrumTime time.Time
+	spectrum         []float32
+	spectrumTime     time.Time
+	spectrumInterval time.Duration // Least time between spectra, 0 for every buffer
+
+	// Peak of each spectrum bin in each of the last historySeconds
+	// seconds, slot i holding the second historyTimes[i]
+	history      [historySeconds][]float32
+	historyTimes [historySeconds]int64
 
 	// Buffers
 	sampleBuffer []int16
@@ -60,6 +102,22 @@ type AudioLevelMonitor struct {
 	sampleCount  int64
 	clipCount    int64
 
+	// Long-term statistics
+	avgRMS      float32 // Exponential moving average of RMS level in dB
+	minRMS      float32
+	maxRMS      float32
+	maxPeak     float32
+	statsStart  time.Time
+	lastSamples time.Time
+
+	// Alarm tracking
+	alarmConfig  AlarmConfig
+	alarmHandler func(AudioAlarm)
+	alarms       map[string]AudioAlarm
+	clipStart    time.Time
+	lastActivity time.Time
+	watchStart   time.Time // Start of the dead input timer, set by the watchdog or first samples
+
 	// Control
 	running bool
 	stopChan chan struct{}
@@ -75,6 +133,11 @@ func NewAudioLevelMonitor(sampleRate, fftSize int) *AudioLevelMonitor {
 		fftBuffer:  make([]complex128, fftSize),
 		window:     makeHannWindow(fftSize),
 		stopChan:   make(chan struct{}),
+
+		alarmConfig: DefaultAlarmConfig(),
+		alarms:      make(map[string]AudioAlarm),
+		maxRMS:      -100.0,
+		maxPeak:     -100.0,
 	}
 
 	return monitor
@@ -96,11 +159,16 @@ func (m *AudioLevelMonitor) ProcessSamples(samples []int16) {
 	}
 
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	var raised []AudioAlarm
+	defer func() {
+		handler := m.alarmHandler
+		m.mutex.Unlock()
+		m.dispatchAlarms(handler, raised)
+	}()
 
 	// Debug logging (limit to avoid spam)
 	if m.sampleCount%1000 == 0 {
-		log.Printf("AudioMonitor: Processing samples %d, buffer len=%d", m.sampleCount, len(samples))
+		logger.Debugf("AudioMonitor: Processing samples %d, buffer len=%d", m.sampleCount, len(samples))
 	}
 
 	// Calculate RMS and peak levels
@@ -109,7 +177,9 @@ func (m *AudioLevelMonitor) ProcessSamples(samples []int16) {
 	// Update spectrum if we have enough samples
 	m.sampleBuffer = append(m.sampleBuffer, samples...)
 	if len(m.sampleBuffer) >= m.fftSize {
-		m.calculateSpectrum()
+		if time.Since(m.spectrumTime) >= m.spectrumInterval {
+			m.calculateSpectrum()
+		}
 		// Keep only the newest samples
 		if len(m.sampleBuffer) > m.fftSize {
 			copy(m.sampleBuffer, m.sampleBuffer[len(m.sampleBuffer)-m.fftSize:])
@@ -118,6 +188,186 @@ func (m *AudioLevelMonitor) ProcessSamples(samples []int16) {
 	}
 
 	m.sampleCount += int64(len(samples))
+
+	// Update long-term statistics and alarm state
+	now := time.Now()
+	m.updateLongTermStats(now)
+	raised = m.checkAlarms(now)
+}
+
+// updateLongTermStats folds the latest levels into the long-term statistics
+func (m *AudioLevelMonitor) updateLongTermStats(now time.Time) {
+	if m.statsStart.IsZero() {
+		m.statsStart = now
+		m.avgRMS = m.currentRMS
+		m.minRMS = m.currentRMS
+	}
+	if m.watchStart.IsZero() {
+		m.watchStart = now
+	}
+	m.lastSamples = now
+
+	// Slow moving average - time constant of several hundred buffers
+	m.avgRMS += (m.currentRMS - m.avgRMS) * 0.001
+	if m.currentRMS < m.minRMS {
+		m.minRMS = m.currentRMS
+	}
+	if m.currentRMS > m.maxRMS {
+		m.maxRMS = m.currentRMS
+	}
+	if m.currentPeak > m.maxPeak {
+		m.maxPeak = m.currentPeak
+	}
+
+	if m.isClipping {
+		if m.clipStart.IsZero() {
+			m.clipStart = now
+		}
+	} else {
+		m.clipStart = time.Time{}
+	}
+
+	if m.currentRMS > m.alarmConfig.DeadInputLevel {
+		m.lastActivity = now
+	}
+}
+
+// checkAlarms evaluates alarm conditions and returns any state changes
+func (m *AudioLevelMonitor) checkAlarms(now time.Time) []AudioAlarm {
+	var changes []AudioAlarm
+
+	// Sustained clipping
+	if m.alarmConfig.ClipDuration > 0 {
+		clipping := !m.clipStart.IsZero() && now.Sub(m.clipStart) >= m.alarmConfig.ClipDuration
+		msg := "RX audio level back within range"
+		if clipping {
+			msg = fmt.Sprintf("RX audio clipping for more than %s - reduce input gain", m.alarmConfig.ClipDuration)
+		}
+		if alarm, changed := m.setAlarm(AlarmClipping, clipping, msg, now); changed {
+			changes = append(changes, alarm)
+		}
+	}
+
+	// Dead input: no samples at all, or nothing above the noise threshold
+	if m.alarmConfig.DeadInputDuration > 0 {
+		since := m.lastActivity
+		if since.IsZero() {
+			since = m.watchStart
+		}
+		dead := !since.IsZero() && now.Sub(since) >= m.alarmConfig.DeadInputDuration
+		msg := "RX audio input restored"
+		if dead {
+			msg = fmt.Sprintf("No RX audio for more than %s - check sound card and cabling", m.alarmConfig.DeadInputDuration)
+		}
+		if alarm, changed := m.setAlarm(AlarmDeadInput, dead, msg, now); changed {
+			changes = append(changes, alarm)
+		}
+	}
+
+	return changes
+}
+
+// setAlarm updates an alarm state, reporting whether it changed
+func (m *AudioLevelMonitor) setAlarm(alarmType string, active bool, message string, now time.Time) (AudioAlarm, bool) {
+	current, exists := m.alarms[alarmType]
+	if (exists && current.Active == active) || (!exists && !active) {
+		return current, false
+	}
+
+	if m.channel != "" {
+		message = fmt.Sprintf("%s: %s", m.channel, message)
+	}
+
+	alarm := AudioAlarm{
+		Type:      alarmType,
+		Channel:   m.channel,
+		Active:    active,
+		Message:   message,
+		Timestamp: now,
+	}
+	m.alarms[alarmType] = alarm
+	return alarm, true
+}
+
+// dispatchAlarms logs alarm changes and forwards them to the handler
+func (m *AudioLevelMonitor) dispatchAlarms(handler func(AudioAlarm), alarms []AudioAlarm) {
+	for _, alarm := range alarms {
+		if alarm.Active {
+			logger.Infof("AudioMonitor: ALARM %s: %s", alarm.Type, alarm.Message)
+		} else {
+			logger.Infof("AudioMonitor: alarm %s cleared: %s", alarm.Type, alarm.Message)
+		}
+		if handler != nil {
+			handler(alarm)
+		}
+	}
+}
+
+// SetAlarmConfig sets the alarm thresholds
+func (m *AudioLevelMonitor) SetAlarmConfig(cfg AlarmConfig) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.alarmConfig = cfg
+}
+
+// SetChannel labels the RX channel this monitor watches, so alarms from
+// separate receivers can be told apart
+func (m *AudioLevelMonitor) SetChannel(channel string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.channel = channel
+}
+
+// SetAlarmHandler registers a callback invoked whenever an alarm is raised or cleared
+func (m *AudioLevelMonitor) SetAlarmHandler(handler func(AudioAlarm)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.alarmHandler = handler
+}
+
+// GetActiveAlarms returns the currently active alarms
+func (m *AudioLevelMonitor) GetActiveAlarms() []AudioAlarm {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	active := []AudioAlarm{}
+	for _, alarm := range m.alarms {
+		if alarm.Active {
+			active = append(active, alarm)
+		}
+	}
+	return active
+}
+
+// CheckAlarms re-evaluates alarm conditions without new samples, so a
+// stalled or unplugged sound card is still detected
+func (m *AudioLevelMonitor) CheckAlarms() {
+	m.mutex.Lock()
+	now := time.Now()
+	if m.watchStart.IsZero() {
+		// Nothing received yet - start the dead input timer now
+		m.watchStart = now
+	}
+	raised := m.checkAlarms(now)
+	handler := m.alarmHandler
+	m.mutex.Unlock()
+
+	m.dispatchAlarms(handler, raised)
+}
+
+// alarmWatchdog periodically checks alarms while the monitor is running
+func (m *AudioLevelMonitor) alarmWatchdog() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.CheckAlarms()
+		case <-m.stopChan:
+			return
+		}
+	}
 }
 
 // calculateLevels computes RMS and peak levels from samples
@@ -189,7 +439,7 @@ func (m *AudioLevelMonitor) calculateSpectrum() {
 	}
 
 	// Perform FFT
-	fftResult := fft.FFT(m.fftBuffer)
+	fftResult := fft.Forward(m.fftBuffer)
 
 	// Calculate magnitude spectrum (only positive frequencies)
 	for i := 0; i < len(m.spectrum); i++ {
@@ -205,6 +455,69 @@ func (m *AudioLevelMonitor) calculateSpectrum() {
 	}
 
 	m.spectrumTime = time.Now()
+	m.recordHistory(m.spectrumTime)
+}
+
+// recordHistory folds the spectrum into the peaks of its second
+func (m *AudioLevelMonitor) recordHistory(now time.Time) {
+	second := now.Unix()
+	slot := int(second % historySeconds)
+	peaks := m.history[slot]
+	if peaks == nil {
+		peaks = make([]float32, len(m.spectrum))
+		m.history[slot] = peaks
+	}
+	if m.historyTimes[slot] != second {
+		m.historyTimes[slot] = second
+		copy(peaks, m.spectrum)
+		return
+	}
+	for i, level := range m.spectrum {
+		if level > peaks[i] {
+			peaks[i] = level
+		}
+	}
+}
+
+// BandPeak returns the strongest level in dB heard between low and high Hz
+// since the given time, up to a minute back. ok is false when no spectrum
+// was taken in that time.
+func (m *AudioLevelMonitor) BandPeak(low, high float64, since time.Time) (float32, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	freqStep := float64(m.sampleRate) / float64(m.fftSize)
+	first := int(low / freqStep)
+	last := int(math.Ceil(high / freqStep))
+	if first < 0 {
+		first = 0
+	}
+	if last >= len(m.spectrum) {
+		last = len(m.spectrum) - 1
+	}
+
+	now := time.Now().Unix()
+	peak, ok := float32(-100), false
+	for slot, second := range m.historyTimes {
+		if m.history[slot] == nil || second < since.Unix() || now-second >= historySeconds {
+			continue
+		}
+		for _, level := range m.history[slot][first : last+1] {
+			if !ok || level > peak {
+				peak, ok = level, true
+			}
+		}
+	}
+	return peak, ok
+}
+
+// SetSpectrumInterval computes the spectrum at most once per interval, to
+// save CPU while nobody watches the waterfall; 0 computes it for every
+// buffer
+func (m *AudioLevelMonitor) SetSpectrumInterval(interval time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.spectrumInterval = interval
 }
 
 // GetCurrentLevels returns the current audio levels
@@ -239,6 +552,33 @@ func (m *AudioLevelMonitor) GetCurrentSpectrum() SpectrumData {
 	}
 }
 
+// NoiseFloor estimates the noise floor in dB as the median of the spectrum
+// across the 200-2800 Hz JS8 passband, where most bins hold no signal. ok is
+// false before the first spectrum or once it is older than maxAge.
+func (m *AudioLevelMonitor) NoiseFloor(maxAge time.Duration) (float32, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.spectrumTime.IsZero() || time.Since(m.spectrumTime) > maxAge {
+		return 0, false
+	}
+
+	freqStep := float32(m.sampleRate) / float32(m.fftSize)
+	low := int(200 / freqStep)
+	high := int(2800/freqStep) + 1
+	if high > len(m.spectrum) {
+		high = len(m.spectrum)
+	}
+	if low >= high {
+		return 0, false
+	}
+
+	bins := make([]float32, high-low)
+	copy(bins, m.spectrum[low:high])
+	sort.Slice(bins, func(i, j int) bool { return bins[i] < bins[j] })
+	return bins[len(bins)/2], true
+}
+
 // GetVisualizationData returns combined audio data for visualization
 func (m *AudioLevelMonitor) GetVisualizationData() AudioVisualizationData {
 	levels := m.GetCurrentLevels()
@@ -260,7 +600,20 @@ func (m *AudioLevelMonitor) GetStatistics() map[string]interface{} {
 		clipRate = float64(m.clipCount) / float64(m.sampleCount) * 100.0
 	}
 
+	activeAlarms := []AudioAlarm{}
+	for _, alarm := range m.alarms {
+		if alarm.Active {
+			activeAlarms = append(activeAlarms, alarm)
+		}
+	}
+
+	var lastSamples interface{}
+	if !m.lastSamples.IsZero() {
+		lastSamples = m.lastSamples
+	}
+
 	return map[string]interface{}{
+		"channel":         m.channel,
 		"sample_count":    m.sampleCount,
 		"clip_count":      m.clipCount,
 		"clip_rate_pct":   clipRate,
@@ -268,15 +621,25 @@ func (m *AudioLevelMonitor) GetStatistics() map[string]interface{} {
 		"sample_rate":     m.sampleRate,
 		"fft_size":        m.fftSize,
 		"buffer_samples":  len(m.sampleBuffer),
+		"avg_rms_db":      m.avgRMS,
+		"min_rms_db":      m.minRMS,
+		"max_rms_db":      m.maxRMS,
+		"max_peak_db":     m.maxPeak,
+		"last_samples":    lastSamples,
+		"alarms":          activeAlarms,
 	}
 }
 
-// Start begins monitoring (currently just marks as running)
+// Start begins monitoring and the alarm watchdog
 func (m *AudioLevelMonitor) Start() error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
+	if m.running {
+		return nil
+	}
 	m.running = true
+	go m.alarmWatchdog()
 	return nil
 }
 
@@ -0,0 +1,238 @@
+package audio
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func loudSamples(n int) []int16 {
+	samples := make([]int16, n)
+	for i := range samples {
+		if i%2 == 0 {
+			samples[i] = 32767
+		} else {
+			samples[i] = -32767
+		}
+	}
+	return samples
+}
+
+func TestAudioAlarms(t *testing.T) {
+	t.Run("Sustained Clipping", func(t *testing.T) {
+		monitor := NewAudioLevelMonitor(48000, 1024)
+		monitor.SetAlarmConfig(AlarmConfig{ClipDuration: time.Second})
+
+		var raised []AudioAlarm
+		monitor.SetAlarmHandler(func(alarm AudioAlarm) {
+			raised = append(raised, alarm)
+		})
+
+		monitor.ProcessSamples(loudSamples(256))
+		if len(raised) != 0 {
+			t.Fatalf("Expected no alarm on first clipped buffer, got %d", len(raised))
+		}
+
+		// Pretend clipping started long enough ago
+		monitor.mutex.Lock()
+		monitor.clipStart = time.Now().Add(-2 * time.Second)
+		monitor.mutex.Unlock()
+
+		monitor.ProcessSamples(loudSamples(256))
+		if len(raised) != 1 || raised[0].Type != AlarmClipping || !raised[0].Active {
+			t.Fatalf("Expected active clipping alarm, got %+v", raised)
+		}
+		if len(monitor.GetActiveAlarms()) != 1 {
+			t.Errorf("Expected 1 active alarm, got %d", len(monitor.GetActiveAlarms()))
+		}
+
+		// Clean audio clears the alarm
+		monitor.ProcessSamples(make([]int16, 256))
+		if len(raised) != 2 || raised[1].Active {
+			t.Fatalf("Expected clipping alarm to clear, got %+v", raised)
+		}
+	})
+
+	t.Run("Dead Input", func(t *testing.T) {
+		monitor := NewAudioLevelMonitor(48000, 1024)
+		monitor.SetAlarmConfig(AlarmConfig{DeadInputDuration: time.Minute, DeadInputLevel: -80})
+
+		var raised []AudioAlarm
+		monitor.SetAlarmHandler(func(alarm AudioAlarm) {
+			raised = append(raised, alarm)
+		})
+
+		// No samples ever received - watchdog starts the timer
+		monitor.CheckAlarms()
+		if len(raised) != 0 {
+			t.Fatalf("Expected no alarm yet, got %+v", raised)
+		}
+
+		monitor.mutex.Lock()
+		monitor.watchStart = time.Now().Add(-2 * time.Minute)
+		monitor.mutex.Unlock()
+
+		monitor.CheckAlarms()
+		if len(raised) != 1 || raised[0].Type != AlarmDeadInput || !raised[0].Active {
+			t.Fatalf("Expected dead input alarm, got %+v", raised)
+		}
+
+		// Any real audio restores the input
+		monitor.ProcessSamples(loudSamples(256))
+		if len(raised) != 2 || raised[1].Type != AlarmDeadInput || raised[1].Active {
+			t.Fatalf("Expected dead input alarm to clear, got %+v", raised)
+		}
+	})
+
+	t.Run("Disabled Alarms", func(t *testing.T) {
+		monitor := NewAudioLevelMonitor(48000, 1024)
+		monitor.SetAlarmConfig(AlarmConfig{})
+
+		monitor.mutex.Lock()
+		monitor.watchStart = time.Now().Add(-time.Hour)
+		monitor.mutex.Unlock()
+
+		monitor.CheckAlarms()
+		if alarms := monitor.GetActiveAlarms(); len(alarms) != 0 {
+			t.Errorf("Expected no alarms when disabled, got %+v", alarms)
+		}
+	})
+}
+
+func TestLongTermStatistics(t *testing.T) {
+	monitor := NewAudioLevelMonitor(48000, 1024)
+	monitor.ProcessSamples(make([]int16, 512))
+	monitor.ProcessSamples(loudSamples(512))
+
+	stats := monitor.GetStatistics()
+	if stats["max_peak_db"].(float32) < -1 {
+		t.Errorf("Expected max peak near 0 dB, got %v", stats["max_peak_db"])
+	}
+	if stats["min_rms_db"].(float32) != -100 {
+		t.Errorf("Expected min RMS of -100 dB for silence, got %v", stats["min_rms_db"])
+	}
+	if stats["last_samples"] == nil {
+		t.Error("Expected last sample time to be recorded")
+	}
+}
+
+func TestLongTermStatisticsAfterWatchdog(t *testing.T) {
+	monitor := NewAudioLevelMonitor(48000, 1024)
+
+	// Watchdog runs before any audio arrives
+	monitor.CheckAlarms()
+
+	quiet := make([]int16, 512)
+	for i := range quiet {
+		quiet[i] = 100
+	}
+	monitor.ProcessSamples(quiet)
+
+	stats := monitor.GetStatistics()
+	avg := stats["avg_rms_db"].(float32)
+	if avg > -40 {
+		t.Errorf("Expected average RMS seeded from first samples, got %v dB", avg)
+	}
+	if stats["min_rms_db"].(float32) != avg {
+		t.Errorf("Expected min RMS %v to match first level, got %v", avg, stats["min_rms_db"])
+	}
+}
+
+func TestChannelAlarms(t *testing.T) {
+	monitor := NewAudioLevelMonitor(48000, 1024)
+	monitor.SetChannel("right")
+	monitor.SetAlarmConfig(AlarmConfig{DeadInputDuration: time.Minute, DeadInputLevel: -80})
+
+	var raised []AudioAlarm
+	monitor.SetAlarmHandler(func(alarm AudioAlarm) {
+		raised = append(raised, alarm)
+	})
+
+	monitor.mutex.Lock()
+	monitor.watchStart = time.Now().Add(-2 * time.Minute)
+	monitor.mutex.Unlock()
+
+	monitor.CheckAlarms()
+	if len(raised) != 1 || raised[0].Channel != "right" {
+		t.Fatalf("Expected dead input alarm tagged with channel, got %+v", raised)
+	}
+	if !strings.HasPrefix(raised[0].Message, "right: ") {
+		t.Errorf("Expected channel in alarm message, got %q", raised[0].Message)
+	}
+}
+
+func TestNoiseFloor(t *testing.T) {
+	monitor := NewAudioLevelMonitor(12000, 1024)
+	if _, ok := monitor.NoiseFloor(time.Minute); ok {
+		t.Fatal("Expected no noise floor before any spectrum")
+	}
+
+	// Noise at -60 dB with a strong signal in a few bins
+	for i := range monitor.spectrum {
+		monitor.spectrum[i] = -60
+	}
+	for i := 120; i < 130; i++ {
+		monitor.spectrum[i] = -10
+	}
+	monitor.spectrumTime = time.Now()
+
+	floor, ok := monitor.NoiseFloor(time.Minute)
+	if !ok || floor != -60 {
+		t.Errorf("Expected a -60 dB noise floor, got %v (%v)", floor, ok)
+	}
+
+	monitor.spectrumTime = time.Now().Add(-2 * time.Minute)
+	if _, ok := monitor.NoiseFloor(time.Minute); ok {
+		t.Error("Expected a stale spectrum to give no noise floor")
+	}
+}
+
+func TestBandPeak(t *testing.T) {
+	monitor := NewAudioLevelMonitor(12000, 1024)
+	now := time.Now()
+	if _, ok := monitor.BandPeak(1400, 1600, now.Add(-time.Minute)); ok {
+		t.Fatal("Expected no peak before any spectrum")
+	}
+
+	// A signal around 1500 Hz (bin 128) ten seconds ago, quiet since
+	for i := range monitor.spectrum {
+		monitor.spectrum[i] = -60
+	}
+	monitor.spectrum[128] = -20
+	monitor.recordHistory(now.Add(-10 * time.Second))
+	monitor.spectrum[128] = -60
+	monitor.recordHistory(now)
+
+	if peak, ok := monitor.BandPeak(1450, 1550, now.Add(-15*time.Second)); !ok || peak != -20 {
+		t.Errorf("Expected the -20 dB signal, got %v (%v)", peak, ok)
+	}
+	if peak, ok := monitor.BandPeak(1450, 1550, now.Add(-5*time.Second)); !ok || peak != -60 {
+		t.Errorf("Expected only noise in the last 5 seconds, got %v (%v)", peak, ok)
+	}
+	if peak, _ := monitor.BandPeak(1000, 1200, now.Add(-15*time.Second)); peak != -60 {
+		t.Errorf("Expected only noise away from the signal, got %v", peak)
+	}
+}
+
+func TestSpectrumInterval(t *testing.T) {
+	monitor := NewAudioLevelMonitor(12000, 256)
+	monitor.SetSpectrumInterval(time.Minute)
+
+	monitor.ProcessSamples(loudSamples(256))
+	first := monitor.GetCurrentSpectrum().Timestamp
+	if first == 0 {
+		t.Fatal("Expected a spectrum from the first full buffer")
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	monitor.ProcessSamples(loudSamples(256))
+	if got := monitor.GetCurrentSpectrum().Timestamp; got != first {
+		t.Errorf("Expected no new spectrum within the interval, got one at %d", got)
+	}
+
+	monitor.SetSpectrumInterval(0)
+	monitor.ProcessSamples(loudSamples(256))
+	if got := monitor.GetCurrentSpectrum().Timestamp; got == first {
+		t.Error("Expected a new spectrum with no interval")
+	}
+}
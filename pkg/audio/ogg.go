@@ -0,0 +1,180 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Ogg page header flags
+const (
+	oggContinued = 0x01 // The page's first packet started on the last page
+	oggFirst     = 0x02
+	oggLast      = 0x04
+)
+
+// oggCRCTable is the CRC-32 of the Ogg framing, polynomial 0x04C11DB7
+// without reflection
+var oggCRCTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		crc := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04C11DB7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+func oggCRC(data []byte) uint32 {
+	var crc uint32
+	for _, b := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// oggWriter writes one logical Ogg stream, gathering packets into pages.
+// Packets are short, Opus's at most 1275 bytes, so none spans pages.
+type oggWriter struct {
+	w        *bufio.Writer
+	serial   uint32
+	sequence uint32
+	first    bool
+
+	lacing  []byte
+	body    []byte
+	granule int64
+}
+
+func newOggWriter(w io.Writer, serial uint32) *oggWriter {
+	return &oggWriter{w: bufio.NewWriter(w), serial: serial, first: true}
+}
+
+// writePacket adds a packet ending at granule position granule, queued
+// until the page is flushed, or written at once when the page is full
+func (o *oggWriter) writePacket(packet []byte, granule int64) error {
+	values := len(packet)/255 + 1
+	if len(o.lacing)+values > 255 {
+		if err := o.flushPage(false); err != nil {
+			return err
+		}
+	}
+	for n := len(packet); n >= 255; n -= 255 {
+		o.lacing = append(o.lacing, 255)
+	}
+	o.lacing = append(o.lacing, byte(len(packet)%255))
+	o.body = append(o.body, packet...)
+	o.granule = granule
+	return nil
+}
+
+// flushPage writes the queued packets as one page, the last of the stream
+// when last is set
+func (o *oggWriter) flushPage(last bool) error {
+	flags := byte(0)
+	if o.first {
+		flags |= oggFirst
+		o.first = false
+	}
+	if last {
+		flags |= oggLast
+	}
+
+	page := make([]byte, 27, 27+len(o.lacing)+len(o.body))
+	copy(page, "OggS")
+	page[5] = flags
+	binary.LittleEndian.PutUint64(page[6:], uint64(o.granule))
+	binary.LittleEndian.PutUint32(page[14:], o.serial)
+	binary.LittleEndian.PutUint32(page[18:], o.sequence)
+	page[26] = byte(len(o.lacing))
+	page = append(append(page, o.lacing...), o.body...)
+	binary.LittleEndian.PutUint32(page[22:], oggCRC(page))
+	o.sequence++
+	o.lacing, o.body = o.lacing[:0], o.body[:0]
+
+	if _, err := o.w.Write(page); err != nil {
+		return err
+	}
+	return o.w.Flush()
+}
+
+// oggReader reads the packets of a single logical Ogg stream
+type oggReader struct {
+	r       *bufio.Reader
+	partial []byte
+	pending [][]byte
+	granule int64
+}
+
+func newOggReader(r io.Reader) *oggReader {
+	return &oggReader{r: bufio.NewReader(r)}
+}
+
+// readPacket returns the next packet and the granule position of the page
+// it ends on, io.EOF after the last
+func (o *oggReader) readPacket() ([]byte, int64, error) {
+	for len(o.pending) == 0 {
+		if err := o.readPage(); err != nil {
+			return nil, 0, err
+		}
+	}
+	packet := o.pending[0]
+	o.pending = o.pending[1:]
+	return packet, o.granule, nil
+}
+
+// readPage reads a page, queuing the packets that end on it
+func (o *oggReader) readPage() error {
+	header := make([]byte, 27)
+	if _, err := io.ReadFull(o.r, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return io.EOF
+		}
+		return err
+	}
+	if string(header[:4]) != "OggS" {
+		return fmt.Errorf("not an Ogg page")
+	}
+	lacing := make([]byte, header[26])
+	if _, err := io.ReadFull(o.r, lacing); err != nil {
+		return io.EOF
+	}
+	size := 0
+	for _, l := range lacing {
+		size += int(l)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(o.r, body); err != nil {
+		// A recording cut off mid-page ends with its last whole page
+		return io.EOF
+	}
+
+	page := append(append(append([]byte(nil), header...), lacing...), body...)
+	crc := binary.LittleEndian.Uint32(page[22:])
+	binary.LittleEndian.PutUint32(page[22:], 0)
+	if oggCRC(page) != crc {
+		return fmt.Errorf("Ogg page %d fails its checksum", binary.LittleEndian.Uint32(header[18:]))
+	}
+
+	if header[5]&oggContinued == 0 {
+		o.partial = nil
+	}
+	for _, l := range lacing {
+		o.partial = append(o.partial, body[:l]...)
+		body = body[l:]
+		if l < 255 {
+			o.pending = append(o.pending, o.partial)
+			o.partial = nil
+		}
+	}
+	o.granule = int64(binary.LittleEndian.Uint64(header[6:]))
+	return nil
+}
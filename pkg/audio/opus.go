@@ -0,0 +1,111 @@
+//go:build opus && cgo
+
+package audio
+
+// Building with -tags opus links libopus, for Opus band recordings. It is
+// left out of default builds so they don't need libopus installed.
+
+/*
+#cgo pkg-config: opus
+#include <opus.h>
+
+// The encoder's controls are variadic, which cgo can't call
+
+static int opus_set_bitrate(OpusEncoder *enc, opus_int32 bitrate) {
+    return opus_encoder_ctl(enc, OPUS_SET_BITRATE(bitrate));
+}
+
+static int opus_get_lookahead(OpusEncoder *enc, opus_int32 *lookahead) {
+    return opus_encoder_ctl(enc, OPUS_GET_LOOKAHEAD(lookahead));
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// OpusAvailable reports whether this js8d was built with libopus
+const OpusAvailable = true
+
+// opusEncoder compresses mono audio to Opus packets
+type opusEncoder struct {
+	enc       *C.OpusEncoder
+	lookahead int
+}
+
+// newOpusEncoder creates an encoder for mono audio at sampleRate, one of
+// the rates Opus takes, aiming for bitrate bits per second. It favours
+// fidelity over speech, so weak signals survive for re-decoding.
+func newOpusEncoder(sampleRate, bitrate int) (*opusEncoder, error) {
+	var status C.int
+	enc := C.opus_encoder_create(C.opus_int32(sampleRate), 1, C.OPUS_APPLICATION_AUDIO, &status)
+	if status != C.OPUS_OK {
+		return nil, fmt.Errorf("failed to create Opus encoder: %s", C.GoString(C.opus_strerror(status)))
+	}
+	if status = C.opus_set_bitrate(enc, C.opus_int32(bitrate)); status != C.OPUS_OK {
+		C.opus_encoder_destroy(enc)
+		return nil, fmt.Errorf("failed to set Opus bitrate %d: %s", bitrate, C.GoString(C.opus_strerror(status)))
+	}
+	var lookahead C.opus_int32
+	C.opus_get_lookahead(enc, &lookahead)
+	return &opusEncoder{enc: enc, lookahead: int(lookahead)}, nil
+}
+
+// encode compresses one frame of audio, 20 ms of it, to a packet
+func (e *opusEncoder) encode(frame []int16) ([]byte, error) {
+	packet := make([]byte, 1500)
+	n := C.opus_encode(e.enc, (*C.opus_int16)(unsafe.Pointer(&frame[0])), C.int(len(frame)),
+		(*C.uchar)(unsafe.Pointer(&packet[0])), C.opus_int32(len(packet)))
+	if n < 0 {
+		return nil, fmt.Errorf("Opus encoding failed: %s", C.GoString(C.opus_strerror(C.int(n))))
+	}
+	return packet[:n], nil
+}
+
+// preSkip returns how many samples at the encoder's rate the decoder drops
+// from the start of the stream
+func (e *opusEncoder) preSkip() int {
+	return e.lookahead
+}
+
+func (e *opusEncoder) close() {
+	C.opus_encoder_destroy(e.enc)
+}
+
+// opusDecoder decompresses Opus packets to mono audio
+type opusDecoder struct {
+	dec        *C.OpusDecoder
+	sampleRate int
+}
+
+// newOpusDecoder creates a decoder giving mono audio at sampleRate
+func newOpusDecoder(sampleRate int) (*opusDecoder, error) {
+	var status C.int
+	dec := C.opus_decoder_create(C.opus_int32(sampleRate), 1, &status)
+	if status != C.OPUS_OK {
+		return nil, fmt.Errorf("failed to create Opus decoder: %s", C.GoString(C.opus_strerror(status)))
+	}
+	return &opusDecoder{dec: dec, sampleRate: sampleRate}, nil
+}
+
+// decode decompresses a packet, appending its audio to out
+func (d *opusDecoder) decode(packet []byte, out []int16) ([]int16, error) {
+	// A packet holds at most 120 ms
+	pcm := make([]int16, d.sampleRate*120/1000)
+	var data *C.uchar
+	if len(packet) > 0 {
+		data = (*C.uchar)(unsafe.Pointer(&packet[0]))
+	}
+	n := C.opus_decode(d.dec, data, C.opus_int32(len(packet)),
+		(*C.opus_int16)(unsafe.Pointer(&pcm[0])), C.int(len(pcm)), 0)
+	if n < 0 {
+		return out, fmt.Errorf("Opus decoding failed: %s", C.GoString(C.opus_strerror(C.int(n))))
+	}
+	return append(out, pcm[:n]...), nil
+}
+
+func (d *opusDecoder) close() {
+	C.opus_decoder_destroy(d.dec)
+}
@@ -0,0 +1,43 @@
+//go:build !opus || !cgo
+
+package audio
+
+import "errors"
+
+// OpusAvailable reports whether this js8d was built with libopus
+const OpusAvailable = false
+
+// errNoOpus is returned for Opus recordings in a js8d built without libopus
+var errNoOpus = errors.New("Opus needs js8d built with -tags opus")
+
+// opusEncoder stands in for the libopus encoder
+type opusEncoder struct {
+	lookahead int
+}
+
+func newOpusEncoder(sampleRate, bitrate int) (*opusEncoder, error) {
+	return nil, errNoOpus
+}
+
+func (e *opusEncoder) encode(frame []int16) ([]byte, error) {
+	return nil, errNoOpus
+}
+
+func (e *opusEncoder) preSkip() int {
+	return e.lookahead
+}
+
+func (e *opusEncoder) close() {}
+
+// opusDecoder stands in for the libopus decoder
+type opusDecoder struct{}
+
+func newOpusDecoder(sampleRate int) (*opusDecoder, error) {
+	return nil, errNoOpus
+}
+
+func (d *opusDecoder) decode(packet []byte, out []int16) ([]int16, error) {
+	return out, errNoOpus
+}
+
+func (d *opusDecoder) close() {}
@@ -0,0 +1,622 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecorderRate is the sample rate recordings are made at, one Opus takes
+// that covers the JS8 passband
+const RecorderRate = 12000
+
+// Recording codecs
+const (
+	CodecOpus  = "opus"  // Ogg Opus, about 11 MB an hour at 24 kbit/s
+	CodecMuLaw = "mulaw" // G.711 mu-law WAV, 43 MB an hour
+)
+
+// Recording and decode index file extensions
+const (
+	opusExt  = ".opus"
+	muLawExt = ".wav"
+	indexExt = ".jsonl"
+)
+
+// opusFrame is 20 ms of audio at RecorderRate, encoded as one Opus packet
+const opusFrame = RecorderRate / 50
+
+// RecorderConfig configures the band recorder
+type RecorderConfig struct {
+	Dir     string
+	Codec   string        // CodecOpus or CodecMuLaw
+	Bitrate int           // Opus bits per second
+	Segment time.Duration // Audio per recording file
+	Quota   int64         // Bytes of recordings kept
+}
+
+// RecordingDecode is a decode made while a recording was running, in the
+// recording's index
+type RecordingDecode struct {
+	Time      time.Time `json:"time"`
+	Position  float64   `json:"position"` // Seconds into the recording when decoded, the signal is in the cycle before
+	Frequency int       `json:"frequency"`
+	Offset    int       `json:"offset"`
+	SNR       float32   `json:"snr"`
+	From      string    `json:"from,omitempty"`
+	Message   string    `json:"message"`
+	Channel   string    `json:"channel,omitempty"`
+}
+
+// Recording is a recording file in the recorder's directory
+type Recording struct {
+	Name    string    `json:"name"`
+	Start   time.Time `json:"start"`
+	Codec   string    `json:"codec"`
+	Size    int64     `json:"size"`
+	Decodes int       `json:"decodes"`
+	Current bool      `json:"current"` // Still being written
+}
+
+// recorderItem is audio, or a decode to index, queued for the recorder
+type recorderItem struct {
+	samples []int16
+	rate    int
+	at      time.Time
+	decode  *RecordingDecode
+}
+
+// Recorder is the band recorder. It writes all RX audio, compressed, to a
+// directory in segments, each with an index of the decodes made while it
+// was written, and removes the oldest once they take more than the quota.
+// Writing happens on its own goroutine, off the audio path.
+type Recorder struct {
+	config RecorderConfig
+	items  chan recorderItem
+	done   chan struct{}
+	closed sync.Once
+
+	mutex   sync.Mutex
+	used    int64 // Bytes taken by finished segments and the current one as last seen
+	current string
+	dropped uint64
+	lastErr error
+
+	// Owned by the writer goroutine
+	segment   segmentEncoder
+	index     *os.File
+	written   int // Samples in the current segment
+	resampler *PCMStream
+}
+
+// segmentEncoder writes one recording file
+type segmentEncoder interface {
+	write(samples []int16) error
+	close() error
+}
+
+// ValidRecordingName reports whether name is a plain recording file name,
+// with no path that could lead out of the directory
+func ValidRecordingName(name string) bool {
+	return (strings.HasSuffix(name, opusExt) || strings.HasSuffix(name, muLawExt)) &&
+		!strings.HasPrefix(name, ".") && filepath.Base(name) == name && !strings.ContainsAny(name, `/\`)
+}
+
+// NewRecorder opens or creates a recording directory and starts writing
+func NewRecorder(config RecorderConfig) (*Recorder, error) {
+	switch config.Codec {
+	case CodecOpus:
+		// Fail now rather than on the first segment
+		encoder, err := newOpusEncoder(RecorderRate, config.Bitrate)
+		if err != nil {
+			return nil, err
+		}
+		encoder.close()
+	case CodecMuLaw:
+	default:
+		return nil, fmt.Errorf("unknown recording codec %q", config.Codec)
+	}
+	if config.Segment <= 0 {
+		config.Segment = time.Hour
+	}
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+
+	r := &Recorder{
+		config: config,
+		items:  make(chan recorderItem, 64),
+		done:   make(chan struct{}),
+	}
+	files, err := r.list()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		r.used += file.size
+	}
+	go r.run()
+	return r, nil
+}
+
+// Write queues mono RX audio at sampleRate, captured ending at, for
+// recording. The samples are copied. Audio is dropped, and counted, when
+// the disk falls behind.
+func (r *Recorder) Write(samples []int16, sampleRate int, at time.Time) {
+	r.queue(recorderItem{samples: append([]int16(nil), samples...), rate: sampleRate, at: at})
+}
+
+// Mark adds a decode to the index of the recording being written
+func (r *Recorder) Mark(decode RecordingDecode) {
+	r.queue(recorderItem{decode: &decode})
+}
+
+func (r *Recorder) queue(item recorderItem) {
+	select {
+	case r.items <- item:
+	default:
+		r.mutex.Lock()
+		r.dropped++
+		r.mutex.Unlock()
+	}
+}
+
+// Close finishes the recording being written
+func (r *Recorder) Close() error {
+	r.closed.Do(func() { close(r.items) })
+	<-r.done
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.lastErr
+}
+
+// run writes queued audio and decodes until Close
+func (r *Recorder) run() {
+	defer close(r.done)
+	for item := range r.items {
+		var err error
+		if item.decode != nil {
+			err = r.writeDecode(*item.decode)
+		} else {
+			err = r.writeAudio(item)
+		}
+		if err != nil {
+			r.fail(err)
+		}
+	}
+	r.finishSegment()
+}
+
+// fail closes a segment that could not be written, so the next audio
+// starts a new one, and keeps the error for the status. An error repeated
+// block after block is logged once.
+func (r *Recorder) fail(err error) {
+	r.finishSegment()
+	r.mutex.Lock()
+	repeated := r.lastErr != nil && r.lastErr.Error() == err.Error()
+	r.lastErr = err
+	r.mutex.Unlock()
+	if !repeated {
+		logger.Warnf("Band recorder: %v", err)
+	}
+}
+
+// writeAudio brings audio to RecorderRate and writes it, starting a new
+// segment when the current one is full
+func (r *Recorder) writeAudio(item recorderItem) error {
+	if item.rate < RecorderRate {
+		return fmt.Errorf("recording needs audio at %d Hz or more, not %d", RecorderRate, item.rate)
+	}
+	if r.resampler == nil || r.resampler.inputRate != item.rate {
+		r.resampler = NewPCMStream(item.rate, RecorderRate)
+		r.resampler.reset()
+	}
+	samples := r.resampler.resample(item.samples, nil)
+
+	for len(samples) > 0 {
+		if r.segment == nil {
+			start := item.at.Add(-time.Duration(len(samples)) * time.Second / RecorderRate)
+			if err := r.startSegment(start); err != nil {
+				return err
+			}
+		}
+		room := int(r.config.Segment.Seconds()*RecorderRate) - r.written
+		n := len(samples)
+		if n > room {
+			n = room
+		}
+		if err := r.segment.write(samples[:n]); err != nil {
+			return fmt.Errorf("failed to write recording: %w", err)
+		}
+		r.written += n
+		samples = samples[n:]
+		if r.written >= int(r.config.Segment.Seconds()*RecorderRate) {
+			r.finishSegment()
+		}
+	}
+	return nil
+}
+
+// writeDecode adds a decode to the current segment's index
+func (r *Recorder) writeDecode(decode RecordingDecode) error {
+	if r.index == nil {
+		return nil
+	}
+	decode.Position = float64(r.written) / RecorderRate
+	line, err := json.Marshal(decode)
+	if err != nil {
+		return err
+	}
+	if _, err := r.index.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to index decode: %w", err)
+	}
+	return nil
+}
+
+// startSegment opens a recording file and its index named for start
+func (r *Recorder) startSegment(start time.Time) error {
+	base := start.UTC().Format("20060102-150405")
+	ext := muLawExt
+	if r.config.Codec == CodecOpus {
+		ext = opusExt
+	}
+	name := base + ext
+
+	file, err := os.Create(filepath.Join(r.config.Dir, name))
+	if err != nil {
+		return fmt.Errorf("failed to create recording: %w", err)
+	}
+	var segment segmentEncoder
+	if r.config.Codec == CodecOpus {
+		segment, err = newOpusSegment(file, r.config.Bitrate, start)
+	} else {
+		segment, err = newMuLawSegment(file)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	index, err := os.Create(filepath.Join(r.config.Dir, base+indexExt))
+	if err != nil {
+		segment.close()
+		os.Remove(file.Name())
+		return fmt.Errorf("failed to create recording index: %w", err)
+	}
+
+	r.segment, r.index, r.written = segment, index, 0
+	r.mutex.Lock()
+	r.current = name
+	r.mutex.Unlock()
+	logger.Infof("Band recorder: recording to %s", name)
+	return nil
+}
+
+// finishSegment closes the current recording, if any, and trims the oldest
+// to stay within the quota
+func (r *Recorder) finishSegment() {
+	if r.segment == nil {
+		return
+	}
+	if err := r.segment.close(); err != nil {
+		logger.Warnf("Band recorder: failed to finish recording: %v", err)
+	}
+	r.index.Close()
+	r.segment, r.index = nil, nil
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.current = ""
+	if _, err := r.usage(); err != nil {
+		logger.Warnf("Band recorder: %v", err)
+		return
+	}
+	if r.used > r.config.Quota {
+		if err := r.trim(); err != nil {
+			logger.Warnf("Band recorder over its quota: %v", err)
+		}
+	}
+}
+
+// Path returns where the recording called name is kept
+func (r *Recorder) Path(name string) (string, error) {
+	if !ValidRecordingName(name) {
+		return "", fmt.Errorf("invalid recording name %q", name)
+	}
+	return filepath.Join(r.config.Dir, name), nil
+}
+
+// Recordings lists the recordings kept, oldest first
+func (r *Recorder) Recordings() ([]Recording, error) {
+	r.mutex.Lock()
+	current := r.current
+	r.mutex.Unlock()
+
+	files, err := r.list()
+	if err != nil {
+		return nil, err
+	}
+	recordings := make([]Recording, 0, len(files))
+	for _, file := range files {
+		recording := Recording{
+			Name:    file.name,
+			Codec:   CodecMuLaw,
+			Size:    file.size,
+			Current: file.name == current,
+		}
+		if strings.HasSuffix(file.name, opusExt) {
+			recording.Codec = CodecOpus
+		}
+		base := strings.TrimSuffix(file.name, filepath.Ext(file.name))
+		recording.Start, _ = time.Parse("20060102-150405", base)
+		if decodes, err := r.Decodes(file.name); err == nil {
+			recording.Decodes = len(decodes)
+		}
+		recordings = append(recordings, recording)
+	}
+	return recordings, nil
+}
+
+// Decodes returns the index of the decodes made while the recording called
+// name was written
+func (r *Recorder) Decodes(name string) ([]RecordingDecode, error) {
+	path, err := r.Path(name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(strings.TrimSuffix(path, filepath.Ext(path)) + indexExt)
+	if os.IsNotExist(err) {
+		return []RecordingDecode{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decodes := []RecordingDecode{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var decode RecordingDecode
+		// A line cut short by a crash is skipped
+		if json.Unmarshal(scanner.Bytes(), &decode) == nil {
+			decodes = append(decodes, decode)
+		}
+	}
+	return decodes, scanner.Err()
+}
+
+// Usage returns the number of recordings, the bytes they take and the
+// quota, and how much audio was dropped with the disk behind
+func (r *Recorder) Usage() (count int, used, quota int64, dropped uint64, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	count, err = r.usage()
+	if err == nil {
+		err = r.lastErr
+	}
+	return count, r.used, r.config.Quota, r.dropped, err
+}
+
+// usage recounts the bytes taken; called with the mutex held
+func (r *Recorder) usage() (int, error) {
+	files, err := r.list()
+	if err != nil {
+		return 0, err
+	}
+	r.used = 0
+	for _, file := range files {
+		r.used += file.size
+	}
+	return len(files), nil
+}
+
+// recordingFile is a recording found in the directory
+type recordingFile struct {
+	name string
+	size int64 // With its index
+}
+
+// list returns the recordings in the directory, oldest first as their
+// names start with the time
+func (r *Recorder) list() ([]recordingFile, error) {
+	entries, err := os.ReadDir(r.config.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording directory: %w", err)
+	}
+	var files []recordingFile
+	for _, entry := range entries {
+		if entry.IsDir() || !ValidRecordingName(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		file := recordingFile{name: entry.Name(), size: info.Size()}
+		index := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())) + indexExt
+		if info, err := os.Stat(filepath.Join(r.config.Dir, index)); err == nil {
+			file.size += info.Size()
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files, nil
+}
+
+// trim removes the oldest recordings and their indexes until they fit the
+// quota, keeping the one being written; called with the mutex held
+func (r *Recorder) trim() error {
+	files, err := r.list()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if r.used <= r.config.Quota {
+			return nil
+		}
+		if file.name == r.current {
+			continue
+		}
+		path := filepath.Join(r.config.Dir, file.name)
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		os.Remove(strings.TrimSuffix(path, filepath.Ext(path)) + indexExt)
+		r.used -= file.size
+		logger.Infof("Band recorder: removed %s to stay within the quota", file.name)
+	}
+	return nil
+}
+
+// muLawSegment writes a mu-law WAV file. The sizes in the header are
+// filled in when it is closed; a file cut short by a crash still plays
+// in most players, which read to the end.
+type muLawSegment struct {
+	file    *os.File
+	w       *bufio.Writer
+	samples uint32
+}
+
+func newMuLawSegment(file *os.File) (*muLawSegment, error) {
+	s := &muLawSegment{file: file, w: bufio.NewWriter(file)}
+	return s, s.header()
+}
+
+// header writes the WAV header for the samples written so far: format 7,
+// mu-law, 8 bits mono
+func (s *muLawSegment) header() error {
+	header := []any{
+		[4]byte{'R', 'I', 'F', 'F'}, 36 + s.samples, [4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '}, uint32(16),
+		uint16(7), uint16(1), uint32(RecorderRate), uint32(RecorderRate), uint16(1), uint16(8),
+		[4]byte{'d', 'a', 't', 'a'}, s.samples,
+	}
+	for _, field := range header {
+		if err := binary.Write(s.w, binary.LittleEndian, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *muLawSegment) write(samples []int16) error {
+	s.samples += uint32(len(samples))
+	_, err := s.w.Write(EncodeMuLaw(samples))
+	return err
+}
+
+func (s *muLawSegment) close() error {
+	err := s.w.Flush()
+	if err == nil {
+		if _, err = s.file.Seek(0, 0); err == nil {
+			s.w.Reset(s.file)
+			if err = s.header(); err == nil {
+				err = s.w.Flush()
+			}
+		}
+	}
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// opusSegment writes an Ogg Opus file, a page a second so little is lost
+// to a crash
+type opusSegment struct {
+	file    *os.File
+	ogg     *oggWriter
+	encoder *opusEncoder
+	pending []int16
+	granule int64 // At 48 kHz, as Ogg Opus counts
+	packets int
+}
+
+func newOpusSegment(file *os.File, bitrate int, start time.Time) (*opusSegment, error) {
+	encoder, err := newOpusEncoder(RecorderRate, bitrate)
+	if err != nil {
+		return nil, err
+	}
+	s := &opusSegment{
+		file:    file,
+		ogg:     newOggWriter(file, uint32(start.Unix())),
+		encoder: encoder,
+		granule: int64(encoder.preSkip() * 48000 / RecorderRate),
+	}
+
+	// The identification and comment headers each go on a page of their own
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8] = 1 // Version
+	head[9] = 1 // Mono
+	binary.LittleEndian.PutUint16(head[10:], uint16(s.granule))
+	binary.LittleEndian.PutUint32(head[12:], RecorderRate)
+	tags := append([]byte("OpusTags"), 4, 0, 0, 0)
+	tags = append(append(tags, "js8d"...), 0, 0, 0, 0)
+	for _, packet := range [][]byte{head, tags} {
+		err := s.ogg.writePacket(packet, 0)
+		if err == nil {
+			err = s.ogg.flushPage(false)
+		}
+		if err != nil {
+			encoder.close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *opusSegment) write(samples []int16) error {
+	s.pending = append(s.pending, samples...)
+	for len(s.pending) >= opusFrame {
+		if err := s.encode(s.pending[:opusFrame]); err != nil {
+			return err
+		}
+		s.pending = append(s.pending[:0], s.pending[opusFrame:]...)
+	}
+	return nil
+}
+
+// encode writes one frame as a packet, flushing the page every second
+func (s *opusSegment) encode(frame []int16) error {
+	packet, err := s.encoder.encode(frame)
+	if err != nil {
+		return err
+	}
+	s.granule += opusFrame * 48000 / RecorderRate
+	if err := s.ogg.writePacket(packet, s.granule); err != nil {
+		return err
+	}
+	s.packets++
+	if s.packets%50 == 0 {
+		return s.ogg.flushPage(false)
+	}
+	return nil
+}
+
+// close pads out the last frame, ending the stream where the audio ends
+func (s *opusSegment) close() error {
+	var err error
+	if len(s.pending) > 0 {
+		short := len(s.pending)
+		frame := append(s.pending, make([]int16, opusFrame-short)...)
+		if err = s.encode(frame); err == nil {
+			s.ogg.granule -= int64((opusFrame - short) * 48000 / RecorderRate)
+		}
+	}
+	if err == nil {
+		err = s.ogg.flushPage(true)
+	}
+	s.encoder.close()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,174 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func newTestRecorder(t *testing.T, segment time.Duration, quota int64) (*Recorder, string) {
+	t.Helper()
+	dir := t.TempDir()
+	recorder, err := NewRecorder(RecorderConfig{Dir: dir, Codec: CodecMuLaw, Segment: segment, Quota: quota})
+	if err != nil {
+		t.Fatalf("NewRecorder failed: %v", err)
+	}
+	return recorder, dir
+}
+
+func TestRecorderSegments(t *testing.T) {
+	recorder, _ := newTestRecorder(t, time.Second, 1<<30)
+	start := time.Date(2026, 10, 16, 14, 20, 0, 0, time.UTC)
+
+	// 2.5 seconds at 48 kHz in 100 ms blocks, with a decode after the first second
+	for i := 1; i <= 25; i++ {
+		recorder.Write(tone(1000, 48000, 4800), 48000, start.Add(time.Duration(i)*100*time.Millisecond))
+		if i == 12 {
+			recorder.Mark(RecordingDecode{Time: start, Frequency: 14078000, Offset: 1500, SNR: -12, From: "N0CALL", Message: "N0CALL: HELLO"})
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	recordings, err := recorder.Recordings()
+	if err != nil || len(recordings) != 3 {
+		t.Fatalf("Recordings = %+v, %v, want 3 one second segments", recordings, err)
+	}
+	if recordings[0].Name != "20261016-142000.wav" || !recordings[0].Start.Equal(start) {
+		t.Errorf("First recording is %s from %v, want it named for %v", recordings[0].Name, recordings[0].Start, start)
+	}
+	if recordings[1].Decodes != 1 {
+		t.Errorf("Expected the decode indexed in the second recording, got %+v", recordings)
+	}
+
+	decodes, err := recorder.Decodes(recordings[1].Name)
+	if err != nil || len(decodes) != 1 || decodes[0].Message != "N0CALL: HELLO" {
+		t.Fatalf("Decodes = %+v, %v", decodes, err)
+	}
+	if decodes[0].Position < 0.1 || decodes[0].Position > 0.3 {
+		t.Errorf("Decode position = %.2fs, want about 0.2s into the segment", decodes[0].Position)
+	}
+
+	// Finished mu-law WAVs carry their sizes
+	path, _ := recorder.Path(recordings[0].Name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := binary.LittleEndian.Uint32(data[40:])
+	if binary.LittleEndian.Uint16(data[20:]) != 7 || samples != RecorderRate || len(data) != 44+RecorderRate {
+		t.Errorf("Expected a mu-law WAV of %d samples, got format %d with %d samples in %d bytes",
+			RecorderRate, binary.LittleEndian.Uint16(data[20:]), samples, len(data))
+	}
+
+	for _, name := range []string{"", "../escape.wav", "sub/dir.opus", ".hidden.wav", "index.jsonl"} {
+		if _, err := recorder.Path(name); err == nil {
+			t.Errorf("Expected %q to be refused", name)
+		}
+	}
+}
+
+func TestRecorderQuota(t *testing.T) {
+	recorder, _ := newTestRecorder(t, time.Second, 0)
+	start := time.Now()
+	for i := 1; i <= 30; i++ {
+		recorder.Write(tone(1000, RecorderRate, RecorderRate/10), RecorderRate, start.Add(time.Duration(i)*100*time.Millisecond))
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Every finished recording goes with no quota
+	if count, used, _, _, err := recorder.Usage(); err != nil || count != 0 || used != 0 {
+		t.Errorf("Usage = %d recordings, %d bytes, %v, want all removed", count, used, err)
+	}
+}
+
+func TestRecorderCodecs(t *testing.T) {
+	_, err := NewRecorder(RecorderConfig{Dir: t.TempDir(), Codec: CodecOpus, Bitrate: 24000, Quota: 1 << 20})
+	if OpusAvailable != (err == nil) {
+		t.Errorf("Opus recorder error %v with OpusAvailable %v", err, OpusAvailable)
+	}
+	if _, err := NewRecorder(RecorderConfig{Dir: t.TempDir(), Codec: "mp3"}); err == nil {
+		t.Error("Expected an unknown codec to be refused")
+	}
+}
+
+func TestRecordingReader(t *testing.T) {
+	recorder, _ := newTestRecorder(t, time.Minute, 1<<30)
+	samples := tone(1000, RecorderRate, 2*RecorderRate)
+	recorder.Write(samples, RecorderRate, time.Now())
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	recordings, _ := recorder.Recordings()
+	path, _ := recorder.Path(recordings[0].Name)
+	reader, err := OpenRecording(path)
+	if err != nil {
+		t.Fatalf("OpenRecording failed: %v", err)
+	}
+	defer reader.Close()
+
+	var read []int16
+	buf := make([]int16, 1000)
+	for {
+		n, err := reader.Read(buf)
+		read = append(read, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+	}
+	if len(read) != len(samples) {
+		t.Fatalf("Read %d samples, want %d", len(read), len(samples))
+	}
+	// Mu-law keeps within about 3% of each sample
+	for i := range samples {
+		if diff := int(read[i]) - int(samples[i]); diff > 1100 || diff < -1100 {
+			t.Fatalf("Sample %d read back as %d, want about %d", i, read[i], samples[i])
+		}
+	}
+
+	if _, err := OpenRecording(path + ".missing"); err == nil {
+		t.Error("Expected a missing recording to fail")
+	}
+}
+
+func TestOggRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := newOggWriter(&buf, 42)
+	packets := [][]byte{[]byte("OpusHead"), bytes.Repeat([]byte{1}, 255), bytes.Repeat([]byte{2}, 600), {}}
+	for i, packet := range packets {
+		if err := w.writePacket(packet, int64(i*960)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.flushPage(true); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newOggReader(bytes.NewReader(buf.Bytes()))
+	for i, want := range packets {
+		packet, granule, err := r.readPacket()
+		if err != nil || !bytes.Equal(packet, want) || granule != 3*960 {
+			t.Fatalf("Packet %d = %d bytes at %d, %v, want %d bytes", i, len(packet), granule, err, len(want))
+		}
+	}
+	if _, _, err := r.readPacket(); err != io.EOF {
+		t.Errorf("Expected EOF after the last packet, got %v", err)
+	}
+
+	// A damaged page fails its checksum
+	damaged := append([]byte(nil), buf.Bytes()...)
+	damaged[len(damaged)-1] ^= 0xff
+	if _, _, err := newOggReader(bytes.NewReader(damaged)).readPacket(); err == nil || err == io.EOF {
+		t.Errorf("Expected a checksum error, got %v", err)
+	}
+}
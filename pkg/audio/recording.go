@@ -0,0 +1,148 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// RecordingReader reads a band recording back as mono audio at
+// RecorderRate, for decoding it again
+type RecordingReader struct {
+	file    *os.File
+	pending []int16
+	next    func() ([]int16, error) // Audio of the next packet or block, io.EOF at the end
+	close   func()
+}
+
+// OpenRecording opens the recording at path, an Ogg Opus or mu-law WAV
+// file the band recorder wrote. One still being written reads to where it
+// has got to.
+func OpenRecording(path string) (*RecordingReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &RecordingReader{file: file, close: func() {}}
+	if strings.HasSuffix(path, opusExt) {
+		err = r.openOpus()
+	} else {
+		err = r.openMuLaw()
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Read fills samples with the next audio, returning how many it read and
+// io.EOF once the recording is done
+func (r *RecordingReader) Read(samples []int16) (int, error) {
+	for len(r.pending) == 0 {
+		block, err := r.next()
+		if err != nil {
+			return 0, err
+		}
+		r.pending = block
+	}
+	n := copy(samples, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// Close closes the recording
+func (r *RecordingReader) Close() error {
+	r.close()
+	return r.file.Close()
+}
+
+// openMuLaw reads the header of a mu-law WAV. Its data runs to the end of
+// the file, whatever the header says, as one cut short by a crash has no
+// sizes in it.
+func (r *RecordingReader) openMuLaw() error {
+	reader := bufio.NewReader(r.file)
+	header := make([]byte, 44)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return fmt.Errorf("not a WAV recording: %w", err)
+	}
+	if string(header[:4]) != "RIFF" || string(header[8:12]) != "WAVE" || string(header[36:40]) != "data" {
+		return fmt.Errorf("not a WAV recording")
+	}
+	format, channels := binary.LittleEndian.Uint16(header[20:]), binary.LittleEndian.Uint16(header[22:])
+	rate := binary.LittleEndian.Uint32(header[24:])
+	if format != 7 || channels != 1 || rate != RecorderRate {
+		return fmt.Errorf("not a %d Hz mono mu-law recording", RecorderRate)
+	}
+
+	block := make([]byte, RecorderRate/10)
+	r.next = func() ([]int16, error) {
+		n, err := reader.Read(block)
+		if n == 0 {
+			if err == nil {
+				err = io.EOF
+			}
+			return nil, err
+		}
+		samples := make([]int16, n)
+		for i, b := range block[:n] {
+			samples[i] = muLawToLinear(b)
+		}
+		return samples, nil
+	}
+	return nil
+}
+
+// openOpus reads the headers of an Ogg Opus recording
+func (r *RecordingReader) openOpus() error {
+	ogg := newOggReader(r.file)
+	head, _, err := ogg.readPacket()
+	if err != nil {
+		return fmt.Errorf("not an Opus recording: %w", err)
+	}
+	if len(head) < 19 || string(head[:8]) != "OpusHead" || head[9] != 1 {
+		return fmt.Errorf("not a mono Opus recording")
+	}
+	preSkip := int64(binary.LittleEndian.Uint16(head[10:]))
+	if _, _, err := ogg.readPacket(); err != nil { // OpusTags
+		return fmt.Errorf("Opus recording has no comment header: %w", err)
+	}
+
+	decoder, err := newOpusDecoder(RecorderRate)
+	if err != nil {
+		return err
+	}
+	r.close = decoder.close
+
+	// Granule positions count 48 kHz samples from before the pre-skip; the
+	// last page's ends the audio short of its final frame
+	skip := preSkip * RecorderRate / 48000
+	var decoded int64
+	r.next = func() ([]int16, error) {
+		for {
+			packet, granule, err := ogg.readPacket()
+			if err != nil {
+				return nil, err
+			}
+			samples, err := decoder.decode(packet, nil)
+			if err != nil {
+				return nil, err
+			}
+			start := decoded
+			decoded += int64(len(samples))
+			if end := (granule - preSkip) * RecorderRate / 48000; decoded-skip > end {
+				samples = samples[:max(0, len(samples)-int(decoded-skip-end))]
+			}
+			if start < skip {
+				samples = samples[min(int64(len(samples)), skip-start):]
+			}
+			if len(samples) > 0 {
+				return samples, nil
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package audio
+
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SnippetExt is the extension of saved snippets, gzipped WAV files
+const SnippetExt = ".wav.gz"
+
+// SnippetRate is the sample rate snippets are saved at
+const SnippetRate = DefaultStreamRate
+
+// Snippets keeps short recordings of the audio around decodes in a
+// directory, removing the oldest once they take more than the quota
+type Snippets struct {
+	dir   string
+	quota int64
+
+	mutex sync.Mutex
+	used  int64 // Bytes taken, counted when opened and kept up to date after
+}
+
+// NewSnippets opens or creates a snippet directory holding at most quota
+// bytes
+func NewSnippets(dir string, quota int64) (*Snippets, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snippet directory: %w", err)
+	}
+	s := &Snippets{dir: dir, quota: quota}
+	files, err := s.list()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		s.used += file.size
+	}
+	return s, nil
+}
+
+// ValidSnippetName reports whether name is a plain snippet file name, with
+// no path that could lead out of the directory
+func ValidSnippetName(name string) bool {
+	return strings.HasSuffix(name, SnippetExt) && !strings.HasPrefix(name, ".") &&
+		filepath.Base(name) == name && !strings.ContainsAny(name, `/\`)
+}
+
+// Path returns where the snippet called name is kept
+func (s *Snippets) Path(name string) (string, error) {
+	if !ValidSnippetName(name) {
+		return "", fmt.Errorf("invalid snippet name %q", name)
+	}
+	return filepath.Join(s.dir, name), nil
+}
+
+// Save writes samples at SnippetRate as the snippet called name and returns
+// the older snippets removed to stay within the quota. An error means the
+// snippet was not saved.
+func (s *Snippets) Save(name string, samples []int16) ([]string, error) {
+	path, err := s.Path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snippet: %w", err)
+	}
+	zw := gzip.NewWriter(file)
+	err = WriteWAV(zw, samples, SnippetRate)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		var info os.FileInfo
+		if info, err = file.Stat(); err == nil {
+			s.used += info.Size()
+		}
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to write snippet: %w", err)
+	}
+
+	if s.used <= s.quota {
+		return nil, nil
+	}
+	removed, err := s.trim(name)
+	if err != nil {
+		logger.Warnf("Snippets over their quota: %v", err)
+	}
+	return removed, nil
+}
+
+// Remove deletes the snippet called name
+func (s *Snippets) Remove(name string) error {
+	path, err := s.Path(name)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	s.used -= info.Size()
+	return nil
+}
+
+// Usage returns the number of snippets, the bytes they take and the quota
+func (s *Snippets) Usage() (int, int64, int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	files, err := s.list()
+	if err != nil {
+		return 0, 0, s.quota, err
+	}
+	s.used = 0
+	for _, file := range files {
+		s.used += file.size
+	}
+	return len(files), s.used, s.quota, nil
+}
+
+// snippetFile is a snippet found in the directory
+type snippetFile struct {
+	name string
+	size int64
+	mod  int64
+}
+
+// list returns the snippets in the directory, oldest first
+func (s *Snippets) list() ([]snippetFile, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snippet directory: %w", err)
+	}
+	var files []snippetFile
+	for _, entry := range entries {
+		if entry.IsDir() || !ValidSnippetName(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed since the directory was read
+		}
+		files = append(files, snippetFile{name: entry.Name(), size: info.Size(), mod: info.ModTime().UnixNano()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].mod != files[j].mod {
+			return files[i].mod < files[j].mod
+		}
+		return files[i].name < files[j].name
+	})
+	return files, nil
+}
+
+// trim removes the oldest snippets, other than keep, until the rest fit in
+// the quota; called with the mutex held
+func (s *Snippets) trim(keep string) ([]string, error) {
+	files, err := s.list()
+	if err != nil {
+		return nil, err
+	}
+	s.used = 0
+	for _, file := range files {
+		s.used += file.size
+	}
+
+	var removed []string
+	for _, file := range files {
+		if s.used <= s.quota {
+			break
+		}
+		if file.name == keep {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, file.name)); err != nil {
+			return removed, fmt.Errorf("failed to remove snippet: %w", err)
+		}
+		s.used -= file.size
+		removed = append(removed, file.name)
+	}
+	return removed, nil
+}
@@ -0,0 +1,91 @@
+package audio
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnippetsSave(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "snippets")
+	snippets, err := NewSnippets(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("NewSnippets failed: %v", err)
+	}
+
+	samples := tone(1000, SnippetRate, SnippetRate)
+	if removed, err := snippets.Save("first"+SnippetExt, samples); err != nil || len(removed) != 0 {
+		t.Fatalf("Save = %v, %v, want nothing removed", removed, err)
+	}
+
+	path, _ := snippets.Path("first" + SnippetExt)
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Snippet not written: %v", err)
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Snippet is not gzipped: %v", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil || len(data) != 44+2*len(samples) || string(data[8:12]) != "WAVE" {
+		t.Errorf("Expected a %d byte WAV, got %d bytes, %v", 44+2*len(samples), len(data), err)
+	}
+
+	if count, used, _, err := snippets.Usage(); err != nil || count != 1 || used == 0 {
+		t.Errorf("Usage = %d, %d, %v, want 1 snippet", count, used, err)
+	}
+
+	for _, name := range []string{"", "../escape" + SnippetExt, "sub/dir" + SnippetExt, ".hidden" + SnippetExt, "plain.wav"} {
+		if _, err := snippets.Save(name, samples); err == nil {
+			t.Errorf("Expected %q to be refused", name)
+		}
+	}
+}
+
+func TestSnippetsQuota(t *testing.T) {
+	dir := t.TempDir()
+	snippets, err := NewSnippets(dir, 0)
+	if err != nil {
+		t.Fatalf("NewSnippets failed: %v", err)
+	}
+	samples := tone(1000, SnippetRate, SnippetRate)
+	if _, err := snippets.Save("a"+SnippetExt, samples); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	_, size, _, _ := snippets.Usage()
+
+	// Room for two, the oldest go as more arrive
+	snippets.quota = 2 * size
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(dir, "a"+SnippetExt), old, old)
+	snippets.Save("b"+SnippetExt, samples)
+	removed, err := snippets.Save("c"+SnippetExt, samples)
+	if err != nil || len(removed) != 1 || removed[0] != "a"+SnippetExt {
+		t.Fatalf("Save = %v, %v, want the oldest removed", removed, err)
+	}
+	if count, used, _, _ := snippets.Usage(); count != 2 || used > snippets.quota {
+		t.Errorf("Expected 2 snippets within the quota, got %d taking %d", count, used)
+	}
+
+	// The snippet just saved stays even when it alone is over the quota
+	snippets.quota = 1
+	removed, _ = snippets.Save("d"+SnippetExt, samples)
+	if len(removed) != 2 {
+		t.Errorf("Expected the 2 older snippets removed, got %v", removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "d"+SnippetExt)); err != nil {
+		t.Errorf("Expected the new snippet kept: %v", err)
+	}
+
+	if err := snippets.Remove("d" + SnippetExt); err != nil {
+		t.Errorf("Remove failed: %v", err)
+	}
+	if count, used, _, _ := snippets.Usage(); count != 0 || used != 0 {
+		t.Errorf("Expected no snippets left, got %d taking %d", count, used)
+	}
+}
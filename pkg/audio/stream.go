@@ -0,0 +1,247 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+)
+
+// Encodings a remote listener can ask for
+const (
+	StreamEncodingMuLaw = "mulaw" // G.711 mu-law, 8 bits per sample
+	StreamEncodingPCM16 = "pcm16" // Signed 16 bit little endian
+)
+
+// DefaultStreamRate is telephone quality, which covers the 3 kHz SSB
+// passband at 64 kbit/s with mu-law
+const DefaultStreamRate = 8000
+
+// streamBiquad is one low-pass section of the anti-alias filter
+type streamBiquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+// newStreamLowPass creates a Butterworth low-pass section
+func newStreamLowPass(sampleRate, cutoff float64) streamBiquad {
+	w0 := 2 * math.Pi * cutoff / sampleRate
+	alpha := math.Sin(w0) / math.Sqrt2 // Q of 1/sqrt(2)
+	cosw0 := math.Cos(w0)
+	a0 := 1 + alpha
+	return streamBiquad{
+		b0: (1 - cosw0) / 2 / a0,
+		b1: (1 - cosw0) / a0,
+		b2: (1 - cosw0) / 2 / a0,
+		a1: -2 * cosw0 / a0,
+		a2: (1 - alpha) / a0,
+	}
+}
+
+func (f *streamBiquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// PCMStream fans RX audio out to remote listeners, resampled down to a low
+// rate and sent in 100 ms chunks. It does no work while nobody is listening.
+type PCMStream struct {
+	inputRate  int
+	outputRate int
+	chunkSize  int
+
+	mutex     sync.Mutex
+	listeners map[chan []int16]struct{}
+	lowpass   []streamBiquad
+	position  float64 // Next output time in input samples, relative to the block
+	previous  float64 // Last filtered input sample, for interpolation
+	pending   []int16
+	dropped   uint64
+}
+
+// NewPCMStream creates a stream resampling from inputRate to outputRate
+func NewPCMStream(inputRate, outputRate int) *PCMStream {
+	if outputRate <= 0 || outputRate > inputRate {
+		outputRate = inputRate
+	}
+	return &PCMStream{
+		inputRate:  inputRate,
+		outputRate: outputRate,
+		chunkSize:  outputRate / 10,
+		listeners:  make(map[chan []int16]struct{}),
+	}
+}
+
+// SampleRate returns the rate of the audio sent to listeners
+func (s *PCMStream) SampleRate() int {
+	return s.outputRate
+}
+
+// Subscribe returns a channel receiving audio chunks and a function to stop
+func (s *PCMStream) Subscribe() (<-chan []int16, func()) {
+	ch := make(chan []int16, 16)
+
+	s.mutex.Lock()
+	if len(s.listeners) == 0 {
+		s.reset()
+	}
+	s.listeners[ch] = struct{}{}
+	s.mutex.Unlock()
+
+	return ch, func() {
+		s.mutex.Lock()
+		delete(s.listeners, ch)
+		s.mutex.Unlock()
+	}
+}
+
+// Listeners returns the number of connected listeners
+func (s *PCMStream) Listeners() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.listeners)
+}
+
+// GetStatistics returns stream statistics
+func (s *PCMStream) GetStatistics() map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return map[string]interface{}{
+		"listeners":      len(s.listeners),
+		"sample_rate":    s.outputRate,
+		"dropped_chunks": s.dropped,
+	}
+}
+
+// reset clears the filter and resampler state; called with the mutex held
+func (s *PCMStream) reset() {
+	s.lowpass = nil
+	if s.outputRate < s.inputRate {
+		// Two sections, 4th order, a little below the output Nyquist
+		cutoff := 0.45 * float64(s.outputRate)
+		s.lowpass = []streamBiquad{
+			newStreamLowPass(float64(s.inputRate), cutoff),
+			newStreamLowPass(float64(s.inputRate), cutoff),
+		}
+	}
+	s.position = 0
+	s.previous = 0
+	s.pending = s.pending[:0]
+}
+
+// Write adds mono RX audio at the input rate
+func (s *PCMStream) Write(samples []int16) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.listeners) == 0 {
+		return
+	}
+
+	s.pending = s.resample(samples, s.pending)
+
+	for len(s.pending) >= s.chunkSize {
+		chunk := append([]int16(nil), s.pending[:s.chunkSize]...)
+		s.pending = append(s.pending[:0], s.pending[s.chunkSize:]...)
+
+		// Slow listeners lose chunks rather than stall the audio path
+		for ch := range s.listeners {
+			select {
+			case ch <- chunk:
+			default:
+				s.dropped++
+			}
+		}
+	}
+}
+
+// resample filters samples and appends them to out at the output rate,
+// carrying the filter and interpolation state over to the next block
+func (s *PCMStream) resample(samples []int16, out []int16) []int16 {
+	step := float64(s.inputRate) / float64(s.outputRate)
+	for i, sample := range samples {
+		x := float64(sample)
+		for j := range s.lowpass {
+			x = s.lowpass[j].process(x)
+		}
+
+		// Emit every output instant between the previous sample and this one
+		for s.position <= float64(i) {
+			frac := s.position - float64(i-1)
+			y := s.previous + frac*(x-s.previous)
+			out = append(out, int16(math.Max(-32768, math.Min(32767, math.Round(y)))))
+			s.position += step
+		}
+		s.previous = x
+	}
+	s.position -= float64(len(samples))
+	return out
+}
+
+// Resample converts mono audio from inRate down to outRate with the same
+// anti-alias filter and interpolation as the stream. Audio already at or
+// below outRate is returned as it is.
+func Resample(samples []int16, inRate, outRate int) []int16 {
+	if outRate <= 0 || outRate >= inRate {
+		return samples
+	}
+	s := NewPCMStream(inRate, outRate)
+	s.reset()
+	return s.resample(samples, make([]int16, 0, len(samples)*outRate/inRate+1))
+}
+
+// EncodeMuLaw compresses samples to G.711 mu-law, one byte per sample
+func EncodeMuLaw(samples []int16) []byte {
+	out := make([]byte, len(samples))
+	for i, sample := range samples {
+		out[i] = linearToMuLaw(sample)
+	}
+	return out
+}
+
+// EncodePCM16 packs samples as signed 16 bit little endian
+func EncodePCM16(samples []int16) []byte {
+	out := make([]byte, 2*len(samples))
+	for i, sample := range samples {
+		binary.LittleEndian.PutUint16(out[2*i:], uint16(sample))
+	}
+	return out
+}
+
+func linearToMuLaw(sample int16) byte {
+	const bias = 0x84
+	const clip = 32635
+
+	value := int(sample)
+	sign := 0
+	if value < 0 {
+		value = -value
+		sign = 0x80
+	}
+	if value > clip {
+		value = clip
+	}
+	value += bias
+
+	exponent := 7
+	for mask := 0x4000; value&mask == 0 && exponent > 0; mask >>= 1 {
+		exponent--
+	}
+	mantissa := (value >> (exponent + 3)) & 0x0f
+	return ^byte(sign | exponent<<4 | mantissa)
+}
+
+func muLawToLinear(encoded byte) int16 {
+	encoded = ^encoded
+	exponent := (encoded >> 4) & 0x07
+	mantissa := int(encoded & 0x0f)
+
+	value := ((mantissa << 3) + 0x84) << exponent
+	value -= 0x84
+	if encoded&0x80 != 0 {
+		value = -value
+	}
+	return int16(value)
+}
@@ -0,0 +1,126 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func tone(freq float64, sampleRate, n int) []int16 {
+	samples := make([]int16, n)
+	for i := range samples {
+		samples[i] = int16(10000 * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)))
+	}
+	return samples
+}
+
+// rms returns the RMS level of a block
+func rms(samples []int16) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+func TestPCMStreamResamples(t *testing.T) {
+	stream := NewPCMStream(48000, DefaultStreamRate)
+	chunks, unsubscribe := stream.Subscribe()
+	defer unsubscribe()
+
+	// One second in uneven blocks, like a sound card would deliver
+	input := tone(1000, 48000, 48000)
+	for start := 0; start < len(input); start += 1023 {
+		end := start + 1023
+		if end > len(input) {
+			end = len(input)
+		}
+		stream.Write(input[start:end])
+	}
+
+	var output []int16
+	for len(chunks) > 0 {
+		chunk := <-chunks
+		if len(chunk) != DefaultStreamRate/10 {
+			t.Fatalf("Expected %d sample chunks, got %d", DefaultStreamRate/10, len(chunk))
+		}
+		output = append(output, chunk...)
+	}
+	if len(output) != DefaultStreamRate {
+		t.Fatalf("Expected %d samples for one second, got %d", DefaultStreamRate, len(output))
+	}
+
+	// A passband tone keeps its level, 10000 peak is about 7071 RMS
+	if level := rms(output[800:]); math.Abs(level-7071) > 500 {
+		t.Errorf("Expected 1 kHz tone near 7071 RMS, got %.0f", level)
+	}
+
+	// A tone above the output Nyquist is filtered rather than aliased
+	stream.Write(tone(7000, 48000, 9600))
+	chunk := <-chunks
+	if level := rms(chunk); level > 700 {
+		t.Errorf("Expected 7 kHz tone to be suppressed, got %.0f RMS", level)
+	}
+}
+
+func TestResample(t *testing.T) {
+	output := Resample(tone(1000, 48000, 48000), 48000, DefaultStreamRate)
+	if len(output) != DefaultStreamRate {
+		t.Fatalf("Expected %d samples for one second, got %d", DefaultStreamRate, len(output))
+	}
+	if level := rms(output[800:]); math.Abs(level-7071) > 500 {
+		t.Errorf("Expected 1 kHz tone near 7071 RMS, got %.0f", level)
+	}
+
+	input := tone(1000, 8000, 100)
+	if output := Resample(input, 8000, DefaultStreamRate); len(output) != len(input) {
+		t.Errorf("Expected audio at the output rate unchanged, got %d samples", len(output))
+	}
+}
+
+func TestPCMStreamIdleWithoutListeners(t *testing.T) {
+	stream := NewPCMStream(48000, DefaultStreamRate)
+	stream.Write(tone(1000, 48000, 4800))
+	if len(stream.pending) != 0 {
+		t.Errorf("Expected no buffered audio without listeners, got %d samples", len(stream.pending))
+	}
+
+	_, unsubscribe := stream.Subscribe()
+	if stream.Listeners() != 1 {
+		t.Errorf("Expected 1 listener, got %d", stream.Listeners())
+	}
+	unsubscribe()
+	if stream.Listeners() != 0 {
+		t.Errorf("Expected 0 listeners, got %d", stream.Listeners())
+	}
+}
+
+func TestPCMStreamDropsForSlowListeners(t *testing.T) {
+	stream := NewPCMStream(8000, DefaultStreamRate)
+	_, unsubscribe := stream.Subscribe()
+	defer unsubscribe()
+
+	// Nobody reads, so anything past the channel buffer is dropped
+	stream.Write(tone(1000, 8000, 8000*3))
+	stats := stream.GetStatistics()
+	if stats["dropped_chunks"].(uint64) != 14 {
+		t.Errorf("Expected 14 dropped chunks, got %v", stats["dropped_chunks"])
+	}
+}
+
+func TestMuLaw(t *testing.T) {
+	for _, sample := range []int16{0, 1, -1, 100, -100, 1000, -1000, 12345, -12345, 32767, -32768} {
+		decoded := muLawToLinear(linearToMuLaw(sample))
+		// Mu-law keeps about 4 significant bits of mantissa
+		tolerance := math.Max(8, math.Abs(float64(sample))/16)
+		if math.Abs(float64(decoded)-float64(sample)) > tolerance {
+			t.Errorf("Mu-law round trip of %d gave %d", sample, decoded)
+		}
+	}
+
+	if encoded := EncodeMuLaw([]int16{0, 0}); len(encoded) != 2 || encoded[0] != 0xff {
+		t.Errorf("Expected silence to encode as 0xff, got %v", encoded)
+	}
+	if encoded := EncodePCM16([]int16{1, -2}); len(encoded) != 4 || encoded[0] != 1 || encoded[2] != 0xfe || encoded[3] != 0xff {
+		t.Errorf("Unexpected PCM16 encoding %v", encoded)
+	}
+}
@@ -0,0 +1,42 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+// WriteWAV writes samples as a 16-bit mono PCM WAV stream
+func WriteWAV(w io.Writer, samples []int16, sampleRate int) error {
+	bw := bufio.NewWriter(w)
+	dataBytes := uint32(2 * len(samples))
+	header := []any{
+		[4]byte{'R', 'I', 'F', 'F'}, 36 + dataBytes, [4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '}, uint32(16),
+		uint16(1), uint16(1), uint32(sampleRate), uint32(2 * sampleRate), uint16(2), uint16(16),
+		[4]byte{'d', 'a', 't', 'a'}, dataBytes,
+	}
+	for _, field := range header {
+		if err := binary.Write(bw, binary.LittleEndian, field); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(bw, binary.LittleEndian, samples); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// WriteWAVFile writes samples to a WAV file at path
+func WriteWAVFile(path string, samples []int16, sampleRate int) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteWAV(file, samples, sampleRate); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,44 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestWriteWAV(t *testing.T) {
+	samples := []int16{0, 1000, -1000, 32767, -32768}
+	var buf bytes.Buffer
+	if err := WriteWAV(&buf, samples, 12000); err != nil {
+		t.Fatalf("WriteWAV failed: %v", err)
+	}
+
+	data := buf.Bytes()
+	if len(data) != 44+2*len(samples) {
+		t.Fatalf("Expected %d bytes, got %d", 44+2*len(samples), len(data))
+	}
+	if string(data[0:4]) != "RIFF" || string(data[8:16]) != "WAVEfmt " || string(data[36:40]) != "data" {
+		t.Errorf("Bad chunk IDs in header %q", data[:44])
+	}
+	le := binary.LittleEndian
+	if got := le.Uint32(data[4:]); got != uint32(len(data)-8) {
+		t.Errorf("RIFF size = %d, want %d", got, len(data)-8)
+	}
+	if format, channels := le.Uint16(data[20:]), le.Uint16(data[22:]); format != 1 || channels != 1 {
+		t.Errorf("Format %d with %d channels, want PCM mono", format, channels)
+	}
+	if rate, byteRate := le.Uint32(data[24:]), le.Uint32(data[28:]); rate != 12000 || byteRate != 24000 {
+		t.Errorf("Rates = %d, %d, want 12000, 24000", rate, byteRate)
+	}
+	if bits := le.Uint16(data[34:]); bits != 16 {
+		t.Errorf("Bits per sample = %d, want 16", bits)
+	}
+	if got := le.Uint32(data[40:]); got != uint32(2*len(samples)) {
+		t.Errorf("Data size = %d, want %d", got, 2*len(samples))
+	}
+	for i, s := range samples {
+		if got := int16(le.Uint16(data[44+2*i:])); got != s {
+			t.Errorf("Sample %d = %d, want %d", i, got, s)
+		}
+	}
+}
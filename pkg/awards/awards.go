@@ -0,0 +1,135 @@
+// Package awards tallies progress toward operating awards from the QSO
+// log: grid squares for VUCC, DXCC entities and the US states for WAS
+package awards
+
+import (
+	"sort"
+	"strings"
+)
+
+// Award targets
+const (
+	VUCCTarget = 100 // Grid squares, as on 6m and 2m
+	DXCCTarget = 100 // Entities
+	WASTarget  = 50  // States
+)
+
+// Contact is a logged QSO as the awards count it
+type Contact struct {
+	Callsign  string
+	Band      string
+	Grid      string // The station's grid square, when known
+	Entity    string // Primary prefix of its DXCC entity, when known
+	State     string // Its US state, from the callbook
+	Confirmed bool   // A QSL confirmed it
+}
+
+// Item is one grid, entity or state toward an award
+type Item struct {
+	ID        string `json:"id"` // Grid, entity prefix or state abbreviation
+	Name      string `json:"name,omitempty"`
+	Contacts  int    `json:"contacts"`
+	Confirmed bool   `json:"confirmed"`
+	Callsign  string `json:"callsign"` // A station worked there, a confirmed one if any
+}
+
+// Award is the progress toward one award
+type Award struct {
+	Name      string `json:"name"`
+	Target    int    `json:"target"`
+	Worked    int    `json:"worked"`
+	Confirmed int    `json:"confirmed"`
+	Items     []Item `json:"items"`
+}
+
+// Progress is the progress toward every award on a band, or on all of them
+type Progress struct {
+	Band     string `json:"band,omitempty"`
+	Contacts int    `json:"contacts"`
+	Grids    Award  `json:"grids"`
+	DXCC     Award  `json:"dxcc"`
+	WAS      Award  `json:"was"`
+}
+
+// States maps the abbreviations of the states that count for WAS to their
+// names
+var States = map[string]string{
+	"AL": "Alabama", "AK": "Alaska", "AZ": "Arizona", "AR": "Arkansas",
+	"CA": "California", "CO": "Colorado", "CT": "Connecticut", "DE": "Delaware",
+	"FL": "Florida", "GA": "Georgia", "HI": "Hawaii", "ID": "Idaho",
+	"IL": "Illinois", "IN": "Indiana", "IA": "Iowa", "KS": "Kansas",
+	"KY": "Kentucky", "LA": "Louisiana", "ME": "Maine", "MD": "Maryland",
+	"MA": "Massachusetts", "MI": "Michigan", "MN": "Minnesota", "MS": "Mississippi",
+	"MO": "Missouri", "MT": "Montana", "NE": "Nebraska", "NV": "Nevada",
+	"NH": "New Hampshire", "NJ": "New Jersey", "NM": "New Mexico", "NY": "New York",
+	"NC": "North Carolina", "ND": "North Dakota", "OH": "Ohio", "OK": "Oklahoma",
+	"OR": "Oregon", "PA": "Pennsylvania", "RI": "Rhode Island", "SC": "South Carolina",
+	"SD": "South Dakota", "TN": "Tennessee", "TX": "Texas", "UT": "Utah",
+	"VT": "Vermont", "VA": "Virginia", "WA": "Washington", "WV": "West Virginia",
+	"WI": "Wisconsin", "WY": "Wyoming",
+}
+
+// Compute tallies the contacts on a band, or on every band when band is
+// empty. Grids count by their first four characters. DXCC items are named
+// by the caller, which knows the entities; states are named here.
+func Compute(contacts []Contact, band string) Progress {
+	progress := Progress{
+		Band:  strings.ToLower(band),
+		Grids: Award{Name: "VUCC", Target: VUCCTarget},
+		DXCC:  Award{Name: "DXCC", Target: DXCCTarget},
+		WAS:   Award{Name: "WAS", Target: WASTarget},
+	}
+	grids := make(map[string]*Item)
+	entities := make(map[string]*Item)
+	states := make(map[string]*Item)
+
+	for _, c := range contacts {
+		if band != "" && !strings.EqualFold(c.Band, band) {
+			continue
+		}
+		progress.Contacts++
+
+		if grid := strings.ToUpper(c.Grid); len(grid) >= 4 {
+			tally(grids, grid[:4], c)
+		}
+		if c.Entity != "" {
+			tally(entities, strings.ToUpper(c.Entity), c)
+		}
+		if state := strings.ToUpper(c.State); States[state] != "" {
+			tally(states, state, c).Name = States[state]
+		}
+	}
+
+	progress.Grids.add(grids)
+	progress.DXCC.add(entities)
+	progress.WAS.add(states)
+	return progress
+}
+
+// tally counts a contact toward an item, returning it
+func tally(items map[string]*Item, id string, c Contact) *Item {
+	item := items[id]
+	if item == nil {
+		item = &Item{ID: id, Callsign: strings.ToUpper(c.Callsign)}
+		items[id] = item
+	}
+	item.Contacts++
+	if c.Confirmed && !item.Confirmed {
+		item.Confirmed = true
+		item.Callsign = strings.ToUpper(c.Callsign)
+	}
+	return item
+}
+
+// add sets an award's items, sorted, and its totals
+func (a *Award) add(items map[string]*Item) {
+	a.Items = make([]Item, 0, len(items))
+	for _, item := range items {
+		a.Items = append(a.Items, *item)
+		if item.Confirmed {
+			a.Confirmed++
+		}
+	}
+	a.Worked = len(a.Items)
+	sort.Slice(a.Items, func(i, j int) bool { return a.Items[i].ID < a.Items[j].ID })
+}
@@ -0,0 +1,50 @@
+package awards
+
+import "testing"
+
+func TestCompute(t *testing.T) {
+	contacts := []Contact{
+		{Callsign: "k1abc", Band: "20m", Grid: "FN42ab", Entity: "K", State: "MA"},
+		{Callsign: "W1XYZ", Band: "20m", Grid: "fn42", Entity: "K", State: "ma", Confirmed: true},
+		{Callsign: "DL1ABC", Band: "20m", Grid: "JO62", Entity: "DL", Confirmed: true},
+		{Callsign: "VE3ABC", Band: "40m", Grid: "FN03", Entity: "VE", State: "ON"},
+		{Callsign: "N0CALL", Band: "40m", Grid: "DM", State: "DC"},
+	}
+
+	all := Compute(contacts, "")
+	if all.Contacts != 5 {
+		t.Errorf("Expected 5 contacts, got %d", all.Contacts)
+	}
+	// A grid needs four characters, and DC and Ontario are not WAS states
+	if all.Grids.Worked != 3 || all.Grids.Confirmed != 2 {
+		t.Errorf("Expected 3 grids worked and 2 confirmed, got %+v", all.Grids)
+	}
+	if all.DXCC.Worked != 3 || all.DXCC.Confirmed != 2 || all.DXCC.Target != DXCCTarget {
+		t.Errorf("Expected 3 entities worked and 2 confirmed, got %+v", all.DXCC)
+	}
+	if all.WAS.Worked != 1 || all.WAS.Confirmed != 1 {
+		t.Errorf("Expected 1 state worked and confirmed, got %+v", all.WAS)
+	}
+
+	if all.Grids.Items[0].ID != "FN03" {
+		t.Errorf("Expected grids sorted, got %+v", all.Grids.Items)
+	}
+	fn42 := all.Grids.Items[1]
+	if fn42.ID != "FN42" || fn42.Contacts != 2 || !fn42.Confirmed || fn42.Callsign != "W1XYZ" {
+		t.Errorf("Expected FN42 confirmed by W1XYZ, got %+v", fn42)
+	}
+	if ma := all.WAS.Items[0]; ma.ID != "MA" || ma.Name != "Massachusetts" {
+		t.Errorf("Expected Massachusetts, got %+v", ma)
+	}
+
+	forty := Compute(contacts, "40M")
+	if forty.Band != "40m" || forty.Contacts != 2 || forty.Grids.Worked != 1 || forty.DXCC.Worked != 1 || forty.WAS.Worked != 0 {
+		t.Errorf("Expected only the 40m contacts, got %+v", forty)
+	}
+}
+
+func TestStates(t *testing.T) {
+	if len(States) != WASTarget {
+		t.Errorf("Expected %d states, got %d", WASTarget, len(States))
+	}
+}
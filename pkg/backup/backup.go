@@ -0,0 +1,205 @@
+// Package backup packs a node's configuration, message databases and logs
+// into a gzipped tarball, and unpacks one again to restore the node
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// Names in the tarball. Databases and logs go in their own directories.
+const (
+	ManifestName = "manifest.json"
+	ConfigName   = "config.yaml"
+	DatabaseDir  = "databases"
+	LogDir       = "logs"
+)
+
+// Manifest describes a backup and comes first in the tarball
+type Manifest struct {
+	Version  string    `json:"version"`
+	Created  time.Time `json:"created"`
+	Callsign string    `json:"callsign"`
+	Files    []string  `json:"files"`
+}
+
+// File is one file to back up, read from Path and stored as Name
+type File struct {
+	Name string
+	Path string
+}
+
+// Write writes a backup of files, after a manifest listing them, to w
+func Write(w io.Writer, manifest Manifest, files []File) error {
+	manifest.Files = make([]string, len(files))
+	for i, file := range files {
+		if !validName(file.Name) {
+			return fmt.Errorf("invalid backup file name %q", file.Name)
+		}
+		manifest.Files[i] = file.Name
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	header := &tar.Header{
+		Name:     ManifestName,
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  manifest.Created,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := writeFile(tw, file); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", file.Name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// writeFile adds one file to the tarball
+func writeFile(tw *tar.Writer, file File) error {
+	f, err := os.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", file.Path)
+	}
+
+	header := &tar.Header{
+		Name:     file.Name,
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	// A log may grow while it is copied, so copy only what the header promised
+	_, err = io.CopyN(tw, f, info.Size())
+	return err
+}
+
+// Extract unpacks a backup into dir and returns its manifest. It accepts
+// only regular files under the names Write uses, no more than maxSize bytes
+// of them in all, and needs the manifest, the configuration and every file
+// the manifest lists.
+func Extract(r io.Reader, dir string, maxSize int64) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a gzipped backup: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	var manifest *Manifest
+	seen := make(map[string]bool)
+	remaining := maxSize
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("backup entry %q is not a regular file", header.Name)
+		}
+		if header.Name != ManifestName && !validName(header.Name) {
+			return nil, fmt.Errorf("unexpected backup entry %q", header.Name)
+		}
+		if seen[header.Name] {
+			return nil, fmt.Errorf("backup entry %q appears twice", header.Name)
+		}
+		seen[header.Name] = true
+		if header.Size > remaining {
+			return nil, fmt.Errorf("backup is larger than %d bytes", maxSize)
+		}
+		remaining -= header.Size
+
+		if header.Name == ManifestName {
+			manifest = &Manifest{}
+			if err := json.NewDecoder(io.LimitReader(tr, header.Size)).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("invalid backup manifest: %w", err)
+			}
+			continue
+		}
+		if err := extractFile(tr, filepath.Join(dir, filepath.FromSlash(header.Name)), header.Size); err != nil {
+			return nil, fmt.Errorf("failed to extract %s: %w", header.Name, err)
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("backup has no %s", ManifestName)
+	}
+	if !seen[ConfigName] {
+		return nil, fmt.Errorf("backup has no %s", ConfigName)
+	}
+	for _, name := range manifest.Files {
+		if !seen[name] {
+			return nil, fmt.Errorf("backup is missing %s", name)
+		}
+	}
+	return manifest, nil
+}
+
+// extractFile writes size bytes of r to dest
+func extractFile(r io.Reader, dest string, size int64) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(f, r, size); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// validName reports whether name is the configuration or a plain file
+// name in the database or log directory
+func validName(name string) bool {
+	if name == ConfigName {
+		return true
+	}
+	dir, base := path.Split(name)
+	if dir != DatabaseDir+"/" && dir != LogDir+"/" {
+		return false
+	}
+	return base != "" && base != "." && base != ".." && path.Clean(name) == name
+}
@@ -0,0 +1,95 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// tarball gzips a tarball of the given entries, in order
+func tarball(t *testing.T, entries map[string]string, order ...string) *bytes.Buffer {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range order {
+		data := entries[name]
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(data))}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(data))
+	}
+	tw.Close()
+	gz.Close()
+	return &buf
+}
+
+func TestWriteExtract(t *testing.T) {
+	src := t.TempDir()
+	files := []File{
+		{Name: ConfigName, Path: filepath.Join(src, "config.yaml")},
+		{Name: "databases/default.db", Path: filepath.Join(src, "js8d.db")},
+		{Name: "logs/js8d.log", Path: filepath.Join(src, "js8d.log")},
+	}
+	for i, file := range files {
+		os.WriteFile(file.Path, []byte(file.Name+string(rune('a'+i))), 0644)
+	}
+
+	var buf bytes.Buffer
+	created := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := Write(&buf, Manifest{Version: "1.0", Created: created, Callsign: "N0CALL"}, files); err != nil {
+		t.Fatalf("Failed to write backup: %v", err)
+	}
+
+	dest := t.TempDir()
+	manifest, err := Extract(&buf, dest, 1<<20)
+	if err != nil {
+		t.Fatalf("Failed to extract backup: %v", err)
+	}
+	if manifest.Callsign != "N0CALL" || !manifest.Created.Equal(created) || len(manifest.Files) != 3 {
+		t.Errorf("Unexpected manifest %+v", manifest)
+	}
+	for i, file := range files {
+		data, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(file.Name)))
+		if err != nil || string(data) != file.Name+string(rune('a'+i)) {
+			t.Errorf("Expected %s restored, got %q, %v", file.Name, data, err)
+		}
+	}
+
+	if err := Write(&bytes.Buffer{}, Manifest{}, []File{{Name: "../etc/passwd", Path: files[0].Path}}); err == nil {
+		t.Error("Expected an error writing a file outside the backup directories")
+	}
+}
+
+func TestExtractRejects(t *testing.T) {
+	manifest := `{"files":["config.yaml","logs/js8d.log"]}`
+	tests := []struct {
+		name    string
+		entries map[string]string
+		order   []string
+		maxSize int64
+	}{
+		{"traversal", map[string]string{ManifestName: `{}`, ConfigName: "a", "logs/../../evil": "x"}, []string{ManifestName, ConfigName, "logs/../../evil"}, 1 << 20},
+		{"absolute", map[string]string{ManifestName: `{}`, ConfigName: "a", "/etc/passwd": "x"}, []string{ManifestName, ConfigName, "/etc/passwd"}, 1 << 20},
+		{"unknown directory", map[string]string{ManifestName: `{}`, ConfigName: "a", "bin/js8d": "x"}, []string{ManifestName, ConfigName, "bin/js8d"}, 1 << 20},
+		{"no manifest", map[string]string{ConfigName: "a"}, []string{ConfigName}, 1 << 20},
+		{"no config", map[string]string{ManifestName: `{}`}, []string{ManifestName}, 1 << 20},
+		{"missing file", map[string]string{ManifestName: manifest, ConfigName: "a"}, []string{ManifestName, ConfigName}, 1 << 20},
+		{"duplicate", map[string]string{ManifestName: `{}`, ConfigName: "a"}, []string{ManifestName, ConfigName, ConfigName}, 1 << 20},
+		{"too large", map[string]string{ManifestName: `{}`, ConfigName: "0123456789"}, []string{ManifestName, ConfigName}, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Extract(tarball(t, tt.entries, tt.order...), t.TempDir(), tt.maxSize); err == nil {
+				t.Error("Expected the backup to be rejected")
+			}
+		})
+	}
+
+	if _, err := Extract(bytes.NewBufferString("not a tarball"), t.TempDir(), 1<<20); err == nil {
+		t.Error("Expected an error for data that is not gzipped")
+	}
+}
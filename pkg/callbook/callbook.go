@@ -0,0 +1,132 @@
+package callbook
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Agent identifies js8d to the callbook services
+const Agent = "js8d"
+
+// ErrNotFound is returned for callsigns the callbook does not know
+var ErrNotFound = errors.New("callsign not found")
+
+// errSessionExpired makes Lookup log in again and retry
+var errSessionExpired = errors.New("callbook session expired")
+
+// Record is what a callbook knows about a callsign
+type Record struct {
+	Callsign  string
+	Name      string
+	Country   string
+	State     string // US state, two letters
+	Grid      string
+	Latitude  float64
+	Longitude float64
+}
+
+// Client looks callsigns up
+type Client interface {
+	Lookup(ctx context.Context, callsign string) (*Record, error)
+}
+
+// New returns a client for a callbook service, "qrz" or "hamqth"
+func New(service, username, password string) (Client, error) {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	switch strings.ToLower(service) {
+	case "qrz":
+		return &sessionClient{service: &qrz{
+			baseURL:  qrzURL,
+			username: username,
+			password: password,
+			http:     httpClient,
+		}}, nil
+	case "hamqth":
+		return &sessionClient{service: &hamQTH{
+			baseURL:  hamQTHURL,
+			username: username,
+			password: password,
+			http:     httpClient,
+		}}, nil
+	}
+	return nil, fmt.Errorf("unknown callbook service %q", service)
+}
+
+// service is a callbook that hands out a session key at login and wants it
+// with every query
+type service interface {
+	login(ctx context.Context) (string, error)
+	query(ctx context.Context, key, callsign string) (*Record, error)
+}
+
+// sessionClient keeps a service's session key, logging in again when it
+// expires
+type sessionClient struct {
+	service service
+
+	mutex sync.Mutex
+	key   string
+}
+
+// Lookup returns the callbook record of a callsign, or ErrNotFound
+func (c *sessionClient) Lookup(ctx context.Context, callsign string) (*Record, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if c.key == "" {
+			key, err := c.service.login(ctx)
+			if err != nil {
+				return nil, err
+			}
+			c.key = key
+		}
+
+		record, err := c.service.query(ctx, c.key, strings.ToUpper(callsign))
+		if err != errSessionExpired {
+			return record, err
+		}
+		c.key = ""
+	}
+	return nil, errSessionExpired
+}
+
+// getXML fetches a URL and decodes the XML response into v
+func getXML(ctx context.Context, client *http.Client, base string, params url.Values, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("callbook returned %s", resp.Status)
+	}
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode callbook response: %w", err)
+	}
+	return nil
+}
+
+// parseCoordinate reads a latitude or longitude, 0 if there is none
+func parseCoordinate(s string) float64 {
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
@@ -0,0 +1,104 @@
+package callbook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQRZLookup(t *testing.T) {
+	logins := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("username") != "":
+			logins++
+			if q.Get("password") != "hunter2" {
+				fmt.Fprint(w, `<QRZDatabase><Session><Error>Username/password incorrect</Error></Session></QRZDatabase>`)
+				return
+			}
+			fmt.Fprintf(w, `<QRZDatabase xmlns="http://xmldata.qrz.com"><Session><Key>key%d</Key></Session></QRZDatabase>`, logins)
+		case q.Get("s") == "key1":
+			// The first session times out after one lookup
+			fmt.Fprint(w, `<QRZDatabase><Session><Error>Session Timeout</Error></Session></QRZDatabase>`)
+		case q.Get("callsign") == "N0ABC":
+			fmt.Fprint(w, `<QRZDatabase xmlns="http://xmldata.qrz.com">
+<Callsign><call>N0ABC</call><fname>Robert</fname><name>Smith</name><country>United States</country><state>CO</state>
+<lat>39.7392</lat><lon>-104.9903</lon><grid>DM79mr</grid></Callsign>
+<Session><Key>key2</Key></Session></QRZDatabase>`)
+		default:
+			fmt.Fprintf(w, `<QRZDatabase><Session><Key>key2</Key><Error>Not found: %s</Error></Session></QRZDatabase>`, q.Get("callsign"))
+		}
+	}))
+	defer server.Close()
+
+	client := &sessionClient{service: &qrz{baseURL: server.URL, username: "K3DEP", password: "hunter2", http: server.Client()}}
+
+	record, err := client.Lookup(context.Background(), "n0abc")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if record.Name != "Robert Smith" || record.Country != "United States" || record.State != "CO" || record.Grid != "DM79MR" {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+	if record.Latitude != 39.7392 || record.Longitude != -104.9903 {
+		t.Errorf("Unexpected location: %v, %v", record.Latitude, record.Longitude)
+	}
+	if logins != 2 {
+		t.Errorf("Expected a second login after the session timed out, got %d logins", logins)
+	}
+
+	if _, err := client.Lookup(context.Background(), "K9XYZ"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	bad := &sessionClient{service: &qrz{baseURL: server.URL, username: "K3DEP", password: "wrong", http: server.Client()}}
+	if _, err := bad.Lookup(context.Background(), "N0ABC"); err == nil {
+		t.Error("Expected a login error for a wrong password")
+	}
+}
+
+func TestHamQTHLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("u") != "":
+			fmt.Fprint(w, `<HamQTH xmlns="https://www.hamqth.com"><session><session_id>abc</session_id></session></HamQTH>`)
+		case q.Get("id") != "abc":
+			fmt.Fprint(w, `<HamQTH><session><error>Session does not exist or expired</error></session></HamQTH>`)
+		case q.Get("callsign") == "OK1RR":
+			fmt.Fprint(w, `<HamQTH xmlns="https://www.hamqth.com"><search><callsign>ok1rr</callsign><nick>Petr</nick>
+<country>Czech Republic</country><grid>jo70va</grid><latitude>50.07</latitude><longitude>14.42</longitude></search></HamQTH>`)
+		default:
+			fmt.Fprint(w, `<HamQTH><session><error>Callsign not found</error></session></HamQTH>`)
+		}
+	}))
+	defer server.Close()
+
+	client := &sessionClient{service: &hamQTH{baseURL: server.URL, username: "K3DEP", password: "hunter2", http: server.Client()}}
+
+	record, err := client.Lookup(context.Background(), "OK1RR")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if record.Callsign != "OK1RR" || record.Name != "Petr" || record.Grid != "JO70VA" || record.Latitude != 50.07 {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+
+	if _, err := client.Lookup(context.Background(), "K9XYZ"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, service := range []string{"qrz", "hamqth", "HamQTH"} {
+		if _, err := New(service, "K3DEP", "hunter2"); err != nil {
+			t.Errorf("%s: %v", service, err)
+		}
+	}
+	if _, err := New("hamcall", "K3DEP", "hunter2"); err == nil {
+		t.Error("Expected an error for an unknown service")
+	}
+}
@@ -0,0 +1,83 @@
+package callbook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// hamQTHURL is HamQTH's free XML service
+const hamQTHURL = "https://www.hamqth.com/xml.php"
+
+// hamQTH looks callsigns up in HamQTH
+type hamQTH struct {
+	baseURL  string
+	username string
+	password string
+	http     *http.Client
+}
+
+// hamQTHResponse is a HamQTH XML reply
+type hamQTHResponse struct {
+	Session struct {
+		ID    string `xml:"session_id"`
+		Error string `xml:"error"`
+	} `xml:"session"`
+	Search *struct {
+		Callsign  string `xml:"callsign"`
+		Nick      string `xml:"nick"`
+		Name      string `xml:"adr_name"`
+		Country   string `xml:"country"`
+		State     string `xml:"us_state"`
+		Grid      string `xml:"grid"`
+		Latitude  string `xml:"latitude"`
+		Longitude string `xml:"longitude"`
+	} `xml:"search"`
+}
+
+func (h *hamQTH) login(ctx context.Context) (string, error) {
+	var resp hamQTHResponse
+	params := url.Values{"u": {h.username}, "p": {h.password}}
+	if err := getXML(ctx, h.http, h.baseURL, params, &resp); err != nil {
+		return "", err
+	}
+	if resp.Session.ID == "" {
+		return "", fmt.Errorf("HamQTH login failed: %s", resp.Session.Error)
+	}
+	return resp.Session.ID, nil
+}
+
+func (h *hamQTH) query(ctx context.Context, key, callsign string) (*Record, error) {
+	var resp hamQTHResponse
+	params := url.Values{"id": {key}, "callsign": {callsign}, "prg": {Agent}}
+	if err := getXML(ctx, h.http, h.baseURL, params, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Search == nil {
+		switch {
+		case strings.Contains(resp.Session.Error, "not found"):
+			return nil, ErrNotFound
+		case strings.Contains(resp.Session.Error, "Session"):
+			return nil, errSessionExpired
+		}
+		return nil, fmt.Errorf("HamQTH lookup failed: %s", resp.Session.Error)
+	}
+
+	s := resp.Search
+	name := s.Name
+	if name == "" {
+		name = s.Nick
+	}
+	return &Record{
+		Callsign:  strings.ToUpper(s.Callsign),
+		Name:      name,
+		Country:   s.Country,
+		State:     strings.ToUpper(s.State),
+		Grid:      strings.ToUpper(s.Grid),
+		Latitude:  parseCoordinate(s.Latitude),
+		Longitude: parseCoordinate(s.Longitude),
+	}, nil
+}
@@ -0,0 +1,79 @@
+package callbook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// qrzURL is QRZ.com's XML service, which needs a subscription
+const qrzURL = "https://xmldata.qrz.com/xml/current/"
+
+// qrz looks callsigns up in QRZ.com's XML service
+type qrz struct {
+	baseURL  string
+	username string
+	password string
+	http     *http.Client
+}
+
+// qrzResponse is a QRZ.com XML reply
+type qrzResponse struct {
+	Callsign *struct {
+		Call      string `xml:"call"`
+		FirstName string `xml:"fname"`
+		Name      string `xml:"name"`
+		Country   string `xml:"country"`
+		State     string `xml:"state"`
+		Grid      string `xml:"grid"`
+		Latitude  string `xml:"lat"`
+		Longitude string `xml:"lon"`
+	} `xml:"Callsign"`
+	Session struct {
+		Key   string `xml:"Key"`
+		Error string `xml:"Error"`
+	} `xml:"Session"`
+}
+
+func (q *qrz) login(ctx context.Context) (string, error) {
+	var resp qrzResponse
+	params := url.Values{"username": {q.username}, "password": {q.password}, "agent": {Agent}}
+	if err := getXML(ctx, q.http, q.baseURL, params, &resp); err != nil {
+		return "", err
+	}
+	if resp.Session.Key == "" {
+		return "", fmt.Errorf("QRZ login failed: %s", resp.Session.Error)
+	}
+	return resp.Session.Key, nil
+}
+
+func (q *qrz) query(ctx context.Context, key, callsign string) (*Record, error) {
+	var resp qrzResponse
+	params := url.Values{"s": {key}, "callsign": {callsign}}
+	if err := getXML(ctx, q.http, q.baseURL, params, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Callsign == nil {
+		switch {
+		case strings.HasPrefix(resp.Session.Error, "Not found"):
+			return nil, ErrNotFound
+		case resp.Session.Key == "" || strings.Contains(resp.Session.Error, "Session"):
+			return nil, errSessionExpired
+		}
+		return nil, fmt.Errorf("QRZ lookup failed: %s", resp.Session.Error)
+	}
+
+	c := resp.Callsign
+	return &Record{
+		Callsign:  strings.ToUpper(c.Call),
+		Name:      strings.TrimSpace(c.FirstName + " " + c.Name),
+		Country:   c.Country,
+		State:     strings.ToUpper(c.State),
+		Grid:      strings.ToUpper(c.Grid),
+		Latitude:  parseCoordinate(c.Latitude),
+		Longitude: parseCoordinate(c.Longitude),
+	}, nil
+}
@@ -3,17 +3,29 @@ package client
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/dougsko/js8d/pkg/protocol"
 )
 
+// ErrNotConnected is returned, wrapped, when the daemon socket can't be reached
+var ErrNotConnected = errors.New("failed to connect to socket")
+
+// errLegacyServer means the daemon answered the v2 handshake with a v1 line
+var errLegacyServer = errors.New("server only speaks protocol version 1")
+
 // SocketClient represents a client connection to the core engine
 type SocketClient struct {
 	socketPath string
 	timeout    time.Duration
+
+	mutex   sync.Mutex
+	version int    // Protocol version, 0 until negotiated
+	token   string // Access token presented on each connection
 }
 
 // NewSocketClient creates a new socket client
@@ -24,26 +36,157 @@ func NewSocketClient(socketPath string) *SocketClient {
 	}
 }
 
-// SendCommand sends a command and returns the response
+// WithTimeout returns a copy of the client that waits up to timeout for
+// each response, for commands that take longer than usual
+func (c *SocketClient) WithTimeout(timeout time.Duration) *SocketClient {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return &SocketClient{
+		socketPath: c.socketPath,
+		timeout:    timeout,
+		version:    c.version,
+		token:      c.token,
+	}
+}
+
+// SetProtocolVersion forces protocol version 1 or 2; 0 negotiates, falling
+// back to version 1 for daemons that predate version 2
+func (c *SocketClient) SetProtocolVersion(version int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.version = version
+}
+
+// SetToken sets the access token presented to a daemon that requires one
+func (c *SocketClient) SetToken(token string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.token = token
+}
+
+func (c *SocketClient) getToken() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.token
+}
+
+// ProtocolVersion returns the protocol version in use, 0 before the first
+// command when negotiating
+func (c *SocketClient) ProtocolVersion() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.version
+}
+
+// SendCommand sends a text command, as typed for js8ctl, and returns the response
 func (c *SocketClient) SendCommand(cmd string) (*protocol.Response, error) {
+	if c.ProtocolVersion() == protocol.Version1 {
+		return c.sendLine(cmd)
+	}
+
+	parsed, err := protocol.ParseCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(parsed)
+}
+
+// Do sends a structured command and returns the response
+func (c *SocketClient) Do(cmd *protocol.Command) (*protocol.Response, error) {
+	if c.ProtocolVersion() != protocol.Version1 {
+		resp, err := c.sendFrame(cmd)
+		if err != errLegacyServer {
+			if err == nil {
+				c.SetProtocolVersion(protocol.Version2)
+			}
+			return resp, err
+		}
+		c.SetProtocolVersion(protocol.Version1)
+	}
+
+	line, err := cmd.Line()
+	if err != nil {
+		return nil, err
+	}
+	return c.sendLine(line)
+}
+
+// sendFrame sends a command over protocol version 2
+func (c *SocketClient) sendFrame(cmd *protocol.Command) (*protocol.Response, error) {
+	conn, err := net.DialTimeout("unix", c.socketPath, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotConnected, err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(c.timeout))
+
+	if err := protocol.WriteHello(conn, "js8d-client", c.getToken()); err != nil {
+		return nil, fmt.Errorf("send error: %w", err)
+	}
+
+	// A version 1 daemon answers the handshake with a JSON error line
+	reader := bufio.NewReader(conn)
+	first, err := reader.Peek(1)
+	if err != nil {
+		return nil, fmt.Errorf("no response received: %w", err)
+	}
+	if !protocol.IsFramed(first[0]) {
+		return nil, errLegacyServer
+	}
+
+	var hello protocol.Response
+	if err := protocol.ReadFrame(reader, &hello); err != nil {
+		return nil, fmt.Errorf("handshake error: %w", err)
+	}
+	if !hello.Success {
+		return nil, fmt.Errorf("handshake rejected: %s", hello.Error)
+	}
+
+	if err := protocol.WriteFrame(conn, cmd); err != nil {
+		return nil, fmt.Errorf("send error: %w", err)
+	}
+
+	var response protocol.Response
+	if err := protocol.ReadFrame(reader, &response); err != nil {
+		return nil, fmt.Errorf("read error: %w", err)
+	}
+	return &response, nil
+}
+
+// sendLine sends a command over protocol version 1
+func (c *SocketClient) sendLine(cmd string) (*protocol.Response, error) {
 	// Connect to Unix socket
 	conn, err := net.DialTimeout("unix", c.socketPath, c.timeout)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to socket: %w", err)
+		return nil, fmt.Errorf("%w: %v", ErrNotConnected, err)
 	}
 	defer conn.Close()
 
 	// Set read/write timeout
 	conn.SetDeadline(time.Now().Add(c.timeout))
+	scanner := bufio.NewScanner(conn)
+
+	// The token goes first on its own line
+	if token := c.getToken(); token != "" {
+		resp, err := exchangeLine(conn, scanner, protocol.CmdAuth+":"+token)
+		if err != nil || !resp.Success {
+			return resp, err
+		}
+	}
 
+	return exchangeLine(conn, scanner, cmd)
+}
+
+// exchangeLine sends one version 1 line and reads the response line
+func exchangeLine(conn net.Conn, scanner *bufio.Scanner, line string) (*protocol.Response, error) {
 	// Send command
-	_, err = conn.Write([]byte(cmd + "\n"))
+	_, err := conn.Write([]byte(line + "\n"))
 	if err != nil {
 		return nil, fmt.Errorf("send error: %w", err)
 	}
 
 	// Read response
-	scanner := bufio.NewScanner(conn)
 	if !scanner.Scan() {
 		return nil, fmt.Errorf("no response received")
 	}
@@ -123,12 +266,23 @@ func (c *SocketClient) GetMessages(limit int) ([]protocol.Message, error) {
 
 // SendMessage sends a message
 func (c *SocketClient) SendMessage(to, messageText string) (*protocol.Message, error) {
-	cmd := fmt.Sprintf("SEND:%s %s", to, messageText)
-	if to == "" {
-		cmd = fmt.Sprintf("SEND:%s", messageText)
-	}
+	return c.SendMessageAtPower(to, messageText, 0)
+}
 
-	resp, err := c.SendCommand(cmd)
+// SendMessageAtPower sends a message at power percent of the rig's full
+// power, or at the rig's own setting when power is 0
+func (c *SocketClient) SendMessageAtPower(to, messageText string, power int) (*protocol.Message, error) {
+	args := map[string]interface{}{
+		"to":      to,
+		"message": messageText,
+	}
+	if power > 0 {
+		args["power"] = power
+	}
+	resp, err := c.Do(&protocol.Command{
+		Type: protocol.CmdSend,
+		Args: args,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// serve answers every connection on a temporary socket with handler
+func serve(t *testing.T, handler func(net.Conn)) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "js8d.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handler(conn)
+			}()
+		}
+	}()
+	return path
+}
+
+// echoLine is a version 1 daemon that echoes the command it got
+func echoLine(conn net.Conn) {
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		resp := protocol.NewSuccessResponse(map[string]interface{}{"line": scanner.Text()})
+		conn.Write([]byte(resp.String() + "\n"))
+	}
+}
+
+// echoFrame is a version 2 daemon that echoes the command it got
+func echoFrame(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	version, token, failure := protocol.ReadHello(reader)
+	if failure != nil {
+		protocol.WriteFrame(conn, failure)
+		return
+	}
+	protocol.WriteFrame(conn, protocol.NewHelloResponse(version, "test"))
+
+	var cmd protocol.Command
+	if err := protocol.ReadFrame(reader, &cmd); err != nil {
+		return
+	}
+	protocol.WriteFrame(conn, protocol.NewSuccessResponse(map[string]interface{}{
+		"type":    cmd.Type,
+		"message": cmd.StringArg("message"),
+		"token":   token,
+	}))
+}
+
+func TestSendCommandVersion2(t *testing.T) {
+	c := NewSocketClient(serve(t, echoFrame))
+
+	resp, err := c.Do(&protocol.Command{
+		Type: protocol.CmdSend,
+		Args: map[string]interface{}{"message": "TWO\nLINES"},
+	})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if resp.Data["message"] != "TWO\nLINES" {
+		t.Errorf("Expected message with newline, got %v", resp.Data)
+	}
+	if c.ProtocolVersion() != protocol.Version2 {
+		t.Errorf("Expected version 2, got %d", c.ProtocolVersion())
+	}
+}
+
+func TestSendCommandFallsBackToVersion1(t *testing.T) {
+	c := NewSocketClient(serve(t, echoLine))
+
+	resp, err := c.SendCommand("SEND:N0ABC Hello")
+	if err != nil {
+		t.Fatalf("SendCommand failed: %v", err)
+	}
+	if resp.Data["line"] != "SEND:N0ABC Hello" {
+		t.Errorf("Expected the v1 line, got %v", resp.Data)
+	}
+	if c.ProtocolVersion() != protocol.Version1 {
+		t.Errorf("Expected fallback to version 1, got %d", c.ProtocolVersion())
+	}
+
+	// The power level goes out as a PWR= word
+	resp, err = c.Do(&protocol.Command{
+		Type: protocol.CmdSend,
+		Args: map[string]interface{}{"to": "N0ABC", "message": "Hello", "power": 10},
+	})
+	if err != nil || resp.Data["line"] != "SEND:PWR=10 N0ABC Hello" {
+		t.Errorf("Expected the power in the v1 line, got %v (%v)", resp, err)
+	}
+
+	// A newline can't be sent on a version 1 line
+	if _, err := c.SendMessage("", "TWO\nLINES"); err == nil {
+		t.Error("Expected error sending a newline over version 1")
+	}
+}
+
+func TestToken(t *testing.T) {
+	c := NewSocketClient(serve(t, echoFrame))
+	c.SetToken("s3cret")
+	resp, err := c.SendCommand("STATUS")
+	if err != nil {
+		t.Fatalf("SendCommand failed: %v", err)
+	}
+	if resp.Data["token"] != "s3cret" {
+		t.Errorf("Expected the token in the handshake, got %v", resp.Data)
+	}
+
+	// Version 1 sends it as an AUTH line first
+	lines := NewSocketClient(serve(t, func(conn net.Conn) {
+		scanner := bufio.NewScanner(conn)
+		scanner.Scan()
+		if scanner.Text() != "AUTH:s3cret" {
+			conn.Write([]byte(protocol.NewCodedErrorResponse(protocol.ErrCodeUnauthorized, "no token").String() + "\n"))
+			return
+		}
+		conn.Write([]byte(protocol.NewSuccessResponse(nil).String() + "\n"))
+		echoLine(conn)
+	}))
+	lines.SetProtocolVersion(protocol.Version1)
+	if resp, err := lines.SendCommand("STATUS"); err != nil || resp.Code != protocol.ErrCodeUnauthorized {
+		t.Errorf("Expected unauthorized without a token, got %v (%v)", resp, err)
+	}
+	lines.SetToken("s3cret")
+	if resp, err := lines.SendCommand("STATUS"); err != nil || resp.Data["line"] != "STATUS" {
+		t.Errorf("Expected the command after AUTH, got %v (%v)", resp, err)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	slow := serve(t, func(conn net.Conn) {
+		time.Sleep(300 * time.Millisecond)
+		echoFrame(conn)
+	})
+
+	c := NewSocketClient(slow)
+	c.SetToken("s3cret")
+	c.timeout = 100 * time.Millisecond
+	if _, err := c.SendCommand("SELFTEST SWEEP"); err == nil {
+		t.Fatal("Expected a slow answer to time out")
+	}
+
+	resp, err := c.WithTimeout(2 * time.Second).SendCommand("SELFTEST SWEEP")
+	if err != nil {
+		t.Fatalf("SendCommand with a longer timeout failed: %v", err)
+	}
+	if resp.Data["type"] != "SELFTEST SWEEP" || resp.Data["token"] != "s3cret" {
+		t.Errorf("Expected the command and token to carry over, got %v", resp.Data)
+	}
+	if c.timeout != 100*time.Millisecond {
+		t.Errorf("Expected the original client to keep its timeout, got %v", c.timeout)
+	}
+}
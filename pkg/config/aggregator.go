@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DefaultAggregatorPollInterval is how often, in seconds, an aggregator
+// polls its nodes
+const DefaultAggregatorPollInterval = 5
+
+// Node is another js8d daemon shown on an aggregator dashboard:
+//
+//	aggregator:
+//	  nodes:
+//	    - name: shack
+//	      url: http://192.168.1.20:8080
+//	    - name: tower
+//	      url: http://192.168.1.21:8080
+//	      token: "${TOWER_TOKEN}"
+type Node struct {
+	Name  string `yaml:"name"`
+	URL   string `yaml:"url"`             // Base URL of the node's web server
+	Token Secret `yaml:"token,omitempty"` // guest token for nodes that require one
+}
+
+// validateAggregator checks the node list of aggregator mode
+func (c *Config) validateAggregator() error {
+	if c.Aggregator.PollInterval < 0 {
+		return fmt.Errorf("aggregator poll_interval (%d) must not be negative", c.Aggregator.PollInterval)
+	}
+
+	names := make(map[string]bool)
+	for i, node := range c.Aggregator.Nodes {
+		if !instanceNamePattern.MatchString(node.Name) {
+			return fmt.Errorf("aggregator node %d: name %q must be letters, digits, - or _", i+1, node.Name)
+		}
+		if names[node.Name] {
+			return fmt.Errorf("aggregator node %q is listed twice", node.Name)
+		}
+		names[node.Name] = true
+
+		u, err := url.Parse(node.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("aggregator node %q: url %q must be an http or https URL", node.Name, node.URL)
+		}
+		c.Aggregator.Nodes[i].URL = strings.TrimRight(node.URL, "/")
+	}
+	return nil
+}
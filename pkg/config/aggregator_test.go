@@ -0,0 +1,48 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAggregator(t *testing.T) {
+	cfg := loadTestConfig(t, sharedConfig+`
+aggregator:
+  nodes:
+    - name: shack
+      url: http://192.168.1.20:8080/
+    - name: tower
+      url: https://tower.example.org
+`)
+	if cfg.Aggregator.PollInterval != DefaultAggregatorPollInterval {
+		t.Errorf("Expected default poll interval %d, got %d", DefaultAggregatorPollInterval, cfg.Aggregator.PollInterval)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected valid aggregator config, got %v", err)
+	}
+	if cfg.Aggregator.Nodes[0].URL != "http://192.168.1.20:8080" {
+		t.Errorf("Expected trailing slash to be trimmed, got %s", cfg.Aggregator.Nodes[0].URL)
+	}
+
+	tests := []struct {
+		name  string
+		nodes []Node
+		want  string
+	}{
+		{"Missing name", []Node{{URL: "http://a:8080"}}, "name"},
+		{"Duplicate name", []Node{{Name: "a", URL: "http://a:8080"}, {Name: "a", URL: "http://b:8080"}}, "listed twice"},
+		{"No scheme", []Node{{Name: "a", URL: "192.168.1.20:8080"}}, "http or https"},
+		{"Wrong scheme", []Node{{Name: "a", URL: "unix:///tmp/js8d.sock"}}, "http or https"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadTestConfig(t, sharedConfig)
+			cfg.Aggregator.Nodes = tt.nodes
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// DefaultAnswerPerHour is how many CQs are answered in an hour at most
+const DefaultAnswerPerHour = 4
+
+// DefaultAnswerReply is sent to a CQ caller when no reply is configured:
+// their report and our grid
+const DefaultAnswerReply = "{CALL} {SNR} {MYGRID}"
+
+// AnswerRule picks CQs to answer. A CQ matches when it was heard on one of
+// the rule's bands and passes any of its filters:
+//
+//	answer:
+//	  rules:
+//	    - bands: [20m, 17m]
+//	      new_country: true
+//	    - new_grid: true
+//	      callsigns: [K1ABC]
+type AnswerRule struct {
+	Bands      []string `yaml:"bands,omitempty"`       // bands the rule applies on, all when empty
+	NewGrid    bool     `yaml:"new_grid,omitempty"`    // callers in a grid square not yet worked
+	NewCountry bool     `yaml:"new_country,omitempty"` // callers in a country (DXCC entity) not yet worked, from callbook lookups
+	Callsigns  []string `yaml:"callsigns,omitempty"`   // watched callers, answered whenever they call
+}
+
+// validateAnswer checks the CQ answering settings
+func (c *Config) validateAnswer() error {
+	if !c.Answer.Enabled {
+		return nil
+	}
+	if err := inRange("answer per_hour", c.Answer.PerHour, 1, 60); err != nil {
+		return err
+	}
+	if strings.TrimSpace(c.Answer.Reply) == "" {
+		return fmt.Errorf("answer reply is empty")
+	}
+	if len(c.Answer.Rules) == 0 {
+		return fmt.Errorf("answer needs at least one rule")
+	}
+	for i, rule := range c.Answer.Rules {
+		name := fmt.Sprintf("answer rules[%d]", i)
+		if !rule.NewGrid && !rule.NewCountry && len(rule.Callsigns) == 0 {
+			return fmt.Errorf("%s needs new_grid, new_country or callsigns", name)
+		}
+		for j, band := range rule.Bands {
+			if !protocol.ValidBand(band) {
+				return fmt.Errorf("%s: unknown band %q", name, band)
+			}
+			c.Answer.Rules[i].Bands[j] = strings.ToLower(band)
+		}
+		for j, callsign := range rule.Callsigns {
+			c.Answer.Rules[i].Callsigns[j] = strings.ToUpper(strings.TrimSpace(callsign))
+		}
+	}
+	return nil
+}
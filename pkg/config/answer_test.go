@@ -0,0 +1,47 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAnswer(t *testing.T) {
+	cfg := loadTestConfig(t, sharedConfig+`
+answer:
+  enabled: true
+  rules:
+    - bands: [20M, 40m]
+      new_grid: true
+    - callsigns: [k1abc]
+`)
+	if cfg.Answer.PerHour != DefaultAnswerPerHour || cfg.Answer.Reply != DefaultAnswerReply {
+		t.Errorf("Expected the default limit and reply, got %d and %q", cfg.Answer.PerHour, cfg.Answer.Reply)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected valid answer config, got %v", err)
+	}
+	if cfg.Answer.Rules[0].Bands[0] != "20m" || cfg.Answer.Rules[1].Callsigns[0] != "K1ABC" {
+		t.Errorf("Expected bands and callsigns normalized, got %+v", cfg.Answer.Rules)
+	}
+
+	cfg.Answer.Rules = append(cfg.Answer.Rules, AnswerRule{Bands: []string{"11m"}, NewGrid: true})
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "unknown band") {
+		t.Errorf("Expected a band error, got %v", err)
+	}
+
+	cfg.Answer.Rules = []AnswerRule{{Bands: []string{"20m"}}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "answer rules[0]") {
+		t.Errorf("Expected a rule without filters to fail, got %v", err)
+	}
+
+	cfg.Answer.Rules = nil
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "at least one rule") {
+		t.Errorf("Expected an error without rules, got %v", err)
+	}
+
+	// Not checked when answering is off
+	cfg.Answer.Enabled = false
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected disabled answering to pass, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// AntennaPort is one antenna on the antenna switch, selected whenever the
+// dial is on one of its bands:
+//
+//	antenna:
+//	  switch: gpio
+//	  ports:
+//	    - name: beam
+//	      bands: [20m, 17m, 15m]
+//	      pin: 5
+//	    - name: dipole
+//	      bands: [40m, 80m]
+//	      pin: 6
+type AntennaPort struct {
+	Name  string   `yaml:"name"`            // shown in the status and used to select the port by hand
+	Bands []string `yaml:"bands,omitempty"` // bands the antenna is used on
+	Pin   int      `yaml:"pin,omitempty"`   // gpio: BCM pin driven high while the port is selected
+	Relay int      `yaml:"relay,omitempty"` // relay: board channel closed while the port is selected
+}
+
+// FindAntennaPort returns the index of the antenna port named name, or -1
+func (c *Config) FindAntennaPort(name string) int {
+	for i, port := range c.Antenna.Ports {
+		if strings.EqualFold(port.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// validateAntenna checks the antenna switch and that each band has at most
+// one port
+func (c *Config) validateAntenna() error {
+	if c.Antenna.Switch == "" {
+		return nil
+	}
+	if err := oneOf("antenna switch", c.Antenna.Switch, "gpio", "relay"); err != nil {
+		return err
+	}
+	c.Antenna.Switch = strings.ToLower(c.Antenna.Switch)
+	if c.Antenna.Switch == "relay" && c.Antenna.Device == "" {
+		return fmt.Errorf("antenna device is required for a relay board")
+	}
+	if len(c.Antenna.Ports) == 0 {
+		return fmt.Errorf("antenna switch needs at least one port")
+	}
+
+	bands := make(map[string]string)
+	outputs := make(map[int]string)
+	for i, port := range c.Antenna.Ports {
+		name := fmt.Sprintf("antenna ports[%d]", i)
+		if strings.TrimSpace(port.Name) == "" {
+			return fmt.Errorf("%s needs a name", name)
+		}
+		if c.FindAntennaPort(port.Name) != i {
+			return fmt.Errorf("%s: name %q is used twice", name, port.Name)
+		}
+		for j, band := range port.Bands {
+			if !protocol.ValidBand(band) {
+				return fmt.Errorf("%s: unknown band %q", name, band)
+			}
+			band = strings.ToLower(band)
+			if other, ok := bands[band]; ok {
+				return fmt.Errorf("%s: band %s is already on port %s", name, band, other)
+			}
+			bands[band] = port.Name
+			c.Antenna.Ports[i].Bands[j] = band
+		}
+
+		kind, output := "pin", port.Pin
+		if c.Antenna.Switch == "gpio" {
+			if err := inRange(name+" pin", port.Pin, 1, maxGPIOPin); err != nil {
+				return err
+			}
+			if c.Hardware.EnableGPIO && (port.Pin == c.Hardware.PTTGPIOPin || port.Pin == c.Hardware.StatusLEDPin) {
+				return fmt.Errorf("%s: pin %d is already the PTT or status LED pin", name, port.Pin)
+			}
+			if c.Hardware.EnableGPIO && c.Hardware.AmpKeying && port.Pin == c.Hardware.AmpGPIOPin {
+				return fmt.Errorf("%s: pin %d is already the amp keying pin", name, port.Pin)
+			}
+		} else {
+			kind, output = "relay", port.Relay
+			if err := inRange(name+" relay", port.Relay, 1, 8); err != nil {
+				return err
+			}
+		}
+		if other, ok := outputs[output]; ok {
+			return fmt.Errorf("%s: %s %d is already port %s", name, kind, output, other)
+		}
+		outputs[output] = port.Name
+	}
+
+	if c.Antenna.Default != "" && c.FindAntennaPort(c.Antenna.Default) < 0 {
+		return fmt.Errorf("antenna default %q is not one of the ports", c.Antenna.Default)
+	}
+	return nil
+}
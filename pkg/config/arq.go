@@ -0,0 +1,26 @@
+package config
+
+// Defaults for arq
+const (
+	DefaultARQWindow  = 4  // Data frames sent before each poll
+	DefaultARQTimeout = 60 // Seconds to wait for an answer
+	DefaultARQRetries = 3
+	DefaultARQIdle    = 30 // Minutes
+)
+
+// validateARQ checks the connected-mode session settings
+func (c *Config) validateARQ() error {
+	if !c.ARQ.Enabled {
+		return nil
+	}
+	if err := inRange("arq window", c.ARQ.Window, 1, 16); err != nil {
+		return err
+	}
+	if err := inRange("arq timeout", c.ARQ.Timeout, 15, 600); err != nil {
+		return err
+	}
+	if err := inRange("arq retries", c.ARQ.Retries, 1, 10); err != nil {
+		return err
+	}
+	return inRange("arq idle", c.ARQ.Idle, 1, 1440)
+}
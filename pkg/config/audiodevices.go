@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AudioDeviceSettings are the audio settings remembered for a sound card,
+// keyed by its input device, and put back whenever it is selected again:
+//
+//	audio:
+//	  input_device: "plughw:2,0"
+//	  devices:
+//	    "plughw:1,0":   # the shack IC-7300
+//	      sample_rate: 48000
+//	      input_channels: stereo-split
+//	      input_gain: -3
+//	    "plughw:2,0":   # a portable QDX
+//	      sample_rate: 48000
+//	      input_channels: mono
+//	      input_gain: 6
+type AudioDeviceSettings struct {
+	SampleRate        int      `yaml:"sample_rate"`
+	InputChannels     string   `yaml:"input_channels"`
+	InputChannelNames []string `yaml:"input_channel_names,omitempty"`
+	InputChannel      string   `yaml:"input_channel,omitempty"`
+	OutputChannels    string   `yaml:"output_channels"`
+	OutputChannel     string   `yaml:"output_channel,omitempty"`
+	InputGain         float64  `yaml:"input_gain"`
+	OutputGain        float64  `yaml:"output_gain"`
+}
+
+// audioDeviceSettings returns the audio settings in use
+func (c *Config) audioDeviceSettings() AudioDeviceSettings {
+	return AudioDeviceSettings{
+		SampleRate:        c.Audio.SampleRate,
+		InputChannels:     c.Audio.InputChannels,
+		InputChannelNames: append([]string(nil), c.Audio.InputChannelNames...),
+		InputChannel:      c.Audio.InputChannel,
+		OutputChannels:    c.Audio.OutputChannels,
+		OutputChannel:     c.Audio.OutputChannel,
+		InputGain:         c.Audio.InputGain,
+		OutputGain:        c.Audio.OutputGain,
+	}
+}
+
+// RememberAudioDevice keeps the audio settings in use for the selected
+// input device
+func (c *Config) RememberAudioDevice() {
+	c.rememberAudioDevice(c.Audio.InputDevice, c.audioDeviceSettings())
+}
+
+// rememberAudioDevice keeps settings for the input device name
+func (c *Config) rememberAudioDevice(name string, settings AudioDeviceSettings) {
+	if name == "" {
+		return
+	}
+	if c.Audio.Devices == nil {
+		c.Audio.Devices = make(map[string]AudioDeviceSettings)
+	}
+	c.Audio.Devices[name] = settings
+}
+
+// RecallAudioDevice puts back the settings remembered for the input device
+// when it has changed from previous. Settings keyed in keep, the audio
+// values given along with the change, stay as they are. It reports whether
+// the device had settings remembered.
+func (c *Config) RecallAudioDevice(previous string, keep map[string]interface{}) bool {
+	if c.Audio.InputDevice == previous {
+		return false
+	}
+	settings, ok := c.Audio.Devices[c.Audio.InputDevice]
+	if !ok {
+		return false
+	}
+
+	recall := func(key string, apply func()) {
+		if _, kept := keep[key]; !kept {
+			apply()
+		}
+	}
+	recall("sample_rate", func() { c.Audio.SampleRate = settings.SampleRate })
+	recall("input_channels", func() { c.Audio.InputChannels = settings.InputChannels })
+	recall("input_channel_names", func() {
+		c.Audio.InputChannelNames = append([]string(nil), settings.InputChannelNames...)
+	})
+	recall("output_channels", func() { c.Audio.OutputChannels = settings.OutputChannels })
+	// Devices remembered before channel mapping keep the mapping in use
+	if settings.InputChannel != "" {
+		recall("input_channel", func() { c.Audio.InputChannel = settings.InputChannel })
+	}
+	if settings.OutputChannel != "" {
+		recall("output_channel", func() { c.Audio.OutputChannel = settings.OutputChannel })
+	}
+	recall("input_gain", func() { c.Audio.InputGain = settings.InputGain })
+	recall("output_gain", func() { c.Audio.OutputGain = settings.OutputGain })
+	return true
+}
+
+// validateAudioDevices checks the gains, and the settings remembered for
+// each device
+func (c *Config) validateAudioDevices() error {
+	if err := validateGains("audio", c.Audio.InputGain, c.Audio.OutputGain); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(c.Audio.Devices))
+	for name := range c.Audio.Devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		device := c.Audio.Devices[name]
+		section := fmt.Sprintf("audio devices[%s]", name)
+		if device.SampleRate != 0 {
+			if err := inRange(section+" sample_rate", device.SampleRate, 8000, 192000); err != nil {
+				return err
+			}
+		}
+		if device.InputChannels != "" {
+			if err := oneOf(section+" input_channels", device.InputChannels, "mono", "stereo", "stereo-split"); err != nil {
+				return err
+			}
+		}
+		if device.OutputChannels != "" {
+			if err := oneOf(section+" output_channels", device.OutputChannels, "mono", "stereo"); err != nil {
+				return err
+			}
+		}
+		if device.InputChannel != "" {
+			if err := oneOf(section+" input_channel", device.InputChannel, "left", "right", "sum"); err != nil {
+				return err
+			}
+		}
+		if device.OutputChannel != "" {
+			if err := oneOf(section+" output_channel", device.OutputChannel, "left", "right", "both"); err != nil {
+				return err
+			}
+		}
+		if len(device.InputChannelNames) > 2 {
+			return fmt.Errorf("%s input_channel_names has %d entries, stereo-split has only 2 channels", section, len(device.InputChannelNames))
+		}
+		if err := validateGains(section, device.InputGain, device.OutputGain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateGains checks an input gain of -20 to +40 dB, for quiet rigs, and
+// an output gain of -40 to 0 dB, as more would clip the TX audio
+func validateGains(section string, input, output float64) error {
+	if input < -20 || input > 40 {
+		return fmt.Errorf("%s input_gain (%.1f) must be between -20 and 40 dB", section, input)
+	}
+	if output < -40 || output > 0 {
+		return fmt.Errorf("%s output_gain (%.1f) must be between -40 and 0 dB", section, output)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAudioDeviceMemory(t *testing.T) {
+	cfg := loadTestConfig(t, string(DefaultYAML))
+	update := func(cfg *Config, values string) *Config {
+		t.Helper()
+		var parsed map[string]interface{}
+		if err := json.Unmarshal([]byte(values), &parsed); err != nil {
+			t.Fatalf("Bad test values: %v", err)
+		}
+		updated, err := cfg.Update(parsed)
+		if err != nil {
+			t.Fatalf("Failed to update: %v", err)
+		}
+		return updated
+	}
+
+	// Settings saved for the shack rig are remembered for it
+	shack := update(cfg, `{"audio": {"input_device": "plughw:1,0", "sample_rate": 44100, "input_gain": -3}}`)
+	// The portable rig starts from the settings in use, then gets its own
+	portable := update(shack, `{"audio": {"input_device": "plughw:2,0"}}`)
+	if portable.Audio.SampleRate != 44100 {
+		t.Errorf("Expected a new device to keep the settings in use, got %d Hz", portable.Audio.SampleRate)
+	}
+	portable = update(portable, `{"audio": {"sample_rate": 48000, "input_channels": "stereo", "input_channel": "right", "input_gain": 6}}`)
+
+	// Switching back puts the shack settings back
+	back := update(portable, `{"audio": {"input_device": "plughw:1,0"}}`)
+	if back.Audio.SampleRate != 44100 || back.Audio.InputGain != -3 || back.Audio.InputChannels != "mono" || back.Audio.InputChannel != "left" {
+		t.Errorf("Expected the shack settings back, got %d Hz, %s, %.1f dB",
+			back.Audio.SampleRate, back.Audio.InputChannels, back.Audio.InputGain)
+	}
+
+	// Settings given along with the switch win over remembered ones
+	again := update(back, `{"audio": {"input_device": "plughw:2,0", "input_gain": 10}}`)
+	if again.Audio.SampleRate != 48000 || again.Audio.InputChannels != "stereo" || again.Audio.InputGain != 10 {
+		t.Errorf("Expected the portable settings with 10 dB gain, got %d Hz, %s, %.1f dB",
+			again.Audio.SampleRate, again.Audio.InputChannels, again.Audio.InputGain)
+	}
+	if again.Audio.InputChannel != "right" {
+		t.Errorf("Expected the portable rig's right channel, got %s", again.Audio.InputChannel)
+	}
+	if again.Audio.Devices["plughw:2,0"].InputGain != 10 {
+		t.Errorf("Expected the new gain remembered, got %+v", again.Audio.Devices["plughw:2,0"])
+	}
+
+	// Other sections leave the memory alone
+	if other := update(again, `{"web": {"port": 8081}}`); len(other.Audio.Devices) != len(again.Audio.Devices) {
+		t.Errorf("Expected %d devices remembered, got %d", len(again.Audio.Devices), len(other.Audio.Devices))
+	}
+
+	// A device swapped in by hand, as on reload, is recalled in full
+	edited := *portable
+	edited.Audio.InputDevice = "plughw:1,0"
+	if !edited.RecallAudioDevice("plughw:2,0", nil) || edited.Audio.SampleRate != 44100 {
+		t.Errorf("Expected the shack settings recalled, got %d Hz", edited.Audio.SampleRate)
+	}
+	if edited.RecallAudioDevice("plughw:1,0", nil) {
+		t.Error("Expected nothing recalled without a device change")
+	}
+}
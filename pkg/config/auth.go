@@ -0,0 +1,72 @@
+package config
+
+import (
+	"crypto/subtle"
+	"fmt"
+)
+
+// APIToken grants a role to whoever presents it:
+//
+//	auth:
+//	  tokens:
+//	    - name: shack-tablet
+//	      token: "secret:tablet_token"
+//	      role: operator
+type APIToken struct {
+	Name  string `yaml:"name"`
+	Token Secret `yaml:"token"`
+	Role  string `yaml:"role"` // guest, operator or admin
+}
+
+// AuthEnabled reports whether tokens are required
+func (c *Config) AuthEnabled() bool {
+	return len(c.Auth.Tokens) > 0
+}
+
+// TokenRole returns the name and role of the configured token matching
+// token, comparing in constant time
+func (c *Config) TokenRole(token string) (name, role string, ok bool) {
+	if token == "" {
+		return "", "", false
+	}
+	for _, t := range c.Auth.Tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Token.Value()), []byte(token)) == 1 {
+			name, role, ok = t.Name, t.Role, true
+		}
+	}
+	return name, role, ok
+}
+
+// validateAuth checks the tokens have names, values and known roles
+func (c *Config) validateAuth() error {
+	if c.Auth.SocketRole != "" {
+		if err := oneOf("auth socket_role", c.Auth.SocketRole, "guest", "operator", "admin"); err != nil {
+			return err
+		}
+	}
+
+	names := make(map[string]bool)
+	values := make(map[string]bool)
+	for i, t := range c.Auth.Tokens {
+		if !instanceNamePattern.MatchString(t.Name) {
+			return fmt.Errorf("auth token %d: name %q must be letters, digits, - or _", i+1, t.Name)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("auth token %q is listed twice", t.Name)
+		}
+		names[t.Name] = true
+
+		if len(t.Token.Value()) < 16 {
+			return fmt.Errorf("auth token %q must be at least 16 characters", t.Name)
+		}
+		if values[t.Token.Value()] {
+			return fmt.Errorf("auth token %q has the same value as another token", t.Name)
+		}
+		values[t.Token.Value()] = true
+
+		if err := oneOf(fmt.Sprintf("auth token %q role", t.Name), t.Role, "guest", "operator", "admin"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
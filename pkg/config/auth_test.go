@@ -0,0 +1,64 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenRole(t *testing.T) {
+	t.Setenv("JS8D_TEST_ADMIN_TOKEN", "admin-token-0123456789")
+	cfg := loadTestConfig(t, sharedConfig+`
+auth:
+  tokens:
+    - name: tablet
+      token: "operator-token-0123456789"
+      role: operator
+    - name: laptop
+      token: "${JS8D_TEST_ADMIN_TOKEN}"
+      role: admin
+`)
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected valid tokens, got %v", err)
+	}
+	if !cfg.AuthEnabled() || cfg.Auth.SocketRole != "admin" {
+		t.Errorf("Expected auth enabled with admin socket clients, got %v, %q", cfg.AuthEnabled(), cfg.Auth.SocketRole)
+	}
+
+	if name, role, ok := cfg.TokenRole("admin-token-0123456789"); !ok || name != "laptop" || role != "admin" {
+		t.Errorf("Expected laptop admin, got %q %q %v", name, role, ok)
+	}
+	if _, role, ok := cfg.TokenRole("operator-token-0123456789"); !ok || role != "operator" {
+		t.Errorf("Expected operator, got %q %v", role, ok)
+	}
+	for _, token := range []string{"", "operator-token", "${JS8D_TEST_ADMIN_TOKEN}"} {
+		if _, _, ok := cfg.TokenRole(token); ok {
+			t.Errorf("Expected %q to be refused", token)
+		}
+	}
+}
+
+func TestValidateAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []APIToken
+		want   string
+	}{
+		{"Short Token", []APIToken{{Name: "a", Token: NewSecret("short"), Role: "admin"}}, "at least 16 characters"},
+		{"Unknown Role", []APIToken{{Name: "a", Token: NewSecret("0123456789abcdef"), Role: "root"}}, "role must be guest, operator or admin"},
+		{"Duplicate Value", []APIToken{
+			{Name: "a", Token: NewSecret("0123456789abcdef"), Role: "guest"},
+			{Name: "b", Token: NewSecret("0123456789abcdef"), Role: "admin"},
+		}, "same value"},
+		{"Missing Name", []APIToken{{Token: NewSecret("0123456789abcdef"), Role: "guest"}}, "name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadTestConfig(t, sharedConfig)
+			cfg.Auth.Tokens = tt.tokens
+			if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
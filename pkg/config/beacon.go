@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Defaults for beacon
+const (
+	DefaultBeaconInterval = 30 // Minutes between beacons
+	DefaultBeaconJitter   = 60 // Seconds a beacon may move either way
+	DefaultBeaconText     = "{MYCALL} {MYGRID}"
+	DefaultBeaconQuiet    = 10 // Minutes without traffic before beacons resume
+	DefaultBeaconIDText   = "DE {MYCALL}"
+)
+
+// validateBeacon checks the beacon and station ID settings
+func (c *Config) validateBeacon() error {
+	if c.Beacon.Enabled {
+		if err := inRange("beacon interval", c.Beacon.Interval, 5, 1440); err != nil {
+			return err
+		}
+		// Up to half the interval, so beacons never bunch up
+		if c.Beacon.Jitter != -1 {
+			if err := inRange("beacon jitter", c.Beacon.Jitter, 0, c.Beacon.Interval*30); err != nil {
+				return err
+			}
+		}
+		if strings.TrimSpace(c.Beacon.Text) == "" {
+			return fmt.Errorf("beacon text is empty")
+		}
+		if err := inRange("beacon quiet", c.Beacon.Quiet, 1, 240); err != nil {
+			return err
+		}
+	}
+	if c.Beacon.IDInterval != 0 {
+		if err := inRange("beacon id_interval", c.Beacon.IDInterval, 1, 60); err != nil {
+			return err
+		}
+		if !c.identifies(c.Beacon.IDText) {
+			return fmt.Errorf("beacon id_text %q must contain {MYCALL} or the station callsign", c.Beacon.IDText)
+		}
+	}
+	return nil
+}
+
+// identifies reports whether text sent from this station carries its
+// callsign, as {MYCALL} or written out
+func (c *Config) identifies(text string) bool {
+	text = strings.ToUpper(text)
+	return strings.Contains(text, "{MYCALL}") ||
+		(c.Station.Callsign != "" && strings.Contains(text, strings.ToUpper(c.Station.Callsign)))
+}
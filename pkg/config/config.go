@@ -3,20 +3,40 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 
 	"gopkg.in/yaml.v2"
+
+	"github.com/dougsko/js8d/pkg/i18n"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// Station callsigns may carry a prefix or suffix (VE3/K3DEP, K3DEP/P), which
+// JS8 sends as a compound callsign of at most 9 characters plus slashes
+var (
+	callsignPattern = regexp.MustCompile(`^(?:[A-Z0-9]{1,4}/)?[A-Z0-9]{0,3}[0-9][A-Z0-9]{0,4}[A-Z](?:/[A-Z0-9]{1,4})?$`)
+	gridPattern     = regexp.MustCompile(`^[A-R]{2}[0-9]{2}(?:[A-X]{2})?$`)
 )
 
+// placeholderCallsign is the callsign the Docker and production configs
+// ship with, to be replaced by the operator's
+const placeholderCallsign = "YOUR_CALLSIGN"
+
 // Config represents the js8d configuration
 type Config struct {
 	Station struct {
 		Callsign string `yaml:"callsign"`
 		Grid     string `yaml:"grid"`
+		Region   int    `yaml:"region"`  // ITU region 1, 2 or 3, whose band plan TX is checked against
+		License  string `yaml:"license"` // license class limiting TX to its data privileges: technician, general, advanced or extra
 	} `yaml:"station"`
 
 	Radio struct {
 		// Basic Configuration
 		UseHamlib    bool   `yaml:"use_hamlib"`
+		Backend      string `yaml:"backend"`      // hamlib, or native for the built-in CAT drivers
+		CATProtocol  string `yaml:"cat_protocol"` // native command set: civ, kenwood or ft8x7, "" to pick by model
 		Model        string `yaml:"model"`
 		PollInterval int    `yaml:"poll_interval"`
 
@@ -41,41 +61,333 @@ type Config struct {
 		SplitOperation string  `yaml:"split_operation"`
 		PTTCommand     string  `yaml:"ptt_command"`
 		TxDelay        float64 `yaml:"tx_delay"`
+		TxTail         float64 `yaml:"tx_tail"` // seconds PTT is held after the audio ends
+		VOXLeader      float64 `yaml:"vox_leader"` // seconds of tone before the audio to trip VOX
+
+		// Frequency Calibration
+		CalibrationPPM float64 `yaml:"calibration_ppm"` // frequency error of the rig's reference, parts per million
+		DriftEstimate  bool    `yaml:"drift_estimate"`  // measure drift of decoded offsets and suggest a correction
+
+		// Transverter
+		TransverterOffset int64 `yaml:"transverter_offset"` // Hz added to the rig's frequency for the RF a transverter is on, 0 without one
+
+		// Antenna Tuner
+		TuneBeforeTX string  `yaml:"tune_before_tx"` // "", carrier or cat: tune before the first TX on a new band
+		TuneSeconds  float64 `yaml:"tune_seconds"`   // how long to key the carrier or wait for the tuner
+		TunePower    int     `yaml:"tune_power"`     // carrier power in percent, 0 for the rig's setting
 	} `yaml:"radio"`
 
 	Audio struct {
 		// Device Configuration
-		InputDevice        string `yaml:"input_device"`
-		InputChannels      string `yaml:"input_channels"`
-		OutputDevice       string `yaml:"output_device"`
-		OutputChannels     string `yaml:"output_channels"`
-		NotificationDevice string `yaml:"notification_device"`
+		InputDevice        string   `yaml:"input_device"`
+		InputChannels      string   `yaml:"input_channels"`      // mono, stereo, stereo-split
+		InputChannelNames  []string `yaml:"input_channel_names"` // labels for stereo-split channels (left, right)
+		InputChannel       string   `yaml:"input_channel"`       // stereo: left, right or sum carries RX
+		OutputDevice       string   `yaml:"output_device"`
+		OutputChannels     string   `yaml:"output_channels"` // mono, stereo
+		OutputChannel      string   `yaml:"output_channel"`  // stereo: left, right or both carry TX
+		NotificationDevice string   `yaml:"notification_device"`
 
 		// Audio Parameters
 		SampleRate   int `yaml:"sample_rate"`
 		BufferSize   int `yaml:"buffer_size"`
+		HalfDuplex   bool `yaml:"half_duplex"` // capture stops while transmitting, for cards that can't do both
+		InputGain    float64 `yaml:"input_gain"`  // dB applied to captured audio
+		OutputGain   float64 `yaml:"output_gain"` // dB applied to TX audio
 
 		// Advanced Options
 		SaveDirectory     string `yaml:"save_directory"`
 		RememberPowerTx   bool   `yaml:"remember_power_tx"`
 		RememberPowerTune bool   `yaml:"remember_power_tune"`
+
+		// RX Level Alarms
+		ClipAlarmSeconds   int     `yaml:"clip_alarm_seconds"`      // sustained clipping before alarm (-1 disables)
+		DeadInputMinutes   int     `yaml:"dead_input_minutes"`      // minutes without audio before alarm (-1 disables)
+		DeadInputThreshold float64 `yaml:"dead_input_threshold_db"` // RMS level in dB considered silence
+
+		// Settings remembered per input device, put back when it is selected
+		Devices map[string]AudioDeviceSettings `yaml:"devices,omitempty"`
 	} `yaml:"audio"`
 
+	DSP struct {
+		// RX Pre-Filter
+		BandPass         bool      `yaml:"band_pass"`         // band-pass filter before decoding
+		LowCut           float64   `yaml:"low_cut"`           // band-pass low edge in Hz
+		HighCut          float64   `yaml:"high_cut"`          // band-pass high edge in Hz
+		NoiseBlanker     bool      `yaml:"noise_blanker"`     // blank impulse noise
+		BlankerThreshold float64   `yaml:"blanker_threshold"` // impulse level as a multiple of average
+		NotchFrequencies []float64 `yaml:"notch_frequencies"` // carrier notch frequencies in Hz
+
+		// Decoder backend: auto, native (C++ library) or go
+		Decoder string `yaml:"decoder"`
+
+		// Audio offsets in Hz decodes are kept between, by their tone 0
+		DecodeLow  int `yaml:"decode_low"`
+		DecodeHigh int `yaml:"decode_high"`
+
+		// FFT backend for the decoder and spectrum: auto, go-dsp, radix2 or neon
+		FFTBackend string `yaml:"fft_backend"`
+
+		// Adaptive decoding for slow hardware
+		AdaptiveDecode bool    `yaml:"adaptive_decode"` // reduce decode work when over budget
+		DecodeBudget   float64 `yaml:"decode_budget"`   // fraction of each cycle decoding may use
+	} `yaml:"dsp"`
+
 	Web struct {
 		Port        int    `yaml:"port"`
 		BindAddress string `yaml:"bind_address"`
+		AssetsDir   string `yaml:"assets_dir"` // templates/ and static/ to serve instead of the built-in copy
+		Language    string `yaml:"language"`   // For browsers that ask for none of the translations
 	} `yaml:"web"`
 
 	API struct {
 		WebSocketPort int    `yaml:"websocket_port"`
 		UnixSocket    string `yaml:"unix_socket"`
+		GRPCAddress   string `yaml:"grpc_address"` // host:port for the gRPC API, empty disables it
 	} `yaml:"api"`
 
+	// Auth requires a token for the web API, gRPC and socket once any are
+	// configured. Each token has a role: guest, operator or admin.
+	Auth struct {
+		Tokens      []APIToken `yaml:"tokens,omitempty"`
+		GuestAccess bool       `yaml:"guest_access"` // requests without a token may view as guest
+		SocketRole  string     `yaml:"socket_role"`  // role of socket clients without a token
+	} `yaml:"auth"`
+
 	Storage struct {
-		DatabasePath string `yaml:"database_path"`
-		MaxMessages  int    `yaml:"max_messages"`
+		Backend          string `yaml:"backend"` // sqlite, postgres for a central database or memory to keep nothing
+		DatabasePath     string `yaml:"database_path"`
+		DSN              Secret `yaml:"dsn"` // postgres connection string, e.g. "secret:postgres_dsn"
+		MaxMessages      int    `yaml:"max_messages"`
+		StatsMinuteHours int    `yaml:"stats_minute_hours"` // hours per-minute stats are kept before rolling up to hourly
+		StatsHourDays    int    `yaml:"stats_hour_days"`    // days hourly stats are kept
+		SNRHistoryDays   int    `yaml:"snr_history_days"`   // days every station's decode SNRs are kept
+		RawFrames        bool   `yaml:"raw_frames"`         // store the payload bits and tones of each decode, for protocol debugging
+
+		// A few seconds of the audio of each decode are saved to
+		// snippet_dir, gzipped WAV, when it is set; the oldest are removed
+		// once they take more than snippet_quota megabytes
+		SnippetDir     string `yaml:"snippet_dir"`     // directory for decode audio snippets, empty for none
+		SnippetSeconds int    `yaml:"snippet_seconds"` // seconds of audio saved from half a second before each decode
+		SnippetQuota   int    `yaml:"snippet_quota"`   // megabytes of snippets kept
+
+		// All RX audio is recorded to recording_dir, when it is set, in
+		// files of recording_minutes each indexed with their decodes; the
+		// oldest are removed once they take more than recording_quota
+		// megabytes
+		RecordingDir     string `yaml:"recording_dir"`     // directory for the band recorder, empty for none
+		RecordingCodec   string `yaml:"recording_codec"`   // auto, opus (js8d built with -tags opus) or mulaw
+		RecordingBitrate int    `yaml:"recording_bitrate"` // Opus bits per second
+		RecordingMinutes int    `yaml:"recording_minutes"` // minutes of audio per recording file
+		RecordingQuota   int    `yaml:"recording_quota"`   // megabytes of recordings kept
+
+		// The database is checked for corruption every check_interval
+		// hours and, when backup_path is set, copied there, keeping
+		// backup_keep copies
+		CheckInterval int    `yaml:"check_interval"` // hours between integrity checks and backups, -1 disables
+		BackupPath    string `yaml:"backup_path"`    // backup copy of the database, older ones get .1, .2...
+		BackupKeep    int    `yaml:"backup_keep"`    // backup copies kept
 	} `yaml:"storage"`
 
+	// Messages tracks acknowledgements of directed messages and groups
+	// conversations into QSOs
+	Messages struct {
+		AckTimeout int `yaml:"ack_timeout"` // seconds to wait for an ACK after sending (-1 disables tracking)
+		AckRetries int `yaml:"ack_retries"` // resends before a message is marked undelivered
+		SessionGap int `yaml:"session_gap"` // minutes of silence after which a new QSO starts
+
+		Escalate       string `yaml:"escalate"`        // how resends change: off, submode, offset or both
+		EscalateAfter  int    `yaml:"escalate_after"`  // unanswered sends before resends start to escalate
+		EscalateOffset int    `yaml:"escalate_offset"` // Hz each escalated resend moves from the TX offset
+	} `yaml:"messages"`
+
+	// TX sets the audio offset transmissions go out at and checks the
+	// channel there for other signals in the cycle before keying
+	TX struct {
+		Offset     int     `yaml:"offset"`      // audio offset of our signal in Hz
+		Busy       string  `yaml:"busy"`        // when the offset is busy: off, report, wait or move
+		BusyMargin int     `yaml:"busy_margin"` // Hz either side of our signal that must be clear too
+		BusyLevel  float64 `yaml:"busy_level"`  // dB above the noise floor counted as a signal
+		BusyWait   int     `yaml:"busy_wait"`   // most cycles waited for the channel to clear before sending anyway
+		FindLow    int     `yaml:"find_low"`    // lowest offset FIND_OFFSET picks, in Hz
+		FindHigh   int     `yaml:"find_high"`   // highest offset FIND_OFFSET picks, in Hz
+		OutOfBand  string  `yaml:"out_of_band"` // TX outside the band plan or license class: off, warn or block
+		SelfDecode string  `yaml:"self_decode"` // Our own TX heard back on RX: blank, tag or off
+		DataFrames bool    `yaml:"data_frames"` // send text longer than a frame as Huffman coded data frames
+	} `yaml:"tx"`
+
+	// Submode is the JS8 speed messages go out in. With adaptive on, a
+	// message to a station goes out in the fastest submode its recent SNR
+	// supports.
+	Submode struct {
+		Default    string  `yaml:"default"`     // normal, fast, turbo or slow, for messages not chosen by SNR
+		Adaptive   bool    `yaml:"adaptive"`    // choose the submode of messages to a station from its recent SNR
+		Window     int     `yaml:"window"`      // minutes of the station's decodes averaged
+		MinDecodes int     `yaml:"min_decodes"` // decodes in the window needed to choose, fewer use default
+		Turbo      float64 `yaml:"turbo"`       // average SNR in dB at or above which Turbo is chosen
+		Fast       float64 `yaml:"fast"`        // at or above which Fast is chosen
+		Normal     float64 `yaml:"normal"`      // at or above which Normal is chosen, Slow below it
+	} `yaml:"submode"`
+
+	// QSO answers a station replying to our CQ with a scripted exchange,
+	// sending each step after the station's reply to the one before
+	QSO struct {
+		Enabled bool     `yaml:"enabled"` // run the script when a station answers a CQ
+		Timeout int      `yaml:"timeout"` // seconds to wait for each reply
+		Retries int      `yaml:"retries"` // resends of a step without a reply before giving up
+		Steps   []string `yaml:"steps"`   // messages to send, which may contain macros and tokens
+	} `yaml:"qso"`
+
+	// Net runs a directed net from this station as net control: a preamble
+	// to the net's group on schedule, and a list of the stations checking in
+	Net struct {
+		Group     string   `yaml:"group"`              // group the net is called on, such as @ARESNET
+		Schedule  []string `yaml:"schedule,omitempty"` // local start times such as "Tue 19:00", or "19:00" for every day
+		Duration  int      `yaml:"duration"`           // minutes a scheduled net stays open
+		Preamble  string   `yaml:"preamble"`           // sent to the group when the net opens; macros and tokens are filled in
+		Closing   string   `yaml:"closing"`            // sent to the group when the net closes
+		Ack       string   `yaml:"ack"`                // reply to each station checking in while automatic replies are on
+		ExportDir string   `yaml:"export_dir"`         // each net's check-ins are written here as CSV when it closes, "" to keep them only in the database
+	} `yaml:"net"`
+
+	// Beacon sends a short text on an interval while the band is quiet, and
+	// the station ID timer makes sure our callsign goes out often enough
+	Beacon struct {
+		Enabled    bool   `yaml:"enabled"`     // send beacons
+		Interval   int    `yaml:"interval"`    // minutes between beacons
+		Jitter     int    `yaml:"jitter"`      // seconds each beacon is moved earlier or later at random, -1 for none
+		Text       string `yaml:"text"`        // the beacon; macros and tokens are filled in
+		Quiet      int    `yaml:"quiet"`       // minutes after the last directed traffic before beacons resume
+		IDInterval int    `yaml:"id_interval"` // minutes of transmitting without our callsign before an ID goes out, 0 for no ID timer
+		IDText     string `yaml:"id_text"`     // the ID sent by the timer
+	} `yaml:"beacon"`
+
+	// Telemetry sends this station's sensor readings and uptime as data
+	// frames on an interval, and keeps the telemetry heard from others
+	Telemetry struct {
+		Enabled  bool     `yaml:"enabled"`   // send telemetry
+		Interval int      `yaml:"interval"`  // minutes between telemetry transmissions
+		Fields   []string `yaml:"fields"`    // voltage, current, temperature and uptime, those read are sent
+		KeepDays int      `yaml:"keep_days"` // days telemetry heard from other stations is kept
+	} `yaml:"telemetry"`
+
+	// Answer replies to CQs that match a rule while automatic replies are
+	// on, logging each decision for review
+	Answer struct {
+		Enabled bool         `yaml:"enabled"`  // answer CQs that match a rule
+		PerHour int          `yaml:"per_hour"` // most CQs answered in any hour
+		Reply   string       `yaml:"reply"`    // message sent to the caller, which may contain macros and tokens
+		Rules   []AnswerRule `yaml:"rules"`    // CQs to answer
+	} `yaml:"answer"`
+
+	// RateLimit caps the automatic replies other stations can set off (SNR
+	// reports, CQ answers and file transfer acknowledgements) with token
+	// buckets, one per station and one over all, so a misbehaving station
+	// can't make js8d spam the band
+	RateLimit struct {
+		CallsignPerHour int `yaml:"callsign_per_hour"` // automatic replies to one station an hour, -1 for no limit
+		CallsignBurst   int `yaml:"callsign_burst"`    // replies to one station in a row before the hourly rate applies
+		GlobalPerHour   int `yaml:"global_per_hour"`   // automatic replies to all stations an hour, -1 for no limit
+		GlobalBurst     int `yaml:"global_burst"`      // replies in a row before the hourly rate applies
+	} `yaml:"rate_limit"`
+
+	// Lookup fills in the station database from an online callbook
+	Lookup struct {
+		Service   string `yaml:"service"`    // qrz or hamqth, empty disables lookups
+		Username  string `yaml:"username"`   // callbook account
+		Password  Secret `yaml:"password"`   // callbook password, e.g. "secret:qrz_password"
+		CacheDays int    `yaml:"cache_days"` // days before a station is looked up again
+	} `yaml:"lookup"`
+
+	// DXCC tags stations with their DXCC entity from a cty.dat country file
+	DXCC struct {
+		File string `yaml:"file"` // country file, refreshed in place; empty uses cty.dat beside the database
+		URL  string `yaml:"url"`  // where a refresh downloads it, empty uses country-files.com
+	} `yaml:"dxcc"`
+
+	// QSL uploads logged QSOs for confirmation to LoTW and eQSL
+	QSL struct {
+		LoTW struct {
+			TQSL     string `yaml:"tqsl"`     // TQSL program, empty runs tqsl from the PATH
+			Location string `yaml:"location"` // TQSL station location to sign with, empty disables LoTW uploads
+		} `yaml:"lotw"`
+		EQSL struct {
+			Username string `yaml:"username"` // eQSL.cc account, empty disables eQSL uploads
+			Password Secret `yaml:"password"` // eQSL password, e.g. "secret:eqsl_password"
+			Nickname string `yaml:"nickname"` // QTH nickname, for accounts with several locations
+			URL      string `yaml:"url"`      // upload address, empty uses eQSL's ImportADIF
+		} `yaml:"eqsl"`
+	} `yaml:"qsl"`
+
+	// LogBroadcast sends each logged QSO to logging programs over UDP
+	LogBroadcast struct {
+		Targets []LogTarget `yaml:"targets,omitempty"`
+	} `yaml:"log_broadcast"`
+
+	// Propagation fetches solar indices for the web interface
+	Propagation struct {
+		Enabled bool   `yaml:"enabled"` // fetch the indices from NOAA
+		URL     string `yaml:"url"`     // geophysical alert to read, empty uses NOAA's wwv.txt
+		Refresh int    `yaml:"refresh"` // minutes between fetches
+	} `yaml:"propagation"`
+
+	// GPS reads time and position from gpsd, for portable stations
+	GPS struct {
+		Enabled      bool   `yaml:"enabled"`       // connect to gpsd
+		Address      string `yaml:"address"`       // gpsd host:port
+		UpdateGrid   bool   `yaml:"update_grid"`   // set the station grid from the position
+		AnnounceGrid bool   `yaml:"announce_grid"` // send a heartbeat when the grid changes
+	} `yaml:"gps"`
+
+	// TimeSync checks the system clock, which JS8 needs right to about two seconds
+	TimeSync struct {
+		Enabled      bool    `yaml:"enabled"`       // measure the clock offset
+		Source       string  `yaml:"source"`        // auto, chrony, ntp or gps
+		Server       string  `yaml:"server"`        // NTP server, empty uses pool.ntp.org
+		Interval     int     `yaml:"interval"`      // minutes between checks
+		MaxOffset    float64 `yaml:"max_offset"`    // seconds off before heartbeats stop
+		AdjustDecode bool    `yaml:"adjust_decode"` // time decodes by the measured offset
+	} `yaml:"time_sync"`
+
+	// Forms are structured messages, such as ICS-213, sent as data frames
+	Forms struct {
+		Templates []FormTemplate `yaml:"templates,omitempty"` // templates besides the built-in ones
+	} `yaml:"forms"`
+
+	// Files sends and receives small files as transfer frames, resending
+	// the frames the receiver missed
+	Files struct {
+		Enabled     bool `yaml:"enabled"`      // send files, and accept and answer transfers to this station
+		MaxSize     int  `yaml:"max_size"`     // largest file sent or accepted, in bytes
+		Retries     int  `yaml:"retries"`      // rounds of resending missing frames before a transfer fails
+		PollTimeout int  `yaml:"poll_timeout"` // seconds to wait for the receiver to answer a poll
+	} `yaml:"files"`
+
+	// ARQ opens connected-mode sessions with other js8d stations, carrying
+	// messages as session frames and resending the frames the other side
+	// missed
+	ARQ struct {
+		Enabled bool `yaml:"enabled"` // open sessions, and accept connects to this station
+		Window  int  `yaml:"window"`  // data frames sent before each poll
+		Timeout int  `yaml:"timeout"` // seconds to wait for an answer to a connect, poll or disconnect
+		Retries int  `yaml:"retries"` // unanswered connects, polls or disconnects before a session is dropped
+		Idle    int  `yaml:"idle"`    // minutes without a frame heard or sent before a session is disconnected
+	} `yaml:"arq"`
+
+	// Spots forwards every decode to webhooks for outside aggregation
+	Spots struct {
+		Webhooks      []Webhook `yaml:"webhooks,omitempty"`
+		BatchSize     int       `yaml:"batch_size"`     // decodes per POST at most
+		BatchInterval int       `yaml:"batch_interval"` // seconds a decode waits for others to share its POST
+		Retries       int       `yaml:"retries"`        // resends of a failed POST, -1 for none
+	} `yaml:"spots"`
+
+	// Triggers call webhooks on station events for home automation
+	Triggers struct {
+		Hooks    []Trigger `yaml:"hooks,omitempty"`
+		SWRAlarm float64   `yaml:"swr_alarm"` // SWR during TX that fires swr_alarm hooks
+	} `yaml:"triggers"`
+
 	Logging struct {
 		Level       string `yaml:"level"`        // debug, info, warn, error
 		File        string `yaml:"file"`         // log file path
@@ -85,17 +397,89 @@ type Config struct {
 		Compress    bool   `yaml:"compress"`     // compress old log files
 		Console     bool   `yaml:"console"`      // also log to console/stdout
 		Structured  bool   `yaml:"structured"`   // use structured JSON logging
+
+		// Levels overrides level for single components, e.g. dsp: debug
+		Levels map[string]string `yaml:"levels,omitempty"`
 	} `yaml:"logging"`
 
+	// Antenna selects an antenna port for each band on a switch driven by
+	// GPIO pins or a USB relay board, following the dial frequency
+	Antenna struct {
+		Switch  string        `yaml:"switch"`          // gpio or relay, empty for no antenna switch
+		Device  string        `yaml:"device"`          // relay board serial port, e.g. /dev/ttyUSB0
+		Default string        `yaml:"default"`         // port for bands no port lists, empty leaves the switch alone
+		Ports   []AntennaPort `yaml:"ports,omitempty"` // antennas and the bands they are used on
+	} `yaml:"antenna"`
+
+	// Sensors samples battery voltage and temperature, for nodes running off
+	// a battery or solar panel
+	Sensors struct {
+		Enabled            bool     `yaml:"enabled"`                       // sample the sensors
+		Interval           int      `yaml:"interval"`                      // seconds between samples
+		I2CBus             int      `yaml:"i2c_bus"`                       // /dev/i2c-<bus> the INA219 is on
+		INA219Address      int      `yaml:"ina219_address"`                // INA219 I2C address, 0 for none
+		ShuntOhms          float64  `yaml:"shunt_ohms"`                    // INA219 shunt resistor
+		Temperature        bool     `yaml:"temperature"`                   // read DS18B20 sensors over 1-wire
+		TemperatureSensors []string `yaml:"temperature_sensors,omitempty"` // 1-wire IDs, empty reads every DS18B20
+		MinVoltage         float64  `yaml:"min_voltage"`                   // volts below which TX stops, 0 never stops it
+	} `yaml:"sensors"`
+
+	// Power switches to low power mode during set hours or on request, to
+	// stretch a battery through the night
+	Power struct {
+		LowPowerHours    []string `yaml:"low_power_hours,omitempty"` // local time windows such as 22:00-06:00
+		RXOnly           bool     `yaml:"rx_only"`                   // no TX in low power mode
+		DecodeLevel      int      `yaml:"decode_level"`              // decode level in low power mode, 0 leaves decoding alone
+		DisableOLED      bool     `yaml:"disable_oled"`              // blank the OLED in low power mode
+		DisableWaterfall bool     `yaml:"disable_waterfall"`         // stop the web spectrum and waterfall in low power mode
+	} `yaml:"power"`
+
 	Hardware struct {
 		PTTGPIOPin     int  `yaml:"ptt_gpio_pin"`
 		StatusLEDPin   int  `yaml:"status_led_pin"`
+		AmpKeying      bool    `yaml:"amp_keying"`   // key an amplifier from amp_gpio_pin around each transmission
+		AmpGPIOPin     int     `yaml:"amp_gpio_pin"`
+		AmpLead        float64 `yaml:"amp_lead"`     // seconds the amplifier is keyed before the rig
+		AmpTail        float64 `yaml:"amp_tail"`     // seconds the amplifier stays keyed after the rig
 		EnableGPIO     bool `yaml:"enable_gpio"`
 		EnableOLED     bool `yaml:"enable_oled"`
 		OLEDI2CAddress int  `yaml:"oled_i2c_address"`
 		OLEDWidth      int  `yaml:"oled_width"`
 		OLEDHeight     int  `yaml:"oled_height"`
 	} `yaml:"hardware"`
+
+	// Secrets unlocks the encrypted file that "secret:<name>" settings
+	// are read from
+	Secrets struct {
+		File           string `yaml:"file"`            // encrypted secrets file
+		PassphraseFile string `yaml:"passphrase_file"` // file holding the passphrase, else $JS8D_SECRETS_PASSPHRASE
+	} `yaml:"secrets"`
+
+	// Aggregator merges the status and messages of other js8d daemons into
+	// one dashboard when js8d runs with -aggregate
+	Aggregator struct {
+		Nodes        []Node `yaml:"nodes,omitempty"`
+		PollInterval int    `yaml:"poll_interval"` // seconds between polls of each node
+	} `yaml:"aggregator,omitempty"`
+
+	// Instances runs several engines (rigs, sound cards, sockets) in one
+	// daemon. Each entry overrides sections of the settings above.
+	Instances []Instance `yaml:"instances,omitempty"`
+
+	// Profiles are named variations of the station, radio, audio, DSP and
+	// hardware settings that can be switched between at runtime
+	Profiles []Profile `yaml:"profiles,omitempty"`
+
+	// Profile names the profile applied at startup, or the one a
+	// configuration returned by ProfileConfig has applied
+	Profile string `yaml:"profile,omitempty"`
+
+	// Instance is the name of the engine instance this configuration is for,
+	// set by InstanceConfigs
+	Instance string `yaml:"-"`
+
+	// secrets holds the unlocked secrets file once a setting refers to it
+	secrets map[string]string
 }
 
 // LoadConfig loads configuration from a YAML file
@@ -105,6 +489,10 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	if err := checkKeys(data); err != nil {
+		return nil, err
+	}
+
 	var config Config
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
@@ -120,12 +508,30 @@ func LoadConfig(path string) (*Config, error) {
 	if config.Audio.InputChannels == "" {
 		config.Audio.InputChannels = "mono"
 	}
+	if config.Audio.InputChannel == "" {
+		config.Audio.InputChannel = "left"
+	}
 	if config.Audio.OutputChannels == "" {
 		config.Audio.OutputChannels = "mono"
 	}
+	if config.Audio.OutputChannel == "" {
+		config.Audio.OutputChannel = "both"
+	}
+	if config.Audio.ClipAlarmSeconds == 0 {
+		config.Audio.ClipAlarmSeconds = 5
+	}
+	if config.Audio.DeadInputMinutes == 0 {
+		config.Audio.DeadInputMinutes = 5
+	}
+	if config.Audio.DeadInputThreshold == 0 {
+		config.Audio.DeadInputThreshold = -80.0
+	}
 	if config.Audio.NotificationDevice == "" {
 		config.Audio.NotificationDevice = "Built-in Output"
 	}
+	if config.Radio.Backend == "" {
+		config.Radio.Backend = "hamlib"
+	}
 	if config.Radio.Model == "" {
 		config.Radio.Model = "10001" // QRP Labs QDX
 	}
@@ -165,17 +571,293 @@ func LoadConfig(path string) (*Config, error) {
 	if config.Radio.TxDelay == 0 {
 		config.Radio.TxDelay = 0.2
 	}
+	if config.Radio.VOXLeader == 0 {
+		config.Radio.VOXLeader = 0.1
+	}
+	if config.Radio.TuneSeconds == 0 {
+		config.Radio.TuneSeconds = 3
+	}
+	if config.DSP.LowCut == 0 {
+		config.DSP.LowCut = 300
+	}
+	if config.DSP.HighCut == 0 {
+		config.DSP.HighCut = 2700
+	}
+	if config.DSP.BlankerThreshold == 0 {
+		config.DSP.BlankerThreshold = 8.0
+	}
+	if config.DSP.DecodeBudget == 0 {
+		config.DSP.DecodeBudget = 0.5
+	}
+	if config.DSP.Decoder == "" {
+		config.DSP.Decoder = "auto"
+	}
+	if config.DSP.DecodeLow == 0 && config.DSP.DecodeHigh == 0 {
+		config.DSP.DecodeLow = 200
+		config.DSP.DecodeHigh = 2900
+	}
+	if config.DSP.FFTBackend == "" {
+		config.DSP.FFTBackend = "auto"
+	}
 	if config.Web.Port == 0 {
 		config.Web.Port = 8080
 	}
 	if config.Web.BindAddress == "" {
 		config.Web.BindAddress = "0.0.0.0"
 	}
+	if config.Web.Language == "" {
+		config.Web.Language = i18n.Default
+	}
+	if config.API.UnixSocket == "" {
+		config.API.UnixSocket = DefaultUnixSocket
+	}
+	if config.Storage.Backend == "" {
+		config.Storage.Backend = "sqlite"
+	}
 	if config.Storage.MaxMessages == 0 {
 		config.Storage.MaxMessages = 10000
 	}
+	if config.Storage.StatsMinuteHours == 0 {
+		config.Storage.StatsMinuteHours = 48
+	}
+	if config.Storage.StatsHourDays == 0 {
+		config.Storage.StatsHourDays = 365
+	}
+	if config.Storage.SNRHistoryDays == 0 {
+		config.Storage.SNRHistoryDays = 30
+	}
+	if config.Storage.SnippetSeconds == 0 {
+		config.Storage.SnippetSeconds = 5
+	}
+	if config.Storage.SnippetQuota == 0 {
+		config.Storage.SnippetQuota = 100
+	}
+	if config.Storage.RecordingCodec == "" {
+		config.Storage.RecordingCodec = "auto"
+	}
+	if config.Storage.RecordingBitrate == 0 {
+		config.Storage.RecordingBitrate = 24000
+	}
+	if config.Storage.RecordingMinutes == 0 {
+		config.Storage.RecordingMinutes = 60
+	}
+	if config.Storage.RecordingQuota == 0 {
+		config.Storage.RecordingQuota = 2000
+	}
+	if config.Storage.CheckInterval == 0 {
+		config.Storage.CheckInterval = 24
+	}
+	if config.Storage.BackupKeep == 0 {
+		config.Storage.BackupKeep = 3
+	}
+	if config.Messages.AckTimeout == 0 {
+		config.Messages.AckTimeout = 90
+	}
+	if config.Messages.SessionGap == 0 {
+		config.Messages.SessionGap = 30
+	}
+	if config.Messages.Escalate == "" {
+		config.Messages.Escalate = DefaultEscalate
+	}
+	if config.Messages.EscalateAfter == 0 {
+		config.Messages.EscalateAfter = DefaultEscalateAfter
+	}
+	if config.Messages.EscalateOffset == 0 {
+		config.Messages.EscalateOffset = DefaultEscalateOffset
+	}
+	if config.TX.Offset == 0 {
+		config.TX.Offset = DefaultTXOffset
+	}
+	if config.TX.Busy == "" {
+		config.TX.Busy = BusyWait
+	}
+	if config.TX.OutOfBand == "" {
+		config.TX.OutOfBand = OutOfBandWarn
+	}
+	if config.TX.SelfDecode == "" {
+		config.TX.SelfDecode = SelfDecodeBlank
+	}
+	if config.Station.Region == 0 {
+		config.Station.Region = DefaultRegion
+	}
+	if config.TX.BusyMargin == 0 {
+		config.TX.BusyMargin = DefaultBusyMargin
+	}
+	if config.TX.BusyLevel == 0 {
+		config.TX.BusyLevel = DefaultBusyLevel
+	}
+	if config.TX.BusyWait == 0 {
+		config.TX.BusyWait = DefaultBusyWait
+	}
+	if config.TX.FindLow == 0 && config.TX.FindHigh == 0 {
+		config.TX.FindLow, config.TX.FindHigh = DefaultFindLow, DefaultFindHigh
+	}
+	if config.QSO.Timeout == 0 {
+		config.QSO.Timeout = DefaultQSOTimeout
+	}
+	if len(config.QSO.Steps) == 0 {
+		config.QSO.Steps = append([]string(nil), DefaultQSOSteps...)
+	}
+	if config.Net.Duration == 0 {
+		config.Net.Duration = DefaultNetDuration
+	}
+	if config.Net.Preamble == "" {
+		config.Net.Preamble = DefaultNetPreamble
+	}
+	if config.Net.Closing == "" {
+		config.Net.Closing = DefaultNetClosing
+	}
+	if config.Net.Ack == "" {
+		config.Net.Ack = DefaultNetAck
+	}
+	if config.Beacon.Interval == 0 {
+		config.Beacon.Interval = DefaultBeaconInterval
+	}
+	if config.Beacon.Jitter == 0 {
+		config.Beacon.Jitter = DefaultBeaconJitter
+	}
+	if config.Beacon.Text == "" {
+		config.Beacon.Text = DefaultBeaconText
+	}
+	if config.Beacon.Quiet == 0 {
+		config.Beacon.Quiet = DefaultBeaconQuiet
+	}
+	if config.Beacon.IDText == "" {
+		config.Beacon.IDText = DefaultBeaconIDText
+	}
+	if config.Submode.Default == "" {
+		config.Submode.Default = DefaultSubmode
+	}
+	if config.Submode.Window == 0 {
+		config.Submode.Window = DefaultSubmodeWindow
+	}
+	if config.Submode.MinDecodes == 0 {
+		config.Submode.MinDecodes = DefaultSubmodeMinDecodes
+	}
+	if config.Submode.Turbo == 0 && config.Submode.Fast == 0 && config.Submode.Normal == 0 {
+		config.Submode.Turbo = DefaultSubmodeTurbo
+		config.Submode.Fast = DefaultSubmodeFast
+		config.Submode.Normal = DefaultSubmodeNormal
+	}
+	if config.Telemetry.Interval == 0 {
+		config.Telemetry.Interval = DefaultTelemetryInterval
+	}
+	if len(config.Telemetry.Fields) == 0 {
+		config.Telemetry.Fields = append([]string{}, DefaultTelemetryFields...)
+	}
+	if config.Telemetry.KeepDays == 0 {
+		config.Telemetry.KeepDays = DefaultTelemetryKeepDays
+	}
+	if config.Answer.PerHour == 0 {
+		config.Answer.PerHour = DefaultAnswerPerHour
+	}
+	if config.Answer.Reply == "" {
+		config.Answer.Reply = DefaultAnswerReply
+	}
+	if config.RateLimit.CallsignPerHour == 0 {
+		config.RateLimit.CallsignPerHour = DefaultRateCallsignPerHour
+	}
+	if config.RateLimit.CallsignBurst == 0 {
+		config.RateLimit.CallsignBurst = DefaultRateCallsignBurst
+	}
+	if config.RateLimit.GlobalPerHour == 0 {
+		config.RateLimit.GlobalPerHour = DefaultRateGlobalPerHour
+	}
+	if config.RateLimit.GlobalBurst == 0 {
+		config.RateLimit.GlobalBurst = DefaultRateGlobalBurst
+	}
+	if config.Lookup.CacheDays == 0 {
+		config.Lookup.CacheDays = DefaultLookupCacheDays
+	}
+	if config.Propagation.Refresh == 0 {
+		config.Propagation.Refresh = DefaultPropagationRefresh
+	}
+	if config.GPS.Address == "" {
+		config.GPS.Address = "localhost:2947"
+	}
+	if config.TimeSync.Source == "" {
+		config.TimeSync.Source = "auto"
+	}
+	if config.Sensors.Interval == 0 {
+		config.Sensors.Interval = DefaultSensorInterval
+	}
+	if config.Sensors.I2CBus == 0 {
+		config.Sensors.I2CBus = DefaultSensorI2CBus
+	}
+	if config.Sensors.ShuntOhms == 0 {
+		config.Sensors.ShuntOhms = DefaultShuntOhms
+	}
+	if config.TimeSync.Interval == 0 {
+		config.TimeSync.Interval = DefaultTimeSyncInterval
+	}
+	if config.TimeSync.MaxOffset == 0 {
+		config.TimeSync.MaxOffset = DefaultTimeSyncMaxOffset
+	}
+	if config.Files.MaxSize == 0 {
+		config.Files.MaxSize = DefaultFileMaxSize
+	}
+	if config.Files.Retries == 0 {
+		config.Files.Retries = DefaultFileRetries
+	}
+	if config.Files.PollTimeout == 0 {
+		config.Files.PollTimeout = DefaultFilePollTimeout
+	}
+	if config.ARQ.Window == 0 {
+		config.ARQ.Window = DefaultARQWindow
+	}
+	if config.ARQ.Timeout == 0 {
+		config.ARQ.Timeout = DefaultARQTimeout
+	}
+	if config.ARQ.Retries == 0 {
+		config.ARQ.Retries = DefaultARQRetries
+	}
+	if config.ARQ.Idle == 0 {
+		config.ARQ.Idle = DefaultARQIdle
+	}
+	if config.Spots.BatchSize == 0 {
+		config.Spots.BatchSize = DefaultSpotBatchSize
+	}
+	if config.Spots.BatchInterval == 0 {
+		config.Spots.BatchInterval = DefaultSpotBatchInterval
+	}
+	if config.Spots.Retries == 0 {
+		config.Spots.Retries = DefaultSpotRetries
+	}
+	if config.Triggers.SWRAlarm == 0 {
+		config.Triggers.SWRAlarm = DefaultSWRAlarm
+	}
+	if config.Hardware.PTTGPIOPin == 0 {
+		config.Hardware.PTTGPIOPin = 18
+	}
+	if config.Hardware.AmpGPIOPin == 0 {
+		config.Hardware.AmpGPIOPin = 23
+	}
+	if config.Hardware.AmpLead == 0 {
+		config.Hardware.AmpLead = 0.05
+	}
+	if config.Hardware.AmpTail == 0 {
+		config.Hardware.AmpTail = 0.05
+	}
+	if config.Hardware.StatusLEDPin == 0 {
+		config.Hardware.StatusLEDPin = 24
+	}
+	if config.Hardware.OLEDI2CAddress == 0 {
+		config.Hardware.OLEDI2CAddress = 0x3C
+	}
+	if config.Hardware.OLEDWidth == 0 {
+		config.Hardware.OLEDWidth = 128
+	}
+	if config.Hardware.OLEDHeight == 0 {
+		config.Hardware.OLEDHeight = 64
+	}
+	if config.Aggregator.PollInterval == 0 {
+		config.Aggregator.PollInterval = DefaultAggregatorPollInterval
+	}
 
 	// Set logging defaults
+	if config.Auth.SocketRole == "" {
+		config.Auth.SocketRole = "admin"
+	}
 	if config.Logging.Level == "" {
 		config.Logging.Level = "info"
 	}
@@ -193,6 +875,11 @@ func LoadConfig(path string) (*Config, error) {
 	// Console and Compress default to false
 	// Structured defaults to false
 
+	// Read credentials from the environment and the secrets file
+	if err := config.resolveSecrets(); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
 
@@ -204,11 +891,14 @@ func (c *Config) Validate() error {
 	if c.Station.Grid == "" {
 		return fmt.Errorf("station grid is required")
 	}
+	if err := c.validateStation(); err != nil {
+		return err
+	}
 	// Check if radio device is required
-	if c.Radio.UseHamlib && c.Radio.Device == "" {
+	if (c.Radio.UseHamlib || strings.EqualFold(c.Radio.Backend, "native")) && c.Radio.Device == "" {
 		// Dummy rig (model "1") doesn't require a device
 		if c.Radio.Model != "1" {
-			return fmt.Errorf("radio device is required when using Hamlib (except for dummy rig)")
+			return fmt.Errorf("radio device is required when using Hamlib or native CAT (except for dummy rig)")
 		}
 	}
 	if c.Audio.InputDevice == "" {
@@ -217,115 +907,184 @@ func (c *Config) Validate() error {
 	if c.Audio.OutputDevice == "" {
 		c.Audio.OutputDevice = "default"
 	}
+	switch c.Audio.InputChannels {
+	case "", "mono", "stereo", "stereo-split":
+	default:
+		return fmt.Errorf("audio input_channels must be mono, stereo or stereo-split, got %q", c.Audio.InputChannels)
+	}
+	if len(c.Audio.InputChannelNames) > 2 {
+		return fmt.Errorf("audio input_channel_names has %d entries, stereo-split has only 2 channels", len(c.Audio.InputChannelNames))
+	}
+	if err := c.validateRanges(); err != nil {
+		return err
+	}
+	if err := c.validateDSP(); err != nil {
+		return err
+	}
+	if err := c.validateAuth(); err != nil {
+		return err
+	}
+	if err := c.validateAggregator(); err != nil {
+		return err
+	}
+	if err := c.validateLookup(); err != nil {
+		return err
+	}
+	if err := c.validateTX(); err != nil {
+		return err
+	}
+	if err := c.validateQSO(); err != nil {
+		return err
+	}
+	if err := c.validateNet(); err != nil {
+		return err
+	}
+	if err := c.validateBeacon(); err != nil {
+		return err
+	}
+	if err := c.validateTelemetry(); err != nil {
+		return err
+	}
+	if err := c.validateEscalation(); err != nil {
+		return err
+	}
+	if err := c.validateSubmode(); err != nil {
+		return err
+	}
+	if err := c.validateAnswer(); err != nil {
+		return err
+	}
+	if err := c.validateRateLimit(); err != nil {
+		return err
+	}
+	if err := c.validateQSL(); err != nil {
+		return err
+	}
+	if err := c.validateLogBroadcast(); err != nil {
+		return err
+	}
+	if err := c.validatePropagation(); err != nil {
+		return err
+	}
+	if err := c.validateGPS(); err != nil {
+		return err
+	}
+	if err := c.validateTimeSync(); err != nil {
+		return err
+	}
+	if err := c.validateForms(); err != nil {
+		return err
+	}
+	if err := c.validateFiles(); err != nil {
+		return err
+	}
+	if err := c.validateARQ(); err != nil {
+		return err
+	}
+	if err := c.validateSpots(); err != nil {
+		return err
+	}
+	if err := c.validateTriggers(); err != nil {
+		return err
+	}
+	if err := c.validateAudioDevices(); err != nil {
+		return err
+	}
+	if err := c.validateAntenna(); err != nil {
+		return err
+	}
+	if err := c.validateSensors(); err != nil {
+		return err
+	}
+	if err := c.validatePower(); err != nil {
+		return err
+	}
+	if err := c.validateProfiles(); err != nil {
+		return err
+	}
 	return nil
 }
 
-// GetRadioName returns a friendly name for the radio model
-func (c *Config) GetRadioName() string {
-	switch c.Radio.Model {
-	case "1":
-		return "Hamlib Dummy"
-	case "2":
-		return "Yaesu FT-847"
-	case "120":
-		return "Yaesu FT-817"
-	case "122":
-		return "Yaesu FT-857"
-	case "135":
-		return "Yaesu FT-891"
-	case "1035":
-		return "Yaesu FT-991"
-	case "1001":
-		return "Yaesu FT-1000"
-	case "1007":
-		return "Yaesu FT-1000MP"
-	case "1014":
-		return "Yaesu FT-2000"
-	case "1020":
-		return "Yaesu FT-450"
-	case "1021":
-		return "Yaesu FT-950"
-	case "1024":
-		return "Yaesu FT-5000"
-	case "1027":
-		return "Yaesu FT-9000"
-	case "201":
-		return "Kenwood TS-50"
-	case "202":
-		return "Kenwood TS-440"
-	case "203":
-		return "Kenwood TS-450"
-	case "204":
-		return "Kenwood TS-570"
-	case "205":
-		return "Kenwood TS-680"
-	case "206":
-		return "Kenwood TS-690"
-	case "207":
-		return "Kenwood TS-711"
-	case "208":
-		return "Kenwood TS-790"
-	case "209":
-		return "Kenwood TS-811"
-	case "210":
-		return "Kenwood TS-850"
-	case "211":
-		return "Kenwood TS-870"
-	case "212":
-		return "Kenwood TS-940"
-	case "213":
-		return "Kenwood TS-950"
-	case "214":
-		return "Kenwood TS-2000"
-	case "229":
-		return "Kenwood TS-480"
-	case "230":
-		return "Kenwood TS-590"
-	case "231":
-		return "Kenwood TS-590SG"
-	case "232":
-		return "Kenwood TS-990"
-	case "235":
-		return "Kenwood TS-890"
-	case "301":
-		return "Icom IC-706"
-	case "306":
-		return "Icom IC-7000"
-	case "307":
-		return "Icom IC-7100"
-	case "311":
-		return "Icom IC-746"
-	case "315":
-		return "Icom IC-756"
-	case "318":
-		return "Icom IC-7200"
-	case "335":
-		return "Icom IC-7300"
-	case "360":
-		return "Icom IC-9100"
-	case "362":
-		return "Icom IC-7410"
-	case "363":
-		return "Icom IC-7700"
-	case "364":
-		return "Icom IC-7800"
-	case "365":
-		return "Icom IC-7600"
-	case "368":
-		return "Icom IC-7851"
-	case "2028":
-		return "Elecraft K3/KX3"
-	case "2029":
-		return "Elecraft KX2"
-	case "2030":
-		return "Elecraft K4"
-	case "10001":
-		return "QRP Labs QDX"
-	case "10002":
-		return "QRP Labs QMX"
-	case "":
-		return "No Radio"
+// validateStation normalizes the callsign and grid and checks they can be
+// sent in JS8 frames
+func (c *Config) validateStation() error {
+	c.Station.Callsign = strings.ToUpper(strings.TrimSpace(c.Station.Callsign))
+	callsign := c.Station.Callsign
+	if callsign == placeholderCallsign {
+		return fmt.Errorf("station callsign is still %s, set station.callsign to your own callsign", placeholderCallsign)
+	}
+	if !callsignPattern.MatchString(callsign) || len(callsign)-strings.Count(callsign, "/") > 9 {
+		return fmt.Errorf("station callsign %q is not a valid callsign (e.g. K3DEP, VE3/K3DEP, K3DEP/P)", callsign)
+	}
+
+	grid := strings.TrimSpace(c.Station.Grid)
+	if len(grid) > 4 {
+		grid = strings.ToUpper(grid[:4]) + strings.ToLower(grid[4:])
+	} else {
+		grid = strings.ToUpper(grid)
+	}
+	if !gridPattern.MatchString(strings.ToUpper(grid)) {
+		return fmt.Errorf("station grid %q must be a 4 or 6 character Maidenhead locator", c.Station.Grid)
+	}
+	c.Station.Grid = grid
+
+	if c.Station.Region != 0 {
+		if err := inRange("station region", c.Station.Region, 1, 3); err != nil {
+			return err
+		}
+	}
+	if c.Station.License != "" {
+		if err := oneOf("station license", c.Station.License, protocol.LicenseClasses...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateDSP checks the RX pre-filter settings against the sample rate
+func (c *Config) validateDSP() error {
+	sampleRate := c.Audio.SampleRate
+	if sampleRate == 0 {
+		sampleRate = 48000
+	}
+	nyquist := float64(sampleRate) / 2
+
+	if c.DSP.LowCut != 0 || c.DSP.HighCut != 0 {
+		if c.DSP.LowCut <= 0 || c.DSP.LowCut >= c.DSP.HighCut {
+			return fmt.Errorf("dsp low_cut (%.0f Hz) must be above 0 and below high_cut (%.0f Hz)", c.DSP.LowCut, c.DSP.HighCut)
+		}
+		if c.DSP.HighCut >= nyquist {
+			return fmt.Errorf("dsp high_cut (%.0f Hz) must be below half the sample rate (%.0f Hz)", c.DSP.HighCut, nyquist)
+		}
+	}
+	for _, freq := range c.DSP.NotchFrequencies {
+		if freq <= 0 || freq >= nyquist {
+			return fmt.Errorf("dsp notch frequency %.0f Hz must be between 0 and %.0f Hz", freq, nyquist)
+		}
+	}
+	if c.DSP.BlankerThreshold != 0 && c.DSP.BlankerThreshold <= 1 {
+		return fmt.Errorf("dsp blanker_threshold (%.1f) must be greater than 1", c.DSP.BlankerThreshold)
+	}
+	if c.DSP.DecodeLow != 0 || c.DSP.DecodeHigh != 0 {
+		if err := inRange("dsp decode_low", c.DSP.DecodeLow, 200, 2850); err != nil {
+			return err
+		}
+		if err := inRange("dsp decode_high", c.DSP.DecodeHigh, c.DSP.DecodeLow+50, 2900); err != nil {
+			return err
+		}
+	}
+	switch c.DSP.Decoder {
+	case "", "auto", "native", "go":
+	default:
+		return fmt.Errorf("dsp decoder must be auto, native or go, got %q", c.DSP.Decoder)
+	}
+	switch c.DSP.FFTBackend {
+	case "", "auto", "go-dsp", "radix2", "neon":
 	default:
-		return fmt.Sprintf("Radio Model %s", c.Radio.Model)
+		return fmt.Errorf("dsp fft_backend must be auto, go-dsp, radix2 or neon, got %q", c.DSP.FFTBackend)
+	}
+	if c.DSP.DecodeBudget < 0 || c.DSP.DecodeBudget > 1 {
+		return fmt.Errorf("dsp decode_budget (%.2f) must be between 0 and 1", c.DSP.DecodeBudget)
 	}
+	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -128,6 +129,10 @@ station:
 		if config.Storage.MaxMessages != 10000 {
 			t.Errorf("Expected default max messages 10000, got %d", config.Storage.MaxMessages)
 		}
+		if config.Storage.StatsMinuteHours != 48 || config.Storage.StatsHourDays != 365 {
+			t.Errorf("Expected default stats retention 48 hours and 365 days, got %d and %d",
+				config.Storage.StatsMinuteHours, config.Storage.StatsHourDays)
+		}
 		if config.Logging.Level != "info" {
 			t.Errorf("Expected default log level info, got %s", config.Logging.Level)
 		}
@@ -196,12 +201,16 @@ func TestValidate(t *testing.T) {
 			Station: struct {
 				Callsign string `yaml:"callsign"`
 				Grid     string `yaml:"grid"`
+				Region   int    `yaml:"region"`
+				License  string `yaml:"license"`
 			}{
 				Callsign: "K3DEP",
 				Grid:     "FN20",
 			},
 			Radio: struct {
 				UseHamlib       bool    `yaml:"use_hamlib"`
+				Backend         string  `yaml:"backend"`
+				CATProtocol     string  `yaml:"cat_protocol"`
 				Model           string  `yaml:"model"`
 				PollInterval    int     `yaml:"poll_interval"`
 				Device          string  `yaml:"device"`
@@ -220,6 +229,14 @@ func TestValidate(t *testing.T) {
 				SplitOperation  string  `yaml:"split_operation"`
 				PTTCommand      string  `yaml:"ptt_command"`
 				TxDelay         float64 `yaml:"tx_delay"`
+				TxTail         float64 `yaml:"tx_tail"`
+				VOXLeader      float64 `yaml:"vox_leader"`
+				CalibrationPPM  float64 `yaml:"calibration_ppm"`
+				DriftEstimate   bool    `yaml:"drift_estimate"`
+				TransverterOffset int64 `yaml:"transverter_offset"`
+				TuneBeforeTX    string  `yaml:"tune_before_tx"`
+				TuneSeconds     float64 `yaml:"tune_seconds"`
+				TunePower       int     `yaml:"tune_power"`
 			}{
 				UseHamlib: true,
 				Model:     "2028",
@@ -238,6 +255,8 @@ func TestValidate(t *testing.T) {
 			Station: struct {
 				Callsign string `yaml:"callsign"`
 				Grid     string `yaml:"grid"`
+				Region   int    `yaml:"region"`
+				License  string `yaml:"license"`
 			}{
 				Grid: "FN20",
 			},
@@ -257,6 +276,8 @@ func TestValidate(t *testing.T) {
 			Station: struct {
 				Callsign string `yaml:"callsign"`
 				Grid     string `yaml:"grid"`
+				Region   int    `yaml:"region"`
+				License  string `yaml:"license"`
 			}{
 				Callsign: "K3DEP",
 			},
@@ -276,12 +297,16 @@ func TestValidate(t *testing.T) {
 			Station: struct {
 				Callsign string `yaml:"callsign"`
 				Grid     string `yaml:"grid"`
+				Region   int    `yaml:"region"`
+				License  string `yaml:"license"`
 			}{
 				Callsign: "K3DEP",
 				Grid:     "FN20",
 			},
 			Radio: struct {
 				UseHamlib       bool    `yaml:"use_hamlib"`
+				Backend         string  `yaml:"backend"`
+				CATProtocol     string  `yaml:"cat_protocol"`
 				Model           string  `yaml:"model"`
 				PollInterval    int     `yaml:"poll_interval"`
 				Device          string  `yaml:"device"`
@@ -300,6 +325,14 @@ func TestValidate(t *testing.T) {
 				SplitOperation  string  `yaml:"split_operation"`
 				PTTCommand      string  `yaml:"ptt_command"`
 				TxDelay         float64 `yaml:"tx_delay"`
+				TxTail         float64 `yaml:"tx_tail"`
+				VOXLeader      float64 `yaml:"vox_leader"`
+				CalibrationPPM  float64 `yaml:"calibration_ppm"`
+				DriftEstimate   bool    `yaml:"drift_estimate"`
+				TransverterOffset int64 `yaml:"transverter_offset"`
+				TuneBeforeTX    string  `yaml:"tune_before_tx"`
+				TuneSeconds     float64 `yaml:"tune_seconds"`
+				TunePower       int     `yaml:"tune_power"`
 			}{
 				UseHamlib: true,
 				Model:     "2028", // Not dummy rig
@@ -321,12 +354,16 @@ func TestValidate(t *testing.T) {
 			Station: struct {
 				Callsign string `yaml:"callsign"`
 				Grid     string `yaml:"grid"`
+				Region   int    `yaml:"region"`
+				License  string `yaml:"license"`
 			}{
 				Callsign: "K3DEP",
 				Grid:     "FN20",
 			},
 			Radio: struct {
 				UseHamlib       bool    `yaml:"use_hamlib"`
+				Backend         string  `yaml:"backend"`
+				CATProtocol     string  `yaml:"cat_protocol"`
 				Model           string  `yaml:"model"`
 				PollInterval    int     `yaml:"poll_interval"`
 				Device          string  `yaml:"device"`
@@ -345,6 +382,14 @@ func TestValidate(t *testing.T) {
 				SplitOperation  string  `yaml:"split_operation"`
 				PTTCommand      string  `yaml:"ptt_command"`
 				TxDelay         float64 `yaml:"tx_delay"`
+				TxTail         float64 `yaml:"tx_tail"`
+				VOXLeader      float64 `yaml:"vox_leader"`
+				CalibrationPPM  float64 `yaml:"calibration_ppm"`
+				DriftEstimate   bool    `yaml:"drift_estimate"`
+				TransverterOffset int64 `yaml:"transverter_offset"`
+				TuneBeforeTX    string  `yaml:"tune_before_tx"`
+				TuneSeconds     float64 `yaml:"tune_seconds"`
+				TunePower       int     `yaml:"tune_power"`
 			}{
 				UseHamlib: true,
 				Model:     "1", // Dummy rig
@@ -363,21 +408,33 @@ func TestValidate(t *testing.T) {
 			Station: struct {
 				Callsign string `yaml:"callsign"`
 				Grid     string `yaml:"grid"`
+				Region   int    `yaml:"region"`
+				License  string `yaml:"license"`
 			}{
 				Callsign: "K3DEP",
 				Grid:     "FN20",
 			},
 			Audio: struct {
-				InputDevice        string `yaml:"input_device"`
-				InputChannels      string `yaml:"input_channels"`
-				OutputDevice       string `yaml:"output_device"`
-				OutputChannels     string `yaml:"output_channels"`
-				NotificationDevice string `yaml:"notification_device"`
-				SampleRate         int    `yaml:"sample_rate"`
-				BufferSize         int    `yaml:"buffer_size"`
-				SaveDirectory      string `yaml:"save_directory"`
-				RememberPowerTx    bool   `yaml:"remember_power_tx"`
-				RememberPowerTune  bool   `yaml:"remember_power_tune"`
+				InputDevice        string   `yaml:"input_device"`
+				InputChannels      string   `yaml:"input_channels"`
+				InputChannelNames  []string `yaml:"input_channel_names"`
+				InputChannel       string   `yaml:"input_channel"`
+				OutputDevice       string   `yaml:"output_device"`
+				OutputChannels     string   `yaml:"output_channels"`
+				OutputChannel      string   `yaml:"output_channel"`
+				NotificationDevice string   `yaml:"notification_device"`
+				SampleRate         int      `yaml:"sample_rate"`
+				BufferSize         int      `yaml:"buffer_size"`
+				HalfDuplex         bool     `yaml:"half_duplex"`
+				InputGain          float64  `yaml:"input_gain"`
+				OutputGain         float64  `yaml:"output_gain"`
+				SaveDirectory      string   `yaml:"save_directory"`
+				RememberPowerTx    bool     `yaml:"remember_power_tx"`
+				RememberPowerTune  bool     `yaml:"remember_power_tune"`
+				ClipAlarmSeconds   int      `yaml:"clip_alarm_seconds"`
+				DeadInputMinutes   int      `yaml:"dead_input_minutes"`
+				DeadInputThreshold float64  `yaml:"dead_input_threshold_db"`
+				Devices            map[string]AudioDeviceSettings `yaml:"devices,omitempty"`
 			}{
 				InputDevice:  "",
 				OutputDevice: "",
@@ -399,58 +456,154 @@ func TestValidate(t *testing.T) {
 	})
 }
 
-func TestGetRadioName(t *testing.T) {
-	testCases := []struct {
-		model    string
-		expected string
+func TestValidateDSP(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(c *Config)
+		want  string
+	}{
+		{"Defaults", func(c *Config) {}, ""},
+		{"Inverted Band", func(c *Config) { c.DSP.LowCut, c.DSP.HighCut = 2700, 300 }, "low_cut"},
+		{"High Cut Above Nyquist", func(c *Config) { c.Audio.SampleRate = 8000; c.DSP.HighCut = 4000 }, "high_cut"},
+		{"Notch Above Nyquist", func(c *Config) { c.DSP.NotchFrequencies = []float64{1000, 30000} }, "notch"},
+		{"Negative Notch", func(c *Config) { c.DSP.NotchFrequencies = []float64{-50} }, "notch"},
+		{"Blanker Threshold Too Low", func(c *Config) { c.DSP.BlankerThreshold = 0.5 }, "blanker_threshold"},
+		{"Unknown Decoder", func(c *Config) { c.DSP.Decoder = "fortran" }, "decoder"},
+		{"Unknown FFT Backend", func(c *Config) { c.DSP.FFTBackend = "cufft" }, "fft_backend"},
+		{"Decode Budget Above One", func(c *Config) { c.DSP.DecodeBudget = 1.5 }, "decode_budget"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{}
+			c.Station.Callsign = "K3DEP"
+			c.Station.Grid = "FN20"
+			c.Audio.SampleRate = 48000
+			c.DSP.LowCut = 300
+			c.DSP.HighCut = 2700
+			c.DSP.BlankerThreshold = 8.0
+			tt.setup(c)
+
+			err := c.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected %s error, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestTimeWindow(t *testing.T) {
+	night, err := ParseTimeWindow("22:00-06:00")
+	if err != nil {
+		t.Fatalf("Failed to parse window: %v", err)
+	}
+	lunch, _ := ParseTimeWindow(" 12:30 - 13:00 ")
+
+	tests := []struct {
+		clock  string
+		window TimeWindow
+		want   bool
+	}{
+		{"23:15", night, true},
+		{"00:00", night, true},
+		{"05:59", night, true},
+		{"06:00", night, false},
+		{"21:59", night, false},
+		{"12:30", lunch, true},
+		{"13:00", lunch, false},
+	}
+	for _, tt := range tests {
+		at, _ := time.Parse("15:04", tt.clock)
+		if got := tt.window.Contains(at); got != tt.want {
+			t.Errorf("Expected %s in %+v to be %t", tt.clock, tt.window, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"22:00", "25:00-06:00", "06:00-06:00"} {
+		if _, err := ParseTimeWindow(bad); err == nil {
+			t.Errorf("Expected an error parsing %q", bad)
+		}
+	}
+}
+
+func TestValidateStation(t *testing.T) {
+	tests := []struct {
+		callsign string
+		grid     string
+		valid    bool
 	}{
-		{"1", "Hamlib Dummy"},
-		{"2028", "Elecraft K3/KX3"},
-		{"10001", "QRP Labs QDX"},
-		{"10002", "QRP Labs QMX"},
-		{"2029", "Elecraft KX2"},
-		{"2030", "Elecraft K4"},
-		{"335", "Icom IC-7300"},
-		{"229", "Kenwood TS-480"},
-		{"120", "Yaesu FT-817"},
-		{"135", "Yaesu FT-891"},
-		{"1035", "Yaesu FT-991"},
-		{"", "No Radio"},
-		{"99999", "Radio Model 99999"},
-		{"unknown", "Radio Model unknown"},
+		{"K3DEP", "FN20", true},
+		{"k3dep", "fn20ab", true},
+		{"VE3/K3DEP", "FN03", true},
+		{"K3DEP/P", "FN20", true},
+		{"KN4CRD/QRP", "EM73", true},
+		{"YOUR_CALLSIGN", "FN20", false},
+		{"K3DEP/", "FN20", false},
+		{"VE3/KN4CRD/QRP", "FN20", false},
+		{"K3DEP", "ZZ99", false},
+		{"K3DEP", "FN2", false},
 	}
 
-	for _, tc := range testCases {
-		t.Run(tc.model, func(t *testing.T) {
-			config := &Config{
-				Radio: struct {
-					UseHamlib       bool    `yaml:"use_hamlib"`
-					Model           string  `yaml:"model"`
-					PollInterval    int     `yaml:"poll_interval"`
-					Device          string  `yaml:"device"`
-					BaudRate        int     `yaml:"baud_rate"`
-					DataBits        string  `yaml:"data_bits"`
-					StopBits        string  `yaml:"stop_bits"`
-					Handshake       string  `yaml:"handshake"`
-					DTR             string  `yaml:"dtr"`
-					RTS             string  `yaml:"rts"`
-					CIVAddress      string  `yaml:"civ_address"`
-					CIVTransceive   bool    `yaml:"civ_transceive"`
-					PTTMethod       string  `yaml:"ptt_method"`
-					PTTPort         string  `yaml:"ptt_port"`
-					Mode            string  `yaml:"mode"`
-					TxAudioSource   string  `yaml:"tx_audio_source"`
-					SplitOperation  string  `yaml:"split_operation"`
-					PTTCommand      string  `yaml:"ptt_command"`
-					TxDelay         float64 `yaml:"tx_delay"`
-				}{
-					Model: tc.model,
-				},
+	for _, tt := range tests {
+		t.Run(tt.callsign+"_"+tt.grid, func(t *testing.T) {
+			c := &Config{}
+			c.Station.Callsign = tt.callsign
+			c.Station.Grid = tt.grid
+
+			err := c.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+			if !tt.valid && (err == nil || !strings.Contains(err.Error(), "station")) {
+				t.Errorf("Expected station error, got: %v", err)
 			}
+		})
+	}
 
-			result := config.GetRadioName()
-			if result != tc.expected {
-				t.Errorf("Expected %s, got %s", tc.expected, result)
+	c := &Config{}
+	c.Station.Callsign = " ve3/k3dep "
+	c.Station.Grid = "fn03ab"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if c.Station.Callsign != "VE3/K3DEP" || c.Station.Grid != "FN03ab" {
+		t.Errorf("Expected VE3/K3DEP FN03ab, got %s %s", c.Station.Callsign, c.Station.Grid)
+	}
+}
+
+func TestValidateInputChannels(t *testing.T) {
+	tests := []struct {
+		channels string
+		names    []string
+		valid    bool
+	}{
+		{"mono", nil, true},
+		{"stereo", nil, true},
+		{"stereo-split", []string{"beverage", "dipole"}, true},
+		{"quad", nil, false},
+		{"stereo-split", []string{"a", "b", "c"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.channels, func(t *testing.T) {
+			c := &Config{}
+			c.Station.Callsign = "K3DEP"
+			c.Station.Grid = "FN20"
+			c.Audio.InputChannels = tt.channels
+			c.Audio.InputChannelNames = tt.names
+
+			err := c.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+			if !tt.valid && (err == nil || !strings.Contains(err.Error(), "input_channel")) {
+				t.Errorf("Expected input channel error, got: %v", err)
 			}
 		})
 	}
@@ -508,9 +661,8 @@ logging:
 		t.Errorf("Expected callsign K3DEP, got %s", config.Station.Callsign)
 	}
 
-	radioName := config.GetRadioName()
-	if radioName != "QRP Labs QMX" {
-		t.Errorf("Expected QRP Labs QMX, got %s", radioName)
+	if config.Radio.Model != "10002" {
+		t.Errorf("Expected model 10002, got %s", config.Radio.Model)
 	}
 
 	// Verify defaults were applied
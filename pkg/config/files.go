@@ -0,0 +1,30 @@
+package config
+
+const (
+	// DefaultFileMaxSize is the largest file, in bytes, sent or accepted.
+	// At 4 bytes a frame a 1 KB file takes about an hour to send.
+	DefaultFileMaxSize = 1024
+	// DefaultFileRetries is how many rounds of resending missing frames a
+	// transfer gets before it fails
+	DefaultFileRetries = 3
+	// DefaultFilePollTimeout is how many seconds the sender waits for the
+	// receiver to answer a poll
+	DefaultFilePollTimeout = 60
+)
+
+// maxFileSize is the most a transfer can carry, less room for the name
+const maxFileSize = 4000
+
+// validateFiles checks the file transfer settings
+func (c *Config) validateFiles() error {
+	if !c.Files.Enabled {
+		return nil
+	}
+	if err := inRange("files max_size", c.Files.MaxSize, 1, maxFileSize); err != nil {
+		return err
+	}
+	if err := inRange("files retries", c.Files.Retries, 1, 10); err != nil {
+		return err
+	}
+	return inRange("files poll_timeout", c.Files.PollTimeout, 15, 600)
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// FormTemplate describes a structured message template, sent as JS8 data
+// frames. The receiving station needs the same template to label the
+// fields; a template with a built-in code such as ICS213 replaces it.
+//
+//	forms:
+//	  templates:
+//	    - code: SITREP
+//	      name: Shelter status report
+//	      fields:
+//	        - {name: shelter, label: Shelter}
+//	        - {name: occupants, label: Occupants}
+//	        - {name: needs, label: Needs, multiline: true}
+type FormTemplate struct {
+	Code   string      `yaml:"code"`   // short name sent with each form
+	Name   string      `yaml:"name"`   // title shown with the form
+	Fields []FormField `yaml:"fields"` // entries in the order they are sent
+}
+
+// FormField is one entry on a form template
+type FormField struct {
+	Name      string `yaml:"name"`                // key the value is filled in under
+	Label     string `yaml:"label,omitempty"`     // shown beside the value, the name when empty
+	Multiline bool   `yaml:"multiline,omitempty"` // edited as a text area
+}
+
+var (
+	formCodePattern  = regexp.MustCompile(`^[A-Za-z0-9]{1,8}$`)
+	formFieldPattern = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+)
+
+// validateForms checks the form templates
+func (c *Config) validateForms() error {
+	codes := make(map[string]bool)
+	for i, template := range c.Forms.Templates {
+		if !formCodePattern.MatchString(template.Code) {
+			return fmt.Errorf("forms template %d: code %q must be 1 to 8 letters or digits", i+1, template.Code)
+		}
+		if codes[template.Code] {
+			return fmt.Errorf("forms template %d: code %q is used twice", i+1, template.Code)
+		}
+		codes[template.Code] = true
+		if len(template.Fields) == 0 {
+			return fmt.Errorf("forms template %s has no fields", template.Code)
+		}
+
+		names := make(map[string]bool)
+		for _, field := range template.Fields {
+			if !formFieldPattern.MatchString(field.Name) {
+				return fmt.Errorf("forms template %s: field name %q must be lowercase letters, digits and underscores", template.Code, field.Name)
+			}
+			if names[field.Name] {
+				return fmt.Errorf("forms template %s: field %q is used twice", template.Code, field.Name)
+			}
+			names[field.Name] = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// validateGPS checks the gpsd settings
+func (c *Config) validateGPS() error {
+	if !c.GPS.Enabled {
+		return nil
+	}
+	host, port, err := net.SplitHostPort(c.GPS.Address)
+	n, _ := strconv.Atoi(port)
+	if err != nil || host == "" || n < 1 || n > 65535 {
+		return fmt.Errorf("gps address %q must be host:port", c.GPS.Address)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultInstance names the single engine of a configuration without
+// an instances list
+const DefaultInstance = "default"
+
+// DefaultUnixSocket is used when api.unix_socket is not set
+const DefaultUnixSocket = "/tmp/js8d.sock"
+
+var instanceNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Instance is one engine of a multi-rig daemon. Any top level section, such
+// as radio, audio or station, overrides the shared settings for this instance:
+//
+//	instances:
+//	  - name: 20m
+//	    radio: {device: /dev/ttyUSB0}
+//	    audio: {input_device: "hw:1,0"}
+//	  - name: 40m
+//	    radio: {device: /dev/ttyUSB1}
+//	    audio: {input_device: "hw:2,0"}
+type Instance struct {
+	Name      string                 `yaml:"name"`
+	Overrides map[string]interface{} `yaml:",inline"`
+}
+
+// InstanceConfigs returns the configuration of every engine instance. Without
+// an instances list this is the configuration itself, named "default".
+// Instances that don't set their own unix socket or database get one derived
+// from the shared path and the instance name, so they never collide.
+func (c *Config) InstanceConfigs() ([]*Config, error) {
+	if len(c.Instances) == 0 {
+		single := *c
+		single.Instance = DefaultInstance
+		return []*Config{&single}, nil
+	}
+
+	base := *c
+	base.Instances = nil
+	baseYAML, err := yaml.Marshal(&base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy shared settings: %w", err)
+	}
+
+	configs := make([]*Config, 0, len(c.Instances))
+	sockets := make(map[string]string)
+	databases := make(map[string]string)
+	grpcAddresses := make(map[string]string)
+
+	for i, instance := range c.Instances {
+		if !instanceNamePattern.MatchString(instance.Name) {
+			return nil, fmt.Errorf("instance %d: name %q must be letters, digits, - or _", i+1, instance.Name)
+		}
+		if _, ok := instance.Overrides["instances"]; ok {
+			return nil, fmt.Errorf("instance %s: instances cannot be nested", instance.Name)
+		}
+
+		cfg := &Config{}
+		if err := yaml.Unmarshal(baseYAML, cfg); err != nil {
+			return nil, fmt.Errorf("instance %s: %w", instance.Name, err)
+		}
+		overrides, err := yaml.Marshal(instance.Overrides)
+		if err != nil {
+			return nil, fmt.Errorf("instance %s: %w", instance.Name, err)
+		}
+		if err := yaml.UnmarshalStrict(overrides, cfg); err != nil {
+			return nil, fmt.Errorf("instance %s: %w", instance.Name, err)
+		}
+		cfg.Instance = instance.Name
+		cfg.secrets = c.secrets
+		if err := cfg.resolveSecrets(); err != nil {
+			return nil, fmt.Errorf("instance %s: %w", instance.Name, err)
+		}
+
+		if cfg.API.UnixSocket == "" || cfg.API.UnixSocket == c.API.UnixSocket {
+			cfg.API.UnixSocket = instancePath(c.API.UnixSocket, DefaultUnixSocket, instance.Name)
+		}
+		if cfg.Storage.DatabasePath != "" && cfg.Storage.DatabasePath == c.Storage.DatabasePath {
+			cfg.Storage.DatabasePath = instancePath(c.Storage.DatabasePath, "", instance.Name)
+		}
+		if cfg.Storage.BackupPath != "" && cfg.Storage.BackupPath == c.Storage.BackupPath {
+			cfg.Storage.BackupPath = instancePath(c.Storage.BackupPath, "", instance.Name)
+		}
+		if cfg.Storage.SnippetDir != "" && cfg.Storage.SnippetDir == c.Storage.SnippetDir {
+			cfg.Storage.SnippetDir = instancePath(c.Storage.SnippetDir, "", instance.Name)
+		}
+		if cfg.Storage.RecordingDir != "" && cfg.Storage.RecordingDir == c.Storage.RecordingDir {
+			cfg.Storage.RecordingDir = instancePath(c.Storage.RecordingDir, "", instance.Name)
+		}
+		if cfg.Net.ExportDir != "" && cfg.Net.ExportDir == c.Net.ExportDir {
+			cfg.Net.ExportDir = instancePath(c.Net.ExportDir, "", instance.Name)
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return nil, fmt.Errorf("instance %s: %w", instance.Name, err)
+		}
+		if other, ok := sockets[cfg.API.UnixSocket]; ok {
+			return nil, fmt.Errorf("instances %s and %s share unix socket %s", other, instance.Name, cfg.API.UnixSocket)
+		}
+		sockets[cfg.API.UnixSocket] = instance.Name
+		if cfg.Storage.DatabasePath != "" {
+			if other, ok := databases[cfg.Storage.DatabasePath]; ok {
+				return nil, fmt.Errorf("instances %s and %s share database %s", other, instance.Name, cfg.Storage.DatabasePath)
+			}
+			databases[cfg.Storage.DatabasePath] = instance.Name
+		}
+		if cfg.API.GRPCAddress != "" {
+			if other, ok := grpcAddresses[cfg.API.GRPCAddress]; ok {
+				return nil, fmt.Errorf("instances %s and %s share grpc address %s", other, instance.Name, cfg.API.GRPCAddress)
+			}
+			grpcAddresses[cfg.API.GRPCAddress] = instance.Name
+		}
+
+		configs = append(configs, cfg)
+	}
+	return configs, nil
+}
+
+// InstanceConfig returns the configuration of the named instance
+func (c *Config) InstanceConfig(name string) (*Config, error) {
+	configs, err := c.InstanceConfigs()
+	if err != nil {
+		return nil, err
+	}
+	for _, cfg := range configs {
+		if cfg.Instance == name {
+			return cfg, nil
+		}
+	}
+	return nil, fmt.Errorf("no instance named %q", name)
+}
+
+// instancePath inserts the instance name before the extension of path,
+// e.g. /tmp/js8d.sock becomes /tmp/js8d-20m.sock
+func instancePath(path, fallback, name string) string {
+	if path == "" {
+		path = fallback
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + name + ext
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func loadTestConfig(t *testing.T, content string) *Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	return cfg
+}
+
+const sharedConfig = `
+station:
+  callsign: "K3DEP"
+  grid: "FN20"
+radio:
+  use_hamlib: true
+  model: "2028"
+  device: "/dev/ttyUSB0"
+audio:
+  input_device: "hw:1,0"
+storage:
+  database_path: "/var/lib/js8d/messages.db"
+`
+
+func TestInstanceConfigsDefault(t *testing.T) {
+	cfg := loadTestConfig(t, sharedConfig)
+
+	configs, err := cfg.InstanceConfigs()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(configs) != 1 || configs[0].Instance != DefaultInstance {
+		t.Fatalf("Expected a single default instance, got %d", len(configs))
+	}
+	if configs[0].Radio.Device != "/dev/ttyUSB0" || configs[0].Storage.DatabasePath != "/var/lib/js8d/messages.db" {
+		t.Error("Expected the default instance to use the shared settings unchanged")
+	}
+}
+
+func TestInstanceConfigs(t *testing.T) {
+	cfg := loadTestConfig(t, sharedConfig+`
+instances:
+  - name: 20m
+    audio:
+      input_device: "hw:2,0"
+  - name: 40m
+    station:
+      callsign: "VE3/K3DEP"
+      grid: "FN03"
+    radio:
+      device: "/dev/ttyUSB1"
+    api:
+      unix_socket: "/run/js8d/40m.sock"
+`)
+
+	configs, err := cfg.InstanceConfigs()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("Expected 2 instances, got %d", len(configs))
+	}
+	twenty, forty := configs[0], configs[1]
+
+	// Overrides apply per field, everything else is shared
+	if twenty.Instance != "20m" || twenty.Audio.InputDevice != "hw:2,0" || twenty.Radio.Device != "/dev/ttyUSB0" {
+		t.Errorf("Unexpected 20m instance: %s %s %s", twenty.Instance, twenty.Audio.InputDevice, twenty.Radio.Device)
+	}
+	if forty.Radio.Device != "/dev/ttyUSB1" || forty.Radio.Model != "2028" || forty.Audio.InputDevice != "hw:1,0" {
+		t.Errorf("Unexpected 40m radio/audio: %s %s %s", forty.Radio.Device, forty.Radio.Model, forty.Audio.InputDevice)
+	}
+	if forty.Station.Callsign != "VE3/K3DEP" || twenty.Station.Callsign != "K3DEP" {
+		t.Errorf("Expected per instance callsigns, got %s and %s", twenty.Station.Callsign, forty.Station.Callsign)
+	}
+	if forty.Audio.SampleRate != 48000 {
+		t.Errorf("Expected defaults to carry into instances, got sample rate %d", forty.Audio.SampleRate)
+	}
+
+	// Shared paths are made unique per instance
+	if twenty.API.UnixSocket != "/tmp/js8d-20m.sock" || forty.API.UnixSocket != "/run/js8d/40m.sock" {
+		t.Errorf("Unexpected sockets %s and %s", twenty.API.UnixSocket, forty.API.UnixSocket)
+	}
+	if twenty.Storage.DatabasePath != "/var/lib/js8d/messages-20m.db" {
+		t.Errorf("Expected a per instance database, got %s", twenty.Storage.DatabasePath)
+	}
+
+	if _, err := cfg.InstanceConfig("40m"); err != nil {
+		t.Errorf("Expected to find instance 40m: %v", err)
+	}
+	if _, err := cfg.InstanceConfig("6m"); err == nil {
+		t.Error("Expected error for unknown instance")
+	}
+}
+
+func TestInstanceConfigsErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		instances string
+		want      string
+	}{
+		{"Bad Name", `
+  - name: "20 m"`, "name"},
+		{"Duplicate Socket", `
+  - name: a
+    api: {unix_socket: /tmp/x.sock}
+  - name: b
+    api: {unix_socket: /tmp/x.sock}`, "share unix socket"},
+		{"Duplicate gRPC Address", `
+  - name: a
+    api: {grpc_address: "127.0.0.1:50051"}
+  - name: b
+    api: {grpc_address: "127.0.0.1:50051"}`, "share grpc address"},
+		{"Invalid Override", `
+  - name: a
+    station: {callsign: "NOTACALL"}`, "callsign"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadTestConfig(t, sharedConfig+"\ninstances:"+tt.instances+"\n")
+			_, err := cfg.InstanceConfigs()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected %s error, got: %v", tt.want, err)
+			}
+		})
+	}
+	// LoadConfig rejects unknown keys, but instances may be set in code
+	cfg := loadTestConfig(t, sharedConfig)
+	cfg.Instances = []Instance{{Name: "a", Overrides: map[string]interface{}{
+		"radio": map[interface{}]interface{}{"devcie": "/dev/ttyUSB1"},
+	}}}
+	if _, err := cfg.InstanceConfigs(); err == nil || !strings.Contains(err.Error(), "devcie") {
+		t.Errorf("Expected devcie error, got: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// LogTarget is a logging program logged QSOs are sent to over UDP:
+//
+//	log_broadcast:
+//	  targets:
+//	    - address: 127.0.0.1:12060
+//	      format: n1mm
+type LogTarget struct {
+	Address string `yaml:"address"`          // host:port
+	Format  string `yaml:"format,omitempty"` // n1mm (contact XML, the default) or adif
+}
+
+// validateLogBroadcast checks where logged QSOs are sent
+func (c *Config) validateLogBroadcast() error {
+	for i, target := range c.LogBroadcast.Targets {
+		host, port, err := net.SplitHostPort(target.Address)
+		n, _ := strconv.Atoi(port)
+		if err != nil || host == "" || n < 1 || n > 65535 {
+			return fmt.Errorf("log_broadcast target %d: address %q must be host:port", i+1, target.Address)
+		}
+		if target.Format != "" {
+			if err := oneOf(fmt.Sprintf("log_broadcast target %d format", i+1), target.Format, "n1mm", "adif"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
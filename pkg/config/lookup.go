@@ -0,0 +1,21 @@
+package config
+
+import "fmt"
+
+// DefaultLookupCacheDays is how long a callbook lookup is kept before the
+// station is looked up again
+const DefaultLookupCacheDays = 30
+
+// validateLookup checks the callbook settings
+func (c *Config) validateLookup() error {
+	if c.Lookup.Service == "" {
+		return nil
+	}
+	if err := oneOf("lookup service", c.Lookup.Service, "qrz", "hamqth"); err != nil {
+		return err
+	}
+	if c.Lookup.Username == "" || !c.Lookup.Password.IsSet() {
+		return fmt.Errorf("lookup username and password are required for %s", c.Lookup.Service)
+	}
+	return inRange("lookup cache_days", c.Lookup.CacheDays, 1, 3650)
+}
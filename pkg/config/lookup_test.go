@@ -0,0 +1,51 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateLookup(t *testing.T) {
+	t.Setenv("QRZ_PASSWORD", "hunter2")
+	cfg := loadTestConfig(t, sharedConfig+`
+lookup:
+  service: qrz
+  username: K3DEP
+  password: "${QRZ_PASSWORD}"
+`)
+	if cfg.Lookup.CacheDays != DefaultLookupCacheDays {
+		t.Errorf("Expected default cache days %d, got %d", DefaultLookupCacheDays, cfg.Lookup.CacheDays)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected valid lookup config, got %v", err)
+	}
+	if cfg.Lookup.Password.Value() != "hunter2" {
+		t.Errorf("Expected the password read from the environment, got %q", cfg.Lookup.Password.Value())
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string
+	}{
+		{"Unknown service", func(c *Config) { c.Lookup.Service = "hamcall" }, "lookup service"},
+		{"No password", func(c *Config) { c.Lookup.Password = Secret{} }, "username and password"},
+		{"Cache days", func(c *Config) { c.Lookup.CacheDays = -1 }, "lookup cache_days"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadTestConfig(t, sharedConfig+`
+lookup:
+  service: hamqth
+  username: K3DEP
+  password: hunter2
+`)
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
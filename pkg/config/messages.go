@@ -0,0 +1,37 @@
+package config
+
+// How an unacknowledged directed message is resent once messages
+// escalate_after sends have gone unanswered, set with messages escalate
+const (
+	EscalateOff     = "off"     // Resend as first sent
+	EscalateSubmode = "submode" // Resend a submode slower each time, down to Slow
+	EscalateOffset  = "offset"  // Resend escalate_offset Hz further from the TX offset each time, above and below in turn
+	EscalateBoth    = "both"    // Both of these
+)
+
+// Defaults for messages escalation
+const (
+	DefaultEscalate       = EscalateOff
+	DefaultEscalateAfter  = 1
+	DefaultEscalateOffset = 100 // Hz
+)
+
+// validateEscalation checks how unacknowledged messages are resent
+func (c *Config) validateEscalation() error {
+	if c.Messages.Escalate == "" {
+		return nil
+	}
+	if err := oneOf("messages escalate", c.Messages.Escalate, EscalateOff, EscalateSubmode, EscalateOffset, EscalateBoth); err != nil {
+		return err
+	}
+	if c.Messages.Escalate == EscalateOff {
+		return nil
+	}
+	if err := inRange("messages escalate_after", c.Messages.EscalateAfter, 1, 10); err != nil {
+		return err
+	}
+	if c.Messages.Escalate == EscalateOffset || c.Messages.Escalate == EscalateBoth {
+		return inRange("messages escalate_offset", c.Messages.EscalateOffset, 10, 1000)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Defaults for net
+const (
+	DefaultNetDuration = 60
+	DefaultNetPreamble = "NET OPEN, QNI TO {MYCALL}"
+	DefaultNetClosing  = "NET CLOSED, 73"
+	DefaultNetAck      = "{CALL} QSL"
+)
+
+// NetTime is a scheduled net start: a local time of day on the days
+// listed, or every day when none are
+type NetTime struct {
+	Days  []time.Weekday
+	Clock time.Duration // Time since midnight
+}
+
+// ParseNetTime parses a net start such as "19:00", "Tue 19:00" or
+// "Mon,Thu 19:00"
+func ParseNetTime(s string) (NetTime, error) {
+	fields := strings.Fields(s)
+	var t NetTime
+	switch len(fields) {
+	case 1:
+	case 2:
+		for _, day := range strings.Split(fields[0], ",") {
+			weekday, ok := parseWeekday(day)
+			if !ok {
+				return NetTime{}, fmt.Errorf("net time %q: %q is not a day such as Mon", s, day)
+			}
+			t.Days = append(t.Days, weekday)
+		}
+	default:
+		return NetTime{}, fmt.Errorf("net time %q must be [days] HH:MM", s)
+	}
+	clock, err := parseClock(fields[len(fields)-1])
+	if err != nil {
+		return NetTime{}, fmt.Errorf("net time %q: %w", s, err)
+	}
+	t.Clock = clock
+	return t, nil
+}
+
+// parseWeekday parses the first three letters of a day's English name
+func parseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if len(s) >= 3 && strings.HasPrefix(strings.ToLower(day.String()), s) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// Due reports whether the net starts after from and no later than to, in
+// to's time zone. The two are expected less than a day apart.
+func (t NetTime) Due(from, to time.Time) bool {
+	year, month, day := to.Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, to.Location()).Add(t.Clock)
+	if start.After(to) {
+		start = start.AddDate(0, 0, -1)
+	}
+	if !start.After(from) {
+		return false
+	}
+	if len(t.Days) == 0 {
+		return true
+	}
+	for _, weekday := range t.Days {
+		if start.Weekday() == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// NetDue reports whether one of net schedule's starts falls after from and
+// no later than to
+func (c *Config) NetDue(from, to time.Time) bool {
+	for _, s := range c.Net.Schedule {
+		if t, err := ParseNetTime(s); err == nil && t.Due(from, to) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidNetGroup reports whether group, in any case, is a JS8 group a net
+// can be called on, such as @ARESNET
+func ValidNetGroup(group string) bool {
+	group = strings.ToUpper(group)
+	if len(group) < 2 || group[0] != '@' {
+		return false
+	}
+	for _, r := range group[1:] {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// validateNet checks the net settings
+func (c *Config) validateNet() error {
+	if c.Net.Group != "" && !ValidNetGroup(c.Net.Group) {
+		return fmt.Errorf("net group %q must be @ and letters or digits, such as @ARESNET", c.Net.Group)
+	}
+	if len(c.Net.Schedule) > 0 && c.Net.Group == "" {
+		return fmt.Errorf("net schedule needs a net group")
+	}
+	for i, s := range c.Net.Schedule {
+		if _, err := ParseNetTime(s); err != nil {
+			return fmt.Errorf("net schedule[%d]: %w", i, err)
+		}
+	}
+	if c.Net.Duration != 0 {
+		if err := inRange("net duration", c.Net.Duration, 5, 720); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNetSchedule(t *testing.T) {
+	cfg := loadTestConfig(t, sharedConfig+`
+net:
+  group: "@ARESNET"
+  schedule: ["Tue,thu 19:00", "06:30"]
+`)
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected valid net config, got %v", err)
+	}
+	if cfg.Net.Duration != DefaultNetDuration || cfg.Net.Preamble != DefaultNetPreamble {
+		t.Errorf("Expected the default duration and preamble, got %d and %q", cfg.Net.Duration, cfg.Net.Preamble)
+	}
+
+	// 2026-10-13 is a Tuesday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     bool
+	}{
+		{"Tuesday Evening", at(13, 18, 59), at(13, 19, 0), true},
+		{"Already Started", at(13, 19, 0), at(13, 19, 1), false},
+		{"Wednesday Evening", at(14, 18, 59), at(14, 19, 0), false},
+		{"Thursday Evening", at(15, 18, 30), at(15, 19, 30), true},
+		{"Every Morning", at(14, 6, 29), at(14, 6, 30), true},
+		{"Over Midnight", at(13, 23, 59), at(14, 0, 1), false},
+	}
+	for _, tt := range tests {
+		if got := cfg.NetDue(tt.from, tt.to); got != tt.want {
+			t.Errorf("%s: NetDue = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if !ValidNetGroup("@aresnet") || ValidNetGroup("@") || ValidNetGroup("@ARES-NET") {
+		t.Error("Expected only @ with letters or digits to be a net group")
+	}
+}
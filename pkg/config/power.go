@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxDecodeLevel is the lightest of the decoder's decode levels, the ones
+// adaptive_decode steps through
+const maxDecodeLevel = 3
+
+// TimeWindow is a daily stretch of local time, from Start up to End, each
+// as time since midnight. A window whose end is before its start runs over
+// midnight.
+type TimeWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseTimeWindow parses a window such as "22:00-06:00"
+func ParseTimeWindow(s string) (TimeWindow, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return TimeWindow{}, fmt.Errorf("time window %q must be HH:MM-HH:MM", s)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("time window %q: %w", s, err)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("time window %q: %w", s, err)
+	}
+	if start == end {
+		return TimeWindow{}, fmt.Errorf("time window %q is empty", s)
+	}
+	return TimeWindow{Start: start, End: end}, nil
+}
+
+// parseClock parses HH:MM as time since midnight
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", strings.TrimSpace(s))
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains reports whether t's local time of day is in the window
+func (w TimeWindow) Contains(t time.Time) bool {
+	hour, min, sec := t.Clock()
+	now := time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec)*time.Second
+	if w.Start < w.End {
+		return now >= w.Start && now < w.End
+	}
+	return now >= w.Start || now < w.End
+}
+
+// InLowPowerHours reports whether t falls in one of power low_power_hours
+func (c *Config) InLowPowerHours(t time.Time) bool {
+	for _, s := range c.Power.LowPowerHours {
+		if window, err := ParseTimeWindow(s); err == nil && window.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePower checks the low power settings
+func (c *Config) validatePower() error {
+	for i, s := range c.Power.LowPowerHours {
+		if _, err := ParseTimeWindow(s); err != nil {
+			return fmt.Errorf("power low_power_hours[%d]: %w", i, err)
+		}
+	}
+	return inRange("power decode_level", c.Power.DecodeLevel, 0, maxDecodeLevel)
+}
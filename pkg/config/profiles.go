@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Sections a profile may override. These are the settings the engine can
+// apply while running; the rest need a restart.
+var profileSections = map[string]bool{
+	"station":  true,
+	"radio":    true,
+	"audio":    true,
+	"dsp":      true,
+	"hardware": true,
+}
+
+// Profile is a named variation of the station, radio, audio, DSP and
+// hardware settings, switched to at runtime with PROFILE:<name>:
+//
+//	profile: home
+//	profiles:
+//	  - name: home
+//	    frequency: 14078000
+//	  - name: portable-qrp
+//	    frequency: 7078000
+//	    radio: {device: /dev/ttyACM0}
+//	    audio: {input_device: "hw:2,0"}
+type Profile struct {
+	Name      string                 `yaml:"name"`
+	Frequency int                    `yaml:"frequency,omitempty"` // Hz to tune to, 0 keeps the current frequency
+	Overrides map[string]interface{} `yaml:",inline"`
+}
+
+// ProfileNames returns the names of the configured profiles in file order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for _, profile := range c.Profiles {
+		names = append(names, profile.Name)
+	}
+	return names
+}
+
+// FindProfile returns the named profile, or nil
+func (c *Config) FindProfile(name string) *Profile {
+	for i := range c.Profiles {
+		if c.Profiles[i].Name == name {
+			return &c.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// ProfileConfig returns the configuration with the named profile applied.
+// The result has no profiles of its own; switch profiles from the original.
+func (c *Config) ProfileConfig(name string) (*Config, error) {
+	profile := c.FindProfile(name)
+	if profile == nil {
+		return nil, fmt.Errorf("no profile named %q", name)
+	}
+	for section := range profile.Overrides {
+		if !profileSections[section] {
+			return nil, fmt.Errorf("profile %s: %s settings cannot change per profile", name, section)
+		}
+	}
+
+	base := *c
+	base.Profiles = nil
+	baseYAML, err := yaml.Marshal(&base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy settings: %w", err)
+	}
+
+	cfg := &Config{}
+	if err := yaml.Unmarshal(baseYAML, cfg); err != nil {
+		return nil, fmt.Errorf("profile %s: %w", name, err)
+	}
+	overrides, err := yaml.Marshal(profile.Overrides)
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: %w", name, err)
+	}
+	if err := yaml.UnmarshalStrict(overrides, cfg); err != nil {
+		return nil, fmt.Errorf("profile %s: %w", name, err)
+	}
+	cfg.Instance = c.Instance
+	cfg.Profile = name
+	cfg.secrets = c.secrets
+	if err := cfg.resolveSecrets(); err != nil {
+		return nil, fmt.Errorf("profile %s: %w", name, err)
+	}
+	return cfg, nil
+}
+
+// validateProfiles checks every profile applies to a valid configuration
+func (c *Config) validateProfiles() error {
+	names := make(map[string]bool)
+	for i, profile := range c.Profiles {
+		if !instanceNamePattern.MatchString(profile.Name) {
+			return fmt.Errorf("profile %d: name %q must be letters, digits, - or _", i+1, profile.Name)
+		}
+		if names[profile.Name] {
+			return fmt.Errorf("profile %q is listed twice", profile.Name)
+		}
+		names[profile.Name] = true
+
+		if profile.Frequency < 0 {
+			return fmt.Errorf("profile %s: frequency (%d) must not be negative", profile.Name, profile.Frequency)
+		}
+		cfg, err := c.ProfileConfig(profile.Name)
+		if err != nil {
+			return err
+		}
+		cfg.Profile = "" // Has no profile list to be checked against
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("profile %s: %w", profile.Name, err)
+		}
+	}
+
+	if c.Profile != "" && !names[c.Profile] {
+		return fmt.Errorf("profile %q is not one of the configured profiles", c.Profile)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProfileConfig(t *testing.T) {
+	cfg := loadTestConfig(t, sharedConfig+`
+profile: home
+profiles:
+  - name: home
+    frequency: 14078000
+  - name: portable-qrp
+    frequency: 7078000
+    radio:
+      model: "10001"
+      device: "/dev/ttyACM0"
+    audio:
+      input_device: "hw:2,0"
+`)
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected profiles to validate, got: %v", err)
+	}
+	if names := cfg.ProfileNames(); len(names) != 2 || names[1] != "portable-qrp" {
+		t.Errorf("Expected [home portable-qrp], got %v", names)
+	}
+
+	portable, err := cfg.ProfileConfig("portable-qrp")
+	if err != nil {
+		t.Fatalf("Failed to apply profile: %v", err)
+	}
+	if portable.Radio.Device != "/dev/ttyACM0" || portable.Audio.InputDevice != "hw:2,0" {
+		t.Error("Expected the profile's radio and audio settings")
+	}
+	if portable.Radio.UseHamlib != true || portable.Station.Callsign != "K3DEP" {
+		t.Error("Expected settings the profile leaves out to be kept")
+	}
+	if portable.Profile != "portable-qrp" || len(portable.Profiles) != 0 {
+		t.Errorf("Expected profile %q without a profile list, got %q with %d", "portable-qrp", portable.Profile, len(portable.Profiles))
+	}
+	if cfg.Radio.Device != "/dev/ttyUSB0" {
+		t.Error("Expected the original configuration to be unchanged")
+	}
+
+	if _, err := cfg.ProfileConfig("vhf"); err == nil {
+		t.Error("Expected an error for an unknown profile")
+	}
+}
+
+func TestProfileErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{"Bad Name", func(c *Config) { c.Profiles = []Profile{{Name: "home qth"}} }, "must be letters, digits"},
+		{"Duplicate", func(c *Config) { c.Profiles = []Profile{{Name: "home"}, {Name: "home"}} }, "listed twice"},
+		{"Unknown Startup Profile", func(c *Config) {
+			c.Profiles = []Profile{{Name: "home"}}
+			c.Profile = "vhf"
+		}, `profile "vhf" is not one of`},
+		{"Restart Only Section", func(c *Config) {
+			c.Profiles = []Profile{{Name: "home", Overrides: map[string]interface{}{"web": map[string]interface{}{"port": 80}}}}
+		}, "web settings cannot change per profile"},
+		{"Invalid Override", func(c *Config) {
+			c.Profiles = []Profile{{Name: "vhf", Overrides: map[string]interface{}{"radio": map[string]interface{}{"baud_rate": 1234}}}}
+		}, "profile vhf: radio baud_rate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadTestConfig(t, sharedConfig)
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
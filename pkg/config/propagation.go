@@ -0,0 +1,13 @@
+package config
+
+// DefaultPropagationRefresh is how often, in minutes, the solar indices are
+// fetched. NOAA updates them every three hours.
+const DefaultPropagationRefresh = 60
+
+// validatePropagation checks the solar index settings
+func (c *Config) validatePropagation() error {
+	if !c.Propagation.Enabled {
+		return nil
+	}
+	return inRange("propagation refresh", c.Propagation.Refresh, 15, 1440)
+}
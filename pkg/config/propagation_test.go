@@ -0,0 +1,30 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePropagation(t *testing.T) {
+	cfg := loadTestConfig(t, sharedConfig+`
+propagation:
+  enabled: true
+`)
+	if cfg.Propagation.Refresh != DefaultPropagationRefresh {
+		t.Errorf("Expected default refresh %d, got %d", DefaultPropagationRefresh, cfg.Propagation.Refresh)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected valid propagation config, got %v", err)
+	}
+
+	cfg.Propagation.Refresh = 5
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "propagation refresh") {
+		t.Errorf("Expected a refresh error, got %v", err)
+	}
+
+	// Not checked when fetching is off
+	cfg.Propagation.Enabled = false
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected disabled propagation to pass, got %v", err)
+	}
+}
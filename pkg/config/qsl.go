@@ -0,0 +1,23 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// validateQSL checks the LoTW and eQSL upload settings
+func (c *Config) validateQSL() error {
+	eqsl := c.QSL.EQSL
+	if eqsl.Username == "" {
+		return nil
+	}
+	if !eqsl.Password.IsSet() {
+		return fmt.Errorf("qsl eqsl password is required for the eQSL account %s", eqsl.Username)
+	}
+	if eqsl.URL != "" {
+		if u, err := url.Parse(eqsl.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("qsl eqsl url %q must be an http or https URL", eqsl.URL)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultQSOTimeout is how long, in seconds, a scripted QSO waits for each
+// reply: a few JS8 normal frames for the other station to answer
+const DefaultQSOTimeout = 90
+
+// DefaultQSOSteps is the scripted exchange used when none is configured:
+// our grid to the station answering the CQ, a signal report after theirs,
+// then 73
+var DefaultQSOSteps = []string{
+	"{CALL} {MYGRID}",
+	"{CALL} {SNR}",
+	"{CALL} RR 73",
+}
+
+// validateQSO checks the scripted QSO settings
+func (c *Config) validateQSO() error {
+	if !c.QSO.Enabled {
+		return nil
+	}
+	if err := inRange("qso timeout", c.QSO.Timeout, 30, 600); err != nil {
+		return err
+	}
+	if err := inRange("qso retries", c.QSO.Retries, 0, 5); err != nil {
+		return err
+	}
+	for i, step := range c.QSO.Steps {
+		if strings.TrimSpace(step) == "" {
+			return fmt.Errorf("qso steps[%d] is empty", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateQSO(t *testing.T) {
+	cfg := loadTestConfig(t, sharedConfig+`
+qso:
+  enabled: true
+`)
+	if cfg.QSO.Timeout != DefaultQSOTimeout || len(cfg.QSO.Steps) != len(DefaultQSOSteps) {
+		t.Errorf("Expected the default timeout and steps, got %d and %v", cfg.QSO.Timeout, cfg.QSO.Steps)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected valid QSO config, got %v", err)
+	}
+
+	cfg.QSO.Steps = []string{"{CALL} {MYGRID}", " "}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "qso steps[1]") {
+		t.Errorf("Expected an empty step error, got %v", err)
+	}
+
+	cfg.QSO.Steps = DefaultQSOSteps
+	cfg.QSO.Timeout = 10
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "qso timeout") {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+
+	// Not checked when the script is off
+	cfg.QSO.Enabled = false
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected a disabled script to pass, got %v", err)
+	}
+}
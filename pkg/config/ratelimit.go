@@ -0,0 +1,34 @@
+package config
+
+// Defaults for rate_limit: a station gets at most a few automatic replies
+// in a row and six an hour, and js8d sends thirty an hour in all
+const (
+	DefaultRateCallsignPerHour = 6
+	DefaultRateCallsignBurst   = 3
+	DefaultRateGlobalPerHour   = 30
+	DefaultRateGlobalBurst     = 10
+)
+
+// validateRateLimit checks the limits on automatic replies
+func (c *Config) validateRateLimit() error {
+	limits := []struct {
+		name    string
+		perHour int
+		burst   int
+	}{
+		{"rate_limit callsign", c.RateLimit.CallsignPerHour, c.RateLimit.CallsignBurst},
+		{"rate_limit global", c.RateLimit.GlobalPerHour, c.RateLimit.GlobalBurst},
+	}
+	for _, limit := range limits {
+		if limit.perHour == -1 {
+			continue
+		}
+		if err := inRange(limit.name+"_per_hour", limit.perHour, 0, 3600); err != nil {
+			return err
+		}
+		if err := inRange(limit.name+"_burst", limit.burst, 0, 100); err != nil {
+			return err
+		}
+	}
+	return nil
+}
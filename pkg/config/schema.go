@@ -0,0 +1,405 @@
+package config
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/dougsko/js8d/pkg/i18n"
+)
+
+// DefaultYAML is a fully commented configuration holding every setting at
+// its default value, printed by js8d -dump-default-config
+//
+//go:embed default.yaml
+var DefaultYAML []byte
+
+// Serial rates Hamlib can open a CAT port at
+var baudRates = []int{300, 600, 1200, 2400, 4800, 9600, 19200, 38400, 57600, 115200, 230400}
+
+// maxIF is the highest frequency in Hz a rig driving a transverter is tuned
+// to, which with the transverter offset must fit an int
+const maxIF = 500000000
+
+// Highest BCM GPIO number on the Raspberry Pi header
+const maxGPIOPin = 27
+
+// checkKeys reports keys in a configuration file that match no setting.
+// yaml.Unmarshal ignores them, so a misspelled key would silently fall back
+// to its default.
+func checkKeys(data []byte) error {
+	var raw map[interface{}]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	var unknown []string
+	unknownKeys(raw, reflect.TypeOf(Config{}), "", &unknown)
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	if len(unknown) == 1 {
+		return fmt.Errorf("unknown config key %s", unknown[0])
+	}
+	return fmt.Errorf("unknown config keys %s", strings.Join(unknown, ", "))
+}
+
+// unknownKeys walks a decoded YAML value alongside the Go type it will be
+// unmarshaled into and collects the dotted paths of keys with no field
+func unknownKeys(value interface{}, t reflect.Type, path string, unknown *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		items, _ := value.([]interface{})
+		for i, item := range items {
+			unknownKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), unknown)
+		}
+	case reflect.Struct:
+		section, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return
+		}
+		for k, v := range section {
+			key := fmt.Sprint(k)
+			field, ok := fieldType(t, key)
+			if !ok {
+				*unknown = append(*unknown, describeUnknown(join(path, key), key, yamlFields(t)))
+				continue
+			}
+			unknownKeys(v, field, join(path, key), unknown)
+		}
+	}
+}
+
+// fieldType returns the type of the setting key in a section of type t
+func fieldType(t reflect.Type, key string) (reflect.Type, bool) {
+	field, ok := yamlFields(t)[key]
+	if !ok && t == reflect.TypeOf(Instance{}) {
+		// Instance overrides are checked against the whole config
+		field, ok = yamlFields(reflect.TypeOf(Config{}))[key]
+	}
+	if !ok && t == reflect.TypeOf(Profile{}) && profileSections[key] {
+		field, ok = yamlFields(reflect.TypeOf(Config{}))[key]
+	}
+	return field, ok
+}
+
+// yamlFields maps the YAML keys of a struct to their field types
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" || f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// describeUnknown names an unknown key and suggests the setting it was
+// probably meant to be
+func describeUnknown(path, key string, fields map[string]reflect.Type) string {
+	best := suggestKey(key, fields)
+	if best == "" {
+		return path
+	}
+	return fmt.Sprintf("%s (did you mean %s?)", path, best)
+}
+
+// suggestKey returns the setting closest to a misspelled key, or "" if
+// none is close
+func suggestKey(key string, fields map[string]reflect.Type) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best, bestDistance := "", 3
+	for _, name := range names {
+		if d := editDistance(normalizeKey(key), normalizeKey(name)); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// oneOf checks a setting against its allowed values, ignoring case
+func oneOf(name, value string, allowed ...string) error {
+	for _, a := range allowed {
+		if strings.EqualFold(value, a) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be %s or %s, got %q", name,
+		strings.Join(allowed[:len(allowed)-1], ", "), allowed[len(allowed)-1], value)
+}
+
+// inRange checks an integer setting against its limits
+func inRange(name string, value, low, high int) error {
+	if value < low || value > high {
+		return fmt.Errorf("%s (%d) must be between %d and %d", name, value, low, high)
+	}
+	return nil
+}
+
+// validateRanges checks ports, pins, serial settings and other values that
+// parse as the right type but would fail once the daemon starts
+func (c *Config) validateRanges() error {
+	if c.Radio.BaudRate != 0 {
+		valid := false
+		for _, rate := range baudRates {
+			valid = valid || c.Radio.BaudRate == rate
+		}
+		if !valid {
+			return fmt.Errorf("radio baud_rate (%d) must be a standard serial rate such as 9600, 38400 or 115200", c.Radio.BaudRate)
+		}
+	}
+	if device := c.Radio.Device; strings.HasPrefix(strings.ToLower(device), "tcp://") {
+		if host, port, err := net.SplitHostPort(strings.TrimSuffix(device[len("tcp://"):], "/")); err != nil || host == "" || port == "" {
+			return fmt.Errorf("radio device %s must be tcp://host:port", device)
+		}
+	}
+	if c.Radio.PollInterval < 0 {
+		return fmt.Errorf("radio poll_interval (%d) must not be negative", c.Radio.PollInterval)
+	}
+	if c.Radio.TxDelay < 0 || c.Radio.TxDelay > 5 {
+		return fmt.Errorf("radio tx_delay (%.2f) must be between 0 and 5 seconds", c.Radio.TxDelay)
+	}
+	if c.Radio.TxTail < 0 || c.Radio.TxTail > 5 {
+		return fmt.Errorf("radio tx_tail (%.2f) must be between 0 and 5 seconds", c.Radio.TxTail)
+	}
+	if c.Radio.VOXLeader < 0 || c.Radio.VOXLeader > 1 {
+		return fmt.Errorf("radio vox_leader (%.2f) must be between 0 and 1 second", c.Radio.VOXLeader)
+	}
+	if c.Radio.CalibrationPPM < -100 || c.Radio.CalibrationPPM > 100 {
+		return fmt.Errorf("radio calibration_ppm (%.2f) must be between -100 and 100", c.Radio.CalibrationPPM)
+	}
+	if c.Radio.TransverterOffset < 0 {
+		return fmt.Errorf("radio transverter_offset (%d) must not be negative", c.Radio.TransverterOffset)
+	}
+	if c.Radio.TransverterOffset > int64(math.MaxInt)-maxIF {
+		return fmt.Errorf("radio transverter_offset (%d) needs a 64-bit build of js8d", c.Radio.TransverterOffset)
+	}
+	if c.Radio.TuneSeconds < 0 || c.Radio.TuneSeconds > 30 {
+		return fmt.Errorf("radio tune_seconds (%.1f) must be between 0 and 30", c.Radio.TuneSeconds)
+	}
+	if c.Radio.TunePower < 0 || c.Radio.TunePower > 100 {
+		return fmt.Errorf("radio tune_power (%d) must be between 0 and 100 percent", c.Radio.TunePower)
+	}
+
+	enums := []struct {
+		name, value string
+		allowed     []string
+	}{
+		{"radio data_bits", c.Radio.DataBits, []string{"default", "7", "8"}},
+		{"radio stop_bits", c.Radio.StopBits, []string{"default", "1", "2"}},
+		{"radio handshake", c.Radio.Handshake, []string{"default", "none", "xon_xoff", "hardware"}},
+		{"radio dtr", c.Radio.DTR, []string{"default", "high", "low"}},
+		{"radio rts", c.Radio.RTS, []string{"default", "high", "low"}},
+		{"radio backend", c.Radio.Backend, []string{"hamlib", "native"}},
+		{"radio cat_protocol", c.Radio.CATProtocol, []string{"civ", "kenwood", "ft8x7"}},
+		{"radio ptt_method", c.Radio.PTTMethod, []string{"cat", "dtr", "rts", "vox", "gpio", "cmd"}},
+		{"radio mode", c.Radio.Mode, []string{"none", "usb", "data"}},
+		{"radio tx_audio_source", c.Radio.TxAudioSource, []string{"rear", "front"}},
+		{"radio split_operation", c.Radio.SplitOperation, []string{"none", "rig", "fake"}},
+		{"radio tune_before_tx", c.Radio.TuneBeforeTX, []string{"carrier", "cat"}},
+		{"audio input_channel", c.Audio.InputChannel, []string{"left", "right", "sum"}},
+		{"audio output_channels", c.Audio.OutputChannels, []string{"mono", "stereo"}},
+		{"audio output_channel", c.Audio.OutputChannel, []string{"left", "right", "both"}},
+		{"storage recording_codec", c.Storage.RecordingCodec, []string{"auto", "opus", "mulaw"}},
+		{"logging level", c.Logging.Level, []string{"debug", "info", "warn", "warning", "error"}},
+	}
+	for _, e := range enums {
+		if e.value == "" {
+			continue
+		}
+		if err := oneOf(e.name, e.value, e.allowed...); err != nil {
+			return err
+		}
+	}
+
+	if c.Audio.SampleRate != 0 {
+		if err := inRange("audio sample_rate", c.Audio.SampleRate, 8000, 192000); err != nil {
+			return err
+		}
+	}
+	if c.Audio.BufferSize != 0 {
+		if err := inRange("audio buffer_size", c.Audio.BufferSize, 64, 65536); err != nil {
+			return err
+		}
+	}
+
+	if c.Web.Port != 0 {
+		if err := inRange("web port", c.Web.Port, 1, 65535); err != nil {
+			return err
+		}
+	}
+	if c.Web.Language != "" {
+		if err := oneOf("web language", c.Web.Language, i18n.Languages...); err != nil {
+			return err
+		}
+	}
+	if err := inRange("api websocket_port", c.API.WebSocketPort, 0, 65535); err != nil {
+		return err
+	}
+	if c.API.GRPCAddress != "" {
+		_, port, err := net.SplitHostPort(c.API.GRPCAddress)
+		if err != nil {
+			return fmt.Errorf("api grpc_address %q must be host:port: %v", c.API.GRPCAddress, err)
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+			return fmt.Errorf("api grpc_address %q has an invalid port", c.API.GRPCAddress)
+		}
+	}
+
+	if c.Storage.Backend != "" {
+		if err := oneOf("storage backend", c.Storage.Backend, "sqlite", "postgres", "memory"); err != nil {
+			return err
+		}
+	}
+	if strings.EqualFold(c.Storage.Backend, "postgres") && !c.Storage.DSN.IsSet() {
+		return fmt.Errorf("storage dsn is required for the postgres backend")
+	}
+	if c.Storage.MaxMessages < 0 {
+		return fmt.Errorf("storage max_messages (%d) must not be negative", c.Storage.MaxMessages)
+	}
+	if err := inRange("storage stats_minute_hours", c.Storage.StatsMinuteHours, 0, 720); err != nil {
+		return err
+	}
+	if err := inRange("storage stats_hour_days", c.Storage.StatsHourDays, 0, 3650); err != nil {
+		return err
+	}
+	if err := inRange("storage snr_history_days", c.Storage.SNRHistoryDays, 0, 3650); err != nil {
+		return err
+	}
+	if err := inRange("storage snippet_seconds", c.Storage.SnippetSeconds, 0, 15); err != nil {
+		return err
+	}
+	if err := inRange("storage snippet_quota", c.Storage.SnippetQuota, 0, 100000); err != nil {
+		return err
+	}
+	if c.Storage.RecordingBitrate != 0 {
+		if err := inRange("storage recording_bitrate", c.Storage.RecordingBitrate, 6000, 128000); err != nil {
+			return err
+		}
+	}
+	if err := inRange("storage recording_minutes", c.Storage.RecordingMinutes, 0, 1440); err != nil {
+		return err
+	}
+	if err := inRange("storage recording_quota", c.Storage.RecordingQuota, 0, 10000000); err != nil {
+		return err
+	}
+	if c.Storage.CheckInterval != -1 {
+		if err := inRange("storage check_interval", c.Storage.CheckInterval, 0, 720); err != nil {
+			return err
+		}
+	}
+	if err := inRange("storage backup_keep", c.Storage.BackupKeep, 0, 30); err != nil {
+		return err
+	}
+	if c.Storage.BackupPath != "" && c.Storage.BackupPath == c.Storage.DatabasePath {
+		return fmt.Errorf("storage backup_path must not be the database_path")
+	}
+	if c.Messages.AckTimeout < -1 {
+		return fmt.Errorf("messages ack_timeout (%d) must be -1 or more", c.Messages.AckTimeout)
+	}
+	if err := inRange("messages ack_retries", c.Messages.AckRetries, 0, 10); err != nil {
+		return err
+	}
+	if c.Messages.SessionGap < 0 {
+		return fmt.Errorf("messages session_gap (%d) must not be negative", c.Messages.SessionGap)
+	}
+	for component, level := range c.Logging.Levels {
+		if err := oneOf("logging levels component", component, "main", "engine", "dsp", "hardware", "audio", "storage", "web"); err != nil {
+			return err
+		}
+		if err := oneOf("logging levels "+component, level, "debug", "info", "warn", "warning", "error"); err != nil {
+			return err
+		}
+	}
+	if c.Logging.MaxSize < 0 || c.Logging.MaxBackups < 0 || c.Logging.MaxAge < 0 {
+		return fmt.Errorf("logging max_size, max_backups and max_age must not be negative")
+	}
+
+	if err := inRange("hardware ptt_gpio_pin", c.Hardware.PTTGPIOPin, 0, maxGPIOPin); err != nil {
+		return err
+	}
+	if err := inRange("hardware status_led_pin", c.Hardware.StatusLEDPin, 0, maxGPIOPin); err != nil {
+		return err
+	}
+	if c.Hardware.EnableGPIO && c.Hardware.PTTGPIOPin == c.Hardware.StatusLEDPin {
+		return fmt.Errorf("hardware ptt_gpio_pin and status_led_pin are both GPIO %d", c.Hardware.PTTGPIOPin)
+	}
+	if c.Hardware.AmpKeying {
+		if c.Radio.PTTMethod == "vox" {
+			return fmt.Errorf("hardware amp_keying needs a radio ptt_method other than vox, which keys nothing")
+		}
+		if err := inRange("hardware amp_gpio_pin", c.Hardware.AmpGPIOPin, 0, maxGPIOPin); err != nil {
+			return err
+		}
+		if c.Hardware.AmpGPIOPin == c.Hardware.PTTGPIOPin || c.Hardware.AmpGPIOPin == c.Hardware.StatusLEDPin {
+			return fmt.Errorf("hardware amp_gpio_pin (%d) is already the PTT or status LED pin", c.Hardware.AmpGPIOPin)
+		}
+		if c.Hardware.AmpLead < 0 || c.Hardware.AmpLead > 1 || c.Hardware.AmpTail < 0 || c.Hardware.AmpTail > 1 {
+			return fmt.Errorf("hardware amp_lead and amp_tail (%.2f, %.2f) must be between 0 and 1 second", c.Hardware.AmpLead, c.Hardware.AmpTail)
+		}
+	}
+	if c.Hardware.EnableOLED {
+		if err := inRange("hardware oled_i2c_address", c.Hardware.OLEDI2CAddress, 0x03, 0x77); err != nil {
+			return err
+		}
+		if c.Hardware.OLEDWidth <= 0 || c.Hardware.OLEDHeight <= 0 {
+			return fmt.Errorf("hardware oled_width and oled_height (%dx%d) must be positive", c.Hardware.OLEDWidth, c.Hardware.OLEDHeight)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestDefaultYAML(t *testing.T) {
+	dumped := loadTestConfig(t, string(DefaultYAML))
+	if err := dumped.Validate(); err != nil {
+		t.Fatalf("Default config does not validate: %v", err)
+	}
+
+	minimal := loadTestConfig(t, "station:\n  callsign: N0CALL\n  grid: FN20\n")
+	if err := minimal.Validate(); err != nil {
+		t.Fatalf("Minimal config does not validate: %v", err)
+	}
+
+	// The dump must show the defaults LoadConfig really applies
+	want, _ := yaml.Marshal(minimal)
+	got, _ := yaml.Marshal(dumped)
+	if string(got) != string(want) {
+		t.Errorf("Default YAML is out of date with LoadConfig\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestShippedConfigs(t *testing.T) {
+	files, err := filepath.Glob("../../configs/*.yaml")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("Expected configs to test, got %v (%v)", files, err)
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			cfg, err := LoadConfig(file)
+			if err != nil {
+				t.Fatalf("Failed to load: %v", err)
+			}
+			// A config shipped with the placeholder callsign says to set it
+			if cfg.Station.Callsign == placeholderCallsign {
+				if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "set station.callsign") {
+					t.Errorf("Expected to be told to set the callsign, got %v", err)
+				}
+				cfg.Station.Callsign = "K3DEP"
+			}
+			if err := cfg.Validate(); err != nil {
+				t.Errorf("Does not validate: %v", err)
+			}
+		})
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"Misspelled Key", "radio:\n  baudrate: 9600\n", "unknown config key radio.baudrate (did you mean baud_rate?)"},
+		{"Unknown Section", "stations:\n  callsign: K3DEP\n", "unknown config key stations (did you mean station?)"},
+		{"No Suggestion", "web:\n  theme: dark\n", "unknown config key web.theme"},
+		{"Aggregator Node", "aggregator:\n  nodes:\n    - name: shack\n      host: example.com\n", "aggregator.nodes[0].host"},
+		{"Instance Override", "instances:\n  - name: 20m\n    radio: {devise: /dev/ttyUSB0}\n", "instances[0].radio.devise (did you mean device?)"},
+		{"Several Keys", "web:\n  prot: 80\nradio:\n  modell: \"1\"\n", "unknown config keys radio.modell (did you mean model?), web.prot (did you mean port?)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			_, err := LoadConfig(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// A wrong type names the line
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte("web:\n  port: fast\n"), 0644)
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected a type error for line 2, got %v", err)
+	}
+}
+
+func TestValidateRanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{"Defaults", func(c *Config) {}, ""},
+		{"Web Port Too High", func(c *Config) { c.Web.Port = 70000 }, "web port (70000) must be between 1 and 65535"},
+		{"Unknown Web Language", func(c *Config) { c.Web.Language = "fr" }, "web language must be en, de, es or ja"},
+		{"Negative WebSocket Port", func(c *Config) { c.API.WebSocketPort = -1 }, "api websocket_port"},
+		{"Bad gRPC Address", func(c *Config) { c.API.GRPCAddress = "localhost" }, "must be host:port"},
+		{"Bad gRPC Port", func(c *Config) { c.API.GRPCAddress = ":grpc" }, "invalid port"},
+		{"Odd Baud Rate", func(c *Config) { c.Radio.BaudRate = 115000 }, "radio baud_rate (115000)"},
+		{"Slow Baud Rate", func(c *Config) { c.Radio.BaudRate = 4800 }, ""},
+		{"Unknown Handshake", func(c *Config) { c.Radio.Handshake = "rtscts" }, "radio handshake must be default, none, xon_xoff or hardware"},
+		{"Unknown PTT Method", func(c *Config) { c.Radio.PTTMethod = "foot" }, "radio ptt_method"},
+		{"Long TX Delay", func(c *Config) { c.Radio.TxDelay = 10 }, "radio tx_delay"},
+		{"Long TX Tail", func(c *Config) { c.Radio.TxTail = 6 }, "radio tx_tail"},
+		{"Long VOX Leader", func(c *Config) { c.Radio.VOXLeader = 2 }, "radio vox_leader"},
+		{"Loud Output", func(c *Config) { c.Audio.OutputGain = 3 }, "audio output_gain"},
+		{"Device Sample Rate", func(c *Config) {
+			c.Audio.Devices = map[string]AudioDeviceSettings{"plughw:1,0": {SampleRate: 1000}}
+		}, "audio devices[plughw:1,0] sample_rate"},
+		{"Right Input", func(c *Config) { c.Audio.InputChannels = "stereo"; c.Audio.InputChannel = "right" }, ""},
+		{"Summed Output", func(c *Config) { c.Audio.OutputChannels = "stereo"; c.Audio.OutputChannel = "sum" }, "audio output_channel"},
+		{"Surround Output", func(c *Config) { c.Audio.OutputChannels = "5.1" }, "audio output_channels"},
+		{"Device Input Channel", func(c *Config) {
+			c.Audio.Devices = map[string]AudioDeviceSettings{"plughw:1,0": {InputChannel: "both"}}
+		}, "audio devices[plughw:1,0] input_channel"},
+		{"Amp Keying", func(c *Config) { c.Hardware.AmpKeying = true }, ""},
+		{"Amp On PTT Pin", func(c *Config) { c.Hardware.AmpKeying = true; c.Hardware.AmpGPIOPin = 18 }, "hardware amp_gpio_pin (18)"},
+		{"Amp Keying With VOX", func(c *Config) { c.Hardware.AmpKeying = true; c.Radio.PTTMethod = "vox" }, "hardware amp_keying"},
+		{"Long Amp Tail", func(c *Config) { c.Hardware.AmpKeying = true; c.Hardware.AmpTail = 2 }, "hardware amp_lead and amp_tail"},
+		{"Time Source", func(c *Config) { c.TimeSync.Enabled = true; c.TimeSync.Source = "gps" }, "time_sync source"},
+		{"Time From GPS", func(c *Config) { c.TimeSync.Enabled = true; c.TimeSync.Source = "gps" }, "time_sync source gps needs gps enabled"},
+		{"GPS Address", func(c *Config) { c.GPS.Enabled = true; c.GPS.Address = "localhost" }, "gps address"},
+		{"Form Code", func(c *Config) {
+			c.Forms.Templates = []FormTemplate{{Code: "SIT-REP", Fields: []FormField{{Name: "shelter"}}}}
+		}, "forms template 1: code"},
+		{"File Size", func(c *Config) { c.Files.Enabled = true; c.Files.MaxSize = 5000 }, "files max_size"},
+		{"File Poll Timeout", func(c *Config) { c.Files.Enabled = true; c.Files.PollTimeout = 5 }, "files poll_timeout"},
+		{"ARQ Window", func(c *Config) { c.ARQ.Enabled = true; c.ARQ.Window = 17 }, "arq window"},
+		{"ARQ Timeout", func(c *Config) { c.ARQ.Enabled = true; c.ARQ.Window = 4; c.ARQ.Timeout = 5 }, "arq timeout"},
+		{"Form Fields", func(c *Config) { c.Forms.Templates = []FormTemplate{{Code: "SITREP"}} }, "forms template SITREP has no fields"},
+		{"Form Field Name", func(c *Config) {
+			c.Forms.Templates = []FormTemplate{{Code: "SITREP", Fields: []FormField{{Name: "Shelter Name"}}}}
+		}, "forms template SITREP: field name"},
+		{"Time Offset", func(c *Config) { c.TimeSync.Enabled = true; c.TimeSync.MaxOffset = 20 }, "time_sync max_offset"},
+		{"Negative Calibration", func(c *Config) { c.Radio.CalibrationPPM = -2.5 }, ""},
+		{"Large Calibration", func(c *Config) { c.Radio.CalibrationPPM = 250 }, "radio calibration_ppm"},
+		{"Tune Carrier", func(c *Config) { c.Radio.TuneBeforeTX = "Carrier"; c.Radio.TunePower = 10 }, ""},
+		{"Unknown Tune Method", func(c *Config) { c.Radio.TuneBeforeTX = "manual" }, "radio tune_before_tx"},
+		{"Tune Power", func(c *Config) { c.Radio.TunePower = 150 }, "radio tune_power"},
+		{"Long Tune", func(c *Config) { c.Radio.TuneSeconds = 60 }, "radio tune_seconds"},
+		{"Log Level Case", func(c *Config) { c.Logging.Level = "DEBUG" }, ""},
+		{"Unknown Log Level", func(c *Config) { c.Logging.Level = "trace" }, "logging level"},
+		{"Component Log Level", func(c *Config) { c.Logging.Levels = map[string]string{"dsp": "debug"} }, ""},
+		{"Unknown Log Component", func(c *Config) { c.Logging.Levels = map[string]string{"decoder": "debug"} }, "logging levels component"},
+		{"Unknown Component Level", func(c *Config) { c.Logging.Levels = map[string]string{"web": "loud"} }, "logging levels web"},
+		{"Sample Rate", func(c *Config) { c.Audio.SampleRate = 1000 }, "audio sample_rate"},
+		{"GPIO Pin", func(c *Config) { c.Hardware.PTTGPIOPin = 40 }, "hardware ptt_gpio_pin (40) must be between 0 and 27"},
+		{"Shared GPIO Pin", func(c *Config) {
+			c.Hardware.EnableGPIO = true
+			c.Hardware.StatusLEDPin = c.Hardware.PTTGPIOPin
+		}, "are both GPIO 18"},
+		{"OLED Address", func(c *Config) {
+			c.Hardware.EnableOLED = true
+			c.Hardware.OLEDI2CAddress = 0x80
+		}, "hardware oled_i2c_address"},
+		{"Antenna Ports", func(c *Config) {
+			c.Antenna.Switch = "GPIO"
+			c.Antenna.Ports = []AntennaPort{{Name: "beam", Bands: []string{"20M"}, Pin: 5}, {Name: "dipole", Bands: []string{"40m"}, Pin: 6}}
+		}, ""},
+		{"Antenna Switch", func(c *Config) { c.Antenna.Switch = "usb" }, "antenna switch must be gpio or relay"},
+		{"Relay Device", func(c *Config) { c.Antenna.Switch = "relay" }, "antenna device is required"},
+		{"Antenna Band Twice", func(c *Config) {
+			c.Antenna.Switch = "gpio"
+			c.Antenna.Ports = []AntennaPort{{Name: "beam", Bands: []string{"20m"}, Pin: 5}, {Name: "dipole", Bands: []string{"20m"}, Pin: 6}}
+		}, "band 20m is already on port beam"},
+		{"Antenna On PTT Pin", func(c *Config) {
+			c.Hardware.EnableGPIO = true
+			c.Antenna.Switch = "gpio"
+			c.Antenna.Ports = []AntennaPort{{Name: "beam", Pin: 18}}
+		}, "antenna ports[0]: pin 18"},
+		{"Antenna Relay", func(c *Config) {
+			c.Antenna.Switch = "relay"
+			c.Antenna.Device = "/dev/ttyUSB0"
+			c.Antenna.Ports = []AntennaPort{{Name: "beam", Relay: 9}}
+		}, "antenna ports[0] relay"},
+		{"Antenna Default", func(c *Config) {
+			c.Antenna.Switch = "gpio"
+			c.Antenna.Default = "vertical"
+			c.Antenna.Ports = []AntennaPort{{Name: "beam", Pin: 5}}
+		}, "antenna default"},
+		{"Sensors", func(c *Config) {
+			c.Sensors.Enabled = true
+			c.Sensors.INA219Address = 0x40
+			c.Sensors.MinVoltage = 11.8
+		}, ""},
+		{"No Sensors", func(c *Config) { c.Sensors.Enabled = true }, "sensors need an ina219_address"},
+		{"INA219 Address", func(c *Config) {
+			c.Sensors.Enabled = true
+			c.Sensors.INA219Address = 0x3C
+		}, "sensors ina219_address"},
+		{"Min Voltage Without INA219", func(c *Config) {
+			c.Sensors.Enabled = true
+			c.Sensors.Temperature = true
+			c.Sensors.MinVoltage = 11.8
+		}, "sensors min_voltage needs"},
+		{"Low Power Hours", func(c *Config) { c.Power.LowPowerHours = []string{"22:00-06:00", "12:30-13:00"} }, ""},
+		{"Bad Low Power Hours", func(c *Config) { c.Power.LowPowerHours = []string{"22-06"} }, "power low_power_hours[0]"},
+		{"Decode Level", func(c *Config) { c.Power.DecodeLevel = 4 }, "power decode_level"},
+		{"Negative Max Messages", func(c *Config) { c.Storage.MaxMessages = -1 }, "storage max_messages"},
+		{"Unknown Storage Backend", func(c *Config) { c.Storage.Backend = "mysql" }, "storage backend"},
+		{"Postgres Without DSN", func(c *Config) { c.Storage.Backend = "postgres" }, "storage dsn is required"},
+		{"Snippets Too Long", func(c *Config) { c.Storage.SnippetSeconds = 30 }, "storage snippet_seconds"},
+		{"Unknown Recording Codec", func(c *Config) { c.Storage.RecordingCodec = "mp3" }, "storage recording_codec"},
+		{"Recording Bitrate Too Low", func(c *Config) { c.Storage.RecordingBitrate = 1000 }, "storage recording_bitrate"},
+		{"Check Interval Too Low", func(c *Config) { c.Storage.CheckInterval = -2 }, "storage check_interval"},
+		{"Backup Over Database", func(c *Config) { c.Storage.DatabasePath, c.Storage.BackupPath = "js8d.db", "js8d.db" }, "storage backup_path"},
+		{"ACK Retries", func(c *Config) { c.Messages.AckRetries = 11 }, "messages ack_retries"},
+		{"Escalate", func(c *Config) { c.Messages.Escalate = "louder" }, "messages escalate"},
+		{"Escalate After", func(c *Config) { c.Messages.Escalate = "submode"; c.Messages.EscalateAfter = 11 }, "messages escalate_after"},
+		{"Escalate Offset", func(c *Config) {
+			c.Messages.Escalate = "both"
+			c.Messages.EscalateAfter = 1
+			c.Messages.EscalateOffset = 5
+		}, "messages escalate_offset"},
+		{"QSO Retries", func(c *Config) { c.QSO.Enabled = true; c.QSO.Retries = 6 }, "qso retries"},
+		{"TX Offset", func(c *Config) { c.TX.Offset = 2900 }, "tx offset"},
+		{"TX Busy Policy", func(c *Config) { c.TX.Busy = "skip" }, "tx busy"},
+		{"Negative Transverter Offset", func(c *Config) { c.Radio.TransverterOffset = -1 }, "radio transverter_offset"},
+		{"Transverter Offset", func(c *Config) { c.Radio.TransverterOffset = 1268000000 }, ""},
+		{"Radio Backend", func(c *Config) { c.Radio.Backend = "rigctld" }, "radio backend"},
+		{"Native Radio Backend", func(c *Config) {
+			c.Radio.Backend = "native"
+			c.Radio.CATProtocol = "civ"
+			c.Radio.Device = "/dev/ttyUSB0"
+		}, ""},
+		{"Native Radio Backend Without Device", func(c *Config) { c.Radio.Backend = "native"; c.Radio.Device = "" }, "radio device"},
+		{"CAT Protocol", func(c *Config) { c.Radio.CATProtocol = "yaesu" }, "radio cat_protocol"},
+		{"TCP Radio Device", func(c *Config) { c.Radio.Device = "tcp://192.168.1.50:4000" }, ""},
+		{"TCP Radio Device Without Port", func(c *Config) { c.Radio.Device = "tcp://192.168.1.50" }, "radio device"},
+		{"TX Out Of Band", func(c *Config) { c.TX.OutOfBand = "ignore" }, "tx out_of_band"},
+		{"TX Self Decode", func(c *Config) { c.TX.SelfDecode = "drop" }, "tx self_decode"},
+		{"Net Group", func(c *Config) { c.Net.Group = "ARESNET" }, "net group"},
+		{"Net Schedule", func(c *Config) { c.Net.Group, c.Net.Schedule = "@ARESNET", []string{"Tue 7pm"} }, "net schedule[0]"},
+		{"Net Schedule Day", func(c *Config) { c.Net.Group, c.Net.Schedule = "@ARESNET", []string{"Tu 19:00"} }, "net schedule[0]"},
+		{"Net Schedule Group", func(c *Config) { c.Net.Schedule = []string{"19:00"} }, "net schedule needs"},
+		{"Net Duration", func(c *Config) { c.Net.Duration = 2 }, "net duration"},
+		{"Beacon Interval", func(c *Config) { c.Beacon.Enabled, c.Beacon.Interval = true, 1 }, "beacon interval"},
+		{"Beacon Jitter", func(c *Config) { c.Beacon.Enabled, c.Beacon.Jitter = true, 1000 }, "beacon jitter"},
+		{"Beacon Text", func(c *Config) { c.Beacon.Enabled, c.Beacon.Text = true, " " }, "beacon text"},
+		{"Beacon ID Interval", func(c *Config) { c.Beacon.IDInterval = 90 }, "beacon id_interval"},
+		{"Beacon ID Text", func(c *Config) { c.Beacon.IDInterval, c.Beacon.IDText = 10, "QRZ?" }, "beacon id_text"},
+		{"Submode Default", func(c *Config) { c.Submode.Default = "ultra" }, "submode default"},
+		{"Submode Window", func(c *Config) { c.Submode.Adaptive, c.Submode.Window = true, 2000 }, "submode window"},
+		{"Submode Thresholds", func(c *Config) { c.Submode.Adaptive, c.Submode.Fast = true, -25 }, "submode fast"},
+		{"Telemetry Interval", func(c *Config) { c.Telemetry.Enabled, c.Telemetry.Interval = true, 5 }, "telemetry interval"},
+		{"Telemetry Fields", func(c *Config) { c.Telemetry.Fields = []string{"voltage", "humidity"} }, "telemetry fields"},
+		{"Telemetry No Fields", func(c *Config) { c.Telemetry.Enabled, c.Telemetry.Fields = true, []string{} }, "telemetry fields"},
+		{"Telemetry Keep Days", func(c *Config) { c.Telemetry.KeepDays = -1 }, "telemetry keep_days"},
+		{"Station Region", func(c *Config) { c.Station.Region = 4 }, "station region"},
+		{"Station License", func(c *Config) { c.Station.License = "novice" }, "station license"},
+		{"Station License Class", func(c *Config) { c.Station.License = "General" }, ""},
+		{"TX Find Range", func(c *Config) { c.TX.FindLow, c.TX.FindHigh = 1500, 1520 }, "tx find_high"},
+		{"Decode Passband", func(c *Config) { c.DSP.DecodeLow, c.DSP.DecodeHigh = 2000, 1000 }, "dsp decode_high"},
+		{"Decode Passband Low", func(c *Config) { c.DSP.DecodeLow, c.DSP.DecodeHigh = 100, 2500 }, "dsp decode_low"},
+		{"TX Busy Level", func(c *Config) { c.TX.BusyLevel = 50 }, "tx busy_level"},
+		{"Rate Limit Burst", func(c *Config) { c.RateLimit.CallsignBurst = 101 }, "rate_limit callsign_burst"},
+		{"Rate Limit Per Hour", func(c *Config) { c.RateLimit.GlobalPerHour = -2 }, "rate_limit global_per_hour"},
+		{"Answer Per Hour", func(c *Config) { c.Answer.Enabled = true; c.Answer.PerHour = 61 }, "answer per_hour"},
+		{"eQSL Password", func(c *Config) { c.QSL.EQSL.Username = "K1ABC" }, "qsl eqsl password"},
+		{"Log Broadcast Address", func(c *Config) { c.LogBroadcast.Targets = []LogTarget{{Address: "localhost"}} }, "log_broadcast target 1"},
+		{"Log Broadcast Format", func(c *Config) { c.LogBroadcast.Targets = []LogTarget{{Address: "localhost:12060", Format: "json"}} }, "log_broadcast target 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadTestConfig(t, string(DefaultYAML))
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
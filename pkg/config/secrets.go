@@ -0,0 +1,404 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v2"
+)
+
+// RedactedValue stands in for secrets in configuration served over the API
+const RedactedValue = "********"
+
+// SecretsPassphraseEnv holds the passphrase of the secrets file when
+// secrets.passphrase_file is not set
+const SecretsPassphraseEnv = "JS8D_SECRETS_PASSPHRASE"
+
+const secretPrefix = "secret:"
+
+var envReference = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+var secretType = reflect.TypeOf(Secret{})
+
+// Secret is a password, API key or other credential setting. In the file it
+// is either the value itself, "${NAME}" to read the environment variable
+// NAME, or "secret:name" to read an entry of the encrypted secrets file.
+// Saving the configuration writes the reference back, never the value it
+// resolved to, and printing a secret shows RedactedValue.
+type Secret struct {
+	ref   string
+	value string
+}
+
+// NewSecret returns a secret holding value literally
+func NewSecret(value string) Secret {
+	return Secret{ref: value, value: value}
+}
+
+// Value returns the credential, resolved when the configuration was loaded
+func (s Secret) Value() string {
+	return s.value
+}
+
+// IsSet reports whether the setting has a value or reference
+func (s Secret) IsSet() bool {
+	return s.ref != ""
+}
+
+// String hides the value so secrets don't end up in logs
+func (s Secret) String() string {
+	if s.ref == "" {
+		return ""
+	}
+	return RedactedValue
+}
+
+// IsZero lets omitempty leave out secrets that aren't set
+func (s Secret) IsZero() bool {
+	return s.ref == ""
+}
+
+// MarshalYAML writes the reference, not the resolved value
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.ref, nil
+}
+
+// UnmarshalYAML reads the value or reference; LoadConfig resolves it
+func (s *Secret) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var ref string
+	if err := unmarshal(&ref); err != nil {
+		return err
+	}
+	*s = NewSecret(ref)
+	return nil
+}
+
+// MarshalJSON hides the value
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// isSecretReference reports whether a setting names where its value is kept
+// rather than holding it
+func isSecretReference(ref string) bool {
+	return envReference.MatchString(ref) || strings.HasPrefix(ref, secretPrefix)
+}
+
+// resolve looks up the value of an environment or secrets file reference
+func (s *Secret) resolve(lookup func(name string) (string, error)) error {
+	if m := envReference.FindStringSubmatch(s.ref); m != nil {
+		value, ok := os.LookupEnv(m[1])
+		if !ok {
+			return fmt.Errorf("environment variable %s is not set", m[1])
+		}
+		s.value = value
+		return nil
+	}
+	if name, ok := strings.CutPrefix(s.ref, secretPrefix); ok {
+		value, err := lookup(name)
+		if err != nil {
+			return err
+		}
+		s.value = value
+		return nil
+	}
+	s.value = s.ref
+	return nil
+}
+
+// eachSecret calls fn with the dotted path of every Secret in v, which must
+// be addressable
+func eachSecret(v reflect.Value, path string, fn func(path string, s *Secret) error) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return eachSecret(v.Elem(), path, fn)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := eachSecret(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		if v.Type() == secretType {
+			return fn(path, v.Addr().Interface().(*Secret))
+		}
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			if err := eachSecret(v.Field(i), join(path, name), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveSecrets fills in every secret setting, unlocking the secrets file
+// the first time one refers to it
+func (c *Config) resolveSecrets() error {
+	return eachSecret(reflect.ValueOf(c).Elem(), "", func(path string, s *Secret) error {
+		if err := s.resolve(c.lookupSecret); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// lookupSecret returns an entry of the secrets file
+func (c *Config) lookupSecret(name string) (string, error) {
+	if c.secrets == nil {
+		passphrase, err := c.SecretsPassphrase()
+		if err != nil {
+			return "", err
+		}
+		secrets, err := ReadSecretsFile(c.Secrets.File, passphrase)
+		if err != nil {
+			return "", err
+		}
+		c.secrets = secrets
+	}
+
+	value, ok := c.secrets[name]
+	if !ok {
+		return "", fmt.Errorf("no secret named %q in %s", name, c.Secrets.File)
+	}
+	return value, nil
+}
+
+// SecretsPassphrase returns the passphrase that unlocks the secrets file
+func (c *Config) SecretsPassphrase() (string, error) {
+	if c.Secrets.File == "" {
+		return "", fmt.Errorf("secrets file is not set, add secrets.file to the configuration")
+	}
+	if c.Secrets.PassphraseFile != "" {
+		data, err := os.ReadFile(c.Secrets.PassphraseFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secrets passphrase: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	if passphrase := os.Getenv(SecretsPassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	return "", fmt.Errorf("set %s or secrets.passphrase_file to unlock %s", SecretsPassphraseEnv, c.Secrets.File)
+}
+
+// RedactedYAML returns the configuration as YAML with every secret written
+// into the file replaced by RedactedValue. References to the environment
+// or the secrets file are kept; they give nothing away.
+func (c *Config) RedactedYAML() ([]byte, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	redactSecrets(raw, reflect.TypeOf(Config{}))
+	return yaml.Marshal(raw)
+}
+
+// redactSecrets walks a decoded YAML value alongside its Go type and blanks
+// the secrets held literally
+func redactSecrets(value interface{}, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		items, _ := value.([]interface{})
+		for _, item := range items {
+			redactSecrets(item, t.Elem())
+		}
+	case reflect.Struct:
+		section, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return
+		}
+		for k, v := range section {
+			field, ok := fieldType(t, fmt.Sprint(k))
+			if !ok {
+				continue
+			}
+			if field == secretType {
+				if ref, _ := v.(string); ref != "" && !isSecretReference(ref) {
+					section[k] = RedactedValue
+				}
+				continue
+			}
+			redactSecrets(v, field)
+		}
+	}
+}
+
+// SecretsStore is an unlocked secrets file opened for editing
+type SecretsStore struct {
+	Path    string
+	Secrets map[string]string
+
+	passphrase string
+}
+
+// OpenSecretsStore unlocks the secrets file named by a configuration file.
+// Only the secrets section is read, so a setting may refer to an entry
+// before it has been added.
+func OpenSecretsStore(configPath string) (*SecretsStore, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	passphrase, err := cfg.SecretsPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := ReadSecretsFile(cfg.Secrets.File, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretsStore{Path: cfg.Secrets.File, Secrets: secrets, passphrase: passphrase}, nil
+}
+
+// Save encrypts the entries back into the secrets file
+func (s *SecretsStore) Save() error {
+	return WriteSecretsFile(s.Path, s.passphrase, s.Secrets)
+}
+
+// Encrypted secrets file layout. The entries are a YAML map sealed with
+// AES-256-GCM under a key derived from the passphrase with scrypt.
+type secretsFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+const secretsFileVersion = 1
+
+func secretsKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+}
+
+// ReadSecretsFile decrypts a secrets file. A missing file holds no secrets.
+func ReadSecretsFile(path, passphrase string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	var file secretsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets file %s: %w", path, err)
+	}
+	if file.Version != secretsFileVersion {
+		return nil, fmt.Errorf("secrets file %s has unsupported version %d", path, file.Version)
+	}
+
+	key, err := secretsKey(passphrase, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("secrets file %s is damaged", path)
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock secrets file %s: wrong passphrase or damaged file", path)
+	}
+
+	secrets := map[string]string{}
+	if err := yaml.Unmarshal(plain, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets file %s: %w", path, err)
+	}
+	return secrets, nil
+}
+
+// WriteSecretsFile encrypts secrets into path, readable by the owner only
+func WriteSecretsFile(path, passphrase string, secrets map[string]string) error {
+	if passphrase == "" {
+		return fmt.Errorf("secrets passphrase must not be empty")
+	}
+	plain, err := yaml.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	file := secretsFile{
+		Version: secretsFileVersion,
+		Salt:    make([]byte, 16),
+	}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return err
+	}
+	key, err := secretsKey(passphrase, file.Salt)
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plain, nil)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Replace the file in one step so a failed write can't lose the secrets
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".secrets-*")
+	if err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+type testCredentials struct {
+	Name     string `yaml:"name"`
+	Password Secret `yaml:"password"`
+	Token    Secret `yaml:"token"`
+}
+
+func TestSecretReferences(t *testing.T) {
+	t.Setenv("JS8D_TEST_TOKEN", "s3cret")
+
+	var creds testCredentials
+	if err := yaml.Unmarshal([]byte("password: hunter2\ntoken: ${JS8D_TEST_TOKEN}\n"), &creds); err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	lookup := func(name string) (string, error) { return "", nil }
+	if err := creds.Token.resolve(lookup); err != nil {
+		t.Fatalf("Failed to resolve: %v", err)
+	}
+	if creds.Password.Value() != "hunter2" || creds.Token.Value() != "s3cret" {
+		t.Errorf("Expected literal and environment values, got %q and %q", creds.Password.Value(), creds.Token.Value())
+	}
+
+	// Saving keeps the reference
+	data, _ := yaml.Marshal(creds)
+	if !strings.Contains(string(data), "${JS8D_TEST_TOKEN}") || strings.Contains(string(data), "s3cret") {
+		t.Errorf("Expected the reference written back, got:\n%s", data)
+	}
+
+	// Printing hides the value
+	encoded, _ := json.Marshal(creds)
+	if strings.Contains(string(encoded), "hunter2") || creds.Password.String() != RedactedValue {
+		t.Errorf("Expected secrets hidden, got %s", encoded)
+	}
+
+	missing := NewSecret("${JS8D_TEST_UNSET}")
+	if err := missing.resolve(lookup); err == nil {
+		t.Error("Expected an error for an unset environment variable")
+	}
+}
+
+func TestSecretsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	if err := WriteSecretsFile(path, "passphrase", map[string]string{"qrz": "letmein"}); err != nil {
+		t.Fatalf("Failed to write secrets file: %v", err)
+	}
+
+	if _, err := ReadSecretsFile(path, "wrong"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("Expected a wrong passphrase error, got %v", err)
+	}
+
+	cfg := &Config{}
+	cfg.Secrets.File = path
+	if _, err := cfg.lookupSecret("qrz"); err == nil {
+		t.Error("Expected an error without a passphrase")
+	}
+
+	t.Setenv(SecretsPassphraseEnv, "passphrase")
+	secret := NewSecret("secret:qrz")
+	if err := secret.resolve(cfg.lookupSecret); err != nil || secret.Value() != "letmein" {
+		t.Errorf("Expected letmein from the secrets file, got %q (%v)", secret.Value(), err)
+	}
+	if _, err := cfg.lookupSecret("aprs"); err == nil {
+		t.Error("Expected an error for a missing secret")
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	var raw interface{}
+	yaml.Unmarshal([]byte("name: shack\npassword: hunter2\ntoken: secret:api\n"), &raw)
+
+	redactSecrets(raw, reflect.TypeOf(testCredentials{}))
+	section := raw.(map[interface{}]interface{})
+	if section["password"] != RedactedValue {
+		t.Errorf("Expected the literal password redacted, got %v", section["password"])
+	}
+	if section["token"] != "secret:api" || section["name"] != "shack" {
+		t.Errorf("Expected references and other settings kept, got %v", section)
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import "fmt"
+
+const (
+	// DefaultSensorInterval is how often, in seconds, the sensors are read
+	DefaultSensorInterval = 60
+	// DefaultSensorI2CBus is the I2C bus on a Raspberry Pi's header
+	DefaultSensorI2CBus = 1
+	// DefaultShuntOhms is the shunt resistor on common INA219 boards
+	DefaultShuntOhms = 0.1
+	// SensorVoltageHysteresis is how far, in volts, the battery must climb
+	// above sensors min_voltage before TX resumes, so a battery hovering at
+	// the threshold doesn't key up and drop out again
+	SensorVoltageHysteresis = 0.2
+)
+
+// validateSensors checks the sensor settings
+func (c *Config) validateSensors() error {
+	if !c.Sensors.Enabled {
+		return nil
+	}
+	if c.Sensors.INA219Address == 0 && !c.Sensors.Temperature {
+		return fmt.Errorf("sensors need an ina219_address or temperature enabled")
+	}
+	if err := inRange("sensors interval", c.Sensors.Interval, 5, 3600); err != nil {
+		return err
+	}
+	if c.Sensors.INA219Address != 0 {
+		if err := inRange("sensors i2c_bus", c.Sensors.I2CBus, 0, 255); err != nil {
+			return err
+		}
+		// The INA219's two address pins give 0x40 to 0x4F
+		if err := inRange("sensors ina219_address", c.Sensors.INA219Address, 0x40, 0x4F); err != nil {
+			return err
+		}
+		if c.Hardware.EnableOLED && c.Hardware.OLEDI2CAddress == c.Sensors.INA219Address {
+			return fmt.Errorf("sensors ina219_address 0x%02x is already the OLED's", c.Sensors.INA219Address)
+		}
+		if c.Sensors.ShuntOhms <= 0 || c.Sensors.ShuntOhms > 10 {
+			return fmt.Errorf("sensors shunt_ohms (%g) must be more than 0 and at most 10", c.Sensors.ShuntOhms)
+		}
+	}
+	if c.Sensors.MinVoltage < 0 || c.Sensors.MinVoltage > 32 {
+		return fmt.Errorf("sensors min_voltage (%.1f) must be between 0 and 32 volts", c.Sensors.MinVoltage)
+	}
+	if c.Sensors.MinVoltage > 0 && c.Sensors.INA219Address == 0 {
+		return fmt.Errorf("sensors min_voltage needs an ina219_address to measure the battery")
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Spot forwarding defaults
+const (
+	DefaultSpotBatchSize     = 50
+	DefaultSpotBatchInterval = 30 // seconds
+	DefaultSpotRetries       = 3
+)
+
+// Webhook is a URL decodes are POSTed to as JSON:
+//
+//	spots:
+//	  webhooks:
+//	    - url: https://example.com/js8/spots
+//	      secret: "${SPOT_SECRET}"
+type Webhook struct {
+	URL    string `yaml:"url"`
+	Secret Secret `yaml:"secret,omitempty"` // signs each batch with HMAC-SHA256 when set
+}
+
+// validateSpots checks the webhooks decodes are forwarded to
+func (c *Config) validateSpots() error {
+	for i, hook := range c.Spots.Webhooks {
+		u, err := url.Parse(hook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("spots webhook %d: url %q must be an http or https URL", i+1, hook.URL)
+		}
+	}
+	if err := inRange("spots batch_size", c.Spots.BatchSize, 0, 1000); err != nil {
+		return err
+	}
+	if err := inRange("spots batch_interval", c.Spots.BatchInterval, 0, 3600); err != nil {
+		return err
+	}
+	return inRange("spots retries", c.Spots.Retries, -1, 10)
+}
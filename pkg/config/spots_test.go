@@ -0,0 +1,53 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateSpots(t *testing.T) {
+	t.Setenv("SPOT_SECRET", "s3cret")
+	cfg := loadTestConfig(t, sharedConfig+`
+spots:
+  webhooks:
+    - url: https://example.com/spots
+      secret: "${SPOT_SECRET}"
+    - url: http://192.168.1.5:1880/js8
+`)
+	if cfg.Spots.BatchSize != DefaultSpotBatchSize || cfg.Spots.BatchInterval != DefaultSpotBatchInterval ||
+		cfg.Spots.Retries != DefaultSpotRetries {
+		t.Errorf("Expected default batching and retries, got %+v", cfg.Spots)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected valid spots config, got %v", err)
+	}
+	if cfg.Spots.Webhooks[0].Secret.Value() != "s3cret" || cfg.Spots.Webhooks[1].Secret.IsSet() {
+		t.Errorf("Unexpected webhook secrets %+v", cfg.Spots.Webhooks)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string
+	}{
+		{"Not HTTP", func(c *Config) { c.Spots.Webhooks[1].URL = "ftp://example.com" }, "spots webhook 2"},
+		{"Batch size", func(c *Config) { c.Spots.BatchSize = 5000 }, "spots batch_size"},
+		{"Retries", func(c *Config) { c.Spots.Retries = -2 }, "spots retries"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadTestConfig(t, sharedConfig+`
+spots:
+  webhooks:
+    - url: https://example.com/spots
+    - url: http://192.168.1.5:1880/js8
+`)
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
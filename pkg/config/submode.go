@@ -0,0 +1,42 @@
+package config
+
+import "fmt"
+
+// Defaults for submode
+const (
+	DefaultSubmode           = "normal"
+	DefaultSubmodeWindow     = 30 // Minutes of decodes averaged
+	DefaultSubmodeMinDecodes = 1
+	DefaultSubmodeTurbo      = -6.0 // Average SNR in dB for Turbo
+	DefaultSubmodeFast       = -12.0
+	DefaultSubmodeNormal     = -20.0
+)
+
+// Submodes are the submodes messages can go out in, fastest first
+var Submodes = []string{"turbo", "fast", "normal", "slow"}
+
+// validateSubmode checks the submode settings
+func (c *Config) validateSubmode() error {
+	if c.Submode.Default != "" {
+		if err := oneOf("submode default", c.Submode.Default, Submodes...); err != nil {
+			return err
+		}
+	}
+	if !c.Submode.Adaptive {
+		return nil
+	}
+	if err := inRange("submode window", c.Submode.Window, 1, 1440); err != nil {
+		return err
+	}
+	if err := inRange("submode min_decodes", c.Submode.MinDecodes, 1, 100); err != nil {
+		return err
+	}
+	// Each faster submode needs a stronger signal
+	if c.Submode.Fast >= c.Submode.Turbo {
+		return fmt.Errorf("submode fast (%.1f dB) must be below submode turbo (%.1f dB)", c.Submode.Fast, c.Submode.Turbo)
+	}
+	if c.Submode.Normal >= c.Submode.Fast {
+		return fmt.Errorf("submode normal (%.1f dB) must be below submode fast (%.1f dB)", c.Submode.Normal, c.Submode.Fast)
+	}
+	return nil
+}
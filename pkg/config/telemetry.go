@@ -0,0 +1,35 @@
+package config
+
+import "fmt"
+
+// Defaults for telemetry
+const (
+	DefaultTelemetryInterval = 60 // Minutes between telemetry transmissions
+	DefaultTelemetryKeepDays = 90
+)
+
+// DefaultTelemetryFields are the readings telemetry sends by default
+var DefaultTelemetryFields = []string{"voltage", "temperature", "uptime"}
+
+// validateTelemetry checks the telemetry settings
+func (c *Config) validateTelemetry() error {
+	if c.Telemetry.Enabled {
+		if err := inRange("telemetry interval", c.Telemetry.Interval, 10, 1440); err != nil {
+			return err
+		}
+		if len(c.Telemetry.Fields) == 0 {
+			return fmt.Errorf("telemetry fields is empty")
+		}
+	}
+	for i, field := range c.Telemetry.Fields {
+		if err := oneOf(fmt.Sprintf("telemetry fields[%d]", i), field, "voltage", "current", "temperature", "uptime"); err != nil {
+			return err
+		}
+	}
+	if c.Telemetry.KeepDays != 0 {
+		if err := inRange("telemetry keep_days", c.Telemetry.KeepDays, 1, 3650); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultTimeSyncInterval is how often, in minutes, the clock is checked
+	DefaultTimeSyncInterval = 10
+	// DefaultTimeSyncMaxOffset is how many seconds the clock may be off
+	// before heartbeats stop. JS8 stops decoding at about two.
+	DefaultTimeSyncMaxOffset = 1.0
+)
+
+// validateTimeSync checks the clock check settings
+func (c *Config) validateTimeSync() error {
+	if !c.TimeSync.Enabled {
+		return nil
+	}
+	if err := oneOf("time_sync source", c.TimeSync.Source, "auto", "chrony", "ntp", "gps"); err != nil {
+		return err
+	}
+	if strings.EqualFold(c.TimeSync.Source, "gps") && !c.GPS.Enabled {
+		return fmt.Errorf("time_sync source gps needs gps enabled")
+	}
+	if err := inRange("time_sync interval", c.TimeSync.Interval, 1, 1440); err != nil {
+		return err
+	}
+	if c.TimeSync.MaxOffset <= 0 || c.TimeSync.MaxOffset > 15 {
+		return fmt.Errorf("time_sync max_offset (%.1f) must be more than 0 and at most 15 seconds", c.TimeSync.MaxOffset)
+	}
+	return nil
+}
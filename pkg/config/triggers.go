@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/triggers"
+)
+
+// DefaultSWRAlarm is the SWR that fires swr_alarm triggers
+const DefaultSWRAlarm = 3.0
+
+// Trigger is a request made when something happens at the station, for
+// home automation:
+//
+//	triggers:
+//	  hooks:
+//	    - event: directed
+//	      url: http://homeassistant.local:8123/api/webhook/js8-call
+//	      template: '{"from": {{json .From}}, "text": {{json .Message}}}'
+type Trigger struct {
+	Event       string   `yaml:"event"`                  // directed, heard, tx_started, tx_failed, swr_alarm or db_corrupt
+	Callsigns   []string `yaml:"callsigns,omitempty"`    // directed and heard: only these senders
+	URL         string   `yaml:"url"`                    // http or https URL to call
+	Method      string   `yaml:"method,omitempty"`       // POST, PUT or GET, POST when empty
+	ContentType string   `yaml:"content_type,omitempty"` // body type, application/json when empty
+	Template    string   `yaml:"template,omitempty"`     // Go template of the body, the event as JSON when empty
+	Token       Secret   `yaml:"token,omitempty"`        // bearer token, e.g. a Home Assistant access token
+	Cooldown    int      `yaml:"cooldown,omitempty"`     // minutes before the hook fires again for a sender
+}
+
+// validateTriggers checks the trigger hooks and their templates
+func (c *Config) validateTriggers() error {
+	for i, hook := range c.Triggers.Hooks {
+		name := fmt.Sprintf("trigger %d", i+1)
+		if err := oneOf(name+" event", hook.Event, triggers.Types...); err != nil {
+			return err
+		}
+		u, err := url.Parse(hook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s: url %q must be an http or https URL", name, hook.URL)
+		}
+		if hook.Method != "" {
+			if err := oneOf(name+" method", hook.Method, "POST", "PUT", "GET"); err != nil {
+				return err
+			}
+		}
+		if _, err := triggers.Parse(hook.Template); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err := inRange(name+" cooldown", hook.Cooldown, 0, 24*60); err != nil {
+			return err
+		}
+		c.Triggers.Hooks[i].Event = strings.ToLower(hook.Event)
+	}
+	if c.Triggers.SWRAlarm != 0 && (c.Triggers.SWRAlarm < 1 || c.Triggers.SWRAlarm > 10) {
+		return fmt.Errorf("triggers swr_alarm (%g) must be between 1 and 10", c.Triggers.SWRAlarm)
+	}
+	return nil
+}
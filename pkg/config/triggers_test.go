@@ -0,0 +1,56 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const triggersConfig = `
+triggers:
+  hooks:
+    - event: Directed
+      url: http://homeassistant.local:8123/api/webhook/js8-call
+      template: '{"from": {{json .From}}}'
+    - event: heard
+      callsigns: [W1AW]
+      url: https://maker.ifttt.com/trigger/w1aw/with/key/abc
+      method: get
+      cooldown: 60
+`
+
+func TestValidateTriggers(t *testing.T) {
+	cfg := loadTestConfig(t, sharedConfig+triggersConfig)
+	if cfg.Triggers.SWRAlarm != DefaultSWRAlarm {
+		t.Errorf("Expected default swr_alarm, got %g", cfg.Triggers.SWRAlarm)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected valid triggers config, got %v", err)
+	}
+	if cfg.Triggers.Hooks[0].Event != "directed" {
+		t.Errorf("Expected the event name lowercased, got %q", cfg.Triggers.Hooks[0].Event)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string
+	}{
+		{"Unknown event", func(c *Config) { c.Triggers.Hooks[0].Event = "sunrise" }, "trigger 1 event"},
+		{"Not HTTP", func(c *Config) { c.Triggers.Hooks[1].URL = "mqtt://broker" }, "trigger 2: url"},
+		{"Method", func(c *Config) { c.Triggers.Hooks[1].Method = "DELETE" }, "trigger 2 method"},
+		{"Template", func(c *Config) { c.Triggers.Hooks[0].Template = "{{.From" }, "trigger 1: bad template"},
+		{"Cooldown", func(c *Config) { c.Triggers.Hooks[1].Cooldown = -1 }, "trigger 2 cooldown"},
+		{"SWR alarm", func(c *Config) { c.Triggers.SWRAlarm = 0.5 }, "triggers swr_alarm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadTestConfig(t, sharedConfig+triggersConfig)
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
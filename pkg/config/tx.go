@@ -0,0 +1,83 @@
+package config
+
+import "fmt"
+
+// Policies for a busy TX offset, set with tx busy
+const (
+	BusyOff    = "off"    // Don't check the channel
+	BusyReport = "report" // Send anyway, reporting the channel busy
+	BusyWait   = "wait"   // Wait up to busy_wait cycles for the channel to clear
+	BusyMove   = "move"   // Move to the nearest clear offset, waiting when there is none
+)
+
+// What to do with a transmission outside the station region's band plan or
+// the station license class's data privileges, set with tx out_of_band
+const (
+	OutOfBandOff   = "off"   // Don't check
+	OutOfBandWarn  = "warn"  // Send anyway, warning about it
+	OutOfBandBlock = "block" // Refuse to send
+)
+
+// What to do with decodes of our own transmissions, picked up from the rig's
+// monitor audio, set with tx self_decode
+const (
+	SelfDecodeOff   = "off"   // Treat them as any other decode
+	SelfDecodeTag   = "tag"   // Tag them and keep them from the message store, replies and spots
+	SelfDecodeBlank = "blank" // Tag them, and blank the decoder's audio while keyed
+)
+
+// DefaultRegion is the ITU region used when station region is unset
+const DefaultRegion = 2
+
+// Defaults for tx
+const (
+	DefaultTXOffset   = 1500
+	DefaultBusyMargin = 10
+	DefaultBusyLevel  = 10.0
+	DefaultBusyWait   = 2
+	DefaultFindLow    = 500
+	DefaultFindHigh   = 2500
+)
+
+// validateTX checks the TX offset, busy channel, clear offset finder and
+// band plan settings
+func (c *Config) validateTX() error {
+	if c.TX.Offset != 0 {
+		if err := inRange("tx offset", c.TX.Offset, 200, 2750); err != nil {
+			return err
+		}
+	}
+	if c.TX.Busy != "" {
+		if err := oneOf("tx busy", c.TX.Busy, BusyOff, BusyReport, BusyWait, BusyMove); err != nil {
+			return err
+		}
+	}
+	if c.TX.OutOfBand != "" {
+		if err := oneOf("tx out_of_band", c.TX.OutOfBand, OutOfBandOff, OutOfBandWarn, OutOfBandBlock); err != nil {
+			return err
+		}
+	}
+	if c.TX.SelfDecode != "" {
+		if err := oneOf("tx self_decode", c.TX.SelfDecode, SelfDecodeOff, SelfDecodeTag, SelfDecodeBlank); err != nil {
+			return err
+		}
+	}
+	if err := inRange("tx busy_margin", c.TX.BusyMargin, 0, 200); err != nil {
+		return err
+	}
+	if c.TX.BusyLevel != 0 && (c.TX.BusyLevel < 1 || c.TX.BusyLevel > 40) {
+		return fmt.Errorf("tx busy_level (%.1f) must be between 1 and 40 dB", c.TX.BusyLevel)
+	}
+	if err := inRange("tx busy_wait", c.TX.BusyWait, 0, 10); err != nil {
+		return err
+	}
+	if c.TX.FindLow != 0 || c.TX.FindHigh != 0 {
+		if err := inRange("tx find_low", c.TX.FindLow, 200, 2750); err != nil {
+			return err
+		}
+		if err := inRange("tx find_high", c.TX.FindHigh, c.TX.FindLow+50, 2800); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// FieldError is a problem with one setting, named by its dotted YAML path
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// FieldErrors lists every setting an update was rejected for
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// SectionNames lists the top-level sections that can be read and updated
+// one at a time
+func SectionNames() []string {
+	var names []string
+	for name, t := range yamlFields(reflect.TypeOf(Config{})) {
+		if t.Kind() == reflect.Struct {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsSection reports whether name is one of SectionNames
+func IsSection(name string) bool {
+	t, ok := yamlFields(reflect.TypeOf(Config{}))[name]
+	return ok && t.Kind() == reflect.Struct
+}
+
+// Update returns a copy of the configuration with values applied, keyed the
+// way they are in the file. Sections are merged key by key, so settings left
+// out keep their current value, as do secrets sent back as RedactedValue.
+// Unknown keys, values of the wrong type and anything Validate rejects are
+// returned as FieldErrors; the configuration itself is never changed.
+func (c *Config) Update(values map[string]interface{}) (*Config, error) {
+	cfg, err := c.clone()
+	if err != nil {
+		return nil, err
+	}
+
+	var errs FieldErrors
+	applyValues(reflect.ValueOf(cfg).Elem(), values, "", &errs)
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+		return nil, errs
+	}
+
+	// A sound card swapped in brings back its settings, and one swapped out
+	// keeps its own
+	if audioValues, ok := values["audio"].(map[string]interface{}); ok {
+		if cfg.Audio.InputDevice != c.Audio.InputDevice {
+			cfg.rememberAudioDevice(c.Audio.InputDevice, c.audioDeviceSettings())
+			cfg.RecallAudioDevice(c.Audio.InputDevice, audioValues)
+		}
+		cfg.RememberAudioDevice()
+	}
+
+	if err := cfg.resolveSecrets(); err != nil {
+		field, _, _ := strings.Cut(err.Error(), ":")
+		return nil, FieldErrors{{Field: field, Message: err.Error()}}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, FieldErrors{{Field: validationField(err.Error()), Message: err.Error()}}
+	}
+	return cfg, nil
+}
+
+// UpdateSection is Update for the settings of a single section
+func (c *Config) UpdateSection(section string, values map[string]interface{}) (*Config, error) {
+	if !IsSection(section) {
+		return nil, fmt.Errorf("unknown config section %q", section)
+	}
+	return c.Update(map[string]interface{}{section: values})
+}
+
+// clone copies the configuration through YAML so nothing is shared
+func (c *Config) clone() (*Config, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy settings: %w", err)
+	}
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to copy settings: %w", err)
+	}
+	cfg.Instance = c.Instance
+	cfg.secrets = c.secrets
+	return cfg, nil
+}
+
+// applyValues sets the fields of the struct v named by the keys of values,
+// descending into sections given as maps
+func applyValues(v reflect.Value, values map[string]interface{}, path string, errs *FieldErrors) {
+	for key, value := range values {
+		field, ok := fieldByKey(v, key)
+		if !ok {
+			*errs = append(*errs, FieldError{Field: join(path, key), Message: unknownMessage(key, yamlFields(v.Type()))})
+			continue
+		}
+
+		if section, isMap := value.(map[string]interface{}); isMap && field.Kind() == reflect.Struct && field.Type() != secretType {
+			applyValues(field, section, join(path, key), errs)
+			continue
+		}
+		if field.Type() == secretType && value == RedactedValue {
+			continue
+		}
+
+		// YAML decoding gives the same result as the file would
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			*errs = append(*errs, FieldError{Field: join(path, key), Message: err.Error()})
+			continue
+		}
+		decoded := reflect.New(field.Type())
+		if err := yaml.UnmarshalStrict(data, decoded.Interface()); err != nil {
+			*errs = append(*errs, FieldError{Field: join(path, key), Message: "must be " + describeType(field.Type())})
+			continue
+		}
+		field.Set(decoded.Elem())
+	}
+}
+
+// fieldByKey returns the field of the struct v stored under key in YAML
+func fieldByKey(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" || f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		if name == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// unknownMessage describes a key with no setting, suggesting the nearest
+func unknownMessage(key string, fields map[string]reflect.Type) string {
+	if best := suggestKey(key, fields); best != "" {
+		return fmt.Sprintf("unknown setting, did you mean %s?", best)
+	}
+	return "unknown setting"
+}
+
+// describeType names the kind of value a setting of type t takes
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a whole number"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice:
+		return "a list"
+	case reflect.Struct:
+		if t == secretType {
+			return "text"
+		}
+		return "a section"
+	}
+	return "text"
+}
+
+// validationField finds the setting a Validate error is about. Validation
+// messages start with the section and key, as in "radio baud_rate (115000)
+// must be ...".
+func validationField(message string) string {
+	words := strings.Fields(message)
+	if len(words) == 0 || !IsSection(words[0]) {
+		return ""
+	}
+	if len(words) > 1 {
+		key := strings.TrimRight(words[1], ",:")
+		if _, ok := yamlFields(yamlFields(reflect.TypeOf(Config{}))[words[0]])[key]; ok {
+			return words[0] + "." + key
+		}
+	}
+	return words[0]
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	cfg := loadTestConfig(t, string(DefaultYAML))
+
+	var values map[string]interface{}
+	json.Unmarshal([]byte(`{"radio": {"baud_rate": 38400, "device": "/dev/ttyUSB1"}, "web": {"port": 8081}}`), &values)
+	updated, err := cfg.Update(values)
+	if err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if updated.Radio.BaudRate != 38400 || updated.Radio.Device != "/dev/ttyUSB1" || updated.Web.Port != 8081 {
+		t.Errorf("Expected the new settings, got baud %d, device %q, port %d", updated.Radio.BaudRate, updated.Radio.Device, updated.Web.Port)
+	}
+	if updated.Radio.Model != cfg.Radio.Model || updated.Station.Callsign != cfg.Station.Callsign {
+		t.Error("Expected settings left out to keep their values")
+	}
+	if cfg.Radio.BaudRate == 38400 {
+		t.Error("Expected the original configuration unchanged")
+	}
+}
+
+func TestUpdateFieldErrors(t *testing.T) {
+	cfg := loadTestConfig(t, string(DefaultYAML))
+
+	tests := []struct {
+		name    string
+		section string
+		values  string
+		want    FieldErrors
+	}{
+		{"Wrong Casing", "audio", `{"InputDevice": "hw:1,0"}`, FieldErrors{{"audio.InputDevice", "unknown setting, did you mean input_device?"}}},
+		{"Wrong Type", "web", `{"port": "fast", "bind_address": 12}`, FieldErrors{{"web.port", "must be a whole number"}}},
+		{"Several Fields", "hardware", `{"enable_gpio": "yes please", "ptt_pin": 4}`, FieldErrors{
+			{"hardware.enable_gpio", "must be true or false"},
+			{"hardware.ptt_pin", "unknown setting"},
+		}},
+		{"Fails Validate", "radio", `{"baud_rate": 115000}`, FieldErrors{{"radio.baud_rate", "radio baud_rate (115000) must be a standard serial rate such as 9600, 38400 or 115200"}}},
+		{"Missing Callsign", "station", `{"callsign": ""}`, FieldErrors{{"station.callsign", "station callsign is required"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var values map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.values), &values); err != nil {
+				t.Fatalf("Bad test values: %v", err)
+			}
+			_, err := cfg.UpdateSection(tt.section, values)
+			var got FieldErrors
+			if !errors.As(err, &got) {
+				t.Fatalf("Expected field errors, got %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want[i], got[i])
+				}
+			}
+		})
+	}
+
+	if _, err := cfg.UpdateSection("instances", nil); err == nil {
+		t.Error("Expected an error for a setting that is not a section")
+	}
+}
+
+func TestUpdateKeepsRedactedSecret(t *testing.T) {
+	creds := testCredentials{Password: NewSecret("hunter2")}
+	v := reflect.ValueOf(&creds).Elem()
+
+	// A redacted secret sent back is skipped; anything else replaces it
+	var errs FieldErrors
+	applyValues(v, map[string]interface{}{"password": RedactedValue}, "", &errs)
+	if len(errs) > 0 || creds.Password.Value() != "hunter2" {
+		t.Errorf("Expected the secret kept, got %q (%v)", creds.Password.Value(), errs)
+	}
+	applyValues(v, map[string]interface{}{"password": "letmein"}, "", &errs)
+	if creds.Password.Value() != "letmein" {
+		t.Errorf("Expected the secret replaced, got %q", creds.Password.Value())
+	}
+}
+
+func TestSectionNames(t *testing.T) {
+	names := SectionNames()
+	if len(names) == 0 || names[0] != "aggregator" {
+		t.Errorf("Expected sorted sections, got %v", names)
+	}
+	if !IsSection("station") || IsSection("profiles") || IsSection("profile") {
+		t.Errorf("Expected only structs to be sections, got %v", names)
+	}
+}
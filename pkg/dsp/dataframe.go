@@ -0,0 +1,113 @@
+package dsp
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+)
+
+// Data frames carry a binary payload, such as a form, across several
+// transmissions. Each frame holds DataFrameBytes of the payload and says
+// which part it is, so the receiver can put them back together. They are
+// js8d frames of the form kind, laid out as
+//
+//	[4 header][6 tag][6 seq][1 final][40 data][15 check]
+//
+// where tag tells apart payloads sent around the same time, final marks the
+// payload's last frame, and check guards against other frames that happen
+// to start like a data frame.
+const (
+	DataFrameBytes = 5
+	MaxDataFrames  = 64
+)
+
+// MaxDataPayload is the largest payload PackDataFrames can send
+const MaxDataPayload = DataFrameBytes * MaxDataFrames
+
+// Payloads start with a header of a format byte and a checksum of the body
+// after it, the low bytes of its CRC-32. Payloads sent in data frames have
+// a 16 bit checksum, and the larger ones sent in transfer frames all 32
+// bits.
+const (
+	DataPayloadHeaderBytes     = 3
+	TransferPayloadHeaderBytes = 5
+)
+
+// PayloadHeader returns the header of a payload of format, size bytes long
+func PayloadHeader(format byte, body []byte, size int) []byte {
+	header := make([]byte, size)
+	header[0] = format
+	sum := crc32.ChecksumIEEE(body)
+	for i := size - 1; i > 0; i-- {
+		header[i] = byte(sum)
+		sum >>= 8
+	}
+	return header
+}
+
+// PayloadChecks reports whether body matches the checksum in a payload
+// header
+func PayloadChecks(header, body []byte) bool {
+	return len(header) > 1 && bytes.Equal(PayloadHeader(header[0], body, len(header)), header)
+}
+
+// PackDataFrames splits payload into data frames, zero-padding the last
+func PackDataFrames(tag uint8, payload []byte) ([]string, error) {
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("empty payload")
+	}
+	if len(payload) > MaxDataPayload {
+		return nil, fmt.Errorf("payload of %d bytes is over the %d byte limit", len(payload), MaxDataPayload)
+	}
+
+	count := (len(payload) + DataFrameBytes - 1) / DataFrameBytes
+	frames := make([]string, 0, count)
+	for seq := 0; seq < count; seq++ {
+		data := make([]byte, DataFrameBytes)
+		copy(data, payload[seq*DataFrameBytes:])
+		frames = append(frames, packDataFrame(tag&63, uint8(seq), seq == count-1, data))
+	}
+	return frames, nil
+}
+
+// packDataFrame packs one data frame
+func packDataFrame(tag, seq uint8, final bool, data []byte) string {
+	bits := js8dHeader(js8dForm)
+	bits = append(bits, intToBits(uint64(tag), 6)...)
+	bits = append(bits, intToBits(uint64(seq), 6)...)
+	bits = append(bits, final)
+	for _, b := range data {
+		bits = append(bits, intToBits(uint64(b), 8)...)
+	}
+	bits = append(bits, intToBits(js8dFrameCheck(bits, 15), 15)...)
+
+	return packJS8DFrame(bits)
+}
+
+// UnpackDataFrame reverses one frame of PackDataFrames, returning the
+// payload tag, the frame's place among them, whether it is the last and its
+// data
+func UnpackDataFrame(frame string) (tag, seq uint8, final bool, data []byte, err error) {
+	bits, err := unpackJS8DFrame(frame, js8dForm)
+	if err != nil {
+		return 0, 0, false, nil, fmt.Errorf("frame %q is not a data frame", frame)
+	}
+	if bitsToInt(bits[57:]) != js8dFrameCheck(bits[:57], 15) {
+		return 0, 0, false, nil, fmt.Errorf("data frame %q fails its check", frame)
+	}
+
+	tag = uint8(bitsToInt(bits[4:10]))
+	seq = uint8(bitsToInt(bits[10:16]))
+	final = bits[16]
+	data = make([]byte, DataFrameBytes)
+	for i := range data {
+		data[i] = uint8(bitsToInt(bits[17+8*i : 25+8*i]))
+	}
+	return tag, seq, final, data, nil
+}
+
+// IsDataFrame reports whether frame is a valid data frame
+func IsDataFrame(frame string) bool {
+	_, _, _, _, err := UnpackDataFrame(frame)
+	return err == nil
+}
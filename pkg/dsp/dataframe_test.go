@@ -0,0 +1,80 @@
+package dsp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDataFrames(t *testing.T) {
+	payload := []byte("ICS213 relayed over JS8\x00\xff")
+	frames, err := PackDataFrames(42, payload)
+	if err != nil {
+		t.Fatalf("PackDataFrames failed: %v", err)
+	}
+	if want := (len(payload) + DataFrameBytes - 1) / DataFrameBytes; len(frames) != want {
+		t.Fatalf("Expected %d frames, got %d", want, len(frames))
+	}
+
+	var joined []byte
+	for i, frame := range frames {
+		if len(frame) != 12 || ValidateMessage(frame) != nil {
+			t.Fatalf("Frame %q is not a 12 character JS8 frame", frame)
+		}
+		if IsCompoundFrame(frame) {
+			t.Errorf("Data frame %q taken for a compound frame", frame)
+		}
+		if PreprocessJS8Message(frame) != frame {
+			t.Errorf("Expected preprocessing to leave %q alone", frame)
+		}
+		tag, seq, final, data, err := UnpackDataFrame(frame)
+		if err != nil {
+			t.Fatalf("UnpackDataFrame(%q) failed: %v", frame, err)
+		}
+		if tag != 42 || int(seq) != i || final != (i == len(frames)-1) {
+			t.Errorf("Frame %d: got tag %d, seq %d, final %v", i, tag, seq, final)
+		}
+		joined = append(joined, data...)
+	}
+	if !bytes.Equal(joined[:len(payload)], payload) || len(bytes.Trim(joined[len(payload):], "\x00")) != 0 {
+		t.Errorf("Expected %q back, got %q", payload, joined)
+	}
+
+	// A damaged frame fails its check
+	damaged := []byte(frames[0])
+	damaged[6] ^= 1
+	if IsDataFrame(string(damaged)) {
+		t.Errorf("Expected damaged frame %q to be rejected", damaged)
+	}
+
+	// Plain text and compound frames are not data frames
+	compound, _ := PackCompoundGrid("VE3/K3DEP", "FN03")
+	for _, frame := range []string{"W1AW-DE-K1AB", "HBAUTOK1ABFN", compound, "short"} {
+		if IsDataFrame(frame) {
+			t.Errorf("Expected %q not to be a data frame", frame)
+		}
+	}
+
+	if _, err := PackDataFrames(0, nil); err == nil {
+		t.Error("Expected error packing an empty payload")
+	}
+	if _, err := PackDataFrames(0, []byte(strings.Repeat("x", MaxDataPayload+1))); err == nil {
+		t.Error("Expected error packing an oversized payload")
+	}
+}
+
+func TestPayloadHeader(t *testing.T) {
+	body := []byte("THE QUICK BROWN FOX")
+	for _, size := range []int{DataPayloadHeaderBytes, TransferPayloadHeaderBytes} {
+		header := PayloadHeader(1, body, size)
+		if len(header) != size || header[0] != 1 {
+			t.Fatalf("Expected a %d byte header of format 1, got %v", size, header)
+		}
+		if !PayloadChecks(header, body) {
+			t.Errorf("Expected the %d byte header to check its body", size)
+		}
+		if PayloadChecks(header, body[1:]) {
+			t.Errorf("Expected the %d byte header to fail another body", size)
+		}
+	}
+}
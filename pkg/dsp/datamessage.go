@@ -0,0 +1,176 @@
+package dsp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// JS8Call sends free text as data frames: the first bit marks a data frame,
+// the second says how the text is coded and the other 70 bits carry the
+// text, padded with a 0 and then 1s. A clear second bit codes each character
+// with the Huffman table below; a set one uses JS8Call's dense word coding,
+// whose word list js8d does not carry.
+//
+//	[1 data][1 dense][70 text and padding]
+
+// ErrDenseCoded is returned for data frames using JS8Call's dense word
+// coding
+var ErrDenseCoded = errors.New("data frame uses dense word coding, which is not supported")
+
+// dataFrameBits is the size of a frame's payload
+const dataFrameBits = 72
+
+// huffTable is JS8Call's default Huffman table, shorter codes for the more
+// common characters. No code is the start of another.
+var huffTable = map[rune]string{
+	' ': "01",
+	'E': "100",
+	'T': "1101",
+	'A': "0011",
+	'O': "11111",
+	'I': "11100",
+	'N': "10111",
+	'S': "10100",
+	'H': "00011",
+	'R': "00000",
+	'D': "111011",
+	'L': "110011",
+	'C': "110001",
+	'U': "101101",
+	'M': "101011",
+	'W': "001011",
+	'F': "001001",
+	'G': "000101",
+	'Y': "000011",
+	'P': "1111011",
+	'B': "1111001",
+	'.': "1110100",
+	'V': "1100101",
+	'K': "1100100",
+	'-': "1100001",
+	'+': "1100000",
+	'?': "1011001",
+	'!': "1011000",
+	'"': "1010101",
+	'X': "1010100",
+	'0': "0010101",
+	'J': "0010100",
+	'1': "0010001",
+	'Q': "0010000",
+	'2': "0001001",
+	'Z': "0001000",
+	'3': "0000101",
+	'5': "0000100",
+	'4': "11110101",
+	'9': "11110100",
+	'8': "11110001",
+	'6': "11110000",
+	'7': "11101011",
+	'/': "11101010",
+}
+
+// huffCodes maps each Huffman code back to its character
+var huffCodes = func() map[string]rune {
+	codes := make(map[string]rune, len(huffTable))
+	for ch, code := range huffTable {
+		codes[code] = ch
+	}
+	return codes
+}()
+
+// PackDataMessage packs as much of text as fits into one Huffman coded data
+// frame, returning the frame and how many characters it holds. Text is sent
+// in upper case; a character the table has no code for is an error.
+func PackDataMessage(text string) (string, int, error) {
+	text = strings.ToUpper(text)
+	for _, ch := range text {
+		if _, ok := huffTable[ch]; !ok {
+			return "", 0, fmt.Errorf("character %q cannot be sent in a data frame", ch)
+		}
+	}
+
+	bits := []bool{true, false}
+	n := 0
+	for _, ch := range text {
+		code := huffTable[ch]
+		if len(bits)+len(code) >= dataFrameBits {
+			break
+		}
+		for _, c := range code {
+			bits = append(bits, c == '1')
+		}
+		n++
+	}
+
+	// At least one pad bit always follows, so the 0 that starts the
+	// padding can be found
+	for pad := 0; len(bits) < dataFrameBits; pad++ {
+		bits = append(bits, pad > 0)
+	}
+	return Pack72bits(bitsToInt(bits[:64]), uint8(bitsToInt(bits[64:]))), n, nil
+}
+
+// PackDataMessageFrames splits text into as few Huffman coded data frames
+// as hold it
+func PackDataMessageFrames(text string) ([]string, error) {
+	if text == "" {
+		return nil, fmt.Errorf("empty message")
+	}
+
+	var frames []string
+	rest := []rune(strings.ToUpper(text))
+	for len(rest) > 0 {
+		frame, n, err := PackDataMessage(string(rest))
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame)
+		rest = rest[n:]
+	}
+	return frames, nil
+}
+
+// UnpackDataMessage returns the text of a data frame packed by
+// PackDataMessage or by JS8Call
+func UnpackDataMessage(frame string) (string, error) {
+	if len(frame) != 12 || ValidateMessage(frame) != nil {
+		return "", fmt.Errorf("frame %q is not a packed frame", frame)
+	}
+
+	var rem uint8
+	bits := append(intToBits(Unpack72bits(frame, &rem), 64), intToBits(uint64(rem), 8)...)
+	if !bits[0] {
+		return "", fmt.Errorf("frame %q is not a data frame", frame)
+	}
+	if bits[1] {
+		return "", ErrDenseCoded
+	}
+
+	// The text ends at the last 0, where the padding starts
+	end := len(bits) - 1
+	for end > 1 && bits[end] {
+		end--
+	}
+	if end <= 1 {
+		return "", fmt.Errorf("data frame %q has no padding", frame)
+	}
+
+	var text strings.Builder
+	code := ""
+	for _, bit := range bits[2:end] {
+		if bit {
+			code += "1"
+		} else {
+			code += "0"
+		}
+		if ch, ok := huffCodes[code]; ok {
+			text.WriteRune(ch)
+			code = ""
+		}
+	}
+	if code != "" {
+		return "", fmt.Errorf("data frame %q ends in the middle of a character", frame)
+	}
+	return text.String(), nil
+}
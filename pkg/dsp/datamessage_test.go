@@ -0,0 +1,80 @@
+package dsp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDataMessage(t *testing.T) {
+	// Frames as JS8Call's packHuffMessage builds them from its default
+	// Huffman table, with the text each holds
+	tests := []struct {
+		text   string
+		frames []string
+		held   []string
+	}{
+		{"hello", []string{"XpFFx+++++++"}, []string{"HELLO"}},
+		{"TEST", []string{"jbDV++++++++"}, []string{"TEST"}},
+		{"CQ CQ DE K3DEP", []string{"iI3YGUuv0lSV", "lR++++++++++"}, []string{"CQ CQ DE K3DE", "P"}},
+		{
+			"THE QUICK BROWN FOX JUMPS OVER THE LAZY DOG 0123456789",
+			[]string{"j74XRp78-GVV", "YxfFwYKjhzqN", "lvO1qSSp41lR", "lYfKY92-eJmV", "klnz7+++++++"},
+			[]string{"THE QUICK BRO", "WN FOX JUMPS ", "OVER THE LAZY D", "OG 0123456", "789"},
+		},
+	}
+
+	for _, tt := range tests {
+		frames, err := PackDataMessageFrames(tt.text)
+		if err != nil {
+			t.Fatalf("PackDataMessageFrames(%q) failed: %v", tt.text, err)
+		}
+		if strings.Join(frames, " ") != strings.Join(tt.frames, " ") {
+			t.Errorf("PackDataMessageFrames(%q) = %v, want %v", tt.text, frames, tt.frames)
+		}
+
+		var joined string
+		for i, frame := range frames {
+			text, err := UnpackDataMessage(frame)
+			if err != nil {
+				t.Fatalf("UnpackDataMessage(%q) failed: %v", frame, err)
+			}
+			if i < len(tt.held) && text != tt.held[i] {
+				t.Errorf("Frame %q holds %q, want %q", frame, text, tt.held[i])
+			}
+			joined += text
+		}
+		if joined != strings.ToUpper(tt.text) {
+			t.Errorf("Expected %q back, got %q", strings.ToUpper(tt.text), joined)
+		}
+	}
+
+	// Common letters take fewer bits, so plain English beats 12 characters
+	// a frame
+	text := "THE NET MEETS AT NINE ON THIS SIDE OF THE HOUR"
+	if frames, _ := PackDataMessageFrames(text); len(frames) != 3 {
+		t.Errorf("Expected %d characters in 3 frames, got %d", len(text), len(frames))
+	}
+}
+
+func TestDataMessageErrors(t *testing.T) {
+	if _, _, err := PackDataMessage("CAFÉ"); err == nil {
+		t.Error("Expected a character with no code to be rejected")
+	}
+	if _, err := PackDataMessageFrames(""); err == nil {
+		t.Error("Expected an empty message to be rejected")
+	}
+
+	// A set second bit is JS8Call's dense word coding
+	bits := append([]bool{true, true}, make([]bool, 70)...)
+	dense := Pack72bits(bitsToInt(bits[:64]), uint8(bitsToInt(bits[64:])))
+	if _, err := UnpackDataMessage(dense); !errors.Is(err, ErrDenseCoded) {
+		t.Errorf("Expected ErrDenseCoded, got %v", err)
+	}
+
+	for _, frame := range []string{"HELLO", "0123456789AB", "HELLO WORLD!"} {
+		if text, err := UnpackDataMessage(frame); err == nil {
+			t.Errorf("Expected %q not to unpack, got %q", frame, text)
+		}
+	}
+}
@@ -0,0 +1,471 @@
+package dsp
+
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/fft"
+)
+
+// JS8 Normal mode framing at the 12 kHz decode rate
+const (
+	decodeSampleRate    = 12000
+	normalSymbolSamples = 1920 // 0.16 s per symbol
+	toneSpacing         = float64(decodeSampleRate) / normalSymbolSamples
+	js8Symbols          = 79
+	js8DataSymbols      = 58
+	syncStep            = normalSymbolSamples / 4
+	downsampleFactor    = 60 // 12 kHz to 200 Hz for demodulation
+	downSymbol          = normalSymbolSamples / downsampleFactor
+
+	minSyncQuality = 2.0 // Costas power over the average of the other tones
+	nominalStart   = 0.5 // Seconds into the cycle a transmission starts
+)
+
+// costasOffsets are the symbol positions of the three Costas arrays
+var costasOffsets = [3]int{0, 36, 72}
+
+// syncCandidate is a possible JS8 signal found by the coarse Costas search
+type syncCandidate struct {
+	freq  float64 // Hz of tone 0
+	start int     // Sample index of the first symbol
+	sync  float64
+}
+
+// decodeNormal decodes JS8 Normal mode frames from 12 kHz audio whose tone 0
+// lies between low and high Hz, returning the number of messages passed to
+// callback
+func decodeNormal(samples []float64, limits DecodeLimits, low, high float64, callback func(*DecodeResult)) int {
+	frameSamples := js8Symbols * normalSymbolSamples
+	if len(samples) < frameSamples || limits.MaxCandidates <= 0 {
+		return 0
+	}
+
+	candidates := findCandidates(samples, limits, low, high)
+	seen := make(map[string]bool)
+	decodeCount := 0
+
+	for _, cand := range candidates {
+		if decodeCount >= limits.MaxCandidates {
+			break
+		}
+		result := decodeCandidate(samples, cand)
+		if result == nil || seen[result.Message] {
+			continue
+		}
+		seen[result.Message] = true
+		decodeCount++
+		callback(result)
+	}
+
+	return decodeCount
+}
+
+// findCandidates runs a coarse Costas search over a quarter-symbol
+// spectrogram. The FFT length comes from the decode limits, so a smaller
+// FFT trades frequency resolution for speed. Only tone 0 offsets from low
+// to high Hz are searched.
+func findCandidates(samples []float64, limits DecodeLimits, low, high float64) []syncCandidate {
+	fftLen := 4 * limits.FFTSize
+	window := normalSymbolSamples
+	if fftLen < window {
+		window = fftLen
+	}
+	binHz := float64(decodeSampleRate) / float64(fftLen)
+	maxBin := int((high+8*toneSpacing)/binHz) + 1
+
+	// Power spectrum every quarter symbol
+	steps := (len(samples)-window)/syncStep + 1
+	power := make([][]float64, steps)
+	frame := make([]float64, fftLen)
+	for t := range power {
+		copy(frame, samples[t*syncStep:t*syncStep+window])
+		spectrum := fft.ForwardReal(frame)
+		power[t] = make([]float64, maxBin)
+		for bin := range power[t] {
+			magnitude := cmplx.Abs(spectrum[bin])
+			power[t][bin] = magnitude * magnitude
+		}
+	}
+
+	// Every start that leaves room for a whole frame
+	lastStart := (len(samples) - js8Symbols*normalSymbolSamples) / syncStep
+	if lastStart >= steps-(js8Symbols-1)*4 {
+		lastStart = steps - (js8Symbols-1)*4 - 1
+	}
+
+	var candidates []syncCandidate
+	for freq := low; freq <= high; freq += toneSpacing / 2 {
+		var bins [8]int
+		for tone := range bins {
+			bins[tone] = int(math.Round((freq + float64(tone)*toneSpacing) / binHz))
+		}
+
+		for t0 := 0; t0 <= lastStart; t0++ {
+			var signal, total float64
+			for array, offset := range costasOffsets {
+				for k := 0; k < 7; k++ {
+					row := power[t0+4*(offset+k)]
+					signal += row[bins[costasNormal[array][k]]]
+					for _, bin := range bins {
+						total += row[bin]
+					}
+				}
+			}
+			noise := (total - signal) / 7
+			if noise <= 0 {
+				continue
+			}
+			if sync := signal / noise; sync >= minSyncQuality {
+				candidates = append(candidates, syncCandidate{freq: freq, start: t0 * syncStep, sync: sync})
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].sync > candidates[j].sync
+	})
+
+	// Keep the strongest candidate near each signal
+	maxTried := 4 * limits.MaxCandidates
+	var kept []syncCandidate
+	for _, cand := range candidates {
+		duplicate := false
+		for _, k := range kept {
+			if math.Abs(cand.freq-k.freq) < 2*toneSpacing && absInt(cand.start-k.start) < normalSymbolSamples {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		kept = append(kept, cand)
+		if len(kept) >= maxTried {
+			break
+		}
+	}
+	return kept
+}
+
+// basebandSymbols mixes the signal down so tone 4 sits at 0 Hz and sums each
+// block of downsampleFactor samples, leaving 32 complex samples per symbol.
+// Tone k then falls in bin k-4 of a 32 point FFT of each symbol.
+func basebandSymbols(samples []float64, start int, freq float64, count int) []complex128 {
+	omega := -2 * math.Pi * (freq + 4*toneSpacing) / decodeSampleRate
+	step := complex(math.Cos(omega), math.Sin(omega))
+	phasor := complex(1, 0)
+
+	out := make([]complex128, count)
+	n := start
+	for m := range out {
+		var sum complex128
+		for t := 0; t < downsampleFactor; t++ {
+			if n >= 0 && n < len(samples) {
+				sum += complex(samples[n], 0) * phasor
+			}
+			phasor *= step
+			n++
+		}
+		out[m] = sum
+	}
+	return out
+}
+
+// symbolTones measures the magnitude of each of the 8 tones in the symbol
+// starting at offset in the baseband samples
+func symbolTones(baseband []complex128, offset int) [8]float64 {
+	var tones [8]float64
+	spectrum := fft.Forward(baseband[offset : offset+downSymbol])
+	for tone := range tones {
+		tones[tone] = cmplx.Abs(spectrum[(tone-4+downSymbol)%downSymbol])
+	}
+	return tones
+}
+
+// costasScore is the fraction of the Costas symbol power in the expected tones
+func costasScore(baseband []complex128, offset int) float64 {
+	var signal, total float64
+	for array, position := range costasOffsets {
+		for k := 0; k < 7; k++ {
+			tones := symbolTones(baseband, offset+(position+k)*downSymbol)
+			expected := tones[costasNormal[array][k]]
+			signal += expected * expected
+			for _, magnitude := range tones {
+				total += magnitude * magnitude
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return signal / total
+}
+
+// decodeCandidate refines a candidate's timing and frequency, then demodulates
+// and LDPC decodes it
+func decodeCandidate(samples []float64, cand syncCandidate) *DecodeResult {
+	// Fine search around the coarse estimate, half a quarter symbol either way
+	const shift = syncStep / 2 / downsampleFactor
+	frameLength := js8Symbols * downSymbol
+
+	var best []complex128
+	bestOffset, bestFreq, bestScore := 0, cand.freq, -1.0
+	for _, df := range []float64{-toneSpacing / 4, 0, toneSpacing / 4} {
+		freq := cand.freq + df
+		baseband := basebandSymbols(samples, cand.start-shift*downsampleFactor, freq, frameLength+2*shift)
+		for offset := 0; offset <= 2*shift; offset += shift {
+			start := cand.start + (offset-shift)*downsampleFactor
+			if start < 0 || start+js8Symbols*normalSymbolSamples > len(samples) {
+				continue
+			}
+			if score := costasScore(baseband, offset); score > bestScore {
+				best, bestOffset, bestFreq, bestScore = baseband, offset, freq, score
+			}
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	bestStart := cand.start + (bestOffset-shift)*downsampleFactor
+
+	// Tone magnitudes for every symbol
+	var s2 [js8Symbols][8]float64
+	for sym := range s2 {
+		s2[sym] = symbolTones(best, bestOffset+sym*downSymbol)
+	}
+
+	// Require at least 7 of the 21 Costas symbols to be the strongest tone
+	nsync := 0
+	for array, offset := range costasOffsets {
+		for k := 0; k < 7; k++ {
+			if strongestTone(s2[offset+k]) == costasNormal[array][k] {
+				nsync++
+			}
+		}
+	}
+	if nsync <= 6 {
+		return nil
+	}
+
+	// Bit metrics from the data symbols, parity block first
+	var llr0, llr1 [ldpcN]float64
+	for j := 0; j < js8DataSymbols; j++ {
+		sym := 7 + j
+		if j >= 29 {
+			sym = 43 + j - 29
+		}
+		ps := s2[sym]
+		llr0[3*j], llr0[3*j+1], llr0[3*j+2] = toneBitMetrics(ps)
+		for i := range ps {
+			ps[i] = math.Log(ps[i] + 1e-32)
+		}
+		llr1[3*j], llr1[3*j+1], llr1[3*j+2] = toneBitMetrics(ps)
+	}
+	normalizeLLR(&llr0)
+	normalizeLLR(&llr1)
+
+	for pass := 1; pass <= 4; pass++ {
+		// Later passes erase the start of the parity block in case it was lost
+		switch pass {
+		case 3:
+			for i := 0; i < 24; i++ {
+				llr0[i] = 0
+			}
+		case 4:
+			for i := 24; i < 48; i++ {
+				llr0[i] = 0
+			}
+		}
+		llr := llr0
+		if pass == 2 {
+			llr = llr1
+		}
+
+		decoded, cw, nerr := bpDecode174(&llr)
+		if nerr < 0 || nerr >= 60 || (pass > 2 && nerr > 39) || (pass == 4 && nerr > 30) {
+			continue
+		}
+		allZero := true
+		for _, bit := range cw {
+			if bit {
+				allZero = false
+				break
+			}
+		}
+		if allZero || !checkCRC12(&decoded) {
+			continue
+		}
+
+		message, frameType := extractMessage(&decoded)
+		tones := make([]int, js8Symbols)
+		for sym := range s2 {
+			tones[sym] = strongestTone(s2[sym])
+		}
+		return &DecodeResult{
+			UTC:       int(time.Now().Unix()),
+			SNR:       estimateSNR(&s2, message, frameType),
+			DT:        float32(float64(bestStart)/decodeSampleRate - nominalStart),
+			Frequency: float32(bestFreq),
+			Message:   message,
+			Type:      frameType,
+			Quality:   float32(1 - float64(nerr)/60),
+			Mode:      int(ModeNormal),
+			Payload:   packBits(decoded[:]),
+			Tones:     tones,
+		}
+	}
+
+	return nil
+}
+
+// toneBitMetrics gives the soft metric for each of the 3 bits a tone carries
+func toneBitMetrics(ps [8]float64) (float64, float64, float64) {
+	max4 := func(a, b, c, d float64) float64 {
+		return math.Max(math.Max(a, b), math.Max(c, d))
+	}
+	return max4(ps[4], ps[5], ps[6], ps[7]) - max4(ps[0], ps[1], ps[2], ps[3]),
+		max4(ps[2], ps[3], ps[6], ps[7]) - max4(ps[0], ps[1], ps[4], ps[5]),
+		max4(ps[1], ps[3], ps[5], ps[7]) - max4(ps[0], ps[2], ps[4], ps[6])
+}
+
+// normalizeLLR scales bit metrics to unit variance, as the JS8Call decoder does
+func normalizeLLR(llr *[ldpcN]float64) {
+	var sum, sumSquares float64
+	for _, value := range llr {
+		sum += value
+		sumSquares += value * value
+	}
+	mean := sum / ldpcN
+	meanSquare := sumSquares / ldpcN
+	variance := meanSquare - mean*mean
+	sigma := math.Sqrt(meanSquare)
+	if variance > 0 {
+		sigma = math.Sqrt(variance)
+	}
+	if sigma == 0 {
+		return
+	}
+	for i := range llr {
+		llr[i] = llr[i] / sigma * 2.83
+	}
+}
+
+// estimateSNR compares the power in the transmitted tones with the other
+// tones, scaled to the 2500 Hz reference bandwidth
+func estimateSNR(s2 *[js8Symbols][8]float64, message string, frameType int) int {
+	tones, err := NewJS8Encoder().EncodeMessage(message, frameType)
+	if err != nil {
+		return -28
+	}
+
+	var signal, total float64
+	for sym, row := range s2 {
+		expected := row[tones[sym]]
+		signal += expected * expected
+		for _, magnitude := range row {
+			total += magnitude * magnitude
+		}
+	}
+	noise := (total - signal) / 7
+	if noise <= 0 {
+		return 30
+	}
+
+	ratio := signal/noise - 1
+	if ratio < 0.001 {
+		ratio = 0.001
+	}
+	snr := int(math.Round(10*math.Log10(ratio) - 10*math.Log10(2500/toneSpacing)))
+	if snr < -28 {
+		snr = -28
+	}
+	return snr
+}
+
+// toDecodeRate converts audio to floating point at the 12 kHz decode rate
+func toDecodeRate(audioData []int16, sampleRate int) []float64 {
+	if sampleRate == decodeSampleRate || sampleRate <= 0 {
+		samples := make([]float64, len(audioData))
+		for i, sample := range audioData {
+			samples[i] = float64(sample) / 32768.0
+		}
+		return samples
+	}
+
+	// Low-pass below the new Nyquist before resampling
+	ratio := float64(sampleRate) / decodeSampleRate
+	cutoff := 0.45 / ratio
+	if cutoff > 0.5 {
+		cutoff = 0.5
+	}
+	taps := resampleFilter(cutoff)
+	half := len(taps) / 2
+
+	filtered := func(i int) float64 {
+		var sum float64
+		for k, tap := range taps {
+			j := i + k - half
+			if j >= 0 && j < len(audioData) {
+				sum += tap * float64(audioData[j])
+			}
+		}
+		return sum / 32768.0
+	}
+
+	outLen := int(float64(len(audioData)) / ratio)
+	samples := make([]float64, outLen)
+	for n := range samples {
+		pos := float64(n) * ratio
+		i := int(pos)
+		frac := pos - float64(i)
+		if frac == 0 {
+			samples[n] = filtered(i)
+		} else {
+			samples[n] = (1-frac)*filtered(i) + frac*filtered(i+1)
+		}
+	}
+	return samples
+}
+
+// resampleFilter builds a Hamming windowed-sinc low-pass filter with the
+// cutoff given as a fraction of the input sample rate
+func resampleFilter(cutoff float64) []float64 {
+	const length = 65
+	taps := make([]float64, length)
+	var sum float64
+	for i := range taps {
+		x := float64(i - length/2)
+		sinc := 2 * cutoff
+		if x != 0 {
+			sinc = math.Sin(2*math.Pi*cutoff*x) / (math.Pi * x)
+		}
+		window := 0.54 - 0.46*math.Cos(2*math.Pi*float64(i)/float64(length-1))
+		taps[i] = sinc * window
+		sum += taps[i]
+	}
+	for i := range taps {
+		taps[i] /= sum
+	}
+	return taps
+}
+
+// strongestTone returns the index of the largest tone magnitude
+func strongestTone(tones [8]float64) int {
+	best := 0
+	for i, magnitude := range tones {
+		if magnitude > tones[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,247 @@
+package dsp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/dougsko/js8d/pkg/fft"
+)
+
+// synthesizeJS8 renders Normal mode tones at the real JS8 symbol rate,
+// starting delay seconds into a buffer of the given length, with optional noise
+func synthesizeJS8(tones []int, freq float64, sampleRate int, delay, seconds, noise float64) []int16 {
+	rng := rand.New(rand.NewSource(1))
+	samples := make([]int16, int(seconds*float64(sampleRate)))
+	symbolSamples := sampleRate * normalSymbolSamples / decodeSampleRate
+	start := int(delay * float64(sampleRate))
+
+	phase := 0.0
+	for i := range samples {
+		value := noise * rng.NormFloat64()
+		if sym := (i - start) / symbolSamples; i >= start && sym < len(tones) {
+			phase += 2 * math.Pi * (freq + float64(tones[sym])*toneSpacing) / float64(sampleRate)
+			value += 8000 * math.Sin(phase)
+		}
+		samples[i] = int16(math.Max(-32768, math.Min(32767, value)))
+	}
+	return samples
+}
+
+// codewordBits recovers the 174 codeword bits from the encoder's tones
+func codewordBits(tones []int) [ldpcN]bool {
+	var bits [ldpcN]bool
+	for j := 0; j < js8DataSymbols; j++ {
+		sym := 7 + j
+		if j >= 29 {
+			sym = 43 + j - 29
+		}
+		for b := 0; b < 3; b++ {
+			bits[3*j+b] = tones[sym]&(4>>b) != 0
+		}
+	}
+	return bits
+}
+
+func TestLDPCEncoderCodewords(t *testing.T) {
+	encoder := NewJS8Encoder()
+
+	for _, message := range []string{"CQ-N0CALL-XX", "K3DEPFN20abc", "000000000001"} {
+		tones, err := encoder.EncodeMessage(message, 2)
+		if err != nil {
+			t.Fatalf("Encoding %s failed: %v", message, err)
+		}
+		bits := codewordBits(tones)
+
+		for check, members := range ldpcBits {
+			var parity bool
+			for _, bit := range members {
+				parity = parity != bits[bit]
+			}
+			if parity {
+				t.Errorf("%s: parity check %d fails", message, check)
+			}
+		}
+	}
+}
+
+func TestBPDecodeCorrectsErrors(t *testing.T) {
+	tones, err := NewJS8Encoder().EncodeMessage("K3DEPFN20abc", 3)
+	if err != nil {
+		t.Fatalf("Encoding failed: %v", err)
+	}
+	bits := codewordBits(tones)
+
+	var llr [ldpcN]float64
+	for i, bit := range bits {
+		llr[i] = -2.0
+		if bit {
+			llr[i] = 2.0
+		}
+	}
+	// Flip a handful of bits with low confidence
+	for _, i := range []int{3, 40, 90, 120, 170} {
+		llr[i] = -llr[i] / 4
+	}
+
+	decoded, _, nerr := bpDecode174(&llr)
+	if nerr != 5 {
+		t.Fatalf("Expected 5 corrected errors, got %d", nerr)
+	}
+	if !checkCRC12(&decoded) {
+		t.Fatal("Expected CRC to match after decoding")
+	}
+	message, frameType := extractMessage(&decoded)
+	if message != "K3DEPFN20abc" || frameType != 3 {
+		t.Errorf("Expected K3DEPFN20abc type 3, got %s type %d", message, frameType)
+	}
+}
+
+func TestGoDecoder(t *testing.T) {
+	tones, err := NewJS8Encoder().EncodeMessage("CQ-N0CALL-XX", 0)
+	if err != nil {
+		t.Fatalf("Encoding failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		sampleRate int
+		freq       float64
+		noise      float64
+	}{
+		{"12kHz", 12000, 1000, 0},
+		{"48kHz", 48000, 1512.5, 0},
+		{"Noisy", 12000, 2000, 4000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audio := synthesizeJS8(tones, tt.freq, tt.sampleRate, 0.5, 14, tt.noise)
+
+			d := NewDSP()
+			d.SetSampleRate(tt.sampleRate)
+
+			var results []*DecodeResult
+			count, err := d.DecodeBuffer(audio, func(result *DecodeResult) {
+				results = append(results, result)
+			})
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if count != 1 || len(results) != 1 {
+				t.Fatalf("Expected 1 decode, got %d", count)
+			}
+
+			result := results[0]
+			if result.Message != "CQ-N0CALL-XX" {
+				t.Errorf("Expected CQ-N0CALL-XX, got %s", result.Message)
+			}
+			if math.Abs(float64(result.Frequency)-tt.freq) > toneSpacing/2 {
+				t.Errorf("Expected frequency near %.1f Hz, got %.1f Hz", tt.freq, result.Frequency)
+			}
+			if math.Abs(float64(result.DT)) > 0.1 {
+				t.Errorf("Expected DT near 0, got %.2f", result.DT)
+			}
+			if len(result.Payload) != 11 || len(result.Tones) != js8Symbols {
+				t.Fatalf("Expected 11 payload bytes and %d tones, got %d and %d", js8Symbols, len(result.Payload), len(result.Tones))
+			}
+			if tt.noise == 0 {
+				for sym, tone := range result.Tones {
+					if tone != tones[sym] {
+						t.Fatalf("Expected tone %d of symbol %d, got %d", tones[sym], sym, tone)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGoDecoderNoSignal(t *testing.T) {
+	audio := synthesizeJS8(nil, 1000, 12000, 0, 14, 3000)
+
+	count, err := NewDSP().DecodeBuffer(audio, func(result *DecodeResult) {
+		t.Errorf("Unexpected decode: %s", result.Message)
+	})
+	if err != nil || count != 0 {
+		t.Errorf("Expected no decodes from noise, got %d (%v)", count, err)
+	}
+}
+
+func TestGoDecoderReducedLimits(t *testing.T) {
+	tones, err := NewJS8Encoder().EncodeMessage("CQ-N0CALL-XX", 0)
+	if err != nil {
+		t.Fatalf("Encoding failed: %v", err)
+	}
+	audio := synthesizeJS8(tones, 1200, 12000, 0.5, 14, 0)
+
+	d := NewDSP()
+	d.SetDecodeLimits(decodeLevels[len(decodeLevels)-1])
+	count, err := d.DecodeBuffer(audio, func(*DecodeResult) {})
+	if err != nil || count != 1 {
+		t.Errorf("Expected a decode at the lowest decode level, got %d (%v)", count, err)
+	}
+}
+
+func TestGoDecoderPassband(t *testing.T) {
+	tones, err := NewJS8Encoder().EncodeMessage("CQ-N0CALL-XX", 0)
+	if err != nil {
+		t.Fatalf("Encoding failed: %v", err)
+	}
+	audio := synthesizeJS8(tones, 1200, 12000, 0.5, 14, 0)
+
+	tests := []struct {
+		low, high float64
+		want      int
+	}{
+		{500, 2500, 1},
+		{1300, 2500, 0},
+		{500, 1100, 0},
+		{0, 5000, 1}, // Held to the decoder's own passband
+	}
+	for _, tt := range tests {
+		d := NewDSP()
+		d.SetPassband(tt.low, tt.high)
+		count, err := d.DecodeBuffer(audio, func(*DecodeResult) {})
+		if err != nil || count != tt.want {
+			t.Errorf("Passband %.0f-%.0f Hz: expected %d decodes of a signal at 1200 Hz, got %d (%v)", tt.low, tt.high, tt.want, count, err)
+		}
+	}
+}
+
+func TestNewEngine(t *testing.T) {
+	if engine, err := NewEngine(DecoderGo); err != nil {
+		t.Errorf("Expected go decoder, got error: %v", err)
+	} else if _, ok := engine.(*DSP); !ok {
+		t.Errorf("Expected *DSP for the go decoder, got %T", engine)
+	}
+
+	if _, err := NewEngine("fortran"); err == nil {
+		t.Error("Expected error for unknown decoder")
+	}
+
+	_, err := NewEngine(DecoderNative)
+	if NativeAvailable && err != nil {
+		t.Errorf("Expected native decoder, got error: %v", err)
+	}
+	if !NativeAvailable && err == nil {
+		t.Error("Expected error for native decoder in a purego build")
+	}
+}
+
+func BenchmarkDecodeNormal(b *testing.B) {
+	tones, err := NewJS8Encoder().EncodeMessage("CQ-N0CALL-XX", 0)
+	if err != nil {
+		b.Fatalf("Encoding failed: %v", err)
+	}
+	samples := toDecodeRate(synthesizeJS8(tones, 1500, 12000, 0.5, 15, 2000), 12000)
+	defer fft.SetBackend(fft.BackendAuto)
+
+	for _, backend := range fft.Backends() {
+		b.Run(backend, func(b *testing.B) {
+			fft.SetBackend(backend)
+			for i := 0; i < b.N; i++ {
+				decodeNormal(samples, DefaultDecodeLimits(), MinDecodeHz, MaxDecodeHz, func(*DecodeResult) {})
+			}
+		})
+	}
+}
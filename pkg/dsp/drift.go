@@ -0,0 +1,151 @@
+package dsp
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Calibrate corrects a frequency read from a rig whose reference is off by
+// ppm parts per million. A positive ppm means the rig is really higher than
+// it reports.
+func Calibrate(frequency float64, ppm float64) float64 {
+	return frequency * (1 + ppm/1e6)
+}
+
+// Uncalibrate is the frequency to ask a rig off by ppm for, so that it
+// really tunes to frequency
+func Uncalibrate(frequency float64, ppm float64) float64 {
+	return frequency / (1 + ppm/1e6)
+}
+
+const (
+	// Decodes of a station needed before the estimate suggests a correction
+	driftMinSamples = 5
+	// A station moving its offset by more than this has retuned, not drifted
+	driftMaxStep = 10.0
+)
+
+// driftBaseline is where a station's offset was when the window started
+type driftBaseline struct {
+	offset float64
+	heard  time.Time
+}
+
+// driftSample is how far a station's offset had moved from its baseline
+type driftSample struct {
+	delta float64
+	heard time.Time
+}
+
+// DriftEstimator measures how far decoded offsets move over time. Stations
+// rarely change offset on their own, so when every station seems to move the
+// same way it is the receiver's reference drifting.
+type DriftEstimator struct {
+	window time.Duration
+
+	baselines map[string]driftBaseline
+	samples   []driftSample
+
+	mutex sync.Mutex
+}
+
+// DriftEstimate is the systematic offset of recent decodes and the
+// calibration that would remove it
+type DriftEstimate struct {
+	OffsetHz     float64 `json:"offset_hz"`     // median movement of decoded offsets
+	Samples      int     `json:"samples"`       // decodes the estimate is based on
+	Stations     int     `json:"stations"`      // stations being tracked
+	Ready        bool    `json:"ready"`         // enough samples to suggest a correction
+	SuggestedPPM float64 `json:"suggested_ppm"` // calibration_ppm that would cancel the drift
+}
+
+// NewDriftEstimator creates an estimator that looks back over window
+func NewDriftEstimator(window time.Duration) *DriftEstimator {
+	if window <= 0 {
+		window = time.Hour
+	}
+	return &DriftEstimator{
+		window:    window,
+		baselines: make(map[string]driftBaseline),
+	}
+}
+
+// Record adds a decode of station at the given audio offset in Hz
+func (d *DriftEstimator) Record(station string, offset float64, now time.Time) {
+	if station == "" {
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.expire(now)
+	baseline, ok := d.baselines[station]
+	if !ok || math.Abs(offset-baseline.offset) > driftMaxStep {
+		d.baselines[station] = driftBaseline{offset: offset, heard: now}
+		return
+	}
+	d.samples = append(d.samples, driftSample{delta: offset - baseline.offset, heard: now})
+}
+
+// expire forgets samples and baselines older than the window
+func (d *DriftEstimator) expire(now time.Time) {
+	cutoff := now.Add(-d.window)
+	kept := d.samples[:0]
+	for _, sample := range d.samples {
+		if sample.heard.After(cutoff) {
+			kept = append(kept, sample)
+		}
+	}
+	d.samples = kept
+	for station, baseline := range d.baselines {
+		if !baseline.heard.After(cutoff) {
+			delete(d.baselines, station)
+		}
+	}
+}
+
+// Estimate returns the drift seen in the window for a rig tuned to dial Hz
+// and calibrated by ppm
+func (d *DriftEstimator) Estimate(dial int, ppm float64, now time.Time) DriftEstimate {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.expire(now)
+	estimate := DriftEstimate{
+		Samples:      len(d.samples),
+		Stations:     len(d.baselines),
+		SuggestedPPM: ppm,
+	}
+	if len(d.samples) == 0 {
+		return estimate
+	}
+
+	deltas := make([]float64, len(d.samples))
+	for i, sample := range d.samples {
+		deltas[i] = sample.delta
+	}
+	sort.Float64s(deltas)
+	middle := len(deltas) / 2
+	estimate.OffsetHz = deltas[middle]
+	if len(deltas)%2 == 0 {
+		estimate.OffsetHz = (deltas[middle-1] + deltas[middle]) / 2
+	}
+
+	// Offsets rising means the rig's reference, and so the dial, has fallen
+	estimate.Ready = len(d.samples) >= driftMinSamples && dial > 0
+	if estimate.Ready {
+		estimate.SuggestedPPM = ppm - estimate.OffsetHz/float64(dial)*1e6
+	}
+	return estimate
+}
+
+// Reset forgets every baseline and sample, for after the calibration changes
+func (d *DriftEstimator) Reset() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.baselines = make(map[string]driftBaseline)
+	d.samples = nil
+}
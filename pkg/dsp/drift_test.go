@@ -0,0 +1,72 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCalibrate(t *testing.T) {
+	if got := Calibrate(14078000, 2); math.Abs(got-14078028.156) > 0.001 {
+		t.Errorf("Expected 14078028.156 Hz, got %.3f", got)
+	}
+	if got := Uncalibrate(Calibrate(7078000, -3.5), -3.5); math.Abs(got-7078000) > 1e-6 {
+		t.Errorf("Expected Uncalibrate to undo Calibrate, got %.6f", got)
+	}
+	if got := Calibrate(1500, 0); got != 1500 {
+		t.Errorf("Expected no change at 0 ppm, got %f", got)
+	}
+}
+
+func TestDriftEstimator(t *testing.T) {
+	d := NewDriftEstimator(time.Hour)
+	now := time.Now()
+	dial := 14078000
+
+	// Baselines alone are not drift
+	d.Record("N0ABC", 1000, now)
+	d.Record("N0XYZ", 1800, now)
+	d.Record("", 1200, now)
+	estimate := d.Estimate(dial, 0, now)
+	if estimate.Samples != 0 || estimate.Stations != 2 || estimate.Ready {
+		t.Fatalf("Expected 2 stations and no samples, got %+v", estimate)
+	}
+
+	// Both stations creep up 7 Hz over the hour
+	for i := 1; i <= 3; i++ {
+		now = now.Add(10 * time.Minute)
+		d.Record("N0ABC", 1000+float64(i)*7/3, now)
+		d.Record("N0XYZ", 1800+float64(i)*7/3, now)
+	}
+	estimate = d.Estimate(dial, 0, now)
+	if estimate.Samples != 6 || !estimate.Ready {
+		t.Fatalf("Expected a ready estimate from 6 samples, got %+v", estimate)
+	}
+	if math.Abs(estimate.OffsetHz-14.0/3) > 1e-9 {
+		t.Errorf("Expected the median offset, got %f", estimate.OffsetHz)
+	}
+	if estimate.SuggestedPPM >= 0 {
+		t.Errorf("Expected a negative correction for rising offsets, got %f", estimate.SuggestedPPM)
+	}
+	if want := 1.5 - estimate.OffsetHz/float64(dial)*1e6; math.Abs(d.Estimate(dial, 1.5, now).SuggestedPPM-want) > 1e-9 {
+		t.Errorf("Expected the correction relative to the current calibration")
+	}
+
+	// A station retuning starts a new baseline instead of skewing the estimate
+	d.Record("N0ABC", 1500, now)
+	if got := d.Estimate(dial, 0, now).Samples; got != 6 {
+		t.Errorf("Expected a retune to add no sample, got %d samples", got)
+	}
+
+	// Everything ages out of the window
+	estimate = d.Estimate(dial, 0, now.Add(2*time.Hour))
+	if estimate.Samples != 0 || estimate.Stations != 0 {
+		t.Errorf("Expected an empty estimate after the window, got %+v", estimate)
+	}
+
+	d.Record("N0ABC", 1000, now)
+	d.Reset()
+	if got := d.Estimate(dial, 0, now).Stations; got != 0 {
+		t.Errorf("Expected no stations after Reset, got %d", got)
+	}
+}
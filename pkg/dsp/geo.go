@@ -0,0 +1,59 @@
+package dsp
+
+import (
+	"math"
+	"strings"
+)
+
+// earthRadiusKm is the mean radius of the earth
+const earthRadiusKm = 6371.0
+
+// GridPath returns the great-circle distance in km and the initial bearing
+// in degrees from true north from one grid square to another, measured
+// between the centres of the squares. ok is false unless both are grids.
+func GridPath(from, to string) (distance, bearing float64, ok bool) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if !packGridPattern.MatchString(from) || !packGridPattern.MatchString(to) {
+		return 0, 0, false
+	}
+
+	// Grid2Deg gives longitude west of Greenwich, as JS8 does
+	wlong1, lat1 := Grid2Deg(from)
+	wlong2, lat2 := Grid2Deg(to)
+	phi1, phi2 := radians(lat1), radians(lat2)
+	dLambda := radians(wlong1 - wlong2) // east from the first to the second
+
+	// Haversine distance
+	a := math.Pow(math.Sin((phi2-phi1)/2), 2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Pow(math.Sin(dLambda/2), 2)
+	distance = 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+	bearing = math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+
+	return distance, bearing, true
+}
+
+// GridCenter returns the latitude and longitude, east positive, of the
+// centre of a grid square. ok is false if grid is not a grid square.
+func GridCenter(grid string) (lat, lon float64, ok bool) {
+	grid = strings.ToUpper(grid)
+	if !packGridPattern.MatchString(grid) {
+		return 0, 0, false
+	}
+	if len(grid) < 6 {
+		// Grid2Deg places a square at its centre subsquare, a little off
+		// the centre of the square itself
+		lon = -180 + 20*float64(grid[0]-'A') + 2*float64(grid[2]-'0') + 1
+		lat = -90 + 10*float64(grid[1]-'A') + float64(grid[3]-'0') + 0.5
+		return lat, lon, true
+	}
+	wlong, latitude := Grid2Deg(grid)
+	return float64(latitude), -float64(wlong), true
+}
+
+// radians converts degrees from Grid2Deg to radians
+func radians(degrees float32) float64 {
+	return float64(degrees) * math.Pi / 180
+}
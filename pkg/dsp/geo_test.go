@@ -0,0 +1,70 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGridPath(t *testing.T) {
+	tests := []struct {
+		from, to    string
+		distance    float64 // km, within 1%
+		bearing     float64 // degrees, within 1
+		description string
+	}{
+		{"FN20", "JO62", 6436, 46, "New Jersey to Berlin"},
+		{"JO62", "FN20", 6436, 296, "Berlin to New Jersey"},
+		{"FN20", "EM12", 2150, 253, "New Jersey to Dallas"},
+		{"DM79mr", "DM79mr", 0, 0, "Same square"},
+	}
+
+	for _, tt := range tests {
+		distance, bearing, ok := GridPath(tt.from, tt.to)
+		if !ok {
+			t.Errorf("%s: expected a path", tt.description)
+			continue
+		}
+		if math.Abs(distance-tt.distance) > tt.distance*0.01+1 {
+			t.Errorf("%s: expected about %.0f km, got %.0f", tt.description, tt.distance, distance)
+		}
+		if tt.distance > 0 && math.Abs(bearing-tt.bearing) > 1 {
+			t.Errorf("%s: expected a bearing of about %.0f, got %.0f", tt.description, tt.bearing, bearing)
+		}
+	}
+
+	for _, grid := range []string{"", "FN2", "ZZ99", "K3DEP"} {
+		if _, _, ok := GridPath("FN20", grid); ok {
+			t.Errorf("Expected no path to %q", grid)
+		}
+	}
+}
+
+func TestGridCenter(t *testing.T) {
+	tests := []struct {
+		grid     string
+		lat, lon float64
+	}{
+		{"FN20", 40.5, -75},
+		{"JO62", 52.5, 13},
+		{"DM79mr", 39.73, -104.96},
+	}
+
+	for _, tt := range tests {
+		lat, lon, ok := GridCenter(tt.grid)
+		if !ok {
+			t.Errorf("%s: expected a position", tt.grid)
+			continue
+		}
+		if math.Abs(lat-tt.lat) > 0.05 || math.Abs(lon-tt.lon) > 0.05 {
+			t.Errorf("%s: expected %.2f,%.2f, got %.2f,%.2f", tt.grid, tt.lat, tt.lon, lat, lon)
+		}
+		// A square is placed at its very centre, not its centre subsquare
+		if len(tt.grid) == 4 && (lat != tt.lat || lon != tt.lon) {
+			t.Errorf("%s: expected exactly %v,%v, got %v,%v", tt.grid, tt.lat, tt.lon, lat, lon)
+		}
+	}
+
+	if _, _, ok := GridCenter("ZZ99"); ok {
+		t.Error("ZZ99 is not a grid square")
+	}
+}
@@ -0,0 +1,162 @@
+package dsp
+
+import (
+	"sync"
+	"time"
+)
+
+// DecodeLimits bounds the work a decoder does on each buffer
+type DecodeLimits struct {
+	FFTSize       int // Signal search FFT length, smaller means coarser frequency resolution
+	MaxCandidates int // Maximum messages decoded from one buffer
+}
+
+// LimitedDecoder is implemented by decoders whose work per buffer can be reduced
+type LimitedDecoder interface {
+	SetDecodeLimits(limits DecodeLimits)
+}
+
+// decodeLevels are the steps the governor walks through, from full quality down
+var decodeLevels = []DecodeLimits{
+	{FFTSize: 1024, MaxCandidates: 10},
+	{FFTSize: 1024, MaxCandidates: 5},
+	{FFTSize: 512, MaxCandidates: 5},
+	{FFTSize: 256, MaxCandidates: 3},
+}
+
+// DefaultDecodeLimits returns the full quality decode limits
+func DefaultDecodeLimits() DecodeLimits {
+	return decodeLevels[0]
+}
+
+// DecodeLevelLimits returns the limits of a decode level, 0 being full
+// quality, held to the levels there are
+func DecodeLevelLimits(level int) DecodeLimits {
+	if level < 0 {
+		level = 0
+	}
+	if level >= len(decodeLevels) {
+		level = len(decodeLevels) - 1
+	}
+	return decodeLevels[level]
+}
+
+const (
+	// Consecutive cycles over budget before shedding work
+	governorOverrunCycles = 2
+	// Consecutive cycles under half the budget before restoring work
+	governorRecoverCycles = 10
+)
+
+// DecodeGovernor measures decode time per cycle and steps the decode
+// limits down when decoding takes more than its share of the cycle
+type DecodeGovernor struct {
+	cycle  time.Duration
+	budget float64 // Fraction of the cycle decoding may use
+
+	level      int
+	cycleStart time.Time
+	busy       time.Duration
+	lastLoad   float64
+	overruns   int
+	underruns  int
+	changes    int
+
+	mutex sync.Mutex
+}
+
+// NewDecodeGovernor creates a governor for the given cycle length and budget fraction
+func NewDecodeGovernor(cycle time.Duration, budget float64) *DecodeGovernor {
+	if budget <= 0 || budget > 1 {
+		budget = 0.5
+	}
+	return &DecodeGovernor{
+		cycle:  cycle,
+		budget: budget,
+	}
+}
+
+// Record adds one decode's elapsed time. When a cycle completes it returns
+// the new limits and true if the decode level changed.
+func (g *DecodeGovernor) Record(elapsed time.Duration, now time.Time) (DecodeLimits, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.cycleStart.IsZero() {
+		g.cycleStart = now
+	}
+	g.busy += elapsed
+
+	if now.Sub(g.cycleStart) < g.cycle {
+		return decodeLevels[g.level], false
+	}
+
+	g.lastLoad = float64(g.busy) / float64(now.Sub(g.cycleStart))
+	g.cycleStart = now
+	g.busy = 0
+
+	previous := g.level
+	switch {
+	case g.lastLoad > g.budget:
+		g.underruns = 0
+		g.overruns++
+		if g.overruns >= governorOverrunCycles && g.level < len(decodeLevels)-1 {
+			g.level++
+			g.overruns = 0
+		}
+	case g.lastLoad < g.budget/2:
+		g.overruns = 0
+		g.underruns++
+		if g.underruns >= governorRecoverCycles && g.level > 0 {
+			g.level--
+			g.underruns = 0
+		}
+	default:
+		g.overruns = 0
+		g.underruns = 0
+	}
+
+	if g.level != previous {
+		g.changes++
+		return decodeLevels[g.level], true
+	}
+	return decodeLevels[g.level], false
+}
+
+// Level returns the current decode level, 0 being full quality
+func (g *DecodeGovernor) Level() int {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.level
+}
+
+// Limits returns the decode limits for the current level
+func (g *DecodeGovernor) Limits() DecodeLimits {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return decodeLevels[g.level]
+}
+
+// LastLoad returns the fraction of the last complete cycle spent decoding
+func (g *DecodeGovernor) LastLoad() float64 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.lastLoad
+}
+
+// GetStatistics returns the governor state for status reporting
+func (g *DecodeGovernor) GetStatistics() map[string]interface{} {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	limits := decodeLevels[g.level]
+	return map[string]interface{}{
+		"level":          g.level,
+		"max_level":      len(decodeLevels) - 1,
+		"budget":         g.budget,
+		"last_load":      g.lastLoad,
+		"fft_size":       limits.FFTSize,
+		"max_candidates": limits.MaxCandidates,
+		"changes":        g.changes,
+	}
+}
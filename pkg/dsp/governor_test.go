@@ -0,0 +1,84 @@
+package dsp
+
+import (
+	"testing"
+	"time"
+)
+
+// runCycles records one decode per cycle using the given load fraction
+func runCycles(g *DecodeGovernor, start time.Time, cycles int, load float64) time.Time {
+	cycle := 15 * time.Second
+	now := start
+	g.Record(0, now)
+	for i := 0; i < cycles; i++ {
+		now = now.Add(cycle)
+		g.Record(time.Duration(load*float64(cycle)), now)
+	}
+	return now
+}
+
+func TestDecodeGovernorStepsDown(t *testing.T) {
+	g := NewDecodeGovernor(15*time.Second, 0.5)
+	now := time.Now()
+
+	// A single slow cycle is tolerated
+	now = runCycles(g, now, 1, 0.9)
+	if g.Level() != 0 {
+		t.Fatalf("Expected level 0 after one slow cycle, got %d", g.Level())
+	}
+
+	now = runCycles(g, now, 1, 0.9)
+	if g.Level() != 1 {
+		t.Fatalf("Expected level 1 after two slow cycles, got %d", g.Level())
+	}
+	if g.Limits().MaxCandidates >= DefaultDecodeLimits().MaxCandidates {
+		t.Errorf("Expected fewer candidates at level 1, got %d", g.Limits().MaxCandidates)
+	}
+
+	// Sustained overload bottoms out at the last level
+	runCycles(g, now, 20, 0.9)
+	if g.Level() != len(decodeLevels)-1 {
+		t.Errorf("Expected level %d under sustained overload, got %d", len(decodeLevels)-1, g.Level())
+	}
+	if g.Limits().FFTSize >= DefaultDecodeLimits().FFTSize {
+		t.Errorf("Expected a smaller FFT at the last level, got %d", g.Limits().FFTSize)
+	}
+}
+
+func TestDecodeGovernorRecovers(t *testing.T) {
+	g := NewDecodeGovernor(15*time.Second, 0.5)
+	now := runCycles(g, time.Now(), 4, 0.9)
+	level := g.Level()
+	if level == 0 {
+		t.Fatal("Expected governor to step down")
+	}
+
+	// Loads between half the budget and the budget hold the level
+	now = runCycles(g, now, 20, 0.4)
+	if g.Level() != level {
+		t.Errorf("Expected level %d to hold, got %d", level, g.Level())
+	}
+
+	runCycles(g, now, governorRecoverCycles, 0.1)
+	if g.Level() != level-1 {
+		t.Errorf("Expected level %d after a quiet stretch, got %d", level-1, g.Level())
+	}
+}
+
+func TestDecodeGovernorReportsChange(t *testing.T) {
+	g := NewDecodeGovernor(time.Second, 0.5)
+	now := time.Now()
+
+	g.Record(900*time.Millisecond, now)
+	if _, changed := g.Record(0, now.Add(time.Second)); changed {
+		t.Error("Expected no change after the first slow cycle")
+	}
+	g.Record(900*time.Millisecond, now.Add(time.Second))
+	limits, changed := g.Record(0, now.Add(2*time.Second))
+	if !changed {
+		t.Fatal("Expected a change after the second slow cycle")
+	}
+	if limits != decodeLevels[1] {
+		t.Errorf("Expected level 1 limits, got %+v", limits)
+	}
+}
@@ -88,13 +88,6 @@ func computeCRC12(data []byte) uint16 {
 	return crc ^ 42 // XOR with 42 as in original
 }
 
-// Placeholder parity matrix function
-// TODO: Replace with real 87x87 parity matrix from JS8Call
-func getParityBit(row, col int) bool {
-	// Placeholder pattern - this generates incorrect parity but allows testing
-	return ((row*13 + col*17) % 3) == 0
-}
-
 // JS8Encoder represents a pure Go JS8 encoder
 type JS8Encoder struct{}
 
@@ -177,7 +170,7 @@ func (e *JS8Encoder) EncodeMessage(message string, frameType int) ([]int, error)
 		var parityMask byte = 0x80
 
 		for j := 0; j < 87; j++ {
-			if getParityBit(i, j) && (bytes[parityByte]&parityMask) != 0 {
+			if parityMatrix[i][j] && (bytes[parityByte]&parityMask) != 0 {
 				parityBits++
 			}
 			if parityMask == 1 {
@@ -219,14 +212,23 @@ func (e *JS8Encoder) EncodeMessage(message string, frameType int) ([]int, error)
 
 // GenerateAudio converts tone sequence to audio samples
 func (e *JS8Encoder) GenerateAudio(tones []int, sampleRate int) []int16 {
+	return e.GenerateAudioAt(tones, DefaultTXOffset, sampleRate)
+}
+
+// GenerateAudioAt converts a tone sequence to audio samples with tone 0 at
+// baseFreq Hz
+func (e *JS8Encoder) GenerateAudioAt(tones []int, baseFreq float64, sampleRate int) []int16 {
 	// JS8 Normal mode parameters (matching original JS8Call)
 	const duration = 15.0                    // seconds
-	const baseFreq = 1500.0                  // Hz (JS8Call standard)
 	const baseFreqSpacing = 6.25             // Hz (JS8Call standard spacing)
 
-	toneDuration := duration / float64(len(tones))
-	samplesPerTone := int(toneDuration * float64(sampleRate))
-	totalSamples := len(tones) * samplesPerTone
+	// Each tone lasts one JS8 symbol, 0.16 s, with silence after the last
+	// to fill the 15 second cycle
+	samplesPerTone := sampleRate * normalSymbolSamples / decodeSampleRate
+	totalSamples := int(duration * float64(sampleRate))
+	if totalSamples < len(tones)*samplesPerTone {
+		totalSamples = len(tones) * samplesPerTone
+	}
 
 	audio := make([]int16, totalSamples)
 	sampleIdx := 0
@@ -255,6 +257,41 @@ func (e *JS8Encoder) GenerateAudio(tones []int, sampleRate int) []int16 {
 	return audio
 }
 
+// GenerateSubmodeAudio converts a Normal mode tone sequence to audio sent in
+// mode with tone 0 at offset Hz, swapping in the submode's Costas arrays and
+// filling the rest of its cycle with silence
+func (e *JS8Encoder) GenerateSubmodeAudio(tones []int, mode JS8Mode, offset float64, sampleRate int) ([]int16, error) {
+	sub, ok := submodes[mode]
+	if !ok {
+		return nil, fmt.Errorf("unknown submode %d", mode)
+	}
+	if len(tones) != js8Symbols {
+		return nil, fmt.Errorf("expected %d tones, got %d", js8Symbols, len(tones))
+	}
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate %d", sampleRate)
+	}
+
+	mix := make([]float64, int(sub.period*float64(sampleRate)))
+	addSignal(mix, sampleRate, submodeTones(append([]int(nil), tones...), sub), sub, offset, 0, 0, 26000)
+
+	audio := make([]int16, len(mix))
+	for i, value := range mix {
+		audio[i] = int16(value)
+	}
+	return audio, nil
+}
+
+// GenerateTone returns seconds of a steady tone at freq Hz, such as the
+// carrier keyed for an antenna tuner
+func GenerateTone(freq, seconds float64, sampleRate int) []int16 {
+	audio := make([]int16, int(seconds*float64(sampleRate)))
+	for i := range audio {
+		audio[i] = int16(26000 * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)))
+	}
+	return audio
+}
+
 // EncodeToAudio is a convenience function that encodes message directly to audio
 func (e *JS8Encoder) EncodeToAudio(message string, frameType int, sampleRate int) ([]int16, error) {
 	tones, err := e.EncodeMessage(message, frameType)
@@ -281,6 +318,12 @@ func ValidateMessage(message string) error {
 // PreprocessJS8Message preprocesses a message to make it compatible with JS8 encoding
 // This handles common JS8 message formats and removes invalid characters
 func PreprocessJS8Message(message string) string {
+	// Compound, data, transfer and session frames are already packed and
+	// use the lowercase half of the alphabet, so they go out as they are
+	if IsCompoundFrame(message) || IsDataFrame(message) || IsTransferFrame(message) || IsSessionFrame(message) {
+		return message
+	}
+
 	// Handle common JS8 message patterns
 	message = strings.TrimSpace(message)
 
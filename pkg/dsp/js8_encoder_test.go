@@ -1,6 +1,7 @@
 package dsp
 
 import (
+	"math"
 	"testing"
 )
 
@@ -175,6 +176,69 @@ func TestAudioGeneration(t *testing.T) {
 	t.Logf("✓ Generated %d audio samples (%d non-zero)", len(audio), nonZero)
 }
 
+func TestSubmodeAudioGeneration(t *testing.T) {
+	encoder := NewJS8Encoder()
+	tones, err := encoder.EncodeMessage("CQ-N0CALL-XX", 0)
+	if err != nil {
+		t.Fatalf("Encoding failed: %v", err)
+	}
+
+	for _, mode := range []JS8Mode{ModeNormal, ModeFast, ModeTurbo, ModeSlow, ModeUltra} {
+		audio, err := encoder.GenerateSubmodeAudio(tones, mode, 1000, 48000)
+		if err != nil {
+			t.Fatalf("%s audio failed: %v", mode, err)
+		}
+		if want := int(SimPeriod(mode) * 48000); len(audio) != want {
+			t.Errorf("%s gave %d samples, want %d", mode, len(audio), want)
+		}
+	}
+	if tones[0] != costasNormal[0][0] {
+		t.Error("GenerateSubmodeAudio changed the tones it was given")
+	}
+
+	// Normal audio at an offset decodes there once placed in a cycle
+	audio, err := encoder.GenerateSubmodeAudio(tones, ModeNormal, 800, decodeSampleRate)
+	if err != nil {
+		t.Fatalf("Normal audio failed: %v", err)
+	}
+	buffer := selfTestBuffer(audio, decodeSampleRate, nil)
+	var decoded *DecodeResult
+	NewDSP().DecodeBuffer(buffer, func(result *DecodeResult) { decoded = result })
+	if decoded == nil || decoded.Message != "CQ-N0CALL-XX" || math.Abs(float64(decoded.Frequency)-800) > 2 {
+		t.Errorf("Expected CQ-N0CALL-XX at 800 Hz, got %+v", decoded)
+	}
+
+	if _, err := encoder.GenerateSubmodeAudio(tones, JS8Mode(3), 1000, 12000); err == nil {
+		t.Error("Expected error for an unknown submode")
+	}
+	if _, err := encoder.GenerateSubmodeAudio(tones[:10], ModeNormal, 1000, 12000); err == nil {
+		t.Error("Expected error for a short tone sequence")
+	}
+}
+
+func TestGenerateTone(t *testing.T) {
+	audio := GenerateTone(1500, 2, 48000)
+	if len(audio) != 96000 {
+		t.Fatalf("Expected 96000 samples, got %d", len(audio))
+	}
+
+	// 1500 Hz crosses zero 3000 times a second
+	crossings := 0
+	peak := 0
+	for i, s := range audio {
+		if i > 0 && (audio[i-1] < 0) != (s < 0) {
+			crossings++
+		}
+		peak = max(peak, int(s))
+	}
+	if crossings < 5990 || crossings > 6010 {
+		t.Errorf("Expected about 6000 zero crossings, got %d", crossings)
+	}
+	if peak < 25000 || peak > 26000 {
+		t.Errorf("Peak %d outside the expected level", peak)
+	}
+}
+
 func TestInvalidMessages(t *testing.T) {
 	encoder := NewJS8Encoder()
 
@@ -0,0 +1,69 @@
+package dsp
+
+import (
+	"fmt"
+	"hash/crc32"
+)
+
+// js8d sends forms, files and sessions in frames of its own, which JS8Call
+// has no frame type for. Every frame type JS8Call has is taken, so they are
+// sent as the dense coded data frames JS8Call sends free text in, which
+// js8d never reads as text, with a kind telling them apart:
+//
+//	[1 data][1 dense][2 kind][68 bits of the kind, ending in a check]
+//
+// A js8d frame can't be read as another kind, or as Huffman coded text. A
+// JS8Call station hears one as a data frame of garbled text. A JS8Call dense
+// coded frame passes a kind's check by chance about once in 130,000 frames
+// for forms, 40,000 for sessions and a million for transfers, and is then
+// taken for a js8d frame rather than shown as text; the CRC of the form or
+// file it lands in keeps it out of the payload. Forms and telemetry always
+// use these frames; files and sessions only once files.enabled and
+// arq.enabled are set.
+type js8dKind uint8
+
+const (
+	js8dForm     js8dKind = 0 // DataFrame
+	js8dTransfer js8dKind = 1 // TransferFrame
+	js8dSession  js8dKind = 2 // SessionFrame
+)
+
+// js8dHeaderBits is the size of the header every js8d frame starts with
+const js8dHeaderBits = 4
+
+// js8dHeader returns the header of a js8d frame of a kind
+func js8dHeader(kind js8dKind) []bool {
+	return append([]bool{true, true}, intToBits(uint64(kind), 2)...)
+}
+
+// packJS8DFrame packs the 72 bits of a js8d frame into 12 characters
+func packJS8DFrame(bits []bool) string {
+	return Pack72bits(bitsToInt(bits[:64]), uint8(bitsToInt(bits[64:])))
+}
+
+// unpackJS8DFrame returns the 72 bits of a js8d frame of a kind, header
+// and all
+func unpackJS8DFrame(frame string, kind js8dKind) ([]bool, error) {
+	if len(frame) != 12 || ValidateMessage(frame) != nil {
+		return nil, fmt.Errorf("frame %q is not a packed frame", frame)
+	}
+
+	var rem uint8
+	bits := append(intToBits(Unpack72bits(frame, &rem), 64), intToBits(uint64(rem), 8)...)
+	if !bits[0] || !bits[1] || js8dKind(bitsToInt(bits[2:js8dHeaderBits])) != kind {
+		return nil, fmt.Errorf("frame %q is not a js8d frame of kind %d", frame, kind)
+	}
+	return bits, nil
+}
+
+// js8dFrameCheck returns the CRC-32 of a js8d frame's bits before its
+// check, cut to the size of the check
+func js8dFrameCheck(bits []bool, size int) uint64 {
+	packed := make([]byte, (len(bits)+7)/8)
+	for i, bit := range bits {
+		if bit {
+			packed[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return uint64(crc32.ChecksumIEEE(packed)) & (1<<size - 1)
+}
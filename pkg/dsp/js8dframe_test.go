@@ -0,0 +1,82 @@
+package dsp
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestJS8DFrameKinds(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomBytes := func(n int) []byte {
+		b := make([]byte, n)
+		rng.Read(b)
+		return b
+	}
+
+	// Frames of each kind, many enough that a check passing by chance
+	// would show
+	kinds := map[string][]string{}
+	for i := 0; i < 200; i++ {
+		forms, _ := PackDataFrames(uint8(i), randomBytes(DataFrameBytes*3))
+		kinds["form"] = append(kinds["form"], forms...)
+
+		data, _ := PackTransferFrame(TransferFrame{ID: uint8(i % TransferFrameIDs), Seq: uint16(i), Data: randomBytes(TransferFrameBytes)})
+		control, _ := PackTransferFrame(TransferFrame{ID: uint8(i % TransferFrameIDs), Control: true, Op: uint8(i % 5), End: uint16(i), FromHash: uint16(rng.Intn(1024)), ToHash: uint16(rng.Intn(1024))})
+		kinds["transfer"] = append(kinds["transfer"], data, control)
+
+		data, _ = PackSessionFrame(SessionFrame{ID: uint8(i % 64), Seq: uint8(i), Data: randomBytes(SessionFrameBytes)})
+		control, _ = PackSessionFrame(SessionFrame{ID: uint8(i % 64), Control: true, Op: uint8(i % 5), Seq: uint8(i), Mask: uint16(rng.Intn(1 << 16)), FromHash: uint16(rng.Intn(1024)), ToHash: uint16(rng.Intn(1024))})
+		kinds["session"] = append(kinds["session"], data, control)
+	}
+	text, _ := PackDataMessageFrames("THE QUICK BROWN FOX JUMPS OVER THE LAZY DOG 0123456789 ?!/+-.")
+	kinds["text"] = text
+
+	is := map[string]func(string) bool{
+		"form":     IsDataFrame,
+		"transfer": IsTransferFrame,
+		"session":  IsSessionFrame,
+		"text": func(frame string) bool {
+			_, err := UnpackDataMessage(frame)
+			return err == nil
+		},
+	}
+	for kind, frames := range kinds {
+		for _, frame := range frames {
+			for other, isOther := range is {
+				if isOther(frame) != (kind == other) {
+					t.Fatalf("%s frame %q: expected taken as a %s frame %v", kind, frame, other, kind == other)
+				}
+			}
+			// js8d frames are dense coded, which is never read as text
+			if _, err := UnpackDataMessage(frame); kind != "text" && !errors.Is(err, ErrDenseCoded) {
+				t.Errorf("%s frame %q: expected dense coding, got %v", kind, frame, err)
+			}
+		}
+	}
+}
+
+func TestJS8DFrameChance(t *testing.T) {
+	// Random dense coded frames, as JS8Call sends free text in, seldom pass
+	// a js8d frame's check: about 1.5 of these for forms, 0.2 for transfers
+	// and 5 for sessions
+	rng := rand.New(rand.NewSource(1))
+	taken := map[string]int{}
+	for i := 0; i < 200000; i++ {
+		frame := Pack72bits(rng.Uint64()|3<<62, uint8(rng.Intn(256)))
+		if IsDataFrame(frame) {
+			taken["form"]++
+		}
+		if IsTransferFrame(frame) {
+			taken["transfer"]++
+		}
+		if IsSessionFrame(frame) {
+			taken["session"]++
+		}
+	}
+	for kind, most := range map[string]int{"form": 10, "transfer": 5, "session": 20} {
+		if taken[kind] > most {
+			t.Errorf("Expected at most %d random frames taken for %s frames, got %d", most, kind, taken[kind])
+		}
+	}
+}
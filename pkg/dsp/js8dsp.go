@@ -2,11 +2,9 @@ package dsp
 
 import (
 	"fmt"
-	"time"
 	"math"
-	"math/cmplx"
-
-	"github.com/mjibson/go-dsp/fft"
+	"strings"
+	"time"
 )
 
 // DSPEngine defines the interface for JS8 DSP implementations
@@ -24,6 +22,71 @@ type DSPEngine interface {
 	GetToneCount(mode JS8Mode) int
 }
 
+// DefaultTXOffset is the audio offset in Hz transmissions go out at unless
+// another is set
+const DefaultTXOffset = 1500.0
+
+// SignalBandwidth is the width in Hz of a Normal mode signal, its 8 tones
+// 6.25 Hz apart
+const SignalBandwidth = 8 * 6.25
+
+// OffsetEncoder is implemented by encoders that can transmit at an audio
+// offset other than DefaultTXOffset
+type OffsetEncoder interface {
+	SetTXOffset(hz float64)
+}
+
+// TransmissionEncoder is implemented by encoders that can mark a frame as
+// the first or last of a message in its transmission type bits
+type TransmissionEncoder interface {
+	SetTXTransmission(t TransmissionType)
+}
+
+// SubmodeDecoder is implemented by decoders that decode only some of the
+// submodes; a decoder without it is taken to decode them all
+type SubmodeDecoder interface {
+	DecodesSubmode(mode JS8Mode) bool
+}
+
+// The audio offsets decoders search for signals between, by their tone 0
+const (
+	MinDecodeHz = 200.0
+	MaxDecodeHz = 2900.0
+)
+
+// PassbandDecoder is implemented by decoders that can keep to a narrower
+// window of audio offsets than MinDecodeHz to MaxDecodeHz
+type PassbandDecoder interface {
+	SetPassband(low, high float64)
+}
+
+// Decoder backends selectable with the dsp.decoder setting
+const (
+	DecoderAuto   = "auto"   // Native library when built in, otherwise pure Go
+	DecoderNative = "native" // C++ library through cgo
+	DecoderGo     = "go"     // Pure Go decoder, no cgo required
+)
+
+// NewEngine creates the DSP engine for the named decoder backend
+func NewEngine(decoder string) (DSPEngine, error) {
+	switch decoder {
+	case "", DecoderAuto:
+		if NativeAvailable {
+			return newNativeEngine(), nil
+		}
+		return NewDSP(), nil
+	case DecoderNative:
+		if !NativeAvailable {
+			return nil, fmt.Errorf("native decoder not built in, rebuild with cgo and without the purego tag")
+		}
+		return newNativeEngine(), nil
+	case DecoderGo:
+		return NewDSP(), nil
+	default:
+		return nil, fmt.Errorf("unknown decoder %q", decoder)
+	}
+}
+
 // JS8Mode represents JS8 submodes
 type JS8Mode int
 
@@ -35,6 +98,33 @@ const (
 	ModeUltra  JS8Mode = 8
 )
 
+// modeNames are the submodes by name, as JS8Call's speed menu gives them
+var modeNames = map[JS8Mode]string{
+	ModeNormal: "normal",
+	ModeFast:   "fast",
+	ModeTurbo:  "turbo",
+	ModeSlow:   "slow",
+	ModeUltra:  "ultra",
+}
+
+// String returns the submode's name
+func (m JS8Mode) String() string {
+	if name, ok := modeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("mode(%d)", int(m))
+}
+
+// ParseJS8Mode returns the submode with the given name, ignoring case
+func ParseJS8Mode(name string) (JS8Mode, error) {
+	for mode, n := range modeNames {
+		if strings.EqualFold(name, n) {
+			return mode, nil
+		}
+	}
+	return ModeNormal, fmt.Errorf("unknown submode %q", name)
+}
+
 // DecodeResult represents a decoded JS8 message
 type DecodeResult struct {
 	UTC       int     `json:"utc"`
@@ -45,12 +135,22 @@ type DecodeResult struct {
 	Type      int     `json:"type"`
 	Quality   float32 `json:"quality"`
 	Mode      int     `json:"mode"`
+
+	// What the message was decoded from, for protocol debugging; only the
+	// pure Go decoder fills them in
+	Payload []byte `json:"-"` // The 87 decoded bits packed MSB first
+	Tones   []int  `json:"-"` // Strongest tone of each of the 79 symbols as received
 }
 
 // DSP represents the pure Go JS8 DSP engine
 type DSP struct {
 	encoder    *JS8Encoder
 	sampleRate int
+	limits     DecodeLimits
+	txOffset   float64 // Audio offset of tone 0 in encoded messages
+	txType     TransmissionType
+	passLow    float64 // Audio offsets of tone 0 searched for signals
+	passHigh   float64
 }
 
 // NewDSP creates a new pure Go DSP instance
@@ -58,6 +158,10 @@ func NewDSP() *DSP {
 	return &DSP{
 		encoder:    NewJS8Encoder(),
 		sampleRate: 12000, // Default JS8 sample rate
+		limits:     DefaultDecodeLimits(),
+		txOffset:   DefaultTXOffset,
+		passLow:    MinDecodeHz,
+		passHigh:   MaxDecodeHz,
 	}
 }
 
@@ -82,44 +186,37 @@ func (d *DSP) GetSampleRate() int {
 	return d.sampleRate
 }
 
-// findSignals uses FFT to find potential JS8 signals in the audio
-func (d *DSP) findSignals(audioData []int16) []float32 {
-	if len(audioData) < 1024 {
-		return nil
-	}
-
-	// Convert int16 to complex128 for FFT
-	fftInput := make([]complex128, 1024)
-	for i := 0; i < 1024 && i < len(audioData); i++ {
-		fftInput[i] = complex(float64(audioData[i])/32768.0, 0)
-	}
-
-	// Apply window function (Hann window)
-	for i := range fftInput {
-		window := 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/float64(len(fftInput)-1)))
-		fftInput[i] *= complex(window, 0)
-	}
+// SetDecodeLimits sets the FFT size and candidate count used per buffer
+func (d *DSP) SetDecodeLimits(limits DecodeLimits) {
+	d.limits = limits
+}
 
-	// Perform FFT using go-dsp
-	fftOutput := fft.FFT(fftInput)
+// SetPassband limits the signal search to tone 0 offsets from low to high
+// Hz, held to MinDecodeHz and MaxDecodeHz. A narrower window searches fewer
+// offsets, so it also takes less CPU.
+func (d *DSP) SetPassband(low, high float64) {
+	d.passLow = math.Max(low, MinDecodeHz)
+	d.passHigh = math.Min(high, MaxDecodeHz)
+}
 
-	// Calculate power spectrum
-	var frequencies []float32
-	for i := 0; i < len(fftOutput)/2; i++ {
-		power := cmplx.Abs(fftOutput[i])
-		freq := float32(i * d.sampleRate / len(fftOutput))
+// SetTXOffset sets the audio offset messages are encoded at
+func (d *DSP) SetTXOffset(hz float64) {
+	d.txOffset = hz
+}
 
-		// JS8 signals are typically between 300-3000 Hz
-		if freq >= 300 && freq <= 3000 && power > 0.1 {
-			frequencies = append(frequencies, freq)
-		}
-	}
+// SetTXTransmission sets the transmission type bits messages are encoded
+// with
+func (d *DSP) SetTXTransmission(t TransmissionType) {
+	d.txType = t
+}
 
-	return frequencies
+// DecodesSubmode reports whether the decoder decodes signals in mode; it
+// searches for Normal signals only
+func (d *DSP) DecodesSubmode(mode JS8Mode) bool {
+	return mode == ModeNormal
 }
 
 // DecodeBuffer decodes audio samples and calls the callback for each decoded message
-// Now uses gonum/fourier for signal detection
 func (d *DSP) DecodeBuffer(audioData []int16, callback func(*DecodeResult)) (int, error) {
 	if len(audioData) == 0 {
 		return 0, fmt.Errorf("empty audio data")
@@ -129,21 +226,8 @@ func (d *DSP) DecodeBuffer(audioData []int16, callback func(*DecodeResult)) (int
 		return 0, fmt.Errorf("callback function required")
 	}
 
-	// Use FFT to find potential signals
-	signals := d.findSignals(audioData)
-
-	// For each potential signal, attempt basic decoding
-	var decodeCount int
-
-	// TODO: Replace with real C++ DSP library integration
-	// For now, disable fake signal generation to prevent spam
-	// Real JS8 signals will be processed once C++ library is integrated
-	_ = signals // Acknowledge we found signals but don't process them
-
-	// Return 0 decode count - no fake messages
-	decodeCount = 0
-
-	return decodeCount, nil
+	samples := toDecodeRate(audioData, d.sampleRate)
+	return decodeNormal(samples, d.limits, d.passLow, d.passHigh, callback), nil
 }
 
 // EncodeMessage encodes a text message to audio samples using pure Go
@@ -153,8 +237,11 @@ func (d *DSP) EncodeMessage(message string, mode JS8Mode) ([]int16, error) {
 		return nil, fmt.Errorf("empty message")
 	}
 
-	// Preprocess message to handle spaces and invalid characters
-	message = PreprocessJS8Message(message)
+	// Preprocess message to handle spaces and invalid characters. Data
+	// frames, sent with transmission type bits, are already packed.
+	if d.txType == JS8Call {
+		message = PreprocessJS8Message(message)
+	}
 	if len(message) == 0 {
 		return nil, fmt.Errorf("message became empty after preprocessing")
 	}
@@ -173,12 +260,34 @@ func (d *DSP) EncodeMessage(message string, mode JS8Mode) ([]int16, error) {
 	}
 
 	// Use pure Go encoder
-	audio, err := d.encoder.EncodeToAudio(paddedMessage, int(mode), d.sampleRate)
+	tones, err := d.encoder.EncodeMessage(paddedMessage, int(d.txType))
 	if err != nil {
 		return nil, fmt.Errorf("encoding failed: %w", err)
 	}
 
-	return audio, nil
+	if mode != ModeNormal {
+		return d.encoder.GenerateSubmodeAudio(tones, mode, d.txOffset, d.sampleRate)
+	}
+	return d.encoder.GenerateAudioAt(tones, d.txOffset, d.sampleRate), nil
+}
+
+// encodeSubmode encodes a message to audio in a submode, and with
+// transmission type bits t, with the pure Go encoder, for engines whose own
+// encoder only sends plain Normal frames
+func encodeSubmode(message string, mode JS8Mode, t TransmissionType, offset float64, sampleRate int) ([]int16, error) {
+	if t == JS8Call {
+		message = PreprocessJS8Message(message)
+	}
+	padded, err := PadMessage(message, '-')
+	if err != nil {
+		return nil, fmt.Errorf("message padding failed: %w", err)
+	}
+	encoder := NewJS8Encoder()
+	tones, err := encoder.EncodeMessage(padded, int(t))
+	if err != nil {
+		return nil, fmt.Errorf("encoding failed: %w", err)
+	}
+	return encoder.GenerateSubmodeAudio(tones, mode, offset, sampleRate)
 }
 
 // GetError returns the last error message (pure Go - not needed)
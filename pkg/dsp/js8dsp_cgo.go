@@ -1,3 +1,5 @@
+//go:build cgo && !purego
+
 package dsp
 
 /*
@@ -13,16 +15,32 @@ import (
 	"unsafe"
 )
 
+// NativeAvailable reports whether the native decode library is built in
+const NativeAvailable = true
+
+func newNativeEngine() DSPEngine {
+	return NewCppDSP()
+}
+
 // CppDSP represents the C++ DSP engine with real JS8Call algorithms
 type CppDSP struct {
 	handle     C.js8dsp_handle_t
 	sampleRate int
+	limits     DecodeLimits
+	passLow    float64 // Audio offsets of tone 0 decodes are kept between
+	passHigh   float64
+	txOffset   float64 // Audio offset of tone 0 in encoded messages
+	txType     TransmissionType
 }
 
 // NewCppDSP creates a new C++ DSP instance
 func NewCppDSP() *CppDSP {
 	return &CppDSP{
 		sampleRate: 48000, // Default to 48kHz
+		limits:     DefaultDecodeLimits(),
+		passLow:    MinDecodeHz,
+		passHigh:   MaxDecodeHz,
+		txOffset:   DefaultTXOffset,
 	}
 }
 
@@ -58,6 +76,36 @@ func (d *CppDSP) GetSampleRate() int {
 	return d.sampleRate
 }
 
+// SetDecodeLimits caps the messages decoded per buffer. The native decoder
+// picks its own FFT size, so only the candidate count applies.
+func (d *CppDSP) SetDecodeLimits(limits DecodeLimits) {
+	d.limits = limits
+}
+
+// SetPassband keeps the decodes whose tone 0 is from low to high Hz. The
+// native decoder still searches its whole passband, so this saves no CPU.
+func (d *CppDSP) SetPassband(low, high float64) {
+	d.passLow = low
+	d.passHigh = high
+}
+
+// SetTXOffset sets the audio offset messages are encoded at
+func (d *CppDSP) SetTXOffset(hz float64) {
+	d.txOffset = hz
+}
+
+// SetTXTransmission sets the transmission type bits messages are encoded
+// with
+func (d *CppDSP) SetTXTransmission(t TransmissionType) {
+	d.txType = t
+}
+
+// DecodesSubmode reports whether the decoder decodes signals in mode; the
+// library decodes Normal signals only
+func (d *CppDSP) DecodesSubmode(mode JS8Mode) bool {
+	return mode == ModeNormal
+}
+
 // DecodeBuffer decodes audio samples and calls the callback for each decoded message
 func (d *CppDSP) DecodeBuffer(audioData []int16, callback func(*DecodeResult)) (int, error) {
 	if d.handle == nil {
@@ -79,7 +127,7 @@ func (d *CppDSP) DecodeBuffer(audioData []int16, callback func(*DecodeResult)) (
 	}
 
 	// Prepare output buffer for decoded messages
-	const maxMessages = 10
+	maxMessages := d.limits.MaxCandidates
 	messages := make([]C.js8dsp_decoded_message_t, maxMessages)
 
 	// Call C++ decode function
@@ -100,8 +148,13 @@ func (d *CppDSP) DecodeBuffer(audioData []int16, callback func(*DecodeResult)) (
 	}
 
 	// Convert C messages to Go and call callback
+	kept := 0
 	for i := 0; i < int(decodeCount); i++ {
 		msg := &messages[i]
+		if offset := float64(msg.freq_offset); offset < d.passLow || offset > d.passHigh {
+			continue
+		}
+		kept++
 		result := &DecodeResult{
 			UTC:       int(time.Now().Unix()),
 			SNR:       int(msg.snr),
@@ -115,7 +168,7 @@ func (d *CppDSP) DecodeBuffer(audioData []int16, callback func(*DecodeResult)) (
 		callback(result)
 	}
 
-	return int(decodeCount), nil
+	return kept, nil
 }
 
 // EncodeMessage encodes a text message to audio samples using C++ DSP
@@ -128,6 +181,12 @@ func (d *CppDSP) EncodeMessage(message string, mode JS8Mode) ([]int16, error) {
 		return nil, fmt.Errorf("empty message")
 	}
 
+	// The library only encodes Normal frames of no transmission type at
+	// DefaultTXOffset; the others use the Go encoder
+	if mode != ModeNormal || d.txType != JS8Call || d.txOffset != DefaultTXOffset {
+		return encodeSubmode(message, mode, d.txType, d.txOffset, d.sampleRate)
+	}
+
 	// Get required buffer size
 	cMessage := C.CString(message)
 	defer C.free(unsafe.Pointer(cMessage))
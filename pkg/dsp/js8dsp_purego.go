@@ -0,0 +1,10 @@
+//go:build !cgo || purego
+
+package dsp
+
+// NativeAvailable reports whether the native decode library is built in
+const NativeAvailable = false
+
+func newNativeEngine() DSPEngine {
+	return nil
+}
@@ -45,6 +45,70 @@ func TestEncodeMessage(t *testing.T) {
 	}
 }
 
+func TestEncodeAtOffset(t *testing.T) {
+	d := NewDSP()
+	d.SetTXOffset(1000)
+
+	encoded, err := d.EncodeMessage("CQ-N0CALL-XX", ModeNormal)
+	if err != nil {
+		t.Fatalf("Failed to encode message: %v", err)
+	}
+
+	// The decoder expects the signal half a second into the buffer
+	audio := append(make([]int16, d.GetSampleRate()/2), encoded...)
+	var results []*DecodeResult
+	d.DecodeBuffer(audio, func(result *DecodeResult) {
+		results = append(results, result)
+	})
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 decode, got %d", len(results))
+	}
+	if freq := float64(results[0].Frequency); freq < 1000-toneSpacing/2 || freq > 1000+toneSpacing/2 {
+		t.Errorf("Expected the signal at 1000 Hz, got %.1f Hz", freq)
+	}
+}
+
+func TestEncodeTransmission(t *testing.T) {
+	d := NewDSP()
+	d.SetTXTransmission(JS8CallFirst)
+
+	frame, _, err := PackDataMessage("HELLO WORLD")
+	if err != nil {
+		t.Fatalf("Failed to pack: %v", err)
+	}
+	encoded, err := d.EncodeMessage(frame, ModeNormal)
+	if err != nil {
+		t.Fatalf("Failed to encode message: %v", err)
+	}
+
+	// The data frame goes out as packed, marked as the first of a message
+	audio := append(make([]int16, d.GetSampleRate()/2), encoded...)
+	var results []*DecodeResult
+	d.DecodeBuffer(audio, func(result *DecodeResult) {
+		results = append(results, result)
+	})
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 decode, got %d", len(results))
+	}
+	if results[0].Message != frame || TransmissionType(results[0].Type) != JS8CallFirst {
+		t.Errorf("Expected %q of type %d, got %q of type %d", frame, JS8CallFirst, results[0].Message, results[0].Type)
+	}
+}
+
+func TestEncodeSubmodes(t *testing.T) {
+	d := NewDSP()
+	for _, mode := range []JS8Mode{ModeFast, ModeTurbo, ModeSlow} {
+		audio, err := d.EncodeMessage("CQ-N0CALL-XX", mode)
+		if err != nil {
+			t.Fatalf("Failed to encode in %s: %v", mode, err)
+		}
+		// A whole cycle of the submode
+		if want := int(d.EstimateAudioDuration(mode).Seconds()) * d.GetSampleRate(); len(audio) != want {
+			t.Errorf("Expected %d samples in %s, got %d", want, mode, len(audio))
+		}
+	}
+}
+
 func TestDecodeMessage(t *testing.T) {
 	dsp := NewDSP()
 	defer dsp.Close()
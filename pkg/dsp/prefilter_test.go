@@ -0,0 +1,133 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+func sineWave(freq float64, sampleRate, n int, amplitude float64) []int16 {
+	samples := make([]int16, n)
+	for i := range samples {
+		samples[i] = int16(amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)))
+	}
+	return samples
+}
+
+func rms(samples []int16) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+func TestPreFilterBandPass(t *testing.T) {
+	const sampleRate = 12000
+
+	tests := []struct {
+		name    string
+		freq    float64
+		passing bool
+	}{
+		{"Below Passband", 60, false},
+		{"In Passband", 1500, true},
+		{"Above Passband", 5000, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewPreFilter(sampleRate, DefaultPreFilterConfig())
+			input := sineWave(tt.freq, sampleRate, sampleRate, 10000)
+			output := filter.Process(input)
+
+			// Skip the filter settling time
+			ratio := rms(output[sampleRate/2:]) / rms(input[sampleRate/2:])
+			if tt.passing && ratio < 0.8 {
+				t.Errorf("Expected %.0f Hz to pass, got ratio %.3f", tt.freq, ratio)
+			}
+			if !tt.passing && ratio > 0.2 {
+				t.Errorf("Expected %.0f Hz to be attenuated, got ratio %.3f", tt.freq, ratio)
+			}
+		})
+	}
+}
+
+func TestPreFilterNotch(t *testing.T) {
+	const sampleRate = 12000
+
+	config := PreFilterConfig{NotchFrequencies: []float64{1000}}
+	filter := NewPreFilter(sampleRate, config)
+
+	input := sineWave(1000, sampleRate, sampleRate, 10000)
+	output := filter.Process(input)
+
+	ratio := rms(output[sampleRate/2:]) / rms(input[sampleRate/2:])
+	if ratio > 0.1 {
+		t.Errorf("Expected carrier to be notched, got ratio %.3f", ratio)
+	}
+}
+
+func TestPreFilterNoiseBlanker(t *testing.T) {
+	const sampleRate = 12000
+
+	filter := NewPreFilter(sampleRate, PreFilterConfig{NoiseBlanker: true, BlankerThreshold: 8})
+	if !filter.Enabled() {
+		t.Fatal("Expected filter with noise blanker to be enabled")
+	}
+
+	input := sineWave(1500, sampleRate, sampleRate, 1000)
+	input[sampleRate/2] = 32000 // Impulse
+
+	output := filter.Process(input)
+	if output[sampleRate/2] != 0 {
+		t.Errorf("Expected impulse to be blanked, got %d", output[sampleRate/2])
+	}
+
+	stats := filter.GetStatistics()
+	if stats["samples_blanked"].(int64) == 0 {
+		t.Error("Expected blanked sample count to be recorded")
+	}
+}
+
+func TestPreFilterNoiseBlankerLevelStep(t *testing.T) {
+	const sampleRate = 12000
+
+	filter := NewPreFilter(sampleRate, PreFilterConfig{NoiseBlanker: true, BlankerThreshold: 8})
+
+	// Quiet band, then a strong station starts
+	quiet := sineWave(1500, sampleRate, sampleRate, 300)
+	loud := sineWave(1500, sampleRate, sampleRate/2, 8000)
+	filter.Process(quiet)
+	output := filter.Process(loud)
+
+	// Once settled the strong signal must pass untouched
+	muted := 0
+	for i := len(loud) / 2; i < len(loud); i++ {
+		if output[i] != loud[i] {
+			muted++
+		}
+	}
+	if muted > 0 {
+		t.Errorf("Expected strong signal to pass after level step, %d of %d samples blanked", muted, len(loud)/2)
+	}
+
+	stats := filter.GetStatistics()
+	if pct := stats["blanked_pct"].(float64); pct > maxBlankFraction*100 {
+		t.Errorf("Expected blanked share below %.0f%%, got %.2f%%", maxBlankFraction*100, pct)
+	}
+}
+
+func TestPreFilterDisabled(t *testing.T) {
+	filter := NewPreFilter(12000, PreFilterConfig{})
+	if filter.Enabled() {
+		t.Error("Expected empty configuration to disable the filter")
+	}
+
+	input := sineWave(1500, 12000, 256, 1000)
+	output := filter.Process(input)
+	for i := range input {
+		if input[i] != output[i] {
+			t.Fatalf("Expected passthrough at sample %d: %d != %d", i, output[i], input[i])
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package dsp
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// The self-test encodes a message, runs the audio back through the decoder
+// and checks the message comes out, optionally with noise added to bring
+// the signal down to a chosen SNR. Sweeping finds the weakest signal the
+// decoder still copies, in steps down from 0 dB.
+const (
+	// SelfTestMessage is sent when no message is given
+	SelfTestMessage = "SELFTEST"
+
+	// Sweep range and step, in dB
+	selfTestSweepTop    = 0.0
+	selfTestSweepBottom = -30.0
+	selfTestSweepStep   = 2.0
+)
+
+// SelfTestResult is the outcome of a self-test
+type SelfTestResult struct {
+	Message   string   `json:"message"`             // The frame as sent, padded to 12 characters
+	Decoded   string   `json:"decoded"`             // What came back, empty when nothing decoded
+	Passed    bool     `json:"passed"`              // The message came back unchanged
+	SNR       *float64 `json:"snr,omitempty"`       // SNR the noise was added at, nil for a clean signal
+	Measured  *int     `json:"measured,omitempty"`  // SNR the decoder reported
+	Decoder   string   `json:"decoder"`             // go or native
+	Sweep     bool     `json:"sweep"`               // A threshold sweep was run
+	Threshold *float64 `json:"threshold,omitempty"` // Lowest SNR of the sweep that decoded
+}
+
+// SelfTest runs message through engine's encoder and decoder, adding noise
+// at snr dB when snr is not nil, and with sweep also finds the decode
+// threshold. The noise is seeded so a test gives the same result each run.
+func SelfTest(engine DSPEngine, message string, snr *float64, sweep bool) (*SelfTestResult, error) {
+	if strings.TrimSpace(message) == "" {
+		message = SelfTestMessage
+	}
+	frame, err := PadMessage(PreprocessJS8Message(message), '-')
+	if err != nil {
+		return nil, fmt.Errorf("self-test message: %w", err)
+	}
+
+	audio, err := engine.EncodeMessage(message, ModeNormal)
+	if err != nil {
+		return nil, fmt.Errorf("self-test encode failed: %w", err)
+	}
+
+	result := &SelfTestResult{Message: frame, SNR: snr, Decoder: DecoderNative, Sweep: sweep}
+	if _, ok := engine.(*DSP); ok {
+		result.Decoder = DecoderGo
+	}
+
+	decoded, measured, err := selfTestDecode(engine, audio, frame, snr)
+	if err != nil {
+		return nil, err
+	}
+	result.Decoded = decoded
+	result.Passed = measured != nil
+	result.Measured = measured
+
+	if sweep {
+		// Decoding gets no easier as the SNR drops, so the threshold is
+		// found by bisecting the sweep's levels. lo passes, hi fails.
+		levels := int((selfTestSweepTop-selfTestSweepBottom)/selfTestSweepStep) + 1
+		lo, hi := -1, levels
+		for hi-lo > 1 {
+			mid := (lo + hi) / 2
+			level := selfTestSweepTop - float64(mid)*selfTestSweepStep
+			_, measured, err := selfTestDecode(engine, audio, frame, &level)
+			if err != nil {
+				return nil, err
+			}
+			if measured != nil {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		if lo >= 0 {
+			threshold := selfTestSweepTop - float64(lo)*selfTestSweepStep
+			result.Threshold = &threshold
+		}
+	}
+	return result, nil
+}
+
+// selfTestDecode decodes audio with noise added at snr, returning what
+// decoded, frame itself when it came back, and the SNR the decoder reported
+// for it, nil when it did not
+func selfTestDecode(engine DSPEngine, audio []int16, frame string, snr *float64) (string, *int, error) {
+	buffer := selfTestBuffer(audio, engine.GetSampleRate(), snr)
+
+	decoded := ""
+	var measured *int
+	_, err := engine.DecodeBuffer(buffer, func(result *DecodeResult) {
+		if measured != nil {
+			return
+		}
+		decoded = result.Message
+		if trimPadding(result.Message) == trimPadding(frame) {
+			snr := result.SNR
+			measured = &snr
+		}
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("self-test decode failed: %w", err)
+	}
+	return decoded, measured, nil
+}
+
+// selfTestBuffer places audio where a transmission starts in a receive
+// cycle and, when snr is not nil, scales it against seeded white noise
+// to that SNR
+func selfTestBuffer(audio []int16, sampleRate int, snr *float64) []int16 {
+	start := int(nominalStart * float64(sampleRate))
+	buffer := make([]int16, start+len(audio))
+	if snr == nil {
+		copy(buffer[start:], audio)
+		return buffer
+	}
+
+	// Signal power over the samples that carry tones
+	var power float64
+	var n int
+	for _, s := range audio {
+		if s != 0 {
+			power += float64(s) * float64(s)
+			n++
+		}
+	}
+	if n == 0 {
+		return buffer
+	}
+	power /= float64(n)
+
+	// The same noise as a simulation, with the audio scaled to a tone of
+	// the amplitude that gives snr
+	gain := snrAmplitude(*snr, sampleRate) / math.Sqrt(2*power)
+
+	rng := rand.New(rand.NewSource(1))
+	for i := range buffer {
+		value := simNoise * rng.NormFloat64()
+		if i >= start {
+			value += gain * float64(audio[i-start])
+		}
+		buffer[i] = int16(math.Max(-32768, math.Min(32767, value)))
+	}
+	return buffer
+}
+
+// trimPadding drops the fill characters a short message is padded with
+func trimPadding(message string) string {
+	return strings.TrimRight(message, "- ")
+}
@@ -0,0 +1,54 @@
+package dsp
+
+import "testing"
+
+func TestSelfTest(t *testing.T) {
+	result, err := SelfTest(NewDSP(), "", nil, false)
+	if err != nil {
+		t.Fatalf("SelfTest failed: %v", err)
+	}
+	if !result.Passed || result.Decoded != "SELFTEST----" || result.Measured == nil {
+		t.Errorf("Expected the clean self-test to pass, got %+v", result)
+	}
+	if result.Decoder != DecoderGo || result.Threshold != nil {
+		t.Errorf("Expected a go decoder result with no sweep, got %+v", result)
+	}
+
+	tests := []struct {
+		snr  float64
+		pass bool
+	}{
+		{-10, true},
+		{-16, true},
+		{-28, false},
+	}
+	for _, tt := range tests {
+		snr := tt.snr
+		result, err := SelfTest(NewDSP(), "K3DEP", &snr, false)
+		if err != nil {
+			t.Fatalf("SelfTest at %v dB failed: %v", tt.snr, err)
+		}
+		if result.Passed != tt.pass {
+			t.Errorf("Self-test at %v dB passed = %v, want %v (%+v)", tt.snr, result.Passed, tt.pass, result)
+		}
+	}
+}
+
+func TestSelfTestSweep(t *testing.T) {
+	result, err := SelfTest(NewDSP(), "CQ CQ CQ", nil, true)
+	if err != nil {
+		t.Fatalf("SelfTest failed: %v", err)
+	}
+	if !result.Sweep || result.Threshold == nil {
+		t.Fatalf("Expected a sweep threshold, got %+v", result)
+	}
+	if *result.Threshold > -14 || *result.Threshold < selfTestSweepBottom {
+		t.Errorf("Expected the decoder to copy below -14 dB, threshold %v dB", *result.Threshold)
+	}
+}
+
+func TestSelfTestErrors(t *testing.T) {
+	if _, err := SelfTest(NewDSP(), "THIS MESSAGE IS FAR TOO LONG", nil, false); err == nil {
+		t.Error("Expected error for a message over 12 characters")
+	}
+}
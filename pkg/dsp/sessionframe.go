@@ -0,0 +1,121 @@
+package dsp
+
+import "fmt"
+
+// Session frames carry a connected-mode session between two js8d stations.
+// Data frames place 5 bytes of the session's stream at a sequence number;
+// control frames connect, poll, acknowledge and disconnect. They are js8d
+// frames of the session kind:
+//
+//	data:    [4 header][1 0][6 id][8 seq][40 data][13 check]
+//	control: [4 header][1 1][6 id][3 op][8 seq][16 mask][10 from][10 to][14 check]
+//
+// from and to are TransferCallHash of the sending and receiving
+// stations, so only they act on a session's control frames. An ACK's seq is
+// the next frame expected in order and its mask the frames after that
+// already in, the lowest bit for seq+1.
+const (
+	SessionFrameBytes = 5
+	SessionMaskFrames = 16
+)
+
+// Session control operations
+const (
+	SessionConnect    uint8 = 0 // the sender asks to open session id
+	SessionAccept     uint8 = 1 // the receiver opens it
+	SessionPoll       uint8 = 2 // the sender asks which frames before seq are in
+	SessionAck        uint8 = 3 // the frames in are seq-1 and before, and those in mask
+	SessionDisconnect uint8 = 4 // either side closes the session, or refuses to open it
+)
+
+// SessionFrame is one frame of a session
+type SessionFrame struct {
+	ID      uint8
+	Control bool
+	Seq     uint8
+
+	// Data frames
+	Data []byte
+
+	// Control frames
+	Op       uint8
+	Mask     uint16
+	FromHash uint16
+	ToHash   uint16
+}
+
+// PackSessionFrame packs a session frame into a 12 character frame
+func PackSessionFrame(f SessionFrame) (string, error) {
+	if f.ID > 63 {
+		return "", fmt.Errorf("session id %d does not fit", f.ID)
+	}
+	bits := js8dHeader(js8dSession)
+	if f.Control {
+		if f.Op > SessionDisconnect {
+			return "", fmt.Errorf("unknown session operation %d", f.Op)
+		}
+		bits = append(bits, true)
+		bits = append(bits, intToBits(uint64(f.ID), 6)...)
+		bits = append(bits, intToBits(uint64(f.Op), 3)...)
+		bits = append(bits, intToBits(uint64(f.Seq), 8)...)
+		bits = append(bits, intToBits(uint64(f.Mask), 16)...)
+		bits = append(bits, intToBits(uint64(f.FromHash&0x3ff), 10)...)
+		bits = append(bits, intToBits(uint64(f.ToHash&0x3ff), 10)...)
+		bits = append(bits, intToBits(js8dFrameCheck(bits, 14), 14)...)
+	} else {
+		if len(f.Data) > SessionFrameBytes {
+			return "", fmt.Errorf("session frame with %d bytes does not fit", len(f.Data))
+		}
+		bits = append(bits, false)
+		bits = append(bits, intToBits(uint64(f.ID), 6)...)
+		bits = append(bits, intToBits(uint64(f.Seq), 8)...)
+		data := make([]byte, SessionFrameBytes)
+		copy(data, f.Data)
+		for _, b := range data {
+			bits = append(bits, intToBits(uint64(b), 8)...)
+		}
+		bits = append(bits, intToBits(js8dFrameCheck(bits, 13), 13)...)
+	}
+
+	return packJS8DFrame(bits), nil
+}
+
+// UnpackSessionFrame reverses PackSessionFrame
+func UnpackSessionFrame(frame string) (SessionFrame, error) {
+	bits, err := unpackJS8DFrame(frame, js8dSession)
+	if err != nil {
+		return SessionFrame{}, fmt.Errorf("frame %q is not a session frame", frame)
+	}
+
+	f := SessionFrame{Control: bits[4], ID: uint8(bitsToInt(bits[5:11]))}
+	if f.Control {
+		if bitsToInt(bits[58:]) != js8dFrameCheck(bits[:58], 14) {
+			return SessionFrame{}, fmt.Errorf("session frame %q fails its check", frame)
+		}
+		f.Op = uint8(bitsToInt(bits[11:14]))
+		f.Seq = uint8(bitsToInt(bits[14:22]))
+		f.Mask = uint16(bitsToInt(bits[22:38]))
+		f.FromHash = uint16(bitsToInt(bits[38:48]))
+		f.ToHash = uint16(bitsToInt(bits[48:58]))
+		if f.Op > SessionDisconnect {
+			return SessionFrame{}, fmt.Errorf("session frame %q is not a valid control frame", frame)
+		}
+		return f, nil
+	}
+
+	if bitsToInt(bits[59:]) != js8dFrameCheck(bits[:59], 13) {
+		return SessionFrame{}, fmt.Errorf("session frame %q fails its check", frame)
+	}
+	f.Seq = uint8(bitsToInt(bits[11:19]))
+	f.Data = make([]byte, SessionFrameBytes)
+	for i := range f.Data {
+		f.Data[i] = uint8(bitsToInt(bits[19+8*i : 27+8*i]))
+	}
+	return f, nil
+}
+
+// IsSessionFrame reports whether frame is a valid session frame
+func IsSessionFrame(frame string) bool {
+	_, err := UnpackSessionFrame(frame)
+	return err == nil
+}
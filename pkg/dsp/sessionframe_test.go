@@ -0,0 +1,67 @@
+package dsp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSessionFrames(t *testing.T) {
+	frames := []SessionFrame{
+		{ID: 63, Seq: 255, Data: []byte{0, 1, 0xfe, 0xff, 7}},
+		{ID: 5, Seq: 12, Data: []byte("ab")},
+		{ID: 5, Control: true, Op: SessionAck, Seq: 9, Mask: 0x8001, FromHash: TransferCallHash("K1ABC"), ToHash: TransferCallHash("W1AW")},
+		{ID: 0, Control: true, Op: SessionDisconnect},
+	}
+	for _, want := range frames {
+		frame, err := PackSessionFrame(want)
+		if err != nil {
+			t.Fatalf("PackSessionFrame(%+v) failed: %v", want, err)
+		}
+		if len(frame) != 12 || ValidateMessage(frame) != nil {
+			t.Fatalf("Frame %q is not a 12 character JS8 frame", frame)
+		}
+		if IsCompoundFrame(frame) || IsDataFrame(frame) || IsTransferFrame(frame) {
+			t.Errorf("Session frame %q taken for another kind", frame)
+		}
+		if PreprocessJS8Message(frame) != frame {
+			t.Errorf("Expected preprocessing to leave %q alone", frame)
+		}
+
+		got, err := UnpackSessionFrame(frame)
+		if err != nil {
+			t.Fatalf("UnpackSessionFrame(%q) failed: %v", frame, err)
+		}
+		if !want.Control {
+			want.Data = append(want.Data, make([]byte, SessionFrameBytes-len(want.Data))...)
+		}
+		if got.ID != want.ID || got.Control != want.Control || got.Seq != want.Seq || !bytes.Equal(got.Data, want.Data) ||
+			got.Op != want.Op || got.Mask != want.Mask || got.FromHash != want.FromHash || got.ToHash != want.ToHash {
+			t.Errorf("Expected %+v back, got %+v", want, got)
+		}
+
+		damaged := []byte(frame)
+		damaged[5] ^= 2
+		if IsSessionFrame(string(damaged)) {
+			t.Errorf("Expected damaged frame %q to be rejected", damaged)
+		}
+	}
+
+	// Form and transfer frames and plain text are not session frames
+	dataFrames, _ := PackDataFrames(1, []byte("form"))
+	transferFrame, _ := PackTransferFrame(TransferFrame{ID: 5, Data: []byte("file")})
+	for _, frame := range []string{dataFrames[0], transferFrame, "W1AW-DE-K1AB", "short"} {
+		if IsSessionFrame(frame) {
+			t.Errorf("Expected %q not to be a session frame", frame)
+		}
+	}
+
+	for _, bad := range []SessionFrame{
+		{ID: 64},
+		{Data: []byte("too long")},
+		{Control: true, Op: 7},
+	} {
+		if _, err := PackSessionFrame(bad); err == nil {
+			t.Errorf("Expected an error packing %+v", bad)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package dsp
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Simulated audio puts any number of JS8 transmissions into one receive
+// cycle of white noise, each at its own offset, SNR, drift and submode, so
+// the decoder can be measured against signals whose content is known.
+const (
+	// simNoise is the RMS of the noise; signals are scaled against it so a
+	// few strong ones still fit without clipping
+	simNoise = 2000.0
+	// snrBandwidth is the bandwidth SNRs are given in, as JS8Call reports
+	// them
+	snrBandwidth = 2500.0
+	// simMatchHz is how far a decode may be from a signal's offset and
+	// still be counted as that signal
+	simMatchHz = 25.0
+)
+
+// submode is the timing of a JS8 submode at the 12 kHz decode rate
+type submode struct {
+	symbolSamples int     // Samples per symbol, which also sets the tone spacing
+	period        float64 // Seconds in a receive cycle
+	startDelay    float64 // Seconds into the cycle a transmission starts
+	costas        [3][7]int
+}
+
+// costasModified are the Costas arrays of every submode but Normal
+var costasModified = [3][7]int{
+	{0, 6, 2, 3, 5, 4, 1},
+	{1, 5, 0, 2, 3, 6, 4},
+	{2, 5, 0, 6, 4, 1, 3},
+}
+
+// submodes are JS8Call's submode timings
+var submodes = map[JS8Mode]submode{
+	ModeNormal: {1920, 15, 0.5, costasNormal},
+	ModeFast:   {1200, 10, 0.2, costasModified},
+	ModeTurbo:  {600, 6, 0.1, costasModified},
+	ModeSlow:   {3840, 30, 0.5, costasModified},
+	ModeUltra:  {384, 4, 0.1, costasModified},
+}
+
+// SimSignal is one transmission in simulated audio
+type SimSignal struct {
+	Message string  `json:"message"` // Sent as js8d would send it, padded to 12 characters
+	Type    int     `json:"type"`    // Frame type bits
+	Mode    JS8Mode `json:"mode"`
+	Offset  float64 `json:"offset"` // Hz of tone 0 at the start
+	SNR     float64 `json:"snr"`    // dB in 2500 Hz
+	Drift   float64 `json:"drift"`  // Hz per second the signal moves
+	DT      float64 `json:"dt"`     // Seconds late against the submode's start
+}
+
+// Frame returns the 12 characters the signal sends
+func (s SimSignal) Frame() (string, error) {
+	return PadMessage(PreprocessJS8Message(s.Message), '-')
+}
+
+// Duration returns how long the transmission lasts
+func (s SimSignal) Duration() float64 {
+	return float64(js8Symbols*submodes[s.Mode].symbolSamples) / decodeSampleRate
+}
+
+// SimPeriod returns the receive cycle of a submode in seconds
+func SimPeriod(mode JS8Mode) float64 {
+	return submodes[mode].period
+}
+
+// ToneSpacing returns the spacing of a submode's tones in Hz
+func ToneSpacing(mode JS8Mode) float64 {
+	if sub, ok := submodes[mode]; ok {
+		return decodeSampleRate / float64(sub.symbolSamples)
+	}
+	return 0
+}
+
+// Simulate renders signals over seconds of white noise at sampleRate. A
+// seconds of 0 is the longest cycle of the signals' submodes, or a Normal
+// cycle when there are no signals. The noise is
+// seeded by seed so a simulation can be run again exactly.
+func Simulate(signals []SimSignal, sampleRate int, seconds float64, seed int64) ([]int16, error) {
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate %d", sampleRate)
+	}
+	if seconds <= 0 {
+		for _, s := range signals {
+			seconds = max(seconds, SimPeriod(s.Mode))
+		}
+	}
+	if seconds <= 0 {
+		seconds = SimPeriod(ModeNormal)
+	}
+
+	mix := make([]float64, int(seconds*float64(sampleRate)))
+	rng := rand.New(rand.NewSource(seed))
+	for i := range mix {
+		mix[i] = simNoise * rng.NormFloat64()
+	}
+
+	encoder := NewJS8Encoder()
+	for n, s := range signals {
+		mode, ok := submodes[s.Mode]
+		if !ok {
+			return nil, fmt.Errorf("signal %d: unknown submode %d", n+1, s.Mode)
+		}
+		frame, err := s.Frame()
+		if err != nil {
+			return nil, fmt.Errorf("signal %d: %w", n+1, err)
+		}
+		tones, err := encoder.EncodeMessage(frame, s.Type)
+		if err != nil {
+			return nil, fmt.Errorf("signal %d: %w", n+1, err)
+		}
+		start := mode.startDelay + s.DT
+		addSignal(mix, sampleRate, submodeTones(tones, mode), mode, s.Offset, s.Drift, start, snrAmplitude(s.SNR, sampleRate))
+	}
+
+	audio := make([]int16, len(mix))
+	for i, value := range mix {
+		audio[i] = int16(math.Max(-32768, math.Min(32767, value)))
+	}
+	return audio, nil
+}
+
+// submodeTones swaps Normal's Costas arrays in tones for mode's
+func submodeTones(tones []int, mode submode) []int {
+	for i, pos := range costasOffsets {
+		copy(tones[pos:pos+7], mode.costas[i][:])
+	}
+	return tones
+}
+
+// addSignal adds tones sent in mode to mix, starting start seconds in at
+// offset Hz and moving drift Hz per second. Phase runs on across tone
+// changes, as a transmitter's does.
+func addSignal(mix []float64, sampleRate int, tones []int, mode submode, offset, drift, start, amplitude float64) {
+	symbol := float64(mode.symbolSamples) / decodeSampleRate
+	spacing := decodeSampleRate / float64(mode.symbolSamples)
+	phase := 0.0
+	for i := max(0, int(start*float64(sampleRate))); i < len(mix); i++ {
+		t := max(0, float64(i)/float64(sampleRate)-start)
+		sym := int(t / symbol)
+		if sym >= len(tones) {
+			break
+		}
+		freq := offset + float64(tones[sym])*spacing + drift*t
+		phase += 2 * math.Pi * freq / float64(sampleRate)
+		mix[i] += amplitude * math.Sin(phase)
+	}
+}
+
+// snrAmplitude is the amplitude of a tone at snr dB over the simulation's
+// noise. The noise spreads evenly up to half the sample rate, so only part
+// of it falls in the bandwidth the SNR is measured in.
+func snrAmplitude(snr float64, sampleRate int) float64 {
+	noiseInBand := simNoise * simNoise * snrBandwidth / (float64(sampleRate) / 2)
+	return math.Sqrt(2 * noiseInBand * math.Pow(10, snr/10))
+}
+
+// SimScore is how a decode of simulated audio went against the signals in it
+type SimScore struct {
+	Found  []bool         `json:"found"`  // Whether each signal decoded, in order
+	Missed int            `json:"missed"` // Signals that did not decode
+	False  []DecodeResult `json:"false"`  // Decodes that match no signal
+}
+
+// ScoreSim matches decodes to the signals they came from: the same frame
+// within simMatchHz of where the signal started. Each signal matches at
+// most one decode, the closest, and the rest are false decodes.
+func ScoreSim(signals []SimSignal, decodes []DecodeResult) SimScore {
+	score := SimScore{Found: make([]bool, len(signals))}
+	frames := make([]string, len(signals))
+	for i, s := range signals {
+		frames[i], _ = s.Frame()
+	}
+
+	// Closest matches first, so a near signal is not taken by a far decode
+	type match struct {
+		signal, decode int
+		distance       float64
+	}
+	var matches []match
+	for d, result := range decodes {
+		for i, s := range signals {
+			distance := math.Abs(float64(result.Frequency) - s.Offset)
+			if distance <= simMatchHz && trimPadding(result.Message) == trimPadding(frames[i]) {
+				matches = append(matches, match{i, d, distance})
+			}
+		}
+	}
+	sort.Slice(matches, func(a, b int) bool { return matches[a].distance < matches[b].distance })
+
+	used := make([]bool, len(decodes))
+	for _, m := range matches {
+		if score.Found[m.signal] || used[m.decode] {
+			continue
+		}
+		score.Found[m.signal] = true
+		used[m.decode] = true
+	}
+
+	for _, found := range score.Found {
+		if !found {
+			score.Missed++
+		}
+	}
+	for d, result := range decodes {
+		if !used[d] {
+			score.False = append(score.False, result)
+		}
+	}
+	return score
+}
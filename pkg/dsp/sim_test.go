@@ -0,0 +1,160 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// decodeSim decodes simulated audio with the Go decoder
+func decodeSim(t *testing.T, audio []int16) []DecodeResult {
+	t.Helper()
+	engine := NewDSP()
+	engine.SetSampleRate(decodeSampleRate)
+	var decodes []DecodeResult
+	if _, err := engine.DecodeBuffer(audio, func(result *DecodeResult) {
+		decodes = append(decodes, *result)
+	}); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	return decodes
+}
+
+func TestSimulateMultipleSignals(t *testing.T) {
+	signals := []SimSignal{
+		{Message: "K3DEP-W1AW", Offset: 600, SNR: -6},
+		{Message: "N0CALL-EM12", Offset: 1200, SNR: -12, DT: 0.4},
+		{Message: "SIMULATED", Offset: 1900, SNR: -8, Drift: 0.1},
+		{Message: "HELLO-WORLD", Offset: 2500, SNR: -10, DT: -0.3},
+	}
+	audio, err := Simulate(signals, decodeSampleRate, 0, 1)
+	if err != nil {
+		t.Fatalf("Simulate failed: %v", err)
+	}
+	if len(audio) != 15*decodeSampleRate {
+		t.Errorf("Expected one 15 s cycle, got %d samples", len(audio))
+	}
+
+	score := ScoreSim(signals, decodeSim(t, audio))
+	for i, found := range score.Found {
+		if !found {
+			t.Errorf("Signal %d (%s at %.0f Hz) did not decode", i+1, signals[i].Message, signals[i].Offset)
+		}
+	}
+	if len(score.False) > 0 {
+		t.Errorf("Unexpected false decodes: %+v", score.False)
+	}
+}
+
+func TestSimulateNoiseOnly(t *testing.T) {
+	for seed := int64(1); seed <= 3; seed++ {
+		audio, err := Simulate(nil, decodeSampleRate, 0, seed)
+		if err != nil {
+			t.Fatalf("Simulate failed: %v", err)
+		}
+		if score := ScoreSim(nil, decodeSim(t, audio)); len(score.False) > 0 {
+			t.Errorf("Seed %d: noise decoded as %+v", seed, score.False)
+		}
+	}
+}
+
+func TestSimulateSNR(t *testing.T) {
+	// A signal above the noise measures close to the SNR it was made at
+	signals := []SimSignal{{Message: "SNRTEST", Offset: 1000, SNR: -4}}
+	audio, err := Simulate(signals, decodeSampleRate, 0, 2)
+	if err != nil {
+		t.Fatalf("Simulate failed: %v", err)
+	}
+	decodes := decodeSim(t, audio)
+	if len(decodes) != 1 {
+		t.Fatalf("Expected one decode, got %d", len(decodes))
+	}
+	if math.Abs(float64(decodes[0].SNR)-signals[0].SNR) > 4 {
+		t.Errorf("Signal at %.0f dB measured %d dB", signals[0].SNR, decodes[0].SNR)
+	}
+}
+
+func TestSimulateSubmodes(t *testing.T) {
+	tests := []struct {
+		mode     JS8Mode
+		period   float64
+		duration float64
+	}{
+		{ModeNormal, 15, 12.64},
+		{ModeFast, 10, 7.9},
+		{ModeTurbo, 6, 3.95},
+		{ModeSlow, 30, 25.28},
+		{ModeUltra, 4, 2.528},
+	}
+	for _, tt := range tests {
+		s := SimSignal{Message: "TEST", Mode: tt.mode, Offset: 1000, SNR: 20}
+		if got := SimPeriod(tt.mode); got != tt.period {
+			t.Errorf("%s period = %v, want %v", tt.mode, got, tt.period)
+		}
+		if got := ToneSpacing(tt.mode); math.Abs(got*tt.duration/79-1) > 1e-9 {
+			t.Errorf("%s tone spacing = %v, want one tone per symbol", tt.mode, got)
+		}
+		if got := s.Duration(); math.Abs(got-tt.duration) > 1e-9 {
+			t.Errorf("%s duration = %v, want %v", tt.mode, got, tt.duration)
+		}
+
+		audio, err := Simulate([]SimSignal{s}, 48000, 0, 1)
+		if err != nil {
+			t.Fatalf("Simulate %s failed: %v", tt.mode, err)
+		}
+		if want := int(tt.period * 48000); len(audio) != want {
+			t.Errorf("%s gave %d samples, want %d", tt.mode, len(audio), want)
+		}
+	}
+
+	// Mixed submodes fill the longest cycle
+	audio, err := Simulate([]SimSignal{{Message: "A", Mode: ModeTurbo}, {Message: "B", Mode: ModeSlow}}, decodeSampleRate, 0, 1)
+	if err != nil || len(audio) != 30*decodeSampleRate {
+		t.Errorf("Mixed submodes gave %d samples, %v", len(audio), err)
+	}
+
+	if _, err := Simulate([]SimSignal{{Message: "A", Mode: JS8Mode(3)}}, decodeSampleRate, 0, 1); err == nil {
+		t.Error("Expected error for an unknown submode")
+	}
+	if _, err := Simulate([]SimSignal{{Message: "THIS MESSAGE IS TOO LONG"}}, decodeSampleRate, 0, 1); err == nil {
+		t.Error("Expected error for a message over 12 characters")
+	}
+}
+
+func TestScoreSim(t *testing.T) {
+	signals := []SimSignal{
+		{Message: "ALPHA", Offset: 1000},
+		{Message: "ALPHA", Offset: 1500},
+		{Message: "BRAVO", Offset: 2000},
+	}
+	decodes := []DecodeResult{
+		{Message: "ALPHA-------", Frequency: 1502},
+		{Message: "ALPHA-------", Frequency: 1498},
+		{Message: "CHARLIE-----", Frequency: 2000},
+		{Message: "BRAVO-------", Frequency: 2100},
+	}
+	score := ScoreSim(signals, decodes)
+	if score.Found[0] || !score.Found[1] || score.Found[2] {
+		t.Errorf("Found = %v, want [false true false]", score.Found)
+	}
+	if score.Missed != 2 {
+		t.Errorf("Missed = %d, want 2", score.Missed)
+	}
+	if len(score.False) != 3 {
+		t.Errorf("Expected 3 false decodes, got %+v", score.False)
+	}
+}
+
+func TestParseJS8Mode(t *testing.T) {
+	for _, mode := range []JS8Mode{ModeNormal, ModeFast, ModeTurbo, ModeSlow, ModeUltra} {
+		got, err := ParseJS8Mode(mode.String())
+		if err != nil || got != mode {
+			t.Errorf("ParseJS8Mode(%q) = %v, %v", mode.String(), got, err)
+		}
+	}
+	if got, err := ParseJS8Mode("TURBO"); err != nil || got != ModeTurbo {
+		t.Errorf("ParseJS8Mode(\"TURBO\") = %v, %v", got, err)
+	}
+	if _, err := ParseJS8Mode("warp"); err == nil {
+		t.Error("Expected error for an unknown submode")
+	}
+}
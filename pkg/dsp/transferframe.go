@@ -0,0 +1,135 @@
+package dsp
+
+import (
+	"fmt"
+	"hash/crc32"
+)
+
+// Transfer frames carry a file between two stations with retransmission of
+// lost parts. Data frames place 4 bytes of the file at a frame number;
+// control frames offer, poll, acknowledge or ask again for a transfer.
+// They are js8d frames of the transfer kind:
+//
+//	data:    [4 header][1 0][7 id][10 seq][32 data][18 check]
+//	control: [4 header][1 1][7 id][3 op][10 start][10 end][10 from][10 to][17 check]
+//
+// from and to are TransferCallHash of the sending and receiving stations,
+// so only they act on a transfer's control frames. The frame count of a
+// transfer is the end of its offer and polls, which leaves data frames room
+// for a check wide enough that a JS8Call dense coded frame is seldom taken
+// for one.
+const (
+	TransferFrameBytes = 4
+	MaxTransferFrames  = 1024
+	TransferFrameIDs   = 128 // Transfers that can be under way at once, one per id
+)
+
+// MaxTransferPayload is the largest payload a transfer can carry
+const MaxTransferPayload = TransferFrameBytes * MaxTransferFrames
+
+// Transfer control operations
+const (
+	TransferOffer  uint8 = 0 // the sender is about to send frames start to end
+	TransferPoll   uint8 = 1 // the sender asks what is missing of frames start to end
+	TransferAck    uint8 = 2 // the receiver has the whole file
+	TransferNack   uint8 = 3 // the receiver is missing frames start to end
+	TransferCancel uint8 = 4 // either side gives up on the transfer
+)
+
+// TransferFrame is one frame of a file transfer
+type TransferFrame struct {
+	ID      uint8
+	Control bool
+
+	// Data frames
+	Seq  uint16
+	Data []byte
+
+	// Control frames
+	Op       uint8
+	Start    uint16
+	End      uint16
+	FromHash uint16
+	ToHash   uint16
+}
+
+// TransferCallHash returns the 10 bit hash of a callsign control frames
+// identify stations by
+func TransferCallHash(callsign string) uint16 {
+	return uint16(crc32.ChecksumIEEE([]byte(callsign)) & 0x3ff)
+}
+
+// PackTransferFrame packs a transfer frame into a 12 character frame
+func PackTransferFrame(f TransferFrame) (string, error) {
+	if f.ID >= TransferFrameIDs {
+		return "", fmt.Errorf("transfer id %d does not fit", f.ID)
+	}
+	bits := js8dHeader(js8dTransfer)
+	if f.Control {
+		if f.Op > TransferCancel {
+			return "", fmt.Errorf("unknown transfer operation %d", f.Op)
+		}
+		bits = append(bits, true)
+		bits = append(bits, intToBits(uint64(f.ID), 7)...)
+		bits = append(bits, intToBits(uint64(f.Op), 3)...)
+		for _, v := range []uint16{f.Start, f.End, f.FromHash, f.ToHash} {
+			bits = append(bits, intToBits(uint64(v&0x3ff), 10)...)
+		}
+		bits = append(bits, intToBits(js8dFrameCheck(bits, 17), 17)...)
+	} else {
+		if len(f.Data) > TransferFrameBytes || f.Seq >= MaxTransferFrames {
+			return "", fmt.Errorf("transfer frame %d with %d bytes does not fit", f.Seq, len(f.Data))
+		}
+		bits = append(bits, false)
+		bits = append(bits, intToBits(uint64(f.ID), 7)...)
+		bits = append(bits, intToBits(uint64(f.Seq), 10)...)
+		data := make([]byte, TransferFrameBytes)
+		copy(data, f.Data)
+		for _, b := range data {
+			bits = append(bits, intToBits(uint64(b), 8)...)
+		}
+		bits = append(bits, intToBits(js8dFrameCheck(bits, 18), 18)...)
+	}
+
+	return packJS8DFrame(bits), nil
+}
+
+// UnpackTransferFrame reverses PackTransferFrame
+func UnpackTransferFrame(frame string) (TransferFrame, error) {
+	bits, err := unpackJS8DFrame(frame, js8dTransfer)
+	if err != nil {
+		return TransferFrame{}, fmt.Errorf("frame %q is not a transfer frame", frame)
+	}
+
+	f := TransferFrame{Control: bits[4], ID: uint8(bitsToInt(bits[5:12]))}
+	if f.Control {
+		if bitsToInt(bits[55:]) != js8dFrameCheck(bits[:55], 17) {
+			return TransferFrame{}, fmt.Errorf("transfer frame %q fails its check", frame)
+		}
+		f.Op = uint8(bitsToInt(bits[12:15]))
+		f.Start = uint16(bitsToInt(bits[15:25]))
+		f.End = uint16(bitsToInt(bits[25:35]))
+		f.FromHash = uint16(bitsToInt(bits[35:45]))
+		f.ToHash = uint16(bitsToInt(bits[45:55]))
+		if f.Op > TransferCancel || f.Start > f.End {
+			return TransferFrame{}, fmt.Errorf("transfer frame %q is not a valid control frame", frame)
+		}
+		return f, nil
+	}
+
+	if bitsToInt(bits[54:]) != js8dFrameCheck(bits[:54], 18) {
+		return TransferFrame{}, fmt.Errorf("transfer frame %q fails its check", frame)
+	}
+	f.Seq = uint16(bitsToInt(bits[12:22]))
+	f.Data = make([]byte, TransferFrameBytes)
+	for i := range f.Data {
+		f.Data[i] = uint8(bitsToInt(bits[22+8*i : 30+8*i]))
+	}
+	return f, nil
+}
+
+// IsTransferFrame reports whether frame is a valid transfer frame
+func IsTransferFrame(frame string) bool {
+	_, err := UnpackTransferFrame(frame)
+	return err == nil
+}
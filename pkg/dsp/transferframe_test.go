@@ -0,0 +1,68 @@
+package dsp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTransferFrames(t *testing.T) {
+	frames := []TransferFrame{
+		{ID: 127, Seq: 1023, Data: []byte{0, 1, 0xfe, 0xff}},
+		{ID: 7, Seq: 12, Data: []byte("ab")},
+		{ID: 7, Control: true, Op: TransferNack, Start: 3, End: 9, FromHash: TransferCallHash("K1ABC"), ToHash: TransferCallHash("W1AW")},
+		{ID: 0, Control: true, Op: TransferCancel},
+	}
+	for _, want := range frames {
+		frame, err := PackTransferFrame(want)
+		if err != nil {
+			t.Fatalf("PackTransferFrame(%+v) failed: %v", want, err)
+		}
+		if len(frame) != 12 || ValidateMessage(frame) != nil {
+			t.Fatalf("Frame %q is not a 12 character JS8 frame", frame)
+		}
+		if IsCompoundFrame(frame) || IsDataFrame(frame) {
+			t.Errorf("Transfer frame %q taken for another kind", frame)
+		}
+		if PreprocessJS8Message(frame) != frame {
+			t.Errorf("Expected preprocessing to leave %q alone", frame)
+		}
+
+		got, err := UnpackTransferFrame(frame)
+		if err != nil {
+			t.Fatalf("UnpackTransferFrame(%q) failed: %v", frame, err)
+		}
+		if !want.Control {
+			want.Data = append(want.Data, make([]byte, TransferFrameBytes-len(want.Data))...)
+		}
+		if got.ID != want.ID || got.Control != want.Control || got.Seq != want.Seq ||
+			!bytes.Equal(got.Data, want.Data) || got.Op != want.Op || got.Start != want.Start || got.End != want.End ||
+			got.FromHash != want.FromHash || got.ToHash != want.ToHash {
+			t.Errorf("Expected %+v back, got %+v", want, got)
+		}
+
+		damaged := []byte(frame)
+		damaged[5] ^= 2
+		if IsTransferFrame(string(damaged)) {
+			t.Errorf("Expected damaged frame %q to be rejected", damaged)
+		}
+	}
+
+	// Form data frames and plain text are not transfer frames
+	dataFrames, _ := PackDataFrames(1, []byte("form"))
+	for _, frame := range []string{dataFrames[0], "W1AW-DE-K1AB", "short"} {
+		if IsTransferFrame(frame) {
+			t.Errorf("Expected %q not to be a transfer frame", frame)
+		}
+	}
+
+	for _, bad := range []TransferFrame{
+		{Seq: MaxTransferFrames},
+		{Data: []byte("too long")},
+		{Control: true, Op: 7},
+		{ID: TransferFrameIDs},
+	} {
+		if _, err := PackTransferFrame(bad); err == nil {
+			t.Errorf("Expected an error packing %+v", bad)
+		}
+	}
+}
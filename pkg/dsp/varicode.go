@@ -2,7 +2,6 @@ package dsp
 
 import (
 	"fmt"
-	"hash/crc32"
 	"regexp"
 	"strings"
 )
@@ -53,7 +52,7 @@ const (
 // Alphabets for encoding
 const (
 	// Base-41 alphabet for FT8 freetext transmission
-	alphabet41 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ+-./?)"
+	alphabet41 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ+-./?"
 	nalphabet  = 41
 
 	// Base-64 alphabet for 72-bit encoding
@@ -65,45 +64,118 @@ const (
 
 // Directed commands mapping
 var directedCmds = map[string]int{
-	" HEARTBEAT":   -1,
-	" HB":          -1,
-	" CQ":          -1,
-	" SNR?":        0,
-	"?":            0,
-	" DIT DIT":     1,
-	" HEARING?":    3,
-	" GRID?":       4,
-	">":            5,
-	" STATUS?":     6,
-	" STATUS":      7,
-	" HEARING":     8,
-	" MSG":         9,
-	" MSG TO:":     10,
-	" QUERY":       11,
-	" QUERY MSGS":  12,
-	" QUERY MSGS?": 12,
-	" QUERY CALL":  13,
-	" GRID":        15,
-	" INFO?":       16,
-	" INFO":        17,
-	" FB":          18,
-	" HW CPY?":     19,
-	" SK":          20,
-	" RR":          21,
-	" QSL?":        22,
-	" QSL":         23,
-	" CMD":         24,
-	" SNR":         25,
-	" NO":          26,
-	" YES":         27,
+	" HEARTBEAT":     -1,
+	" HB":            -1,
+	" CQ":            -1,
+	" SNR?":          0,
+	"?":              0,
+	" DIT DIT":       1,
+	" HEARING?":      3,
+	" GRID?":         4,
+	">":              5,
+	" STATUS?":       6,
+	" STATUS":        7,
+	" HEARING":       8,
+	" MSG":           9,
+	" MSG TO:":       10,
+	" QUERY":         11,
+	" QUERY MSGS":    12,
+	" QUERY MSGS?":   12,
+	" QUERY CALL":    13,
+	" GRID":          15,
+	" INFO?":         16,
+	" INFO":          17,
+	" FB":            18,
+	" HW CPY?":       19,
+	" SK":            20,
+	" RR":            21,
+	" QSL?":          22,
+	" QSL":           23,
+	" CMD":           24,
+	" SNR":           25,
+	" NO":            26,
+	" YES":           27,
+	" 73":            28,
+	" NACK":          2,
+	" ACK":           14,
+	" HEARTBEAT SNR": 29,
+	" AGN?":          30,
 }
 
 // Regular expressions for parsing
 var (
 	gridPattern     = regexp.MustCompile(`[A-X]{2}[0-9]{2}(?:[A-X]{2}(?:[0-9]{2})?)?`)
-	callsignPattern = regexp.MustCompile(`[A-Z0-9]{1,3}[0-9][A-Z]{1,3}(?:/[A-Z0-9]+)?`)
+	callsignPattern = regexp.MustCompile(`(?:[A-Z0-9]{1,4}/)?[A-Z0-9]{1,3}[0-9][A-Z]{1,3}(?:/[A-Z0-9]+)?`)
+
+	// Whole string patterns used to validate a single callsign or grid
+	baseCallsignPattern     = regexp.MustCompile(`^[0-9A-Z]?[0-9A-Z][0-9][A-Z]{0,3}(?:/P)?$`)
+	compoundCallsignPattern = regexp.MustCompile(`^@?[A-Z0-9/@][A-Z0-9/]{0,2}/?[A-Z0-9/]{0,3}/?[A-Z0-9/]{0,3}$`)
+	packCallsignPattern     = regexp.MustCompile(`^[0-9A-Z ][0-9A-Z][0-9][A-Z ][A-Z ][A-Z ]$`)
+	packGridPattern         = regexp.MustCompile(`^[A-R]{2}[0-9]{2}`)
+	mixedAlphaNumeric       = regexp.MustCompile(`[0-9][A-Z]|[A-Z][0-9]`)
 )
 
+// Packed value ranges shared by callsigns and grids
+const (
+	nbasecall = 37 * 36 * 10 * 27 * 27 * 27 // Largest standard packed callsign
+	nbasegrid = 180 * 180                   // Largest packed grid
+	nusergrid = nbasegrid + 10              // Grid values above this carry commands
+	nmaxgrid  = (1 << 15) - 1               // Empty grid
+)
+
+// Group and special callsigns with reserved packed values
+var basecalls = map[string]uint32{
+	"<....>":     nbasecall + 1, // Incomplete callsign
+	"@ALLCALL":   nbasecall + 2,
+	"@JS8NET":    nbasecall + 3,
+	"@DX/NA":     nbasecall + 4,
+	"@DX/SA":     nbasecall + 5,
+	"@DX/EU":     nbasecall + 6,
+	"@DX/AS":     nbasecall + 7,
+	"@DX/AF":     nbasecall + 8,
+	"@DX/OC":     nbasecall + 9,
+	"@DX/AN":     nbasecall + 10,
+	"@REGION/1":  nbasecall + 11,
+	"@REGION/2":  nbasecall + 12,
+	"@REGION/3":  nbasecall + 13,
+	"@GROUP/0":   nbasecall + 14,
+	"@GROUP/1":   nbasecall + 15,
+	"@GROUP/2":   nbasecall + 16,
+	"@GROUP/3":   nbasecall + 17,
+	"@GROUP/4":   nbasecall + 18,
+	"@GROUP/5":   nbasecall + 19,
+	"@GROUP/6":   nbasecall + 20,
+	"@GROUP/7":   nbasecall + 21,
+	"@GROUP/8":   nbasecall + 22,
+	"@GROUP/9":   nbasecall + 23,
+	"@COMMAND":   nbasecall + 24,
+	"@CONTROL":   nbasecall + 25,
+	"@NET":       nbasecall + 26,
+	"@NTS":       nbasecall + 27,
+	"@RESERVE/0": nbasecall + 28,
+	"@RESERVE/1": nbasecall + 29,
+	"@RESERVE/2": nbasecall + 30,
+	"@RESERVE/3": nbasecall + 31,
+	"@RESERVE/4": nbasecall + 32,
+	"@APRSIS":    nbasecall + 33,
+	"@RAGCHEW":   nbasecall + 34,
+	"@JS8":       nbasecall + 35,
+	"@EMCOMM":    nbasecall + 36,
+	"@ARES":      nbasecall + 37,
+	"@MARS":      nbasecall + 38,
+	"@AMRRON":    nbasecall + 39,
+	"@RACES":     nbasecall + 40,
+	"@RAYNET":    nbasecall + 41,
+	"@RADAR":     nbasecall + 42,
+	"@SKYWARN":   nbasecall + 43,
+	"@CQ":        nbasecall + 44,
+	"@HB":        nbasecall + 45,
+	"@QSO":       nbasecall + 46,
+	"@QSOPARTY":  nbasecall + 47,
+	"@CONTEST":   nbasecall + 48,
+	"@FIELDDAY":  nbasecall + 49,
+}
+
 // Bit manipulation utilities
 func intToBits(value uint64, bitCount int) []bool {
 	bits := make([]bool, bitCount)
@@ -284,11 +356,10 @@ func Pack72bits(value uint64, rem uint8) string {
 
 // Checksum functions
 
-// Checksum16 computes a 16-bit CRC checksum and packs it into 3 characters
+// Checksum16 computes the CRC-16/KERMIT of input packed into 3 characters,
+// as JS8Call checksums directed commands
 func Checksum16(input string) string {
-	data := []byte(input)
-	crc := crc32.ChecksumIEEE(data) & 0xFFFF // Use only lower 16 bits
-	checksum := Pack16bits(uint16(crc))
+	checksum := Pack16bits(crc16Kermit([]byte(input)))
 
 	// Pad to 3 characters if needed
 	for len(checksum) < 3 {
@@ -302,11 +373,10 @@ func Checksum16Valid(checksum, input string) bool {
 	return Checksum16(input) == checksum
 }
 
-// Checksum32 computes a 32-bit CRC checksum and packs it into 6 characters
+// Checksum32 computes the CRC-32/BZIP2 of input packed into 6 characters,
+// as JS8Call checksums directed messages
 func Checksum32(input string) string {
-	data := []byte(input)
-	crc := crc32.ChecksumIEEE(data)
-	checksum := Pack32bits(crc)
+	checksum := Pack32bits(crc32Bzip2([]byte(input)))
 
 	// Pad to 6 characters if needed
 	for len(checksum) < 6 {
@@ -320,6 +390,40 @@ func Checksum32Valid(checksum, input string) bool {
 	return Checksum32(input) == checksum
 }
 
+// crc16Kermit is the reflected CCITT CRC with a zero start, which JS8Call
+// calls CRC_16_KERMIT
+func crc16Kermit(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 == 1 {
+				crc = crc>>1 ^ 0x8408
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+// crc32Bzip2 is the unreflected form of the IEEE CRC-32, which JS8Call
+// calls CRC_32_BZIP2
+func crc32Bzip2(data []byte) uint32 {
+	crc := uint32(0xffffffff)
+	for _, b := range data {
+		crc ^= uint32(b) << 24
+		for i := 0; i < 8; i++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return ^crc
+}
+
 // String utility functions
 
 // Rstrip removes trailing whitespace
@@ -373,6 +477,23 @@ func IsSNRCommand(cmd string) bool {
 	return cmd == " SNR?" || cmd == " SNR"
 }
 
+// IsAcknowledgement checks if message text acknowledges a directed message
+// with ACK, RR/RRR or HW CPY. "HW CPY?" asks for a report instead.
+func IsAcknowledgement(text string) bool {
+	words := strings.Fields(strings.ToUpper(text))
+	for i, word := range words {
+		switch word {
+		case "ACK", "RR", "RRR":
+			return true
+		case "HW":
+			if i+1 < len(words) && words[i+1] == "CPY" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // IsCommandAllowed checks if a command is allowed
 func IsCommandAllowed(cmd string) bool {
 	_, exists := directedCmds[cmd]
@@ -527,14 +648,15 @@ func Grid2Deg(grid string) (float32, float32) {
 	return dlong, dlat
 }
 
-// PackGrid packs a grid square into 16 bits using JS8 format
+// PackGrid packs a 4 character grid square into 15 bits using JS8 format.
+// Anything that is not a valid grid packs to the empty grid value.
 func PackGrid(grid string) uint16 {
-	if len(grid) < 4 {
-		return (1 << 15) - 1 // Return max value for invalid input
+	grid = strings.ToUpper(strings.TrimSpace(grid))
+	if !packGridPattern.MatchString(grid) {
+		return nmaxgrid
 	}
 
-	grid = strings.TrimSpace(strings.ToUpper(grid[:4]))
-	dlong, dlat := Grid2Deg(grid)
+	dlong, dlat := Grid2Deg(grid[:4])
 
 	ilong := int(dlong)
 	ilat := int(dlat + 90)
@@ -544,8 +666,6 @@ func PackGrid(grid string) uint16 {
 
 // UnpackGrid unpacks a 16-bit value into a grid square using JS8 format
 func UnpackGrid(packed uint16) string {
-	const nbasegrid = 32400 // Max valid grid value in JS8
-
 	if packed > nbasegrid {
 		return ""
 	}
@@ -555,3 +675,364 @@ func UnpackGrid(packed uint16) string {
 
 	return Deg2Grid(dlong, dlat)
 }
+
+// IsValidCallsign reports whether callsign can be sent in a JS8 frame, either
+// as a standard callsign, a group, or a compound callsign such as VE3/K3DEP
+func IsValidCallsign(callsign string) bool {
+	if _, ok := basecalls[callsign]; ok {
+		return true
+	}
+	if baseCallsignPattern.MatchString(callsign) {
+		return len(callsign) > 2 && mixedAlphaNumeric.MatchString(callsign)
+	}
+	return IsCompoundCallsign(callsign)
+}
+
+// IsCompoundCallsign reports whether callsign needs a compound frame because
+// it has a prefix or suffix, or does not fit the standard 28 bit packing
+func IsCompoundCallsign(callsign string) bool {
+	if _, ok := basecalls[callsign]; ok && !strings.HasPrefix(callsign, "@") {
+		return false
+	}
+	if baseCallsignPattern.MatchString(callsign) || !compoundCallsignPattern.MatchString(callsign) {
+		return false
+	}
+
+	// At most 9 characters once the slashes are removed
+	if len(callsign)-strings.Count(callsign, "/") > 9 {
+		return false
+	}
+	if index := strings.Index(callsign, "/"); index != -1 {
+		_, group := basecalls[callsign[:index]]
+		return !group
+	}
+	if strings.HasPrefix(callsign, "@") {
+		return true
+	}
+	return len(callsign) > 2 && mixedAlphaNumeric.MatchString(callsign)
+}
+
+// PackCallsign packs a standard callsign into 28 bits, returning whether a
+// /P suffix was stripped. Callsigns that need a compound frame pack to 0.
+func PackCallsign(value string) (uint32, bool) {
+	callsign := strings.ToUpper(strings.TrimSpace(value))
+	if packed, ok := basecalls[callsign]; ok {
+		return packed, false
+	}
+
+	portable := false
+	if strings.HasSuffix(callsign, "/P") {
+		callsign = strings.TrimSuffix(callsign, "/P")
+		portable = true
+	}
+
+	// Workarounds for Swaziland and Guinea
+	if strings.HasPrefix(callsign, "3DA0") {
+		callsign = "3D0" + callsign[4:]
+	}
+	if strings.HasPrefix(callsign, "3X") && len(callsign) > 2 && callsign[2] >= 'A' && callsign[2] <= 'Z' {
+		callsign = "Q" + callsign[2:]
+	}
+
+	if len(callsign) < 2 || len(callsign) > 6 {
+		return 0, portable
+	}
+
+	// Align the call area digit to the third position
+	permutations := []string{callsign}
+	switch len(callsign) {
+	case 2:
+		permutations = append(permutations, " "+callsign+"   ")
+	case 3:
+		permutations = append(permutations, " "+callsign+"  ", callsign+"   ")
+	case 4:
+		permutations = append(permutations, " "+callsign+" ", callsign+"  ")
+	case 5:
+		permutations = append(permutations, " "+callsign, callsign+" ")
+	}
+
+	matched := ""
+	for _, permutation := range permutations {
+		if packCallsignPattern.MatchString(permutation) {
+			matched = permutation
+		}
+	}
+	if matched == "" {
+		return 0, portable
+	}
+
+	packed := uint32(strings.IndexByte(alphanumeric, matched[0]))
+	packed = 36*packed + uint32(strings.IndexByte(alphanumeric, matched[1]))
+	packed = 10*packed + uint32(strings.IndexByte(alphanumeric, matched[2]))
+	packed = 27*packed + uint32(strings.IndexByte(alphanumeric, matched[3])) - 10
+	packed = 27*packed + uint32(strings.IndexByte(alphanumeric, matched[4])) - 10
+	packed = 27*packed + uint32(strings.IndexByte(alphanumeric, matched[5])) - 10
+
+	return packed, portable
+}
+
+// UnpackCallsign unpacks a 28 bit callsign, adding /P when portable is set
+func UnpackCallsign(value uint32, portable bool) string {
+	for callsign, packed := range basecalls {
+		if packed == value {
+			return callsign
+		}
+	}
+
+	word := make([]byte, 6)
+	word[5] = alphanumeric[value%27+10]
+	value /= 27
+	word[4] = alphanumeric[value%27+10]
+	value /= 27
+	word[3] = alphanumeric[value%27+10]
+	value /= 27
+	word[2] = alphanumeric[value%10]
+	value /= 10
+	word[1] = alphanumeric[value%36]
+	value /= 36
+	word[0] = alphanumeric[value%uint32(len(alphanumeric))]
+
+	callsign := string(word)
+	if strings.HasPrefix(callsign, "3D0") {
+		callsign = "3DA0" + callsign[3:]
+	}
+	if callsign[0] == 'Q' && callsign[1] >= 'A' && callsign[1] <= 'Z' {
+		callsign = "3X" + callsign[1:]
+	}
+
+	callsign = strings.TrimSpace(callsign)
+	if portable {
+		callsign += "/P"
+	}
+	return callsign
+}
+
+// PackAlphaNumeric50 packs up to 11 characters of callsign alphabet into 50
+// bits. Slashes are only allowed in the 4th and 8th positions:
+//
+//	[K][N][4][ ][C][R][D][/][Q][R][P]
+//	[V][E][3][/][K][3][D][ ][E][P][ ]
+//	[@][R][A][ ][C][E][S][ ][ ][ ][ ]
+func PackAlphaNumeric50(value string) uint64 {
+	var word []byte
+	for _, c := range []byte(value) {
+		if strings.IndexByte(alphanumeric, c) != -1 {
+			word = append(word, c)
+		}
+	}
+	if len(word) > 3 && word[3] != '/' {
+		word = append(word[:3], append([]byte{' '}, word[3:]...)...)
+	}
+	if len(word) > 7 && word[7] != '/' {
+		word = append(word[:7], append([]byte{' '}, word[7:]...)...)
+	}
+	for len(word) < 11 {
+		word = append(word, ' ')
+	}
+
+	index := func(i int) uint64 {
+		return uint64(strings.IndexByte(alphanumeric, word[i]))
+	}
+	slash := func(i int) uint64 {
+		if word[i] == '/' {
+			return 1
+		}
+		return 0
+	}
+
+	// Mixed radix [39][38][38][2][38][38][38][2][38][38][38]
+	packed := index(0)
+	packed = 38*packed + index(1)
+	packed = 38*packed + index(2)
+	packed = 2*packed + slash(3)
+	packed = 38*packed + index(4)
+	packed = 38*packed + index(5)
+	packed = 38*packed + index(6)
+	packed = 2*packed + slash(7)
+	packed = 38*packed + index(8)
+	packed = 38*packed + index(9)
+	packed = 38*packed + index(10)
+	return packed
+}
+
+// UnpackAlphaNumeric50 reverses PackAlphaNumeric50, dropping padding spaces
+func UnpackAlphaNumeric50(packed uint64) string {
+	word := make([]byte, 11)
+	for i := 10; i >= 0; i-- {
+		switch i {
+		case 3, 7:
+			word[i] = ' '
+			if packed%2 == 1 {
+				word[i] = '/'
+			}
+			packed /= 2
+		case 0:
+			word[i] = alphanumeric[packed%39]
+		default:
+			word[i] = alphanumeric[packed%38]
+			packed /= 38
+		}
+	}
+	return strings.ReplaceAll(string(word), " ", "")
+}
+
+// PackCompoundFrame packs a compound or group callsign with 16 bits of extra
+// data, usually a packed grid, and 3 spare bits into a 12 character frame.
+// The layout is [3 type][50 callsign][11 extra high],[5 extra low][3 bits3].
+func PackCompoundFrame(callsign string, frameType FrameType, extra uint16, bits3 uint8) (string, error) {
+	if frameType != FrameHeartbeat && frameType != FrameCompound && frameType != FrameCompoundDirected {
+		return "", fmt.Errorf("%s is not a compound frame type", frameType)
+	}
+
+	packedCallsign := PackAlphaNumeric50(strings.ToUpper(strings.TrimSpace(callsign)))
+	if packedCallsign == 0 {
+		return "", fmt.Errorf("callsign %q cannot be packed", callsign)
+	}
+
+	bits := append(intToBits(uint64(frameType), 3), intToBits(packedCallsign, 50)...)
+	bits = append(bits, intToBits(uint64(extra>>5), 11)...)
+	rem := uint8(extra&0x1f)<<3 | bits3&0x7
+
+	return Pack72bits(bitsToInt(bits), rem), nil
+}
+
+// UnpackCompoundFrame reverses PackCompoundFrame
+func UnpackCompoundFrame(frame string) (callsign string, frameType FrameType, extra uint16, bits3 uint8, err error) {
+	if len(frame) < 12 || strings.Contains(frame, " ") {
+		return "", FrameUnknown, 0, 0, fmt.Errorf("frame %q is not a packed frame", frame)
+	}
+
+	var rem uint8
+	bits := intToBits(Unpack72bits(frame, &rem), 64)
+
+	frameType = FrameType(bitsToInt(bits[0:3]))
+	if frameType != FrameHeartbeat && frameType != FrameCompound && frameType != FrameCompoundDirected {
+		return "", FrameUnknown, 0, 0, fmt.Errorf("frame type %s is not a compound frame", frameType)
+	}
+
+	callsign = UnpackAlphaNumeric50(bitsToInt(bits[3:53]))
+	extra = uint16(bitsToInt(bits[53:64]))<<5 | uint16(rem>>3)
+	return callsign, frameType, extra, rem & 0x7, nil
+}
+
+// IsCompoundFrame reports whether frame is a packed compound frame carrying a
+// valid callsign and either a grid, a command or no extra data
+func IsCompoundFrame(frame string) bool {
+	if len(frame) != 12 || ValidateMessage(frame) != nil {
+		return false
+	}
+	callsign, frameType, extra, _, err := UnpackCompoundFrame(frame)
+	if err != nil || frameType == FrameHeartbeat || !IsValidCallsign(callsign) {
+		return false
+	}
+	return extra <= nbasegrid || extra >= nusergrid
+}
+
+// PackCompoundGrid packs a station identification frame carrying a compound
+// callsign and its grid, as sent by portable and DX-prefixed operators
+func PackCompoundGrid(callsign, grid string) (string, error) {
+	return PackCompoundFrame(callsign, FrameCompound, PackGrid(grid), 0)
+}
+
+// UnpackCompoundGrid returns the callsign and grid from a compound frame
+func UnpackCompoundGrid(frame string) (string, string, error) {
+	callsign, frameType, extra, _, err := UnpackCompoundFrame(frame)
+	if err != nil {
+		return "", "", err
+	}
+	if frameType != FrameCompound {
+		return "", "", fmt.Errorf("frame type %s does not carry a grid", frameType)
+	}
+	return callsign, UnpackGrid(extra), nil
+}
+
+// PackDirectedFrame packs a directed command between two standard
+// callsigns, with an optional number such as an SNR report, into a 12
+// character frame. A compound callsign on either side is sent as <....>,
+// its full form following in a compound frame.
+// The layout is [3 type][28 from][28 to][5 command],[1 from /P][1 to /P][6 number].
+func PackDirectedFrame(from, to, cmd, num string) (string, error) {
+	code, ok := directedCmds[cmd]
+	if !ok || code < 0 {
+		return "", fmt.Errorf("%q is not a directed command", cmd)
+	}
+
+	packedFrom, portableFrom := packDirectedCallsign(from)
+	packedTo, portableTo := packDirectedCallsign(to)
+	if packedFrom == 0 || packedTo == 0 {
+		return "", fmt.Errorf("%s to %s cannot be packed", from, to)
+	}
+
+	var packedNum uint8
+	if num = strings.TrimSpace(num); num != "" {
+		var n int
+		if _, err := fmt.Sscanf(num, "%d", &n); err != nil {
+			return "", fmt.Errorf("invalid number %q", num)
+		}
+		packedNum = uint8(max(-30, min(n, 31)) + 31)
+	}
+
+	bits := append(intToBits(uint64(FrameDirected), 3), intToBits(uint64(packedFrom), 28)...)
+	bits = append(bits, intToBits(uint64(packedTo), 28)...)
+	bits = append(bits, intToBits(uint64(code%32), 5)...)
+
+	rem := packedNum
+	if portableFrom {
+		rem |= 1 << 7
+	}
+	if portableTo {
+		rem |= 1 << 6
+	}
+	return Pack72bits(bitsToInt(bits), rem), nil
+}
+
+// UnpackDirectedFrame reverses PackDirectedFrame. num is empty when the
+// frame carries no number and is formatted as an SNR for SNR reports.
+func UnpackDirectedFrame(frame string) (from, to, cmd, num string, err error) {
+	if len(frame) < 12 || strings.Contains(frame, " ") {
+		return "", "", "", "", fmt.Errorf("frame %q is not a packed frame", frame)
+	}
+
+	var rem uint8
+	bits := intToBits(Unpack72bits(frame, &rem), 64)
+	if FrameType(bitsToInt(bits[0:3])) != FrameDirected {
+		return "", "", "", "", fmt.Errorf("frame %q is not a directed frame", frame)
+	}
+
+	from = UnpackCallsign(uint32(bitsToInt(bits[3:31])), rem&(1<<7) != 0)
+	to = UnpackCallsign(uint32(bitsToInt(bits[31:59])), rem&(1<<6) != 0)
+	code := int(bitsToInt(bits[59:64]))
+	cmd = directedCmdName(code)
+
+	if n := int(rem % 64); n != 0 {
+		if code == directedCmds[" SNR"] || code == directedCmds[" HEARTBEAT SNR"] {
+			num = FormatSNR(n - 31)
+		} else {
+			num = fmt.Sprintf("%d", n-31)
+		}
+	}
+	return from, to, cmd, num, nil
+}
+
+// packDirectedCallsign packs a callsign for a directed frame, standing in
+// <....> for a compound callsign. Groups have codes of their own.
+func packDirectedCallsign(callsign string) (uint32, bool) {
+	callsign = strings.ToUpper(strings.TrimSpace(callsign))
+	if _, group := basecalls[callsign]; !group && IsCompoundCallsign(callsign) {
+		callsign = "<....>"
+	}
+	return PackCallsign(callsign)
+}
+
+// directedCmdName returns the command a directed frame's code stands for.
+// Where several commands share a code, JS8Call shows the one first in
+// sort order, so this does too.
+func directedCmdName(code int) string {
+	name := ""
+	for cmd, c := range directedCmds {
+		if c == code && (name == "" || cmd < name) {
+			name = cmd
+		}
+	}
+	return name
+}
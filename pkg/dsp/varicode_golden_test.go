@@ -0,0 +1,242 @@
+package dsp
+
+import "testing"
+
+// Golden vectors for the JS8 frame packing, worked out with the algorithms
+// in libjs8dsp/varicode.cpp rather than with this package, so a change that
+// stops js8d's frames matching JS8Call's fails here. Data frame vectors are
+// in datamessage_test.go.
+
+func TestGoldenChecksums(t *testing.T) {
+	// The standard check values of the two CRCs
+	if got := crc16Kermit([]byte("123456789")); got != 0x2189 {
+		t.Errorf("CRC-16/KERMIT check value = %#04x, want 0x2189", got)
+	}
+	if got := crc32Bzip2([]byte("123456789")); got != 0xfc891918 {
+		t.Errorf("CRC-32/BZIP2 check value = %#08x, want 0xfc891918", got)
+	}
+
+	tests := []struct {
+		input string
+		sum16 string
+		sum32 string
+	}{
+		{"", "000", "000000"},
+		{"HELLO", "387", "HM17Q+"},
+		{"123456789", "54G", ".IX3XS"},
+		{"K3DEP: W1AW SNR?", "-J-", "X9OHHL"},
+		{"CQ N0CALL EM12", "YAM", "VNPXAD"},
+	}
+	for _, tt := range tests {
+		if got := Checksum16(tt.input); got != tt.sum16 {
+			t.Errorf("Checksum16(%q) = %q, want %q", tt.input, got, tt.sum16)
+		}
+		if got := Checksum32(tt.input); got != tt.sum32 {
+			t.Errorf("Checksum32(%q) = %q, want %q", tt.input, got, tt.sum32)
+		}
+	}
+}
+
+func TestGoldenCallsigns(t *testing.T) {
+	tests := []struct {
+		callsign string
+		packed   uint32
+		portable bool
+	}{
+		{"K3DEP", 259089639, false},
+		{"W1AW", 261410543, false},
+		{"2E0ABC", 16927409, false},
+		{"K3DEP/P", 259089639, true},
+		{"3DA0XYZ", 23833870, false},
+		{"3XY1A", 190945511, false},
+		{"@ALLCALL", 262177562, false},
+		{"<....>", 262177561, false},
+		{"N0CALL", 0, false},
+	}
+	for _, tt := range tests {
+		packed, portable := PackCallsign(tt.callsign)
+		if packed != tt.packed || portable != tt.portable {
+			t.Errorf("PackCallsign(%q) = %d, %v, want %d, %v", tt.callsign, packed, portable, tt.packed, tt.portable)
+			continue
+		}
+		if packed == 0 {
+			continue
+		}
+		if got := UnpackCallsign(packed, portable); got != tt.callsign {
+			t.Errorf("UnpackCallsign(%d, %v) = %q, want %q", packed, portable, got, tt.callsign)
+		}
+	}
+
+	alphaNumeric := []struct {
+		callsign string
+		packed   uint64
+	}{
+		{"VE3/K3DEP", 545578834273442},
+		{"KN4CRD/QRP", 358399795421803},
+		{"K3DEP/MM", 349355253697424},
+		{"@RACES", 673343688580748},
+		{"N0CALL", 400143076325148},
+	}
+	for _, tt := range alphaNumeric {
+		if got := PackAlphaNumeric50(tt.callsign); got != tt.packed {
+			t.Errorf("PackAlphaNumeric50(%q) = %d, want %d", tt.callsign, got, tt.packed)
+		}
+		if got := UnpackAlphaNumeric50(tt.packed); got != tt.callsign {
+			t.Errorf("UnpackAlphaNumeric50(%d) = %q, want %q", tt.packed, got, tt.callsign)
+		}
+	}
+}
+
+func TestGoldenGrids(t *testing.T) {
+	tests := []struct {
+		grid   string
+		packed uint16
+	}{
+		{"FN20", 22990},
+		{"EM12", 24962},
+		{"AA00", 32220},
+		{"RR99", 179},
+		{"JO65", 15085},
+		{"QF56", 2576},
+		{"PM95", 3725},
+		{"GG66", 20406},
+	}
+	for _, tt := range tests {
+		if got := PackGrid(tt.grid); got != tt.packed {
+			t.Errorf("PackGrid(%q) = %d, want %d", tt.grid, got, tt.packed)
+		}
+		if got := UnpackGrid(tt.packed); got != tt.grid {
+			t.Errorf("UnpackGrid(%d) = %q, want %q", tt.packed, got, tt.grid)
+		}
+	}
+}
+
+func TestGoldenCompoundFrames(t *testing.T) {
+	tests := []struct {
+		callsign string
+		grid     string
+		frame    string
+	}{
+		{"VE3/K3DEP", "FN20", "Bu6RnEab4ivm"},
+		{"K3DEP/MM", "EM12", "AUtatc1SWmmG"},
+		{"KN4CRD/QRP", "", "AY-pe+CJM++u"},
+	}
+	for _, tt := range tests {
+		frame, err := PackCompoundGrid(tt.callsign, tt.grid)
+		if err != nil || frame != tt.frame {
+			t.Errorf("PackCompoundGrid(%q, %q) = %q, %v, want %q", tt.callsign, tt.grid, frame, err, tt.frame)
+		}
+		callsign, grid, err := UnpackCompoundGrid(tt.frame)
+		if err != nil || callsign != tt.callsign || grid != tt.grid {
+			t.Errorf("UnpackCompoundGrid(%q) = %q, %q, %v", tt.frame, callsign, grid, err)
+		}
+	}
+}
+
+func TestGoldenHeartbeatFrames(t *testing.T) {
+	// A heartbeat is a compound frame whose extra bits carry the grid, with
+	// the top bit set for a CQ, and whose last 3 bits number the CQ
+	tests := []struct {
+		callsign string
+		grid     string
+		cq       bool
+		number   uint8
+		frame    string
+	}{
+		{"K3DEP", "FN20", false, 0, "2UtatbL+Oivm"},
+		{"K3DEP", "FN20", true, 7, "2UtatbL+Pivt"},
+		{"N0CALL", "", false, 0, "2rziZsEuu++u"},
+		{"VE3/K3DEP", "EM12", true, 1, "3u6RnEab5mmH"},
+	}
+	for _, tt := range tests {
+		extra := PackGrid(tt.grid)
+		if tt.cq {
+			extra |= 1 << 15
+		}
+		frame, err := PackCompoundFrame(tt.callsign, FrameHeartbeat, extra, tt.number)
+		if err != nil || frame != tt.frame {
+			t.Errorf("heartbeat from %s = %q, %v, want %q", tt.callsign, frame, err, tt.frame)
+			continue
+		}
+
+		callsign, frameType, extra, number, err := UnpackCompoundFrame(tt.frame)
+		if err != nil || callsign != tt.callsign || frameType != FrameHeartbeat || number != tt.number {
+			t.Errorf("UnpackCompoundFrame(%q) = %q, %s, %d, %v", tt.frame, callsign, frameType, number, err)
+		}
+		if cq := extra&(1<<15) != 0; cq != tt.cq {
+			t.Errorf("heartbeat %q CQ = %v, want %v", tt.frame, cq, tt.cq)
+		}
+		if grid := UnpackGrid(extra &^ (1 << 15)); grid != tt.grid {
+			t.Errorf("heartbeat %q grid = %q, want %q", tt.frame, grid, tt.grid)
+		}
+	}
+}
+
+func TestGoldenDirectedFrames(t *testing.T) {
+	tests := []struct {
+		from  string
+		to    string
+		cmd   string
+		num   string
+		frame string
+	}{
+		{"K3DEP", "W1AW", " SNR?", "", "VkB9p+APtU00"},
+		{"K3DEP", "W1AW", " SNR", "-12", "VkB9p+APtVaJ"},
+		{"W1AW", "K3DEP", " SNR", "+05", "VocTt-uidFaa"},
+		{"K3DEP/P", "W1AW", " HEARING?", "", "VkB9p+APtUE0"},
+		{"K3DEP", "@ALLCALL", " STATUS?", "", "VkB9p+GGOqO0"},
+		{"K3DEP", "W1AW/P", " ACK", "", "VkB9p+APtUv0"},
+		{"K3DEP", "W1AW", " HEARTBEAT SNR", "-20", "VkB9p+APtVqB"},
+		{"<....>", "W1AW", " GRID?", "", "Vq46C+APtUG0"},
+	}
+	for _, tt := range tests {
+		frame, err := PackDirectedFrame(tt.from, tt.to, tt.cmd, tt.num)
+		if err != nil || frame != tt.frame {
+			t.Errorf("PackDirectedFrame(%q, %q, %q, %q) = %q, %v, want %q", tt.from, tt.to, tt.cmd, tt.num, frame, err, tt.frame)
+		}
+		from, to, cmd, num, err := UnpackDirectedFrame(tt.frame)
+		if err != nil || from != tt.from || to != tt.to || cmd != tt.cmd || num != tt.num {
+			t.Errorf("UnpackDirectedFrame(%q) = %q, %q, %q, %q, %v", tt.frame, from, to, cmd, num, err)
+		}
+	}
+
+	// A compound callsign goes out as <....>
+	frame, err := PackDirectedFrame("VE3/K3DEP", "W1AW", " GRID?", "")
+	if err != nil || frame != "Vq46C+APtUG0" {
+		t.Errorf("directed frame from VE3/K3DEP = %q, %v", frame, err)
+	}
+
+	if _, err := PackDirectedFrame("K3DEP", "W1AW", " BOGUS", ""); err == nil {
+		t.Error("Expected error packing an unknown command")
+	}
+	if _, err := PackDirectedFrame("K3DEP", "W1AW", " HB", ""); err == nil {
+		t.Error("Expected error packing a heartbeat as a directed command")
+	}
+	if _, _, _, _, err := UnpackDirectedFrame("Bu6RnEab4ivm"); err == nil {
+		t.Error("Expected error unpacking a compound frame as directed")
+	}
+}
+
+func TestGoldenDirectedCommands(t *testing.T) {
+	// JS8Call's command codes, which go out in directed frames
+	codes := map[string]int{
+		" SNR?": 0, "?": 0, " DIT DIT": 1, " NACK": 2, " HEARING?": 3,
+		" GRID?": 4, ">": 5, " STATUS?": 6, " STATUS": 7, " HEARING": 8,
+		" MSG": 9, " MSG TO:": 10, " QUERY": 11, " QUERY MSGS": 12,
+		" QUERY MSGS?": 12, " QUERY CALL": 13, " ACK": 14, " GRID": 15,
+		" INFO?": 16, " INFO": 17, " FB": 18, " HW CPY?": 19, " SK": 20,
+		" RR": 21, " QSL?": 22, " QSL": 23, " CMD": 24, " SNR": 25,
+		" NO": 26, " YES": 27, " 73": 28, " HEARTBEAT SNR": 29, " AGN?": 30,
+	}
+	for cmd, code := range codes {
+		if got, ok := directedCmds[cmd]; !ok || got != code {
+			t.Errorf("command %q has code %d (%v), want %d", cmd, got, ok, code)
+		}
+	}
+	if got := directedCmdName(0); got != " SNR?" {
+		t.Errorf("code 0 names %q, want \" SNR?\"", got)
+	}
+	if got := directedCmdName(12); got != " QUERY MSGS" {
+		t.Errorf("code 12 names %q, want \" QUERY MSGS\"", got)
+	}
+}
@@ -405,3 +405,151 @@ func abs(x float32) float32 {
 	}
 	return x
 }
+
+func TestIsValidCallsign(t *testing.T) {
+	tests := []struct {
+		callsign string
+		valid    bool
+		compound bool
+	}{
+		{"K3DEP", true, false},
+		{"K3DEP/P", true, false},
+		{"W1AW", true, false},
+		{"VE3/K3DEP", true, true},
+		{"K3DEP/MM", true, true},
+		{"N0CALL", true, true},
+		{"@ALLCALL", true, true},
+		{"@RACES", true, true},
+		{"@MYGROUP", true, true},
+		{"HELLO", false, false},
+		{"K3", false, false},
+		{"VE3/K3DEP/QRPXX", false, false},
+		{"K3DEP!", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.callsign, func(t *testing.T) {
+			if got := IsValidCallsign(tt.callsign); got != tt.valid {
+				t.Errorf("IsValidCallsign(%q) = %v, want %v", tt.callsign, got, tt.valid)
+			}
+			if got := IsCompoundCallsign(tt.callsign); got != tt.compound {
+				t.Errorf("IsCompoundCallsign(%q) = %v, want %v", tt.callsign, got, tt.compound)
+			}
+		})
+	}
+}
+
+func TestPackUnpackCallsign(t *testing.T) {
+	tests := []struct {
+		callsign string
+		portable bool
+	}{
+		{"K3DEP", false},
+		{"W1AW", false},
+		{"2E0ABC", false},
+		{"K3DEP/P", true},
+		{"3DA0XYZ", false},
+		{"@ALLCALL", false},
+	}
+
+	for _, tt := range tests {
+		packed, portable := PackCallsign(tt.callsign)
+		if packed == 0 || portable != tt.portable {
+			t.Errorf("PackCallsign(%q) = %d, %v", tt.callsign, packed, portable)
+			continue
+		}
+		if unpacked := UnpackCallsign(packed, portable); unpacked != tt.callsign {
+			t.Errorf("Callsign round trip for %q gave %q", tt.callsign, unpacked)
+		}
+	}
+
+	// Compound callsigns do not fit 28 bits
+	if packed, _ := PackCallsign("VE3/K3DEP"); packed != 0 {
+		t.Errorf("Expected VE3/K3DEP to need a compound frame, got %d", packed)
+	}
+}
+
+func TestPackUnpackAlphaNumeric50(t *testing.T) {
+	for _, value := range []string{"K3DEP", "VE3/K3DEP", "KN4CRD/QRP", "K3DEP/P", "@RACES", "N0CALL"} {
+		packed := PackAlphaNumeric50(value)
+		if packed >= 1<<50 {
+			t.Errorf("%q packed to more than 50 bits", value)
+		}
+		if unpacked := UnpackAlphaNumeric50(packed); unpacked != value {
+			t.Errorf("AlphaNumeric50 round trip for %q gave %q", value, unpacked)
+		}
+	}
+}
+
+func TestPackUnpackCompoundFrame(t *testing.T) {
+	tests := []struct {
+		callsign string
+		grid     string
+	}{
+		{"VE3/K3DEP", "FN20"},
+		{"K3DEP/MM", "EM12"},
+		{"KN4CRD/QRP", ""},
+	}
+
+	for _, tt := range tests {
+		frame, err := PackCompoundGrid(tt.callsign, tt.grid)
+		if err != nil {
+			t.Fatalf("PackCompoundGrid(%q) failed: %v", tt.callsign, err)
+		}
+		if len(frame) != 12 || ValidateMessage(frame) != nil {
+			t.Fatalf("Frame %q is not a 12 character JS8 frame", frame)
+		}
+		if !IsCompoundFrame(frame) {
+			t.Errorf("Expected %q to be recognized as a compound frame", frame)
+		}
+		if PreprocessJS8Message(frame) != frame {
+			t.Errorf("Expected preprocessing to leave %q alone", frame)
+		}
+
+		callsign, grid, err := UnpackCompoundGrid(frame)
+		if err != nil {
+			t.Fatalf("UnpackCompoundGrid(%q) failed: %v", frame, err)
+		}
+		if callsign != tt.callsign || grid != tt.grid {
+			t.Errorf("Expected %s %s, got %s %s", tt.callsign, tt.grid, callsign, grid)
+		}
+	}
+
+	if _, err := PackCompoundFrame("VE3/K3DEP", FrameDirected, 0, 0); err == nil {
+		t.Error("Expected error packing a directed frame as compound")
+	}
+	if _, _, _, _, err := UnpackCompoundFrame("short"); err == nil {
+		t.Error("Expected error unpacking a short frame")
+	}
+}
+
+func TestPackGridNormalizes(t *testing.T) {
+	want := PackGrid("FN20")
+	for _, grid := range []string{"fn20", " FN20", "FN20xa", "fn20XA "} {
+		if got := PackGrid(grid); got != want {
+			t.Errorf("PackGrid(%q) = %d, want %d", grid, got, want)
+		}
+	}
+	for _, grid := range []string{"", "ZZ99", "FN2", "12AB"} {
+		if got := PackGrid(grid); got != nmaxgrid {
+			t.Errorf("PackGrid(%q) = %d, want empty grid %d", grid, got, nmaxgrid)
+		}
+	}
+}
+
+func TestIsAcknowledgement(t *testing.T) {
+	tests := map[string]bool{
+		"K3DEP ACK":         true,
+		"K3DEP rr 73":       true,
+		"K3DEP RRR":         true,
+		"K3DEP HW CPY":      true,
+		"K3DEP HW CPY?":     false,
+		"K3DEP SNR -12":     false,
+		"K3DEP CARRYING ON": false,
+	}
+	for text, want := range tests {
+		if got := IsAcknowledgement(text); got != want {
+			t.Errorf("IsAcknowledgement(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
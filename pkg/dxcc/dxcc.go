@@ -0,0 +1,318 @@
+// Package dxcc resolves callsigns to their DXCC entity, continent and CQ
+// and ITU zones from a cty.dat country file, as published by AD1C at
+// country-files.com
+package dxcc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultURL is the current cty.dat, updated for each new DXpedition
+const DefaultURL = "https://www.country-files.com/cty/cty.dat"
+
+// maxFileSize bounds a downloaded country file; cty.dat is about 100 kB
+const maxFileSize = 4 << 20
+
+// Entity is a DXCC entity, or the part of one a prefix or callsign is in
+// when it overrides the entity's zones or continent
+type Entity struct {
+	Name      string  `json:"name"`
+	Prefix    string  `json:"prefix"`    // Primary prefix, e.g. DL
+	Continent string  `json:"continent"` // AF, AN, AS, EU, NA, OC or SA
+	CQZone    int     `json:"cq_zone"`
+	ITUZone   int     `json:"itu_zone"`
+	Latitude  float64 `json:"latitude"`   // Degrees north
+	Longitude float64 `json:"longitude"`  // Degrees east
+	UTCOffset float64 `json:"utc_offset"` // Hours ahead of UTC
+}
+
+// Database maps callsigns to entities
+type Database struct {
+	entities []*Entity
+	prefixes map[string]*Entity
+	calls    map[string]*Entity // Exact callsigns, which win over prefixes
+	longest  int                // Longest prefix, in characters
+}
+
+// aliasPattern splits a prefix or =callsign from its overrides
+var aliasPattern = regexp.MustCompile(`^(=?)([A-Z0-9/]+)(.*)$`)
+
+// Overrides that may follow a prefix or callsign
+var (
+	cqPattern        = regexp.MustCompile(`\((\d+)\)`)
+	ituPattern       = regexp.MustCompile(`\[(\d+)\]`)
+	continentPattern = regexp.MustCompile(`\{([A-Z]{2})\}`)
+	locationPattern  = regexp.MustCompile(`<(-?[\d.]+)/(-?[\d.]+)>`)
+	offsetPattern    = regexp.MustCompile(`~(-?[\d.]+)~`)
+)
+
+// Parse reads a cty.dat country file. Each entity is a header line of
+// colon separated fields followed by its prefixes, ending with a semicolon:
+//
+//	Germany:                  14:  28:  EU:   51.00:   -10.00:    -1.0:  DL:
+//	    DA,DB,DC,DD,DE,DF,DG,DH,DI,DJ,DK,DL,DM,DN,DO,DP,DQ,DR,Y2,Y3,Y4,Y5,Y6,Y7,
+//	    Y8,Y9;
+//
+// Longitudes and offsets in the file are west positive. Entities whose
+// primary prefix starts with * count only for the WAE award, so their
+// stations resolve to the DXCC entity they are part of.
+func Parse(r io.Reader) (*Database, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &Database{
+		prefixes: make(map[string]*Entity),
+		calls:    make(map[string]*Entity),
+	}
+	for _, record := range strings.Split(string(data), ";") {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+
+		fields := strings.SplitN(record, ":", 9)
+		if len(fields) != 9 {
+			return nil, fmt.Errorf("malformed entity %q", firstLine(record))
+		}
+		entity, err := parseHeader(fields[:8])
+		if err != nil {
+			return nil, fmt.Errorf("entity %q: %w", strings.TrimSpace(fields[0]), err)
+		}
+		if strings.HasPrefix(entity.Prefix, "*") {
+			continue
+		}
+		db.entities = append(db.entities, entity)
+
+		for _, alias := range strings.Split(fields[8], ",") {
+			alias = strings.ToUpper(strings.Join(strings.Fields(alias), ""))
+			m := aliasPattern.FindStringSubmatch(alias)
+			if m == nil {
+				continue
+			}
+			target := withOverrides(entity, m[3])
+			if m[1] == "=" {
+				db.calls[m[2]] = target
+				continue
+			}
+			db.prefixes[m[2]] = target
+			if len(m[2]) > db.longest {
+				db.longest = len(m[2])
+			}
+		}
+	}
+
+	if len(db.entities) == 0 {
+		return nil, errors.New("no entities in the country file")
+	}
+	return db, nil
+}
+
+// parseHeader reads an entity's name, zones, continent, location, UTC
+// offset and primary prefix
+func parseHeader(fields []string) (*Entity, error) {
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	var entity Entity
+	var err error
+	entity.Name = fields[0]
+	if entity.CQZone, err = strconv.Atoi(fields[1]); err != nil {
+		return nil, fmt.Errorf("invalid CQ zone %q", fields[1])
+	}
+	if entity.ITUZone, err = strconv.Atoi(fields[2]); err != nil {
+		return nil, fmt.Errorf("invalid ITU zone %q", fields[2])
+	}
+	entity.Continent = fields[3]
+	if entity.Latitude, err = strconv.ParseFloat(fields[4], 64); err != nil {
+		return nil, fmt.Errorf("invalid latitude %q", fields[4])
+	}
+	longitude, err := strconv.ParseFloat(fields[5], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid longitude %q", fields[5])
+	}
+	offset, err := strconv.ParseFloat(fields[6], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UTC offset %q", fields[6])
+	}
+	entity.Longitude, entity.UTCOffset = -longitude, -offset
+	entity.Prefix = strings.ToUpper(fields[7])
+	if entity.Name == "" || entity.Prefix == "" {
+		return nil, errors.New("missing name or prefix")
+	}
+	return &entity, nil
+}
+
+// withOverrides returns entity with the overrides that followed a prefix
+// or callsign applied, or entity itself when there are none
+func withOverrides(entity *Entity, overrides string) *Entity {
+	if overrides == "" {
+		return entity
+	}
+
+	e := *entity
+	if m := cqPattern.FindStringSubmatch(overrides); m != nil {
+		e.CQZone, _ = strconv.Atoi(m[1])
+	}
+	if m := ituPattern.FindStringSubmatch(overrides); m != nil {
+		e.ITUZone, _ = strconv.Atoi(m[1])
+	}
+	if m := continentPattern.FindStringSubmatch(overrides); m != nil {
+		e.Continent = m[1]
+	}
+	if m := locationPattern.FindStringSubmatch(overrides); m != nil {
+		latitude, err1 := strconv.ParseFloat(m[1], 64)
+		longitude, err2 := strconv.ParseFloat(m[2], 64)
+		if err1 == nil && err2 == nil {
+			e.Latitude, e.Longitude = latitude, -longitude
+		}
+	}
+	if m := offsetPattern.FindStringSubmatch(overrides); m != nil {
+		if offset, err := strconv.ParseFloat(m[1], 64); err == nil {
+			e.UTCOffset = -offset
+		}
+	}
+	return &e
+}
+
+// firstLine returns a record's first line, for errors
+func firstLine(record string) string {
+	line, _, _ := strings.Cut(record, "\n")
+	return strings.TrimSpace(line)
+}
+
+// Load reads a country file from disk
+func Load(path string) (*Database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Fetch downloads a country file from url, checks that it parses and
+// replaces the file at path with it, returning the new database
+func Fetch(ctx context.Context, client *http.Client, url, path string) (*Database, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "js8d")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("country file source returned %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFileSize))
+	if err != nil {
+		return nil, err
+	}
+	db, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	// Write beside the old file and rename, so a failed write leaves it whole
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Entities returns how many DXCC entities the database has
+func (db *Database) Entities() int {
+	return len(db.entities)
+}
+
+// Entity returns the entity with a primary prefix, or nil
+func (db *Database) Entity(prefix string) *Entity {
+	prefix = strings.ToUpper(prefix)
+	for _, entity := range db.entities {
+		if entity.Prefix == prefix {
+			return entity
+		}
+	}
+	return nil
+}
+
+// Lookup returns the entity a callsign is in, or nil when it matches no
+// prefix or is maritime or aeronautical mobile. Portable prefixes such as
+// DL/K1ABC and call area suffixes such as K1ABC/4 are followed.
+func (db *Database) Lookup(callsign string) *Entity {
+	callsign = strings.ToUpper(strings.TrimSpace(callsign))
+	if callsign == "" {
+		return nil
+	}
+	if entity, ok := db.calls[callsign]; ok {
+		return entity
+	}
+
+	call := callsign
+	if parts := strings.Split(callsign, "/"); len(parts) > 1 {
+		call = parts[0]
+		for _, part := range parts[1:] {
+			switch {
+			case part == "MM" || part == "AM":
+				return nil
+			case part == "P" || part == "M" || part == "QRP" || part == "A" || part == "R" || part == "":
+			case len(part) == 1 && part[0] >= '0' && part[0] <= '9':
+				// A call area suffix moves the station to that area
+				call = withCallArea(call, part[0])
+			case len(part) < len(call):
+				// The shorter side is the prefix of the country operated from
+				call = part
+			}
+		}
+		if entity, ok := db.calls[call]; ok {
+			return entity
+		}
+	}
+
+	for n := min(len(call), db.longest); n > 0; n-- {
+		if entity, ok := db.prefixes[call[:n]]; ok {
+			return entity
+		}
+	}
+	return nil
+}
+
+// withCallArea replaces the digit in a callsign's prefix with area
+func withCallArea(call string, area byte) string {
+	for i := 1; i < len(call); i++ {
+		if call[i] >= '0' && call[i] <= '9' {
+			return call[:i] + string(area)
+		}
+	}
+	return call
+}
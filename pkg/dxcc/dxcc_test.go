@@ -0,0 +1,133 @@
+package dxcc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadTestDatabase(t *testing.T) *Database {
+	t.Helper()
+	db, err := Load(filepath.Join("testdata", "cty.dat"))
+	if err != nil {
+		t.Fatalf("Failed to load country file: %v", err)
+	}
+	return db
+}
+
+func TestParse(t *testing.T) {
+	db := loadTestDatabase(t)
+
+	// African Italy counts only for WAE
+	if db.Entities() != 8 {
+		t.Errorf("Expected 8 entities, got %d", db.Entities())
+	}
+
+	germany := db.Entity("dl")
+	if germany == nil {
+		t.Fatal("Expected Germany by its prefix")
+	}
+	if germany.Name != "Germany" || germany.Continent != "EU" || germany.CQZone != 14 || germany.ITUZone != 28 {
+		t.Errorf("Unexpected Germany %+v", germany)
+	}
+	if germany.Longitude != 10 || germany.UTCOffset != 1 {
+		t.Errorf("Expected east positive longitude and offset, got %g and %g", germany.Longitude, germany.UTCOffset)
+	}
+
+	for _, bad := range []string{"", "Nowhere: 1: 2: EU;", "Nowhere: x: 2: EU: 1: 2: 0: NW: NW;"} {
+		if _, err := Parse(strings.NewReader(bad)); err == nil {
+			t.Errorf("Expected an error parsing %q", bad)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	db := loadTestDatabase(t)
+
+	tests := []struct {
+		callsign string
+		prefix   string
+		cqZone   int
+	}{
+		{"DL1ABC", "DL", 14},
+		{"dj2xyz", "DL", 14},
+		{"JA1ABC", "JA", 25},
+		{"7K1ABC", "JA", 25},
+		{"K1ABC", "K", 5},
+		{"W6ABC", "K", 3},
+		{"KH6ABC", "KH6", 31},
+		{"KL7ABC", "KL", 1},
+		{"K1XYZ", "KL", 1},     // Exact callsign
+		{"K1ABC/6", "K", 3},    // Call area suffix
+		{"DL/K1ABC", "DL", 14}, // Portable prefix
+		{"K1ABC/DL", "DL", 14},
+		{"DL1ABC/P", "DL", 14},
+		{"IH9ABC", "I", 15}, // WAE only, so Italy
+		{"G4ABC", "G", 14},
+		{"1A0KM", "1A", 15},
+	}
+	for _, tt := range tests {
+		entity := db.Lookup(tt.callsign)
+		if entity == nil {
+			t.Errorf("Lookup(%q) found nothing, want %s", tt.callsign, tt.prefix)
+			continue
+		}
+		if entity.Prefix != tt.prefix || entity.CQZone != tt.cqZone {
+			t.Errorf("Lookup(%q) = %s zone %d, want %s zone %d", tt.callsign, entity.Prefix, entity.CQZone, tt.prefix, tt.cqZone)
+		}
+	}
+
+	special := db.Lookup("W1AW/7")
+	if special == nil || special.ITUZone != 6 || special.Latitude != 47.6 || special.Longitude != -122.3 || special.UTCOffset != -8 {
+		t.Errorf("Expected W1AW/7 with its overrides, got %+v", special)
+	}
+
+	for _, callsign := range []string{"", "XX1ABC", "DL1ABC/MM"} {
+		if entity := db.Lookup(callsign); entity != nil {
+			t.Errorf("Lookup(%q) = %s, want nothing", callsign, entity.Prefix)
+		}
+	}
+}
+
+func TestFetch(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "cty.dat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bad" {
+			w.Write([]byte("<html>Not a country file</html>"))
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cty.dat")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	db, err := Fetch(ctx, server.Client(), server.URL+"/cty.dat", path)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if db.Lookup("DL1ABC") == nil {
+		t.Error("Expected the fetched database to resolve DL1ABC")
+	}
+	if _, err := Load(path); err != nil {
+		t.Errorf("Expected the fetched file saved, got %v", err)
+	}
+
+	// A bad download leaves the saved file alone
+	if _, err := Fetch(ctx, server.Client(), server.URL+"/bad", path); err == nil {
+		t.Error("Expected an error fetching a bad country file")
+	}
+	if saved, _ := os.ReadFile(path); string(saved) != string(data) {
+		t.Error("Expected the saved file untouched after a bad fetch")
+	}
+}
@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// defaultSummaryWindow is how far back GET_STATS_SUMMARY looks when no
+// window is given
+const defaultSummaryWindow = 7 * 24 * time.Hour
+
+// handleGetStatsSummary handles GET_STATS_SUMMARY [seconds], counting the
+// decodes in the window by hour, band, day and offset, and naming the most
+// distant station heard
+func (e *CoreEngine) handleGetStatsSummary(args []string) *protocol.Response {
+	window := defaultSummaryWindow
+	if len(args) > 0 {
+		seconds, err := strconv.Atoi(args[0])
+		if err != nil || seconds <= 0 {
+			return protocol.NewErrorResponse(fmt.Sprintf("invalid window %q", args[0]))
+		}
+		window = time.Duration(seconds) * time.Second
+	}
+	since := time.Now().Add(-window)
+
+	e.msgMutex.RLock()
+	defer e.msgMutex.RUnlock()
+	if e.messageStore == nil {
+		return protocol.NewErrorResponse("message storage not available")
+	}
+
+	summary, err := e.messageStore.GetActivitySummary(since)
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to summarize activity: %v", err))
+	}
+	heard, err := e.messageStore.GetHeardStations(since)
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to get heard stations: %v", err))
+	}
+	entities, err := e.messageStore.GetEntityActivity(since)
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to get entity activity: %v", err))
+	}
+	e.addEntityNames(entities)
+
+	// Best DX is the farthest station with a known grid
+	var bestDX map[string]interface{}
+	var best *protocol.Path
+	for _, h := range heard {
+		path := e.pathTo(h.Grid)
+		if path == nil || (best != nil && path.Distance <= best.Distance) {
+			continue
+		}
+		best = path
+		bestDX = map[string]interface{}{
+			"callsign":   h.Callsign,
+			"grid":       h.Grid,
+			"distance":   path.Distance,
+			"bearing":    path.Bearing,
+			"snr":        h.SNR,
+			"band":       protocol.Band(h.Frequency),
+			"last_heard": h.LastHeard,
+		}
+	}
+
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"window":   int(window.Seconds()),
+		"summary":  summary,
+		"best_dx":  bestDX,
+		"entities": entities,
+	})
+}
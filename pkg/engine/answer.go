@@ -0,0 +1,220 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/storage"
+)
+
+// answeredCQ is a CQ answered within the last hour
+type answeredCQ struct {
+	call string
+	at   time.Time
+}
+
+// answerCQ decides whether to answer a CQ from another station and queues
+// the reply when a rule matches, automatic replies are on and the hourly
+// limit allows. Every decision is logged for review.
+func (e *CoreEngine) answerCQ(msg protocol.Message) {
+	if !e.config.Answer.Enabled || msg.From == "" || msg.From == e.config.Station.Callsign || !isCQ(msg) {
+		return
+	}
+
+	decision := storage.AnswerDecision{
+		Timestamp: msg.Timestamp,
+		Callsign:  strings.ToUpper(msg.From),
+		Band:      protocol.Band(msg.Frequency),
+		Frequency: msg.Frequency,
+		SNR:       msg.SNR,
+		Message:   msg.Message,
+		Decision:  storage.AnswerSkipped,
+	}
+	if decision.Timestamp.IsZero() {
+		decision.Timestamp = time.Now()
+	}
+
+	reason, matched := e.matchAnswerRule(decision.Callsign, decision.Band, heardGrid(msg))
+	decision.Reason = reason
+	if matched {
+		if skip := e.answerLimit(decision.Callsign, decision.Timestamp); skip != "" {
+			decision.Reason = skip + ", " + reason
+		} else {
+			e.sendAnswer(&decision)
+		}
+	}
+
+	logger.Infof("CQ from %s %s: %s", decision.Callsign, decision.Decision, decision.Reason)
+	e.msgMutex.Lock()
+	defer e.msgMutex.Unlock()
+	if e.messageStore == nil {
+		return
+	}
+	if err := e.messageStore.RecordAnswerDecision(&decision); err != nil {
+		logger.Warnf("Failed to log the answer decision on %s: %v", decision.Callsign, err)
+	}
+}
+
+// matchAnswerRule checks a CQ against the answer rules, returning why it
+// matched the first rule it does or why none did
+func (e *CoreEngine) matchAnswerRule(call, band, grid string) (string, bool) {
+	e.msgMutex.RLock()
+	defer e.msgMutex.RUnlock()
+
+	var station *storage.Station
+	if e.messageStore != nil {
+		found, err := e.messageStore.GetStation(call)
+		if err != nil {
+			logger.Warnf("Failed to look up %s for answering: %v", call, err)
+		}
+		station = found
+	}
+	country := ""
+	if station != nil {
+		country = station.Country
+		if grid == "" {
+			grid = station.Grid
+		}
+	}
+
+	for _, rule := range e.config.Answer.Rules {
+		if len(rule.Bands) > 0 && !containsString(rule.Bands, band) {
+			continue
+		}
+		if containsString(rule.Callsigns, call) {
+			return "watched callsign", true
+		}
+		if rule.NewGrid && len(grid) >= 4 && e.messageStore != nil {
+			if worked, err := e.messageStore.WorkedGrid(grid); err == nil && !worked {
+				return "new grid " + strings.ToUpper(grid[:4]), true
+			}
+		}
+		if rule.NewCountry && country != "" && e.messageStore != nil {
+			if worked, err := e.messageStore.WorkedCountry(country); err == nil && !worked {
+				return "new country " + country, true
+			}
+		}
+	}
+	if band == "" {
+		return "no rule matched outside the amateur bands", false
+	}
+	return "no rule matched on " + band, false
+}
+
+// answerLimit returns why a matching CQ can't be answered now, or "" when
+// it can: automatic replies are off, the block or allow list holds the
+// caller back, the caller was answered within the hour or answer.per_hour
+// CQs have been
+func (e *CoreEngine) answerLimit(call string, now time.Time) string {
+	if !e.autoReplyEnabled() {
+		return "automatic replies are off"
+	}
+	if refused := e.autoListRefusal(rateAnswer, call); refused != "" {
+		return refused
+	}
+
+	e.answerMutex.Lock()
+	defer e.answerMutex.Unlock()
+
+	recent := e.answered[:0]
+	for _, a := range e.answered {
+		if now.Sub(a.at) < time.Hour {
+			recent = append(recent, a)
+		}
+	}
+	e.answered = recent
+
+	for _, a := range e.answered {
+		if a.call == call {
+			return "answered within the hour"
+		}
+	}
+	if len(e.answered) >= e.config.Answer.PerHour {
+		return fmt.Sprintf("limit of %d answers an hour reached", e.config.Answer.PerHour)
+	}
+	if !e.rateAllowed(rateAnswer, call) {
+		return "rate limited"
+	}
+	return ""
+}
+
+// sendAnswer queues the reply to a CQ, counting it against the hourly
+// limit, and fills in the decision
+func (e *CoreEngine) sendAnswer(decision *storage.AnswerDecision) {
+	text, err := e.expandMacros(decision.Callsign, e.config.Answer.Reply)
+	if err != nil {
+		decision.Reason = fmt.Sprintf("reply failed: %v, %s", err, decision.Reason)
+		return
+	}
+
+	msg := protocol.Message{
+		ID:        int(time.Now().Unix()),
+		Timestamp: time.Now(),
+		From:      e.config.Station.Callsign,
+		To:        decision.Callsign,
+		Message:   text,
+		Mode:      "JS8",
+	}
+	if _, ok := e.queueTX(msg); !ok {
+		decision.Reason = "TX queue full, " + decision.Reason
+		return
+	}
+
+	e.answerMutex.Lock()
+	e.answered = append(e.answered, answeredCQ{call: decision.Callsign, at: decision.Timestamp})
+	e.answerMutex.Unlock()
+	decision.Decision = storage.AnswerSent
+	decision.Reply = text
+}
+
+// handleGetAnswers lists the latest answer decisions, newest first
+func (e *CoreEngine) handleGetAnswers(args []string) *protocol.Response {
+	limit := 50
+	if len(args) > 0 {
+		if l, err := strconv.Atoi(args[0]); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	e.answerMutex.Lock()
+	answered := 0
+	for _, a := range e.answered {
+		if time.Since(a.at) < time.Hour {
+			answered++
+		}
+	}
+	e.answerMutex.Unlock()
+
+	e.msgMutex.RLock()
+	defer e.msgMutex.RUnlock()
+	if e.messageStore == nil {
+		return protocol.NewErrorResponse("message storage not available")
+	}
+
+	decisions, err := e.messageStore.GetAnswerDecisions(limit)
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to get answer decisions: %v", err))
+	}
+
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"enabled":            e.config.Answer.Enabled,
+		"auto":               e.autoReplyEnabled(),
+		"per_hour":           e.config.Answer.PerHour,
+		"answered_last_hour": answered,
+		"decisions":          decisions,
+		"count":              len(decisions),
+	})
+}
+
+// containsString reports whether values holds value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
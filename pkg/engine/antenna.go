@@ -0,0 +1,231 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/hardware"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// With antenna.switch set, the antenna switch follows the dial: each band
+// gets the port that lists it, or the default port. ANTENNA <port> picks a
+// port by hand and holds it through band changes until ANTENNA AUTO.
+
+// startAntenna opens the antenna switch and selects the port for the
+// current band. A switch that fails to open is logged and left out.
+func (e *CoreEngine) startAntenna() {
+	e.mutex.RLock()
+	cfg := e.config
+	e.mutex.RUnlock()
+	if cfg.Antenna.Switch == "" {
+		return
+	}
+
+	antennas, err := openAntennaSwitch(cfg)
+	if err != nil {
+		logger.Errorf("Antenna switch not available: %v", err)
+		return
+	}
+
+	e.antennaMutex.Lock()
+	e.antenna = antennas
+	e.antennaPorts = append([]config.AntennaPort(nil), cfg.Antenna.Ports...)
+	e.antennaDefault = cfg.Antenna.Default
+	e.antennaMutex.Unlock()
+	logger.Infof("Antenna switch ready (%s, %d ports)", cfg.Antenna.Switch, len(cfg.Antenna.Ports))
+
+	e.switchAntenna(e.dialFrequency())
+}
+
+// openAntennaSwitch opens the switch driver configured in cfg
+func openAntennaSwitch(cfg *config.Config) (hardware.AntennaSwitch, error) {
+	switch strings.ToLower(cfg.Antenna.Switch) {
+	case "gpio":
+		gpio := hardware.NewLinuxGPIO()
+		if err := gpio.Initialize(); err != nil {
+			return nil, err
+		}
+		pins := make([]int, len(cfg.Antenna.Ports))
+		for i, port := range cfg.Antenna.Ports {
+			pins[i] = port.Pin
+		}
+		return hardware.NewGPIOAntennaSwitch(gpio, pins), nil
+	case "relay":
+		relays := make([]int, len(cfg.Antenna.Ports))
+		for i, port := range cfg.Antenna.Ports {
+			relays[i] = port.Relay
+		}
+		return hardware.OpenRelayAntennaSwitch(cfg.Antenna.Device, relays)
+	}
+	return nil, fmt.Errorf("unknown antenna switch %q", cfg.Antenna.Switch)
+}
+
+// closeAntenna closes the antenna switch
+func (e *CoreEngine) closeAntenna() {
+	e.antennaMutex.Lock()
+	defer e.antennaMutex.Unlock()
+	if e.antenna == nil {
+		return
+	}
+	if err := e.antenna.Close(); err != nil {
+		logger.Warnf("Error closing antenna switch: %v", err)
+	}
+	e.antenna = nil
+}
+
+// switchAntenna selects the port for the band of frequency, unless the
+// operator has picked one. Like setAntenna it leaves the antenna alone while
+// transmitting; transmitMessage switches before it keys and again once it
+// is done. It does not take e.mutex, so callers may hold it.
+func (e *CoreEngine) switchAntenna(frequency int) {
+	// Hot switching could damage the rig or the switch
+	if e.isTransmitting() {
+		logger.Warnf("Not switching antennas while transmitting")
+		return
+	}
+
+	e.antennaMutex.Lock()
+	defer e.antennaMutex.Unlock()
+	if e.antenna == nil || e.antennaManual {
+		return
+	}
+
+	port := e.antennaFor(protocol.Band(frequency))
+	if port < 0 || strings.EqualFold(e.antennaPorts[port].Name, e.antennaPort) {
+		return
+	}
+	e.selectAntennaLocked(port)
+}
+
+// antennaFor returns the port listing band, else the default port, else -1.
+// The caller holds e.antennaMutex.
+func (e *CoreEngine) antennaFor(band string) int {
+	if band != "" {
+		for i, port := range e.antennaPorts {
+			for _, b := range port.Bands {
+				if b == band {
+					return i
+				}
+			}
+		}
+	}
+	return e.findAntenna(e.antennaDefault)
+}
+
+// findAntenna returns the index of the port named name, or -1. The caller
+// holds e.antennaMutex.
+func (e *CoreEngine) findAntenna(name string) int {
+	if name == "" {
+		return -1
+	}
+	for i, port := range e.antennaPorts {
+		if strings.EqualFold(port.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// selectAntennaLocked switches to port, keeping the error when the switch
+// fails. The caller holds e.antennaMutex.
+func (e *CoreEngine) selectAntennaLocked(port int) error {
+	name := e.antennaPorts[port].Name
+	if err := e.antenna.Select(port); err != nil {
+		logger.Warnf("Failed to select antenna %s: %v", name, err)
+		e.antennaError = err.Error()
+		return err
+	}
+	logger.Infof("Antenna %s selected", name)
+	e.antennaPort = name
+	e.antennaError = ""
+	return nil
+}
+
+// selectedAntenna returns the name of the port selected, "" for none
+func (e *CoreEngine) selectedAntenna() string {
+	e.antennaMutex.Lock()
+	defer e.antennaMutex.Unlock()
+	return e.antennaPort
+}
+
+// handleAntenna handles ANTENNA, which shows the antenna switch, ANTENNA
+// <port>, which selects a port and holds it, and ANTENNA AUTO, which
+// follows the band again
+func (e *CoreEngine) handleAntenna(args []string) *protocol.Response {
+	e.antennaMutex.Lock()
+	available := e.antenna != nil
+	e.antennaMutex.Unlock()
+	if !available {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeNotConnected, "no antenna switch configured")
+	}
+
+	if len(args) > 0 {
+		if resp := e.setAntenna(strings.Join(args, " ")); resp != nil {
+			return resp
+		}
+	}
+	return protocol.NewSuccessResponse(e.antennaStatus())
+}
+
+// setAntenna selects the port named name and holds it, or follows the band
+// again for AUTO, returning a response only on failure
+func (e *CoreEngine) setAntenna(name string) *protocol.Response {
+	if strings.EqualFold(name, "AUTO") {
+		e.antennaMutex.Lock()
+		e.antennaManual = false
+		e.antennaPort = "" // Select again even if the port looks right
+		e.antennaMutex.Unlock()
+		logger.Infof("Antenna follows the band")
+		e.switchAntenna(e.dialFrequency())
+		return nil
+	}
+
+	// Hot switching could damage the rig or the switch
+	if e.isTransmitting() {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeRadio, "cannot switch antennas while transmitting")
+	}
+
+	e.antennaMutex.Lock()
+	defer e.antennaMutex.Unlock()
+	port := e.findAntenna(name)
+	if port < 0 {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("unknown antenna %q", name))
+	}
+	if err := e.selectAntennaLocked(port); err != nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeRadio, err.Error())
+	}
+	e.antennaManual = true
+	return nil
+}
+
+// antennaStatus returns the antenna switch's ports and the one selected
+func (e *CoreEngine) antennaStatus() map[string]interface{} {
+	e.mutex.RLock()
+	driver := e.config.Antenna.Switch
+	e.mutex.RUnlock()
+	band := protocol.Band(e.dialFrequency())
+
+	e.antennaMutex.Lock()
+	defer e.antennaMutex.Unlock()
+	ports := make([]map[string]interface{}, len(e.antennaPorts))
+	for i, port := range e.antennaPorts {
+		bands := port.Bands
+		if bands == nil {
+			bands = []string{}
+		}
+		ports[i] = map[string]interface{}{
+			"name":  port.Name,
+			"bands": bands,
+		}
+	}
+	return map[string]interface{}{
+		"switch": driver,
+		"band":   band,
+		"port":   e.antennaPort,
+		"manual": e.antennaManual,
+		"ports":  ports,
+		"error":  e.antennaError,
+	}
+}
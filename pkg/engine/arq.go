@@ -0,0 +1,531 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/arq"
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+const (
+	// sessionFrameIDs is how many sessions can be open at once, one per
+	// session frame id
+	sessionFrameIDs = 64
+	// sessionMessageLimit is how many messages each session keeps
+	sessionMessageLimit = 100
+	// sessionHistoryLimit is how many closed sessions are kept to look at
+	sessionHistoryLimit = 20
+)
+
+// autoSession is the acceptance of a session another station connects,
+// held back by the block and allow lists alongside the rate limited kinds
+const autoSession = "session"
+
+// A Disconnect's seq tells a request, which is answered, from the answer,
+// which is not, so two stations that have both dropped a session don't
+// answer each other for ever
+const (
+	disconnectRequest uint8 = 0
+	disconnectConfirm uint8 = 1
+)
+
+// arqSession is a connected-mode session with another station
+type arqSession struct {
+	info     protocol.Session
+	sender   *arq.Sender
+	receiver *arq.Receiver
+	peerHash uint16   // TransferCallHash of the other station
+	hello    bool     // The connecting station's callsign, the first message it sends, is in
+	pending  []string // Frames still to be queued
+	control  string   // The control frame awaiting an answer, sent again when it times out
+	deadline time.Time
+	tries    int // Answers timed out in a row
+	messages []protocol.SessionMessage
+}
+
+// handleSession lists, shows, opens, writes to or closes sessions
+func (e *CoreEngine) handleSession(cmd *protocol.Command) *protocol.Response {
+	action := cmd.SessionAction()
+	if action == protocol.SessionList {
+		e.sessionMutex.Lock()
+		defer e.sessionMutex.Unlock()
+		sessions := []protocol.Session{}
+		for _, s := range e.sessions {
+			sessions = append(sessions, s.snapshot())
+		}
+		sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID > sessions[j].ID })
+		for i := len(e.closedSessions) - 1; i >= 0; i-- {
+			sessions = append(sessions, e.closedSessions[i].snapshot())
+		}
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"sessions": sessions,
+			"count":    len(sessions),
+		})
+	}
+	if action == protocol.SessionConnect {
+		return e.connectSession(cmd.StringArg("to"))
+	}
+
+	id, err := strconv.Atoi(cmd.StringArg("id"))
+	if err != nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("invalid session id %q", cmd.StringArg("id")))
+	}
+	e.sessionMutex.Lock()
+	defer e.sessionMutex.Unlock()
+	s := e.findSession(id)
+	if s == nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("no session %d", id))
+	}
+
+	switch action {
+	case protocol.SessionGet:
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"session":  s.snapshot(),
+			"messages": append([]protocol.SessionMessage{}, s.messages...),
+		})
+
+	case protocol.SessionSend:
+		if s.info.Status != protocol.SessionConnecting && s.info.Status != protocol.SessionConnected {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("session %d is %s", id, s.info.Status))
+		}
+		message := cmd.StringArg("message")
+		if err := s.sender.Write(message); err != nil {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, err.Error())
+		}
+		s.info.MessagesSent++
+		e.sessionMessage(s, "TX", message)
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"status":  "queued",
+			"session": s.snapshot(),
+		})
+
+	case protocol.SessionDisconnect:
+		switch s.info.Status {
+		case protocol.SessionConnecting, protocol.SessionConnected:
+		default:
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("session %d is %s", id, s.info.Status))
+		}
+		// Whatever is not yet sent is dropped
+		s.info.Status = protocol.SessionDisconnecting
+		s.pending, s.tries = nil, 0
+		s.sendControl(arq.Control(s.info.FrameID, dsp.SessionDisconnect, disconnectRequest, 0, e.config.Station.Callsign, s.info.Peer))
+		e.saveSession(s)
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"status":  "disconnecting",
+			"session": s.snapshot(),
+		})
+
+	default:
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("unknown SESSION action %q", action))
+	}
+}
+
+// connectSession opens a session to a station. The connect is fed to the
+// transmit queue by the session loop; once accepted, our callsign goes out
+// as the first message so the other station knows who we are.
+func (e *CoreEngine) connectSession(to string) *protocol.Response {
+	if !e.config.ARQ.Enabled {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "sessions are disabled, set arq.enabled")
+	}
+	to = strings.ToUpper(strings.TrimSpace(to))
+	if !dsp.IsValidCallsign(to) {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("invalid callsign %q", to))
+	}
+
+	e.sessionMutex.Lock()
+	defer e.sessionMutex.Unlock()
+
+	frameID, ok := e.freeSessionFrameID()
+	if !ok {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeQueueFull, "too many sessions open")
+	}
+	from := strings.ToUpper(e.config.Station.Callsign)
+	s := e.newSession(frameID, to, dsp.TransferCallHash(to), true)
+	s.sender.Write(from)
+	s.sendControl(arq.Control(frameID, dsp.SessionConnect, 0, 0, from, to))
+	e.saveSession(s)
+
+	logger.Infof("TX: connecting session %d to %s", s.info.ID, to)
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"status":  "connecting",
+		"session": s.snapshot(),
+	})
+}
+
+// newSession opens a session on frame id. The caller holds sessionMutex.
+func (e *CoreEngine) newSession(frameID uint8, peer string, peerHash uint16, initiator bool) *arqSession {
+	e.lastSessionID++
+	now := time.Now()
+	status := protocol.SessionConnected
+	if initiator {
+		status = protocol.SessionConnecting
+	}
+	s := &arqSession{
+		info: protocol.Session{
+			ID:        e.lastSessionID,
+			FrameID:   frameID,
+			Peer:      peer,
+			Initiator: initiator,
+			Status:    status,
+			Opened:    now,
+			Heard:     now,
+		},
+		sender:   arq.NewSender(frameID),
+		receiver: arq.NewReceiver(frameID),
+		peerHash: peerHash,
+		hello:    initiator,
+	}
+	e.sessions[frameID] = s
+	return s
+}
+
+// freeSessionFrameID picks a session frame id no open session uses. The
+// caller holds sessionMutex.
+func (e *CoreEngine) freeSessionFrameID() (uint8, bool) {
+	start := rand.Intn(sessionFrameIDs)
+	for i := 0; i < sessionFrameIDs; i++ {
+		id := uint8((start + i) % sessionFrameIDs)
+		if _, used := e.sessions[id]; !used {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// findSession returns the open or recently closed session with a stored
+// ID. The caller holds sessionMutex.
+func (e *CoreEngine) findSession(id int) *arqSession {
+	for _, s := range e.sessions {
+		if s.info.ID == id {
+			return s
+		}
+	}
+	for _, s := range e.closedSessions {
+		if s.info.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+// sessionLoop feeds session frames to the transmit queue and times out
+// answers and sessions that have gone quiet
+func (e *CoreEngine) sessionLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			e.tickSessions(now)
+
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// tickSessions does one pass of the session loop
+func (e *CoreEngine) tickSessions(now time.Time) {
+	e.mutex.RLock()
+	cfg := e.config.ARQ
+	e.mutex.RUnlock()
+
+	e.sessionMutex.Lock()
+	defer e.sessionMutex.Unlock()
+
+	for _, s := range e.sessions {
+		// An answer that doesn't come sends the control frame again
+		if !s.deadline.IsZero() && !now.Before(s.deadline) {
+			s.deadline = time.Time{}
+			s.tries++
+			if s.tries > cfg.Retries {
+				if s.info.Status == protocol.SessionDisconnecting {
+					e.closeSession(s, protocol.SessionClosed, "disconnect unanswered")
+				} else {
+					e.closeSession(s, protocol.SessionFailed, fmt.Sprintf("no answer after %d tries", s.tries))
+				}
+				continue
+			}
+			logger.Infof("Session %d with %s: no answer, sending again", s.info.ID, s.info.Peer)
+			s.pending = []string{s.control}
+		}
+
+		// With nothing awaiting an answer, the next round of data goes out
+		if s.info.Status == protocol.SessionConnected && s.control == "" && len(s.pending) == 0 && s.sender.Pending() {
+			before := s.sender.Next()
+			frames := s.sender.Round(cfg.Window)
+			fresh := int(s.sender.Next() - before)
+			s.info.FramesSent += len(frames)
+			s.info.FramesResent += len(frames) - fresh
+			s.pending = frames
+			s.sendControl(arq.Control(s.info.FrameID, dsp.SessionPoll, s.sender.Next(), 0, e.config.Station.Callsign, s.info.Peer))
+			e.saveSession(s)
+		}
+
+		for len(s.pending) > 0 && cap(e.txMessages)-len(e.txMessages) > fileQueueReserve {
+			if !e.queueTransferFrame(s.to(), s.pending[0]) {
+				break
+			}
+			s.pending = s.pending[1:]
+		}
+
+		idle := time.Duration(cfg.Idle) * time.Minute
+		if s.info.Status == protocol.SessionConnected && !s.sender.Pending() && s.control == "" && now.Sub(s.info.Heard) >= idle {
+			logger.Infof("Session %d with %s idle, disconnecting", s.info.ID, s.info.Peer)
+			s.info.Status = protocol.SessionDisconnecting
+			s.tries = 0
+			s.sendControl(arq.Control(s.info.FrameID, dsp.SessionDisconnect, disconnectRequest, 0, e.config.Station.Callsign, s.info.Peer))
+			e.saveSession(s)
+		}
+	}
+}
+
+// sendControl queues a control frame after the frames pending, to be sent
+// again until it is answered
+func (s *arqSession) sendControl(frame string) {
+	s.control = frame
+	s.deadline = time.Time{}
+	s.pending = append(s.pending, frame)
+}
+
+// sessionSent follows a session frame that has gone out: a control frame
+// awaiting an answer starts its timeout. A failed transmission drops the
+// session. Other messages are ignored.
+func (e *CoreEngine) sessionSent(msg protocol.Message, err error) {
+	f, perr := dsp.UnpackSessionFrame(msg.Message)
+	if perr != nil {
+		return
+	}
+
+	e.sessionMutex.Lock()
+	defer e.sessionMutex.Unlock()
+
+	s := e.sessions[f.ID]
+	if s == nil || (f.Control && f.ToHash != s.peerHash) {
+		return
+	}
+	if err != nil {
+		e.closeSession(s, protocol.SessionFailed, fmt.Sprintf("transmit failed: %v", err))
+		return
+	}
+	s.info.Heard = time.Now()
+	if f.Control && msg.Message == s.control && len(s.pending) == 0 {
+		s.deadline = time.Now().Add(time.Duration(e.config.ARQ.Timeout) * time.Second)
+	}
+}
+
+// receiveSessionFrame takes a decoded session frame, following the sessions
+// with this station. It reports whether msg was a session frame.
+func (e *CoreEngine) receiveSessionFrame(msg protocol.Message) bool {
+	f, err := dsp.UnpackSessionFrame(msg.Message)
+	if err != nil {
+		return false
+	}
+	if !e.config.ARQ.Enabled {
+		return true
+	}
+
+	e.sessionMutex.Lock()
+	defer e.sessionMutex.Unlock()
+
+	if !f.Control {
+		logger.Debugf("RX: session frame %d of session %d (SNR: %.1fdB)", f.Seq, f.ID, msg.SNR)
+		e.receiveSessionData(f)
+		return true
+	}
+	if f.ToHash != dsp.TransferCallHash(strings.ToUpper(e.config.Station.Callsign)) {
+		return true
+	}
+
+	s := e.sessions[f.ID]
+	if s != nil && s.peerHash != f.FromHash {
+		// Another station on a frame id in use here is refused
+		if f.Op == dsp.SessionConnect {
+			e.queueTransferFrame("", arq.Reply(f, dsp.SessionDisconnect, disconnectConfirm, 0))
+		}
+		return true
+	}
+
+	switch f.Op {
+	case dsp.SessionConnect:
+		e.receiveConnect(f, s)
+
+	case dsp.SessionAccept:
+		if s != nil && s.info.Status == protocol.SessionConnecting {
+			s.info.Status = protocol.SessionConnected
+			s.control, s.deadline, s.tries = "", time.Time{}, 0
+			s.info.Heard = time.Now()
+			logger.Infof("Session %d with %s connected", s.info.ID, s.info.Peer)
+			e.saveSession(s)
+		}
+
+	case dsp.SessionPoll:
+		if s == nil {
+			// A session dropped here is closed there too
+			e.queueTransferFrame("", arq.Reply(f, dsp.SessionDisconnect, disconnectConfirm, 0))
+			return true
+		}
+		// A poll before the accept is heard means the accept was lost
+		if s.info.Status == protocol.SessionConnecting {
+			s.info.Status = protocol.SessionConnected
+			s.control, s.deadline, s.tries = "", time.Time{}, 0
+			logger.Infof("Session %d with %s connected", s.info.ID, s.info.Peer)
+			e.saveSession(s)
+		}
+		s.info.Heard = time.Now()
+		seq, mask := s.receiver.Ack()
+		e.queueTransferFrame(s.to(), arq.Reply(f, dsp.SessionAck, seq, mask))
+
+	case dsp.SessionAck:
+		if s == nil || s.info.Status != protocol.SessionConnected || s.control == "" {
+			return true
+		}
+		if op, _ := dsp.UnpackSessionFrame(s.control); op.Op != dsp.SessionPoll {
+			return true
+		}
+		acked := s.sender.Ack(f.Seq, f.Mask)
+		s.control, s.deadline, s.tries = "", time.Time{}, 0
+		s.info.Heard = time.Now()
+		logger.Debugf("Session %d with %s: %d frames acknowledged, %d in flight", s.info.ID, s.info.Peer, acked, s.sender.InFlight())
+		e.saveSession(s)
+
+	case dsp.SessionDisconnect:
+		if s == nil {
+			if f.Seq == disconnectRequest {
+				e.queueTransferFrame("", arq.Reply(f, dsp.SessionDisconnect, disconnectConfirm, 0))
+			}
+			return true
+		}
+		switch {
+		case s.info.Status == protocol.SessionConnecting:
+			e.closeSession(s, protocol.SessionFailed, "refused")
+		case s.info.Status == protocol.SessionDisconnecting:
+			e.closeSession(s, protocol.SessionClosed, "")
+		default:
+			if f.Seq == disconnectRequest {
+				e.queueTransferFrame(s.to(), arq.Reply(f, dsp.SessionDisconnect, disconnectConfirm, 0))
+			}
+			e.closeSession(s, protocol.SessionClosed, "disconnected by "+s.info.Peer)
+		}
+	}
+	return true
+}
+
+// receiveConnect accepts a connect to this station, or answers it again
+// when the accept was lost, while automatic replies are on and the block
+// and allow lists let the station in. The caller holds sessionMutex.
+func (e *CoreEngine) receiveConnect(f dsp.SessionFrame, s *arqSession) {
+	if s != nil {
+		if !s.info.Initiator && s.info.Status == protocol.SessionConnected {
+			e.queueTransferFrame(s.to(), arq.Reply(f, dsp.SessionAccept, 0, 0))
+		}
+		return
+	}
+
+	caller := fmt.Sprintf("#%04X", f.FromHash)
+	if !e.autoReplyEnabled() || e.autoListRefusal(autoSession, caller) != "" {
+		logger.Infof("RX: session connect from %s refused", caller)
+		e.queueTransferFrame("", arq.Reply(f, dsp.SessionDisconnect, disconnectConfirm, 0))
+		return
+	}
+
+	s = e.newSession(f.ID, caller, f.FromHash, false)
+	logger.Infof("RX: session %d connected from %s", s.info.ID, caller)
+	e.queueTransferFrame("", arq.Reply(f, dsp.SessionAccept, 0, 0))
+	e.saveSession(s)
+}
+
+// receiveSessionData keeps a data frame of an open session and takes the
+// messages it completes. The caller holds sessionMutex.
+func (e *CoreEngine) receiveSessionData(f dsp.SessionFrame) {
+	s := e.sessions[f.ID]
+	if s == nil || s.info.Status != protocol.SessionConnected {
+		return
+	}
+	messages, fresh := s.receiver.Add(f)
+	if !fresh {
+		return
+	}
+	s.info.FramesReceived++
+	s.info.Heard = time.Now()
+
+	for _, message := range messages {
+		// The station that connected sends its callsign first
+		if !s.hello {
+			s.hello = true
+			if call := strings.ToUpper(strings.TrimSpace(message)); dsp.IsValidCallsign(call) && dsp.TransferCallHash(call) == s.peerHash {
+				logger.Infof("Session %d is with %s", s.info.ID, call)
+				s.info.Peer = call
+				continue
+			}
+		}
+		s.info.MessagesRecvd++
+		logger.Infof("RX: session %d from %s: %s", s.info.ID, s.info.Peer, message)
+		e.sessionMessage(s, "RX", message)
+	}
+	e.saveSession(s)
+}
+
+// sessionMessage keeps a message written to or received in a session and
+// announces it. The caller holds sessionMutex.
+func (e *CoreEngine) sessionMessage(s *arqSession, direction, text string) {
+	message := protocol.SessionMessage{Time: time.Now(), Direction: direction, Text: text}
+	s.messages = append(s.messages, message)
+	if len(s.messages) > sessionMessageLimit {
+		s.messages = s.messages[len(s.messages)-sessionMessageLimit:]
+	}
+	e.publishEvent(protocol.EventSession, map[string]interface{}{
+		"session": s.snapshot(),
+		"message": message,
+	})
+}
+
+// closeSession ends a session, freeing its frame id and keeping it a while
+// to look at. The caller holds sessionMutex.
+func (e *CoreEngine) closeSession(s *arqSession, status, reason string) {
+	delete(e.sessions, s.info.FrameID)
+	s.info.Status = status
+	s.info.Error = reason
+	s.pending, s.control, s.deadline = nil, "", time.Time{}
+	e.closedSessions = append(e.closedSessions, s)
+	if len(e.closedSessions) > sessionHistoryLimit {
+		e.closedSessions = e.closedSessions[len(e.closedSessions)-sessionHistoryLimit:]
+	}
+	if status == protocol.SessionFailed {
+		logger.Warnf("Session %d with %s failed: %s", s.info.ID, s.info.Peer, reason)
+	} else {
+		logger.Infof("Session %d with %s closed", s.info.ID, s.info.Peer)
+	}
+	e.saveSession(s)
+}
+
+// to is who the session's frames are queued to: the other station, once
+// its callsign is known
+func (s *arqSession) to() string {
+	if strings.HasPrefix(s.info.Peer, "#") {
+		return ""
+	}
+	return s.info.Peer
+}
+
+// snapshot returns the session as shown, with its stream's progress
+func (s *arqSession) snapshot() protocol.Session {
+	info := s.info
+	info.Queued = s.sender.Queued()
+	info.InFlight = s.sender.InFlight()
+	return info
+}
+
+// saveSession announces a session's progress
+func (e *CoreEngine) saveSession(s *arqSession) {
+	e.publishEvent(protocol.EventSession, map[string]interface{}{
+		"session": s.snapshot(),
+	})
+}
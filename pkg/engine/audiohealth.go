@@ -0,0 +1,161 @@
+package engine
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/hardware"
+)
+
+// How far captured audio may wait for the decoder before we warn that
+// decoding has fallen behind real time, and how often we say so
+const (
+	pipelineLagWarning   = 2 * time.Second
+	pipelineWarnInterval = time.Minute
+)
+
+// pipelineHealth measures how long captured audio waits before the decoder
+// takes it, a wait that grows until blocks are dropped when decoding cannot
+// keep up with real time
+type pipelineHealth struct {
+	mutex      sync.Mutex
+	lag        time.Duration // Last block's wait for the decoder
+	maxLag     time.Duration
+	dropped    int64         // Blocks dropped with the decoder's queue full
+	decodeTime time.Duration // Last decode pass
+	warned     time.Time
+}
+
+// record notes the wait of a block the decoder took at a time, reporting
+// whether to warn that decoding is behind
+func (p *pipelineHealth) record(lag time.Duration, at time.Time) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.lag = lag
+	if lag > p.maxLag {
+		p.maxLag = lag
+	}
+	return lag > pipelineLagWarning && p.warn(at)
+}
+
+// drop notes a block dropped at a time, reporting whether to warn
+func (p *pipelineHealth) drop(at time.Time) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.dropped++
+	return p.warn(at)
+}
+
+// decoded notes how long a decode pass took
+func (p *pipelineHealth) decoded(elapsed time.Duration) {
+	p.mutex.Lock()
+	p.decodeTime = elapsed
+	p.mutex.Unlock()
+}
+
+// warn limits warnings to one a pipelineWarnInterval. The mutex must be held.
+func (p *pipelineHealth) warn(at time.Time) bool {
+	if at.Sub(p.warned) < pipelineWarnInterval {
+		return false
+	}
+	p.warned = at
+	return true
+}
+
+// warnBehind logs that decoding has fallen behind real time
+func (e *CoreEngine) warnBehind() {
+	e.pipeline.mutex.Lock()
+	lag, dropped := e.pipeline.lag, e.pipeline.dropped
+	e.pipeline.mutex.Unlock()
+	dspLogger.Warnf("Decoding is %.1fs behind real time with %d audio blocks dropped; "+
+		"raise audio buffer_size or lower the decode load", lag.Seconds(), dropped)
+}
+
+// AudioHealth is the audio path's latency and buffer state, from the sound
+// card to the decoder
+type AudioHealth struct {
+	Card         hardware.AudioHealth
+	CardMeasured bool // Whether the sound card reports Card
+	SampleRate   int
+	BufferSize   int // Frames per capture block, audio buffer_size
+
+	DecodeQueued    int // Captured blocks waiting for the decoder
+	DecodeQueueSize int
+	DecodeLag       time.Duration
+	MaxDecodeLag    time.Duration
+	DecodeDropped   int64
+	DecodeTime      time.Duration
+}
+
+// AudioHealth reports the audio path's latency and buffer state
+func (e *CoreEngine) AudioHealth() AudioHealth {
+	hw := e.hardwareManager.GetConfig()
+	health := AudioHealth{
+		SampleRate:      hw.SampleRate,
+		BufferSize:      hw.BufferSize,
+		DecodeQueued:    len(e.rxAudio),
+		DecodeQueueSize: cap(e.rxAudio),
+	}
+	health.Card, health.CardMeasured = e.hardwareManager.GetAudioHealth()
+
+	e.pipeline.mutex.Lock()
+	health.DecodeLag = e.pipeline.lag
+	health.MaxDecodeLag = e.pipeline.maxLag
+	health.DecodeDropped = e.pipeline.dropped
+	health.DecodeTime = e.pipeline.decodeTime
+	e.pipeline.mutex.Unlock()
+	return health
+}
+
+// GetAudioHealthStatistics returns the audio path's latency and buffer state
+// for the API, latencies in milliseconds and fills as fractions
+func (e *CoreEngine) GetAudioHealthStatistics() map[string]interface{} {
+	health := e.AudioHealth()
+	fill := func(queued, size int) float64 {
+		if size == 0 {
+			return 0
+		}
+		return float64(queued) / float64(size)
+	}
+	ms := func(d time.Duration) float64 {
+		return math.Round(float64(d)/float64(time.Millisecond)*10) / 10
+	}
+
+	stats := map[string]interface{}{
+		"sample_rate": health.SampleRate,
+		"buffer_size": health.BufferSize,
+		"decode": map[string]interface{}{
+			"queued":         health.DecodeQueued,
+			"queue_size":     health.DecodeQueueSize,
+			"fill":           fill(health.DecodeQueued, health.DecodeQueueSize),
+			"lag_ms":         ms(health.DecodeLag),
+			"max_lag_ms":     ms(health.MaxDecodeLag),
+			"dropped_blocks": health.DecodeDropped,
+			"decode_time_ms": ms(health.DecodeTime),
+			"behind":         health.DecodeLag > pipelineLagWarning,
+		},
+	}
+	if health.SampleRate > 0 {
+		stats["buffer_ms"] = ms(time.Duration(health.BufferSize) * time.Second / time.Duration(health.SampleRate))
+	}
+	if health.CardMeasured {
+		card := health.Card
+		stats["input"] = map[string]interface{}{
+			"latency_ms":     ms(card.InputLatency),
+			"queued":         card.InputQueued,
+			"queue_size":     card.InputQueueSize,
+			"fill":           fill(card.InputQueued, card.InputQueueSize),
+			"overruns":       card.Overruns,
+			"dropped_blocks": card.InputDropped,
+		}
+		stats["output"] = map[string]interface{}{
+			"latency_ms": ms(card.OutputLatency),
+			"queued":     card.OutputQueued,
+			"queue_size": card.OutputQueueSize,
+			"fill":       fill(card.OutputQueued, card.OutputQueueSize),
+			"underruns":  card.Underruns,
+		}
+	}
+	return stats
+}
@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/rpc/js8dv1"
+)
+
+// Roles the gRPC methods need
+var grpcMethodRoles = map[string]string{
+	js8dv1.JS8D_GetStatus_FullMethodName:         protocol.RoleGuest,
+	js8dv1.JS8D_ListMessages_FullMethodName:      protocol.RoleGuest,
+	js8dv1.JS8D_StreamDecodes_FullMethodName:     protocol.RoleGuest,
+	js8dv1.JS8D_SendMessage_FullMethodName:       protocol.RoleOperator,
+	js8dv1.JS8D_AbortTransmission_FullMethodName: protocol.RoleOperator,
+}
+
+// newSessionToken returns a random token for clients in the same process
+func newSessionToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// SessionToken returns a token granting admin on the socket, for the web
+// server in the same process. It changes every time js8d starts.
+func (e *CoreEngine) SessionToken() string {
+	return e.adminToken
+}
+
+// socketRole is the role of a socket client that hasn't presented a token
+func (e *CoreEngine) socketRole() string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	if !e.config.AuthEnabled() || e.config.Auth.SocketRole == "" {
+		return protocol.RoleAdmin
+	}
+	return e.config.Auth.SocketRole
+}
+
+// authenticate returns the role of a token, or the error to send back
+func (e *CoreEngine) authenticate(token string) (string, *protocol.Response) {
+	if subtle.ConstantTimeCompare([]byte(token), []byte(e.adminToken)) == 1 {
+		return protocol.RoleAdmin, nil
+	}
+
+	e.mutex.RLock()
+	_, role, ok := e.config.TokenRole(token)
+	e.mutex.RUnlock()
+	if !ok {
+		return "", protocol.NewCodedErrorResponse(protocol.ErrCodeUnauthorized, "unknown token")
+	}
+	return role, nil
+}
+
+// handleAuth switches a connection to the role of the token it presented
+func (e *CoreEngine) handleAuth(cmd *protocol.Command, role *string) *protocol.Response {
+	newRole, failure := e.authenticate(cmd.StringArg("token"))
+	if failure != nil {
+		return failure
+	}
+	*role = newRole
+	return protocol.NewSuccessResponse(map[string]interface{}{"role": newRole})
+}
+
+// authorize returns an error response if role may not run cmd
+func authorize(role string, cmd *protocol.Command) *protocol.Response {
+	required := protocol.RequiredRole(cmd)
+	if protocol.RoleAllows(role, required) {
+		return nil
+	}
+	return protocol.NewCodedErrorResponse(protocol.ErrCodeForbidden,
+		fmt.Sprintf("%s needs the %s role, this connection has %s", cmd.Type, required, role))
+}
+
+// grpcRole checks the bearer token in the request metadata against the
+// role the method needs
+func (e *CoreEngine) grpcRole(ctx context.Context, method string) error {
+	e.mutex.RLock()
+	enabled := e.config.AuthEnabled()
+	guestAccess := e.config.Auth.GuestAccess
+	e.mutex.RUnlock()
+	if !enabled {
+		return nil
+	}
+
+	required, ok := grpcMethodRoles[method]
+	if !ok {
+		required = protocol.RoleAdmin
+	}
+
+	role := ""
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		token, _ := strings.CutPrefix(values[0], "Bearer ")
+		var failure *protocol.Response
+		if role, failure = e.authenticate(token); failure != nil {
+			return status.Error(codes.Unauthenticated, failure.Error)
+		}
+	} else if guestAccess {
+		role = protocol.RoleGuest
+	} else {
+		return status.Error(codes.Unauthenticated, "missing authorization token")
+	}
+
+	if !protocol.RoleAllows(role, required) {
+		return status.Errorf(codes.PermissionDenied, "%s needs the %s role", method, required)
+	}
+	return nil
+}
+
+func (e *CoreEngine) grpcUnaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := e.grpcRole(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (e *CoreEngine) grpcStreamAuth(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := e.grpcRole(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// handleAuto reports whether automatic replies are on, or turns them on or
+// off with AUTO ON or AUTO OFF. The setting lasts until js8d restarts.
+func (e *CoreEngine) handleAuto(args []string) *protocol.Response {
+	if len(args) > 0 {
+		var enabled bool
+		switch args[0] {
+		case "ON":
+			enabled = true
+		case "OFF":
+			enabled = false
+		default:
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid,
+				fmt.Sprintf("invalid AUTO setting %q, use ON or OFF", args[0]))
+		}
+
+		e.mutex.Lock()
+		e.autoReply = enabled
+		e.mutex.Unlock()
+		logger.Infof("Automatic replies turned %s", strings.ToLower(args[0]))
+	}
+
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"auto": e.autoReplyEnabled(),
+	})
+}
+
+// autoReplyEnabled reports whether queries directed to us are answered
+func (e *CoreEngine) autoReplyEnabled() bool {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.autoReply
+}
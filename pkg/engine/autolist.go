@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/storage"
+)
+
+// autoQSO is a scripted QSO started with a station answering our CQ, held
+// back by the block and allow lists alongside the rate limited kinds
+const autoQSO = "qso"
+
+// Why the block and allow lists hold back an automatic reply
+const (
+	autoListBlocked    = "blocked"
+	autoListNotAllowed = "not on the allow list"
+)
+
+// loadAutoList reads the block and allow lists from the message store
+func (e *CoreEngine) loadAutoList() error {
+	e.msgMutex.RLock()
+	if e.messageStore == nil {
+		e.msgMutex.RUnlock()
+		return nil
+	}
+	entries, err := e.messageStore.GetAutoList()
+	e.msgMutex.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	e.autoListMutex.Lock()
+	e.autoList = entries
+	e.autoListMutex.Unlock()
+	return nil
+}
+
+// autoListMatches reports whether a list entry covers a callsign. A prefix
+// entry covers every callsign starting with it; any other covers the
+// station with a prefix or suffix too, such as N0ABC/P.
+func autoListMatches(entry storage.AutoListEntry, call string) bool {
+	call = strings.ToUpper(call)
+	if prefix, ok := strings.CutSuffix(entry.Callsign, "*"); ok {
+		return strings.HasPrefix(call, prefix)
+	}
+	return call == entry.Callsign || strings.HasPrefix(call, entry.Callsign+"/") || strings.HasSuffix(call, "/"+entry.Callsign)
+}
+
+// autoListRefusal returns why the block and allow lists hold back an
+// automatic reply of kind to call, or "" when they don't: the station is
+// on the block list, or the allow list has entries and the station isn't
+// on it. Each refusal is counted by kind.
+func (e *CoreEngine) autoListRefusal(kind, call string) string {
+	e.autoListMutex.Lock()
+	defer e.autoListMutex.Unlock()
+
+	reason := ""
+	allowList, allowed := false, false
+	for _, entry := range e.autoList {
+		if !autoListMatches(entry, call) {
+			allowList = allowList || entry.List == protocol.AutoListAllow
+			continue
+		}
+		if entry.List == protocol.AutoListBlock {
+			reason = autoListBlocked
+			break
+		}
+		allowList, allowed = true, true
+	}
+	if reason == "" && allowList && !allowed {
+		reason = autoListNotAllowed
+	}
+	if reason == "" {
+		return ""
+	}
+
+	if e.autoListHeld == nil {
+		e.autoListHeld = make(map[string]int)
+	}
+	e.autoListHeld[kind]++
+	logger.Debugf("Automatic %s to %s held back: %s", kind, call, reason)
+	return reason
+}
+
+// handleAutoList handles the AUTOLIST command, which lists, adds to or
+// deletes from the block and allow lists of automatic replies
+func (e *CoreEngine) handleAutoList(cmd *protocol.Command) *protocol.Response {
+	action := cmd.AutoListAction()
+
+	var entry storage.AutoListEntry
+	var id int64
+	switch action {
+	case protocol.AutoListList:
+		return e.autoListStatus()
+
+	case protocol.AutoListBlock, protocol.AutoListAllow:
+		entry.List = action
+		entry.Callsign = strings.ToUpper(strings.TrimSpace(cmd.StringArg("callsign")))
+		entry.Note = cmd.StringArg("note")
+		if call := strings.TrimSuffix(entry.Callsign, "*"); call == "" || strings.ContainsAny(call, "* :") {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "callsign or prefix ending in * required")
+		}
+
+	case protocol.AutoListDelete:
+		var err error
+		id, err = strconv.ParseInt(strings.TrimSpace(cmd.StringArg("id")), 10, 64)
+		if err != nil || id <= 0 {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "usage: AUTOLIST:delete:<id>")
+		}
+
+	default:
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("unknown AUTOLIST action %q", action))
+	}
+
+	e.msgMutex.Lock()
+	if e.messageStore == nil {
+		e.msgMutex.Unlock()
+		return protocol.NewErrorResponse("message storage not available")
+	}
+	var err error
+	var deleted bool
+	if action == protocol.AutoListDelete {
+		deleted, err = e.messageStore.DeleteAutoListEntry(id)
+	} else {
+		err = e.messageStore.AddAutoListEntry(&entry)
+	}
+	e.msgMutex.Unlock()
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to update the auto list: %v", err))
+	}
+	if action == protocol.AutoListDelete && !deleted {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("no auto list entry %d", id))
+	}
+	if err := e.loadAutoList(); err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to load the auto list: %v", err))
+	}
+
+	if action == protocol.AutoListDelete {
+		logger.Infof("Auto list entry %d deleted", id)
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"deleted": id,
+		})
+	}
+	logger.Infof("Auto list entry %d added: %s %s", entry.ID, entry.List, entry.Callsign)
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"entry": entry,
+	})
+}
+
+// autoListStatus returns the block and allow lists, whether automatic
+// replies are kept to the allow list, and the replies held back by kind
+// since starting
+func (e *CoreEngine) autoListStatus() *protocol.Response {
+	e.autoListMutex.RLock()
+	defer e.autoListMutex.RUnlock()
+
+	entries := append([]storage.AutoListEntry{}, e.autoList...)
+	allowOnly := false
+	for _, entry := range entries {
+		allowOnly = allowOnly || entry.List == protocol.AutoListAllow
+	}
+	held := map[string]int{}
+	for _, kind := range []string{rateReply, rateAnswer, rateTransfer, autoQSO, autoNet, autoSession} {
+		held[kind] = e.autoListHeld[kind]
+	}
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"entries":    entries,
+		"count":      len(entries),
+		"allow_only": allowOnly,
+		"held":       held,
+	})
+}
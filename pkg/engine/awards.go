@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/awards"
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/storage"
+)
+
+// sessionGap returns the silence after which messages with a station
+// start a new QSO
+func (e *CoreEngine) sessionGap() time.Duration {
+	if e.config.Messages.SessionGap <= 0 {
+		return storage.DefaultSessionGap
+	}
+	return time.Duration(e.config.Messages.SessionGap) * time.Minute
+}
+
+// logHeardQSO logs a contact when a station we sent to within the session
+// gap sends us a directed message. The caller holds msgMutex.
+func (e *CoreEngine) logHeardQSO(msg protocol.Message) {
+	if msg.From == "" || msg.From == e.config.Station.Callsign || !strings.EqualFold(msg.To, e.config.Station.Callsign) {
+		return
+	}
+	at := msg.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	e.logQSO(&storage.QSO{
+		Callsign:  strings.ToUpper(msg.From),
+		End:       at,
+		Band:      protocol.Band(msg.Frequency),
+		Frequency: msg.Frequency,
+		Grid:      heardGrid(msg),
+		SNR:       msg.SNR,
+	}, "TX", e.config.Station.Callsign, msg.From)
+}
+
+// logSentQSO logs a contact when a message goes out to a station that
+// sent us a directed message within the session gap
+func (e *CoreEngine) logSentQSO(msg protocol.Message) {
+	if msg.To == "" || strings.HasPrefix(msg.To, "@") {
+		return
+	}
+	frequency := msg.Frequency
+	if frequency == 0 {
+		frequency = e.dialFrequency() + msg.Offset
+	}
+
+	e.msgMutex.Lock()
+	defer e.msgMutex.Unlock()
+	if e.messageStore == nil {
+		return
+	}
+	e.logQSO(&storage.QSO{
+		Callsign:  strings.ToUpper(msg.To),
+		End:       time.Now(),
+		Band:      protocol.Band(frequency),
+		Frequency: frequency,
+	}, "RX", msg.To, e.config.Station.Callsign)
+}
+
+// logQSO logs a contact ending now when the other side of the exchange,
+// the latest message in direction from one station to the other, came
+// within the session gap. The QSO starts with that message. The caller
+// holds msgMutex.
+func (e *CoreEngine) logQSO(qso *storage.QSO, direction, from, to string) {
+	gap := e.sessionGap()
+	last, err := e.messageStore.LastDirected(direction, from, to)
+	if err != nil {
+		logger.Warnf("Failed to check for a QSO with %s: %v", qso.Callsign, err)
+		return
+	}
+	if last.IsZero() || qso.End.Sub(last) > gap {
+		return
+	}
+
+	qso.Start = last
+	if qso.Start.After(qso.End) {
+		qso.Start = qso.End
+	}
+	isNew, err := e.messageStore.LogQSO(qso, gap)
+	if err != nil {
+		logger.Warnf("Failed to log the QSO with %s: %v", qso.Callsign, err)
+		return
+	}
+	if isNew {
+		logger.Infof("Logged a QSO with %s on %s", qso.Callsign, qso.Band)
+	}
+	e.broadcastQSO(qso.ID, !isNew)
+}
+
+// handleGetLog lists the logged QSOs, newest first, optionally with one
+// station: GET_LOG [limit] [callsign]
+func (e *CoreEngine) handleGetLog(args []string) *protocol.Response {
+	limit := 100
+	if len(args) > 0 {
+		if l, err := strconv.Atoi(args[0]); err == nil && l > 0 {
+			limit = l
+		}
+	}
+	callsign := ""
+	if len(args) > 1 {
+		callsign = strings.ToUpper(args[1])
+	}
+
+	e.msgMutex.RLock()
+	defer e.msgMutex.RUnlock()
+	if e.messageStore == nil {
+		return protocol.NewErrorResponse("message storage not available")
+	}
+
+	qsos, err := e.messageStore.GetQSOs(callsign, limit)
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to get the log: %v", err))
+	}
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"qsos":  qsos,
+		"count": len(qsos),
+	})
+}
+
+// handleGetAwards reports the progress toward the grid, DXCC and WAS
+// awards from the log, on one band or all of them: GET_AWARDS [band]
+func (e *CoreEngine) handleGetAwards(args []string) *protocol.Response {
+	band := ""
+	if len(args) > 0 {
+		band = strings.ToLower(args[0])
+		if !protocol.ValidBand(band) {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("unknown band %q", args[0]))
+		}
+	}
+
+	e.msgMutex.RLock()
+	if e.messageStore == nil {
+		e.msgMutex.RUnlock()
+		return protocol.NewErrorResponse("message storage not available")
+	}
+	qsos, err := e.messageStore.GetQSOs("", 0)
+	e.msgMutex.RUnlock()
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to get the log: %v", err))
+	}
+
+	contacts := make([]awards.Contact, len(qsos))
+	seen := make(map[string]bool)
+	bands := []string{}
+	for i, q := range qsos {
+		contacts[i] = awards.Contact{
+			Callsign:  q.Callsign,
+			Band:      q.Band,
+			Grid:      q.Grid,
+			Entity:    q.Entity,
+			State:     q.State,
+			Confirmed: q.QSLStatus == storage.QSLConfirmed,
+		}
+		if q.Band != "" && !seen[q.Band] {
+			seen[q.Band] = true
+			bands = append(bands, q.Band)
+		}
+	}
+	sort.Strings(bands)
+
+	progress := awards.Compute(contacts, band)
+	e.dxccMutex.RLock()
+	if e.dxcc != nil {
+		for i, item := range progress.DXCC.Items {
+			if entity := e.dxcc.Entity(item.ID); entity != nil {
+				progress.DXCC.Items[i].Name = entity.Name
+			}
+		}
+	}
+	e.dxccMutex.RUnlock()
+
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"awards": progress,
+		"bands":  bands,
+	})
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// stationRegion returns the station region setting, the default when unset
+func stationRegion(cfg *config.Config) int {
+	if cfg.Station.Region == 0 {
+		return config.DefaultRegion
+	}
+	return cfg.Station.Region
+}
+
+// bandPlanCheck checks the signal we would send at a dial frequency and TX
+// offset against the station region's band plan and license class
+func bandPlanCheck(cfg *config.Config, dial, offset int) protocol.BandCheck {
+	low := dial + offset
+	return protocol.CheckBandPlan(low, low+int(dsp.SignalBandwidth), stationRegion(cfg), cfg.Station.License)
+}
+
+// checkBandPlan follows tx out_of_band before keying outside the band plan
+// or license class, reporting it with a band_plan event. It returns an
+// error when the transmission is blocked.
+func (e *CoreEngine) checkBandPlan() error {
+	e.mutex.RLock()
+	policy := strings.ToLower(e.config.TX.OutOfBand)
+	check := bandPlanCheck(e.config, e.frequency, e.txAudioOffset)
+	e.mutex.RUnlock()
+
+	if policy == config.OutOfBandOff || check.OK() {
+		return nil
+	}
+	blocked := policy == config.OutOfBandBlock
+	e.publishEvent(protocol.EventBandPlan, map[string]interface{}{
+		"check":   check,
+		"blocked": blocked,
+	})
+	if blocked {
+		return fmt.Errorf("TX blocked by tx out_of_band: %s", check.Warning)
+	}
+	logger.Warnf("TX outside the band plan: %s", check.Warning)
+	return nil
+}
+
+// handleBandPlan handles BANDPLAN [<frequency>]: where TX at a dial
+// frequency in Hz, the current one without, would fall in the station
+// region's band plan, and the bands allocated there
+func (e *CoreEngine) handleBandPlan(args []string) *protocol.Response {
+	e.mutex.RLock()
+	dial, offset := e.frequency, e.txAudioOffset
+	cfg := e.config
+	e.mutex.RUnlock()
+
+	if len(args) > 0 {
+		frequency, err := strconv.Atoi(args[0])
+		if err != nil || frequency <= 0 {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("invalid frequency %s", args[0]))
+		}
+		dial = frequency
+	}
+
+	region := stationRegion(cfg)
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"frequency":   dial,
+		"offset":      offset,
+		"region":      region,
+		"license":     cfg.Station.License,
+		"out_of_band": cfg.TX.OutOfBand,
+		"check":       bandPlanCheck(cfg, dial, offset),
+		"bands":       protocol.BandPlan(region),
+	})
+}
@@ -0,0 +1,273 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// beaconCheckInterval is how often the beacon and station ID timers are
+// checked
+const beaconCheckInterval = 5 * time.Second
+
+// Why a beacon is held back
+const (
+	beaconHeldTraffic = "directed traffic"
+	beaconHeldQSO     = "scripted QSO"
+	beaconHeldNet     = "net open"
+)
+
+// beaconLoop sends beacons and station IDs as they come due
+func (e *CoreEngine) beaconLoop() {
+	ticker := time.NewTicker(beaconCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		e.checkBeacon(time.Now())
+
+		select {
+		case <-ticker.C:
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// beaconEnabled reports whether beacons go out: beacon enabled, unless
+// BEACON ON or OFF overrode it
+func (e *CoreEngine) beaconEnabled() bool {
+	e.beaconMutex.Lock()
+	override := e.beaconOverride
+	e.beaconMutex.Unlock()
+	if override != nil {
+		return *override
+	}
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.config.Beacon.Enabled
+}
+
+// nextBeaconTime returns when the beacon after one at from is due: beacon
+// interval minutes later, moved up to beacon jitter seconds either way
+func (e *CoreEngine) nextBeaconTime(from time.Time) time.Time {
+	e.mutex.RLock()
+	interval := time.Duration(e.config.Beacon.Interval) * time.Minute
+	jitter := e.config.Beacon.Jitter
+	e.mutex.RUnlock()
+
+	next := from.Add(interval)
+	if jitter > 0 {
+		next = next.Add(time.Duration(rand.Intn(2*jitter+1)-jitter) * time.Second)
+	}
+	return next
+}
+
+// beaconHeld returns why beacons are held back at now, or "" when they
+// aren't: directed traffic with another station in the last beacon quiet
+// minutes, a scripted QSO running or a net open
+func (e *CoreEngine) beaconHeld(now time.Time) string {
+	e.mutex.RLock()
+	quiet := time.Duration(e.config.Beacon.Quiet) * time.Minute
+	e.mutex.RUnlock()
+
+	e.beaconMutex.Lock()
+	traffic := e.lastTraffic
+	e.beaconMutex.Unlock()
+	if !traffic.IsZero() && now.Sub(traffic) < quiet {
+		return beaconHeldTraffic
+	}
+	if _, qso := e.qsoStatus(); qso != nil {
+		return beaconHeldQSO
+	}
+	e.netMutex.Lock()
+	netOpen := e.net != nil
+	e.netMutex.Unlock()
+	if netOpen {
+		return beaconHeldNet
+	}
+	return ""
+}
+
+// checkBeacon queues the station ID when the ID timer is up, and the
+// beacon when it is due and not held back. A beacon held back goes out
+// once the hold ends.
+func (e *CoreEngine) checkBeacon(now time.Time) {
+	e.mutex.RLock()
+	idInterval := time.Duration(e.config.Beacon.IDInterval) * time.Minute
+	e.mutex.RUnlock()
+
+	e.beaconMutex.Lock()
+	idDue := idInterval > 0 && !e.unidentified.IsZero() && now.Sub(e.unidentified) >= idInterval
+	if idDue {
+		// Waits for the ID to go out before the timer runs again
+		e.unidentified = time.Time{}
+	}
+	e.beaconMutex.Unlock()
+	if idDue {
+		e.sendBeacon("station ID", e.config.Beacon.IDText)
+	}
+
+	if !e.beaconEnabled() {
+		e.beaconMutex.Lock()
+		e.nextBeacon = time.Time{}
+		e.beaconMutex.Unlock()
+		return
+	}
+
+	e.beaconMutex.Lock()
+	if e.nextBeacon.IsZero() {
+		e.nextBeacon = e.nextBeaconTime(now)
+	}
+	due := !now.Before(e.nextBeacon)
+	e.beaconMutex.Unlock()
+	if !due {
+		return
+	}
+	if held := e.beaconHeld(now); held != "" {
+		logger.Debugf("Beacon held back: %s", held)
+		return
+	}
+
+	e.sendBeacon("beacon", e.config.Beacon.Text)
+	e.beaconMutex.Lock()
+	e.nextBeacon = e.nextBeaconTime(now)
+	e.beaconMutex.Unlock()
+}
+
+// sendBeacon queues a broadcast of text, with its macros and tokens
+// filled in, naming it kind in the log
+func (e *CoreEngine) sendBeacon(kind, text string) (protocol.Message, error) {
+	if offset, off := e.clockOff(); off {
+		err := fmt.Errorf("clock is %.1f s off", offset.Seconds())
+		logger.Warnf("Not sending %s: %v", kind, err)
+		return protocol.Message{}, err
+	}
+	if err := e.txInhibitedError(); err != nil {
+		logger.Infof("Not sending %s: %v", kind, err)
+		return protocol.Message{}, err
+	}
+	text, err := e.expandMacros("", text)
+	if err != nil {
+		logger.Warnf("Not sending %s: %v", kind, err)
+		return protocol.Message{}, err
+	}
+
+	msg, ok := e.queueTX(protocol.Message{
+		ID:        int(time.Now().Unix()),
+		Timestamp: time.Now(),
+		From:      e.config.Station.Callsign,
+		Message:   text,
+		Mode:      "JS8",
+	})
+	if !ok {
+		logger.Warnf("TX queue full, dropping %s", kind)
+		return msg, fmt.Errorf("transmit queue full")
+	}
+	logger.Infof("Queued %s: %s", kind, text)
+	return msg, nil
+}
+
+// noteTraffic remembers directed traffic with another station, heard or
+// sent, which holds beacons back for beacon quiet minutes
+func (e *CoreEngine) noteTraffic(msg protocol.Message, now time.Time) {
+	callsign := e.config.Station.Callsign
+	var other string
+	switch {
+	case strings.EqualFold(msg.From, callsign):
+		other = msg.To
+	case strings.EqualFold(msg.To, callsign):
+		other = msg.From
+	}
+	if other == "" || other == "UNKNOWN" || strings.HasPrefix(other, "@") {
+		return
+	}
+	e.beaconMutex.Lock()
+	e.lastTraffic = now
+	e.beaconMutex.Unlock()
+}
+
+// noteIdentified runs the station ID timer for a transmission that went
+// out: one carrying our callsign resets it, and the first one without
+// since then starts it
+func (e *CoreEngine) noteIdentified(msg protocol.Message, now time.Time) {
+	callsign := strings.ToUpper(e.config.Station.Callsign)
+	e.beaconMutex.Lock()
+	defer e.beaconMutex.Unlock()
+	if callsign != "" && strings.Contains(strings.ToUpper(msg.Message), callsign) {
+		e.lastID = now
+		e.unidentified = time.Time{}
+	} else if e.unidentified.IsZero() {
+		e.unidentified = now
+	}
+}
+
+// handleBeacon handles the BEACON command: the beacon and station ID
+// timers, BEACON ON or OFF to turn beacons on or off until js8d restarts,
+// and BEACON NOW to send one at once
+func (e *CoreEngine) handleBeacon(args []string) *protocol.Response {
+	if len(args) > 0 {
+		switch strings.ToUpper(args[0]) {
+		case "ON", "OFF":
+			enabled := strings.EqualFold(args[0], "ON")
+			e.beaconMutex.Lock()
+			e.beaconOverride = &enabled
+			e.beaconMutex.Unlock()
+			logger.Infof("Beacons turned %s", strings.ToLower(args[0]))
+
+		case "NOW":
+			msg, err := e.sendBeacon("beacon", e.config.Beacon.Text)
+			if err != nil {
+				return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, err.Error())
+			}
+			if e.beaconEnabled() {
+				e.beaconMutex.Lock()
+				e.nextBeacon = e.nextBeaconTime(time.Now())
+				e.beaconMutex.Unlock()
+			}
+			return protocol.NewSuccessResponse(map[string]interface{}{
+				"status":  "queued",
+				"message": msg,
+			})
+
+		default:
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid,
+				fmt.Sprintf("invalid BEACON setting %q, use ON, OFF or NOW", args[0]))
+		}
+	}
+	return e.beaconStatus(time.Now())
+}
+
+// beaconStatus returns whether beacons go out, when the next is due and
+// what holds it back, and the station ID timer
+func (e *CoreEngine) beaconStatus(now time.Time) *protocol.Response {
+	e.mutex.RLock()
+	cfg := e.config.Beacon
+	e.mutex.RUnlock()
+
+	enabled := e.beaconEnabled()
+	data := map[string]interface{}{
+		"enabled":     enabled,
+		"interval":    cfg.Interval,
+		"text":        cfg.Text,
+		"id_interval": cfg.IDInterval,
+	}
+	if held := e.beaconHeld(now); enabled && held != "" {
+		data["held"] = held
+	}
+
+	e.beaconMutex.Lock()
+	defer e.beaconMutex.Unlock()
+	if enabled && !e.nextBeacon.IsZero() {
+		data["next"] = e.nextBeacon
+	}
+	if !e.lastID.IsZero() {
+		data["last_id"] = e.lastID
+	}
+	if cfg.IDInterval > 0 && !e.unidentified.IsZero() {
+		data["id_due"] = e.unidentified.Add(time.Duration(cfg.IDInterval) * time.Minute)
+	}
+	return protocol.NewSuccessResponse(data)
+}
@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// busyCycle is how far back the channel is checked before keying, one
+// Normal mode cycle
+const busyCycle = 15 * time.Second
+
+// Where tx busy move looks for a clear offset
+const (
+	busyMoveLow  = 300
+	busyMoveHigh = 2700
+)
+
+// heardSignal is a decode remembered for the busy channel check
+type heardSignal struct {
+	from   string
+	offset int
+	at     time.Time
+}
+
+// noteSignal remembers where a decode was heard for the busy channel
+// check, forgetting those more than a cycle old
+func (e *CoreEngine) noteSignal(msg protocol.Message) {
+	now := time.Now()
+
+	e.busyMutex.Lock()
+	defer e.busyMutex.Unlock()
+
+	recent := e.heardSignals[:0]
+	for _, s := range e.heardSignals {
+		if now.Sub(s.at) < busyCycle {
+			recent = append(recent, s)
+		}
+	}
+	e.heardSignals = append(recent, heardSignal{from: msg.From, offset: msg.Offset, at: now})
+}
+
+// configuredTXOffset returns the tx offset setting, the default when unset
+func configuredTXOffset(cfg *config.Config) int {
+	if cfg.TX.Offset == 0 {
+		return int(dsp.DefaultTXOffset)
+	}
+	return cfg.TX.Offset
+}
+
+// txOffset returns the audio offset transmissions go out at
+func (e *CoreEngine) txOffset() int {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.txAudioOffset
+}
+
+// channelBusy returns what was heard on offset, give or take margin Hz,
+// since the given time: a decode there or a signal level dB above the noise
+// floor. It returns "" when the channel is clear.
+func (e *CoreEngine) channelBusy(offset, margin int, level float64, since time.Time) string {
+	low := offset - margin
+	high := offset + int(dsp.SignalBandwidth) + margin
+
+	e.busyMutex.Lock()
+	for _, s := range e.heardSignals {
+		if s.at.Before(since) || strings.EqualFold(s.from, e.config.Station.Callsign) {
+			continue
+		}
+		if s.offset+int(dsp.SignalBandwidth) > low && s.offset < high {
+			e.busyMutex.Unlock()
+			return fmt.Sprintf("%s heard at %d Hz", s.from, s.offset)
+		}
+	}
+	e.busyMutex.Unlock()
+
+	for _, monitor := range e.GetAudioMonitors() {
+		floor, ok := monitor.NoiseFloor(busyCycle)
+		if !ok {
+			continue
+		}
+		peak, ok := monitor.BandPeak(float64(low), float64(high), since)
+		if ok && float64(peak-floor) >= level {
+			return fmt.Sprintf("signal %.0f dB over the noise at %d-%d Hz", peak-floor, low, high)
+		}
+	}
+	return ""
+}
+
+// clearOffset returns the clear offset nearest to offset, stepping a
+// signal's width at a time, or 0 when the whole passband is busy
+func (e *CoreEngine) clearOffset(offset, margin int, level float64, since time.Time) int {
+	step := int(dsp.SignalBandwidth)
+	for distance := step; ; distance += step {
+		tried := false
+		for _, candidate := range []int{offset - distance, offset + distance} {
+			if candidate < busyMoveLow || candidate+step > busyMoveHigh {
+				continue
+			}
+			tried = true
+			if e.channelBusy(candidate, margin, level, since) == "" {
+				return candidate
+			}
+		}
+		if !tried {
+			return 0
+		}
+	}
+}
+
+// clearChannel checks the TX offset before keying and, following tx busy,
+// waits for it to clear or moves to a clear one. Each busy channel is
+// reported with a channel_busy event. It returns an error when the
+// transmission is aborted while waiting.
+func (e *CoreEngine) clearChannel() error {
+	e.mutex.RLock()
+	cfg := e.config.TX
+	lastTX := e.lastTXEnd
+	e.mutex.RUnlock()
+
+	cfg.Busy = strings.ToLower(cfg.Busy)
+	switch cfg.Busy {
+	case config.BusyReport, config.BusyWait, config.BusyMove:
+	default:
+		return nil
+	}
+
+	_, movable := e.dspEngine.(dsp.OffsetEncoder)
+	for waited := 0; ; waited++ {
+		// Our own signal is not someone else's
+		since := time.Now().Add(-busyCycle)
+		if lastTX.After(since) {
+			since = lastTX
+		}
+
+		offset := e.txOffset()
+		reason := e.channelBusy(offset, cfg.BusyMargin, cfg.BusyLevel, since)
+		if reason == "" {
+			return nil
+		}
+
+		action := cfg.Busy
+		event := map[string]interface{}{
+			"offset": offset,
+			"reason": reason,
+		}
+		if action == config.BusyMove {
+			// Without a clear offset, or an encoder that can move, wait
+			action = config.BusyWait
+			if movable {
+				if moved := e.clearOffset(offset, cfg.BusyMargin, cfg.BusyLevel, since); moved != 0 {
+					action = config.BusyMove
+					event["moved_to"] = moved
+					e.mutex.Lock()
+					e.txAudioOffset = moved
+					e.mutex.Unlock()
+				}
+			}
+		}
+		if action == config.BusyWait && waited >= cfg.BusyWait {
+			action = config.BusyReport
+		}
+		event["action"] = action
+		e.publishEvent(protocol.EventChannelBusy, event)
+
+		switch action {
+		case config.BusyMove:
+			logger.Infof("TX offset %d Hz busy (%s), moved to %d Hz", offset, reason, event["moved_to"])
+			return nil
+		case config.BusyReport:
+			logger.Warnf("TX offset %d Hz busy (%s), sending anyway", offset, reason)
+			return nil
+		}
+
+		logger.Infof("TX offset %d Hz busy (%s), waiting a cycle", offset, reason)
+		select {
+		case <-time.After(busyCycle):
+		case <-e.abortTx:
+			return fmt.Errorf("transmission aborted while waiting for a clear channel")
+		case <-e.ctx.Done():
+			return fmt.Errorf("transmission aborted while waiting for a clear channel")
+		}
+	}
+}
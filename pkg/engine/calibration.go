@@ -0,0 +1,33 @@
+package engine
+
+import (
+	"math"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/dsp"
+)
+
+// How far back the drift estimator looks
+const driftWindow = time.Hour
+
+// newDriftEstimator creates the drift estimator when the configuration asks for one
+func newDriftEstimator(cfg *config.Config) *dsp.DriftEstimator {
+	if !cfg.Radio.DriftEstimate {
+		return nil
+	}
+	return dsp.NewDriftEstimator(driftWindow)
+}
+
+// calibrate corrects a frequency read from the radio by the rig's
+// calibration and, with a transverter, moves it from the IF up to the RF.
+// The caller holds e.mutex.
+func (e *CoreEngine) calibrate(freq int64) int64 {
+	return int64(math.Round(dsp.Calibrate(float64(freq), e.config.Radio.CalibrationPPM))) + e.config.Radio.TransverterOffset
+}
+
+// uncalibrate is the frequency to set on the radio so it tunes to freq, the
+// IF when a transverter is on freq. The caller holds e.mutex.
+func (e *CoreEngine) uncalibrate(freq int64) int64 {
+	return int64(math.Round(dsp.Uncalibrate(float64(freq-e.config.Radio.TransverterOffset), e.config.Radio.CalibrationPPM)))
+}
@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/storage"
+	"github.com/dougsko/js8d/pkg/triggers"
+)
+
+// databaseCheckDelay is how long after starting the database is first
+// checked, out of the way of the startup work
+const databaseCheckDelay = 5 * time.Minute
+
+// databaseCheck is the outcome of the latest integrity check and backup of
+// the message database
+type databaseCheck struct {
+	Checked    time.Time  `json:"checked"`
+	OK         bool       `json:"ok"`
+	Problems   []string   `json:"problems,omitempty"` // What integrity_check found, first few only
+	Error      string     `json:"error,omitempty"`    // Why the check or backup failed to run
+	BackupPath string     `json:"backup_path,omitempty"`
+	BackedUp   *time.Time `json:"backed_up,omitempty"` // When the last backup finished
+}
+
+// maxReportedProblems is how many integrity_check findings are kept for
+// the stats and the db_corrupt trigger
+const maxReportedProblems = 10
+
+// databaseLoop checks the message database for corruption and backs it up
+// every storage check_interval hours
+func (e *CoreEngine) databaseLoop() {
+	e.mutex.RLock()
+	interval := time.Duration(e.config.Storage.CheckInterval) * time.Hour
+	e.mutex.RUnlock()
+
+	timer := time.NewTimer(databaseCheckDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			e.checkDatabase(time.Now())
+			timer.Reset(interval)
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkDatabase runs the integrity check and, when the database passes and
+// storage backup_path is set, the backup. A corrupt database fires the
+// db_corrupt triggers and leaves the earlier backups alone.
+func (e *CoreEngine) checkDatabase(now time.Time) {
+	e.mutex.RLock()
+	cfg := e.config.Storage
+	e.mutex.RUnlock()
+
+	e.databaseMutex.Lock()
+	check := databaseCheck{Checked: now, BackupPath: cfg.BackupPath}
+	if e.lastDatabaseCheck != nil {
+		check.BackedUp = e.lastDatabaseCheck.BackedUp
+	}
+	e.databaseMutex.Unlock()
+
+	defer func() {
+		e.databaseMutex.Lock()
+		e.lastDatabaseCheck = &check
+		e.databaseMutex.Unlock()
+	}()
+
+	problems, err := e.messageStore.IntegrityCheck()
+	if err != nil {
+		check.Error = err.Error()
+		logger.Errorf("Database integrity check failed to run: %v", err)
+		return
+	}
+	if len(problems) > 0 {
+		logger.Errorf("Database %s is corrupt, %d problems found: %s", cfg.DatabasePath, len(problems), problems[0])
+		if len(problems) > maxReportedProblems {
+			problems = problems[:maxReportedProblems]
+		}
+		check.Problems = problems
+		e.trigger(triggers.Event{
+			Type:  triggers.DBCorrupt,
+			Time:  now,
+			Error: strings.Join(problems, "; "),
+		})
+		return
+	}
+	check.OK = true
+	logger.Debugf("Database integrity check passed")
+
+	if cfg.BackupPath == "" {
+		return
+	}
+	start := time.Now()
+	if err := e.messageStore.Backup(cfg.BackupPath, cfg.BackupKeep); err != nil {
+		check.Error = err.Error()
+		logger.Errorf("Database backup failed: %v", err)
+		return
+	}
+	finished := time.Now()
+	check.BackedUp = &finished
+	logger.Infof("Database backed up to %s in %v", cfg.BackupPath, time.Since(start).Round(time.Millisecond))
+}
+
+// databaseStatus returns the latest integrity check and backup for the
+// message stats, nil before the first or when they are off
+func (e *CoreEngine) databaseStatus() *databaseCheck {
+	e.databaseMutex.Lock()
+	defer e.databaseMutex.Unlock()
+	return e.lastDatabaseCheck
+}
+
+// databaseFile reports whether storage keeps the messages in a SQLite file,
+// which is checked, backed up and restored by js8d itself
+func databaseFile(cfg *config.Config) bool {
+	return cfg.Storage.Backend == "" || strings.EqualFold(cfg.Storage.Backend, storage.BackendSQLite)
+}
+
+// openMessageStore opens the storage backend storage configures
+func openMessageStore(cfg *config.Config) (storage.StorageBackend, error) {
+	var store *storage.MessageStore
+	var err error
+	switch strings.ToLower(cfg.Storage.Backend) {
+	case storage.BackendPostgres:
+		store, err = storage.NewPostgresStore(cfg.Storage.DSN.Value(), cfg.Storage.MaxMessages)
+	case storage.BackendMemory:
+		store, err = storage.NewMemoryStore(cfg.Storage.MaxMessages)
+	default:
+		store, err = storage.NewMessageStore(cfg.Storage.DatabasePath, cfg.Storage.MaxMessages)
+	}
+	// A nil *MessageStore must not become a non-nil StorageBackend
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
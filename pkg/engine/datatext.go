@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+const (
+	// dataTextTimeout is how long the rest of a text message sent as data
+	// frames is waited for after its last frame heard
+	dataTextTimeout = 2 * time.Minute
+	// dataTextOffsetMatch is how far apart in Hz two frames may be heard
+	// and still be from the same station
+	dataTextOffsetMatch = 10
+)
+
+// dataText is a text message being received as data frames
+type dataText struct {
+	offset int
+	text   string
+	heard  time.Time
+}
+
+// txFrame is one transmission of a queued message
+type txFrame struct {
+	text         string
+	transmission dsp.TransmissionType
+}
+
+// dataTextFrames packs a message to be sent whole as Huffman coded data
+// frames. The text names the stations the way a decode is read, the station
+// called and then the caller. It fails for text that fits one frame or has
+// characters the Huffman table lacks, which goes out in one frame as usual.
+func dataTextFrames(msg protocol.Message) ([]string, error) {
+	if len(msg.Message) <= 12 {
+		return nil, fmt.Errorf("%q fits one frame", msg.Message)
+	}
+	text := msg.From + " " + msg.Message
+	if msg.To != "" {
+		text = msg.To + " " + text
+	}
+	return dsp.PackDataMessageFrames(text)
+}
+
+// txFrames returns the frames a queued message goes out in: the data frames
+// of a message sent whole, the first and last marked in their transmission
+// type, or else one frame cut to 12 characters
+func txFrames(msg protocol.Message) []txFrame {
+	if msg.DataFrames {
+		packed, err := dataTextFrames(msg)
+		if err == nil {
+			frames := make([]txFrame, len(packed))
+			for i, text := range packed {
+				frames[i].text = text
+				if i == 0 {
+					frames[i].transmission |= dsp.JS8CallFirst
+				}
+				if i == len(packed)-1 {
+					frames[i].transmission |= dsp.JS8CallLast
+				}
+			}
+			return frames
+		}
+		logger.Warnf("TX in one frame: %v", err)
+	}
+
+	text := msg.Message
+	if len(text) > 12 {
+		text = text[:12]
+	}
+	return []txFrame{{text: text, transmission: dsp.TransmissionType(msg.Transmission)}}
+}
+
+// receiveDataText puts together a text message sent as data frames. It
+// returns the message to go on with and whether to go on: a frame that is
+// not a data frame as it is, the whole message once its last frame is in,
+// and false for the frames before that. A data frame with no first frame
+// heard before it at its offset goes on by itself with its text, as from a
+// decoder that doesn't report the transmission type.
+func (e *CoreEngine) receiveDataText(msg protocol.Message) (protocol.Message, bool) {
+	text, err := dsp.UnpackDataMessage(msg.Message)
+	if err != nil {
+		return msg, true
+	}
+	transmission := dsp.TransmissionType(msg.Transmission)
+
+	e.dataTextMutex.Lock()
+	defer e.dataTextMutex.Unlock()
+
+	var partial *dataText
+	kept := e.dataTexts[:0]
+	for _, d := range e.dataTexts {
+		if msg.Timestamp.Sub(d.heard) > dataTextTimeout {
+			continue
+		}
+		if diff := msg.Offset - d.offset; diff >= -dataTextOffsetMatch && diff <= dataTextOffsetMatch {
+			partial = d
+			if transmission&dsp.JS8CallFirst != 0 {
+				continue
+			}
+		}
+		kept = append(kept, d)
+	}
+	e.dataTexts = kept
+
+	if transmission&dsp.JS8CallFirst != 0 {
+		partial = &dataText{offset: msg.Offset}
+		e.dataTexts = append(e.dataTexts, partial)
+	} else if partial == nil {
+		msg.Message = text
+		return msg, true
+	}
+	partial.text += text
+	partial.heard = msg.Timestamp
+	logger.Debugf("RX: data frame %q at %d Hz (SNR: %.1fdB)", text, msg.Offset, msg.SNR)
+	if transmission&dsp.JS8CallLast == 0 {
+		return msg, false
+	}
+
+	for i, d := range e.dataTexts {
+		if d == partial {
+			e.dataTexts = append(e.dataTexts[:i], e.dataTexts[i+1:]...)
+			break
+		}
+	}
+	whole := e.parseJS8Message(&dsp.DecodeResult{Message: partial.text, SNR: int(msg.SNR)})
+	whole.Timestamp = msg.Timestamp
+	whole.SNR = msg.SNR
+	whole.Submode = msg.Submode
+	whole.Offset = msg.Offset
+	whole.Dial = msg.Dial
+	whole.Frequency = msg.Frequency
+	whole.Band = msg.Band
+	whole.Channel = msg.Channel
+	whole.NoiseFloor = msg.NoiseFloor
+	return whole, true
+}
@@ -0,0 +1,294 @@
+package engine
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// pendingAck is a directed message that has been sent and waits for the
+// station it went to to acknowledge it
+type pendingAck struct {
+	msg       protocol.Message
+	deadline  time.Time // Zero while a resend waits in the TX queue
+	sent      time.Time // When it last went out
+	sends     int
+	delivered bool   // Acknowledged while a resend was queued
+	submode   string // Submode of the first send, which resends escalate from
+	shift     int    // Hz the resend goes out from the TX offset
+}
+
+// tracksDelivery reports whether a message to this destination waits for an
+// ACK: it is sent to one station and tracking is enabled
+func (e *CoreEngine) tracksDelivery(to string) bool {
+	return e.config.Messages.AckTimeout > 0 && to != "" && !strings.HasPrefix(to, "@")
+}
+
+// awaitAck starts the ACK timer of a directed message that has just been sent
+func (e *CoreEngine) awaitAck(msg protocol.Message) {
+	e.ackMutex.Lock()
+	defer e.ackMutex.Unlock()
+
+	pending := e.acks[msg.ID]
+	if pending == nil {
+		pending = &pendingAck{msg: msg, submode: msg.Submode}
+		e.acks[msg.ID] = pending
+	}
+	pending.sends++
+	pending.sent = time.Now()
+	pending.deadline = pending.sent.Add(time.Duration(e.config.Messages.AckTimeout) * time.Second)
+}
+
+// ackedBeforeResend reports whether a queued resend is no longer needed
+// because the ACK arrived while it waited
+func (e *CoreEngine) ackedBeforeResend(msg protocol.Message) bool {
+	e.ackMutex.Lock()
+	defer e.ackMutex.Unlock()
+
+	pending := e.acks[msg.ID]
+	if pending == nil || !pending.delivered {
+		return false
+	}
+	delete(e.acks, msg.ID)
+	return true
+}
+
+// abandonAck gives up on a directed message that failed to transmit
+func (e *CoreEngine) abandonAck(msg protocol.Message) {
+	if msg.Delivery != protocol.DeliveryAwaiting {
+		return
+	}
+
+	e.ackMutex.Lock()
+	delete(e.acks, msg.ID)
+	e.ackMutex.Unlock()
+
+	e.setDelivery(msg, protocol.DeliveryUndelivered)
+}
+
+// checkAck marks the message waiting on the sender of a received ACK as
+// delivered. An ACK doesn't say which message it answers, so it is taken for
+// the one last sent to that station; the others wait for ACKs of their own.
+func (e *CoreEngine) checkAck(msg protocol.Message) {
+	if !dsp.IsAcknowledgement(msg.Message) {
+		return
+	}
+	if !strings.EqualFold(msg.To, e.config.Station.Callsign) && !e.isDirectedToMe(msg.Message) {
+		return
+	}
+
+	e.ackMutex.Lock()
+	var latest *pendingAck
+	for _, pending := range e.acks {
+		if pending.delivered || !strings.EqualFold(pending.msg.To, msg.From) {
+			continue
+		}
+		if latest == nil || pending.sent.After(latest.sent) || (pending.sent.Equal(latest.sent) && pending.msg.ID > latest.msg.ID) {
+			latest = pending
+		}
+	}
+	if latest == nil {
+		e.ackMutex.Unlock()
+		return
+	}
+	// A resend already queued is skipped when it comes up
+	if latest.deadline.IsZero() {
+		latest.delivered = true
+	} else {
+		delete(e.acks, latest.msg.ID)
+	}
+	sent := latest.msg
+	e.ackMutex.Unlock()
+
+	logger.Infof("%s acknowledged: %s", msg.From, sent.Message)
+	e.setDelivery(sent, protocol.DeliveryDelivered)
+}
+
+// ackMonitor resends or gives up on messages whose ACK is overdue
+func (e *CoreEngine) ackMonitor() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			e.checkAckTimeouts(now)
+
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkAckTimeouts queues a resend of each message whose ACK is overdue,
+// until ack_retries resends have gone unanswered
+func (e *CoreEngine) checkAckTimeouts(now time.Time) {
+	e.mutex.RLock()
+	cfg := e.config.Messages
+	e.mutex.RUnlock()
+	offset := e.txOffset()
+
+	var resend, undelivered []protocol.Message
+	e.ackMutex.Lock()
+	for id, pending := range e.acks {
+		if pending.deadline.IsZero() || now.Before(pending.deadline) {
+			continue
+		}
+		if pending.sends > cfg.AckRetries {
+			delete(e.acks, id)
+			undelivered = append(undelivered, pending.msg)
+			continue
+		}
+		pending.deadline = time.Time{}
+		pending.msg.Resends++
+		e.escalateResend(pending, cfg.Escalate, cfg.EscalateAfter, cfg.EscalateOffset, offset)
+		resend = append(resend, pending.msg)
+	}
+	e.ackMutex.Unlock()
+
+	for _, msg := range undelivered {
+		logger.Warnf("No ACK from %s, undelivered: %s", msg.To, msg.Message)
+		e.setDelivery(msg, protocol.DeliveryUndelivered)
+	}
+
+	for _, msg := range resend {
+		logger.Infof("No ACK from %s, resend %d in %s at %d Hz: %s", msg.To, msg.Resends, msg.Submode, msg.Offset, msg.Message)
+		e.setResend(msg)
+		e.setTXStatus(msg, protocol.MessageQueued)
+		select {
+		case e.txMessages <- msg:
+		default:
+			logger.Warnf("TX queue full, not resending to %s", msg.To)
+			e.setTXStatus(msg, protocol.MessageSent)
+			e.abandonAck(msg)
+		}
+	}
+}
+
+// escalateResend changes a resend as messages escalate sets, once
+// escalate_after sends have gone unanswered: a submode slower than the
+// first send, and escalate_offset Hz further from the TX offset, above and
+// below in turn, for each unanswered send since. The offset stays put for
+// an encoder that can't move. Called with ackMutex held.
+func (e *CoreEngine) escalateResend(pending *pendingAck, policy string, after, step, offset int) {
+	level := pending.sends - after + 1
+	if policy == "" || policy == config.EscalateOff || after < 1 || level < 1 {
+		return
+	}
+
+	if policy == config.EscalateSubmode || policy == config.EscalateBoth {
+		// A first send in none of them counts as Normal
+		first := -1
+		for i, submode := range config.Submodes {
+			if strings.EqualFold(submode, pending.submode) || (first < 0 && submode == dsp.ModeNormal.String()) {
+				first = i
+			}
+		}
+		slower := first + level
+		if slower >= len(config.Submodes) {
+			slower = len(config.Submodes) - 1
+		}
+		pending.msg.Submode = config.Submodes[slower]
+	}
+
+	if _, movable := e.dspEngine.(dsp.OffsetEncoder); movable && (policy == config.EscalateOffset || policy == config.EscalateBoth) {
+		shift := step * ((level + 1) / 2)
+		if level%2 == 0 {
+			shift = -shift
+		}
+		// Off the edge of the passband, try the other side
+		inPassband := func(shift int) bool {
+			return offset+shift >= busyMoveLow && offset+shift+int(dsp.SignalBandwidth) <= busyMoveHigh
+		}
+		if !inPassband(shift) {
+			shift = -shift
+		}
+		if !inPassband(shift) {
+			shift = 0
+		}
+		pending.shift = shift
+	}
+
+	pending.msg.Offset = offset + pending.shift
+	if pending.msg.Dial != 0 {
+		pending.msg.Frequency = pending.msg.Dial + pending.msg.Offset
+	}
+}
+
+// resendShift returns how far from the TX offset a message goes out, moved
+// by an escalated resend
+func (e *CoreEngine) resendShift(id int) int {
+	e.ackMutex.Lock()
+	defer e.ackMutex.Unlock()
+	if pending := e.acks[id]; pending != nil {
+		return pending.shift
+	}
+	return 0
+}
+
+// setResend records a resend's count, submode and offset in the message
+// store and tells event subscribers
+func (e *CoreEngine) setResend(msg protocol.Message) {
+	e.msgMutex.Lock()
+	if e.messageStore == nil {
+		e.msgMutex.Unlock()
+		return
+	}
+	err := e.messageStore.SetMessageResend(msg.ID, msg.Resends, msg.Submode, msg.Offset, msg.Frequency)
+	e.msgMutex.Unlock()
+	if err != nil {
+		logger.Warnf("Failed to record resend %d of TX message %d: %v", msg.Resends, msg.ID, err)
+		return
+	}
+
+	e.publishEvent(protocol.EventMessageStatus, map[string]interface{}{
+		"id":        msg.ID,
+		"delivery":  msg.Delivery,
+		"resends":   msg.Resends,
+		"submode":   msg.Submode,
+		"offset":    msg.Offset,
+		"frequency": msg.Frequency,
+	})
+}
+
+// setDelivery records a directed message's delivery state in the message
+// store and tells event subscribers
+func (e *CoreEngine) setDelivery(msg protocol.Message, delivery string) {
+	e.msgMutex.Lock()
+	if e.messageStore == nil {
+		e.msgMutex.Unlock()
+		return
+	}
+	err := e.messageStore.SetMessageDelivery(msg.ID, delivery)
+	e.msgMutex.Unlock()
+	if err != nil {
+		logger.Warnf("Failed to mark TX message %d %s: %v", msg.ID, delivery, err)
+		return
+	}
+
+	e.publishEvent(protocol.EventMessageStatus, map[string]interface{}{
+		"id":       msg.ID,
+		"delivery": delivery,
+	})
+}
+
+// expireDeliveries gives up on ACKs a previous run was waiting for
+func (e *CoreEngine) expireDeliveries() {
+	e.msgMutex.Lock()
+	defer e.msgMutex.Unlock()
+	if e.messageStore == nil {
+		return
+	}
+
+	expired, err := e.messageStore.ExpireDeliveries()
+	if err != nil {
+		logger.Warnf("Failed to expire deliveries: %v", err)
+		return
+	}
+	if expired > 0 {
+		logger.Infof("%d messages from the last run never acknowledged, marked undelivered", expired)
+	}
+}
@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// duplexState is which way a half-duplex sound card is switched
+type duplexState int
+
+const (
+	duplexRX         duplexState = iota // Capturing
+	duplexTX                            // Playing, capture stopped
+	duplexRecovering                    // Capture would not restart after playing
+)
+
+func (s duplexState) String() string {
+	switch s {
+	case duplexTX:
+		return "tx"
+	case duplexRecovering:
+		return "recovering"
+	}
+	return "rx"
+}
+
+// duplexAudio starts and stops the two directions of the sound card, as
+// the hardware manager does
+type duplexAudio interface {
+	StartAudioInput() error
+	StopAudioInput() error
+	StartAudioOutput() error
+	StopAudioOutput() error
+}
+
+// halfDuplex arbitrates a sound card that can't capture and play at once.
+// Capture is stopped for each transmission and started again after it.
+// Starting either direction is retried, as the card can stay busy for a
+// moment after the other is released.
+type halfDuplex struct {
+	audio      duplexAudio
+	state      duplexState
+	attempts   int
+	retryDelay time.Duration
+	mutex      sync.Mutex
+}
+
+// newHalfDuplex arbitrates audio, which is capturing
+func newHalfDuplex(audio duplexAudio) *halfDuplex {
+	return &halfDuplex{
+		audio:      audio,
+		attempts:   3,
+		retryDelay: 200 * time.Millisecond,
+	}
+}
+
+// State returns which way the card is switched
+func (d *halfDuplex) State() duplexState {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.state
+}
+
+// toTX stops capture and starts playback. When playback won't start,
+// capture is started again and the error returned.
+func (d *halfDuplex) toTX() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	switch d.state {
+	case duplexTX:
+		return fmt.Errorf("audio output already started")
+	case duplexRX:
+		if err := d.audio.StopAudioInput(); err != nil {
+			return fmt.Errorf("failed to stop audio input: %w", err)
+		}
+	}
+
+	if err := d.retry(d.audio.StartAudioOutput); err != nil {
+		if restartErr := d.startInput(); restartErr != nil {
+			logger.Errorf("Half-duplex audio: %v", restartErr)
+		}
+		return fmt.Errorf("failed to start audio output: %w", err)
+	}
+	d.state = duplexTX
+	return nil
+}
+
+// toRX stops playback and starts capture again. A card left recovering is
+// tried again on the next call.
+func (d *halfDuplex) toRX() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	switch d.state {
+	case duplexRX:
+		return nil
+	case duplexTX:
+		if err := d.audio.StopAudioOutput(); err != nil {
+			logger.Warnf("Half-duplex audio: failed to stop audio output: %v", err)
+		}
+	}
+	return d.startInput()
+}
+
+// startInput starts capture, leaving the card recovering when it won't
+func (d *halfDuplex) startInput() error {
+	if err := d.retry(d.audio.StartAudioInput); err != nil {
+		d.state = duplexRecovering
+		return fmt.Errorf("failed to restart audio input: %w", err)
+	}
+	d.state = duplexRX
+	return nil
+}
+
+// retry calls start until it succeeds or the attempts run out
+func (d *halfDuplex) retry(start func() error) error {
+	var err error
+	for attempt := 0; attempt < d.attempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(d.retryDelay)
+		}
+		if err = start(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// audioToTX switches a half-duplex sound card to playback for a
+// transmission, returning the switch back to capture for when the
+// transmission is over. A full-duplex card is left as it is.
+func (e *CoreEngine) audioToTX() (toRX func(), err error) {
+	e.mutex.RLock()
+	duplex := e.duplex
+	e.mutex.RUnlock()
+
+	if duplex == nil {
+		return func() {}, nil
+	}
+	if err := duplex.toTX(); err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := duplex.toRX(); err != nil {
+			logger.Errorf("Half-duplex audio: %v, RX stopped until the next transmission", err)
+		}
+	}, nil
+}
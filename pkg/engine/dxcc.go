@@ -0,0 +1,199 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/dxcc"
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/storage"
+)
+
+// dxccTimeout bounds one download of the country file
+const dxccTimeout = 30 * time.Second
+
+// dxccPath returns where the country file is kept
+func (e *CoreEngine) dxccPath() string {
+	if e.config.DXCC.File != "" {
+		return e.config.DXCC.File
+	}
+	return filepath.Join(filepath.Dir(e.config.Storage.DatabasePath), "cty.dat")
+}
+
+// loadDXCC loads the country file, if there is one, and tags the station
+// database with it. Without one stations go untagged until it is refreshed.
+func (e *CoreEngine) loadDXCC() {
+	path := e.dxccPath()
+	db, err := dxcc.Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		logger.Infof("No country file at %s; refresh it to tag stations with their DXCC entity", path)
+		return
+	}
+	if err != nil {
+		logger.Warnf("Failed to load country file %s: %v", path, err)
+		return
+	}
+	e.setDXCC(db)
+	logger.Infof("Loaded %d DXCC entities from %s", db.Entities(), path)
+}
+
+// setDXCC switches to a country database and retags the stations with it
+func (e *CoreEngine) setDXCC(db *dxcc.Database) {
+	e.dxccMutex.Lock()
+	e.dxcc = db
+	e.dxccMutex.Unlock()
+
+	e.msgMutex.Lock()
+	defer e.msgMutex.Unlock()
+	if e.messageStore == nil {
+		return
+	}
+	changed, err := e.messageStore.RetagEntities(func(callsign string) string {
+		if entity := db.Lookup(callsign); entity != nil {
+			return entity.Prefix
+		}
+		return ""
+	})
+	if err != nil {
+		logger.Warnf("Failed to tag stations with their DXCC entity: %v", err)
+		return
+	}
+	if changed > 0 {
+		logger.Infof("Tagged %d stations with their DXCC entity", changed)
+	}
+}
+
+// lookupEntity returns the DXCC entity a callsign is in, or nil when it
+// is not known or no country file is loaded
+func (e *CoreEngine) lookupEntity(callsign string) *dxcc.Entity {
+	e.dxccMutex.RLock()
+	defer e.dxccMutex.RUnlock()
+	if e.dxcc == nil || callsign == "" || strings.HasPrefix(callsign, "@") {
+		return nil
+	}
+	return e.dxcc.Lookup(callsign)
+}
+
+// entityOf returns the DXCC entity of a callsign as messages and stations
+// carry it
+func (e *CoreEngine) entityOf(callsign string) *protocol.Entity {
+	entity := e.lookupEntity(callsign)
+	if entity == nil {
+		return nil
+	}
+	return &protocol.Entity{
+		Name:      entity.Name,
+		Prefix:    entity.Prefix,
+		Continent: entity.Continent,
+		CQZone:    entity.CQZone,
+		ITUZone:   entity.ITUZone,
+	}
+}
+
+// recordEntity tags a heard station with its DXCC entity and tells event
+// subscribers when no station was heard from it before. The caller holds
+// msgMutex.
+func (e *CoreEngine) recordEntity(callsign string) {
+	entity := e.entityOf(callsign)
+	if entity == nil {
+		return
+	}
+	isNew, err := e.messageStore.RecordEntity(callsign, entity.Prefix)
+	if err != nil {
+		logger.Warnf("Failed to record the DXCC entity of %s: %v", callsign, err)
+		return
+	}
+	if isNew {
+		logger.Infof("New DXCC entity: %s (%s) heard from %s", entity.Name, entity.Prefix, strings.ToUpper(callsign))
+		e.publishEvent(protocol.EventNewEntity, map[string]interface{}{
+			"callsign": strings.ToUpper(callsign),
+			"entity":   entity,
+		})
+	}
+}
+
+// addEntityNames fills in the names and continents of the entities in an
+// activity summary
+func (e *CoreEngine) addEntityNames(entities []storage.EntityActivity) {
+	e.dxccMutex.RLock()
+	defer e.dxccMutex.RUnlock()
+	if e.dxcc == nil {
+		return
+	}
+	for i := range entities {
+		if entity := e.dxcc.Entity(entities[i].Prefix); entity != nil {
+			entities[i].Name = entity.Name
+			entities[i].Continent = entity.Continent
+		}
+	}
+}
+
+// handleDXCC reports the country file, looks a callsign up with DXCC
+// LOOKUP <callsign>, or downloads the current file with DXCC REFRESH
+func (e *CoreEngine) handleDXCC(args []string) *protocol.Response {
+	if len(args) > 0 {
+		switch strings.ToUpper(args[0]) {
+		case "LOOKUP":
+			if len(args) < 2 {
+				return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "callsign required")
+			}
+			callsign := strings.ToUpper(args[1])
+			return protocol.NewSuccessResponse(map[string]interface{}{
+				"callsign": callsign,
+				"entity":   e.lookupEntity(callsign),
+			})
+
+		case "REFRESH":
+			if err := e.refreshDXCC(); err != nil {
+				return protocol.NewErrorResponse(fmt.Sprintf("failed to refresh the country file: %v", err))
+			}
+
+		default:
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid,
+				fmt.Sprintf("invalid DXCC action %q, use LOOKUP or REFRESH", args[0]))
+		}
+	}
+
+	path := e.dxccPath()
+	data := map[string]interface{}{
+		"file":     path,
+		"loaded":   false,
+		"entities": 0,
+	}
+	e.dxccMutex.RLock()
+	if e.dxcc != nil {
+		data["loaded"] = true
+		data["entities"] = e.dxcc.Entities()
+	}
+	e.dxccMutex.RUnlock()
+	if info, err := os.Stat(path); err == nil {
+		data["updated"] = info.ModTime()
+	}
+	return protocol.NewSuccessResponse(data)
+}
+
+// refreshDXCC downloads the country file, replacing the one on disk, and
+// switches to it
+func (e *CoreEngine) refreshDXCC() error {
+	url := e.config.DXCC.URL
+	if url == "" {
+		url = dxcc.DefaultURL
+	}
+
+	ctx, cancel := context.WithTimeout(e.ctx, dxccTimeout)
+	defer cancel()
+	db, err := dxcc.Fetch(ctx, &http.Client{Timeout: dxccTimeout}, url, e.dxccPath())
+	if err != nil {
+		return err
+	}
+
+	e.setDXCC(db)
+	logger.Infof("Refreshed the country file from %s: %d DXCC entities", url, db.Entities())
+	return nil
+}
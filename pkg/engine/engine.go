@@ -2,8 +2,11 @@ package engine
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"math"
 	"net"
 	"os"
 	"strconv"
@@ -11,56 +14,398 @@ import (
 	"sync"
 	"time"
 
+	"google.golang.org/grpc"
+
 	"github.com/dougsko/js8d/pkg/audio"
+	"github.com/dougsko/js8d/pkg/callbook"
 	"github.com/dougsko/js8d/pkg/config"
 	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/dxcc"
+	"github.com/dougsko/js8d/pkg/fft"
+	"github.com/dougsko/js8d/pkg/forms"
+	"github.com/dougsko/js8d/pkg/gpsd"
 	"github.com/dougsko/js8d/pkg/hardware"
+	"github.com/dougsko/js8d/pkg/logging"
+	"github.com/dougsko/js8d/pkg/propagation"
 	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/release"
 	"github.com/dougsko/js8d/pkg/storage"
+	"github.com/dougsko/js8d/pkg/timesync"
+	"github.com/dougsko/js8d/pkg/triggers"
+	"github.com/dougsko/js8d/pkg/udplog"
+)
+
+// Loggers for the engine and for encoding and decoding
+var (
+	logger    = logging.Component("engine")
+	dspLogger = logging.Component("dsp")
 )
 
 // CoreEngine represents the main JS8 processing engine
 type CoreEngine struct {
 	config     *config.Config
+	baseConfig *config.Config // Settings before the active profile, for switching profiles
 	configPath string
 	socketPath string
 	listener   net.Listener
+	grpcServer *grpc.Server // Nil unless api.grpc_address is set
+	adminToken string       // Session token granting admin, for the web server
+	supervisor Supervisor   // Restarts goroutines that panic, nil runs them bare
 	running    bool
 	mutex      sync.RWMutex
 	startTime  time.Time
 
 	// DSP and hardware components
 	dspEngine       dsp.DSPEngine
+	rxDecoders      []dsp.DSPEngine  // One per RX channel, the first shares dspEngine
+	preFilters      []*dsp.PreFilter // One per RX channel, filters keep per-channel state
+	rxChannels      []string         // RX channel labels, a single "" for mono input
 	hardwareManager *hardware.HardwareManager
-	audioMonitor    *audio.AudioLevelMonitor
+	audioMonitors   []*audio.AudioLevelMonitor // One per RX channel, each with its own alarms
+	pcmStream       *audio.PCMStream           // First RX channel for remote listeners
+	decodeGovernor  *dsp.DecodeGovernor        // Nil unless adaptive decoding is enabled
+	driftEstimator  *dsp.DriftEstimator        // Nil unless drift estimation is enabled
+	formAssembler   *forms.Assembler           // Puts received form and telemetry frames back together
+	dataTexts       []*dataText                // Text messages being received as data frames
+	dataTextMutex   sync.Mutex
 
 	// Message storage
-	messageStore *storage.MessageStore
+	messageStore storage.StorageBackend
 	msgMutex     sync.RWMutex
+	snippets     *audio.Snippets // Nil unless storage snippet_dir is set
+	recorder     *audio.Recorder // Nil unless storage recording_dir is set
+	redecode     *redecodeRun    // Last REDECODE run, nil before the first
+
+	// Decode filters, kept in the message store and loaded at start
+	filters     []storage.Filter
+	filterHits  map[int64]int // Decodes each filter dropped since starting
+	filtered    int           // Decodes dropped by every filter together
+	filterMutex sync.RWMutex
+
+	// Block and allow lists of automatic replies, from the message store
+	autoList      []storage.AutoListEntry
+	autoListHeld  map[string]int // Automatic replies held back by kind since starting
+	autoListMutex sync.RWMutex
 
 	// Radio state
 	frequency        int
+	txAudioOffset    int       // Audio offset transmissions go out at, moved by tx busy move
+	lastTXEnd        time.Time // When our last transmission ended, kept out of the busy check
+	passbandLow      int       // Audio offsets decoded, narrowed with PASSBAND
+	passbandHigh     int
 	ptt              bool
 	connected        bool
 	fullyInitialized bool // Prevents transmissions during startup
+	autoReply        bool // Answer queries like SNR? directed to us
 
 	// Channels for message processing
 	rxMessages chan protocol.Message
 	txMessages chan protocol.Message
+	rxAudio    chan rxBlock // Pre-filtered audio handed from the sample reader to the decoder
+	pipeline   pipelineHealth
+
+	// Audio goroutines, stopped while the audio devices are reconfigured
+	audioStop chan struct{}
+	audioWG   sync.WaitGroup
+
+	// Switches a half-duplex sound card between capture and playback, nil
+	// when the card does both at once
+	duplex *halfDuplex
+
+	// Long-lived goroutines, cancelled and waited for by Shutdown
+	ctx    context.Context
+	cancel context.CancelFunc
+	loops  sync.WaitGroup
 
 	// Transmission control
 	abortTx      chan bool
 	transmitting bool
+	tuneActive   bool
 	txMutex      sync.RWMutex
+
+	// Power levels kept per band, by kind and band
+	powerLevels map[string]int
+	powerMutex  sync.Mutex
+
+	// Antenna tuner results per band, and a lock held while tuning
+	tuneResults map[string]tuneResult
+	tuneMutex   sync.Mutex
+	tuning      sync.Mutex
+
+	// Antenna switch, nil without one, its ports and default port from the
+	// configuration at start, the port selected and whether the operator
+	// picked it
+	antenna        hardware.AntennaSwitch
+	antennaPorts   []config.AntennaPort
+	antennaDefault string
+	antennaPort    string
+	antennaManual  bool
+	antennaError   string
+	antennaMutex   sync.Mutex
+
+	// RX level alarm events, dispatched off the audio path
+	alarmEvents      chan audio.AudioAlarm
+	alarmSubscribers map[chan audio.AudioAlarm]struct{}
+	alarmMutex       sync.Mutex
+
+	// Decoded messages for streaming API clients
+	decodeSubscribers map[chan protocol.Message]struct{}
+	decodeMutex       sync.Mutex
+
+	// Message store changes for event stream clients
+	eventSubscribers map[chan protocol.Event]struct{}
+	eventMutex       sync.Mutex
+
+	// Scripted QSO with a station answering our CQ, nil when none is running
+	qsoScripts bool // Run scripts for CQ answers
+	qso        *qsoScript
+	lastCQ     time.Time // When our last CQ went out, zero once answered
+	qsoMutex   sync.Mutex
+
+	// Net run from this station as net control, nil when none is open
+	net        *netSession
+	netChecked time.Time // When the net schedule was last checked
+	netMutex   sync.Mutex
+
+	// Beacons and the station ID timer
+	beaconOverride *bool     // Set by BEACON ON or OFF, nil to follow beacon enabled
+	nextBeacon     time.Time // When the next beacon is due, zero while beacons are off
+	lastTraffic    time.Time // Last directed traffic with another station, which holds beacons back
+	lastID         time.Time // When our callsign last went out
+	unidentified   time.Time // First transmission without our callsign since then, zero when none
+	beaconMutex    sync.Mutex
+
+	// Telemetry sent on a schedule
+	nextTelemetry  time.Time // When the next telemetry is due, zero while telemetry is off
+	lastTelemetry  time.Time // When telemetry was last queued
+	telemetryMutex sync.Mutex
+
+	// Country file for tagging stations with their DXCC entity, nil until
+	// one is loaded
+	dxcc      *dxcc.Database
+	dxccMutex sync.RWMutex
+
+	// CQs answered in the last hour, for the answer.per_hour limit
+	answered    []answeredCQ
+	answerMutex sync.Mutex
+
+	// Token buckets limiting the automatic replies other stations set off
+	rateLimiter rateLimiter
+
+	// Decodes of the last cycle, for the busy channel check before keying
+	heardSignals []heardSignal
+	busyMutex    sync.Mutex
+
+	// Recent transmissions and the decodes of them set aside, for tx
+	// self_decode
+	txWindows   []txWindow
+	selfDecodes int
+	selfMutex   sync.Mutex
+
+	// Held while QSOs are uploaded for confirmation, so they go up once
+	qslMutex sync.Mutex
+
+	// Directed messages awaiting an ACK, by message ID
+	acks     map[int]*pendingAck
+	ackMutex sync.Mutex
+
+	// Heard stations waiting for a callbook lookup, nil client when disabled
+	callbook callbook.Client
+	lookups  chan string
+
+	// Trigger webhooks for station events, nil when there are none
+	triggers *triggers.Dispatcher
+
+	// Logging programs logged QSOs are sent to, nil when there are none
+	logBroadcast *udplog.Broadcaster
+
+	// Restarts the daemon and reboots the host, nil when not available
+	lifecycle Lifecycle
+
+	// js8d version, and where the latest release is looked up
+	version    string
+	releaseURL string
+
+	// Latest solar indices, nil until the first fetch succeeds
+	propagation      *propagation.Conditions
+	propagationMutex sync.RWMutex
+
+	// Last clock check, nil until one succeeds, and why the latest failed
+	clock      *timesync.Result
+	clockError error
+	clockMutex sync.RWMutex
+
+	// Latest gpsd report, and when the grid was last announced
+	gpsFix        *gpsd.Fix
+	gpsConnected  bool
+	gridAnnounced time.Time
+	gpsMutex      sync.RWMutex
+
+	// Battery and temperature readings over the last day, why the latest
+	// sample failed, and whether the battery is too low to transmit
+	sensorHistory []protocol.SensorReading
+	sensorError   string
+	lowBattery    bool
+	sensorMutex   sync.RWMutex
+
+	// The latest integrity check and backup of the message database
+	lastDatabaseCheck *databaseCheck
+	databaseMutex     sync.Mutex
+
+	// Low power mode: whether it is on, and POWER LOW or NORMAL holding a
+	// mode through the schedule, "" to follow it
+	lowPower      bool
+	powerOverride string
+	lowPowerMutex sync.Mutex
+
+	// Files being sent by transfer id, and being received by transfer id
+	// with the ones received kept a while to answer repeated polls
+	outgoing  map[uint8]*outgoingFile
+	incoming  map[uint8]*incomingFile
+	fileMutex sync.Mutex
+
+	// Sessions open by session frame id, and the last ones closed
+	sessions       map[uint8]*arqSession
+	closedSessions []*arqSession
+	lastSessionID  int
+	sessionMutex   sync.Mutex
+
+	// OLED state - active alarms take over the message line until cleared
+	oledMessage string
+	oledAlarms  map[string]string
+	oledMutex   sync.Mutex
 }
 
 // NewCoreEngine creates a new core engine with config path for reloading
 func NewCoreEngine(cfg *config.Config, socketPath, configPath string) *CoreEngine {
-	// Create hardware configuration from config
+	// The startup profile is applied on top of the file's settings
+	baseConfig := cfg
+	frequency := 14078000 // Default JS8 frequency
+	if cfg.Profile != "" {
+		if profileConfig, err := cfg.ProfileConfig(cfg.Profile); err != nil {
+			logger.Warnf("%v, starting without a profile", err)
+		} else {
+			cfg = profileConfig
+			if profile := baseConfig.FindProfile(cfg.Profile); profile.Frequency > 0 {
+				frequency = profile.Frequency
+			}
+		}
+	}
+
+	hardwareConfig := hardwareConfigFor(cfg)
+	rxChannels := rxChannelNames(cfg)
+
+	// Initialize message store
+	messageStore, err := openMessageStore(cfg)
+	if err != nil {
+		logger.Warnf("Failed to initialize message store: %v", err)
+		messageStore = nil // Continue without storage
+	}
+	snippets, err := openSnippets(cfg)
+	if err != nil {
+		logger.Warnf("Failed to open the snippet directory, decode audio will not be saved: %v", err)
+	}
+	recorder, err := openRecorder(cfg)
+	if err != nil {
+		logger.Warnf("Failed to start the band recorder, audio will not be recorded: %v", err)
+	}
+
+	// Initialize audio monitors for real-time visualization and RX level alarms
+	audioMonitors := newAudioMonitors(cfg, rxChannels, hardwareConfig.SampleRate)
+
+	// The FFT backend is shared by the decoder and the spectrum display
+	if err := fft.SetBackend(cfg.DSP.FFTBackend); err != nil {
+		logger.Warnf("%v, using the default FFT backend", err)
+		fft.SetBackend(fft.BackendAuto)
+	}
+
+	dspEngine, err := dsp.NewEngine(cfg.DSP.Decoder)
+	if err != nil {
+		logger.Warnf("%v, using the default decoder", err)
+		dspEngine, _ = dsp.NewEngine(dsp.DecoderAuto)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	engine := &CoreEngine{
+		config:          cfg,
+		baseConfig:      baseConfig,
+		configPath:      configPath,
+		socketPath:      socketPath,
+		adminToken:      newSessionToken(),
+		startTime:       time.Now(),
+		frequency:       frequency,
+		txAudioOffset:   configuredTXOffset(cfg),
+		autoReply:       true,
+		connected:       true, // Mock - assume connected
+		rxMessages:      make(chan protocol.Message, 100),
+		txMessages:      make(chan protocol.Message, 100),
+		rxAudio:         make(chan rxBlock, 32),
+		messageStore:    messageStore,
+		snippets:        snippets,
+		recorder:        recorder,
+		dspEngine:       dspEngine,
+		rxDecoders:      newDecoders(len(rxChannels), dspEngine, cfg.DSP.Decoder),
+		decodeGovernor:  newDecodeGovernor(cfg, dspEngine),
+		driftEstimator:  newDriftEstimator(cfg),
+		formAssembler:   forms.NewAssembler(formTimeout),
+		outgoing:        make(map[uint8]*outgoingFile),
+		incoming:        make(map[uint8]*incomingFile),
+		sessions:        make(map[uint8]*arqSession),
+		preFilters:      newPreFilters(len(rxChannels), hardwareConfig.SampleRate, preFilterConfig(cfg)),
+		rxChannels:      rxChannels,
+		hardwareManager: hardware.NewHardwareManager(hardwareConfig),
+		audioMonitors:   audioMonitors,
+		pcmStream:       audio.NewPCMStream(hardwareConfig.SampleRate, audio.DefaultStreamRate),
+		abortTx:         make(chan bool, 1),
+		transmitting:    false,
+		powerLevels:     make(map[string]int),
+		tuneResults:     make(map[string]tuneResult),
+
+		alarmEvents:      make(chan audio.AudioAlarm, 16),
+		alarmSubscribers: make(map[chan audio.AudioAlarm]struct{}),
+		oledAlarms:       make(map[string]string),
+
+		decodeSubscribers: make(map[chan protocol.Message]struct{}),
+
+		eventSubscribers: make(map[chan protocol.Event]struct{}),
+		acks:             make(map[int]*pendingAck),
+		qsoScripts:       cfg.QSO.Enabled,
+		lookups:          make(chan string, 64),
+
+		version:    "0.1.0-dev", // The daemon sets its own with SetVersion
+		releaseURL: release.DefaultURL,
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	// Surface RX level alarms on the local display
+	for _, monitor := range audioMonitors {
+		monitor.SetAlarmHandler(engine.handleAudioAlarm)
+	}
+
+	if err := engine.loadFilters(); err != nil {
+		logger.Warnf("Failed to load decode filters: %v", err)
+	}
+	if err := engine.loadAutoList(); err != nil {
+		logger.Warnf("Failed to load the auto list: %v", err)
+	}
+	engine.closeOpenNets()
+
+	engine.passbandLow, engine.passbandHigh = configuredPassband(cfg)
+	engine.applyPassband()
+
+	return engine
+}
+
+// hardwareConfigFor builds the hardware manager settings from configuration
+func hardwareConfigFor(cfg *config.Config) hardware.HardwareConfig {
 	hardwareConfig := hardware.HardwareConfig{
 		EnableGPIO:     cfg.Hardware.EnableGPIO,
 		PTTGPIOPin:     cfg.Hardware.PTTGPIOPin,
 		StatusLEDPin:   cfg.Hardware.StatusLEDPin,
+		AmpKeying:      cfg.Hardware.AmpKeying,
+		AmpGPIOPin:     cfg.Hardware.AmpGPIOPin,
 		EnableOLED:     cfg.Hardware.EnableOLED,
 		OLEDI2CAddress: cfg.Hardware.OLEDI2CAddress,
 		OLEDWidth:      cfg.Hardware.OLEDWidth,
@@ -70,8 +415,11 @@ func NewCoreEngine(cfg *config.Config, socketPath, configPath string) *CoreEngin
 		AudioOutput:    cfg.Audio.OutputDevice,
 		SampleRate:     cfg.Audio.SampleRate,
 		BufferSize:     cfg.Audio.BufferSize,
+		HalfDuplex:     cfg.Audio.HalfDuplex,
 		EnableRadio:    cfg.Radio.Device != "", // Enable radio if device is specified
 		UseHamlib:      cfg.Radio.UseHamlib,
+		RadioBackend:   cfg.Radio.Backend,
+		CATProtocol:    cfg.Radio.CATProtocol,
 		RadioModel:     cfg.Radio.Model,
 		RadioDevice:    cfg.Radio.Device,
 		RadioBaudRate:  cfg.Radio.BaudRate,
@@ -96,31 +444,318 @@ func NewCoreEngine(cfg *config.Config, socketPath, configPath string) *CoreEngin
 		hardwareConfig.RadioBaudRate = 4800 // Default radio baud rate
 	}
 
-	// Initialize message store
-	messageStore, err := storage.NewMessageStore(cfg.Storage.DatabasePath, cfg.Storage.MaxMessages)
-	if err != nil {
-		log.Printf("Warning: Failed to initialize message store: %v", err)
-		messageStore = nil // Continue without storage
+	// A stereo device is captured in stereo, whether each channel gets its
+	// own decode pipeline or one carries RX, and played in stereo
+	hardwareConfig.InputChannels = captureChannels(cfg)
+	hardwareConfig.OutputChannels = 1
+	if cfg.Audio.OutputChannels == "stereo" {
+		hardwareConfig.OutputChannels = 2
 	}
 
-	// Initialize audio monitor for real-time visualization
-	audioMonitor := audio.NewAudioLevelMonitor(hardwareConfig.SampleRate, 1024)
+	return hardwareConfig
+}
 
-	return &CoreEngine{
-		config:          cfg,
-		configPath:      configPath,
-		socketPath:      socketPath,
-		startTime:       time.Now(),
-		frequency:       14078000, // Default JS8 frequency
-		connected:       true,     // Mock - assume connected
-		rxMessages:      make(chan protocol.Message, 100),
-		txMessages:      make(chan protocol.Message, 100),
-		messageStore:    messageStore,
-		dspEngine:       dsp.NewCppDSP(),
-		hardwareManager: hardware.NewHardwareManager(hardwareConfig),
-		audioMonitor:    audioMonitor,
-		abortTx:         make(chan bool, 1),
-		transmitting:    false,
+// newAudioMonitors creates a level monitor for each RX channel
+func newAudioMonitors(cfg *config.Config, rxChannels []string, sampleRate int) []*audio.AudioLevelMonitor {
+	monitors := make([]*audio.AudioLevelMonitor, len(rxChannels))
+	for ch, name := range rxChannels {
+		monitors[ch] = audio.NewAudioLevelMonitor(sampleRate, 1024)
+		monitors[ch].SetChannel(name)
+		monitors[ch].SetAlarmConfig(audioAlarmConfig(cfg))
+	}
+	return monitors
+}
+
+// audioAlarmConfig builds RX level alarm thresholds from configuration
+func audioAlarmConfig(cfg *config.Config) audio.AlarmConfig {
+	alarmConfig := audio.DefaultAlarmConfig()
+
+	if cfg.Audio.ClipAlarmSeconds > 0 {
+		alarmConfig.ClipDuration = time.Duration(cfg.Audio.ClipAlarmSeconds) * time.Second
+	} else if cfg.Audio.ClipAlarmSeconds < 0 {
+		alarmConfig.ClipDuration = 0
+	}
+	if cfg.Audio.DeadInputMinutes > 0 {
+		alarmConfig.DeadInputDuration = time.Duration(cfg.Audio.DeadInputMinutes) * time.Minute
+	} else if cfg.Audio.DeadInputMinutes < 0 {
+		alarmConfig.DeadInputDuration = 0
+	}
+	if cfg.Audio.DeadInputThreshold != 0 {
+		alarmConfig.DeadInputLevel = float32(cfg.Audio.DeadInputThreshold)
+	}
+
+	return alarmConfig
+}
+
+// preFilterConfig builds the RX pre-filter settings from configuration
+func preFilterConfig(cfg *config.Config) dsp.PreFilterConfig {
+	filterConfig := dsp.DefaultPreFilterConfig()
+	filterConfig.BandPass = cfg.DSP.BandPass
+	filterConfig.NoiseBlanker = cfg.DSP.NoiseBlanker
+	filterConfig.NotchFrequencies = cfg.DSP.NotchFrequencies
+
+	if cfg.DSP.LowCut > 0 {
+		filterConfig.LowCut = cfg.DSP.LowCut
+	}
+	if cfg.DSP.HighCut > 0 {
+		filterConfig.HighCut = cfg.DSP.HighCut
+	}
+	if cfg.DSP.BlankerThreshold > 0 {
+		filterConfig.BlankerThreshold = cfg.DSP.BlankerThreshold
+	}
+
+	return filterConfig
+}
+
+// newPreFilters creates an independent pre-filter for each RX channel
+func newPreFilters(channels, sampleRate int, filterConfig dsp.PreFilterConfig) []*dsp.PreFilter {
+	filters := make([]*dsp.PreFilter, channels)
+	for i := range filters {
+		filters[i] = dsp.NewPreFilter(sampleRate, filterConfig)
+	}
+	return filters
+}
+
+// newDecodeGovernor creates the decode time governor when adaptive decoding is enabled
+func newDecodeGovernor(cfg *config.Config, decoder dsp.DSPEngine) *dsp.DecodeGovernor {
+	if !cfg.DSP.AdaptiveDecode {
+		return nil
+	}
+	return dsp.NewDecodeGovernor(decoder.EstimateAudioDuration(dsp.ModeNormal), cfg.DSP.DecodeBudget)
+}
+
+// decoderName reports which decoder backend an engine uses
+func decoderName(engine dsp.DSPEngine) string {
+	if _, ok := engine.(*dsp.DSP); ok {
+		return dsp.DecoderGo
+	}
+	return dsp.DecoderNative
+}
+
+// newDecoders creates a decoder per RX channel so each channel keeps its own
+// decoder state. The first channel reuses the main DSP engine.
+func newDecoders(channels int, primary dsp.DSPEngine, backend string) []dsp.DSPEngine {
+	decoders := make([]dsp.DSPEngine, channels)
+	for i := range decoders {
+		if i == 0 {
+			decoders[i] = primary
+			continue
+		}
+		decoder, err := dsp.NewEngine(backend)
+		if err != nil {
+			decoder, _ = dsp.NewEngine(dsp.DecoderAuto)
+		}
+		decoders[i] = decoder
+	}
+	return decoders
+}
+
+// rxChannelNames returns the label of each RX channel to decode
+func rxChannelNames(cfg *config.Config) []string {
+	if cfg.Audio.InputChannels != "stereo-split" {
+		return []string{""}
+	}
+
+	names := []string{"left", "right"}
+	for i, name := range cfg.Audio.InputChannelNames {
+		if i < len(names) && name != "" {
+			names[i] = name
+		}
+	}
+	return names
+}
+
+// captureChannels returns how many channels to capture, two for a stereo
+// device
+func captureChannels(cfg *config.Config) int {
+	if cfg.Audio.InputChannels == "stereo" || cfg.Audio.InputChannels == "stereo-split" {
+		return 2
+	}
+	return 1
+}
+
+// splitChannels deinterleaves captured audio into one slice per channel
+func splitChannels(samples []int16, channels int) [][]int16 {
+	if channels <= 1 {
+		return [][]int16{samples}
+	}
+
+	frames := len(samples) / channels
+	split := make([][]int16, channels)
+	for ch := range split {
+		split[ch] = make([]int16, frames)
+		for i := 0; i < frames; i++ {
+			split[ch][i] = samples[i*channels+ch]
+		}
+	}
+	return split
+}
+
+// rxBlock is one captured audio block, split per RX channel and pre-filtered
+type rxBlock struct {
+	channels [][]int16
+	raw      []int16   // Original capture buffer, recycled once copied
+	captured time.Time // When the block was read from the sound card
+}
+
+// audioGains are the sound card's input and output gains in dB
+type audioGains struct {
+	input, output float64
+}
+
+// audioGains reads the gains from the configuration, so a new sound card
+// or reload applies at once
+func (e *CoreEngine) audioGains() audioGains {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return audioGains{input: e.config.Audio.InputGain, output: e.config.Audio.OutputGain}
+}
+
+// audioChannels are the channels of a stereo sound card carrying RX and TX
+// audio, "" when that direction is mono or, for RX, split into receivers
+type audioChannels struct {
+	input, output string
+}
+
+// audioChannels reads the channel mapping from the configuration
+func (e *CoreEngine) audioChannels() audioChannels {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	var channels audioChannels
+	if e.config.Audio.InputChannels == "stereo" {
+		channels.input = e.config.Audio.InputChannel
+	}
+	if e.config.Audio.OutputChannels == "stereo" {
+		channels.output = e.config.Audio.OutputChannel
+	}
+	return channels
+}
+
+// txAudio readies audio for the sound card: the output gain, and the
+// channel mapping of a stereo card
+func (e *CoreEngine) txAudio(samples []int16) []int16 {
+	audio.ApplyGain(samples, e.audioGains().output)
+	if channel := e.audioChannels().output; channel != "" {
+		return audio.ToStereo(samples, channel)
+	}
+	return samples
+}
+
+// preprocessRX runs one RX channel through its pre-filter before decoding
+func (e *CoreEngine) preprocessRX(channel int, samples []int16) []int16 {
+	e.mutex.RLock()
+	var filter *dsp.PreFilter
+	if channel < len(e.preFilters) {
+		filter = e.preFilters[channel]
+	}
+	e.mutex.RUnlock()
+
+	if filter == nil || !filter.Enabled() {
+		return samples
+	}
+	return filter.Process(samples)
+}
+
+// handleAudioAlarm queues RX level alarms raised by the audio monitor. It runs
+// on the audio sample goroutine, so it must never block.
+func (e *CoreEngine) handleAudioAlarm(alarm audio.AudioAlarm) {
+	select {
+	case e.alarmEvents <- alarm:
+	default:
+		logger.Warnf("Alarm queue full, dropping %s alarm event", alarm.Type)
+	}
+}
+
+// alarmDispatcher delivers queued alarm events to the display and web clients
+func (e *CoreEngine) alarmDispatcher() {
+	for e.isRunning() {
+		select {
+		case alarm := <-e.alarmEvents:
+			e.handleAlarmEvent(alarm)
+
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// handleAlarmEvent shows an alarm change on the OLED and notifies subscribers
+func (e *CoreEngine) handleAlarmEvent(alarm audio.AudioAlarm) {
+	// Each RX channel raises its own alarms
+	key := alarm.Type
+	display := fmt.Sprintf("ALARM: %s", alarm.Type)
+	if alarm.Channel != "" {
+		key = alarm.Type + "/" + alarm.Channel
+		display = fmt.Sprintf("ALARM: %s %s", alarm.Channel, alarm.Type)
+	}
+
+	e.oledMutex.Lock()
+	if alarm.Active {
+		logger.Infof("Audio alarm raised (%s): %s", key, alarm.Message)
+		e.oledAlarms[key] = display
+	} else {
+		logger.Infof("Audio alarm cleared (%s)", key)
+		delete(e.oledAlarms, key)
+	}
+	e.oledMutex.Unlock()
+
+	// Show the alarm, or restore the normal display once it clears
+	e.refreshOLEDDisplay()
+
+	e.alarmMutex.Lock()
+	for ch := range e.alarmSubscribers {
+		select {
+		case ch <- alarm:
+		default:
+			// Slow subscriber, drop the event rather than stall others
+		}
+	}
+	e.alarmMutex.Unlock()
+}
+
+// SubscribeAlarms returns a channel receiving RX level alarm changes and a
+// function that cancels the subscription
+func (e *CoreEngine) SubscribeAlarms() (<-chan audio.AudioAlarm, func()) {
+	ch := make(chan audio.AudioAlarm, 8)
+
+	e.alarmMutex.Lock()
+	e.alarmSubscribers[ch] = struct{}{}
+	e.alarmMutex.Unlock()
+
+	return ch, func() {
+		e.alarmMutex.Lock()
+		delete(e.alarmSubscribers, ch)
+		e.alarmMutex.Unlock()
+	}
+}
+
+// publishDecode delivers a received message to decode subscribers
+func (e *CoreEngine) publishDecode(msg protocol.Message) {
+	e.decodeMutex.Lock()
+	defer e.decodeMutex.Unlock()
+
+	for ch := range e.decodeSubscribers {
+		select {
+		case ch <- msg:
+		default:
+			// Slow subscriber, drop the message rather than stall others
+		}
+	}
+}
+
+// SubscribeDecodes returns a channel receiving every decoded message and a
+// function that cancels the subscription
+func (e *CoreEngine) SubscribeDecodes() (<-chan protocol.Message, func()) {
+	ch := make(chan protocol.Message, 32)
+
+	e.decodeMutex.Lock()
+	e.decodeSubscribers[ch] = struct{}{}
+	e.decodeMutex.Unlock()
+
+	return ch, func() {
+		e.decodeMutex.Lock()
+		delete(e.decodeSubscribers, ch)
+		e.decodeMutex.Unlock()
 	}
 }
 
@@ -135,34 +770,46 @@ func (e *CoreEngine) Start() error {
 	if err := e.dspEngine.Initialize(); err != nil {
 		return fmt.Errorf("failed to initialize DSP engine: %w", err)
 	}
-	log.Printf("DSP engine initialized successfully (sample rate: %d Hz)", e.hardwareManager.GetConfig().SampleRate)
+	dspLogger.Infof("DSP engine initialized successfully (sample rate: %d Hz, decoder: %s, FFT: %s)", e.hardwareManager.GetConfig().SampleRate, decoderName(e.dspEngine), fft.Current())
+	if err := e.checkDecoding(e.config); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// Messages the last run left unsent, read before any new ones are queued
+	unsent := e.loadTXQueue()
+	e.expireDeliveries()
+
+	// Additional RX channels decode on their own DSP instances
+	for ch, decoder := range e.rxDecoders {
+		if decoder == e.dspEngine {
+			continue
+		}
+		decoder.SetSampleRate(e.hardwareManager.GetConfig().SampleRate)
+		if err := decoder.Initialize(); err != nil {
+			return fmt.Errorf("failed to initialize DSP engine for RX channel %s: %w", e.rxChannels[ch], err)
+		}
+	}
 
 	// Initialize hardware manager
 	if err := e.hardwareManager.Initialize(); err != nil {
 		return fmt.Errorf("failed to initialize hardware manager: %w", err)
 	}
 
-	// Start audio input for decoding
-	log.Printf("DEBUG: About to start audio input...")
-	if err := e.hardwareManager.StartAudioInput(); err != nil {
-		log.Printf("Warning: failed to start audio input: %v", err)
-	} else {
-		log.Printf("DEBUG: Audio input startup completed successfully")
+	// Splitting a mono capture would hand each receiver every other sample
+	if audioInput := e.hardwareManager.GetAudio(); audioInput != nil && audioInput.GetInputChannels() != captureChannels(e.config) {
+		return fmt.Errorf("audio input captures %d channel(s) but input_channels %q needs %d",
+			audioInput.GetInputChannels(), e.config.Audio.InputChannels, captureChannels(e.config))
 	}
 
-	// Start audio output for transmission
-	if err := e.hardwareManager.StartAudioOutput(); err != nil {
-		log.Printf("Warning: failed to start audio output: %v", err)
-	}
+	// Open the antenna switch before tuning, so the profile's band gets
+	// its antenna
+	e.startAntenna()
 
-	// Start audio monitoring
-	if err := e.audioMonitor.Start(); err != nil {
-		log.Printf("Warning: failed to start audio monitor: %v", err)
-	} else {
-		// Start audio sample processing goroutine
-		go e.processAudioSamples()
-		log.Printf("Audio monitoring started")
-	}
+	// Tune to the startup profile's frequency
+	e.tuneProfile(e.config.Profile)
+
+	// Start audio capture, playback, monitoring and decoding
+	e.startAudio()
 
 	// Remove existing socket file
 	os.Remove(e.socketPath)
@@ -176,22 +823,100 @@ func (e *CoreEngine) Start() error {
 
 	// Set socket permissions (readable/writable by owner and group)
 	if err := os.Chmod(e.socketPath, 0660); err != nil {
-		log.Printf("Warning: failed to set socket permissions: %v", err)
+		logger.Warnf("Failed to set socket permissions: %v", err)
 	}
 
-	log.Printf("Core engine listening on %s", e.socketPath)
+	logger.Infof("Core engine listening on %s", e.socketPath)
 
-	// Start message processor
-	go e.messageProcessor()
+	// Serve the gRPC API alongside the socket
+	if e.config.API.GRPCAddress != "" {
+		if err := e.startGRPC(e.config.API.GRPCAddress); err != nil {
+			return err
+		}
+	}
+
+	// Load the country file for tagging stations with their DXCC entity
+	e.loadDXCC()
 
-	// Start audio processor
-	go e.audioProcessor()
+	// Start calling trigger webhooks, before the transmit loop fires them
+	e.startTriggers()
+
+	// Send logged QSOs to logging programs
+	e.startLogBroadcast()
+
+	// Start message processor
+	e.startLoop("transmit", e.messageProcessor)
 
 	// Start heartbeat generator
-	go e.heartbeatGenerator()
+	e.startLoop("heartbeat", e.heartbeatGenerator)
+
+	// Start alarm event dispatcher
+	e.startLoop("alarms", e.alarmDispatcher)
+
+	// Start delivery tracking of directed messages
+	e.startLoop("acks", e.ackMonitor)
+
+	// Start resending scripted QSO steps that go unanswered
+	e.startLoop("qso", e.qsoMonitor)
+
+	// Start opening and closing nets, on a schedule a reload may add
+	e.startLoop("net", e.netLoop)
+
+	// Start sending beacons and station IDs, which a reload may turn on
+	e.startLoop("beacon", e.beaconLoop)
+
+	// Start sending telemetry, which a reload may turn on
+	e.startLoop("telemetry", e.telemetryLoop)
+
+	// Start recording the stats history
+	e.startLoop("stats", e.statsRecorder)
+
+	// Start checking the message database for corruption and backing it up
+	if e.messageStore != nil && e.config.Storage.CheckInterval > 0 && databaseFile(e.config) {
+		e.startLoop("database", e.databaseLoop)
+	}
+
+	// Start callbook lookups of heard stations
+	e.startLookups()
+
+	// Start forwarding decodes to spots webhooks
+	e.startSpots()
+
+	// Start fetching solar indices for the web interface
+	if e.config.Propagation.Enabled {
+		e.startLoop("propagation", e.propagationLoop)
+	}
+
+	// Start feeding files being sent to the transmit queue
+	if e.config.Files.Enabled {
+		e.startLoop("files", e.fileLoop)
+	}
+
+	// Start feeding session frames to the transmit queue
+	if e.config.ARQ.Enabled {
+		e.startLoop("sessions", e.sessionLoop)
+	}
+
+	// Start reading time and position from gpsd
+	if e.config.GPS.Enabled {
+		e.startLoop("gps", e.gpsLoop)
+	}
+
+	// Start checking the system clock, unless GPS fixes are checking it
+	if e.config.TimeSync.Enabled && !strings.EqualFold(e.config.TimeSync.Source, timesync.SourceGPS) {
+		e.startLoop("timesync", e.timeSyncLoop)
+	}
+
+	// Start sampling the battery and temperature sensors
+	if e.config.Sensors.Enabled {
+		e.startLoop("sensors", e.sensorLoop)
+	}
+
+	// Start following the low power schedule, which a reload may add
+	e.startLoop("power", e.powerLoop)
 
 	// Accept connections
-	go e.acceptConnections()
+	e.startLoop("socket", e.acceptConnections)
 
 	// Mark engine as fully initialized after a startup delay
 	go func() {
@@ -199,7 +924,10 @@ func (e *CoreEngine) Start() error {
 		e.mutex.Lock()
 		e.fullyInitialized = true
 		e.mutex.Unlock()
-		log.Printf("Engine: Fully initialized - transmissions now enabled")
+		logger.Infof("Fully initialized - transmissions now enabled")
+
+		// Pick up where the last run left off
+		e.resumeTXQueue(unsent)
 	}()
 
 	return nil
@@ -212,7 +940,7 @@ func (e *CoreEngine) acceptConnections() {
 		conn, err := e.listener.Accept()
 		if err != nil {
 			if e.isRunning() {
-				log.Printf("Socket accept error: %v", err)
+				logger.Errorf("Socket accept error: %v", err)
 			}
 			continue
 		}
@@ -224,8 +952,20 @@ func (e *CoreEngine) acceptConnections() {
 // handleConnection handles a single socket connection
 func (e *CoreEngine) handleConnection(conn net.Conn) {
 	defer conn.Close()
+	// A command that panics drops its connection, not the daemon
+	defer e.recoverPanic("connection")
 
-	scanner := bufio.NewScanner(conn)
+	// Version 2 clients open with a length-prefixed HELLO frame
+	reader := bufio.NewReader(conn)
+	if first, err := reader.Peek(1); err == nil && protocol.IsFramed(first[0]) {
+		e.handleFramedConnection(conn, reader)
+		return
+	}
+
+	// AUTH:<token> raises or lowers the role for the rest of the connection
+	role := e.socketRole()
+
+	scanner := bufio.NewScanner(reader)
 	for scanner.Scan() {
 		line := scanner.Text()
 		if line == "" {
@@ -241,9 +981,18 @@ func (e *CoreEngine) handleConnection(conn net.Conn) {
 		}
 
 		// Handle command
-		response := e.handleCommand(cmd)
+		response := e.handleAuthorizedCommand(cmd, &role)
 		conn.Write([]byte(response.String() + "\n"))
 
+		// EVENTS turns the connection into a stream of JSON event lines
+		if cmd.Type == protocol.CmdEvents && response.Success {
+			e.streamEvents(reader, func(event protocol.Event) error {
+				_, err := conn.Write([]byte(event.String() + "\n"))
+				return err
+			})
+			return
+		}
+
 		// Close connection after QUIT command
 		if cmd.Type == protocol.CmdQuit {
 			break
@@ -251,6 +1000,67 @@ func (e *CoreEngine) handleConnection(conn net.Conn) {
 	}
 }
 
+// handleFramedConnection serves a version 2 connection, where commands and
+// responses are length-prefixed JSON frames
+func (e *CoreEngine) handleFramedConnection(conn net.Conn, reader *bufio.Reader) {
+	version, token, failure := protocol.ReadHello(reader)
+	if failure != nil {
+		protocol.WriteFrame(conn, failure)
+		return
+	}
+	role := e.socketRole()
+	if token != "" {
+		if role, failure = e.authenticate(token); failure != nil {
+			protocol.WriteFrame(conn, failure)
+			return
+		}
+	}
+	if err := protocol.WriteFrame(conn, protocol.NewHelloResponse(version, "js8d/"+e.version)); err != nil {
+		return
+	}
+
+	for {
+		var cmd protocol.Command
+		if err := protocol.ReadFrame(reader, &cmd); err != nil {
+			if err != io.EOF {
+				protocol.WriteFrame(conn, protocol.NewErrorResponse(fmt.Sprintf("frame error: %v", err)))
+			}
+			return
+		}
+		cmd.Type = strings.ToUpper(strings.TrimSpace(cmd.Type))
+		if cmd.Args == nil {
+			cmd.Args = make(map[string]interface{})
+		}
+
+		response := e.handleAuthorizedCommand(&cmd, &role)
+		if err := protocol.WriteResponse(conn, response); err != nil {
+			return
+		}
+		if cmd.Type == protocol.CmdQuit {
+			return
+		}
+
+		// EVENTS turns the connection into a stream of event frames
+		if cmd.Type == protocol.CmdEvents && response.Success {
+			e.streamEvents(reader, func(event protocol.Event) error {
+				return protocol.WriteFrame(conn, event)
+			})
+			return
+		}
+	}
+}
+
+// handleAuthorizedCommand runs a command if the connection's role allows it
+func (e *CoreEngine) handleAuthorizedCommand(cmd *protocol.Command, role *string) *protocol.Response {
+	if cmd.Type == protocol.CmdAuth {
+		return e.handleAuth(cmd, role)
+	}
+	if failure := authorize(*role, cmd); failure != nil {
+		return failure
+	}
+	return e.handleCommand(cmd)
+}
+
 // handleCommand processes a single command
 func (e *CoreEngine) handleCommand(cmd *protocol.Command) *protocol.Response {
 	switch cmd.Type {
@@ -277,9 +1087,47 @@ func (e *CoreEngine) handleCommand(cmd *protocol.Command) *protocol.Response {
 	case protocol.CmdAbort:
 		return e.handleAbort()
 
+	case protocol.CmdStation:
+		return e.handleStation(cmd)
+
+	case protocol.CmdRaw:
+		return e.handleRaw(cmd)
+
+	case protocol.CmdRedecode:
+		return e.handleRedecode(cmd)
+
+	case protocol.CmdMacro:
+		return e.handleMacro(cmd)
+
+	case protocol.CmdFilter:
+		return e.handleFilter(cmd)
+	case protocol.CmdAutoList:
+		return e.handleAutoList(cmd)
+
+	case protocol.CmdForm:
+		return e.handleForm(cmd)
+
+	case protocol.CmdFile:
+		return e.handleFile(cmd)
+
+	case protocol.CmdSession:
+		return e.handleSession(cmd)
+
+	case protocol.CmdEvents:
+		// The connection handler streams events after this response
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"status": "subscribed",
+		})
+
 	case protocol.CmdReload:
 		return e.handleReload()
 
+	case protocol.CmdProfile:
+		return e.handleProfile(cmd)
+
+	case protocol.CmdLogLevel:
+		return e.handleLogLevel(cmd)
+
 	case protocol.CmdQuit:
 		return protocol.NewSuccessResponse(map[string]interface{}{
 			"message": "goodbye",
@@ -311,6 +1159,24 @@ func (e *CoreEngine) handleStringCommand(cmd *protocol.Command) *protocol.Respon
 		return e.handleSearchMessages(parts[1:])
 	case "GET_MESSAGE_STATS":
 		return e.handleGetMessageStats()
+	case "GET_STATS_SUMMARY":
+		return e.handleGetStatsSummary(parts[1:])
+	case "GET_STATS_SERIES":
+		return e.handleGetStatsSeries(parts[1:])
+	case "GET_BAND_NOISE":
+		return e.handleGetBandNoise(parts[1:])
+	case "GET_SNR_HISTORY":
+		return e.handleGetSNRHistory(parts[1:])
+	case "GET_TELEMETRY":
+		return e.handleGetTelemetry(parts[1:])
+	case "GET_TX_QUEUE":
+		return e.handleGetTXQueue()
+	case "GET_STATIONS":
+		return e.handleGetStations(parts[1:])
+	case "GET_MAP":
+		return e.handleGetMap(parts[1:])
+	case "GET_PROPAGATION":
+		return e.handleGetPropagation()
 	case "CLEANUP_MESSAGES":
 		return e.handleCleanupMessages()
 	case "TEST_CAT":
@@ -321,6 +1187,52 @@ func (e *CoreEngine) handleStringCommand(cmd *protocol.Command) *protocol.Respon
 		return e.handleTestPTTOff()
 	case "RETRY_RADIO":
 		return e.handleRetryRadio()
+	case "RESTART":
+		return e.handleRestart()
+	case "REBOOT":
+		return e.handleReboot()
+	case "CHECK_UPDATE":
+		return e.handleCheckUpdate()
+	case "AUTO":
+		return e.handleAuto(parts[1:])
+	case "QSO":
+		return e.handleQSOCommand(parts[1:])
+	case "NET":
+		return e.handleNet(parts[1:])
+	case "BEACON":
+		return e.handleBeacon(parts[1:])
+	case "TELEMETRY":
+		return e.handleTelemetry(parts[1:])
+	case "GET_ANSWERS":
+		return e.handleGetAnswers(parts[1:])
+	case "GET_RATE_LIMITS":
+		return e.handleGetRateLimits()
+	case "DXCC":
+		return e.handleDXCC(parts[1:])
+	case "GET_LOG":
+		return e.handleGetLog(parts[1:])
+	case "GET_AWARDS":
+		return e.handleGetAwards(parts[1:])
+	case "EXPORT_ADIF":
+		return e.handleExportADIF(parts[1:])
+	case "QSL":
+		return e.handleQSL(parts[1:])
+	case "SELFTEST":
+		return e.handleSelfTest(parts[1:])
+	case "TUNE":
+		return e.handleTune(parts[1:])
+	case "PASSBAND":
+		return e.handlePassband(parts[1:])
+	case "FIND_OFFSET":
+		return e.handleFindOffset()
+	case "ANTENNA":
+		return e.handleAntenna(parts[1:])
+	case "SENSORS":
+		return e.handleSensors()
+	case "POWER":
+		return e.handlePower(parts[1:])
+	case "BANDPLAN":
+		return e.handleBandPlan(parts[1:])
 	default:
 		return protocol.NewErrorResponse(fmt.Sprintf("unknown command: %s", cmdStr))
 	}
@@ -338,13 +1250,13 @@ func (e *CoreEngine) handleStatus() *protocol.Response {
 		radioConnected = e.hardwareManager.IsRadioConnected()
 		if radioConnected {
 			if radioFreq, err := e.hardwareManager.GetRadioFrequency(); err == nil {
-				currentFreq = int(radioFreq)
-				log.Printf("DEBUG: Got frequency from radio: %d Hz", currentFreq)
+				currentFreq = int(e.calibrate(radioFreq))
+				logger.Debugf("Got frequency from radio: %d Hz", currentFreq)
 			} else {
-				log.Printf("DEBUG: Failed to get frequency from radio: %v", err)
+				logger.Debugf("Failed to get frequency from radio: %v", err)
 			}
 		} else {
-			log.Printf("DEBUG: Radio not connected, using cached frequency: %d Hz", currentFreq)
+			logger.Debugf("Radio not connected, using cached frequency: %d Hz", currentFreq)
 		}
 	}
 
@@ -352,12 +1264,23 @@ func (e *CoreEngine) handleStatus() *protocol.Response {
 		Callsign:  e.config.Station.Callsign,
 		Grid:      e.config.Station.Grid,
 		Frequency: currentFreq,
+		TXOffset:  e.txAudioOffset,
 		Mode:      "JS8",
 		PTT:       e.ptt,
 		Connected: e.connected,
 		Uptime:    time.Since(e.startTime).String(),
 		StartTime: e.startTime,
-		Version:   "0.1.0-dev",
+		Version:   e.version,
+		Auto:      e.autoReply,
+		Restarts:  e.restartCounts(),
+	}
+	status.QSOScript, status.QSO = e.qsoStatus()
+	status.Clock = e.clockStatus()
+	status.GPS = e.gpsStatus()
+	status.Sensors = e.sensorStatus()
+	status.LowPower = e.LowPower()
+	if check := bandPlanCheck(e.config, currentFreq, e.txAudioOffset); !check.OK() {
+		status.BandPlan = &check
 	}
 
 	// Add hardware status if hardware manager is available
@@ -369,52 +1292,59 @@ func (e *CoreEngine) handleStatus() *protocol.Response {
 		hardwareStatus := map[string]interface{}{
 			"initialized": true,
 			"ptt_active":  e.hardwareManager.GetPTT(),
+			"amp_keyed":   e.hardwareManager.GetAmpKey(),
 			"config":      e.hardwareManager.GetConfig(),
 		}
 
 		// Add audio status if available
 		if audio := e.hardwareManager.GetAudio(); audio != nil {
+			// full, or which way a half-duplex card is switched
+			duplex := "full"
+			if e.duplex != nil {
+				duplex = e.duplex.State().String()
+			}
 			hardwareStatus["audio"] = map[string]interface{}{
 				"recording":   audio.IsRecording(),
 				"playing":     audio.IsPlaying(),
 				"sample_rate": audio.GetSampleRate(),
 				"buffer_size": audio.GetBufferSize(),
+				"rx_channels": e.rxChannels,
+				"duplex":      duplex,
 			}
 		}
 
 		data["hardware"] = hardwareStatus
 	}
 
+	data["audio_alarms"] = e.getActiveAlarms()
+
 	return protocol.NewSuccessResponse(data)
 }
 
-// handleMessages returns message history
+// handleMessages returns the most recent stored messages, newest first
 func (e *CoreEngine) handleMessages(cmd *protocol.Command) *protocol.Response {
 	e.msgMutex.RLock()
 	defer e.msgMutex.RUnlock()
 
-	// For now, return mock messages
-	messages := []protocol.Message{
-		{
-			ID:        1,
-			Timestamp: time.Now().Add(-5 * time.Minute),
-			From:      "N0ABC",
-			To:        e.config.Station.Callsign,
-			Message:   "Hello from the mountains!",
-			SNR:       -12.5,
-			Frequency: 14078000,
-			Mode:      "JS8",
-		},
-		{
-			ID:        2,
-			Timestamp: time.Now().Add(-2 * time.Minute),
-			From:      "N0DEF",
-			To:        "",
-			Message:   "CQ CQ DE N0DEF N0DEF K",
-			SNR:       -8.2,
-			Frequency: 14078500,
-			Mode:      "JS8",
-		},
+	query := storage.MessageQuery{Limit: 50}
+	if limit, err := strconv.Atoi(cmd.StringArg("limit")); err == nil && limit > 0 {
+		query.Limit = limit
+	}
+	if since, err := strconv.ParseInt(cmd.StringArg("since"), 10, 64); err == nil {
+		sinceTime := time.Unix(since, 0)
+		query.Since = &sinceTime
+	}
+
+	messages := []protocol.Message{}
+	if e.messageStore != nil {
+		stored, err := e.messageStore.GetMessages(query)
+		if err != nil {
+			return protocol.NewErrorResponse(fmt.Sprintf("failed to get messages: %v", err))
+		}
+		if stored != nil {
+			messages = stored
+		}
+		e.addPaths(messages)
 	}
 
 	return protocol.NewSuccessResponse(map[string]interface{}{
@@ -425,11 +1355,40 @@ func (e *CoreEngine) handleMessages(cmd *protocol.Command) *protocol.Response {
 
 // handleSend queues a message for transmission
 func (e *CoreEngine) handleSend(cmd *protocol.Command) *protocol.Response {
-	to, _ := cmd.Args["to"].(string)
-	message, _ := cmd.Args["message"].(string)
+	to := cmd.StringArg("to")
+	message := cmd.StringArg("message")
 
 	if message == "" {
-		return protocol.NewErrorResponse("message cannot be empty")
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "message cannot be empty")
+	}
+	power, err := parsePower(cmd.StringArg("power"))
+	if err != nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, err.Error())
+	}
+	if err := e.txInhibitedError(); err != nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeRadio, err.Error())
+	}
+
+	to = strings.ToUpper(strings.TrimSpace(to))
+	if to != "" && !dsp.IsValidCallsign(to) {
+		// SEND:<message> puts the first word in "to"; a word with no digits
+		// or group marker can't be a callsign, so it starts a broadcast
+		if strings.ContainsAny(to, "0123456789/@") {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("invalid callsign %q", to))
+		}
+		message = to + " " + message
+		to = ""
+	}
+
+	// A message to the station of a scripted QSO takes it over
+	if to != "" && e.endQSO(to, protocol.QSOStopped) {
+		logger.Infof("Operator took over the scripted QSO with %s", to)
+	}
+
+	// Fill in saved macros and tokens like {MYCALL} and {SNR}
+	message, err = e.expandMacros(to, message)
+	if err != nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, err.Error())
 	}
 
 	msg := protocol.Message{
@@ -439,25 +1398,41 @@ func (e *CoreEngine) handleSend(cmd *protocol.Command) *protocol.Response {
 		To:        to,
 		Message:   message,
 		Mode:      "JS8",
+		Power:     power,
+	}
+	if e.tracksDelivery(to) {
+		msg.Delivery = protocol.DeliveryAwaiting
 	}
 
-	// Queue for transmission
-	select {
-	case e.txMessages <- msg:
-		log.Printf("TX queued: %s -> %s: %s", msg.From, msg.To, msg.Message)
-		return protocol.NewSuccessResponse(map[string]interface{}{
-			"status":  "queued",
-			"message": msg,
-		})
-	default:
-		return protocol.NewErrorResponse("transmit queue full")
+	// Text longer than a frame may go out whole as data frames
+	var frames []string
+	if e.config.TX.DataFrames {
+		if frames, err = dataTextFrames(msg); err != nil {
+			logger.Debugf("Sending in one frame: %v", err)
+		}
+		msg.DataFrames = len(frames) > 0
+	}
+
+	// Queue for transmission
+	msg, ok := e.queueTX(msg)
+	if !ok {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeQueueFull, "transmit queue full")
+	}
+	logger.Infof("TX queued: %s -> %s: %s", msg.From, msg.To, msg.Message)
+	data := map[string]interface{}{
+		"status":  "queued",
+		"message": msg,
+	}
+	if msg.DataFrames {
+		data["frames"] = len(frames)
 	}
+	return protocol.NewSuccessResponse(data)
 }
 
 // handleFrequency sets the radio frequency
 func (e *CoreEngine) handleFrequency(cmd *protocol.Command) *protocol.Response {
 	// TODO: Implement actual radio control
-	freqStr, _ := cmd.Args["frequency"].(string)
+	freqStr := cmd.StringArg("frequency")
 
 	// For now, just acknowledge
 	return protocol.NewSuccessResponse(map[string]interface{}{
@@ -471,14 +1446,34 @@ func (e *CoreEngine) handleRadio() *protocol.Response {
 	e.mutex.RLock()
 	defer e.mutex.RUnlock()
 
-	return protocol.NewSuccessResponse(map[string]interface{}{
-		"frequency": e.frequency,
-		"mode":      "USB",
-		"ptt":       e.ptt,
-		"connected": e.connected,
-		"model":     e.config.Radio.Model,
-		"device":    e.config.Radio.Device,
-	})
+	radio := map[string]interface{}{
+		"frequency":       e.frequency,
+		"mode":            "USB",
+		"ptt":             e.ptt,
+		"connected":       e.connected,
+		"model":           e.config.Radio.Model,
+		"device":          e.config.Radio.Device,
+		"calibration_ppm": e.config.Radio.CalibrationPPM,
+	}
+	if offset := e.config.Radio.TransverterOffset; offset != 0 {
+		radio["transverter_offset"] = offset
+		radio["if_frequency"] = e.uncalibrate(int64(e.frequency))
+	}
+	if e.driftEstimator != nil {
+		radio["drift"] = e.driftEstimator.Estimate(e.frequency, e.config.Radio.CalibrationPPM, time.Now())
+	}
+	if tuned := e.tuneResultsList(); len(tuned) > 0 {
+		radio["tuner"] = tuned
+	}
+	if port := e.selectedAntenna(); port != "" {
+		radio["antenna"] = port
+	}
+	if e.hardwareManager != nil {
+		if link, ok := e.hardwareManager.RadioLink(); ok {
+			radio["link"] = link
+		}
+	}
+	return protocol.NewSuccessResponse(radio)
 }
 
 // messageProcessor handles incoming and outgoing messages
@@ -486,45 +1481,99 @@ func (e *CoreEngine) messageProcessor() {
 	for e.isRunning() {
 		select {
 		case msg := <-e.rxMessages:
-			log.Printf("RX: %s -> %s: %s (SNR: %.1fdB)", msg.From, msg.To, msg.Message, msg.SNR)
-
-			// Store message in database
-			e.msgMutex.Lock()
-			if e.messageStore != nil {
-				messageType := e.classifyMessage(msg.Message)
-				if err := e.messageStore.StoreMessage(msg, "RX", messageType); err != nil {
-					log.Printf("Failed to store RX message: %v", err)
-				}
+			// Frames of a file or form are kept until the whole of it is
+			// in, and session frames go to their session
+			if e.receiveTransferFrame(msg) || e.receiveSessionFrame(msg) || e.receiveFormFrame(msg) {
+				e.publishDecode(msg)
+				continue
+			}
+
+			// Text sent as data frames is taken once its last frame is in
+			msg, whole := e.receiveDataText(msg)
+			if !whole {
+				e.publishDecode(msg)
+				continue
+			}
+
+			logger.Infof("RX: %s -> %s: %s (SNR: %.1fdB)", msg.From, msg.To, msg.Message, msg.SNR)
+
+			// Store message in database; a second decode of one already
+			// stored goes no further
+			if e.storeRX(msg) {
+				continue
 			}
-			e.msgMutex.Unlock()
 
 			// Update OLED display with received message
 			e.updateOLEDDisplay(fmt.Sprintf("RX: %s", msg.Message))
 
-			// Handle auto-replies for directed messages
-			e.handleAutoReply(msg)
+			// Hand the decode to streaming API clients
+			e.publishDecode(msg)
+
+			// Mark our directed messages acknowledged
+			e.checkAck(msg)
+
+			// Check the station in to the net we are running
+			e.netCheckin(msg)
+
+			// Directed traffic with us holds beacons back
+			e.noteTraffic(msg, time.Now())
+
+			// Call the heard and directed trigger webhooks
+			e.triggerRX(msg)
+
+			// Answer a CQ that matches an answer rule
+			e.answerCQ(msg)
+
+			// Answer a station calling our CQ with the QSO script, and
+			// handle auto-replies for other directed messages
+			if !e.advanceQSO(msg) {
+				e.handleAutoReply(msg)
+			}
 
 		case msg := <-e.txMessages:
-			log.Printf("TX: %s -> %s: %s", msg.From, msg.To, msg.Message)
-
-			// Store TX message in database
-			e.msgMutex.Lock()
-			if e.messageStore != nil {
-				messageType := e.classifyMessage(msg.Message)
-				if err := e.messageStore.StoreMessage(msg, "TX", messageType); err != nil {
-					log.Printf("Failed to store TX message: %v", err)
-				}
+			// Nothing new goes out once shutdown has begun; the message
+			// stays queued for the next start
+			if !e.isRunning() {
+				continue
+			}
+
+			// A resend whose ACK came in while it was queued is not needed
+			if e.ackedBeforeResend(msg) {
+				e.setTXStatus(msg, protocol.MessageSent)
+				continue
 			}
-			e.msgMutex.Unlock()
+
+			logger.Infof("TX: %s -> %s: %s (%s)", msg.From, msg.To, msg.Message, msg.Submode)
+			e.setTXStatus(msg, protocol.MessageTransmitting)
+			e.triggerTX(msg, nil)
 
 			// Encode message using real DSP
 			if err := e.transmitMessage(msg); err != nil {
-				log.Printf("TX error: %v", err)
+				logger.Errorf("TX error: %v", err)
+				e.setTXStatus(msg, protocol.MessageFailed)
+				e.triggerTX(msg, err)
+				e.abandonAck(msg)
+				e.qsoSent(msg, err)
+				e.transferSent(msg, err)
+				e.sessionSent(msg, err)
+				continue
+			}
+			e.setTXStatus(msg, protocol.MessageSent)
+			e.noteTraffic(msg, time.Now())
+			e.noteIdentified(msg, time.Now())
+			e.logSentQSO(msg)
+			e.noteCQ(msg)
+			e.qsoSent(msg, nil)
+			e.transferSent(msg, nil)
+			e.sessionSent(msg, nil)
+
+			if msg.Delivery == protocol.DeliveryAwaiting {
+				e.awaitAck(msg)
 			}
 
-		case <-time.After(1 * time.Second):
-			// Periodic processing (keep-alive, etc.)
-			continue
+		case <-e.ctx.Done():
+			// A transmission in progress has finished or been aborted
+			return
 		}
 	}
 }
@@ -536,6 +1585,14 @@ func (e *CoreEngine) isRunning() bool {
 	return e.running
 }
 
+// isTransmitting reports whether a message is being sent or the antenna
+// tuner is running
+func (e *CoreEngine) isTransmitting() bool {
+	e.txMutex.RLock()
+	defer e.txMutex.RUnlock()
+	return e.transmitting || e.tuneActive
+}
+
 // transmitMessage encodes and transmits a message using the DSP engine
 func (e *CoreEngine) transmitMessage(msg protocol.Message) error {
 	// Check if engine is fully initialized
@@ -544,10 +1601,28 @@ func (e *CoreEngine) transmitMessage(msg protocol.Message) error {
 	e.mutex.RUnlock()
 
 	if !initialized {
-		log.Printf("TX blocked: Engine not fully initialized yet, dropping message: %s", msg.Message)
+		logger.Warnf("TX blocked: Engine not fully initialized yet, dropping message: %s", msg.Message)
 		return fmt.Errorf("engine not fully initialized - transmission blocked for safety")
 	}
 
+	// A flat battery would brown out the rig mid-transmission
+	if err := e.txInhibitedError(); err != nil {
+		return err
+	}
+
+	// The dial and offset may have moved out of the band since queueing
+	if err := e.checkBandPlan(); err != nil {
+		return err
+	}
+
+	// Someone else may be on our offset
+	if err := e.clearChannel(); err != nil {
+		return err
+	}
+
+	// The band's antenna goes in before tuning or keying
+	e.switchAntenna(e.dialFrequency())
+
 	// Set transmission state
 	e.txMutex.Lock()
 	e.transmitting = true
@@ -557,157 +1632,282 @@ func (e *CoreEngine) transmitMessage(msg protocol.Message) error {
 		e.txMutex.Lock()
 		e.transmitting = false
 		e.txMutex.Unlock()
-	}()
-
-	// Set PTT flag and hardware PTT during transmission
-	e.mutex.Lock()
-	e.ptt = true
-	e.mutex.Unlock()
-
-	// Activate hardware PTT
-	if err := e.hardwareManager.SetRadioPTT(true); err != nil {
-		log.Printf("Warning: failed to set radio PTT: %v", err)
-	}
-
-	defer func() {
-		// Deactivate hardware PTT - try multiple times if it fails
-		for attempts := 0; attempts < 3; attempts++ {
-			if err := e.hardwareManager.SetRadioPTT(false); err != nil {
-				log.Printf("Warning: failed to clear radio PTT (attempt %d/3): %v", attempts+1, err)
-				time.Sleep(100 * time.Millisecond) // Brief delay before retry
-			} else {
-				log.Printf("Radio PTT cleared successfully")
-				break
-			}
-		}
 
 		e.mutex.Lock()
-		e.ptt = false
+		e.lastTXEnd = time.Now()
 		e.mutex.Unlock()
+
+		// A band change while on the air moves the antenna once unkeyed
+		e.switchAntenna(e.dialFrequency())
 	}()
 
-	// Format message for JS8 transmission (12 characters max)
-	txMessage := msg.Message
-	if len(txMessage) > 12 {
-		txMessage = txMessage[:12]
+	// The first transmission on a band waits for the antenna tuner
+	if err := e.tuneIfNewBand(); err != nil {
+		return fmt.Errorf("transmission aborted while tuning")
 	}
 
-	// Use normal mode for now
-	mode := dsp.ModeNormal
+	// Set the message's power before keying, put back once PTT is off
+	defer e.setPower(powerTX, msg.Power)()
 
-	// Encode to audio samples
-	audioData, err := e.dspEngine.EncodeMessage(txMessage, mode)
+	// A half-duplex sound card stops capturing to play, until unkeyed
+	toRX, err := e.audioToTX()
 	if err != nil {
-		return fmt.Errorf("DSP encoding failed: %w", err)
+		return err
 	}
+	defer toRX()
 
-	log.Printf("DSP: Encoded '%s' to %d audio samples", txMessage, len(audioData))
+	// Key the amplifier and rig, and unkey them in turn when done
+	unkey, err := e.keyTransmitter()
+	if err != nil {
+		return err
+	}
+	aborted := false
+	defer func() { unkey(aborted) }()
 
-	// Send audio data to hardware audio system for output
-	if err := e.hardwareManager.PlayAudio(audioData); err != nil {
-		return fmt.Errorf("audio output failed: %w", err)
+	// The submode chosen when the message was queued, Normal for one
+	// queued without
+	mode := dsp.ModeNormal
+	if msg.Submode != "" {
+		if mode, err = dsp.ParseJS8Mode(msg.Submode); err != nil {
+			logger.Warnf("TX in normal submode: %v", err)
+		}
 	}
 
-	// Wait for transmission to complete with abort monitoring
-	duration := e.dspEngine.EstimateAudioDuration(mode)
+	// The SWR is read once a second on the air, until it raises an alarm
+	watchSWR := e.watchesSWR()
+	nextSWRCheck := time.Now().Add(time.Second)
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
-	endTime := time.Now().Add(duration)
-	for time.Now().Before(endTime) {
-		select {
-		case <-e.abortTx:
-			log.Printf("DSP: Transmission aborted by user")
-			return fmt.Errorf("transmission aborted")
-		case <-ticker.C:
-			// Continue waiting
+	// A message sent whole as data frames takes a transmission for each
+	for _, frame := range txFrames(msg) {
+		// Encode to audio samples at the TX offset
+		if encoder, ok := e.dspEngine.(dsp.OffsetEncoder); ok {
+			encoder.SetTXOffset(float64(e.txOffset() + e.resendShift(msg.ID)))
+		}
+		if encoder, ok := e.dspEngine.(dsp.TransmissionEncoder); ok {
+			encoder.SetTXTransmission(frame.transmission)
+		}
+		audioData, err := e.dspEngine.EncodeMessage(frame.text, mode)
+		if err != nil {
+			return fmt.Errorf("DSP encoding failed: %w", err)
+		}
+
+		dspLogger.Infof("Encoded '%s' in %s to %d audio samples", frame.text, mode, len(audioData))
+
+		// VOX needs a moment of tone to trip on
+		audioData, leader := e.voxLeader(audioData)
+		audioData = e.txAudio(audioData)
+
+		// Send audio data to hardware audio system for output
+		if err := e.hardwareManager.PlayAudio(audioData); err != nil {
+			return fmt.Errorf("audio output failed: %w", err)
+		}
+
+		// Wait for transmission to complete with abort monitoring
+		duration := e.dspEngine.EstimateAudioDuration(mode) + leader
+		endTime := time.Now().Add(duration)
+		for time.Now().Before(endTime) {
+			select {
+			case <-e.abortTx:
+				dspLogger.Infof("Transmission aborted by user")
+				aborted = true
+				return fmt.Errorf("transmission aborted")
+			case <-ticker.C:
+				if watchSWR && time.Now().After(nextSWRCheck) {
+					watchSWR = !e.checkSWR(msg)
+					nextSWRCheck = time.Now().Add(time.Second)
+				}
+			}
 		}
 	}
 
-	log.Printf("DSP: Transmission complete")
+	dspLogger.Infof("Transmission complete")
 
 	// Update OLED display with transmission status
-	e.updateOLEDDisplay(fmt.Sprintf("TX: %s", txMessage))
+	display := msg.Message
+	if len(display) > 12 {
+		display = display[:12]
+	}
+	e.updateOLEDDisplay(fmt.Sprintf("TX: %s", display))
 
 	return nil
 }
 
-// audioProcessor handles incoming audio data and decoding
-func (e *CoreEngine) audioProcessor() {
-	inputSamples := e.hardwareManager.GetAudioInputSamples()
-
+// audioProcessor accumulates pre-filtered RX audio and decodes it
+func (e *CoreEngine) audioProcessor(stop <-chan struct{}) {
 	// If audio is not available, just exit
-	if inputSamples == nil {
-		log.Printf("Audio input not available, audio processor disabled")
+	if e.hardwareManager.GetAudioInputSamples() == nil {
+		logger.Warnf("Audio input not available, audio processor disabled")
 		return
 	}
 
-	// Buffer for accumulating samples for decoding
-	var audioBuffer []int16
+	// Buffers for accumulating samples for decoding, one per RX channel
+	audioBuffers := make([][]int16, len(e.rxChannels))
 	const bufferLimit = 15 * 48000 // 15 seconds at 48kHz max
 
 	for e.isRunning() {
 		select {
-		case samples := <-inputSamples:
-			// Accumulate audio samples
-			audioBuffer = append(audioBuffer, samples...)
+		case <-stop:
+			return
+
+		case block := <-e.rxAudio:
+			if now := time.Now(); e.pipeline.record(now.Sub(block.captured), now) {
+				e.warnBehind()
+			}
+
+			// Accumulate pre-filtered audio samples per channel
+			for ch, channelSamples := range block.channels {
+				audioBuffers[ch] = append(audioBuffers[ch], channelSamples...)
+			}
 
 			// Optionally recycle the buffer for improved performance
 			// This helps reduce GC pressure on resource-constrained devices
-			hardware.RecycleAudioSamples(samples)
-
-			// If buffer gets too large, trim it to prevent memory issues
-			if len(audioBuffer) > bufferLimit {
-				// Keep last 10 seconds worth
-				keepSamples := 10 * 48000
-				if len(audioBuffer) > keepSamples {
-					audioBuffer = audioBuffer[len(audioBuffer)-keepSamples:]
+			hardware.RecycleAudioSamples(block.raw)
+
+			for ch, audioBuffer := range audioBuffers {
+				// If buffer gets too large, trim it to prevent memory issues
+				if len(audioBuffer) > bufferLimit {
+					// Keep last 10 seconds worth
+					keepSamples := 10 * 48000
+					if len(audioBuffer) > keepSamples {
+						audioBuffer = audioBuffer[len(audioBuffer)-keepSamples:]
+						audioBuffers[ch] = audioBuffer
+					}
 				}
-			}
 
-			// Try to decode if we have enough samples (at least 3 seconds)
-			minSamples := 3 * 48000
-			if len(audioBuffer) >= minSamples {
-				e.attemptDecode(audioBuffer)
+				// Try to decode if we have enough samples (at least 3 seconds)
+				minSamples := 3 * 48000
+				if len(audioBuffer) >= minSamples {
+					e.attemptDecode(ch, audioBuffer)
+				}
 			}
 
 		case <-time.After(1 * time.Second):
-			// Periodic cleanup - try to decode accumulated buffer
-			if len(audioBuffer) > 0 {
-				e.attemptDecode(audioBuffer)
-				// Clear buffer after decode attempt
-				audioBuffer = audioBuffer[:0]
+			// Periodic cleanup - try to decode accumulated buffers
+			for ch, audioBuffer := range audioBuffers {
+				if len(audioBuffer) > 0 {
+					e.attemptDecode(ch, audioBuffer)
+					// Clear buffer after decode attempt
+					audioBuffers[ch] = audioBuffer[:0]
+				}
 			}
 		}
 	}
 }
 
-// attemptDecode tries to decode JS8 messages from audio buffer
-func (e *CoreEngine) attemptDecode(audioBuffer []int16) {
-	if len(audioBuffer) == 0 {
+// attemptDecode tries to decode JS8 messages from one RX channel's audio buffer
+func (e *CoreEngine) attemptDecode(ch int, audioBuffer []int16) {
+	if len(audioBuffer) == 0 || ch >= len(e.rxDecoders) {
 		return
 	}
+	channel := e.rxChannels[ch]
+	decodeStart := time.Now()
+
+	dial := e.readDial()
+
+	e.mutex.RLock()
+	ppm := e.config.Radio.CalibrationPPM
+	drift := e.driftEstimator
+	rawFrames := e.config.Storage.RawFrames
+	snippetSeconds := e.config.Storage.SnippetSeconds
+	var monitor *audio.AudioLevelMonitor
+	if ch < len(e.audioMonitors) {
+		monitor = e.audioMonitors[ch]
+	}
+	e.mutex.RUnlock()
+	noiseFloor := decodeNoiseFloor(monitor)
+	sampleRate := e.hardwareManager.GetConfig().SampleRate
 
-	// Use DSP to decode the audio buffer
-	decodeCount, err := e.dspEngine.DecodeBuffer(audioBuffer, func(result *dsp.DecodeResult) {
+	// Use the channel's own decoder on the audio buffer
+	decodeCount, err := e.rxDecoders[ch].DecodeBuffer(audioBuffer, func(result *dsp.DecodeResult) {
 		// Parse JS8 message to extract callsigns and determine message type
 		msg := e.parseJS8Message(result)
+		msg.Channel = channel
+		msg.Offset = int(math.Round(dsp.Calibrate(float64(result.Frequency), ppm)))
+		msg.Dial = dial
+		msg.Frequency = dial + msg.Offset
+		msg.Band = protocol.Band(msg.Frequency)
+		msg.NoiseFloor = noiseFloor
+		if reason := e.selfDecode(msg, time.Now()); reason != "" {
+			e.handleSelfDecode(msg, reason)
+			return
+		}
+		e.noteSignal(msg)
+		if e.filterDecode(msg) {
+			return
+		}
+		if rawFrames && len(result.Payload) > 0 {
+			msg.Raw = &protocol.RawFrame{Payload: result.Payload, Tones: result.Tones}
+		}
+		if e.snippets != nil {
+			msg.Audio = captureSnippet(audioBuffer, sampleRate, result.DT, snippetSeconds)
+		}
+		if e.recorder != nil {
+			e.markRecording(msg)
+		}
+		if drift != nil && msg.From != "UNKNOWN" {
+			drift.Record(msg.From, float64(result.Frequency), msg.Timestamp)
+		}
 
 		// Queue the received message
 		select {
 		case e.rxMessages <- msg:
-			log.Printf("RX decoded: %s (SNR: %ddB, Freq: %.1fHz, Type: %s)",
+			dspLogger.Infof("RX decoded: %s (SNR: %ddB, Freq: %.1fHz, Type: %s)",
 				result.Message, result.SNR, result.Frequency, e.getMessageType(result.Message))
 		default:
-			log.Printf("RX buffer full, dropping message: %s", result.Message)
+			dspLogger.Warnf("RX buffer full, dropping message: %s", result.Message)
 		}
 	})
 
 	if err != nil {
-		log.Printf("Decode error: %v", err)
+		dspLogger.Errorf("Decode error: %v", err)
 	} else if decodeCount > 0 {
-		log.Printf("Decoded %d message(s) from audio buffer", decodeCount)
+		dspLogger.Debugf("Decoded %d message(s) from audio buffer", decodeCount)
 	}
+
+	elapsed := time.Since(decodeStart)
+	e.pipeline.decoded(elapsed)
+	e.governDecode(elapsed)
+}
+
+// governDecode feeds decode time to the governor and applies any new decode
+// limits to every RX decoder
+func (e *CoreEngine) governDecode(elapsed time.Duration) {
+	e.mutex.RLock()
+	governor := e.decodeGovernor
+	e.mutex.RUnlock()
+	if governor == nil {
+		return
+	}
+
+	limits, changed := governor.Record(elapsed, time.Now())
+	if !changed || e.lowPowerDecode() {
+		return
+	}
+	e.applyDecodeLimits(limits)
+	dspLogger.Infof("Decoding used %.0f%% of the cycle, decode level now %d (FFT %d, %d candidates)",
+		governor.LastLoad()*100, governor.Level(), limits.FFTSize, limits.MaxCandidates)
+}
+
+// applyDecodeLimits sets the decode limits on every RX decoder that supports them
+func (e *CoreEngine) applyDecodeLimits(limits dsp.DecodeLimits) {
+	for _, decoder := range e.rxDecoders {
+		if limited, ok := decoder.(dsp.LimitedDecoder); ok {
+			limited.SetDecodeLimits(limits)
+		}
+	}
+}
+
+// GetDecodeGovernorStatistics returns the adaptive decode state, or nil when disabled
+func (e *CoreEngine) GetDecodeGovernorStatistics() map[string]interface{} {
+	e.mutex.RLock()
+	governor := e.decodeGovernor
+	e.mutex.RUnlock()
+	if governor == nil {
+		return nil
+	}
+	return governor.GetStatistics()
 }
 
 // parseJS8Message parses a JS8 decode result into a protocol message
@@ -743,15 +1943,19 @@ func (e *CoreEngine) parseJS8Message(result *dsp.DecodeResult) protocol.Message
 		fromCall = "UNKNOWN"
 	}
 
+	// Decodes are stamped with the corrected time when time_sync adjusts them
+	now := e.now()
 	return protocol.Message{
-		ID:        int(time.Now().Unix()),
-		Timestamp: time.Now(),
-		From:      fromCall,
-		To:        toCall,
-		Message:   message,
-		SNR:       float32(result.SNR),
-		Frequency: int(result.Frequency),
-		Mode:      "JS8",
+		ID:           int(now.Unix()),
+		Timestamp:    now,
+		From:         fromCall,
+		To:           toCall,
+		Message:      message,
+		SNR:          float32(result.SNR),
+		Offset:       int(result.Frequency), // Frequency is the dial plus this, set by the caller
+		Mode:         "JS8",
+		Submode:      dsp.JS8Mode(result.Mode).String(),
+		Transmission: uint8(result.Type),
 	}
 }
 
@@ -783,17 +1987,38 @@ func (e *CoreEngine) isDirectedToMe(message string) bool {
 	return len(message) > len(myCall) && message[:len(myCall)] == myCall
 }
 
+// directedCommand returns the command of a decode directed to a station,
+// the text after its callsign with the leading space, such as " SNR?" from
+// "K3DEP SNR?" or "DL1ABC: K3DEP SNR?". Text not naming the station is
+// returned as it is.
+func directedCommand(message, to string) string {
+	words := strings.Fields(message)
+	for i, word := range words {
+		if strings.EqualFold(word, to) {
+			return " " + strings.Join(words[i+1:], " ")
+		}
+	}
+	return message
+}
+
 // handleAutoReply processes messages that require automatic responses
 func (e *CoreEngine) handleAutoReply(msg protocol.Message) {
+	if !e.autoReplyEnabled() {
+		return
+	}
+
 	// Only auto-reply to messages directed to us
 	if msg.To != e.config.Station.Callsign || msg.From == e.config.Station.Callsign {
 		return
 	}
 
-	message := msg.Message
+	message := directedCommand(msg.Message, msg.To)
 
 	// Check for SNR requests
 	if dsp.IsSNRCommand(message) {
+		if e.autoListRefusal(rateReply, msg.From) != "" || !e.rateAllowed(rateReply, msg.From) {
+			return
+		}
 		snr := int(msg.SNR)
 		response := fmt.Sprintf("%s %s", msg.From, dsp.FormatSNR(snr))
 
@@ -807,11 +2032,10 @@ func (e *CoreEngine) handleAutoReply(msg protocol.Message) {
 		}
 
 		// Queue the auto-reply
-		select {
-		case e.txMessages <- replyMsg:
-			log.Printf("Auto-reply queued: SNR report %s to %s", dsp.FormatSNR(snr), msg.From)
-		default:
-			log.Printf("TX queue full, dropping auto-reply to %s", msg.From)
+		if _, ok := e.queueTX(replyMsg); ok {
+			logger.Infof("Auto-reply queued: SNR report %s to %s", dsp.FormatSNR(snr), msg.From)
+		} else {
+			logger.Warnf("TX queue full, dropping auto-reply to %s", msg.From)
 		}
 	}
 
@@ -829,25 +2053,42 @@ func (e *CoreEngine) heartbeatGenerator() {
 		case <-ticker.C:
 			e.sendHeartbeat()
 
-		case <-time.After(30 * time.Second):
-			// Keep the goroutine alive
-			continue
+		case <-e.ctx.Done():
+			return
 		}
 	}
 }
 
 // updateOLEDDisplay updates the OLED display with current station info
 func (e *CoreEngine) updateOLEDDisplay(lastMessage string) {
-	if e.hardwareManager == nil {
+	e.oledMutex.Lock()
+	e.oledMessage = lastMessage
+	e.oledMutex.Unlock()
+
+	e.refreshOLEDDisplay()
+}
+
+// refreshOLEDDisplay redraws the OLED, showing an active alarm in place of
+// the last message
+func (e *CoreEngine) refreshOLEDDisplay() {
+	if e.hardwareManager == nil || e.lowPowerActive(e.config.Power.DisableOLED) {
 		return
 	}
 
+	e.oledMutex.Lock()
+	lastMessage := e.oledMessage
+	for _, alarm := range e.oledAlarms {
+		lastMessage = alarm
+		break
+	}
+	e.oledMutex.Unlock()
+
 	callsign := e.config.Station.Callsign
 	grid := e.config.Station.Grid
 	frequency := e.frequency
 
 	if err := e.hardwareManager.UpdateOLED(callsign, grid, frequency, lastMessage); err != nil {
-		log.Printf("Warning: failed to update OLED: %v", err)
+		logger.Warnf("Failed to update OLED: %v", err)
 	}
 }
 
@@ -859,10 +2100,26 @@ func (e *CoreEngine) sendHeartbeat() {
 	if callsign == "" {
 		return // Can't send heartbeat without callsign
 	}
+	if offset, off := e.clockOff(); off {
+		logger.Warnf("Clock is %.1f s off, not sending heartbeat", offset.Seconds())
+		return
+	}
+	if err := e.txInhibitedError(); err != nil {
+		logger.Infof("Not sending heartbeat: %v", err)
+		return
+	}
 
 	// Format heartbeat message: "HBAUTO" + callsign + grid (no spaces - JS8 doesn't support them)
 	var hbMessage string
-	if grid != "" {
+	if dsp.IsCompoundCallsign(callsign) {
+		// Portable and DX-prefixed callsigns go out as a compound frame
+		frame, err := dsp.PackCompoundGrid(callsign, grid)
+		if err != nil {
+			logger.Warnf("Cannot pack heartbeat for %s: %v", callsign, err)
+			return
+		}
+		hbMessage = frame
+	} else if grid != "" {
 		hbMessage = fmt.Sprintf("HBAUTO%s%s", callsign, grid)
 	} else {
 		hbMessage = fmt.Sprintf("HBAUTO%s", callsign)
@@ -883,11 +2140,10 @@ func (e *CoreEngine) sendHeartbeat() {
 	}
 
 	// Queue the heartbeat
-	select {
-	case e.txMessages <- heartbeat:
-		log.Printf("Heartbeat queued: %s", hbMessage)
-	default:
-		log.Printf("TX queue full, dropping heartbeat")
+	if _, ok := e.queueTX(heartbeat); ok {
+		logger.Infof("Heartbeat queued: %s", hbMessage)
+	} else {
+		logger.Warnf("TX queue full, dropping heartbeat")
 	}
 }
 
@@ -896,14 +2152,17 @@ func (e *CoreEngine) SetRadioFrequency(freq int64) error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
-	// Set radio frequency
-	if err := e.hardwareManager.SetRadioFrequency(freq); err != nil {
+	// Set radio frequency, allowing for the rig's calibration
+	if err := e.hardwareManager.SetRadioFrequency(e.uncalibrate(freq)); err != nil {
 		return fmt.Errorf("failed to set radio frequency: %w", err)
 	}
 
 	// Update engine frequency state
 	e.frequency = int(freq)
-	log.Printf("Engine: Radio frequency set to %.3f MHz", float64(freq)/1000000.0)
+	logger.Infof("Radio frequency set to %.3f MHz", float64(freq)/1000000.0)
+
+	// Follow the band with the antenna switch
+	e.switchAntenna(e.frequency)
 	return nil
 }
 
@@ -912,7 +2171,33 @@ func (e *CoreEngine) GetRadioFrequency() (int64, error) {
 	e.mutex.RLock()
 	defer e.mutex.RUnlock()
 
-	return e.hardwareManager.GetRadioFrequency()
+	freq, err := e.hardwareManager.GetRadioFrequency()
+	if err != nil {
+		return 0, err
+	}
+	return e.calibrate(freq), nil
+}
+
+// readDial returns the dial frequency, read from the rig when it is
+// connected so decodes follow retuning at the rig itself, and otherwise the
+// last one set
+func (e *CoreEngine) readDial() int {
+	dial := e.dialFrequency()
+	if e.hardwareManager == nil || !e.hardwareManager.IsRadioConnected() {
+		return dial
+	}
+	freq, err := e.GetRadioFrequency()
+	if err != nil || freq <= 0 {
+		return dial
+	}
+
+	if int(freq) != dial {
+		e.mutex.Lock()
+		e.frequency = int(freq)
+		e.mutex.Unlock()
+		logger.Infof("Rig retuned to %.3f MHz", float64(freq)/1000000.0)
+	}
+	return int(freq)
 }
 
 // SetRadioMode sets the radio mode for JS8 operation
@@ -930,14 +2215,14 @@ func (e *CoreEngine) EnablePTT() error {
 
 	// Set both GPIO and radio PTT
 	if err := e.hardwareManager.SetPTT(true); err != nil {
-		log.Printf("Warning: GPIO PTT failed: %v", err)
+		logger.Warnf("GPIO PTT failed: %v", err)
 	}
 
 	if err := e.hardwareManager.SetRadioPTT(true); err != nil {
 		return fmt.Errorf("failed to enable radio PTT: %w", err)
 	}
 
-	log.Printf("Engine: PTT enabled")
+	logger.Infof("PTT enabled")
 	return nil
 }
 
@@ -948,14 +2233,14 @@ func (e *CoreEngine) DisablePTT() error {
 
 	// Disable both radio and GPIO PTT
 	if err := e.hardwareManager.SetRadioPTT(false); err != nil {
-		log.Printf("Warning: Radio PTT disable failed: %v", err)
+		logger.Warnf("Radio PTT disable failed: %v", err)
 	}
 
 	if err := e.hardwareManager.SetPTT(false); err != nil {
-		log.Printf("Warning: GPIO PTT disable failed: %v", err)
+		logger.Warnf("GPIO PTT disable failed: %v", err)
 	}
 
-	log.Printf("Engine: PTT disabled")
+	logger.Infof("PTT disabled")
 	return nil
 }
 
@@ -969,7 +2254,7 @@ func (e *CoreEngine) GetRadioStatus() map[string]interface{} {
 	}
 
 	if freq, err := e.hardwareManager.GetRadioFrequency(); err == nil {
-		status["frequency"] = freq
+		status["frequency"] = e.calibrate(freq)
 	}
 
 	if mode, bandwidth, err := e.hardwareManager.GetRadioMode(); err == nil {
@@ -998,34 +2283,25 @@ func (e *CoreEngine) GetRadioStatus() map[string]interface{} {
 
 // handleAbort aborts any ongoing transmission and turns off PTT
 func (e *CoreEngine) handleAbort() *protocol.Response {
-	e.txMutex.Lock()
-	isTransmitting := e.transmitting
-	e.txMutex.Unlock()
+	isTransmitting := e.isTransmitting()
 
 	if isTransmitting {
 		// Signal abort to any ongoing transmission
 		select {
 		case e.abortTx <- true:
-			log.Printf("Engine: Transmission abort signal sent")
+			logger.Infof("Transmission abort signal sent")
 		default:
 			// Channel is full or no one is listening, but that's ok
 		}
 	}
 
 	// Force PTT off immediately (both GPIO and radio)
-	if err := e.hardwareManager.SetRadioPTT(false); err != nil {
-		log.Printf("Warning: failed to clear radio PTT during abort: %v", err)
-	}
-	if err := e.hardwareManager.SetPTT(false); err != nil {
-		log.Printf("Warning: failed to clear GPIO PTT during abort: %v", err)
-	}
+	e.dropPTT()
 
-	// Update engine PTT state
-	e.mutex.Lock()
-	e.ptt = false
-	e.mutex.Unlock()
+	// Nothing more of a scripted QSO goes out
+	e.endQSO("", protocol.QSOStopped)
 
-	log.Printf("Engine: Emergency transmission abort completed")
+	logger.Infof("Emergency transmission abort completed")
 
 	return protocol.NewSuccessResponse(map[string]interface{}{
 		"status":        "aborted",
@@ -1051,73 +2327,65 @@ func (e *CoreEngine) handleReload() *protocol.Response {
 		return protocol.NewErrorResponse(fmt.Sprintf("invalid configuration: %v", err))
 	}
 
-	// Check if audio configuration changed
-	e.mutex.Lock()
-	oldCallsign := e.config.Station.Callsign
-	oldGrid := e.config.Station.Grid
-	audioChanged := (e.config.Audio.InputDevice != newConfig.Audio.InputDevice ||
-		e.config.Audio.OutputDevice != newConfig.Audio.OutputDevice ||
-		e.config.Audio.SampleRate != newConfig.Audio.SampleRate)
-	e.config = newConfig
-	e.mutex.Unlock()
-
-	log.Printf("Engine: Configuration reloaded from %s", e.configPath)
-	log.Printf("Engine: Station updated - %s (%s)", newConfig.Station.Callsign, newConfig.Station.Grid)
-
-	// Warn about audio changes - full reinit would require restart
-	if audioChanged {
-		log.Printf("Engine: Warning - Audio configuration changed. Full restart recommended for audio changes.")
-		return protocol.NewSuccessResponse(map[string]interface{}{
-			"status":       "reloaded",
-			"config_path":  e.configPath,
-			"old_callsign": oldCallsign,
-			"new_callsign": newConfig.Station.Callsign,
-			"old_grid":     oldGrid,
-			"new_grid":     newConfig.Station.Grid,
-			"warning":      "Audio configuration changed - restart recommended",
-		})
+	// In a multi-rig daemon each engine picks its own instance from the file
+	e.mutex.RLock()
+	instance := e.config.Instance
+	profile := e.config.Profile
+	previousDevice := e.baseConfig.Audio.InputDevice
+	e.mutex.RUnlock()
+	if instance != "" {
+		if newConfig, err = newConfig.InstanceConfig(instance); err != nil {
+			return protocol.NewErrorResponse(fmt.Sprintf("invalid configuration: %v", err))
+		}
 	}
 
-	return protocol.NewSuccessResponse(map[string]interface{}{
-		"status":       "reloaded",
-		"config_path":  e.configPath,
-		"old_callsign": oldCallsign,
-		"new_callsign": newConfig.Station.Callsign,
-		"old_grid":     oldGrid,
-		"new_grid":     newConfig.Station.Grid,
-	})
-}
-
-// Stop gracefully shuts down the core engine
-func (e *CoreEngine) Stop() error {
-	log.Printf("Stopping core engine...")
-
-	// Stop the engine
-	e.mutex.Lock()
-	e.running = false
-	e.mutex.Unlock()
-
-	// Close listener if it exists
-	if e.listener != nil {
-		if err := e.listener.Close(); err != nil {
-			log.Printf("Error closing listener: %v", err)
+	// A sound card swapped in the file brings back its remembered settings
+	if newConfig.RecallAudioDevice(previousDevice, nil) {
+		logger.Infof("Audio settings recalled for %s", newConfig.Audio.InputDevice)
+		if err := newConfig.Validate(); err != nil {
+			return protocol.NewErrorResponse(fmt.Sprintf("invalid configuration: %v", err))
 		}
 	}
 
-	// Close message store
-	if e.messageStore != nil {
-		if err := e.messageStore.Close(); err != nil {
-			log.Printf("Error closing message store: %v", err)
+	// Stay on the active profile while the file still has it
+	base := newConfig
+	if base.FindProfile(profile) == nil {
+		profile = base.Profile
+	}
+	if profile != "" {
+		if newConfig, err = base.ProfileConfig(profile); err != nil {
+			return protocol.NewErrorResponse(fmt.Sprintf("invalid configuration: %v", err))
 		}
 	}
 
-	// Close hardware manager
-	if e.hardwareManager != nil {
-		e.hardwareManager.Close()
+	if err := e.checkDecoding(newConfig); err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("invalid configuration: %v", err))
 	}
 
-	log.Printf("Core engine stopped")
-	return nil
+	// Swapping the sound card or rig mid-transmission would leave PTT keyed
+	if e.isTransmitting() {
+		return protocol.NewErrorResponse("cannot reload while transmitting")
+	}
+
+	oldConfig, restarted, err := e.applyConfig(base, newConfig)
+
+	logger.Infof("Configuration reloaded from %s", e.configPath)
+	logger.Infof("Station updated - %s (%s)", newConfig.Station.Callsign, newConfig.Station.Grid)
+
+	data := map[string]interface{}{
+		"status":       "reloaded",
+		"config_path":  e.configPath,
+		"old_callsign": oldConfig.Station.Callsign,
+		"new_callsign": newConfig.Station.Callsign,
+		"old_grid":     oldConfig.Station.Grid,
+		"new_grid":     newConfig.Station.Grid,
+		"profile":      newConfig.Profile,
+		"restarted":    restarted,
+	}
+	if err != nil {
+		data["warning"] = fmt.Sprintf("Configuration saved but hardware failed to restart: %v", err)
+	}
+	return protocol.NewSuccessResponse(data)
 }
 
 // classifyMessage determines the type of a JS8 message
@@ -1197,6 +2465,7 @@ func (e *CoreEngine) handleGetMessageHistory(args []string) *protocol.Response {
 	if err != nil {
 		return protocol.NewErrorResponse(fmt.Sprintf("failed to get messages: %v", err))
 	}
+	e.addPaths(messages)
 
 	return protocol.NewSuccessResponse(map[string]interface{}{
 		"messages": messages,
@@ -1222,6 +2491,27 @@ func (e *CoreEngine) handleGetConversations(args []string) *protocol.Response {
 		return protocol.NewErrorResponse(fmt.Sprintf("failed to get conversations: %v", err))
 	}
 
+	// Split each conversation into its QSOs
+	gap := time.Duration(e.config.Messages.SessionGap) * time.Minute
+	for i := range conversations {
+		sessions, err := e.messageStore.GetSessions(conversations[i].Callsign, gap)
+		if err != nil {
+			return protocol.NewErrorResponse(fmt.Sprintf("failed to get sessions: %v", err))
+		}
+		conversations[i].Sessions = sessions
+
+		// What the station database knows about the other station
+		station, err := e.messageStore.GetStation(conversations[i].Callsign)
+		if err != nil {
+			return protocol.NewErrorResponse(fmt.Sprintf("failed to get station: %v", err))
+		}
+		if station != nil {
+			station.Path = e.pathTo(station.Grid)
+			station.Entity = e.entityOf(station.Callsign)
+		}
+		conversations[i].Station = station
+	}
+
 	return protocol.NewSuccessResponse(map[string]interface{}{
 		"conversations": conversations,
 		"count":         len(conversations),
@@ -1243,6 +2533,12 @@ func (e *CoreEngine) handleMarkMessagesRead(args []string) *protocol.Response {
 		return protocol.NewErrorResponse(fmt.Sprintf("failed to mark messages as read: %v", err))
 	}
 
+	// Other clients clear their unread markers too
+	e.publishEvent(protocol.EventMessagesRead, map[string]interface{}{
+		"callsign": callsign,
+		"unread":   e.unreadCount(),
+	})
+
 	return protocol.NewSuccessResponse(map[string]interface{}{
 		"status":   "success",
 		"callsign": callsign,
@@ -1271,6 +2567,7 @@ func (e *CoreEngine) handleSearchMessages(args []string) *protocol.Response {
 	if err != nil {
 		return protocol.NewErrorResponse(fmt.Sprintf("search failed: %v", err))
 	}
+	e.addPaths(messages)
 
 	return protocol.NewSuccessResponse(map[string]interface{}{
 		"messages": messages,
@@ -1295,6 +2592,14 @@ func (e *CoreEngine) handleGetMessageStats() *protocol.Response {
 		"total_rx":       stats.TotalRX,
 		"total_tx":       stats.TotalTX,
 		"last_cleanup":   stats.LastCleanup,
+		"dedup": map[string]interface{}{
+			"duplicates":     stats.Duplicates,
+			"last_duplicate": stats.LastDuplicate,
+			"window":         storage.DedupWindow.Seconds(),
+		},
+		"integrity":    e.databaseStatus(),
+		"snippets":     e.snippetStats(),
+		"self_decodes": e.selfDecodeCount(),
 	})
 }
 
@@ -1318,12 +2623,12 @@ func (e *CoreEngine) handleCleanupMessages() *protocol.Response {
 	// Get count after cleanup to see how many were deleted
 	newCount, err := e.messageStore.GetMessageCount()
 	if err != nil {
-		log.Printf("Warning: failed to get post-cleanup count: %v", err)
+		logger.Warnf("Failed to get post-cleanup count: %v", err)
 		newCount = currentCount // Fallback
 	}
 
 	deletedCount := currentCount - newCount
-	log.Printf("Manual cleanup completed: %d messages deleted", deletedCount)
+	logger.Infof("Manual cleanup completed: %d messages deleted", deletedCount)
 
 	return protocol.NewSuccessResponse(map[string]interface{}{
 		"status":        "success",
@@ -1347,7 +2652,7 @@ func (e *CoreEngine) handleTestCAT(args []string) *protocol.Response {
 	}
 
 	// TODO: Implement actual CAT testing via hardware manager
-	log.Printf("Testing CAT: device=%s model=%s baud=%d", device, model, baudRate)
+	logger.Infof("Testing CAT: device=%s model=%s baud=%d", device, model, baudRate)
 
 	return protocol.NewSuccessResponse(map[string]interface{}{
 		"status":  "success",
@@ -1371,28 +2676,28 @@ func (e *CoreEngine) handleTestPTT(args []string) *protocol.Response {
 		return protocol.NewErrorResponse("invalid delay value")
 	}
 
-	log.Printf("Testing PTT: method=%s port=%s delay=%.1f", method, port, delay)
+	logger.Infof("Testing PTT: method=%s port=%s delay=%.1f", method, port, delay)
 
 	// Test PTT via hardware manager
 	if e.hardwareManager == nil {
-		return protocol.NewErrorResponse("hardware manager not available")
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeNotConnected, "hardware manager not available")
 	}
 
 	// Check if radio is connected for CAT PTT
 	if method == "cat" && !e.hardwareManager.IsRadioConnected() {
-		return protocol.NewErrorResponse("radio not connected - CAT PTT requires working radio connection")
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeNotConnected, "radio not connected - CAT PTT requires working radio connection")
 	}
 
 	// Test PTT activation
-	log.Printf("Activating PTT...")
+	logger.Infof("Activating PTT...")
 	if err := e.hardwareManager.SetRadioPTT(true); err != nil {
-		return protocol.NewErrorResponse(fmt.Sprintf("failed to activate PTT: %v", err))
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeRadio, fmt.Sprintf("failed to activate PTT: %v", err))
 	}
 
 	// For toggle mode, just activate and return success
 	// Don't automatically turn off - let user control it
 	if delay <= 1.0 {
-		log.Printf("PTT activated for toggle mode")
+		logger.Infof("PTT activated for toggle mode")
 		return protocol.NewSuccessResponse(map[string]interface{}{
 			"status":  "success",
 			"method":  method,
@@ -1406,9 +2711,9 @@ func (e *CoreEngine) handleTestPTT(args []string) *protocol.Response {
 	time.Sleep(time.Duration(delay * float64(time.Second)))
 
 	// Deactivate PTT
-	log.Printf("Deactivating PTT...")
+	logger.Infof("Deactivating PTT...")
 	if err := e.hardwareManager.SetRadioPTT(false); err != nil {
-		return protocol.NewErrorResponse(fmt.Sprintf("failed to deactivate PTT: %v", err))
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeRadio, fmt.Sprintf("failed to deactivate PTT: %v", err))
 	}
 
 	return protocol.NewSuccessResponse(map[string]interface{}{
@@ -1424,16 +2729,16 @@ func (e *CoreEngine) handleTestPTT(args []string) *protocol.Response {
 func (e *CoreEngine) handleTestPTTOff() *protocol.Response {
 	// Test PTT via hardware manager
 	if e.hardwareManager == nil {
-		return protocol.NewErrorResponse("hardware manager not available")
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeNotConnected, "hardware manager not available")
 	}
 
 	// Deactivate PTT
-	log.Printf("Deactivating PTT...")
+	logger.Infof("Deactivating PTT...")
 	if err := e.hardwareManager.SetRadioPTT(false); err != nil {
-		return protocol.NewErrorResponse(fmt.Sprintf("failed to deactivate PTT: %v", err))
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeRadio, fmt.Sprintf("failed to deactivate PTT: %v", err))
 	}
 
-	log.Printf("PTT deactivated successfully")
+	logger.Infof("PTT deactivated successfully")
 	return protocol.NewSuccessResponse(map[string]interface{}{
 		"status":  "success",
 		"message": "PTT deactivated successfully",
@@ -1443,15 +2748,15 @@ func (e *CoreEngine) handleTestPTTOff() *protocol.Response {
 // handleRetryRadio handles RETRY_RADIO command to reconnect radio
 func (e *CoreEngine) handleRetryRadio() *protocol.Response {
 	if e.hardwareManager == nil {
-		return protocol.NewErrorResponse("hardware manager not available")
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeNotConnected, "hardware manager not available")
 	}
 
-	log.Printf("Attempting to retry radio connection...")
+	logger.Infof("Attempting to retry radio connection...")
 
 	// Try to reconnect the radio
 	if err := e.hardwareManager.RetryRadioConnection(); err != nil {
-		log.Printf("Radio retry failed: %v", err)
-		return protocol.NewErrorResponse(fmt.Sprintf("radio retry failed: %v", err))
+		logger.Errorf("Radio retry failed: %v", err)
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeRadio, fmt.Sprintf("radio retry failed: %v", err))
 	}
 
 	return protocol.NewSuccessResponse(map[string]interface{}{
@@ -1460,19 +2765,21 @@ func (e *CoreEngine) handleRetryRadio() *protocol.Response {
 	})
 }
 
-// processAudioSamples processes incoming audio samples for monitoring
-func (e *CoreEngine) processAudioSamples() {
-	log.Printf("Starting audio sample processing for monitoring")
+// processAudioSamples reads captured audio, feeds the level monitor and
+// pre-filters each RX channel for the decoder
+func (e *CoreEngine) processAudioSamples(stop <-chan struct{}) {
+	logger.Infof("Starting audio sample processing for monitoring")
 
 	// Get the audio input samples channel
 	audioSamples := e.hardwareManager.GetAudioInputSamples()
 	if audioSamples == nil {
-		log.Printf("Warning: no audio input samples available - check audio configuration")
+		logger.Warnf("No audio input samples available - check audio configuration")
 		return
 	}
 
-	log.Printf("Audio sample processing ready - waiting for samples...")
+	logger.Infof("Audio sample processing ready - waiting for samples...")
 	sampleCount := 0
+	sampleRate := e.hardwareManager.GetConfig().SampleRate
 
 	// Set up a debug timer to report if we're not getting samples
 	debugTicker := time.NewTicker(5 * time.Second)
@@ -1481,62 +2788,71 @@ func (e *CoreEngine) processAudioSamples() {
 
 	for {
 		select {
+		case <-stop:
+			return
+
 		case samples, ok := <-audioSamples:
 			if !ok {
-				log.Printf("Audio samples channel closed, stopping processing")
+				logger.Infof("Audio samples channel closed, stopping processing")
 				return
 			}
+			captured := time.Now()
 
 			sampleCount++
 			if sampleCount%100 == 0 {
-				log.Printf("Processed %d audio sample blocks (latest: %d samples)", sampleCount, len(samples))
+				logger.Debugf("Processed %d audio sample blocks (latest: %d samples)", sampleCount, len(samples))
 			}
 
-			// Process samples through the audio monitor
-			if e.audioMonitor != nil {
-				e.audioMonitor.ProcessSamples(samples)
+			// Stereo is reduced to the RX channel in place; the whole
+			// capture buffer is recycled once the decoder has it
+			raw := samples
+			if channel := e.audioChannels().input; channel != "" {
+				samples = audio.FromStereo(samples, channel)
 			}
+			audio.ApplyGain(samples, e.audioGains().input)
 
-			// Also process samples through DSP for JS8 decoding
-			if e.dspEngine != nil {
-				_, err := e.dspEngine.DecodeBuffer(samples, func(result *dsp.DecodeResult) {
-					// Convert DSP result to protocol message
-					message := protocol.Message{
-						ID:        0, // Will be assigned by storage
-						Timestamp: time.Now(),
-						From:      "UNKNOWN", // Would need to parse from message
-						To:        "ALL",     // Default for broadcasts
-						Message:   result.Message,
-						SNR:       float32(result.SNR),
-						Frequency: int(result.Frequency),
-						Mode:      "JS8",
-					}
+			// Monitor and pre-filter each channel exactly once, this goroutine owns the filter state
+			split := splitChannels(samples, len(e.rxChannels))
+			e.pcmStream.Write(split[0])
+			if e.recorder != nil {
+				e.recorder.Write(split[0], sampleRate, captured)
+			}
+			for ch, channelSamples := range split {
+				if ch < len(e.audioMonitors) {
+					e.audioMonitors[ch].ProcessSamples(channelSamples)
+				}
+				split[ch] = e.preprocessRX(ch, channelSamples)
+			}
 
-					// Send to RX message channel for processing
-					select {
-					case e.rxMessages <- message:
-					default:
-						// Channel full, drop message
-					}
-				})
+			// Our own transmission reaches the decoder as silence with tx
+			// self_decode blank; the recorder and monitors still hear it
+			if e.blankRX(captured) {
+				for ch := range split {
+					split[ch] = make([]int16, len(split[ch]))
+				}
+			}
 
-				if err != nil {
-					log.Printf("DSP decode error: %v", err)
+			// Hand the filtered audio to the decoder
+			select {
+			case e.rxAudio <- rxBlock{channels: split, raw: raw, captured: captured}:
+			default:
+				if e.pipeline.drop(time.Now()) {
+					e.warnBehind()
 				}
 			}
 
 		case <-debugTicker.C:
 			if sampleCount == lastSampleCount {
-				log.Printf("DEBUG: No audio samples received in last 5 seconds (total count: %d)", sampleCount)
+				logger.Debugf("No audio samples received in last 5 seconds (total count: %d)", sampleCount)
 				// Check audio input status
 				audioSamples2 := e.hardwareManager.GetAudioInputSamples()
 				if audioSamples2 == nil {
-					log.Printf("DEBUG: Audio input samples channel is nil - audio may not be started")
+					logger.Debugf("Audio input samples channel is nil - audio may not be started")
 				} else {
-					log.Printf("DEBUG: Audio input samples channel exists but no data flowing")
+					logger.Debugf("Audio input samples channel exists but no data flowing")
 				}
 			} else {
-				log.Printf("DEBUG: Audio flowing normally (%d new samples)", sampleCount-lastSampleCount)
+				logger.Debugf("Audio flowing normally (%d new samples)", sampleCount-lastSampleCount)
 			}
 			lastSampleCount = sampleCount
 
@@ -1547,14 +2863,86 @@ func (e *CoreEngine) processAudioSamples() {
 			e.mutex.RUnlock()
 
 			if !running {
-				log.Printf("Engine stopped, ending audio processing")
+				logger.Infof("Engine stopped, ending audio processing")
 				return
 			}
 		}
 	}
 }
 
-// GetAudioMonitor returns the audio monitor for direct access
+// GetAudioMonitor returns the audio monitor of the first RX channel, used for visualization
 func (e *CoreEngine) GetAudioMonitor() *audio.AudioLevelMonitor {
-	return e.audioMonitor
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	if len(e.audioMonitors) == 0 {
+		return nil
+	}
+	return e.audioMonitors[0]
+}
+
+// GetAudioMonitors returns the audio monitor of every RX channel
+func (e *CoreEngine) GetAudioMonitors() []*audio.AudioLevelMonitor {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.audioMonitors
+}
+
+// GetSnippets returns the recordings of decodes, nil unless storage
+// snippet_dir is set
+func (e *CoreEngine) GetSnippets() *audio.Snippets {
+	return e.snippets
+}
+
+// GetPCMStream returns the RX audio stream for remote listeners
+func (e *CoreEngine) GetPCMStream() *audio.PCMStream {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.pcmStream
+}
+
+// getActiveAlarms returns the active RX level alarms across all channels
+// (must be called with e.mutex held)
+func (e *CoreEngine) getActiveAlarms() []audio.AudioAlarm {
+	alarms := []audio.AudioAlarm{}
+	for _, monitor := range e.audioMonitors {
+		alarms = append(alarms, monitor.GetActiveAlarms()...)
+	}
+	return alarms
+}
+
+// GetPreFilterStatistics returns the RX pre-filter statistics of every channel
+func (e *CoreEngine) GetPreFilterStatistics() []map[string]interface{} {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	stats := make([]map[string]interface{}, 0, len(e.preFilters))
+	for ch, filter := range e.preFilters {
+		filterStats := filter.GetStatistics()
+		filterStats["channel"] = e.rxChannels[ch]
+		stats = append(stats, filterStats)
+	}
+	return stats
+}
+
+// GetRXChannels returns the labels of the RX channels being decoded
+func (e *CoreEngine) GetRXChannels() []string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.rxChannels
+}
+
+// SnapshotDatabase writes a consistent copy of the message database to
+// path for a backup, returning false when there is no message store or it
+// is not kept in a SQLite file
+func (e *CoreEngine) SnapshotDatabase(path string) (bool, error) {
+	if e.messageStore == nil {
+		return false, nil
+	}
+	if err := e.messageStore.Snapshot(path); err != nil {
+		if errors.Is(err, storage.ErrUnsupported) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
 }
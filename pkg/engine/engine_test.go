@@ -1,13 +1,36 @@
 package engine
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/dougsko/js8d/pkg/audio"
+	"github.com/dougsko/js8d/pkg/awards"
 	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/dxcc"
+	"github.com/dougsko/js8d/pkg/forms"
+	"github.com/dougsko/js8d/pkg/gpsd"
+	"github.com/dougsko/js8d/pkg/hardware"
+	"github.com/dougsko/js8d/pkg/propagation"
 	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/release"
+	"github.com/dougsko/js8d/pkg/storage"
+	"github.com/dougsko/js8d/pkg/timesync"
+	"github.com/dougsko/js8d/pkg/triggers"
 )
 
 func TestNewCoreEngine(t *testing.T) {
@@ -77,7 +100,7 @@ func TestNewCoreEngine(t *testing.T) {
 			t.Error("Expected hardware manager to be initialized")
 		}
 
-		if engine.audioMonitor == nil {
+		if len(engine.audioMonitors) != 1 || engine.GetAudioMonitor() == nil {
 			t.Error("Expected audio monitor to be initialized")
 		}
 
@@ -321,6 +344,49 @@ func TestCoreEngineMessages(t *testing.T) {
 	})
 }
 
+func TestAudioAlarmEvents(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "js8d-engine-alarm-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	cfg := createTestConfig(tempDir)
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+
+	alarms, unsubscribe := engine.SubscribeAlarms()
+	defer unsubscribe()
+
+	engine.updateOLEDDisplay("RX: CQ K3DEP")
+
+	// Raising an alarm only queues it, the audio path never blocks
+	engine.handleAudioAlarm(audio.AudioAlarm{Type: audio.AlarmDeadInput, Active: true, Message: "dead"})
+	engine.handleAlarmEvent(<-engine.alarmEvents)
+
+	select {
+	case alarm := <-alarms:
+		if alarm.Type != audio.AlarmDeadInput || !alarm.Active {
+			t.Errorf("Unexpected alarm event: %+v", alarm)
+		}
+	default:
+		t.Fatal("Expected alarm event for subscriber")
+	}
+	if len(engine.oledAlarms) != 1 {
+		t.Errorf("Expected alarm on display, got %v", engine.oledAlarms)
+	}
+
+	// Clearing the alarm restores the normal display
+	engine.handleAudioAlarm(audio.AudioAlarm{Type: audio.AlarmDeadInput, Active: false, Message: "restored"})
+	engine.handleAlarmEvent(<-engine.alarmEvents)
+
+	if alarm := <-alarms; alarm.Active {
+		t.Errorf("Expected cleared alarm event, got %+v", alarm)
+	}
+	if len(engine.oledAlarms) != 0 || engine.oledMessage != "RX: CQ K3DEP" {
+		t.Errorf("Expected display restored, got alarms %v message %q", engine.oledAlarms, engine.oledMessage)
+	}
+}
+
 func TestCoreEngineIntegration(t *testing.T) {
 	t.Skip("Skipping integration test due to ALSA race condition in test environment")
 	tempDir, err := os.MkdirTemp("", "js8d-engine-integration-test")
@@ -474,5 +540,3689 @@ func createTestConfig(tempDir string) *config.Config {
 	cfg.Storage.MaxMessages = 1000
 	cfg.Hardware.EnableGPIO = false
 	cfg.Hardware.EnableOLED = false
+	cfg.RateLimit.CallsignPerHour = config.DefaultRateCallsignPerHour
+	cfg.RateLimit.CallsignBurst = config.DefaultRateCallsignBurst
+	cfg.RateLimit.GlobalPerHour = config.DefaultRateGlobalPerHour
+	cfg.RateLimit.GlobalBurst = config.DefaultRateGlobalBurst
 	return cfg
-}
\ No newline at end of file
+}
+
+func TestRXChannels(t *testing.T) {
+	t.Run("Mono Input", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.Audio.InputChannels = "mono"
+
+		names := rxChannelNames(cfg)
+		if len(names) != 1 || names[0] != "" {
+			t.Errorf("Expected single untagged channel, got %v", names)
+		}
+	})
+
+	t.Run("Stereo Split Names", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.Audio.InputChannels = "stereo-split"
+		cfg.Audio.InputChannelNames = []string{"beverage"}
+
+		names := rxChannelNames(cfg)
+		if len(names) != 2 || names[0] != "beverage" || names[1] != "right" {
+			t.Errorf("Expected [beverage right], got %v", names)
+		}
+	})
+
+	t.Run("Split", func(t *testing.T) {
+		interleaved := []int16{100, -100, 200, -200, 300, -300}
+
+		split := splitChannels(interleaved, 2)
+		if len(split) != 2 || len(split[0]) != 3 {
+			t.Fatalf("Expected 2 channels of 3 samples, got %v", split)
+		}
+		if split[0][2] != 300 || split[1][2] != -300 {
+			t.Errorf("Unexpected deinterleave result: %v", split)
+		}
+	})
+}
+
+func TestStereoChannelMapping(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Audio.InputChannels = "stereo"
+	cfg.Audio.InputChannel = "right"
+	cfg.Audio.OutputChannels = "stereo"
+	cfg.Audio.OutputChannel = "left"
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	hw := hardwareConfigFor(cfg)
+	if hw.InputChannels != 2 || hw.OutputChannels != 2 {
+		t.Errorf("Expected stereo capture and playback, got %d and %d channels", hw.InputChannels, hw.OutputChannels)
+	}
+	if got := engine.GetRXChannels(); len(got) != 1 {
+		t.Errorf("Expected one RX channel from a stereo device, got %v", got)
+	}
+	if channels := engine.audioChannels(); channels.input != "right" || channels.output != "left" {
+		t.Errorf("Expected RX on the right and TX on the left, got %+v", channels)
+	}
+	if got := engine.txAudio([]int16{5, -7}); len(got) != 4 || got[0] != 5 || got[1] != 0 || got[2] != -7 {
+		t.Errorf("Expected TX audio on the left channel only, got %v", got)
+	}
+
+	// Stereo-split decodes each channel, so there is nothing to map
+	cfg.Audio.InputChannels = "stereo-split"
+	cfg.Audio.OutputChannels = "mono"
+	if channels := engine.audioChannels(); channels.input != "" || channels.output != "" {
+		t.Errorf("Expected no mapping, got %+v", channels)
+	}
+	if got := engine.txAudio([]int16{5, -7}); len(got) != 2 {
+		t.Errorf("Expected mono TX audio, got %v", got)
+	}
+}
+
+// fakeDecoder reports one fixed decode for every buffer it is given
+type fakeDecoder struct {
+	dsp.DSPEngine
+	message string
+	calls   int
+}
+
+func (f *fakeDecoder) DecodeBuffer(audioData []int16, callback func(*dsp.DecodeResult)) (int, error) {
+	f.calls++
+	callback(&dsp.DecodeResult{Message: f.message, SNR: -10, Frequency: 1500})
+	return 1, nil
+}
+
+func TestRXChannelDecodeTagging(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "js8d-engine-channel-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	cfg := createTestConfig(tempDir)
+	cfg.Audio.InputChannels = "stereo-split"
+	cfg.Audio.InputChannelNames = []string{"beverage", "dipole"}
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+
+	if len(engine.rxDecoders) != 2 || engine.rxDecoders[0] == engine.rxDecoders[1] {
+		t.Fatal("Expected an independent decoder per RX channel")
+	}
+	if len(engine.audioMonitors) != 2 || len(engine.GetPreFilterStatistics()) != 2 {
+		t.Fatal("Expected a level monitor and pre-filter per RX channel")
+	}
+
+	left := &fakeDecoder{message: "CQ N0ABC FN20"}
+	right := &fakeDecoder{message: "CQ W1AW FN31"}
+	engine.rxDecoders = []dsp.DSPEngine{left, right}
+
+	engine.attemptDecode(1, make([]int16, 1024))
+	engine.attemptDecode(0, make([]int16, 1024))
+
+	if left.calls != 1 || right.calls != 1 {
+		t.Errorf("Expected each channel decoded once, got left=%d right=%d", left.calls, right.calls)
+	}
+
+	want := map[string]string{"CQ W1AW FN31": "dipole", "CQ N0ABC FN20": "beverage"}
+	for i := 0; i < 2; i++ {
+		msg := <-engine.rxMessages
+		if msg.Channel != want[msg.Message] {
+			t.Errorf("Expected %q tagged %q, got %q", msg.Message, want[msg.Message], msg.Channel)
+		}
+	}
+}
+
+func TestApplyHardwareConfig(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "js8d-engine-reload-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	cfg := createTestConfig(tempDir)
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	oldMonitor := engine.GetAudioMonitor()
+
+	t.Run("Unchanged", func(t *testing.T) {
+		restarted, err := engine.applyHardwareConfig(cfg)
+		if err != nil || len(restarted) != 0 {
+			t.Errorf("Expected nothing restarted, got %v (%v)", restarted, err)
+		}
+		if engine.GetAudioMonitor() != oldMonitor {
+			t.Error("Expected the audio monitor to be kept")
+		}
+	})
+
+	t.Run("Stereo Split", func(t *testing.T) {
+		newCfg := createTestConfig(tempDir)
+		newCfg.Audio.InputChannels = "stereo-split"
+		newCfg.Audio.SampleRate = 12000
+		newCfg.Hardware.PTTGPIOPin = 17
+
+		restarted, err := engine.applyHardwareConfig(newCfg)
+		if err != nil {
+			t.Fatalf("Failed to apply hardware config: %v", err)
+		}
+		if len(restarted) != 2 || restarted[0] != hardware.SubsystemAudio || restarted[1] != hardware.SubsystemGPIO {
+			t.Errorf("Expected audio and gpio restarted, got %v", restarted)
+		}
+
+		if got := engine.GetRXChannels(); len(got) != 2 || got[0] != "left" {
+			t.Errorf("Expected left and right RX channels, got %v", got)
+		}
+		if len(engine.rxDecoders) != 2 || len(engine.GetAudioMonitors()) != 2 || len(engine.GetPreFilterStatistics()) != 2 {
+			t.Error("Expected a decoder, level monitor and pre-filter per RX channel")
+		}
+		if engine.dspEngine.GetSampleRate() != 12000 {
+			t.Errorf("Expected the decoder at 12000 Hz, got %d", engine.dspEngine.GetSampleRate())
+		}
+		if engine.GetAudioMonitor() == oldMonitor {
+			t.Error("Expected new audio monitors for the new channel layout")
+		}
+		if engine.hardwareManager.GetConfig().PTTGPIOPin != 17 {
+			t.Error("Expected the hardware manager to hold the new PTT pin")
+		}
+	})
+}
+
+func TestProfileSwitching(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "js8d-engine-profile-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	cfg := createTestConfig(tempDir)
+	cfg.Profile = "home"
+	cfg.Profiles = []config.Profile{
+		{Name: "home", Frequency: 14078000},
+		{Name: "portable-qrp", Frequency: 7078000, Overrides: map[string]interface{}{
+			"hardware": map[string]interface{}{"ptt_gpio_pin": 17},
+		}},
+	}
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+
+	resp := engine.handleCommand(&protocol.Command{Type: protocol.CmdProfile})
+	if !resp.Success || resp.Data["active"] != "home" {
+		t.Fatalf("Expected home active, got %+v", resp)
+	}
+
+	resp = engine.handleCommand(&protocol.Command{Type: protocol.CmdProfile, Args: map[string]interface{}{"name": "portable-qrp"}})
+	if !resp.Success {
+		t.Fatalf("Failed to switch profile: %s", resp.Error)
+	}
+	if resp.Data["previous"] != "home" || resp.Data["frequency"] != 7078000 {
+		t.Errorf("Expected a switch from home to 7078000 Hz, got %+v", resp.Data)
+	}
+	if restarted, _ := resp.Data["restarted"].([]string); len(restarted) != 1 || restarted[0] != hardware.SubsystemGPIO {
+		t.Errorf("Expected gpio restarted, got %v", resp.Data["restarted"])
+	}
+	if engine.config.Profile != "portable-qrp" || engine.hardwareManager.GetConfig().PTTGPIOPin != 17 {
+		t.Error("Expected the portable-qrp settings to be running")
+	}
+
+	// Switching back drops the other profile's overrides
+	engine.handleCommand(&protocol.Command{Type: protocol.CmdProfile, Args: map[string]interface{}{"name": "home"}})
+	if engine.hardwareManager.GetConfig().PTTGPIOPin != cfg.Hardware.PTTGPIOPin {
+		t.Error("Expected the shared PTT pin after switching back")
+	}
+
+	resp = engine.handleCommand(&protocol.Command{Type: protocol.CmdProfile, Args: map[string]interface{}{"name": "vhf"}})
+	if resp.Success || resp.Code != protocol.ErrCodeInvalid {
+		t.Errorf("Expected an invalid request error for an unknown profile, got %+v", resp)
+	}
+}
+
+func TestSocketRoles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "js8d-engine-roles-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	cfg := createTestConfig(tempDir)
+	cfg.Auth.SocketRole = protocol.RoleGuest
+	cfg.Auth.Tokens = []config.APIToken{
+		{Name: "tablet", Token: config.NewSecret("operator-token-0123456789"), Role: protocol.RoleOperator},
+	}
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+
+	role := engine.socketRole()
+	if role != protocol.RoleGuest {
+		t.Fatalf("Expected guest without a token, got %s", role)
+	}
+	if resp := engine.handleAuthorizedCommand(&protocol.Command{Type: protocol.CmdStatus}, &role); !resp.Success {
+		t.Errorf("Expected a guest to get status, got %s", resp.Error)
+	}
+	send := &protocol.Command{Type: protocol.CmdSend, Args: map[string]interface{}{"to": "W1AW", "message": "HELLO"}}
+	if resp := engine.handleAuthorizedCommand(send, &role); resp.Code != protocol.ErrCodeForbidden {
+		t.Errorf("Expected a guest to be refused sending, got %+v", resp)
+	}
+
+	auth := &protocol.Command{Type: protocol.CmdAuth, Args: map[string]interface{}{"token": "wrong"}}
+	if resp := engine.handleAuthorizedCommand(auth, &role); resp.Code != protocol.ErrCodeUnauthorized || role != protocol.RoleGuest {
+		t.Errorf("Expected an unknown token refused, got %+v as %s", resp, role)
+	}
+	auth.Args["token"] = "operator-token-0123456789"
+	if resp := engine.handleAuthorizedCommand(auth, &role); !resp.Success || role != protocol.RoleOperator {
+		t.Fatalf("Expected operator after AUTH, got %+v as %s", resp, role)
+	}
+	if resp := engine.handleAuthorizedCommand(send, &role); !resp.Success {
+		t.Errorf("Expected an operator to send, got %s", resp.Error)
+	}
+	if resp := engine.handleAuthorizedCommand(&protocol.Command{Type: protocol.CmdReload}, &role); resp.Code != protocol.ErrCodeForbidden {
+		t.Errorf("Expected an operator to be refused reloading, got %+v", resp)
+	}
+
+	// The web server in the same process is admin
+	if admin, failure := engine.authenticate(engine.SessionToken()); failure != nil || admin != protocol.RoleAdmin {
+		t.Errorf("Expected the session token to be admin, got %s (%v)", admin, failure)
+	}
+}
+
+func TestShutdownKeepsQueuedMessages(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+
+	msg, ok := engine.queueTX(protocol.Message{From: "K3DEP", To: "N0CALL", Message: "HELLO", Timestamp: time.Now()})
+	if !ok || msg.Status != protocol.MessageQueued {
+		t.Fatalf("Expected the message queued, got %+v", msg)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := engine.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if engine.ptt {
+		t.Error("Expected PTT dropped after shutdown")
+	}
+
+	// The next engine on the same database picks the message up again
+	restarted := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer restarted.Stop()
+
+	unsent := restarted.loadTXQueue()
+	if len(unsent) != 1 || unsent[0].ID != msg.ID || unsent[0].Message != "HELLO" {
+		t.Fatalf("Expected the queued message loaded, got %+v", unsent)
+	}
+	restarted.resumeTXQueue(unsent)
+	if len(restarted.txMessages) != 1 {
+		t.Errorf("Expected the message back in the TX queue, got %d", len(restarted.txMessages))
+	}
+
+	response := restarted.handleGetTXQueue()
+	if !response.Success || response.Data["count"] != 1 {
+		t.Errorf("Expected one message in the TX queue listing, got %+v", response)
+	}
+}
+
+func TestDeliveryTracking(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Messages.AckTimeout = 60
+	cfg.Messages.AckRetries = 1
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	delivery := func(id int) string {
+		t.Helper()
+		messages, err := engine.messageStore.GetMessages(storage.MessageQuery{Direction: "TX"})
+		if err != nil {
+			t.Fatalf("Failed to get messages: %v", err)
+		}
+		for _, msg := range messages {
+			if msg.ID == id {
+				return msg.Delivery
+			}
+		}
+		t.Fatalf("Message %d not stored", id)
+		return ""
+	}
+
+	// A directed message is delivered once the station answers with ACK
+	response := engine.handleSend(&protocol.Command{Type: protocol.CmdSend, Args: map[string]interface{}{"to": "N0CALL", "message": "HELLO"}})
+	if !response.Success {
+		t.Fatalf("Send failed: %s", response.Error)
+	}
+	sent := <-engine.txMessages
+	if sent.Delivery != protocol.DeliveryAwaiting {
+		t.Fatalf("Expected the directed message to await an ACK, got %q", sent.Delivery)
+	}
+	engine.awaitAck(sent)
+
+	engine.checkAck(protocol.Message{From: "N0CALL", To: "K3DEP", Message: "K3DEP SNR -10"})
+	if got := delivery(sent.ID); got != protocol.DeliveryAwaiting {
+		t.Errorf("Expected a non-ACK reply ignored, got %q", got)
+	}
+	engine.checkAck(protocol.Message{From: "N0CALL", To: "K3DEP", Message: "K3DEP RR"})
+	if got := delivery(sent.ID); got != protocol.DeliveryDelivered {
+		t.Errorf("Expected delivered, got %q", got)
+	}
+
+	// An ACK answers only the message last sent to the station, whatever
+	// the case of the callsigns
+	first, _ := engine.queueTX(protocol.Message{From: "K3DEP", To: "N0CALL", Message: "FIRST", Delivery: protocol.DeliveryAwaiting})
+	<-engine.txMessages
+	engine.awaitAck(first)
+	second, _ := engine.queueTX(protocol.Message{From: "K3DEP", To: "N0CALL", Message: "SECOND", Delivery: protocol.DeliveryAwaiting})
+	<-engine.txMessages
+	engine.awaitAck(second)
+	engine.checkAck(protocol.Message{From: "n0call", To: "k3dep", Message: "K3DEP ACK"})
+	if got := delivery(second.ID); got != protocol.DeliveryDelivered {
+		t.Errorf("Expected the last message sent delivered, got %q", got)
+	}
+	if got := delivery(first.ID); got != protocol.DeliveryAwaiting {
+		t.Errorf("Expected the earlier message still awaiting its ACK, got %q", got)
+	}
+	engine.checkAck(protocol.Message{From: "N0CALL", To: "K3DEP", Message: "K3DEP ACK"})
+	if got := delivery(first.ID); got != protocol.DeliveryDelivered {
+		t.Errorf("Expected the earlier message delivered by a second ACK, got %q", got)
+	}
+
+	// Without an answer it is resent once, then undelivered
+	msg, _ := engine.queueTX(protocol.Message{From: "K3DEP", To: "N0ABC", Message: "HELLO", Delivery: protocol.DeliveryAwaiting})
+	<-engine.txMessages
+	engine.awaitAck(msg)
+
+	engine.checkAckTimeouts(time.Now().Add(61 * time.Second))
+	select {
+	case resent := <-engine.txMessages:
+		if resent.ID != msg.ID {
+			t.Errorf("Expected message %d resent, got %d", msg.ID, resent.ID)
+		}
+	default:
+		t.Fatal("Expected the message resent after the timeout")
+	}
+	engine.awaitAck(msg)
+
+	engine.checkAckTimeouts(time.Now().Add(61 * time.Second))
+	if len(engine.txMessages) != 0 {
+		t.Error("Expected no resend once the retries are used up")
+	}
+	if got := delivery(msg.ID); got != protocol.DeliveryUndelivered {
+		t.Errorf("Expected undelivered, got %q", got)
+	}
+
+	// Broadcasts are not tracked
+	if engine.tracksDelivery("") || engine.tracksDelivery("@ALLCALL") {
+		t.Error("Expected broadcasts and groups sent without tracking")
+	}
+}
+
+func TestMessageEvents(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	events, unsubscribe := engine.SubscribeEvents()
+	defer unsubscribe()
+
+	next := func(eventType string) protocol.Event {
+		t.Helper()
+		select {
+		case event := <-events:
+			if event.Type != eventType {
+				t.Fatalf("Expected a %s event, got %+v", eventType, event)
+			}
+			return event
+		default:
+			t.Fatalf("Expected a %s event", eventType)
+		}
+		return protocol.Event{}
+	}
+
+	// The first message from a station opens a conversation
+	rx := protocol.Message{Timestamp: time.Now(), From: "N0ABC", To: "K3DEP", Message: "K3DEP HELLO", Frequency: 14078000}
+	engine.storeRX(rx)
+	if got := next(protocol.EventConversation).Data["callsign"]; got != "N0ABC" {
+		t.Errorf("Expected a conversation with N0ABC, got %v", got)
+	}
+	if got := next(protocol.EventMessage).Data["unread"]; got != 1 {
+		t.Errorf("Expected 1 unread message, got %v", got)
+	}
+
+	// A second decode of the same message is dropped without an event
+	if !engine.storeRX(rx) {
+		t.Error("Expected a second decode to be a duplicate")
+	}
+	rx.Timestamp = rx.Timestamp.Add(15 * time.Second)
+	engine.storeRX(rx)
+	next(protocol.EventMessage)
+
+	// Another client reading the conversation clears it everywhere
+	engine.handleMarkMessagesRead([]string{"N0ABC"})
+	if got := next(protocol.EventMessagesRead).Data["unread"]; got != 0 {
+		t.Errorf("Expected no unread messages, got %v", got)
+	}
+
+	// TX progress reaches subscribers by message ID
+	msg, _ := engine.queueTX(protocol.Message{From: "K3DEP", To: "N0ABC", Message: "N0ABC HI"})
+	next(protocol.EventMessage)
+	engine.setTXStatus(msg, protocol.MessageSent)
+	if got := next(protocol.EventMessageStatus).Data; got["id"] != msg.ID || got["status"] != protocol.MessageSent {
+		t.Errorf("Expected message %d sent, got %v", msg.ID, got)
+	}
+}
+
+func TestDecodeSnippets(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Storage.SnippetDir = filepath.Join(tempDir, "snippets")
+	cfg.Storage.SnippetSeconds = 5
+	cfg.Storage.SnippetQuota = 1
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	// Half a second before a signal 0.5 s late, 5 s of it at the snippet rate
+	buffer := make([]int16, 10*48000)
+	if got := captureSnippet(buffer, 48000, 0.5, 5); len(got) != 5*audio.SnippetRate {
+		t.Errorf("Expected %d samples, got %d", 5*audio.SnippetRate, len(got))
+	}
+	if got := captureSnippet(buffer, 48000, 8, 5); len(got) != 2*audio.SnippetRate {
+		t.Errorf("Expected the snippet cut short at the end of the buffer, got %d samples", len(got))
+	}
+
+	rx := protocol.Message{Timestamp: time.Now(), From: "N0ABC", Message: "N0ABC: @HB HEARTBEAT FN20", Offset: 1500, Channel: "A",
+		Audio: captureSnippet(buffer, 48000, 0, 5)}
+	engine.storeRX(rx)
+	if !engine.storeRX(rx) {
+		t.Error("Expected a second decode to be a duplicate")
+	}
+
+	messages, _ := engine.messageStore.GetMessages(storage.MessageQuery{Callsign: "N0ABC"})
+	if len(messages) != 1 || !strings.HasSuffix(messages[0].Snippet, "-1500-A"+audio.SnippetExt) {
+		t.Fatalf("Expected the message to reference its snippet, got %+v", messages)
+	}
+	path, _ := engine.snippets.Path(messages[0].Snippet)
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the snippet saved: %v", err)
+	}
+
+	stats := engine.handleGetMessageStats().Data["snippets"].(map[string]interface{})
+	if stats["count"] != 1 || stats["quota"] != int64(1<<20) {
+		t.Errorf("Expected 1 snippet, got %v", stats)
+	}
+}
+
+func TestSelfDecode(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.TX.SelfDecode = config.SelfDecodeBlank
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	now := time.Now()
+	other := protocol.Message{From: "N0ABC", Message: "N0ABC: HELLO", Offset: 1500}
+	if reason := engine.selfDecode(other, now); reason != "" || engine.blankRX(now) {
+		t.Fatalf("Expected nothing taken for our own before keying, got %q", reason)
+	}
+	if reason := engine.selfDecode(protocol.Message{From: strings.ToLower(cfg.Station.Callsign), Offset: 800}, now); reason != selfByCallsign {
+		t.Errorf("Expected a decode from our callsign taken for our own, got %q", reason)
+	}
+
+	// Blanked while keyed and just after, our offset is ours for two cycles
+	engine.mutex.Lock()
+	engine.txAudioOffset = 1510
+	engine.mutex.Unlock()
+	engine.noteKeying(true, 1510, now.Add(-20*time.Second))
+	if !engine.blankRX(now.Add(-10 * time.Second)) {
+		t.Error("Expected the decoder blanked while keyed")
+	}
+	engine.noteKeying(false, 1510, now.Add(-8*time.Second))
+	if !engine.blankRX(now.Add(-8*time.Second+selfBlankTail/2)) || engine.blankRX(now) {
+		t.Error("Expected the decoder blanked only for the tail after unkeying")
+	}
+	if reason := engine.selfDecode(other, now); reason != selfByTXWindow {
+		t.Errorf("Expected a decode at our offset during TX taken for our own, got %q", reason)
+	}
+	if reason := engine.selfDecode(protocol.Message{From: "N0ABC", Offset: 1600}, now); reason != "" {
+		t.Errorf("Expected a decode off our offset left alone, got %q", reason)
+	}
+	if reason := engine.selfDecode(other, now.Add(selfDecodeSpan)); reason != "" {
+		t.Errorf("Expected a decode long after TX left alone, got %q", reason)
+	}
+
+	// A self-decode is an event and a count, never a received message
+	events, cancel := engine.SubscribeEvents()
+	defer cancel()
+	engine.rxDecoders = []dsp.DSPEngine{&fakeDecoder{message: "CQ K3DEP FN20"}}
+	engine.attemptDecode(0, make([]int16, 1024))
+	select {
+	case msg := <-engine.rxMessages:
+		t.Errorf("Expected our own CQ kept from RX, got %+v", msg)
+	default:
+	}
+	select {
+	case event := <-events:
+		if event.Type != protocol.EventSelfDecode || event.Data["reason"] != selfByCallsign {
+			t.Errorf("Expected a self_decode event for our callsign, got %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected a self_decode event")
+	}
+
+	engine.handleSelfDecode(other, selfByTXWindow)
+	select {
+	case event := <-events:
+		msg, _ := event.Data["message"].(protocol.Message)
+		if event.Type != protocol.EventSelfDecode || !msg.Self || event.Data["reason"] != selfByTXWindow {
+			t.Errorf("Expected a tagged self_decode event, got %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected a self_decode event")
+	}
+	if messages, _ := engine.messageStore.GetMessages(storage.MessageQuery{Callsign: "N0ABC"}); len(messages) != 0 {
+		t.Errorf("Expected the self-decode not stored, got %+v", messages)
+	}
+	if count := engine.handleGetMessageStats().Data["self_decodes"]; count != 2 {
+		t.Errorf("Expected 2 self-decodes counted, got %v", count)
+	}
+
+	// With off, nothing is ours
+	engine.mutex.Lock()
+	engine.config.TX.SelfDecode = config.SelfDecodeOff
+	engine.mutex.Unlock()
+	if reason := engine.selfDecode(other, now); reason != "" {
+		t.Errorf("Expected tx self_decode off to take nothing for our own, got %q", reason)
+	}
+}
+
+func TestBandRecorder(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Storage.RecordingDir = filepath.Join(tempDir, "recordings")
+	cfg.Storage.RecordingCodec = "mulaw"
+	cfg.Storage.RecordingMinutes = 1
+	cfg.Storage.RecordingQuota = 10
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	if engine.GetRecorder() == nil {
+		t.Fatal("Expected the band recorder running")
+	}
+
+	engine.recorder.Write(make([]int16, 48000), 48000, time.Now())
+	engine.markRecording(protocol.Message{Timestamp: time.Now(), From: "N0ABC", Message: "N0ABC: @HB HEARTBEAT FN20", SNR: -10, Offset: 1500})
+	engine.Stop()
+
+	recordings, err := engine.recorder.Recordings()
+	if err != nil || len(recordings) != 1 || recordings[0].Codec != audio.CodecMuLaw || recordings[0].Decodes != 1 {
+		t.Fatalf("Expected one mu-law recording with its decode, got %+v, %v", recordings, err)
+	}
+	if stats := engine.RecorderStats(); stats["count"] != 1 || stats["quota"] != int64(10<<20) {
+		t.Errorf("Expected 1 recording against a 10 MB quota, got %v", stats)
+	}
+
+	cfg.Storage.RecordingCodec = "opus"
+	if recorder, err := openRecorder(cfg); (err == nil) != audio.OpusAvailable {
+		t.Errorf("openRecorder with opus = %v, %v with OpusAvailable %v", recorder, err, audio.OpusAvailable)
+	} else if recorder != nil {
+		recorder.Close()
+	}
+}
+
+func TestRedecode(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Storage.RecordingDir = filepath.Join(tempDir, "recordings")
+	cfg.Storage.RecordingCodec = "mulaw"
+	cfg.Storage.RecordingQuota = 10
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	// Two cycles recorded, a signal in the second that was never decoded live
+	encoder, _ := dsp.NewEngine(dsp.DecoderGo)
+	encoder.SetSampleRate(audio.RecorderRate)
+	signal, err := encoder.EncodeMessage("HELLO", dsp.ModeNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 10, 16, 14, 20, 0, 0, time.UTC)
+	samples := make([]int16, 30*audio.RecorderRate)
+	copy(samples[int(15.5*audio.RecorderRate):], signal)
+	engine.recorder.Write(samples, audio.RecorderRate, start.Add(30*time.Second))
+	engine.recorder.Close()
+
+	if resp := engine.handleCommand(&protocol.Command{Type: protocol.CmdRedecode, Args: map[string]interface{}{"range": "2020-01-01T00:00:00Z/2020-01-02T00:00:00Z"}}); resp.Success {
+		t.Error("Expected a range without recordings to fail")
+	}
+	resp := engine.handleCommand(&protocol.Command{Type: protocol.CmdRedecode,
+		Args: map[string]interface{}{"range": "2026-10-16T14:20:00Z/2026-10-16T14:21:00Z"}})
+	if !resp.Success {
+		t.Fatalf("REDECODE failed: %s", resp.Error)
+	}
+
+	var status map[string]interface{}
+	for deadline := time.Now().Add(20 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if status = engine.RedecodeStatus(); status["running"] == false {
+			break
+		}
+	}
+	messages := status["messages"].([]RedecodedMessage)
+	if status["running"] != false || status["cycles"] != 2 || len(messages) != 1 {
+		t.Fatalf("Expected one message from 2 cycles, got %v", status)
+	}
+	msg := messages[0]
+	if !strings.HasPrefix(msg.Message.Message, "HELLO") || !msg.Missed || !msg.Timestamp.Equal(start.Add(15*time.Second)) || msg.Position != 15 {
+		t.Errorf("Expected HELLO missed live in the cycle at 15s, got %+v", msg)
+	}
+
+	if from, to, err := parseRedecodeRange("2h", start); err != nil || !to.Equal(start) || to.Sub(from) != 2*time.Hour {
+		t.Errorf("parseRedecodeRange(2h) = %v, %v, %v", from, to, err)
+	}
+	if _, _, err := parseRedecodeRange("20261016-1500/20261016-1400", start); err == nil {
+		t.Error("Expected a backwards range to fail")
+	}
+}
+
+func TestAutoList(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Answer.Enabled = true
+	cfg.Answer.PerHour = 10
+	cfg.Answer.Reply = "{CALL} HELLO"
+	cfg.Answer.Rules = []config.AnswerRule{{Callsigns: []string{"N0ABC", "K3XYZ", "W1AW"}}}
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	run := func(line string) *protocol.Response {
+		t.Helper()
+		cmd, _ := protocol.ParseCommand(line)
+		return engine.handleAutoList(cmd)
+	}
+	answered := func(from string) bool {
+		t.Helper()
+		engine.answered = nil
+		engine.answerCQ(protocol.Message{Timestamp: time.Now(), From: from, To: "@ALLCALL", Message: "CQ CQ", SNR: -10})
+		select {
+		case <-engine.txMessages:
+			return true
+		default:
+			return false
+		}
+	}
+
+	if resp := run("AUTOLIST:block:n0abc:Keeps calling"); !resp.Success {
+		t.Fatalf("Failed to block a callsign: %s", resp.Error)
+	}
+	for _, line := range []string{"AUTOLIST:block:", "AUTOLIST:allow:*", "AUTOLIST:ignore:N0ABC", "AUTOLIST:delete:x"} {
+		if resp := run(line); resp.Success || resp.Code != protocol.ErrCodeInvalid {
+			t.Errorf("Expected %s refused as invalid, got %+v", line, resp)
+		}
+	}
+	if answered("N0ABC") || !answered("K3XYZ") || !answered("W1AW") {
+		t.Error("Expected only the blocked station left unanswered")
+	}
+	if reason := engine.autoListRefusal(rateReply, "n0abc/p"); reason != autoListBlocked {
+		t.Errorf("Expected the blocked station held back portable too, got %q", reason)
+	}
+
+	// With an allow list, only the stations on it are answered
+	resp := run("AUTOLIST:allow:K3*")
+	if !resp.Success {
+		t.Fatalf("Failed to allow a prefix: %s", resp.Error)
+	}
+	allowed := resp.Data["entry"].(storage.AutoListEntry)
+	if answered("N0ABC") || !answered("K3XYZ") || answered("W1AW") {
+		t.Error("Expected only the allowed prefix answered")
+	}
+	if reason := engine.autoListRefusal(rateReply, "W1AW"); reason != autoListNotAllowed {
+		t.Errorf("Expected W1AW held back off the allow list, got %q", reason)
+	}
+	if engine.autoListRefusal(autoQSO, "K3ABC") != "" {
+		t.Error("Expected K3ABC allowed by the prefix")
+	}
+
+	resp = run("AUTOLIST")
+	held, _ := resp.Data["held"].(map[string]int)
+	if !resp.Success || resp.Data["count"] != 2 || resp.Data["allow_only"] != true || held[rateAnswer] != 3 || held[rateReply] != 2 {
+		t.Errorf("Expected 2 entries with 3 answers and 2 replies held back, got %v", resp.Data)
+	}
+	decisions, _ := engine.messageStore.GetAnswerDecisions(1)
+	if len(decisions) != 1 || decisions[0].Reason != autoListNotAllowed+", watched callsign" {
+		t.Errorf("Expected the answer decision to give the allow list, got %+v", decisions)
+	}
+
+	// The lists are kept in the message store
+	reloaded := NewCoreEngine(cfg, filepath.Join(tempDir, "test2.sock"), "")
+	defer reloaded.Stop()
+	if reason := reloaded.autoListRefusal(rateReply, "N0ABC"); reason != autoListBlocked {
+		t.Errorf("Expected the block list loaded on start, got %q", reason)
+	}
+
+	if resp := run(fmt.Sprintf("AUTOLIST:delete:%d", allowed.ID)); !resp.Success {
+		t.Fatalf("Failed to delete: %s", resp.Error)
+	}
+	if resp := run(fmt.Sprintf("AUTOLIST:delete:%d", allowed.ID)); resp.Success || resp.Code != protocol.ErrCodeInvalid {
+		t.Error("Expected a second delete to find nothing")
+	}
+	if !answered("W1AW") {
+		t.Error("Expected every station but the blocked one answered once the allow list is empty")
+	}
+}
+
+func TestNetMode(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Net.Group = "@ARESNET"
+	cfg.Net.Duration = 60
+	cfg.Net.Preamble = config.DefaultNetPreamble
+	cfg.Net.Closing = config.DefaultNetClosing
+	cfg.Net.Ack = config.DefaultNetAck
+	cfg.Net.ExportDir = filepath.Join(tempDir, "nets")
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	engine.autoReply = true
+
+	sent := func() string {
+		t.Helper()
+		select {
+		case msg := <-engine.txMessages:
+			return msg.Message
+		default:
+			return ""
+		}
+	}
+	checkin := func(from, to, text string) {
+		engine.netCheckin(protocol.Message{Timestamp: time.Now(), From: from, To: to, Message: text, SNR: -12})
+	}
+
+	resp := engine.handleNet([]string{"OPEN"})
+	if !resp.Success {
+		t.Fatalf("Failed to open a net: %s", resp.Error)
+	}
+	if text := sent(); text != "@ARESNET NET OPEN, QNI TO K3DEP" {
+		t.Errorf("Expected the preamble sent to the group, got %q", text)
+	}
+	if resp := engine.handleNet([]string{"OPEN", "@OTHER"}); resp.Success || resp.Code != protocol.ErrCodeInvalid {
+		t.Error("Expected a second net refused while one is open")
+	}
+
+	// Stations calling the group or us check in, and are answered once
+	checkin("N0ABC", "", "N0ABC: @ARESNET QNI FN20")
+	if text := sent(); text != "N0ABC QSL" {
+		t.Errorf("Expected N0ABC acknowledged, got %q", text)
+	}
+	checkin("N0ABC", "", "N0ABC: @ARESNET NO TRAFFIC")
+	checkin("W1AW", "K3DEP", "K3DEP W1AW QNI")
+	checkin("K3XYZ", "", "K3XYZ: @OTHERNET QNI")
+	checkin("K3XYZ", "", "K3XYZ: @ARESNET CQ CQ")
+	if text := sent(); text != "W1AW QSL" {
+		t.Errorf("Expected only W1AW acknowledged next, got %q", text)
+	}
+	if text := sent(); text != "" {
+		t.Errorf("Expected nothing more sent, got %q", text)
+	}
+
+	resp = engine.handleNet(nil)
+	running, _ := resp.Data["net"].(*storage.Net)
+	if !resp.Success || resp.Data["open"] != true || running == nil || running.Count != 2 {
+		t.Fatalf("Expected the open net with 2 check-ins, got %v", resp.Data)
+	}
+	if c := running.Checkins[0]; c.Callsign != "N0ABC" || c.Grid != "FN20" || c.Messages != 2 || c.Message != "N0ABC: @ARESNET QNI FN20" {
+		t.Errorf("Expected N0ABC's check-in with its grid and 2 messages, got %+v", c)
+	}
+
+	if resp := engine.handleNet([]string{"CLOSE"}); !resp.Success {
+		t.Fatalf("Failed to close the net: %s", resp.Error)
+	}
+	if text := sent(); text != "@ARESNET NET CLOSED, 73" {
+		t.Errorf("Expected the closing sent to the group, got %q", text)
+	}
+	checkin("K3XYZ", "", "K3XYZ: @ARESNET QNI")
+	if text := sent(); text != "" {
+		t.Errorf("Expected no check-ins once closed, got %q", text)
+	}
+
+	exported, err := os.ReadFile(filepath.Join(cfg.Net.ExportDir, netExportName(running)))
+	if err != nil {
+		t.Fatalf("Expected the check-ins exported on close: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(exported)), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "N0ABC,") || !strings.HasPrefix(lines[2], "W1AW,") {
+		t.Errorf("Expected a header and 2 check-ins in order, got %q", exported)
+	}
+	resp = engine.handleNet([]string{"EXPORT", strconv.FormatInt(running.ID, 10)})
+	if !resp.Success || resp.Data["csv"] != string(exported) {
+		t.Errorf("Expected NET EXPORT to match the file, got %v", resp.Data)
+	}
+	if resp := engine.handleNet([]string{"LIST"}); !resp.Success || resp.Data["count"] != 1 {
+		t.Errorf("Expected 1 net listed, got %v", resp.Data)
+	}
+
+	// A scheduled net opens when its time comes and closes after duration
+	engine.config.Net.Schedule = []string{"19:00"}
+	start := time.Date(2026, 10, 16, 19, 0, 0, 0, time.Local)
+	engine.checkNet(start.Add(-10 * time.Second))
+	engine.checkNet(start.Add(5 * time.Second))
+	if text := sent(); !strings.HasPrefix(text, "@ARESNET NET OPEN") {
+		t.Fatalf("Expected the scheduled net opened, got %q", text)
+	}
+	engine.checkNet(start.Add(59 * time.Minute))
+	if text := sent(); text != "" {
+		t.Errorf("Expected the net still open, got %q", text)
+	}
+	engine.checkNet(start.Add(60*time.Minute + 5*time.Second))
+	if text := sent(); text != "@ARESNET NET CLOSED, 73" {
+		t.Errorf("Expected the scheduled net closed after its duration, got %q", text)
+	}
+	if resp := engine.handleNet([]string{"CLOSE"}); resp.Success || resp.Code != protocol.ErrCodeInvalid {
+		t.Error("Expected NET CLOSE refused with no net open")
+	}
+}
+
+func TestBeacon(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Beacon.Enabled = true
+	cfg.Beacon.Interval = 30
+	cfg.Beacon.Jitter = -1
+	cfg.Beacon.Text = "{MYCALL} SOLAR {VOLTS}"
+	cfg.Beacon.Quiet = 10
+	cfg.Beacon.IDInterval = 10
+	cfg.Beacon.IDText = config.DefaultBeaconIDText
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	sent := func() string {
+		t.Helper()
+		select {
+		case msg := <-engine.txMessages:
+			return msg.Message
+		default:
+			return ""
+		}
+	}
+
+	// {VOLTS} needs a reading
+	if resp := engine.handleBeacon([]string{"NOW"}); resp.Success || resp.Code != protocol.ErrCodeInvalid {
+		t.Errorf("Expected BEACON NOW refused without a battery voltage, got %+v", resp)
+	}
+	voltage := 13.24
+	engine.recordSensors(protocol.SensorReading{Time: time.Now(), Voltage: &voltage}, nil)
+
+	start := time.Now()
+	engine.checkBeacon(start)
+	if text := sent(); text != "" {
+		t.Errorf("Expected no beacon before the interval, got %q", text)
+	}
+	engine.checkBeacon(start.Add(31 * time.Minute))
+	if text := sent(); text != "K3DEP SOLAR 13.2V" {
+		t.Errorf("Expected the beacon after the interval, got %q", text)
+	}
+
+	// Directed traffic holds the next beacon back until it has been quiet
+	engine.noteTraffic(protocol.Message{From: "N0ABC", To: "K3DEP", Message: "K3DEP N0ABC SNR?"}, start.Add(55*time.Minute))
+	engine.noteTraffic(protocol.Message{From: "W1AW", To: "@ALLCALL", Message: "CQ"}, start.Add(60*time.Minute))
+	engine.checkBeacon(start.Add(62 * time.Minute))
+	if text := sent(); text != "" {
+		t.Errorf("Expected the beacon held back by traffic, got %q", text)
+	}
+	if held := engine.beaconHeld(start.Add(62 * time.Minute)); held != beaconHeldTraffic {
+		t.Errorf("Expected the beacon held for traffic, got %q", held)
+	}
+	engine.checkBeacon(start.Add(65 * time.Minute))
+	if text := sent(); text != "K3DEP SOLAR 13.2V" {
+		t.Errorf("Expected the held beacon sent once quiet, got %q", text)
+	}
+
+	if resp := engine.handleBeacon([]string{"OFF"}); !resp.Success || resp.Data["enabled"] != false {
+		t.Fatalf("Expected beacons turned off, got %+v", resp)
+	}
+	engine.checkBeacon(start.Add(200 * time.Minute))
+	if text := sent(); text != "" {
+		t.Errorf("Expected no beacon while off, got %q", text)
+	}
+
+	// The ID goes out once we have sent for id_interval without our callsign
+	idStart := start.Add(300 * time.Minute)
+	engine.noteIdentified(protocol.Message{Message: "N0ABC QSL"}, idStart)
+	engine.noteIdentified(protocol.Message{Message: "N0ABC 73"}, idStart.Add(5*time.Minute))
+	engine.checkBeacon(idStart.Add(9 * time.Minute))
+	if text := sent(); text != "" {
+		t.Errorf("Expected no ID before id_interval, got %q", text)
+	}
+	if resp := engine.handleBeacon(nil); resp.Data["id_due"] != idStart.Add(10*time.Minute) {
+		t.Errorf("Expected the ID due 10 minutes after the first transmission, got %v", resp.Data)
+	}
+	engine.checkBeacon(idStart.Add(10 * time.Minute))
+	if text := sent(); text != "DE K3DEP" {
+		t.Errorf("Expected the station ID, got %q", text)
+	}
+	engine.noteIdentified(protocol.Message{Message: "DE K3DEP"}, idStart.Add(11*time.Minute))
+	engine.checkBeacon(idStart.Add(30 * time.Minute))
+	if text := sent(); text != "" {
+		t.Errorf("Expected no ID once identified, got %q", text)
+	}
+	if resp := engine.handleBeacon(nil); resp.Data["last_id"] != idStart.Add(11*time.Minute) || resp.Data["id_due"] != nil {
+		t.Errorf("Expected the ID timer reset, got %v", resp.Data)
+	}
+}
+
+func TestDecodeFilters(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+
+	add := func(line string) *protocol.Response {
+		t.Helper()
+		cmd, _ := protocol.ParseCommand(line)
+		return engine.handleFilter(cmd)
+	}
+	if resp := add("FILTER:add:callsign:n0abc:Stuck beacon"); !resp.Success {
+		t.Fatalf("Failed to add callsign filter: %s", resp.Error)
+	}
+	resp := add("FILTER:add:offset:1480")
+	if !resp.Success {
+		t.Fatalf("Failed to add offset filter: %s", resp.Error)
+	}
+	if birdie := resp.Data["filter"].(storage.Filter); birdie.Width != protocol.DefaultFilterWidth {
+		t.Errorf("Expected the default width, got %d", birdie.Width)
+	}
+	for _, line := range []string{"FILTER:add:callsign:", "FILTER:add:offset:abc", "FILTER:add:offset:1500:-5", "FILTER:add:grid:FN20", "FILTER:delete:99", "FILTER:frob"} {
+		if resp := add(line); resp.Success || resp.Code != protocol.ErrCodeInvalid {
+			t.Errorf("Expected %s to be invalid, got %+v", line, resp)
+		}
+	}
+
+	tests := []struct {
+		msg  protocol.Message
+		want bool
+	}{
+		{protocol.Message{From: "N0ABC", Offset: 1000}, true},
+		{protocol.Message{From: "N0ABC/P", Offset: 1000}, true},
+		{protocol.Message{From: "N0ABCD", Offset: 1000}, false},
+		{protocol.Message{From: "K1XYZ", Offset: 1490}, true},
+		{protocol.Message{From: "K1XYZ", Offset: 1491}, false},
+	}
+	for _, tt := range tests {
+		if got := engine.filterDecode(tt.msg); got != tt.want {
+			t.Errorf("%s at %d Hz: expected dropped %v, got %v", tt.msg.From, tt.msg.Offset, tt.want, got)
+		}
+	}
+
+	list := add("FILTER").Data
+	filters := list["filters"].([]filterStatus)
+	if list["dropped"] != 3 || len(filters) != 2 || filters[0].Hits != 2 || filters[1].Hits != 1 {
+		t.Errorf("Expected 3 decodes dropped, 2 and 1, got %v", list)
+	}
+	engine.Stop()
+
+	// The filters outlast a restart, the counts don't
+	engine = NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	if !engine.filterDecode(protocol.Message{From: "N0ABC"}) {
+		t.Error("Expected the callsign filter loaded again")
+	}
+	if resp := add(fmt.Sprintf("FILTER:delete:%d", filters[0].ID)); !resp.Success {
+		t.Fatalf("Failed to delete filter: %s", resp.Error)
+	}
+	if engine.filterDecode(protocol.Message{From: "N0ABC"}) {
+		t.Error("Expected the callsign filter gone")
+	}
+}
+
+func TestHeardGrid(t *testing.T) {
+	tests := []struct {
+		msg  protocol.Message
+		want string
+	}{
+		{protocol.Message{From: "N0ABC", Message: "N0ABC: @HB HEARTBEAT FN20"}, "FN20"},
+		{protocol.Message{From: "N0ABC", To: "K3DEP", Message: "K3DEP GRID EM12KX"}, "EM12KX"},
+		{protocol.Message{From: "N0ABC", To: "K3DEP", Message: "K3DEP RR73"}, ""},
+		{protocol.Message{From: "N0ABC", To: "K3DEP", Message: "K3DEP SNR -10"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := heardGrid(tt.msg); got != tt.want {
+			t.Errorf("%q: expected grid %q, got %q", tt.msg.Message, tt.want, got)
+		}
+	}
+}
+
+func TestMessagePaths(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Station.Grid = "FN20"
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	engine.storeRX(protocol.Message{Timestamp: time.Now(), From: "DL1ABC", Message: "DL1ABC: @HB HEARTBEAT JO62"})
+	engine.storeRX(protocol.Message{Timestamp: time.Now(), From: "N0XYZ", To: cfg.Station.Callsign, Message: "HELLO"})
+
+	response := engine.handleMessages(&protocol.Command{Type: protocol.CmdMessages, Args: map[string]interface{}{}})
+	messages := response.Data["messages"].([]protocol.Message)
+	for _, msg := range messages {
+		switch msg.From {
+		case "DL1ABC":
+			if msg.Path == nil || msg.Path.Grid != "JO62" || msg.Path.Distance != 6436 || msg.Path.Bearing != 46 {
+				t.Errorf("Expected the path to JO62, got %+v", msg.Path)
+			}
+		case "N0XYZ":
+			if msg.Path != nil {
+				t.Errorf("Expected no path without a grid, got %+v", msg.Path)
+			}
+		}
+	}
+}
+
+func TestHeardMap(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Station.Grid = "FN20"
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	engine.storeRX(protocol.Message{Timestamp: time.Now(), From: "DL1ABC", Message: "DL1ABC: @HB HEARTBEAT JO62", SNR: -12, Frequency: 14078000})
+	engine.storeRX(protocol.Message{Timestamp: time.Now(), From: "N0XYZ", Message: "N0XYZ: @HB HEARTBEAT", Frequency: 14078000})
+
+	response := engine.handleGetMap([]string{"3600"})
+	if !response.Success {
+		t.Fatalf("GET_MAP failed: %s", response.Error)
+	}
+	features := response.Data["features"].([]map[string]interface{})
+	if len(features) != 1 || response.Data["unplaced"] != 1 {
+		t.Fatalf("Expected DL1ABC on the map and N0XYZ unplaced, got %+v", response.Data)
+	}
+	coordinates := features[0]["geometry"].(map[string]interface{})["coordinates"].([]float64)
+	if coordinates[0] != 13 || coordinates[1] != 52.5 {
+		t.Errorf("Expected JO62 at 13,52.5, got %v", coordinates)
+	}
+	properties := features[0]["properties"].(map[string]interface{})
+	if properties["callsign"] != "DL1ABC" || properties["band"] != "20m" || properties["distance"] != 6436.0 {
+		t.Errorf("Unexpected properties %+v", properties)
+	}
+
+	response = engine.handleGetMap([]string{"3600", "40m"})
+	if features := response.Data["features"].([]map[string]interface{}); len(features) != 0 {
+		t.Errorf("Expected nobody heard on 40m, got %+v", features)
+	}
+
+	if response := engine.handleGetMap([]string{"soon"}); response.Success {
+		t.Error("Expected an invalid window to fail")
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, ":Issued: 2024 Jan 15 1205 UTC\n"+
+			"Solar flux 160 and estimated planetary A-index 5.\n"+
+			"The estimated planetary K-index at 1200 UTC on 15 January was 1.33.\n")
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Propagation.Enabled = true
+	cfg.Propagation.URL = server.URL
+	cfg.Propagation.Refresh = 60
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	response := engine.handleGetPropagation()
+	if response.Data["conditions"].(*propagation.Conditions) != nil {
+		t.Fatal("Expected no conditions before the first fetch")
+	}
+
+	go engine.propagationLoop()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		response = engine.handleGetPropagation()
+		if conditions := response.Data["conditions"].(*propagation.Conditions); conditions != nil {
+			if conditions.SolarFlux != 160 || conditions.AIndex != 5 || conditions.KIndex != 1.33 {
+				t.Errorf("Unexpected conditions %+v", conditions)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the solar indices")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStatsSummary(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Station.Grid = "FN20"
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	engine.storeRX(protocol.Message{Timestamp: time.Now(), From: "N0ABC", Message: "N0ABC: @HB HEARTBEAT EM12", Frequency: 7079500, Offset: 1500})
+	engine.storeRX(protocol.Message{Timestamp: time.Now(), From: "DL1ABC", Message: "DL1ABC: @HB HEARTBEAT JO62", Frequency: 14079520, Offset: 1520})
+	engine.storeRX(protocol.Message{Timestamp: time.Now(), From: "N0XYZ", Message: "N0XYZ: @HB HEARTBEAT", Frequency: 14080000, Offset: 2000})
+
+	response := engine.handleGetStatsSummary([]string{"3600"})
+	if !response.Success {
+		t.Fatalf("GET_STATS_SUMMARY failed: %s", response.Error)
+	}
+	summary := response.Data["summary"].(*storage.ActivitySummary)
+	if summary.TotalDecodes != 3 || summary.UniqueCallsigns != 3 || summary.Bands["20m"] != 2 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+	bestDX := response.Data["best_dx"].(map[string]interface{})
+	if bestDX["callsign"] != "DL1ABC" || bestDX["distance"] != 6436.0 || bestDX["band"] != "20m" {
+		t.Errorf("Expected DL1ABC as best DX, got %+v", bestDX)
+	}
+
+	if response := engine.handleGetStatsSummary([]string{"-1"}); response.Success {
+		t.Error("Expected an invalid window to fail")
+	}
+}
+
+func TestStatsSeries(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Storage.StatsMinuteHours = 1
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	minute := time.Now().Truncate(time.Minute).Add(-time.Minute)
+	engine.storeRX(protocol.Message{Timestamp: minute.Add(time.Second), From: "N0ABC", Message: "N0ABC: @HB HEARTBEAT", SNR: -12})
+	noise := -95.0
+	engine.recordStatsMinute(minute, &noise)
+
+	from := strconv.FormatInt(minute.Add(-10*time.Minute).Unix(), 10)
+	to := strconv.FormatInt(time.Now().Unix(), 10)
+	response := engine.handleGetStatsSeries([]string{"auto", from, to})
+	if !response.Success {
+		t.Fatalf("GET_STATS_SERIES failed: %s", response.Error)
+	}
+	if response.Data["resolution"] != storage.StatsMinute {
+		t.Errorf("Expected minutes for the last hour, got %v", response.Data["resolution"])
+	}
+	points := response.Data["points"].([]storage.StatsPoint)
+	if len(points) != 1 || points[0].Decodes != 1 || *points[0].AvgSNR != -12 || *points[0].NoiseFloor != -95 {
+		t.Errorf("Unexpected points %+v", points)
+	}
+
+	// Older than the minute retention, only hours are left
+	from = strconv.FormatInt(time.Now().Add(-3*time.Hour).Unix(), 10)
+	response = engine.handleGetStatsSeries([]string{"auto", from, to})
+	if response.Data["resolution"] != storage.StatsHour {
+		t.Errorf("Expected hours beyond the minute retention, got %v", response.Data["resolution"])
+	}
+
+	if response := engine.handleGetStatsSeries([]string{"1m", to, from}); response.Success {
+		t.Error("Expected a backwards range to fail")
+	}
+}
+
+func TestSpotFor(t *testing.T) {
+	at := time.Date(2024, time.January, 15, 11, 15, 0, 0, time.UTC)
+	spot := spotFor(protocol.Message{
+		Timestamp: at, From: "DL1ABC", Message: "DL1ABC: @HB HEARTBEAT JO62",
+		SNR: -12, Frequency: 14079520, Offset: 1520, Mode: "JS8",
+	})
+	if spot.From != "DL1ABC" || spot.Grid != "JO62" || spot.Band != "20m" || spot.Offset != 1520 || !spot.Time.Equal(at) {
+		t.Errorf("Unexpected spot %+v", spot)
+	}
+}
+
+func TestTriggers(t *testing.T) {
+	requests := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- r.URL.Path + " " + string(body)
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Triggers.Hooks = []config.Trigger{
+		{Event: triggers.Directed, URL: server.URL + "/directed", Template: "{{.From}} calls {{.Station}}"},
+		{Event: triggers.Heard, Callsigns: []string{"N0ABC"}, URL: server.URL + "/heard", Template: "{{.From}} {{.SNR}}"},
+		{Event: triggers.TXFailed, URL: server.URL + "/failed", Template: "{{.Message}}: {{.Error}}"},
+	}
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	engine.startTriggers()
+
+	callsign := cfg.Station.Callsign
+	engine.triggerRX(protocol.Message{From: "DL1ABC", To: callsign, Message: callsign + " SNR?", SNR: -5})
+	engine.triggerRX(protocol.Message{From: "N0ABC", To: "@HB", Message: "HEARTBEAT", SNR: -12})
+	engine.triggerTX(protocol.Message{From: callsign, Message: "CQ CQ"}, fmt.Errorf("audio output failed"))
+
+	for _, want := range []string{
+		"/directed DL1ABC calls " + callsign,
+		"/heard N0ABC -12",
+		"/failed CQ CQ: audio output failed",
+	} {
+		select {
+		case got := <-requests:
+			if got != want {
+				t.Errorf("Got request %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %q", want)
+		}
+	}
+}
+
+type fakeLifecycle struct {
+	restarts  chan struct{}
+	rebootErr error
+}
+
+func (l *fakeLifecycle) Restart()         { l.restarts <- struct{}{} }
+func (l *fakeLifecycle) CanReboot() error { return l.rebootErr }
+func (l *fakeLifecycle) Reboot()          {}
+
+func TestLifecycle(t *testing.T) {
+	tempDir := t.TempDir()
+	engine := NewCoreEngine(createTestConfig(tempDir), filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	if response := engine.handleCommand(&protocol.Command{Type: "RESTART"}); response.Success {
+		t.Error("Expected RESTART to fail without a lifecycle")
+	}
+
+	lifecycle := &fakeLifecycle{restarts: make(chan struct{}, 1), rebootErr: fmt.Errorf("no CAP_SYS_BOOT")}
+	engine.SetLifecycle(lifecycle)
+	engine.SetVersion("0.2.0")
+
+	if response := engine.handleCommand(&protocol.Command{Type: "RESTART"}); !response.Success {
+		t.Errorf("RESTART failed: %s", response.Error)
+	}
+	select {
+	case <-lifecycle.restarts:
+	case <-time.After(5 * time.Second):
+		t.Error("Restart was not requested")
+	}
+
+	response := engine.handleCommand(&protocol.Command{Type: "REBOOT"})
+	if response.Success || !strings.Contains(response.Error, "no CAP_SYS_BOOT") {
+		t.Errorf("Expected REBOOT to be refused, got %+v", response)
+	}
+
+	status := engine.handleStatus().Data["status"].(protocol.Status)
+	if status.Version != "0.2.0" {
+		t.Errorf("Expected version 0.2.0 in the status, got %q", status.Version)
+	}
+}
+
+func TestAutoReplyToggle(t *testing.T) {
+	tempDir := t.TempDir()
+	engine := NewCoreEngine(createTestConfig(tempDir), filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	// Decodes carry the whole text, the command after our callsign
+	callsign := engine.config.Station.Callsign
+	query := protocol.Message{From: "DL1ABC", To: callsign, Message: callsign + " SNR?", SNR: -5}
+
+	engine.handleAutoReply(query)
+	if len(engine.txMessages) != 1 {
+		t.Fatalf("Expected an SNR reply to be queued, got %d messages", len(engine.txMessages))
+	}
+	if reply := <-engine.txMessages; reply.To != "DL1ABC" || reply.Message != "DL1ABC -05" {
+		t.Errorf("Expected an SNR report to DL1ABC, got %+v", reply)
+	}
+	engine.handleAutoReply(protocol.Message{From: "DL1ABC", To: callsign, Message: callsign + " HELLO", SNR: -5})
+	if len(engine.txMessages) != 0 {
+		t.Fatal("Expected no reply to a message that isn't a query")
+	}
+
+	response := engine.handleCommand(&protocol.Command{Type: "AUTO OFF"})
+	if !response.Success || response.Data["auto"] != false {
+		t.Fatalf("Expected AUTO OFF to turn replies off, got %+v", response)
+	}
+	if status := engine.handleStatus().Data["status"].(protocol.Status); status.Auto {
+		t.Error("Expected the status to show auto replies off")
+	}
+
+	engine.handleAutoReply(query)
+	if len(engine.txMessages) != 0 {
+		t.Error("Expected no reply with auto replies off")
+	}
+
+	if response := engine.handleCommand(&protocol.Command{Type: "AUTO MAYBE"}); response.Success {
+		t.Error("Expected AUTO MAYBE to be rejected")
+	}
+	if response := engine.handleCommand(&protocol.Command{Type: "AUTO ON"}); response.Data["auto"] != true {
+		t.Errorf("Expected AUTO ON to turn replies on, got %+v", response)
+	}
+
+	// The same query from another station is answered again once back on
+	engine.handleAutoReply(protocol.Message{From: "W1AW", To: callsign, Message: "W1AW: " + callsign + " SNR?", SNR: -12})
+	if len(engine.txMessages) != 1 {
+		t.Fatalf("Expected an SNR reply with auto replies back on, got %d messages", len(engine.txMessages))
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	limits := rateLimits{callsignPerHour: 6, callsignBurst: 2, globalPerHour: 30, globalBurst: 3}
+	var limiter rateLimiter
+	start := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if !limiter.allow(rateReply, "N0ABC", start, limits) {
+			t.Fatalf("Expected reply %d to N0ABC within the burst", i+1)
+		}
+	}
+	if limiter.allow(rateReply, "n0abc", start, limits) {
+		t.Error("Expected the third reply to N0ABC to be held back")
+	}
+	if !limiter.allow(rateAnswer, "DL1ABC", start, limits) {
+		t.Error("Expected another station to be answered")
+	}
+	if limiter.allow(rateAnswer, "JA1ABC", start, limits) {
+		t.Error("Expected the global burst to be used up")
+	}
+
+	// Ten minutes earn N0ABC one reply at 6 an hour and refill the global
+	// bucket
+	later := start.Add(10 * time.Minute)
+	if !limiter.allow(rateReply, "N0ABC", later, limits) {
+		t.Error("Expected N0ABC's bucket to have refilled a token")
+	}
+	if limiter.allow(rateReply, "N0ABC", later, limits) {
+		t.Error("Expected only one token to have refilled")
+	}
+
+	global, stations, counts := limiter.snapshot(later, limits)
+	if global != 2 {
+		t.Errorf("Expected 2 global tokens, got %.2f", global)
+	}
+	if len(stations) != 3 || stations[2].Callsign != "N0ABC" || !stations[2].Limited {
+		t.Errorf("Expected N0ABC limited among 3 stations, got %+v", stations)
+	}
+	if counts[rateReply] != (rateCount{Allowed: 3, Limited: 2}) || counts[rateAnswer] != (rateCount{Allowed: 1, Limited: 1}) {
+		t.Errorf("Unexpected counts %+v", counts)
+	}
+
+	// Idle stations with full buckets are dropped
+	if _, stations, _ := limiter.snapshot(later.Add(2*time.Hour), limits); len(stations) != 0 {
+		t.Errorf("Expected idle stations to be pruned, got %+v", stations)
+	}
+
+	// Without limits every reply goes out
+	unlimited := rateLimits{callsignPerHour: -1, callsignBurst: 1, globalPerHour: -1, globalBurst: 1}
+	for i := 0; i < 10; i++ {
+		if !limiter.allow(rateTransfer, "N0ABC", later, unlimited) {
+			t.Fatal("Expected no limit with per_hour -1")
+		}
+	}
+
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.RateLimit.CallsignBurst = 1
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	engine.handleCommand(&protocol.Command{Type: "AUTO ON"})
+
+	query := protocol.Message{From: "DL1ABC", To: cfg.Station.Callsign, Message: " SNR?", SNR: -5}
+	engine.handleAutoReply(query)
+	engine.handleAutoReply(query)
+	if len(engine.txMessages) != 1 {
+		t.Errorf("Expected one SNR reply within the limit, got %d", len(engine.txMessages))
+	}
+
+	response := engine.handleCommand(&protocol.Command{Type: "GET_RATE_LIMITS"})
+	if !response.Success {
+		t.Fatalf("GET_RATE_LIMITS failed: %s", response.Error)
+	}
+	if counts := response.Data["counts"].(map[string]rateCount); counts[rateReply] != (rateCount{Allowed: 1, Limited: 1}) {
+		t.Errorf("Expected one reply sent and one held back, got %+v", counts)
+	}
+}
+
+func TestBusyChannel(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.DSP.Decoder = dsp.DecoderGo
+	cfg.TX.Offset = 1500
+	cfg.TX.Busy = config.BusyMove
+	cfg.TX.BusyMargin = 10
+	cfg.TX.BusyLevel = 10
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	since := time.Now().Add(-busyCycle)
+	if reason := engine.channelBusy(1500, 10, 10, since); reason != "" {
+		t.Fatalf("Expected a clear channel, got %q", reason)
+	}
+
+	// Our own callsign is never in the way
+	engine.noteSignal(protocol.Message{From: "K3DEP", Offset: 1500})
+	engine.noteSignal(protocol.Message{From: "N0ABC", Offset: 1520})
+	if reason := engine.channelBusy(1500, 10, 10, since); !strings.Contains(reason, "N0ABC") {
+		t.Errorf("Expected N0ABC to make 1500 Hz busy, got %q", reason)
+	}
+	if reason := engine.channelBusy(1600, 10, 10, since); reason != "" {
+		t.Errorf("Expected 1600 Hz clear, got %q", reason)
+	}
+	if reason := engine.channelBusy(1500, 10, 10, time.Now().Add(time.Second)); reason != "" {
+		t.Errorf("Expected decodes before the window to be left out, got %q", reason)
+	}
+
+	events, unsubscribe := engine.SubscribeEvents()
+	defer unsubscribe()
+
+	if err := engine.clearChannel(); err != nil {
+		t.Fatalf("Expected the message to move, got %v", err)
+	}
+	if offset := engine.txOffset(); offset != 1450 {
+		t.Errorf("Expected the TX offset moved to 1450 Hz, got %d", offset)
+	}
+	select {
+	case event := <-events:
+		if event.Type != protocol.EventChannelBusy || event.Data["action"] != config.BusyMove || event.Data["moved_to"] != 1450 {
+			t.Errorf("Expected a channel_busy event moving to 1450 Hz, got %+v", event)
+		}
+	default:
+		t.Error("Expected a channel_busy event")
+	}
+
+	// Reporting sends at once
+	engine.config.TX.Busy = config.BusyReport
+	engine.mutex.Lock()
+	engine.txAudioOffset = 1500
+	engine.mutex.Unlock()
+	if err := engine.clearChannel(); err != nil || engine.txOffset() != 1500 {
+		t.Errorf("Expected report to send at 1500 Hz, got %v at %d Hz", err, engine.txOffset())
+	}
+	if event := <-events; event.Data["action"] != config.BusyReport {
+		t.Errorf("Expected a report, got %+v", event)
+	}
+}
+
+func TestFindOffset(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.DSP.Decoder = dsp.DecoderGo
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	if response := engine.handleCommand(&protocol.Command{Type: "FIND_OFFSET"}); response.Success {
+		t.Fatal("Expected FIND_OFFSET to fail without any spectrum")
+	}
+
+	// Noise with strong signals at 1000 and 1500 Hz
+	monitor := engine.GetAudioMonitors()[0]
+	rate := float64(cfg.Audio.SampleRate)
+	samples := make([]int16, 4096)
+	seed := uint32(1)
+	for i := range samples {
+		seed = seed*1664525 + 1013904223
+		noise := float64(int32(seed)>>20) / 4
+		signal := 8000*math.Sin(2*math.Pi*1000*float64(i)/rate) + 8000*math.Sin(2*math.Pi*1500*float64(i)/rate)
+		samples[i] = int16(signal + noise)
+	}
+	monitor.ProcessSamples(samples)
+
+	response := engine.handleCommand(&protocol.Command{Type: "FIND_OFFSET"})
+	if !response.Success {
+		t.Fatalf("FIND_OFFSET failed: %s", response.Error)
+	}
+	offset := response.Data["offset"].(int)
+	if response.Data["previous"] != 1500 || engine.txOffset() != offset {
+		t.Errorf("Expected the offset moved from 1500 Hz to %d Hz, got %+v", offset, response.Data)
+	}
+	if offset < config.DefaultFindLow || offset+50 > config.DefaultFindHigh {
+		t.Errorf("Expected an offset within the find range, got %d", offset)
+	}
+	for _, busy := range []int{1000, 1500} {
+		if middle := offset + 25; middle > busy-100 && middle < busy+100 {
+			t.Errorf("Expected an offset away from the signal at %d Hz, got %d", busy, offset)
+		}
+	}
+}
+
+func TestBandNoise(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	if decodeNoiseFloor(engine.GetAudioMonitors()[0]) != nil {
+		t.Error("Expected no noise floor before any audio")
+	}
+
+	now := time.Now()
+	bands := map[string]*bandSamples{}
+	engine.frequency = 14078000
+	engine.sampleBandNoise(bands, -90)
+	engine.sampleBandNoise(bands, -100)
+	engine.frequency = 7078000
+	engine.sampleBandNoise(bands, -80)
+	engine.frequency = 5000000 // Outside the bands
+	engine.sampleBandNoise(bands, -70)
+	engine.recordBandNoise(now, bands)
+
+	if response := engine.handleCommand(&protocol.Command{Type: "GET_BAND_NOISE 1 0"}); response.Success {
+		t.Error("Expected a backwards range to fail")
+	}
+	command := fmt.Sprintf("GET_BAND_NOISE %d %d", now.Add(-time.Hour).Unix(), now.Add(time.Hour).Unix())
+	response := engine.handleCommand(&protocol.Command{Type: command})
+	if !response.Success {
+		t.Fatalf("GET_BAND_NOISE failed: %s", response.Error)
+	}
+	noise := response.Data["bands"].([]storage.BandNoise)
+	if len(noise) != 2 || noise[0].Band != "20m" || noise[0].Average != -95 || noise[1].Band != "40m" || noise[1].Average != -80 {
+		t.Errorf("Expected 20m and 40m noise, got %+v", noise)
+	}
+	if response.Data["band"] != "" {
+		t.Errorf("Expected no band outside the bands, got %v", response.Data["band"])
+	}
+}
+
+func TestPassband(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.DSP.Decoder = dsp.DecoderGo
+	cfg.DSP.DecodeLow, cfg.DSP.DecodeHigh = 300, 2700
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	response := engine.handleCommand(&protocol.Command{Type: "PASSBAND"})
+	if !response.Success || response.Data["low"] != 300 || response.Data["high"] != 2700 || response.Data["searched"] != true {
+		t.Fatalf("Expected the configured passband, got %+v (%s)", response.Data, response.Error)
+	}
+
+	for _, bad := range []string{"PASSBAND 100 2500", "PASSBAND 1000 1020", "PASSBAND 500", "PASSBAND low high"} {
+		if response := engine.handleCommand(&protocol.Command{Type: bad}); response.Success || response.Code != protocol.ErrCodeInvalid {
+			t.Errorf("Expected %s to be refused", bad)
+		}
+	}
+
+	// A signal at 1200 Hz is decoded inside the passband only
+	tones, err := dsp.NewJS8Encoder().EncodeMessage("CQ-N0CALL-XX", 0)
+	if err != nil {
+		t.Fatalf("Encoding failed: %v", err)
+	}
+	signal := dsp.NewJS8Encoder().GenerateAudioAt(tones, 1200, 12000)
+	buffer := make([]int16, 15*12000)
+	copy(buffer[6000:], signal)
+	decoder := engine.rxDecoders[0]
+	decoder.SetSampleRate(12000)
+	decodes := func() int {
+		count, err := decoder.DecodeBuffer(buffer, func(*dsp.DecodeResult) {})
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		return count
+	}
+	if decodes() != 1 {
+		t.Fatal("Expected the signal decoded in the configured passband")
+	}
+
+	response = engine.handleCommand(&protocol.Command{Type: "PASSBAND 1500 2500"})
+	if !response.Success || response.Data["low"] != 1500 || response.Data["configured_low"] != 300 {
+		t.Fatalf("Expected the passband narrowed, got %+v (%s)", response.Data, response.Error)
+	}
+	if decodes() != 0 {
+		t.Error("Expected no decode outside the narrowed passband")
+	}
+
+	response = engine.handleCommand(&protocol.Command{Type: "PASSBAND RESET"})
+	if !response.Success || response.Data["low"] != 300 || decodes() != 1 {
+		t.Errorf("Expected the configured passband back, got %+v", response.Data)
+	}
+}
+
+func TestBandPlan(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Station.Region = 1
+	cfg.TX.OutOfBand = config.OutOfBandBlock
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	if err := engine.checkBandPlan(); err != nil {
+		t.Errorf("Expected 20m to be open in region 1, got %v", err)
+	}
+
+	// 7.25 MHz is in the US 40m band but not region 1's
+	response := engine.handleCommand(&protocol.Command{Type: "BANDPLAN 7250000"})
+	check, _ := response.Data["check"].(protocol.BandCheck)
+	if !response.Success || check.Allocated || check.Band != "40m" || check.Warning == "" {
+		t.Fatalf("Expected 7.25 MHz outside region 1's 40m, got %+v (%s)", response.Data, response.Error)
+	}
+	if response := engine.handleCommand(&protocol.Command{Type: "BANDPLAN 40m"}); response.Success || response.Code != protocol.ErrCodeInvalid {
+		t.Error("Expected a band name to be refused as a frequency")
+	}
+
+	events, cancel := engine.SubscribeEvents()
+	defer cancel()
+	engine.mutex.Lock()
+	engine.frequency = 7250000
+	engine.mutex.Unlock()
+	if err := engine.checkBandPlan(); err == nil {
+		t.Error("Expected TX outside the band plan to be blocked")
+	}
+	select {
+	case event := <-events:
+		if event.Type != protocol.EventBandPlan || event.Data["blocked"] != true {
+			t.Errorf("Expected a blocked band_plan event, got %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected a band_plan event")
+	}
+
+	response = engine.handleStatus()
+	if status := response.Data["status"].(protocol.Status); status.BandPlan == nil || status.BandPlan.Allocated {
+		t.Errorf("Expected STATUS to warn about the band plan, got %+v", status.BandPlan)
+	}
+
+	engine.mutex.Lock()
+	engine.config.TX.OutOfBand = config.OutOfBandWarn
+	engine.mutex.Unlock()
+	if err := engine.checkBandPlan(); err != nil {
+		t.Errorf("Expected TX outside the band plan only warned about, got %v", err)
+	}
+}
+
+func TestMessageFrequencies(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	engine.frequency = 7078000
+	if dial := engine.readDial(); dial != 7078000 {
+		t.Errorf("Expected the last dial set without a rig, got %d", dial)
+	}
+
+	queued, ok := engine.queueTX(protocol.Message{Timestamp: time.Now(), From: cfg.Station.Callsign, Message: "HB", Mode: "JS8"})
+	if !ok {
+		t.Fatal("Failed to queue message")
+	}
+	if queued.Dial != 7078000 || queued.Offset != 1500 || queued.Frequency != 7079500 {
+		t.Errorf("Expected TX at the dial plus the TX offset, got %+v", queued)
+	}
+
+	messages, err := engine.messageStore.GetQueuedMessages()
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("Expected the queued message stored, got %d (%v)", len(messages), err)
+	}
+	if m := messages[0]; m.Dial != 7078000 || m.Offset != 1500 || m.Frequency != 7079500 {
+		t.Errorf("Expected the dial and offset stored, got %+v", m)
+	}
+}
+
+func TestMacros(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Station.Grid = "FN20"
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	engine.storeRX(protocol.Message{Timestamp: time.Now(), From: "DL1ABC", Message: "DL1ABC: @HB HEARTBEAT JO62", SNR: -7})
+
+	save := &protocol.Command{Type: protocol.CmdMacro, Args: map[string]interface{}{
+		"action": protocol.MacroSet, "name": "rpt", "text": "{CALL} {SNR} {GRID} DE {MYCALL} {MYGRID}",
+	}}
+	if response := engine.handleCommand(save); !response.Success {
+		t.Fatalf("Failed to save macro: %s", response.Error)
+	}
+
+	response := engine.handleSend(&protocol.Command{Type: protocol.CmdSend, Args: map[string]interface{}{
+		"to": "DL1ABC", "message": "{RPT} 73",
+	}})
+	if !response.Success {
+		t.Fatalf("Failed to send macro: %s", response.Error)
+	}
+	want := "DL1ABC -07 JO62 DE " + cfg.Station.Callsign + " FN20 73"
+	if msg := response.Data["message"].(protocol.Message); msg.Message != want {
+		t.Errorf("Expected %q, got %q", want, msg.Message)
+	}
+
+	failures := []struct {
+		to   string
+		text string
+	}{
+		{"", "{SNR}"},        // No station to report
+		{"N0XYZ", "{GRID}"},  // Never heard
+		{"DL1ABC", "{NOPE}"}, // No such macro
+	}
+	for _, tt := range failures {
+		expand := &protocol.Command{Type: protocol.CmdMacro, Args: map[string]interface{}{
+			"action": protocol.MacroExpand, "to": tt.to, "text": tt.text,
+		}}
+		if response := engine.handleCommand(expand); response.Success || response.Code != protocol.ErrCodeInvalid {
+			t.Errorf("Expected %q to %q to fail, got %+v", tt.text, tt.to, response)
+		}
+	}
+
+	list := engine.handleCommand(&protocol.Command{Type: protocol.CmdMacro})
+	if list.Data["count"] != 1 {
+		t.Errorf("Expected one macro, got %+v", list.Data)
+	}
+	remove := &protocol.Command{Type: protocol.CmdMacro, Args: map[string]interface{}{"action": protocol.MacroDelete, "name": "RPT"}}
+	if response := engine.handleCommand(remove); !response.Success {
+		t.Errorf("Failed to delete macro: %s", response.Error)
+	}
+	if response := engine.handleCommand(remove); response.Success {
+		t.Error("Expected deleting a missing macro to fail")
+	}
+}
+
+func TestScriptedQSO(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Station.Grid = "FN20"
+	cfg.QSO.Enabled = true
+	cfg.QSO.Timeout = 60
+	cfg.QSO.Steps = []string{"{CALL} {MYGRID}", "{CALL} {SNR}", "{CALL} RR 73"}
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	callsign := cfg.Station.Callsign
+
+	// A directed message without a CQ out is left to the auto-replies
+	call := protocol.Message{Timestamp: time.Now(), From: "DL1ABC", To: callsign, Message: callsign + " JO62", SNR: -7}
+	if engine.advanceQSO(call) {
+		t.Fatal("Expected no scripted QSO without a CQ")
+	}
+
+	engine.noteCQ(protocol.Message{From: callsign, Message: "CQ CQ " + callsign})
+	engine.storeRX(call)
+	want := []string{"DL1ABC FN20", "DL1ABC -07", "DL1ABC RR 73"}
+	for i, text := range want {
+		if !engine.advanceQSO(call) {
+			t.Fatalf("Expected step %d to answer DL1ABC", i+1)
+		}
+		msg := <-engine.txMessages
+		if msg.To != "DL1ABC" || msg.Message != text {
+			t.Errorf("Expected step %d to send %q, got %q to %q", i+1, text, msg.Message, msg.To)
+		}
+
+		// A repeat before the step goes out gets no second answer
+		engine.advanceQSO(call)
+		if len(engine.txMessages) != 0 {
+			t.Errorf("Expected no answer to a repeat before step %d went out", i+1)
+		}
+		engine.qsoSent(msg, nil)
+	}
+	if _, state := engine.qsoStatus(); state != nil {
+		t.Errorf("Expected the QSO complete after the last step, got %+v", state)
+	}
+
+	// A station that stops answering times the QSO out after the retries
+	engine.noteCQ(protocol.Message{From: callsign, Message: "CQ " + callsign})
+	engine.advanceQSO(protocol.Message{Timestamp: time.Now(), From: "N0XYZ", To: callsign, Message: callsign + " EM12"})
+	engine.qsoSent(<-engine.txMessages, nil)
+	engine.checkQSOTimeout(time.Now().Add(2 * time.Minute))
+	if _, state := engine.qsoStatus(); state != nil {
+		t.Errorf("Expected the QSO timed out, got %+v", state)
+	}
+
+	if response := engine.handleCommand(&protocol.Command{Type: "QSO STOP"}); response.Success {
+		t.Error("Expected QSO STOP to fail without a QSO running")
+	}
+	response := engine.handleCommand(&protocol.Command{Type: "QSO OFF"})
+	if !response.Success || response.Data["enabled"] != false {
+		t.Errorf("Expected QSO OFF to turn scripts off, got %+v", response)
+	}
+	engine.noteCQ(protocol.Message{From: callsign, Message: "CQ " + callsign})
+	if engine.advanceQSO(call) {
+		t.Error("Expected no scripted QSO with scripts off")
+	}
+}
+
+func TestAnswerCQ(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Station.Grid = "FN20"
+	cfg.Answer.Enabled = true
+	cfg.Answer.PerHour = 2
+	cfg.Answer.Reply = "{CALL} {SNR} {MYGRID}"
+	cfg.Answer.Rules = []config.AnswerRule{
+		{Bands: []string{"20m"}, NewGrid: true},
+		{Callsigns: []string{"K1ABC"}},
+	}
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	cq := func(from, grid string, frequency int) protocol.Message {
+		msg := protocol.Message{Timestamp: time.Now(), From: from, To: "@ALLCALL", Message: "CQ CQ " + grid, SNR: -12, Frequency: frequency}
+		engine.storeRX(msg)
+		engine.answerCQ(msg)
+		return msg
+	}
+
+	cq("DL1ABC", "JO62", 14078000)
+	if msg := <-engine.txMessages; msg.To != "DL1ABC" || msg.Message != "DL1ABC -12 FN20" {
+		t.Errorf("Expected the new grid answered, got %q to %q", msg.Message, msg.To)
+	}
+
+	// Only 20m has the new grid rule, and DL1ABC was just answered
+	cq("JA1ABC", "PM95", 7078000)
+	cq("DL1ABC", "JO62", 14078000)
+	if len(engine.txMessages) != 0 {
+		t.Error("Expected no answer off 20m or to a caller answered within the hour")
+	}
+
+	// A watched call is answered on any band, then the limit is reached
+	cq("K1ABC", "FN42", 7078000)
+	<-engine.txMessages
+	cq("VK2ABC", "QF56", 14078000)
+	if len(engine.txMessages) != 0 {
+		t.Error("Expected no answer over the hourly limit")
+	}
+
+	response := engine.handleCommand(&protocol.Command{Type: "GET_ANSWERS 10"})
+	if !response.Success {
+		t.Fatalf("GET_ANSWERS failed: %s", response.Error)
+	}
+	decisions := response.Data["decisions"].([]storage.AnswerDecision)
+	want := []string{storage.AnswerSkipped, storage.AnswerSent, storage.AnswerSkipped, storage.AnswerSkipped, storage.AnswerSent}
+	if len(decisions) != len(want) {
+		t.Fatalf("Expected %d decisions, got %+v", len(want), decisions)
+	}
+	for i, decision := range want {
+		if decisions[i].Decision != decision {
+			t.Errorf("Expected decision %d %s, got %+v", i, decision, decisions[i])
+		}
+	}
+	if !strings.Contains(decisions[0].Reason, "limit") || decisions[4].Reason != "new grid JO62" {
+		t.Errorf("Expected the limit and new grid reasons, got %q and %q", decisions[0].Reason, decisions[4].Reason)
+	}
+
+	// Nothing goes out with automatic replies off
+	engine.handleCommand(&protocol.Command{Type: "AUTO OFF"})
+	engine.answered = nil
+	cq("VK2ABC", "QF56", 14078000)
+	if len(engine.txMessages) != 0 {
+		t.Error("Expected no answer with automatic replies off")
+	}
+}
+
+func TestDXCC(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.DXCC.File = filepath.Join("..", "dxcc", "testdata", "cty.dat")
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	engine.loadDXCC()
+
+	events, unsubscribe := engine.SubscribeEvents()
+	defer unsubscribe()
+	newEntities := func() []string {
+		var prefixes []string
+		for {
+			select {
+			case event := <-events:
+				if event.Type == protocol.EventNewEntity {
+					prefixes = append(prefixes, event.Data["entity"].(*protocol.Entity).Prefix)
+				}
+			default:
+				return prefixes
+			}
+		}
+	}
+
+	for _, from := range []string{"DL1ABC", "DJ2XYZ", "JA1ABC"} {
+		engine.storeRX(protocol.Message{Timestamp: time.Now(), From: from, To: "@ALLCALL", Message: "CQ CQ", SNR: -10})
+	}
+	if got := newEntities(); strings.Join(got, " ") != "DL JA" {
+		t.Errorf("Expected new entity alerts for DL and JA, got %v", got)
+	}
+
+	response := engine.handleCommand(&protocol.Command{Type: "GET_STATIONS 10"})
+	stations := response.Data["stations"].([]storage.Station)
+	for _, station := range stations {
+		if station.Entity == nil || (station.Entity.Prefix != "DL" && station.Entity.Prefix != "JA") {
+			t.Errorf("Expected %s tagged with its entity, got %+v", station.Callsign, station.Entity)
+		}
+	}
+
+	response = engine.handleCommand(&protocol.Command{Type: "GET_STATS_SUMMARY 3600"})
+	entities := response.Data["entities"].([]storage.EntityActivity)
+	if len(entities) != 2 || entities[0].Name != "Germany" || entities[0].Stations != 2 || !entities[0].New {
+		t.Errorf("Expected Germany first with 2 stations, got %+v", entities)
+	}
+
+	response = engine.handleCommand(&protocol.Command{Type: "DXCC LOOKUP W6ABC"})
+	if entity, ok := response.Data["entity"].(*dxcc.Entity); !ok || entity == nil || entity.CQZone != 3 {
+		t.Errorf("Expected W6ABC in CQ zone 3, got %+v", response.Data["entity"])
+	}
+	response = engine.handleCommand(&protocol.Command{Type: "DXCC"})
+	if response.Data["loaded"] != true || response.Data["entities"] != 8 {
+		t.Errorf("Expected the country file loaded, got %+v", response.Data)
+	}
+}
+
+func TestAwards(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.DXCC.File = filepath.Join("..", "dxcc", "testdata", "cty.dat")
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	engine.loadDXCC()
+	mycall := cfg.Station.Callsign
+
+	// Heard after we called: a QSO on 20m
+	sent := protocol.Message{Timestamp: time.Now().Add(-time.Minute), From: mycall, To: "DL1ABC", Message: "DL1ABC SNR?", Status: protocol.MessageSent}
+	if err := engine.messageStore.StoreMessage(sent, "TX", "DIRECTED"); err != nil {
+		t.Fatalf("Failed to store message: %v", err)
+	}
+	engine.storeRX(protocol.Message{Timestamp: time.Now(), From: "DL1ABC", To: mycall, Message: "SNR -08 JO62", Frequency: 14079500, Offset: 1500, SNR: -8})
+
+	// Calling a station heard calling us: a QSO on 40m
+	engine.storeRX(protocol.Message{Timestamp: time.Now(), From: "JA1ABC", To: mycall, Message: "HEARTBEAT SNR", Frequency: 7079500, Offset: 1500})
+	engine.frequency = 7078000
+	engine.logSentQSO(protocol.Message{From: mycall, To: "JA1ABC", Message: "JA1ABC SNR -12", Offset: 1500})
+
+	// A station heard without an exchange is not logged
+	engine.storeRX(protocol.Message{Timestamp: time.Now(), From: "W6ABC", To: "@ALLCALL", Message: "CQ CQ", Frequency: 14079500})
+
+	response := engine.handleCommand(&protocol.Command{Type: "GET_LOG 10"})
+	if !response.Success {
+		t.Fatalf("GET_LOG failed: %s", response.Error)
+	}
+	qsos := response.Data["qsos"].([]storage.QSO)
+	if len(qsos) != 2 || qsos[0].Callsign != "JA1ABC" || qsos[0].Band != "40m" || qsos[1].Band != "20m" || qsos[1].Grid != "JO62" {
+		t.Fatalf("Expected QSOs with JA1ABC on 40m and DL1ABC on 20m, got %+v", qsos)
+	}
+
+	confirmed := storage.QSLConfirmed
+	if _, err := engine.messageStore.UpdateStation("DL1ABC", storage.StationUpdate{QSLStatus: &confirmed}); err != nil {
+		t.Fatalf("Failed to confirm DL1ABC: %v", err)
+	}
+
+	response = engine.handleCommand(&protocol.Command{Type: "GET_AWARDS"})
+	if !response.Success {
+		t.Fatalf("GET_AWARDS failed: %s", response.Error)
+	}
+	progress := response.Data["awards"].(awards.Progress)
+	if progress.Contacts != 2 || progress.DXCC.Worked != 2 || progress.DXCC.Confirmed != 1 || progress.Grids.Worked != 1 {
+		t.Errorf("Expected 2 entities worked, 1 confirmed and 1 grid, got %+v", progress)
+	}
+	if progress.DXCC.Items[0].Name != "Germany" {
+		t.Errorf("Expected DXCC items named, got %+v", progress.DXCC.Items)
+	}
+	if bands := response.Data["bands"].([]string); strings.Join(bands, " ") != "20m 40m" {
+		t.Errorf("Expected 20m and 40m, got %v", bands)
+	}
+
+	response = engine.handleCommand(&protocol.Command{Type: "GET_AWARDS 40m"})
+	if progress := response.Data["awards"].(awards.Progress); progress.Contacts != 1 || progress.DXCC.Items[0].ID != "JA" {
+		t.Errorf("Expected only the 40m QSO, got %+v", progress)
+	}
+	if response := engine.handleCommand(&protocol.Command{Type: "GET_AWARDS 11m"}); response.Success {
+		t.Error("Expected an unknown band rejected")
+	}
+}
+
+func TestQSL(t *testing.T) {
+	var uploaded string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploaded = r.FormValue("ADIFData")
+		fmt.Fprint(w, "<HTML><BODY>Result: 1 out of 1 records added<BR></BODY></HTML>")
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.QSL.EQSL.Username = cfg.Station.Callsign
+	cfg.QSL.EQSL.Password = config.NewSecret("hunter2")
+	cfg.QSL.EQSL.URL = server.URL
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	start := time.Date(2024, 1, 15, 11, 12, 0, 0, time.UTC)
+	for i, call := range []string{"DL1ABC", "JA1ABC"} {
+		qso := &storage.QSO{Callsign: call, Start: start.Add(time.Duration(i) * time.Hour), End: start.Add(time.Duration(i) * time.Hour), Band: "20m", Frequency: 14079500, SNR: -8}
+		if _, err := engine.messageStore.LogQSO(qso, time.Minute); err != nil {
+			t.Fatalf("Failed to log QSO: %v", err)
+		}
+	}
+
+	response := engine.handleCommand(&protocol.Command{Type: "EXPORT_ADIF"})
+	if !response.Success || response.Data["count"] != 2 {
+		t.Fatalf("Expected 2 QSOs exported, got %+v", response)
+	}
+	adif := response.Data["adif"].(string)
+	for _, want := range []string{"<CALL:6>DL1ABC", "<QSO_DATE:8>20240115", "<TIME_ON:6>111200", "<MODE:4>MFSK <SUBMODE:3>JS8",
+		"<RST_RCVD:3>-08", "<STATION_CALLSIGN:5>" + cfg.Station.Callsign, "<LOTW_QSL_SENT:1>N"} {
+		if !strings.Contains(adif, want) {
+			t.Errorf("Expected %s in the export, got %s", want, adif)
+		}
+	}
+	if strings.Index(adif, "DL1ABC") > strings.Index(adif, "JA1ABC") {
+		t.Error("Expected the export oldest first")
+	}
+
+	// Signed in TQSL by hand and marked
+	qsos, _ := engine.messageStore.GetQSOs("DL1ABC", 0)
+	response = engine.handleCommand(&protocol.Command{Type: fmt.Sprintf("QSL MARK LOTW %d", qsos[0].ID)})
+	if !response.Success || response.Data["marked"] != 1 {
+		t.Errorf("Expected 1 QSO marked, got %+v", response)
+	}
+	response = engine.handleCommand(&protocol.Command{Type: "EXPORT_ADIF LOTW"})
+	if response.Data["count"] != 1 || strings.Contains(response.Data["adif"].(string), "DL1ABC") {
+		t.Errorf("Expected only JA1ABC pending for LoTW, got %+v", response.Data)
+	}
+
+	response = engine.handleCommand(&protocol.Command{Type: "QSL UPLOAD EQSL"})
+	if !response.Success || response.Data["uploaded"] != 2 {
+		t.Fatalf("Expected 2 QSOs uploaded to eQSL, got %+v", response)
+	}
+	if !strings.Contains(uploaded, "<EQSL_PSWD:7>hunter2") || !strings.Contains(uploaded, "JA1ABC") {
+		t.Errorf("Expected the account and log uploaded, got %s", uploaded)
+	}
+
+	response = engine.handleCommand(&protocol.Command{Type: "QSL"})
+	lotw := response.Data["lotw"].(map[string]interface{})
+	eqsl := response.Data["eqsl"].(map[string]interface{})
+	if lotw["configured"] != false || lotw["pending"] != 1 || eqsl["configured"] != true || eqsl["pending"] != 0 {
+		t.Errorf("Expected 1 QSO pending for LoTW and none for eQSL, got %+v", response.Data)
+	}
+
+	if response := engine.handleCommand(&protocol.Command{Type: "QSL UPLOAD LOTW"}); response.Success {
+		t.Error("Expected an upload to LoTW without a station location to fail")
+	}
+	if response := engine.handleCommand(&protocol.Command{Type: "QSL UPLOAD QRZ"}); response.Success {
+		t.Error("Expected an unknown service rejected")
+	}
+
+	// A long log is exported a page at a time, the header on the first
+	for i := 0; i < adifPage; i++ {
+		qso := &storage.QSO{Callsign: fmt.Sprintf("K%dABC", i), Start: start.Add(time.Duration(i+2) * time.Hour), Band: "20m"}
+		qso.End = qso.Start
+		if _, err := engine.messageStore.LogQSO(qso, time.Minute); err != nil {
+			t.Fatalf("Failed to log QSO: %v", err)
+		}
+	}
+	response = engine.handleCommand(&protocol.Command{Type: "EXPORT_ADIF"})
+	if response.Data["count"] != adifPage || response.Data["total"] != adifPage+2 || response.Data["next"] != adifPage {
+		t.Fatalf("Expected the first %d QSOs, got count %v of %v, next %v", adifPage, response.Data["count"], response.Data["total"], response.Data["next"])
+	}
+	if adif := response.Data["adif"].(string); !strings.Contains(adif, "<EOH>") || !strings.Contains(adif, "DL1ABC") {
+		t.Error("Expected the header and the oldest QSOs on the first page")
+	}
+	response = engine.handleCommand(&protocol.Command{Type: fmt.Sprintf("EXPORT_ADIF %d", adifPage)})
+	if _, more := response.Data["next"]; response.Data["count"] != 2 || more {
+		t.Fatalf("Expected the last 2 QSOs and no next page, got %+v", response.Data)
+	}
+	if adif := response.Data["adif"].(string); strings.Contains(adif, "<EOH>") || !strings.Contains(adif, fmt.Sprintf("K%dABC", adifPage-1)) {
+		t.Errorf("Expected the newest QSOs without a header, got %s", adif)
+	}
+}
+
+func TestLogBroadcast(t *testing.T) {
+	listener, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	receive := func() string {
+		listener.SetReadDeadline(time.Now().Add(2 * time.Second))
+		buf := make([]byte, 4096)
+		n, err := listener.Read(buf)
+		if err != nil {
+			t.Fatalf("No datagram: %v", err)
+		}
+		return string(buf[:n])
+	}
+
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.LogBroadcast.Targets = []config.LogTarget{{Address: listener.LocalAddr().String(), Format: "N1MM"}}
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	engine.startLogBroadcast()
+	mycall := cfg.Station.Callsign
+
+	sent := protocol.Message{Timestamp: time.Now().Add(-time.Minute), From: mycall, To: "DL1ABC", Message: "DL1ABC SNR?", Status: protocol.MessageSent}
+	if err := engine.messageStore.StoreMessage(sent, "TX", "DIRECTED"); err != nil {
+		t.Fatalf("Failed to store message: %v", err)
+	}
+	engine.storeRX(protocol.Message{Timestamp: time.Now(), From: "DL1ABC", To: mycall, Message: "SNR -08", Frequency: 14079500, Offset: 1500, SNR: -8})
+
+	datagram := receive()
+	for _, want := range []string{"<contactinfo>", "<call>DL1ABC</call>", "<band>14</band>", "<mycall>" + mycall + "</mycall>"} {
+		if !strings.Contains(datagram, want) {
+			t.Errorf("Expected %s in %s", want, datagram)
+		}
+	}
+
+	// The grid heard later in the QSO updates the contact
+	engine.storeRX(protocol.Message{Timestamp: time.Now(), From: "DL1ABC", To: mycall, Message: "QTH JO62", Frequency: 14079500, Offset: 1500, SNR: -9})
+	datagram = receive()
+	if !strings.Contains(datagram, "<contactreplace>") || !strings.Contains(datagram, "<gridsquare>JO62</gridsquare>") {
+		t.Errorf("Expected the contact replaced with its grid, got %s", datagram)
+	}
+}
+
+func TestCheckUpdate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tag_name": "v0.3.0", "html_url": "https://github.com/dougsko/js8d/releases/tag/v0.3.0"}`)
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	engine := NewCoreEngine(createTestConfig(tempDir), filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	engine.SetVersion("0.2.0")
+	engine.releaseURL = server.URL
+
+	response := engine.handleCommand(&protocol.Command{Type: "CHECK_UPDATE"})
+	if !response.Success {
+		t.Fatalf("CHECK_UPDATE failed: %s", response.Error)
+	}
+	if response.Data["update_available"] != true || response.Data["latest"].(*release.Release).Version != "0.3.0" {
+		t.Errorf("Unexpected update check %+v", response.Data)
+	}
+}
+
+func TestCalibration(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Radio.CalibrationPPM = 2
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	if got := engine.calibrate(14078000); got != 14078028 {
+		t.Errorf("Expected the radio's 14078000 Hz to be 14078028 Hz, got %d", got)
+	}
+	if got := engine.uncalibrate(14078028); got != 14078000 {
+		t.Errorf("Expected to set 14078000 Hz for 14078028 Hz, got %d", got)
+	}
+
+	radio := engine.handleRadio().Data
+	if radio["calibration_ppm"] != 2.0 {
+		t.Errorf("Expected calibration_ppm 2, got %v", radio["calibration_ppm"])
+	}
+	if _, ok := radio["drift"]; ok {
+		t.Errorf("Expected no drift estimate while disabled")
+	}
+
+	// With the estimator on, the drift is reported in the radio status
+	cfg = createTestConfig(tempDir)
+	cfg.Radio.DriftEstimate = true
+	engine = NewCoreEngine(cfg, filepath.Join(tempDir, "drift.sock"), "")
+	defer engine.Stop()
+	now := time.Now()
+	for i := 0; i < 6; i++ {
+		engine.driftEstimator.Record("DL1ABC", 1500+float64(i), now.Add(time.Duration(i)*time.Minute))
+	}
+	drift, ok := engine.handleRadio().Data["drift"].(dsp.DriftEstimate)
+	if !ok || drift.Samples != 5 || !drift.Ready {
+		t.Errorf("Expected a ready drift estimate from 5 samples, got %+v", drift)
+	}
+}
+
+func TestTransverter(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Profile = "hf"
+	cfg.Profiles = []config.Profile{
+		{Name: "hf", Frequency: 28200000},
+		{Name: "23cm", Frequency: 1296200000, Overrides: map[string]interface{}{
+			"radio": map[string]interface{}{"transverter_offset": 1268000000},
+		}},
+	}
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	resp := engine.handleCommand(&protocol.Command{Type: protocol.CmdProfile, Args: map[string]interface{}{"name": "23cm"}})
+	if !resp.Success || resp.Data["frequency"] != 1296200000 {
+		t.Fatalf("Expected the 23cm profile on 1296.2 MHz, got %+v (%s)", resp.Data, resp.Error)
+	}
+	if got := engine.calibrate(28200000); got != 1296200000 {
+		t.Errorf("Expected the rig's 28.2 MHz IF to be 1296.2 MHz, got %d", got)
+	}
+	if got := engine.uncalibrate(1296200000); got != 28200000 {
+		t.Errorf("Expected to tune the rig to 28.2 MHz for 1296.2 MHz, got %d", got)
+	}
+	if engine.hardwareManager.IsRadioConnected() {
+		if freq, err := engine.hardwareManager.GetRadioFrequency(); err != nil || freq != 28200000 {
+			t.Errorf("Expected the rig commanded at the IF, got %d (%v)", freq, err)
+		}
+	}
+	radio := engine.handleRadio().Data
+	if radio["transverter_offset"] != int64(1268000000) || radio["if_frequency"] != int64(28200000) {
+		t.Errorf("Expected the transverter in the radio status, got %+v", radio)
+	}
+
+	// Decodes and sent messages are on the RF
+	msg, _ := engine.queueTX(protocol.Message{From: "K3DEP", Message: "CQ"})
+	if msg.Dial != 1296200000 || msg.Band != "23cm" {
+		t.Errorf("Expected a message sent on 23cm, got %+v", msg)
+	}
+
+	// Without the transverter the rig's IF is the frequency again
+	engine.mutex.Lock()
+	engine.baseConfig.Profiles[0].Frequency = 0
+	engine.mutex.Unlock()
+	engine.handleCommand(&protocol.Command{Type: protocol.CmdProfile, Args: map[string]interface{}{"name": "hf"}})
+	if frequency := engine.dialFrequency(); frequency != 28200000 {
+		t.Errorf("Expected 28.2 MHz without the transverter, got %d", frequency)
+	}
+}
+
+func TestTimeSync(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	if engine.clockStatus() != nil {
+		t.Errorf("Expected no clock status while time_sync is off")
+	}
+
+	cfg.TimeSync.Enabled = true
+	cfg.TimeSync.MaxOffset = 1
+	cfg.TimeSync.AdjustDecode = true
+
+	// Unchecked, the clock is not blamed
+	status := engine.clockStatus()
+	if status == nil || status.Synced || status.Checked != nil {
+		t.Fatalf("Expected an unchecked, unsynced clock, got %+v", status)
+	}
+	if _, off := engine.clockOff(); off {
+		t.Errorf("Expected an unchecked clock not to be off")
+	}
+
+	engine.recordClockCheck(&timesync.Result{Offset: 3 * time.Second, Source: "ntp", Server: "192.0.2.1:123", Checked: time.Now()}, nil)
+	status = engine.clockStatus()
+	if status.Synced || status.Offset != 3 || status.Server != "192.0.2.1:123" {
+		t.Errorf("Expected a clock 3s out, got %+v", status)
+	}
+
+	// Heartbeats stop while the clock is out
+	engine.sendHeartbeat()
+	if len(engine.txMessages) != 0 {
+		t.Errorf("Expected no heartbeat with the clock 3s off, got %d queued", len(engine.txMessages))
+	}
+
+	// Decodes are stamped with the corrected time
+	msg := engine.parseJS8Message(&dsp.DecodeResult{Message: "CQ DL1ABC JO62", Frequency: 1500})
+	if lag := time.Since(msg.Timestamp); lag < 2900*time.Millisecond || lag > 4*time.Second {
+		t.Errorf("Expected the decode stamped 3s earlier, got %v", lag)
+	}
+
+	// A failed check keeps the last offset and reports the error
+	engine.recordClockCheck(nil, errors.New("no answer"))
+	status = engine.clockStatus()
+	if status.Offset != 3 || status.Error != "no answer" {
+		t.Errorf("Expected the last offset with the error, got %+v", status)
+	}
+
+	engine.recordClockCheck(&timesync.Result{Offset: -200 * time.Millisecond, Source: "chrony", Checked: time.Now()}, nil)
+	if status = engine.clockStatus(); !status.Synced || status.Error != "" {
+		t.Errorf("Expected the clock back in sync, got %+v", status)
+	}
+	engine.sendHeartbeat()
+	if len(engine.txMessages) != 1 {
+		t.Errorf("Expected a heartbeat once the clock is back, got %d queued", len(engine.txMessages))
+	}
+}
+
+func TestGPS(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.GPS.Enabled = true
+	cfg.GPS.Address = "localhost:2947"
+	cfg.GPS.UpdateGrid = true
+	cfg.GPS.AnnounceGrid = true
+	cfg.TimeSync.Enabled = true
+	cfg.TimeSync.Source = "gps"
+	cfg.TimeSync.Interval = 10
+	cfg.TimeSync.MaxOffset = 1
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	if status := engine.gpsStatus(); status == nil || status.Connected {
+		t.Fatalf("Expected a disconnected GPS status, got %+v", status)
+	}
+
+	// A fix away from home moves the grid and announces it
+	now := time.Now()
+	engine.handleGPSFix(gpsd.Fix{Mode: gpsd.Mode3D, Lat: 51.5074, Lon: -0.1278, Time: now.Add(-200 * time.Millisecond), Received: now})
+	if grid := engine.config.Station.Grid; grid != "IO91wm" {
+		t.Errorf("Expected the grid to follow the GPS to IO91wm, got %s", grid)
+	}
+	if len(engine.txMessages) != 1 {
+		t.Errorf("Expected a heartbeat announcing the grid, got %d queued", len(engine.txMessages))
+	}
+	status := engine.gpsStatus()
+	if !status.Connected || status.Mode != gpsd.Mode3D || status.Grid != "IO91wm" || status.Time == nil {
+		t.Errorf("Unexpected GPS status %+v", status)
+	}
+
+	// GPS time checks the clock
+	clock := engine.clockStatus()
+	if clock == nil || !clock.Synced || clock.Source != "gps" || clock.Offset < 0.19 || clock.Offset > 0.21 {
+		t.Errorf("Expected the clock 0.2s ahead of GPS, got %+v", clock)
+	}
+
+	// Moving again soon changes the grid without another heartbeat, and the
+	// clock is not checked again before the interval
+	engine.handleGPSFix(gpsd.Fix{Mode: gpsd.Mode2D, Lat: 51.55, Lon: -0.05, Time: now.Add(-5 * time.Second), Received: now.Add(time.Second)})
+	if grid := engine.config.Station.Grid; grid != "IO91xn" {
+		t.Errorf("Expected the grid to move to IO91xn, got %s", grid)
+	}
+	if len(engine.txMessages) != 1 {
+		t.Errorf("Expected no second announcement so soon, got %d queued", len(engine.txMessages))
+	}
+	if clock := engine.clockStatus(); !clock.Synced {
+		t.Errorf("Expected the clock check to wait for the interval, got %+v", clock)
+	}
+
+	// Without a position the grid stays
+	engine.handleGPSFix(gpsd.Fix{Mode: gpsd.ModeNoFix, Received: now})
+	if grid := engine.config.Station.Grid; grid != "IO91xn" {
+		t.Errorf("Expected the grid to stay without a fix, got %s", grid)
+	}
+}
+
+func TestForms(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Forms.Templates = []config.FormTemplate{{
+		Code:   "sitrep",
+		Fields: []config.FormField{{Name: "shelter"}, {Name: "needs", Label: "Needs", Multiline: true}},
+	}}
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	resp := engine.handleForm(&protocol.Command{Type: protocol.CmdForm, Args: map[string]interface{}{"action": "templates"}})
+	templates, _ := resp.Data["templates"].([]forms.Template)
+	if len(templates) != 2 || templates[0].Code != "SITREP" || templates[0].Fields[0].Label != "shelter" || templates[1].Code != "ICS213" {
+		t.Fatalf("Expected SITREP and ICS213, got %+v", templates)
+	}
+
+	resp = engine.handleForm(&protocol.Command{Type: protocol.CmdForm, Args: map[string]interface{}{
+		"action":   "send",
+		"template": "ics213",
+		"to":       "w1aw",
+		"values":   map[string]interface{}{"subject": "Supplies", "message": "Need 200 cots"},
+	}})
+	if !resp.Success {
+		t.Fatalf("Expected the form to be queued, got %s", resp.Error)
+	}
+	frames := resp.Data["frames"].(int)
+	if frames < 2 || len(engine.txMessages) != frames {
+		t.Fatalf("Expected %d frames queued, got %d", frames, len(engine.txMessages))
+	}
+
+	// Hearing the frames puts the form back together
+	for i := 0; i < frames; i++ {
+		frame := <-engine.txMessages
+		if !dsp.IsDataFrame(frame.Message) || frame.To != "W1AW" {
+			t.Fatalf("Expected a data frame to W1AW, got %+v", frame)
+		}
+		heard := protocol.Message{Timestamp: time.Now(), From: "UNKNOWN", Message: frame.Message, SNR: -10, Frequency: 14079500}
+		if !engine.receiveFormFrame(heard) {
+			t.Fatalf("Expected frame %q to be taken as a form frame", frame.Message)
+		}
+	}
+	if engine.receiveFormFrame(protocol.Message{Message: "HELLO-WORLD-"}) {
+		t.Error("Expected plain text not to be a form frame")
+	}
+
+	received, err := engine.messageStore.GetForms("RX", 10)
+	if err != nil || len(received) != 1 {
+		t.Fatalf("Expected the form received, got %+v, %v", received, err)
+	}
+	if form := received[0]; form.Template != "ICS213" || form.From != cfg.Station.Callsign || form.To != "W1AW" || form.Frames != frames {
+		t.Errorf("Unexpected received form %+v", form)
+	}
+	history, _ := engine.messageStore.GetMessages(storage.MessageQuery{Direction: "RX", Limit: 10})
+	if len(history) == 0 || history[0].Message != "[ICS213] ICS-213 General Message: Supplies" {
+		t.Errorf("Expected the form summary in the history, got %+v", history)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"action": "send", "template": "RADIOGRAM"},
+		{"action": "send", "template": "ICS213", "values": map[string]interface{}{"colour": "red"}},
+		{"action": "list", "direction": "sideways"},
+	} {
+		if resp := engine.handleForm(&protocol.Command{Type: protocol.CmdForm, Args: args}); resp.Success {
+			t.Errorf("Expected %v to fail", args)
+		}
+	}
+}
+
+func TestTelemetry(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Telemetry.Enabled = true
+	cfg.Telemetry.Interval = 60
+	cfg.Telemetry.Fields = []string{"voltage", "temperature", "uptime"}
+	cfg.Sensors.TemperatureSensors = []string{"28-0002", "28-0001"}
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	start := time.Now()
+	engine.checkTelemetry(start)
+	if len(engine.txMessages) != 0 {
+		t.Fatalf("Expected no telemetry before the interval, got %d frames", len(engine.txMessages))
+	}
+
+	voltage, current := 12.71, 0.42
+	engine.recordSensors(protocol.SensorReading{
+		Time:         start.Add(65 * time.Minute),
+		Voltage:      &voltage,
+		Current:      &current,
+		Temperatures: map[string]float64{"28-0001": 30.5, "28-0002": 18.25},
+	}, nil)
+	engine.checkTelemetry(start.Add(67 * time.Minute))
+	frames := len(engine.txMessages)
+	if frames == 0 {
+		t.Fatal("Expected telemetry queued after the interval")
+	}
+	if resp := engine.handleTelemetry(nil); resp.Data["next"] == nil || resp.Data["last"] == nil {
+		t.Errorf("Expected the next and last telemetry times, got %v", resp.Data)
+	}
+
+	// Hearing the frames stores the reading, without the current left out
+	// of telemetry fields
+	for i := 0; i < frames; i++ {
+		frame := <-engine.txMessages
+		if !dsp.IsDataFrame(frame.Message) || frame.To != "" {
+			t.Fatalf("Expected a broadcast data frame, got %+v", frame)
+		}
+		heard := protocol.Message{Timestamp: time.Now(), From: "UNKNOWN", Message: frame.Message, SNR: -8, Frequency: 14079500}
+		if !engine.receiveFormFrame(heard) {
+			t.Fatalf("Expected frame %q to be taken as a data frame", frame.Message)
+		}
+	}
+	if received, _ := engine.messageStore.GetForms("RX", 10); len(received) != 0 {
+		t.Errorf("Expected telemetry not to be stored as a form, got %+v", received)
+	}
+
+	resp := engine.handleTelemetry([]string{"STATIONS"})
+	stations, _ := resp.Data["stations"].([]storage.Telemetry)
+	if len(stations) != 1 {
+		t.Fatalf("Expected telemetry from one station, got %+v", resp)
+	}
+	got := stations[0]
+	if got.Callsign != "K3DEP" || got.SNR != -8 || got.Frequency != 14079500 {
+		t.Errorf("Unexpected telemetry %+v", got)
+	}
+	if got.Voltage == nil || *got.Voltage != 12.71 || got.Current != nil {
+		t.Errorf("Expected 12.71 V and no current, got %+v", got)
+	}
+	if got.Temperature == nil || *got.Temperature != 18.3 {
+		t.Errorf("Expected the first listed sensor's 18.3 C, got %v", got.Temperature)
+	}
+	if got.Uptime == nil || *got.Uptime < 67*60 {
+		t.Errorf("Expected an uptime of at least 67 minutes, got %v", got.Uptime)
+	}
+
+	from, to := strconv.FormatInt(start.Add(-time.Hour).Unix(), 10), strconv.FormatInt(start.Add(time.Hour).Unix(), 10)
+	if resp := engine.handleGetTelemetry([]string{"k3dep", from, to}); !resp.Success || resp.Data["count"] != 1 {
+		t.Errorf("Expected one telemetry point in range, got %+v", resp)
+	}
+	if resp := engine.handleGetTelemetry([]string{"K3DEP", to, from}); resp.Success || resp.Code != protocol.ErrCodeInvalid {
+		t.Errorf("Expected a reversed range refused, got %+v", resp)
+	}
+	if resp := engine.handleTelemetry([]string{"SIDEWAYS"}); resp.Success {
+		t.Error("Expected an unknown TELEMETRY action to fail")
+	}
+}
+
+func TestFiles(t *testing.T) {
+	newStation := func(callsign string) *CoreEngine {
+		tempDir := t.TempDir()
+		cfg := createTestConfig(tempDir)
+		cfg.Station.Callsign = callsign
+		cfg.Files.Enabled = true
+		cfg.Files.MaxSize = config.DefaultFileMaxSize
+		cfg.Files.Retries = config.DefaultFileRetries
+		cfg.Files.PollTimeout = config.DefaultFilePollTimeout
+		return NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	}
+	sender, receiver := newStation("K3DEP"), newStation("W1AW")
+	defer sender.Stop()
+	defer receiver.Stop()
+
+	// relay sends what one station has queued to the other, dropping the
+	// data frames numbered in lost
+	relay := func(from, to *CoreEngine, lost map[uint16]bool) int {
+		relayed := 0
+		for len(from.txMessages) > 0 {
+			msg := <-from.txMessages
+			from.transferSent(msg, nil)
+			if f, _ := dsp.UnpackTransferFrame(msg.Message); !f.Control && lost[f.Seq] {
+				lost[f.Seq] = false
+				continue
+			}
+			if !to.receiveTransferFrame(protocol.Message{Timestamp: time.Now(), Message: msg.Message}) {
+				t.Fatalf("Expected %q to be taken as a transfer frame", msg.Message)
+			}
+			relayed++
+		}
+		return relayed
+	}
+
+	content := []byte("Shelter roster\nSmith, J - 4 cots\nJones, K - 2 cots\n")
+	resp := sender.handleFile(&protocol.Command{Type: protocol.CmdFile, Args: map[string]interface{}{
+		"action":  "send",
+		"to":      "w1aw",
+		"name":    "roster.txt",
+		"content": base64.StdEncoding.EncodeToString(content),
+	}})
+	if !resp.Success {
+		t.Fatalf("Expected the file to be queued, got %s", resp.Error)
+	}
+	frames := resp.Data["frames"].(int)
+
+	// The first round loses two frames, which the receiver asks for again
+	sender.tickFiles(time.Now())
+	if len(sender.txMessages) != frames+2 {
+		t.Fatalf("Expected an offer, %d frames and a poll queued, got %d", frames, len(sender.txMessages))
+	}
+	relay(sender, receiver, map[uint16]bool{1: true, 3: true})
+	if nacks := relay(receiver, sender, nil); nacks == 0 {
+		t.Fatal("Expected the poll answered with NACKs")
+	}
+	sender.tickFiles(time.Now())
+	if len(sender.txMessages) != 0 {
+		t.Fatalf("Expected the resend to wait for the other NACKs, got %d queued", len(sender.txMessages))
+	}
+	sender.tickFiles(time.Now().Add(fileNackSettle))
+	if resent := relay(sender, receiver, nil); resent != 3 {
+		t.Fatalf("Expected frames 1 and 3 and a poll resent, got %d frames", resent)
+	}
+	relay(receiver, sender, nil)
+
+	sent, _ := sender.messageStore.GetFileTransfers(10)
+	if len(sent) != 1 || sent[0].Status != protocol.FileComplete || sent[0].Done != frames || sent[0].Rounds != 1 {
+		t.Fatalf("Expected the file sent in one resend round, got %+v", sent)
+	}
+	received, _ := receiver.messageStore.GetFileTransfers(10)
+	if len(received) != 1 || received[0].Status != protocol.FileComplete || received[0].From != "K3DEP" || received[0].Name != "roster.txt" {
+		t.Fatalf("Expected roster.txt received from K3DEP, got %+v", received)
+	}
+	resp = receiver.handleFile(&protocol.Command{Type: protocol.CmdFile, Args: map[string]interface{}{
+		"action": "get",
+		"id":     strconv.FormatInt(received[0].ID, 10),
+	}})
+	if !resp.Success || resp.Data["content"] != base64.StdEncoding.EncodeToString(content) {
+		t.Errorf("Expected the received content back, got %+v", resp)
+	}
+
+	// A receiver that never answers fails the transfer once the polls run out
+	resp = sender.handleFile(&protocol.Command{Type: protocol.CmdFile, Args: map[string]interface{}{
+		"action": "send", "to": "N0CALL", "name": "x.bin", "content": "AQID",
+	}})
+	if !resp.Success {
+		t.Fatalf("Expected the file to be queued, got %s", resp.Error)
+	}
+	now := time.Now()
+	for i := 0; i <= config.DefaultFileRetries; i++ {
+		sender.tickFiles(now)
+		for len(sender.txMessages) > 0 {
+			sender.transferSent(<-sender.txMessages, nil)
+		}
+		now = now.Add(time.Duration(config.DefaultFilePollTimeout+1) * time.Second)
+		sender.tickFiles(now)
+	}
+	if sent, _ := sender.messageStore.GetFileTransfers(1); sent[0].Status != protocol.FileFailed {
+		t.Errorf("Expected the unanswered transfer failed, got %+v", sent[0])
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"action": "send", "to": "W1AW", "name": "big.bin", "content": base64.StdEncoding.EncodeToString(make([]byte, 2000))},
+		{"action": "send", "to": "W1AW", "name": "x.bin", "content": "not base64!"},
+		{"action": "send", "to": "W1AW", "name": "empty.bin"},
+		{"action": "cancel", "id": "99"},
+		{"action": "get", "id": "99"},
+	} {
+		if resp := sender.handleFile(&protocol.Command{Type: protocol.CmdFile, Args: args}); resp.Success {
+			t.Errorf("Expected %v to fail", args)
+		}
+	}
+}
+
+func TestSessions(t *testing.T) {
+	newStation := func(callsign string) *CoreEngine {
+		tempDir := t.TempDir()
+		cfg := createTestConfig(tempDir)
+		cfg.Station.Callsign = callsign
+		cfg.ARQ.Enabled = true
+		cfg.ARQ.Window = config.DefaultARQWindow
+		cfg.ARQ.Timeout = config.DefaultARQTimeout
+		cfg.ARQ.Retries = config.DefaultARQRetries
+		cfg.ARQ.Idle = config.DefaultARQIdle
+		return NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	}
+	caller, called := newStation("K3DEP"), newStation("W1AW")
+	defer caller.Stop()
+	defer called.Stop()
+
+	// relay sends what one station has queued to the other, dropping the
+	// data frames numbered in lost the first time they go out
+	relay := func(from, to *CoreEngine, lost map[uint8]bool) {
+		for len(from.txMessages) > 0 {
+			msg := <-from.txMessages
+			from.sessionSent(msg, nil)
+			if f, _ := dsp.UnpackSessionFrame(msg.Message); !f.Control && lost[f.Seq] {
+				lost[f.Seq] = false
+				continue
+			}
+			if !to.receiveSessionFrame(protocol.Message{Timestamp: time.Now(), Message: msg.Message}) {
+				t.Fatalf("Expected %q to be taken as a session frame", msg.Message)
+			}
+		}
+	}
+	session := func(e *CoreEngine, args map[string]interface{}) *protocol.Response {
+		t.Helper()
+		resp := e.handleSession(&protocol.Command{Type: protocol.CmdSession, Args: args})
+		if !resp.Success {
+			t.Fatalf("Expected SESSION %v to succeed, got %s", args, resp.Error)
+		}
+		return resp
+	}
+	// exchange runs the session loop of from until what it has written is
+	// acknowledged
+	exchange := func(from, to *CoreEngine, lost map[uint8]bool) {
+		for i := 0; i < 20; i++ {
+			from.tickSessions(time.Now())
+			relay(from, to, lost)
+			relay(to, from, nil)
+			from.tickSessions(time.Now())
+			if len(from.txMessages) == 0 {
+				return
+			}
+		}
+		t.Fatal("Expected the session's messages acknowledged")
+	}
+
+	resp := session(caller, map[string]interface{}{"action": "connect", "to": "w1aw"})
+	id := strconv.Itoa(resp.Data["session"].(protocol.Session).ID)
+	caller.tickSessions(time.Now())
+	relay(caller, called, nil)
+	relay(called, caller, nil)
+	if got := session(caller, map[string]interface{}{"action": "get", "id": id}).Data["session"].(protocol.Session); got.Status != protocol.SessionConnected {
+		t.Fatalf("Expected the session connected, got %+v", got)
+	}
+
+	// Two messages, with a frame of the first round and one of the second
+	// lost and sent again
+	session(caller, map[string]interface{}{"action": "send", "id": id, "message": "Shelter open, 40 cots"})
+	session(caller, map[string]interface{}{"action": "send", "id": id, "message": "Need water"})
+	exchange(caller, called, map[uint8]bool{2: true, 5: true})
+
+	list := session(called, map[string]interface{}{}).Data["sessions"].([]protocol.Session)
+	if len(list) != 1 || list[0].Peer != "K3DEP" || list[0].Initiator || list[0].MessagesRecvd != 2 {
+		t.Fatalf("Expected a session from K3DEP with two messages, got %+v", list)
+	}
+	calledID := strconv.Itoa(list[0].ID)
+	messages := session(called, map[string]interface{}{"action": "get", "id": calledID}).Data["messages"].([]protocol.SessionMessage)
+	if len(messages) != 2 || messages[0].Text != "Shelter open, 40 cots" || messages[1].Text != "Need water" || messages[0].Direction != "RX" {
+		t.Fatalf("Expected both messages received in order, got %+v", messages)
+	}
+	got := session(caller, map[string]interface{}{"action": "get", "id": id}).Data["session"].(protocol.Session)
+	if got.FramesResent != 2 || got.InFlight != 0 || got.Queued != 0 {
+		t.Errorf("Expected two frames resent and nothing left, got %+v", got)
+	}
+
+	// The called station answers over the same session
+	session(called, map[string]interface{}{"action": "send", "id": calledID, "message": "Copy, sending"})
+	exchange(called, caller, nil)
+	messages = session(caller, map[string]interface{}{"action": "get", "id": id}).Data["messages"].([]protocol.SessionMessage)
+	if n := len(messages); n != 3 || messages[n-1].Text != "Copy, sending" || messages[n-1].Direction != "RX" {
+		t.Fatalf("Expected the answer received, got %+v", messages)
+	}
+
+	session(caller, map[string]interface{}{"action": "disconnect", "id": id})
+	caller.tickSessions(time.Now())
+	relay(caller, called, nil)
+	relay(called, caller, nil)
+	for _, e := range []*CoreEngine{caller, called} {
+		list := session(e, map[string]interface{}{}).Data["sessions"].([]protocol.Session)
+		if len(list) != 1 || list[0].Status != protocol.SessionClosed {
+			t.Errorf("Expected the session closed at both ends, got %+v", list)
+		}
+	}
+
+	// A station that never answers fails the connect once the tries run out
+	resp = session(caller, map[string]interface{}{"action": "connect", "to": "N0CALL"})
+	id = strconv.Itoa(resp.Data["session"].(protocol.Session).ID)
+	now := time.Now()
+	for i := 0; i <= config.DefaultARQRetries; i++ {
+		caller.tickSessions(now)
+		for len(caller.txMessages) > 0 {
+			caller.sessionSent(<-caller.txMessages, nil)
+		}
+		now = now.Add(time.Duration(config.DefaultARQTimeout+1) * time.Second)
+		caller.tickSessions(now)
+	}
+	if got := session(caller, map[string]interface{}{"action": "get", "id": id}).Data["session"].(protocol.Session); got.Status != protocol.SessionFailed {
+		t.Errorf("Expected the unanswered connect failed, got %+v", got)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"action": "connect", "to": "not a call"},
+		{"action": "send", "id": id, "message": "too late"},
+		{"action": "send", "id": "99", "message": "hello"},
+		{"action": "disconnect", "id": id},
+		{"action": "get", "id": "x"},
+		{"action": "sideways", "id": id},
+	} {
+		if resp := caller.handleSession(&protocol.Command{Type: protocol.CmdSession, Args: args}); resp.Success {
+			t.Errorf("Expected %v to fail", args)
+		}
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.DSP.Decoder = dsp.DecoderGo
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	response := engine.handleCommand(&protocol.Command{Type: "SELFTEST -12 K3DEP"})
+	if !response.Success {
+		t.Fatalf("SELFTEST failed: %s", response.Error)
+	}
+	result := response.Data["selftest"].(*dsp.SelfTestResult)
+	if !result.Passed || result.Message != "K3DEP-------" || result.SNR == nil || *result.SNR != -12 {
+		t.Errorf("Expected K3DEP to decode at -12 dB, got %+v", result)
+	}
+
+	response = engine.handleCommand(&protocol.Command{Type: "SELFTEST -29"})
+	if !response.Success || response.Data["selftest"].(*dsp.SelfTestResult).Passed {
+		t.Errorf("Expected the self-test to fail at -29 dB, got %+v", response)
+	}
+
+	if response := engine.handleCommand(&protocol.Command{Type: "SELFTEST 45"}); response.Success || response.Code != protocol.ErrCodeInvalid {
+		t.Errorf("Expected an SNR out of range to be rejected, got %+v", response)
+	}
+}
+
+func TestTXPower(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Radio.Device = "mock"
+	cfg.Audio.RememberPowerTx = true
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	if err := engine.hardwareManager.Initialize(); err != nil {
+		t.Skipf("Hardware not available: %v", err)
+	}
+	engine.frequency = 14078000
+
+	level := func() float32 {
+		t.Helper()
+		power, err := engine.hardwareManager.GetRadioPowerLevel()
+		if err != nil {
+			t.Fatalf("Failed to read power: %v", err)
+		}
+		return power
+	}
+	start := level()
+
+	// A message's power is set for its transmission and put back after
+	restore := engine.setPower(powerTX, 10)
+	if got := level(); got != 0.1 {
+		t.Errorf("Expected 10%% power while sending, got %v", got)
+	}
+	restore()
+	if got := level(); got != start {
+		t.Errorf("Expected power restored to %v, got %v", start, got)
+	}
+
+	// The band remembers it for messages without one
+	restore = engine.setPower(powerTX, 0)
+	if got := level(); got != 0.1 {
+		t.Errorf("Expected the remembered 10%% on 20m, got %v", got)
+	}
+	restore()
+
+	// but not on another band, or when not remembering
+	engine.frequency = 7078000
+	engine.setPower(powerTX, 0)
+	if got := level(); got != start {
+		t.Errorf("Expected the rig's own power on 40m, got %v", got)
+	}
+	engine.frequency = 14078000
+	engine.config.Audio.RememberPowerTx = false
+	engine.setPower(powerTX, 0)
+	if got := level(); got != start {
+		t.Errorf("Expected the rig's own power when not remembering, got %v", got)
+	}
+
+	response := engine.handleSend(&protocol.Command{Type: protocol.CmdSend, Args: map[string]interface{}{"to": "N0CALL", "message": "HELLO", "power": "25"}})
+	if !response.Success || response.Data["message"].(protocol.Message).Power != 25 {
+		t.Errorf("Expected the message queued at 25%% power, got %+v", response)
+	}
+	for _, power := range []string{"150", "-5", "lots"} {
+		response := engine.handleSend(&protocol.Command{Type: protocol.CmdSend, Args: map[string]interface{}{"message": "HELLO", "power": power}})
+		if response.Success || response.Code != protocol.ErrCodeInvalid {
+			t.Errorf("Expected power %q rejected, got %+v", power, response)
+		}
+	}
+}
+
+func TestTuneBeforeTX(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Radio.Device = "mock"
+	cfg.Radio.TuneBeforeTX = "cat"
+	cfg.Radio.TuneSeconds = 0.05
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	if err := engine.hardwareManager.Initialize(); err != nil {
+		t.Skipf("Hardware not available: %v", err)
+	}
+	engine.frequency = 14078000
+
+	// The first transmission on a band tunes it, later ones do not
+	if err := engine.tuneIfNewBand(); err != nil {
+		t.Fatalf("Tune failed: %v", err)
+	}
+	first := engine.tuneResultsList()
+	if len(first) != 1 || first[0].Band != "20m" || first[0].Method != "cat" || first[0].Error != "" {
+		t.Fatalf("Expected a cat tune on 20m, got %+v", first)
+	}
+	engine.tuneIfNewBand()
+	if again := engine.tuneResultsList(); len(again) != 1 || !again[0].Tuned.Equal(first[0].Tuned) {
+		t.Errorf("Expected 20m not to be tuned again, got %+v", again)
+	}
+
+	// An abort stops a tune, and nothing is kept for the band
+	engine.frequency = 7078000
+	engine.abortTx <- true
+	if err := engine.tuneIfNewBand(); err == nil {
+		t.Error("Expected an aborted tune to stop the transmission")
+	}
+	if results := engine.tuneResultsList(); len(results) != 1 {
+		t.Errorf("Expected no result for an aborted tune, got %+v", results)
+	}
+
+	// TUNE tunes the current band again
+	engine.frequency = 14078000
+	response := engine.handleTune(nil)
+	if !response.Success || response.Data["band"] != "20m" {
+		t.Fatalf("Expected TUNE to tune 20m, got %+v", response)
+	}
+	if results := engine.tuneResultsList(); len(results) != 1 || !results[0].Tuned.After(first[0].Tuned) {
+		t.Errorf("Expected TUNE to replace the 20m result, got %+v", results)
+	}
+	if response := engine.handleTune([]string{"manual"}); response.Success {
+		t.Error("Expected an unknown tune method to fail")
+	}
+	if response := engine.handleTune([]string{"PWR=150"}); response.Success {
+		t.Error("Expected a power over 100% to fail")
+	}
+
+	// Nothing is tuned without tune_before_tx
+	engine.config.Radio.TuneBeforeTX = ""
+	engine.frequency = 3578000
+	engine.tuneIfNewBand()
+	if results := engine.tuneResultsList(); len(results) != 1 {
+		t.Errorf("Expected no tune with tune_before_tx off, got %+v", results)
+	}
+}
+
+func TestAntennaSwitch(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Antenna.Switch = "gpio"
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	gpio := hardware.NewMockGPIO()
+	engine.antenna = hardware.NewGPIOAntennaSwitch(gpio, []int{5, 6})
+	engine.antennaPorts = []config.AntennaPort{
+		{Name: "beam", Bands: []string{"20m", "17m"}, Pin: 5},
+		{Name: "dipole", Bands: []string{"40m"}, Pin: 6},
+	}
+	selected := func() (beam, dipole bool) {
+		beam, _ = gpio.GetPin(5)
+		dipole, _ = gpio.GetPin(6)
+		return beam, dipole
+	}
+
+	// The port follows the band, and bands without one leave it alone
+	engine.switchAntenna(14078000)
+	if beam, dipole := selected(); !beam || dipole || engine.selectedAntenna() != "beam" {
+		t.Fatalf("Expected beam on 20m, got beam=%t dipole=%t", beam, dipole)
+	}
+	engine.switchAntenna(7078000)
+	if beam, dipole := selected(); beam || !dipole {
+		t.Errorf("Expected dipole on 40m, got beam=%t dipole=%t", beam, dipole)
+	}
+	engine.switchAntenna(3578000)
+	if engine.selectedAntenna() != "dipole" {
+		t.Errorf("Expected a band without a port to keep dipole, got %q", engine.selectedAntenna())
+	}
+
+	// A port picked by hand holds until AUTO
+	response := engine.handleAntenna([]string{"BEAM"})
+	if !response.Success || response.Data["port"] != "beam" || response.Data["manual"] != true {
+		t.Fatalf("Expected ANTENNA BEAM to hold beam, got %+v", response)
+	}
+	engine.switchAntenna(7078000)
+	if engine.selectedAntenna() != "beam" {
+		t.Errorf("Expected beam held on 40m, got %q", engine.selectedAntenna())
+	}
+	engine.frequency = 7078000
+	response = engine.handleAntenna([]string{"AUTO"})
+	if !response.Success || response.Data["port"] != "dipole" || response.Data["manual"] != false {
+		t.Errorf("Expected ANTENNA AUTO to select dipole on 40m, got %+v", response)
+	}
+	if response := engine.handleAntenna([]string{"VERTICAL"}); response.Success || response.Code != protocol.ErrCodeInvalid {
+		t.Errorf("Expected an unknown port to fail, got %+v", response)
+	}
+
+	// Switching by hand waits for the transmission to end
+	engine.transmitting = true
+	if response := engine.handleAntenna([]string{"BEAM"}); response.Success {
+		t.Error("Expected switching while transmitting to fail")
+	}
+	// and so does following the band
+	engine.switchAntenna(14078000)
+	if engine.selectedAntenna() != "dipole" {
+		t.Errorf("Expected dipole kept while transmitting, got %q", engine.selectedAntenna())
+	}
+	engine.transmitting = false
+	engine.switchAntenna(14078000)
+	if engine.selectedAntenna() != "beam" {
+		t.Errorf("Expected beam on 20m once off the air, got %q", engine.selectedAntenna())
+	}
+
+	// The default port takes bands no port lists
+	engine.antennaDefault = "beam"
+	engine.switchAntenna(3578000)
+	if engine.selectedAntenna() != "beam" {
+		t.Errorf("Expected the default port on 80m, got %q", engine.selectedAntenna())
+	}
+}
+
+func TestSensors(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	if engine.sensorStatus() != nil {
+		t.Errorf("Expected no sensor status while sensors are off")
+	}
+
+	cfg.Sensors.Enabled = true
+	cfg.Sensors.MinVoltage = 11.8
+	sample := func(at time.Time, voltage float64) {
+		engine.recordSensors(protocol.SensorReading{Time: at, Voltage: &voltage}, nil)
+	}
+
+	start := time.Now().Add(-25 * time.Hour)
+	sample(start, 12.6)
+	sample(start.Add(24*time.Hour), 12.5)
+	if _, low := engine.batteryLow(); low {
+		t.Fatalf("Expected TX allowed at 12.5 V")
+	}
+
+	// Below min_voltage TX stops
+	sample(start.Add(24*time.Hour+time.Minute), 11.5)
+	if voltage, low := engine.batteryLow(); !low || voltage != 11.5 {
+		t.Fatalf("Expected TX stopped at 11.5 V, got %v %v", voltage, low)
+	}
+	response := engine.handleSend(&protocol.Command{Type: protocol.CmdSend, Args: map[string]interface{}{"to": "N0CALL", "message": "HELLO"}})
+	if response.Success || response.Code != protocol.ErrCodeRadio {
+		t.Errorf("Expected SEND refused on a low battery, got %+v", response)
+	}
+	engine.sendHeartbeat()
+	if len(engine.txMessages) != 0 {
+		t.Errorf("Expected no heartbeat on a low battery, got %d queued", len(engine.txMessages))
+	}
+
+	// A failed sample keeps TX stopped
+	engine.recordSensors(protocol.SensorReading{Time: time.Now()}, errors.New("i2c timeout"))
+	if status := engine.sensorStatus(); !status.LowBattery || status.Error != "i2c timeout" || *status.Latest.Voltage != 11.5 {
+		t.Errorf("Expected the low reading kept with the error, got %+v", status)
+	}
+
+	// TX resumes only once the battery is clear of the threshold
+	sample(start.Add(24*time.Hour+2*time.Minute), 11.9)
+	if _, low := engine.batteryLow(); !low {
+		t.Errorf("Expected TX still stopped at 11.9 V")
+	}
+	sample(start.Add(24*time.Hour+3*time.Minute), 12.1)
+	if _, low := engine.batteryLow(); low {
+		t.Errorf("Expected TX resumed at 12.1 V")
+	}
+
+	// The history covers the last day
+	response = engine.handleSensors()
+	history := response.Data["history"].([]protocol.SensorReading)
+	if len(history) != 4 || *history[0].Voltage != 12.5 {
+		t.Errorf("Expected the 25 hour old reading dropped, got %d readings", len(history))
+	}
+}
+
+func TestLowPower(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	now := time.Now()
+	cfg.Power.LowPowerHours = []string{now.Add(-time.Hour).Format("15:04") + "-" + now.Add(time.Hour).Format("15:04")}
+	cfg.Power.RXOnly = true
+	cfg.Power.DecodeLevel = 3
+	cfg.Power.DisableWaterfall = true
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	if engine.LowPower() || engine.txInhibitedError() != nil {
+		t.Fatal("Expected normal mode before the schedule is checked")
+	}
+
+	// In the window the node listens only
+	engine.updatePower(now)
+	if !engine.LowPower() || engine.WaterfallEnabled() {
+		t.Fatalf("Expected low power mode with the waterfall off")
+	}
+	response := engine.handleSend(&protocol.Command{Type: protocol.CmdSend, Args: map[string]interface{}{"to": "N0CALL", "message": "HELLO"}})
+	if response.Success || response.Code != protocol.ErrCodeRadio {
+		t.Errorf("Expected SEND refused in RX-only low power mode, got %+v", response)
+	}
+
+	// NORMAL holds normal mode through the schedule, AUTO follows it again
+	response = engine.handlePower([]string{"NORMAL"})
+	if !response.Success || response.Data["mode"] != "normal" || response.Data["override"] != "normal" {
+		t.Errorf("Expected normal mode held, got %+v", response.Data)
+	}
+	engine.updatePower(now)
+	if engine.LowPower() || !engine.WaterfallEnabled() {
+		t.Errorf("Expected the override to outlast a schedule check")
+	}
+	response = engine.handlePower([]string{"AUTO"})
+	if response.Data["mode"] != "low" || response.Data["override"] != "auto" {
+		t.Errorf("Expected low power mode from the schedule, got %+v", response.Data)
+	}
+
+	if response := engine.handlePower([]string{"SLEEP"}); response.Success || response.Code != protocol.ErrCodeInvalid {
+		t.Errorf("Expected an unknown power mode refused, got %+v", response)
+	}
+}
+
+func TestCheckDatabase(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Storage.BackupPath = filepath.Join(tempDir, "backup", "test.db")
+	cfg.Storage.BackupKeep = 2
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	if engine.databaseStatus() != nil {
+		t.Errorf("Expected no integrity check before the first")
+	}
+
+	engine.checkDatabase(time.Now())
+	check := engine.databaseStatus()
+	if check == nil || !check.OK || check.Error != "" || check.BackedUp == nil {
+		t.Fatalf("Expected a passed check and a backup, got %+v", check)
+	}
+	if _, err := os.Stat(cfg.Storage.BackupPath); err != nil {
+		t.Errorf("Expected a backup at %s: %v", cfg.Storage.BackupPath, err)
+	}
+
+	response := engine.handleGetMessageStats()
+	if !response.Success || response.Data["integrity"] != check {
+		t.Errorf("Expected the check in the message stats, got %+v", response.Data)
+	}
+}
+
+func TestPTTSequence(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Radio.Device = "mock"
+	cfg.Radio.TxDelay = 0.05
+	cfg.Radio.TxTail = 0.05
+	cfg.Hardware.AmpKeying = true
+	cfg.Hardware.AmpGPIOPin = 23
+	cfg.Hardware.AmpLead = 0.03
+	cfg.Hardware.AmpTail = 0.03
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	if err := engine.hardwareManager.Initialize(); err != nil {
+		t.Skipf("Hardware not available: %v", err)
+	}
+
+	// The amplifier and rig are keyed through amp_lead and tx_delay
+	start := time.Now()
+	unkey, err := engine.keyTransmitter()
+	if err != nil {
+		t.Fatalf("Keying failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Expected audio held back 80ms, got %v", elapsed)
+	}
+	if ptt, _ := engine.hardwareManager.GetRadioPTT(); !ptt || !engine.hardwareManager.GetAmpKey() {
+		t.Errorf("Expected rig and amplifier keyed, got PTT %v amp %v", ptt, engine.hardwareManager.GetAmpKey())
+	}
+
+	// And released through tx_tail and amp_tail
+	start = time.Now()
+	unkey(false)
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Expected unkeying to take 80ms, got %v", elapsed)
+	}
+	if ptt, _ := engine.hardwareManager.GetRadioPTT(); ptt || engine.hardwareManager.GetAmpKey() || engine.ptt {
+		t.Errorf("Expected rig and amplifier released, got PTT %v amp %v", ptt, engine.hardwareManager.GetAmpKey())
+	}
+
+	// An abort while keying releases everything
+	engine.abortTx <- true
+	if _, err := engine.keyTransmitter(); err == nil {
+		t.Error("Expected an abort while keying to fail")
+	}
+	if ptt, _ := engine.hardwareManager.GetRadioPTT(); ptt || engine.hardwareManager.GetAmpKey() {
+		t.Errorf("Expected nothing keyed after an abort, got PTT %v amp %v", ptt, engine.hardwareManager.GetAmpKey())
+	}
+}
+
+func TestVOXKeying(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Radio.Device = "mock"
+	cfg.Radio.PTTMethod = "vox"
+	cfg.Radio.TxDelay = 0.5
+	cfg.Radio.TxTail = 0.05
+	cfg.Radio.VOXLeader = 0.1
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	if err := engine.hardwareManager.Initialize(); err != nil {
+		t.Skipf("Hardware not available: %v", err)
+	}
+
+	// Nothing is keyed and tx_delay is skipped, but PTT is tracked
+	start := time.Now()
+	unkey, err := engine.keyTransmitter()
+	if err != nil {
+		t.Fatalf("Keying failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= 500*time.Millisecond {
+		t.Errorf("Expected no tx_delay on VOX, took %v", elapsed)
+	}
+	if ptt, _ := engine.hardwareManager.GetRadioPTT(); ptt || !engine.ptt {
+		t.Errorf("Expected PTT tracked but the rig not keyed, got rig %v tracked %v", ptt, engine.ptt)
+	}
+
+	// The leader tone goes before the audio
+	audio, leader := engine.voxLeader(make([]int16, 100))
+	rate := engine.dspEngine.GetSampleRate()
+	if leader != 100*time.Millisecond || len(audio) != 100+rate/10 {
+		t.Errorf("Expected 100ms of leader, got %v and %d samples", leader, len(audio))
+	}
+
+	// PTT is tracked through the VOX hang time
+	start = time.Now()
+	unkey(false)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected tx_tail held, took %v", elapsed)
+	}
+	if engine.ptt {
+		t.Error("Expected PTT cleared")
+	}
+
+	// Other PTT methods get no leader
+	engine.config.Radio.PTTMethod = "cat"
+	if audio, leader := engine.voxLeader(make([]int16, 100)); leader != 0 || len(audio) != 100 {
+		t.Errorf("Expected no leader off VOX, got %v and %d samples", leader, len(audio))
+	}
+}
+
+// fakeDuplexAudio records the sound card calls of a half-duplex switch and
+// fails each call its count in fail says
+type fakeDuplexAudio struct {
+	calls []string
+	fail  map[string]int
+}
+
+func (f *fakeDuplexAudio) call(name string) error {
+	f.calls = append(f.calls, name)
+	if f.fail[name] > 0 {
+		f.fail[name]--
+		return fmt.Errorf("device busy")
+	}
+	return nil
+}
+
+func (f *fakeDuplexAudio) StartAudioInput() error  { return f.call("start input") }
+func (f *fakeDuplexAudio) StopAudioInput() error   { return f.call("stop input") }
+func (f *fakeDuplexAudio) StartAudioOutput() error { return f.call("start output") }
+func (f *fakeDuplexAudio) StopAudioOutput() error  { return f.call("stop output") }
+
+func TestHalfDuplex(t *testing.T) {
+	tests := []struct {
+		name  string
+		fail  map[string]int
+		steps []func(d *halfDuplex) error
+		state duplexState
+		calls string
+		err   bool
+	}{
+		{
+			name:  "Transmission",
+			steps: []func(d *halfDuplex) error{(*halfDuplex).toTX, (*halfDuplex).toRX},
+			state: duplexRX,
+			calls: "stop input, start output, stop output, start input",
+		},
+		{
+			name:  "Playing",
+			steps: []func(d *halfDuplex) error{(*halfDuplex).toTX},
+			state: duplexTX,
+			calls: "stop input, start output",
+		},
+		{
+			name:  "Already Playing",
+			steps: []func(d *halfDuplex) error{(*halfDuplex).toTX, (*halfDuplex).toTX},
+			state: duplexTX,
+			calls: "stop input, start output",
+			err:   true,
+		},
+		{
+			name:  "Already Capturing",
+			steps: []func(d *halfDuplex) error{(*halfDuplex).toRX},
+			state: duplexRX,
+		},
+		{
+			name:  "Output Busy Once",
+			fail:  map[string]int{"start output": 1},
+			steps: []func(d *halfDuplex) error{(*halfDuplex).toTX},
+			state: duplexTX,
+			calls: "stop input, start output, start output",
+		},
+		{
+			name:  "Output Never Starts",
+			fail:  map[string]int{"start output": 3},
+			steps: []func(d *halfDuplex) error{(*halfDuplex).toTX},
+			state: duplexRX,
+			calls: "stop input, start output, start output, start output, start input",
+			err:   true,
+		},
+		{
+			name:  "Input Never Restarts",
+			fail:  map[string]int{"start input": 3},
+			steps: []func(d *halfDuplex) error{(*halfDuplex).toTX, (*halfDuplex).toRX},
+			state: duplexRecovering,
+			calls: "stop input, start output, stop output, start input, start input, start input",
+			err:   true,
+		},
+		{
+			// Capture is already stopped, so the next transmission just plays
+			name: "Recovered By Next Transmission",
+			fail: map[string]int{"start input": 3},
+			steps: []func(d *halfDuplex) error{(*halfDuplex).toTX, (*halfDuplex).toRX,
+				(*halfDuplex).toTX, (*halfDuplex).toRX},
+			state: duplexRX,
+			calls: "stop input, start output, stop output, start input, start input, start input, " +
+				"start output, stop output, start input",
+			err: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audio := &fakeDuplexAudio{fail: tt.fail}
+			d := newHalfDuplex(audio)
+			d.retryDelay = time.Millisecond
+
+			failed := false
+			for _, step := range tt.steps {
+				failed = step(d) != nil || failed
+			}
+			if failed != tt.err {
+				t.Errorf("Expected error %v, got %v", tt.err, failed)
+			}
+			if d.State() != tt.state {
+				t.Errorf("Expected state %s, got %s", tt.state, d.State())
+			}
+			if calls := strings.Join(audio.calls, ", "); calls != tt.calls {
+				t.Errorf("Expected calls %q, got %q", tt.calls, calls)
+			}
+		})
+	}
+}
+
+func TestHalfDuplexTransmit(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Audio.HalfDuplex = true
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	if err := engine.hardwareManager.Initialize(); err != nil {
+		t.Skipf("Hardware not available: %v", err)
+	}
+	engine.startAudio()
+	defer engine.stopAudio()
+
+	audio := engine.hardwareManager.GetAudio()
+	if audio == nil {
+		t.Skip("Audio not available")
+	}
+	// startAudio only logs a capture device that won't open
+	if !audio.IsRecording() {
+		t.Skip("Audio capture not available")
+	}
+	if audio.IsPlaying() {
+		t.Fatal("Expected capture only, got playback running too")
+	}
+
+	toRX, err := engine.audioToTX()
+	if err != nil {
+		t.Fatalf("Switching to TX failed: %v", err)
+	}
+	if audio.IsRecording() || !audio.IsPlaying() {
+		t.Errorf("Expected playback only, got recording %v playing %v", audio.IsRecording(), audio.IsPlaying())
+	}
+
+	toRX()
+	if !audio.IsRecording() || audio.IsPlaying() {
+		t.Errorf("Expected capture again, got recording %v playing %v", audio.IsRecording(), audio.IsPlaying())
+	}
+}
+
+func TestPipelineHealth(t *testing.T) {
+	var pipeline pipelineHealth
+	start := time.Now()
+
+	steps := []struct {
+		name string
+		step func() bool
+		warn bool
+	}{
+		{"On Time", func() bool { return pipeline.record(100*time.Millisecond, start) }, false},
+		{"Behind", func() bool { return pipeline.record(3*time.Second, start) }, true},
+		{"Still Behind", func() bool { return pipeline.record(4*time.Second, start.Add(time.Second)) }, false},
+		{"Dropped", func() bool { return pipeline.drop(start.Add(2 * time.Second)) }, false},
+		{"Dropped Later", func() bool { return pipeline.drop(start.Add(2 * time.Minute)) }, true},
+		{"Caught Up", func() bool { return pipeline.record(50*time.Millisecond, start.Add(5*time.Minute)) }, false},
+	}
+	for _, step := range steps {
+		if got := step.step(); got != step.warn {
+			t.Errorf("%s: expected warn %v, got %v", step.name, step.warn, got)
+		}
+	}
+	if pipeline.lag != 50*time.Millisecond || pipeline.maxLag != 4*time.Second || pipeline.dropped != 2 {
+		t.Errorf("Expected lag 50ms, max 4s and 2 dropped, got %v, %v and %d", pipeline.lag, pipeline.maxLag, pipeline.dropped)
+	}
+}
+
+func TestAudioHealthStatistics(t *testing.T) {
+	tempDir := t.TempDir()
+	engine := NewCoreEngine(createTestConfig(tempDir), filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	if err := engine.hardwareManager.Initialize(); err != nil {
+		t.Skipf("Hardware not available: %v", err)
+	}
+
+	engine.rxAudio <- rxBlock{captured: time.Now()}
+	engine.pipeline.record(3*time.Second, time.Now())
+
+	stats := engine.GetAudioHealthStatistics()
+	decode := stats["decode"].(map[string]interface{})
+	if decode["queued"] != 1 || decode["lag_ms"] != 3000.0 || decode["behind"] != true {
+		t.Errorf("Expected one block queued 3s behind, got %v", decode)
+	}
+	if engine.hardwareManager.GetAudio() != nil {
+		if _, ok := stats["input"]; !ok {
+			t.Errorf("Expected the sound card's input health, got %v", stats)
+		}
+	}
+}
+
+// allSubmodesDSP is the pure Go DSP taken to decode every submode, as a
+// decoder that does would
+type allSubmodesDSP struct{ *dsp.DSP }
+
+func (allSubmodesDSP) DecodesSubmode(dsp.JS8Mode) bool { return true }
+
+func TestAdaptiveSubmode(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Submode.Default = "normal"
+	cfg.Submode.Adaptive = true
+	cfg.Submode.Window = 30
+	cfg.Submode.MinDecodes = 2
+	cfg.Submode.Turbo, cfg.Submode.Fast, cfg.Submode.Normal = -6, -12, -20
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	stock := engine.dspEngine
+	engine.dspEngine = allSubmodesDSP{dsp.NewDSP()}
+
+	now := time.Now()
+	record := func(callsign string, snrs ...float32) {
+		for i, snr := range snrs {
+			heard := now.Add(-time.Duration(len(snrs)-i) * time.Minute)
+			if err := engine.messageStore.RecordSNR(callsign, heard, snr, 14079500); err != nil {
+				t.Fatalf("Failed to record SNR: %v", err)
+			}
+		}
+	}
+	record("N0ABC", 2, -4)
+	record("K1XYZ", -14, -16)
+	record("N0FST", -8, -12)
+	record("W1AW", -24, -22)
+	record("N0ONE", 5)
+	engine.messageStore.RecordSNR("N0OLD", now.Add(-2*time.Hour), 10, 14079500)
+	engine.messageStore.RecordSNR("N0OLD", now.Add(-3*time.Hour), 10, 14079500)
+
+	tests := []struct {
+		to      string
+		submode string
+	}{
+		{"N0ABC", "turbo"},
+		{"N0FST", "fast"},
+		{"K1XYZ", "normal"},
+		{"W1AW", "slow"},
+		{"N0ONE", "normal"}, // Fewer than min_decodes
+		{"N0OLD", "normal"}, // Decodes outside the window
+		{"@ALLCALL", "normal"},
+		{"", "normal"},
+	}
+	for _, tt := range tests {
+		msg, ok := engine.queueTX(protocol.Message{Timestamp: now, From: "K3DEP", To: tt.to, Message: "HELLO", Mode: "JS8"})
+		if !ok {
+			t.Fatalf("Failed to queue a message to %q", tt.to)
+		}
+		if msg.Submode != tt.submode {
+			t.Errorf("Expected %s to %q, got %s", tt.submode, tt.to, msg.Submode)
+		}
+		if queued := <-engine.txMessages; queued.Submode != tt.submode {
+			t.Errorf("Expected %s on the TX queue to %q, got %s", tt.submode, tt.to, queued.Submode)
+		}
+	}
+
+	// A message queued with a submode keeps it
+	if msg, _ := engine.queueTX(protocol.Message{From: "K3DEP", To: "W1AW", Message: "HELLO", Submode: "fast"}); msg.Submode != "fast" {
+		t.Errorf("Expected a chosen submode kept, got %s", msg.Submode)
+	}
+	<-engine.txMessages
+
+	// Adaptive submodes are refused with a decoder that only decodes Normal
+	if err := engine.checkDecoding(engine.config); err != nil {
+		t.Errorf("Expected adaptive submodes taken with a decoder for every submode, got %v", err)
+	}
+	engine.dspEngine = stock
+	if err := engine.checkDecoding(engine.config); err == nil || !strings.Contains(err.Error(), "submode adaptive") {
+		t.Errorf("Expected adaptive submodes refused, got %v", err)
+	}
+
+	engine.config.Submode.Adaptive = false
+	if submode, _ := engine.chooseSubmode("N0ABC", now); submode != "normal" {
+		t.Errorf("Expected the default submode with adaptive off, got %s", submode)
+	}
+}
+
+func TestResendEscalation(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := createTestConfig(tempDir)
+	cfg.Messages.AckTimeout = 60
+	cfg.Messages.AckRetries = 4
+	cfg.Messages.Escalate = "both"
+	cfg.Messages.EscalateAfter = 2
+	cfg.Messages.EscalateOffset = 100
+	cfg.TX.Offset = 1500
+	engine := NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+	stock := engine.dspEngine
+	engine.dspEngine = allSubmodesDSP{dsp.NewDSP()}
+	_, movable := engine.dspEngine.(dsp.OffsetEncoder)
+
+	events, unsubscribe := engine.SubscribeEvents()
+	defer unsubscribe()
+
+	msg, _ := engine.queueTX(protocol.Message{From: "K3DEP", To: "N0ABC", Message: "HELLO", Submode: "fast", Delivery: protocol.DeliveryAwaiting})
+	<-engine.txMessages
+	engine.awaitAck(msg)
+
+	// The first resend goes out as sent, later ones a submode slower and
+	// further from the TX offset each time
+	tests := []struct {
+		submode string
+		shift   int
+	}{
+		{"fast", 0},
+		{"normal", 100},
+		{"slow", -100},
+		{"slow", 200},
+	}
+	for i, tt := range tests {
+		engine.checkAckTimeouts(time.Now().Add(61 * time.Second))
+		var resent protocol.Message
+		select {
+		case resent = <-engine.txMessages:
+		default:
+			t.Fatalf("Expected resend %d", i+1)
+		}
+		if !movable {
+			tt.shift = 0
+		}
+		if resent.Resends != i+1 || resent.Submode != tt.submode || resent.Offset != 1500+tt.shift {
+			t.Errorf("Resend %d: expected %s at %d Hz, got %d in %s at %d Hz", i+1, tt.submode, 1500+tt.shift, resent.Resends, resent.Submode, resent.Offset)
+		}
+		if shift := engine.resendShift(msg.ID); shift != tt.shift {
+			t.Errorf("Resend %d: expected to go out %d Hz from the TX offset, got %d", i+1, tt.shift, shift)
+		}
+		engine.awaitAck(resent)
+	}
+
+	// Delivery status shows the latest resend
+	messages, err := engine.messageStore.GetMessages(storage.MessageQuery{Direction: "TX"})
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("Expected the message stored, got %v (%v)", messages, err)
+	}
+	if messages[0].Resends != 4 || messages[0].Submode != "slow" {
+		t.Errorf("Expected 4 resends stored, the last in slow, got %+v", messages[0])
+	}
+	found := false
+	for len(events) > 0 {
+		event := <-events
+		if event.Type == protocol.EventMessageStatus && event.Data["resends"] == 4 {
+			found = event.Data["submode"] == "slow"
+		}
+	}
+	if !found {
+		t.Error("Expected a message_status event with the last resend")
+	}
+
+	engine.checkAck(protocol.Message{From: "N0ABC", To: "K3DEP", Message: "K3DEP ACK"})
+	if shift := engine.resendShift(msg.ID); shift != 0 {
+		t.Errorf("Expected no shift once delivered, got %d", shift)
+	}
+
+	// Escalating by submode is refused with a decoder that only decodes
+	// Normal, and by offset isn't
+	engine.dspEngine = stock
+	for escalate, refused := range map[string]bool{config.EscalateSubmode: true, config.EscalateBoth: true, config.EscalateOffset: false} {
+		engine.config.Messages.Escalate = escalate
+		if err := engine.checkDecoding(engine.config); (err != nil) != refused || (refused && !strings.Contains(err.Error(), "messages escalate "+escalate)) {
+			t.Errorf("escalate %s: expected refused %v, got %v", escalate, refused, err)
+		}
+	}
+	if _, movable := stock.(dsp.OffsetEncoder); !movable {
+		t.Error("Expected the stock encoder to move its offset")
+	}
+}
+
+func TestDataText(t *testing.T) {
+	newStation := func(callsign string, dataFrames bool) *CoreEngine {
+		tempDir := t.TempDir()
+		cfg := createTestConfig(tempDir)
+		cfg.Station.Callsign = callsign
+		cfg.TX.DataFrames = dataFrames
+		cfg.Messages.AckTimeout = 60
+		return NewCoreEngine(cfg, filepath.Join(tempDir, "test.sock"), "")
+	}
+	sender, receiver := newStation("K3DEP", true), newStation("W1AW", false)
+	defer sender.Stop()
+	defer receiver.Stop()
+
+	text := "Net tonight at 8PM on 7.078 - please check in"
+	resp := sender.handleSend(&protocol.Command{Type: protocol.CmdSend, Args: map[string]interface{}{"to": "W1AW", "message": text}})
+	if !resp.Success {
+		t.Fatalf("Expected the message queued, got %s", resp.Error)
+	}
+	// The message is queued once, with its text, and tracked for delivery
+	if len(sender.txMessages) != 1 {
+		t.Fatalf("Expected the message queued once, got %d queued", len(sender.txMessages))
+	}
+	queued := <-sender.txMessages
+	if !queued.DataFrames || queued.Message != text || queued.Delivery != protocol.DeliveryAwaiting {
+		t.Errorf("Expected the text queued as data frames awaiting an ACK, got %+v", queued)
+	}
+	if stored, err := sender.messageStore.GetQueuedMessages(); err != nil || len(stored) != 1 || !stored[0].DataFrames || stored[0].Message != text {
+		t.Errorf("Expected the text stored as data frames, got %+v (%v)", stored, err)
+	}
+
+	// It goes out in fewer frames than plain text would take
+	frames := txFrames(queued)
+	plain := (len("W1AW K3DEP "+text) + 11) / 12
+	if len(frames) < 2 || len(frames) >= plain || resp.Data["frames"] != len(frames) {
+		t.Fatalf("Expected fewer than %d data frames, got %d (%v in the response)", plain, len(frames), resp.Data["frames"])
+	}
+
+	// Hearing the frames puts the message back together
+	var whole protocol.Message
+	for i, frame := range frames {
+		want := dsp.TransmissionType(0)
+		if i == 0 {
+			want |= dsp.JS8CallFirst
+		}
+		if i == len(frames)-1 {
+			want |= dsp.JS8CallLast
+		}
+		if frame.transmission != want {
+			t.Errorf("Frame %d: expected transmission type %d, got %d", i, want, frame.transmission)
+		}
+		heard := protocol.Message{Timestamp: time.Now(), From: "UNKNOWN", Message: frame.text, SNR: -10, Offset: 1500, Transmission: uint8(frame.transmission)}
+		msg, ok := receiver.receiveDataText(heard)
+		if ok != (i == len(frames)-1) {
+			t.Fatalf("Frame %d: expected the message whole only after the last frame, got %v", i, ok)
+		}
+		whole = msg
+	}
+	if whole.From != "K3DEP" || whole.To != "W1AW" || whole.Message != "W1AW K3DEP "+strings.ToUpper(text) || whole.Offset != 1500 {
+		t.Errorf("Unexpected message %+v", whole)
+	}
+
+	// A data frame with no first frame before it goes on with its text
+	frame, _, _ := dsp.PackDataMessage("HELLO")
+	orphan := protocol.Message{Timestamp: time.Now(), Message: frame, Offset: 900, Transmission: uint8(dsp.JS8CallLast)}
+	if msg, ok := receiver.receiveDataText(orphan); !ok || msg.Message != "HELLO" {
+		t.Errorf("Expected the orphan frame's text, got %+v", msg)
+	}
+
+	// Without data_frames the message goes out as before
+	resp = receiver.handleSend(&protocol.Command{Type: protocol.CmdSend, Args: map[string]interface{}{"to": "K3DEP", "message": text}})
+	if !resp.Success || len(receiver.txMessages) != 1 {
+		t.Fatalf("Expected one plain message queued, got %v (%d queued)", resp.Error, len(receiver.txMessages))
+	}
+	if msg := <-receiver.txMessages; msg.Message != text || msg.DataFrames || len(txFrames(msg)) != 1 {
+		t.Errorf("Expected the plain message, got %+v", msg)
+	}
+}
+
+func TestDecodeSubmode(t *testing.T) {
+	tempDir := t.TempDir()
+	engine := NewCoreEngine(createTestConfig(tempDir), filepath.Join(tempDir, "test.sock"), "")
+	defer engine.Stop()
+
+	// The same text heard in two submodes at once is two messages
+	for _, mode := range []dsp.JS8Mode{dsp.ModeNormal, dsp.ModeFast, dsp.ModeFast} {
+		engine.storeRX(engine.parseJS8Message(&dsp.DecodeResult{Message: "K3DEP N0ABC HELLO", Frequency: 1500, Mode: int(mode)}))
+	}
+	messages, err := engine.messageStore.GetMessages(storage.MessageQuery{Direction: "RX"})
+	if err != nil || len(messages) != 2 {
+		t.Fatalf("Expected one message in each submode stored, got %+v (%v)", messages, err)
+	}
+	submodes := map[string]bool{messages[0].Submode: true, messages[1].Submode: true}
+	if !submodes["normal"] || !submodes["fast"] {
+		t.Errorf("Expected normal and fast stored, got %v", submodes)
+	}
+}
@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"errors"
+	"io"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/storage"
+)
+
+// publishEvent delivers a message store change to event subscribers
+func (e *CoreEngine) publishEvent(eventType string, data map[string]interface{}) {
+	event := protocol.NewEvent(eventType, data)
+
+	e.eventMutex.Lock()
+	defer e.eventMutex.Unlock()
+
+	for ch := range e.eventSubscribers {
+		select {
+		case ch <- event:
+		default:
+			// Slow subscriber, drop the event rather than stall others
+		}
+	}
+}
+
+// SubscribeEvents returns a channel receiving new messages, TX status and
+// delivery changes, read-state changes and new conversations, and a function
+// that cancels the subscription
+func (e *CoreEngine) SubscribeEvents() (<-chan protocol.Event, func()) {
+	ch := make(chan protocol.Event, 32)
+
+	e.eventMutex.Lock()
+	e.eventSubscribers[ch] = struct{}{}
+	e.eventMutex.Unlock()
+
+	return ch, func() {
+		e.eventMutex.Lock()
+		delete(e.eventSubscribers, ch)
+		e.eventMutex.Unlock()
+	}
+}
+
+// streamEvents turns a socket connection that sent EVENTS into an event
+// stream, writing each event until the client hangs up or the engine stops
+func (e *CoreEngine) streamEvents(reader io.Reader, write func(protocol.Event) error) {
+	events, cancel := e.SubscribeEvents()
+	defer cancel()
+
+	// Anything the client sends now is ignored; EOF means it has gone
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, reader)
+		close(closed)
+	}()
+
+	for {
+		select {
+		case event := <-events:
+			if err := write(event); err != nil {
+				return
+			}
+
+		case <-closed:
+			return
+
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// unreadCount returns the number of unread messages for read-state events
+func (e *CoreEngine) unreadCount() int {
+	e.msgMutex.RLock()
+	defer e.msgMutex.RUnlock()
+	if e.messageStore == nil {
+		return 0
+	}
+
+	count, err := e.messageStore.GetUnreadCount()
+	if err != nil {
+		logger.Warnf("Failed to count unread messages: %v", err)
+	}
+	return count
+}
+
+// storeRX stores a received message and tells event subscribers about it,
+// and about the conversation it starts if it is the first from its sender.
+// It reports whether the message was a duplicate of one already stored,
+// which is dropped.
+func (e *CoreEngine) storeRX(msg protocol.Message) bool {
+	e.msgMutex.Lock()
+	if e.messageStore == nil {
+		e.msgMutex.Unlock()
+		return false
+	}
+	if e.snippets != nil && len(msg.Audio) > 0 {
+		msg.Snippet = snippetName(msg)
+	}
+	stored, newConversation, err := e.messageStore.StoreReceived(msg, e.classifyMessage(msg.Message))
+	if err == nil {
+		e.recordHeard(msg)
+		e.logHeardQSO(msg)
+		paths := []protocol.Message{stored}
+		e.addPaths(paths)
+		stored = paths[0]
+	}
+	e.msgMutex.Unlock()
+	if errors.Is(err, storage.ErrDuplicate) {
+		logger.Debugf("Dropped duplicate RX from %s: %s", msg.From, msg.Message)
+		return true
+	}
+	if err != nil {
+		logger.Errorf("Failed to store RX message: %v", err)
+		return false
+	}
+	if stored.Snippet != "" {
+		e.saveSnippet(stored)
+		stored.Audio = nil
+	}
+
+	if newConversation {
+		e.publishEvent(protocol.EventConversation, map[string]interface{}{
+			"callsign": stored.From,
+		})
+	}
+	e.publishEvent(protocol.EventMessage, map[string]interface{}{
+		"direction": "RX",
+		"message":   stored,
+		"unread":    e.unreadCount(),
+	})
+	return false
+}
@@ -0,0 +1,572 @@
+package engine
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/filetransfer"
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/storage"
+)
+
+const (
+	// fileQueueReserve is how much of the transmit queue a file being sent
+	// leaves free for other messages
+	fileQueueReserve = 10
+	// fileNackSettle is how long the sender waits after a NACK before
+	// resending, so the receiver's other NACKs for the round are heard first
+	fileNackSettle = 20 * time.Second
+	// fileIdle is how long a transfer being received is kept without a
+	// frame heard, and a received one kept to answer repeated polls
+	fileIdle = 30 * time.Minute
+	// fileMaxNacks is the most NACKs a poll is answered with
+	fileMaxNacks = 4
+	// fileListLimit is how many transfers FILE lists
+	fileListLimit = 100
+)
+
+// outgoingFile is a file being sent
+type outgoingFile struct {
+	record   *storage.FileTransfer
+	frames   []string // The data frames, by frame number
+	pending  []string // Frames still to be queued, ending with a poll
+	sent     map[uint16]bool
+	resendAt time.Time // After a NACK, pending waits until then
+	deadline time.Time // When the poll that went out times out
+	polls    int       // Polls gone unanswered in a row
+}
+
+// incomingFile is a file being received. Data frames don't name the
+// stations, so frames heard before an offer or poll to us are kept
+// unclaimed, with no record, until one claims them.
+type incomingFile struct {
+	record *storage.FileTransfer
+	frames *filetransfer.Incoming
+	sender uint16 // TransferCallHash of the sending station, once claimed
+	heard  time.Time
+}
+
+// handleFile lists, fetches, sends or cancels file transfers
+func (e *CoreEngine) handleFile(cmd *protocol.Command) *protocol.Response {
+	switch action := cmd.FileAction(); action {
+	case protocol.FileList:
+		e.msgMutex.RLock()
+		defer e.msgMutex.RUnlock()
+		if e.messageStore == nil {
+			return protocol.NewErrorResponse("message storage not available")
+		}
+		list, err := e.messageStore.GetFileTransfers(fileListLimit)
+		if err != nil {
+			return protocol.NewErrorResponse(err.Error())
+		}
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"transfers": list,
+		})
+
+	case protocol.FileGet:
+		id, err := strconv.ParseInt(cmd.StringArg("id"), 10, 64)
+		if err != nil {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("invalid transfer id %q", cmd.StringArg("id")))
+		}
+		e.msgMutex.RLock()
+		defer e.msgMutex.RUnlock()
+		if e.messageStore == nil {
+			return protocol.NewErrorResponse("message storage not available")
+		}
+		transfer, err := e.messageStore.GetFileTransfer(id)
+		if err != nil {
+			return protocol.NewErrorResponse(err.Error())
+		}
+		if transfer == nil {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("no file transfer %d", id))
+		}
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"transfer": transfer,
+			"content":  base64.StdEncoding.EncodeToString(transfer.Content),
+		})
+
+	case protocol.FileSend:
+		content, err := base64.StdEncoding.DecodeString(cmd.StringArg("content"))
+		if err != nil {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("file content must be base64: %v", err))
+		}
+		return e.sendFile(cmd.StringArg("to"), cmd.StringArg("name"), content)
+
+	case protocol.FileCancel:
+		id, err := strconv.ParseInt(cmd.StringArg("id"), 10, 64)
+		if err != nil {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("invalid transfer id %q", cmd.StringArg("id")))
+		}
+		return e.cancelFile(id)
+
+	default:
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("unknown FILE action %q", action))
+	}
+}
+
+// sendFile starts sending a file to a station. The offer, data frames and
+// poll are fed to the transmit queue by the file loop as it has room.
+func (e *CoreEngine) sendFile(to, name string, content []byte) *protocol.Response {
+	if !e.config.Files.Enabled {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "file transfer is disabled, set files.enabled")
+	}
+	to = strings.ToUpper(strings.TrimSpace(to))
+	if !dsp.IsValidCallsign(to) {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("invalid callsign %q", to))
+	}
+	if len(content) == 0 {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "file is empty")
+	}
+	if len(content) > e.config.Files.MaxSize {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid,
+			fmt.Sprintf("file is %d bytes, over the files.max_size of %d", len(content), e.config.Files.MaxSize))
+	}
+
+	from := strings.ToUpper(e.config.Station.Callsign)
+	payload, err := filetransfer.Encode(filetransfer.File{Name: name, From: from, Content: content})
+	if err != nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, err.Error())
+	}
+
+	e.fileMutex.Lock()
+	defer e.fileMutex.Unlock()
+
+	id, ok := e.freeTransferID()
+	if !ok {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeQueueFull, "too many file transfers in progress")
+	}
+	frames, err := filetransfer.Frames(id, payload)
+	if err != nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, err.Error())
+	}
+	last := uint16(len(frames) - 1)
+
+	out := &outgoingFile{
+		record: &storage.FileTransfer{
+			TransferID: id,
+			Direction:  "TX",
+			Name:       filetransfer.CleanName(name),
+			From:       from,
+			To:         to,
+			Size:       len(content),
+			Frames:     len(frames),
+			Status:     protocol.FileSending,
+			Content:    content,
+		},
+		frames: frames,
+		sent:   make(map[uint16]bool),
+	}
+	out.pending = append(out.pending, filetransfer.Control(id, dsp.TransferOffer, 0, last, from, to))
+	out.pending = append(out.pending, frames...)
+	out.pending = append(out.pending, filetransfer.Control(id, dsp.TransferPoll, 0, last, from, to))
+	e.outgoing[id] = out
+	e.saveTransfer(out.record)
+
+	logger.Infof("TX: sending %s (%d bytes) to %s in %d frames", out.record.Name, len(content), to, len(frames))
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"status":   "queued",
+		"frames":   len(frames),
+		"transfer": *out.record,
+	})
+}
+
+// freeTransferID picks an id no file being sent uses. The caller holds
+// fileMutex.
+func (e *CoreEngine) freeTransferID() (uint8, bool) {
+	start := rand.Intn(dsp.TransferFrameIDs)
+	for i := 0; i < dsp.TransferFrameIDs; i++ {
+		id := uint8((start + i) % dsp.TransferFrameIDs)
+		if _, used := e.outgoing[id]; !used {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// cancelFile gives up on a transfer in progress by its stored ID, telling
+// the other station
+func (e *CoreEngine) cancelFile(id int64) *protocol.Response {
+	e.fileMutex.Lock()
+	defer e.fileMutex.Unlock()
+
+	for _, out := range e.outgoing {
+		if out.record.ID != id {
+			continue
+		}
+		t := out.record
+		e.queueTransferFrame(t.To, filetransfer.Control(t.TransferID, dsp.TransferCancel, 0, uint16(t.Frames-1), t.From, t.To))
+		e.endOutgoing(out, protocol.FileCancelled, "cancelled")
+		return protocol.NewSuccessResponse(map[string]interface{}{"transfer": *t})
+	}
+	for _, in := range e.incoming {
+		if in.record == nil || in.record.ID != id || in.record.Status != protocol.FileReceiving {
+			continue
+		}
+		e.queueTransferFrame("", filetransfer.Reply(in.claim(), dsp.TransferCancel, 0, in.frames.Last))
+		e.endIncoming(in, protocol.FileCancelled, "cancelled")
+		return protocol.NewSuccessResponse(map[string]interface{}{"transfer": *in.record})
+	}
+	return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("no file transfer %d in progress", id))
+}
+
+// fileLoop feeds the frames of files being sent to the transmit queue and
+// times out polls and transfers that have gone quiet
+func (e *CoreEngine) fileLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			e.tickFiles(now)
+
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// tickFiles does one pass of the file loop
+func (e *CoreEngine) tickFiles(now time.Time) {
+	e.fileMutex.Lock()
+	defer e.fileMutex.Unlock()
+
+	for _, out := range e.outgoing {
+		switch out.record.Status {
+		case protocol.FileSending:
+			if now.Before(out.resendAt) {
+				continue
+			}
+			for len(out.pending) > 0 && cap(e.txMessages)-len(e.txMessages) > fileQueueReserve {
+				if !e.queueTransferFrame(out.record.To, out.pending[0]) {
+					break
+				}
+				out.pending = out.pending[1:]
+			}
+
+		case protocol.FilePolling:
+			if now.Before(out.deadline) {
+				continue
+			}
+			out.polls++
+			if out.polls > e.config.Files.Retries {
+				e.endOutgoing(out, protocol.FileFailed, fmt.Sprintf("no answer to %d polls", out.polls))
+				continue
+			}
+			t := out.record
+			logger.Infof("File %s to %s: poll unanswered, polling again", t.Name, t.To)
+			out.pending = []string{filetransfer.Control(t.TransferID, dsp.TransferPoll, 0, uint16(t.Frames-1), t.From, t.To)}
+			t.Status = protocol.FileSending
+			e.saveTransfer(t)
+		}
+	}
+
+	for id, in := range e.incoming {
+		if now.Sub(in.heard) < fileIdle {
+			continue
+		}
+		delete(e.incoming, id)
+		if in.record != nil && in.record.Status == protocol.FileReceiving {
+			e.endIncoming(in, protocol.FileFailed, fmt.Sprintf("no frames heard for %v", fileIdle))
+		}
+	}
+}
+
+// transferSent follows a transfer frame that has gone out: the data frames
+// count toward the progress of their file, and the poll starts the wait for
+// the receiver's answer. Other messages are ignored.
+func (e *CoreEngine) transferSent(msg protocol.Message, err error) {
+	f, perr := dsp.UnpackTransferFrame(msg.Message)
+	if perr != nil {
+		return
+	}
+
+	e.fileMutex.Lock()
+	defer e.fileMutex.Unlock()
+
+	out := e.outgoing[f.ID]
+	if out == nil || (f.Control && f.FromHash != dsp.TransferCallHash(out.record.From)) {
+		return
+	}
+	if err != nil {
+		e.endOutgoing(out, protocol.FileFailed, fmt.Sprintf("transmit failed: %v", err))
+		return
+	}
+
+	if !f.Control {
+		if !out.sent[f.Seq] {
+			out.sent[f.Seq] = true
+			out.record.Done = len(out.sent)
+			e.saveTransfer(out.record)
+		}
+		return
+	}
+	if f.Op == dsp.TransferPoll && out.record.Status == protocol.FileSending && len(out.pending) == 0 {
+		out.record.Status = protocol.FilePolling
+		out.deadline = time.Now().Add(time.Duration(e.config.Files.PollTimeout) * time.Second)
+		e.saveTransfer(out.record)
+	}
+}
+
+// receiveTransferFrame takes a decoded transfer frame, following the files
+// sent to and by this station. It reports whether msg was a transfer frame.
+func (e *CoreEngine) receiveTransferFrame(msg protocol.Message) bool {
+	f, err := dsp.UnpackTransferFrame(msg.Message)
+	if err != nil {
+		return false
+	}
+	if !e.config.Files.Enabled {
+		return true
+	}
+
+	e.fileMutex.Lock()
+	defer e.fileMutex.Unlock()
+
+	if !f.Control {
+		logger.Debugf("RX: transfer %d frame %d (SNR: %.1fdB)", f.ID, f.Seq+1, msg.SNR)
+		e.receiveFileData(f)
+		return true
+	}
+	if f.ToHash != dsp.TransferCallHash(strings.ToUpper(e.config.Station.Callsign)) {
+		return true
+	}
+
+	switch f.Op {
+	case dsp.TransferOffer, dsp.TransferPoll:
+		e.receiveFilePoll(f)
+	case dsp.TransferAck, dsp.TransferNack:
+		e.receiveFileAnswer(f)
+	case dsp.TransferCancel:
+		if out := e.outgoing[f.ID]; out != nil && f.FromHash == dsp.TransferCallHash(out.record.To) {
+			e.endOutgoing(out, protocol.FileCancelled, "cancelled by the receiver")
+		} else if in := e.incoming[f.ID]; in != nil && in.record != nil && in.sender == f.FromHash &&
+			in.record.Status == protocol.FileReceiving {
+			e.endIncoming(in, protocol.FileCancelled, "cancelled by the sender")
+		}
+	}
+	return true
+}
+
+// receiveFileData keeps a data frame, finishing its file when it was the
+// last one missing. The caller holds fileMutex.
+func (e *CoreEngine) receiveFileData(f dsp.TransferFrame) {
+	in := e.incoming[f.ID]
+	if in == nil {
+		in = &incomingFile{frames: filetransfer.NewUnsized(f.ID)}
+		e.incoming[f.ID] = in
+	}
+	if in.record != nil && in.record.Status != protocol.FileReceiving {
+		return
+	}
+	in.heard = time.Now()
+	if !in.frames.Add(f) || in.record == nil {
+		return
+	}
+
+	in.record.Frames = in.frames.Total()
+	in.record.Done = in.frames.Received()
+	if in.frames.Complete() {
+		e.finishIncoming(in)
+		return
+	}
+	e.saveTransfer(in.record)
+}
+
+// receiveFilePoll claims the transfer an offer or poll to this station is
+// about and, for a poll, answers with what is still missing. The caller
+// holds fileMutex.
+func (e *CoreEngine) receiveFilePoll(f dsp.TransferFrame) {
+	in := e.incoming[f.ID]
+	finished := in != nil && in.record != nil && in.record.Status != protocol.FileReceiving
+	switch {
+	case in == nil, in.record != nil && in.sender != f.FromHash,
+		f.Op == dsp.TransferOffer && finished:
+		// A new transfer, or one reusing the id of a finished one
+		in = &incomingFile{frames: filetransfer.NewIncoming(f.ID, f.End)}
+		e.incoming[f.ID] = in
+	case !in.frames.Sized():
+		// Frames heard before the offer are kept
+		in.frames.Size(f.End)
+	case in.frames.Last != f.End && !finished:
+		in.frames = filetransfer.NewIncoming(f.ID, f.End)
+	}
+	in.heard = time.Now()
+
+	if in.record == nil {
+		in.sender = f.FromHash
+		in.record = &storage.FileTransfer{
+			TransferID: f.ID,
+			Direction:  "RX",
+			To:         e.config.Station.Callsign,
+			Frames:     in.frames.Total(),
+			Done:       in.frames.Received(),
+			Status:     protocol.FileReceiving,
+		}
+		logger.Infof("RX: file transfer %d of %d frames offered", f.ID, in.frames.Total())
+		if in.frames.Complete() {
+			// Every frame was heard before the poll; the ACK answers it
+			e.finishIncoming(in)
+			return
+		}
+		e.saveTransfer(in.record)
+	}
+	if f.Op != dsp.TransferPoll {
+		return
+	}
+
+	in.record.Rounds++
+	e.saveTransfer(in.record)
+	if !e.autoReplyEnabled() || e.autoListRefusal(rateTransfer, in.caller()) != "" || !e.rateAllowed(rateTransfer, in.caller()) {
+		return
+	}
+	switch in.record.Status {
+	case protocol.FileComplete:
+		e.queueTransferFrame(in.record.From, filetransfer.Reply(f, dsp.TransferAck, 0, in.frames.Last))
+	case protocol.FileReceiving:
+		for _, r := range in.frames.Missing(fileMaxNacks) {
+			e.queueTransferFrame("", filetransfer.Reply(f, dsp.TransferNack, r.Start, r.End))
+		}
+	default:
+		e.queueTransferFrame("", filetransfer.Reply(f, dsp.TransferCancel, 0, in.frames.Last))
+	}
+}
+
+// finishIncoming decodes and stores a file whose frames are all in, and
+// acknowledges it. The caller holds fileMutex.
+func (e *CoreEngine) finishIncoming(in *incomingFile) {
+	file, err := filetransfer.Decode(in.frames.Payload())
+	if err != nil {
+		e.endIncoming(in, protocol.FileFailed, err.Error())
+		return
+	}
+
+	t := in.record
+	t.Name, t.From, t.Size = file.Name, file.From, len(file.Content)
+	if len(file.Content) > e.config.Files.MaxSize {
+		e.endIncoming(in, protocol.FileFailed, fmt.Sprintf("%d bytes is over the files.max_size of %d", len(file.Content), e.config.Files.MaxSize))
+		if e.autoReplyEnabled() {
+			e.queueTransferFrame(t.From, filetransfer.Reply(in.claim(), dsp.TransferCancel, 0, in.frames.Last))
+		}
+		return
+	}
+
+	t.Content = file.Content
+	e.endIncoming(in, protocol.FileComplete, "")
+	if e.autoReplyEnabled() {
+		e.queueTransferFrame(t.From, filetransfer.Reply(in.claim(), dsp.TransferAck, 0, in.frames.Last))
+	}
+}
+
+// receiveFileAnswer takes the receiver's answer to a poll: an ACK completes
+// the file, NACKs queue the frames they name to be sent again with a new
+// poll. The caller holds fileMutex.
+func (e *CoreEngine) receiveFileAnswer(f dsp.TransferFrame) {
+	out := e.outgoing[f.ID]
+	if out == nil || f.FromHash != dsp.TransferCallHash(out.record.To) {
+		return
+	}
+	t := out.record
+
+	if f.Op == dsp.TransferAck {
+		t.Done = t.Frames
+		e.endOutgoing(out, protocol.FileComplete, "")
+		return
+	}
+
+	// The first NACK after a poll starts a new round of resending
+	if t.Status == protocol.FilePolling {
+		t.Rounds++
+		if t.Rounds > e.config.Files.Retries {
+			e.queueTransferFrame(t.To, filetransfer.Control(t.TransferID, dsp.TransferCancel, 0, uint16(t.Frames-1), t.From, t.To))
+			e.endOutgoing(out, protocol.FileFailed, fmt.Sprintf("frames still missing after %d rounds", t.Rounds-1))
+			return
+		}
+		out.pending = nil
+		t.Status = protocol.FileSending
+	}
+
+	// Frames named by this NACK go before the poll the round ends with
+	if n := len(out.pending); n > 0 {
+		out.pending = out.pending[:n-1]
+	}
+	for seq := int(f.Start); seq <= int(f.End) && seq < len(out.frames); seq++ {
+		out.pending = append(out.pending, out.frames[seq])
+	}
+	out.pending = append(out.pending, filetransfer.Control(t.TransferID, dsp.TransferPoll, 0, uint16(t.Frames-1), t.From, t.To))
+	out.resendAt = time.Now().Add(fileNackSettle)
+	out.polls = 0
+	logger.Infof("File %s to %s: resending frames %d to %d", t.Name, t.To, f.Start, f.End)
+	e.saveTransfer(t)
+}
+
+// claim returns a control frame from the sending station of an incoming
+// file, for Reply to answer
+func (in *incomingFile) claim() dsp.TransferFrame {
+	return dsp.TransferFrame{ID: in.frames.ID, Control: true, FromHash: in.sender}
+}
+
+// caller names the sending station for the rate limits: its callsign once
+// the file is decoded, its call hash before
+func (in *incomingFile) caller() string {
+	if in.record.From != "" {
+		return in.record.From
+	}
+	return fmt.Sprintf("#%04X", in.sender)
+}
+
+// endOutgoing finishes a file being sent. The caller holds fileMutex.
+func (e *CoreEngine) endOutgoing(out *outgoingFile, status, reason string) {
+	delete(e.outgoing, out.record.TransferID)
+	out.record.Status = status
+	out.record.Error = reason
+	logger.Infof("File %s to %s %s after %d rounds", out.record.Name, out.record.To, status, out.record.Rounds)
+	e.saveTransfer(out.record)
+}
+
+// endIncoming finishes a file being received, keeping it to answer polls
+// until it goes idle. The caller holds fileMutex.
+func (e *CoreEngine) endIncoming(in *incomingFile, status, reason string) {
+	in.record.Status = status
+	in.record.Error = reason
+	if status == protocol.FileComplete {
+		logger.Infof("RX: file %s (%d bytes) from %s", in.record.Name, in.record.Size, in.record.From)
+	} else {
+		logger.Warnf("RX: file transfer %d %s: %s", in.record.TransferID, status, reason)
+	}
+	e.saveTransfer(in.record)
+}
+
+// queueTransferFrame queues one transfer frame for transmission, reporting
+// whether there was room
+func (e *CoreEngine) queueTransferFrame(to, frame string) bool {
+	msg := protocol.Message{
+		ID:        int(time.Now().Unix()),
+		Timestamp: time.Now(),
+		From:      e.config.Station.Callsign,
+		To:        to,
+		Message:   frame,
+		Mode:      "JS8",
+	}
+	_, ok := e.queueTX(msg)
+	return ok
+}
+
+// saveTransfer stores a transfer's progress and announces it
+func (e *CoreEngine) saveTransfer(t *storage.FileTransfer) {
+	e.msgMutex.Lock()
+	if e.messageStore != nil {
+		if err := e.messageStore.SaveFileTransfer(t); err != nil {
+			logger.Errorf("Failed to store file transfer %d: %v", t.TransferID, err)
+		}
+	}
+	e.msgMutex.Unlock()
+
+	e.publishEvent(protocol.EventFile, map[string]interface{}{
+		"transfer": *t,
+	})
+}
@@ -0,0 +1,177 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/storage"
+)
+
+// filterStatus is a filter with the decodes it dropped since js8d started
+type filterStatus struct {
+	storage.Filter
+	Hits int `json:"hits"`
+}
+
+// loadFilters reads the decode filters from the message store
+func (e *CoreEngine) loadFilters() error {
+	e.msgMutex.RLock()
+	if e.messageStore == nil {
+		e.msgMutex.RUnlock()
+		return nil
+	}
+	filters, err := e.messageStore.GetFilters()
+	e.msgMutex.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	e.filterMutex.Lock()
+	e.filters = filters
+	e.filterMutex.Unlock()
+	return nil
+}
+
+// filterMatches reports whether a filter drops a decode. A callsign filter
+// also drops the station with a prefix or suffix, such as N0ABC/P.
+func filterMatches(filter storage.Filter, msg protocol.Message) bool {
+	switch filter.Type {
+	case protocol.FilterCallsign:
+		from := strings.ToUpper(msg.From)
+		return from == filter.Callsign || strings.HasPrefix(from, filter.Callsign+"/") || strings.HasSuffix(from, "/"+filter.Callsign)
+	case protocol.FilterOffset:
+		distance := msg.Offset - filter.Offset
+		return distance >= -filter.Width && distance <= filter.Width
+	}
+	return false
+}
+
+// filterDecode reports whether a decode is dropped by a filter, counting it
+// against the first that matches
+func (e *CoreEngine) filterDecode(msg protocol.Message) bool {
+	e.filterMutex.Lock()
+	defer e.filterMutex.Unlock()
+
+	for _, filter := range e.filters {
+		if filterMatches(filter, msg) {
+			if e.filterHits == nil {
+				e.filterHits = make(map[int64]int)
+			}
+			e.filterHits[filter.ID]++
+			e.filtered++
+			dspLogger.Debugf("Decode from %s at %d Hz dropped by filter %d: %s", msg.From, msg.Offset, filter.ID, msg.Message)
+			return true
+		}
+	}
+	return false
+}
+
+// handleFilter handles the FILTER command, which lists, adds or deletes the
+// filters dropping decodes before they are stored or answered
+func (e *CoreEngine) handleFilter(cmd *protocol.Command) *protocol.Response {
+	action := cmd.FilterAction()
+
+	var filter storage.Filter
+	var id int64
+	switch action {
+	case protocol.FilterList:
+		return e.filterList()
+
+	case protocol.FilterAdd:
+		filter.Type = strings.ToLower(strings.TrimSpace(cmd.StringArg("type")))
+		filter.Note = cmd.StringArg("note")
+		switch filter.Type {
+		case protocol.FilterCallsign:
+			filter.Callsign = strings.ToUpper(strings.TrimSpace(cmd.StringArg("callsign")))
+			if filter.Callsign == "" {
+				return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "callsign required")
+			}
+		case protocol.FilterOffset:
+			offset, err := strconv.Atoi(strings.TrimSpace(cmd.StringArg("offset")))
+			if err != nil || offset <= 0 {
+				return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "offset must be a positive number of Hz")
+			}
+			filter.Offset = offset
+			filter.Width = protocol.DefaultFilterWidth
+			if width := strings.TrimSpace(cmd.StringArg("width")); width != "" {
+				filter.Width, err = strconv.Atoi(width)
+				if err != nil || filter.Width < 0 {
+					return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "width must be a number of Hz, 0 or more")
+				}
+			}
+		default:
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid,
+				fmt.Sprintf("unknown filter type %q, use %s or %s", filter.Type, protocol.FilterCallsign, protocol.FilterOffset))
+		}
+
+	case protocol.FilterDelete:
+		var err error
+		id, err = strconv.ParseInt(strings.TrimSpace(cmd.StringArg("id")), 10, 64)
+		if err != nil || id <= 0 {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "usage: FILTER:delete:<id>")
+		}
+
+	default:
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("unknown FILTER action %q", action))
+	}
+
+	e.msgMutex.Lock()
+	if e.messageStore == nil {
+		e.msgMutex.Unlock()
+		return protocol.NewErrorResponse("message storage not available")
+	}
+	var err error
+	var deleted bool
+	if action == protocol.FilterAdd {
+		err = e.messageStore.AddFilter(&filter)
+	} else {
+		deleted, err = e.messageStore.DeleteFilter(id)
+	}
+	e.msgMutex.Unlock()
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to %s filter: %v", action, err))
+	}
+	if action == protocol.FilterDelete && !deleted {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("no filter %d", id))
+	}
+	if err := e.loadFilters(); err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to load filters: %v", err))
+	}
+
+	if action == protocol.FilterAdd {
+		logger.Infof("Filter %d added: %s", filter.ID, describeFilter(filter))
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"filter": filter,
+		})
+	}
+	logger.Infof("Filter %d deleted", id)
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"deleted": id,
+	})
+}
+
+// filterList returns the filters with the decodes each has dropped
+func (e *CoreEngine) filterList() *protocol.Response {
+	e.filterMutex.RLock()
+	defer e.filterMutex.RUnlock()
+
+	filters := make([]filterStatus, len(e.filters))
+	for i, filter := range e.filters {
+		filters[i] = filterStatus{Filter: filter, Hits: e.filterHits[filter.ID]}
+	}
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"filters": filters,
+		"count":   len(filters),
+		"dropped": e.filtered,
+	})
+}
+
+// describeFilter says what a filter drops, for the log
+func describeFilter(filter storage.Filter) string {
+	if filter.Type == protocol.FilterOffset {
+		return fmt.Sprintf("decodes within %d Hz of %d Hz", filter.Width, filter.Offset)
+	}
+	return "decodes from " + filter.Callsign
+}
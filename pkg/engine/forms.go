@@ -0,0 +1,197 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/forms"
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/storage"
+	"github.com/dougsko/js8d/pkg/telemetry"
+)
+
+const (
+	// formTimeout is how long a form being received waits for its next
+	// frame before the frames heard so far are dropped
+	formTimeout = 10 * time.Minute
+	// formListLimit is how many forms FORM lists
+	formListLimit = 100
+)
+
+// handleForm lists forms and templates, or sends a form
+func (e *CoreEngine) handleForm(cmd *protocol.Command) *protocol.Response {
+	switch action := cmd.FormAction(); action {
+	case protocol.FormTemplates:
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"templates": e.formTemplates(),
+		})
+
+	case protocol.FormList:
+		direction := strings.ToUpper(cmd.StringArg("direction"))
+		if direction != "" && direction != "RX" && direction != "TX" {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("unknown direction %q, use rx or tx", direction))
+		}
+		e.msgMutex.RLock()
+		defer e.msgMutex.RUnlock()
+		if e.messageStore == nil {
+			return protocol.NewErrorResponse("message storage not available")
+		}
+		list, err := e.messageStore.GetForms(direction, formListLimit)
+		if err != nil {
+			return protocol.NewErrorResponse(err.Error())
+		}
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"forms": list,
+		})
+
+	case protocol.FormSend:
+		values, err := cmd.FormValues()
+		if err != nil {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("form values must be a JSON object: %v", err))
+		}
+		return e.sendForm(cmd.StringArg("template"), cmd.StringArg("to"), values)
+
+	default:
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("unknown FORM action %q", action))
+	}
+}
+
+// sendForm fills in a template and queues its data frames, one per
+// transmission
+func (e *CoreEngine) sendForm(code, to string, values map[string]string) *protocol.Response {
+	template, ok := forms.Find(e.formTemplates(), code)
+	if !ok {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("unknown form template %q", code))
+	}
+	to = strings.ToUpper(strings.TrimSpace(to))
+	if to != "" && !dsp.IsValidCallsign(to) {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("invalid callsign %q", to))
+	}
+
+	form, err := forms.Fill(template, e.config.Station.Callsign, to, values)
+	if err != nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, err.Error())
+	}
+	frames, err := forms.Frames(form, uint8(rand.Intn(64)))
+	if err != nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, err.Error())
+	}
+
+	// The whole form goes out or none of it does
+	if room := cap(e.txMessages) - len(e.txMessages); len(frames) > room {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeQueueFull,
+			fmt.Sprintf("form needs %d transmissions but the transmit queue has room for %d", len(frames), room))
+	}
+	for _, frame := range frames {
+		msg := protocol.Message{
+			ID:        int(time.Now().Unix()),
+			Timestamp: time.Now(),
+			From:      e.config.Station.Callsign,
+			To:        to,
+			Message:   frame,
+			Mode:      "JS8",
+		}
+		if _, ok := e.queueTX(msg); !ok {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeQueueFull, "transmit queue full")
+		}
+	}
+	logger.Infof("TX queued: %s form to %s in %d frames", form.Template, to, len(frames))
+
+	stored := e.saveForm(form, "TX", protocol.Message{Frequency: e.dialFrequency()}, len(frames))
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"status": "queued",
+		"frames": len(frames),
+		"form":   stored,
+	})
+}
+
+// receiveFormFrame takes a decoded data frame, storing the form or
+// telemetry it belongs to once all its frames are in. It reports whether
+// msg was a data frame.
+func (e *CoreEngine) receiveFormFrame(msg protocol.Message) bool {
+	seq, last, payload, err := e.formAssembler.Add(msg.Message, msg.Timestamp)
+	if err != nil {
+		return false
+	}
+	logger.Debugf("RX: data frame %d (SNR: %.1fdB)", seq+1, msg.SNR)
+	if payload == nil {
+		return true
+	}
+	if telemetry.IsPayload(payload) {
+		e.receiveTelemetry(payload, msg, int(last)+1)
+		return true
+	}
+
+	form, err := forms.Decode(payload, e.formTemplates())
+	if err != nil {
+		logger.Warnf("Dropped a form of %d frames: %v", last+1, err)
+		return true
+	}
+	logger.Infof("RX: %s form from %s to %s in %d frames", form.Template, form.From, form.To, last+1)
+	e.saveForm(form, "RX", msg, int(last)+1)
+
+	// The form also shows in the message history as a one line summary
+	summary := msg
+	summary.From = form.From
+	summary.To = form.To
+	summary.Message = form.Summary()
+	e.storeRX(summary)
+	e.triggerRX(summary)
+	return true
+}
+
+// saveForm stores a form sent or received and announces it. msg carries
+// the frequency and, for a received form, the SNR of its last frame.
+func (e *CoreEngine) saveForm(form forms.Form, direction string, msg protocol.Message, frames int) *storage.StoredForm {
+	values, _ := json.Marshal(form.Values)
+	stored := &storage.StoredForm{
+		Timestamp: time.Now(),
+		Direction: direction,
+		Template:  form.Template,
+		Name:      form.Name,
+		From:      form.From,
+		To:        form.To,
+		Frequency: msg.Frequency,
+		SNR:       msg.SNR,
+		Frames:    frames,
+		Values:    values,
+	}
+
+	e.msgMutex.Lock()
+	if e.messageStore != nil {
+		if err := e.messageStore.SaveForm(stored); err != nil {
+			logger.Errorf("Failed to store %s form: %v", direction, err)
+		}
+	}
+	e.msgMutex.Unlock()
+
+	e.publishEvent(protocol.EventForm, map[string]interface{}{
+		"form": stored,
+	})
+	return stored
+}
+
+// formTemplates returns the configured form templates followed by the
+// built-in ones, so a configured template can replace a built-in one
+func (e *CoreEngine) formTemplates() []forms.Template {
+	var templates []forms.Template
+	for _, t := range e.config.Forms.Templates {
+		template := forms.Template{Code: strings.ToUpper(t.Code), Name: t.Name}
+		if template.Name == "" {
+			template.Name = template.Code
+		}
+		for _, f := range t.Fields {
+			field := forms.Field{Name: f.Name, Label: f.Label, Multiline: f.Multiline}
+			if field.Label == "" {
+				field.Label = f.Name
+			}
+			template.Fields = append(template.Fields, field)
+		}
+		templates = append(templates, template)
+	}
+	return append(templates, forms.Builtin()...)
+}
@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/gpsd"
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/timesync"
+)
+
+const (
+	// gpsRetry is how long to wait before reconnecting to gpsd
+	gpsRetry = 30 * time.Second
+	// gridAnnounceInterval is the least time between heartbeats announcing a
+	// new grid, so driving across subsquares doesn't key the radio each time
+	gridAnnounceInterval = 10 * time.Minute
+)
+
+// gpsLoop reads fixes from gpsd, reconnecting when the connection drops
+func (e *CoreEngine) gpsLoop() {
+	client := &gpsd.Client{Address: e.config.GPS.Address}
+	failing := false
+
+	for {
+		err := client.Watch(e.ctx, e.handleGPSFix)
+		if e.ctx.Err() != nil {
+			return
+		}
+
+		e.gpsMutex.Lock()
+		connected := e.gpsConnected
+		e.gpsConnected = false
+		e.gpsMutex.Unlock()
+		if connected || !failing {
+			logger.Warnf("GPS unavailable, retrying every %v: %v", gpsRetry, err)
+		}
+		failing = true
+
+		select {
+		case <-time.After(gpsRetry):
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// handleGPSFix keeps a report from gpsd, checks the clock against its time
+// and follows its position with the station grid
+func (e *CoreEngine) handleGPSFix(fix gpsd.Fix) {
+	e.gpsMutex.Lock()
+	if !e.gpsConnected {
+		logger.Infof("GPS connected to gpsd at %s", e.config.GPS.Address)
+	}
+	e.gpsConnected = true
+	e.gpsFix = &fix
+	e.gpsMutex.Unlock()
+
+	if !fix.Time.IsZero() {
+		e.checkClockAgainstGPS(fix)
+	}
+	if fix.HasPosition() && e.config.GPS.UpdateGrid {
+		e.followGrid(gpsd.Grid(fix.Lat, fix.Lon))
+	}
+}
+
+// checkClockAgainstGPS records the clock offset from a fix's time when
+// time_sync uses GPS, once per time_sync interval
+func (e *CoreEngine) checkClockAgainstGPS(fix gpsd.Fix) {
+	if !e.config.TimeSync.Enabled || !strings.EqualFold(e.config.TimeSync.Source, timesync.SourceGPS) {
+		return
+	}
+
+	e.clockMutex.RLock()
+	last := e.clock
+	e.clockMutex.RUnlock()
+	interval := time.Duration(e.config.TimeSync.Interval) * time.Minute
+	if last != nil && fix.Received.Sub(last.Checked) < interval {
+		return
+	}
+
+	e.recordClockCheck(&timesync.Result{
+		Offset:  fix.Received.Sub(fix.Time),
+		Source:  timesync.SourceGPS,
+		Server:  e.config.GPS.Address,
+		Checked: fix.Received,
+	}, nil)
+}
+
+// followGrid makes grid the station grid if it has changed, announcing it
+// with a heartbeat when gps announce_grid is on. The configuration file is
+// left alone; a reload goes back to its grid until the next fix.
+func (e *CoreEngine) followGrid(grid string) {
+	e.mutex.Lock()
+	old := e.config.Station.Grid
+	if strings.EqualFold(old, grid) {
+		e.mutex.Unlock()
+		return
+	}
+	cfg := *e.config
+	cfg.Station.Grid = grid
+	e.config = &cfg
+	e.mutex.Unlock()
+
+	logger.Infof("GPS moved the station grid from %s to %s", old, grid)
+	e.publishEvent(protocol.EventGrid, map[string]interface{}{
+		"grid":     grid,
+		"previous": old,
+	})
+
+	if !e.config.GPS.AnnounceGrid {
+		return
+	}
+	e.gpsMutex.Lock()
+	announce := time.Since(e.gridAnnounced) >= gridAnnounceInterval
+	if announce {
+		e.gridAnnounced = time.Now()
+	}
+	e.gpsMutex.Unlock()
+	if announce {
+		e.sendHeartbeat()
+	}
+}
+
+// gpsStatus reports the latest gpsd report for STATUS, nil when GPS is off
+func (e *CoreEngine) gpsStatus() *protocol.GPSStatus {
+	if !e.config.GPS.Enabled {
+		return nil
+	}
+
+	e.gpsMutex.RLock()
+	defer e.gpsMutex.RUnlock()
+
+	status := &protocol.GPSStatus{Connected: e.gpsConnected}
+	if fix := e.gpsFix; fix != nil {
+		status.Mode = fix.Mode
+		if fix.HasPosition() {
+			status.Lat, status.Lon = fix.Lat, fix.Lon
+			status.Grid = gpsd.Grid(fix.Lat, fix.Lon)
+		}
+		if !fix.Time.IsZero() {
+			t := fix.Time
+			status.Time = &t
+		}
+	}
+	return status
+}
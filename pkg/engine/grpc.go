@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/rpc/js8dv1"
+	"github.com/dougsko/js8d/pkg/storage"
+)
+
+// grpcService implements the js8d.v1 gRPC API on top of the engine
+type grpcService struct {
+	js8dv1.UnimplementedJS8DServer
+	engine *CoreEngine
+}
+
+// startGRPC listens on address and serves the gRPC API
+func (e *CoreEngine) startGRPC(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen for gRPC on %s: %w", address, err)
+	}
+
+	e.grpcServer = e.newGRPCServer()
+
+	go func() {
+		if err := e.grpcServer.Serve(listener); err != nil {
+			logger.Errorf("gRPC server error: %v", err)
+		}
+	}()
+
+	logger.Infof("Core engine serving gRPC on %s", listener.Addr())
+	return nil
+}
+
+// newGRPCServer creates a server for the gRPC API that checks each call's
+// token against the role its method needs
+func (e *CoreEngine) newGRPCServer() *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(e.grpcUnaryAuth),
+		grpc.StreamInterceptor(e.grpcStreamAuth),
+	)
+	js8dv1.RegisterJS8DServer(server, &grpcService{engine: e})
+	return server
+}
+
+// toProtoMessage converts a protocol message for the gRPC API
+func toProtoMessage(msg protocol.Message) *js8dv1.Message {
+	return &js8dv1.Message{
+		Id:        int64(msg.ID),
+		Timestamp: timestamppb.New(msg.Timestamp),
+		From:      msg.From,
+		To:        msg.To,
+		Text:      msg.Message,
+		Snr:       msg.SNR,
+		Frequency: int64(msg.Frequency),
+		Mode:      msg.Mode,
+		Channel:   msg.Channel,
+	}
+}
+
+// involves reports whether a message is from or to the callsign
+func involves(msg protocol.Message, callsign string) bool {
+	return callsign == "" || strings.EqualFold(msg.From, callsign) || strings.EqualFold(msg.To, callsign)
+}
+
+func (s *grpcService) GetStatus(ctx context.Context, req *js8dv1.GetStatusRequest) (*js8dv1.Status, error) {
+	resp := s.engine.handleStatus()
+	st, ok := resp.Data["status"].(protocol.Status)
+	if !resp.Success || !ok {
+		return nil, status.Error(codes.Internal, resp.Error)
+	}
+
+	return &js8dv1.Status{
+		Callsign:  st.Callsign,
+		Grid:      st.Grid,
+		Frequency: int64(st.Frequency),
+		Mode:      st.Mode,
+		Ptt:       st.PTT,
+		Connected: st.Connected,
+		StartTime: timestamppb.New(st.StartTime),
+		Version:   st.Version,
+		Instance:  s.engine.config.Instance,
+	}, nil
+}
+
+func (s *grpcService) ListMessages(ctx context.Context, req *js8dv1.ListMessagesRequest) (*js8dv1.ListMessagesResponse, error) {
+	if s.engine.messageStore == nil {
+		return nil, status.Error(codes.Unavailable, "message storage not available")
+	}
+
+	query := storage.MessageQuery{
+		Limit:    int(req.GetLimit()),
+		Offset:   int(req.GetOffset()),
+		Callsign: strings.ToUpper(req.GetCallsign()),
+	}
+	if query.Limit <= 0 {
+		query.Limit = 50
+	}
+	switch req.GetDirection() {
+	case js8dv1.Direction_DIRECTION_RX:
+		query.Direction = "RX"
+	case js8dv1.Direction_DIRECTION_TX:
+		query.Direction = "TX"
+	}
+
+	s.engine.msgMutex.RLock()
+	messages, err := s.engine.messageStore.GetMessages(query)
+	s.engine.msgMutex.RUnlock()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get messages: %v", err)
+	}
+
+	resp := &js8dv1.ListMessagesResponse{}
+	for _, msg := range messages {
+		resp.Messages = append(resp.Messages, toProtoMessage(msg))
+	}
+	return resp, nil
+}
+
+func (s *grpcService) SendMessage(ctx context.Context, req *js8dv1.SendMessageRequest) (*js8dv1.SendMessageResponse, error) {
+	resp := s.engine.handleSend(&protocol.Command{
+		Type: protocol.CmdSend,
+		Args: map[string]interface{}{
+			"to":      req.GetTo(),
+			"message": req.GetText(),
+		},
+	})
+	if !resp.Success {
+		code := codes.InvalidArgument
+		if resp.Code == protocol.ErrCodeQueueFull {
+			code = codes.ResourceExhausted
+		}
+		return nil, status.Error(code, resp.Error)
+	}
+
+	msg, _ := resp.Data["message"].(protocol.Message)
+	return &js8dv1.SendMessageResponse{Message: toProtoMessage(msg)}, nil
+}
+
+func (s *grpcService) AbortTransmission(ctx context.Context, req *js8dv1.AbortTransmissionRequest) (*js8dv1.AbortTransmissionResponse, error) {
+	resp := s.engine.handleAbort()
+	wasTransmitting, _ := resp.Data["was_transmitting"].(bool)
+	return &js8dv1.AbortTransmissionResponse{WasTransmitting: wasTransmitting}, nil
+}
+
+func (s *grpcService) StreamDecodes(req *js8dv1.StreamDecodesRequest, stream js8dv1.JS8D_StreamDecodesServer) error {
+	decodes, cancel := s.engine.SubscribeDecodes()
+	defer cancel()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case msg := <-decodes:
+			if !involves(msg, req.GetCallsign()) {
+				continue
+			}
+			if err := stream.Send(toProtoMessage(msg)); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// defaultMapWindow is how far back GET_MAP looks when no window is given
+const defaultMapWindow = 24 * time.Hour
+
+// handleGetMap handles GET_MAP [seconds] [band], returning the stations
+// heard in the window as a GeoJSON FeatureCollection. Stations are placed at
+// the centre of their grid square, or at their callbook position, and left
+// off the map when neither is known.
+func (e *CoreEngine) handleGetMap(args []string) *protocol.Response {
+	window := defaultMapWindow
+	if len(args) > 0 {
+		seconds, err := strconv.Atoi(args[0])
+		if err != nil || seconds <= 0 {
+			return protocol.NewErrorResponse(fmt.Sprintf("invalid window %q", args[0]))
+		}
+		window = time.Duration(seconds) * time.Second
+	}
+	band := ""
+	if len(args) > 1 {
+		band = strings.ToLower(args[1])
+	}
+
+	e.msgMutex.RLock()
+	defer e.msgMutex.RUnlock()
+	if e.messageStore == nil {
+		return protocol.NewErrorResponse("message storage not available")
+	}
+
+	heard, err := e.messageStore.GetHeardStations(time.Now().Add(-window))
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to get heard stations: %v", err))
+	}
+
+	features := []map[string]interface{}{}
+	unplaced := 0
+	for _, h := range heard {
+		stationBand := protocol.Band(h.Frequency)
+		if band != "" && stationBand != band {
+			continue
+		}
+
+		lat, lon, ok := dsp.GridCenter(h.Grid)
+		if !ok {
+			lat, lon, ok = h.Latitude, h.Longitude, h.Latitude != 0 || h.Longitude != 0
+		}
+		if !ok {
+			unplaced++
+			continue
+		}
+
+		properties := map[string]interface{}{
+			"callsign":   h.Callsign,
+			"grid":       h.Grid,
+			"snr":        h.SNR,
+			"last_heard": h.LastHeard,
+			"band":       stationBand,
+			"frequency":  h.Frequency,
+			"count":      h.Count,
+			"worked":     h.Worked,
+		}
+		if path := e.pathTo(h.Grid); path != nil {
+			properties["distance"] = path.Distance
+			properties["bearing"] = path.Bearing
+		}
+
+		features = append(features, map[string]interface{}{
+			"type": "Feature",
+			"geometry": map[string]interface{}{
+				"type":        "Point",
+				"coordinates": []float64{lon, lat}, // GeoJSON order
+			},
+			"properties": properties,
+		})
+	}
+
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"type":     "FeatureCollection",
+		"features": features,
+		"window":   int(window.Seconds()),
+		"unplaced": unplaced,
+	})
+}
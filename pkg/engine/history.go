@@ -0,0 +1,189 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/storage"
+)
+
+// statsSampleInterval is how often the noise floor is sampled for the
+// per-minute stats history
+const statsSampleInterval = 10 * time.Second
+
+// statsRecorder snapshots each minute's decodes and average noise floor into
+// the stats history, adds the noise floor to the band noise of the band the
+// rig was on, and rolls old minutes up into hours once an hour
+func (e *CoreEngine) statsRecorder() {
+	ticker := time.NewTicker(statsSampleInterval)
+	defer ticker.Stop()
+
+	minute := time.Now().Truncate(time.Minute)
+	noiseTotal, noiseSamples := 0.0, 0
+	bands := map[string]*bandSamples{}
+	var lastRollup time.Time
+
+	for {
+		select {
+		case now := <-ticker.C:
+			if current := now.Truncate(time.Minute); current.After(minute) {
+				var noise *float64
+				if noiseSamples > 0 {
+					average := noiseTotal / float64(noiseSamples)
+					noise = &average
+				}
+				e.recordStatsMinute(minute, noise)
+				e.recordBandNoise(minute, bands)
+				minute, noiseTotal, noiseSamples = current, 0, 0
+				bands = map[string]*bandSamples{}
+
+				if now.Sub(lastRollup) >= time.Hour {
+					e.rollupStats(now)
+					lastRollup = now
+				}
+			}
+
+			if floor, ok := e.noiseFloor(); ok {
+				noiseTotal += floor
+				noiseSamples++
+				e.sampleBandNoise(bands, floor)
+			}
+
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// noiseFloor averages the noise floor of the RX channels
+func (e *CoreEngine) noiseFloor() (float64, bool) {
+	total, count := 0.0, 0
+	for _, monitor := range e.audioMonitors {
+		if floor, ok := monitor.NoiseFloor(2 * statsSampleInterval); ok {
+			total += float64(floor)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return total / float64(count), true
+}
+
+// recordStatsMinute stores one minute of the stats history
+func (e *CoreEngine) recordStatsMinute(minute time.Time, noise *float64) {
+	e.msgMutex.Lock()
+	defer e.msgMutex.Unlock()
+	if e.messageStore == nil {
+		return
+	}
+	if err := e.messageStore.RecordStatsMinute(minute, noise); err != nil {
+		logger.Warnf("%v", err)
+	}
+}
+
+// rollupStats folds minutes older than storage stats_minute_hours into
+// hours and drops hours and band noise older than stats_hour_days, and SNR
+// history older than snr_history_days
+func (e *CoreEngine) rollupStats(now time.Time) {
+	minuteRetention, hourRetention := e.statsRetention()
+
+	e.msgMutex.Lock()
+	defer e.msgMutex.Unlock()
+	if e.messageStore == nil {
+		return
+	}
+
+	// Whole hours only, so an hour is never split between the two
+	rolledUp, err := e.messageStore.RollupStats(now.Add(-minuteRetention).Truncate(time.Hour), now.Add(-hourRetention))
+	if err != nil {
+		logger.Warnf("%v", err)
+	} else if rolledUp > 0 {
+		logger.Debugf("Rolled %d minutes of stats up into hours", rolledUp)
+	}
+
+	snrRetention := storage.DefaultSNRHistoryRetention
+	if d := e.config.Storage.SNRHistoryDays; d > 0 {
+		snrRetention = time.Duration(d) * 24 * time.Hour
+	}
+	pruned, err := e.messageStore.PruneSNRHistory(now.Add(-snrRetention))
+	if err != nil {
+		logger.Warnf("%v", err)
+	} else if pruned > 0 {
+		logger.Debugf("Removed %d decodes from the SNR history", pruned)
+	}
+
+	telemetryRetention := storage.DefaultTelemetryRetention
+	if d := e.config.Telemetry.KeepDays; d > 0 {
+		telemetryRetention = time.Duration(d) * 24 * time.Hour
+	}
+	pruned, err = e.messageStore.PruneTelemetry(now.Add(-telemetryRetention))
+	if err != nil {
+		logger.Warnf("%v", err)
+	} else if pruned > 0 {
+		logger.Debugf("Removed %d telemetry readings", pruned)
+	}
+
+	pruned, err = e.messageStore.PruneBandNoise(now.Add(-hourRetention))
+	if err != nil {
+		logger.Warnf("%v", err)
+	} else if pruned > 0 {
+		logger.Debugf("Removed %d hours of band noise", pruned)
+	}
+}
+
+// statsRetention returns how long minute and hour stats are kept
+func (e *CoreEngine) statsRetention() (minutes, hours time.Duration) {
+	minutes, hours = storage.DefaultStatsMinuteRetention, storage.DefaultStatsHourRetention
+	if h := e.config.Storage.StatsMinuteHours; h > 0 {
+		minutes = time.Duration(h) * time.Hour
+	}
+	if d := e.config.Storage.StatsHourDays; d > 0 {
+		hours = time.Duration(d) * 24 * time.Hour
+	}
+	return minutes, hours
+}
+
+// handleGetStatsSeries handles GET_STATS_SERIES resolution from to, with
+// Unix times. An "auto" resolution is per minute when the minutes are still
+// kept and hourly otherwise.
+func (e *CoreEngine) handleGetStatsSeries(args []string) *protocol.Response {
+	if len(args) < 3 {
+		return protocol.NewErrorResponse("usage: GET_STATS_SERIES <1m|1h|auto> <from> <to>")
+	}
+	from, err1 := strconv.ParseInt(args[1], 10, 64)
+	to, err2 := strconv.ParseInt(args[2], 10, 64)
+	if err1 != nil || err2 != nil || to <= from {
+		return protocol.NewErrorResponse(fmt.Sprintf("invalid time range %s to %s", args[1], args[2]))
+	}
+	start, end := time.Unix(from, 0), time.Unix(to, 0)
+
+	resolution := args[0]
+	if resolution == "auto" {
+		minuteRetention, _ := e.statsRetention()
+		resolution = storage.StatsHour
+		if start.After(time.Now().Add(-minuteRetention)) {
+			resolution = storage.StatsMinute
+		}
+	}
+
+	e.msgMutex.RLock()
+	defer e.msgMutex.RUnlock()
+	if e.messageStore == nil {
+		return protocol.NewErrorResponse("message storage not available")
+	}
+
+	points, err := e.messageStore.GetStatsSeries(resolution, start, end)
+	if err != nil {
+		return protocol.NewErrorResponse(err.Error())
+	}
+
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"resolution": resolution,
+		"from":       start.UTC(),
+		"to":         end.UTC(),
+		"points":     points,
+	})
+}
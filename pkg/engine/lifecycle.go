@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/release"
+)
+
+// lifecycleDelay lets the reply to RESTART or REBOOT reach the client
+// before the daemon starts to shut down
+const lifecycleDelay = 500 * time.Millisecond
+
+// Lifecycle restarts the daemon and reboots the host it runs on, for
+// remotely managed stations. Both stop every engine gracefully first, so a
+// transmission in progress finishes and PTT is dropped.
+type Lifecycle interface {
+	// Restart stops the daemon and starts it again in the same process
+	Restart()
+
+	// CanReboot returns why the host can't be rebooted, or nil if it can
+	CanReboot() error
+
+	// Reboot stops the daemon and reboots the host
+	Reboot()
+}
+
+// SetLifecycle lets admins restart the daemon and reboot the host. It must
+// be called before Start; without one RESTART and REBOOT fail.
+func (e *CoreEngine) SetLifecycle(l Lifecycle) {
+	e.lifecycle = l
+}
+
+// SetVersion sets the js8d version reported in the status and checked
+// against the latest release. It must be called before Start.
+func (e *CoreEngine) SetVersion(version string) {
+	e.version = version
+}
+
+// handleRestart restarts the daemon once the reply is sent
+func (e *CoreEngine) handleRestart() *protocol.Response {
+	if e.lifecycle == nil {
+		return protocol.NewErrorResponse("restarting is not available")
+	}
+
+	logger.Infof("Restart requested")
+	time.AfterFunc(lifecycleDelay, e.lifecycle.Restart)
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"status": "restarting",
+	})
+}
+
+// handleReboot reboots the host once the reply is sent, if js8d is allowed
+// to
+func (e *CoreEngine) handleReboot() *protocol.Response {
+	if e.lifecycle == nil {
+		return protocol.NewErrorResponse("rebooting is not available")
+	}
+	if err := e.lifecycle.CanReboot(); err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("cannot reboot: %v", err))
+	}
+
+	logger.Warnf("Host reboot requested")
+	time.AfterFunc(lifecycleDelay, e.lifecycle.Reboot)
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"status": "rebooting",
+	})
+}
+
+// handleCheckUpdate compares the running version with the latest release
+func (e *CoreEngine) handleCheckUpdate() *protocol.Response {
+	ctx, cancel := context.WithTimeout(e.ctx, 15*time.Second)
+	defer cancel()
+
+	latest, err := release.Latest(ctx, &http.Client{}, e.releaseURL)
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to check for updates: %v", err))
+	}
+
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"current":          e.version,
+		"latest":           latest,
+		"update_available": release.Newer(e.version, latest.Version),
+	})
+}
@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/udplog"
+)
+
+// startLogBroadcast sets up sending logged QSOs to logging programs over
+// UDP, when there are any to send to
+func (e *CoreEngine) startLogBroadcast() {
+	cfg := e.config.LogBroadcast
+	if len(cfg.Targets) == 0 {
+		return
+	}
+
+	targets := make([]udplog.Target, len(cfg.Targets))
+	for i, target := range cfg.Targets {
+		targets[i] = udplog.Target{Address: target.Address, Format: strings.ToLower(target.Format)}
+	}
+	broadcaster, err := udplog.New(targets)
+	if err != nil {
+		logger.Errorf("Log broadcast disabled: %v", err)
+		return
+	}
+	e.logBroadcast = broadcaster
+	logger.Infof("Sending logged QSOs to %d logging programs", len(targets))
+}
+
+// broadcastQSO sends a logged QSO to the logging programs, as an update
+// when it extends one sent before. The caller holds msgMutex.
+func (e *CoreEngine) broadcastQSO(id int64, update bool) {
+	if e.logBroadcast == nil {
+		return
+	}
+	qso, err := e.messageStore.GetQSO(id)
+	if err != nil || qso == nil {
+		logger.Warnf("Failed to read QSO %d for the log broadcast: %v", id, err)
+		return
+	}
+
+	contact := udplog.Contact{
+		ID:        qso.ID,
+		Callsign:  qso.Callsign,
+		Start:     qso.Start,
+		Band:      qso.Band,
+		Frequency: qso.Frequency,
+		Mode:      qso.Mode,
+		Grid:      qso.Grid,
+		SNR:       qso.SNR,
+		Station:   e.config.Station.Callsign,
+		Record:    e.adifRecord(*qso),
+	}
+	if entity := e.lookupEntity(qso.Callsign); entity != nil {
+		contact.Prefix = entity.Prefix
+		contact.Continent = entity.Continent
+		contact.CQZone = entity.CQZone
+	}
+	if err := e.logBroadcast.Send(contact, update); err != nil {
+		logger.Warnf("Log broadcast of the QSO with %s: %v", qso.Callsign, err)
+	}
+}
@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/logging"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// handleLogLevel reports the log level of every component, or changes the
+// level of one, or of all of them when no component is named. Changes last
+// until js8d restarts.
+func (e *CoreEngine) handleLogLevel(cmd *protocol.Command) *protocol.Response {
+	global := logging.GetGlobalLogger()
+
+	if name := cmd.StringArg("level"); name != "" {
+		level, err := logging.ParseLevel(name)
+		if err != nil {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, err.Error())
+		}
+		component := strings.ToLower(cmd.StringArg("component"))
+		if component != "" && !logging.ValidComponent(component) {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid,
+				fmt.Sprintf("unknown component %q, use one of %s", component, strings.Join(logging.Components, ", ")))
+		}
+
+		global.SetLevel(component, level)
+		if component == "" {
+			component = "every component"
+		}
+		logger.Infof("Log level of %s set to %s", component, strings.ToLower(level.String()))
+	}
+
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"levels": global.Levels(),
+	})
+}
@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/callbook"
+	"github.com/dougsko/js8d/pkg/storage"
+)
+
+// lookupInterval spaces callbook queries to stay well within the services'
+// rate limits when a busy band brings many new stations at once
+const lookupInterval = 2 * time.Second
+
+// lookupTimeout bounds one callbook query, including a new login
+const lookupTimeout = 15 * time.Second
+
+// startLookups looks heard stations up in the callbook when one is
+// configured
+func (e *CoreEngine) startLookups() {
+	lookup := e.config.Lookup
+	if lookup.Service == "" {
+		return
+	}
+
+	client, err := callbook.New(lookup.Service, lookup.Username, lookup.Password.Value())
+	if err != nil {
+		logger.Errorf("Callbook lookups disabled: %v", err)
+		return
+	}
+	e.callbook = client
+	e.startLoop("lookup", e.lookupLoop)
+	logger.Infof("Looking up heard stations on %s", lookup.Service)
+}
+
+// queueLookup asks for a station to be looked up unless the callbook was
+// asked about it within lookup cache_days. The caller holds msgMutex.
+func (e *CoreEngine) queueLookup(callsign string) {
+	if e.callbook == nil {
+		return
+	}
+
+	needed, err := e.messageStore.NeedsLookup(callsign, e.lookupTTL())
+	if err != nil {
+		logger.Warnf("%v", err)
+		return
+	}
+	if !needed {
+		return
+	}
+
+	select {
+	case e.lookups <- callsign:
+	default:
+		// The station is queued again the next time it is heard
+	}
+}
+
+// lookupLoop queries the callbook for queued stations one at a time
+func (e *CoreEngine) lookupLoop() {
+	for {
+		select {
+		case callsign := <-e.lookups:
+			e.lookupStation(callsign)
+
+			select {
+			case <-time.After(lookupInterval):
+			case <-e.ctx.Done():
+				return
+			}
+
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// lookupStation queries the callbook for one station and stores the result
+func (e *CoreEngine) lookupStation(callsign string) {
+	// A station heard twice before its lookup is queued twice
+	e.msgMutex.RLock()
+	needed := false
+	if e.messageStore != nil {
+		needed, _ = e.messageStore.NeedsLookup(callsign, e.lookupTTL())
+	}
+	e.msgMutex.RUnlock()
+	if !needed {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(e.ctx, lookupTimeout)
+	record, err := e.callbook.Lookup(ctx, callsign)
+	cancel()
+
+	var entry *storage.CallbookEntry
+	switch {
+	case err == callbook.ErrNotFound:
+		logger.Debugf("%s is not in the callbook", callsign)
+	case err != nil:
+		// Not recorded, so the station is tried again when next heard
+		logger.Warnf("Callbook lookup of %s failed: %v", callsign, err)
+		return
+	default:
+		entry = &storage.CallbookEntry{
+			Name:      record.Name,
+			Country:   record.Country,
+			State:     record.State,
+			Grid:      record.Grid,
+			Latitude:  record.Latitude,
+			Longitude: record.Longitude,
+		}
+		logger.Debugf("Callbook: %s is %s, %s", callsign, record.Name, record.Country)
+	}
+
+	e.msgMutex.Lock()
+	defer e.msgMutex.Unlock()
+	if e.messageStore == nil {
+		return
+	}
+	if err := e.messageStore.SetCallbookEntry(callsign, entry); err != nil {
+		logger.Warnf("%v", err)
+	}
+}
+
+// lookupTTL is how long a callbook lookup is kept
+func (e *CoreEngine) lookupTTL() time.Duration {
+	return time.Duration(e.config.Lookup.CacheDays) * 24 * time.Hour
+}
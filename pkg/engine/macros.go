@@ -0,0 +1,222 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/storage"
+)
+
+// handleMacro handles the MACRO command, which lists, shows, saves, deletes
+// or tries out the operator's message macros
+func (e *CoreEngine) handleMacro(cmd *protocol.Command) *protocol.Response {
+	action := cmd.MacroAction()
+	name := strings.ToUpper(strings.TrimSpace(cmd.StringArg("name")))
+
+	if action == protocol.MacroExpand {
+		to := strings.ToUpper(strings.TrimSpace(cmd.StringArg("to")))
+		text, err := e.expandMacros(to, cmd.StringArg("text"))
+		if err != nil {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, err.Error())
+		}
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"text": text,
+		})
+	}
+
+	switch action {
+	case protocol.MacroList:
+	case protocol.MacroGet, protocol.MacroDelete:
+		if name == "" {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "macro name required")
+		}
+	case protocol.MacroSet:
+		if !protocol.ValidMacroName(name) {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid,
+				fmt.Sprintf("invalid macro name %q, use up to %d letters, digits and underscores other than a token", name, protocol.MaxMacroName))
+		}
+		if strings.TrimSpace(cmd.StringArg("text")) == "" {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "macro text cannot be empty")
+		}
+	default:
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("unknown MACRO action %q", action))
+	}
+
+	if action == protocol.MacroSet || action == protocol.MacroDelete {
+		e.msgMutex.Lock()
+		defer e.msgMutex.Unlock()
+	} else {
+		e.msgMutex.RLock()
+		defer e.msgMutex.RUnlock()
+	}
+	if e.messageStore == nil {
+		return protocol.NewErrorResponse("message storage not available")
+	}
+
+	switch action {
+	case protocol.MacroList:
+		macros, err := e.messageStore.GetMacros()
+		if err != nil {
+			return protocol.NewErrorResponse(fmt.Sprintf("failed to get macros: %v", err))
+		}
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"macros": macros,
+			"count":  len(macros),
+		})
+
+	case protocol.MacroSet:
+		macro, err := e.messageStore.SaveMacro(name, cmd.StringArg("text"))
+		if err != nil {
+			return protocol.NewErrorResponse(fmt.Sprintf("failed to save macro: %v", err))
+		}
+		logger.Infof("Macro %s saved", name)
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"macro": macro,
+		})
+
+	case protocol.MacroDelete:
+		deleted, err := e.messageStore.DeleteMacro(name)
+		if err != nil {
+			return protocol.NewErrorResponse(fmt.Sprintf("failed to delete macro: %v", err))
+		}
+		if !deleted {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("no macro named %s", name))
+		}
+		logger.Infof("Macro %s deleted", name)
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"deleted": name,
+		})
+	}
+
+	macro, err := e.messageStore.GetMacro(name)
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to get macro: %v", err))
+	}
+	if macro == nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("no macro named %s", name))
+	}
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"macro": macro,
+	})
+}
+
+// expandMacros fills in the saved macros named in a message, then the
+// tokens in the result, for a message to a station or to nobody. Macros
+// are not expanded inside macros, so they can't refer to each other in a
+// loop.
+func (e *CoreEngine) expandMacros(to, text string) (string, error) {
+	if !protocol.MacroPattern.MatchString(text) {
+		return text, nil
+	}
+
+	e.msgMutex.RLock()
+	defer e.msgMutex.RUnlock()
+
+	text, err := replaceMacros(text, func(name string) (string, bool, error) {
+		for _, token := range protocol.MacroTokens {
+			if name == token {
+				return "", false, nil
+			}
+		}
+		if e.messageStore == nil {
+			return "", false, fmt.Errorf("message storage not available for macro {%s}", name)
+		}
+		macro, err := e.messageStore.GetMacro(name)
+		if err != nil {
+			return "", false, err
+		}
+		if macro == nil {
+			return "", false, fmt.Errorf("no macro or token named {%s}", name)
+		}
+		return macro.Text, true, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// The station is only looked up for the tokens that need it
+	var station *storage.Station
+	lookup := func(token string) (*storage.Station, error) {
+		if to == "" || strings.HasPrefix(to, "@") {
+			return nil, fmt.Errorf("{%s} needs a message to a station", token)
+		}
+		if station == nil && e.messageStore != nil {
+			found, err := e.messageStore.GetStation(to)
+			if err != nil {
+				return nil, err
+			}
+			station = found
+		}
+		if station == nil {
+			return nil, fmt.Errorf("{%s} needs %s in the station database", token, to)
+		}
+		return station, nil
+	}
+
+	return replaceMacros(text, func(name string) (string, bool, error) {
+		switch name {
+		case protocol.TokenMyCall:
+			return e.config.Station.Callsign, true, nil
+		case protocol.TokenMyGrid:
+			if e.config.Station.Grid == "" {
+				return "", false, fmt.Errorf("{%s} needs station.grid set", name)
+			}
+			return e.config.Station.Grid, true, nil
+		case protocol.TokenCall:
+			if to == "" {
+				return "", false, fmt.Errorf("{%s} needs a message to a station", name)
+			}
+			return to, true, nil
+		case protocol.TokenGrid:
+			station, err := lookup(name)
+			if err != nil {
+				return "", false, err
+			}
+			if station.Grid == "" {
+				return "", false, fmt.Errorf("the grid of %s is not known", to)
+			}
+			return station.Grid, true, nil
+		case protocol.TokenSNR:
+			station, err := lookup(name)
+			if err != nil {
+				return "", false, err
+			}
+			if station.HeardCount == 0 {
+				return "", false, fmt.Errorf("%s has not been heard", to)
+			}
+			return dsp.FormatSNR(int(station.LastSNR)), true, nil
+		case protocol.TokenTime:
+			return time.Now().UTC().Format("1504"), true, nil
+		case protocol.TokenVolts:
+			voltage, ok := e.batteryVoltage()
+			if !ok {
+				return "", false, fmt.Errorf("{%s} needs a battery voltage from the sensors", name)
+			}
+			return fmt.Sprintf("%.1fV", voltage), true, nil
+		}
+		return "", false, fmt.Errorf("macro {%s} is used inside another macro, which is not supported", name)
+	})
+}
+
+// replaceMacros replaces each name in braces with what value returns for
+// it, leaving it in place when value reports false
+func replaceMacros(text string, value func(name string) (string, bool, error)) (string, error) {
+	var failed error
+	text = protocol.MacroPattern.ReplaceAllStringFunc(text, func(match string) string {
+		if failed != nil {
+			return match
+		}
+		replacement, ok, err := value(strings.ToUpper(match[1 : len(match)-1]))
+		if err != nil {
+			failed = err
+		}
+		if !ok {
+			return match
+		}
+		return replacement
+	})
+	return text, failed
+}
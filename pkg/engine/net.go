@@ -0,0 +1,487 @@
+package engine
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/storage"
+)
+
+// netCheckInterval is how often the net schedule and the running net's
+// closing time are checked
+const netCheckInterval = 15 * time.Second
+
+// autoNet is the acknowledgement of a station checking in to a net, held
+// back by the block and allow lists alongside the rate limited kinds
+const autoNet = "net"
+
+// netSession is the net running from this station
+type netSession struct {
+	net    storage.Net
+	closes time.Time // When it closes unless NET CLOSE comes first
+}
+
+// netLoop opens nets as the schedule comes due and closes them once their
+// duration is up
+func (e *CoreEngine) netLoop() {
+	ticker := time.NewTicker(netCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		e.checkNet(time.Now())
+
+		select {
+		case <-ticker.C:
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkNet closes the running net when its time is up, or opens one when
+// the schedule has a start since the last check. Starts missed while js8d
+// was stopped are not made up.
+func (e *CoreEngine) checkNet(now time.Time) {
+	e.netMutex.Lock()
+	from := e.netChecked
+	e.netChecked = now
+	running := e.net
+	e.netMutex.Unlock()
+
+	if running != nil {
+		if !now.Before(running.closes) {
+			if _, err := e.closeNet(now); err != nil {
+				logger.Warnf("Failed to close the %s net: %v", running.net.Group, err)
+			}
+		}
+		return
+	}
+
+	e.mutex.RLock()
+	due := !from.IsZero() && e.config.NetDue(from, now)
+	e.mutex.RUnlock()
+	if !due {
+		return
+	}
+	if _, err := e.openNet("", now); err != nil {
+		logger.Warnf("Failed to open the scheduled net: %v", err)
+	}
+}
+
+// openNet opens a net on group, or net group when empty, and queues the
+// preamble to it. The net closes after net duration unless closed first.
+func (e *CoreEngine) openNet(group string, now time.Time) (*storage.Net, error) {
+	e.mutex.RLock()
+	cfg := e.config.Net
+	e.mutex.RUnlock()
+
+	if group == "" {
+		group = cfg.Group
+	}
+	group = strings.ToUpper(group)
+	if group == "" {
+		return nil, fmt.Errorf("no net group given or set in net group")
+	}
+	if !config.ValidNetGroup(group) {
+		return nil, fmt.Errorf("invalid net group %q, use @ and letters or digits such as @ARESNET", group)
+	}
+
+	e.netMutex.Lock()
+	defer e.netMutex.Unlock()
+	if e.net != nil {
+		return nil, fmt.Errorf("the %s net is already open", e.net.net.Group)
+	}
+
+	session := &netSession{
+		net: storage.Net{
+			Group:     group,
+			Frequency: e.dialFrequency(),
+			Opened:    now,
+		},
+		closes: now.Add(time.Duration(cfg.Duration) * time.Minute),
+	}
+	e.msgMutex.Lock()
+	if e.messageStore == nil {
+		e.msgMutex.Unlock()
+		return nil, fmt.Errorf("message storage not available")
+	}
+	err := e.messageStore.OpenNet(&session.net)
+	e.msgMutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	e.net = session
+	logger.Infof("Net %d opened on %s until %s", session.net.ID, group, session.closes.Format("15:04"))
+	e.sendToNet(group, cfg.Preamble)
+	e.publishNet(protocol.NetOpened, session.net)
+	net := session.net
+	return &net, nil
+}
+
+// closeNet closes the running net, queues the closing message to its
+// group and writes its check-ins to net export_dir
+func (e *CoreEngine) closeNet(now time.Time) (*storage.Net, error) {
+	e.mutex.RLock()
+	cfg := e.config.Net
+	e.mutex.RUnlock()
+
+	e.netMutex.Lock()
+	defer e.netMutex.Unlock()
+	session := e.net
+	if session == nil {
+		return nil, fmt.Errorf("no net is open")
+	}
+	e.net = nil
+
+	e.msgMutex.Lock()
+	var net *storage.Net
+	err := fmt.Errorf("message storage not available")
+	if e.messageStore != nil {
+		if err = e.messageStore.CloseNet(session.net.ID, now); err == nil {
+			net, err = e.messageStore.GetNet(session.net.ID)
+		}
+	}
+	e.msgMutex.Unlock()
+	if err == nil && net == nil {
+		err = fmt.Errorf("net %d is not in the database", session.net.ID)
+	}
+
+	e.sendToNet(session.net.Group, cfg.Closing)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("Net %d on %s closed with %d check-ins", net.ID, net.Group, net.Count)
+
+	if cfg.ExportDir != "" {
+		if path, err := exportNet(cfg.ExportDir, net); err != nil {
+			logger.Warnf("Failed to export net %d: %v", net.ID, err)
+		} else {
+			logger.Infof("Net %d check-ins written to %s", net.ID, path)
+		}
+	}
+	e.publishNet(protocol.NetClosed, *net)
+	return net, nil
+}
+
+// sendToNet queues a message to a net's group, with its macros and tokens
+// filled in. The caller holds netMutex.
+func (e *CoreEngine) sendToNet(group, text string) {
+	if text == "" {
+		return
+	}
+	text, err := e.expandMacros(group, text)
+	if err != nil {
+		logger.Warnf("Not sending to the %s net: %v", group, err)
+		return
+	}
+	msg := protocol.Message{
+		ID:        int(time.Now().Unix()),
+		Timestamp: time.Now(),
+		From:      e.config.Station.Callsign,
+		To:        group,
+		Message:   group + " " + text,
+		Mode:      "JS8",
+	}
+	if _, ok := e.queueTX(msg); !ok {
+		logger.Warnf("TX queue full, dropping the message to the %s net", group)
+	}
+}
+
+// netAddressed reports whether a decode calls the net: a directed message
+// to its group or to us that isn't a CQ
+func netAddressed(msg protocol.Message, group, callsign string) bool {
+	if isCQ(msg) {
+		return false
+	}
+	if strings.EqualFold(msg.To, group) || (callsign != "" && strings.EqualFold(msg.To, callsign)) {
+		return true
+	}
+	for _, word := range strings.Fields(strings.ToUpper(msg.Message)) {
+		if strings.Trim(word, ".,:;!?") == group {
+			return true
+		}
+	}
+	return false
+}
+
+// netCheckin checks in the station sending a decode that calls the
+// running net. A station checking in for the first time is answered with
+// net ack while automatic replies are on.
+func (e *CoreEngine) netCheckin(msg protocol.Message) {
+	callsign := e.config.Station.Callsign
+	if msg.From == "" || msg.From == "UNKNOWN" || strings.EqualFold(msg.From, callsign) {
+		return
+	}
+
+	e.netMutex.Lock()
+	session := e.net
+	if session == nil || !netAddressed(msg, session.net.Group, callsign) {
+		e.netMutex.Unlock()
+		return
+	}
+	checkin := storage.NetCheckin{
+		Callsign: msg.From,
+		Time:     msg.Timestamp,
+		Last:     msg.Timestamp,
+		SNR:      msg.SNR,
+		Grid:     heardGrid(msg),
+		Message:  msg.Message,
+		Messages: 1,
+	}
+	e.msgMutex.Lock()
+	var first bool
+	err := fmt.Errorf("message storage not available")
+	if e.messageStore != nil {
+		first, err = e.messageStore.RecordCheckin(session.net.ID, &checkin)
+	}
+	e.msgMutex.Unlock()
+	net := session.net
+	e.netMutex.Unlock()
+
+	if err != nil {
+		logger.Warnf("Failed to check %s in to the %s net: %v", msg.From, net.Group, err)
+		return
+	}
+	if !first {
+		return
+	}
+	logger.Infof("%s checked in to the %s net", checkin.Callsign, net.Group)
+	e.publishEvent(protocol.EventNet, map[string]interface{}{
+		"state":   protocol.NetCheckin,
+		"net":     net,
+		"checkin": checkin,
+	})
+
+	if !e.autoReplyEnabled() || e.config.Net.Ack == "" ||
+		e.autoListRefusal(autoNet, checkin.Callsign) != "" || !e.rateAllowed(rateReply, checkin.Callsign) {
+		return
+	}
+	text, err := e.expandMacros(checkin.Callsign, e.config.Net.Ack)
+	if err != nil {
+		logger.Warnf("Not acknowledging %s on the %s net: %v", checkin.Callsign, net.Group, err)
+		return
+	}
+	reply := protocol.Message{
+		ID:        int(time.Now().Unix()),
+		Timestamp: time.Now(),
+		From:      callsign,
+		To:        checkin.Callsign,
+		Message:   text,
+		Mode:      "JS8",
+	}
+	if _, ok := e.queueTX(reply); !ok {
+		logger.Warnf("TX queue full, dropping the net acknowledgement to %s", checkin.Callsign)
+	}
+}
+
+// publishNet tells event subscribers a net opened or closed
+func (e *CoreEngine) publishNet(state string, net storage.Net) {
+	e.publishEvent(protocol.EventNet, map[string]interface{}{
+		"state": state,
+		"net":   net,
+	})
+}
+
+// closeOpenNets closes the nets js8d stopped without closing
+func (e *CoreEngine) closeOpenNets() {
+	e.msgMutex.Lock()
+	defer e.msgMutex.Unlock()
+	if e.messageStore == nil {
+		return
+	}
+	closed, err := e.messageStore.CloseOpenNets(time.Now())
+	if err != nil {
+		logger.Warnf("Failed to close the nets left open: %v", err)
+	} else if closed > 0 {
+		logger.Infof("Closed %d nets left open when js8d stopped", closed)
+	}
+}
+
+// writeNetCSV writes a net's check-ins as CSV, one station a row in the
+// order they checked in
+func writeNetCSV(w io.Writer, net *storage.Net) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"callsign", "checked_in", "last_heard", "snr", "grid", "messages", "message"})
+	for _, c := range net.Checkins {
+		out.Write([]string{
+			c.Callsign,
+			c.Time.UTC().Format(time.RFC3339),
+			c.Last.UTC().Format(time.RFC3339),
+			strconv.FormatFloat(float64(c.SNR), 'f', -1, 32),
+			c.Grid,
+			strconv.Itoa(c.Messages),
+			c.Message,
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// netExportName is the file a net's check-ins are exported to
+func netExportName(net *storage.Net) string {
+	return fmt.Sprintf("net-%d-%s-%s.csv", net.ID, strings.TrimPrefix(net.Group, "@"), net.Opened.Format("20060102"))
+}
+
+// exportNet writes a net's check-ins to a CSV file in dir, returning its path
+func exportNet(dir string, net *storage.Net) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, netExportName(net))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := writeNetCSV(f, net); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// handleNet handles the NET command: the running net with its check-ins,
+// NET OPEN [@GROUP], NET CLOSE, NET LIST [limit], NET GET <id> and
+// NET EXPORT <id>
+func (e *CoreEngine) handleNet(args []string) *protocol.Response {
+	action := ""
+	if len(args) > 0 {
+		action = strings.ToUpper(args[0])
+	}
+
+	switch action {
+	case "":
+		return e.netStatus()
+
+	case "OPEN":
+		group := ""
+		if len(args) > 1 {
+			group = args[1]
+		}
+		net, err := e.openNet(group, time.Now())
+		if err != nil {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, err.Error())
+		}
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"net": net,
+		})
+
+	case "CLOSE":
+		e.netMutex.Lock()
+		open := e.net != nil
+		e.netMutex.Unlock()
+		if !open {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "no net is open")
+		}
+		net, err := e.closeNet(time.Now())
+		if err != nil {
+			return protocol.NewErrorResponse(fmt.Sprintf("failed to close the net: %v", err))
+		}
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"net": net,
+		})
+
+	case "LIST":
+		limit := 20
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "usage: NET LIST [limit]")
+			}
+			limit = n
+		}
+		e.msgMutex.RLock()
+		if e.messageStore == nil {
+			e.msgMutex.RUnlock()
+			return protocol.NewErrorResponse("message storage not available")
+		}
+		nets, err := e.messageStore.GetNets(limit)
+		e.msgMutex.RUnlock()
+		if err != nil {
+			return protocol.NewErrorResponse(fmt.Sprintf("failed to list nets: %v", err))
+		}
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"nets":  nets,
+			"count": len(nets),
+		})
+
+	case "GET", "EXPORT":
+		var id int64
+		if len(args) > 1 {
+			id, _ = strconv.ParseInt(args[1], 10, 64)
+		}
+		if id <= 0 {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("usage: NET %s <id>", action))
+		}
+		e.msgMutex.RLock()
+		if e.messageStore == nil {
+			e.msgMutex.RUnlock()
+			return protocol.NewErrorResponse("message storage not available")
+		}
+		net, err := e.messageStore.GetNet(id)
+		e.msgMutex.RUnlock()
+		if err != nil {
+			return protocol.NewErrorResponse(fmt.Sprintf("failed to get net %d: %v", id, err))
+		}
+		if net == nil {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("no net %d", id))
+		}
+		if action == "GET" {
+			return protocol.NewSuccessResponse(map[string]interface{}{
+				"net": net,
+			})
+		}
+
+		var b strings.Builder
+		if err := writeNetCSV(&b, net); err != nil {
+			return protocol.NewErrorResponse(fmt.Sprintf("failed to write CSV: %v", err))
+		}
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"csv":      b.String(),
+			"filename": netExportName(net),
+		})
+
+	default:
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid,
+			fmt.Sprintf("unknown NET action %q, use OPEN, CLOSE, LIST, GET or EXPORT", args[0]))
+	}
+}
+
+// netStatus returns the net settings and the running net, if any, with
+// its check-ins so far
+func (e *CoreEngine) netStatus() *protocol.Response {
+	e.mutex.RLock()
+	cfg := e.config.Net
+	e.mutex.RUnlock()
+
+	data := map[string]interface{}{
+		"open":     false,
+		"group":    cfg.Group,
+		"schedule": append([]string{}, cfg.Schedule...),
+		"duration": cfg.Duration,
+	}
+
+	e.netMutex.Lock()
+	defer e.netMutex.Unlock()
+	if e.net == nil {
+		return protocol.NewSuccessResponse(data)
+	}
+	e.msgMutex.RLock()
+	net, err := e.messageStore.GetNet(e.net.net.ID)
+	e.msgMutex.RUnlock()
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to get the net: %v", err))
+	}
+	data["open"] = true
+	data["net"] = net
+	data["closes"] = e.net.closes
+	return protocol.NewSuccessResponse(data)
+}
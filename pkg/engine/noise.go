@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/audio"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// bandSamples adds up the noise floor samples taken on one band
+type bandSamples struct {
+	total   float64
+	samples int
+}
+
+// sampleBandNoise adds a noise floor sample to the band the rig is on,
+// leaving out frequencies outside the amateur bands
+func (e *CoreEngine) sampleBandNoise(bands map[string]*bandSamples, floor float64) {
+	e.mutex.RLock()
+	band := protocol.Band(e.frequency)
+	e.mutex.RUnlock()
+	if band == "" {
+		return
+	}
+
+	b := bands[band]
+	if b == nil {
+		b = &bandSamples{}
+		bands[band] = b
+	}
+	b.total += floor
+	b.samples++
+}
+
+// recordBandNoise stores a minute of noise floor samples by band
+func (e *CoreEngine) recordBandNoise(minute time.Time, bands map[string]*bandSamples) {
+	e.msgMutex.Lock()
+	defer e.msgMutex.Unlock()
+	if e.messageStore == nil {
+		return
+	}
+	for band, b := range bands {
+		if err := e.messageStore.RecordBandNoise(band, minute, b.total, b.samples); err != nil {
+			logger.Warnf("%v", err)
+		}
+	}
+}
+
+// decodeNoiseFloor returns the noise floor of an RX channel's audio to the
+// tenth of a dB, nil when it has not been measured lately
+func decodeNoiseFloor(monitor *audio.AudioLevelMonitor) *float64 {
+	if monitor == nil {
+		return nil
+	}
+	floor, ok := monitor.NoiseFloor(2 * statsSampleInterval)
+	if !ok {
+		return nil
+	}
+	rounded := math.Round(float64(floor)*10) / 10
+	return &rounded
+}
+
+// handleGetBandNoise handles GET_BAND_NOISE from to, with Unix times: the
+// noise floor of each band heard in the range, with the band the rig is on
+// and the noise floor now
+func (e *CoreEngine) handleGetBandNoise(args []string) *protocol.Response {
+	if len(args) < 2 {
+		return protocol.NewErrorResponse("usage: GET_BAND_NOISE <from> <to>")
+	}
+	from, err1 := strconv.ParseInt(args[0], 10, 64)
+	to, err2 := strconv.ParseInt(args[1], 10, 64)
+	if err1 != nil || err2 != nil || to <= from {
+		return protocol.NewErrorResponse(fmt.Sprintf("invalid time range %s to %s", args[0], args[1]))
+	}
+	start, end := time.Unix(from, 0), time.Unix(to, 0)
+
+	e.mutex.RLock()
+	band := protocol.Band(e.frequency)
+	e.mutex.RUnlock()
+
+	data := map[string]interface{}{
+		"from": start.UTC(),
+		"to":   end.UTC(),
+		"band": band,
+	}
+	if floor, ok := e.noiseFloor(); ok {
+		data["noise_floor"] = math.Round(floor*10) / 10
+	}
+
+	e.msgMutex.RLock()
+	defer e.msgMutex.RUnlock()
+	if e.messageStore == nil {
+		return protocol.NewErrorResponse("message storage not available")
+	}
+
+	bands, err := e.messageStore.GetBandNoise(start, end)
+	if err != nil {
+		return protocol.NewErrorResponse(err.Error())
+	}
+	data["bands"] = bands
+	return protocol.NewSuccessResponse(data)
+}
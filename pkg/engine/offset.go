@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"math"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// findWindow is how much spectrum history FIND_OFFSET looks through
+const findWindow = time.Minute
+
+// findStep is how far apart the slots FIND_OFFSET compares start
+const findStep = 10
+
+// findTie is how close in dB slots count as equally quiet, the nearest to
+// the current offset winning
+const findTie = 1.0
+
+// findRange returns the offsets FIND_OFFSET picks from, the defaults when
+// tx find_low and find_high are unset
+func findRange(cfg *config.Config) (int, int) {
+	if cfg.TX.FindLow == 0 && cfg.TX.FindHigh == 0 {
+		return config.DefaultFindLow, config.DefaultFindHigh
+	}
+	return cfg.TX.FindLow, cfg.TX.FindHigh
+}
+
+// quietestOffset returns the offset between low and high whose signal's
+// width heard the least over the spectrum history since the given time,
+// the nearest to current of those within findTie dB, and the strongest
+// level heard there. ok is false without any spectrum.
+func (e *CoreEngine) quietestOffset(low, high, current int, since time.Time) (int, float32, bool) {
+	width := int(dsp.SignalBandwidth)
+	levels := map[int]float32{}
+	quietest := float32(math.MaxFloat32)
+	for offset := low; offset+width <= high; offset += findStep {
+		for _, monitor := range e.GetAudioMonitors() {
+			peak, ok := monitor.BandPeak(float64(offset), float64(offset+width), since)
+			if level, seen := levels[offset]; ok && (!seen || peak > level) {
+				levels[offset] = peak
+			}
+		}
+		if level, ok := levels[offset]; ok && level < quietest {
+			quietest = level
+		}
+	}
+	if len(levels) == 0 {
+		return 0, 0, false
+	}
+
+	best := -1
+	for offset, level := range levels {
+		if level-quietest > findTie {
+			continue
+		}
+		if best < 0 || distance(offset, current) < distance(best, current) ||
+			distance(offset, current) == distance(best, current) && offset < best {
+			best = offset
+		}
+	}
+	return best, levels[best], true
+}
+
+// distance returns how many Hz apart two offsets are
+func distance(a, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// handleFindOffset moves the TX offset to the quietest slot between tx
+// find_low and find_high over the last minute of spectrum, leaving out our
+// own last transmission
+func (e *CoreEngine) handleFindOffset() *protocol.Response {
+	e.mutex.RLock()
+	low, high := findRange(e.config)
+	lastTX := e.lastTXEnd
+	previous := e.txAudioOffset
+	e.mutex.RUnlock()
+
+	since := time.Now().Add(-findWindow)
+	if lastTX.After(since) {
+		since = lastTX
+	}
+	if _, ok := e.dspEngine.(dsp.OffsetEncoder); !ok {
+		return protocol.NewErrorResponse("the DSP engine transmits at a fixed offset")
+	}
+
+	offset, level, ok := e.quietestOffset(low, high, previous, since)
+	if !ok {
+		return protocol.NewErrorResponse("no spectrum heard in the last minute")
+	}
+
+	e.mutex.Lock()
+	e.txAudioOffset = offset
+	e.mutex.Unlock()
+	logger.Infof("TX offset moved from %d Hz to the clearest slot at %d Hz", previous, offset)
+
+	data := map[string]interface{}{
+		"offset":   offset,
+		"previous": previous,
+		"low":      low,
+		"high":     high,
+		"level":    level,
+	}
+	if floor, ok := e.noiseFloor(); ok {
+		data["noise_floor"] = math.Round(floor*10) / 10
+	}
+	return protocol.NewSuccessResponse(data)
+}
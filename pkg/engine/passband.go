@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// minPassband is the narrowest decode passband, a signal's width
+const minPassband = int(dsp.SignalBandwidth)
+
+// configuredPassband returns the dsp decode_low and decode_high settings,
+// the decoders' whole passband when unset
+func configuredPassband(cfg *config.Config) (int, int) {
+	if cfg.DSP.DecodeLow == 0 && cfg.DSP.DecodeHigh == 0 {
+		return int(dsp.MinDecodeHz), int(dsp.MaxDecodeHz)
+	}
+	return cfg.DSP.DecodeLow, cfg.DSP.DecodeHigh
+}
+
+// applyPassband sets the decode passband on every RX decoder that supports
+// one
+func (e *CoreEngine) applyPassband() {
+	e.mutex.RLock()
+	low, high := e.passbandLow, e.passbandHigh
+	decoders := e.rxDecoders
+	e.mutex.RUnlock()
+
+	for _, decoder := range decoders {
+		if passband, ok := decoder.(dsp.PassbandDecoder); ok {
+			passband.SetPassband(float64(low), float64(high))
+		}
+	}
+}
+
+// handlePassband handles PASSBAND [<low> <high>|RESET]: with a range it
+// narrows decoding to those audio offsets until js8d restarts or a reload
+// changes dsp decode_low or decode_high, and RESET goes back to them
+func (e *CoreEngine) handlePassband(args []string) *protocol.Response {
+	if len(args) > 0 {
+		var low, high int
+		if strings.EqualFold(args[0], "RESET") {
+			e.mutex.RLock()
+			low, high = configuredPassband(e.config)
+			e.mutex.RUnlock()
+		} else {
+			if len(args) < 2 {
+				return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "usage: PASSBAND [<low> <high>|RESET]")
+			}
+			var err1, err2 error
+			low, err1 = strconv.Atoi(args[0])
+			high, err2 = strconv.Atoi(args[1])
+			if err1 != nil || err2 != nil {
+				return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("invalid passband %s to %s", args[0], args[1]))
+			}
+			if low < int(dsp.MinDecodeHz) || high > int(dsp.MaxDecodeHz) || high-low < minPassband {
+				return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf(
+					"passband must lie within %.0f-%.0f Hz and be at least %d Hz wide", dsp.MinDecodeHz, dsp.MaxDecodeHz, minPassband))
+			}
+		}
+
+		e.mutex.Lock()
+		e.passbandLow, e.passbandHigh = low, high
+		e.mutex.Unlock()
+		e.applyPassband()
+		dspLogger.Infof("Decoding audio offsets %d-%d Hz", low, high)
+	}
+	return protocol.NewSuccessResponse(e.passbandStatus())
+}
+
+// passbandStatus returns the decode passband in use and the configured one,
+// and whether every RX decoder searches only the passband, which saves CPU
+// rather than just dropping the decodes outside it
+func (e *CoreEngine) passbandStatus() map[string]interface{} {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	configuredLow, configuredHigh := configuredPassband(e.config)
+	searched := true
+	for _, decoder := range e.rxDecoders {
+		if _, ok := decoder.(*dsp.DSP); !ok {
+			searched = false
+		}
+	}
+	return map[string]interface{}{
+		"low":             e.passbandLow,
+		"high":            e.passbandHigh,
+		"configured_low":  configuredLow,
+		"configured_high": configuredHigh,
+		"searched":        searched,
+	}
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"math"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/storage"
+)
+
+// pathTo returns the great-circle path from the station's grid to grid, or
+// nil when either is not a grid square
+func (e *CoreEngine) pathTo(grid string) *protocol.Path {
+	distance, bearing, ok := dsp.GridPath(e.config.Station.Grid, grid)
+	if !ok {
+		return nil
+	}
+	return &protocol.Path{
+		Grid:     grid,
+		Distance: math.Round(distance),
+		Bearing:  math.Round(bearing),
+	}
+}
+
+// otherStation returns the station a message was exchanged with
+func (e *CoreEngine) otherStation(msg protocol.Message) string {
+	if strings.EqualFold(msg.From, e.config.Station.Callsign) {
+		return msg.To
+	}
+	return msg.From
+}
+
+// addPaths sets the path to the other station of each message whose grid
+// is in the station database, and its DXCC entity. The caller holds
+// msgMutex.
+func (e *CoreEngine) addPaths(messages []protocol.Message) {
+	if e.messageStore == nil || len(messages) == 0 {
+		return
+	}
+
+	seen := make(map[string]bool)
+	var callsigns []string
+	for _, msg := range messages {
+		callsign := strings.ToUpper(e.otherStation(msg))
+		if callsign != "" && !seen[callsign] {
+			seen[callsign] = true
+			callsigns = append(callsigns, callsign)
+		}
+	}
+
+	grids, err := e.messageStore.GetStationGrids(callsigns)
+	if err != nil {
+		logger.Warnf("Failed to get station grids: %v", err)
+		return
+	}
+	for i := range messages {
+		callsign := strings.ToUpper(e.otherStation(messages[i]))
+		if grid, ok := grids[callsign]; ok {
+			messages[i].Path = e.pathTo(grid)
+		}
+		messages[i].Entity = e.entityOf(callsign)
+	}
+}
+
+// addStationPaths sets the path to each station with a known grid, and
+// its DXCC entity
+func (e *CoreEngine) addStationPaths(stations []storage.Station) {
+	for i := range stations {
+		stations[i].Path = e.pathTo(stations[i].Grid)
+		stations[i].Entity = e.entityOf(stations[i].Callsign)
+	}
+}
@@ -0,0 +1,206 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// Power modes, as POWER takes and reports them
+const (
+	powerNormal = "normal"
+	powerLow    = "low"
+	powerAuto   = "auto"
+)
+
+// powerCheckInterval is how often the low power schedule is checked
+const powerCheckInterval = time.Minute
+
+// lowPowerSpectrumInterval is how often the spectrum is computed while the
+// waterfall is off, often enough for the noise floor in the stats history
+const lowPowerSpectrumInterval = 5 * time.Second
+
+// In low power mode, during power low_power_hours or after POWER LOW, the
+// node listens only, decodes at a lighter level, blanks the OLED and stops
+// the web spectrum and waterfall, as power configures. POWER NORMAL holds
+// normal mode through the schedule until POWER AUTO.
+
+// powerLoop follows the low power schedule
+func (e *CoreEngine) powerLoop() {
+	ticker := time.NewTicker(powerCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		e.updatePower(time.Now())
+
+		select {
+		case <-ticker.C:
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// updatePower switches low power mode on or off for the override, or else
+// the schedule at now
+func (e *CoreEngine) updatePower(now time.Time) {
+	e.mutex.RLock()
+	scheduled := e.config.InLowPowerHours(now)
+	e.mutex.RUnlock()
+
+	e.lowPowerMutex.Lock()
+	low := scheduled
+	switch e.powerOverride {
+	case powerLow:
+		low = true
+	case powerNormal:
+		low = false
+	}
+	changed := low != e.lowPower
+	e.lowPower = low
+	e.lowPowerMutex.Unlock()
+
+	if changed {
+		e.applyPower(low)
+	}
+}
+
+// applyPower puts the decoder, OLED and spectrum into low power mode, or
+// back to normal
+func (e *CoreEngine) applyPower(low bool) {
+	e.mutex.RLock()
+	power := e.config.Power
+	e.mutex.RUnlock()
+
+	if low {
+		logger.Infof("Low power mode on")
+	} else {
+		logger.Infof("Low power mode off")
+	}
+
+	if power.DecodeLevel > 0 {
+		limits := e.normalDecodeLimits()
+		if low {
+			limits = dsp.DecodeLevelLimits(power.DecodeLevel)
+		}
+		e.applyDecodeLimits(limits)
+	}
+
+	if power.DisableWaterfall {
+		interval := time.Duration(0)
+		if low {
+			interval = lowPowerSpectrumInterval
+		}
+		for _, monitor := range e.audioMonitors {
+			monitor.SetSpectrumInterval(interval)
+		}
+	}
+
+	if power.DisableOLED && e.hardwareManager != nil {
+		if low {
+			if err := e.hardwareManager.BlankOLED(); err != nil {
+				logger.Warnf("Failed to blank OLED: %v", err)
+			}
+		} else {
+			e.refreshOLEDDisplay()
+		}
+	}
+}
+
+// normalDecodeLimits are the decode limits outside low power mode: the
+// governor's, or full quality without one
+func (e *CoreEngine) normalDecodeLimits() dsp.DecodeLimits {
+	e.mutex.RLock()
+	governor := e.decodeGovernor
+	e.mutex.RUnlock()
+	if governor == nil {
+		return dsp.DefaultDecodeLimits()
+	}
+	return governor.Limits()
+}
+
+// lowPowerActive reports whether low power mode is on and has setting on,
+// e.g. lowPowerActive(cfg.Power.RXOnly)
+func (e *CoreEngine) lowPowerActive(setting bool) bool {
+	e.lowPowerMutex.Lock()
+	defer e.lowPowerMutex.Unlock()
+	return e.lowPower && setting
+}
+
+// lowPowerDecode reports whether low power mode has the decoder held at
+// power decode_level, out of the governor's hands
+func (e *CoreEngine) lowPowerDecode() bool {
+	e.mutex.RLock()
+	level := e.config.Power.DecodeLevel
+	e.mutex.RUnlock()
+	return e.lowPowerActive(level > 0)
+}
+
+// LowPower reports whether low power mode is on
+func (e *CoreEngine) LowPower() bool {
+	return e.lowPowerActive(true)
+}
+
+// WaterfallEnabled reports whether the web spectrum and waterfall should be
+// fed, false while low power mode has them off
+func (e *CoreEngine) WaterfallEnabled() bool {
+	e.mutex.RLock()
+	disable := e.config.Power.DisableWaterfall
+	e.mutex.RUnlock()
+	return !e.lowPowerActive(disable)
+}
+
+// handlePower handles POWER, which shows the power mode, and POWER LOW,
+// NORMAL or AUTO, which hold a mode or follow the schedule again
+func (e *CoreEngine) handlePower(args []string) *protocol.Response {
+	if len(args) > 0 {
+		mode := strings.ToLower(args[0])
+		switch mode {
+		case powerLow, powerNormal, powerAuto:
+		default:
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("unknown power mode %q, use LOW, NORMAL or AUTO", args[0]))
+		}
+		if mode == powerAuto {
+			mode = ""
+		}
+		e.lowPowerMutex.Lock()
+		e.powerOverride = mode
+		e.lowPowerMutex.Unlock()
+		e.updatePower(time.Now())
+	}
+	return protocol.NewSuccessResponse(e.powerStatus())
+}
+
+// powerStatus returns the power mode, what set it and what it changes
+func (e *CoreEngine) powerStatus() map[string]interface{} {
+	e.mutex.RLock()
+	power := e.config.Power
+	e.mutex.RUnlock()
+
+	e.lowPowerMutex.Lock()
+	defer e.lowPowerMutex.Unlock()
+
+	mode, override := powerNormal, e.powerOverride
+	if e.lowPower {
+		mode = powerLow
+	}
+	if override == "" {
+		override = powerAuto
+	}
+	hours := power.LowPowerHours
+	if hours == nil {
+		hours = []string{}
+	}
+	return map[string]interface{}{
+		"mode":              mode,
+		"override":          override,
+		"low_power_hours":   hours,
+		"rx_only":           power.RXOnly,
+		"decode_level":      power.DecodeLevel,
+		"disable_oled":      power.DisableOLED,
+		"disable_waterfall": power.DisableWaterfall,
+	}
+}
@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// handleProfile lists the configured profiles, or switches to the one named
+// and restarts the hardware whose settings differ
+func (e *CoreEngine) handleProfile(cmd *protocol.Command) *protocol.Response {
+	name := cmd.StringArg("name")
+
+	e.mutex.RLock()
+	base := e.baseConfig
+	active := e.config.Profile
+	e.mutex.RUnlock()
+
+	if name == "" {
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"profiles": base.ProfileNames(),
+			"active":   active,
+		})
+	}
+
+	if base.FindProfile(name) == nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("unknown profile: %s", name))
+	}
+	newConfig, err := base.ProfileConfig(name)
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("invalid profile: %v", err))
+	}
+	if err := e.checkDecoding(newConfig); err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("invalid profile: %v", err))
+	}
+
+	// Swapping the sound card or rig mid-transmission would leave PTT keyed
+	if e.isTransmitting() {
+		return protocol.NewErrorResponse("cannot switch profiles while transmitting")
+	}
+
+	_, restarted, err := e.applyConfig(base, newConfig)
+	e.tuneProfile(name)
+	logger.Infof("Switched to profile %s", name)
+
+	e.mutex.RLock()
+	frequency := e.frequency
+	e.mutex.RUnlock()
+
+	data := map[string]interface{}{
+		"profile":   name,
+		"previous":  active,
+		"frequency": frequency,
+		"restarted": restarted,
+	}
+	if err != nil {
+		data["warning"] = fmt.Sprintf("Profile applied but hardware failed to restart: %v", err)
+	}
+	return protocol.NewSuccessResponse(data)
+}
+
+// tuneProfile tunes to the frequency of the named profile, if it sets one.
+// Without a radio only the frequency shown in the status changes.
+func (e *CoreEngine) tuneProfile(name string) {
+	e.mutex.RLock()
+	profile := e.baseConfig.FindProfile(name)
+	e.mutex.RUnlock()
+	if profile == nil || profile.Frequency <= 0 {
+		return
+	}
+
+	if e.hardwareManager.IsRadioConnected() {
+		err := e.SetRadioFrequency(int64(profile.Frequency))
+		if err == nil {
+			return
+		}
+		logger.Warnf("Failed to tune to profile %s: %v", name, err)
+	}
+
+	e.mutex.Lock()
+	e.frequency = profile.Frequency
+	e.mutex.Unlock()
+	e.switchAntenna(profile.Frequency)
+}
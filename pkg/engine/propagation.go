@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/propagation"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// propagationTimeout bounds one fetch of the solar indices
+const propagationTimeout = 30 * time.Second
+
+// propagationLoop fetches the solar indices at startup and then every
+// propagation refresh minutes, keeping the last good ones when a fetch fails
+func (e *CoreEngine) propagationLoop() {
+	url := e.config.Propagation.URL
+	if url == "" {
+		url = propagation.DefaultURL
+	}
+	client := &http.Client{Timeout: propagationTimeout}
+
+	ticker := time.NewTicker(time.Duration(e.config.Propagation.Refresh) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(e.ctx, propagationTimeout)
+		conditions, err := propagation.Fetch(ctx, client, url)
+		cancel()
+		if err != nil {
+			logger.Warnf("Failed to fetch solar indices: %v", err)
+		} else {
+			logger.Debugf("Solar flux %d, A %d, K %.2f", conditions.SolarFlux, conditions.AIndex, conditions.KIndex)
+			e.propagationMutex.Lock()
+			e.propagation = conditions
+			e.propagationMutex.Unlock()
+		}
+
+		select {
+		case <-ticker.C:
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// handleGetPropagation returns the latest solar indices, null until the
+// first fetch succeeds
+func (e *CoreEngine) handleGetPropagation() *protocol.Response {
+	e.propagationMutex.RLock()
+	defer e.propagationMutex.RUnlock()
+
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"enabled":    e.config.Propagation.Enabled,
+		"conditions": e.propagation,
+	})
+}
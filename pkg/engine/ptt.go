@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+)
+
+// pttSequence is the timing of keying for a transmission: tx_delay and
+// tx_tail around the audio, and an amplifier keyed amp_lead before the rig
+// and released amp_tail after it. A rig on VOX keys itself from the audio,
+// tripped by voxLeader of tone.
+type pttSequence struct {
+	delay, tail      time.Duration
+	amp              bool
+	ampLead, ampTail time.Duration
+	vox              bool
+	voxLeader        time.Duration
+}
+
+// pttSequence reads the keying timing from the configuration
+func (e *CoreEngine) pttSequence() pttSequence {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	seconds := func(s float64) time.Duration { return time.Duration(s * float64(time.Second)) }
+	return pttSequence{
+		delay:   seconds(e.config.Radio.TxDelay),
+		tail:    seconds(e.config.Radio.TxTail),
+		amp:     e.config.Hardware.AmpKeying,
+		ampLead: seconds(e.config.Hardware.AmpLead),
+		ampTail: seconds(e.config.Hardware.AmpTail),
+
+		vox:       e.config.Radio.PTTMethod == "vox",
+		voxLeader: seconds(e.config.Radio.VOXLeader),
+	}
+}
+
+// keyTransmitter keys for a transmission. The amplifier goes first, so its
+// relays have settled before RF reaches them, then the rig, and tx_delay
+// passes for the rig to switch over before any audio. The unkey returned
+// runs the sequence backwards: PTT is held tx_tail after the audio, unless
+// the transmission was aborted, and dropPTT holds the amplifier amp_tail
+// after the rig. An abort while keying unkeys and fails.
+//
+// On VOX nothing is keyed, but PTT is still tracked from the start of the
+// audio to tx_tail after it, the rig's VOX hang time.
+func (e *CoreEngine) keyTransmitter() (unkey func(aborted bool), err error) {
+	sequence := e.pttSequence()
+
+	if sequence.vox {
+		e.setPTTState(true)
+		return func(aborted bool) {
+			if !aborted {
+				e.waitTX(sequence.tail)
+			}
+			e.setPTTState(false)
+		}, nil
+	}
+
+	if sequence.amp {
+		if err := e.hardwareManager.SetAmpKey(true); err != nil {
+			e.hardwareManager.SetAmpKey(false)
+			return nil, fmt.Errorf("failed to key amplifier: %w", err)
+		}
+		if !e.waitTX(sequence.ampLead) {
+			e.dropPTT()
+			return nil, fmt.Errorf("transmission aborted")
+		}
+	}
+
+	e.setPTTState(true)
+	if err := e.hardwareManager.SetRadioPTT(true); err != nil {
+		logger.Warnf("Failed to set radio PTT: %v", err)
+	}
+
+	if !e.waitTX(sequence.delay) {
+		e.dropPTT()
+		return nil, fmt.Errorf("transmission aborted")
+	}
+
+	return func(aborted bool) {
+		if !aborted {
+			e.waitTX(sequence.tail)
+		}
+		e.dropPTT()
+	}, nil
+}
+
+// waitTX waits through a step of the PTT sequence, reporting false when
+// the transmission is aborted meanwhile
+func (e *CoreEngine) waitTX(d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-e.abortTx:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// setPTTState records whether we are on the air, for the status
+func (e *CoreEngine) setPTTState(on bool) {
+	e.mutex.Lock()
+	e.ptt = on
+	offset := e.txAudioOffset
+	e.mutex.Unlock()
+	e.noteKeying(on, offset, time.Now())
+}
+
+// voxLeader puts the VOX leader tone, at the TX offset, before the audio
+// of a transmission on VOX, so the rig has switched over before the first
+// symbol. It returns how much longer the audio is.
+func (e *CoreEngine) voxLeader(audio []int16) ([]int16, time.Duration) {
+	sequence := e.pttSequence()
+	if !sequence.vox || sequence.voxLeader <= 0 {
+		return audio, 0
+	}
+	leader := dsp.GenerateTone(float64(e.txOffset()), sequence.voxLeader.Seconds(), e.dspEngine.GetSampleRate())
+	return append(leader, audio...), sequence.voxLeader
+}
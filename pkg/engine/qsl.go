@@ -0,0 +1,290 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/adif"
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/qsl"
+	"github.com/dougsko/js8d/pkg/storage"
+)
+
+// qslTimeout bounds one upload, which for LoTW includes TQSL signing
+const qslTimeout = 5 * time.Minute
+
+// adifPage is the most QSOs one EXPORT_ADIF response carries, to stay well
+// inside a socket frame
+const adifPage = 500
+
+// adifRecord returns a logged QSO as an ADIF record from this station
+func (e *CoreEngine) adifRecord(q storage.QSO) adif.Record {
+	var r adif.Record
+	r.Add("CALL", q.Callsign)
+	r.Add("QSO_DATE", adif.Date(q.Start))
+	r.Add("TIME_ON", adif.Time(q.Start))
+	r.Add("QSO_DATE_OFF", adif.Date(q.End))
+	r.Add("TIME_OFF", adif.Time(q.End))
+	r.Add("BAND", q.Band)
+	r.Add("FREQ", adif.Frequency(q.Frequency))
+	// JS8 is a submode of MFSK in ADIF
+	if strings.EqualFold(q.Mode, "JS8") {
+		r.Add("MODE", "MFSK")
+		r.Add("SUBMODE", "JS8")
+	} else {
+		r.Add("MODE", q.Mode)
+	}
+	r.Add("GRIDSQUARE", q.Grid)
+	r.Add("STATE", q.State)
+	r.Add("RST_RCVD", dsp.FormatSNR(int(q.SNR)))
+	r.Add("STATION_CALLSIGN", e.config.Station.Callsign)
+	r.Add("MY_GRIDSQUARE", e.config.Station.Grid)
+	addUploaded(&r, "LOTW", q.LoTWUploaded)
+	addUploaded(&r, "EQSL", q.EQSLUploaded)
+	return r
+}
+
+// addUploaded records in an ADIF record whether and when a QSO went up to
+// a service
+func addUploaded(r *adif.Record, service string, at *time.Time) {
+	if at == nil {
+		r.Add(service+"_QSL_SENT", "N")
+		return
+	}
+	r.Add(service+"_QSL_SENT", "Y")
+	r.Add(service+"_QSLSDATE", adif.Date(*at))
+}
+
+// qslService reads a service named in a command
+func qslService(arg string) (string, *protocol.Response) {
+	service := strings.ToLower(arg)
+	if !storage.ValidQSLService(service) {
+		return "", protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid,
+			fmt.Sprintf("invalid QSL service %q, use LOTW or EQSL", arg))
+	}
+	return service, nil
+}
+
+// handleExportADIF exports the log as ADIF, or with EXPORT_ADIF <service>
+// only the QSOs not yet uploaded to LOTW or EQSL, oldest first. A response
+// carries adifPage QSOs, the first with the file's header; next is where
+// EXPORT_ADIF [service] <next> carries on, until the last page leaves it
+// out.
+func (e *CoreEngine) handleExportADIF(args []string) *protocol.Response {
+	service := ""
+	from := 0
+	for _, arg := range args {
+		if n, err := strconv.Atoi(arg); err == nil {
+			if n < 0 {
+				return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("invalid export start %d", n))
+			}
+			from = n
+			continue
+		}
+		var failed *protocol.Response
+		if service, failed = qslService(arg); failed != nil {
+			return failed
+		}
+	}
+
+	e.msgMutex.RLock()
+	if e.messageStore == nil {
+		e.msgMutex.RUnlock()
+		return protocol.NewErrorResponse("message storage not available")
+	}
+	var qsos []storage.QSO
+	var err error
+	if service != "" {
+		qsos, err = e.messageStore.GetPendingQSOs(service)
+	} else {
+		qsos, err = e.messageStore.GetQSOs("", 0)
+	}
+	e.msgMutex.RUnlock()
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to get the log: %v", err))
+	}
+
+	// The full log is newest first; ADIF reads better in order
+	if service == "" {
+		for i, j := 0, len(qsos)-1; i < j; i, j = i+1, j-1 {
+			qsos[i], qsos[j] = qsos[j], qsos[i]
+		}
+	}
+	page := qsos[min(from, len(qsos)):min(from+adifPage, len(qsos))]
+	records := make([]adif.Record, len(page))
+	for i, q := range page {
+		records[i] = e.adifRecord(q)
+	}
+
+	var b strings.Builder
+	if from == 0 {
+		if err := adif.WriteHeader(&b, "js8d", adif.Record{{Name: "PROGRAMVERSION", Value: e.version}}); err != nil {
+			return protocol.NewErrorResponse(fmt.Sprintf("failed to write ADIF: %v", err))
+		}
+	}
+	if err := adif.WriteRecords(&b, records); err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to write ADIF: %v", err))
+	}
+	data := map[string]interface{}{
+		"adif":  b.String(),
+		"count": len(records),
+		"total": len(qsos),
+	}
+	if next := from + len(records); next < len(qsos) {
+		data["next"] = next
+	}
+	return protocol.NewSuccessResponse(data)
+}
+
+// handleQSL reports the QSOs waiting for each service, or takes an action:
+// QSL UPLOAD <service> uploads them and QSL MARK <service> <id>... records
+// QSOs as uploaded by hand, e.g. after signing an export in TQSL
+func (e *CoreEngine) handleQSL(args []string) *protocol.Response {
+	if len(args) > 0 {
+		if len(args) < 2 {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "QSL service required")
+		}
+		service, failed := qslService(args[1])
+		if failed != nil {
+			return failed
+		}
+
+		switch strings.ToUpper(args[0]) {
+		case "UPLOAD":
+			uploaded, err := e.uploadQSL(service)
+			if err != nil {
+				return protocol.NewErrorResponse(fmt.Sprintf("failed to upload to %s: %v", service, err))
+			}
+			return protocol.NewSuccessResponse(map[string]interface{}{
+				"service":  service,
+				"uploaded": uploaded,
+			})
+
+		case "MARK":
+			var ids []int64
+			for _, arg := range args[2:] {
+				id, err := strconv.ParseInt(arg, 10, 64)
+				if err != nil {
+					return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("invalid QSO ID %q", arg))
+				}
+				ids = append(ids, id)
+			}
+			if len(ids) == 0 {
+				return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "QSO IDs required")
+			}
+			e.msgMutex.Lock()
+			defer e.msgMutex.Unlock()
+			if e.messageStore == nil {
+				return protocol.NewErrorResponse("message storage not available")
+			}
+			marked, err := e.messageStore.MarkUploaded(service, ids, time.Now())
+			if err != nil {
+				return protocol.NewErrorResponse(fmt.Sprintf("failed to mark QSOs uploaded: %v", err))
+			}
+			return protocol.NewSuccessResponse(map[string]interface{}{
+				"service": service,
+				"marked":  marked,
+			})
+
+		default:
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid,
+				fmt.Sprintf("invalid QSL action %q, use UPLOAD or MARK", args[0]))
+		}
+	}
+
+	e.msgMutex.RLock()
+	defer e.msgMutex.RUnlock()
+	if e.messageStore == nil {
+		return protocol.NewErrorResponse("message storage not available")
+	}
+	data := make(map[string]interface{})
+	for _, service := range []string{storage.QSLServiceLoTW, storage.QSLServiceEQSL} {
+		pending, err := e.messageStore.GetPendingQSOs(service)
+		if err != nil {
+			return protocol.NewErrorResponse(fmt.Sprintf("failed to get the log: %v", err))
+		}
+		data[service] = map[string]interface{}{
+			"configured": e.qslConfigured(service),
+			"pending":    len(pending),
+		}
+	}
+	return protocol.NewSuccessResponse(data)
+}
+
+// qslConfigured reports whether uploads to a service are set up
+func (e *CoreEngine) qslConfigured(service string) bool {
+	if service == storage.QSLServiceLoTW {
+		return e.config.QSL.LoTW.Location != ""
+	}
+	return e.config.QSL.EQSL.Username != ""
+}
+
+// uploadQSL uploads the QSOs not yet sent to a service and marks them,
+// returning how many went up
+func (e *CoreEngine) uploadQSL(service string) (int, error) {
+	if !e.qslConfigured(service) {
+		return 0, fmt.Errorf("uploads to %s are not configured", service)
+	}
+
+	e.qslMutex.Lock()
+	defer e.qslMutex.Unlock()
+
+	e.msgMutex.RLock()
+	if e.messageStore == nil {
+		e.msgMutex.RUnlock()
+		return 0, fmt.Errorf("message storage not available")
+	}
+	qsos, err := e.messageStore.GetPendingQSOs(service)
+	e.msgMutex.RUnlock()
+	if err != nil {
+		return 0, err
+	}
+	if len(qsos) == 0 {
+		return 0, nil
+	}
+
+	records := make([]adif.Record, len(qsos))
+	ids := make([]int64, len(qsos))
+	for i, q := range qsos {
+		records[i] = e.adifRecord(q)
+		ids[i] = q.ID
+	}
+
+	ctx, cancel := context.WithTimeout(e.ctx, qslTimeout)
+	defer cancel()
+	if service == storage.QSLServiceLoTW {
+		lotw := &qsl.LoTW{TQSL: e.config.QSL.LoTW.TQSL, Location: e.config.QSL.LoTW.Location, Program: "js8d"}
+		err = lotw.Upload(ctx, records)
+	} else {
+		cfg := e.config.QSL.EQSL
+		eqsl := &qsl.EQSL{
+			URL:      cfg.URL,
+			Username: cfg.Username,
+			Password: cfg.Password.Value(),
+			Nickname: cfg.Nickname,
+			Program:  "js8d",
+			Client:   &http.Client{Timeout: qslTimeout},
+		}
+		var added int
+		added, err = eqsl.Upload(ctx, records)
+		if err == nil && added < len(records) {
+			logger.Infof("eQSL added %d of %d QSOs; it already had the rest", added, len(records))
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	e.msgMutex.Lock()
+	defer e.msgMutex.Unlock()
+	if _, err := e.messageStore.MarkUploaded(service, ids, time.Now()); err != nil {
+		return 0, err
+	}
+	logger.Infof("Uploaded %d QSOs to %s", len(ids), service)
+	return len(ids), nil
+}
@@ -0,0 +1,280 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// qsoScript is a scripted QSO with a station that answered our CQ. Each
+// reply from the station sends the next of the configured steps.
+type qsoScript struct {
+	state    protocol.QSOState
+	msg      protocol.Message // The step last queued, for resending
+	sent     bool             // It has gone out, so a reply may follow
+	sends    int              // Times it has gone out
+	deadline time.Time        // When it is resent or the QSO times out, once sent
+}
+
+// isCQ reports whether a message is a CQ: a broadcast with CQ as a word
+func isCQ(msg protocol.Message) bool {
+	if msg.To != "" && !strings.HasPrefix(msg.To, "@") {
+		return false
+	}
+	for _, word := range strings.Fields(strings.ToUpper(msg.Message)) {
+		if strings.Trim(word, ".,:;!?") == "CQ" {
+			return true
+		}
+	}
+	return false
+}
+
+// handleQSOCommand reports the scripted QSO, or takes the operator's
+// override: QSO ON or OFF to run scripts for CQ answers or not, QSO STOP
+// to end the one running and QSO NEXT to send its next step now. ON and
+// OFF last until js8d restarts.
+func (e *CoreEngine) handleQSOCommand(args []string) *protocol.Response {
+	if len(args) > 0 {
+		switch args[0] {
+		case "ON", "OFF":
+			enabled := args[0] == "ON"
+			e.qsoMutex.Lock()
+			e.qsoScripts = enabled
+			e.qsoMutex.Unlock()
+			logger.Infof("Scripted QSOs turned %s", strings.ToLower(args[0]))
+
+		case "STOP":
+			if !e.endQSO("", protocol.QSOStopped) {
+				return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "no scripted QSO is running")
+			}
+
+		case "NEXT":
+			e.qsoMutex.Lock()
+			script := e.qso
+			if script != nil {
+				e.nextQSOStep(script)
+			}
+			e.qsoMutex.Unlock()
+			if script == nil {
+				return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "no scripted QSO is running")
+			}
+
+		default:
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid,
+				fmt.Sprintf("invalid QSO setting %q, use ON, OFF, STOP or NEXT", args[0]))
+		}
+	}
+
+	enabled, state := e.qsoStatus()
+	data := map[string]interface{}{
+		"enabled": enabled,
+	}
+	if state != nil {
+		data["qso"] = state
+	}
+	return protocol.NewSuccessResponse(data)
+}
+
+// qsoStatus reports whether scripts run for CQ answers, and the progress
+// of the one running, if any
+func (e *CoreEngine) qsoStatus() (bool, *protocol.QSOState) {
+	e.qsoMutex.Lock()
+	defer e.qsoMutex.Unlock()
+	if e.qso == nil {
+		return e.qsoScripts, nil
+	}
+	state := e.qso.state
+	return e.qsoScripts, &state
+}
+
+// advanceQSO answers a directed message with the script's next step, when
+// it is the reply the running QSO waits for or a station answering our CQ.
+// It reports whether the message was for the script, which leaves it
+// without auto-replies.
+func (e *CoreEngine) advanceQSO(msg protocol.Message) bool {
+	if msg.From == "" || msg.From == e.config.Station.Callsign || msg.To != e.config.Station.Callsign {
+		return false
+	}
+
+	e.qsoMutex.Lock()
+	defer e.qsoMutex.Unlock()
+
+	if script := e.qso; script != nil {
+		if !strings.EqualFold(msg.From, script.state.Call) {
+			return false
+		}
+		// A repeat heard before our step went out needs no new answer
+		if script.sent {
+			e.nextQSOStep(script)
+		}
+		return true
+	}
+
+	timeout := time.Duration(e.config.QSO.Timeout) * time.Second
+	if !e.qsoScripts || len(e.config.QSO.Steps) == 0 || e.lastCQ.IsZero() || time.Since(e.lastCQ) > timeout {
+		return false
+	}
+	if refused := e.autoListRefusal(autoQSO, msg.From); refused != "" {
+		logger.Infof("%s answered our CQ, not starting a scripted QSO: %s", msg.From, refused)
+		return false
+	}
+
+	logger.Infof("%s answered our CQ, starting a scripted QSO", msg.From)
+	e.lastCQ = time.Time{}
+	e.qso = &qsoScript{state: protocol.QSOState{
+		Call:    strings.ToUpper(msg.From),
+		Steps:   len(e.config.QSO.Steps),
+		Started: time.Now(),
+	}}
+	e.nextQSOStep(e.qso)
+	return true
+}
+
+// nextQSOStep queues the step after the one last sent. The caller holds
+// qsoMutex.
+func (e *CoreEngine) nextQSOStep(script *qsoScript) {
+	step := script.state.Step
+	if step >= len(e.config.QSO.Steps) {
+		return
+	}
+
+	text, err := e.expandMacros(script.state.Call, e.config.QSO.Steps[step])
+	if err != nil {
+		logger.Warnf("Scripted QSO with %s: step %d: %v", script.state.Call, step+1, err)
+		e.finishQSO(protocol.QSOFailed)
+		return
+	}
+
+	msg := protocol.Message{
+		ID:        int(time.Now().Unix()),
+		Timestamp: time.Now(),
+		From:      e.config.Station.Callsign,
+		To:        script.state.Call,
+		Message:   text,
+		Mode:      "JS8",
+	}
+	msg, ok := e.queueTX(msg)
+	if !ok {
+		logger.Warnf("TX queue full, dropping the scripted QSO with %s", script.state.Call)
+		e.finishQSO(protocol.QSOFailed)
+		return
+	}
+
+	script.msg = msg
+	script.sent = false
+	script.sends = 0
+	script.state.Step = step + 1
+	script.state.State = protocol.QSOWaiting
+	logger.Infof("Scripted QSO with %s: step %d of %d queued: %s", script.state.Call, script.state.Step, script.state.Steps, text)
+	e.publishQSO(script.state)
+}
+
+// qsoSent starts waiting for the reply to a step that has gone out, or
+// completes the QSO when it was the last step. Other messages are ignored.
+func (e *CoreEngine) qsoSent(msg protocol.Message, err error) {
+	e.qsoMutex.Lock()
+	defer e.qsoMutex.Unlock()
+
+	script := e.qso
+	if script == nil || script.msg.ID != msg.ID {
+		return
+	}
+	if err != nil {
+		e.finishQSO(protocol.QSOFailed)
+		return
+	}
+	if script.state.Step == script.state.Steps {
+		e.finishQSO(protocol.QSOComplete)
+		return
+	}
+
+	script.sent = true
+	script.sends++
+	script.deadline = time.Now().Add(time.Duration(e.config.QSO.Timeout) * time.Second)
+}
+
+// noteCQ remembers when a CQ went out, opening the window in which a
+// station answering it starts a scripted QSO
+func (e *CoreEngine) noteCQ(msg protocol.Message) {
+	if !isCQ(msg) {
+		return
+	}
+	e.qsoMutex.Lock()
+	e.lastCQ = time.Now()
+	e.qsoMutex.Unlock()
+}
+
+// endQSO stops the running QSO with a state, when it is with call or call
+// is empty, and reports whether there was one
+func (e *CoreEngine) endQSO(call, state string) bool {
+	e.qsoMutex.Lock()
+	defer e.qsoMutex.Unlock()
+
+	if e.qso == nil || (call != "" && !strings.EqualFold(call, e.qso.state.Call)) {
+		return false
+	}
+	e.finishQSO(state)
+	return true
+}
+
+// finishQSO ends the running QSO and tells event subscribers. The caller
+// holds qsoMutex.
+func (e *CoreEngine) finishQSO(state string) {
+	script := e.qso
+	e.qso = nil
+	script.state.State = state
+	logger.Infof("Scripted QSO with %s %s after step %d of %d", script.state.Call,
+		strings.ReplaceAll(state, "_", " "), script.state.Step, script.state.Steps)
+	e.publishQSO(script.state)
+}
+
+// publishQSO tells event subscribers how a scripted QSO stands
+func (e *CoreEngine) publishQSO(state protocol.QSOState) {
+	e.publishEvent(protocol.EventQSO, map[string]interface{}{
+		"qso": state,
+	})
+}
+
+// qsoMonitor resends steps whose reply is overdue
+func (e *CoreEngine) qsoMonitor() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			e.checkQSOTimeout(now)
+
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkQSOTimeout resends the step of the running QSO when its reply is
+// overdue, until qso.retries resends have gone unanswered
+func (e *CoreEngine) checkQSOTimeout(now time.Time) {
+	e.qsoMutex.Lock()
+	defer e.qsoMutex.Unlock()
+
+	script := e.qso
+	if script == nil || !script.sent || now.Before(script.deadline) {
+		return
+	}
+	if script.sends > e.config.QSO.Retries {
+		e.finishQSO(protocol.QSOTimedOut)
+		return
+	}
+
+	logger.Infof("No reply from %s, resending step %d", script.state.Call, script.state.Step)
+	msg, ok := e.queueTX(script.msg)
+	if !ok {
+		logger.Warnf("TX queue full, dropping the scripted QSO with %s", script.state.Call)
+		e.finishQSO(protocol.QSOFailed)
+		return
+	}
+	script.msg = msg
+	script.sent = false
+}
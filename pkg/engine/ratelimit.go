@@ -0,0 +1,201 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// Kinds of automatic reply counted against the rate limits
+const (
+	rateReply    = "reply"    // SNR reports answering SNR? queries
+	rateAnswer   = "answer"   // Replies to CQs
+	rateTransfer = "transfer" // Acknowledgements of file transfer polls
+)
+
+// rateIdle is how long a station's bucket is kept once it has refilled
+const rateIdle = time.Hour
+
+// tokenBucket holds up to burst tokens, refilled at a steady rate; each
+// automatic reply takes one
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// refill adds the tokens earned since the bucket was last touched. A new
+// bucket starts full.
+func (b *tokenBucket) refill(now time.Time, perHour, burst int) {
+	if b.updated.IsZero() {
+		b.tokens = float64(burst)
+	} else if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens += elapsed.Hours() * float64(perHour)
+	}
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+	b.updated = now
+}
+
+// rateLimits are the rate_limit settings an automatic reply is checked
+// against; a per-hour rate of -1 is no limit
+type rateLimits struct {
+	callsignPerHour, callsignBurst int
+	globalPerHour, globalBurst     int
+}
+
+// rateCount is how many automatic replies of a kind were sent and held back
+type rateCount struct {
+	Allowed int `json:"allowed"`
+	Limited int `json:"limited"`
+}
+
+// rateLimiter keeps the token buckets of rate_limit in memory: one per
+// station and one over all of them, both of which a reply must pass
+type rateLimiter struct {
+	mutex    sync.Mutex
+	global   tokenBucket
+	stations map[string]*tokenBucket
+	limited  map[string]bool // Stations held back since their bucket was last full, warned about once
+	counts   map[string]*rateCount
+}
+
+// allow reports whether an automatic reply of kind to call may go out now,
+// taking a token from the station's bucket and the global one when it may
+func (r *rateLimiter) allow(kind, call string, now time.Time, limits rateLimits) bool {
+	call = strings.ToUpper(call)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.stations == nil {
+		r.stations = map[string]*tokenBucket{}
+		r.limited = map[string]bool{}
+		r.counts = map[string]*rateCount{}
+	}
+	r.prune(now, limits)
+
+	station := r.stations[call]
+	if station == nil {
+		station = &tokenBucket{}
+		r.stations[call] = station
+	}
+	station.refill(now, limits.callsignPerHour, limits.callsignBurst)
+	r.global.refill(now, limits.globalPerHour, limits.globalBurst)
+
+	count := r.counts[kind]
+	if count == nil {
+		count = &rateCount{}
+		r.counts[kind] = count
+	}
+
+	// Neither bucket gives up a token unless both have one
+	stationOK := limits.callsignPerHour == -1 || station.tokens >= 1
+	globalOK := limits.globalPerHour == -1 || r.global.tokens >= 1
+	if !stationOK || !globalOK {
+		count.Limited++
+		if !r.limited[call] {
+			r.limited[call] = true
+			if stationOK {
+				logger.Warnf("Rate limit: holding back automatic replies, %d an hour reached (%s to %s)", limits.globalPerHour, kind, call)
+			} else {
+				logger.Warnf("Rate limit: holding back automatic replies to %s, %d an hour reached (%s)", call, limits.callsignPerHour, kind)
+			}
+		}
+		return false
+	}
+
+	if limits.callsignPerHour != -1 {
+		station.tokens--
+	}
+	if limits.globalPerHour != -1 {
+		r.global.tokens--
+	}
+	delete(r.limited, call)
+	count.Allowed++
+	return true
+}
+
+// prune drops the buckets of stations that have refilled and gone quiet;
+// called with the mutex held
+func (r *rateLimiter) prune(now time.Time, limits rateLimits) {
+	for call, bucket := range r.stations {
+		if now.Sub(bucket.updated) < rateIdle {
+			continue
+		}
+		bucket.refill(now, limits.callsignPerHour, limits.callsignBurst)
+		if limits.callsignPerHour == -1 || bucket.tokens >= float64(limits.callsignBurst) {
+			delete(r.stations, call)
+			delete(r.limited, call)
+		}
+	}
+}
+
+// stationRate is a station's bucket in the rate limit counters
+type stationRate struct {
+	Callsign string  `json:"callsign"`
+	Tokens   float64 `json:"tokens"`
+	Limited  bool    `json:"limited"`
+}
+
+// snapshot returns the global tokens left, each station's bucket and the
+// counts by kind
+func (r *rateLimiter) snapshot(now time.Time, limits rateLimits) (float64, []stationRate, map[string]rateCount) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.prune(now, limits)
+	r.global.refill(now, limits.globalPerHour, limits.globalBurst)
+
+	stations := make([]stationRate, 0, len(r.stations))
+	for call, bucket := range r.stations {
+		bucket.refill(now, limits.callsignPerHour, limits.callsignBurst)
+		stations = append(stations, stationRate{Callsign: call, Tokens: bucket.tokens, Limited: r.limited[call]})
+	}
+	sort.Slice(stations, func(i, j int) bool { return stations[i].Callsign < stations[j].Callsign })
+	counts := map[string]rateCount{}
+	for _, kind := range []string{rateReply, rateAnswer, rateTransfer} {
+		counts[kind] = rateCount{}
+		if count := r.counts[kind]; count != nil {
+			counts[kind] = *count
+		}
+	}
+	return r.global.tokens, stations, counts
+}
+
+// currentRateLimits returns the rate_limit settings
+func (e *CoreEngine) currentRateLimits() rateLimits {
+	cfg := e.config.RateLimit
+	return rateLimits{
+		callsignPerHour: cfg.CallsignPerHour,
+		callsignBurst:   cfg.CallsignBurst,
+		globalPerHour:   cfg.GlobalPerHour,
+		globalBurst:     cfg.GlobalBurst,
+	}
+}
+
+// rateAllowed reports whether an automatic reply of kind to call is within
+// the rate limits, counting it when it is
+func (e *CoreEngine) rateAllowed(kind, call string) bool {
+	return e.rateLimiter.allow(kind, call, time.Now(), e.currentRateLimits())
+}
+
+// handleGetRateLimits returns the rate limits, the tokens left in the
+// global bucket and each station's, and the automatic replies sent and held
+// back since starting
+func (e *CoreEngine) handleGetRateLimits() *protocol.Response {
+	limits := e.currentRateLimits()
+	global, stations, counts := e.rateLimiter.snapshot(time.Now(), limits)
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"callsign_per_hour": limits.callsignPerHour,
+		"callsign_burst":    limits.callsignBurst,
+		"global_per_hour":   limits.globalPerHour,
+		"global_burst":      limits.globalBurst,
+		"global_tokens":     global,
+		"stations":          stations,
+		"counts":            counts,
+	})
+}
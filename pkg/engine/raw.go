@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// Bits of a decoded JS8 frame: the message, the frame type, then the CRC
+const (
+	rawMessageBits   = 72
+	rawFrameTypeBits = 3
+	rawCRCBits       = 12
+)
+
+// handleRaw handles RAW:<id>, showing the payload bits and received tones a
+// stored message was decoded from
+func (e *CoreEngine) handleRaw(cmd *protocol.Command) *protocol.Response {
+	id, err := strconv.Atoi(strings.TrimSpace(cmd.StringArg("id")))
+	if err != nil || id <= 0 {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "usage: RAW:<message id>")
+	}
+
+	e.msgMutex.RLock()
+	defer e.msgMutex.RUnlock()
+	if e.messageStore == nil {
+		return protocol.NewErrorResponse("message storage not available")
+	}
+
+	frame, err := e.messageStore.GetRawFrame(id)
+	if err != nil {
+		return protocol.NewErrorResponse(err.Error())
+	}
+	if frame == nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid,
+			fmt.Sprintf("no raw frame for message %d; it was not a decode stored with storage raw_frames on", id))
+	}
+
+	var bits strings.Builder
+	for i := 0; i < rawMessageBits+rawFrameTypeBits+rawCRCBits; i++ {
+		bit := byte('0')
+		if i/8 < len(frame.Payload) && frame.Payload[i/8]&(0x80>>(i%8)) != 0 {
+			bit = '1'
+		}
+		bits.WriteByte(bit)
+	}
+	var tones strings.Builder
+	for _, tone := range frame.Tones {
+		tones.WriteString(strconv.Itoa(tone))
+	}
+	frameType, _ := strconv.ParseUint(bits.String()[rawMessageBits:rawMessageBits+rawFrameTypeBits], 2, 8)
+
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"id":         frame.MessageID,
+		"timestamp":  frame.Timestamp,
+		"from":       frame.From,
+		"message":    frame.Message,
+		"payload":    hex.EncodeToString(frame.Payload),
+		"hex_dump":   hex.Dump(frame.Payload),
+		"bits":       bits.String(),
+		"frame_type": frameType,
+		"crc":        bits.String()[rawMessageBits+rawFrameTypeBits:],
+		"tones":      tones.String(),
+	})
+}
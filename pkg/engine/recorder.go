@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/dougsko/js8d/pkg/audio"
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// openRecorder starts the band recorder in storage recording_dir, nil when
+// it is off. Codec auto records Opus when js8d was built with it.
+func openRecorder(cfg *config.Config) (*audio.Recorder, error) {
+	if cfg.Storage.RecordingDir == "" {
+		return nil, nil
+	}
+	codec := cfg.Storage.RecordingCodec
+	if codec == "" || codec == "auto" {
+		codec = audio.CodecMuLaw
+		if audio.OpusAvailable {
+			codec = audio.CodecOpus
+		}
+	}
+	return audio.NewRecorder(audio.RecorderConfig{
+		Dir:     cfg.Storage.RecordingDir,
+		Codec:   codec,
+		Bitrate: cfg.Storage.RecordingBitrate,
+		Segment: time.Duration(cfg.Storage.RecordingMinutes) * time.Minute,
+		Quota:   int64(cfg.Storage.RecordingQuota) << 20,
+	})
+}
+
+// markRecording indexes a decode in the recording being written
+func (e *CoreEngine) markRecording(msg protocol.Message) {
+	e.recorder.Mark(audio.RecordingDecode{
+		Time:      msg.Timestamp,
+		Frequency: msg.Frequency,
+		Offset:    msg.Offset,
+		SNR:       msg.SNR,
+		From:      msg.From,
+		Message:   msg.Message,
+		Channel:   msg.Channel,
+	})
+}
+
+// RecorderStats returns the band recorder's recordings against its quota,
+// nil when it is off
+func (e *CoreEngine) RecorderStats() map[string]interface{} {
+	if e.recorder == nil {
+		return nil
+	}
+	count, used, quota, dropped, err := e.recorder.Usage()
+	stats := map[string]interface{}{
+		"count":          count,
+		"bytes":          used,
+		"quota":          quota,
+		"dropped_blocks": dropped,
+	}
+	if err != nil {
+		stats["error"] = err.Error()
+	}
+	return stats
+}
+
+// GetRecorder returns the band recorder, nil unless storage recording_dir
+// is set
+func (e *CoreEngine) GetRecorder() *audio.Recorder {
+	return e.recorder
+}
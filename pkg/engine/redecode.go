@@ -0,0 +1,401 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/audio"
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// Re-decoding cuts recordings into JS8 Normal receive cycles on the UTC
+// boundaries, as live decoding does, and keeps at most redecodeMaxMessages
+// of what it finds. A run covers at most redecodeMaxSpan of audio.
+const (
+	redecodeCycle       = 15 * time.Second
+	redecodeMaxSpan     = 7 * 24 * time.Hour
+	redecodeMaxMessages = 10000
+)
+
+// RedecodedMessage is a message decoded again from a band recording
+type RedecodedMessage struct {
+	protocol.Message
+	Recording string  `json:"recording"`
+	Position  float64 `json:"position"` // Seconds into the recording its cycle starts
+	Missed    bool    `json:"missed"`   // Not in the recording's index, so not decoded live
+}
+
+// redecodeRun is a pass of the decoder over recorded audio and the
+// messages it found, kept apart from those decoded live
+type redecodeRun struct {
+	mutex    sync.Mutex
+	from, to time.Time
+	started  time.Time
+	finished time.Time
+	reached  time.Time // Start of the last cycle decoded
+	cycles   int
+	messages []RedecodedMessage
+	err      error
+	cancel   chan struct{}
+}
+
+// parseRedecodeRange reads the span of a REDECODE: <from>/<to>, either end
+// an RFC 3339 time or a recording name's 20060102-150405, <from> alone
+// to now, or a duration back from now such as 2h
+func parseRedecodeRange(text string, now time.Time) (from, to time.Time, err error) {
+	if d, err := time.ParseDuration(text); err == nil {
+		if d <= 0 {
+			return from, to, fmt.Errorf("duration must be positive")
+		}
+		return now.Add(-d), now, nil
+	}
+
+	parse := func(s string) (time.Time, error) {
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "20060102-150405", "20060102-1504"} {
+			if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unknown time %q, use RFC 3339 such as 2024-01-15T10:00:00Z", s)
+	}
+	start, end, ranged := strings.Cut(text, "/")
+	if from, err = parse(strings.TrimSpace(start)); err != nil {
+		return from, to, err
+	}
+	to = now
+	if ranged {
+		if to, err = parse(strings.TrimSpace(end)); err != nil {
+			return from, to, err
+		}
+	}
+	if !to.After(from) {
+		return from, to, fmt.Errorf("the range must end after it starts")
+	}
+	return from, to, nil
+}
+
+// handleRedecode handles REDECODE, showing the last run, and
+// REDECODE:<range> and REDECODE:CANCEL
+func (e *CoreEngine) handleRedecode(cmd *protocol.Command) *protocol.Response {
+	arg := strings.TrimSpace(cmd.StringArg("range"))
+	switch {
+	case arg == "":
+		return protocol.NewSuccessResponse(map[string]interface{}{"redecode": e.RedecodeStatus()})
+
+	case strings.EqualFold(arg, protocol.RedecodeCancel):
+		if !e.CancelRedecode() {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "no re-decode is running")
+		}
+		return protocol.NewSuccessResponse(map[string]interface{}{"redecode": e.RedecodeStatus()})
+	}
+
+	from, to, err := parseRedecodeRange(arg, time.Now())
+	if err != nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid,
+			fmt.Sprintf("%v; usage: REDECODE:<from>/<to>, REDECODE:<from> or REDECODE:<duration>", err))
+	}
+	if err := e.StartRedecode(from, to); err != nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, err.Error())
+	}
+	return protocol.NewSuccessResponse(map[string]interface{}{"redecode": e.RedecodeStatus()})
+}
+
+// StartRedecode decodes the band recordings from from to to again with the
+// current decoder, in the background, replacing the last run's messages
+func (e *CoreEngine) StartRedecode(from, to time.Time) error {
+	if e.recorder == nil {
+		return fmt.Errorf("band recorder is off, set storage recording_dir")
+	}
+	if to.Sub(from) > redecodeMaxSpan {
+		return fmt.Errorf("re-decode at most %s of audio at once", redecodeMaxSpan)
+	}
+	recordings, err := e.recorder.Recordings()
+	if err != nil {
+		return err
+	}
+	recordings = recordingsBetween(recordings, from, to)
+	if len(recordings) == 0 {
+		return fmt.Errorf("no recordings from %s to %s", from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339))
+	}
+
+	e.mutex.Lock()
+	if e.redecode != nil && e.redecode.running() {
+		e.mutex.Unlock()
+		return fmt.Errorf("a re-decode is already running, REDECODE:CANCEL stops it")
+	}
+	run := &redecodeRun{from: from, to: to, started: time.Now(), cancel: make(chan struct{})}
+	e.redecode = run
+	decoder := e.config.DSP.Decoder
+	e.mutex.Unlock()
+
+	logger.Infof("Re-decoding %d recording(s) from %s to %s", len(recordings),
+		from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339))
+	go e.runRedecode(run, decoder, recordings)
+	return nil
+}
+
+// CancelRedecode stops a re-decode in progress, reporting whether one was
+func (e *CoreEngine) CancelRedecode() bool {
+	e.mutex.RLock()
+	run := e.redecode
+	e.mutex.RUnlock()
+	if run == nil || !run.running() {
+		return false
+	}
+	run.mutex.Lock()
+	defer run.mutex.Unlock()
+	select {
+	case <-run.cancel:
+	default:
+		close(run.cancel)
+	}
+	return true
+}
+
+// RedecodeStatus returns the last re-decode's progress and messages, nil
+// when there has been none
+func (e *CoreEngine) RedecodeStatus() map[string]interface{} {
+	e.mutex.RLock()
+	run := e.redecode
+	e.mutex.RUnlock()
+	if run == nil {
+		return nil
+	}
+
+	run.mutex.Lock()
+	defer run.mutex.Unlock()
+	missed := 0
+	for _, msg := range run.messages {
+		if msg.Missed {
+			missed++
+		}
+	}
+	status := map[string]interface{}{
+		"from":     run.from,
+		"to":       run.to,
+		"started":  run.started,
+		"running":  run.finished.IsZero(),
+		"cycles":   run.cycles,
+		"decoded":  len(run.messages),
+		"missed":   missed,
+		"messages": append([]RedecodedMessage(nil), run.messages...),
+	}
+	if !run.reached.IsZero() {
+		status["reached"] = run.reached
+	}
+	if !run.finished.IsZero() {
+		status["finished"] = run.finished
+	}
+	if run.err != nil {
+		status["error"] = run.err.Error()
+	}
+	return status
+}
+
+// running reports whether the run has still to finish
+func (r *redecodeRun) running() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.finished.IsZero()
+}
+
+// cancelled reports whether the run was cancelled
+func (r *redecodeRun) cancelled() bool {
+	select {
+	case <-r.cancel:
+		return true
+	default:
+		return false
+	}
+}
+
+// recordingsBetween returns the recordings with audio from from to to. A
+// recording runs until the next one starts, or until now for the last.
+func recordingsBetween(recordings []audio.Recording, from, to time.Time) []audio.Recording {
+	var between []audio.Recording
+	for i, recording := range recordings {
+		if recording.Start.IsZero() || !recording.Start.Before(to) {
+			continue
+		}
+		if i+1 < len(recordings) && !recordings[i+1].Start.After(from) {
+			continue
+		}
+		between = append(between, recording)
+	}
+	return between
+}
+
+// runRedecode decodes each recording's cycles between the run's times on a
+// decoder of its own, so live decoding carries on alongside
+func (e *CoreEngine) runRedecode(run *redecodeRun, decoderName string, recordings []audio.Recording) {
+	err := func() error {
+		decoder, err := dsp.NewEngine(decoderName)
+		if err != nil {
+			return err
+		}
+		decoder.SetSampleRate(audio.RecorderRate)
+		if err := decoder.Initialize(); err != nil {
+			return fmt.Errorf("re-decode decoder failed to start: %w", err)
+		}
+		defer decoder.Close()
+
+		for _, recording := range recordings {
+			if err := e.redecodeRecording(run, decoder, recording); err != nil {
+				return err
+			}
+			if run.cancelled() {
+				return fmt.Errorf("cancelled")
+			}
+		}
+		return nil
+	}()
+
+	run.mutex.Lock()
+	run.finished = time.Now()
+	run.err = err
+	decoded := len(run.messages)
+	run.mutex.Unlock()
+	if err != nil {
+		logger.Warnf("Re-decode stopped after %d message(s): %v", decoded, err)
+		return
+	}
+	logger.Infof("Re-decode finished with %d message(s)", decoded)
+}
+
+// redecodeRecording decodes the cycles of one recording that fall in the
+// run's span
+func (e *CoreEngine) redecodeRecording(run *redecodeRun, decoder dsp.DSPEngine, recording audio.Recording) error {
+	path, err := e.recorder.Path(recording.Name)
+	if err != nil {
+		return err
+	}
+	reader, err := audio.OpenRecording(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	index, _ := e.recorder.Decodes(recording.Name)
+
+	// Skip to the first cycle boundary
+	cycle := recording.Start.Truncate(redecodeCycle)
+	if cycle.Before(recording.Start) {
+		cycle = cycle.Add(redecodeCycle)
+	}
+	cycleSamples := int(redecodeCycle.Seconds() * audio.RecorderRate)
+	buffer := make([]int16, cycleSamples)
+	lead := int(cycle.Sub(recording.Start).Seconds() * audio.RecorderRate)
+	if _, err := readFull(reader, buffer[:lead]); err != nil {
+		return nil
+	}
+
+	for position := lead; cycle.Before(run.to) && !run.cancelled(); position += cycleSamples {
+		n, err := readFull(reader, buffer)
+		if n < cycleSamples/2 {
+			return nil // The recording ends without a whole transmission
+		}
+		if cycle.Add(redecodeCycle).After(run.from) {
+			e.redecodeCycle(run, decoder, recording, index, buffer[:n], cycle, float64(position)/audio.RecorderRate)
+		}
+		if err != nil {
+			return nil
+		}
+		cycle = cycle.Add(redecodeCycle)
+		e.yieldToDecoder()
+	}
+	return nil
+}
+
+// redecodeCycle decodes one cycle of a recording, keeping what it finds
+func (e *CoreEngine) redecodeCycle(run *redecodeRun, decoder dsp.DSPEngine, recording audio.Recording,
+	index []audio.RecordingDecode, samples []int16, cycle time.Time, position float64) {
+	dial := recordingDial(index, cycle)
+
+	var found []RedecodedMessage
+	decoder.DecodeBuffer(samples, func(result *dsp.DecodeResult) {
+		msg := e.parseJS8Message(result)
+		msg.Timestamp = cycle
+		msg.Offset = int(result.Frequency)
+		if dial > 0 {
+			msg.Dial = dial
+			msg.Frequency = dial + msg.Offset
+			msg.Band = protocol.Band(msg.Frequency)
+		}
+		found = append(found, RedecodedMessage{
+			Message:   msg,
+			Recording: recording.Name,
+			Position:  position,
+			Missed:    !decodedLive(index, msg.Message, cycle),
+		})
+	})
+
+	run.mutex.Lock()
+	defer run.mutex.Unlock()
+	run.cycles++
+	run.reached = cycle
+	for _, msg := range found {
+		if len(run.messages) >= redecodeMaxMessages {
+			break
+		}
+		msg.ID = len(run.messages) + 1
+		run.messages = append(run.messages, msg)
+	}
+}
+
+// recordingDial returns the dial frequency of the last decode indexed
+// before the end of a cycle, 0 when none is
+func recordingDial(index []audio.RecordingDecode, cycle time.Time) int {
+	dial := 0
+	for _, decode := range index {
+		if decode.Time.After(cycle.Add(2 * redecodeCycle)) {
+			break
+		}
+		if decode.Frequency > 0 {
+			dial = decode.Frequency - decode.Offset
+		}
+	}
+	return dial
+}
+
+// decodedLive reports whether the recording's index has message decoded
+// live within a cycle either side of cycle's end
+func decodedLive(index []audio.RecordingDecode, message string, cycle time.Time) bool {
+	end := cycle.Add(redecodeCycle)
+	for _, decode := range index {
+		if gap := decode.Time.Sub(end); gap > -redecodeCycle && gap < redecodeCycle &&
+			strings.TrimSpace(decode.Message) == strings.TrimSpace(message) {
+			return true
+		}
+	}
+	return false
+}
+
+// yieldToDecoder pauses a re-decode while live decoding is behind, which
+// matters more
+func (e *CoreEngine) yieldToDecoder() {
+	e.pipeline.mutex.Lock()
+	behind := e.pipeline.lag > pipelineLagWarning
+	e.pipeline.mutex.Unlock()
+	if behind {
+		time.Sleep(time.Second)
+	}
+}
+
+// readFull reads until samples is full or the recording ends
+func readFull(reader *audio.RecordingReader, samples []int16) (int, error) {
+	n := 0
+	for n < len(samples) {
+		read, err := reader.Read(samples[n:])
+		n += read
+		if err != nil {
+			if err == io.EOF && n == len(samples) {
+				return n, nil
+			}
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,218 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/audio"
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/hardware"
+)
+
+// startAudio starts capture and playback and the goroutines that monitor,
+// filter and decode the captured audio
+func (e *CoreEngine) startAudio() {
+	// Start audio input for decoding
+	logger.Debugf("About to start audio input...")
+	if err := e.hardwareManager.StartAudioInput(); err != nil {
+		logger.Warnf("Failed to start audio input: %v", err)
+	} else {
+		logger.Debugf("Audio input startup completed successfully")
+	}
+
+	// Start audio output for transmission. A half-duplex card only plays
+	// while transmitting.
+	e.mutex.RLock()
+	halfDuplexAudio := e.config.Audio.HalfDuplex
+	e.mutex.RUnlock()
+	var duplex *halfDuplex
+	if halfDuplexAudio {
+		duplex = newHalfDuplex(e.hardwareManager)
+		logger.Infof("Half-duplex audio: capture stops while transmitting")
+	} else if err := e.hardwareManager.StartAudioOutput(); err != nil {
+		logger.Warnf("Failed to start audio output: %v", err)
+	}
+
+	// Start audio monitoring
+	monitors := e.GetAudioMonitors()
+	for ch, monitor := range monitors {
+		if err := monitor.Start(); err != nil {
+			logger.Warnf("Failed to start audio monitor for RX channel %d: %v", ch, err)
+		}
+	}
+	logger.Infof("Audio monitoring started (%d RX channel(s))", len(monitors))
+
+	stop := make(chan struct{})
+	e.mutex.Lock()
+	e.audioStop = stop
+	e.duplex = duplex
+	e.mutex.Unlock()
+
+	// The sample reader is the single reader of captured audio and feeds the decoder
+	e.audioWG.Add(2)
+	go func() {
+		defer e.audioWG.Done()
+		e.supervise("audio", stop, func() { e.processAudioSamples(stop) })
+	}()
+	go func() {
+		defer e.audioWG.Done()
+		e.supervise("decode", stop, func() { e.audioProcessor(stop) })
+	}()
+}
+
+// stopAudio stops the audio goroutines and waits for them to return, so
+// the audio devices and RX pipeline can be replaced underneath them
+func (e *CoreEngine) stopAudio() {
+	e.mutex.Lock()
+	stop := e.audioStop
+	e.audioStop = nil
+	e.mutex.Unlock()
+
+	if stop != nil {
+		close(stop)
+	}
+	e.audioWG.Wait()
+
+	// Blocks still queued were split for the old channel layout
+	for {
+		select {
+		case block := <-e.rxAudio:
+			hardware.RecycleAudioSamples(block.raw)
+		default:
+			return
+		}
+	}
+}
+
+// rebuildRXPipeline replaces the per-channel monitors, decoders and PCM
+// stream for a new channel layout or sample rate. The audio goroutines must
+// be stopped. Remote audio listeners have to reconnect to the new stream.
+func (e *CoreEngine) rebuildRXPipeline(cfg *config.Config, sampleRate int) error {
+	rxChannels := rxChannelNames(cfg)
+
+	monitors := newAudioMonitors(cfg, rxChannels, sampleRate)
+	for _, monitor := range monitors {
+		monitor.SetAlarmHandler(e.handleAudioAlarm)
+	}
+
+	// The native decoder re-creates itself when its rate changes
+	e.dspEngine.SetSampleRate(sampleRate)
+	decoders := newDecoders(len(rxChannels), e.dspEngine, cfg.DSP.Decoder)
+	for ch, decoder := range decoders {
+		if decoder == e.dspEngine {
+			continue
+		}
+		decoder.SetSampleRate(sampleRate)
+		if err := decoder.Initialize(); err != nil {
+			return fmt.Errorf("failed to initialize DSP engine for RX channel %s: %w", rxChannels[ch], err)
+		}
+	}
+
+	e.mutex.Lock()
+	oldMonitors, oldDecoders := e.audioMonitors, e.rxDecoders
+	e.rxChannels = rxChannels
+	e.audioMonitors = monitors
+	e.rxDecoders = decoders
+	e.preFilters = newPreFilters(len(rxChannels), sampleRate, preFilterConfig(cfg))
+	e.pcmStream = audio.NewPCMStream(sampleRate, audio.DefaultStreamRate)
+	e.mutex.Unlock()
+
+	for _, monitor := range oldMonitors {
+		monitor.Stop()
+	}
+	for _, decoder := range oldDecoders {
+		if decoder != e.dspEngine {
+			decoder.Close()
+		}
+	}
+
+	logger.Infof("RX pipeline rebuilt (%d channel(s) at %d Hz)", len(rxChannels), sampleRate)
+	return nil
+}
+
+// applyHardwareConfig restarts the hardware subsystems whose settings differ
+// in cfg and returns their names. Audio capture is paused while the sound
+// card is reopened; the radio, GPIO and OLED are swapped under the hardware
+// manager's lock, so PTT is never left keyed on an old pin or rig.
+func (e *CoreEngine) applyHardwareConfig(cfg *config.Config) ([]string, error) {
+	oldConfig := e.hardwareManager.GetConfig()
+	newConfig := hardwareConfigFor(cfg)
+
+	audioChanged := false
+	for _, subsystem := range hardware.ChangedSubsystems(oldConfig, newConfig) {
+		audioChanged = audioChanged || subsystem == hardware.SubsystemAudio
+	}
+
+	if audioChanged {
+		e.stopAudio()
+	}
+
+	restarted, err := e.hardwareManager.Reconfigure(newConfig)
+
+	if audioChanged {
+		if oldConfig.SampleRate != newConfig.SampleRate || oldConfig.InputChannels != newConfig.InputChannels {
+			if rebuildErr := e.rebuildRXPipeline(cfg, newConfig.SampleRate); rebuildErr != nil && err == nil {
+				err = rebuildErr
+			}
+		}
+
+		// Splitting a mono capture would hand each receiver every other sample
+		if audioInput := e.hardwareManager.GetAudio(); audioInput != nil && audioInput.GetInputChannels() != newConfig.InputChannels {
+			return restarted, fmt.Errorf("audio input captures %d channel(s) but input_channels %q needs %d, RX stopped",
+				audioInput.GetInputChannels(), cfg.Audio.InputChannels, newConfig.InputChannels)
+		}
+
+		if e.isRunning() {
+			e.startAudio()
+		}
+	}
+
+	return restarted, err
+}
+
+// applyConfig makes cfg the running configuration. base is the configuration
+// before any profile was applied, kept for switching profiles. Hardware whose
+// settings changed is restarted; its names and any restart error are
+// returned with the configuration that was replaced.
+func (e *CoreEngine) applyConfig(base, cfg *config.Config) (*config.Config, []string, error) {
+	e.mutex.Lock()
+	oldConfig := e.config
+	e.config = cfg
+	e.baseConfig = base
+	e.preFilters = newPreFilters(len(e.rxChannels), hardwareConfigFor(cfg).SampleRate, preFilterConfig(cfg))
+	e.decodeGovernor = newDecodeGovernor(cfg, e.dspEngine)
+	if cfg.Radio.DriftEstimate != oldConfig.Radio.DriftEstimate || cfg.Radio.CalibrationPPM != oldConfig.Radio.CalibrationPPM {
+		e.driftEstimator = newDriftEstimator(cfg) // Old samples were measured against the old calibration
+	}
+	if cfg.DSP.DecodeLow != oldConfig.DSP.DecodeLow || cfg.DSP.DecodeHigh != oldConfig.DSP.DecodeHigh {
+		e.passbandLow, e.passbandHigh = configuredPassband(cfg)
+	}
+	// The rig stays on its IF, which a new transverter puts on another RF
+	if shift := cfg.Radio.TransverterOffset - oldConfig.Radio.TransverterOffset; shift != 0 {
+		e.frequency += int(shift)
+	}
+	e.mutex.Unlock()
+
+	// Reopen the audio, radio, GPIO and OLED whose settings changed
+	restarted, err := e.applyHardwareConfig(cfg)
+	if err != nil {
+		logger.Errorf("Error applying hardware settings: %v", err)
+	}
+	if len(restarted) > 0 {
+		logger.Infof("Restarted %s", strings.Join(restarted, ", "))
+	}
+
+	// A new governor starts at full quality
+	e.applyDecodeLimits(dsp.DefaultDecodeLimits())
+
+	// Decoders rebuilt for a new channel layout start with the whole passband
+	e.applyPassband()
+
+	// Alarm thresholds can be applied without restarting audio
+	for _, monitor := range e.GetAudioMonitors() {
+		monitor.SetAlarmConfig(audioAlarmConfig(cfg))
+	}
+
+	return oldConfig, restarted, err
+}
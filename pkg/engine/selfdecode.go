@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// selfDecodeSpan is how far back a decode's audio may have started: a
+// frame takes up to a cycle and is decoded up to a cycle after it ends
+const selfDecodeSpan = 2 * busyCycle
+
+// selfBlankTail is how long the decoder stays blanked after unkeying, for
+// the rig's switch back to receive and audio still in its buffers
+const selfBlankTail = 500 * time.Millisecond
+
+// Why a decode was taken for one of our own transmissions
+const (
+	selfByCallsign = "callsign"  // It is from our callsign
+	selfByTXWindow = "tx_window" // It was at our TX offset while we were keyed
+)
+
+// txWindow is a transmission remembered to recognise its decode
+type txWindow struct {
+	start, end time.Time // end is zero while still keyed
+	offset     int
+}
+
+// selfDecodePolicy returns tx self_decode
+func (e *CoreEngine) selfDecodePolicy() string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return strings.ToLower(e.config.TX.SelfDecode)
+}
+
+// noteKeying remembers when PTT went on and off and at what offset,
+// forgetting transmissions too old to still be decoded
+func (e *CoreEngine) noteKeying(on bool, offset int, now time.Time) {
+	e.selfMutex.Lock()
+	defer e.selfMutex.Unlock()
+
+	recent := e.txWindows[:0]
+	for _, w := range e.txWindows {
+		if w.end.IsZero() || now.Sub(w.end) < selfDecodeSpan {
+			recent = append(recent, w)
+		}
+	}
+	e.txWindows = recent
+
+	last := len(e.txWindows) - 1
+	keyed := last >= 0 && e.txWindows[last].end.IsZero()
+	switch {
+	case on && !keyed:
+		e.txWindows = append(e.txWindows, txWindow{start: now, offset: offset})
+	case !on && keyed:
+		e.txWindows[last].end = now
+	}
+}
+
+// blankRX reports whether the decoder's audio is blanked at now: with tx
+// self_decode blank, while keyed and for selfBlankTail after
+func (e *CoreEngine) blankRX(now time.Time) bool {
+	if e.selfDecodePolicy() != config.SelfDecodeBlank {
+		return false
+	}
+	e.selfMutex.Lock()
+	defer e.selfMutex.Unlock()
+	if len(e.txWindows) == 0 {
+		return false
+	}
+	w := e.txWindows[len(e.txWindows)-1]
+	return w.end.IsZero() || now.Sub(w.end) < selfBlankTail
+}
+
+// selfDecode returns why a decode made at now is of one of our own
+// transmissions, empty when it is another station's or tx self_decode is
+// off. It is ours when it is from our callsign, or when its audio could
+// have overlapped a transmission at its offset.
+func (e *CoreEngine) selfDecode(msg protocol.Message, now time.Time) string {
+	if e.selfDecodePolicy() == config.SelfDecodeOff {
+		return ""
+	}
+	e.mutex.RLock()
+	callsign := e.config.Station.Callsign
+	e.mutex.RUnlock()
+	if callsign != "" && strings.EqualFold(msg.From, callsign) {
+		return selfByCallsign
+	}
+
+	e.selfMutex.Lock()
+	defer e.selfMutex.Unlock()
+	since := now.Add(-selfDecodeSpan)
+	for _, w := range e.txWindows {
+		if w.start.After(now) || (!w.end.IsZero() && w.end.Before(since)) {
+			continue
+		}
+		if diff := msg.Offset - w.offset; diff > -int(dsp.SignalBandwidth) && diff < int(dsp.SignalBandwidth) {
+			return selfByTXWindow
+		}
+	}
+	return ""
+}
+
+// handleSelfDecode tags a decode of our own transmission and publishes it
+// as a self_decode event. It goes no further: it is not stored, answered,
+// spotted or counted as a signal on the channel.
+func (e *CoreEngine) handleSelfDecode(msg protocol.Message, reason string) {
+	msg.Self = true
+	dspLogger.Infof("Own transmission decoded (%s): %s", reason, msg.Message)
+
+	e.selfMutex.Lock()
+	e.selfDecodes++
+	e.selfMutex.Unlock()
+
+	e.publishEvent(protocol.EventSelfDecode, map[string]interface{}{
+		"message": msg,
+		"reason":  reason,
+	})
+}
+
+// selfDecodeCount returns how many decodes of our own transmissions were
+// set aside
+func (e *CoreEngine) selfDecodeCount() int {
+	e.selfMutex.Lock()
+	defer e.selfMutex.Unlock()
+	return e.selfDecodes
+}
@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// selfTestMaxSNR bounds the SNR a self-test adds noise at, in dB
+const selfTestMaxSNR = 30
+
+// handleSelfTest encodes a message and decodes it again in software, on a
+// decoder of its own so receiving carries on: SELFTEST [SWEEP] [snr]
+// [message]. With an SNR noise is added to bring the signal down to it;
+// SWEEP also finds the weakest signal that still decodes.
+func (e *CoreEngine) handleSelfTest(args []string) *protocol.Response {
+	var snr *float64
+	sweep := false
+	for len(args) > 0 {
+		if strings.EqualFold(args[0], "SWEEP") {
+			sweep = true
+		} else if value, err := strconv.ParseFloat(args[0], 64); err == nil && snr == nil {
+			if value < -selfTestMaxSNR || value > selfTestMaxSNR {
+				return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid,
+					fmt.Sprintf("SNR must be between -%d and %d dB", selfTestMaxSNR, selfTestMaxSNR))
+			}
+			snr = &value
+		} else {
+			break
+		}
+		args = args[1:]
+	}
+	message := strings.Join(args, " ")
+
+	decoder, err := dsp.NewEngine(e.config.DSP.Decoder)
+	if err != nil {
+		return protocol.NewErrorResponse(err.Error())
+	}
+	if err := decoder.Initialize(); err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("self-test decoder failed to start: %v", err))
+	}
+	defer decoder.Close()
+
+	result, err := dsp.SelfTest(decoder, message, snr, sweep)
+	if err != nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, err.Error())
+	}
+
+	switch {
+	case !result.Passed:
+		logger.Warnf("DSP self-test failed: sent %s, decoded %q", result.Message, result.Decoded)
+	case result.Threshold != nil:
+		logger.Infof("DSP self-test passed on the %s decoder, decoding down to %.0f dB", result.Decoder, *result.Threshold)
+	default:
+		logger.Infof("DSP self-test passed on the %s decoder", result.Decoder)
+	}
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"selftest": result,
+	})
+}
@@ -0,0 +1,206 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/hardware"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// sensorHistoryAge is how far back the sensor history for the dashboard goes
+const sensorHistoryAge = 24 * time.Hour
+
+// sensorLoop samples the battery and temperature sensors every sensors
+// interval seconds. An INA219 that fails is closed and opened again at the
+// next sample, so a loose I2C connection recovers on its own.
+func (e *CoreEngine) sensorLoop() {
+	e.mutex.RLock()
+	cfg := e.config.Sensors
+	e.mutex.RUnlock()
+
+	var battery *hardware.INA219
+	defer func() {
+		if battery != nil {
+			battery.Close()
+		}
+	}()
+
+	ticker := time.NewTicker(time.Duration(cfg.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		reading := protocol.SensorReading{Time: time.Now()}
+		var errs []error
+
+		if cfg.INA219Address != 0 {
+			if battery == nil {
+				var err error
+				if battery, err = hardware.OpenINA219(cfg.I2CBus, cfg.INA219Address, cfg.ShuntOhms); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			if battery != nil {
+				voltage, current, err := battery.Read()
+				if err != nil {
+					errs = append(errs, err)
+					battery.Close()
+					battery = nil
+				} else {
+					reading.Voltage, reading.Current = &voltage, &current
+				}
+			}
+		}
+
+		if cfg.Temperature {
+			temperatures, err := hardware.ReadTemperatures(hardware.OneWireDevices, cfg.TemperatureSensors)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if len(temperatures) > 0 {
+				reading.Temperatures = temperatures
+			}
+		}
+
+		e.recordSensors(reading, errors.Join(errs...))
+
+		select {
+		case <-ticker.C:
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// recordSensors keeps a sensor reading, stopping TX when the battery drops
+// below sensors min_voltage and allowing it again once the battery is back
+// above it by config.SensorVoltageHysteresis. A reading without a voltage
+// leaves TX as it was.
+func (e *CoreEngine) recordSensors(reading protocol.SensorReading, err error) {
+	e.mutex.RLock()
+	minVoltage := e.config.Sensors.MinVoltage
+	e.mutex.RUnlock()
+
+	e.sensorMutex.Lock()
+	defer e.sensorMutex.Unlock()
+
+	e.sensorError = ""
+	if err != nil {
+		e.sensorError = err.Error()
+		logger.Warnf("Failed to read sensors: %v", err)
+	}
+	if reading.Voltage == nil && reading.Temperatures == nil {
+		return
+	}
+
+	cutoff := reading.Time.Add(-sensorHistoryAge)
+	drop := 0
+	for drop < len(e.sensorHistory) && e.sensorHistory[drop].Time.Before(cutoff) {
+		drop++
+	}
+	e.sensorHistory = append(e.sensorHistory[drop:], reading)
+
+	if reading.Voltage == nil {
+		return
+	}
+	voltage := *reading.Voltage
+	logger.Debugf("Battery at %.2f V", voltage)
+	switch {
+	case minVoltage <= 0:
+		e.lowBattery = false
+	case !e.lowBattery && voltage < minVoltage:
+		e.lowBattery = true
+		logger.Warnf("Battery at %.2f V, below %.2f V: TX stopped", voltage, minVoltage)
+	case e.lowBattery && voltage >= minVoltage+config.SensorVoltageHysteresis:
+		e.lowBattery = false
+		logger.Infof("Battery back up to %.2f V, TX resumed", voltage)
+	}
+}
+
+// batteryLow returns the latest battery voltage and whether it has stopped
+// TX
+func (e *CoreEngine) batteryLow() (float64, bool) {
+	e.sensorMutex.RLock()
+	defer e.sensorMutex.RUnlock()
+
+	if !e.lowBattery {
+		return 0, false
+	}
+	for i := len(e.sensorHistory) - 1; i >= 0; i-- {
+		if voltage := e.sensorHistory[i].Voltage; voltage != nil {
+			return *voltage, true
+		}
+	}
+	return 0, true
+}
+
+// batteryVoltage returns the latest battery voltage read, reporting false
+// when there is none
+func (e *CoreEngine) batteryVoltage() (float64, bool) {
+	e.sensorMutex.RLock()
+	defer e.sensorMutex.RUnlock()
+
+	for i := len(e.sensorHistory) - 1; i >= 0; i-- {
+		if voltage := e.sensorHistory[i].Voltage; voltage != nil {
+			return *voltage, true
+		}
+	}
+	return 0, false
+}
+
+// txInhibitedError explains why a low battery or RX-only low power mode
+// has stopped TX, nil when neither has
+func (e *CoreEngine) txInhibitedError() error {
+	if voltage, low := e.batteryLow(); low {
+		return fmt.Errorf("battery at %.2f V is below sensors min_voltage, TX inhibited", voltage)
+	}
+	e.mutex.RLock()
+	rxOnly := e.config.Power.RXOnly
+	e.mutex.RUnlock()
+	if e.lowPowerActive(rxOnly) {
+		return fmt.Errorf("low power mode is RX only, TX inhibited")
+	}
+	return nil
+}
+
+// sensorStatus reports the latest sensor reading for STATUS, nil when the
+// sensors are not enabled. It does not take e.mutex, which STATUS holds.
+func (e *CoreEngine) sensorStatus() *protocol.SensorStatus {
+	if !e.config.Sensors.Enabled {
+		return nil
+	}
+
+	e.sensorMutex.RLock()
+	defer e.sensorMutex.RUnlock()
+
+	status := &protocol.SensorStatus{
+		MinVoltage: e.config.Sensors.MinVoltage,
+		LowBattery: e.lowBattery,
+		Error:      e.sensorError,
+	}
+	if n := len(e.sensorHistory); n > 0 {
+		latest := e.sensorHistory[n-1]
+		status.Latest = &latest
+	}
+	return status
+}
+
+// handleSensors returns the latest sensor reading and the last day's
+// readings, oldest first
+func (e *CoreEngine) handleSensors() *protocol.Response {
+	status := e.sensorStatus()
+	if status == nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeNotConnected, "sensors are not enabled")
+	}
+
+	e.sensorMutex.RLock()
+	history := append([]protocol.SensorReading{}, e.sensorHistory...)
+	e.sensorMutex.RUnlock()
+
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"sensors": status,
+		"history": history,
+	})
+}
@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ShutdownTimeout is how long Stop lets a transmission in progress run on,
+// one normal-mode JS8 frame
+const ShutdownTimeout = 15 * time.Second
+
+// abortGrace is how long an aborted transmission has to unkey the radio
+const abortGrace = 2 * time.Second
+
+// startLoop runs one of the engine's long-lived goroutines under the
+// supervisor, for Shutdown to cancel and wait for
+func (e *CoreEngine) startLoop(name string, run func()) {
+	e.loops.Add(1)
+	go func() {
+		defer e.loops.Done()
+		e.supervise(name, e.ctx.Done(), run)
+	}()
+}
+
+// Stop gracefully shuts down the core engine, giving a transmission in
+// progress ShutdownTimeout to finish
+func (e *CoreEngine) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	return e.Shutdown(ctx)
+}
+
+// Shutdown stops the engine. A transmission in progress runs on until ctx is
+// done and is aborted after that. PTT is dropped before the hardware closes
+// whichever way it ends, and messages still waiting for TX stay queued in
+// the message store for the next start.
+func (e *CoreEngine) Shutdown(ctx context.Context) error {
+	logger.Infof("Stopping core engine...")
+
+	e.mutex.Lock()
+	e.running = false
+	e.mutex.Unlock()
+
+	// Take no new commands while the queue drains
+	if e.listener != nil {
+		if err := e.listener.Close(); err != nil {
+			logger.Errorf("Error closing listener: %v", err)
+		}
+	}
+	if e.grpcServer != nil {
+		e.grpcServer.Stop()
+	}
+
+	// Idle loops return at once, the transmit loop once its frame is sent
+	e.cancel()
+	if !waitFor(ctx, &e.loops) {
+		logger.Warnf("Shutdown deadline reached, aborting transmission")
+		select {
+		case e.abortTx <- true:
+		default:
+		}
+
+		grace, cancel := context.WithTimeout(context.Background(), abortGrace)
+		if !waitFor(grace, &e.loops) {
+			logger.Errorf("Engine goroutines did not stop within %s of the abort", abortGrace)
+		}
+		cancel()
+	}
+
+	// Let the audio goroutines finish before the devices close
+	e.stopAudio()
+	e.CancelRedecode()
+
+	// Finish the recording being written
+	if e.recorder != nil {
+		if err := e.recorder.Close(); err != nil {
+			logger.Errorf("Error closing band recorder: %v", err)
+		}
+	}
+
+	// Never leave the transmitter keyed, even if the transmit loop is stuck
+	e.dropPTT()
+
+	// Messages that never went out stay queued in the message store
+	e.drainPending()
+
+	// Close message store
+	e.msgMutex.Lock()
+	if e.messageStore != nil {
+		if err := e.messageStore.Close(); err != nil {
+			logger.Errorf("Error closing message store: %v", err)
+		}
+	}
+	e.msgMutex.Unlock()
+
+	// Close the antenna switch, leaving the antenna connected
+	e.closeAntenna()
+
+	// Close hardware manager
+	if e.hardwareManager != nil {
+		e.hardwareManager.Close()
+	}
+
+	logger.Infof("Core engine stopped")
+	return ctx.Err()
+}
+
+// waitFor waits for wg until ctx is done, reporting whether it finished
+func waitFor(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// dropPTT unkeys the radio and the GPIO PTT line, retrying the radio, then
+// releases a keyed amplifier amp_tail later
+func (e *CoreEngine) dropPTT() {
+	for attempts := 0; attempts < 3; attempts++ {
+		err := e.hardwareManager.SetRadioPTT(false)
+		if err == nil {
+			break
+		}
+		logger.Warnf("Failed to clear radio PTT (attempt %d/3): %v", attempts+1, err)
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err := e.hardwareManager.SetPTT(false); err != nil {
+		logger.Warnf("Failed to clear GPIO PTT: %v", err)
+	}
+
+	e.mutex.Lock()
+	e.ptt = false
+	e.mutex.Unlock()
+
+	// The rig's RF has died away before the amplifier switches back
+	if e.hardwareManager.GetAmpKey() {
+		time.Sleep(e.pttSequence().ampTail)
+		if err := e.hardwareManager.SetAmpKey(false); err != nil {
+			logger.Warnf("Failed to release amplifier: %v", err)
+		}
+	}
+}
+
+// drainPending empties the TX queue. The messages are already stored as
+// queued, so the next start sends them.
+func (e *CoreEngine) drainPending() {
+	kept := 0
+	for {
+		select {
+		case <-e.txMessages:
+			kept++
+		default:
+			if kept > 0 {
+				logger.Infof("%d queued TX messages kept for the next start", kept)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/audio"
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// snippetLead is how much audio before a signal starts goes in its snippet
+const snippetLead = 0.5
+
+// openSnippets opens the snippet directory storage snippet_dir names, nil
+// when snippets are off
+func openSnippets(cfg *config.Config) (*audio.Snippets, error) {
+	if cfg.Storage.SnippetDir == "" {
+		return nil, nil
+	}
+	return audio.NewSnippets(cfg.Storage.SnippetDir, int64(cfg.Storage.SnippetQuota)<<20)
+}
+
+// captureSnippet cuts the audio of a decode out of the buffer it was decoded
+// from, starting snippetLead seconds before the signal, and brings it down
+// to the snippet rate. dt is the decode's time offset from the nominal start
+// half a second into the buffer.
+func captureSnippet(buffer []int16, sampleRate int, dt float32, seconds int) []int16 {
+	start := int((float64(dt) + 0.5 - snippetLead) * float64(sampleRate))
+	if start < 0 {
+		start = 0
+	}
+	end := start + seconds*sampleRate
+	if end > len(buffer) {
+		end = len(buffer)
+	}
+	if start >= end {
+		return nil
+	}
+
+	// The buffer is reused for the next audio, so keep a copy
+	window := buffer[start:end]
+	if sampleRate <= audio.SnippetRate {
+		return append([]int16(nil), window...)
+	}
+	return audio.Resample(window, sampleRate, audio.SnippetRate)
+}
+
+// snippetName names the snippet of a decode by its time, audio offset and
+// RX channel
+func snippetName(msg protocol.Message) string {
+	name := fmt.Sprintf("%s-%d", msg.Timestamp.UTC().Format("20060102-150405"), msg.Offset)
+	if msg.Channel != "" {
+		name += "-" + strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+				return r
+			}
+			return '_'
+		}, msg.Channel)
+	}
+	return name + audio.SnippetExt
+}
+
+// saveSnippet writes the audio of a stored decode to its snippet and drops
+// the references to any removed to stay within storage snippet_quota
+func (e *CoreEngine) saveSnippet(msg protocol.Message) {
+	removed, err := e.snippets.Save(msg.Snippet, msg.Audio)
+	if err != nil {
+		logger.Warnf("Failed to save the audio of %s's decode: %v", msg.From, err)
+		removed = append(removed, msg.Snippet)
+	}
+	if len(removed) == 0 {
+		return
+	}
+
+	e.msgMutex.Lock()
+	defer e.msgMutex.Unlock()
+	if e.messageStore == nil {
+		return
+	}
+	if _, err := e.messageStore.ClearSnippets(removed); err != nil {
+		logger.Warnf("Failed to clear removed snippets: %v", err)
+	}
+}
+
+// snippetStats returns the snippets kept for the message stats, nil when
+// they are off
+func (e *CoreEngine) snippetStats() map[string]interface{} {
+	if e.snippets == nil {
+		return nil
+	}
+	count, used, quota, err := e.snippets.Usage()
+	stats := map[string]interface{}{
+		"count": count,
+		"bytes": used,
+		"quota": quota,
+	}
+	if err != nil {
+		stats["error"] = err.Error()
+	}
+	return stats
+}
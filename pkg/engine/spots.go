@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/spots"
+)
+
+// startSpots forwards every decode to the spots webhooks, when there are any
+func (e *CoreEngine) startSpots() {
+	cfg := e.config.Spots
+	if len(cfg.Webhooks) == 0 {
+		return
+	}
+
+	hooks := make([]spots.Webhook, len(cfg.Webhooks))
+	for i, hook := range cfg.Webhooks {
+		hooks[i] = spots.Webhook{URL: hook.URL, Secret: hook.Secret.Value()}
+	}
+	retries := cfg.Retries
+	if retries < 0 {
+		retries = 0
+	}
+
+	forwarder := spots.New(spots.Reporter{
+		Callsign: e.config.Station.Callsign,
+		Grid:     e.config.Station.Grid,
+	}, hooks, spots.Options{
+		BatchSize:     cfg.BatchSize,
+		BatchInterval: time.Duration(cfg.BatchInterval) * time.Second,
+		Retries:       retries,
+		OnError: func(url string, err error) {
+			logger.Warnf("Spots webhook %s: %v", url, err)
+		},
+	})
+
+	decodes, cancel := e.SubscribeDecodes()
+	e.startLoop("spots", func() {
+		defer cancel()
+		go forwarder.Run(e.ctx)
+
+		for {
+			select {
+			case msg := <-decodes:
+				if !forwarder.Add(spotFor(msg)) {
+					logger.Warnf("Spots queue full, dropped %s", msg.From)
+				}
+			case <-e.ctx.Done():
+				return
+			}
+		}
+	})
+	logger.Infof("Forwarding spots to %d webhooks", len(hooks))
+}
+
+// spotFor describes a decode for the spots webhooks
+func spotFor(msg protocol.Message) spots.Spot {
+	return spots.Spot{
+		Time:      msg.Timestamp.UTC(),
+		From:      msg.From,
+		To:        msg.To,
+		Grid:      heardGrid(msg),
+		Message:   msg.Message,
+		SNR:       msg.SNR,
+		Frequency: msg.Frequency,
+		Offset:    msg.Offset,
+		Band:      protocol.Band(msg.Frequency),
+		Mode:      msg.Mode,
+		Channel:   msg.Channel,
+	}
+}
@@ -0,0 +1,177 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/storage"
+)
+
+// heardGrid returns the grid square a station gave in a decode, if any
+func heardGrid(msg protocol.Message) string {
+	for _, word := range strings.Fields(strings.ToUpper(msg.Message)) {
+		word = strings.Trim(word, ".,:;!?")
+		// RR73 has the shape of a grid but signs off
+		if word == "RR73" || word == msg.From || word == msg.To {
+			continue
+		}
+		if (len(word) == 4 || len(word) == 6) && dsp.IsGridSquare(word) {
+			return word
+		}
+	}
+	return ""
+}
+
+// recordHeard adds a decode to the station database. The caller holds
+// msgMutex.
+func (e *CoreEngine) recordHeard(msg protocol.Message) {
+	if msg.From == "" {
+		return
+	}
+	if err := e.messageStore.RecordHeard(msg.From, heardGrid(msg), msg.Timestamp, msg.SNR); err != nil {
+		logger.Warnf("Failed to record %s in the station database: %v", msg.From, err)
+		return
+	}
+	if err := e.messageStore.RecordSNR(msg.From, msg.Timestamp, msg.SNR, msg.Frequency); err != nil {
+		logger.Warnf("Failed to record the SNR of %s: %v", msg.From, err)
+	}
+	e.recordEntity(msg.From)
+	e.queueLookup(msg.From)
+}
+
+// handleStation handles the STATION command, which looks a station up or
+// edits its name, QTH, notes and QSL status
+func (e *CoreEngine) handleStation(cmd *protocol.Command) *protocol.Response {
+	callsign := strings.ToUpper(strings.TrimSpace(cmd.StringArg("callsign")))
+	if callsign == "" {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "callsign required")
+	}
+
+	var update storage.StationUpdate
+	for field, value := range cmd.StationEdits() {
+		value := value
+		switch field {
+		case "name":
+			update.Name = &value
+		case "qth":
+			update.QTH = &value
+		case "notes":
+			update.Notes = &value
+		case "qsl_status":
+			value = strings.ToLower(value)
+			if !storage.ValidQSLStatus(value) {
+				return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid,
+					fmt.Sprintf("qsl_status must be one of %q, %q, %q or empty", storage.QSLSent, storage.QSLReceived, storage.QSLConfirmed))
+			}
+			update.QSLStatus = &value
+		}
+	}
+	edited := len(cmd.StationEdits()) > 0
+
+	e.msgMutex.Lock()
+	defer e.msgMutex.Unlock()
+	if e.messageStore == nil {
+		return protocol.NewErrorResponse("message storage not available")
+	}
+
+	var station *storage.Station
+	var err error
+	if edited {
+		station, err = e.messageStore.UpdateStation(callsign, update)
+	} else {
+		station, err = e.messageStore.GetStation(callsign)
+	}
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to get station: %v", err))
+	}
+	if station == nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("station %s is not in the station database", callsign))
+	}
+	station.Path = e.pathTo(station.Grid)
+	station.Entity = e.entityOf(station.Callsign)
+
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"station": station,
+	})
+}
+
+// handleGetStations handles GET_STATIONS [limit] [search]
+func (e *CoreEngine) handleGetStations(args []string) *protocol.Response {
+	limit := 50
+	if len(args) > 0 {
+		if l, err := strconv.Atoi(args[0]); err == nil {
+			limit = l
+		}
+	}
+	search := ""
+	if len(args) > 1 {
+		search = strings.Join(args[1:], " ")
+	}
+
+	e.msgMutex.RLock()
+	defer e.msgMutex.RUnlock()
+	if e.messageStore == nil {
+		return protocol.NewErrorResponse("message storage not available")
+	}
+
+	stations, err := e.messageStore.GetStations(search, limit)
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to get stations: %v", err))
+	}
+	e.addStationPaths(stations)
+
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"stations": stations,
+		"count":    len(stations),
+	})
+}
+
+// handleGetSNRHistory handles GET_SNR_HISTORY callsign from to, with Unix
+// times: every decode of the station in the range and the trend of its SNR
+func (e *CoreEngine) handleGetSNRHistory(args []string) *protocol.Response {
+	if len(args) < 3 {
+		return protocol.NewErrorResponse("usage: GET_SNR_HISTORY <callsign> <from> <to>")
+	}
+	callsign := strings.ToUpper(args[0])
+	from, err1 := strconv.ParseInt(args[1], 10, 64)
+	to, err2 := strconv.ParseInt(args[2], 10, 64)
+	if err1 != nil || err2 != nil || to <= from {
+		return protocol.NewErrorResponse(fmt.Sprintf("invalid time range %s to %s", args[1], args[2]))
+	}
+	start, end := time.Unix(from, 0), time.Unix(to, 0)
+
+	e.msgMutex.RLock()
+	defer e.msgMutex.RUnlock()
+	if e.messageStore == nil {
+		return protocol.NewErrorResponse("message storage not available")
+	}
+
+	points, err := e.messageStore.GetSNRHistory(callsign, start, end)
+	if err != nil {
+		return protocol.NewErrorResponse(err.Error())
+	}
+
+	var average *float64
+	if len(points) > 0 {
+		total := 0.0
+		for _, p := range points {
+			total += float64(p.SNR)
+		}
+		avg := total / float64(len(points))
+		average = &avg
+	}
+
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"callsign": callsign,
+		"from":     start.UTC(),
+		"to":       end.UTC(),
+		"points":   points,
+		"count":    len(points),
+		"avg_snr":  average,
+		"trend":    storage.SNRTrend(points),
+	})
+}
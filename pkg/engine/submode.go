@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/config"
+	"github.com/dougsko/js8d/pkg/dsp"
+)
+
+// checkDecoding returns an error for settings of cfg the decoder can't
+// honour. Adaptive submodes, and resends escalated to slower submodes, move
+// messages to a station off Normal, and the station's replies in the
+// submode it was called in are lost to a decoder that can't decode it.
+func (e *CoreEngine) checkDecoding(cfg *config.Config) error {
+	var setting, off string
+	switch {
+	case cfg.Submode.Adaptive:
+		setting, off = "submode adaptive", "turn submode adaptive off"
+	case cfg.Messages.Escalate == config.EscalateSubmode || cfg.Messages.Escalate == config.EscalateBoth:
+		setting, off = "messages escalate "+cfg.Messages.Escalate, "escalate by offset or not at all"
+	default:
+		return nil
+	}
+	for _, submode := range config.Submodes {
+		if !e.decodesSubmode(submode) {
+			return fmt.Errorf("%s needs a decoder for every submode, and the %s decoder can't decode %s; %s",
+				setting, decoderName(e.dspEngine), submode, off)
+		}
+	}
+	return nil
+}
+
+// decodesSubmode reports whether the decoder decodes signals in submode
+func (e *CoreEngine) decodesSubmode(submode string) bool {
+	mode, err := dsp.ParseJS8Mode(submode)
+	if err != nil {
+		return false
+	}
+	decoder, ok := e.dspEngine.(dsp.SubmodeDecoder)
+	return !ok || decoder.DecodesSubmode(mode)
+}
+
+// chooseSubmode returns the submode a message to to goes out in: with
+// adaptive submodes on, the fastest the average SNR of the station's recent
+// decodes supports, and the default submode otherwise. It also returns the
+// reason for the choice, for the log.
+func (e *CoreEngine) chooseSubmode(to string, now time.Time) (string, string) {
+	e.mutex.RLock()
+	cfg := e.config.Submode
+	e.mutex.RUnlock()
+
+	submode := cfg.Default
+	if submode == "" {
+		submode = dsp.ModeNormal.String()
+	}
+	if !cfg.Adaptive {
+		return submode, "default"
+	}
+	if to == "" || strings.HasPrefix(to, "@") {
+		return submode, "default, not to a station"
+	}
+
+	window := time.Duration(cfg.Window) * time.Minute
+	e.msgMutex.RLock()
+	if e.messageStore == nil {
+		e.msgMutex.RUnlock()
+		return submode, "default, no SNR history"
+	}
+	points, err := e.messageStore.GetSNRHistory(to, now.Add(-window), now.Add(time.Second))
+	e.msgMutex.RUnlock()
+	if err != nil {
+		logger.Warnf("Failed to read the SNR history of %s: %v", to, err)
+		return submode, "default, no SNR history"
+	}
+	if len(points) == 0 || len(points) < cfg.MinDecodes {
+		return submode, "default, too few recent decodes"
+	}
+
+	var sum float64
+	for _, point := range points {
+		sum += float64(point.SNR)
+	}
+	average := sum / float64(len(points))
+	reason := fmt.Sprintf("average SNR %+.1f dB over %d decodes", average, len(points))
+	switch {
+	case average >= cfg.Turbo:
+		return dsp.ModeTurbo.String(), reason
+	case average >= cfg.Fast:
+		return dsp.ModeFast.String(), reason
+	case average >= cfg.Normal:
+		return dsp.ModeNormal.String(), reason
+	default:
+		return dsp.ModeSlow.String(), reason
+	}
+}
@@ -0,0 +1,50 @@
+package engine
+
+// Supervisor runs the engine's long-lived goroutines and keeps them going
+// when they panic, so one bad decode doesn't stop an unattended station
+type Supervisor interface {
+	// Run calls run and, each time it panics, calls it again after a
+	// backoff, until it returns normally or stop is closed
+	Run(name string, stop <-chan struct{}, run func())
+
+	// Recovered records a panic recovered from a goroutine that is not
+	// restarted, such as a single socket connection
+	Recovered(name string, value interface{})
+
+	// Restarts returns the number of panics recovered for each goroutine
+	Restarts() map[string]int
+}
+
+// SetSupervisor makes s run the engine's goroutines. It must be called
+// before Start; without one a panic stops the process as usual.
+func (e *CoreEngine) SetSupervisor(s Supervisor) {
+	e.supervisor = s
+}
+
+// supervise runs one of the engine's goroutines under the supervisor
+func (e *CoreEngine) supervise(name string, stop <-chan struct{}, run func()) {
+	if e.supervisor == nil {
+		run()
+		return
+	}
+	e.supervisor.Run(name, stop, run)
+}
+
+// recoverPanic reports a panic in a goroutine that ends with it. It must be
+// deferred directly.
+func (e *CoreEngine) recoverPanic(name string) {
+	if e.supervisor == nil {
+		return
+	}
+	if r := recover(); r != nil {
+		e.supervisor.Recovered(name, r)
+	}
+}
+
+// restartCounts returns the supervisor's restart counts for STATUS
+func (e *CoreEngine) restartCounts() map[string]int {
+	if e.supervisor == nil {
+		return map[string]int{}
+	}
+	return e.supervisor.Restarts()
+}
@@ -0,0 +1,342 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/storage"
+	"github.com/dougsko/js8d/pkg/telemetry"
+)
+
+// telemetryCheckInterval is how often the telemetry schedule is checked
+const telemetryCheckInterval = 15 * time.Second
+
+// telemetryReadingAge is how old the latest sensor reading may be and
+// still be sent
+const telemetryReadingAge = time.Hour
+
+// telemetryLoop sends this station's telemetry as it comes due
+func (e *CoreEngine) telemetryLoop() {
+	ticker := time.NewTicker(telemetryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		e.checkTelemetry(time.Now())
+
+		select {
+		case <-ticker.C:
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// nextTelemetryTime returns when the telemetry after one at from is due:
+// telemetry interval minutes later, moved up to a tenth of that either way
+// so nodes started together don't all send at once
+func (e *CoreEngine) nextTelemetryTime(from time.Time) time.Time {
+	e.mutex.RLock()
+	interval := time.Duration(e.config.Telemetry.Interval) * time.Minute
+	e.mutex.RUnlock()
+
+	jitter := int64(interval / 10)
+	next := from.Add(interval)
+	if jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(2*jitter+1) - jitter))
+	}
+	return next
+}
+
+// checkTelemetry queues the telemetry when it is due, holding it back for
+// the same reasons as beacons
+func (e *CoreEngine) checkTelemetry(now time.Time) {
+	e.mutex.RLock()
+	enabled := e.config.Telemetry.Enabled
+	e.mutex.RUnlock()
+
+	e.telemetryMutex.Lock()
+	if !enabled {
+		e.nextTelemetry = time.Time{}
+		e.telemetryMutex.Unlock()
+		return
+	}
+	if e.nextTelemetry.IsZero() {
+		e.nextTelemetry = e.nextTelemetryTime(now)
+	}
+	due := !now.Before(e.nextTelemetry)
+	e.telemetryMutex.Unlock()
+	if !due {
+		return
+	}
+	if held := e.beaconHeld(now); held != "" {
+		logger.Debugf("Telemetry held back: %s", held)
+		return
+	}
+
+	// A reading that can't be sent waits for the next interval rather
+	// than being tried every check
+	e.sendTelemetry(now)
+	e.telemetryMutex.Lock()
+	e.nextTelemetry = e.nextTelemetryTime(now)
+	e.telemetryMutex.Unlock()
+}
+
+// telemetryReading returns this station's telemetry at now: the telemetry
+// fields read by the sensors in the last hour, and the time since js8d
+// started
+func (e *CoreEngine) telemetryReading(now time.Time) telemetry.Reading {
+	e.mutex.RLock()
+	fields := append([]string{}, e.config.Telemetry.Fields...)
+	sensors := append([]string{}, e.config.Sensors.TemperatureSensors...)
+	e.mutex.RUnlock()
+
+	reading := telemetry.Reading{Callsign: e.config.Station.Callsign}
+
+	e.sensorMutex.RLock()
+	var latest protocol.SensorReading
+	if n := len(e.sensorHistory); n > 0 && now.Sub(e.sensorHistory[n-1].Time) < telemetryReadingAge {
+		latest = e.sensorHistory[n-1]
+	}
+	e.sensorMutex.RUnlock()
+
+	for _, field := range fields {
+		switch strings.ToLower(field) {
+		case telemetry.FieldVoltage:
+			reading.Voltage = latest.Voltage
+		case telemetry.FieldCurrent:
+			reading.Current = latest.Current
+		case telemetry.FieldTemperature:
+			if temperature, ok := firstTemperature(latest.Temperatures, sensors); ok {
+				reading.Temperature = &temperature
+			}
+		case telemetry.FieldUptime:
+			uptime := now.Sub(e.startTime)
+			reading.Uptime = &uptime
+		}
+	}
+	return reading
+}
+
+// firstTemperature returns the temperature of the first of sensors read,
+// or of the lowest sensor ID when none of them was
+func firstTemperature(temperatures map[string]float64, sensors []string) (float64, bool) {
+	for _, id := range sensors {
+		if temperature, ok := temperatures[id]; ok {
+			return temperature, true
+		}
+	}
+	ids := make([]string, 0, len(temperatures))
+	for id := range temperatures {
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return 0, false
+	}
+	sort.Strings(ids)
+	return temperatures[ids[0]], true
+}
+
+// sendTelemetry queues this station's telemetry as data frames, all of
+// them or none, returning how many
+func (e *CoreEngine) sendTelemetry(now time.Time) (int, telemetry.Reading, error) {
+	reading := e.telemetryReading(now)
+	fail := func(err error) (int, telemetry.Reading, error) {
+		logger.Warnf("Not sending telemetry: %v", err)
+		return 0, reading, err
+	}
+	if offset, off := e.clockOff(); off {
+		return fail(fmt.Errorf("clock is %.1f s off", offset.Seconds()))
+	}
+	if err := e.txInhibitedError(); err != nil {
+		return fail(err)
+	}
+	frames, err := telemetry.Frames(reading, uint8(rand.Intn(64)))
+	if err != nil {
+		return fail(err)
+	}
+
+	if room := cap(e.txMessages) - len(e.txMessages); len(frames) > room {
+		return fail(fmt.Errorf("telemetry needs %d transmissions but the transmit queue has room for %d", len(frames), room))
+	}
+	for _, frame := range frames {
+		msg := protocol.Message{
+			ID:        int(time.Now().Unix()),
+			Timestamp: time.Now(),
+			From:      e.config.Station.Callsign,
+			Message:   frame,
+			Mode:      "JS8",
+		}
+		if _, ok := e.queueTX(msg); !ok {
+			return fail(fmt.Errorf("transmit queue full"))
+		}
+	}
+	logger.Infof("TX queued: telemetry in %d frames", len(frames))
+
+	e.telemetryMutex.Lock()
+	e.lastTelemetry = now
+	e.telemetryMutex.Unlock()
+	return len(frames), reading, nil
+}
+
+// telemetryRecord turns a reading into the form it is stored and shown in,
+// heard in msg
+func telemetryRecord(r telemetry.Reading, msg protocol.Message) storage.Telemetry {
+	record := storage.Telemetry{
+		Callsign:    r.Callsign,
+		Time:        msg.Timestamp,
+		Frequency:   msg.Frequency,
+		SNR:         msg.SNR,
+		Voltage:     r.Voltage,
+		Current:     r.Current,
+		Temperature: r.Temperature,
+	}
+	if r.Uptime != nil {
+		seconds := int64(r.Uptime.Seconds())
+		record.Uptime = &seconds
+	}
+	return record
+}
+
+// receiveTelemetry stores the telemetry in a payload put together from
+// data frames and announces it
+func (e *CoreEngine) receiveTelemetry(payload []byte, msg protocol.Message, frames int) {
+	reading, err := telemetry.Decode(payload)
+	if err != nil {
+		logger.Warnf("Dropped telemetry of %d frames: %v", frames, err)
+		return
+	}
+	record := telemetryRecord(reading, msg)
+	logger.Infof("RX: telemetry from %s in %d frames", record.Callsign, frames)
+
+	e.msgMutex.Lock()
+	if e.messageStore != nil {
+		if err := e.messageStore.SaveTelemetry(&record); err != nil {
+			logger.Errorf("Failed to store telemetry from %s: %v", record.Callsign, err)
+		}
+	}
+	e.msgMutex.Unlock()
+
+	e.publishEvent(protocol.EventTelemetry, map[string]interface{}{
+		"telemetry": record,
+	})
+}
+
+// handleTelemetry handles the TELEMETRY command: the telemetry schedule
+// and the reading it would send, TELEMETRY NOW to send it at once and
+// TELEMETRY STATIONS for the latest reading heard from each station
+func (e *CoreEngine) handleTelemetry(args []string) *protocol.Response {
+	action := ""
+	if len(args) > 0 {
+		action = strings.ToUpper(args[0])
+	}
+
+	switch action {
+	case "":
+		return e.telemetryStatus(time.Now())
+
+	case "NOW":
+		now := time.Now()
+		frames, reading, err := e.sendTelemetry(now)
+		if err != nil {
+			return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, err.Error())
+		}
+		e.mutex.RLock()
+		enabled := e.config.Telemetry.Enabled
+		e.mutex.RUnlock()
+		if enabled {
+			e.telemetryMutex.Lock()
+			e.nextTelemetry = e.nextTelemetryTime(now)
+			e.telemetryMutex.Unlock()
+		}
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"status":  "queued",
+			"frames":  frames,
+			"reading": telemetryRecord(reading, protocol.Message{Timestamp: now}),
+		})
+
+	case "STATIONS":
+		e.msgMutex.RLock()
+		defer e.msgMutex.RUnlock()
+		if e.messageStore == nil {
+			return protocol.NewErrorResponse("message storage not available")
+		}
+		stations, err := e.messageStore.GetTelemetryStations()
+		if err != nil {
+			return protocol.NewErrorResponse(err.Error())
+		}
+		return protocol.NewSuccessResponse(map[string]interface{}{
+			"stations": stations,
+			"count":    len(stations),
+		})
+
+	default:
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid,
+			fmt.Sprintf("unknown TELEMETRY action %q, use NOW or STATIONS", args[0]))
+	}
+}
+
+// telemetryStatus returns the telemetry settings, when the next is due and
+// the reading it would send now
+func (e *CoreEngine) telemetryStatus(now time.Time) *protocol.Response {
+	e.mutex.RLock()
+	cfg := e.config.Telemetry
+	e.mutex.RUnlock()
+
+	data := map[string]interface{}{
+		"enabled":  cfg.Enabled,
+		"interval": cfg.Interval,
+		"fields":   append([]string{}, cfg.Fields...),
+		"reading":  telemetryRecord(e.telemetryReading(now), protocol.Message{Timestamp: now}),
+	}
+	if held := e.beaconHeld(now); cfg.Enabled && held != "" {
+		data["held"] = held
+	}
+
+	e.telemetryMutex.Lock()
+	defer e.telemetryMutex.Unlock()
+	if cfg.Enabled && !e.nextTelemetry.IsZero() {
+		data["next"] = e.nextTelemetry
+	}
+	if !e.lastTelemetry.IsZero() {
+		data["last"] = e.lastTelemetry
+	}
+	return protocol.NewSuccessResponse(data)
+}
+
+// handleGetTelemetry returns a station's telemetry between two Unix times,
+// oldest first, for charting
+func (e *CoreEngine) handleGetTelemetry(args []string) *protocol.Response {
+	if len(args) < 3 {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, "usage: GET_TELEMETRY <callsign> <from> <to>")
+	}
+	callsign := strings.ToUpper(args[0])
+	from, err1 := strconv.ParseInt(args[1], 10, 64)
+	to, err2 := strconv.ParseInt(args[2], 10, 64)
+	if err1 != nil || err2 != nil || to <= from {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeInvalid, fmt.Sprintf("invalid time range %s to %s", args[1], args[2]))
+	}
+	start, end := time.Unix(from, 0), time.Unix(to, 0)
+
+	e.msgMutex.RLock()
+	defer e.msgMutex.RUnlock()
+	if e.messageStore == nil {
+		return protocol.NewErrorResponse("message storage not available")
+	}
+
+	points, err := e.messageStore.GetTelemetry(callsign, start, end)
+	if err != nil {
+		return protocol.NewErrorResponse(err.Error())
+	}
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"callsign": callsign,
+		"from":     start.UTC(),
+		"to":       end.UTC(),
+		"points":   points,
+		"count":    len(points),
+	})
+}
@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/timesync"
+)
+
+// timeSyncTimeout bounds one check of the system clock
+const timeSyncTimeout = 10 * time.Second
+
+// timeSyncLoop measures the clock offset at startup and then every time_sync
+// interval minutes, warning when the clock drifts out of JS8's tolerance
+func (e *CoreEngine) timeSyncLoop() {
+	checker := &timesync.Checker{
+		Source: e.config.TimeSync.Source,
+		Server: e.config.TimeSync.Server,
+	}
+
+	ticker := time.NewTicker(time.Duration(e.config.TimeSync.Interval) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(e.ctx, timeSyncTimeout)
+		result, err := checker.Check(ctx)
+		cancel()
+		e.recordClockCheck(result, err)
+
+		select {
+		case <-ticker.C:
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// recordClockCheck keeps a clock check, logging when the clock goes out of
+// or comes back into tolerance. A failed check keeps the last offset.
+func (e *CoreEngine) recordClockCheck(result *timesync.Result, err error) {
+	_, wasOff := e.clockOff()
+
+	e.clockMutex.Lock()
+	e.clockError = err
+	if err == nil {
+		e.clock = result
+	}
+	e.clockMutex.Unlock()
+
+	if err != nil {
+		logger.Warnf("Failed to check the system clock: %v", err)
+		return
+	}
+	logger.Debugf("Clock is %.3f s off %s (%s)", result.Offset.Seconds(), result.Server, result.Source)
+
+	offset, off := e.clockOff()
+	switch {
+	case off && !wasOff:
+		logger.Warnf("Clock is %.1f s off %s, more than the %.1f s allowed: decoding will suffer and heartbeats are stopped",
+			offset.Seconds(), result.Server, e.config.TimeSync.MaxOffset)
+	case !off && wasOff:
+		logger.Infof("Clock is back within %.1f s, heartbeats resumed", e.config.TimeSync.MaxOffset)
+	}
+}
+
+// clockOff returns the last measured clock offset and whether it is more
+// than time_sync max_offset. An unchecked clock is not considered off.
+func (e *CoreEngine) clockOff() (time.Duration, bool) {
+	e.clockMutex.RLock()
+	defer e.clockMutex.RUnlock()
+
+	if e.clock == nil || !e.config.TimeSync.Enabled {
+		return 0, false
+	}
+	limit := time.Duration(e.config.TimeSync.MaxOffset * float64(time.Second))
+	return e.clock.Offset, e.clock.Offset.Abs() > limit
+}
+
+// clockStatus reports the last clock check for STATUS, nil when the clock
+// is not being checked
+func (e *CoreEngine) clockStatus() *protocol.ClockStatus {
+	if !e.config.TimeSync.Enabled {
+		return nil
+	}
+	_, off := e.clockOff()
+
+	e.clockMutex.RLock()
+	defer e.clockMutex.RUnlock()
+
+	status := &protocol.ClockStatus{Synced: e.clock != nil && !off}
+	if e.clock != nil {
+		status.Offset = e.clock.Offset.Seconds()
+		status.Source = e.clock.Source
+		status.Server = e.clock.Server
+		checked := e.clock.Checked
+		status.Checked = &checked
+	}
+	if e.clockError != nil {
+		status.Error = e.clockError.Error()
+	}
+	return status
+}
+
+// now is the time to stamp decodes with: the system clock, corrected by the
+// measured offset when time_sync adjust_decode is on
+func (e *CoreEngine) now() time.Time {
+	now := time.Now()
+	if !e.config.TimeSync.AdjustDecode {
+		return now
+	}
+
+	e.clockMutex.RLock()
+	defer e.clockMutex.RUnlock()
+	if e.clock == nil {
+		return now
+	}
+	return now.Add(-e.clock.Offset)
+}
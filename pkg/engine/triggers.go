@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+	"github.com/dougsko/js8d/pkg/triggers"
+)
+
+// startTriggers calls the trigger webhooks on station events, when there
+// are any. It runs before the transmit loop, which fires TX events.
+func (e *CoreEngine) startTriggers() {
+	cfg := e.config.Triggers
+	if len(cfg.Hooks) == 0 {
+		return
+	}
+
+	hooks := make([]triggers.Hook, len(cfg.Hooks))
+	for i, hook := range cfg.Hooks {
+		hooks[i] = triggers.Hook{
+			Event:       hook.Event,
+			Callsigns:   hook.Callsigns,
+			URL:         hook.URL,
+			Method:      hook.Method,
+			ContentType: hook.ContentType,
+			Template:    hook.Template,
+			Token:       hook.Token.Value(),
+			Cooldown:    time.Duration(hook.Cooldown) * time.Minute,
+		}
+	}
+
+	dispatcher, err := triggers.New(hooks, func(url string, err error) {
+		logger.Warnf("Trigger webhook %s: %v", url, err)
+	})
+	if err != nil {
+		logger.Errorf("Triggers disabled: %v", err)
+		return
+	}
+	e.triggers = dispatcher
+	e.startLoop("triggers", func() { dispatcher.Run(e.ctx) })
+	logger.Infof("Calling %d trigger webhooks on station events", len(hooks))
+}
+
+// trigger fires the hooks for an event, if there are any
+func (e *CoreEngine) trigger(event triggers.Event) {
+	if e.triggers == nil {
+		return
+	}
+	event.Station = e.config.Station.Callsign
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if !e.triggers.Fire(event) {
+		logger.Warnf("Trigger queue full, dropped %s event", event.Type)
+	}
+}
+
+// triggerRX fires the heard hooks for a decode, and the directed ones when
+// it is addressed to us
+func (e *CoreEngine) triggerRX(msg protocol.Message) {
+	if msg.From == "" || msg.From == e.config.Station.Callsign {
+		return
+	}
+
+	event := triggers.Event{
+		Type:      triggers.Heard,
+		Time:      msg.Timestamp,
+		From:      msg.From,
+		To:        msg.To,
+		Message:   msg.Message,
+		SNR:       msg.SNR,
+		Frequency: msg.Frequency,
+	}
+	e.trigger(event)
+
+	if msg.To == e.config.Station.Callsign {
+		event.Type = triggers.Directed
+		e.trigger(event)
+	}
+}
+
+// triggerTX fires the hooks for a message going on the air, or failing to
+// when err is set
+func (e *CoreEngine) triggerTX(msg protocol.Message, err error) {
+	event := triggers.Event{
+		Type:      triggers.TXStarted,
+		From:      msg.From,
+		To:        msg.To,
+		Message:   msg.Message,
+		Frequency: e.dialFrequency(),
+	}
+	if err != nil {
+		event.Type = triggers.TXFailed
+		event.Error = err.Error()
+	}
+	e.trigger(event)
+}
+
+// watchesSWR reports whether transmissions should check the SWR
+func (e *CoreEngine) watchesSWR() bool {
+	return e.triggers != nil && e.triggers.Wants(triggers.SWRAlarm)
+}
+
+// checkSWR reads the SWR while transmitting msg and fires the swr_alarm
+// hooks if it is over the alarm level, reporting whether it was
+func (e *CoreEngine) checkSWR(msg protocol.Message) bool {
+	swr, err := e.hardwareManager.GetRadioSWRLevel()
+	if err != nil || float64(swr) < e.config.Triggers.SWRAlarm {
+		return false
+	}
+
+	logger.Warnf("SWR %.1f while transmitting: %s", swr, msg.Message)
+	e.trigger(triggers.Event{
+		Type:      triggers.SWRAlarm,
+		To:        msg.To,
+		Message:   msg.Message,
+		Frequency: e.dialFrequency(),
+		SWR:       swr,
+	})
+	return true
+}
+
+// dialFrequency returns the frequency the radio is tuned to
+func (e *CoreEngine) dialFrequency() int {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.frequency
+}
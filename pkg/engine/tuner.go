@@ -0,0 +1,238 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// With radio.tune_before_tx set, the first transmission on a band is held
+// while the antenna tuner matches it: either a steady carrier is keyed for
+// the rig's automatic tuner to work on (carrier), or the rig is told to
+// start its tuner over CAT (cat). How that went is kept per band, so later
+// transmissions there go straight out; TUNE tunes the current band again.
+const (
+	tuneCarrier = "carrier"
+	tuneCAT     = "cat"
+
+	// tuneToneHz is the audio frequency of the tune carrier
+	tuneToneHz = 1500.0
+)
+
+// tuneResult is how tuning went on a band
+type tuneResult struct {
+	Band   string    `json:"band"`
+	Method string    `json:"method"`
+	Tuned  time.Time `json:"tuned"`
+	SWR    float32   `json:"swr,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// tuneMethod returns how the tuner is run before TX, "" for not at all
+func (e *CoreEngine) tuneMethod() string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return strings.ToLower(e.config.Radio.TuneBeforeTX)
+}
+
+// tuneIfNewBand tunes the current band when nothing has been sent on it
+// yet, and waits out a TUNE in progress. A failed tune is logged and kept
+// like any other, so the transmission still goes out; only an abort stops
+// it.
+func (e *CoreEngine) tuneIfNewBand() error {
+	e.tuning.Lock()
+	defer e.tuning.Unlock()
+
+	method := e.tuneMethod()
+	band := protocol.Band(e.dialFrequency())
+	if method == "" || band == "" || e.hardwareManager == nil {
+		return nil
+	}
+
+	e.tuneMutex.Lock()
+	_, tuned := e.tuneResults[band]
+	e.tuneMutex.Unlock()
+	if tuned {
+		return nil
+	}
+
+	_, err := e.tune(method, band, 0)
+	return err
+}
+
+// tune runs the tuner on band by method and keeps the result, returning an
+// error only when the tune was aborted. A carrier goes out at power
+// percent, or else the level kept for the band, or else tune_power. The
+// caller holds e.tuning.
+func (e *CoreEngine) tune(method, band string, power int) (tuneResult, error) {
+	e.mutex.RLock()
+	seconds := e.config.Radio.TuneSeconds
+	configured := e.config.Radio.TunePower
+	e.mutex.RUnlock()
+	if power == 0 && e.keptPower(powerTune) == 0 {
+		power = configured
+	}
+
+	logger.Infof("Tuning %s band by %s for %.1f s", band, method, seconds)
+	e.setTuneActive(true)
+	defer e.setTuneActive(false)
+	result := tuneResult{Band: band, Method: method, Tuned: time.Now()}
+
+	var err error
+	switch method {
+	case tuneCarrier:
+		err = e.tuneCarrier(seconds, power, &result)
+	case tuneCAT:
+		err = e.hardwareManager.StartRadioTune()
+		if err == nil {
+			err = e.waitTune(seconds)
+		}
+	default:
+		err = fmt.Errorf("unknown tune method %q", method)
+	}
+	if errors.Is(err, errTuneAborted) {
+		logger.Infof("Tune aborted on %s band", band)
+		return result, err
+	}
+	if err != nil {
+		logger.Warnf("Tune on %s band failed, transmitting anyway: %v", band, err)
+		result.Error = err.Error()
+	} else if result.SWR > 0 {
+		logger.Infof("Tuned %s band, SWR %.1f", band, result.SWR)
+	}
+
+	e.tuneMutex.Lock()
+	e.tuneResults[band] = result
+	e.tuneMutex.Unlock()
+	return result, nil
+}
+
+// setTuneActive marks a tune as running, so ABORT stops it and a reload
+// waits for it
+func (e *CoreEngine) setTuneActive(active bool) {
+	e.txMutex.Lock()
+	e.tuneActive = active
+	e.txMutex.Unlock()
+}
+
+// errTuneAborted is returned when ABORT stops a tune
+var errTuneAborted = errors.New("tune aborted")
+
+// tuneCarrier keys a carrier at power percent for seconds, reading the SWR
+// near the end when the rig reports it. On VOX the tone keys the rig.
+func (e *CoreEngine) tuneCarrier(seconds float64, power int, result *tuneResult) error {
+	defer e.setPower(powerTune, power)()
+
+	toRX, err := e.audioToTX()
+	if err != nil {
+		return err
+	}
+	defer toRX()
+
+	vox := e.pttSequence().vox
+	e.setPTTState(true)
+	defer func() {
+		if !vox {
+			if err := e.hardwareManager.SetRadioPTT(false); err != nil {
+				logger.Warnf("Failed to clear radio PTT after tuning: %v", err)
+			}
+		}
+		e.setPTTState(false)
+	}()
+
+	if !vox {
+		if err := e.hardwareManager.SetRadioPTT(true); err != nil {
+			return fmt.Errorf("PTT failed: %w", err)
+		}
+	}
+	carrier := dsp.GenerateTone(tuneToneHz, seconds, e.dspEngine.GetSampleRate())
+	if err := e.hardwareManager.PlayAudio(e.txAudio(carrier)); err != nil {
+		return fmt.Errorf("audio output failed: %w", err)
+	}
+	if err := e.waitTune(seconds); err != nil {
+		return err
+	}
+
+	if swr, err := e.hardwareManager.GetRadioSWRLevel(); err == nil {
+		result.SWR = swr
+	}
+	return nil
+}
+
+// waitTune waits seconds for the tuner, or until a transmission is aborted
+func (e *CoreEngine) waitTune(seconds float64) error {
+	timer := time.NewTimer(time.Duration(seconds * float64(time.Second)))
+	defer timer.Stop()
+	select {
+	case <-e.abortTx:
+		return errTuneAborted
+	case <-timer.C:
+		return nil
+	}
+}
+
+// tuneResultsList returns the kept tune results, for the radio status
+func (e *CoreEngine) tuneResultsList() []tuneResult {
+	e.tuneMutex.Lock()
+	defer e.tuneMutex.Unlock()
+	results := make([]tuneResult, 0, len(e.tuneResults))
+	for _, result := range e.tuneResults {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Tuned.Before(results[j].Tuned) })
+	return results
+}
+
+// handleTune handles TUNE [carrier|cat] [PWR=n], which tunes the current
+// band again by the configured method or the one given
+func (e *CoreEngine) handleTune(args []string) *protocol.Response {
+	if e.hardwareManager == nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeNotConnected, "hardware manager not available")
+	}
+	method := e.tuneMethod()
+	power := 0
+	for _, arg := range args {
+		if value, ok := strings.CutPrefix(strings.ToUpper(arg), "PWR="); ok {
+			level, err := parsePower(value)
+			if err != nil {
+				return protocol.NewErrorResponse(err.Error())
+			}
+			power = level
+			continue
+		}
+		method = strings.ToLower(arg)
+	}
+	if method != tuneCarrier && method != tuneCAT {
+		return protocol.NewErrorResponse("usage: TUNE [carrier|cat] [PWR=n]")
+	}
+	if err := e.txInhibitedError(); err != nil {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeRadio, err.Error())
+	}
+	band := protocol.Band(e.dialFrequency())
+	if band == "" {
+		return protocol.NewErrorResponse(fmt.Sprintf("%d Hz is not in an amateur band", e.dialFrequency()))
+	}
+
+	// A message queued meanwhile waits for the tune in tuneIfNewBand
+	e.tuning.Lock()
+	defer e.tuning.Unlock()
+	if e.isTransmitting() {
+		return protocol.NewCodedErrorResponse(protocol.ErrCodeRadio, "cannot tune while transmitting")
+	}
+
+	result, err := e.tune(method, band, power)
+	if err != nil {
+		return protocol.NewErrorResponse(err.Error())
+	}
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"band":   result.Band,
+		"method": result.Method,
+		"swr":    result.SWR,
+		"error":  result.Error,
+	})
+}
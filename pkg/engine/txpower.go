@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// A message sent with a power level sets the rig's RF power before it is
+// keyed and puts it back after. With audio.remember_power_tx the level used
+// on a band is kept, and later messages on that band without one go out at
+// it too; audio.remember_power_tune does the same for tune carriers.
+const (
+	powerTX   = "tx"
+	powerTune = "tune"
+)
+
+// parsePower reads a power level in percent, where "" and 0 leave the rig
+// as it is set
+func parsePower(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	power, err := strconv.Atoi(value)
+	if err != nil || power < 0 || power > 100 {
+		return 0, fmt.Errorf("power must be 1-100 percent, got %q", value)
+	}
+	return power, nil
+}
+
+// remembersPower reports whether levels used for kind are kept per band
+func (e *CoreEngine) remembersPower(kind string) bool {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	if kind == powerTune {
+		return e.config.Audio.RememberPowerTune
+	}
+	return e.config.Audio.RememberPowerTx
+}
+
+// keptPower returns the level kept for kind on the current band, 0 when
+// there is none or levels are not remembered
+func (e *CoreEngine) keptPower(kind string) int {
+	band := protocol.Band(e.dialFrequency())
+	if !e.remembersPower(kind) || band == "" {
+		return 0
+	}
+	e.powerMutex.Lock()
+	defer e.powerMutex.Unlock()
+	return e.powerLevels[kind+" "+band]
+}
+
+// setPower sets the rig to level percent for a transmission of kind, or to
+// the level remembered for the band when level is 0, and returns a func
+// that puts the rig back as it was
+func (e *CoreEngine) setPower(kind string, level int) func() {
+	restore := func() {}
+	if e.hardwareManager == nil {
+		return restore
+	}
+
+	band := protocol.Band(e.dialFrequency())
+	remember := e.remembersPower(kind) && band != ""
+	key := kind + " " + band
+	e.powerMutex.Lock()
+	if level > 0 && remember {
+		e.powerLevels[key] = level
+	} else if level == 0 && remember {
+		level = e.powerLevels[key]
+	}
+	e.powerMutex.Unlock()
+	if level == 0 {
+		return restore
+	}
+
+	previous, err := e.hardwareManager.GetRadioPowerLevel()
+	if err != nil {
+		logger.Warnf("Cannot read the radio's power level, sending at its own: %v", err)
+		return restore
+	}
+	if err := e.hardwareManager.SetRadioPowerLevel(float32(level) / 100); err != nil {
+		logger.Warnf("Failed to set %s power to %d%%: %v", kind, level, err)
+		return restore
+	}
+	logger.Infof("Radio power set to %d%% for %s", level, kind)
+
+	return func() {
+		if err := e.hardwareManager.SetRadioPowerLevel(previous); err != nil {
+			logger.Warnf("Failed to restore radio power to %.0f%%: %v", previous*100, err)
+		}
+	}
+}
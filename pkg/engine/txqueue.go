@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// queueTX stores an outbound message as queued, at the dial frequency and TX
+// offset unless it has a frequency, and in the submode chosen for its
+// station unless it has one, and hands it to the transmit loop. It returns
+// the message with its stored ID and status, and false when the queue is
+// full.
+func (e *CoreEngine) queueTX(msg protocol.Message) (protocol.Message, bool) {
+	if len(e.txMessages) == cap(e.txMessages) {
+		return msg, false
+	}
+
+	msg.Status = protocol.MessageQueued
+	if msg.Frequency == 0 {
+		msg.Dial, msg.Offset = e.dialFrequency(), e.txOffset()
+		msg.Frequency = msg.Dial + msg.Offset
+	}
+	if msg.Band == "" {
+		msg.Band = protocol.Band(msg.Frequency)
+	}
+	if msg.Submode == "" {
+		var reason string
+		msg.Submode, reason = e.chooseSubmode(msg.To, time.Now())
+		logger.Debugf("TX submode %s for %q: %s", msg.Submode, msg.To, reason)
+	}
+	e.msgMutex.Lock()
+	if e.messageStore != nil {
+		stored, err := e.messageStore.QueueMessage(msg, e.classifyMessage(msg.Message))
+		if err != nil {
+			logger.Errorf("Failed to store TX message: %v", err)
+		} else {
+			msg = stored
+		}
+	}
+	e.msgMutex.Unlock()
+
+	e.publishEvent(protocol.EventMessage, map[string]interface{}{
+		"direction": "TX",
+		"message":   msg,
+	})
+
+	select {
+	case e.txMessages <- msg:
+		return msg, true
+	default:
+		e.setTXStatus(msg, protocol.MessageFailed)
+		e.abandonAck(msg)
+		return msg, false
+	}
+}
+
+// setTXStatus records a queued message's progress in the message store and
+// tells event subscribers
+func (e *CoreEngine) setTXStatus(msg protocol.Message, status string) {
+	e.msgMutex.Lock()
+	if e.messageStore == nil {
+		e.msgMutex.Unlock()
+		return
+	}
+	err := e.messageStore.SetMessageStatus(msg.ID, status)
+	e.msgMutex.Unlock()
+	if err != nil {
+		logger.Warnf("Failed to mark TX message %d %s: %v", msg.ID, status, err)
+		return
+	}
+
+	e.publishEvent(protocol.EventMessageStatus, map[string]interface{}{
+		"id":     msg.ID,
+		"status": status,
+	})
+}
+
+// loadTXQueue returns the messages a previous run left unsent. It runs
+// before the engine takes commands, so nothing queued by this run is in it.
+func (e *CoreEngine) loadTXQueue() []protocol.Message {
+	e.msgMutex.RLock()
+	defer e.msgMutex.RUnlock()
+	if e.messageStore == nil {
+		return nil
+	}
+
+	messages, err := e.messageStore.GetQueuedMessages()
+	if err != nil {
+		logger.Errorf("Failed to load the TX queue: %v", err)
+		return nil
+	}
+	return messages
+}
+
+// resumeTXQueue requeues the messages from loadTXQueue. One that was on the
+// air when js8d stopped may have gone out in part, so it is marked failed
+// rather than sent twice.
+func (e *CoreEngine) resumeTXQueue(messages []protocol.Message) {
+	resumed := 0
+	for i, msg := range messages {
+		if msg.Status == protocol.MessageTransmitting {
+			logger.Warnf("TX interrupted by the last shutdown, not resending: %s", msg.Message)
+			e.setTXStatus(msg, protocol.MessageFailed)
+			e.abandonAck(msg)
+			continue
+		}
+
+		select {
+		case e.txMessages <- msg:
+			resumed++
+		default:
+			logger.Warnf("TX queue full, %d stored messages left for the next start", len(messages)-i)
+			return
+		}
+	}
+	if resumed > 0 {
+		logger.Infof("Resumed %d queued TX messages", resumed)
+	}
+}
+
+// handleGetTXQueue lists the messages waiting to be sent or on the air
+func (e *CoreEngine) handleGetTXQueue() *protocol.Response {
+	e.msgMutex.RLock()
+	defer e.msgMutex.RUnlock()
+
+	if e.messageStore == nil {
+		return protocol.NewErrorResponse("message storage not available")
+	}
+
+	messages, err := e.messageStore.GetQueuedMessages()
+	if err != nil {
+		return protocol.NewErrorResponse(fmt.Sprintf("failed to get TX queue: %v", err))
+	}
+
+	return protocol.NewSuccessResponse(map[string]interface{}{
+		"messages": messages,
+		"count":    len(messages),
+	})
+}
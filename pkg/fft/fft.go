@@ -0,0 +1,112 @@
+package fft
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Backend computes forward FFTs of complex input
+type Backend interface {
+	Name() string
+	Forward(x []complex128) []complex128
+}
+
+// Backend names selectable with the dsp.fft_backend setting
+const (
+	BackendAuto   = "auto"
+	BackendGoDSP  = "go-dsp" // github.com/mjibson/go-dsp, handles any length
+	BackendRadix2 = "radix2" // Planned pure Go radix-2, power of two lengths
+	BackendNEON   = "neon"   // C radix-2 using ARM NEON, built with the neon tag
+)
+
+var (
+	backends = map[string]Backend{}
+	current  Backend
+	mutex    sync.RWMutex
+)
+
+// register adds a backend; called from init in each backend's file
+func register(b Backend) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	backends[b.Name()] = b
+}
+
+// Backends lists the backends built into this binary
+func Backends() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetBackend selects the backend used by Forward and ForwardReal. Auto picks
+// NEON when built in and otherwise the radix-2 backend.
+func SetBackend(name string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if name == "" || name == BackendAuto {
+		current = defaultBackend()
+		return nil
+	}
+	b, ok := backends[name]
+	if !ok {
+		return fmt.Errorf("FFT backend %q not available", name)
+	}
+	current = b
+	return nil
+}
+
+// Current returns the name of the selected backend
+func Current() string {
+	return backend().Name()
+}
+
+func defaultBackend() Backend {
+	if b, ok := backends[BackendNEON]; ok {
+		return b
+	}
+	return backends[BackendRadix2]
+}
+
+func backend() Backend {
+	mutex.RLock()
+	b := current
+	mutex.RUnlock()
+	if b != nil {
+		return b
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if current == nil {
+		current = defaultBackend()
+	}
+	return current
+}
+
+// Forward returns the FFT of x using the selected backend
+func Forward(x []complex128) []complex128 {
+	return backend().Forward(x)
+}
+
+// ForwardReal returns the FFT of real input using the selected backend
+func ForwardReal(x []float64) []complex128 {
+	c := make([]complex128, len(x))
+	for i, v := range x {
+		c[i] = complex(v, 0)
+	}
+	return backend().Forward(c)
+}
+
+// isPowerOfTwo reports whether n is a power of two
+func isPowerOfTwo(n int) bool {
+	return n > 0 && n&(n-1) == 0
+}
@@ -0,0 +1,127 @@
+package fft
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+// naiveDFT is the reference transform
+func naiveDFT(x []complex128) []complex128 {
+	n := len(x)
+	out := make([]complex128, n)
+	for k := range out {
+		for t, v := range x {
+			angle := -2 * math.Pi * float64(k*t) / float64(n)
+			out[k] += v * cmplx.Exp(complex(0, angle))
+		}
+	}
+	return out
+}
+
+func randomSignal(n int) []complex128 {
+	rng := rand.New(rand.NewSource(int64(n)))
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = complex(rng.Float64()*2-1, rng.Float64()*2-1)
+	}
+	return x
+}
+
+func TestBackendsMatchDFT(t *testing.T) {
+	for _, name := range Backends() {
+		b := backends[name]
+
+		// NEON works in float32
+		tolerance := 1e-9
+		if name == BackendNEON {
+			tolerance = 1e-3
+		}
+
+		for _, n := range []int{1, 2, 8, 64, 1024, 1920} {
+			t.Run(fmt.Sprintf("%s/%d", name, n), func(t *testing.T) {
+				x := randomSignal(n)
+				want := naiveDFT(x)
+				got := b.Forward(x)
+				if len(got) != n {
+					t.Fatalf("Expected %d outputs, got %d", n, len(got))
+				}
+				for k := range want {
+					if cmplx.Abs(got[k]-want[k]) > tolerance*float64(n) {
+						t.Fatalf("Bin %d: expected %v, got %v", k, want[k], got[k])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestForwardLeavesInput(t *testing.T) {
+	x := randomSignal(256)
+	original := append([]complex128(nil), x...)
+	for _, name := range Backends() {
+		backends[name].Forward(x)
+		for i := range x {
+			if x[i] != original[i] {
+				t.Fatalf("%s modified its input", name)
+			}
+		}
+	}
+}
+
+func TestSetBackend(t *testing.T) {
+	defer SetBackend(BackendAuto)
+
+	if err := SetBackend(BackendGoDSP); err != nil {
+		t.Fatalf("Expected go-dsp backend, got error: %v", err)
+	}
+	if Current() != BackendGoDSP {
+		t.Errorf("Expected go-dsp, got %s", Current())
+	}
+
+	if err := SetBackend("cufft"); err == nil {
+		t.Error("Expected error for unknown backend")
+	}
+	if Current() != BackendGoDSP {
+		t.Errorf("Expected a failed switch to keep go-dsp, got %s", Current())
+	}
+
+	if err := SetBackend(BackendAuto); err != nil {
+		t.Fatalf("Expected auto backend, got error: %v", err)
+	}
+	if Current() == BackendGoDSP {
+		t.Error("Expected auto to pick an accelerated backend")
+	}
+}
+
+func TestForwardReal(t *testing.T) {
+	const n = 512
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = math.Cos(2 * math.Pi * 32 * float64(i) / n)
+	}
+
+	spectrum := ForwardReal(x)
+	if math.Abs(cmplx.Abs(spectrum[32])-n/2) > 1e-3 {
+		t.Errorf("Expected bin 32 magnitude %d, got %.3f", n/2, cmplx.Abs(spectrum[32]))
+	}
+	if cmplx.Abs(spectrum[10]) > 1e-3 {
+		t.Errorf("Expected bin 10 to be empty, got %.3f", cmplx.Abs(spectrum[10]))
+	}
+}
+
+func BenchmarkForward(b *testing.B) {
+	for _, name := range Backends() {
+		backend := backends[name]
+		for _, n := range []int{1024, 4096} {
+			x := randomSignal(n)
+			b.Run(fmt.Sprintf("%s/%d", name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					backend.Forward(x)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,126 @@
+//go:build cgo && neon
+
+package fft
+
+/*
+#cgo CFLAGS: -O3
+#if defined(__aarch64__)
+#include <arm_neon.h>
+#endif
+
+// Radix-2 decimation in time over interleaved float32 complex data that is
+// already in bit reversed order. Twiddles are stored per stage: the stage
+// with half size m starts at complex index m-1 and holds exp(-i*pi*j/m).
+static void js8d_fft_radix2(float *data, const float *twiddles, int n) {
+	for (int half = 1; half < n; half <<= 1) {
+		const float *w = twiddles + 2 * (half - 1);
+		for (int start = 0; start < n; start += 2 * half) {
+			float *a = data + 2 * start;
+			float *b = a + 2 * half;
+			int j = 0;
+#if defined(__aarch64__)
+			const float32x4_t sign = {-1.0f, 1.0f, -1.0f, 1.0f};
+			for (; j + 2 <= half; j += 2) {
+				float32x4_t tw = vld1q_f32(w + 2 * j);
+				float32x4_t wr = vtrn1q_f32(tw, tw);
+				float32x4_t wi = vtrn2q_f32(tw, tw);
+				float32x4_t bv = vld1q_f32(b + 2 * j);
+				float32x4_t t = vmulq_f32(bv, wr);
+				t = vfmaq_f32(t, vmulq_f32(vrev64q_f32(bv), wi), sign);
+				float32x4_t av = vld1q_f32(a + 2 * j);
+				vst1q_f32(a + 2 * j, vaddq_f32(av, t));
+				vst1q_f32(b + 2 * j, vsubq_f32(av, t));
+			}
+#endif
+			for (; j < half; j++) {
+				float wr = w[2 * j], wi = w[2 * j + 1];
+				float br = b[2 * j], bi = b[2 * j + 1];
+				float tr = br * wr - bi * wi;
+				float ti = br * wi + bi * wr;
+				float ar = a[2 * j], ai = a[2 * j + 1];
+				a[2 * j] = ar + tr;
+				a[2 * j + 1] = ai + ti;
+				b[2 * j] = ar - tr;
+				b[2 * j + 1] = ai - ti;
+			}
+		}
+	}
+}
+*/
+import "C"
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+	"unsafe"
+
+	godsp "github.com/mjibson/go-dsp/fft"
+)
+
+func init() {
+	register(&neonBackend{})
+}
+
+// neonPlan holds the float32 tables for one transform length
+type neonPlan struct {
+	reversed []int
+	twiddles []float32 // Per stage, see js8d_fft_radix2
+}
+
+// neonBackend runs the radix-2 butterflies in C, using NEON on arm64. It
+// works in float32, which is plenty for spectrum display and sync search.
+type neonBackend struct {
+	plans sync.Map // int -> *neonPlan
+}
+
+func (nb *neonBackend) Name() string { return BackendNEON }
+
+func (nb *neonBackend) plan(n int) *neonPlan {
+	if p, ok := nb.plans.Load(n); ok {
+		return p.(*neonPlan)
+	}
+
+	shift := 64 - bits.TrailingZeros(uint(n))
+	p := &neonPlan{
+		reversed: make([]int, n),
+		twiddles: make([]float32, 0, 2*(n-1)),
+	}
+	for i := range p.reversed {
+		p.reversed[i] = int(bits.Reverse64(uint64(i)) >> shift)
+	}
+	for half := 1; half < n; half <<= 1 {
+		for j := 0; j < half; j++ {
+			angle := -math.Pi * float64(j) / float64(half)
+			p.twiddles = append(p.twiddles, float32(math.Cos(angle)), float32(math.Sin(angle)))
+		}
+	}
+
+	actual, _ := nb.plans.LoadOrStore(n, p)
+	return actual.(*neonPlan)
+}
+
+func (nb *neonBackend) Forward(x []complex128) []complex128 {
+	n := len(x)
+	if n <= 1 {
+		return append([]complex128(nil), x...)
+	}
+	if !isPowerOfTwo(n) {
+		return godsp.FFT(x)
+	}
+
+	p := nb.plan(n)
+	data := make([]float32, 2*n)
+	for i, j := range p.reversed {
+		data[2*j] = float32(real(x[i]))
+		data[2*j+1] = float32(imag(x[i]))
+	}
+
+	C.js8d_fft_radix2((*C.float)(unsafe.Pointer(&data[0])), (*C.float)(unsafe.Pointer(&p.twiddles[0])), C.int(n))
+
+	out := make([]complex128, n)
+	for i := range out {
+		out[i] = complex(float64(data[2*i]), float64(data[2*i+1]))
+	}
+	return out
+}
@@ -0,0 +1,90 @@
+package fft
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+
+	godsp "github.com/mjibson/go-dsp/fft"
+)
+
+func init() {
+	register(goDSPBackend{})
+	register(&radix2Backend{})
+}
+
+// goDSPBackend wraps go-dsp, which handles any length
+type goDSPBackend struct{}
+
+func (goDSPBackend) Name() string { return BackendGoDSP }
+
+func (goDSPBackend) Forward(x []complex128) []complex128 {
+	return godsp.FFT(x)
+}
+
+// radix2Plan holds the tables for one transform length
+type radix2Plan struct {
+	reversed []int        // Bit reversed index of each input
+	twiddles []complex128 // exp(-2*pi*i*k/n) for k < n/2
+}
+
+// radix2Backend is an iterative radix-2 FFT with cached plans. It avoids the
+// per call allocations and goroutines of go-dsp, which matters on small
+// single core boards. Lengths that are not a power of two go to go-dsp.
+type radix2Backend struct {
+	plans sync.Map // int -> *radix2Plan
+}
+
+func (r *radix2Backend) Name() string { return BackendRadix2 }
+
+func (r *radix2Backend) plan(n int) *radix2Plan {
+	if p, ok := r.plans.Load(n); ok {
+		return p.(*radix2Plan)
+	}
+
+	shift := 64 - bits.TrailingZeros(uint(n))
+	p := &radix2Plan{
+		reversed: make([]int, n),
+		twiddles: make([]complex128, n/2),
+	}
+	for i := range p.reversed {
+		p.reversed[i] = int(bits.Reverse64(uint64(i)) >> shift)
+	}
+	for k := range p.twiddles {
+		angle := -2 * math.Pi * float64(k) / float64(n)
+		p.twiddles[k] = complex(math.Cos(angle), math.Sin(angle))
+	}
+
+	actual, _ := r.plans.LoadOrStore(n, p)
+	return actual.(*radix2Plan)
+}
+
+func (r *radix2Backend) Forward(x []complex128) []complex128 {
+	n := len(x)
+	if n <= 1 {
+		return append([]complex128(nil), x...)
+	}
+	if !isPowerOfTwo(n) {
+		return godsp.FFT(x)
+	}
+
+	p := r.plan(n)
+	out := make([]complex128, n)
+	for i, j := range p.reversed {
+		out[j] = x[i]
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		stride := n / size
+		for start := 0; start < n; start += size {
+			for j := 0; j < half; j++ {
+				t := p.twiddles[j*stride] * out[start+j+half]
+				u := out[start+j]
+				out[start+j] = u + t
+				out[start+j+half] = u - t
+			}
+		}
+	}
+	return out
+}
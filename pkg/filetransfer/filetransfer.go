@@ -0,0 +1,300 @@
+// Package filetransfer sends small files between js8d stations as JS8
+// transfer frames. The sender offers the transfer, sends the file 4 bytes a
+// frame and polls the receiver, which answers with the frames it is
+// missing, sent again in the next round, or an acknowledgement once it has
+// the whole file.
+package filetransfer
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+)
+
+// MaxNameLength is the longest file name sent with a file
+const MaxNameLength = 64
+
+// Payload formats
+const (
+	formatPlain   = 0
+	formatDeflate = 1
+)
+
+// File is a file with the station that sent it
+type File struct {
+	Name    string
+	From    string
+	Content []byte
+}
+
+// CleanName reduces a file name to its base name with no separators or
+// control characters, so a received name can't point outside a directory
+func CleanName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '/' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "." || name == ".." || name == "" {
+		name = "file"
+	}
+	if len(name) > MaxNameLength {
+		name = name[:MaxNameLength]
+	}
+	return name
+}
+
+// Encode packs a file into a payload: a format byte, the CRC-32 of the body
+// and the body, which is the name, the sender and the content separated by
+// NULs, deflated when that makes it smaller
+func Encode(f File) ([]byte, error) {
+	var body bytes.Buffer
+	body.WriteString(CleanName(f.Name))
+	body.WriteByte(0)
+	body.WriteString(strings.ToUpper(f.From))
+	body.WriteByte(0)
+	body.Write(f.Content)
+
+	payload := dsp.PayloadHeader(formatPlain, body.Bytes(), dsp.TransferPayloadHeaderBytes)
+
+	var deflated bytes.Buffer
+	w, _ := flate.NewWriter(&deflated, flate.BestCompression)
+	w.Write(body.Bytes())
+	w.Close()
+	if deflated.Len() < body.Len() {
+		payload[0] = formatDeflate
+		payload = append(payload, deflated.Bytes()...)
+	} else {
+		payload = append(payload, body.Bytes()...)
+	}
+
+	if len(payload) > dsp.MaxTransferPayload {
+		return nil, fmt.Errorf("file is %d bytes packed, over the %d byte limit", len(payload), dsp.MaxTransferPayload)
+	}
+	return payload, nil
+}
+
+// Decode unpacks a payload made by Encode. The zero padding of the last
+// frame is told apart from the content by the checksum.
+func Decode(payload []byte) (File, error) {
+	if len(payload) < dsp.TransferPayloadHeaderBytes {
+		return File{}, fmt.Errorf("file payload is too short")
+	}
+	header := payload[:dsp.TransferPayloadHeaderBytes]
+
+	var body []byte
+	switch payload[0] {
+	case formatPlain:
+		// Padding is at most one frame of zeros, which the content may
+		// also end with
+		body = payload[dsp.TransferPayloadHeaderBytes:]
+		for trim := 0; trim < dsp.TransferFrameBytes && len(body) > 0 && !dsp.PayloadChecks(header, body); trim++ {
+			if body[len(body)-1] != 0 {
+				break
+			}
+			body = body[:len(body)-1]
+		}
+	case formatDeflate:
+		inflated, err := io.ReadAll(flate.NewReader(bytes.NewReader(payload[dsp.TransferPayloadHeaderBytes:])))
+		if err != nil {
+			return File{}, fmt.Errorf("file payload does not inflate: %w", err)
+		}
+		body = inflated
+	default:
+		return File{}, fmt.Errorf("unknown file payload format %d", payload[0])
+	}
+	if !dsp.PayloadChecks(header, body) {
+		return File{}, fmt.Errorf("file payload fails its checksum")
+	}
+
+	parts := bytes.SplitN(body, []byte{0}, 3)
+	if len(parts) < 3 {
+		return File{}, fmt.Errorf("file payload is missing its header")
+	}
+	return File{Name: CleanName(string(parts[0])), From: string(parts[1]), Content: parts[2]}, nil
+}
+
+// Frames splits a payload into the data frames of transfer id
+func Frames(id uint8, payload []byte) ([]string, error) {
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("empty payload")
+	}
+	if len(payload) > dsp.MaxTransferPayload {
+		return nil, fmt.Errorf("payload of %d bytes is over the %d byte limit", len(payload), dsp.MaxTransferPayload)
+	}
+
+	count := (len(payload) + dsp.TransferFrameBytes - 1) / dsp.TransferFrameBytes
+	frames := make([]string, 0, count)
+	for seq := 0; seq < count; seq++ {
+		end := (seq + 1) * dsp.TransferFrameBytes
+		if end > len(payload) {
+			end = len(payload)
+		}
+		frame, err := dsp.PackTransferFrame(dsp.TransferFrame{
+			ID:   id,
+			Seq:  uint16(seq),
+			Data: payload[seq*dsp.TransferFrameBytes : end],
+		})
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+// Control packs a control frame of transfer id between the stations from
+// and to
+func Control(id, op uint8, start, end uint16, from, to string) string {
+	frame, _ := dsp.PackTransferFrame(dsp.TransferFrame{
+		ID:       id,
+		Control:  true,
+		Op:       op,
+		Start:    start,
+		End:      end,
+		FromHash: dsp.TransferCallHash(strings.ToUpper(from)),
+		ToHash:   dsp.TransferCallHash(strings.ToUpper(to)),
+	})
+	return frame
+}
+
+// Reply packs a control frame answering f, a control frame from the other
+// station, back to the station that sent it
+func Reply(f dsp.TransferFrame, op uint8, start, end uint16) string {
+	frame, _ := dsp.PackTransferFrame(dsp.TransferFrame{
+		ID:       f.ID,
+		Control:  true,
+		Op:       op,
+		Start:    start,
+		End:      end,
+		FromHash: f.ToHash,
+		ToHash:   f.FromHash,
+	})
+	return frame
+}
+
+// Range is a run of frame numbers, both ends included
+type Range struct {
+	Start uint16 `json:"start"`
+	End   uint16 `json:"end"`
+}
+
+// Incoming collects the data frames of a transfer being received
+type Incoming struct {
+	ID     uint8
+	Last   uint16
+	sized  bool // Last is known, from an offer or poll
+	frames map[uint16][]byte
+}
+
+// NewIncoming starts collecting transfer id of last+1 frames
+func NewIncoming(id uint8, last uint16) *Incoming {
+	return &Incoming{ID: id, Last: last, sized: true, frames: make(map[uint16][]byte)}
+}
+
+// NewUnsized starts collecting transfer id from data frames heard before
+// its offer, which gives its frame count
+func NewUnsized(id uint8) *Incoming {
+	return &Incoming{ID: id, frames: make(map[uint16][]byte)}
+}
+
+// Sized reports whether the frame count is known
+func (in *Incoming) Sized() bool {
+	return in.sized
+}
+
+// Size sets the frame count of a transfer started by NewUnsized to last+1,
+// dropping any frames numbered past it
+func (in *Incoming) Size(last uint16) {
+	in.Last, in.sized = last, true
+	for seq := range in.frames {
+		if seq > last {
+			delete(in.frames, seq)
+		}
+	}
+}
+
+// Add keeps a data frame, reporting whether it was new. A frame numbered
+// past the last is left out.
+func (in *Incoming) Add(f dsp.TransferFrame) bool {
+	if in.sized && f.Seq > in.Last {
+		return false
+	}
+	if _, ok := in.frames[f.Seq]; ok {
+		return false
+	}
+	in.frames[f.Seq] = f.Data
+	return true
+}
+
+// Received is how many of the frames are in
+func (in *Incoming) Received() int {
+	return len(in.frames)
+}
+
+// Total is how many frames the transfer has, 0 until the count is known
+func (in *Incoming) Total() int {
+	if !in.sized {
+		return 0
+	}
+	return int(in.Last) + 1
+}
+
+// Complete reports whether every frame is in
+func (in *Incoming) Complete() bool {
+	return in.sized && in.Received() == in.Total()
+}
+
+// Payload joins the frames, nil until the transfer is complete
+func (in *Incoming) Payload() []byte {
+	if !in.Complete() {
+		return nil
+	}
+	var payload []byte
+	for seq := 0; seq < in.Total(); seq++ {
+		payload = append(payload, in.frames[uint16(seq)]...)
+	}
+	return payload
+}
+
+// Missing returns the runs of frames not yet in, at most max of them: when
+// there are more, the runs closest together are asked for as one
+func (in *Incoming) Missing(max int) []Range {
+	var ranges []Range
+	for seq := 0; seq < in.Total(); seq++ {
+		if _, ok := in.frames[uint16(seq)]; ok {
+			continue
+		}
+		if n := len(ranges); n > 0 && int(ranges[n-1].End) == seq-1 {
+			ranges[n-1].End = uint16(seq)
+		} else {
+			ranges = append(ranges, Range{Start: uint16(seq), End: uint16(seq)})
+		}
+	}
+	return MergeRanges(ranges, max)
+}
+
+// MergeRanges joins the ranges separated by the smallest gaps until there
+// are at most max of them
+func MergeRanges(ranges []Range, max int) []Range {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+	for max > 0 && len(ranges) > max {
+		closest := 0
+		for i := 1; i < len(ranges)-1; i++ {
+			if ranges[i+1].Start-ranges[i].End < ranges[closest+1].Start-ranges[closest].End {
+				closest = i
+			}
+		}
+		ranges[closest].End = ranges[closest+1].End
+		ranges = append(ranges[:closest+1], ranges[closest+2:]...)
+	}
+	return ranges
+}
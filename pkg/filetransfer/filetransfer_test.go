@@ -0,0 +1,131 @@
+package filetransfer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+)
+
+func TestTransfer(t *testing.T) {
+	for _, content := range [][]byte{
+		[]byte("Shelter roster\nSmith, J\nJones, K\n"),
+		{1, 2, 3, 0, 0}, // Binary that ends in zeros and won't compress
+	} {
+		payload, err := Encode(File{Name: "../roster.txt", From: "k1abc", Content: content})
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		frames, err := Frames(9, payload)
+		if err != nil {
+			t.Fatalf("Frames failed: %v", err)
+		}
+
+		// Frames lost on the first pass are asked for again
+		in := NewIncoming(9, uint16(len(frames)-1))
+		for seq, frame := range frames {
+			if seq%3 == 1 {
+				continue
+			}
+			f, err := dsp.UnpackTransferFrame(frame)
+			if err != nil {
+				t.Fatalf("UnpackTransferFrame failed: %v", err)
+			}
+			in.Add(f)
+		}
+		missing := in.Missing(16)
+		if len(frames) > 1 && (in.Complete() || len(missing) == 0 || missing[0] != (Range{1, 1})) {
+			t.Fatalf("Expected frame 1 missing, got %+v", missing)
+		}
+		for _, r := range missing {
+			for seq := r.Start; seq <= r.End; seq++ {
+				f, _ := dsp.UnpackTransferFrame(frames[seq])
+				in.Add(f)
+			}
+		}
+		if !in.Complete() || len(in.Missing(4)) != 0 {
+			t.Fatalf("Expected the transfer complete, %d of %d", in.Received(), in.Total())
+		}
+
+		file, err := Decode(in.Payload())
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		if file.Name != "roster.txt" || file.From != "K1ABC" || !bytes.Equal(file.Content, content) {
+			t.Errorf("Expected roster.txt from K1ABC with %q, got %+v", content, file)
+		}
+	}
+}
+
+func TestUnsized(t *testing.T) {
+	frames, _ := Frames(3, []byte("twelve bytes"))
+	in := NewUnsized(3)
+	for _, frame := range frames[1:] {
+		f, _ := dsp.UnpackTransferFrame(frame)
+		in.Add(f)
+	}
+	extra, _ := dsp.PackTransferFrame(dsp.TransferFrame{ID: 3, Seq: 9})
+	f, _ := dsp.UnpackTransferFrame(extra)
+	in.Add(f)
+	if in.Complete() || in.Total() != 0 {
+		t.Fatalf("Expected nothing complete before the frame count is known, %d of %d", in.Received(), in.Total())
+	}
+
+	// The offer sizes it, keeping the frames heard so far
+	in.Size(uint16(len(frames) - 1))
+	if in.Received() != len(frames)-1 || !reflect.DeepEqual(in.Missing(4), []Range{{0, 0}}) {
+		t.Fatalf("Expected frame 0 missing of %d, got %d in, missing %+v", in.Total(), in.Received(), in.Missing(4))
+	}
+	f, _ = dsp.UnpackTransferFrame(frames[0])
+	if !in.Add(f) || !in.Complete() || string(in.Payload()) != "twelve bytes" {
+		t.Errorf("Expected the payload whole, got %q", in.Payload())
+	}
+}
+
+func TestMergeRanges(t *testing.T) {
+	ranges := []Range{{20, 25}, {0, 1}, {4, 5}, {40, 40}}
+	if got := MergeRanges(ranges, 2); !reflect.DeepEqual(got, []Range{{0, 25}, {40, 40}}) {
+		t.Errorf("Expected the closest ranges joined, got %+v", got)
+	}
+
+	in := NewIncoming(1, 9)
+	if got := in.Missing(4); !reflect.DeepEqual(got, []Range{{0, 9}}) {
+		t.Errorf("Expected everything missing, got %+v", got)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	payload, _ := Encode(File{Name: "a.txt", From: "K1ABC", Content: []byte("hello")})
+	payload[len(payload)-1] ^= 0xff
+	if _, err := Decode(payload); err == nil {
+		t.Error("Expected a damaged payload to fail its checksum")
+	}
+	if _, err := Encode(File{Name: "empty"}); err != nil {
+		t.Errorf("Expected an empty file to encode, got %v", err)
+	}
+
+	names := map[string]string{"/etc/passwd": "passwd", "..": "file", "a\x00b.txt": "ab.txt", `C:\tmp\x.bin`: "x.bin"}
+	for name, want := range names {
+		if got := CleanName(name); got != want {
+			t.Errorf("CleanName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestControl(t *testing.T) {
+	poll, err := dsp.UnpackTransferFrame(Control(7, dsp.TransferPoll, 0, 41, "k1abc", "W1AW"))
+	if err != nil || !poll.Control || poll.Op != dsp.TransferPoll || poll.End != 41 {
+		t.Fatalf("Expected a poll of frames 0 to 41, got %+v, %v", poll, err)
+	}
+	if poll.FromHash != dsp.TransferCallHash("K1ABC") || poll.ToHash != dsp.TransferCallHash("W1AW") {
+		t.Errorf("Expected the poll from K1ABC to W1AW, got %+v", poll)
+	}
+
+	// The answer goes back to the station that polled
+	nack, _ := dsp.UnpackTransferFrame(Reply(poll, dsp.TransferNack, 3, 5))
+	if nack.ID != 7 || nack.Op != dsp.TransferNack || nack.Start != 3 || nack.End != 5 ||
+		nack.FromHash != poll.ToHash || nack.ToHash != poll.FromHash {
+		t.Errorf("Expected a NACK of frames 3 to 5 back to K1ABC, got %+v", nack)
+	}
+}
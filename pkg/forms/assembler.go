@@ -0,0 +1,81 @@
+package forms
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+)
+
+// Assembler puts received data frames back together into payloads. Frames
+// may arrive in any order; a payload that stops arriving is dropped once
+// no frame of it has been heard for the timeout.
+type Assembler struct {
+	timeout time.Duration
+
+	mutex   sync.Mutex
+	partial map[uint8]*partialPayload
+}
+
+// partialPayload is the frames of one payload heard so far
+type partialPayload struct {
+	last    int // Position of the final frame, -1 until it is heard
+	frames  map[uint8][]byte
+	updated time.Time
+}
+
+// NewAssembler returns an assembler that drops incomplete payloads after
+// timeout without a new frame
+func NewAssembler(timeout time.Duration) *Assembler {
+	return &Assembler{timeout: timeout, partial: make(map[uint8]*partialPayload)}
+}
+
+// Add takes a decoded data frame heard at now. It returns the frame's
+// position, and once its last missing frame arrives the whole payload and
+// the position of its final frame.
+func (a *Assembler) Add(frame string, now time.Time) (seq, last uint8, payload []byte, err error) {
+	tag, seq, final, data, err := dsp.UnpackDataFrame(frame)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for t, p := range a.partial {
+		if now.Sub(p.updated) > a.timeout {
+			delete(a.partial, t)
+		}
+	}
+
+	// A frame past the final one, or a final frame at another position,
+	// under the same tag is a new payload
+	p := a.partial[tag]
+	if p != nil && p.last >= 0 && (int(seq) > p.last || final && int(seq) != p.last) {
+		p = nil
+	}
+	if p == nil {
+		p = &partialPayload{last: -1, frames: make(map[uint8][]byte)}
+		a.partial[tag] = p
+	}
+	if final && p.last < 0 {
+		// Frames past it were of an earlier payload
+		for s := range p.frames {
+			if s > seq {
+				delete(p.frames, s)
+			}
+		}
+		p.last = int(seq)
+	}
+	p.frames[seq] = data
+	p.updated = now
+
+	if p.last < 0 || len(p.frames) < p.last+1 {
+		return seq, 0, nil, nil
+	}
+	delete(a.partial, tag)
+	for i := 0; i <= p.last; i++ {
+		payload = append(payload, p.frames[uint8(i)]...)
+	}
+	return seq, uint8(p.last), payload, nil
+}
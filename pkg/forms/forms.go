@@ -0,0 +1,251 @@
+// Package forms sends structured messages, such as the ICS-213 general
+// message used in emergency communications, as JS8 data frames. A form's
+// values are packed in template order, compressed and split across as many
+// frames as they need; the receiver puts the frames back together and
+// lays the values out under the template's field labels.
+package forms
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+)
+
+// Field is one entry on a form
+type Field struct {
+	Name      string `json:"name" yaml:"name"`
+	Label     string `json:"label" yaml:"label"`
+	Multiline bool   `json:"multiline,omitempty" yaml:"multiline"`
+}
+
+// Template describes a form. Both ends need the same template for the
+// receiver to label the values; the code is sent with every form.
+type Template struct {
+	Code   string  `json:"code" yaml:"code"`
+	Name   string  `json:"name" yaml:"name"`
+	Fields []Field `json:"fields" yaml:"fields"`
+}
+
+// ICS213 is the ICS-213 general message form
+var ICS213 = Template{
+	Code: "ICS213",
+	Name: "ICS-213 General Message",
+	Fields: []Field{
+		{Name: "to", Label: "To (name and position)"},
+		{Name: "from", Label: "From (name and position)"},
+		{Name: "subject", Label: "Subject"},
+		{Name: "date", Label: "Date"},
+		{Name: "time", Label: "Time"},
+		{Name: "message", Label: "Message", Multiline: true},
+		{Name: "approved_by", Label: "Approved by"},
+	},
+}
+
+// Builtin returns the templates js8d always knows
+func Builtin() []Template {
+	return []Template{ICS213}
+}
+
+// Find returns the template with code from templates, ignoring case
+func Find(templates []Template, code string) (Template, bool) {
+	for _, t := range templates {
+		if strings.EqualFold(t.Code, code) {
+			return t, true
+		}
+	}
+	return Template{}, false
+}
+
+// Value is one filled in field of a form
+type Value struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Text  string `json:"text"`
+}
+
+// Form is a filled in template, with the stations it is from and to
+type Form struct {
+	Template string  `json:"template"`
+	Name     string  `json:"name"`
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Values   []Value `json:"values"`
+}
+
+// Fill makes a form from a template and values by field name. Fields left
+// out are blank; names the template does not have are an error.
+func Fill(t Template, from, to string, values map[string]string) (Form, error) {
+	for name := range values {
+		if !hasField(t, name) {
+			return Form{}, fmt.Errorf("form %s has no field %q", t.Code, name)
+		}
+	}
+
+	form := Form{Template: t.Code, Name: t.Name, From: strings.ToUpper(from), To: strings.ToUpper(to)}
+	for _, field := range t.Fields {
+		text := strings.TrimSpace(values[field.Name])
+		if !utf8.ValidString(text) || strings.ContainsRune(text, separator) {
+			return Form{}, fmt.Errorf("field %q contains characters that cannot be sent", field.Name)
+		}
+		form.Values = append(form.Values, Value{Name: field.Name, Label: field.Label, Text: text})
+	}
+	return form, nil
+}
+
+// hasField reports whether t has a field called name
+func hasField(t Template, name string) bool {
+	for _, field := range t.Fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the text of the field called name
+func (f Form) Get(name string) string {
+	for _, v := range f.Values {
+		if v.Name == name {
+			return v.Text
+		}
+	}
+	return ""
+}
+
+// Summary is a one line description of the form for message lists
+func (f Form) Summary() string {
+	summary := fmt.Sprintf("[%s] %s", f.Template, f.Name)
+	if subject := f.Get("subject"); subject != "" {
+		summary += ": " + subject
+	}
+	return summary
+}
+
+// Render lays the form out as text, one field per line
+func (f Form) Render() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s)\n", f.Name, f.Template)
+	fmt.Fprintf(&b, "From station: %s\n", f.From)
+	if f.To != "" {
+		fmt.Fprintf(&b, "To station: %s\n", f.To)
+	}
+	for _, v := range f.Values {
+		if strings.Contains(v.Text, "\n") {
+			fmt.Fprintf(&b, "%s:\n%s\n", v.Label, v.Text)
+		} else {
+			fmt.Fprintf(&b, "%s: %s\n", v.Label, v.Text)
+		}
+	}
+	return b.String()
+}
+
+// Payload formats. Telemetry payloads share the data frames with
+// format 2.
+const (
+	formatPlain   = 0
+	formatDeflate = 1
+)
+
+// separator goes between the values of an encoded form
+const separator = '\x1f'
+
+// MaxPayload is the largest encoded form that fits in the data frames
+const MaxPayload = dsp.MaxDataPayload
+
+// Encode packs a form into a payload: a format byte, a 16 bit checksum and
+// the template code, stations and values in template order, deflated when
+// that makes them smaller. Trailing blank values are left out.
+func Encode(f Form) ([]byte, error) {
+	parts := []string{f.Template, f.From, f.To}
+	for _, v := range f.Values {
+		parts = append(parts, v.Text)
+	}
+	for len(parts) > 3 && parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+	body := []byte(strings.Join(parts, string(separator)))
+
+	payload := dsp.PayloadHeader(formatPlain, body, dsp.DataPayloadHeaderBytes)
+
+	var deflated bytes.Buffer
+	w, _ := flate.NewWriter(&deflated, flate.BestCompression)
+	w.Write(body)
+	w.Close()
+	if deflated.Len() < len(body) {
+		payload[0] = formatDeflate
+		body = deflated.Bytes()
+	}
+	payload = append(payload, body...)
+
+	if len(payload) > MaxPayload {
+		return nil, fmt.Errorf("form is %d bytes packed, over the %d byte limit", len(payload), MaxPayload)
+	}
+	return payload, nil
+}
+
+// Decode unpacks a payload made by Encode, labelling the values from the
+// matching template. A form whose template is not known keeps its values
+// under numbered fields. Zero padding after the payload is ignored.
+func Decode(payload []byte, templates []Template) (Form, error) {
+	if len(payload) < dsp.DataPayloadHeaderBytes {
+		return Form{}, fmt.Errorf("form payload is too short")
+	}
+
+	body := payload[dsp.DataPayloadHeaderBytes:]
+	switch payload[0] {
+	case formatPlain:
+		body = bytes.TrimRight(body, "\x00")
+	case formatDeflate:
+		inflated, err := io.ReadAll(flate.NewReader(bytes.NewReader(body)))
+		if err != nil {
+			return Form{}, fmt.Errorf("form payload does not inflate: %w", err)
+		}
+		body = inflated
+	default:
+		return Form{}, fmt.Errorf("unknown form payload format %d", payload[0])
+	}
+	if !dsp.PayloadChecks(payload[:dsp.DataPayloadHeaderBytes], body) {
+		return Form{}, fmt.Errorf("form payload fails its checksum")
+	}
+
+	parts := strings.Split(string(body), string(separator))
+	if len(parts) < 3 {
+		return Form{}, fmt.Errorf("form payload is missing its header")
+	}
+	form := Form{Template: parts[0], From: parts[1], To: parts[2]}
+	values := parts[3:]
+
+	t, known := Find(templates, form.Template)
+	if !known {
+		form.Name = form.Template
+		for i, text := range values {
+			name := fmt.Sprintf("field%d", i+1)
+			form.Values = append(form.Values, Value{Name: name, Label: fmt.Sprintf("Field %d", i+1), Text: text})
+		}
+		return form, nil
+	}
+
+	form.Template, form.Name = t.Code, t.Name
+	for i, field := range t.Fields {
+		text := ""
+		if i < len(values) {
+			text = values[i]
+		}
+		form.Values = append(form.Values, Value{Name: field.Name, Label: field.Label, Text: text})
+	}
+	return form, nil
+}
+
+// Frames encodes a form and splits it into data frames under tag
+func Frames(f Form, tag uint8) ([]string, error) {
+	payload, err := Encode(f)
+	if err != nil {
+		return nil, err
+	}
+	return dsp.PackDataFrames(tag, payload)
+}
@@ -0,0 +1,123 @@
+package forms
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormRoundTrip(t *testing.T) {
+	form, err := Fill(ICS213, "k1abc", "w1aw", map[string]string{
+		"to":      "EOC Director",
+		"from":    "Shelter 3 Manager",
+		"subject": "Supplies",
+		"message": "Need 200 cots and 400 blankets by 1800.\nWater is holding.",
+	})
+	if err != nil {
+		t.Fatalf("Fill failed: %v", err)
+	}
+
+	frames, err := Frames(form, 7)
+	if err != nil {
+		t.Fatalf("Frames failed: %v", err)
+	}
+
+	// Frames out of order and repeated still assemble once
+	assembler := NewAssembler(10 * time.Minute)
+	order := rand.New(rand.NewSource(1)).Perm(len(frames))
+	order = append(order, order[0])
+	now := time.Now()
+	var payload []byte
+	for i, n := range order {
+		_, _, p, err := assembler.Add(frames[n], now)
+		if err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if p != nil {
+			if i != len(frames)-1 {
+				t.Fatalf("Payload complete after %d of %d frames", i+1, len(frames))
+			}
+			payload = p
+		}
+	}
+	if payload == nil {
+		t.Fatal("Expected a complete payload")
+	}
+
+	got, err := Decode(payload, Builtin())
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if got.From != "K1ABC" || got.To != "W1AW" || got.Name != ICS213.Name || len(got.Values) != len(ICS213.Fields) {
+		t.Errorf("Unexpected form %+v", got)
+	}
+	if got.Get("message") != form.Get("message") || got.Get("approved_by") != "" {
+		t.Errorf("Expected the message back, got %+v", got.Values)
+	}
+	if got.Summary() != "[ICS213] ICS-213 General Message: Supplies" {
+		t.Errorf("Unexpected summary %q", got.Summary())
+	}
+	if rendered := got.Render(); !strings.Contains(rendered, "Subject: Supplies") || !strings.Contains(rendered, "Message:\nNeed 200 cots") {
+		t.Errorf("Unexpected rendering:\n%s", rendered)
+	}
+
+	// Without the template the values keep their order
+	got, err = Decode(payload, nil)
+	if err != nil || got.Name != "ICS213" || got.Values[2].Label != "Field 3" || got.Values[2].Text != "Supplies" {
+		t.Errorf("Unexpected form without template: %+v, %v", got, err)
+	}
+}
+
+func TestFormErrors(t *testing.T) {
+	if _, err := Fill(ICS213, "K1ABC", "", map[string]string{"colour": "red"}); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+
+	form, _ := Fill(ICS213, "K1ABC", "", map[string]string{"message": strings.Repeat("Long message. ", 20) + randomText(600)})
+	if _, err := Encode(form); err == nil {
+		t.Error("Expected an error for a form too big to send")
+	}
+
+	form, _ = Fill(ICS213, "K1ABC", "", map[string]string{"subject": "Test"})
+	payload, _ := Encode(form)
+	payload[len(payload)-1] ^= 0xff
+	if _, err := Decode(payload, Builtin()); err == nil {
+		t.Error("Expected a damaged payload to fail its checksum")
+	}
+}
+
+func TestAssemblerTimeout(t *testing.T) {
+	form, _ := Fill(ICS213, "K1ABC", "", map[string]string{"message": "Status report, all well at the shelter"})
+	frames, _ := Frames(form, 3)
+	if len(frames) < 2 {
+		t.Fatalf("Expected several frames, got %d", len(frames))
+	}
+
+	assembler := NewAssembler(time.Minute)
+	start := time.Now()
+	assembler.Add(frames[0], start)
+	for i, frame := range frames[1:] {
+		_, _, payload, _ := assembler.Add(frame, start.Add(2*time.Minute))
+		if payload != nil {
+			t.Fatalf("Expected the stale first frame to be dropped, completed at frame %d", i+1)
+		}
+	}
+	if _, _, payload, _ := assembler.Add(frames[0], start.Add(2*time.Minute)); payload == nil {
+		t.Error("Expected the payload once the first frame is heard again")
+	}
+
+	if _, _, _, err := assembler.Add("HELLO-WORLD-", start); err == nil {
+		t.Error("Expected plain text to be rejected")
+	}
+}
+
+// randomText returns text that does not compress
+func randomText(n int) string {
+	r := rand.New(rand.NewSource(2))
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('a' + r.Intn(26))
+	}
+	return string(b)
+}
@@ -0,0 +1,138 @@
+// Package gpsd reads time and position fixes from gpsd, so a portable
+// station can keep its clock and grid square right without a network.
+package gpsd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"time"
+)
+
+// DefaultAddress is where gpsd listens unless configured otherwise
+const DefaultAddress = "localhost:2947"
+
+// Fix modes reported by gpsd
+const (
+	ModeNoFix = 1
+	Mode2D    = 2
+	Mode3D    = 3
+)
+
+// watchCommand asks gpsd to stream reports as JSON
+const watchCommand = "?WATCH={\"enable\":true,\"json\":true};\n"
+
+// Fix is one time-position-velocity report from gpsd
+type Fix struct {
+	Mode     int       // ModeNoFix, Mode2D or Mode3D; 0 when unknown
+	Time     time.Time // GPS time of the fix, zero when gpsd has none
+	Lat      float64   // degrees north
+	Lon      float64   // degrees east
+	Received time.Time // system time the report arrived
+}
+
+// HasPosition reports whether the fix includes a position
+func (f Fix) HasPosition() bool {
+	return f.Mode >= Mode2D
+}
+
+// tpv is the part of gpsd's TPV report js8d reads
+type tpv struct {
+	Class string   `json:"class"`
+	Mode  int      `json:"mode"`
+	Time  string   `json:"time"`
+	Lat   *float64 `json:"lat"`
+	Lon   *float64 `json:"lon"`
+}
+
+// Client connects to gpsd
+type Client struct {
+	Address string // host:port, empty uses DefaultAddress
+}
+
+// Watch connects to gpsd and calls fix for each TPV report until ctx is
+// done or the connection fails
+func (c *Client) Watch(ctx context.Context, fix func(Fix)) error {
+	address := c.Address
+	if address == "" {
+		address = DefaultAddress
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to gpsd at %s: %w", address, err)
+	}
+	defer conn.Close()
+
+	// Unblock the read when ctx ends
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if _, err := conn.Write([]byte(watchCommand)); err != nil {
+		return fmt.Errorf("failed to start gpsd watch: %w", err)
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		if report, ok := ParseReport(scanner.Bytes(), time.Now()); ok {
+			fix(report)
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("gpsd connection failed: %w", err)
+	}
+	return fmt.Errorf("gpsd closed the connection")
+}
+
+// ParseReport reads one line from gpsd, returning the fix if it is a TPV
+// report. Other report classes are ignored.
+func ParseReport(line []byte, received time.Time) (Fix, bool) {
+	var report tpv
+	if err := json.Unmarshal(line, &report); err != nil || report.Class != "TPV" {
+		return Fix{}, false
+	}
+
+	fix := Fix{Mode: report.Mode, Received: received}
+	if report.Time != "" {
+		if t, err := time.Parse(time.RFC3339Nano, report.Time); err == nil {
+			fix.Time = t
+		}
+	}
+	if report.Lat != nil && report.Lon != nil {
+		fix.Lat, fix.Lon = *report.Lat, *report.Lon
+	} else if fix.Mode >= Mode2D {
+		fix.Mode = ModeNoFix
+	}
+	return fix, true
+}
+
+// Grid returns the 6 character Maidenhead locator of a position, east and
+// north positive, e.g. FN20xr
+func Grid(lat, lon float64) string {
+	lon = math.Min(math.Max(lon+180, 0), 360-1e-9)
+	lat = math.Min(math.Max(lat+90, 0), 180-1e-9)
+
+	grid := []byte{
+		byte('A' + int(lon/20)),
+		byte('A' + int(lat/10)),
+		byte('0' + int(math.Mod(lon, 20)/2)),
+		byte('0' + int(math.Mod(lat, 10))),
+		byte('a' + int(math.Mod(lon, 2)*12)),
+		byte('a' + int(math.Mod(lat, 1)*24)),
+	}
+	return string(grid)
+}
@@ -0,0 +1,103 @@
+package gpsd
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGrid(t *testing.T) {
+	tests := []struct {
+		lat, lon float64
+		grid     string
+	}{
+		{40.7128, -74.0060, "FN20xr"},  // New York
+		{51.5074, -0.1278, "IO91wm"},   // London
+		{-33.8688, 151.2093, "QF56od"}, // Sydney
+		{35.6762, 139.6503, "PM95tq"},  // Tokyo
+		{90, 180, "RR99xx"},            // Edges stay in range
+		{-90, -180, "AA00aa"},
+	}
+	for _, tt := range tests {
+		if got := Grid(tt.lat, tt.lon); got != tt.grid {
+			t.Errorf("Grid(%.4f, %.4f) = %s, want %s", tt.lat, tt.lon, got, tt.grid)
+		}
+	}
+}
+
+func TestParseReport(t *testing.T) {
+	now := time.Now()
+
+	fix, ok := ParseReport([]byte(`{"class":"TPV","device":"/dev/ttyACM0","mode":3,"time":"2026-03-01T12:00:00.500Z","lat":40.7128,"lon":-74.0060,"alt":10.0}`), now)
+	if !ok || !fix.HasPosition() {
+		t.Fatalf("Expected a 3D fix, got %+v", fix)
+	}
+	if fix.Lat != 40.7128 || fix.Lon != -74.0060 || fix.Received != now {
+		t.Errorf("Unexpected position %+v", fix)
+	}
+	if want := time.Date(2026, 3, 1, 12, 0, 0, 500000000, time.UTC); !fix.Time.Equal(want) {
+		t.Errorf("Expected time %v, got %v", want, fix.Time)
+	}
+
+	// Time without a position, as before the first fix
+	fix, ok = ParseReport([]byte(`{"class":"TPV","mode":1,"time":"2026-03-01T12:00:01Z"}`), now)
+	if !ok || fix.HasPosition() || fix.Time.IsZero() {
+		t.Errorf("Expected time only, got %+v", fix)
+	}
+
+	for _, line := range []string{
+		`{"class":"SKY","satellites":[]}`,
+		`{"class":"VERSION","release":"3.25"}`,
+		`not json`,
+	} {
+		if _, ok := ParseReport([]byte(line), now); ok {
+			t.Errorf("Expected %s to be ignored", line)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	watched := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte(`{"class":"VERSION","release":"3.25","proto_major":3}` + "\n"))
+		command, _ := bufio.NewReader(conn).ReadString('\n')
+		watched <- command
+		conn.Write([]byte(`{"class":"TPV","mode":2,"time":"2026-03-01T12:00:00Z","lat":51.5074,"lon":-0.1278}` + "\n"))
+		conn.Write([]byte(`{"class":"TPV","mode":3,"time":"2026-03-01T12:00:01Z","lat":51.5075,"lon":-0.1279}` + "\n"))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var fixes []Fix
+	client := &Client{Address: listener.Addr().String()}
+	err = client.Watch(ctx, func(fix Fix) {
+		fixes = append(fixes, fix)
+	})
+	if err == nil {
+		t.Errorf("Expected an error once gpsd hangs up")
+	}
+	if command := <-watched; !strings.HasPrefix(command, "?WATCH=") {
+		t.Errorf("Expected a WATCH command, got %q", command)
+	}
+	if len(fixes) != 2 || Grid(fixes[1].Lat, fixes[1].Lon) != "IO91wm" {
+		t.Errorf("Expected two fixes in IO91wm, got %+v", fixes)
+	}
+
+	if err := (&Client{Address: "127.0.0.1:1"}).Watch(ctx, func(Fix) {}); err == nil {
+		t.Errorf("Expected an error when gpsd is not running")
+	}
+}
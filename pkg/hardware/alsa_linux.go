@@ -4,10 +4,10 @@ package hardware
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 )
@@ -53,7 +53,17 @@ type ALSAAudio struct {
 	outputSamples chan []int16
 
 	// Worker control
-	stopChan chan struct{}
+	stopChan   chan struct{}
+	inputDone  chan struct{} // closed when the input worker returns
+	outputDone chan struct{} // closed when the output worker returns
+
+	// Buffer health, updated by the workers: latencies in nanoseconds
+	// measured after each read and write, and event counts
+	inputLatency  int64
+	outputLatency int64
+	overruns      int64
+	underruns     int64
+	dropped       int64
 }
 
 // Override the fallback function with real ALSA implementation
@@ -62,11 +72,11 @@ func init() {
 		audio := NewALSAAudio(config)
 		// Test if ALSA is actually available by trying to validate devices
 		if err := audio.validateDevices(); err != nil {
-			log.Printf("ALSA: Device validation failed: %v", err)
-			log.Printf("ALSA: Falling back to mock audio - check device configuration")
+			logger.Errorf("ALSA: Device validation failed: %v", err)
+			logger.Warnf("ALSA: Falling back to mock audio - check device configuration")
 			return nil
 		}
-		log.Printf("ALSA: Real ALSA audio system validated successfully")
+		logger.Infof("ALSA: Real ALSA audio system validated successfully")
 		return audio
 	}
 }
@@ -83,6 +93,9 @@ func NewALSAAudio(config ALSAAudioConfig) *ALSAAudio {
 	if config.Channels == 0 {
 		config.Channels = 1 // Mono for radio applications
 	}
+	if config.InputChannels == 0 {
+		config.InputChannels = config.Channels
+	}
 
 	return &ALSAAudio{
 		config:        config,
@@ -125,11 +138,11 @@ func (a *ALSAAudio) validateDevices() error {
 
 // Initialize initializes the ALSA audio system
 func (a *ALSAAudio) Initialize() error {
-	log.Printf("ALSA: Initializing audio system...")
-	log.Printf("ALSA: Input device: %s", a.config.InputDevice)
-	log.Printf("ALSA: Output device: %s", a.config.OutputDevice)
-	log.Printf("ALSA: Sample rate: %d Hz", a.config.SampleRate)
-	log.Printf("ALSA: Buffer size: %d samples", a.config.BufferSize)
+	logger.Infof("ALSA: Initializing audio system...")
+	logger.Infof("ALSA: Input device: %s", a.config.InputDevice)
+	logger.Infof("ALSA: Output device: %s", a.config.OutputDevice)
+	logger.Infof("ALSA: Sample rate: %d Hz", a.config.SampleRate)
+	logger.Infof("ALSA: Buffer size: %d samples", a.config.BufferSize)
 
 	// Initialize input device
 	if a.config.InputDevice != "" {
@@ -138,20 +151,21 @@ func (a *ALSAAudio) Initialize() error {
 		}
 	}
 
-	// Initialize output device
-	if a.config.OutputDevice != "" {
+	// Initialize output device; a half-duplex card is only opened for
+	// output while playing
+	if a.config.OutputDevice != "" && !a.config.HalfDuplex {
 		if err := a.initializeOutput(); err != nil {
 			return fmt.Errorf("failed to initialize output: %w", err)
 		}
 	}
 
-	log.Printf("ALSA: Audio system initialized successfully")
+	logger.Infof("ALSA: Audio system initialized successfully")
 	return nil
 }
 
 // initializeInput initializes ALSA input device
 func (a *ALSAAudio) initializeInput() error {
-	log.Printf("ALSA: Setting up input device: %s", a.config.InputDevice)
+	logger.Infof("ALSA: Setting up input device: %s", a.config.InputDevice)
 
 	// Validate device existence before attempting to open
 	if err := a.validateDeviceExists(a.config.InputDevice, "input"); err != nil {
@@ -165,7 +179,7 @@ func (a *ALSAAudio) initializeInput() error {
 	ret := C.snd_pcm_open(&a.inputHandle, deviceName, C.SND_PCM_STREAM_CAPTURE, 0)
 	if ret < 0 {
 		alsaError := C.GoString(C.alsa_strerror_wrapper(ret))
-		log.Printf("ALSA: Failed to open input device %s: %s (error code: %d)",
+		logger.Errorf("ALSA: Failed to open input device %s: %s (error code: %d)",
 			a.config.InputDevice, alsaError, int(ret))
 		return fmt.Errorf("unable to open input device %s: %s (error code: %d)",
 			a.config.InputDevice, alsaError, int(ret))
@@ -173,19 +187,19 @@ func (a *ALSAAudio) initializeInput() error {
 
 	// Configure hardware parameters
 	if err := a.configureHardwareParams(a.inputHandle, "input"); err != nil {
-		log.Printf("ALSA: Hardware parameter configuration failed for input device, closing handle")
+		logger.Errorf("ALSA: Hardware parameter configuration failed for input device, closing handle")
 		C.snd_pcm_close(a.inputHandle)
 		a.inputHandle = nil
 		return err
 	}
 
-	log.Printf("ALSA: Input device configured successfully")
+	logger.Infof("ALSA: Input device configured successfully")
 	return nil
 }
 
 // initializeOutput initializes ALSA output device
 func (a *ALSAAudio) initializeOutput() error {
-	log.Printf("ALSA: Setting up output device: %s", a.config.OutputDevice)
+	logger.Infof("ALSA: Setting up output device: %s", a.config.OutputDevice)
 
 	// Validate device existence before attempting to open
 	if err := a.validateDeviceExists(a.config.OutputDevice, "output"); err != nil {
@@ -199,7 +213,7 @@ func (a *ALSAAudio) initializeOutput() error {
 	ret := C.snd_pcm_open(&a.outputHandle, deviceName, C.SND_PCM_STREAM_PLAYBACK, 0)
 	if ret < 0 {
 		alsaError := C.GoString(C.alsa_strerror_wrapper(ret))
-		log.Printf("ALSA: Failed to open output device %s: %s (error code: %d)",
+		logger.Errorf("ALSA: Failed to open output device %s: %s (error code: %d)",
 			a.config.OutputDevice, alsaError, int(ret))
 		return fmt.Errorf("unable to open output device %s: %s (error code: %d)",
 			a.config.OutputDevice, alsaError, int(ret))
@@ -207,13 +221,13 @@ func (a *ALSAAudio) initializeOutput() error {
 
 	// Configure hardware parameters
 	if err := a.configureHardwareParams(a.outputHandle, "output"); err != nil {
-		log.Printf("ALSA: Hardware parameter configuration failed for output device, closing handle")
+		logger.Errorf("ALSA: Hardware parameter configuration failed for output device, closing handle")
 		C.snd_pcm_close(a.outputHandle)
 		a.outputHandle = nil
 		return err
 	}
 
-	log.Printf("ALSA: Output device configured successfully")
+	logger.Infof("ALSA: Output device configured successfully")
 	return nil
 }
 
@@ -250,8 +264,12 @@ func (a *ALSAAudio) configureHardwareParams(handle *C.snd_pcm_t, deviceType stri
 			deviceType, C.GoString(C.alsa_strerror_wrapper(ret)))
 	}
 
-	// Set number of channels
-	ret = C.snd_pcm_hw_params_set_channels(handle, params, C.uint(a.config.Channels))
+	// Set number of channels (capture may be stereo for two receivers)
+	channels := a.config.Channels
+	if deviceType == "input" {
+		channels = a.config.InputChannels
+	}
+	ret = C.snd_pcm_hw_params_set_channels(handle, params, C.uint(channels))
 	if ret < 0 {
 		return fmt.Errorf("unable to set channels for %s: %s",
 			deviceType, C.GoString(C.alsa_strerror_wrapper(ret)))
@@ -280,8 +298,8 @@ func (a *ALSAAudio) configureHardwareParams(handle *C.snd_pcm_t, deviceType stri
 			deviceType, C.GoString(C.alsa_strerror_wrapper(ret)))
 	}
 
-	log.Printf("ALSA: %s configured - %d Hz, %d channels, %d buffer",
-		deviceType, int(sampleRate), a.config.Channels, int(bufferSize))
+	logger.Infof("ALSA: %s configured - %d Hz, %d channels, %d buffer",
+		deviceType, int(sampleRate), channels, int(bufferSize))
 	return nil
 }
 
@@ -294,24 +312,45 @@ func (a *ALSAAudio) StartInput() error {
 		return fmt.Errorf("audio input already started")
 	}
 
+	if a.inputHandle == nil && a.config.HalfDuplex && a.config.InputDevice != "" {
+		if err := a.initializeInput(); err != nil {
+			return err
+		}
+	}
 	if a.inputHandle == nil {
 		return fmt.Errorf("input device not initialized")
 	}
 
 	a.recording = true
-	go a.inputWorker()
+	a.inputDone = make(chan struct{})
+	go a.inputWorker(a.inputDone)
 
-	log.Printf("ALSA: Audio input started")
+	logger.Infof("ALSA: Audio input started")
 	return nil
 }
 
-// StopInput stops audio input capture
+// StopInput stops audio input capture. A half-duplex card is closed once
+// the read in progress finishes, so it is free for output.
 func (a *ALSAAudio) StopInput() error {
 	a.mutex.Lock()
-	defer a.mutex.Unlock()
-
 	a.recording = false
-	log.Printf("ALSA: Audio input stopped")
+	done := a.inputDone
+	a.mutex.Unlock()
+
+	if a.config.HalfDuplex {
+		if done != nil {
+			<-done
+		}
+		a.mutex.Lock()
+		if a.inputHandle != nil {
+			C.snd_pcm_drop(a.inputHandle)
+			C.snd_pcm_close(a.inputHandle)
+			a.inputHandle = nil
+		}
+		a.mutex.Unlock()
+	}
+
+	logger.Infof("ALSA: Audio input stopped")
 	return nil
 }
 
@@ -324,24 +363,45 @@ func (a *ALSAAudio) StartOutput() error {
 		return fmt.Errorf("audio output already started")
 	}
 
+	if a.outputHandle == nil && a.config.HalfDuplex && a.config.OutputDevice != "" {
+		if err := a.initializeOutput(); err != nil {
+			return err
+		}
+	}
 	if a.outputHandle == nil {
 		return fmt.Errorf("output device not initialized")
 	}
 
 	a.playing = true
-	go a.outputWorker()
+	a.outputDone = make(chan struct{})
+	go a.outputWorker(a.outputDone)
 
-	log.Printf("ALSA: Audio output started")
+	logger.Infof("ALSA: Audio output started")
 	return nil
 }
 
-// StopOutput stops audio output
+// StopOutput stops audio output. A half-duplex card is closed once what
+// was written has played out, so it is free for input.
 func (a *ALSAAudio) StopOutput() error {
 	a.mutex.Lock()
-	defer a.mutex.Unlock()
-
 	a.playing = false
-	log.Printf("ALSA: Audio output stopped")
+	done := a.outputDone
+	a.mutex.Unlock()
+
+	if a.config.HalfDuplex {
+		if done != nil {
+			<-done
+		}
+		a.mutex.Lock()
+		if a.outputHandle != nil {
+			C.snd_pcm_drain(a.outputHandle)
+			C.snd_pcm_close(a.outputHandle)
+			a.outputHandle = nil
+		}
+		a.mutex.Unlock()
+	}
+
+	logger.Infof("ALSA: Audio output stopped")
 	return nil
 }
 
@@ -388,13 +448,14 @@ func (a *ALSAAudio) Close() error {
 	close(a.inputSamples)
 	close(a.outputSamples)
 
-	log.Printf("ALSA: Audio system closed")
+	logger.Infof("ALSA: Audio system closed")
 	return nil
 }
 
 // inputWorker captures audio from ALSA input device
-func (a *ALSAAudio) inputWorker() {
-	buffer := make([]int16, a.config.BufferSize*a.config.Channels)
+func (a *ALSAAudio) inputWorker(done chan struct{}) {
+	defer close(done)
+	buffer := make([]int16, a.config.BufferSize*a.config.InputChannels)
 
 	for a.isRecording() {
 		ret := C.snd_pcm_readi(a.inputHandle,
@@ -402,18 +463,26 @@ func (a *ALSAAudio) inputWorker() {
 			C.snd_pcm_uframes_t(a.config.BufferSize))
 
 		if ret < 0 {
-			// Handle underrun
+			// Handle overrun, the card's buffer filled before we read it
 			if ret == -C.EPIPE {
-				log.Printf("ALSA: Input underrun, recovering...")
+				atomic.AddInt64(&a.overruns, 1)
+				logger.Warnf("ALSA: Input overrun, recovering...")
 				C.snd_pcm_prepare(a.inputHandle)
 				continue
 			}
-			log.Printf("ALSA: Input error: %s", C.GoString(C.alsa_strerror_wrapper(C.int(ret))))
+			logger.Errorf("ALSA: Input error: %s", C.GoString(C.alsa_strerror_wrapper(C.int(ret))))
 			continue
 		}
 
+		// What is still waiting in the card, and the block just read,
+		// is how old this audio is
+		var delay C.snd_pcm_sframes_t
+		if C.snd_pcm_delay(a.inputHandle, &delay) == 0 {
+			atomic.StoreInt64(&a.inputLatency, int64(a.framesDuration(int(delay)+int(ret))))
+		}
+
 		// Copy samples to avoid race conditions using buffer pool
-		sampleCount := int(ret * C.snd_pcm_sframes_t(a.config.Channels))
+		sampleCount := int(ret * C.snd_pcm_sframes_t(a.config.InputChannels))
 		samples := GetAudioBufferSlice(sampleCount)
 		copy(samples, buffer[:sampleCount])
 
@@ -422,12 +491,14 @@ func (a *ALSAAudio) inputWorker() {
 		case a.inputSamples <- samples:
 		default:
 			// Drop samples if buffer full
+			atomic.AddInt64(&a.dropped, 1)
 		}
 	}
 }
 
 // outputWorker plays audio to ALSA output device
-func (a *ALSAAudio) outputWorker() {
+func (a *ALSAAudio) outputWorker(done chan struct{}) {
+	defer close(done)
 	for a.isPlaying() {
 		select {
 		case samples := <-a.outputSamples:
@@ -438,15 +509,21 @@ func (a *ALSAAudio) outputWorker() {
 			if ret < 0 {
 				// Handle underrun
 				if ret == -C.EPIPE {
-					log.Printf("ALSA: Output underrun, recovering...")
+					atomic.AddInt64(&a.underruns, 1)
+					logger.Warnf("ALSA: Output underrun, recovering...")
 					C.snd_pcm_prepare(a.outputHandle)
 					continue
 				}
-				log.Printf("ALSA: Output error: %s", C.GoString(C.alsa_strerror_wrapper(C.int(ret))))
+				logger.Errorf("ALSA: Output error: %s", C.GoString(C.alsa_strerror_wrapper(C.int(ret))))
 				continue
 			}
 
-			log.Printf("ALSA: Played %d samples", len(samples))
+			var delay C.snd_pcm_sframes_t
+			if C.snd_pcm_delay(a.outputHandle, &delay) == 0 {
+				atomic.StoreInt64(&a.outputLatency, int64(a.framesDuration(int(delay))))
+			}
+
+			logger.Debugf("ALSA: Played %d samples", len(samples))
 
 		case <-a.stopChan:
 			return
@@ -458,6 +535,26 @@ func (a *ALSAAudio) outputWorker() {
 	}
 }
 
+// framesDuration is how long frames take to play at the sample rate
+func (a *ALSAAudio) framesDuration(frames int) time.Duration {
+	return time.Duration(frames) * time.Second / time.Duration(a.config.SampleRate)
+}
+
+// Health reports the latencies last measured and the buffer events
+func (a *ALSAAudio) Health() AudioHealth {
+	return AudioHealth{
+		InputLatency:    time.Duration(atomic.LoadInt64(&a.inputLatency)),
+		OutputLatency:   time.Duration(atomic.LoadInt64(&a.outputLatency)),
+		InputQueued:     len(a.inputSamples),
+		InputQueueSize:  cap(a.inputSamples),
+		OutputQueued:    len(a.outputSamples),
+		OutputQueueSize: cap(a.outputSamples),
+		Overruns:        atomic.LoadInt64(&a.overruns),
+		Underruns:       atomic.LoadInt64(&a.underruns),
+		InputDropped:    atomic.LoadInt64(&a.dropped),
+	}
+}
+
 // isRecording checks if audio input is active
 func (a *ALSAAudio) isRecording() bool {
 	a.mutex.RLock()
@@ -482,6 +579,11 @@ func (a *ALSAAudio) GetBufferSize() int {
 	return a.config.BufferSize
 }
 
+// GetInputChannels returns the number of interleaved capture channels
+func (a *ALSAAudio) GetInputChannels() int {
+	return a.config.InputChannels
+}
+
 // IsRecording returns whether audio input is active
 func (a *ALSAAudio) IsRecording() bool {
 	return a.isRecording()
@@ -529,18 +631,18 @@ func (a *ALSAAudio) validateDeviceExists(deviceName, deviceType string) error {
 				}
 
 				if _, err := os.Stat(pcmPath); err != nil {
-					log.Printf("ALSA: Warning - PCM device %s not found, but will attempt to open anyway", pcmPath)
+					logger.Warnf("ALSA: PCM device %s not found, but will attempt to open anyway", pcmPath)
 					// Don't fail here as some devices may not have separate device nodes
 				}
 			}
 
-			log.Printf("ALSA: Device validation passed for %s (%s)", deviceName, deviceType)
+			logger.Infof("ALSA: Device validation passed for %s (%s)", deviceName, deviceType)
 			return nil
 		}
 	}
 
 	// For other device names (like "pulse", custom names), log but don't fail
-	log.Printf("ALSA: Cannot validate non-standard device name '%s', will attempt to open", deviceName)
+	logger.Warnf("ALSA: Cannot validate non-standard device name '%s', will attempt to open", deviceName)
 	return nil
 }
 
@@ -600,9 +702,9 @@ func GetAudioDevices() ([]AudioDevice, error) {
 		})
 		deviceID++
 
-		log.Printf("ALSA: Found audio card %d: %s", card, cardName)
+		logger.Infof("ALSA: Found audio card %d: %s", card, cardName)
 	}
 
-	log.Printf("ALSA: Enumerated %d audio devices", len(devices))
+	logger.Infof("ALSA: Enumerated %d audio devices", len(devices))
 	return devices, nil
 }
\ No newline at end of file
@@ -4,11 +4,13 @@ package hardware
 
 // ALSAAudioConfig represents ALSA audio configuration
 type ALSAAudioConfig struct {
-	InputDevice  string
-	OutputDevice string
-	SampleRate   int
-	BufferSize   int
-	Channels     int
+	InputDevice   string
+	OutputDevice  string
+	SampleRate    int
+	BufferSize    int
+	Channels      int
+	InputChannels int  // Capture channels, defaults to Channels
+	HalfDuplex    bool // The card can't capture and play at once
 }
 
 // Fallback tryCreateALSAAudio when ALSA is not available
@@ -0,0 +1,123 @@
+package hardware
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// AntennaSwitch connects the rig to one of several antenna ports
+type AntennaSwitch interface {
+	Select(port int) error
+	Close() error
+}
+
+// GPIOAntennaSwitch drives a relay per antenna port from GPIO pins, the
+// selected port's pin high and the others low
+type GPIOAntennaSwitch struct {
+	gpio  GPIOInterface
+	pins  []int
+	mutex sync.Mutex
+}
+
+// NewGPIOAntennaSwitch creates an antenna switch with one pin per port
+func NewGPIOAntennaSwitch(gpio GPIOInterface, pins []int) *GPIOAntennaSwitch {
+	return &GPIOAntennaSwitch{
+		gpio: gpio,
+		pins: pins,
+	}
+}
+
+// Select drives the pin of port high. The other pins go low first, so two
+// antennas are never connected at once.
+func (s *GPIOAntennaSwitch) Select(port int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if port < 0 || port >= len(s.pins) {
+		return fmt.Errorf("antenna port %d out of range", port)
+	}
+	for i, pin := range s.pins {
+		if i == port {
+			continue
+		}
+		if err := s.gpio.SetPin(pin, false); err != nil {
+			return err
+		}
+	}
+	return s.gpio.SetPin(s.pins[port], true)
+}
+
+// Close releases the GPIO pins
+func (s *GPIOAntennaSwitch) Close() error {
+	return s.gpio.Close()
+}
+
+// RelayAntennaSwitch drives a USB serial relay board of the LCUS type, whose
+// channels are switched by 4-byte commands: 0xA0, the channel, 1 for on or
+// 0 for off, and the sum of the first three
+type RelayAntennaSwitch struct {
+	port   io.WriteCloser
+	relays []int
+	mutex  sync.Mutex
+}
+
+// NewRelayAntennaSwitch creates an antenna switch writing to port, with one
+// relay channel per antenna port
+func NewRelayAntennaSwitch(port io.WriteCloser, relays []int) *RelayAntennaSwitch {
+	return &RelayAntennaSwitch{
+		port:   port,
+		relays: relays,
+	}
+}
+
+// OpenRelayAntennaSwitch opens a relay board on a serial device such as
+// /dev/ttyUSB0. The boards listen at 9600 baud, the rate Linux opens serial
+// ports at.
+func OpenRelayAntennaSwitch(device string, relays []int) (*RelayAntennaSwitch, error) {
+	port, err := os.OpenFile(device, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open relay board: %w", err)
+	}
+	logger.Infof("Relay board opened on %s (%d channels used)", device, len(relays))
+	return NewRelayAntennaSwitch(port, relays), nil
+}
+
+// Select closes the relay of port, opening the others first
+func (s *RelayAntennaSwitch) Select(port int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if port < 0 || port >= len(s.relays) {
+		return fmt.Errorf("antenna port %d out of range", port)
+	}
+	for i, relay := range s.relays {
+		if i == port {
+			continue
+		}
+		if _, err := s.port.Write(relayCommand(relay, false)); err != nil {
+			return fmt.Errorf("failed to open relay %d: %w", relay, err)
+		}
+	}
+	if _, err := s.port.Write(relayCommand(s.relays[port], true)); err != nil {
+		return fmt.Errorf("failed to close relay %d: %w", s.relays[port], err)
+	}
+	return nil
+}
+
+// Close closes the serial port, leaving the relays as they are
+func (s *RelayAntennaSwitch) Close() error {
+	return s.port.Close()
+}
+
+// relayCommand returns the command switching a relay board channel
+func relayCommand(channel int, on bool) []byte {
+	state := byte(0)
+	if on {
+		state = 1
+	}
+	command := []byte{0xA0, byte(channel), state, 0}
+	command[3] = command[0] + command[1] + command[2]
+	return command
+}
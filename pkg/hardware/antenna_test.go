@@ -0,0 +1,61 @@
+package hardware
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGPIOAntennaSwitch(t *testing.T) {
+	gpio := NewMockGPIO()
+	antennas := NewGPIOAntennaSwitch(gpio, []int{5, 6, 13})
+
+	for port, pin := range []int{5, 6, 13} {
+		if err := antennas.Select(port); err != nil {
+			t.Fatalf("Failed to select port %d: %v", port, err)
+		}
+		for _, other := range []int{5, 6, 13} {
+			value, _ := gpio.GetPin(other)
+			if value != (other == pin) {
+				t.Errorf("Port %d selected, expected pin %d to be %t", port, other, other == pin)
+			}
+		}
+	}
+
+	if err := antennas.Select(3); err == nil {
+		t.Error("Expected an error selecting a port with no pin")
+	}
+}
+
+// relayPort records what is written to a relay board
+type relayPort struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (p *relayPort) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestRelayAntennaSwitch(t *testing.T) {
+	port := &relayPort{}
+	antennas := NewRelayAntennaSwitch(port, []int{1, 2})
+
+	if err := antennas.Select(1); err != nil {
+		t.Fatalf("Failed to select port: %v", err)
+	}
+	want := []byte{
+		0xA0, 0x01, 0x00, 0xA1, // relay 1 open
+		0xA0, 0x02, 0x01, 0xA3, // relay 2 closed
+	}
+	if !bytes.Equal(port.Bytes(), want) {
+		t.Errorf("Expected commands % X, got % X", want, port.Bytes())
+	}
+
+	if err := antennas.Select(-1); err == nil {
+		t.Error("Expected an error selecting a negative port")
+	}
+	if err := antennas.Close(); err != nil || !port.closed {
+		t.Errorf("Expected the port to close, got %v", err)
+	}
+}
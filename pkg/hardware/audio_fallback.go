@@ -5,11 +5,12 @@ package hardware
 // NewPlatformAudio creates a mock audio implementation for unsupported platforms
 func NewPlatformAudio(config PlatformAudioConfig) AudioInterface {
 	mockConfig := MockAudioConfig{
-		InputDevice:  config.InputDevice,
-		OutputDevice: config.OutputDevice,
-		SampleRate:   config.SampleRate,
-		BufferSize:   config.BufferSize,
-		Channels:     config.Channels,
+		InputDevice:   config.InputDevice,
+		OutputDevice:  config.OutputDevice,
+		SampleRate:    config.SampleRate,
+		BufferSize:    config.BufferSize,
+		Channels:      config.Channels,
+		InputChannels: config.InputChannels,
 	}
 	return NewMockAudio(mockConfig)
 }
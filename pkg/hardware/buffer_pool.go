@@ -1,7 +1,6 @@
 package hardware
 
 import (
-	"log"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -118,7 +117,7 @@ func NewAudioBufferPool(maxBufferSize int, enableStats bool) *AudioBufferPool {
 // Get retrieves a buffer of at least the requested size from the appropriate pool
 func (p *AudioBufferPool) Get(size int) *AudioBuffer {
 	if size <= 0 {
-		log.Printf("AudioBufferPool: Invalid buffer size requested: %d", size)
+		logger.Debugf("AudioBufferPool: Invalid buffer size requested: %d", size)
 		return &AudioBuffer{
 			Data: make([]int16, 1024),
 			Size: size,
@@ -127,7 +126,7 @@ func (p *AudioBufferPool) Get(size int) *AudioBuffer {
 	}
 
 	if size > p.maxBufferSize {
-		log.Printf("AudioBufferPool: Requested size %d exceeds max %d, allocating directly",
+		logger.Debugf("AudioBufferPool: Requested size %d exceeds max %d, allocating directly",
 			size, p.maxBufferSize)
 		return &AudioBuffer{
 			Data: make([]int16, size),
@@ -160,7 +159,7 @@ func (p *AudioBufferPool) Get(size int) *AudioBuffer {
 	// Ensure buffer is large enough and set actual size
 	if cap(buffer.Data) < size {
 		// This shouldn't happen with our pool design, but handle gracefully
-		log.Printf("AudioBufferPool: Pool buffer too small (cap=%d, need=%d), reallocating",
+		logger.Debugf("AudioBufferPool: Pool buffer too small (cap=%d, need=%d), reallocating",
 			cap(buffer.Data), size)
 		buffer.Data = make([]int16, size)
 	}
@@ -253,7 +252,7 @@ func (p *AudioBufferPool) statisticsReporter() {
 
 		if totalRequests > 0 {
 			hitRate := float64(totalHits) / float64(totalRequests) * 100
-			log.Printf("AudioBufferPool Stats: %d requests, %.1f%% hit rate (S:%d/%d M:%d/%d L:%d/%d)",
+			logger.Debugf("AudioBufferPool Stats: %d requests, %.1f%% hit rate (S:%d/%d M:%d/%d L:%d/%d)",
 				totalRequests, hitRate,
 				stats["small_hits"], stats["small_miss"],
 				stats["medium_hits"], stats["medium_miss"],
@@ -0,0 +1,352 @@
+package hardware
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Radio control backends
+const (
+	BackendHamlib = "hamlib"
+	BackendNative = "native"
+)
+
+// CAT command sets the native backend speaks
+const (
+	CATCIV     = "civ"     // Icom CI-V
+	CATKenwood = "kenwood" // Kenwood and Elecraft ASCII, and the QRP Labs rigs
+	CATFT8x7   = "ft8x7"   // Yaesu FT-817, FT-818, FT-857 and FT-897
+)
+
+// CATProtocols lists the CAT command sets of the native backend
+var CATProtocols = []string{CATCIV, CATKenwood, CATFT8x7}
+
+// catTimeout is how long a rig has to answer a CAT command
+const catTimeout = time.Second
+
+// civAddresses are the default CI-V addresses of Icom rigs by Hamlib model
+var civAddresses = map[string]byte{
+	"3009": 0x48, // IC-706
+	"3010": 0x4E, // IC-706MkII
+	"3011": 0x58, // IC-706MkIIG
+	"3056": 0x6A, // IC-7800
+	"3060": 0x70, // IC-7000
+	"3061": 0x76, // IC-7200
+	"3062": 0x74, // IC-7700
+	"3063": 0x7A, // IC-7600
+	"3067": 0x80, // IC-7410
+	"3068": 0x7C, // IC-9100
+	"3070": 0x88, // IC-7100
+	"3073": 0x94, // IC-7300
+	"3075": 0x8E, // IC-7851
+	"3078": 0x98, // IC-7610
+	"3081": 0xA2, // IC-9700
+	"3085": 0xA4, // IC-705
+}
+
+// ft8x7Models are the Hamlib models of the Yaesu rigs with the FT-8x7
+// binary command set; Yaesu's other rigs speak an ASCII one
+var ft8x7Models = map[string]bool{
+	"1020": true, // FT-817
+	"1022": true, // FT-857
+	"1023": true, // FT-897
+	"1041": true, // FT-818
+}
+
+// CATProtocolFor returns the CAT command set of a Hamlib model number,
+// "" when the native backend has no driver for it. Hamlib numbers models
+// by backend, so every Kenwood and Elecraft model (2xxx) and every Icom
+// (3xxx) has one; the QRP Labs QDX and QMX emulate a Kenwood TS-480.
+func CATProtocolFor(model string) string {
+	switch {
+	case model == "10001" || model == "10002":
+		return CATKenwood
+	case ft8x7Models[model]:
+		return CATFT8x7
+	case len(model) == 4 && strings.HasPrefix(model, "2"):
+		return CATKenwood
+	case len(model) == 4 && strings.HasPrefix(model, "3"):
+		return CATCIV
+	}
+	return ""
+}
+
+// catPort is a serial port whose reads can time out
+type catPort interface {
+	io.ReadWriteCloser
+	SetReadDeadline(t time.Time) error
+}
+
+// catLink sends CAT commands over a serial port and reads the answers
+type catLink struct {
+	port   catPort
+	reader *bufio.Reader
+}
+
+// newCATLink wraps a serial port for CAT commands
+func newCATLink(port catPort) *catLink {
+	return &catLink{port: port, reader: bufio.NewReader(port)}
+}
+
+// send writes a command
+func (l *catLink) send(command []byte) error {
+	_, err := l.port.Write(command)
+	return err
+}
+
+// readUntil reads up to and including delim, waiting at most catTimeout
+func (l *catLink) readUntil(delim byte) ([]byte, error) {
+	l.port.SetReadDeadline(time.Now().Add(catTimeout))
+	return l.reader.ReadBytes(delim)
+}
+
+// readN reads n bytes, waiting at most catTimeout
+func (l *catLink) readN(n int) ([]byte, error) {
+	l.port.SetReadDeadline(time.Now().Add(catTimeout))
+	answer := make([]byte, n)
+	_, err := io.ReadFull(l.reader, answer)
+	return answer, err
+}
+
+// catProtocol is one rig family's CAT command set. Modes are the Hamlib
+// names RadioInterface uses, like USB and PKTUSB.
+type catProtocol interface {
+	Name() string
+	SetFrequency(link *catLink, freq int64) error
+	GetFrequency(link *catLink) (int64, error)
+	SetMode(link *catLink, mode string) error
+	GetMode(link *catLink) (string, error)
+	SetPTT(link *catLink, state bool) error
+	GetPTT(link *catLink) (bool, error)
+}
+
+// newCATProtocol returns the command set the radio configuration selects
+func newCATProtocol(config RadioConfig) (catProtocol, error) {
+	name := strings.ToLower(config.CATProtocol)
+	if name == "" {
+		name = CATProtocolFor(config.Model)
+	}
+	switch name {
+	case CATKenwood:
+		return kenwoodCAT{}, nil
+	case CATFT8x7:
+		return ft8x7CAT{}, nil
+	case CATCIV:
+		address, ok := civAddresses[config.Model]
+		if config.CIVAddress != "" {
+			parsed, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(config.CIVAddress), "0x"), 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CI-V address %s", config.CIVAddress)
+			}
+			address, ok = byte(parsed), true
+		}
+		if !ok {
+			return nil, fmt.Errorf("model %s needs a civ_address for native CI-V", config.Model)
+		}
+		return civCAT{address: address}, nil
+	case "":
+		return nil, fmt.Errorf("no native CAT driver for model %s; set cat_protocol or use the hamlib backend", config.Model)
+	}
+	return nil, fmt.Errorf("unknown CAT protocol %s", config.CATProtocol)
+}
+
+// NativeRadio implements RadioInterface by speaking the rig's CAT command
+// set directly, without Hamlib
+type NativeRadio struct {
+	config RadioConfig
+	mutex  sync.Mutex
+
+	protocol  catProtocol
+	link      *catLink
+	open      func() (catPort, error)
+	connected bool
+	bandwidth int
+}
+
+// NewNativeRadio creates a radio controlled by the native CAT drivers
+func NewNativeRadio(config RadioConfig) *NativeRadio {
+	return &NativeRadio{
+		config: config,
+		open: func() (catPort, error) {
+			return openSerial(config.Device, config.BaudRate)
+		},
+	}
+}
+
+// Initialize opens the serial port and checks the rig answers
+func (r *NativeRadio) Initialize() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	protocol, err := newCATProtocol(r.config)
+	if err != nil {
+		return err
+	}
+	port, err := r.open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", r.config.Device, err)
+	}
+	link := newCATLink(port)
+	freq, err := protocol.GetFrequency(link)
+	if err != nil {
+		port.Close()
+		return fmt.Errorf("rig on %s did not answer %s CAT: %w", r.config.Device, protocol.Name(), err)
+	}
+
+	r.protocol = protocol
+	r.link = link
+	r.connected = true
+	logger.Infof("Native CAT: %s rig on %s at %.3f MHz", protocol.Name(), r.config.Device, float64(freq)/1000000.0)
+	return nil
+}
+
+// Close closes the serial port
+func (r *NativeRadio) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.connected {
+		return nil
+	}
+	r.connected = false
+	return r.link.port.Close()
+}
+
+// command runs a CAT command with the radio locked, failing when it isn't
+// connected
+func (r *NativeRadio) command(run func() error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.connected {
+		return fmt.Errorf("radio not connected")
+	}
+	return run()
+}
+
+// SetFrequency tunes the rig
+func (r *NativeRadio) SetFrequency(freq int64) error {
+	return r.command(func() error {
+		if err := r.protocol.SetFrequency(r.link, freq); err != nil {
+			return fmt.Errorf("failed to set frequency: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetFrequency reads the rig's frequency
+func (r *NativeRadio) GetFrequency() (int64, error) {
+	var freq int64
+	err := r.command(func() (err error) {
+		if freq, err = r.protocol.GetFrequency(r.link); err != nil {
+			return fmt.Errorf("failed to get frequency: %w", err)
+		}
+		return nil
+	})
+	return freq, err
+}
+
+// SetMode sets the rig's mode. The CAT command sets leave the filter as
+// it is, so the bandwidth is only remembered.
+func (r *NativeRadio) SetMode(mode string, bandwidth int) error {
+	return r.command(func() error {
+		if err := r.protocol.SetMode(r.link, mode); err != nil {
+			return fmt.Errorf("failed to set mode: %w", err)
+		}
+		r.bandwidth = bandwidth
+		return nil
+	})
+}
+
+// GetMode reads the rig's mode, with the bandwidth last set
+func (r *NativeRadio) GetMode() (string, int, error) {
+	var mode string
+	var bandwidth int
+	err := r.command(func() (err error) {
+		if mode, err = r.protocol.GetMode(r.link); err != nil {
+			return fmt.Errorf("failed to get mode: %w", err)
+		}
+		bandwidth = r.bandwidth
+		return nil
+	})
+	return mode, bandwidth, err
+}
+
+// SetPTT keys or unkeys the rig
+func (r *NativeRadio) SetPTT(state bool) error {
+	return r.command(func() error {
+		if err := r.protocol.SetPTT(r.link, state); err != nil {
+			return fmt.Errorf("failed to set PTT: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetPTT reads whether the rig is transmitting
+func (r *NativeRadio) GetPTT() (bool, error) {
+	var ptt bool
+	err := r.command(func() (err error) {
+		if ptt, err = r.protocol.GetPTT(r.link); err != nil {
+			return fmt.Errorf("failed to get PTT: %w", err)
+		}
+		return nil
+	})
+	return ptt, err
+}
+
+// GetRadioInfo describes the driver in use
+func (r *NativeRadio) GetRadioInfo() (RadioInfo, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.connected {
+		return RadioInfo{}, fmt.Errorf("radio not connected")
+	}
+	return RadioInfo{
+		Model:        r.config.Model,
+		Manufacturer: "Native CAT (" + r.protocol.Name() + ")",
+		Version:      "native",
+		Capabilities: []string{"frequency", "mode", "ptt"},
+	}, nil
+}
+
+// IsConnected returns whether the rig answered when opened
+func (r *NativeRadio) IsConnected() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.connected
+}
+
+// errNativeUnsupported is returned for the levels the native drivers don't
+// read or set
+var errNativeUnsupported = fmt.Errorf("not supported by the native CAT backend")
+
+// GetPowerLevel is not supported natively
+func (r *NativeRadio) GetPowerLevel() (float32, error) {
+	return 0, errNativeUnsupported
+}
+
+// SetPowerLevel is not supported natively
+func (r *NativeRadio) SetPowerLevel(level float32) error {
+	return errNativeUnsupported
+}
+
+// StartTune is not supported natively
+func (r *NativeRadio) StartTune() error {
+	return errNativeUnsupported
+}
+
+// GetSWRLevel is not supported natively
+func (r *NativeRadio) GetSWRLevel() (float32, error) {
+	return 0, errNativeUnsupported
+}
+
+// GetSignalLevel is not supported natively
+func (r *NativeRadio) GetSignalLevel() (int, error) {
+	return 0, errNativeUnsupported
+}
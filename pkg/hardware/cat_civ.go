@@ -0,0 +1,189 @@
+package hardware
+
+import (
+	"fmt"
+)
+
+// CI-V framing and the controller's address
+const (
+	civPreamble   = 0xFE
+	civEnd        = 0xFD
+	civOK         = 0xFB
+	civNG         = 0xFA
+	civController = 0xE0
+)
+
+// civModes maps CI-V mode numbers to Hamlib mode names
+var civModes = map[byte]string{
+	0x00: ModeLSB,
+	0x01: ModeUSB,
+	0x02: ModeAM,
+	0x03: ModeCW,
+	0x04: ModeRTTY,
+	0x05: ModeFM,
+	0x07: ModeCW,
+	0x08: ModeRTTY,
+}
+
+// civCAT speaks Icom's binary CI-V protocol to the rig at address
+type civCAT struct {
+	address byte
+}
+
+// Name names the command set
+func (civCAT) Name() string {
+	return "Icom CI-V"
+}
+
+// command sends a frame and returns the data of the rig's answer, after
+// the command and subcommand it echoes. The echo of our own frame that a
+// CI-V bus returns, and frames for other controllers, are skipped.
+func (c civCAT) command(link *catLink, command ...byte) ([]byte, error) {
+	frame := append([]byte{civPreamble, civPreamble, c.address, civController}, command...)
+	if err := link.send(append(frame, civEnd)); err != nil {
+		return nil, err
+	}
+	for {
+		answer, err := link.readUntil(civEnd)
+		if err != nil {
+			return nil, err
+		}
+		// Collisions can leave stray bytes before the preamble
+		start := 0
+		for start < len(answer) && answer[start] != civPreamble {
+			start++
+		}
+		answer = answer[start:]
+		for len(answer) > 0 && answer[0] == civPreamble {
+			answer = answer[1:]
+		}
+		if len(answer) < 3 || answer[0] != civController || answer[1] != c.address {
+			continue
+		}
+		data := answer[2 : len(answer)-1]
+		switch {
+		case len(data) == 1 && data[0] == civOK:
+			return nil, nil
+		case len(data) == 1 && data[0] == civNG:
+			return nil, fmt.Errorf("rig rejected command %02X", command[0])
+		case len(data) >= len(command) && string(data[:len(command)]) == string(command):
+			return data[len(command):], nil
+		}
+	}
+}
+
+// civBCD encodes n as digits two to a byte, least significant first
+func civBCD(n int64, bytes int) []byte {
+	encoded := make([]byte, bytes)
+	for i := range encoded {
+		low := n % 10
+		n /= 10
+		encoded[i] = byte(n%10<<4 | low)
+		n /= 10
+	}
+	return encoded
+}
+
+// civFromBCD decodes least significant first BCD
+func civFromBCD(encoded []byte) int64 {
+	var n int64
+	for i := len(encoded) - 1; i >= 0; i-- {
+		n = n*100 + int64(encoded[i]>>4)*10 + int64(encoded[i]&0x0F)
+	}
+	return n
+}
+
+// SetFrequency sets the operating frequency (command 05), five BCD bytes
+// up to 9.999 GHz
+func (c civCAT) SetFrequency(link *catLink, freq int64) error {
+	_, err := c.command(link, append([]byte{0x05}, civBCD(freq, 5)...)...)
+	return err
+}
+
+// GetFrequency reads the operating frequency (command 03)
+func (c civCAT) GetFrequency(link *catLink) (int64, error) {
+	answer, err := c.command(link, 0x03)
+	if err != nil {
+		return 0, err
+	}
+	if len(answer) < 5 {
+		return 0, fmt.Errorf("short frequency % X", answer)
+	}
+	return civFromBCD(answer[:5]), nil
+}
+
+// SetMode sets the mode (command 06) and, for the packet modes, turns data
+// mode on (command 1A 06); the other modes turn it off
+func (c civCAT) SetMode(link *catLink, mode string) error {
+	number, data := byte(0x01), byte(0x00)
+	switch mode {
+	case ModeLSB:
+		number = 0x00
+	case ModePKTLSB:
+		number, data = 0x00, 0x01
+	case ModePKTUSB, ModeJT8, ModePSK:
+		data = 0x01
+	case ModeAM:
+		number = 0x02
+	case ModeCW:
+		number = 0x03
+	case ModeRTTY:
+		number = 0x04
+	case ModeFM:
+		number = 0x05
+	}
+	if _, err := c.command(link, 0x06, number); err != nil {
+		return err
+	}
+	if number > 0x01 {
+		return nil
+	}
+	// Data mode with the first filter; older rigs without it refuse
+	if _, err := c.command(link, 0x1A, 0x06, data, data); err != nil && data == 0x01 {
+		return err
+	}
+	return nil
+}
+
+// GetMode reads the mode (command 04) and, for SSB, whether data mode is on
+func (c civCAT) GetMode(link *catLink) (string, error) {
+	answer, err := c.command(link, 0x04)
+	if err != nil {
+		return "", err
+	}
+	if len(answer) == 0 || civModes[answer[0]] == "" {
+		return "", fmt.Errorf("bad mode % X", answer)
+	}
+	if answer[0] > 0x01 {
+		return civModes[answer[0]], nil
+	}
+	if data, err := c.command(link, 0x1A, 0x06); err == nil && len(data) > 0 && data[0] != 0 {
+		if answer[0] == 0x00 {
+			return ModePKTLSB, nil
+		}
+		return ModePKTUSB, nil
+	}
+	return civModes[answer[0]], nil
+}
+
+// SetPTT keys the transmitter (command 1C 00)
+func (c civCAT) SetPTT(link *catLink, state bool) error {
+	on := byte(0x00)
+	if state {
+		on = 0x01
+	}
+	_, err := c.command(link, 0x1C, 0x00, on)
+	return err
+}
+
+// GetPTT reads whether the rig is transmitting (command 1C 00)
+func (c civCAT) GetPTT(link *catLink) (bool, error) {
+	answer, err := c.command(link, 0x1C, 0x00)
+	if err != nil {
+		return false, err
+	}
+	if len(answer) == 0 {
+		return false, fmt.Errorf("short PTT answer")
+	}
+	return answer[0] == 0x01, nil
+}
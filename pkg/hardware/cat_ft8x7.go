@@ -0,0 +1,129 @@
+package hardware
+
+import (
+	"fmt"
+)
+
+// ft8x7Modes maps FT-8x7 mode numbers to Hamlib mode names
+var ft8x7Modes = map[byte]string{
+	0x00: ModeLSB,
+	0x01: ModeUSB,
+	0x02: ModeCW,
+	0x03: ModeCW,
+	0x04: ModeAM,
+	0x06: ModeFM,
+	0x08: ModeFM,
+	0x0A: ModePKTUSB, // DIG, set to USB-side data by the rig's menu
+	0x0C: ModePKTUSB, // PKT
+	0x88: ModeFM,
+}
+
+// ft8x7CAT speaks the binary CAT protocol of the Yaesu FT-817, FT-818,
+// FT-857 and FT-897: four parameter bytes and an opcode per command
+type ft8x7CAT struct{}
+
+// Name names the command set
+func (ft8x7CAT) Name() string {
+	return "Yaesu FT-8x7"
+}
+
+// ft8x7BCD encodes n as eight BCD digits, most significant first
+func ft8x7BCD(n int64) []byte {
+	encoded := make([]byte, 4)
+	for i := 3; i >= 0; i-- {
+		low := n % 10
+		n /= 10
+		encoded[i] = byte(n%10<<4 | low)
+		n /= 10
+	}
+	return encoded
+}
+
+// ft8x7FromBCD decodes most significant first BCD
+func ft8x7FromBCD(encoded []byte) int64 {
+	var n int64
+	for _, b := range encoded {
+		n = n*100 + int64(b>>4)*10 + int64(b&0x0F)
+	}
+	return n
+}
+
+// SetFrequency sets the frequency (opcode 01) in 10 Hz steps
+func (ft8x7CAT) SetFrequency(link *catLink, freq int64) error {
+	return link.send(append(ft8x7BCD((freq+5)/10), 0x01))
+}
+
+// readStatus reads the frequency and mode (opcode 03)
+func (ft8x7CAT) readStatus(link *catLink) ([]byte, error) {
+	if err := link.send([]byte{0, 0, 0, 0, 0x03}); err != nil {
+		return nil, err
+	}
+	return link.readN(5)
+}
+
+// GetFrequency reads the frequency
+func (f ft8x7CAT) GetFrequency(link *catLink) (int64, error) {
+	status, err := f.readStatus(link)
+	if err != nil {
+		return 0, err
+	}
+	return ft8x7FromBCD(status[:4]) * 10, nil
+}
+
+// SetMode sets the mode (opcode 07), the data modes as DIG
+func (ft8x7CAT) SetMode(link *catLink, mode string) error {
+	number := byte(0x01)
+	switch mode {
+	case ModeLSB:
+		number = 0x00
+	case ModeCW:
+		number = 0x02
+	case ModeAM:
+		number = 0x04
+	case ModeFM:
+		number = 0x08
+	case ModePKTUSB, ModePKTLSB, ModeJT8, ModePSK, ModeRTTY:
+		number = 0x0A
+	}
+	return link.send([]byte{number, 0, 0, 0, 0x07})
+}
+
+// GetMode reads the mode
+func (f ft8x7CAT) GetMode(link *catLink) (string, error) {
+	status, err := f.readStatus(link)
+	if err != nil {
+		return "", err
+	}
+	mode, ok := ft8x7Modes[status[4]]
+	if !ok {
+		return "", fmt.Errorf("bad mode %02X", status[4])
+	}
+	return mode, nil
+}
+
+// SetPTT keys (opcode 08) or unkeys (opcode 88) the transmitter; the rig
+// answers 00, or F0 when it was already so
+func (ft8x7CAT) SetPTT(link *catLink, state bool) error {
+	opcode := byte(0x88)
+	if state {
+		opcode = 0x08
+	}
+	if err := link.send([]byte{0, 0, 0, 0, opcode}); err != nil {
+		return err
+	}
+	_, err := link.readN(1)
+	return err
+}
+
+// GetPTT reads the TX status (opcode F7), whose top bit is clear while
+// transmitting
+func (ft8x7CAT) GetPTT(link *catLink) (bool, error) {
+	if err := link.send([]byte{0, 0, 0, 0, 0xF7}); err != nil {
+		return false, err
+	}
+	status, err := link.readN(1)
+	if err != nil {
+		return false, err
+	}
+	return status[0]&0x80 == 0, nil
+}
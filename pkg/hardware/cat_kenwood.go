@@ -0,0 +1,119 @@
+package hardware
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// kenwoodModes maps Kenwood MD mode numbers to Hamlib mode names. The data
+// modes are USB with the rig's data input selected by its menu.
+var kenwoodModes = map[byte]string{
+	'1': ModeLSB,
+	'2': ModeUSB,
+	'3': ModeCW,
+	'4': ModeFM,
+	'5': ModeAM,
+	'6': ModeRTTY,
+	'7': ModeCW,
+	'9': ModeRTTY,
+}
+
+// kenwoodCAT speaks the ASCII command set of Kenwood and Elecraft rigs,
+// commands like FA00014078000; ending in a semicolon
+type kenwoodCAT struct{}
+
+// Name names the command set
+func (kenwoodCAT) Name() string {
+	return "Kenwood"
+}
+
+// query sends a command and returns the answer starting with its first two
+// letters, skipping anything else the rig sends such as auto-information
+func (kenwoodCAT) query(link *catLink, command string) ([]byte, error) {
+	if err := link.send([]byte(command + ";")); err != nil {
+		return nil, err
+	}
+	for {
+		answer, err := link.readUntil(';')
+		if err != nil {
+			return nil, err
+		}
+		answer = bytes.TrimLeft(answer, "\r\n ")
+		if bytes.HasPrefix(answer, []byte("?;")) {
+			return nil, fmt.Errorf("rig rejected %s", command)
+		}
+		if bytes.HasPrefix(answer, []byte(command[:2])) {
+			return answer[2 : len(answer)-1], nil
+		}
+	}
+}
+
+// SetFrequency sets VFO A
+func (k kenwoodCAT) SetFrequency(link *catLink, freq int64) error {
+	return link.send([]byte(fmt.Sprintf("FA%011d;", freq)))
+}
+
+// GetFrequency reads VFO A
+func (k kenwoodCAT) GetFrequency(link *catLink) (int64, error) {
+	answer, err := k.query(link, "FA")
+	if err != nil {
+		return 0, err
+	}
+	freq, err := strconv.ParseInt(string(answer), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad frequency %q", answer)
+	}
+	return freq, nil
+}
+
+// SetMode sets the mode, data modes as USB or LSB
+func (kenwoodCAT) SetMode(link *catLink, mode string) error {
+	number := byte('2')
+	switch mode {
+	case ModeLSB, ModePKTLSB:
+		number = '1'
+	case ModeCW:
+		number = '3'
+	case ModeFM:
+		number = '4'
+	case ModeAM:
+		number = '5'
+	case ModeRTTY:
+		number = '6'
+	}
+	return link.send([]byte{'M', 'D', number, ';'})
+}
+
+// GetMode reads the mode
+func (k kenwoodCAT) GetMode(link *catLink) (string, error) {
+	answer, err := k.query(link, "MD")
+	if err != nil {
+		return "", err
+	}
+	if len(answer) != 1 || kenwoodModes[answer[0]] == "" {
+		return "", fmt.Errorf("bad mode %q", answer)
+	}
+	return kenwoodModes[answer[0]], nil
+}
+
+// SetPTT transmits with TX and receives with RX
+func (kenwoodCAT) SetPTT(link *catLink, state bool) error {
+	if state {
+		return link.send([]byte("TX;"))
+	}
+	return link.send([]byte("RX;"))
+}
+
+// GetPTT reads the TX/RX flag of the IF status
+func (k kenwoodCAT) GetPTT(link *catLink) (bool, error) {
+	answer, err := k.query(link, "IF")
+	if err != nil {
+		return false, err
+	}
+	// Frequency, step, RIT offset, RIT, XIT and memory channel come first
+	if len(answer) < 27 {
+		return false, fmt.Errorf("short status %q", answer)
+	}
+	return answer[26] == '1', nil
+}
@@ -0,0 +1,233 @@
+package hardware
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeRig answers each CAT command written to a native radio with what
+// answer returns, nothing for nil. It records every command.
+type fakeRig struct {
+	commands [][]byte
+	done     chan struct{}
+}
+
+// startFakeRig connects a native radio to a fake rig and initializes it
+func startFakeRig(t *testing.T, config RadioConfig, answer func(command []byte) [][]byte) (*NativeRadio, *fakeRig) {
+	t.Helper()
+	radioEnd, rigEnd := net.Pipe()
+	rig := &fakeRig{done: make(chan struct{})}
+	go func() {
+		defer close(rig.done)
+		buffer := make([]byte, 256)
+		for {
+			n, err := rigEnd.Read(buffer)
+			if err != nil {
+				return
+			}
+			command := append([]byte(nil), buffer[:n]...)
+			rig.commands = append(rig.commands, command)
+			for _, reply := range answer(command) {
+				if _, err := rigEnd.Write(reply); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	radio := NewNativeRadio(config)
+	radio.open = func() (catPort, error) { return radioEnd, nil }
+	if err := radio.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() {
+		radio.Close()
+		rigEnd.Close()
+		<-rig.done
+	})
+	return radio, rig
+}
+
+func TestCATProtocolFor(t *testing.T) {
+	tests := map[string]string{
+		"3073":  CATCIV,
+		"3085":  CATCIV,
+		"2028":  CATKenwood,
+		"2045":  CATKenwood,
+		"10001": CATKenwood,
+		"10002": CATKenwood,
+		"1020":  CATFT8x7,
+		"1022":  CATFT8x7,
+		"1035":  "",
+		"1":     "",
+	}
+	for model, want := range tests {
+		if got := CATProtocolFor(model); got != want {
+			t.Errorf("CATProtocolFor(%s) = %q, want %q", model, got, want)
+		}
+	}
+
+	if _, err := newCATProtocol(RadioConfig{Model: "1035"}); err == nil {
+		t.Error("Expected no driver for an FT-991")
+	}
+	if _, err := newCATProtocol(RadioConfig{Model: "3999"}); err == nil || !strings.Contains(err.Error(), "civ_address") {
+		t.Errorf("Expected an unknown Icom to need civ_address, got %v", err)
+	}
+	protocol, err := newCATProtocol(RadioConfig{Model: "3999", CIVAddress: "0x76"})
+	if err != nil || protocol.(civCAT).address != 0x76 {
+		t.Errorf("Expected CI-V address 76, got %v, %v", protocol, err)
+	}
+	protocol, err = newCATProtocol(RadioConfig{Model: "1035", CATProtocol: "kenwood"})
+	if err != nil || protocol.Name() != "Kenwood" {
+		t.Errorf("Expected cat_protocol to override the model, got %v, %v", protocol, err)
+	}
+}
+
+func TestNativeKenwood(t *testing.T) {
+	ptt := byte('0')
+	radio, rig := startFakeRig(t, RadioConfig{Model: "10001", Device: "/dev/ttyACM0"}, func(command []byte) [][]byte {
+		switch string(command) {
+		case "FA;":
+			// Auto-information arriving first is skipped
+			return [][]byte{[]byte("MD2;FA00014078000;")}
+		case "MD;":
+			return [][]byte{[]byte("MD2;")}
+		case "TX;":
+			ptt = '1'
+		case "RX;":
+			ptt = '0'
+		case "IF;":
+			return [][]byte{[]byte("IF00014078000     +000000000" + string(ptt) + "20000000;")}
+		}
+		return nil
+	})
+
+	freq, err := radio.GetFrequency()
+	if err != nil || freq != 14078000 {
+		t.Errorf("Expected 14078000 Hz, got %d, %v", freq, err)
+	}
+	if err := radio.SetFrequency(7078000); err != nil {
+		t.Fatalf("SetFrequency failed: %v", err)
+	}
+	if err := radio.SetMode(ModePKTUSB, 3000); err != nil {
+		t.Fatalf("SetMode failed: %v", err)
+	}
+	if mode, bandwidth, err := radio.GetMode(); err != nil || mode != ModeUSB || bandwidth != 3000 {
+		t.Errorf("Expected USB 3000, got %s %d, %v", mode, bandwidth, err)
+	}
+	if err := radio.SetPTT(true); err != nil {
+		t.Fatalf("SetPTT failed: %v", err)
+	}
+	if on, err := radio.GetPTT(); err != nil || !on {
+		t.Errorf("Expected PTT on, got %v, %v", on, err)
+	}
+	radio.SetPTT(false)
+	if on, err := radio.GetPTT(); err != nil || on {
+		t.Errorf("Expected PTT off, got %v, %v", on, err)
+	}
+
+	sent := string(bytes.Join(rig.commands, nil))
+	for _, command := range []string{"FA00007078000;", "MD2;", "TX;", "RX;"} {
+		if !strings.Contains(sent, command) {
+			t.Errorf("Expected %s sent, got %s", command, sent)
+		}
+	}
+}
+
+func TestNativeCIV(t *testing.T) {
+	var freq []byte
+	radio, rig := startFakeRig(t, RadioConfig{Model: "3073", Device: "/dev/ttyUSB0"}, func(command []byte) [][]byte {
+		// The bus echoes every frame back
+		replies := [][]byte{command}
+		if len(command) < 6 || command[2] != 0x94 || command[3] != civController {
+			return replies
+		}
+		reply := func(data ...byte) [][]byte {
+			frame := append([]byte{civPreamble, civPreamble, civController, 0x94}, data...)
+			return append(replies, append(frame, civEnd))
+		}
+		body := command[4 : len(command)-1]
+		switch {
+		case body[0] == 0x03:
+			return reply(append([]byte{0x03}, civBCD(14078000, 5)...)...)
+		case body[0] == 0x05:
+			freq = append([]byte(nil), body[1:]...)
+			return reply(civOK)
+		case body[0] == 0x1C && len(body) == 2:
+			return reply(0x1C, 0x00, 0x01)
+		case body[0] == 0x1A && len(body) == 2:
+			return reply(0x1A, 0x06, 0x01, 0x01)
+		case body[0] == 0x04:
+			return reply(0x04, 0x01, 0x01)
+		case body[0] == 0x7F:
+			return reply(civNG)
+		}
+		return reply(civOK)
+	})
+
+	if got, err := radio.GetFrequency(); err != nil || got != 14078000 {
+		t.Errorf("Expected 14078000 Hz, got %d, %v", got, err)
+	}
+	if err := radio.SetFrequency(1296200000); err != nil {
+		t.Fatalf("SetFrequency failed: %v", err)
+	}
+	if !bytes.Equal(freq, []byte{0x00, 0x00, 0x20, 0x96, 0x12}) || civFromBCD(freq) != 1296200000 {
+		t.Errorf("Expected 1296.2 MHz in BCD, got % X", freq)
+	}
+	if mode, _, err := radio.GetMode(); err != nil || mode != ModePKTUSB {
+		t.Errorf("Expected PKTUSB, got %s, %v", mode, err)
+	}
+	if err := radio.SetPTT(true); err != nil {
+		t.Fatalf("SetPTT failed: %v", err)
+	}
+	if on, err := radio.GetPTT(); err != nil || !on {
+		t.Errorf("Expected PTT on, got %v, %v", on, err)
+	}
+	if _, err := radio.protocol.(civCAT).command(radio.link, 0x7F); err == nil {
+		t.Error("Expected a rejected command to fail")
+	}
+	if len(rig.commands) == 0 {
+		t.Error("Expected commands sent")
+	}
+}
+
+func TestNativeFT8x7(t *testing.T) {
+	radio, rig := startFakeRig(t, RadioConfig{Model: "1020", Device: "/dev/ttyUSB0"}, func(command []byte) [][]byte {
+		switch command[4] {
+		case 0x03:
+			return [][]byte{{0x01, 0x40, 0x78, 0x00, 0x0A}}
+		case 0x08, 0x88:
+			return [][]byte{{0x00}}
+		case 0xF7:
+			return [][]byte{{0x7F}}
+		}
+		return nil
+	})
+
+	if freq, err := radio.GetFrequency(); err != nil || freq != 14078000 {
+		t.Errorf("Expected 14078000 Hz, got %d, %v", freq, err)
+	}
+	if mode, _, err := radio.GetMode(); err != nil || mode != ModePKTUSB {
+		t.Errorf("Expected PKTUSB, got %s, %v", mode, err)
+	}
+	if err := radio.SetFrequency(7078000); err != nil {
+		t.Fatalf("SetFrequency failed: %v", err)
+	}
+	if err := radio.SetPTT(true); err != nil {
+		t.Fatalf("SetPTT failed: %v", err)
+	}
+	if on, err := radio.GetPTT(); err != nil || !on {
+		t.Errorf("Expected PTT on, got %v, %v", on, err)
+	}
+
+	want := []byte{0x00, 0x70, 0x78, 0x00, 0x01}
+	found := false
+	for _, command := range rig.commands {
+		found = found || bytes.Equal(command, want)
+	}
+	if !found {
+		t.Errorf("Expected % X sent, got % X", want, rig.commands)
+	}
+}
@@ -25,6 +25,7 @@ static AudioRingBuffer inputBuffer = {0};
 static AudioRingBuffer outputBuffer = {0};
 static AudioUnit inputAudioUnit = NULL;
 static AudioUnit outputAudioUnit = NULL;
+static UInt32 inputChannels = 1; // Interleaved capture channels (2 for stereo-split)
 
 // Initialize audio buffer
 int initAudioBuffer(AudioRingBuffer* buf, int capacity) {
@@ -95,11 +96,12 @@ OSStatus inputCallback(void* inRefCon,
         printf("***** AUDIO DEBUG: inputCallback called (count: %d, frames: %u) *****\n", callbackCount, inNumberFrames);
     }
 
-    // Create buffer list for input - CoreAudio uses float samples
+    // Create buffer list for input - CoreAudio uses interleaved float samples
+    UInt32 inputSampleCount = inNumberFrames * inputChannels;
     AudioBufferList bufferList;
     bufferList.mNumberBuffers = 1;
-    bufferList.mBuffers[0].mNumberChannels = 1;
-    bufferList.mBuffers[0].mDataByteSize = inNumberFrames * sizeof(float);
+    bufferList.mBuffers[0].mNumberChannels = inputChannels;
+    bufferList.mBuffers[0].mDataByteSize = inputSampleCount * sizeof(float);
     bufferList.mBuffers[0].mData = malloc(bufferList.mBuffers[0].mDataByteSize);
 
     // Render input audio
@@ -109,9 +111,9 @@ OSStatus inputCallback(void* inRefCon,
     if (status == noErr) {
         // Convert float samples to int16 and write to buffer
         float* floatSamples = (float*)bufferList.mBuffers[0].mData;
-        int16_t* intSamples = malloc(inNumberFrames * sizeof(int16_t));
+        int16_t* intSamples = malloc(inputSampleCount * sizeof(int16_t));
 
-        for (UInt32 i = 0; i < inNumberFrames; i++) {
+        for (UInt32 i = 0; i < inputSampleCount; i++) {
             // Convert float (-1.0 to 1.0) to int16 (-32768 to 32767)
             float sample = floatSamples[i];
             if (sample > 1.0f) sample = 1.0f;
@@ -119,7 +121,7 @@ OSStatus inputCallback(void* inRefCon,
             intSamples[i] = (int16_t)(sample * 32767.0f);
         }
 
-        int written = writeAudioBuffer(&inputBuffer, intSamples, inNumberFrames);
+        int written = writeAudioBuffer(&inputBuffer, intSamples, inputSampleCount);
         if (callbackCount % 100 == 1) {
             printf("***** AUDIO DEBUG: inputCallback wrote %d samples to buffer *****\n", written);
         }
@@ -214,8 +216,9 @@ AudioDeviceID findAudioDeviceByName(const char* deviceName) {
 }
 
 // Initialize Core Audio input
-OSStatus initCoreAudioInput(UInt32 sampleRate, UInt32 bufferSize) {
-    printf("***** AUDIO DEBUG: Initializing CoreAudio input... *****\n");
+OSStatus initCoreAudioInput(UInt32 sampleRate, UInt32 bufferSize, UInt32 channels) {
+    printf("***** AUDIO DEBUG: Initializing CoreAudio input (%u channels)... *****\n", (unsigned int)channels);
+    inputChannels = channels > 0 ? channels : 1;
 
     AudioComponentDescription desc;
     desc.componentType = kAudioUnitType_Output;
@@ -254,15 +257,15 @@ OSStatus initCoreAudioInput(UInt32 sampleRate, UInt32 bufferSize) {
         return status;
     }
 
-    // Set format
+    // Set format - interleaved so stereo-split frames stay together
     AudioStreamBasicDescription format;
     format.mSampleRate = sampleRate;
     format.mFormatID = kAudioFormatLinearPCM;
     format.mFormatFlags = kAudioFormatFlagIsFloat | kAudioFormatFlagIsPacked;
-    format.mBytesPerPacket = sizeof(float);
+    format.mBytesPerPacket = sizeof(float) * inputChannels;
     format.mFramesPerPacket = 1;
-    format.mBytesPerFrame = sizeof(float);
-    format.mChannelsPerFrame = 1;
+    format.mBytesPerFrame = sizeof(float) * inputChannels;
+    format.mChannelsPerFrame = inputChannels;
     format.mBitsPerChannel = 32;
 
     status = AudioUnitSetProperty(inputAudioUnit, kAudioUnitProperty_StreamFormat,
@@ -284,7 +287,7 @@ OSStatus initCoreAudioInput(UInt32 sampleRate, UInt32 bufferSize) {
     if (status != noErr) return status;
 
     // Initialize buffers
-    if (initAudioBuffer(&inputBuffer, sampleRate * 2) != 0) return -1; // 2 second buffer
+    if (initAudioBuffer(&inputBuffer, sampleRate * 2 * inputChannels) != 0) return -1; // 2 second buffer
 
     return AudioUnitInitialize(inputAudioUnit);
 }
@@ -498,7 +501,6 @@ import "C"
 
 import (
 	"fmt"
-	"log"
 	"sync"
 	"time"
 	"unsafe"
@@ -506,11 +508,12 @@ import (
 
 // CoreAudioConfig represents Core Audio configuration
 type CoreAudioConfig struct {
-	InputDevice  string
-	OutputDevice string
-	SampleRate   int
-	BufferSize   int
-	Channels     int
+	InputDevice   string
+	OutputDevice  string
+	SampleRate    int
+	BufferSize    int
+	Channels      int
+	InputChannels int // Capture channels, defaults to Channels
 }
 
 // CoreAudio implements real Core Audio I/O for macOS
@@ -543,6 +546,9 @@ func NewCoreAudio(config CoreAudioConfig) *CoreAudio {
 	if config.Channels == 0 {
 		config.Channels = 1 // Mono for radio applications
 	}
+	if config.InputChannels == 0 {
+		config.InputChannels = config.Channels
+	}
 
 	return &CoreAudio{
 		config:        config,
@@ -555,12 +561,12 @@ func NewCoreAudio(config CoreAudioConfig) *CoreAudio {
 
 // Initialize initializes the Core Audio system
 func (a *CoreAudio) Initialize() error {
-	log.Printf("CoreAudio: Initializing audio system...")
-	log.Printf("CoreAudio: Sample rate: %d Hz", a.config.SampleRate)
-	log.Printf("CoreAudio: Buffer size: %d samples", a.config.BufferSize)
+	logger.Infof("CoreAudio: Initializing audio system...")
+	logger.Infof("CoreAudio: Sample rate: %d Hz", a.config.SampleRate)
+	logger.Infof("CoreAudio: Buffer size: %d samples", a.config.BufferSize)
 
 	// Initialize Core Audio input
-	status := C.initCoreAudioInput(C.UInt32(a.config.SampleRate), C.UInt32(a.config.BufferSize))
+	status := C.initCoreAudioInput(C.UInt32(a.config.SampleRate), C.UInt32(a.config.BufferSize), C.UInt32(a.config.InputChannels))
 	if status != 0 {
 		return fmt.Errorf("failed to initialize Core Audio input: %d", int(status))
 	}
@@ -572,7 +578,7 @@ func (a *CoreAudio) Initialize() error {
 		return fmt.Errorf("failed to initialize Core Audio output: %d", int(status))
 	}
 
-	log.Printf("CoreAudio: Audio system initialized successfully")
+	logger.Infof("CoreAudio: Audio system initialized successfully")
 	return nil
 }
 
@@ -581,15 +587,15 @@ func (a *CoreAudio) StartInput() error {
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 
-	log.Printf("***** AUDIO DEBUG: CoreAudio StartInput() called - recording:%v *****", a.recording)
+	logger.Debugf("CoreAudio: StartInput() called - recording:%v", a.recording)
 
 	if a.recording {
 		return fmt.Errorf("audio input already started")
 	}
 
-	log.Printf("***** AUDIO DEBUG: Calling C.startCoreAudioInput()... *****")
+	logger.Debugf("CoreAudio: Calling C.startCoreAudioInput()...")
 	status := C.startCoreAudioInput()
-	log.Printf("***** AUDIO DEBUG: C.startCoreAudioInput() returned status: %d *****", int(status))
+	logger.Debugf("CoreAudio: C.startCoreAudioInput() returned status: %d", int(status))
 
 	if status != 0 {
 		return fmt.Errorf("failed to start Core Audio input: %d", int(status))
@@ -598,7 +604,7 @@ func (a *CoreAudio) StartInput() error {
 	a.recording = true
 	go a.inputReaderWorker()
 
-	log.Printf("CoreAudio: Audio input started successfully")
+	logger.Infof("CoreAudio: Audio input started successfully")
 	return nil
 }
 
@@ -616,10 +622,10 @@ func (a *CoreAudio) StopInput() error {
 
 	status := C.stopCoreAudioInput()
 	if status != 0 {
-		log.Printf("CoreAudio: Warning - failed to stop input: %d", int(status))
+		logger.Warnf("CoreAudio: failed to stop input: %d", int(status))
 	}
 
-	log.Printf("CoreAudio: Audio input stopped")
+	logger.Infof("CoreAudio: Audio input stopped")
 	return nil
 }
 
@@ -640,7 +646,7 @@ func (a *CoreAudio) StartOutput() error {
 	a.playing = true
 	go a.outputWriterWorker()
 
-	log.Printf("CoreAudio: Audio output started")
+	logger.Infof("CoreAudio: Audio output started")
 	return nil
 }
 
@@ -657,10 +663,10 @@ func (a *CoreAudio) StopOutput() error {
 
 	status := C.stopCoreAudioOutput()
 	if status != 0 {
-		log.Printf("CoreAudio: Warning - failed to stop output: %d", int(status))
+		logger.Warnf("CoreAudio: failed to stop output: %d", int(status))
 	}
 
-	log.Printf("CoreAudio: Audio output stopped")
+	logger.Infof("CoreAudio: Audio output stopped")
 	return nil
 }
 
@@ -699,14 +705,15 @@ func (a *CoreAudio) Close() error {
 	close(a.inputSamples)
 	close(a.outputSamples)
 
-	log.Printf("CoreAudio: Audio system closed")
+	logger.Infof("CoreAudio: Audio system closed")
 	return nil
 }
 
 // inputReaderWorker reads audio from Core Audio input buffer
 func (a *CoreAudio) inputReaderWorker() {
-	buffer := make([]int16, a.config.BufferSize)
-	log.Printf("***** AUDIO DEBUG: inputReaderWorker started, buffer size: %d *****", len(buffer))
+	// Whole interleaved frames only, so channels never swap between blocks
+	buffer := make([]int16, a.config.BufferSize*a.config.InputChannels)
+	logger.Debugf("CoreAudio: inputReaderWorker started, buffer size: %d", len(buffer))
 
 	sampleCount := 0
 	lastLogTime := time.Now()
@@ -720,7 +727,7 @@ func (a *CoreAudio) inputReaderWorker() {
 
 			// Log occasionally to show activity
 			if time.Since(lastLogTime) > 5*time.Second {
-				log.Printf("***** AUDIO DEBUG: inputReaderWorker active - read %d samples (total blocks: %d) *****", samplesRead, sampleCount)
+				logger.Debugf("CoreAudio: inputReaderWorker active - read %d samples (total blocks: %d)", samplesRead, sampleCount)
 				lastLogTime = time.Now()
 			}
 
@@ -732,12 +739,12 @@ func (a *CoreAudio) inputReaderWorker() {
 			select {
 			case a.inputSamples <- samples:
 			default:
-				log.Printf("***** AUDIO DEBUG: inputSamples channel full, dropping %d samples *****", samplesRead)
+				logger.Debugf("CoreAudio: inputSamples channel full, dropping %d samples", samplesRead)
 			}
 		} else {
 			// Log if we're not getting samples
 			if time.Since(lastLogTime) > 5*time.Second {
-				log.Printf("***** AUDIO DEBUG: inputReaderWorker running but C.readInputSamples() returned 0 samples *****")
+				logger.Debugf("CoreAudio: inputReaderWorker running but C.readInputSamples() returned 0 samples")
 				lastLogTime = time.Now()
 			}
 		}
@@ -748,13 +755,13 @@ func (a *CoreAudio) inputReaderWorker() {
 		// Check for stop signal
 		select {
 		case <-a.inputWorker:
-			log.Printf("***** AUDIO DEBUG: inputReaderWorker stopping (received stop signal) *****")
+			logger.Debugf("CoreAudio: inputReaderWorker stopping (received stop signal)")
 			return
 		default:
 		}
 	}
 
-	log.Printf("***** AUDIO DEBUG: inputReaderWorker stopped (a.isRecording() = false) *****")
+	logger.Debugf("CoreAudio: inputReaderWorker stopped (a.isRecording() = false)")
 }
 
 // outputWriterWorker writes audio to Core Audio output buffer
@@ -766,7 +773,7 @@ func (a *CoreAudio) outputWriterWorker() {
 			samplesWritten := int(C.writeOutputSamples((*C.int16_t)(unsafe.Pointer(&samples[0])), C.int(len(samples))))
 
 			if samplesWritten > 0 {
-				log.Printf("CoreAudio: Wrote %d samples to output", samplesWritten)
+				logger.Debugf("CoreAudio: Wrote %d samples to output", samplesWritten)
 			}
 
 		case <-a.stopChan:
@@ -803,6 +810,11 @@ func (a *CoreAudio) GetBufferSize() int {
 	return a.config.BufferSize
 }
 
+// GetInputChannels returns the number of interleaved capture channels
+func (a *CoreAudio) GetInputChannels() int {
+	return a.config.InputChannels
+}
+
 // IsRecording returns whether audio input is active
 func (a *CoreAudio) IsRecording() bool {
 	return a.isRecording()
@@ -826,9 +838,9 @@ func GetAudioDevices() ([]AudioDevice, error) {
 	const maxDevices = 64
 	devices := make([]C.AudioDeviceInfo, maxDevices)
 
-	log.Printf("CoreAudio: Calling C.getAudioDevices...")
+	logger.Debugf("CoreAudio: Calling C.getAudioDevices...")
 	count := int(C.getAudioDevices(&devices[0], C.int(maxDevices)))
-	log.Printf("CoreAudio: C.getAudioDevices returned count=%d", count)
+	logger.Debugf("CoreAudio: C.getAudioDevices returned count=%d", count)
 
 	if count < 0 {
 		return nil, fmt.Errorf("failed to enumerate audio devices (returned %d)", count)
@@ -840,7 +852,7 @@ func GetAudioDevices() ([]AudioDevice, error) {
 		isInput := devices[i].isInput != 0
 		isOutput := devices[i].isOutput != 0
 
-		log.Printf("CoreAudio: Device %d: %s (input:%v, output:%v)", i, name, isInput, isOutput)
+		logger.Debugf("CoreAudio: Device %d: %s (input:%v, output:%v)", i, name, isInput, isOutput)
 
 		result[i] = AudioDevice{
 			ID:       uint32(devices[i].deviceID),
@@ -850,6 +862,6 @@ func GetAudioDevices() ([]AudioDevice, error) {
 		}
 	}
 
-	log.Printf("CoreAudio: Returning %d devices", len(result))
+	logger.Debugf("CoreAudio: Returning %d devices", len(result))
 	return result, nil
 }
\ No newline at end of file
@@ -2,7 +2,6 @@ package hardware
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"strconv"
 	"strings"
@@ -30,7 +29,7 @@ func (g *LinuxGPIO) Initialize() error {
 		return fmt.Errorf("GPIO not available on this system")
 	}
 
-	log.Printf("LinuxGPIO: Initialized")
+	logger.Infof("LinuxGPIO: Initialized")
 	return nil
 }
 
@@ -44,7 +43,7 @@ func (g *LinuxGPIO) Close() error {
 		g.unexportPin(pin)
 	}
 
-	log.Printf("LinuxGPIO: Closed")
+	logger.Infof("LinuxGPIO: Closed")
 	return nil
 }
 
@@ -130,7 +129,7 @@ func (g *LinuxGPIO) exportPin(pin int) error {
 	// Wait for the pin directory to appear
 	for i := 0; i < 10; i++ {
 		if _, err := os.Stat(pinPath); err == nil {
-			log.Printf("LinuxGPIO: Exported pin %d", pin)
+			logger.Infof("LinuxGPIO: Exported pin %d", pin)
 			return nil
 		}
 		// Small delay to allow kernel to create the directory
@@ -149,7 +148,7 @@ func (g *LinuxGPIO) unexportPin(pin int) error {
 		return fmt.Errorf("failed to unexport GPIO pin %d: %w", pin, err)
 	}
 
-	log.Printf("LinuxGPIO: Unexported pin %d", pin)
+	logger.Infof("LinuxGPIO: Unexported pin %d", pin)
 	return nil
 }
 
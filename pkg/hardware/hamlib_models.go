@@ -0,0 +1,52 @@
+//go:build !nohamlib
+
+package hardware
+
+/*
+#cgo pkg-config: hamlib
+#include <hamlib/rig.h>
+#include <stdint.h>
+
+extern int addRigModel(int model, char *mfg, char *name, char *version, char *status, uintptr_t list);
+
+static inline int rig_model_callback(const struct rig_caps *caps, rig_ptr_t data) {
+    return addRigModel((int)caps->rig_model, (char *)caps->mfg_name, (char *)caps->model_name,
+        (char *)caps->version, (char *)rig_strstatus(caps->status), (uintptr_t)data);
+}
+
+static inline int list_rig_models(uintptr_t list) {
+    rig_load_all_backends();
+    return rig_list_foreach(rig_model_callback, (rig_ptr_t)list);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"runtime/cgo"
+)
+
+// hamlibRigModels lists every model of every Hamlib backend
+func hamlibRigModels() ([]RigModel, error) {
+	models := []RigModel{}
+	handle := cgo.NewHandle(&models)
+	defer handle.Delete()
+
+	if ret := C.list_rig_models(C.uintptr_t(handle)); ret != C.RIG_OK {
+		return nil, fmt.Errorf("failed to list Hamlib models: %s", C.GoString(C.rigerror(ret)))
+	}
+	return models, nil
+}
+
+//export addRigModel
+func addRigModel(model C.int, mfg, name, version, status *C.char, list C.uintptr_t) C.int {
+	models := cgo.Handle(list).Value().(*[]RigModel)
+	*models = append(*models, RigModel{
+		ID:           int(model),
+		Manufacturer: C.GoString(mfg),
+		Model:        C.GoString(name),
+		Version:      C.GoString(version),
+		Status:       C.GoString(status),
+	})
+	return 1 // Carry on
+}
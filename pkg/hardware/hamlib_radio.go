@@ -1,3 +1,4 @@
+//go:build !nohamlib
 
 package hardware
 
@@ -84,6 +85,32 @@ static rmode_t string_to_mode(const char* mode) {
     if (strcmp(mode, "AM") == 0) return RIG_MODE_AM;
     return RIG_MODE_USB; // Default to USB
 }
+
+// Helper functions to read and set the RF power level, which hamlib passes
+// in a union
+static int get_rf_power(RIG *rig, float *level) {
+    value_t val;
+    int ret = rig_get_level(rig, RIG_VFO_CURR, RIG_LEVEL_RFPOWER, &val);
+    if (ret == RIG_OK) {
+        *level = val.f;
+    }
+    return ret;
+}
+
+static int set_rf_power(RIG *rig, float level) {
+    value_t val;
+    val.f = level;
+    return rig_set_level(rig, RIG_VFO_CURR, RIG_LEVEL_RFPOWER, val);
+}
+
+// Helper function to start the antenna tuner, for rigs whose backend has a
+// tune operation
+static int start_tune(RIG *rig) {
+    if (!rig_has_vfo_op(rig, RIG_OP_TUNE)) {
+        return -RIG_ENAVAIL;
+    }
+    return rig_vfo_op(rig, RIG_VFO_CURR, RIG_OP_TUNE);
+}
 */
 import "C"
 
@@ -93,7 +120,7 @@ import (
 	"sync"
 	"unsafe"
 
-	"github.com/dougsko/js8d/pkg/verbose"
+	"github.com/dougsko/js8d/pkg/logging"
 )
 
 // HamlibRadio implements RadioInterface using Hamlib
@@ -110,7 +137,7 @@ type HamlibRadio struct {
 // NewHamlibRadio creates a new Hamlib radio interface
 func NewHamlibRadio(config RadioConfig) *HamlibRadio {
 	// Set hamlib debug level immediately when creating radio instance
-	if verbose.IsEnabled() {
+	if logger.Enabled(logging.LevelDebug) {
 		C.try_set_hamlib_debug(3) // RIG_DEBUG_VERBOSE
 	} else {
 		C.try_set_hamlib_debug(0) // RIG_DEBUG_NONE - suppress all hamlib output
@@ -127,17 +154,17 @@ func (r *HamlibRadio) Initialize() error {
 	defer r.mutex.Unlock()
 
 	// Set hamlib debug level using multiple approaches
-	if verbose.IsEnabled() {
+	if logger.Enabled(logging.LevelDebug) {
 		C.try_set_hamlib_debug(3) // RIG_DEBUG_VERBOSE
-		verbose.Printf("Hamlib: Verbose debugging enabled")
+		logger.Debugf("Hamlib: Verbose debugging enabled")
 	} else {
 		C.try_set_hamlib_debug(0) // RIG_DEBUG_NONE - suppress all hamlib output
 	}
 
-	verbose.Printf("Hamlib: Initializing radio control...")
-	verbose.Printf("Hamlib: Model: %s", r.config.Model)
-	verbose.Printf("Hamlib: Device: %s", r.config.Device)
-	verbose.Printf("Hamlib: Baud Rate: %d", r.config.BaudRate)
+	logger.Debugf("Hamlib: Initializing radio control...")
+	logger.Debugf("Hamlib: Model: %s", r.config.Model)
+	logger.Debugf("Hamlib: Device: %s", r.config.Device)
+	logger.Debugf("Hamlib: Baud Rate: %d", r.config.BaudRate)
 
 	// Parse model ID (can be numeric or string)
 	var modelID C.rig_model_t
@@ -147,7 +174,7 @@ func (r *HamlibRadio) Initialize() error {
 	} else {
 		// Try to find model by name
 		// For now, default to a common model if string provided
-		verbose.Printf("Hamlib: Model name provided, using auto-detection")
+		logger.Debugf("Hamlib: Model name provided, using auto-detection")
 		modelID = C.RIG_MODEL_DUMMY // Will be replaced with proper name lookup
 	}
 
@@ -164,17 +191,17 @@ func (r *HamlibRadio) Initialize() error {
 		devicePath := C.CString(r.config.Device)
 		defer C.free(unsafe.Pointer(devicePath))
 
-		verbose.Printf("Hamlib: Setting device to %s", r.config.Device)
+		logger.Debugf("Hamlib: Setting device to %s", r.config.Device)
 
 		// Set the device path using rig_set_conf (JS8Call approach)
 		ret := C.set_device_path(r.rig, devicePath)
 		if ret != C.RIG_OK {
-			verbose.Printf("Hamlib: Warning - failed to set device path (%s), may use default", C.GoString(C.rigerror(ret)))
+			logger.Debugf("Hamlib: Warning - failed to set device path (%s), may use default", C.GoString(C.rigerror(ret)))
 		} else {
-			verbose.Printf("Hamlib: Device path set successfully")
+			logger.Debugf("Hamlib: Device path set successfully")
 		}
 	} else if r.config.Model == "1" {
-		verbose.Printf("Hamlib: Using dummy rig - no device path needed")
+		logger.Debugf("Hamlib: Using dummy rig - no device path needed")
 	}
 
 	// Set baud rate explicitly
@@ -182,14 +209,14 @@ func (r *HamlibRadio) Initialize() error {
 		baudStr := C.CString(fmt.Sprintf("%d", r.config.BaudRate))
 		defer C.free(unsafe.Pointer(baudStr))
 
-		verbose.Printf("Hamlib: Setting baud rate to %d", r.config.BaudRate)
+		logger.Debugf("Hamlib: Setting baud rate to %d", r.config.BaudRate)
 
 		// Set the baud rate using rig_set_conf (JS8Call approach)
 		ret := C.set_baud_rate(r.rig, baudStr)
 		if ret != C.RIG_OK {
-			verbose.Printf("Hamlib: Warning - failed to set baud rate (%s), using default", C.GoString(C.rigerror(ret)))
+			logger.Debugf("Hamlib: Warning - failed to set baud rate (%s), using default", C.GoString(C.rigerror(ret)))
 		} else {
-			verbose.Printf("Hamlib: Baud rate set successfully")
+			logger.Debugf("Hamlib: Baud rate set successfully")
 		}
 	}
 
@@ -201,11 +228,11 @@ func (r *HamlibRadio) Initialize() error {
 	}
 
 	r.connected = true
-	verbose.Printf("Hamlib: Radio connection established successfully")
+	logger.Debugf("Hamlib: Radio connection established successfully")
 
 	// Get radio info for verification
 	if info, err := r.getRadioInfoLocked(); err == nil {
-		verbose.Printf("Hamlib: Connected to %s %s (version %s)",
+		logger.Debugf("Hamlib: Connected to %s %s (version %s)",
 			info.Manufacturer, info.Model, info.Version)
 	}
 
@@ -222,7 +249,7 @@ func (r *HamlibRadio) Close() error {
 		return nil
 	}
 
-	verbose.Printf("Hamlib: Closing radio connection...")
+	logger.Debugf("Hamlib: Closing radio connection...")
 
 	if r.rig != nil {
 		C.rig_close(r.rig)
@@ -231,7 +258,7 @@ func (r *HamlibRadio) Close() error {
 	}
 
 	r.connected = false
-	verbose.Printf("Hamlib: Radio connection closed")
+	logger.Debugf("Hamlib: Radio connection closed")
 	return nil
 }
 
@@ -244,7 +271,7 @@ func (r *HamlibRadio) SetFrequency(freq int64) error {
 		return fmt.Errorf("radio not connected")
 	}
 
-	verbose.Printf("Hamlib: Setting frequency to %d Hz (%.3f MHz)", freq, float64(freq)/1000000.0)
+	logger.Debugf("Hamlib: Setting frequency to %d Hz (%.3f MHz)", freq, float64(freq)/1000000.0)
 
 	ret := C.rig_set_freq(r.rig, C.RIG_VFO_CURR, C.freq_t(freq))
 	if ret != C.RIG_OK {
@@ -281,7 +308,7 @@ func (r *HamlibRadio) SetMode(mode string, bandwidth int) error {
 		return fmt.Errorf("radio not connected")
 	}
 
-	verbose.Printf("Hamlib: Setting mode to %s with bandwidth %d Hz", mode, bandwidth)
+	logger.Debugf("Hamlib: Setting mode to %s with bandwidth %d Hz", mode, bandwidth)
 
 	modeStr := C.CString(mode)
 	defer C.free(unsafe.Pointer(modeStr))
@@ -329,10 +356,10 @@ func (r *HamlibRadio) SetPTT(state bool) error {
 	var ptt C.ptt_t
 	if state {
 		ptt = C.RIG_PTT_ON
-		verbose.Printf("Hamlib: PTT ON")
+		logger.Debugf("Hamlib: PTT ON")
 	} else {
 		ptt = C.RIG_PTT_OFF
-		verbose.Printf("Hamlib: PTT OFF")
+		logger.Debugf("Hamlib: PTT OFF")
 	}
 
 	ret := C.rig_set_ptt(r.rig, C.RIG_VFO_CURR, ptt)
@@ -404,10 +431,52 @@ func (r *HamlibRadio) GetPowerLevel() (float32, error) {
 		return 0, fmt.Errorf("radio not connected")
 	}
 
-	// For now, return a mock value since hamlib API has changed
-	// This would need to be updated for specific hamlib version compatibility
-	verbose.Printf("Hamlib: GetPowerLevel called (returning mock value)")
-	return 0.5, nil // 50% power
+	var level C.float
+	ret := C.get_rf_power(r.rig, &level)
+	if ret != C.RIG_OK {
+		return 0, fmt.Errorf("failed to get power level: %s", C.GoString(C.rigerror(ret)))
+	}
+
+	return float32(level), nil
+}
+
+// SetPowerLevel sets the power level (0.0-1.0)
+func (r *HamlibRadio) SetPowerLevel(level float32) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.connected {
+		return fmt.Errorf("radio not connected")
+	}
+	if level < 0 || level > 1 {
+		return fmt.Errorf("power level %.2f out of range", level)
+	}
+
+	logger.Debugf("Hamlib: Setting power level to %.2f", level)
+	ret := C.set_rf_power(r.rig, C.float(level))
+	if ret != C.RIG_OK {
+		return fmt.Errorf("failed to set power level: %s", C.GoString(C.rigerror(ret)))
+	}
+
+	return nil
+}
+
+// StartTune starts the rig's automatic antenna tuner
+func (r *HamlibRadio) StartTune() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.connected {
+		return fmt.Errorf("radio not connected")
+	}
+
+	logger.Debugf("Hamlib: Starting antenna tuner")
+	ret := C.start_tune(r.rig)
+	if ret != C.RIG_OK {
+		return fmt.Errorf("failed to start tuner: %s", C.GoString(C.rigerror(ret)))
+	}
+
+	return nil
 }
 
 // GetSWRLevel gets the current SWR level
@@ -420,7 +489,7 @@ func (r *HamlibRadio) GetSWRLevel() (float32, error) {
 	}
 
 	// For now, return a mock value since hamlib API has changed
-	verbose.Printf("Hamlib: GetSWRLevel called (returning mock value)")
+	logger.Debugf("Hamlib: GetSWRLevel called (returning mock value)")
 	return 1.2, nil // 1.2:1 SWR
 }
 
@@ -434,7 +503,7 @@ func (r *HamlibRadio) GetSignalLevel() (int, error) {
 	}
 
 	// For now, return a mock value since hamlib API has changed
-	verbose.Printf("Hamlib: GetSignalLevel called (returning mock value)")
+	logger.Debugf("Hamlib: GetSignalLevel called (returning mock value)")
 	return -73, nil // -73 dBm signal level
 }
 
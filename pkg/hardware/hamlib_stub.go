@@ -0,0 +1,62 @@
+//go:build nohamlib
+
+package hardware
+
+import "fmt"
+
+// HamlibRadio stands in for Hamlib in builds without it, which control
+// radios with the native backend
+type HamlibRadio struct {
+	*MockRadio
+}
+
+// NewHamlibRadio creates a radio that fails to connect
+func NewHamlibRadio(config RadioConfig) *HamlibRadio {
+	return &HamlibRadio{NewMockRadio(config)}
+}
+
+// Initialize fails, pointing at the native backend
+func (r *HamlibRadio) Initialize() error {
+	return fmt.Errorf("js8d was built without Hamlib; set radio backend to native")
+}
+
+// hamlibRigModels lists the models the native drivers know instead
+func hamlibRigModels() ([]RigModel, error) {
+	return nativeRigModels, nil
+}
+
+// nativeRigModels are the models of the native CAT drivers, with their
+// Hamlib numbers
+var nativeRigModels = []RigModel{
+	{ID: 1020, Manufacturer: "Yaesu", Model: "FT-817", Status: "Native"},
+	{ID: 1022, Manufacturer: "Yaesu", Model: "FT-857", Status: "Native"},
+	{ID: 1023, Manufacturer: "Yaesu", Model: "FT-897", Status: "Native"},
+	{ID: 1041, Manufacturer: "Yaesu", Model: "FT-818", Status: "Native"},
+	{ID: 2014, Manufacturer: "Kenwood", Model: "TS-2000", Status: "Native"},
+	{ID: 2028, Manufacturer: "Kenwood", Model: "TS-480", Status: "Native"},
+	{ID: 2029, Manufacturer: "Elecraft", Model: "K3", Status: "Native"},
+	{ID: 2031, Manufacturer: "Kenwood", Model: "TS-590S", Status: "Native"},
+	{ID: 2037, Manufacturer: "Kenwood", Model: "TS-590SG", Status: "Native"},
+	{ID: 2039, Manufacturer: "Kenwood", Model: "TS-990S", Status: "Native"},
+	{ID: 2041, Manufacturer: "Kenwood", Model: "TS-890S", Status: "Native"},
+	{ID: 2043, Manufacturer: "Elecraft", Model: "K3S", Status: "Native"},
+	{ID: 2044, Manufacturer: "Elecraft", Model: "KX2", Status: "Native"},
+	{ID: 2045, Manufacturer: "Elecraft", Model: "KX3", Status: "Native"},
+	{ID: 2047, Manufacturer: "Elecraft", Model: "K4", Status: "Native"},
+	{ID: 3009, Manufacturer: "Icom", Model: "IC-706", Status: "Native"},
+	{ID: 3010, Manufacturer: "Icom", Model: "IC-706MkII", Status: "Native"},
+	{ID: 3011, Manufacturer: "Icom", Model: "IC-706MkIIG", Status: "Native"},
+	{ID: 3056, Manufacturer: "Icom", Model: "IC-7800", Status: "Native"},
+	{ID: 3060, Manufacturer: "Icom", Model: "IC-7000", Status: "Native"},
+	{ID: 3061, Manufacturer: "Icom", Model: "IC-7200", Status: "Native"},
+	{ID: 3062, Manufacturer: "Icom", Model: "IC-7700", Status: "Native"},
+	{ID: 3063, Manufacturer: "Icom", Model: "IC-7600", Status: "Native"},
+	{ID: 3067, Manufacturer: "Icom", Model: "IC-7410", Status: "Native"},
+	{ID: 3068, Manufacturer: "Icom", Model: "IC-9100", Status: "Native"},
+	{ID: 3070, Manufacturer: "Icom", Model: "IC-7100", Status: "Native"},
+	{ID: 3073, Manufacturer: "Icom", Model: "IC-7300", Status: "Native"},
+	{ID: 3075, Manufacturer: "Icom", Model: "IC-7851", Status: "Native"},
+	{ID: 3078, Manufacturer: "Icom", Model: "IC-7610", Status: "Native"},
+	{ID: 3081, Manufacturer: "Icom", Model: "IC-9700", Status: "Native"},
+	{ID: 3085, Manufacturer: "Icom", Model: "IC-705", Status: "Native"},
+}
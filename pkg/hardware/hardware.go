@@ -2,19 +2,22 @@ package hardware
 
 import (
 	"fmt"
-	"log"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/dougsko/js8d/pkg/verbose"
+	"github.com/dougsko/js8d/pkg/logging"
 )
 
+var logger = logging.Component("hardware")
+
 // HardwareConfig represents hardware configuration
 type HardwareConfig struct {
 	EnableGPIO     bool
 	PTTGPIOPin     int
 	StatusLEDPin   int
+	AmpKeying      bool // Key an amplifier from AmpGPIOPin around each transmission
+	AmpGPIOPin     int
 	EnableOLED     bool
 	OLEDI2CAddress int
 	OLEDWidth      int
@@ -24,8 +27,13 @@ type HardwareConfig struct {
 	AudioOutput    string
 	SampleRate     int
 	BufferSize     int
+	InputChannels  int    // Capture channels (2 for stereo devices)
+	OutputChannels int    // Playback channels (2 for stereo devices)
+	HalfDuplex     bool   // The sound card can't capture and play at once
 	EnableRadio    bool
 	UseHamlib      bool   // If true, use hamlib for radio control; if false, use mock
+	RadioBackend   string // BackendNative for the built-in CAT drivers, overriding UseHamlib
+	CATProtocol    string // Native CAT command set, "" to pick by RadioModel
 	RadioModel     string
 	RadioDevice    string
 	RadioBaudRate  int
@@ -43,7 +51,9 @@ type HardwareManager struct {
 	oled      OLEDInterface
 	audio     AudioInterface
 	radio     RadioInterface
+	bridge    *SerialBridge // Carries a tcp:// radio device
 	pttActive bool
+	ampActive bool
 
 	// State
 	initialized bool
@@ -80,10 +90,30 @@ type AudioInterface interface {
 	GetInputSamples() <-chan []int16
 	GetSampleRate() int
 	GetBufferSize() int
+	GetInputChannels() int
 	IsRecording() bool
 	IsPlaying() bool
 }
 
+// AudioHealth is the state of a sound card's buffers, for tuning buffer_size
+type AudioHealth struct {
+	InputLatency    time.Duration // Age of captured audio when it is read
+	OutputLatency   time.Duration // Audio written to the card not yet played
+	InputQueued     int           // Captured blocks waiting for the engine
+	InputQueueSize  int
+	OutputQueued    int // Blocks waiting to be written to the card
+	OutputQueueSize int
+	Overruns        int64 // Times capture filled the card's buffer before it was read
+	Underruns       int64 // Times playback ran the card's buffer dry
+	InputDropped    int64 // Captured blocks dropped with the engine not reading
+}
+
+// AudioHealthReporter is implemented by audio interfaces that measure
+// their buffers
+type AudioHealthReporter interface {
+	Health() AudioHealth
+}
+
 // NewHardwareManager creates a new hardware manager
 func NewHardwareManager(config HardwareConfig) *HardwareManager {
 	return &HardwareManager{
@@ -100,152 +130,235 @@ func (h *HardwareManager) Initialize() error {
 		return nil
 	}
 
-	log.Printf("Hardware: Initializing hardware manager...")
+	logger.Infof("Initializing hardware manager...")
 
-	// Initialize GPIO if enabled
 	if h.config.EnableGPIO {
-		log.Printf("Hardware: Initializing GPIO...")
-
-		// Use mock GPIO for now - will be replaced with real implementation
-		h.gpio = NewMockGPIO()
-		if err := h.gpio.Initialize(); err != nil {
-			return fmt.Errorf("failed to initialize GPIO: %w", err)
+		if err := h.initGPIO(); err != nil {
+			return err
 		}
-		log.Printf("Hardware: GPIO initialized (PTT pin: %d, LED pin: %d)",
-			h.config.PTTGPIOPin, h.config.StatusLEDPin)
 	}
-
-	// Initialize OLED if enabled
 	if h.config.EnableOLED {
-		log.Printf("Hardware: Initializing OLED...")
-
-		// Use mock OLED for now - will be replaced with real implementation
-		h.oled = NewMockOLED(h.config.OLEDWidth, h.config.OLEDHeight)
-		if err := h.oled.Initialize(); err != nil {
-			return fmt.Errorf("failed to initialize OLED: %w", err)
+		if err := h.initOLED(); err != nil {
+			return err
 		}
-		log.Printf("Hardware: OLED initialized (%dx%d at I2C 0x%02x)",
-			h.config.OLEDWidth, h.config.OLEDHeight, h.config.OLEDI2CAddress)
 	}
-
-	// Initialize Audio if enabled
 	if h.config.EnableAudio {
-		log.Printf("Hardware: Initializing Audio...")
+		if err := h.initAudio(); err != nil {
+			return err
+		}
+	}
+	// Radio failures are non-fatal - daemon continues without radio if connection fails
+	if h.config.EnableRadio {
+		h.initRadio()
+	}
 
-		// Enumerate available audio devices
-		log.Printf("Hardware: Enumerating available audio devices...")
-		if devices, err := GetAudioDevices(); err != nil {
-			log.Printf("Hardware: Warning - could not enumerate audio devices: %v", err)
-		} else {
-			log.Printf("Hardware: Found %d audio devices:", len(devices))
-			for _, device := range devices {
-				capabilities := []string{}
-				if device.IsInput {
-					capabilities = append(capabilities, "input")
-				}
-				if device.IsOutput {
-					capabilities = append(capabilities, "output")
-				}
-				log.Printf("Hardware:   %s (%s)", device.Name, strings.Join(capabilities, ", "))
+	h.initialized = true
+	logger.Infof("Hardware manager initialized successfully")
+	return nil
+}
+
+// initGPIO opens the GPIO pins (must be called with lock held)
+func (h *HardwareManager) initGPIO() error {
+	logger.Infof("Initializing GPIO...")
+
+	// Use mock GPIO for now - will be replaced with real implementation
+	h.gpio = NewMockGPIO()
+	if err := h.gpio.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize GPIO: %w", err)
+	}
+	logger.Infof("GPIO initialized (PTT pin: %d, LED pin: %d)",
+		h.config.PTTGPIOPin, h.config.StatusLEDPin)
+	return nil
+}
+
+// initOLED opens the OLED display (must be called with lock held)
+func (h *HardwareManager) initOLED() error {
+	logger.Infof("Initializing OLED...")
+
+	// Use mock OLED for now - will be replaced with real implementation
+	h.oled = NewMockOLED(h.config.OLEDWidth, h.config.OLEDHeight)
+	if err := h.oled.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize OLED: %w", err)
+	}
+	logger.Infof("OLED initialized (%dx%d at I2C 0x%02x)",
+		h.config.OLEDWidth, h.config.OLEDHeight, h.config.OLEDI2CAddress)
+	return nil
+}
+
+// initAudio opens the audio devices (must be called with lock held)
+func (h *HardwareManager) initAudio() error {
+	logger.Infof("Initializing Audio...")
+
+	// Enumerate available audio devices
+	logger.Infof("Enumerating available audio devices...")
+	if devices, err := GetAudioDevices(); err != nil {
+		logger.Warnf("Could not enumerate audio devices: %v", err)
+	} else {
+		logger.Infof("Found %d audio devices:", len(devices))
+		for _, device := range devices {
+			capabilities := []string{}
+			if device.IsInput {
+				capabilities = append(capabilities, "input")
+			}
+			if device.IsOutput {
+				capabilities = append(capabilities, "output")
 			}
+			logger.Infof("  %s (%s)", device.Name, strings.Join(capabilities, ", "))
 		}
+	}
 
-		// Use platform-specific audio implementation
-		audioConfig := PlatformAudioConfig{
-			InputDevice:  h.config.AudioInput,
-			OutputDevice: h.config.AudioOutput,
-			SampleRate:   h.config.SampleRate,
-			BufferSize:   h.config.BufferSize,
-			Channels:     1, // Mono for radio
-		}
-		h.audio = NewPlatformAudio(audioConfig)
-		if err := h.audio.Initialize(); err != nil {
-			return fmt.Errorf("failed to initialize audio: %w", err)
-		}
-		log.Printf("Hardware: Audio initialized (%s -> %s, %d Hz)",
-			h.config.AudioInput, h.config.AudioOutput, h.config.SampleRate)
+	// Use platform-specific audio implementation
+	audioConfig := PlatformAudioConfig{
+		InputDevice:  h.config.AudioInput,
+		OutputDevice: h.config.AudioOutput,
+		SampleRate:   h.config.SampleRate,
+		BufferSize:   h.config.BufferSize,
+		Channels:      h.config.OutputChannels,
+		InputChannels: h.config.InputChannels,
+		HalfDuplex:    h.config.HalfDuplex,
+	}
+	h.audio = NewPlatformAudio(audioConfig)
+	if err := h.audio.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize audio: %w", err)
 	}
+	logger.Infof("Audio initialized (%s -> %s, %d Hz)",
+		h.config.AudioInput, h.config.AudioOutput, h.config.SampleRate)
+	return nil
+}
 
-	// Initialize Radio if enabled (non-fatal - daemon continues without radio if connection fails)
-	if h.config.EnableRadio {
-		log.Printf("Hardware: Initializing Radio...")
-
-		// Use Hamlib for radio control
-		radioConfig := RadioConfig{
-			Model:         h.config.RadioModel,
-			Device:        h.config.RadioDevice,
-			BaudRate:      h.config.RadioBaudRate,
-			Enabled:       true,
-			CIVAddress:    h.config.CIVAddress,
-			CIVTransceive: h.config.CIVTransceive,
-		}
+// initRadio connects the radio, leaving it nil when the connection fails or
+// times out (must be called with lock held)
+func (h *HardwareManager) initRadio() {
+	logger.Infof("Initializing Radio...")
 
-		// Choose between hamlib and mock radio based on configuration
-		if h.config.UseHamlib {
-			verbose.Printf("Hardware: Using Hamlib for radio control")
-			h.radio = NewHamlibRadio(radioConfig)
-		} else {
-			log.Printf("Hardware: Using mock radio for testing")
-			h.radio = NewMockRadio(radioConfig)
-		}
+	radio, err := h.newRadio()
+	if err != nil {
+		logger.Warnf("Failed to initialize radio: %v", err)
+		return
+	}
+	h.radio = radio
 
-		// Initialize radio with timeout to prevent daemon hanging
-		log.Printf("Hardware: Initializing radio with 10-second timeout...")
-		initDone := make(chan error, 1)
-		tempRadio := h.radio // Keep reference for cleanup
+	// Initialize radio with timeout to prevent daemon hanging
+	logger.Infof("Initializing radio with 10-second timeout...")
+	initDone := make(chan error, 1)
+	tempRadio := h.radio // Keep reference for cleanup
 
-		go func() {
-			defer func() {
-				if r := recover(); r != nil {
-					log.Printf("Hardware: Radio initialization panicked: %v", r)
-					initDone <- fmt.Errorf("radio initialization panicked: %v", r)
-				}
-			}()
-			initDone <- tempRadio.Initialize()
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Errorf("Radio initialization panicked: %v", r)
+				initDone <- fmt.Errorf("radio initialization panicked: %v", r)
+			}
 		}()
+		initDone <- tempRadio.Initialize()
+	}()
+
+	select {
+	case err := <-initDone:
+		if err != nil {
+			logger.Warnf("Failed to initialize radio: %v", err)
+			logger.Warnf("Daemon will continue without radio connection - configure radio in web UI")
+			h.radio = nil // Clear the radio interface so methods know it's not available
+			h.closeBridge()
+		} else {
+			logger.Infof("Radio initialized successfully (%s on %s)",
+				h.config.RadioModel, h.config.RadioDevice)
 
-		select {
-		case err := <-initDone:
-			if err != nil {
-				log.Printf("Hardware: Warning - failed to initialize radio: %v", err)
-				log.Printf("Hardware: Daemon will continue without radio connection - configure radio in web UI")
-				h.radio = nil // Clear the radio interface so methods know it's not available
+			// Fail-safe: Ensure PTT is OFF after initialization
+			logger.Infof("Ensuring PTT is OFF (fail-safe)")
+			if pttErr := tempRadio.SetPTT(false); pttErr != nil {
+				logger.Warnf("Failed to ensure PTT OFF: %v", pttErr)
 			} else {
-				log.Printf("Hardware: Radio initialized successfully (%s on %s)",
-					h.config.RadioModel, h.config.RadioDevice)
-
-				// Fail-safe: Ensure PTT is OFF after initialization
-				log.Printf("Hardware: Ensuring PTT is OFF (fail-safe)")
-				if pttErr := tempRadio.SetPTT(false); pttErr != nil {
-					log.Printf("Hardware: Warning - failed to ensure PTT OFF: %v", pttErr)
-				} else {
-					log.Printf("Hardware: PTT confirmed OFF")
-				}
+				logger.Infof("PTT confirmed OFF")
 			}
-		case <-time.After(10 * time.Second):
-			log.Printf("Hardware: ⚠️  Radio initialization timed out after 10 seconds")
-			log.Printf("Hardware: This usually means the radio is not responding or device is wrong")
-			log.Printf("Hardware: Daemon will continue without radio connection")
-			log.Printf("Hardware: Check radio connection, device path, and Hamlib configuration")
-
-			// Emergency PTT OFF attempt before giving up
-			log.Printf("Hardware: Emergency PTT OFF attempt before timeout...")
-			go func() {
-				if pttErr := tempRadio.SetPTT(false); pttErr != nil {
-					log.Printf("Hardware: Emergency PTT OFF failed: %v", pttErr)
-				} else {
-					log.Printf("Hardware: Emergency PTT OFF succeeded")
-				}
-			}()
-
-			h.radio = nil // Clear the radio interface
-			// Note: The goroutine may still be blocked, but daemon continues
 		}
+	case <-time.After(10 * time.Second):
+		logger.Warnf("⚠️  Radio initialization timed out after 10 seconds")
+		logger.Warnf("This usually means the radio is not responding or device is wrong")
+		logger.Warnf("Daemon will continue without radio connection")
+		logger.Warnf("Check radio connection, device path, and Hamlib configuration")
+
+		// Emergency PTT OFF attempt before giving up
+		logger.Warnf("Emergency PTT OFF attempt before timeout...")
+		go func() {
+			if pttErr := tempRadio.SetPTT(false); pttErr != nil {
+				logger.Errorf("Emergency PTT OFF failed: %v", pttErr)
+			} else {
+				logger.Infof("Emergency PTT OFF succeeded")
+			}
+		}()
+
+		h.radio = nil // Clear the radio interface
+		h.closeBridge()
+		// Note: The goroutine may still be blocked, but daemon continues
 	}
+}
 
-	h.initialized = true
-	log.Printf("Hardware: Hardware manager initialized successfully")
-	return nil
+// newRadio creates the radio from configuration, bridging a tcp:// device
+// to a pseudo-terminal rig control opens like a local serial port (must be
+// called with lock held)
+func (h *HardwareManager) newRadio() (RadioInterface, error) {
+	device := h.config.RadioDevice
+	address, remote, err := TCPSerialAddress(device)
+	if err != nil {
+		return nil, err
+	}
+	if remote {
+		bridge, err := NewSerialBridge(address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to bridge %s: %w", device, err)
+		}
+		h.bridge = bridge
+		device = bridge.Device()
+	}
+
+	// Use Hamlib for radio control
+	radioConfig := RadioConfig{
+		Model:         h.config.RadioModel,
+		Device:        device,
+		BaudRate:      h.config.RadioBaudRate,
+		Enabled:       true,
+		CIVAddress:    h.config.CIVAddress,
+		CIVTransceive: h.config.CIVTransceive,
+		CATProtocol:   h.config.CATProtocol,
+	}
+
+	// Choose between the native drivers, hamlib and mock radio based on
+	// configuration
+	if strings.EqualFold(h.config.RadioBackend, BackendNative) {
+		logger.Debugf("Using native CAT for radio control")
+		return NewNativeRadio(radioConfig), nil
+	}
+	if h.config.UseHamlib {
+		logger.Debugf("Using Hamlib for radio control")
+		return NewHamlibRadio(radioConfig), nil
+	}
+	logger.Infof("Using mock radio for testing")
+	return NewMockRadio(radioConfig), nil
+}
+
+// closeBridge closes the serial bridge of a tcp:// radio device, if any
+// (must be called with lock held)
+func (h *HardwareManager) closeBridge() {
+	if h.bridge == nil {
+		return
+	}
+	if err := h.bridge.Close(); err != nil {
+		logger.Warnf("Error closing serial bridge: %v", err)
+	}
+	h.bridge = nil
+}
+
+// RadioLink returns the state of the serial-over-TCP link to a tcp://
+// radio device, ok false for a local serial port
+func (h *HardwareManager) RadioLink() (LinkStatus, bool) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	if h.bridge == nil {
+		return LinkStatus{}, false
+	}
+	return h.bridge.Status(), true
 }
 
 // Close shuts down all hardware interfaces
@@ -257,7 +370,7 @@ func (h *HardwareManager) Close() error {
 		return nil
 	}
 
-	log.Printf("Hardware: Shutting down hardware manager...")
+	logger.Infof("Shutting down hardware manager...")
 
 	// Turn off PTT if active
 	if h.pttActive {
@@ -267,33 +380,34 @@ func (h *HardwareManager) Close() error {
 	// Close Radio
 	if h.radio != nil {
 		if err := h.radio.Close(); err != nil {
-			log.Printf("Hardware: Error closing Radio: %v", err)
+			logger.Errorf("Error closing Radio: %v", err)
 		}
 	}
+	h.closeBridge()
 
 	// Close Audio
 	if h.audio != nil {
 		if err := h.audio.Close(); err != nil {
-			log.Printf("Hardware: Error closing Audio: %v", err)
+			logger.Errorf("Error closing Audio: %v", err)
 		}
 	}
 
 	// Close OLED
 	if h.oled != nil {
 		if err := h.oled.Close(); err != nil {
-			log.Printf("Hardware: Error closing OLED: %v", err)
+			logger.Errorf("Error closing OLED: %v", err)
 		}
 	}
 
 	// Close GPIO
 	if h.gpio != nil {
 		if err := h.gpio.Close(); err != nil {
-			log.Printf("Hardware: Error closing GPIO: %v", err)
+			logger.Errorf("Error closing GPIO: %v", err)
 		}
 	}
 
 	h.initialized = false
-	log.Printf("Hardware: Hardware manager shut down")
+	logger.Infof("Hardware manager shut down")
 	return nil
 }
 
@@ -310,7 +424,7 @@ func (h *HardwareManager) setPTTLocked(active bool) error {
 	if !h.initialized || !h.config.EnableGPIO || h.gpio == nil {
 		// Just log for mock mode
 		if h.pttActive != active {
-			log.Printf("Hardware: PTT %s (mock)", map[bool]string{true: "ON", false: "OFF"}[active])
+			logger.Infof("PTT %s (mock)", map[bool]string{true: "ON", false: "OFF"}[active])
 		}
 		h.pttActive = active
 		return nil
@@ -321,13 +435,44 @@ func (h *HardwareManager) setPTTLocked(active bool) error {
 			return fmt.Errorf("failed to set PTT: %w", err)
 		}
 		h.pttActive = active
-		log.Printf("Hardware: PTT %s (GPIO pin %d)",
+		logger.Infof("PTT %s (GPIO pin %d)",
 			map[bool]string{true: "ON", false: "OFF"}[active], h.config.PTTGPIOPin)
 	}
 
 	return nil
 }
 
+// SetAmpKey keys or releases the amplifier's PTT line. Without amp keying
+// configured it does nothing.
+func (h *HardwareManager) SetAmpKey(active bool) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if !h.config.AmpKeying || h.ampActive == active {
+		return nil
+	}
+	if !h.initialized || !h.config.EnableGPIO || h.gpio == nil {
+		logger.Infof("Amp %s (mock)", map[bool]string{true: "KEYED", false: "RELEASED"}[active])
+		h.ampActive = active
+		return nil
+	}
+
+	if err := h.gpio.SetPin(h.config.AmpGPIOPin, active); err != nil {
+		return fmt.Errorf("failed to key amp: %w", err)
+	}
+	h.ampActive = active
+	logger.Infof("Amp %s (GPIO pin %d)",
+		map[bool]string{true: "KEYED", false: "RELEASED"}[active], h.config.AmpGPIOPin)
+	return nil
+}
+
+// GetAmpKey returns whether the amplifier is keyed
+func (h *HardwareManager) GetAmpKey() bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.ampActive
+}
+
 // GetPTT returns the current PTT state
 func (h *HardwareManager) GetPTT() bool {
 	h.mutex.RLock()
@@ -342,7 +487,7 @@ func (h *HardwareManager) SetStatusLED(active bool) error {
 
 	if !h.initialized || !h.config.EnableGPIO || h.gpio == nil {
 		// Just log for mock mode
-		log.Printf("Hardware: Status LED %s (mock)", map[bool]string{true: "ON", false: "OFF"}[active])
+		logger.Infof("Status LED %s (mock)", map[bool]string{true: "ON", false: "OFF"}[active])
 		return nil
 	}
 
@@ -350,11 +495,25 @@ func (h *HardwareManager) SetStatusLED(active bool) error {
 		return fmt.Errorf("failed to set status LED: %w", err)
 	}
 
-	log.Printf("Hardware: Status LED %s (GPIO pin %d)",
+	logger.Infof("Status LED %s (GPIO pin %d)",
 		map[bool]string{true: "ON", false: "OFF"}[active], h.config.StatusLEDPin)
 	return nil
 }
 
+// BlankOLED clears the OLED display until the next update
+func (h *HardwareManager) BlankOLED() error {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	if !h.initialized || !h.config.EnableOLED || h.oled == nil {
+		return nil
+	}
+	if err := h.oled.Clear(); err != nil {
+		return fmt.Errorf("failed to clear OLED: %w", err)
+	}
+	return h.oled.Display()
+}
+
 // UpdateOLED updates the OLED display with station information
 func (h *HardwareManager) UpdateOLED(callsign, grid string, frequency int, lastMessage string) error {
 	h.mutex.RLock()
@@ -362,7 +521,7 @@ func (h *HardwareManager) UpdateOLED(callsign, grid string, frequency int, lastM
 
 	if !h.initialized || !h.config.EnableOLED || h.oled == nil {
 		// Just log for mock mode
-		log.Printf("Hardware: OLED update (mock): %s %s | %.3f MHz | %s",
+		logger.Infof("OLED update (mock): %s %s | %.3f MHz | %s",
 			callsign, grid, float64(frequency)/1000000.0, lastMessage)
 		return nil
 	}
@@ -410,6 +569,8 @@ func (h *HardwareManager) IsInitialized() bool {
 
 // GetConfig returns the hardware configuration
 func (h *HardwareManager) GetConfig() HardwareConfig {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
 	return h.config
 }
 
@@ -418,7 +579,7 @@ func (h *HardwareManager) StartAudioInput() error {
 	h.mutex.RLock()
 	defer h.mutex.RUnlock()
 
-	log.Printf("DEBUG: StartAudioInput called - initialized:%v, enableAudio:%v, audio!=nil:%v",
+	logger.Debugf("StartAudioInput called - initialized:%v, enableAudio:%v, audio!=nil:%v",
 		h.initialized, h.config.EnableAudio, h.audio != nil)
 
 	if !h.initialized {
@@ -431,14 +592,14 @@ func (h *HardwareManager) StartAudioInput() error {
 		return fmt.Errorf("audio interface not available")
 	}
 
-	log.Printf("DEBUG: Starting audio input...")
+	logger.Debugf("Starting audio input...")
 	err := h.audio.StartInput()
 	if err != nil {
-		log.Printf("DEBUG: Audio input start failed: %v", err)
+		logger.Debugf("Audio input start failed: %v", err)
 		return err
 	}
 
-	log.Printf("DEBUG: Audio input started successfully")
+	logger.Debugf("Audio input started successfully")
 	return nil
 }
 
@@ -509,6 +670,19 @@ func (h *HardwareManager) GetAudio() AudioInterface {
 	return h.audio
 }
 
+// GetAudioHealth returns the sound card's latency and buffer state, false
+// when the audio interface does not measure them
+func (h *HardwareManager) GetAudioHealth() (AudioHealth, bool) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	reporter, ok := h.audio.(AudioHealthReporter)
+	if !ok {
+		return AudioHealth{}, false
+	}
+	return reporter.Health(), true
+}
+
 // GetRadio returns the radio interface for direct access
 func (h *HardwareManager) GetRadio() RadioInterface {
 	h.mutex.RLock()
@@ -624,6 +798,30 @@ func (h *HardwareManager) GetRadioPowerLevel() (float32, error) {
 	return h.radio.GetPowerLevel()
 }
 
+// SetRadioPowerLevel sets the radio power level (0.0-1.0)
+func (h *HardwareManager) SetRadioPowerLevel(level float32) error {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	if !h.initialized || !h.config.EnableRadio || h.radio == nil {
+		return fmt.Errorf("radio not initialized")
+	}
+
+	return h.radio.SetPowerLevel(level)
+}
+
+// StartRadioTune starts the radio's automatic antenna tuner
+func (h *HardwareManager) StartRadioTune() error {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	if !h.initialized || !h.config.EnableRadio || h.radio == nil {
+		return fmt.Errorf("radio not initialized")
+	}
+
+	return h.radio.StartTune()
+}
+
 // GetRadioSWRLevel gets the radio SWR level
 func (h *HardwareManager) GetRadioSWRLevel() (float32, error) {
 	h.mutex.RLock()
@@ -660,41 +858,31 @@ func (h *HardwareManager) RetryRadioConnection() error {
 
 	// Close existing radio connection if any
 	if h.radio != nil {
-		log.Printf("Hardware: Closing existing radio connection...")
+		logger.Infof("Closing existing radio connection...")
 		if err := h.radio.Close(); err != nil {
-			log.Printf("Hardware: Warning - error closing radio: %v", err)
+			logger.Warnf("Error closing radio: %v", err)
 		}
 		h.radio = nil
 	}
+	h.closeBridge()
 
-	log.Printf("Hardware: Attempting to reconnect radio...")
-
-	// Create new radio configuration
-	radioConfig := RadioConfig{
-		Model:         h.config.RadioModel,
-		Device:        h.config.RadioDevice,
-		BaudRate:      h.config.RadioBaudRate,
-		Enabled:       true,
-		CIVAddress:    h.config.CIVAddress,
-		CIVTransceive: h.config.CIVTransceive,
-	}
+	logger.Infof("Attempting to reconnect radio...")
 
-	// Choose between hamlib and mock radio based on configuration
-	if h.config.UseHamlib {
-		verbose.Printf("Hardware: Using Hamlib for radio control")
-		h.radio = NewHamlibRadio(radioConfig)
-	} else {
-		log.Printf("Hardware: Using mock radio for testing")
-		h.radio = NewMockRadio(radioConfig)
+	radio, err := h.newRadio()
+	if err != nil {
+		logger.Errorf("Radio reconnection failed: %v", err)
+		return fmt.Errorf("failed to reconnect radio: %w", err)
 	}
+	h.radio = radio
 
 	if err := h.radio.Initialize(); err != nil {
-		log.Printf("Hardware: Radio reconnection failed: %v", err)
+		logger.Errorf("Radio reconnection failed: %v", err)
 		h.radio = nil
+		h.closeBridge()
 		return fmt.Errorf("failed to reconnect radio: %w", err)
 	}
 
-	log.Printf("Hardware: Radio reconnected successfully (%s on %s)",
+	logger.Infof("Radio reconnected successfully (%s on %s)",
 		h.config.RadioModel, h.config.RadioDevice)
 	return nil
 }
@@ -711,7 +899,7 @@ func (h *HardwareManager) UpdateRadioConfig(model, device string, baudRate int,
 	h.config.UseHamlib = useHamlib
 	h.config.EnableRadio = true
 
-	verbose.Printf("Hardware: Radio configuration updated: Model=%s, Device=%s, Baud=%d, UseHamlib=%t",
+	logger.Debugf("Radio configuration updated: Model=%s, Device=%s, Baud=%d, UseHamlib=%t",
 		model, device, baudRate, useHamlib)
 
 	return nil
@@ -719,9 +907,11 @@ func (h *HardwareManager) UpdateRadioConfig(model, device string, baudRate int,
 
 // PlatformAudioConfig represents cross-platform audio configuration
 type PlatformAudioConfig struct {
-	InputDevice  string
-	OutputDevice string
-	SampleRate   int
-	BufferSize   int
-	Channels     int
+	InputDevice   string
+	OutputDevice  string
+	SampleRate    int
+	BufferSize    int
+	Channels      int
+	InputChannels int  // Defaults to Channels when zero
+	HalfDuplex    bool // Open input and output one at a time
 }
\ No newline at end of file
@@ -647,6 +647,70 @@ func TestHardwareManagerClose(t *testing.T) {
 	})
 }
 
+func TestHardwareManagerReconfigure(t *testing.T) {
+	config := HardwareConfig{
+		EnableGPIO:  true,
+		PTTGPIOPin:  18,
+		EnableOLED:  true,
+		OLEDWidth:   128,
+		OLEDHeight:  64,
+		EnableAudio: false, // Disable to avoid platform dependencies
+		EnableRadio: true,
+		UseHamlib:   false, // Use mock
+		RadioModel:  "1",
+	}
+
+	manager := NewHardwareManager(config)
+	if err := manager.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize manager: %v", err)
+	}
+	defer manager.Close()
+
+	t.Run("Unchanged", func(t *testing.T) {
+		changed, err := manager.Reconfigure(config)
+		if err != nil || len(changed) != 0 {
+			t.Errorf("Expected nothing restarted, got %v (%v)", changed, err)
+		}
+	})
+
+	t.Run("Radio And GPIO", func(t *testing.T) {
+		oldRadio := manager.GetRadio()
+		manager.SetPTT(true)
+
+		updated := config
+		updated.RadioModel = "2"
+		updated.PTTGPIOPin = 17
+		changed, err := manager.Reconfigure(updated)
+		if err != nil {
+			t.Fatalf("Reconfigure failed: %v", err)
+		}
+		if len(changed) != 2 || changed[0] != SubsystemRadio || changed[1] != SubsystemGPIO {
+			t.Errorf("Expected radio and gpio restarted, got %v", changed)
+		}
+		if manager.GetRadio() == nil || manager.GetRadio() == oldRadio {
+			t.Error("Expected a new radio connection")
+		}
+		if manager.GetPTT() {
+			t.Error("Expected PTT released by reconfiguration")
+		}
+		if manager.GetConfig().PTTGPIOPin != 17 {
+			t.Errorf("Expected PTT pin 17, got %d", manager.GetConfig().PTTGPIOPin)
+		}
+	})
+
+	t.Run("Disable OLED", func(t *testing.T) {
+		updated := manager.GetConfig()
+		updated.EnableOLED = false
+		changed, err := manager.Reconfigure(updated)
+		if err != nil || len(changed) != 1 || changed[0] != SubsystemOLED {
+			t.Errorf("Expected oled restarted, got %v (%v)", changed, err)
+		}
+		if manager.oled != nil {
+			t.Error("Expected OLED closed once disabled")
+		}
+	})
+}
+
 func TestHardwareManagerConcurrency(t *testing.T) {
 	config := HardwareConfig{
 		EnableGPIO:  true,
@@ -703,4 +767,43 @@ func TestHardwareManagerConcurrency(t *testing.T) {
 			<-done
 		}
 	})
-}
\ No newline at end of file
+}
+func TestHardwareManagerAmpKey(t *testing.T) {
+	config := HardwareConfig{
+		EnableGPIO: true,
+		PTTGPIOPin: 18,
+		AmpKeying:  true,
+		AmpGPIOPin: 23,
+	}
+	manager := NewHardwareManager(config)
+	if err := manager.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize manager: %v", err)
+	}
+	defer manager.Close()
+	gpio := manager.gpio.(*MockGPIO)
+
+	if err := manager.SetAmpKey(true); err != nil {
+		t.Fatalf("SetAmpKey failed: %v", err)
+	}
+	if on, _ := gpio.GetPin(23); !on || !manager.GetAmpKey() {
+		t.Error("Expected the amp pin high")
+	}
+
+	// Reconfiguring releases the amplifier on its old pin
+	updated := config
+	updated.AmpGPIOPin = 22
+	if _, err := manager.Reconfigure(updated); err != nil {
+		t.Fatalf("Reconfigure failed: %v", err)
+	}
+	if on, _ := gpio.GetPin(23); on || manager.GetAmpKey() {
+		t.Error("Expected the amp released by reconfiguration")
+	}
+
+	// Without amp keying nothing is keyed
+	updated.AmpKeying = false
+	manager.Reconfigure(updated)
+	manager.SetAmpKey(true)
+	if manager.GetAmpKey() {
+		t.Error("Expected no amp keying when it is off")
+	}
+}
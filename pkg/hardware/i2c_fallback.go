@@ -0,0 +1,13 @@
+//go:build !linux
+
+package hardware
+
+import (
+	"fmt"
+	"os"
+)
+
+// openI2C is only supported on Linux
+func openI2C(bus, address int) (*os.File, error) {
+	return nil, fmt.Errorf("I2C is not supported on this platform")
+}
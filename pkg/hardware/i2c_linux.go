@@ -0,0 +1,23 @@
+package hardware
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// i2cSlave is the ioctl addressing an I2C device file to one chip
+const i2cSlave = 0x0703
+
+// openI2C opens /dev/i2c-<bus> addressed to the chip at address
+func openI2C(bus, address int) (*os.File, error) {
+	dev, err := os.OpenFile(fmt.Sprintf("/dev/i2c-%d", bus), os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dev.Fd(), i2cSlave, uintptr(address)); errno != 0 {
+		dev.Close()
+		return nil, fmt.Errorf("failed to address I2C 0x%02x: %w", address, errno)
+	}
+	return dev, nil
+}
@@ -2,8 +2,9 @@ package hardware
 
 import (
 	"fmt"
-	"log"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // MockGPIO implements GPIOInterface for testing
@@ -21,13 +22,13 @@ func NewMockGPIO() *MockGPIO {
 
 // Initialize initializes the mock GPIO
 func (g *MockGPIO) Initialize() error {
-	log.Printf("MockGPIO: Initialized")
+	logger.Infof("MockGPIO: Initialized")
 	return nil
 }
 
 // Close closes the mock GPIO
 func (g *MockGPIO) Close() error {
-	log.Printf("MockGPIO: Closed")
+	logger.Infof("MockGPIO: Closed")
 	return nil
 }
 
@@ -37,7 +38,7 @@ func (g *MockGPIO) SetPin(pin int, value bool) error {
 	defer g.mu.Unlock()
 
 	g.pins[pin] = value
-	log.Printf("MockGPIO: Pin %d set to %t", pin, value)
+	logger.Debugf("MockGPIO: Pin %d set to %t", pin, value)
 	return nil
 }
 
@@ -69,13 +70,13 @@ func NewMockOLED(width, height int) *MockOLED {
 
 // Initialize initializes the mock OLED
 func (o *MockOLED) Initialize() error {
-	log.Printf("MockOLED: Initialized (%dx%d)", o.width, o.height)
+	logger.Infof("MockOLED: Initialized (%dx%d)", o.width, o.height)
 	return nil
 }
 
 // Close closes the mock OLED
 func (o *MockOLED) Close() error {
-	log.Printf("MockOLED: Closed")
+	logger.Infof("MockOLED: Closed")
 	return nil
 }
 
@@ -106,10 +107,10 @@ func (o *MockOLED) Display() error {
 	o.mu.RLock()
 	defer o.mu.RUnlock()
 
-	log.Printf("MockOLED: Display updated:")
+	logger.Infof("MockOLED: Display updated:")
 	for i := 0; i < o.height/8; i++ {
 		if text, exists := o.lines[i]; exists && text != "" {
-			log.Printf("MockOLED:   Line %d: %s", i, text)
+			logger.Infof("MockOLED:   Line %d: %s", i, text)
 		}
 	}
 	return nil
@@ -133,15 +134,17 @@ type MockAudio struct {
 	mutex        sync.RWMutex
 	inputSamples chan []int16
 	stopChan     chan struct{}
+	dropped      int64 // Injected blocks refused with the capture stream full
 }
 
 // MockAudioConfig represents mock audio configuration
 type MockAudioConfig struct {
-	InputDevice  string
-	OutputDevice string
-	SampleRate   int
-	BufferSize   int
-	Channels     int
+	InputDevice   string
+	OutputDevice  string
+	SampleRate    int
+	BufferSize    int
+	Channels      int
+	InputChannels int
 }
 
 // NewMockAudio creates a new mock audio interface
@@ -155,6 +158,9 @@ func NewMockAudio(config MockAudioConfig) *MockAudio {
 	if config.Channels == 0 {
 		config.Channels = 1
 	}
+	if config.InputChannels == 0 {
+		config.InputChannels = config.Channels
+	}
 
 	return &MockAudio{
 		config:       config,
@@ -165,8 +171,8 @@ func NewMockAudio(config MockAudioConfig) *MockAudio {
 
 // Initialize initializes the mock audio system
 func (a *MockAudio) Initialize() error {
-	log.Printf("MockAudio: Initialized - %d Hz, %d channels, %d buffer",
-		a.config.SampleRate, a.config.Channels, a.config.BufferSize)
+	logger.Infof("MockAudio: Initialized - %d Hz, %d channels (%d capture), %d buffer",
+		a.config.SampleRate, a.config.Channels, a.config.InputChannels, a.config.BufferSize)
 	return nil
 }
 
@@ -176,7 +182,7 @@ func (a *MockAudio) Close() error {
 	a.StopInput()
 	a.StopOutput()
 	close(a.inputSamples)
-	log.Printf("MockAudio: Closed")
+	logger.Infof("MockAudio: Closed")
 	return nil
 }
 
@@ -190,7 +196,7 @@ func (a *MockAudio) StartInput() error {
 	}
 
 	a.recording = true
-	log.Printf("MockAudio: Input started")
+	logger.Infof("MockAudio: Input started")
 	return nil
 }
 
@@ -200,7 +206,7 @@ func (a *MockAudio) StopInput() error {
 	defer a.mutex.Unlock()
 
 	a.recording = false
-	log.Printf("MockAudio: Input stopped")
+	logger.Infof("MockAudio: Input stopped")
 	return nil
 }
 
@@ -214,7 +220,7 @@ func (a *MockAudio) StartOutput() error {
 	}
 
 	a.playing = true
-	log.Printf("MockAudio: Output started")
+	logger.Infof("MockAudio: Output started")
 	return nil
 }
 
@@ -224,7 +230,7 @@ func (a *MockAudio) StopOutput() error {
 	defer a.mutex.Unlock()
 
 	a.playing = false
-	log.Printf("MockAudio: Output stopped")
+	logger.Infof("MockAudio: Output stopped")
 	return nil
 }
 
@@ -234,7 +240,7 @@ func (a *MockAudio) PlayAudio(samples []int16) error {
 		return fmt.Errorf("audio output not started")
 	}
 
-	log.Printf("MockAudio: Playing %d samples", len(samples))
+	logger.Debugf("MockAudio: Playing %d samples", len(samples))
 	return nil
 }
 
@@ -253,6 +259,43 @@ func (a *MockAudio) GetBufferSize() int {
 	return a.config.BufferSize
 }
 
+// GetInputChannels returns the number of interleaved capture channels
+func (a *MockAudio) GetInputChannels() int {
+	return a.config.InputChannels
+}
+
+// InjectInput feeds interleaved samples into the mock capture stream
+func (a *MockAudio) InjectInput(samples []int16) error {
+	if !a.IsRecording() {
+		return fmt.Errorf("audio input not started")
+	}
+	if len(samples)%a.config.InputChannels != 0 {
+		return fmt.Errorf("sample count %d is not a whole number of %d-channel frames",
+			len(samples), a.config.InputChannels)
+	}
+
+	select {
+	case a.inputSamples <- samples:
+		return nil
+	default:
+		atomic.AddInt64(&a.dropped, 1)
+		return fmt.Errorf("input buffer full")
+	}
+}
+
+// Health reports the mock capture stream's fill, with one buffer of latency
+// each way as a sound card would have
+func (a *MockAudio) Health() AudioHealth {
+	buffer := time.Duration(a.config.BufferSize) * time.Second / time.Duration(a.config.SampleRate)
+	return AudioHealth{
+		InputLatency:   buffer,
+		OutputLatency:  buffer,
+		InputQueued:    len(a.inputSamples),
+		InputQueueSize: cap(a.inputSamples),
+		InputDropped:   atomic.LoadInt64(&a.dropped),
+	}
+}
+
 // IsRecording returns mock recording state
 func (a *MockAudio) IsRecording() bool {
 	a.mutex.RLock()
@@ -2,7 +2,6 @@ package hardware
 
 import (
 	"fmt"
-	"log"
 	"sync"
 )
 
@@ -40,15 +39,15 @@ func (r *MockRadio) Initialize() error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	log.Printf("MockRadio: Initializing radio control (mock)...")
-	log.Printf("MockRadio: Model: %s", r.config.Model)
-	log.Printf("MockRadio: Device: %s", r.config.Device)
-	log.Printf("MockRadio: Baud Rate: %d", r.config.BaudRate)
+	logger.Infof("MockRadio: Initializing radio control (mock)...")
+	logger.Infof("MockRadio: Model: %s", r.config.Model)
+	logger.Infof("MockRadio: Device: %s", r.config.Device)
+	logger.Infof("MockRadio: Baud Rate: %d", r.config.BaudRate)
 
 	r.connected = true
-	log.Printf("MockRadio: Mock radio connection established")
-	log.Printf("MockRadio: Frequency: %.3f MHz", float64(r.frequency)/1000000.0)
-	log.Printf("MockRadio: Mode: %s", r.mode)
+	logger.Infof("MockRadio: Mock radio connection established")
+	logger.Infof("MockRadio: Frequency: %.3f MHz", float64(r.frequency)/1000000.0)
+	logger.Infof("MockRadio: Mode: %s", r.mode)
 
 	return nil
 }
@@ -62,7 +61,7 @@ func (r *MockRadio) Close() error {
 		return nil
 	}
 
-	log.Printf("MockRadio: Closing radio connection (mock)")
+	logger.Infof("MockRadio: Closing radio connection (mock)")
 	r.connected = false
 	return nil
 }
@@ -76,7 +75,7 @@ func (r *MockRadio) SetFrequency(freq int64) error {
 		return fmt.Errorf("radio not connected")
 	}
 
-	log.Printf("MockRadio: Setting frequency to %d Hz (%.3f MHz)", freq, float64(freq)/1000000.0)
+	logger.Infof("MockRadio: Setting frequency to %d Hz (%.3f MHz)", freq, float64(freq)/1000000.0)
 	r.frequency = freq
 	return nil
 }
@@ -102,7 +101,7 @@ func (r *MockRadio) SetMode(mode string, bandwidth int) error {
 		return fmt.Errorf("radio not connected")
 	}
 
-	log.Printf("MockRadio: Setting mode to %s with bandwidth %d Hz", mode, bandwidth)
+	logger.Infof("MockRadio: Setting mode to %s with bandwidth %d Hz", mode, bandwidth)
 	r.mode = mode
 	r.bandwidth = bandwidth
 	return nil
@@ -131,9 +130,9 @@ func (r *MockRadio) SetPTT(state bool) error {
 
 	if state != r.ptt {
 		if state {
-			log.Printf("MockRadio: PTT ON")
+			logger.Infof("MockRadio: PTT ON")
 		} else {
-			log.Printf("MockRadio: PTT OFF")
+			logger.Infof("MockRadio: PTT OFF")
 		}
 		r.ptt = state
 	}
@@ -198,6 +197,36 @@ func (r *MockRadio) GetPowerLevel() (float32, error) {
 	return r.power, nil
 }
 
+// SetPowerLevel sets mock power level
+func (r *MockRadio) SetPowerLevel(level float32) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.connected {
+		return fmt.Errorf("radio not connected")
+	}
+	if level < 0 || level > 1 {
+		return fmt.Errorf("power level %.2f out of range", level)
+	}
+
+	logger.Infof("MockRadio: Power level set to %.0f%%", level*100)
+	r.power = level
+	return nil
+}
+
+// StartTune simulates starting the antenna tuner
+func (r *MockRadio) StartTune() error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if !r.connected {
+		return fmt.Errorf("radio not connected")
+	}
+
+	logger.Infof("MockRadio: Tuner started")
+	return nil
+}
+
 // GetSWRLevel gets mock SWR level
 func (r *MockRadio) GetSWRLevel() (float32, error) {
 	r.mutex.RLock()
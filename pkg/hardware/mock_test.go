@@ -2,6 +2,7 @@ package hardware
 
 import (
 	"testing"
+	"time"
 )
 
 func TestMockGPIO(t *testing.T) {
@@ -335,6 +336,66 @@ func TestMockAudio(t *testing.T) {
 	})
 }
 
+func TestMockAudioStereoCapture(t *testing.T) {
+	audio := NewMockAudio(MockAudioConfig{Channels: 1, InputChannels: 2})
+	if audio.GetInputChannels() != 2 {
+		t.Fatalf("Expected 2 capture channels, got %d", audio.GetInputChannels())
+	}
+
+	if err := audio.InjectInput([]int16{1, 2}); err == nil {
+		t.Error("Expected error when injecting before input is started")
+	}
+
+	if err := audio.StartInput(); err != nil {
+		t.Fatalf("Failed to start input: %v", err)
+	}
+	defer audio.Close()
+
+	if err := audio.InjectInput([]int16{1, 2, 3}); err == nil {
+		t.Error("Expected error for a partial stereo frame")
+	}
+
+	frames := []int16{100, -100, 200, -200}
+	if err := audio.InjectInput(frames); err != nil {
+		t.Fatalf("Failed to inject stereo frames: %v", err)
+	}
+	if got := <-audio.GetInputSamples(); len(got) != len(frames) || got[1] != -100 {
+		t.Errorf("Expected interleaved frames %v, got %v", frames, got)
+	}
+}
+
+func TestMockAudioHealth(t *testing.T) {
+	audio := NewMockAudio(MockAudioConfig{SampleRate: 48000, BufferSize: 4800})
+	if err := audio.StartInput(); err != nil {
+		t.Fatalf("Failed to start input: %v", err)
+	}
+	defer audio.Close()
+
+	size := cap(audio.inputSamples)
+	for i := 0; i < size+2; i++ {
+		audio.InjectInput([]int16{1})
+	}
+
+	health := audio.Health()
+	if health.InputQueued != size || health.InputQueueSize != size {
+		t.Errorf("Expected a full queue of %d, got %d of %d", size, health.InputQueued, health.InputQueueSize)
+	}
+	if health.InputDropped != 2 {
+		t.Errorf("Expected 2 dropped blocks, got %d", health.InputDropped)
+	}
+	if health.InputLatency != 100*time.Millisecond {
+		t.Errorf("Expected 100ms of latency, got %v", health.InputLatency)
+	}
+
+	manager := &HardwareManager{audio: audio}
+	if got, ok := manager.GetAudioHealth(); !ok || got.InputDropped != 2 {
+		t.Errorf("Expected the manager to report the card's health, got %+v, %v", got, ok)
+	}
+	if _, ok := (&HardwareManager{}).GetAudioHealth(); ok {
+		t.Error("Expected no health without audio")
+	}
+}
+
 func TestMockAudioConcurrency(t *testing.T) {
 	config := MockAudioConfig{
 		SampleRate: 48000,
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package hardware
+
+import (
+	"fmt"
+	"os"
+)
+
+// openPTY is only supported on Linux
+func openPTY() (*os.File, *os.File, string, error) {
+	return nil, nil, "", fmt.Errorf("serial over TCP is not supported on this platform")
+}
@@ -8,6 +8,7 @@ type RadioConfig struct {
 	Enabled       bool   // Whether radio control is enabled
 	CIVAddress    string // CI-V Address (hex, e.g., "94" for IC-7300)
 	CIVTransceive bool   // CI-V Transceive ON/OFF
+	CATProtocol   string // Native CAT command set, "" to pick by Model
 }
 
 // RadioInterface defines radio control operations
@@ -33,6 +34,8 @@ type RadioInterface interface {
 
 	// Power and status
 	GetPowerLevel() (float32, error)
+	SetPowerLevel(level float32) error
+	StartTune() error
 	GetSWRLevel() (float32, error)
 	GetSignalLevel() (int, error)
 }
@@ -53,6 +56,8 @@ const (
 	ModeRTTY = "RTTY"
 	ModePSK  = "PSK"
 	ModeJT8  = "JT8" // For JS8 and similar digital modes
+	ModePKTUSB = "PKTUSB" // USB with the rig's data input
+	ModePKTLSB = "PKTLSB"
 	ModeFM   = "FM"
 	ModeAM   = "AM"
 )
@@ -0,0 +1,134 @@
+package hardware
+
+// Hardware subsystems that can be torn down and re-created while running
+const (
+	SubsystemAudio = "audio"
+	SubsystemRadio = "radio"
+	SubsystemGPIO  = "gpio"
+	SubsystemOLED  = "oled"
+)
+
+// ChangedSubsystems lists the subsystems whose settings differ between two
+// configurations, in the order Reconfigure restarts them
+func ChangedSubsystems(old, new HardwareConfig) []string {
+	var changed []string
+	if old.EnableAudio != new.EnableAudio || old.AudioInput != new.AudioInput ||
+		old.AudioOutput != new.AudioOutput || old.SampleRate != new.SampleRate ||
+		old.BufferSize != new.BufferSize || old.InputChannels != new.InputChannels ||
+		old.OutputChannels != new.OutputChannels || old.HalfDuplex != new.HalfDuplex {
+		changed = append(changed, SubsystemAudio)
+	}
+	if old.EnableRadio != new.EnableRadio || old.UseHamlib != new.UseHamlib ||
+		old.RadioModel != new.RadioModel || old.RadioDevice != new.RadioDevice ||
+		old.RadioBaudRate != new.RadioBaudRate || old.CIVAddress != new.CIVAddress ||
+		old.CIVTransceive != new.CIVTransceive || old.RadioBackend != new.RadioBackend ||
+		old.CATProtocol != new.CATProtocol {
+		changed = append(changed, SubsystemRadio)
+	}
+	if old.EnableGPIO != new.EnableGPIO || old.PTTGPIOPin != new.PTTGPIOPin ||
+		old.StatusLEDPin != new.StatusLEDPin || old.AmpKeying != new.AmpKeying ||
+		old.AmpGPIOPin != new.AmpGPIOPin {
+		changed = append(changed, SubsystemGPIO)
+	}
+	if old.EnableOLED != new.EnableOLED || old.OLEDI2CAddress != new.OLEDI2CAddress ||
+		old.OLEDWidth != new.OLEDWidth || old.OLEDHeight != new.OLEDHeight {
+		changed = append(changed, SubsystemOLED)
+	}
+	return changed
+}
+
+// Reconfigure applies a new configuration to a running hardware manager.
+// Each changed subsystem is closed and opened again with its new settings;
+// the others keep running. PTT is released first so a pin or radio never
+// stays keyed across the change. Callers reading audio samples must stop
+// before an audio change and fetch the new sample channel afterwards.
+func (h *HardwareManager) Reconfigure(config HardwareConfig) ([]string, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	old := h.config
+	changed := ChangedSubsystems(old, config)
+	h.config = config
+	if !h.initialized || len(changed) == 0 {
+		return changed, nil
+	}
+
+	// The old pins are still configured on the old GPIO
+	if h.pttActive && h.gpio != nil {
+		h.gpio.SetPin(old.PTTGPIOPin, false)
+	}
+	if h.ampActive && h.gpio != nil {
+		h.gpio.SetPin(old.AmpGPIOPin, false)
+	}
+	h.pttActive = false
+	h.ampActive = false
+
+	for _, subsystem := range changed {
+		logger.Infof("Restarting %s with new settings", subsystem)
+		switch subsystem {
+		case SubsystemAudio:
+			if h.audio != nil {
+				h.audio.StopInput()
+				h.audio.StopOutput()
+				if err := h.audio.Close(); err != nil {
+					logger.Errorf("Error closing Audio: %v", err)
+				}
+				h.audio = nil
+			}
+			if h.config.EnableAudio {
+				if err := h.initAudio(); err != nil {
+					h.audio = nil
+					return changed, err
+				}
+			}
+
+		case SubsystemRadio:
+			if h.radio != nil {
+				if err := h.radio.SetPTT(false); err != nil {
+					logger.Warnf("Failed to release radio PTT: %v", err)
+				}
+				if err := h.radio.Close(); err != nil {
+					logger.Errorf("Error closing Radio: %v", err)
+				}
+				h.radio = nil
+			}
+			h.closeBridge()
+			if h.config.EnableRadio {
+				h.initRadio()
+				if h.radio == nil {
+					logger.Warnf("Radio not connected after reconfiguration")
+				}
+			}
+
+		case SubsystemGPIO:
+			if h.gpio != nil {
+				if err := h.gpio.Close(); err != nil {
+					logger.Errorf("Error closing GPIO: %v", err)
+				}
+				h.gpio = nil
+			}
+			if h.config.EnableGPIO {
+				if err := h.initGPIO(); err != nil {
+					h.gpio = nil
+					return changed, err
+				}
+			}
+
+		case SubsystemOLED:
+			if h.oled != nil {
+				if err := h.oled.Close(); err != nil {
+					logger.Errorf("Error closing OLED: %v", err)
+				}
+				h.oled = nil
+			}
+			if h.config.EnableOLED {
+				if err := h.initOLED(); err != nil {
+					h.oled = nil
+					return changed, err
+				}
+			}
+		}
+	}
+
+	return changed, nil
+}
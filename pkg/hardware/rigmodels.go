@@ -0,0 +1,114 @@
+package hardware
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RigModel is a radio model js8d can control, numbered as Hamlib numbers it
+type RigModel struct {
+	ID           int    `json:"id"`
+	Manufacturer string `json:"manufacturer"`
+	Model        string `json:"model"`
+	Version      string `json:"version,omitempty"` // backend version
+	Status       string `json:"status"`            // Alpha, Untested, Beta, Stable or Buggy
+}
+
+// Name returns the manufacturer and model
+func (m RigModel) Name() string {
+	if m.Manufacturer == "" {
+		return m.Model
+	}
+	return m.Manufacturer + " " + m.Model
+}
+
+// js8dRigModels are js8d's own model numbers for the QRP Labs rigs, which
+// speak Kenwood TS-480 CAT. They take the place of any Hamlib model with
+// the same number.
+var js8dRigModels = []RigModel{
+	{ID: 10001, Manufacturer: "QRP Labs", Model: "QDX", Status: "Stable"},
+	{ID: 10002, Manufacturer: "QRP Labs", Model: "QMX", Status: "Stable"},
+}
+
+var (
+	rigModelsOnce sync.Once
+	rigModels     []RigModel
+	rigModelsErr  error
+)
+
+// RigModels lists the radio models by number: all of Hamlib's, or in a
+// build without Hamlib those of the native drivers. Loading every Hamlib
+// backend takes a moment, so the list is made once.
+func RigModels() ([]RigModel, error) {
+	rigModelsOnce.Do(func() {
+		models, err := hamlibRigModels()
+		if err != nil {
+			rigModelsErr = err
+			return
+		}
+		own := make(map[int]bool, len(js8dRigModels))
+		for _, model := range js8dRigModels {
+			own[model.ID] = true
+		}
+		kept := append([]RigModel(nil), js8dRigModels...)
+		for _, model := range models {
+			if !own[model.ID] {
+				kept = append(kept, model)
+			}
+		}
+		models = kept
+		sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+		rigModels = models
+	})
+	return rigModels, rigModelsErr
+}
+
+// RigModelName returns a friendly name for a radio model number
+func RigModelName(model string) string {
+	if model == "" {
+		return "No Radio"
+	}
+	if id, err := strconv.Atoi(model); err == nil {
+		models, _ := RigModels()
+		for _, m := range models {
+			if m.ID == id {
+				return m.Name()
+			}
+		}
+	}
+	return fmt.Sprintf("Radio Model %s", model)
+}
+
+// FilterRigModels returns the models matching a search, a manufacturer and
+// a status, each ignored when empty. Every word of the search must match
+// the start of the model number or part of the name, ignoring case.
+func FilterRigModels(models []RigModel, search, manufacturer, status string) []RigModel {
+	words := strings.Fields(strings.ToLower(search))
+	matches := []RigModel{}
+	for _, m := range models {
+		if manufacturer != "" && !strings.EqualFold(m.Manufacturer, manufacturer) {
+			continue
+		}
+		if status != "" && !strings.EqualFold(m.Status, status) {
+			continue
+		}
+		if matchesWords(m, words) {
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// matchesWords reports whether every word matches a model's number or name
+func matchesWords(m RigModel, words []string) bool {
+	id, name := strconv.Itoa(m.ID), strings.ToLower(m.Name())
+	for _, word := range words {
+		if !strings.HasPrefix(id, word) && !strings.Contains(name, word) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package hardware
+
+import (
+	"testing"
+)
+
+func TestRigModelName(t *testing.T) {
+	tests := map[string]string{
+		"3073":    "Icom IC-7300",
+		"2028":    "Kenwood TS-480",
+		"10001":   "QRP Labs QDX",
+		"10002":   "QRP Labs QMX",
+		"":        "No Radio",
+		"99999":   "Radio Model 99999",
+		"unknown": "Radio Model unknown",
+	}
+	for model, want := range tests {
+		if got := RigModelName(model); got != want {
+			t.Errorf("RigModelName(%q) = %q, want %q", model, got, want)
+		}
+	}
+
+	models, err := RigModels()
+	if err != nil {
+		t.Fatalf("RigModels failed: %v", err)
+	}
+	for i := 1; i < len(models); i++ {
+		if models[i-1].ID >= models[i].ID {
+			t.Fatalf("Expected models in order, got %d before %d", models[i-1].ID, models[i].ID)
+		}
+	}
+}
+
+func TestFilterRigModels(t *testing.T) {
+	models := []RigModel{
+		{ID: 1, Manufacturer: "Hamlib", Model: "Dummy", Status: "Stable"},
+		{ID: 2028, Manufacturer: "Kenwood", Model: "TS-480", Status: "Stable"},
+		{ID: 3073, Manufacturer: "Icom", Model: "IC-7300", Status: "Stable"},
+		{ID: 3085, Manufacturer: "Icom", Model: "IC-705", Status: "Beta"},
+	}
+
+	tests := []struct {
+		search, manufacturer, status string
+		want                         []int
+	}{
+		{"", "", "", []int{1, 2028, 3073, 3085}},
+		{"ic-7", "", "", []int{3073, 3085}},
+		{"icom 7300", "", "", []int{3073}},
+		{"30", "", "", []int{3073, 3085}},
+		{"", "icom", "", []int{3073, 3085}},
+		{"", "Icom", "stable", []int{3073}},
+		{"ts-480", "Icom", "", nil},
+	}
+	for _, tt := range tests {
+		got := FilterRigModels(models, tt.search, tt.manufacturer, tt.status)
+		ids := []int{}
+		for _, m := range got {
+			ids = append(ids, m.ID)
+		}
+		if len(ids) != len(tt.want) {
+			t.Errorf("FilterRigModels(%q, %q, %q) = %v, want %v", tt.search, tt.manufacturer, tt.status, ids, tt.want)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tt.want[i] {
+				t.Errorf("FilterRigModels(%q, %q, %q) = %v, want %v", tt.search, tt.manufacturer, tt.status, ids, tt.want)
+				break
+			}
+		}
+	}
+}
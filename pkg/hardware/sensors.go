@@ -0,0 +1,147 @@
+package hardware
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OneWireDevices is where the Linux w1 driver lists 1-wire sensors
+const OneWireDevices = "/sys/bus/w1/devices"
+
+// INA219 registers
+const (
+	ina219ShuntVoltage = 0x01
+	ina219BusVoltage   = 0x02
+)
+
+// INA219 reads bus voltage and current from an INA219 power monitor over
+// I2C, at its power-on configuration: 32 V range, ±320 mV across the shunt
+type INA219 struct {
+	dev       io.ReadWriter
+	shuntOhms float64
+	mutex     sync.Mutex
+}
+
+// NewINA219 creates an INA219 reader on dev, an I2C device already
+// addressed to the chip, with a shunt resistor of shuntOhms
+func NewINA219(dev io.ReadWriter, shuntOhms float64) *INA219 {
+	return &INA219{
+		dev:       dev,
+		shuntOhms: shuntOhms,
+	}
+}
+
+// OpenINA219 opens the INA219 at address on I2C bus, /dev/i2c-<bus>
+func OpenINA219(bus, address int, shuntOhms float64) (*INA219, error) {
+	dev, err := openI2C(bus, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open INA219: %w", err)
+	}
+	logger.Infof("INA219 opened on I2C bus %d at 0x%02x", bus, address)
+	return NewINA219(dev, shuntOhms), nil
+}
+
+// Read returns the bus voltage in volts and the current through the shunt
+// in amps, negative while the battery charges if wired that way round
+func (s *INA219) Read() (voltage, current float64, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	bus, err := s.register(ina219BusVoltage)
+	if err != nil {
+		return 0, 0, err
+	}
+	shunt, err := s.register(ina219ShuntVoltage)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// The bus voltage sits in the top 13 bits at 4 mV a step, the shunt
+	// voltage is signed at 10 µV a step
+	voltage = float64(bus>>3) * 0.004
+	current = float64(int16(shunt)) * 10e-6 / s.shuntOhms
+	return voltage, current, nil
+}
+
+// register reads a 16-bit register, which the chip sends high byte first
+func (s *INA219) register(reg byte) (uint16, error) {
+	if _, err := s.dev.Write([]byte{reg}); err != nil {
+		return 0, fmt.Errorf("failed to select INA219 register 0x%02x: %w", reg, err)
+	}
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(s.dev, buf); err != nil {
+		return 0, fmt.Errorf("failed to read INA219 register 0x%02x: %w", reg, err)
+	}
+	return binary.BigEndian.Uint16(buf), nil
+}
+
+// Close closes the I2C device
+func (s *INA219) Close() error {
+	if closer, ok := s.dev.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// ReadTemperatures reads DS18B20 sensors through the w1 driver under dir,
+// in degrees Celsius by sensor ID. With no ids it reads every DS18B20
+// found, those with IDs starting 28-. A sensor that fails is left out and
+// its error returned alongside the others' readings.
+func ReadTemperatures(dir string, ids []string) (map[string]float64, error) {
+	if len(ids) == 0 {
+		paths, err := filepath.Glob(filepath.Join(dir, "28-*"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			ids = append(ids, filepath.Base(path))
+		}
+	}
+
+	temperatures := make(map[string]float64)
+	var firstErr error
+	for _, id := range ids {
+		data, err := os.ReadFile(filepath.Join(dir, id, "w1_slave"))
+		if err == nil {
+			var celsius float64
+			if celsius, err = ParseW1Slave(string(data)); err == nil {
+				temperatures[id] = celsius
+				continue
+			}
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("sensor %s: %w", id, err)
+		}
+	}
+	return temperatures, firstErr
+}
+
+// ParseW1Slave parses the w1_slave file of a DS18B20, whose first line ends
+// YES when the CRC checked and whose second ends t= and millidegrees:
+//
+//	72 01 4b 46 7f ff 0e 10 57 : crc=57 YES
+//	72 01 4b 46 7f ff 0e 10 57 t=23125
+func ParseW1Slave(data string) (float64, error) {
+	lines := strings.Split(strings.TrimSpace(data), "\n")
+	if len(lines) < 2 {
+		return 0, fmt.Errorf("short w1_slave reading")
+	}
+	if !strings.HasSuffix(strings.TrimSpace(lines[0]), "YES") {
+		return 0, fmt.Errorf("CRC check failed")
+	}
+	i := strings.LastIndex(lines[1], "t=")
+	if i < 0 {
+		return 0, fmt.Errorf("no temperature in w1_slave reading")
+	}
+	milli, err := strconv.Atoi(strings.TrimSpace(lines[1][i+2:]))
+	if err != nil {
+		return 0, fmt.Errorf("bad temperature: %w", err)
+	}
+	return float64(milli) / 1000, nil
+}
@@ -0,0 +1,69 @@
+package hardware
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ina219Chip answers register reads like an INA219
+type ina219Chip struct {
+	registers map[byte]uint16
+	selected  byte
+}
+
+func (c *ina219Chip) Write(p []byte) (int, error) {
+	c.selected = p[0]
+	return len(p), nil
+}
+
+func (c *ina219Chip) Read(p []byte) (int, error) {
+	value := c.registers[c.selected]
+	return copy(p, []byte{byte(value >> 8), byte(value)}), nil
+}
+
+func TestINA219(t *testing.T) {
+	chip := &ina219Chip{registers: map[byte]uint16{
+		ina219BusVoltage:   3250 << 3, // 13.0 V
+		ina219ShuntVoltage: 0xFF38,    // -2 mV
+	}}
+	sensor := NewINA219(chip, 0.1)
+
+	voltage, current, err := sensor.Read()
+	if err != nil {
+		t.Fatalf("Failed to read INA219: %v", err)
+	}
+	if math.Abs(voltage-13.0) > 1e-9 {
+		t.Errorf("Expected 13.0 V, got %v", voltage)
+	}
+	if math.Abs(current+0.02) > 1e-9 {
+		t.Errorf("Expected -0.02 A, got %v", current)
+	}
+}
+
+func TestReadTemperatures(t *testing.T) {
+	dir := t.TempDir()
+	sensors := map[string]string{
+		"28-0000071f2a5c": "72 01 4b 46 7f ff 0e 10 57 : crc=57 YES\n72 01 4b 46 7f ff 0e 10 57 t=23125\n",
+		"28-0000071f3b6d": "50 05 4b 46 7f ff 0c 10 1c : crc=1c NO\n50 05 4b 46 7f ff 0c 10 1c t=85000\n",
+	}
+	for id, data := range sensors {
+		os.MkdirAll(filepath.Join(dir, id), 0755)
+		os.WriteFile(filepath.Join(dir, id, "w1_slave"), []byte(data), 0644)
+	}
+	// Not a DS18B20
+	os.MkdirAll(filepath.Join(dir, "w1_bus_master1"), 0755)
+
+	temperatures, err := ReadTemperatures(dir, nil)
+	if err == nil {
+		t.Error("Expected an error for the sensor failing its CRC")
+	}
+	if len(temperatures) != 1 || temperatures["28-0000071f2a5c"] != 23.125 {
+		t.Errorf("Expected 23.125 C from one sensor, got %v", temperatures)
+	}
+
+	if _, err := ReadTemperatures(dir, []string{"28-missing"}); err == nil {
+		t.Error("Expected an error for a missing sensor")
+	}
+}
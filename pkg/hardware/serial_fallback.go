@@ -0,0 +1,13 @@
+//go:build !linux
+
+package hardware
+
+import (
+	"fmt"
+	"os"
+)
+
+// openSerial is only supported on Linux
+func openSerial(device string, baud int) (*os.File, error) {
+	return nil, fmt.Errorf("native CAT is not supported on this platform")
+}
@@ -0,0 +1,64 @@
+package hardware
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Termios flags the syscall package leaves out
+const (
+	termiosCBAUD   = 0x100F     // Mask of the baud rate bits
+	termiosCRTSCTS = 0x80000000 // Hardware flow control
+)
+
+// serialSpeeds maps baud rates to their termios speeds
+var serialSpeeds = map[int]uint32{
+	1200:   syscall.B1200,
+	2400:   syscall.B2400,
+	4800:   syscall.B4800,
+	9600:   syscall.B9600,
+	19200:  syscall.B19200,
+	38400:  syscall.B38400,
+	57600:  syscall.B57600,
+	115200: syscall.B115200,
+	230400: syscall.B230400,
+}
+
+// openSerial opens a serial port in raw mode, 8N1 at a baud rate, or the
+// port's current rate when baud is 0. Reads honour deadlines.
+func openSerial(device string, baud int) (*os.File, error) {
+	port, err := os.OpenFile(device, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var termios syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, port.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&termios))); errno != 0 {
+		port.Close()
+		return nil, fmt.Errorf("failed to read %s settings: %w", device, errno)
+	}
+	termios.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON | syscall.IXOFF
+	termios.Oflag &^= syscall.OPOST
+	termios.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	termios.Cflag &^= syscall.CSIZE | syscall.PARENB | syscall.CSTOPB | termiosCRTSCTS
+	termios.Cflag |= syscall.CS8 | syscall.CREAD | syscall.CLOCAL
+	if baud != 0 {
+		speed, ok := serialSpeeds[baud]
+		if !ok {
+			port.Close()
+			return nil, fmt.Errorf("unsupported baud rate %d", baud)
+		}
+		termios.Cflag &^= termiosCBAUD
+		termios.Cflag |= speed
+		termios.Ispeed = speed
+		termios.Ospeed = speed
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, port.Fd(), syscall.TCSETS, uintptr(unsafe.Pointer(&termios))); errno != 0 {
+		port.Close()
+		return nil, fmt.Errorf("failed to set up %s: %w", device, errno)
+	}
+	return port, nil
+}
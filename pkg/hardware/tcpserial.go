@@ -0,0 +1,295 @@
+package hardware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TCPSerialScheme prefixes a radio device reached over a network serial
+// server such as ser2net or an ESP32 bridge, as in tcp://192.168.1.50:4000
+const TCPSerialScheme = "tcp://"
+
+// Reconnection backoff for a serial server that can't be reached
+const (
+	bridgeDialTimeout  = 5 * time.Second
+	bridgeMinBackoff   = time.Second
+	bridgeMaxBackoff   = 30 * time.Second
+	bridgeSlowLatency  = 500 * time.Millisecond
+	bridgeSlowInterval = time.Minute
+)
+
+// TCPSerialAddress returns the host:port of a tcp:// radio device, ok false
+// for a local serial port
+func TCPSerialAddress(device string) (string, bool, error) {
+	if !strings.HasPrefix(strings.ToLower(device), TCPSerialScheme) {
+		return "", false, nil
+	}
+	address := strings.TrimSuffix(device[len(TCPSerialScheme):], "/")
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || host == "" || port == "" {
+		return "", true, fmt.Errorf("radio device %s must be tcp://host:port", device)
+	}
+	return address, true, nil
+}
+
+// LinkStatus describes a serial-over-TCP link to the radio
+type LinkStatus struct {
+	Address        string    `json:"address"`
+	Device         string    `json:"device"` // Pseudo-terminal the rig control opens
+	Connected      bool      `json:"connected"`
+	ConnectedSince time.Time `json:"connected_since,omitempty"`
+	Reconnects     int       `json:"reconnects"`
+	LastError      string    `json:"last_error,omitempty"`
+	Latency        float64   `json:"latency_ms"`         // Last command to its first reply byte
+	AverageLatency float64   `json:"average_latency_ms"` // Over every reply on the link
+	MaxLatency     float64   `json:"max_latency_ms"`
+	Samples        int       `json:"samples"`
+}
+
+// SerialBridge carries a radio's serial port over TCP. Rig control opens
+// the slave of a pseudo-terminal as though it were a local serial port,
+// and bytes are copied between the pseudo-terminal and the serial server,
+// which is redialled with backoff whenever the connection drops.
+type SerialBridge struct {
+	address string
+	master  *os.File
+	slave   *os.File // Held open so reading the master doesn't fail while rig control reopens the port
+	device  string
+
+	mutex          sync.Mutex
+	conn           net.Conn
+	connectedSince time.Time
+	reconnects     int
+	lastError      string
+	sentAt         time.Time // When a command went out with no reply yet
+	latency        time.Duration
+	totalLatency   time.Duration
+	maxLatency     time.Duration
+	samples        int
+	slowWarned     time.Time
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewSerialBridge opens a pseudo-terminal for a serial server at host:port
+// and starts connecting to the server in the background
+func NewSerialBridge(address string) (*SerialBridge, error) {
+	master, slave, device, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &SerialBridge{
+		address: address,
+		master:  master,
+		slave:   slave,
+		device:  device,
+		done:    make(chan struct{}),
+	}
+	b.wg.Add(2)
+	go b.connectLoop()
+	go b.forwardCommands()
+	logger.Infof("Serial bridge to %s on %s", address, device)
+	return b, nil
+}
+
+// Device returns the pseudo-terminal path to open as the radio's serial port
+func (b *SerialBridge) Device() string {
+	return b.device
+}
+
+// Status returns the state of the link and its latency
+func (b *SerialBridge) Status() LinkStatus {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	status := LinkStatus{
+		Address:        b.address,
+		Device:         b.device,
+		Connected:      b.conn != nil,
+		ConnectedSince: b.connectedSince,
+		Reconnects:     b.reconnects,
+		LastError:      b.lastError,
+		Latency:        milliseconds(b.latency),
+		MaxLatency:     milliseconds(b.maxLatency),
+		Samples:        b.samples,
+	}
+	if b.samples > 0 {
+		status.AverageLatency = milliseconds(b.totalLatency / time.Duration(b.samples))
+	}
+	return status
+}
+
+// milliseconds rounds a duration to a tenth of a millisecond
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*10) / 10
+}
+
+// Close disconnects from the serial server and closes the pseudo-terminal
+func (b *SerialBridge) Close() error {
+	select {
+	case <-b.done:
+		return nil
+	default:
+	}
+	close(b.done)
+
+	b.mutex.Lock()
+	if b.conn != nil {
+		b.conn.Close()
+	}
+	b.mutex.Unlock()
+	b.slave.Close()
+	err := b.master.Close()
+	b.wg.Wait()
+	return err
+}
+
+// closed reports whether Close has been called
+func (b *SerialBridge) closed() bool {
+	select {
+	case <-b.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// connectLoop keeps a connection to the serial server, copying its replies
+// to the pseudo-terminal and redialling when it drops
+func (b *SerialBridge) connectLoop() {
+	defer b.wg.Done()
+
+	backoff := bridgeMinBackoff
+	for !b.closed() {
+		conn, err := net.DialTimeout("tcp", b.address, bridgeDialTimeout)
+		if err != nil {
+			b.mutex.Lock()
+			b.lastError = err.Error()
+			b.mutex.Unlock()
+			logger.Warnf("Serial bridge to %s failed, retrying in %v: %v", b.address, backoff, err)
+			select {
+			case <-b.done:
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > bridgeMaxBackoff {
+				backoff = bridgeMaxBackoff
+			}
+			continue
+		}
+		if tcp, ok := conn.(*net.TCPConn); ok {
+			tcp.SetNoDelay(true)
+		}
+
+		b.mutex.Lock()
+		if b.closed() {
+			b.mutex.Unlock()
+			conn.Close()
+			return
+		}
+		b.conn = conn
+		b.connectedSince = time.Now()
+		b.lastError = ""
+		b.mutex.Unlock()
+		backoff = bridgeMinBackoff
+		logger.Infof("Serial bridge connected to %s", b.address)
+
+		err = b.forwardReplies(conn)
+
+		b.mutex.Lock()
+		b.conn = nil
+		b.connectedSince = time.Time{}
+		b.sentAt = time.Time{}
+		if b.closed() {
+			b.mutex.Unlock()
+			return
+		}
+		b.reconnects++
+		if err != nil {
+			b.lastError = err.Error()
+		}
+		b.mutex.Unlock()
+		conn.Close()
+		logger.Warnf("Serial bridge to %s dropped, reconnecting: %v", b.address, err)
+	}
+}
+
+// forwardReplies copies what the radio sends to the pseudo-terminal until
+// the connection drops, timing the first byte after each command
+func (b *SerialBridge) forwardReplies(conn net.Conn) error {
+	buffer := make([]byte, 1024)
+	for {
+		n, err := conn.Read(buffer)
+		if n > 0 {
+			b.recordReply(time.Now())
+			if _, werr := b.master.Write(buffer[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// recordReply times a reply against the command it answers
+func (b *SerialBridge) recordReply(at time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.sentAt.IsZero() {
+		return
+	}
+	latency := at.Sub(b.sentAt)
+	b.sentAt = time.Time{}
+	b.latency = latency
+	b.totalLatency += latency
+	b.samples++
+	if latency > b.maxLatency {
+		b.maxLatency = latency
+	}
+	if latency > bridgeSlowLatency && at.Sub(b.slowWarned) > bridgeSlowInterval {
+		b.slowWarned = at
+		logger.Warnf("Serial bridge to %s took %v to reply, CAT commands may time out", b.address, latency.Round(time.Millisecond))
+	}
+}
+
+// forwardCommands copies what rig control writes to the pseudo-terminal to
+// the serial server. Commands written while it is unreachable are dropped
+// and time out as they would on an unplugged serial port.
+func (b *SerialBridge) forwardCommands() {
+	defer b.wg.Done()
+
+	buffer := make([]byte, 1024)
+	for {
+		n, err := b.master.Read(buffer)
+		if err != nil {
+			if !b.closed() {
+				logger.Errorf("Serial bridge pty %s failed: %v", b.device, err)
+			}
+			return
+		}
+
+		b.mutex.Lock()
+		conn := b.conn
+		if conn != nil && b.sentAt.IsZero() {
+			b.sentAt = time.Now()
+		}
+		b.mutex.Unlock()
+		if conn == nil {
+			continue
+		}
+		if _, err := conn.Write(buffer[:n]); err != nil {
+			// connectLoop sees the connection drop on its next read
+			conn.Close()
+		}
+	}
+}
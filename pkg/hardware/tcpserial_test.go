@@ -0,0 +1,120 @@
+//go:build linux
+
+package hardware
+
+import (
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTCPSerialAddress(t *testing.T) {
+	tests := []struct {
+		device  string
+		address string
+		remote  bool
+		err     bool
+	}{
+		{"/dev/ttyUSB0", "", false, false},
+		{"", "", false, false},
+		{"tcp://192.168.1.50:4000", "192.168.1.50:4000", true, false},
+		{"TCP://rig.local:2000/", "rig.local:2000", true, false},
+		{"tcp://192.168.1.50", "", true, true},
+		{"tcp://:4000", "", true, true},
+	}
+	for _, tt := range tests {
+		address, remote, err := TCPSerialAddress(tt.device)
+		if address != tt.address || remote != tt.remote || (err != nil) != tt.err {
+			t.Errorf("TCPSerialAddress(%q) = %q, %v, %v; want %q, %v, error %v",
+				tt.device, address, remote, err, tt.address, tt.remote, tt.err)
+		}
+	}
+}
+
+func TestSerialBridge(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	bridge, err := NewSerialBridge(listener.Addr().String())
+	if err != nil {
+		t.Skipf("No pseudo-terminals: %v", err)
+	}
+	defer bridge.Close()
+
+	var server net.Conn
+	select {
+	case server = <-accepted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Bridge never connected")
+	}
+	waitFor(t, func() bool { return bridge.Status().Connected })
+
+	port, err := os.OpenFile(bridge.Device(), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", bridge.Device(), err)
+	}
+	defer port.Close()
+
+	// A command reaches the serial server and its reply comes back
+	if _, err := port.Write([]byte("FA;")); err != nil {
+		t.Fatalf("Failed to write command: %v", err)
+	}
+	command := make([]byte, 3)
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadFull(server, command); err != nil || string(command) != "FA;" {
+		t.Fatalf("Server read %q, %v; want FA;", command, err)
+	}
+	if _, err := server.Write([]byte("FA00014078000;")); err != nil {
+		t.Fatalf("Failed to write reply: %v", err)
+	}
+	reply := make([]byte, 14)
+	if _, err := io.ReadFull(port, reply); err != nil || string(reply) != "FA00014078000;" {
+		t.Fatalf("Port read %q, %v; want FA00014078000;", reply, err)
+	}
+
+	status := bridge.Status()
+	if status.Samples != 1 || status.Latency < 0 || status.MaxLatency != status.Latency {
+		t.Errorf("Expected one latency sample, got %+v", status)
+	}
+
+	// A dropped connection is redialled
+	server.Close()
+	select {
+	case server = <-accepted:
+		defer server.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("Bridge never reconnected")
+	}
+	waitFor(t, func() bool {
+		status := bridge.Status()
+		return status.Connected && status.Reconnects == 1
+	})
+}
+
+// waitFor polls a condition for up to five seconds
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
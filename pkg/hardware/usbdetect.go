@@ -0,0 +1,134 @@
+package hardware
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// QRP Labs rigs enumerate with the STMicroelectronics vendor ID and a
+// product ID of QRP Labs' own, as a CDC serial port and a USB sound card
+const (
+	qrpLabsVendorID  = "0483"
+	qrpLabsProductID = "a34c"
+)
+
+// Where USB devices and their stable serial port names are found; tests
+// point these at a fake tree
+var (
+	usbDevicesRoot = "/sys/bus/usb/devices"
+	serialByIDDir  = "/dev/serial/by-id"
+)
+
+// DetectedRig is a rig found on USB, with the settings it needs
+type DetectedRig struct {
+	Name         string `json:"name"`
+	Model        string `json:"model"` // radio model number
+	VendorID     string `json:"vendor_id"`
+	ProductID    string `json:"product_id"`
+	Serial       string `json:"serial,omitempty"`
+	SerialDevice string `json:"serial_device"`
+	AudioDevice  string `json:"audio_device,omitempty"`
+
+	// Settings are the recommended configuration by section and key, as
+	// taken by a config update
+	Settings map[string]interface{} `json:"settings"`
+}
+
+// DetectUSBRigs finds the QRP Labs QDX and QMX rigs plugged in. Rigs whose
+// serial port has not appeared yet are left out.
+func DetectUSBRigs() ([]DetectedRig, error) {
+	entries, err := os.ReadDir(usbDevicesRoot)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list USB devices: %w", err)
+	}
+
+	rigs := []DetectedRig{}
+	for _, entry := range entries {
+		// Interfaces are named bus-port:config.interface
+		if strings.Contains(entry.Name(), ":") {
+			continue
+		}
+		dir := filepath.Join(usbDevicesRoot, entry.Name())
+		if readSysfs(dir, "idVendor") != qrpLabsVendorID || readSysfs(dir, "idProduct") != qrpLabsProductID {
+			continue
+		}
+
+		rig := DetectedRig{
+			Name:      "QRP Labs QDX",
+			Model:     "10001",
+			VendorID:  qrpLabsVendorID,
+			ProductID: qrpLabsProductID,
+			Serial:    readSysfs(dir, "serial"),
+		}
+		if strings.Contains(strings.ToUpper(readSysfs(dir, "product")), "QMX") {
+			rig.Name, rig.Model = "QRP Labs QMX", "10002"
+		}
+
+		ttys, _ := filepath.Glob(dir + ":*/tty/tty*")
+		if len(ttys) == 0 {
+			continue
+		}
+		sort.Strings(ttys)
+		rig.SerialDevice = serialDeviceName("/dev/" + filepath.Base(ttys[0]))
+
+		cards, _ := filepath.Glob(dir + ":*/sound/card*")
+		if len(cards) > 0 {
+			sort.Strings(cards)
+			rig.AudioDevice = fmt.Sprintf("plughw:%s,0", strings.TrimPrefix(filepath.Base(cards[0]), "card"))
+		}
+
+		rig.Settings = rig.recommendedSettings()
+		rigs = append(rigs, rig)
+	}
+	return rigs, nil
+}
+
+// recommendedSettings drives the rig with the native Kenwood-style CAT,
+// keyed over CAT, and its sound card at 48 kHz
+func (r DetectedRig) recommendedSettings() map[string]interface{} {
+	settings := map[string]interface{}{
+		"radio": map[string]interface{}{
+			"backend":    BackendNative,
+			"model":      r.Model,
+			"device":     r.SerialDevice,
+			"baud_rate":  115200,
+			"ptt_method": "cat",
+		},
+	}
+	if r.AudioDevice != "" {
+		settings["audio"] = map[string]interface{}{
+			"input_device":  r.AudioDevice,
+			"output_device": r.AudioDevice,
+			"sample_rate":   48000,
+		}
+	}
+	return settings
+}
+
+// serialDeviceName returns the /dev/serial/by-id name of a serial port,
+// which survives other devices being plugged in, or the port itself
+func serialDeviceName(device string) string {
+	links, _ := filepath.Glob(filepath.Join(serialByIDDir, "*"))
+	sort.Strings(links)
+	for _, link := range links {
+		if target, err := os.Readlink(link); err == nil && filepath.Base(target) == filepath.Base(device) {
+			return link
+		}
+	}
+	return device
+}
+
+// readSysfs reads a sysfs attribute, "" when it is missing
+func readSysfs(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
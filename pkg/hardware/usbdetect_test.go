@@ -0,0 +1,79 @@
+package hardware
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeUSBDevice adds a USB device with its attributes to a fake sysfs tree
+func fakeUSBDevice(t *testing.T, root, name string, attributes map[string]string, interfaces ...string) {
+	t.Helper()
+	for attribute, value := range attributes {
+		path := filepath.Join(root, name, attribute)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(value+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, path := range interfaces {
+		if err := os.MkdirAll(filepath.Join(root, path), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDetectUSBRigs(t *testing.T) {
+	root, byID := t.TempDir(), t.TempDir()
+	oldRoot, oldByID := usbDevicesRoot, serialByIDDir
+	usbDevicesRoot, serialByIDDir = root, byID
+	defer func() { usbDevicesRoot, serialByIDDir = oldRoot, oldByID }()
+
+	fakeUSBDevice(t, root, "1-1", map[string]string{
+		"idVendor": "0483", "idProduct": "a34c", "product": "QMX Transceiver", "serial": "1234",
+	}, "1-1:1.0/tty/ttyACM1", "1-1:1.2/sound/card2")
+	fakeUSBDevice(t, root, "1-2", map[string]string{
+		"idVendor": "0483", "idProduct": "a34c", "product": "QDX Transceiver",
+	}, "1-2:1.0/tty/ttyACM0")
+	// Another vendor's serial adapter, and a QDX whose port is not up yet
+	fakeUSBDevice(t, root, "1-3", map[string]string{"idVendor": "10c4", "idProduct": "ea60"}, "1-3:1.0/tty/ttyUSB0")
+	fakeUSBDevice(t, root, "1-4", map[string]string{"idVendor": "0483", "idProduct": "a34c"})
+
+	link := filepath.Join(byID, "usb-QRP_Labs_QMX_Transceiver_1234-if00")
+	if err := os.Symlink("../../ttyACM1", link); err != nil {
+		t.Fatal(err)
+	}
+
+	rigs, err := DetectUSBRigs()
+	if err != nil {
+		t.Fatalf("DetectUSBRigs failed: %v", err)
+	}
+	if len(rigs) != 2 {
+		t.Fatalf("Expected 2 rigs, got %+v", rigs)
+	}
+
+	qmx := rigs[0]
+	if qmx.Name != "QRP Labs QMX" || qmx.Model != "10002" || qmx.Serial != "1234" {
+		t.Errorf("Expected the QMX, got %+v", qmx)
+	}
+	if qmx.SerialDevice != link || qmx.AudioDevice != "plughw:2,0" {
+		t.Errorf("Expected %s and plughw:2,0, got %s and %s", link, qmx.SerialDevice, qmx.AudioDevice)
+	}
+	radio := qmx.Settings["radio"].(map[string]interface{})
+	if radio["backend"] != BackendNative || radio["device"] != link || radio["ptt_method"] != "cat" {
+		t.Errorf("Unexpected radio settings %v", radio)
+	}
+	if audio := qmx.Settings["audio"].(map[string]interface{}); audio["input_device"] != "plughw:2,0" {
+		t.Errorf("Unexpected audio settings %v", audio)
+	}
+
+	qdx := rigs[1]
+	if qdx.Model != "10001" || qdx.SerialDevice != "/dev/ttyACM0" || qdx.AudioDevice != "" {
+		t.Errorf("Expected the QDX without audio, got %+v", qdx)
+	}
+	if _, ok := qdx.Settings["audio"]; ok {
+		t.Error("Expected no audio settings without a sound card")
+	}
+}
@@ -0,0 +1,104 @@
+// Package i18n translates the web UI and the errors of the REST API. Each
+// language is a catalog of message keys in locales/<language>.json; keys
+// missing from a catalog fall back to English.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is the language catalogs fall back to
+const Default = "en"
+
+// Languages lists the languages with a catalog, the default first
+var Languages = []string{"en", "de", "es", "ja"}
+
+// Names are the languages in their own words, for a language picker
+var Names = map[string]string{
+	"en": "English",
+	"de": "Deutsch",
+	"es": "Español",
+	"ja": "日本語",
+}
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// catalogs holds the messages of each language by key
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	catalogs := make(map[string]map[string]string, len(Languages))
+	for _, lang := range Languages {
+		data, err := locales.ReadFile("locales/" + lang + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalog for %s: %v", lang, err))
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: bad catalog for %s: %v", lang, err))
+		}
+		catalogs[lang] = messages
+	}
+	return catalogs
+}
+
+// Supported reports whether there is a catalog for a language
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// T returns the message for a key in a language, formatted with args like
+// fmt.Sprintf. It falls back to English, then to the key itself.
+func T(lang, key string, args ...interface{}) string {
+	message, ok := catalogs[lang][key]
+	if !ok {
+		if message, ok = catalogs[Default][key]; !ok {
+			message = key
+		}
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Keys returns the keys of a language's catalog, sorted
+func Keys(lang string) []string {
+	keys := make([]string, 0, len(catalogs[lang]))
+	for key := range catalogs[lang] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Match picks the supported language a browser prefers most from an
+// Accept-Language header, matching on the primary tag so de-AT gets German.
+// It returns fallback when the browser asks for none of them.
+func Match(acceptLanguage, fallback string) string {
+	best, bestQ := fallback, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if Supported(primary) && q > bestQ {
+			best, bestQ = primary, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,70 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+)
+
+// verbs matches the fmt verbs of a message
+var verbs = regexp.MustCompile(`%[a-z]`)
+
+func TestCatalogsComplete(t *testing.T) {
+	english := Keys(Default)
+	for _, lang := range Languages {
+		for _, key := range english {
+			message, ok := catalogs[lang][key]
+			if !ok {
+				t.Errorf("%s is missing %s", lang, key)
+				continue
+			}
+			want := verbs.FindAllString(catalogs[Default][key], -1)
+			got := verbs.FindAllString(message, -1)
+			if len(got) != len(want) {
+				t.Errorf("%s %s has verbs %v, English has %v", lang, key, got, want)
+			}
+		}
+		for _, key := range Keys(lang) {
+			if _, ok := catalogs[Default][key]; !ok {
+				t.Errorf("%s has %s, which English doesn't", lang, key)
+			}
+		}
+		if Names[lang] == "" {
+			t.Errorf("%s has no name", lang)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T("de", "nav.settings"); got != "Einstellungen" {
+		t.Errorf("Expected Einstellungen, got %q", got)
+	}
+	if got := T("ja", "error.unknown_instance", "rig2"); got != "不明なインスタンスです: rig2" {
+		t.Errorf("Expected the instance in the message, got %q", got)
+	}
+	if got := T("fr", "nav.settings"); got != "Settings" {
+		t.Errorf("Expected English for an unknown language, got %q", got)
+	}
+	if got := T("de", "no.such.key"); got != "no.such.key" {
+		t.Errorf("Expected the key for an unknown key, got %q", got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		header, fallback, want string
+	}{
+		{"", "en", "en"},
+		{"", "de", "de"},
+		{"de-AT,de;q=0.9,en;q=0.8", "en", "de"},
+		{"fr-FR,fr;q=0.9,es;q=0.5,en;q=0.4", "en", "es"},
+		{"en;q=0.3, ja", "en", "ja"},
+		{"fr, it", "es", "es"},
+		{"ja;q=bad, de;q=0.1", "en", "de"},
+		{"*", "en", "en"},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.header, tt.fallback); got != tt.want {
+			t.Errorf("Match(%q, %q) = %q, want %q", tt.header, tt.fallback, got, tt.want)
+		}
+	}
+}
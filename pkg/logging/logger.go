@@ -1,11 +1,14 @@
 package logging
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dougsko/js8d/pkg/config"
@@ -40,23 +43,47 @@ func (l LogLevel) String() string {
 
 // ParseLogLevel parses a string log level
 func ParseLogLevel(level string) LogLevel {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return LevelInfo
+	}
+	return parsed
+}
+
+// ParseLevel parses a string log level, rejecting unknown names
+func ParseLevel(level string) (LogLevel, error) {
 	switch strings.ToLower(level) {
 	case "debug":
-		return LevelDebug
+		return LevelDebug, nil
 	case "info":
-		return LevelInfo
+		return LevelInfo, nil
 	case "warn", "warning":
-		return LevelWarn
+		return LevelWarn, nil
 	case "error":
-		return LevelError
+		return LevelError, nil
 	default:
-		return LevelInfo
+		return LevelInfo, fmt.Errorf("unknown log level %q, use debug, info, warn or error", level)
 	}
 }
 
+// Components lists the parts of js8d that log, each with its own level
+var Components = []string{"main", "engine", "dsp", "hardware", "audio", "storage", "web"}
+
+// ValidComponent reports whether name is one of Components
+func ValidComponent(name string) bool {
+	for _, component := range Components {
+		if component == name {
+			return true
+		}
+	}
+	return false
+}
+
 // Logger provides structured logging functionality
 type Logger struct {
+	mutex         sync.RWMutex
 	level         LogLevel
+	levels        map[string]LogLevel // Per-component overrides of level
 	fileLogger    *log.Logger
 	consoleLogger *log.Logger
 	structured    bool
@@ -67,8 +94,16 @@ type Logger struct {
 func NewLogger(cfg *config.Config) (*Logger, error) {
 	logger := &Logger{
 		level:      ParseLogLevel(cfg.Logging.Level),
+		levels:     make(map[string]LogLevel),
 		structured: cfg.Logging.Structured,
 	}
+	for component, name := range cfg.Logging.Levels {
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, fmt.Errorf("logging levels %s: %w", component, err)
+		}
+		logger.levels[strings.ToLower(component)] = level
+	}
 
 	// Setup file logging with rotation (only if file path is specified)
 	if cfg.Logging.File != "" && cfg.Logging.File != "/var/log/js8d/js8d.log" {
@@ -106,45 +141,110 @@ func (l *Logger) Close() error {
 	return nil
 }
 
-// shouldLog checks if a message should be logged at the given level
-func (l *Logger) shouldLog(level LogLevel) bool {
-	return level >= l.level
+// shouldLog checks if a message from component should be logged at the
+// given level
+func (l *Logger) shouldLog(level LogLevel, component string) bool {
+	return level >= l.Level(component)
+}
+
+// Level returns the level component logs at
+func (l *Logger) Level(component string) LogLevel {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	if level, ok := l.levels[component]; ok {
+		return level
+	}
+	return l.level
+}
+
+// SetLevel changes the level of one component at runtime. An empty
+// component sets the default level and clears every override.
+func (l *Logger) SetLevel(component string, level LogLevel) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if component == "" {
+		l.level = level
+		l.levels = make(map[string]LogLevel)
+		return
+	}
+	l.levels[component] = level
+}
+
+// Levels returns the level of every component, in lower case as they are
+// written in the configuration
+func (l *Logger) Levels() map[string]string {
+	levels := make(map[string]string, len(Components))
+	for _, component := range Components {
+		levels[component] = strings.ToLower(l.Level(component).String())
+	}
+	return levels
 }
 
 // formatMessage formats a log message
 func (l *Logger) formatMessage(level LogLevel, component, message string, fields map[string]interface{}) string {
-	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
+	now := time.Now()
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 
 	if l.structured {
-		// JSON-like structured format
-		fieldsStr := ""
-		if len(fields) > 0 {
-			var parts []string
-			for k, v := range fields {
-				parts = append(parts, fmt.Sprintf(`"%s":"%v"`, k, v))
+		// One JSON object per line, with the fields alongside the message
+		// for journald and log shippers
+		var b strings.Builder
+		b.WriteString(`{"time":`)
+		writeJSON(&b, now.Format(time.RFC3339Nano))
+		b.WriteString(`,"level":`)
+		writeJSON(&b, level.String())
+		b.WriteString(`,"component":`)
+		writeJSON(&b, component)
+		b.WriteString(`,"message":`)
+		writeJSON(&b, message)
+		for _, k := range keys {
+			switch k {
+			case "time", "level", "component", "message":
+				continue
 			}
-			fieldsStr = fmt.Sprintf(" {%s}", strings.Join(parts, ","))
+			b.WriteByte(',')
+			writeJSON(&b, k)
+			b.WriteByte(':')
+			writeJSON(&b, fields[k])
 		}
-		return fmt.Sprintf(`{"time":"%s","level":"%s","component":"%s","message":"%s"%s}`,
-			timestamp, level.String(), component, message, fieldsStr)
-	} else {
-		// Human-readable format
-		fieldsStr := ""
-		if len(fields) > 0 {
-			var parts []string
-			for k, v := range fields {
-				parts = append(parts, fmt.Sprintf("%s=%v", k, v))
-			}
-			fieldsStr = fmt.Sprintf(" [%s]", strings.Join(parts, " "))
+		b.WriteByte('}')
+		return b.String()
+	}
+
+	// Human-readable format
+	fieldsStr := ""
+	if len(keys) > 0 {
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = fmt.Sprintf("%s=%v", k, fields[k])
 		}
-		return fmt.Sprintf("%s [%s] %s: %s%s",
-			timestamp, level.String(), component, message, fieldsStr)
+		fieldsStr = fmt.Sprintf(" [%s]", strings.Join(parts, " "))
 	}
+	return fmt.Sprintf("%s [%s] %s: %s%s",
+		now.Format("2006-01-02 15:04:05.000"), level.String(), component, message, fieldsStr)
+}
+
+// writeJSON appends v encoded as JSON, or as a JSON string of its printed
+// form if it can't be encoded
+func writeJSON(b *strings.Builder, v interface{}) {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	b.Write(data)
 }
 
 // log writes a log message
 func (l *Logger) log(level LogLevel, component, message string, fields map[string]interface{}) {
-	if !l.shouldLog(level) {
+	if !l.shouldLog(level, component) {
 		return
 	}
 
@@ -288,6 +388,7 @@ func GetGlobalLogger() *Logger {
 		// Fallback to console logging if not initialized
 		globalLogger = &Logger{
 			level:         LevelInfo,
+			levels:        make(map[string]LogLevel),
 			consoleLogger: log.New(os.Stdout, "", 0),
 		}
 	}
@@ -333,4 +434,55 @@ func Warnf(component, format string, args ...interface{}) {
 
 func Errorf(component, format string, args ...interface{}) {
 	GetGlobalLogger().Errorf(component, format, args...)
-}
\ No newline at end of file
+}
+
+// Component logs for one part of js8d through the global logger, at the
+// level set for it in logging.levels. Packages keep one in a variable,
+// which works before logging is initialized.
+type Component string
+
+// Enabled reports whether the component logs messages at level
+func (c Component) Enabled(level LogLevel) bool {
+	return GetGlobalLogger().shouldLog(level, string(c))
+}
+
+// Debugf logs a formatted debug message
+func (c Component) Debugf(format string, args ...interface{}) {
+	GetGlobalLogger().Debugf(string(c), format, args...)
+}
+
+// Infof logs a formatted info message
+func (c Component) Infof(format string, args ...interface{}) {
+	GetGlobalLogger().Infof(string(c), format, args...)
+}
+
+// Warnf logs a formatted warning message
+func (c Component) Warnf(format string, args ...interface{}) {
+	GetGlobalLogger().Warnf(string(c), format, args...)
+}
+
+// Errorf logs a formatted error message
+func (c Component) Errorf(format string, args ...interface{}) {
+	GetGlobalLogger().Errorf(string(c), format, args...)
+}
+
+// Debug logs a debug message with fields, which structured output keeps
+// as separate JSON keys
+func (c Component) Debug(message string, fields map[string]interface{}) {
+	GetGlobalLogger().Debug(string(c), message, fields)
+}
+
+// Info logs an info message with fields
+func (c Component) Info(message string, fields map[string]interface{}) {
+	GetGlobalLogger().Info(string(c), message, fields)
+}
+
+// Warn logs a warning message with fields
+func (c Component) Warn(message string, fields map[string]interface{}) {
+	GetGlobalLogger().Warn(string(c), message, fields)
+}
+
+// Error logs an error message with fields
+func (c Component) Error(message string, fields map[string]interface{}) {
+	GetGlobalLogger().Error(string(c), message, fields)
+}
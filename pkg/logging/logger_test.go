@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/dougsko/js8d/pkg/config"
+)
+
+func newTestLogger(t *testing.T, levels map[string]string, structured bool) (*Logger, *bytes.Buffer) {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Logging.Level = "info"
+	cfg.Logging.Levels = levels
+	cfg.Logging.Structured = structured
+
+	logger, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	var buf bytes.Buffer
+	logger.consoleLogger = log.New(&buf, "", 0)
+	return logger, &buf
+}
+
+func TestComponentLevels(t *testing.T) {
+	logger, buf := newTestLogger(t, map[string]string{"DSP": "debug", "hardware": "error"}, false)
+
+	logger.Debugf("dsp", "candidate %d", 1)
+	logger.Debugf("engine", "hidden")
+	logger.Warnf("hardware", "hidden")
+	logger.Infof("engine", "shown")
+
+	out := buf.String()
+	if !strings.Contains(out, "[DEBUG] dsp: candidate 1") || !strings.Contains(out, "[INFO] engine: shown") {
+		t.Errorf("Expected the dsp debug and engine info lines, got:\n%s", out)
+	}
+	if strings.Contains(out, "hidden") {
+		t.Errorf("Expected messages below the component level dropped, got:\n%s", out)
+	}
+
+	// Setting every component clears the overrides
+	logger.SetLevel("hardware", LevelDebug)
+	if logger.Level("hardware") != LevelDebug {
+		t.Errorf("Expected hardware at debug, got %s", logger.Level("hardware"))
+	}
+	logger.SetLevel("", LevelWarn)
+	for component, level := range logger.Levels() {
+		if level != "warn" {
+			t.Errorf("Expected %s at warn, got %s", component, level)
+		}
+	}
+
+	cfg := &config.Config{}
+	cfg.Logging.Levels = map[string]string{"dsp": "loud"}
+	if _, err := NewLogger(cfg); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+}
+
+func TestStructuredOutput(t *testing.T) {
+	logger, buf := newTestLogger(t, nil, true)
+
+	logger.Info("web", `said "hi"`, map[string]interface{}{"status": 404, "path": "/api/v1/x", "level": "ignored"})
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected one JSON object, got %q: %v", buf.String(), err)
+	}
+	if entry["level"] != "INFO" || entry["component"] != "web" || entry["message"] != `said "hi"` {
+		t.Errorf("Unexpected entry %v", entry)
+	}
+	if entry["status"] != float64(404) || entry["path"] != "/api/v1/x" {
+		t.Errorf("Expected the fields as JSON keys, got %v", entry)
+	}
+	if entry["time"] == nil {
+		t.Error("Expected a timestamp")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if level, err := ParseLevel("WARNING"); err != nil || level != LevelWarn {
+		t.Errorf("Expected warn, got %s (%v)", level, err)
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+	if ParseLogLevel("verbose") != LevelInfo {
+		t.Error("Expected ParseLogLevel to fall back to info")
+	}
+}
@@ -0,0 +1,102 @@
+// Package propagation fetches the solar and geomagnetic indices that govern
+// HF propagation from NOAA's Space Weather Prediction Center
+package propagation
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultURL is NOAA's geophysical alert, the text WWV broadcasts at 18
+// minutes past the hour, updated every three hours
+const DefaultURL = "https://services.swpc.noaa.gov/text/wwv.txt"
+
+// Conditions are the indices of one geophysical alert
+type Conditions struct {
+	SolarFlux int       `json:"sfi"`     // 10.7 cm solar flux
+	AIndex    int       `json:"a_index"` // Estimated planetary A-index for the previous day
+	KIndex    float64   `json:"k_index"` // Latest estimated planetary K-index
+	Issued    time.Time `json:"issued"`
+	Fetched   time.Time `json:"fetched"`
+}
+
+var (
+	issuedPattern = regexp.MustCompile(`^:Issued:\s+(\d{4} \w{3} \d{1,2} \d{4}) UTC`)
+	fluxPattern   = regexp.MustCompile(`Solar flux (\d+) and estimated planetary A-index (\d+)`)
+	kPattern      = regexp.MustCompile(`planetary K-index at .* was ([\d.]+)`)
+)
+
+// Fetch downloads and parses the geophysical alert at url
+func Fetch(ctx context.Context, client *http.Client, url string) (*Conditions, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "js8d")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("propagation source returned %s", resp.Status)
+	}
+
+	conditions, err := Parse(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	conditions.Fetched = time.Now()
+	return conditions, nil
+}
+
+// Parse reads the indices from a geophysical alert such as
+//
+//	:Issued: 2024 Jan 15 1205 UTC
+//	Solar flux 160 and estimated planetary A-index 5.
+//	The estimated planetary K-index at 1200 UTC on 15 January was 1.33.
+func Parse(r io.Reader) (*Conditions, error) {
+	var conditions Conditions
+	foundFlux, foundK := false, false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if m := issuedPattern.FindStringSubmatch(line); m != nil {
+			if issued, err := time.Parse("2006 Jan 2 1504", m[1]); err == nil {
+				conditions.Issued = issued
+			}
+		}
+		if m := fluxPattern.FindStringSubmatch(line); m != nil {
+			conditions.SolarFlux, _ = strconv.Atoi(m[1])
+			conditions.AIndex, _ = strconv.Atoi(m[2])
+			foundFlux = true
+		}
+		if m := kPattern.FindStringSubmatch(line); m != nil {
+			k, err := strconv.ParseFloat(strings.TrimSuffix(m[1], "."), 64)
+			if err == nil {
+				conditions.KIndex = k
+				foundK = true
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !foundFlux || !foundK {
+		return nil, errors.New("no solar indices in the geophysical alert")
+	}
+	return &conditions, nil
+}
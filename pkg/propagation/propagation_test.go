@@ -0,0 +1,70 @@
+package propagation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleAlert = `:Product: Geophysical Alert Message wwv.txt
+:Issued: 2024 Jan 15 1205 UTC
+# Prepared by the US Dept. of Commerce, NOAA, Space Weather Prediction Center
+#
+#          Geophysical Alert Message
+#
+Solar-terrestrial indices for 14 January follow.
+Solar flux 160 and estimated planetary A-index 5.
+The estimated planetary K-index at 1200 UTC on 15 January was 1.33.
+
+No space weather storms were observed for the past 24 hours.
+`
+
+func TestParse(t *testing.T) {
+	conditions, err := Parse(strings.NewReader(sampleAlert))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if conditions.SolarFlux != 160 || conditions.AIndex != 5 || conditions.KIndex != 1.33 {
+		t.Errorf("Unexpected indices: %+v", conditions)
+	}
+	if want := time.Date(2024, time.January, 15, 12, 5, 0, 0, time.UTC); !conditions.Issued.Equal(want) {
+		t.Errorf("Expected issued %v, got %v", want, conditions.Issued)
+	}
+
+	// Older alerts give whole K values ending the sentence
+	conditions, err = Parse(strings.NewReader(strings.Replace(sampleAlert, "was 1.33.", "was 3.", 1)))
+	if err != nil || conditions.KIndex != 3 {
+		t.Errorf("Expected K 3, got %+v, %v", conditions, err)
+	}
+
+	if _, err := Parse(strings.NewReader("<html>Service unavailable</html>")); err == nil {
+		t.Error("Expected an error without indices")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/text/wwv.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, sampleAlert)
+	}))
+	defer server.Close()
+
+	conditions, err := Fetch(context.Background(), server.Client(), server.URL+"/text/wwv.txt")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if conditions.SolarFlux != 160 || conditions.Fetched.IsZero() {
+		t.Errorf("Unexpected conditions: %+v", conditions)
+	}
+
+	if _, err := Fetch(context.Background(), server.Client(), server.URL+"/missing"); err == nil {
+		t.Error("Expected an error for a missing alert")
+	}
+}
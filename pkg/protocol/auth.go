@@ -0,0 +1,170 @@
+package protocol
+
+import "strings"
+
+// Roles a client can hold. Each role may do everything the ones before it
+// may: a guest views, an operator also transmits and tunes, and an admin
+// also changes the configuration and tests hardware.
+const (
+	RoleGuest    = "guest"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// CmdAuth presents a token on a connection: AUTH:<token>
+const CmdAuth = "AUTH"
+
+// Error codes for commands refused by access control
+const (
+	ErrCodeUnauthorized = "UNAUTHORIZED" // Missing or unknown token
+	ErrCodeForbidden    = "FORBIDDEN"    // The token's role doesn't allow the command
+)
+
+var roleRanks = map[string]int{
+	RoleGuest:    1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// ValidRole reports whether role is one of the known roles
+func ValidRole(role string) bool {
+	return roleRanks[role] > 0
+}
+
+// RoleAllows reports whether a client with role may do what required needs
+func RoleAllows(role, required string) bool {
+	return ValidRole(role) && roleRanks[role] >= roleRanks[required]
+}
+
+// RequiredRole returns the role a command needs. Commands not listed here
+// need admin, so new ones are locked down until they are classified.
+func RequiredRole(cmd *Command) string {
+	name, rest, _ := strings.Cut(cmd.Type, " ")
+	switch name {
+	case CmdStatus, CmdMessages, CmdRadio, CmdAudio, CmdPing, CmdQuit, CmdHello, CmdAuth, CmdEvents, CmdRaw,
+		"GET_MESSAGE_HISTORY", "GET_CONVERSATIONS", "SEARCH_MESSAGES", "GET_MESSAGE_STATS", "GET_TX_QUEUE",
+		"GET_STATIONS", "GET_MAP", "GET_PROPAGATION", "GET_STATS_SUMMARY",
+		"GET_STATS_SERIES", "GET_BAND_NOISE", "GET_SNR_HISTORY", "GET_TELEMETRY", "GET_ANSWERS", "GET_RATE_LIMITS", "GET_LOG", "GET_AWARDS", "EXPORT_ADIF", "SENSORS", "BANDPLAN":
+		return RoleGuest
+
+	case CmdStation:
+		// Looking a station up is viewing; noting details about it is operating
+		if len(cmd.StationEdits()) == 0 {
+			return RoleGuest
+		}
+		return RoleOperator
+
+	case CmdMacro:
+		// Anyone may see and try out macros; saving and deleting them is operating
+		switch cmd.MacroAction() {
+		case MacroSet, MacroDelete:
+			return RoleOperator
+		}
+		return RoleGuest
+
+	case CmdFilter:
+		// Anyone may see the filters; changing them is operating
+		if cmd.FilterAction() == FilterList {
+			return RoleGuest
+		}
+		return RoleOperator
+
+	case CmdAutoList:
+		// Anyone may see the lists; changing them is operating
+		if cmd.AutoListAction() == AutoListList {
+			return RoleGuest
+		}
+		return RoleOperator
+
+	case CmdForm:
+		// Anyone may read forms; sending one transmits
+		if cmd.FormAction() == FormSend {
+			return RoleOperator
+		}
+		return RoleGuest
+
+	case CmdFile:
+		// Anyone may read transfers; sending or cancelling one transmits
+		if action := cmd.FileAction(); action == FileSend || action == FileCancel {
+			return RoleOperator
+		}
+		return RoleGuest
+
+	case CmdSession:
+		// Anyone may follow sessions; opening, writing to and closing
+		// them transmits
+		switch cmd.SessionAction() {
+		case SessionConnect, SessionSend, SessionDisconnect:
+			return RoleOperator
+		}
+		return RoleGuest
+
+	case CmdProfile:
+		// Listing profiles is viewing; switching retunes the radio
+		if cmd.StringArg("name") == "" {
+			return RoleGuest
+		}
+		return RoleOperator
+
+	case CmdRedecode:
+		// Anyone may see the re-decoded messages; a run takes the CPU
+		// from live decoding, so starting one is operating
+		if cmd.StringArg("range") == "" {
+			return RoleGuest
+		}
+		return RoleOperator
+
+	case CmdLogLevel:
+		// Anyone may see the levels; changing them is admin
+		if cmd.StringArg("level") == "" {
+			return RoleGuest
+		}
+		return RoleAdmin
+
+	case "AUTO", CmdQSO, "QSL", CmdBeacon:
+		// Anyone may see whether auto replies, scripted QSOs and beacons
+		// are on and what awaits QSL upload; changing them is operating
+		if strings.TrimSpace(rest) == "" {
+			return RoleGuest
+		}
+		return RoleOperator
+
+	case CmdNet:
+		// Anyone may follow a net and read its check-ins; opening and
+		// closing one transmits
+		action, _, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		switch strings.ToUpper(action) {
+		case "OPEN", "CLOSE":
+			return RoleOperator
+		}
+		return RoleGuest
+
+	case CmdTelemetry:
+		// Anyone may read telemetry; sending it now transmits
+		if strings.EqualFold(strings.TrimSpace(rest), "NOW") {
+			return RoleOperator
+		}
+		return RoleGuest
+
+	case "ANTENNA", "POWER", "PASSBAND":
+		// Anyone may see which antenna, power mode and decode passband
+		// are in use; switching them is operating
+		if strings.TrimSpace(rest) == "" {
+			return RoleGuest
+		}
+		return RoleOperator
+
+	case "DXCC":
+		// Anyone may look callsigns up; downloading a new country file
+		// is left to admins
+		action, _, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		if action == "" || strings.EqualFold(action, "LOOKUP") {
+			return RoleGuest
+		}
+		return RoleAdmin
+
+	case CmdSend, CmdFrequency, CmdAbort, "MARK_MESSAGES_READ", "RETRY_RADIO", "TUNE", "FIND_OFFSET":
+		return RoleOperator
+	}
+	return RoleAdmin
+}
@@ -0,0 +1,113 @@
+package protocol
+
+import "testing"
+
+func TestRequiredRole(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"STATUS", RoleGuest},
+		{"MESSAGES:10", RoleGuest},
+		{"GET_MESSAGE_HISTORY 50", RoleGuest},
+		{"GET_TX_QUEUE", RoleGuest},
+		{"EVENTS", RoleGuest},
+		{"STATION:N0ABC", RoleGuest},
+		{"GET_STATIONS 50", RoleGuest},
+		{"GET_MAP 3600 40m", RoleGuest},
+		{"GET_PROPAGATION", RoleGuest},
+		{"SENSORS", RoleGuest},
+		{"BANDPLAN 7250000", RoleGuest},
+		{"GET_STATS_SUMMARY 86400", RoleGuest},
+		{"GET_STATS_SERIES auto 1700000000 1700086400", RoleGuest},
+		{"GET_BAND_NOISE 1700000000 1700086400", RoleGuest},
+		{"GET_ANSWERS 20", RoleGuest},
+		{"GET_RATE_LIMITS", RoleGuest},
+		{"FIND_OFFSET", RoleOperator},
+		{"REDECODE", RoleGuest},
+		{"REDECODE:2h", RoleOperator},
+		{"GET_LOG 10 K1ABC", RoleGuest},
+		{"GET_AWARDS 20m", RoleGuest},
+		{"EXPORT_ADIF LOTW", RoleGuest},
+		{"QSL", RoleGuest},
+		{"QSL UPLOAD EQSL", RoleOperator},
+		{"DXCC", RoleGuest},
+		{"DXCC LOOKUP DL1ABC", RoleGuest},
+		{"DXCC REFRESH", RoleAdmin},
+		{"STATION:N0ABC:notes:Met at Dayton", RoleOperator},
+		{"PROFILE", RoleGuest},
+		{"AUTH:abc", RoleGuest},
+		{"LOGLEVEL", RoleGuest},
+		{"AUTO", RoleGuest},
+		{"QSO", RoleGuest},
+		{"BEACON", RoleGuest},
+		{"ANTENNA", RoleGuest},
+		{"MACRO", RoleGuest},
+		{"FILTER", RoleGuest},
+		{"AUTOLIST", RoleGuest},
+		{"NET", RoleGuest},
+		{"NET EXPORT 3", RoleGuest},
+		{"TELEMETRY", RoleGuest},
+		{"TELEMETRY STATIONS", RoleGuest},
+		{"GET_TELEMETRY N0ABC 1700000000 1700086400", RoleGuest},
+		{"MACRO:expand:N0ABC:{CALL} {SNR}", RoleGuest},
+		{"FORM", RoleGuest},
+		{"FORM:templates", RoleGuest},
+		{"FILE", RoleGuest},
+		{"FILE:get:3", RoleGuest},
+		{"SESSION", RoleGuest},
+		{"SESSION:get:2", RoleGuest},
+		{"PROFILE:portable", RoleOperator},
+		{"SEND:N0CALL Hello", RoleOperator},
+		{"FREQUENCY:14078000", RoleOperator},
+		{"ABORT", RoleOperator},
+		{"TUNE carrier", RoleOperator},
+		{"ANTENNA beam", RoleOperator},
+		{"POWER", RoleGuest},
+		{"POWER LOW", RoleOperator},
+		{"PASSBAND", RoleGuest},
+		{"PASSBAND 500 2500", RoleOperator},
+		{"AUTO OFF", RoleOperator},
+		{"QSO STOP", RoleOperator},
+		{"BEACON NOW", RoleOperator},
+		{"MACRO:set:CQ:CQ CQ {MYCALL}", RoleOperator},
+		{"MACRO:delete:CQ", RoleOperator},
+		{"FILTER:add:callsign:N0ABC", RoleOperator},
+		{"FILTER:delete:3", RoleOperator},
+		{"AUTOLIST:block:N0ABC", RoleOperator},
+		{"AUTOLIST:delete:3", RoleOperator},
+		{"NET OPEN @ARESNET", RoleOperator},
+		{"NET CLOSE", RoleOperator},
+		{"TELEMETRY NOW", RoleOperator},
+		{"FORM:send:ICS213:W1AW:{}", RoleOperator},
+		{"FILE:send:W1AW:notes.txt:aGVsbG8=", RoleOperator},
+		{"FILE:cancel:3", RoleOperator},
+		{"SESSION:connect:W1AW", RoleOperator},
+		{"SESSION:send:2:HELLO", RoleOperator},
+		{"SESSION:disconnect:2", RoleOperator},
+		{"RELOAD", RoleAdmin},
+		{"LOGLEVEL:dsp:debug", RoleAdmin},
+		{"TEST_PTT 1", RoleAdmin},
+		{"CLEANUP_MESSAGES", RoleAdmin},
+		{"RESTART", RoleAdmin},
+		{"REBOOT", RoleAdmin},
+		{"CHECK_UPDATE", RoleAdmin},
+		{"SOMETHING_NEW", RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		cmd, _ := ParseCommand(tt.line)
+		if got := RequiredRole(cmd); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.line, tt.want, got)
+		}
+	}
+}
+
+func TestRoleAllows(t *testing.T) {
+	if !RoleAllows(RoleAdmin, RoleOperator) || !RoleAllows(RoleOperator, RoleOperator) {
+		t.Error("Expected higher and equal roles to be allowed")
+	}
+	if RoleAllows(RoleGuest, RoleOperator) || RoleAllows("", RoleGuest) || RoleAllows("root", RoleGuest) {
+		t.Error("Expected lower and unknown roles to be refused")
+	}
+}
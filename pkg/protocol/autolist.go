@@ -0,0 +1,58 @@
+package protocol
+
+import "strings"
+
+// CmdAutoList lists, adds or deletes the callsigns automatic functions
+// ignore or are kept to: AUTOLIST, AUTOLIST:block:<call>[:<note>],
+// AUTOLIST:allow:<call>[:<note>] or AUTOLIST:delete:<id>. A call ending in
+// * is a prefix.
+const CmdAutoList = "AUTOLIST"
+
+// AUTOLIST command actions
+const (
+	AutoListList   = "list"
+	AutoListBlock  = "block" // Never answer the station automatically
+	AutoListAllow  = "allow" // Answer only allowed stations while any are
+	AutoListDelete = "delete"
+)
+
+// AutoListAction returns what an AUTOLIST command does, listing when no
+// action is given
+func (c *Command) AutoListAction() string {
+	if action := strings.ToLower(c.StringArg("action")); action != "" {
+		return action
+	}
+	return AutoListList
+}
+
+// parseAutoListArgs reads the arguments of a version 1 AUTOLIST line
+func parseAutoListArgs(cmd *Command, args string) {
+	action, rest, _ := strings.Cut(args, ":")
+	cmd.Args["action"] = strings.ToLower(action)
+	switch cmd.Args["action"] {
+	case AutoListBlock, AutoListAllow:
+		callsign, note, _ := strings.Cut(rest, ":")
+		cmd.Args["callsign"] = callsign
+		cmd.Args["note"] = note
+	default:
+		cmd.Args["id"] = rest
+	}
+}
+
+// autoListLine formats the arguments of an AUTOLIST command as a version 1
+// line
+func autoListLine(cmd *Command) string {
+	action := cmd.AutoListAction()
+	switch action {
+	case AutoListList:
+		return ""
+	case AutoListBlock, AutoListAllow:
+		line := action + ":" + cmd.StringArg("callsign")
+		if note := cmd.StringArg("note"); note != "" {
+			line += ":" + note
+		}
+		return line
+	default:
+		return action + ":" + cmd.StringArg("id")
+	}
+}
@@ -0,0 +1,220 @@
+package protocol
+
+import (
+	"fmt"
+	"strings"
+)
+
+// edges are a band's low and high edges in Hz, zero where a region has no
+// allocation. They are int64 so the microwave bands fit on 32-bit builds,
+// which can't tune to them.
+type edges struct {
+	low, high int64
+}
+
+// band is an amateur band and its edges in ITU regions 1, 2 and 3
+type band struct {
+	name    string
+	regions [3]edges
+}
+
+// widest returns the lowest and highest edges over every region
+func (b band) widest() (int64, int64) {
+	var low, high int64
+	for _, r := range b.regions {
+		if r.high == 0 {
+			continue
+		}
+		if low == 0 || r.low < low {
+			low = r.low
+		}
+		if r.high > high {
+			high = r.high
+		}
+	}
+	return low, high
+}
+
+// bands is the band plan from 2200m to 3cm, the edges the ITU allocates
+// each region with the widest national allocations where they differ much
+var bands = []band{
+	{"2200m", [3]edges{{135700, 137800}, {135700, 137800}, {135700, 137800}}},
+	{"630m", [3]edges{{472000, 479000}, {472000, 479000}, {472000, 479000}}},
+	{"160m", [3]edges{{1810000, 2000000}, {1800000, 2000000}, {1800000, 2000000}}},
+	{"80m", [3]edges{{3500000, 3800000}, {3500000, 4000000}, {3500000, 3900000}}},
+	{"60m", [3]edges{{5351500, 5366500}, {5250000, 5450000}, {5351500, 5366500}}},
+	{"40m", [3]edges{{7000000, 7200000}, {7000000, 7300000}, {7000000, 7200000}}},
+	{"30m", [3]edges{{10100000, 10150000}, {10100000, 10150000}, {10100000, 10150000}}},
+	{"20m", [3]edges{{14000000, 14350000}, {14000000, 14350000}, {14000000, 14350000}}},
+	{"17m", [3]edges{{18068000, 18168000}, {18068000, 18168000}, {18068000, 18168000}}},
+	{"15m", [3]edges{{21000000, 21450000}, {21000000, 21450000}, {21000000, 21450000}}},
+	{"12m", [3]edges{{24890000, 24990000}, {24890000, 24990000}, {24890000, 24990000}}},
+	{"10m", [3]edges{{28000000, 29700000}, {28000000, 29700000}, {28000000, 29700000}}},
+	{"6m", [3]edges{{50000000, 52000000}, {50000000, 54000000}, {50000000, 54000000}}},
+	{"4m", [3]edges{{69900000, 70500000}, {}, {}}},
+	{"2m", [3]edges{{144000000, 146000000}, {144000000, 148000000}, {144000000, 148000000}}},
+	{"1.25m", [3]edges{{}, {220000000, 225000000}, {}}},
+	{"70cm", [3]edges{{430000000, 440000000}, {420000000, 450000000}, {430000000, 440000000}}},
+	{"23cm", [3]edges{{1240000000, 1300000000}, {1240000000, 1300000000}, {1240000000, 1300000000}}},
+	{"13cm", [3]edges{{2300000000, 2450000000}, {2300000000, 2450000000}, {2300000000, 2450000000}}},
+	{"9cm", [3]edges{{3400000000, 3475000000}, {3300000000, 3500000000}, {3300000000, 3500000000}}},
+	{"6cm", [3]edges{{5650000000, 5850000000}, {5650000000, 5925000000}, {5650000000, 5850000000}}},
+	{"3cm", [3]edges{{10000000000, 10500000000}, {10000000000, 10500000000}, {10000000000, 10500000000}}},
+}
+
+// Band names the amateur band a frequency in Hz falls in, in any region,
+// or "" outside them
+func Band(frequency int) string {
+	for _, b := range bands {
+		if low, high := b.widest(); int64(frequency) >= low && int64(frequency) <= high {
+			return b.name
+		}
+	}
+	return ""
+}
+
+// ValidBand reports whether name is one of the bands Band returns, like 20m
+func ValidBand(name string) bool {
+	for _, b := range bands {
+		if strings.EqualFold(name, b.name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allocation is a band's edges in one ITU region
+type Allocation struct {
+	Band string `json:"band"`
+	Low  int64  `json:"low"`  // Hz
+	High int64  `json:"high"` // Hz
+}
+
+// BandPlan lists the bands allocated in ITU region 1, 2 or 3, lowest first
+func BandPlan(region int) []Allocation {
+	if region < 1 || region > 3 {
+		return nil
+	}
+	var plan []Allocation
+	for _, b := range bands {
+		if r := b.regions[region-1]; r.high != 0 {
+			plan = append(plan, Allocation{Band: b.name, Low: r.low, High: r.high})
+		}
+	}
+	return plan
+}
+
+// License classes with data privileges limited to parts of the bands, the
+// FCC's in the US
+const (
+	LicenseTechnician = "technician"
+	LicenseGeneral    = "general"
+	LicenseAdvanced   = "advanced"
+	LicenseExtra      = "extra"
+)
+
+// LicenseClasses lists the license classes CheckBandPlan knows, lowest first
+var LicenseClasses = []string{LicenseTechnician, LicenseGeneral, LicenseAdvanced, LicenseExtra}
+
+// vhfAndUp covers every band from 6m up, open to data on every class
+var vhfAndUp = edges{50000000, 10500000000}
+
+// licenseSegments are where each class may send data. Bands a class has no
+// segment in are closed to it.
+var licenseSegments = map[string][]edges{
+	LicenseTechnician: {
+		{28000000, 28300000},
+		vhfAndUp,
+	},
+	LicenseGeneral: {
+		{135700, 137800}, {472000, 479000}, {1800000, 2000000},
+		{3525000, 3600000}, {5330500, 5406400}, {7025000, 7125000},
+		{10100000, 10150000}, {14025000, 14150000}, {18068000, 18110000},
+		{21025000, 21200000}, {24890000, 24930000}, {28000000, 28300000},
+		vhfAndUp,
+	},
+	LicenseAdvanced: {
+		{135700, 137800}, {472000, 479000}, {1800000, 2000000},
+		{3525000, 3600000}, {5330500, 5406400}, {7025000, 7125000},
+		{10100000, 10150000}, {14025000, 14150000}, {18068000, 18110000},
+		{21025000, 21200000}, {24890000, 24930000}, {28000000, 28300000},
+		vhfAndUp,
+	},
+	LicenseExtra: {
+		{135700, 137800}, {472000, 479000}, {1800000, 2000000},
+		{3500000, 3600000}, {5330500, 5406400}, {7000000, 7125000},
+		{10100000, 10150000}, {14000000, 14150000}, {18068000, 18110000},
+		{21000000, 21200000}, {24890000, 24930000}, {28000000, 28300000},
+		vhfAndUp,
+	},
+}
+
+// ValidLicense reports whether class is one of LicenseClasses, or "" for
+// none
+func ValidLicense(class string) bool {
+	if class == "" {
+		return true
+	}
+	_, ok := licenseSegments[strings.ToLower(class)]
+	return ok
+}
+
+// BandCheck is where a signal falls in a region's band plan
+type BandCheck struct {
+	Low       int    `json:"low"`            // Signal's lowest RF in Hz
+	High      int    `json:"high"`           // Signal's highest RF in Hz
+	Band      string `json:"band,omitempty"` // Band the signal is in, in any region
+	Region    int    `json:"region"`
+	License   string `json:"license,omitempty"`
+	Allocated bool   `json:"allocated"`         // The whole signal is inside the region's allocation
+	Licensed  bool   `json:"licensed"`          // The whole signal is inside the class's data privileges, true without a class
+	Warning   string `json:"warning,omitempty"` // Why the signal is outside the band plan
+}
+
+// OK reports whether a signal may be sent where it was checked
+func (c BandCheck) OK() bool {
+	return c.Allocated && c.Licensed
+}
+
+// CheckBandPlan checks a signal from low to high Hz against the amateur
+// allocations of ITU region 1, 2 or 3 and, unless class is "", the data
+// privileges of that license class
+func CheckBandPlan(low, high, region int, class string) BandCheck {
+	class = strings.ToLower(class)
+	check := BandCheck{Low: low, High: high, Band: Band(low), Region: region, License: class}
+	if region < 1 || region > 3 {
+		check.Warning = fmt.Sprintf("unknown ITU region %d", region)
+		return check
+	}
+
+	for _, b := range bands {
+		r := b.regions[region-1]
+		if r.high != 0 && int64(low) >= r.low && int64(high) <= r.high {
+			check.Allocated = true
+			check.Band = b.name
+		}
+	}
+	if !check.Allocated {
+		if check.Band == "" {
+			check.Warning = fmt.Sprintf("%.3f kHz is outside every amateur band", float64(low)/1000)
+		} else {
+			check.Warning = fmt.Sprintf("%.3f kHz is outside the %s allocation in ITU region %d", float64(low)/1000, check.Band, region)
+		}
+		return check
+	}
+
+	segments, ok := licenseSegments[class]
+	if !ok {
+		check.Licensed = true
+		return check
+	}
+	for _, s := range segments {
+		if int64(low) >= s.low && int64(high) <= s.high {
+			check.Licensed = true
+		}
+	}
+	if !check.Licensed {
+		check.Warning = fmt.Sprintf("%.3f kHz is outside the %s class data privileges on %s", float64(low)/1000, class, check.Band)
+	}
+	return check
+}
@@ -0,0 +1,107 @@
+package protocol
+
+import "testing"
+
+func TestBand(t *testing.T) {
+	tests := map[int]string{
+		14078000:  "20m",
+		7078000:   "40m",
+		3578000:   "80m",
+		50318000:  "6m",
+		144178000: "2m",
+		136000:    "2200m",
+		475000:    "630m",
+		70100000:  "4m",
+		222000000: "1.25m",
+		432000000: "70cm",
+		1296200000: "23cm",
+		14500000:  "",
+		0:         "",
+	}
+	for frequency, want := range tests {
+		if got := Band(frequency); got != want {
+			t.Errorf("Band(%d) = %q, want %q", frequency, got, want)
+		}
+	}
+}
+
+func TestValidBand(t *testing.T) {
+	for _, name := range []string{"20m", "160M", "2m"} {
+		if !ValidBand(name) {
+			t.Errorf("Expected %q to be a band", name)
+		}
+	}
+	for _, name := range []string{"", "20", "11m"} {
+		if ValidBand(name) {
+			t.Errorf("Expected %q not to be a band", name)
+		}
+	}
+}
+
+func TestCheckBandPlan(t *testing.T) {
+	tests := []struct {
+		low, region int
+		class       string
+		allocated   bool
+		licensed    bool
+		band        string
+	}{
+		{14078000, 2, "", true, true, "20m"},
+		{14078000, 2, LicenseGeneral, true, true, "20m"},
+		{14078000, 2, LicenseTechnician, true, false, "20m"},
+		{28078000, 2, LicenseTechnician, true, true, "10m"},
+		{14010000, 2, LicenseGeneral, true, false, "20m"},
+		{14010000, 2, LicenseExtra, true, true, "20m"},
+		{7250000, 2, "", true, true, "40m"},
+		{7250000, 1, "", false, false, "40m"},
+		{3900000, 3, "", false, false, "80m"},
+		{70100000, 1, "", true, true, "4m"},
+		{70100000, 2, "", false, false, "4m"},
+		{222100000, 2, LicenseTechnician, true, true, "1.25m"},
+		{1296200000, 1, LicenseTechnician, true, true, "23cm"},
+		{11000000, 2, "", false, false, ""},
+	}
+	for _, tt := range tests {
+		check := CheckBandPlan(tt.low, tt.low+50, tt.region, tt.class)
+		if check.Allocated != tt.allocated || check.Licensed != tt.licensed || check.Band != tt.band {
+			t.Errorf("CheckBandPlan(%d, region %d, %q) = %+v", tt.low, tt.region, tt.class, check)
+		}
+		if check.OK() == (check.Warning != "") {
+			t.Errorf("CheckBandPlan(%d, region %d, %q) warning %q with OK %v", tt.low, tt.region, tt.class, check.Warning, check.OK())
+		}
+	}
+
+	// A signal straddling a band edge is outside it
+	if check := CheckBandPlan(14349980, 14350030, 2, ""); check.Allocated {
+		t.Errorf("Expected a signal over the 20m edge to be outside it, got %+v", check)
+	}
+	if check := CheckBandPlan(14078000, 14078050, 4, ""); check.OK() || check.Warning == "" {
+		t.Errorf("Expected an unknown region to be refused, got %+v", check)
+	}
+}
+
+func TestBandPlan(t *testing.T) {
+	plan := BandPlan(1)
+	if len(plan) == 0 || plan[0].Band != "2200m" {
+		t.Fatalf("Expected region 1's plan to start at 2200m, got %+v", plan)
+	}
+	for _, a := range plan {
+		if a.Band == "1.25m" {
+			t.Error("Expected no 1.25m allocation in region 1")
+		}
+	}
+	if BandPlan(0) != nil {
+		t.Error("Expected no plan for an unknown region")
+	}
+}
+
+func TestValidLicense(t *testing.T) {
+	for _, class := range []string{"", "technician", "General", "extra"} {
+		if !ValidLicense(class) {
+			t.Errorf("Expected %q to be a license class", class)
+		}
+	}
+	if ValidLicense("novice") {
+		t.Error("Expected novice not to be a license class")
+	}
+}
@@ -0,0 +1,6 @@
+package protocol
+
+// CmdBeacon shows when the next beacon and station ID are due, turns
+// beacons on or off with BEACON ON or BEACON OFF until js8d restarts, or
+// sends one now with BEACON NOW
+const CmdBeacon = "BEACON"
@@ -0,0 +1,14 @@
+package protocol
+
+// EventNewEntity is pushed when a station is heard from a DXCC entity no
+// station was heard from before
+const EventNewEntity = "new_entity"
+
+// Entity is the DXCC entity a station is in, from its callsign prefix
+type Entity struct {
+	Name      string `json:"name"`
+	Prefix    string `json:"prefix"`    // Primary prefix, e.g. DL
+	Continent string `json:"continent"` // AF, AN, AS, EU, NA, OC or SA
+	CQZone    int    `json:"cq_zone"`
+	ITUZone   int    `json:"itu_zone"`
+}
@@ -0,0 +1,36 @@
+package protocol
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types pushed to clients subscribed with EVENTS or the /ws/messages
+// WebSocket
+const (
+	EventMessage       = "message"        // A message was received or queued for TX
+	EventMessageStatus = "message_status" // A TX message's status or delivery changed
+	EventMessagesRead  = "messages_read"  // A conversation was marked read
+	EventConversation  = "conversation"   // The first message from a new station arrived
+	EventChannelBusy   = "channel_busy"   // The TX offset was busy before keying
+	EventBandPlan      = "band_plan"      // A transmission was outside the band plan or license class
+	EventSelfDecode    = "self_decode"    // One of our own transmissions was decoded off the rig's monitor audio
+)
+
+// Event is a change to the message store that other clients should see
+type Event struct {
+	Type string                 `json:"type"`
+	Time time.Time              `json:"time"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// NewEvent creates an event stamped with the current time
+func NewEvent(eventType string, data map[string]interface{}) Event {
+	return Event{Type: eventType, Time: time.Now(), Data: data}
+}
+
+// String converts an event to a JSON line for version 1 socket clients
+func (e Event) String() string {
+	data, _ := json.Marshal(e)
+	return string(data)
+}
@@ -0,0 +1,75 @@
+package protocol
+
+import (
+	"strings"
+)
+
+// CmdFile lists, fetches, sends or cancels file transfers: FILE,
+// FILE:get:<id>, FILE:send:<to>:<name>:<content as base64> or
+// FILE:cancel:<id>
+const CmdFile = "FILE"
+
+// FILE command actions
+const (
+	FileList   = "list"
+	FileGet    = "get"
+	FileSend   = "send"
+	FileCancel = "cancel"
+)
+
+// File transfer statuses
+const (
+	FileSending   = "sending"   // data frames are queued for transmission
+	FilePolling   = "polling"   // waiting for the receiver to answer a poll
+	FileReceiving = "receiving" // data frames are coming in
+	FileComplete  = "complete"
+	FileFailed    = "failed"
+	FileCancelled = "cancelled"
+)
+
+// EventFile is published as a file transfer makes progress, with the
+// transfer and its frames done so far
+const EventFile = "file"
+
+// FileAction returns what a FILE command does, listing when no action is
+// given
+func (c *Command) FileAction() string {
+	if action := strings.ToLower(c.StringArg("action")); action != "" {
+		return action
+	}
+	return FileList
+}
+
+// parseFileArgs reads the arguments of a version 1 FILE line
+func parseFileArgs(cmd *Command, args string) {
+	action, rest, _ := strings.Cut(args, ":")
+	cmd.Args["action"] = strings.ToLower(action)
+	switch cmd.Args["action"] {
+	case FileSend:
+		parts := strings.SplitN(rest, ":", 3)
+		cmd.Args["to"] = parts[0]
+		if len(parts) > 1 {
+			cmd.Args["name"] = parts[1]
+		}
+		if len(parts) > 2 {
+			cmd.Args["content"] = parts[2]
+		}
+	case FileGet, FileCancel:
+		cmd.Args["id"] = rest
+	}
+}
+
+// fileLine formats the arguments of a FILE command as a version 1 line
+func fileLine(cmd *Command) string {
+	action := cmd.FileAction()
+	switch action {
+	case FileSend:
+		return action + ":" + cmd.StringArg("to") + ":" + cmd.StringArg("name") + ":" + cmd.StringArg("content")
+	case FileGet, FileCancel:
+		return action + ":" + cmd.StringArg("id")
+	case FileList:
+		return ""
+	default:
+		return action
+	}
+}
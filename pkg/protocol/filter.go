@@ -0,0 +1,84 @@
+package protocol
+
+import "strings"
+
+// CmdFilter lists, adds or deletes the rules that drop decodes before they
+// are stored or answered: FILTER, FILTER:add:callsign:<call>[:<note>],
+// FILTER:add:offset:<Hz>[:<width Hz>[:<note>]] or FILTER:delete:<id>
+const CmdFilter = "FILTER"
+
+// FILTER command actions
+const (
+	FilterList   = "list"
+	FilterAdd    = "add"
+	FilterDelete = "delete"
+)
+
+// Kinds of filter
+const (
+	FilterCallsign = "callsign" // Drops every decode from a station
+	FilterOffset   = "offset"   // Drops decodes near an audio offset, such as a local birdie
+)
+
+// DefaultFilterWidth is how many Hz either side of an offset filter decodes
+// are dropped when no width is given
+const DefaultFilterWidth = 10
+
+// FilterAction returns what a FILTER command does, listing when no action
+// is given
+func (c *Command) FilterAction() string {
+	if action := strings.ToLower(c.StringArg("action")); action != "" {
+		return action
+	}
+	return FilterList
+}
+
+// parseFilterArgs reads the arguments of a version 1 FILTER line
+func parseFilterArgs(cmd *Command, args string) {
+	action, rest, _ := strings.Cut(args, ":")
+	cmd.Args["action"] = strings.ToLower(action)
+	switch cmd.Args["action"] {
+	case FilterAdd:
+		kind, rest, _ := strings.Cut(rest, ":")
+		cmd.Args["type"] = strings.ToLower(kind)
+		if strings.ToLower(kind) == FilterOffset {
+			offset, rest, _ := strings.Cut(rest, ":")
+			width, note, _ := strings.Cut(rest, ":")
+			cmd.Args["offset"] = offset
+			cmd.Args["width"] = width
+			cmd.Args["note"] = note
+		} else {
+			callsign, note, _ := strings.Cut(rest, ":")
+			cmd.Args["callsign"] = callsign
+			cmd.Args["note"] = note
+		}
+	default:
+		cmd.Args["id"] = rest
+	}
+}
+
+// filterLine formats the arguments of a FILTER command as a version 1 line
+func filterLine(cmd *Command) string {
+	action := cmd.FilterAction()
+	switch action {
+	case FilterList:
+		return ""
+	case FilterAdd:
+		parts := []string{action, cmd.StringArg("type")}
+		note := cmd.StringArg("note")
+		if strings.ToLower(cmd.StringArg("type")) == FilterOffset {
+			parts = append(parts, cmd.StringArg("offset"))
+			if width := cmd.StringArg("width"); width != "" || note != "" {
+				parts = append(parts, width)
+			}
+		} else {
+			parts = append(parts, cmd.StringArg("callsign"))
+		}
+		if note != "" {
+			parts = append(parts, note)
+		}
+		return strings.Join(parts, ":")
+	default:
+		return action + ":" + cmd.StringArg("id")
+	}
+}
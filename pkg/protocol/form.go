@@ -0,0 +1,98 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CmdForm lists the forms sent and received, lists the form templates or
+// sends a form: FORM, FORM:list:<rx|tx>, FORM:templates or
+// FORM:send:<template>:<to>:<values as a JSON object>
+const CmdForm = "FORM"
+
+// FORM command actions
+const (
+	FormList      = "list"
+	FormTemplates = "templates"
+	FormSend      = "send"
+)
+
+// EventForm is published when a form has been sent or received in full,
+// with the stored form
+const EventForm = "form"
+
+// FormAction returns what a FORM command does, listing when no action is
+// given
+func (c *Command) FormAction() string {
+	if action := strings.ToLower(c.StringArg("action")); action != "" {
+		return action
+	}
+	return FormList
+}
+
+// FormValues returns the field values a FORM send command fills in, given
+// either as an object or as JSON text
+func (c *Command) FormValues() (map[string]string, error) {
+	values := make(map[string]string)
+	switch v := c.Args["values"].(type) {
+	case nil:
+	case string:
+		if strings.TrimSpace(v) == "" {
+			break
+		}
+		if err := json.Unmarshal([]byte(v), &values); err != nil {
+			return nil, err
+		}
+	case map[string]string:
+		for name, text := range v {
+			values[name] = text
+		}
+	case map[string]interface{}:
+		for name, text := range v {
+			if s, ok := text.(string); ok {
+				values[name] = s
+			} else if text != nil {
+				values[name] = fmt.Sprint(text)
+			}
+		}
+	}
+	return values, nil
+}
+
+// parseFormArgs reads the arguments of a version 1 FORM line
+func parseFormArgs(cmd *Command, args string) {
+	action, rest, _ := strings.Cut(args, ":")
+	cmd.Args["action"] = strings.ToLower(action)
+	switch cmd.Args["action"] {
+	case FormSend:
+		parts := strings.SplitN(rest, ":", 3)
+		cmd.Args["template"] = parts[0]
+		if len(parts) > 1 {
+			cmd.Args["to"] = parts[1]
+		}
+		if len(parts) > 2 {
+			cmd.Args["values"] = parts[2]
+		}
+	case FormList:
+		cmd.Args["direction"] = rest
+	}
+}
+
+// formLine formats the arguments of a FORM command as a version 1 line
+func formLine(cmd *Command) string {
+	action := cmd.FormAction()
+	switch action {
+	case FormSend:
+		values, _ := cmd.FormValues()
+		data, _ := json.Marshal(values)
+		return action + ":" + cmd.StringArg("template") + ":" + cmd.StringArg("to") + ":" + string(data)
+	case FormList:
+		if direction := cmd.StringArg("direction"); direction != "" {
+			return action + ":" + direction
+		}
+		return ""
+	default:
+		return action
+	}
+}
@@ -0,0 +1,233 @@
+package protocol
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Socket protocol versions. Version 1 is one text command per line with a
+// JSON response line. Version 2 sends JSON commands and responses in frames
+// prefixed with their length as a 4 byte big endian integer, so messages may
+// contain newlines, and starts with a HELLO handshake agreeing on a version.
+const (
+	Version1       = 1
+	Version2       = 2
+	CurrentVersion = Version2
+)
+
+// CmdHello opens a version 2 connection
+const CmdHello = "HELLO"
+
+// MaxFrameSize bounds a frame so a bad length can't exhaust memory. It keeps
+// the first byte of every length prefix zero, which never starts a v1 line.
+const MaxFrameSize = 1 << 20
+
+// ErrFrameTooLarge is returned by WriteFrame for a value whose JSON is over
+// MaxFrameSize, before anything is written
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// IsFramed reports whether a connection starting with this byte speaks
+// version 2. Version 1 commands start with a letter.
+func IsFramed(first byte) bool {
+	return first == 0
+}
+
+// WriteFrame sends v as one length-prefixed JSON frame
+func WriteFrame(w io.Writer, v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode frame: %w", err)
+	}
+	if len(payload) > MaxFrameSize {
+		return fmt.Errorf("%w: %d bytes exceeds the %d byte limit", ErrFrameTooLarge, len(payload), MaxFrameSize)
+	}
+
+	frame := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	copy(frame[4:], payload)
+	_, err = w.Write(frame)
+	return err
+}
+
+// WriteResponse sends a response as one frame. A response too large for a
+// frame is answered with a TOO_LARGE error instead, so the client learns why
+// rather than seeing the connection close.
+func WriteResponse(w io.Writer, resp *Response) error {
+	err := WriteFrame(w, resp)
+	if errors.Is(err, ErrFrameTooLarge) {
+		return WriteFrame(w, NewCodedErrorResponse(ErrCodeTooLarge, fmt.Sprintf("response %v; ask for less", err)))
+	}
+	return err
+}
+
+// ReadFrame reads one length-prefixed JSON frame into v
+func ReadFrame(r io.Reader, v interface{}) error {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > MaxFrameSize {
+		return fmt.Errorf("frame of %d bytes exceeds the %d byte limit", size, MaxFrameSize)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("decode frame: %w", err)
+	}
+	return nil
+}
+
+// NewHello creates the handshake command offering every supported version,
+// presenting token if it isn't empty
+func NewHello(client, token string) *Command {
+	hello := &Command{
+		Type: CmdHello,
+		Args: map[string]interface{}{
+			"versions": []int{Version2},
+			"client":   client,
+		},
+	}
+	if token != "" {
+		hello.Args["token"] = token
+	}
+	return hello
+}
+
+// WriteHello sends the handshake. The frame is followed by a newline so a
+// server that only speaks version 1 answers with an error line at once
+// instead of waiting for the rest of a line.
+func WriteHello(w io.Writer, client, token string) error {
+	var buf strings.Builder
+	if err := WriteFrame(&buf, NewHello(client, token)); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// ReadHello reads the handshake on a framed connection and returns the
+// version to use and the token presented, or an error response to send back
+// before closing
+func ReadHello(r *bufio.Reader) (int, string, *Response) {
+	var hello Command
+	if err := ReadFrame(r, &hello); err != nil {
+		return 0, "", NewErrorResponse(fmt.Sprintf("bad handshake: %v", err))
+	}
+	if b, err := r.ReadByte(); err != nil || b != '\n' {
+		return 0, "", NewErrorResponse("bad handshake: expected newline after HELLO frame")
+	}
+	if strings.ToUpper(hello.Type) != CmdHello {
+		return 0, "", NewErrorResponse(fmt.Sprintf("expected %s, got %s", CmdHello, hello.Type))
+	}
+
+	offered, _ := hello.Args["versions"].([]interface{})
+	version := 0
+	for _, v := range offered {
+		if n, ok := v.(float64); ok && int(n) == Version2 {
+			version = Version2
+		}
+	}
+	if version == 0 {
+		return 0, "", NewErrorResponse(fmt.Sprintf("no common protocol version, server supports %d and %d", Version1, Version2))
+	}
+	return version, hello.StringArg("token"), nil
+}
+
+// NewHelloResponse acknowledges the handshake
+func NewHelloResponse(version int, server string) *Response {
+	return NewSuccessResponse(map[string]interface{}{
+		"version": version,
+		"server":  server,
+	})
+}
+
+// StringArg returns an argument as a string, whether it arrived as text on a
+// version 1 line or as a JSON number or bool in a version 2 frame
+func (c *Command) StringArg(key string) string {
+	switch v := c.Args[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Line formats the command as a version 1 text line
+func (c *Command) Line() (string, error) {
+	var args string
+	switch c.Type {
+	case CmdSend:
+		args = strings.TrimSpace(c.StringArg("to") + " " + c.StringArg("message"))
+		if power := c.StringArg("power"); power != "" && power != "0" {
+			args = "PWR=" + power + " " + args
+		}
+	case CmdMessages:
+		args = c.StringArg("limit")
+		if since := c.StringArg("since"); since != "" {
+			args = "since:" + since
+		}
+	case CmdFrequency:
+		args = c.StringArg("frequency")
+	case CmdProfile:
+		args = c.StringArg("name")
+	case CmdLogLevel:
+		args = c.StringArg("level")
+		if component := c.StringArg("component"); component != "" {
+			args = component + ":" + args
+		}
+	case CmdAuth:
+		args = c.StringArg("token")
+	case CmdRaw:
+		args = c.StringArg("id")
+	case CmdRedecode:
+		args = c.StringArg("range")
+	case CmdStation:
+		var err error
+		if args, err = stationLine(c); err != nil {
+			return "", err
+		}
+	case CmdMacro:
+		args = macroLine(c)
+	case CmdFilter:
+		args = filterLine(c)
+	case CmdAutoList:
+		args = autoListLine(c)
+	case CmdForm:
+		args = formLine(c)
+	case CmdFile:
+		args = fileLine(c)
+	case CmdSession:
+		args = sessionLine(c)
+	case CmdConfig:
+		args = c.StringArg("action")
+		for _, key := range []string{"key", "value"} {
+			if value := c.StringArg(key); value != "" {
+				args += ":" + value
+			}
+		}
+	}
+
+	line := c.Type
+	if args != "" {
+		line += ":" + args
+	}
+	if strings.ContainsAny(line, "\r\n") {
+		return "", fmt.Errorf("%s command contains a newline, which needs protocol version %d", c.Type, Version2)
+	}
+	return line, nil
+}
@@ -0,0 +1,173 @@
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &Command{
+		Type: CmdSend,
+		Args: map[string]interface{}{"to": "N0ABC", "message": "LINE ONE\nLINE TWO"},
+	}
+	if err := WriteFrame(&buf, cmd); err != nil {
+		t.Fatalf("WriteFrame failed: %v", err)
+	}
+	if !IsFramed(buf.Bytes()[0]) {
+		t.Error("Expected a frame to start with a zero byte")
+	}
+
+	var got Command
+	if err := ReadFrame(&buf, &got); err != nil {
+		t.Fatalf("ReadFrame failed: %v", err)
+	}
+	if got.Type != CmdSend || got.StringArg("message") != "LINE ONE\nLINE TWO" {
+		t.Errorf("Expected the newline to survive, got %+v", got)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected the whole frame consumed, %d bytes left", buf.Len())
+	}
+}
+
+func TestReadFrameTooLarge(t *testing.T) {
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], MaxFrameSize+1)
+
+	var cmd Command
+	err := ReadFrame(bytes.NewReader(header[:]), &cmd)
+	if err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("Expected size limit error, got %v", err)
+	}
+}
+
+func TestWriteResponseTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	resp := NewSuccessResponse(map[string]interface{}{"log": strings.Repeat("x", MaxFrameSize)})
+	if err := WriteFrame(&buf, resp); !errors.Is(err, ErrFrameTooLarge) || buf.Len() != 0 {
+		t.Fatalf("Expected ErrFrameTooLarge with nothing written, got %v (%d bytes)", err, buf.Len())
+	}
+
+	// The client is told why instead
+	if err := WriteResponse(&buf, resp); err != nil {
+		t.Fatalf("WriteResponse failed: %v", err)
+	}
+	var got Response
+	if err := ReadFrame(&buf, &got); err != nil {
+		t.Fatalf("ReadFrame failed: %v", err)
+	}
+	if got.Success || got.Code != ErrCodeTooLarge {
+		t.Errorf("Expected a TOO_LARGE error, got %+v", got)
+	}
+}
+
+func TestHello(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHello(&buf, "test", "s3cret"); err != nil {
+		t.Fatalf("WriteHello failed: %v", err)
+	}
+	version, token, failure := ReadHello(bufio.NewReader(&buf))
+	if failure != nil || version != Version2 || token != "s3cret" {
+		t.Errorf("Expected version 2 with the token, got %d, %q (%v)", version, token, failure)
+	}
+
+	tests := []struct {
+		name  string
+		frame *Command
+		want  string
+	}{
+		{"Not Hello", &Command{Type: CmdStatus}, "expected HELLO"},
+		{"No Common Version", &Command{Type: CmdHello, Args: map[string]interface{}{"versions": []int{3}}}, "no common protocol version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			WriteFrame(&buf, tt.frame)
+			buf.WriteByte('\n')
+			_, _, failure := ReadHello(bufio.NewReader(&buf))
+			if failure == nil || !strings.Contains(failure.Error, tt.want) {
+				t.Errorf("Expected %q error, got %v", tt.want, failure)
+			}
+		})
+	}
+}
+
+func TestStringArg(t *testing.T) {
+	cmd := &Command{Args: map[string]interface{}{
+		"text":   "14078000",
+		"number": float64(14078000),
+		"flag":   true,
+	}}
+
+	for key, want := range map[string]string{"text": "14078000", "number": "14078000", "flag": "true", "missing": ""} {
+		if got := cmd.StringArg(key); got != want {
+			t.Errorf("StringArg(%s): expected %q, got %q", key, want, got)
+		}
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"STATUS", "STATUS"},
+		{"SEND:N0ABC Hello there", "SEND:N0ABC Hello there"},
+		{"SEND:PWR=10 N0ABC Hello there", "SEND:PWR=10 N0ABC Hello there"},
+		{"SEND:pwr=25 CQ CQ", "SEND:PWR=25 CQ CQ"},
+		{"MESSAGES:10", "MESSAGES:10"},
+		{"FREQUENCY:14078000", "FREQUENCY:14078000"},
+		{"CONFIG:set:callsign:K3DEP", "CONFIG:set:callsign:K3DEP"},
+		{"PROFILE:vhf", "PROFILE:vhf"},
+		{"LOGLEVEL:hardware:debug", "LOGLEVEL:hardware:debug"},
+		{"STATION:N0ABC", "STATION:N0ABC"},
+		{"STATION:N0ABC:notes:Op Bob: QRP", "STATION:N0ABC:notes:Op Bob: QRP"},
+		{"MACRO", "MACRO"},
+		{"MACRO:delete:CQ", "MACRO:delete:CQ"},
+		{"MACRO:set:CQ:CQ CQ {MYCALL}", "MACRO:set:CQ:CQ CQ {MYCALL}"},
+		{"MACRO:expand:N0ABC:{CALL} {SNR}", "MACRO:expand:N0ABC:{CALL} {SNR}"},
+		{"FILTER", "FILTER"},
+		{"FILTER:add:callsign:N0ABC:Stuck beacon: ignore", "FILTER:add:callsign:N0ABC:Stuck beacon: ignore"},
+		{"FILTER:add:offset:1500", "FILTER:add:offset:1500"},
+		{"FILTER:add:offset:1500::birdie", "FILTER:add:offset:1500::birdie"},
+		{"FILTER:delete:3", "FILTER:delete:3"},
+		{"AUTOLIST", "AUTOLIST"},
+		{"AUTOLIST:block:N0ABC:Keeps calling: ignore", "AUTOLIST:block:N0ABC:Keeps calling: ignore"},
+		{"AUTOLIST:allow:K3*", "AUTOLIST:allow:K3*"},
+		{"AUTOLIST:delete:3", "AUTOLIST:delete:3"},
+		{"FORM", "FORM"},
+		{"FORM:list:rx", "FORM:list:rx"},
+		{"FORM:templates", "FORM:templates"},
+		{`FORM:send:ICS213:W1AW:{"subject":"Supplies: cots"}`, `FORM:send:ICS213:W1AW:{"subject":"Supplies: cots"}`},
+		{"FILE", "FILE"},
+		{"FILE:get:3", "FILE:get:3"},
+		{"SESSION:send:2:Net at 19:00Z", "SESSION:send:2:Net at 19:00Z"},
+		{"FILE:send:W1AW:notes.txt:aGVsbG8=", "FILE:send:W1AW:notes.txt:aGVsbG8="},
+		{"RAW:42", "RAW:42"},
+		{"REDECODE", "REDECODE"},
+		{"REDECODE:2024-01-15T10:00:00Z/2024-01-15T11:00:00Z", "REDECODE:2024-01-15T10:00:00Z/2024-01-15T11:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		cmd, _ := ParseCommand(tt.line)
+		got, err := cmd.Line()
+		if err != nil || got != tt.want {
+			t.Errorf("Expected %q, got %q (%v)", tt.want, got, err)
+		}
+	}
+
+	cmd := &Command{Type: CmdSend, Args: map[string]interface{}{"message": "TWO\nLINES"}}
+	if _, err := cmd.Line(); err == nil {
+		t.Error("Expected error for a newline on a version 1 line")
+	}
+
+	cmd = &Command{Type: CmdStation, Args: map[string]interface{}{"callsign": "N0ABC", "name": "Bob", "qth": "Denver"}}
+	if _, err := cmd.Line(); err == nil {
+		t.Error("Expected error for several station edits on a version 1 line")
+	}
+}
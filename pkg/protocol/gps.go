@@ -0,0 +1,17 @@
+package protocol
+
+import "time"
+
+// EventGrid is published when the station grid changes to follow the GPS
+// position, with the old and new grid
+const EventGrid = "grid"
+
+// GPSStatus is the latest report from gpsd
+type GPSStatus struct {
+	Connected bool       `json:"connected"`
+	Mode      int        `json:"mode"`           // 0 unknown, 1 no fix, 2 for 2D and 3 for 3D fixes
+	Lat       float64    `json:"lat,omitempty"`  // Degrees north
+	Lon       float64    `json:"lon,omitempty"`  // Degrees east
+	Grid      string     `json:"grid,omitempty"` // 6 character locator of the position
+	Time      *time.Time `json:"time,omitempty"` // GPS time of the latest report
+}
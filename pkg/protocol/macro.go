@@ -0,0 +1,98 @@
+package protocol
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CmdMacro lists, shows, saves, deletes or expands the operator's message
+// macros: MACRO, MACRO:get:<name>, MACRO:set:<name>:<text>,
+// MACRO:delete:<name> or MACRO:expand:<to>:<text>
+const CmdMacro = "MACRO"
+
+// MACRO command actions
+const (
+	MacroList   = "list"
+	MacroGet    = "get"
+	MacroSet    = "set"
+	MacroDelete = "delete"
+	MacroExpand = "expand"
+)
+
+// Tokens a message or macro can contain in braces, such as {MYCALL}, filled
+// in when it is sent. Any other name in braces is a saved macro.
+const (
+	TokenMyCall = "MYCALL" // This station's callsign
+	TokenMyGrid = "MYGRID" // This station's grid square
+	TokenCall   = "CALL"   // The station the message is to
+	TokenGrid   = "GRID"   // That station's grid square
+	TokenSNR    = "SNR"    // The SNR that station was last heard at
+	TokenTime   = "TIME"   // The UTC time as HHMM
+	TokenVolts  = "VOLTS"  // The battery voltage from the sensors, such as 13.2V
+)
+
+// MacroTokens lists every token
+var MacroTokens = []string{TokenMyCall, TokenMyGrid, TokenCall, TokenGrid, TokenSNR, TokenTime, TokenVolts}
+
+// MaxMacroName is the longest a macro name can be
+const MaxMacroName = 16
+
+// MacroPattern matches a token or macro name in braces
+var MacroPattern = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+// ValidMacroName reports whether name can be given to a macro: letters,
+// digits and underscores, and not one of the tokens
+func ValidMacroName(name string) bool {
+	name = strings.ToUpper(name)
+	if name == "" || len(name) > MaxMacroName || !MacroPattern.MatchString("{"+name+"}") {
+		return false
+	}
+	for _, token := range MacroTokens {
+		if name == token {
+			return false
+		}
+	}
+	return true
+}
+
+// MacroAction returns what a MACRO command does, listing when no action is
+// given
+func (c *Command) MacroAction() string {
+	if action := strings.ToLower(c.StringArg("action")); action != "" {
+		return action
+	}
+	return MacroList
+}
+
+// parseMacroArgs reads the arguments of a version 1 MACRO line
+func parseMacroArgs(cmd *Command, args string) {
+	action, rest, _ := strings.Cut(args, ":")
+	cmd.Args["action"] = strings.ToLower(action)
+	switch cmd.Args["action"] {
+	case MacroSet:
+		name, text, _ := strings.Cut(rest, ":")
+		cmd.Args["name"] = name
+		cmd.Args["text"] = text
+	case MacroExpand:
+		to, text, _ := strings.Cut(rest, ":")
+		cmd.Args["to"] = to
+		cmd.Args["text"] = text
+	default:
+		cmd.Args["name"] = rest
+	}
+}
+
+// macroLine formats the arguments of a MACRO command as a version 1 line
+func macroLine(cmd *Command) string {
+	action := cmd.MacroAction()
+	switch action {
+	case MacroList:
+		return ""
+	case MacroSet:
+		return action + ":" + cmd.StringArg("name") + ":" + cmd.StringArg("text")
+	case MacroExpand:
+		return action + ":" + cmd.StringArg("to") + ":" + cmd.StringArg("text")
+	default:
+		return action + ":" + cmd.StringArg("name")
+	}
+}
@@ -0,0 +1,17 @@
+package protocol
+
+// CmdNet shows the net running, opens one with NET OPEN [@GROUP], closes it
+// with NET CLOSE, lists past nets with NET LIST [limit], shows one's
+// check-ins with NET GET <id> and returns them as CSV with NET EXPORT <id>
+const CmdNet = "NET"
+
+// EventNet is published when a net opens or closes and when a station
+// checks in
+const EventNet = "net"
+
+// What an EventNet reports
+const (
+	NetOpened  = "opened"
+	NetCheckin = "checkin" // A station checked in for the first time
+	NetClosed  = "closed"
+)
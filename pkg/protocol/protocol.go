@@ -17,31 +17,101 @@ type Response struct {
 	Success bool                   `json:"success"`
 	Data    map[string]interface{} `json:"data,omitempty"`
 	Error   string                 `json:"error,omitempty"`
+	Code    string                 `json:"code,omitempty"` // One of the ErrCode constants, set on some errors
 }
 
 // Message represents a JS8 message
 type Message struct {
-	ID        int       `json:"id"`
-	Timestamp time.Time `json:"timestamp"`
-	From      string    `json:"from"`
-	To        string    `json:"to"`
-	Message   string    `json:"message"`
-	SNR       float32   `json:"snr"`
-	Frequency int       `json:"frequency"`
-	Mode      string    `json:"mode"`
+	ID         int       `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Message    string    `json:"message"`
+	SNR        float32   `json:"snr"`
+	Frequency  int       `json:"frequency"`        // Absolute RF in Hz, Dial plus Offset
+	Dial       int       `json:"dial,omitempty"`   // Dial frequency in Hz the rig was on, 0 when not known
+	Offset     int       `json:"offset,omitempty"` // Audio offset in Hz of the signal, which Frequency includes
+	Band       string    `json:"band,omitempty"`   // Amateur band Frequency is in, "" outside them
+	Mode       string    `json:"mode"`
+	Submode    string    `json:"submode,omitempty"`     // JS8 speed a TX goes out in or an RX was decoded in: normal, fast, turbo or slow
+	Channel    string    `json:"channel,omitempty"`     // RX channel/antenna the message arrived on
+	Status     string    `json:"status,omitempty"`      // TX progress, one of the MessageQueued... constants
+	Delivery   string    `json:"delivery,omitempty"`    // ACK state of a directed TX, one of the Delivery... constants
+	Resends    int       `json:"resends,omitempty"`     // Times a directed TX was resent for want of an ACK
+	Power      int       `json:"power,omitempty"`       // TX power in percent of the rig's full power, 0 to leave it as set
+	Path       *Path     `json:"path,omitempty"`        // Great-circle path to the other station, when its grid is known
+	Entity     *Entity   `json:"entity,omitempty"`      // DXCC entity of the other station, when the country file is loaded
+	Raw        *RawFrame `json:"-"`                     // What a decode was made from, stored with storage raw_frames
+	Snippet    string    `json:"snippet,omitempty"`     // Recording of the decode's audio, kept with storage snippet_dir
+	Audio      []int16   `json:"-"`                     // RX audio around a decode at audio.SnippetRate, saved as its snippet
+	NoiseFloor *float64  `json:"noise_floor,omitempty"` // Noise floor in dB of the channel a decode was heard on, to cross-check its SNR
+	Self       bool      `json:"self,omitempty"`        // A decode of our own transmission, heard back from the rig's monitor audio
+	DataFrames bool      `json:"data_frames,omitempty"` // A TX sent whole as Huffman coded data frames rather than cut to one frame
+
+	// Transmission is the JS8 transmission type bits of a frame, marking
+	// the first and last data frames of a text message
+	Transmission uint8 `json:"-"`
+}
+
+// Path is the great-circle path from this station to another
+type Path struct {
+	Grid     string  `json:"grid"`     // The other station's grid square
+	Distance float64 `json:"distance"` // Kilometres
+	Bearing  float64 `json:"bearing"`  // Degrees from true north
 }
 
+// Outbound message statuses. A message is queued until the transmit loop
+// takes it, then transmitting until it is sent or fails; queued messages
+// survive a restart.
+const (
+	MessageQueued       = "queued"
+	MessageTransmitting = "transmitting"
+	MessageSent         = "sent"
+	MessageFailed       = "failed"
+)
+
+// Delivery states of a directed message sent with acknowledgement tracking.
+// It awaits an ACK after each transmission and is undelivered once the
+// retries run out without one.
+const (
+	DeliveryAwaiting    = "awaiting"
+	DeliveryDelivered   = "delivered"
+	DeliveryUndelivered = "undelivered"
+)
+
 // Status represents the current daemon status
 type Status struct {
-	Callsign  string    `json:"callsign"`
-	Grid      string    `json:"grid"`
-	Frequency int       `json:"frequency"`
-	Mode      string    `json:"mode"`
-	PTT       bool      `json:"ptt"`
-	Connected bool      `json:"connected"`
-	Uptime    string    `json:"uptime"`
-	StartTime time.Time `json:"start_time"`
-	Version   string    `json:"version"`
+	Callsign  string        `json:"callsign"`
+	Grid      string        `json:"grid"`
+	Frequency int           `json:"frequency"`
+	TXOffset  int           `json:"tx_offset"` // Audio offset transmissions go out at, in Hz
+	Mode      string        `json:"mode"`
+	PTT       bool          `json:"ptt"`
+	Connected bool          `json:"connected"`
+	Uptime    string        `json:"uptime"`
+	StartTime time.Time     `json:"start_time"`
+	Version   string        `json:"version"`
+	Auto      bool          `json:"auto"`                // Automatic replies to queries like SNR? are on
+	QSOScript bool          `json:"qso_script"`          // Stations answering a CQ get a scripted QSO
+	QSO       *QSOState     `json:"qso,omitempty"`       // The scripted QSO running, if any
+	Clock     *ClockStatus  `json:"clock,omitempty"`     // System clock offset, when time_sync is enabled
+	GPS       *GPSStatus    `json:"gps,omitempty"`       // Latest gpsd report, when gps is enabled
+	Sensors   *SensorStatus `json:"sensors,omitempty"`   // Battery and temperature, when sensors is enabled
+	LowPower  bool          `json:"low_power"`           // Low power mode is on
+	BandPlan  *BandCheck    `json:"band_plan,omitempty"` // Why TX at the current frequency is outside the band plan, nil when it isn't
+
+	// Panics recovered in each of the engine's goroutines since it started
+	Restarts map[string]int `json:"restarts,omitempty"`
+}
+
+// ClockStatus is the last check of the system clock against true time
+type ClockStatus struct {
+	Offset  float64    `json:"offset"`            // Seconds the clock is ahead, negative when behind
+	Source  string     `json:"source,omitempty"`  // chrony, ntp or gps
+	Server  string     `json:"server,omitempty"`  // Reference the offset was measured against
+	Checked *time.Time `json:"checked,omitempty"` // Nil until a check succeeds
+	Synced  bool       `json:"synced"`            // Within max_offset, heartbeats allowed
+	Error   string     `json:"error,omitempty"`   // Why the latest check failed
 }
 
 // ParseCommand parses a text command into a Command struct
@@ -59,7 +129,12 @@ func ParseCommand(text string) (*Command, error) {
 
 		switch cmd.Type {
 		case "SEND":
-			// SEND:N0CALL Hello world
+			// SEND:N0CALL Hello world, or SEND:PWR=10 N0CALL Hello world
+			// to transmit at 10% power
+			if word, rest, ok := strings.Cut(args, " "); ok && strings.HasPrefix(strings.ToUpper(word), "PWR=") {
+				cmd.Args["power"] = word[len("PWR="):]
+				args = rest
+			}
 			sendParts := strings.SplitN(args, " ", 2)
 			if len(sendParts) >= 2 {
 				cmd.Args["to"] = sendParts[0]
@@ -84,6 +159,59 @@ func ParseCommand(text string) (*Command, error) {
 			// FREQUENCY:14078000
 			cmd.Args["frequency"] = args
 
+		case "PROFILE":
+			// PROFILE:portable-qrp
+			cmd.Args["name"] = args
+
+		case "LOGLEVEL":
+			// LOGLEVEL:debug or LOGLEVEL:dsp:debug
+			if component, level, ok := strings.Cut(args, ":"); ok {
+				cmd.Args["component"] = component
+				cmd.Args["level"] = level
+			} else {
+				cmd.Args["level"] = args
+			}
+
+		case "AUTH":
+			// AUTH:<token>
+			cmd.Args["token"] = args
+
+		case "RAW":
+			// RAW:42
+			cmd.Args["id"] = args
+
+		case "REDECODE":
+			// REDECODE:2024-01-15T10:00:00Z/2024-01-15T11:00:00Z or REDECODE:2h
+			cmd.Args["range"] = args
+
+		case "STATION":
+			// STATION:N0CALL or STATION:N0CALL:notes:Met at Dayton
+			parseStationArgs(cmd, args)
+
+		case "MACRO":
+			// MACRO:get:CQ or MACRO:set:CQ:CQ CQ {MYCALL} {MYGRID}
+			parseMacroArgs(cmd, args)
+
+		case "FILTER":
+			// FILTER:add:callsign:N0ABC or FILTER:add:offset:1500:20:birdie
+			parseFilterArgs(cmd, args)
+
+		case "AUTOLIST":
+			// AUTOLIST:block:N0ABC:Keeps calling or AUTOLIST:allow:K3*
+			parseAutoListArgs(cmd, args)
+
+		case "FORM":
+			// FORM:templates or FORM:send:ICS213:W1AW:{"subject":"Supplies"}
+			parseFormArgs(cmd, args)
+
+		case "FILE":
+			// FILE:get:3 or FILE:send:W1AW:roster.txt:SzFBQkMK
+			parseFileArgs(cmd, args)
+
+		case "SESSION":
+			// SESSION:connect:W1AW or SESSION:send:2:Net starts at 1900Z
+			parseSessionArgs(cmd, args)
+
 		case "CONFIG":
 			// CONFIG:set:key:value or CONFIG:get:key
 			configParts := strings.SplitN(args, ":", 3)
@@ -124,6 +252,24 @@ func NewErrorResponse(err string) *Response {
 	}
 }
 
+// NewCodedErrorResponse creates an error response with an error code
+func NewCodedErrorResponse(code, err string) *Response {
+	return &Response{
+		Success: false,
+		Error:   err,
+		Code:    code,
+	}
+}
+
+// Error codes let clients tell failures apart without matching the message
+const (
+	ErrCodeInvalid      = "INVALID_REQUEST" // Bad arguments
+	ErrCodeQueueFull    = "QUEUE_FULL"      // Transmit queue is full
+	ErrCodeRadio        = "RADIO_ERROR"     // The radio rejected or failed a command
+	ErrCodeNotConnected = "NOT_CONNECTED"   // No radio or hardware to talk to
+	ErrCodeTooLarge     = "TOO_LARGE"       // The response is over MaxFrameSize
+)
+
 // Protocol commands
 const (
 	CmdStatus    = "STATUS"
@@ -137,4 +283,7 @@ const (
 	CmdAudio     = "AUDIO"
 	CmdAbort     = "ABORT"
 	CmdReload    = "RELOAD"
+	CmdProfile   = "PROFILE"
+	CmdLogLevel  = "LOGLEVEL"
+	CmdEvents    = "EVENTS"
 )
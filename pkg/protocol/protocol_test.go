@@ -58,6 +58,26 @@ func TestParseCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("SEND Command with Power", func(t *testing.T) {
+		cmd, err := ParseCommand("SEND:PWR=10 N0CALL Hello world")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if cmd.Args["power"] != "10" {
+			t.Errorf("Expected power '10', got %v", cmd.Args["power"])
+		}
+		if cmd.Args["to"] != "N0CALL" || cmd.Args["message"] != "Hello world" {
+			t.Errorf("Expected N0CALL 'Hello world', got %v %v", cmd.Args["to"], cmd.Args["message"])
+		}
+
+		// PWR= only counts as the first word
+		cmd, _ = ParseCommand("SEND:N0CALL PWR=10 please")
+		if _, ok := cmd.Args["power"]; ok || cmd.Args["message"] != "PWR=10 please" {
+			t.Errorf("Expected PWR= in the message text, got %v", cmd.Args)
+		}
+	})
+
 	t.Run("MESSAGES Command with Limit", func(t *testing.T) {
 		cmd, err := ParseCommand("MESSAGES:20")
 		if err != nil {
@@ -100,6 +120,149 @@ func TestParseCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("PROFILE Command", func(t *testing.T) {
+		cmd, err := ParseCommand("profile:portable-qrp")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if cmd.Type != CmdProfile {
+			t.Errorf("Expected type PROFILE, got %s", cmd.Type)
+		}
+		if cmd.Args["name"] != "portable-qrp" {
+			t.Errorf("Expected name portable-qrp, got %v", cmd.Args["name"])
+		}
+	})
+
+	t.Run("LOGLEVEL Command", func(t *testing.T) {
+		cmd, _ := ParseCommand("LOGLEVEL:debug")
+		if cmd.Type != CmdLogLevel || cmd.Args["level"] != "debug" || cmd.Args["component"] != nil {
+			t.Errorf("Expected level debug for every component, got %v", cmd.Args)
+		}
+
+		cmd, _ = ParseCommand("loglevel:dsp:warn")
+		if cmd.Args["component"] != "dsp" || cmd.Args["level"] != "warn" {
+			t.Errorf("Expected dsp at warn, got %v", cmd.Args)
+		}
+	})
+
+	t.Run("STATION Command", func(t *testing.T) {
+		cmd, _ := ParseCommand("STATION:N0ABC")
+		if cmd.Type != CmdStation || cmd.Args["callsign"] != "N0ABC" || len(cmd.StationEdits()) != 0 {
+			t.Errorf("Expected a lookup of N0ABC, got %v", cmd.Args)
+		}
+
+		cmd, _ = ParseCommand("STATION:N0ABC:QTH:Denver, CO")
+		if edits := cmd.StationEdits(); len(edits) != 1 || edits["qth"] != "Denver, CO" {
+			t.Errorf("Expected the QTH set, got %v", edits)
+		}
+	})
+
+	t.Run("MACRO Command", func(t *testing.T) {
+		cmd, _ := ParseCommand("MACRO")
+		if cmd.Type != CmdMacro || cmd.MacroAction() != MacroList {
+			t.Errorf("Expected a macro list, got %s %v", cmd.Type, cmd.Args)
+		}
+
+		cmd, _ = ParseCommand("MACRO:SET:QTH:{MYCALL}: QTH {MYGRID}")
+		if cmd.MacroAction() != MacroSet || cmd.Args["name"] != "QTH" || cmd.Args["text"] != "{MYCALL}: QTH {MYGRID}" {
+			t.Errorf("Expected QTH saved, got %v", cmd.Args)
+		}
+
+		cmd, _ = ParseCommand("MACRO:expand::{MYCALL} 73")
+		if cmd.MacroAction() != MacroExpand || cmd.Args["to"] != "" || cmd.Args["text"] != "{MYCALL} 73" {
+			t.Errorf("Expected an expansion with no station, got %v", cmd.Args)
+		}
+
+		for name, want := range map[string]bool{"cq_2": true, "SNR": false, "TWO WORDS": false, "": false} {
+			if ValidMacroName(name) != want {
+				t.Errorf("Expected ValidMacroName(%q) to be %v", name, want)
+			}
+		}
+	})
+
+	t.Run("FILTER Command", func(t *testing.T) {
+		cmd, _ := ParseCommand("FILTER")
+		if cmd.Type != CmdFilter || cmd.FilterAction() != FilterList {
+			t.Errorf("Expected a filter list, got %s %v", cmd.Type, cmd.Args)
+		}
+
+		cmd, _ = ParseCommand("FILTER:ADD:Offset:1480:25:Birdie: PSU")
+		if cmd.FilterAction() != FilterAdd || cmd.Args["type"] != FilterOffset || cmd.Args["offset"] != "1480" ||
+			cmd.Args["width"] != "25" || cmd.Args["note"] != "Birdie: PSU" {
+			t.Errorf("Expected an offset filter, got %v", cmd.Args)
+		}
+
+		cmd, _ = ParseCommand("FILTER:add:callsign:N0ABC")
+		if cmd.Args["type"] != FilterCallsign || cmd.Args["callsign"] != "N0ABC" || cmd.Args["note"] != "" {
+			t.Errorf("Expected a callsign filter, got %v", cmd.Args)
+		}
+	})
+
+	t.Run("FORM Command", func(t *testing.T) {
+		cmd, _ := ParseCommand("FORM")
+		if cmd.Type != CmdForm || cmd.FormAction() != FormList {
+			t.Errorf("Expected a form list, got %s %v", cmd.Type, cmd.Args)
+		}
+
+		cmd, _ = ParseCommand(`FORM:send:ICS213:W1AW:{"subject":"Supplies: cots","message":"Need 200"}`)
+		values, err := cmd.FormValues()
+		if cmd.FormAction() != FormSend || cmd.Args["template"] != "ICS213" || cmd.Args["to"] != "W1AW" {
+			t.Errorf("Expected an ICS213 to W1AW, got %v", cmd.Args)
+		}
+		if err != nil || values["subject"] != "Supplies: cots" || values["message"] != "Need 200" {
+			t.Errorf("Expected the values back, got %v, %v", values, err)
+		}
+
+		// Version 2 commands carry the values as an object
+		cmd = &Command{Type: CmdForm, Args: map[string]interface{}{
+			"action": "send",
+			"values": map[string]interface{}{"subject": "Supplies", "count": float64(3)},
+		}}
+		if values, _ := cmd.FormValues(); values["subject"] != "Supplies" || values["count"] != "3" {
+			t.Errorf("Expected values from an object, got %v", values)
+		}
+
+		cmd, _ = ParseCommand("FORM:send:ICS213:W1AW:not json")
+		if _, err := cmd.FormValues(); err == nil {
+			t.Error("Expected an error for values that are not JSON")
+		}
+	})
+
+	t.Run("FILE Command", func(t *testing.T) {
+		cmd, _ := ParseCommand("FILE")
+		if cmd.Type != CmdFile || cmd.FileAction() != FileList {
+			t.Errorf("Expected a file list, got %s %v", cmd.Type, cmd.Args)
+		}
+
+		cmd, _ = ParseCommand("FILE:send:W1AW:notes.txt:aGVsbG8=")
+		if cmd.FileAction() != FileSend || cmd.Args["to"] != "W1AW" || cmd.Args["name"] != "notes.txt" || cmd.Args["content"] != "aGVsbG8=" {
+			t.Errorf("Expected notes.txt to W1AW, got %v", cmd.Args)
+		}
+
+		cmd, _ = ParseCommand("FILE:CANCEL:7")
+		if cmd.FileAction() != FileCancel || cmd.Args["id"] != "7" {
+			t.Errorf("Expected transfer 7 cancelled, got %v", cmd.Args)
+		}
+	})
+
+	t.Run("SESSION Command", func(t *testing.T) {
+		cmd, _ := ParseCommand("SESSION")
+		if cmd.Type != CmdSession || cmd.SessionAction() != SessionList {
+			t.Errorf("Expected a session list, got %s %v", cmd.Type, cmd.Args)
+		}
+
+		cmd, _ = ParseCommand("SESSION:connect:W1AW")
+		if cmd.SessionAction() != SessionConnect || cmd.Args["to"] != "W1AW" {
+			t.Errorf("Expected a connect to W1AW, got %v", cmd.Args)
+		}
+
+		cmd, _ = ParseCommand("SESSION:send:2:Net at 19:00Z")
+		if cmd.SessionAction() != SessionSend || cmd.Args["id"] != "2" || cmd.Args["message"] != "Net at 19:00Z" {
+			t.Errorf("Expected a message to session 2, got %v", cmd.Args)
+		}
+	})
+
 	t.Run("CONFIG Command Set", func(t *testing.T) {
 		cmd, err := ParseCommand("CONFIG:set:callsign:K3DEP")
 		if err != nil {
@@ -263,6 +426,19 @@ func TestResponse(t *testing.T) {
 		}
 	})
 
+	t.Run("Coded Error Response JSON", func(t *testing.T) {
+		resp := NewCodedErrorResponse(ErrCodeQueueFull, "transmit queue full")
+		if resp.Success || resp.Code != ErrCodeQueueFull {
+			t.Errorf("Expected failed response with code, got %+v", resp)
+		}
+		if !strings.Contains(resp.String(), `"code":"QUEUE_FULL"`) {
+			t.Errorf("Expected code in JSON, got %s", resp.String())
+		}
+		if strings.Contains(NewErrorResponse("x").String(), `"code"`) {
+			t.Error("Expected no code for an uncoded error")
+		}
+	})
+
 	t.Run("Empty Success Response", func(t *testing.T) {
 		resp := NewSuccessResponse(nil)
 		jsonStr := resp.String()
@@ -407,7 +583,8 @@ func TestConstants(t *testing.T) {
 	// Test that all command constants are defined
 	expectedCommands := []string{
 		"STATUS", "MESSAGES", "SEND", "FREQUENCY", "CONFIG",
-		"QUIT", "PING", "RADIO", "AUDIO", "ABORT", "RELOAD",
+		"QUIT", "PING", "RADIO", "AUDIO", "ABORT", "RELOAD", "PROFILE", "LOGLEVEL", "EVENTS",
+		"STATION",
 	}
 
 	constants := map[string]string{
@@ -422,6 +599,10 @@ func TestConstants(t *testing.T) {
 		"AUDIO":     CmdAudio,
 		"ABORT":     CmdAbort,
 		"RELOAD":    CmdReload,
+		"PROFILE":   CmdProfile,
+		"LOGLEVEL":  CmdLogLevel,
+		"EVENTS":    CmdEvents,
+		"STATION":   CmdStation,
 	}
 
 	for _, expected := range expectedCommands {
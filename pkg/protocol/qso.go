@@ -0,0 +1,31 @@
+package protocol
+
+import "time"
+
+// CmdQSO shows the scripted QSO, turns scripting on or off with QSO ON or
+// QSO OFF, stops the QSO running with QSO STOP, or sends its next step
+// without waiting for the reply with QSO NEXT
+const CmdQSO = "QSO"
+
+// EventQSO is published when a scripted QSO starts, moves to another step
+// or ends
+const EventQSO = "qso"
+
+// Scripted QSO states. A QSO waits for the other station's reply after
+// each step, and ends complete once its last step has been sent.
+const (
+	QSOWaiting  = "waiting"   // A step was sent or queued, awaiting the reply
+	QSOComplete = "complete"  // The last step went out
+	QSOTimedOut = "timed_out" // The other station stopped answering
+	QSOStopped  = "stopped"   // The operator stopped it or took over
+	QSOFailed   = "failed"    // A step could not be sent
+)
+
+// QSOState is the progress of a scripted QSO
+type QSOState struct {
+	Call    string    `json:"call"`  // The station that answered the CQ
+	State   string    `json:"state"` // One of the QSO... constants
+	Step    int       `json:"step"`  // The step last sent, from 1
+	Steps   int       `json:"steps"`
+	Started time.Time `json:"started"`
+}
@@ -0,0 +1,11 @@
+package protocol
+
+// CmdRaw shows the payload bits and received tones a stored message was
+// decoded from, when storage raw_frames is on: RAW:<id>
+const CmdRaw = "RAW"
+
+// RawFrame is what a decode was made from, kept to debug the protocol
+type RawFrame struct {
+	Payload []byte // The 87 bits, 72 of message, 3 of frame type and the 12 bit CRC, packed MSB first
+	Tones   []int  // Strongest tone of each of the 79 symbols as received
+}
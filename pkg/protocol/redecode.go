@@ -0,0 +1,9 @@
+package protocol
+
+// CmdRedecode decodes band recordings again with the current decoder, into
+// a message set of their own: REDECODE shows the last run, REDECODE:<from>/<to>
+// or REDECODE:<duration> starts one and REDECODE:CANCEL stops it
+const CmdRedecode = "REDECODE"
+
+// RedecodeCancel stops a re-decode in progress
+const RedecodeCancel = "CANCEL"
@@ -0,0 +1,19 @@
+package protocol
+
+import "time"
+
+// SensorReading is one sample of the battery and temperature sensors
+type SensorReading struct {
+	Time         time.Time          `json:"time"`
+	Voltage      *float64           `json:"voltage,omitempty"`      // Battery volts, when an INA219 is fitted
+	Current      *float64           `json:"current,omitempty"`      // Amps drawn, negative while charging
+	Temperatures map[string]float64 `json:"temperatures,omitempty"` // Degrees Celsius by 1-wire sensor ID
+}
+
+// SensorStatus is the latest sensor reading and whether it has stopped TX
+type SensorStatus struct {
+	Latest     *SensorReading `json:"latest,omitempty"`      // Nil until a sample succeeds
+	MinVoltage float64        `json:"min_voltage,omitempty"` // Volts below which TX stops
+	LowBattery bool           `json:"low_battery"`           // Below min_voltage, TX inhibited
+	Error      string         `json:"error,omitempty"`       // Why the latest sample failed
+}
@@ -0,0 +1,102 @@
+package protocol
+
+import (
+	"strings"
+	"time"
+)
+
+// CmdSession lists, opens, writes to or closes connected-mode sessions with
+// other js8d stations: SESSION, SESSION:get:<id>, SESSION:connect:<to>,
+// SESSION:send:<id>:<message> or SESSION:disconnect:<id>
+const CmdSession = "SESSION"
+
+// SESSION command actions
+const (
+	SessionList       = "list"
+	SessionGet        = "get"
+	SessionConnect    = "connect"
+	SessionSend       = "send"
+	SessionDisconnect = "disconnect"
+)
+
+// Session statuses
+const (
+	SessionConnecting    = "connecting"    // waiting for the other station to accept
+	SessionConnected     = "connected"     // messages go both ways
+	SessionDisconnecting = "disconnecting" // waiting for the other station to confirm the disconnect
+	SessionClosed        = "closed"
+	SessionFailed        = "failed" // refused, or the other station stopped answering
+)
+
+// EventSession is published as a session opens, closes or carries a
+// message, with the session and the message
+const EventSession = "session"
+
+// Session is a connected-mode session with another js8d station
+type Session struct {
+	ID             int       `json:"id"`
+	FrameID        uint8     `json:"frame_id"`          // Session id its frames carry
+	Peer           string    `json:"peer"`              // The other station, its call hash as #0123 until it has sent its callsign
+	Initiator      bool      `json:"initiator"`         // This station connected
+	Status         string    `json:"status"`            // One of the Session... statuses
+	Error          string    `json:"error,omitempty"`   // Why it failed or closed
+	Opened         time.Time `json:"opened"`            // When the connect was sent or heard
+	Heard          time.Time `json:"heard"`             // When a frame was last heard or sent
+	Queued         int       `json:"queued"`            // Bytes written and not yet sent
+	InFlight       int       `json:"in_flight"`         // Frames sent and not yet acknowledged
+	FramesSent     int       `json:"frames_sent"`       // Data frames sent, resends included
+	FramesResent   int       `json:"frames_resent"`     // Data frames sent again after the other station missed them
+	FramesReceived int       `json:"frames_received"`   // Data frames heard, each once
+	MessagesSent   int       `json:"messages_sent"`     // Messages written
+	MessagesRecvd  int       `json:"messages_received"` // Messages received whole
+}
+
+// SessionMessage is a message written to or received in a session
+type SessionMessage struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"` // TX or RX
+	Text      string    `json:"text"`
+}
+
+// SessionAction returns what a SESSION command does, listing when no
+// action is given
+func (c *Command) SessionAction() string {
+	if action := strings.ToLower(c.StringArg("action")); action != "" {
+		return action
+	}
+	return SessionList
+}
+
+// parseSessionArgs reads the arguments of a version 1 SESSION line
+func parseSessionArgs(cmd *Command, args string) {
+	action, rest, _ := strings.Cut(args, ":")
+	cmd.Args["action"] = strings.ToLower(action)
+	switch cmd.Args["action"] {
+	case SessionConnect:
+		cmd.Args["to"] = rest
+	case SessionSend:
+		id, message, _ := strings.Cut(rest, ":")
+		cmd.Args["id"] = id
+		cmd.Args["message"] = message
+	case SessionGet, SessionDisconnect:
+		cmd.Args["id"] = rest
+	}
+}
+
+// sessionLine formats the arguments of a SESSION command as a version 1
+// line
+func sessionLine(cmd *Command) string {
+	action := cmd.SessionAction()
+	switch action {
+	case SessionConnect:
+		return action + ":" + cmd.StringArg("to")
+	case SessionSend:
+		return action + ":" + cmd.StringArg("id") + ":" + cmd.StringArg("message")
+	case SessionGet, SessionDisconnect:
+		return action + ":" + cmd.StringArg("id")
+	case SessionList:
+		return ""
+	default:
+		return action
+	}
+}
@@ -0,0 +1,48 @@
+package protocol
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CmdStation shows a station from the station database, or edits what the
+// operator has noted about it: STATION:<callsign> or
+// STATION:<callsign>:<field>:<value>
+const CmdStation = "STATION"
+
+// StationFields are the station details the operator can edit
+var StationFields = []string{"name", "qth", "notes", "qsl_status"}
+
+// StationEdits returns the station fields a STATION command sets
+func (c *Command) StationEdits() map[string]string {
+	edits := make(map[string]string)
+	for _, field := range StationFields {
+		if _, ok := c.Args[field]; ok {
+			edits[field] = c.StringArg(field)
+		}
+	}
+	return edits
+}
+
+// parseStationArgs reads the arguments of a version 1 STATION line
+func parseStationArgs(cmd *Command, args string) {
+	parts := strings.SplitN(args, ":", 3)
+	cmd.Args["callsign"] = parts[0]
+	if len(parts) == 3 {
+		cmd.Args[strings.ToLower(parts[1])] = parts[2]
+	}
+}
+
+// stationLine formats the arguments of a STATION command as a version 1
+// line, which can carry one edit
+func stationLine(cmd *Command) (string, error) {
+	args := cmd.StringArg("callsign")
+	edits := cmd.StationEdits()
+	if len(edits) > 1 {
+		return "", fmt.Errorf("%s command edits several fields, which needs protocol version %d", CmdStation, Version2)
+	}
+	for field, value := range edits {
+		args += ":" + field + ":" + value
+	}
+	return args, nil
+}
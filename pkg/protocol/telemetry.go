@@ -0,0 +1,10 @@
+package protocol
+
+// CmdTelemetry shows when this station's next telemetry is due and the
+// reading it would send, sends it now with TELEMETRY NOW, or lists the
+// latest reading of every station heard sending telemetry with TELEMETRY
+// STATIONS
+const CmdTelemetry = "TELEMETRY"
+
+// EventTelemetry is published when telemetry is heard from another station
+const EventTelemetry = "telemetry"
@@ -0,0 +1,98 @@
+package qsl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/adif"
+)
+
+// DefaultEQSLURL is eQSL's ADIF upload
+const DefaultEQSLURL = "https://www.eqsl.cc/qslcard/ImportADIF.cfm"
+
+// maxResponse bounds the page eQSL answers an upload with
+const maxResponse = 1 << 20
+
+// eQSL answers with an HTML page reporting the result or an error
+var (
+	eqslResult = regexp.MustCompile(`Result:\s*(\d+) out of (\d+) records added`)
+	eqslError  = regexp.MustCompile(`Error:\s*([^<\r\n]+)`)
+)
+
+// EQSL uploads logs to an eQSL.cc account
+type EQSL struct {
+	URL      string // Empty uses DefaultEQSLURL
+	Username string
+	Password string
+	Nickname string // QTH nickname, for accounts with several locations
+	Program  string // Written into the ADIF header
+	Client   *http.Client
+}
+
+// Upload sends the records to eQSL, returning how many it added. QSOs it
+// already has count as uploaded but not added.
+func (q *EQSL) Upload(ctx context.Context, records []adif.Record) (int, error) {
+	if q.Username == "" || q.Password == "" {
+		return 0, errors.New("no eQSL account configured")
+	}
+
+	header := adif.Record{}
+	header.Add("EQSL_USER", q.Username)
+	header.Add("EQSL_PSWD", q.Password)
+	upload := make([]adif.Record, len(records))
+	for i, record := range records {
+		upload[i] = append(adif.Record{}, record...)
+		upload[i].Add("APP_EQSL_QTH_NICKNAME", q.Nickname)
+	}
+	var data bytes.Buffer
+	if err := adif.Write(&data, q.Program, header, upload); err != nil {
+		return 0, err
+	}
+
+	target := q.URL
+	if target == "" {
+		target = DefaultEQSLURL
+	}
+	form := url.Values{"ADIFData": {data.String()}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "js8d")
+
+	client := q.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("eQSL returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	if err != nil {
+		return 0, err
+	}
+
+	if m := eqslResult.FindSubmatch(body); m != nil {
+		added, _ := strconv.Atoi(string(m[1]))
+		return added, nil
+	}
+	if m := eqslError.FindSubmatch(body); m != nil {
+		return 0, fmt.Errorf("eQSL: %s", strings.TrimSpace(string(m[1])))
+	}
+	return 0, errors.New("eQSL did not report a result")
+}
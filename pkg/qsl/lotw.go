@@ -0,0 +1,81 @@
+// Package qsl uploads logged QSOs for confirmation: to ARRL's Logbook of
+// The World, signed by TQSL, and to eQSL.cc
+package qsl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/dougsko/js8d/pkg/adif"
+)
+
+// DefaultTQSL is the TQSL program run when none is configured
+const DefaultTQSL = "tqsl"
+
+// TQSL exit codes that still mean the log was accepted
+const (
+	tqslAllDuplicates  = 8 // Every QSO was already uploaded or out of the date range
+	tqslSomeDuplicates = 9 // Some were; the rest went up
+)
+
+// LoTW signs logs with a TQSL station location and uploads them
+type LoTW struct {
+	TQSL     string // TQSL program, empty runs tqsl from the PATH
+	Location string // Station location whose certificate signs the QSOs
+	Program  string // Written into the ADIF header
+}
+
+// Upload signs the records and uploads them to LoTW. QSOs LoTW already has
+// are skipped by TQSL and don't fail the upload.
+func (l *LoTW) Upload(ctx context.Context, records []adif.Record) error {
+	if l.Location == "" {
+		return errors.New("no TQSL station location configured")
+	}
+
+	f, err := os.CreateTemp("", "js8d-lotw-*.adi")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := adif.Write(f, l.Program, nil, records); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	program := l.TQSL
+	if program == "" {
+		program = DefaultTQSL
+	}
+	// Batch mode: no dialogs, sign only QSOs that are not duplicates,
+	// upload and exit
+	cmd := exec.CommandContext(ctx, program, "-q", "-d", "-x", "-u", "-a", "compliant", "-l", l.Location, f.Name())
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		switch exitErr.ExitCode() {
+		case tqslAllDuplicates, tqslSomeDuplicates:
+			return nil
+		}
+		return fmt.Errorf("tqsl exited with status %d: %s", exitErr.ExitCode(), lastLine(output.String()))
+	}
+	return err
+}
+
+// lastLine returns the last line of a program's output, where TQSL puts
+// why it failed
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,92 @@
+package qsl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dougsko/js8d/pkg/adif"
+)
+
+func testRecords() []adif.Record {
+	var record adif.Record
+	record.Add("CALL", "DL1ABC")
+	record.Add("QSO_DATE", "20240115")
+	record.Add("TIME_ON", "111230")
+	return []adif.Record{record}
+}
+
+func TestEQSLUpload(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.FormValue("ADIFData")
+		if strings.Contains(got, "<EQSL_PSWD:5>wrong") {
+			fmt.Fprint(w, "<HTML><BODY>Error: No match on eQSL_User/eQSL_Pswd</BODY></HTML>")
+			return
+		}
+		fmt.Fprint(w, "<HTML><BODY>Result: 1 out of 1 records added<BR></BODY></HTML>")
+	}))
+	defer server.Close()
+
+	records := testRecords()
+	q := &EQSL{URL: server.URL, Username: "K1ABC", Password: "hunter2", Nickname: "Home", Program: "js8d"}
+	added, err := q.Upload(context.Background(), records)
+	if err != nil || added != 1 {
+		t.Fatalf("Expected 1 record added, got %d, %v", added, err)
+	}
+	for _, want := range []string{"<EQSL_USER:5>K1ABC", "<EQSL_PSWD:7>hunter2", "<CALL:6>DL1ABC", "<APP_EQSL_QTH_NICKNAME:4>Home"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %s in the upload, got %s", want, got)
+		}
+	}
+	if len(records[0]) != 3 {
+		t.Errorf("Expected the records left as they were, got %+v", records[0])
+	}
+
+	q.Password = "wrong"
+	if _, err := q.Upload(context.Background(), records); err == nil || !strings.Contains(err.Error(), "No match") {
+		t.Errorf("Expected eQSL's error, got %v", err)
+	}
+	if _, err := (&EQSL{URL: server.URL}).Upload(context.Background(), records); err == nil {
+		t.Error("Expected an error without an account")
+	}
+}
+
+func TestLoTWUpload(t *testing.T) {
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+
+	// A stand-in for TQSL that records its arguments and exits with $STATUS
+	tqsl := filepath.Join(dir, "tqsl")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %s\necho 'Signing failed: no certificate'\nexit ${STATUS:-0}\n", args)
+	if err := os.WriteFile(tqsl, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+
+	l := &LoTW{TQSL: tqsl, Location: "Home", Program: "js8d"}
+	if err := l.Upload(context.Background(), testRecords()); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	data, _ := os.ReadFile(args)
+	if !strings.Contains(string(data), "-u -a compliant -l Home") {
+		t.Errorf("Expected TQSL asked to sign with Home and upload, got %s", data)
+	}
+
+	t.Setenv("STATUS", "9")
+	if err := l.Upload(context.Background(), testRecords()); err != nil {
+		t.Errorf("Expected duplicates not to fail the upload, got %v", err)
+	}
+	t.Setenv("STATUS", "4")
+	if err := l.Upload(context.Background(), testRecords()); err == nil || !strings.Contains(err.Error(), "no certificate") {
+		t.Errorf("Expected TQSL's error, got %v", err)
+	}
+
+	if err := (&LoTW{TQSL: tqsl}).Upload(context.Background(), testRecords()); err == nil {
+		t.Error("Expected an error without a station location")
+	}
+}
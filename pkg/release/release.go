@@ -0,0 +1,119 @@
+// Package release looks up the latest js8d release on GitHub, so a station
+// can tell whether it is running the newest version
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultURL is the GitHub API endpoint of js8d's latest release
+const DefaultURL = "https://api.github.com/repos/dougsko/js8d/releases/latest"
+
+// Release is a published version
+type Release struct {
+	Version   string    `json:"version"` // Tag without its leading v
+	Name      string    `json:"name,omitempty"`
+	URL       string    `json:"url"` // Release page
+	Published time.Time `json:"published"`
+	Notes     string    `json:"notes,omitempty"`
+}
+
+// Latest fetches the latest release from the GitHub API at url
+func Latest(ctx context.Context, client *http.Client, url string) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "js8d")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("release source returned %s", resp.Status)
+	}
+
+	var latest struct {
+		TagName     string    `json:"tag_name"`
+		Name        string    `json:"name"`
+		HTMLURL     string    `json:"html_url"`
+		PublishedAt time.Time `json:"published_at"`
+		Body        string    `json:"body"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&latest); err != nil {
+		return nil, fmt.Errorf("failed to read release: %w", err)
+	}
+	if latest.TagName == "" {
+		return nil, fmt.Errorf("release has no tag")
+	}
+
+	return &Release{
+		Version:   strings.TrimPrefix(latest.TagName, "v"),
+		Name:      latest.Name,
+		URL:       latest.HTMLURL,
+		Published: latest.PublishedAt,
+		Notes:     latest.Body,
+	}, nil
+}
+
+// Newer reports whether version latest is newer than current
+func Newer(current, latest string) bool {
+	return Compare(latest, current) > 0
+}
+
+// Compare orders two semantic versions, with or without a leading v,
+// returning -1, 0 or 1. A pre-release such as 0.2.0-dev comes before the
+// release itself, and pre-releases compare as text. Missing or non-numeric
+// parts count as 0, so "1.2" equals "1.2.0".
+func Compare(a, b string) int {
+	aCore, aPre, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	bCore, bPre, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+
+	aParts := strings.Split(aCore, ".")
+	bParts := strings.Split(bCore, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		if c := compareInts(part(aParts, i), part(bParts, i)); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return strings.Compare(aPre, bPre)
+}
+
+// part returns the number at index i of a dotted version, or 0
+func part(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+	n, _ := strconv.Atoi(parts[i])
+	return n
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
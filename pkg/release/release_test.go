@@ -0,0 +1,61 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLatest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/missing" {
+			fmt.Fprint(w, `{"tag_name": "v0.3.1", "name": "js8d 0.3.1",
+				"html_url": "https://github.com/dougsko/js8d/releases/tag/v0.3.1",
+				"published_at": "2024-03-02T10:00:00Z", "body": "Bug fixes"}`)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	latest, err := Latest(context.Background(), server.Client(), server.URL)
+	if err != nil {
+		t.Fatalf("Latest failed: %v", err)
+	}
+	if latest.Version != "0.3.1" || latest.Notes != "Bug fixes" || latest.Published.Year() != 2024 {
+		t.Errorf("Unexpected release %+v", latest)
+	}
+
+	if _, err := Latest(context.Background(), server.Client(), server.URL+"/missing"); err == nil {
+		t.Error("Expected an error for a 404")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"0.3.1", "v0.3.1", 0},
+		{"1.2", "1.2.0", 0},
+		{"0.10.0", "0.9.0", 1},
+		{"0.1.0-dev", "0.1.0", -1},
+		{"0.1.0", "0.1.0-rc1", 1},
+		{"0.1.0-rc2", "0.1.0-rc1", 1},
+		{"1.0.0", "0.99.99", 1},
+	}
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := Compare(tt.b, tt.a); got != -tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+
+	if !Newer("0.1.0-dev", "0.1.0") || Newer("0.2.0", "0.1.5") {
+		t.Error("Newer got the order wrong")
+	}
+}
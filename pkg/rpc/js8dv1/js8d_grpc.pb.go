@@ -0,0 +1,298 @@
+// js8d gRPC API, version 1.
+//
+// Served by the core engine alongside its Unix socket when api.grpc_address
+// is set. Regenerate the Go code with `make proto`.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: js8d/v1/js8d.proto
+
+package js8dv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	JS8D_GetStatus_FullMethodName         = "/js8d.v1.JS8D/GetStatus"
+	JS8D_ListMessages_FullMethodName      = "/js8d.v1.JS8D/ListMessages"
+	JS8D_SendMessage_FullMethodName       = "/js8d.v1.JS8D/SendMessage"
+	JS8D_AbortTransmission_FullMethodName = "/js8d.v1.JS8D/AbortTransmission"
+	JS8D_StreamDecodes_FullMethodName     = "/js8d.v1.JS8D/StreamDecodes"
+)
+
+// JS8DClient is the client API for JS8D service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// JS8D controls one js8d engine.
+type JS8DClient interface {
+	// GetStatus returns the station and radio status.
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*Status, error)
+	// ListMessages returns stored RX and TX messages, newest first.
+	ListMessages(ctx context.Context, in *ListMessagesRequest, opts ...grpc.CallOption) (*ListMessagesResponse, error)
+	// SendMessage queues a message for transmission.
+	SendMessage(ctx context.Context, in *SendMessageRequest, opts ...grpc.CallOption) (*SendMessageResponse, error)
+	// AbortTransmission stops the current transmission and clears PTT.
+	AbortTransmission(ctx context.Context, in *AbortTransmissionRequest, opts ...grpc.CallOption) (*AbortTransmissionResponse, error)
+	// StreamDecodes sends every message decoded from now on until the
+	// client cancels.
+	StreamDecodes(ctx context.Context, in *StreamDecodesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Message], error)
+}
+
+type jS8DClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewJS8DClient(cc grpc.ClientConnInterface) JS8DClient {
+	return &jS8DClient{cc}
+}
+
+func (c *jS8DClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*Status, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Status)
+	err := c.cc.Invoke(ctx, JS8D_GetStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jS8DClient) ListMessages(ctx context.Context, in *ListMessagesRequest, opts ...grpc.CallOption) (*ListMessagesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMessagesResponse)
+	err := c.cc.Invoke(ctx, JS8D_ListMessages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jS8DClient) SendMessage(ctx context.Context, in *SendMessageRequest, opts ...grpc.CallOption) (*SendMessageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SendMessageResponse)
+	err := c.cc.Invoke(ctx, JS8D_SendMessage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jS8DClient) AbortTransmission(ctx context.Context, in *AbortTransmissionRequest, opts ...grpc.CallOption) (*AbortTransmissionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AbortTransmissionResponse)
+	err := c.cc.Invoke(ctx, JS8D_AbortTransmission_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jS8DClient) StreamDecodes(ctx context.Context, in *StreamDecodesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Message], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &JS8D_ServiceDesc.Streams[0], JS8D_StreamDecodes_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamDecodesRequest, Message]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JS8D_StreamDecodesClient = grpc.ServerStreamingClient[Message]
+
+// JS8DServer is the server API for JS8D service.
+// All implementations must embed UnimplementedJS8DServer
+// for forward compatibility.
+//
+// JS8D controls one js8d engine.
+type JS8DServer interface {
+	// GetStatus returns the station and radio status.
+	GetStatus(context.Context, *GetStatusRequest) (*Status, error)
+	// ListMessages returns stored RX and TX messages, newest first.
+	ListMessages(context.Context, *ListMessagesRequest) (*ListMessagesResponse, error)
+	// SendMessage queues a message for transmission.
+	SendMessage(context.Context, *SendMessageRequest) (*SendMessageResponse, error)
+	// AbortTransmission stops the current transmission and clears PTT.
+	AbortTransmission(context.Context, *AbortTransmissionRequest) (*AbortTransmissionResponse, error)
+	// StreamDecodes sends every message decoded from now on until the
+	// client cancels.
+	StreamDecodes(*StreamDecodesRequest, grpc.ServerStreamingServer[Message]) error
+	mustEmbedUnimplementedJS8DServer()
+}
+
+// UnimplementedJS8DServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedJS8DServer struct{}
+
+func (UnimplementedJS8DServer) GetStatus(context.Context, *GetStatusRequest) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedJS8DServer) ListMessages(context.Context, *ListMessagesRequest) (*ListMessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMessages not implemented")
+}
+func (UnimplementedJS8DServer) SendMessage(context.Context, *SendMessageRequest) (*SendMessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendMessage not implemented")
+}
+func (UnimplementedJS8DServer) AbortTransmission(context.Context, *AbortTransmissionRequest) (*AbortTransmissionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AbortTransmission not implemented")
+}
+func (UnimplementedJS8DServer) StreamDecodes(*StreamDecodesRequest, grpc.ServerStreamingServer[Message]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamDecodes not implemented")
+}
+func (UnimplementedJS8DServer) mustEmbedUnimplementedJS8DServer() {}
+func (UnimplementedJS8DServer) testEmbeddedByValue()              {}
+
+// UnsafeJS8DServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to JS8DServer will
+// result in compilation errors.
+type UnsafeJS8DServer interface {
+	mustEmbedUnimplementedJS8DServer()
+}
+
+func RegisterJS8DServer(s grpc.ServiceRegistrar, srv JS8DServer) {
+	// If the following call pancis, it indicates UnimplementedJS8DServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&JS8D_ServiceDesc, srv)
+}
+
+func _JS8D_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JS8DServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JS8D_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JS8DServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JS8D_ListMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JS8DServer).ListMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JS8D_ListMessages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JS8DServer).ListMessages(ctx, req.(*ListMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JS8D_SendMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JS8DServer).SendMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JS8D_SendMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JS8DServer).SendMessage(ctx, req.(*SendMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JS8D_AbortTransmission_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AbortTransmissionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JS8DServer).AbortTransmission(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JS8D_AbortTransmission_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JS8DServer).AbortTransmission(ctx, req.(*AbortTransmissionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JS8D_StreamDecodes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamDecodesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(JS8DServer).StreamDecodes(m, &grpc.GenericServerStream[StreamDecodesRequest, Message]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JS8D_StreamDecodesServer = grpc.ServerStreamingServer[Message]
+
+// JS8D_ServiceDesc is the grpc.ServiceDesc for JS8D service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var JS8D_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "js8d.v1.JS8D",
+	HandlerType: (*JS8DServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStatus",
+			Handler:    _JS8D_GetStatus_Handler,
+		},
+		{
+			MethodName: "ListMessages",
+			Handler:    _JS8D_ListMessages_Handler,
+		},
+		{
+			MethodName: "SendMessage",
+			Handler:    _JS8D_SendMessage_Handler,
+		},
+		{
+			MethodName: "AbortTransmission",
+			Handler:    _JS8D_AbortTransmission_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamDecodes",
+			Handler:       _JS8D_StreamDecodes_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "js8d/v1/js8d.proto",
+}
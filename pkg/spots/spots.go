@@ -0,0 +1,242 @@
+// Package spots forwards decodes to webhooks as batches of JSON spots, so
+// they can be collected by outside aggregation services or automations
+package spots
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the request
+// body, keyed with the webhook's secret
+const SignatureHeader = "X-Js8d-Signature"
+
+// Agent identifies js8d to webhooks
+const Agent = "js8d"
+
+// pendingBatches is how many batches may wait for a slow webhook before
+// new ones are dropped
+const pendingBatches = 16
+
+// Reporter is the station that heard the spots
+type Reporter struct {
+	Callsign string `json:"callsign"`
+	Grid     string `json:"grid,omitempty"`
+}
+
+// Spot is one decode
+type Spot struct {
+	Time      time.Time `json:"time"`
+	From      string    `json:"from"`
+	To        string    `json:"to,omitempty"`
+	Grid      string    `json:"grid,omitempty"` // Grid square the sender gave in the message
+	Message   string    `json:"message"`
+	SNR       float32   `json:"snr"`
+	Frequency int       `json:"frequency"`        // Hz, dial plus offset
+	Offset    int       `json:"offset,omitempty"` // Audio offset in Hz
+	Band      string    `json:"band,omitempty"`
+	Mode      string    `json:"mode"`
+	Channel   string    `json:"channel,omitempty"`
+}
+
+// Batch is the JSON body of one POST
+type Batch struct {
+	Reporter Reporter `json:"reporter"`
+	Spots    []Spot   `json:"spots"`
+}
+
+// Webhook is a URL batches are POSTed to
+type Webhook struct {
+	URL    string
+	Secret string // Signs each batch when set
+}
+
+// Options control batching and retries
+type Options struct {
+	BatchSize     int           // Spots per POST at most
+	BatchInterval time.Duration // How long a spot waits for others to share its POST
+	Retries       int           // Resends of a failed POST
+	Backoff       time.Duration // Wait before the first resend, doubling for each one after
+
+	// OnError is told about batches a webhook did not take, after any retries
+	OnError func(url string, err error)
+}
+
+// Forwarder collects spots into batches and sends each batch to every
+// webhook. Each webhook has its own queue, so a slow one does not hold the
+// others up.
+type Forwarder struct {
+	reporter Reporter
+	hooks    []Webhook
+	options  Options
+	client   *http.Client
+	spots    chan Spot
+}
+
+// New returns a forwarder for the given webhooks
+func New(reporter Reporter, hooks []Webhook, options Options) *Forwarder {
+	if options.BatchSize <= 0 {
+		options.BatchSize = 50
+	}
+	if options.BatchInterval <= 0 {
+		options.BatchInterval = 30 * time.Second
+	}
+	if options.Backoff <= 0 {
+		options.Backoff = 2 * time.Second
+	}
+	return &Forwarder{
+		reporter: reporter,
+		hooks:    hooks,
+		options:  options,
+		client:   &http.Client{Timeout: 15 * time.Second},
+		spots:    make(chan Spot, options.BatchSize*4),
+	}
+}
+
+// Add queues a spot, returning false if the queue is full and it was dropped
+func (f *Forwarder) Add(spot Spot) bool {
+	select {
+	case f.spots <- spot:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run batches queued spots and sends them until ctx is done. Spots not yet
+// sent by then are dropped.
+func (f *Forwarder) Run(ctx context.Context) {
+	queues := make([]chan []Spot, len(f.hooks))
+	for i, hook := range f.hooks {
+		queues[i] = make(chan []Spot, pendingBatches)
+		go f.deliver(ctx, hook, queues[i])
+	}
+
+	ticker := time.NewTicker(f.options.BatchInterval)
+	defer ticker.Stop()
+
+	var batch []Spot
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		for i, queue := range queues {
+			select {
+			case queue <- batch:
+			default:
+				f.fail(f.hooks[i].URL, fmt.Errorf("%d batches waiting, dropped %d spots", pendingBatches, len(batch)))
+			}
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case spot := <-f.spots:
+			batch = append(batch, spot)
+			if len(batch) >= f.options.BatchSize {
+				flush()
+			}
+
+		case <-ticker.C:
+			flush()
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver sends one webhook's batches in order
+func (f *Forwarder) deliver(ctx context.Context, hook Webhook, queue <-chan []Spot) {
+	for {
+		select {
+		case spots := <-queue:
+			if err := f.send(ctx, hook, spots); err != nil && ctx.Err() == nil {
+				f.fail(hook.URL, err)
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// send POSTs a batch, resending it with backoff after network errors, 429s
+// and server errors
+func (f *Forwarder) send(ctx context.Context, hook Webhook, spots []Spot) error {
+	body, err := json.Marshal(Batch{Reporter: f.reporter, Spots: spots})
+	if err != nil {
+		return err
+	}
+
+	backoff := f.options.Backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := f.post(ctx, hook, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= f.options.Retries {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// post makes one POST, reporting whether a failure is worth retrying
+func (f *Forwarder) post(ctx context.Context, hook Webhook, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", Agent)
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook returned %s", resp.Status)
+	default:
+		return false, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+}
+
+// fail reports a batch a webhook did not take
+func (f *Forwarder) fail(url string, err error) {
+	if f.options.OnError != nil {
+		f.options.OnError(url, err)
+	}
+}
+
+// Sign returns the SignatureHeader value of a body. Receivers compute the
+// same over the raw body and compare with hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
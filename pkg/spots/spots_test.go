@@ -0,0 +1,97 @@
+package spots
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestForwarder(t *testing.T) {
+	var mutex sync.Mutex
+	var batches []Batch
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		requests++
+		if requests == 1 {
+			// The first attempt fails and is retried
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if got := r.Header.Get(SignatureHeader); got != Sign("s3cret", body) {
+			t.Errorf("Bad signature %q", got)
+		}
+
+		var batch Batch
+		if err := json.Unmarshal(body, &batch); err != nil {
+			t.Errorf("Bad body: %v", err)
+		}
+		batches = append(batches, batch)
+	}))
+	defer server.Close()
+
+	forwarder := New(Reporter{Callsign: "K3DEP", Grid: "FN20"}, []Webhook{{URL: server.URL, Secret: "s3cret"}}, Options{
+		BatchSize:     2,
+		BatchInterval: 50 * time.Millisecond,
+		Retries:       2,
+		Backoff:       10 * time.Millisecond,
+		OnError:       func(url string, err error) { t.Errorf("Unexpected error from %s: %v", url, err) },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go forwarder.Run(ctx)
+
+	// Two spots fill a batch, the third goes when the interval is up
+	for _, from := range []string{"N0ABC", "DL1ABC", "W1AW"} {
+		if !forwarder.Add(Spot{Time: time.Now(), From: from, Message: "HB", SNR: -10, Frequency: 14079500, Mode: "JS8"}) {
+			t.Fatal("Spot dropped")
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mutex.Lock()
+		done := len(batches) == 2
+		mutex.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for batches, got %+v", batches)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if len(batches[0].Spots) != 2 || batches[0].Spots[0].From != "N0ABC" || batches[0].Reporter.Callsign != "K3DEP" {
+		t.Errorf("Unexpected first batch %+v", batches[0])
+	}
+	if len(batches[1].Spots) != 1 || batches[1].Spots[0].From != "W1AW" {
+		t.Errorf("Unexpected second batch %+v", batches[1])
+	}
+}
+
+func TestForwarderGivesUp(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	forwarder := New(Reporter{Callsign: "K3DEP"}, []Webhook{{URL: server.URL}}, Options{Retries: 3, Backoff: time.Millisecond})
+
+	// A rejected batch is not resent
+	err := forwarder.send(context.Background(), forwarder.hooks[0], []Spot{{From: "N0ABC"}})
+	if err == nil || requests != 1 {
+		t.Errorf("Expected one attempt and an error, got %d and %v", requests, err)
+	}
+}
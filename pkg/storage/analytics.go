@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// OffsetBucket is the width in Hz of the audio offset ranges decodes are
+// counted in, about the width of a normal speed JS8 signal
+const OffsetBucket = 50
+
+// busiestOffsets is how many offset ranges a summary lists
+const busiestOffsets = 10
+
+// ActivitySummary is decode activity over a time window
+type ActivitySummary struct {
+	Since           time.Time      `json:"since"`
+	TotalDecodes    int            `json:"total_decodes"`
+	UniqueCallsigns int            `json:"unique_callsigns"`
+	Bands           map[string]int `json:"bands"` // Decodes per band
+	Hourly          []HourActivity `json:"hourly"`
+	Daily           []DayActivity  `json:"daily"`
+	Offsets         []OffsetCount  `json:"offsets"` // Busiest offsets first
+}
+
+// HourActivity is the decodes in one hour, by band
+type HourActivity struct {
+	Hour    time.Time      `json:"hour"`
+	Decodes int            `json:"decodes"`
+	Bands   map[string]int `json:"bands"`
+}
+
+// DayActivity is the decodes and distinct stations heard on one UTC day
+type DayActivity struct {
+	Day       string `json:"day"` // YYYY-MM-DD
+	Decodes   int    `json:"decodes"`
+	Callsigns int    `json:"callsigns"`
+}
+
+// OffsetCount is the decodes in one OffsetBucket wide range of audio
+// offsets starting at Offset
+type OffsetCount struct {
+	Offset  int `json:"offset"`
+	Decodes int `json:"decodes"`
+}
+
+// GetActivitySummary counts the decodes since a time by hour, band, day and
+// audio offset. Hours and days are UTC and listed oldest first.
+func (ms *MessageStore) GetActivitySummary(since time.Time) (*ActivitySummary, error) {
+	rows, err := ms.db.Query(`
+		SELECT timestamp, from_callsign, frequency, "offset"
+		FROM messages
+		WHERE direction = 'RX' AND timestamp >= ?
+		ORDER BY timestamp ASC
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query decodes: %w", err)
+	}
+	defer rows.Close()
+
+	summary := &ActivitySummary{
+		Since:   since,
+		Bands:   make(map[string]int),
+		Hourly:  []HourActivity{},
+		Daily:   []DayActivity{},
+		Offsets: []OffsetCount{},
+	}
+	callsigns := make(map[string]bool)
+	dayCallsigns := make(map[string]bool)
+	offsets := make(map[int]int)
+
+	for rows.Next() {
+		var timestamp time.Time
+		var from string
+		var frequency, offset int
+		if err := rows.Scan(&timestamp, &from, &frequency, &offset); err != nil {
+			return nil, fmt.Errorf("failed to scan decode: %w", err)
+		}
+		timestamp = timestamp.UTC()
+		from = strings.ToUpper(from)
+		band := protocol.Band(frequency)
+
+		summary.TotalDecodes++
+		callsigns[from] = true
+		if band != "" {
+			summary.Bands[band]++
+		}
+
+		// Rows arrive in time order, so a new hour or day is always the last
+		hour := timestamp.Truncate(time.Hour)
+		if n := len(summary.Hourly); n == 0 || !summary.Hourly[n-1].Hour.Equal(hour) {
+			summary.Hourly = append(summary.Hourly, HourActivity{Hour: hour, Bands: make(map[string]int)})
+		}
+		current := &summary.Hourly[len(summary.Hourly)-1]
+		current.Decodes++
+		if band != "" {
+			current.Bands[band]++
+		}
+
+		day := timestamp.Format("2006-01-02")
+		if n := len(summary.Daily); n == 0 || summary.Daily[n-1].Day != day {
+			summary.Daily = append(summary.Daily, DayActivity{Day: day})
+			dayCallsigns = make(map[string]bool)
+		}
+		today := &summary.Daily[len(summary.Daily)-1]
+		today.Decodes++
+		if !dayCallsigns[from] {
+			dayCallsigns[from] = true
+			today.Callsigns++
+		}
+
+		// Decodes stored before offsets were recorded have none
+		if offset > 0 {
+			offsets[offset/OffsetBucket*OffsetBucket]++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	summary.UniqueCallsigns = len(callsigns)
+	for offset, decodes := range offsets {
+		summary.Offsets = append(summary.Offsets, OffsetCount{Offset: offset, Decodes: decodes})
+	}
+	sort.Slice(summary.Offsets, func(i, j int) bool {
+		a, b := summary.Offsets[i], summary.Offsets[j]
+		if a.Decodes != b.Decodes {
+			return a.Decodes > b.Decodes
+		}
+		return a.Offset < b.Offset
+	})
+	if len(summary.Offsets) > busiestOffsets {
+		summary.Offsets = summary.Offsets[:busiestOffsets]
+	}
+
+	return summary, nil
+}
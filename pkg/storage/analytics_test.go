@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+func TestGetActivitySummary(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	day := time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC)
+	decodes := []struct {
+		from      string
+		at        time.Time
+		frequency int
+		offset    int
+	}{
+		{"N0ABC", day.Add(-time.Hour + 10*time.Minute), 7079500, 1500},
+		{"N0ABC", day.Add(10 * time.Minute), 14079510, 1510},
+		{"DL1ABC", day.Add(20 * time.Minute), 14079520, 1520},
+		{"n0abc", day.Add(70 * time.Minute), 14080000, 2000},
+		{"W1AW", day.Add(-48 * time.Hour), 14079500, 1500}, // Before the window
+	}
+	for _, d := range decodes {
+		msg := protocol.Message{Timestamp: d.at, From: d.from, Message: "HB", Frequency: d.frequency, Offset: d.offset}
+		if err := store.StoreMessage(msg, "RX", "HEARTBEAT"); err != nil {
+			t.Fatalf("Failed to store message: %v", err)
+		}
+	}
+	sent := protocol.Message{Timestamp: day, From: "K3DEP", To: "N0ABC", Message: "N0ABC SNR?", Frequency: 14079500}
+	if err := store.StoreMessage(sent, "TX", "DIRECTED"); err != nil {
+		t.Fatalf("Failed to store message: %v", err)
+	}
+
+	summary, err := store.GetActivitySummary(day.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to get summary: %v", err)
+	}
+
+	if summary.TotalDecodes != 4 || summary.UniqueCallsigns != 2 {
+		t.Errorf("Expected 4 decodes from 2 stations, got %d from %d", summary.TotalDecodes, summary.UniqueCallsigns)
+	}
+	if summary.Bands["20m"] != 3 || summary.Bands["40m"] != 1 {
+		t.Errorf("Unexpected bands %v", summary.Bands)
+	}
+
+	if len(summary.Hourly) != 3 {
+		t.Fatalf("Expected 3 hours, got %+v", summary.Hourly)
+	}
+	if !summary.Hourly[1].Hour.Equal(day) || summary.Hourly[1].Decodes != 2 || summary.Hourly[1].Bands["20m"] != 2 {
+		t.Errorf("Unexpected first hour of the day %+v", summary.Hourly[1])
+	}
+
+	if len(summary.Daily) != 2 {
+		t.Fatalf("Expected 2 days, got %+v", summary.Daily)
+	}
+	if summary.Daily[1].Day != "2024-01-15" || summary.Daily[1].Decodes != 3 || summary.Daily[1].Callsigns != 2 {
+		t.Errorf("Unexpected day %+v", summary.Daily[1])
+	}
+
+	if len(summary.Offsets) != 2 || summary.Offsets[0] != (OffsetCount{Offset: 1500, Decodes: 3}) {
+		t.Errorf("Expected 1500 Hz busiest, got %+v", summary.Offsets)
+	}
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Decisions made on a CQ
+const (
+	AnswerSent    = "answered"
+	AnswerSkipped = "skipped"
+)
+
+// MaxAnswerDecisions is how many answer decisions are kept; older ones are
+// dropped as new ones are recorded
+const MaxAnswerDecisions = 1000
+
+// AnswerDecision records whether a CQ was answered automatically, and why
+type AnswerDecision struct {
+	ID        int64     `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Callsign  string    `json:"callsign"`
+	Band      string    `json:"band,omitempty"`
+	Frequency int       `json:"frequency"`
+	SNR       float32   `json:"snr"`
+	Message   string    `json:"message"` // The CQ
+	Decision  string    `json:"decision"`
+	Reason    string    `json:"reason"`
+	Reply     string    `json:"reply,omitempty"` // What was sent, when answered
+}
+
+// RecordAnswerDecision stores a decision, setting its ID
+func (ms *MessageStore) RecordAnswerDecision(decision *AnswerDecision) error {
+	id, err := ms.db.insert(`
+		INSERT INTO answer_decisions (timestamp, callsign, band, frequency, snr, message, decision, reason, reply)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, decision.Timestamp, strings.ToUpper(decision.Callsign), decision.Band, decision.Frequency,
+		decision.SNR, decision.Message, decision.Decision, decision.Reason, decision.Reply)
+	if err != nil {
+		return fmt.Errorf("failed to record answer decision: %w", err)
+	}
+	decision.ID = id
+
+	_, err = ms.db.Exec("DELETE FROM answer_decisions WHERE id <= ?", decision.ID-MaxAnswerDecisions)
+	if err != nil {
+		return fmt.Errorf("failed to trim answer decisions: %w", err)
+	}
+	return nil
+}
+
+// GetAnswerDecisions returns the latest answer decisions, newest first
+func (ms *MessageStore) GetAnswerDecisions(limit int) ([]AnswerDecision, error) {
+	rows, err := ms.db.Query(`
+		SELECT id, timestamp, callsign, band, frequency, snr, message, decision, reason, reply
+		FROM answer_decisions
+		ORDER BY id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query answer decisions: %w", err)
+	}
+	defer rows.Close()
+
+	decisions := []AnswerDecision{}
+	for rows.Next() {
+		var d AnswerDecision
+		err := rows.Scan(&d.ID, &d.Timestamp, &d.Callsign, &d.Band, &d.Frequency, &d.SNR,
+			&d.Message, &d.Decision, &d.Reason, &d.Reply)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan answer decision: %w", err)
+		}
+		decisions = append(decisions, d)
+	}
+
+	return decisions, rows.Err()
+}
+
+// WorkedGrid reports whether a message has been sent to a station in the
+// grid square, compared by its first four characters
+func (ms *MessageStore) WorkedGrid(grid string) (bool, error) {
+	if len(grid) < 4 {
+		return false, fmt.Errorf("invalid grid %q", grid)
+	}
+
+	var worked bool
+	err := ms.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM stations s
+			JOIN messages tx ON tx.direction = 'TX' AND UPPER(tx.to_callsign) = s.callsign
+			WHERE UPPER(SUBSTR(s.grid, 1, 4)) = ?
+		)
+	`, strings.ToUpper(grid[:4])).Scan(&worked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check worked grid: %w", err)
+	}
+	return worked, nil
+}
+
+// WorkedCountry reports whether a message has been sent to a station the
+// callbook placed in the country
+func (ms *MessageStore) WorkedCountry(country string) (bool, error) {
+	var worked bool
+	err := ms.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM stations s
+			JOIN messages tx ON tx.direction = 'TX' AND UPPER(tx.to_callsign) = s.callsign
+			WHERE UPPER(s.country) = UPPER(?)
+		)
+	`, country).Scan(&worked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check worked country: %w", err)
+	}
+	return worked, nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+func TestAnswerDecisions(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	for i, call := range []string{"k1abc", "DL1ABC"} {
+		decision := &AnswerDecision{
+			Timestamp: time.Now(),
+			Callsign:  call,
+			Band:      "20m",
+			Message:   "CQ CQ " + call,
+			Decision:  AnswerSkipped,
+			Reason:    "no rule matched",
+		}
+		if err := store.RecordAnswerDecision(decision); err != nil {
+			t.Fatalf("Failed to record decision: %v", err)
+		}
+		if decision.ID != int64(i+1) {
+			t.Errorf("Expected decision ID %d, got %d", i+1, decision.ID)
+		}
+	}
+
+	decisions, err := store.GetAnswerDecisions(10)
+	if err != nil {
+		t.Fatalf("Failed to get decisions: %v", err)
+	}
+	if len(decisions) != 2 || decisions[0].Callsign != "DL1ABC" || decisions[1].Callsign != "K1ABC" {
+		t.Errorf("Expected DL1ABC then K1ABC, got %+v", decisions)
+	}
+}
+
+func TestWorked(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	if err := store.RecordHeard("DL1ABC", "JO62QM", time.Now(), -10); err != nil {
+		t.Fatalf("Failed to record station: %v", err)
+	}
+	if err := store.SetCallbookEntry("DL1ABC", &CallbookEntry{Country: "Germany"}); err != nil {
+		t.Fatalf("Failed to record callbook entry: %v", err)
+	}
+
+	if worked, err := store.WorkedGrid("JO62"); worked || err != nil {
+		t.Errorf("Expected JO62 not worked before sending to it, got %v, %v", worked, err)
+	}
+
+	sent := protocol.Message{Timestamp: time.Now(), From: "N0CALL", To: "DL1ABC", Message: "DL1ABC -10"}
+	if err := store.StoreMessage(sent, "TX", "DIRECTED"); err != nil {
+		t.Fatalf("Failed to store message: %v", err)
+	}
+
+	if worked, err := store.WorkedGrid("jo62ab"); !worked || err != nil {
+		t.Errorf("Expected JO62 worked, got %v, %v", worked, err)
+	}
+	if worked, _ := store.WorkedGrid("JO63"); worked {
+		t.Error("Expected JO63 not worked")
+	}
+	if worked, err := store.WorkedCountry("germany"); !worked || err != nil {
+		t.Errorf("Expected Germany worked, got %v, %v", worked, err)
+	}
+	if worked, _ := store.WorkedCountry("Japan"); worked {
+		t.Error("Expected Japan not worked")
+	}
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// AutoListEntry is a callsign automatic functions never answer, on the
+// block list, or one of those they are kept to, on the allow list. A
+// callsign ending in * is a prefix, such as VK* for every VK station.
+type AutoListEntry struct {
+	ID        int64     `json:"id"`
+	List      string    `json:"list"` // protocol.AutoListBlock or protocol.AutoListAllow
+	Callsign  string    `json:"callsign"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddAutoListEntry stores a new block or allow list entry and sets its ID
+// and creation time
+func (ms *MessageStore) AddAutoListEntry(entry *AutoListEntry) error {
+	entry.List = strings.ToLower(entry.List)
+	entry.Callsign = strings.ToUpper(strings.TrimSpace(entry.Callsign))
+	if entry.List != protocol.AutoListBlock && entry.List != protocol.AutoListAllow {
+		return fmt.Errorf("unknown list %q", entry.List)
+	}
+	call := strings.TrimSuffix(entry.Callsign, "*")
+	if call == "" || strings.ContainsAny(call, "* :") {
+		return fmt.Errorf("%q is not a callsign or prefix", entry.Callsign)
+	}
+
+	entry.CreatedAt = time.Now()
+	id, err := ms.db.insert(`
+		INSERT INTO auto_list (list, callsign, note, created_at)
+		VALUES (?, ?, ?, ?)
+	`, entry.List, entry.Callsign, strings.TrimSpace(entry.Note), entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add %s list entry: %w", entry.List, err)
+	}
+	entry.ID = id
+	return nil
+}
+
+// GetAutoList returns every block and allow list entry, oldest first
+func (ms *MessageStore) GetAutoList() ([]AutoListEntry, error) {
+	rows, err := ms.db.Query(`SELECT id, list, callsign, note, created_at FROM auto_list ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the auto list: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []AutoListEntry{}
+	for rows.Next() {
+		var entry AutoListEntry
+		if err := rows.Scan(&entry.ID, &entry.List, &entry.Callsign, &entry.Note, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan auto list entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// DeleteAutoListEntry removes a block or allow list entry, reporting
+// whether there was one to remove
+func (ms *MessageStore) DeleteAutoListEntry(id int64) (bool, error) {
+	result, err := ms.db.Exec("DELETE FROM auto_list WHERE id = ?", id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete auto list entry: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
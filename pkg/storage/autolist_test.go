@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+func TestAutoList(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	blocked := &AutoListEntry{List: "BLOCK", Callsign: " n0abc ", Note: "Keeps calling"}
+	if err := store.AddAutoListEntry(blocked); err != nil {
+		t.Fatalf("Failed to add entry: %v", err)
+	}
+	prefix := &AutoListEntry{List: protocol.AutoListAllow, Callsign: "k3*"}
+	if err := store.AddAutoListEntry(prefix); err != nil {
+		t.Fatalf("Failed to add entry: %v", err)
+	}
+
+	for _, bad := range []*AutoListEntry{
+		{List: protocol.AutoListBlock},
+		{List: protocol.AutoListBlock, Callsign: "*"},
+		{List: protocol.AutoListAllow, Callsign: "K*3"},
+		{List: "ignore", Callsign: "N0ABC"},
+	} {
+		if err := store.AddAutoListEntry(bad); err == nil {
+			t.Errorf("Expected an error adding %+v", bad)
+		}
+	}
+
+	entries, err := store.GetAutoList()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d, %v", len(entries), err)
+	}
+	if entries[0].List != protocol.AutoListBlock || entries[0].Callsign != "N0ABC" || entries[0].Note != "Keeps calling" || entries[1].Callsign != "K3*" {
+		t.Errorf("Expected the entries as added, got %+v", entries)
+	}
+
+	if deleted, err := store.DeleteAutoListEntry(blocked.ID); !deleted || err != nil {
+		t.Errorf("Expected the block entry deleted, got %v, %v", deleted, err)
+	}
+	if deleted, _ := store.DeleteAutoListEntry(blocked.ID); deleted {
+		t.Error("Expected nothing to delete the second time")
+	}
+	if entries, _ := store.GetAutoList(); len(entries) != 1 || entries[0].ID != prefix.ID {
+		t.Errorf("Expected only the allow entry left, got %+v", entries)
+	}
+}
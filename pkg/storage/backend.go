@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// ErrUnsupported is returned for what a backend cannot do, such as backing
+// up a PostgreSQL database, which is left to pg_dump, or one in memory
+var ErrUnsupported = errors.New("not supported by this storage backend")
+
+// StorageBackend keeps the messages, stations, QSOs and the rest the engine
+// records. MessageStore implements it on SQLite, in memory and on
+// PostgreSQL.
+type StorageBackend interface {
+	// Messages
+	StoreMessage(msg protocol.Message, direction string, messageType string) error
+	StoreReceived(msg protocol.Message, messageType string) (protocol.Message, bool, error)
+	GetMessages(query MessageQuery) ([]protocol.Message, error)
+	GetMessagesByCallsign(callsign string, limit int, offset int) ([]protocol.Message, error)
+	GetRecentMessages(limit int) ([]protocol.Message, error)
+	GetUnreadMessages() ([]protocol.Message, error)
+	SearchMessages(searchTerm string, limit int) ([]protocol.Message, error)
+	GetConversations(limit int) ([]ConversationSummary, error)
+	MarkMessagesAsRead(callsign string) error
+	GetMessageStats() (*MessageStats, error)
+	GetMessageCount() (int, error)
+	GetUnreadCount() (int, error)
+	CleanupOldMessages() error
+	LastDirected(direction, from, to string) (time.Time, error)
+	GetSessions(callsign string, gap time.Duration) ([]Session, error)
+	GetRawFrame(messageID int) (*RawFrame, error)
+	ClearSnippets(names []string) (int, error)
+
+	// TX queue and delivery tracking
+	QueueMessage(msg protocol.Message, messageType string) (protocol.Message, error)
+	SetMessageStatus(id int, status string) error
+	SetMessageDelivery(id int, delivery string) error
+	SetMessageResend(id, resends int, submode string, offset, frequency int) error
+	ExpireDeliveries() (int, error)
+	GetQueuedMessages() ([]protocol.Message, error)
+
+	// Stations and DXCC entities
+	RecordHeard(callsign, grid string, heard time.Time, snr float32) error
+	NeedsLookup(callsign string, ttl time.Duration) (bool, error)
+	SetCallbookEntry(callsign string, entry *CallbookEntry) error
+	UpdateStation(callsign string, update StationUpdate) (*Station, error)
+	GetStation(callsign string) (*Station, error)
+	GetStations(search string, limit int) ([]Station, error)
+	GetStationGrids(callsigns []string) (map[string]string, error)
+	GetHeardStations(since time.Time) ([]HeardStation, error)
+	RecordEntity(callsign, prefix string) (bool, error)
+	RetagEntities(resolve func(callsign string) string) (int, error)
+	GetEntityActivity(since time.Time) ([]EntityActivity, error)
+	RecordSNR(callsign string, heard time.Time, snr float32, frequency int) error
+	GetSNRHistory(callsign string, from, to time.Time) ([]SNRPoint, error)
+	PruneSNRHistory(before time.Time) (int, error)
+
+	// QSO log
+	LogQSO(qso *QSO, gap time.Duration) (bool, error)
+	GetQSOs(callsign string, limit int) ([]QSO, error)
+	GetQSO(id int64) (*QSO, error)
+	GetPendingQSOs(service string) ([]QSO, error)
+	MarkUploaded(service string, ids []int64, at time.Time) (int, error)
+	WorkedGrid(grid string) (bool, error)
+	WorkedCountry(country string) (bool, error)
+
+	// Answering CQs
+	RecordAnswerDecision(decision *AnswerDecision) error
+	GetAnswerDecisions(limit int) ([]AnswerDecision, error)
+
+	// Macros, forms and file transfers
+	SaveMacro(name, text string) (*Macro, error)
+	GetMacro(name string) (*Macro, error)
+	GetMacros() ([]Macro, error)
+	DeleteMacro(name string) (bool, error)
+	SaveForm(form *StoredForm) error
+	GetForms(direction string, limit int) ([]StoredForm, error)
+	SaveFileTransfer(t *FileTransfer) error
+	GetFileTransfers(limit int) ([]FileTransfer, error)
+	GetFileTransfer(id int64) (*FileTransfer, error)
+
+	// Decode filters
+	AddFilter(filter *Filter) error
+	GetFilters() ([]Filter, error)
+	DeleteFilter(id int64) (bool, error)
+
+	// Nets run as net control
+	OpenNet(net *Net) error
+	CloseNet(id int64, at time.Time) error
+	CloseOpenNets(at time.Time) (int, error)
+	RecordCheckin(netID int64, checkin *NetCheckin) (bool, error)
+	GetNets(limit int) ([]Net, error)
+	GetNet(id int64) (*Net, error)
+
+	// Telemetry heard from other stations
+	SaveTelemetry(t *Telemetry) error
+	GetTelemetry(callsign string, from, to time.Time) ([]Telemetry, error)
+	GetTelemetryStations() ([]Telemetry, error)
+	PruneTelemetry(before time.Time) (int, error)
+
+	// Block and allow lists for automatic replies
+	AddAutoListEntry(entry *AutoListEntry) error
+	GetAutoList() ([]AutoListEntry, error)
+	DeleteAutoListEntry(id int64) (bool, error)
+
+	// Stats history
+	GetActivitySummary(since time.Time) (*ActivitySummary, error)
+	RecordStatsMinute(minute time.Time, noiseFloor *float64) error
+	RollupStats(minuteBefore, hourBefore time.Time) (int, error)
+	GetStatsSeries(resolution string, from, to time.Time) ([]StatsPoint, error)
+	RecordBandNoise(band string, at time.Time, total float64, samples int) error
+	PruneBandNoise(before time.Time) (int, error)
+	GetBandNoise(from, to time.Time) ([]BandNoise, error)
+
+	// Maintenance, which may return ErrUnsupported
+	Snapshot(path string) error
+	Backup(path string, keep int) error
+	IntegrityCheck() ([]string, error)
+
+	Close() error
+}
+
+var _ StorageBackend = (*MessageStore)(nil)
+
+// NewPostgresStore creates a message store in a PostgreSQL database, which
+// several nodes may log to. The driver is only linked into js8d built with
+// -tags postgres.
+func NewPostgresStore(dsn string, maxMessages int) (*MessageStore, error) {
+	db, err := sql.Open(BackendPostgres, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w (build js8d with -tags postgres for PostgreSQL)", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+	}
+
+	store := &MessageStore{
+		db:          &database{DB: db, dialect: BackendPostgres},
+		maxMessages: maxMessages,
+	}
+	if err := store.createSchema(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize message store: %w", err)
+	}
+
+	logger.Infof("Message store initialized on PostgreSQL (max %d messages)", maxMessages)
+	return store, nil
+}
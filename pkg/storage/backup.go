@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// RestoreSuffix marks a database staged by StageRestore, swapped in for the
+// database at the next start
+const RestoreSuffix = ".restore"
+
+// backupStepPages is how many pages the online backup copies at a time,
+// letting messages be stored in between
+const backupStepPages = 256
+
+// sqliteHeader starts every SQLite database file
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// Snapshot writes a consistent copy of the database to path, which must not
+// exist yet. Messages keep being stored while it runs. SQLite files only.
+func (ms *MessageStore) Snapshot(path string) error {
+	if ms.db.dialect != BackendSQLite || ms.memory {
+		return ErrUnsupported
+	}
+	if _, err := ms.db.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	return nil
+}
+
+// Backup copies the database to path with SQLite's online backup API, a
+// few pages at a time so messages keep being stored meanwhile. The copy
+// already at path moves to path.1, that one to path.2 and so on, keeping
+// keep copies in all. SQLite files only.
+func (ms *MessageStore) Backup(path string, keep int) error {
+	if ms.db.dialect != BackendSQLite || ms.memory {
+		return ErrUnsupported
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	if err := ms.backupTo(tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	// Only a finished copy pushes the older ones down
+	for i := keep - 1; i >= 1; i-- {
+		newer := path
+		if i > 1 {
+			newer = fmt.Sprintf("%s.%d", path, i-1)
+		}
+		if err := os.Rename(newer, fmt.Sprintf("%s.%d", path, i)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate backups: %w", err)
+		}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	return nil
+}
+
+// backupTo runs the online backup into a new database at path
+func (ms *MessageStore) backupTo(path string) error {
+	ctx := context.Background()
+
+	dest, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+	srcConn, err := ms.db.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destDriver interface{}) error {
+		return srcConn.Raw(func(srcDriver interface{}) error {
+			backup, err := destDriver.(*sqlite3.SQLiteConn).Backup("main", srcDriver.(*sqlite3.SQLiteConn), "main")
+			if err != nil {
+				return err
+			}
+			for {
+				done, err := backup.Step(backupStepPages)
+				if err != nil {
+					backup.Finish()
+					return err
+				}
+				if done {
+					return backup.Finish()
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	})
+}
+
+// IntegrityCheck runs PRAGMA integrity_check and returns the problems it
+// found, none when the database is sound. SQLite files only.
+func (ms *MessageStore) IntegrityCheck() ([]string, error) {
+	if ms.db.dialect != BackendSQLite || ms.memory {
+		return nil, ErrUnsupported
+	}
+	rows, err := ms.db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check database: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return nil, fmt.Errorf("failed to check database: %w", err)
+		}
+		if !strings.EqualFold(result, "ok") {
+			problems = append(problems, result)
+		}
+	}
+	return problems, rows.Err()
+}
+
+// ValidateDatabase checks that path is a SQLite database holding js8d
+// messages
+func ValidateDatabase(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, len(sqliteHeader))
+	_, err = io.ReadFull(file, header)
+	file.Close()
+	if err != nil || !bytes.Equal(header, sqliteHeader) {
+		return fmt.Errorf("%s is not a SQLite database", path)
+	}
+
+	db, err := sql.Open("sqlite3", path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM messages").Scan(&count); err != nil {
+		return fmt.Errorf("%s has no js8d messages: %w", path, err)
+	}
+	return nil
+}
+
+// StageRestore validates the database at src and copies it next to dbPath,
+// to replace dbPath when the message store is next opened
+func StageRestore(src, dbPath string) error {
+	if dbPath == "" {
+		dbPath = defaultDatabasePath
+	}
+	if err := ValidateDatabase(src); err != nil {
+		return err
+	}
+	if err := copyFile(src, dbPath+RestoreSuffix); err != nil {
+		os.Remove(dbPath + RestoreSuffix)
+		return fmt.Errorf("failed to stage database: %w", err)
+	}
+	return nil
+}
+
+// copyFile copies src to dest, replacing dest
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// applyRestore swaps a database staged by StageRestore in for dbPath,
+// dropping the old database's write-ahead log along with it
+func applyRestore(dbPath string) error {
+	staged := dbPath + RestoreSuffix
+	if _, err := os.Stat(staged); err != nil {
+		return nil
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(staged, dbPath); err != nil {
+		return err
+	}
+	logger.Infof("Restored database %s from backup", dbPath)
+	return nil
+}
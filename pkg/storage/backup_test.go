@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "js8d.db")
+	store, err := NewMessageStore(dbPath, 1000)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	msg := protocol.Message{Timestamp: time.Now(), From: "N0CALL", To: "K1ABC", Message: "BACKED UP", SNR: -10, Frequency: 1500}
+	if err := store.StoreMessage(msg, "RX", "directed"); err != nil {
+		t.Fatalf("Failed to store message: %v", err)
+	}
+
+	snapshot := filepath.Join(dir, "snapshot.db")
+	if err := store.Snapshot(snapshot); err != nil {
+		t.Fatalf("Failed to snapshot: %v", err)
+	}
+	if err := ValidateDatabase(snapshot); err != nil {
+		t.Fatalf("Snapshot failed validation: %v", err)
+	}
+
+	// Messages stored after the snapshot go when it is restored
+	msg.Message = "AFTER"
+	store.StoreMessage(msg, "RX", "directed")
+	if err := StageRestore(snapshot, dbPath); err != nil {
+		t.Fatalf("Failed to stage restore: %v", err)
+	}
+	store.Close()
+
+	store, err = NewMessageStore(dbPath, 1000)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	if _, err := os.Stat(dbPath + RestoreSuffix); !os.IsNotExist(err) {
+		t.Error("Expected the staged database to be swapped in")
+	}
+	var count int
+	store.db.QueryRow("SELECT COUNT(*) FROM messages").Scan(&count)
+	if count != 1 {
+		t.Errorf("Expected the 1 message from the snapshot, got %d", count)
+	}
+}
+
+func TestValidateDatabase(t *testing.T) {
+	dir := t.TempDir()
+
+	text := filepath.Join(dir, "text.db")
+	os.WriteFile(text, []byte("not a database at all"), 0644)
+	if err := ValidateDatabase(text); err == nil {
+		t.Error("Expected an error for a file that is not SQLite")
+	}
+	if err := StageRestore(text, filepath.Join(dir, "js8d.db")); err == nil {
+		t.Error("Expected staging an invalid database to fail")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "js8d.db"+RestoreSuffix)); !os.IsNotExist(err) {
+		t.Error("Expected nothing staged for an invalid database")
+	}
+}
+
+func TestBackupRotation(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewMessageStore(filepath.Join(dir, "js8d.db"), 1000)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	problems, err := store.IntegrityCheck()
+	if err != nil || len(problems) != 0 {
+		t.Fatalf("Expected a sound database, got %v, %v", problems, err)
+	}
+
+	backupPath := filepath.Join(dir, "backup", "js8d.db")
+	for i := 0; i < 3; i++ {
+		msg := protocol.Message{Timestamp: time.Now(), From: "N0CALL", To: "K1ABC", Message: "BACKUP", Frequency: 1500}
+		store.StoreMessage(msg, "RX", "directed")
+		if err := store.Backup(backupPath, 2); err != nil {
+			t.Fatalf("Backup %d failed: %v", i+1, err)
+		}
+	}
+
+	// The newest copy has all three messages, the one before two
+	for path, want := range map[string]int{backupPath: 3, backupPath + ".1": 2} {
+		if err := ValidateDatabase(path); err != nil {
+			t.Fatalf("Backup %s failed validation: %v", path, err)
+		}
+		backup, err := NewMessageStore(path, 1000)
+		if err != nil {
+			t.Fatalf("Failed to open backup %s: %v", path, err)
+		}
+		var count int
+		backup.db.QueryRow("SELECT COUNT(*) FROM messages").Scan(&count)
+		backup.Close()
+		if count != want {
+			t.Errorf("Expected %d messages in %s, got %d", want, path, count)
+		}
+	}
+	if _, err := os.Stat(backupPath + ".2"); !os.IsNotExist(err) {
+		t.Error("Expected only backup_keep copies kept")
+	}
+}
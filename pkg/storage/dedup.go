@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// DedupWindow is how far apart two decodes of the same text from the same
+// station in the same submode may be and still be one message, as when
+// overlapping decode passes, both RX channels or a restart mid-cycle
+// decode it twice
+const DedupWindow = 2 * time.Second
+
+// ErrDuplicate is returned by StoreReceived for a message already stored
+var ErrDuplicate = errors.New("duplicate message")
+
+// isDuplicate reports whether a received message was already stored within
+// DedupWindow of it
+func (ms *MessageStore) isDuplicate(msg protocol.Message) (bool, error) {
+	// Timestamps are compared here rather than in SQL, where they are text
+	// that may be in different time zones
+	rows, err := ms.db.Query(`
+		SELECT timestamp FROM messages
+		WHERE direction = 'RX' AND from_callsign = ? AND message_text = ? AND mode = ? AND submode = ?
+		ORDER BY id DESC
+		LIMIT 10
+	`, msg.From, msg.Message, msg.Mode, msg.Submode)
+	if err != nil {
+		return false, fmt.Errorf("failed to look for duplicates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stored time.Time
+		if err := rows.Scan(&stored); err != nil {
+			return false, fmt.Errorf("failed to scan duplicate: %w", err)
+		}
+		if d := stored.Sub(msg.Timestamp); d <= DedupWindow && d >= -DedupWindow {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// countDuplicate adds a dropped duplicate to the message stats
+func (ms *MessageStore) countDuplicate() error {
+	_, err := ms.db.Exec(`
+		UPDATE message_stats SET
+			duplicates = duplicates + 1,
+			last_duplicate = ?
+		WHERE id = 1
+	`, time.Now())
+	return err
+}
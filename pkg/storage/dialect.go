@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Backends the message store can keep its tables in
+const (
+	BackendSQLite   = "sqlite"
+	BackendPostgres = "postgres"
+	BackendMemory   = "memory"
+)
+
+// dialect is the SQL flavour of a backend. Queries are written for SQLite
+// with ? placeholders and rewritten for PostgreSQL as they run.
+type dialect string
+
+// likeOperator finds LIKE, which is case-insensitive in SQLite only
+var likeOperator = regexp.MustCompile(`\bLIKE\b`)
+
+// postgresTypes maps SQLite column types to PostgreSQL ones
+var postgresTypes = strings.NewReplacer(
+	"INTEGER PRIMARY KEY AUTOINCREMENT", "BIGSERIAL PRIMARY KEY",
+	"INTEGER", "BIGINT",
+	"DATETIME", "TIMESTAMPTZ",
+	"REAL", "DOUBLE PRECISION",
+	"BLOB", "BYTEA",
+)
+
+// rebind rewrites a query for the dialect: $1, $2... for PostgreSQL's
+// placeholders and ILIKE for LIKE
+func (d dialect) rebind(query string) string {
+	if d != BackendPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n, quoted := 0, false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if c == '\'' {
+			quoted = !quoted
+		}
+		if c == '?' && !quoted {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return likeOperator.ReplaceAllString(b.String(), "ILIKE")
+}
+
+// schema rewrites the column types of table definitions for the dialect
+func (d dialect) schema(ddl string) string {
+	if d != BackendPostgres {
+		return ddl
+	}
+	return postgresTypes.Replace(ddl)
+}
+
+// database runs queries written for SQLite on any backend
+type database struct {
+	*sql.DB
+	dialect dialect
+}
+
+func (db *database) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.DB.Exec(db.dialect.rebind(query), args...)
+}
+
+func (db *database) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DB.Query(db.dialect.rebind(query), args...)
+}
+
+func (db *database) QueryRow(query string, args ...interface{}) *sql.Row {
+	return db.DB.QueryRow(db.dialect.rebind(query), args...)
+}
+
+func (db *database) Begin() (*transaction, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &transaction{Tx: tx, dialect: db.dialect}, nil
+}
+
+// insert runs an INSERT and returns the id of the new row
+func (db *database) insert(query string, args ...interface{}) (int64, error) {
+	return insertID(db.dialect, db.DB, query, args)
+}
+
+// transaction is a database transaction running queries written for SQLite
+type transaction struct {
+	*sql.Tx
+	dialect dialect
+}
+
+func (tx *transaction) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.Tx.Exec(tx.dialect.rebind(query), args...)
+}
+
+func (tx *transaction) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.Tx.Query(tx.dialect.rebind(query), args...)
+}
+
+func (tx *transaction) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.Tx.QueryRow(tx.dialect.rebind(query), args...)
+}
+
+// insert runs an INSERT and returns the id of the new row
+func (tx *transaction) insert(query string, args ...interface{}) (int64, error) {
+	return insertID(tx.dialect, tx.Tx, query, args)
+}
+
+// execer is a database or transaction
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// insertID runs an INSERT and returns the new row's id, which PostgreSQL
+// only returns when asked and SQLite keeps for LastInsertId
+func insertID(d dialect, e execer, query string, args []interface{}) (int64, error) {
+	if d == BackendPostgres {
+		var id int64
+		err := e.QueryRow(d.rebind(strings.TrimSpace(query)+" RETURNING id"), args...).Scan(&id)
+		return id, err
+	}
+	result, err := e.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
@@ -0,0 +1,25 @@
+package storage
+
+import "testing"
+
+func TestDialectRebind(t *testing.T) {
+	query := "SELECT id FROM messages WHERE from_callsign = ? AND message_text LIKE ? AND status != '?'"
+	if got := dialect(BackendSQLite).rebind(query); got != query {
+		t.Errorf("Expected SQLite queries unchanged, got %q", got)
+	}
+	want := "SELECT id FROM messages WHERE from_callsign = $1 AND message_text ILIKE $2 AND status != '?'"
+	if got := dialect(BackendPostgres).rebind(query); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestDialectSchema(t *testing.T) {
+	ddl := "id INTEGER PRIMARY KEY AUTOINCREMENT, frequency INTEGER NOT NULL, snr REAL, seen DATETIME, content BLOB"
+	want := "id BIGSERIAL PRIMARY KEY, frequency BIGINT NOT NULL, snr DOUBLE PRECISION, seen TIMESTAMPTZ, content BYTEA"
+	if got := dialect(BackendPostgres).schema(ddl); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := dialect(BackendSQLite).schema(ddl); got != ddl {
+		t.Errorf("Expected SQLite schema unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EntityActivity is the stations heard from one DXCC entity
+type EntityActivity struct {
+	Prefix     string    `json:"prefix"`              // Primary prefix of the entity
+	Name       string    `json:"name,omitempty"`      // Filled in by the engine
+	Continent  string    `json:"continent,omitempty"` // Filled in by the engine
+	Stations   int       `json:"stations"`            // Heard in the window
+	FirstHeard time.Time `json:"first_heard"`         // When its first station was ever heard
+	New        bool      `json:"new"`                 // First heard within the window
+}
+
+// RecordEntity tags a station with the primary prefix of its DXCC entity,
+// reporting whether no station had been tagged with it before
+func (ms *MessageStore) RecordEntity(callsign, prefix string) (bool, error) {
+	var known bool
+	err := ms.db.QueryRow("SELECT EXISTS (SELECT 1 FROM stations WHERE entity = ?)", prefix).Scan(&known)
+	if err != nil {
+		return false, fmt.Errorf("failed to check entity: %w", err)
+	}
+
+	_, err = ms.db.Exec("UPDATE stations SET entity = ? WHERE callsign = ?", prefix, strings.ToUpper(callsign))
+	if err != nil {
+		return false, fmt.Errorf("failed to record entity: %w", err)
+	}
+	return !known, nil
+}
+
+// RetagEntities tags every station with the prefix resolve returns for its
+// callsign, after the country file changes, returning how many changed
+func (ms *MessageStore) RetagEntities(resolve func(callsign string) string) (int, error) {
+	rows, err := ms.db.Query("SELECT callsign, entity FROM stations")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query stations: %w", err)
+	}
+	changed := make(map[string]string)
+	for rows.Next() {
+		var callsign, entity string
+		if err := rows.Scan(&callsign, &entity); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan station: %w", err)
+		}
+		if prefix := resolve(callsign); prefix != entity {
+			changed[callsign] = prefix
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(changed) == 0 {
+		return 0, nil
+	}
+
+	tx, err := ms.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	for callsign, prefix := range changed {
+		if _, err := tx.Exec("UPDATE stations SET entity = ? WHERE callsign = ?", prefix, callsign); err != nil {
+			return 0, fmt.Errorf("failed to tag %s: %w", callsign, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit entities: %w", err)
+	}
+	return len(changed), nil
+}
+
+// GetEntityActivity counts the stations heard since a time by DXCC entity,
+// most stations first
+func (ms *MessageStore) GetEntityActivity(since time.Time) ([]EntityActivity, error) {
+	rows, err := ms.db.Query(`
+		SELECT entity, first_heard, last_heard
+		FROM stations
+		WHERE entity != '' AND first_heard IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entity activity: %w", err)
+	}
+	defer rows.Close()
+
+	byPrefix := make(map[string]*EntityActivity)
+	for rows.Next() {
+		var prefix string
+		var firstHeard, lastHeard sql.NullTime
+		if err := rows.Scan(&prefix, &firstHeard, &lastHeard); err != nil {
+			return nil, fmt.Errorf("failed to scan entity activity: %w", err)
+		}
+
+		a, ok := byPrefix[prefix]
+		if !ok {
+			a = &EntityActivity{Prefix: prefix, FirstHeard: firstHeard.Time}
+			byPrefix[prefix] = a
+		}
+		if firstHeard.Time.Before(a.FirstHeard) {
+			a.FirstHeard = firstHeard.Time
+		}
+		if lastHeard.Valid && !lastHeard.Time.Before(since) {
+			a.Stations++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	entities := []EntityActivity{}
+	for _, a := range byPrefix {
+		if a.Stations == 0 {
+			continue
+		}
+		a.New = !a.FirstHeard.Before(since)
+		entities = append(entities, *a)
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		if entities[i].Stations != entities[j].Stations {
+			return entities[i].Stations > entities[j].Stations
+		}
+		return entities[i].Prefix < entities[j].Prefix
+	})
+	return entities, nil
+}
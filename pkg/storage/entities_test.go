@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEntities(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	now := time.Now()
+	heard := map[string]time.Time{
+		"DL1ABC": now.Add(-48 * time.Hour),
+		"DJ2XYZ": now.Add(-time.Hour),
+		"JA1ABC": now.Add(-time.Hour),
+	}
+	for callsign, at := range heard {
+		if err := store.RecordHeard(callsign, "", at, -10); err != nil {
+			t.Fatalf("Failed to record %s: %v", callsign, err)
+		}
+	}
+
+	if isNew, err := store.RecordEntity("DL1ABC", "DL"); !isNew || err != nil {
+		t.Errorf("Expected DL new, got %v, %v", isNew, err)
+	}
+	if isNew, _ := store.RecordEntity("DL1ABC", "DL"); isNew {
+		t.Error("Expected DL not new the second time")
+	}
+
+	// Tag the rest as a newly loaded country file would
+	changed, err := store.RetagEntities(func(callsign string) string {
+		if callsign == "JA1ABC" {
+			return "JA"
+		}
+		return "DL"
+	})
+	if err != nil || changed != 2 {
+		t.Errorf("Expected 2 stations retagged, got %d, %v", changed, err)
+	}
+
+	activity, err := store.GetEntityActivity(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to get entity activity: %v", err)
+	}
+	if len(activity) != 2 {
+		t.Fatalf("Expected 2 entities, got %+v", activity)
+	}
+	if activity[0].Prefix != "DL" || activity[0].Stations != 1 || activity[0].New {
+		t.Errorf("Expected DL heard before the window, got %+v", activity[0])
+	}
+	if activity[1].Prefix != "JA" || activity[1].Stations != 1 || !activity[1].New {
+		t.Errorf("Expected JA new in the window, got %+v", activity[1])
+	}
+}
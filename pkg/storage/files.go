@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FileTransfer is a file sent or received as transfer frames
+type FileTransfer struct {
+	ID         int64     `json:"id"`
+	TransferID uint8     `json:"transfer_id"` // The id its frames carry
+	Direction  string    `json:"direction"`   // RX or TX
+	Name       string    `json:"name"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Size       int       `json:"size"`   // Bytes, once known
+	Frames     int       `json:"frames"` // Frames in the transfer
+	Done       int       `json:"done"`   // Frames sent or received so far
+	Rounds     int       `json:"rounds"` // Polls sent or answered
+	Status     string    `json:"status"` // One of the protocol.File... statuses
+	Error      string    `json:"error,omitempty"`
+	Started    time.Time `json:"started"`
+	Updated    time.Time `json:"updated"`
+	Content    []byte    `json:"-"`
+}
+
+// SaveFileTransfer stores a new transfer, setting its ID, or updates a
+// stored one
+func (ms *MessageStore) SaveFileTransfer(t *FileTransfer) error {
+	t.Updated = time.Now()
+	if t.ID == 0 {
+		if t.Started.IsZero() {
+			t.Started = t.Updated
+		}
+		id, err := ms.db.insert(`
+			INSERT INTO file_transfers (transfer_id, direction, name, from_callsign, to_callsign, size, frames,
+				done, rounds, status, error, content, started_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, t.TransferID, t.Direction, t.Name, strings.ToUpper(t.From), strings.ToUpper(t.To), t.Size, t.Frames,
+			t.Done, t.Rounds, t.Status, t.Error, t.Content, t.Started, t.Updated)
+		if err != nil {
+			return fmt.Errorf("failed to save file transfer: %w", err)
+		}
+		t.ID = id
+		return nil
+	}
+
+	_, err := ms.db.Exec(`
+		UPDATE file_transfers
+		SET name = ?, from_callsign = ?, to_callsign = ?, size = ?, frames = ?, done = ?, rounds = ?,
+			status = ?, error = ?, content = ?, updated_at = ?
+		WHERE id = ?
+	`, t.Name, strings.ToUpper(t.From), strings.ToUpper(t.To), t.Size, t.Frames, t.Done, t.Rounds,
+		t.Status, t.Error, t.Content, t.Updated, t.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update file transfer %d: %w", t.ID, err)
+	}
+	return nil
+}
+
+// GetFileTransfers returns the latest transfers, newest first, without
+// their content
+func (ms *MessageStore) GetFileTransfers(limit int) ([]FileTransfer, error) {
+	rows, err := ms.db.Query(`
+		SELECT id, transfer_id, direction, name, from_callsign, to_callsign, size, frames, done, rounds,
+			status, error, started_at, updated_at
+		FROM file_transfers
+		ORDER BY id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query file transfers: %w", err)
+	}
+	defer rows.Close()
+
+	transfers := []FileTransfer{}
+	for rows.Next() {
+		var t FileTransfer
+		err := rows.Scan(&t.ID, &t.TransferID, &t.Direction, &t.Name, &t.From, &t.To, &t.Size, &t.Frames,
+			&t.Done, &t.Rounds, &t.Status, &t.Error, &t.Started, &t.Updated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan file transfer: %w", err)
+		}
+		transfers = append(transfers, t)
+	}
+
+	return transfers, rows.Err()
+}
+
+// GetFileTransfer returns one transfer with its content, nil if there is
+// none with the ID
+func (ms *MessageStore) GetFileTransfer(id int64) (*FileTransfer, error) {
+	var t FileTransfer
+	err := ms.db.QueryRow(`
+		SELECT id, transfer_id, direction, name, from_callsign, to_callsign, size, frames, done, rounds,
+			status, error, content, started_at, updated_at
+		FROM file_transfers
+		WHERE id = ?
+	`, id).Scan(&t.ID, &t.TransferID, &t.Direction, &t.Name, &t.From, &t.To, &t.Size, &t.Frames,
+		&t.Done, &t.Rounds, &t.Status, &t.Error, &t.Content, &t.Started, &t.Updated)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file transfer %d: %w", id, err)
+	}
+	return &t, nil
+}
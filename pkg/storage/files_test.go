@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFileTransfers(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	sent := &FileTransfer{TransferID: 9, Direction: "TX", Name: "roster.txt", From: "k1abc", To: "w1aw",
+		Size: 5, Frames: 6, Status: "sending", Content: []byte("hello")}
+	if err := store.SaveFileTransfer(sent); err != nil {
+		t.Fatalf("Failed to save transfer: %v", err)
+	}
+	received := &FileTransfer{TransferID: 12, Direction: "RX", From: "W1AW", To: "K1ABC", Frames: 40, Status: "receiving"}
+	if err := store.SaveFileTransfer(received); err != nil {
+		t.Fatalf("Failed to save transfer: %v", err)
+	}
+	if sent.ID != 1 || received.ID != 2 || sent.Started.IsZero() {
+		t.Fatalf("Expected IDs 1 and 2 with start times, got %+v and %+v", sent, received)
+	}
+
+	// Progress and completion update the row in place
+	received.Done, received.Status, received.Name, received.Content = 40, "complete", "map.png", []byte{0x89, 'P', 'N', 'G'}
+	if err := store.SaveFileTransfer(received); err != nil {
+		t.Fatalf("Failed to update transfer: %v", err)
+	}
+
+	transfers, err := store.GetFileTransfers(10)
+	if err != nil {
+		t.Fatalf("Failed to list transfers: %v", err)
+	}
+	if len(transfers) != 2 || transfers[0].Status != "complete" || transfers[0].Done != 40 || transfers[1].From != "K1ABC" {
+		t.Fatalf("Expected the completed RX transfer first, got %+v", transfers)
+	}
+	if transfers[0].Content != nil {
+		t.Errorf("Expected the list without content")
+	}
+
+	got, err := store.GetFileTransfer(received.ID)
+	if err != nil || got == nil || got.Name != "map.png" || !bytes.Equal(got.Content, received.Content) {
+		t.Errorf("Expected map.png with its content, got %+v, %v", got, err)
+	}
+	if got, err := store.GetFileTransfer(99); got != nil || err != nil {
+		t.Errorf("Expected no transfer 99, got %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// Filter drops decodes before they are stored or answered: those from a
+// callsign, or those within Width Hz of an audio offset
+type Filter struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"` // protocol.FilterCallsign or protocol.FilterOffset
+	Callsign  string    `json:"callsign,omitempty"`
+	Offset    int       `json:"offset,omitempty"`
+	Width     int       `json:"width,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddFilter stores a new filter and sets its ID and creation time
+func (ms *MessageStore) AddFilter(filter *Filter) error {
+	filter.Type = strings.ToLower(filter.Type)
+	filter.Callsign = strings.ToUpper(strings.TrimSpace(filter.Callsign))
+	switch filter.Type {
+	case protocol.FilterCallsign:
+		if filter.Callsign == "" {
+			return fmt.Errorf("callsign filter needs a callsign")
+		}
+		filter.Offset, filter.Width = 0, 0
+	case protocol.FilterOffset:
+		if filter.Offset <= 0 || filter.Width < 0 {
+			return fmt.Errorf("offset filter needs a positive offset and width")
+		}
+		filter.Callsign = ""
+	default:
+		return fmt.Errorf("unknown filter type %q", filter.Type)
+	}
+
+	filter.CreatedAt = time.Now()
+	id, err := ms.db.insert(`
+		INSERT INTO filters (type, callsign, "offset", width, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, filter.Type, filter.Callsign, filter.Offset, filter.Width, strings.TrimSpace(filter.Note), filter.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add filter: %w", err)
+	}
+	filter.ID = id
+	return nil
+}
+
+// GetFilters returns every filter, oldest first
+func (ms *MessageStore) GetFilters() ([]Filter, error) {
+	rows, err := ms.db.Query(`SELECT id, type, callsign, "offset", width, note, created_at FROM filters ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query filters: %w", err)
+	}
+	defer rows.Close()
+
+	filters := []Filter{}
+	for rows.Next() {
+		var filter Filter
+		if err := rows.Scan(&filter.ID, &filter.Type, &filter.Callsign, &filter.Offset, &filter.Width, &filter.Note, &filter.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan filter: %w", err)
+		}
+		filters = append(filters, filter)
+	}
+
+	return filters, rows.Err()
+}
+
+// DeleteFilter removes a filter, reporting whether there was one to remove
+func (ms *MessageStore) DeleteFilter(id int64) (bool, error) {
+	result, err := ms.db.Exec("DELETE FROM filters WHERE id = ?", id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete filter: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+func TestFilters(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	call := &Filter{Type: protocol.FilterCallsign, Callsign: " n0abc ", Note: "Stuck beacon"}
+	if err := store.AddFilter(call); err != nil {
+		t.Fatalf("Failed to add filter: %v", err)
+	}
+	birdie := &Filter{Type: "OFFSET", Offset: 1480, Width: 20}
+	if err := store.AddFilter(birdie); err != nil {
+		t.Fatalf("Failed to add filter: %v", err)
+	}
+	if call.ID == 0 || birdie.ID == call.ID {
+		t.Errorf("Expected distinct IDs, got %d and %d", call.ID, birdie.ID)
+	}
+
+	for _, bad := range []*Filter{
+		{Type: protocol.FilterCallsign},
+		{Type: protocol.FilterOffset, Width: 10},
+		{Type: protocol.FilterOffset, Offset: 1500, Width: -1},
+		{Type: "grid", Callsign: "FN20"},
+	} {
+		if err := store.AddFilter(bad); err == nil {
+			t.Errorf("Expected an error adding %+v", bad)
+		}
+	}
+
+	filters, err := store.GetFilters()
+	if err != nil || len(filters) != 2 {
+		t.Fatalf("Expected 2 filters, got %d, %v", len(filters), err)
+	}
+	if filters[0].Callsign != "N0ABC" || filters[0].Note != "Stuck beacon" || filters[1].Type != protocol.FilterOffset || filters[1].Offset != 1480 {
+		t.Errorf("Expected the filters as added, got %+v", filters)
+	}
+
+	if deleted, err := store.DeleteFilter(call.ID); !deleted || err != nil {
+		t.Errorf("Expected the callsign filter deleted, got %v, %v", deleted, err)
+	}
+	if deleted, _ := store.DeleteFilter(call.ID); deleted {
+		t.Error("Expected nothing to delete the second time")
+	}
+	if filters, _ := store.GetFilters(); len(filters) != 1 || filters[0].ID != birdie.ID {
+		t.Errorf("Expected only the offset filter left, got %+v", filters)
+	}
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StoredForm is a structured message sent or received as data frames
+type StoredForm struct {
+	ID        int64           `json:"id"`
+	Timestamp time.Time       `json:"timestamp"`
+	Direction string          `json:"direction"` // RX or TX
+	Template  string          `json:"template"`  // Template code, e.g. ICS213
+	Name      string          `json:"name"`      // Template title
+	From      string          `json:"from"`
+	To        string          `json:"to,omitempty"`
+	Frequency int             `json:"frequency"`
+	SNR       float32         `json:"snr,omitempty"`
+	Frames    int             `json:"frames"`
+	Values    json.RawMessage `json:"values"` // The filled in fields, in template order
+}
+
+// SaveForm stores a form, setting its ID
+func (ms *MessageStore) SaveForm(form *StoredForm) error {
+	values := form.Values
+	if len(values) == 0 {
+		values = json.RawMessage("[]")
+	}
+	id, err := ms.db.insert(`
+		INSERT INTO forms (timestamp, direction, template, name, from_callsign, to_callsign, frequency, snr, frames, form_values)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, form.Timestamp, form.Direction, form.Template, form.Name, strings.ToUpper(form.From),
+		strings.ToUpper(form.To), form.Frequency, form.SNR, form.Frames, string(values))
+	if err != nil {
+		return fmt.Errorf("failed to save form: %w", err)
+	}
+	form.ID = id
+	return nil
+}
+
+// GetForms returns the latest forms, newest first, only those in direction
+// when it is not empty
+func (ms *MessageStore) GetForms(direction string, limit int) ([]StoredForm, error) {
+	rows, err := ms.db.Query(`
+		SELECT id, timestamp, direction, template, name, from_callsign, to_callsign, frequency, snr, frames, form_values
+		FROM forms
+		WHERE ? = '' OR direction = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`, direction, direction, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query forms: %w", err)
+	}
+	defer rows.Close()
+
+	forms := []StoredForm{}
+	for rows.Next() {
+		var f StoredForm
+		var values string
+		err := rows.Scan(&f.ID, &f.Timestamp, &f.Direction, &f.Template, &f.Name, &f.From, &f.To,
+			&f.Frequency, &f.SNR, &f.Frames, &values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan form: %w", err)
+		}
+		f.Values = json.RawMessage(values)
+		forms = append(forms, f)
+	}
+
+	return forms, rows.Err()
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestForms(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	sent := &StoredForm{
+		Timestamp: time.Now(),
+		Direction: "TX",
+		Template:  "ICS213",
+		Name:      "ICS-213 General Message",
+		From:      "k1abc",
+		To:        "w1aw",
+		Frames:    12,
+		Values:    json.RawMessage(`[{"name":"subject","label":"Subject","text":"Supplies"}]`),
+	}
+	received := &StoredForm{Timestamp: time.Now(), Direction: "RX", Template: "SITREP", From: "W1AW", SNR: -12}
+	for _, form := range []*StoredForm{sent, received} {
+		if err := store.SaveForm(form); err != nil {
+			t.Fatalf("Failed to save form: %v", err)
+		}
+	}
+	if sent.ID != 1 || received.ID != 2 {
+		t.Errorf("Expected IDs 1 and 2, got %d and %d", sent.ID, received.ID)
+	}
+
+	forms, err := store.GetForms("", 10)
+	if err != nil {
+		t.Fatalf("Failed to get forms: %v", err)
+	}
+	if len(forms) != 2 || forms[0].Template != "SITREP" || forms[1].From != "K1ABC" || forms[1].To != "W1AW" {
+		t.Fatalf("Expected SITREP then ICS213, got %+v", forms)
+	}
+	if string(forms[0].Values) != "[]" || string(forms[1].Values) != string(sent.Values) {
+		t.Errorf("Unexpected values %s and %s", forms[0].Values, forms[1].Values)
+	}
+
+	forms, err = store.GetForms("RX", 10)
+	if err != nil || len(forms) != 1 || forms[0].Direction != "RX" {
+		t.Errorf("Expected only the received form, got %+v, %v", forms, err)
+	}
+}
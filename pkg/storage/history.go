@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Resolutions of the stats history. Minute snapshots are rolled up into
+// hours once they are older than the minute retention.
+const (
+	StatsMinute = "1m"
+	StatsHour   = "1h"
+)
+
+// Default stats history retention
+const (
+	DefaultStatsMinuteRetention = 48 * time.Hour
+	DefaultStatsHourRetention   = 365 * 24 * time.Hour
+)
+
+// StatsPoint is the decode activity and noise floor of one minute or hour
+type StatsPoint struct {
+	Time       time.Time `json:"time"`
+	Decodes    int       `json:"decodes"`
+	AvgSNR     *float64  `json:"avg_snr"`     // Nil without decodes
+	NoiseFloor *float64  `json:"noise_floor"` // dB, nil when the audio was not measured
+}
+
+// RecordStatsMinute snapshots the minute starting at minute: the decodes
+// stored for it and their SNR, and the noise floor measured over it, which
+// is nil when there was no audio
+func (ms *MessageStore) RecordStatsMinute(minute time.Time, noiseFloor *float64) error {
+	minute = minute.Truncate(time.Minute)
+
+	var decodes int
+	var snrTotal float64
+	err := ms.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(snr), 0)
+		FROM messages
+		WHERE direction = 'RX' AND timestamp >= ? AND timestamp < ?
+	`, minute, minute.Add(time.Minute)).Scan(&decodes, &snrTotal)
+	if err != nil {
+		return fmt.Errorf("failed to count decodes: %w", err)
+	}
+
+	noiseTotal, noiseSamples := 0.0, 0
+	if noiseFloor != nil {
+		noiseTotal, noiseSamples = *noiseFloor, 1
+	}
+
+	_, err = ms.db.Exec(`
+		INSERT INTO stats_history (resolution, bucket, decodes, snr_total, noise_total, noise_samples)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(resolution, bucket) DO UPDATE SET
+			decodes = excluded.decodes,
+			snr_total = excluded.snr_total,
+			noise_total = excluded.noise_total,
+			noise_samples = excluded.noise_samples
+	`, StatsMinute, minute.Unix(), decodes, snrTotal, noiseTotal, noiseSamples)
+	if err != nil {
+		return fmt.Errorf("failed to record stats: %w", err)
+	}
+	return nil
+}
+
+// RollupStats folds the minute snapshots before a time into hourly ones,
+// then removes hourly ones before hourBefore. It returns how many minute
+// snapshots were rolled up.
+func (ms *MessageStore) RollupStats(minuteBefore, hourBefore time.Time) (int, error) {
+	tx, err := ms.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Sums and counts add up, so a partial hour merges with its rest later
+	_, err = tx.Exec(`
+		INSERT INTO stats_history (resolution, bucket, decodes, snr_total, noise_total, noise_samples)
+		SELECT ?, bucket - bucket % 3600, SUM(decodes), SUM(snr_total), SUM(noise_total), SUM(noise_samples)
+		FROM stats_history
+		WHERE resolution = ? AND bucket < ?
+		GROUP BY bucket - bucket % 3600
+		ON CONFLICT(resolution, bucket) DO UPDATE SET
+			decodes = decodes + excluded.decodes,
+			snr_total = snr_total + excluded.snr_total,
+			noise_total = noise_total + excluded.noise_total,
+			noise_samples = noise_samples + excluded.noise_samples
+	`, StatsHour, StatsMinute, minuteBefore.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to roll up stats: %w", err)
+	}
+
+	result, err := tx.Exec("DELETE FROM stats_history WHERE resolution = ? AND bucket < ?", StatsMinute, minuteBefore.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove rolled up stats: %w", err)
+	}
+	rolledUp, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM stats_history WHERE resolution = ? AND bucket < ?", StatsHour, hourBefore.Unix()); err != nil {
+		return 0, fmt.Errorf("failed to remove old stats: %w", err)
+	}
+
+	return int(rolledUp), tx.Commit()
+}
+
+// GetStatsSeries returns the stats history between two times, oldest first.
+// Hourly series include the hours still held as minute snapshots.
+func (ms *MessageStore) GetStatsSeries(resolution string, from, to time.Time) ([]StatsPoint, error) {
+	var query string
+	var args []interface{}
+	switch resolution {
+	case StatsMinute:
+		query = `
+			SELECT bucket, decodes, snr_total, noise_total, noise_samples
+			FROM stats_history
+			WHERE resolution = ? AND bucket >= ? AND bucket < ?
+			ORDER BY bucket`
+		args = []interface{}{StatsMinute, from.Unix(), to.Unix()}
+	case StatsHour:
+		query = `
+			SELECT bucket, SUM(decodes), SUM(snr_total), SUM(noise_total), SUM(noise_samples)
+			FROM (
+				SELECT bucket, decodes, snr_total, noise_total, noise_samples
+				FROM stats_history
+				WHERE resolution = ? AND bucket >= ? AND bucket < ?
+				UNION ALL
+				SELECT bucket - bucket % 3600, decodes, snr_total, noise_total, noise_samples
+				FROM stats_history
+				WHERE resolution = ? AND bucket >= ? AND bucket < ?
+			) AS buckets
+			GROUP BY bucket
+			ORDER BY bucket`
+		start := from.Truncate(time.Hour).Unix()
+		args = []interface{}{StatsHour, start, to.Unix(), StatsMinute, start, to.Unix()}
+	default:
+		return nil, fmt.Errorf("invalid resolution %q, want %s or %s", resolution, StatsMinute, StatsHour)
+	}
+
+	rows, err := ms.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stats history: %w", err)
+	}
+	defer rows.Close()
+
+	points := []StatsPoint{}
+	for rows.Next() {
+		var bucket int64
+		var point StatsPoint
+		var snrTotal, noiseTotal float64
+		var noiseSamples int
+		if err := rows.Scan(&bucket, &point.Decodes, &snrTotal, &noiseTotal, &noiseSamples); err != nil {
+			return nil, fmt.Errorf("failed to scan stats: %w", err)
+		}
+
+		point.Time = time.Unix(bucket, 0).UTC()
+		if point.Decodes > 0 {
+			avg := snrTotal / float64(point.Decodes)
+			point.AvgSNR = &avg
+		}
+		if noiseSamples > 0 {
+			noise := noiseTotal / float64(noiseSamples)
+			point.NoiseFloor = &noise
+		}
+		points = append(points, point)
+	}
+
+	return points, rows.Err()
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+func TestStatsHistory(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	hour := time.Date(2024, time.January, 15, 10, 0, 0, 0, time.UTC)
+	for i, snr := range []float32{-10, -20, 0} {
+		// Two decodes in the first minute, one in the second
+		at := hour.Add(time.Duration(i/2)*time.Minute + 10*time.Second)
+		msg := protocol.Message{Timestamp: at, From: "N0ABC", Message: "HB", SNR: snr}
+		if err := store.StoreMessage(msg, "RX", "HEARTBEAT"); err != nil {
+			t.Fatalf("Failed to store message: %v", err)
+		}
+	}
+
+	noise := []float64{-90, -100}
+	for i := range noise {
+		if err := store.RecordStatsMinute(hour.Add(time.Duration(i)*time.Minute), &noise[i]); err != nil {
+			t.Fatalf("Failed to record stats: %v", err)
+		}
+	}
+	// A minute without audio in the next hour
+	if err := store.RecordStatsMinute(hour.Add(time.Hour), nil); err != nil {
+		t.Fatalf("Failed to record stats: %v", err)
+	}
+
+	points, err := store.GetStatsSeries(StatsMinute, hour, hour.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to get series: %v", err)
+	}
+	if len(points) != 3 {
+		t.Fatalf("Expected 3 minutes, got %+v", points)
+	}
+	if points[0].Decodes != 2 || *points[0].AvgSNR != -15 || *points[0].NoiseFloor != -90 {
+		t.Errorf("Unexpected first minute %+v", points[0])
+	}
+	if points[2].Decodes != 0 || points[2].AvgSNR != nil || points[2].NoiseFloor != nil {
+		t.Errorf("Expected an empty minute, got %+v", points[2])
+	}
+
+	checkHours := func(when string) {
+		t.Helper()
+		hours, err := store.GetStatsSeries(StatsHour, hour, hour.Add(2*time.Hour))
+		if err != nil {
+			t.Fatalf("Failed to get hourly series %s: %v", when, err)
+		}
+		if len(hours) != 2 || !hours[0].Time.Equal(hour) || hours[0].Decodes != 3 ||
+			*hours[0].AvgSNR != -10 || *hours[0].NoiseFloor != -95 {
+			t.Errorf("Unexpected hours %s: %+v", when, hours)
+		}
+	}
+
+	// Hourly series cover minutes not yet rolled up...
+	checkHours("before the rollup")
+
+	// ...and are the same once they are
+	rolledUp, err := store.RollupStats(hour.Add(time.Hour), hour.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to roll up: %v", err)
+	}
+	if rolledUp != 2 {
+		t.Errorf("Expected 2 minutes rolled up, got %d", rolledUp)
+	}
+	checkHours("after the rollup")
+
+	points, _ = store.GetStatsSeries(StatsMinute, hour, hour.Add(2*time.Hour))
+	if len(points) != 1 {
+		t.Errorf("Expected only the newest minute kept, got %+v", points)
+	}
+
+	// Hours past their retention are removed
+	if _, err := store.RollupStats(hour, hour.Add(90*time.Minute)); err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+	hours, _ := store.GetStatsSeries(StatsHour, hour, hour.Add(2*time.Hour))
+	if len(hours) != 1 || !hours[0].Time.Equal(hour.Add(time.Hour)) {
+		t.Errorf("Expected only the second hour left, got %+v", hours)
+	}
+
+	if _, err := store.GetStatsSeries("5m", hour, hour.Add(time.Hour)); err == nil {
+		t.Error("Expected an error for an unknown resolution")
+	}
+}
+
+func TestBandNoise(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	hour := time.Date(2024, time.January, 15, 10, 0, 0, 0, time.UTC)
+	samples := []struct {
+		band    string
+		at      time.Time
+		total   float64
+		samples int
+	}{
+		{"20m", hour, -600, 6},
+		{"20m", hour.Add(30 * time.Minute), -600, 6},
+		{"20m", hour.Add(time.Hour), -540, 6},
+		{"40m", hour, -570, 6},
+		{"40m", hour.Add(time.Hour), 0, 0},
+	}
+	for _, s := range samples {
+		if err := store.RecordBandNoise(s.band, s.at, s.total, s.samples); err != nil {
+			t.Fatalf("Failed to record band noise: %v", err)
+		}
+	}
+
+	bands, err := store.GetBandNoise(hour, hour.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to get band noise: %v", err)
+	}
+	if len(bands) != 2 || bands[0].Band != "20m" || bands[1].Band != "40m" {
+		t.Fatalf("Expected 20m and 40m, got %+v", bands)
+	}
+
+	b := bands[0]
+	if b.Samples != 18 || len(b.Points) != 2 || b.Quietest != -100 || b.Loudest != -90 {
+		t.Errorf("Unexpected 20m noise %+v", b)
+	}
+	if math.Abs(b.Average-(-1740.0/18)) > 1e-9 {
+		t.Errorf("Expected an average over every sample, got %v", b.Average)
+	}
+	if !b.Latest.Time.Equal(hour.Add(time.Hour)) || b.SUnits != 1.7 {
+		t.Errorf("Expected the latest hour 1.7 S-units over the quietest, got %+v", b)
+	}
+	if b.ByHour[10] == nil || *b.ByHour[10] != -100 || b.ByHour[11] == nil || b.ByHour[9] != nil {
+		t.Errorf("Unexpected hours of the day %v", b.ByHour)
+	}
+	if bands[1].Samples != 6 || bands[1].SUnits != 0 {
+		t.Errorf("Unexpected 40m noise %+v", bands[1])
+	}
+
+	pruned, err := store.PruneBandNoise(hour.Add(time.Hour))
+	if err != nil || pruned != 2 {
+		t.Fatalf("Expected 2 hours pruned, got %d (%v)", pruned, err)
+	}
+	bands, err = store.GetBandNoise(hour, hour.Add(2*time.Hour))
+	if err != nil || len(bands) != 1 || len(bands[0].Points) != 1 {
+		t.Errorf("Expected the last 20m hour left, got %+v (%v)", bands, err)
+	}
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// Macro is message text the operator has saved under a name, which may
+// contain tokens such as {MYCALL} that are filled in when it is sent
+type Macro struct {
+	Name      string    `json:"name"`
+	Text      string    `json:"text"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SaveMacro adds a macro or replaces the text of an existing one
+func (ms *MessageStore) SaveMacro(name, text string) (*Macro, error) {
+	if !protocol.ValidMacroName(name) {
+		return nil, fmt.Errorf("invalid macro name %q", name)
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("macro %s has no text", name)
+	}
+
+	macro := &Macro{Name: strings.ToUpper(name), Text: text, UpdatedAt: time.Now()}
+	_, err := ms.db.Exec(`
+		INSERT INTO macros (name, text, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET text = excluded.text, updated_at = excluded.updated_at
+	`, macro.Name, macro.Text, macro.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save macro: %w", err)
+	}
+	return macro, nil
+}
+
+// GetMacro returns a macro, or nil if there is none by that name
+func (ms *MessageStore) GetMacro(name string) (*Macro, error) {
+	var macro Macro
+	err := ms.db.QueryRow("SELECT name, text, updated_at FROM macros WHERE name = ?", strings.ToUpper(name)).
+		Scan(&macro.Name, &macro.Text, &macro.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get macro: %w", err)
+	}
+	return &macro, nil
+}
+
+// GetMacros returns every macro by name
+func (ms *MessageStore) GetMacros() ([]Macro, error) {
+	rows, err := ms.db.Query("SELECT name, text, updated_at FROM macros ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query macros: %w", err)
+	}
+	defer rows.Close()
+
+	macros := []Macro{}
+	for rows.Next() {
+		var macro Macro
+		if err := rows.Scan(&macro.Name, &macro.Text, &macro.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan macro: %w", err)
+		}
+		macros = append(macros, macro)
+	}
+
+	return macros, rows.Err()
+}
+
+// DeleteMacro removes a macro, reporting whether there was one to remove
+func (ms *MessageStore) DeleteMacro(name string) (bool, error) {
+	result, err := ms.db.Exec("DELETE FROM macros WHERE name = ?", strings.ToUpper(name))
+	if err != nil {
+		return false, fmt.Errorf("failed to delete macro: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
@@ -0,0 +1,51 @@
+package storage
+
+import "testing"
+
+func TestMacros(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	if _, err := store.SaveMacro("qth", "{MYCALL}: QTH {MYGRID}"); err != nil {
+		t.Fatalf("Failed to save macro: %v", err)
+	}
+	if _, err := store.SaveMacro("CQ", "CQ CQ {MYCALL}"); err != nil {
+		t.Fatalf("Failed to save macro: %v", err)
+	}
+	// Saving under an existing name replaces the text
+	if _, err := store.SaveMacro("cq", " CQ CQ CQ {MYCALL} {MYGRID} "); err != nil {
+		t.Fatalf("Failed to replace macro: %v", err)
+	}
+
+	macros, err := store.GetMacros()
+	if err != nil {
+		t.Fatalf("Failed to get macros: %v", err)
+	}
+	if len(macros) != 2 || macros[0].Name != "CQ" || macros[0].Text != "CQ CQ CQ {MYCALL} {MYGRID}" || macros[1].Name != "QTH" {
+		t.Errorf("Expected CQ and QTH, got %+v", macros)
+	}
+
+	macro, err := store.GetMacro("Qth")
+	if err != nil || macro == nil || macro.Text != "{MYCALL}: QTH {MYGRID}" {
+		t.Errorf("Expected the QTH macro, got %+v, %v", macro, err)
+	}
+
+	for _, name := range []string{"SNR", "TWO WORDS", ""} {
+		if _, err := store.SaveMacro(name, "73"); err == nil {
+			t.Errorf("Expected an error saving a macro named %q", name)
+		}
+	}
+	if _, err := store.SaveMacro("EMPTY", "  "); err == nil {
+		t.Error("Expected an error saving a macro without text")
+	}
+
+	if deleted, err := store.DeleteMacro("qth"); !deleted || err != nil {
+		t.Errorf("Expected QTH deleted, got %v, %v", deleted, err)
+	}
+	if deleted, _ := store.DeleteMacro("QTH"); deleted {
+		t.Error("Expected nothing to delete the second time")
+	}
+	if macro, err := store.GetMacro("QTH"); macro != nil || err != nil {
+		t.Errorf("Expected no QTH macro, got %+v, %v", macro, err)
+	}
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// NewMemoryStore creates a message store that lives in memory only, for
+// monitoring nodes that keep nothing and for tests. It holds the last
+// maxMessages messages, dropping the oldest as new ones arrive, and is
+// gone when closed.
+func NewMemoryStore(maxMessages int) (*MessageStore, error) {
+	db, err := sql.Open("sqlite3", ":memory:?_foreign_keys=on")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// Every connection to :memory: gets a database of its own, so there
+	// must only ever be the one
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+
+	store := &MessageStore{
+		db:          &database{DB: db, dialect: BackendSQLite},
+		maxMessages: maxMessages,
+		memory:      true,
+	}
+	if err := store.createSchema(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize message store: %w", err)
+	}
+
+	logger.Infof("Message store initialized in memory (max %d messages)", maxMessages)
+	return store, nil
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store, err := NewMemoryStore(3)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	baseTime := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		msg := protocol.Message{
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			From:      "K3DEP",
+			To:        "N0ABC",
+			Message:   fmt.Sprintf("Message %d", i+1),
+			Frequency: 14078000,
+		}
+		if err := store.StoreMessage(msg, "RX", "MESSAGE"); err != nil {
+			t.Fatalf("Failed to store message %d: %v", i+1, err)
+		}
+	}
+
+	// Only the newest max_messages are kept
+	messages, err := store.GetRecentMessages(10)
+	if err != nil {
+		t.Fatalf("Failed to get messages: %v", err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(messages))
+	}
+	if messages[0].Message != "Message 5" || messages[2].Message != "Message 3" {
+		t.Errorf("Expected messages 5 to 3, got %q to %q", messages[0].Message, messages[2].Message)
+	}
+
+	// Nothing to back up
+	if err := store.Snapshot(filepath.Join(t.TempDir(), "snapshot.db")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported from Snapshot, got %v", err)
+	}
+	if _, err := store.IntegrityCheck(); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported from IntegrityCheck, got %v", err)
+	}
+
+	// Each store is a database of its own
+	other, err := NewMemoryStore(3)
+	if err != nil {
+		t.Fatalf("Failed to create second store: %v", err)
+	}
+	defer other.Close()
+	if count, _ := other.GetMessageCount(); count != 0 {
+		t.Errorf("Expected an empty second store, got %d messages", count)
+	}
+}
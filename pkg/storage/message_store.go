@@ -3,20 +3,26 @@ package storage
 import (
 	"database/sql"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/dougsko/js8d/pkg/logging"
 	"github.com/dougsko/js8d/pkg/protocol"
 	_ "github.com/mattn/go-sqlite3"
 )
 
+var logger = logging.Component("storage")
+
+// defaultDatabasePath is where the database goes without database_path
+const defaultDatabasePath = "./js8d.db"
+
 // MessageStore handles persistent storage of JS8 messages
 type MessageStore struct {
-	db          *sql.DB
+	db          *database
 	dbPath      string
 	maxMessages int
+	memory      bool // Kept in memory by NewMemoryStore, nothing to back up
 }
 
 // NewMessageStore creates a new message store with SQLite backend
@@ -42,7 +48,12 @@ func (ms *MessageStore) initialize() error {
 
 	// Handle empty database path
 	if ms.dbPath == "" {
-		ms.dbPath = "./js8d.db" // Default database path
+		ms.dbPath = defaultDatabasePath
+	}
+
+	// Swap in a database restored from a backup
+	if err := applyRestore(ms.dbPath); err != nil {
+		return fmt.Errorf("failed to restore database: %w", err)
 	}
 
 	// Build connection string properly with query parameters
@@ -54,19 +65,32 @@ func (ms *MessageStore) initialize() error {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
 
-	ms.db = db
+	ms.db = &database{DB: db, dialect: BackendSQLite}
+	if err := ms.createSchema(); err != nil {
+		return err
+	}
+
+	logger.Infof("Message store initialized: %s (max %d messages)", ms.dbPath, ms.maxMessages)
+	return nil
+}
 
+// createSchema creates the tables and indexes, bringing those of older
+// versions up to date
+func (ms *MessageStore) createSchema() error {
 	// Create tables
 	if err := ms.createTables(); err != nil {
 		return fmt.Errorf("failed to create tables: %w", err)
 	}
 
+	// Bring databases created by older versions up to date
+	if err := ms.migrateSchema(); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
 	// Create indexes for performance
 	if err := ms.createIndexes(); err != nil {
 		return fmt.Errorf("failed to create indexes: %w", err)
 	}
-
-	log.Printf("Message store initialized: %s (max %d messages)", ms.dbPath, ms.maxMessages)
 	return nil
 }
 
@@ -81,11 +105,23 @@ func (ms *MessageStore) createTables() error {
 		message_text TEXT NOT NULL,
 		snr REAL NOT NULL DEFAULT 0.0,
 		frequency INTEGER NOT NULL DEFAULT 0,
+		"offset" INTEGER NOT NULL DEFAULT 0,
 		mode TEXT NOT NULL DEFAULT 'NORMAL',
 		direction TEXT NOT NULL CHECK (direction IN ('RX', 'TX')),
 		message_type TEXT NOT NULL DEFAULT 'MESSAGE',
 		is_read BOOLEAN NOT NULL DEFAULT FALSE,
 		grid_square TEXT DEFAULT '',
+		channel TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT '',
+		delivery TEXT NOT NULL DEFAULT '',
+		tx_power INTEGER NOT NULL DEFAULT 0,
+		snippet TEXT NOT NULL DEFAULT '',
+		noise_floor REAL,
+		dial INTEGER NOT NULL DEFAULT 0,
+		band TEXT NOT NULL DEFAULT '',
+		submode TEXT NOT NULL DEFAULT '',
+		resends INTEGER NOT NULL DEFAULT 0,
+		data_frames BOOLEAN NOT NULL DEFAULT FALSE,
 		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);
@@ -101,25 +137,355 @@ func (ms *MessageStore) createTables() error {
 		FOREIGN KEY (last_message_id) REFERENCES messages(id) ON DELETE SET NULL
 	);
 
+	CREATE TABLE IF NOT EXISTS stations (
+		callsign TEXT PRIMARY KEY,
+		grid TEXT NOT NULL DEFAULT '',
+		first_heard DATETIME,
+		last_heard DATETIME,
+		heard_count INTEGER NOT NULL DEFAULT 0,
+		last_snr REAL NOT NULL DEFAULT 0.0,
+		name TEXT NOT NULL DEFAULT '',
+		qth TEXT NOT NULL DEFAULT '',
+		notes TEXT NOT NULL DEFAULT '',
+		qsl_status TEXT NOT NULL DEFAULT '',
+		country TEXT NOT NULL DEFAULT '',
+		latitude REAL NOT NULL DEFAULT 0.0,
+		longitude REAL NOT NULL DEFAULT 0.0,
+		looked_up_at DATETIME,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
 	CREATE TABLE IF NOT EXISTS message_stats (
 		id INTEGER PRIMARY KEY,
 		total_messages INTEGER NOT NULL DEFAULT 0,
 		total_rx INTEGER NOT NULL DEFAULT 0,
 		total_tx INTEGER NOT NULL DEFAULT 0,
 		last_cleanup DATETIME,
+		duplicates INTEGER NOT NULL DEFAULT 0,
+		last_duplicate DATETIME,
 		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);
 
+	-- Decode counts and noise floor per minute, rolled up into hours;
+	-- bucket is the Unix time the minute or hour starts
+	CREATE TABLE IF NOT EXISTS stats_history (
+		resolution TEXT NOT NULL,
+		bucket INTEGER NOT NULL,
+		decodes INTEGER NOT NULL DEFAULT 0,
+		snr_total REAL NOT NULL DEFAULT 0.0,
+		noise_total REAL NOT NULL DEFAULT 0.0,
+		noise_samples INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (resolution, bucket)
+	);
+
+	-- Noise floor per band and hour, kept like the hourly stats; bucket is
+	-- the Unix time the hour starts
+	CREATE TABLE IF NOT EXISTS band_noise (
+		band TEXT NOT NULL,
+		bucket INTEGER NOT NULL,
+		noise_total REAL NOT NULL DEFAULT 0.0,
+		noise_samples INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (band, bucket)
+	);
+
+	-- The payload bits and received tones of decodes, stored with the
+	-- messages when storage raw_frames is on; tones holds a digit per symbol
+	CREATE TABLE IF NOT EXISTS raw_frames (
+		message_id INTEGER PRIMARY KEY,
+		payload BLOB NOT NULL,
+		tones TEXT NOT NULL,
+		FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+	);
+
+	-- Every decode of a station, for plotting how a path opens and
+	-- closes; heard is the Unix time of the decode
+	CREATE TABLE IF NOT EXISTS snr_history (
+		callsign TEXT NOT NULL,
+		heard INTEGER NOT NULL,
+		snr REAL NOT NULL,
+		frequency INTEGER NOT NULL DEFAULT 0
+	);
+
+	-- Decodes dropped before they are stored: every one from a callsign,
+	-- or those within width Hz of an audio offset
+	CREATE TABLE IF NOT EXISTS filters (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT NOT NULL,
+		callsign TEXT NOT NULL DEFAULT '',
+		"offset" INTEGER NOT NULL DEFAULT 0,
+		width INTEGER NOT NULL DEFAULT 0,
+		note TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Callsigns, or prefixes ending in *, that automatic replies skip
+	-- (list block) or are kept to (list allow)
+	CREATE TABLE IF NOT EXISTS auto_list (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		list TEXT NOT NULL,
+		callsign TEXT NOT NULL,
+		note TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Directed nets run from this station, and the stations that
+	-- checked in to each
+	CREATE TABLE IF NOT EXISTS nets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		net_group TEXT NOT NULL,
+		frequency INTEGER NOT NULL DEFAULT 0,
+		opened_at DATETIME NOT NULL,
+		closed_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS net_checkins (
+		net_id INTEGER NOT NULL,
+		callsign TEXT NOT NULL,
+		first_at DATETIME NOT NULL,
+		last_at DATETIME NOT NULL,
+		snr REAL NOT NULL DEFAULT 0.0,
+		grid TEXT NOT NULL DEFAULT '',
+		message TEXT NOT NULL DEFAULT '',
+		messages INTEGER NOT NULL DEFAULT 1,
+		PRIMARY KEY (net_id, callsign),
+		FOREIGN KEY (net_id) REFERENCES nets(id) ON DELETE CASCADE
+	);
+
+	-- Message templates the operator has saved, by upper case name
+	CREATE TABLE IF NOT EXISTS macros (
+		name TEXT PRIMARY KEY,
+		text TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Decisions made on CQs heard while answering them automatically
+	CREATE TABLE IF NOT EXISTS answer_decisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL,
+		callsign TEXT NOT NULL,
+		band TEXT NOT NULL DEFAULT '',
+		frequency INTEGER NOT NULL DEFAULT 0,
+		snr REAL NOT NULL DEFAULT 0.0,
+		message TEXT NOT NULL,
+		decision TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		reply TEXT NOT NULL DEFAULT ''
+	);
+
+	-- Contacts: stations we have both sent to and heard from, one row per
+	-- band and exchange
+	CREATE TABLE IF NOT EXISTS qso_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		callsign TEXT NOT NULL,
+		start_time DATETIME NOT NULL,
+		end_time DATETIME NOT NULL,
+		band TEXT NOT NULL DEFAULT '',
+		frequency INTEGER NOT NULL DEFAULT 0,
+		mode TEXT NOT NULL DEFAULT 'JS8',
+		grid TEXT NOT NULL DEFAULT '',
+		snr REAL NOT NULL DEFAULT 0.0
+	);
+
+	-- Structured messages sent and received as data frames, with their
+	-- values as JSON in template order
+	CREATE TABLE IF NOT EXISTS forms (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL,
+		direction TEXT NOT NULL,
+		template TEXT NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		from_callsign TEXT NOT NULL,
+		to_callsign TEXT NOT NULL DEFAULT '',
+		frequency INTEGER NOT NULL DEFAULT 0,
+		snr REAL NOT NULL DEFAULT 0.0,
+		frames INTEGER NOT NULL DEFAULT 0,
+		form_values TEXT NOT NULL DEFAULT '[]'
+	);
+
+	-- Files sent and received as transfer frames, with their progress
+	CREATE TABLE IF NOT EXISTS file_transfers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		transfer_id INTEGER NOT NULL,
+		direction TEXT NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		from_callsign TEXT NOT NULL DEFAULT '',
+		to_callsign TEXT NOT NULL DEFAULT '',
+		size INTEGER NOT NULL DEFAULT 0,
+		frames INTEGER NOT NULL DEFAULT 0,
+		done INTEGER NOT NULL DEFAULT 0,
+		rounds INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		content BLOB,
+		started_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	-- Telemetry heard from other stations; heard is the Unix time of the
+	-- last frame and uptime is in seconds. Fields not sent are NULL.
+	CREATE TABLE IF NOT EXISTS telemetry (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		callsign TEXT NOT NULL,
+		heard INTEGER NOT NULL,
+		frequency INTEGER NOT NULL DEFAULT 0,
+		snr REAL NOT NULL DEFAULT 0.0,
+		voltage REAL,
+		current REAL,
+		temperature REAL,
+		uptime INTEGER
+	);
+
 	-- Initialize stats if empty
-	INSERT OR IGNORE INTO message_stats (id, total_messages, total_rx, total_tx)
-	VALUES (1, 0, 0, 0);
+	INSERT INTO message_stats (id, total_messages, total_rx, total_tx)
+	VALUES (1, 0, 0, 0)
+	ON CONFLICT DO NOTHING;
 	`
 
-	_, err := ms.db.Exec(schema)
+	_, err := ms.db.Exec(ms.db.dialect.schema(schema))
 	return err
 }
 
+// migrateSchema adds columns introduced after the original schema
+func (ms *MessageStore) migrateSchema() error {
+	columns := []struct {
+		table      string
+		column     string
+		definition string
+	}{
+		{"messages", "channel", "TEXT NOT NULL DEFAULT ''"},
+		{"messages", "status", "TEXT NOT NULL DEFAULT ''"},
+		{"messages", "delivery", "TEXT NOT NULL DEFAULT ''"},
+		{"messages", "offset", "INTEGER NOT NULL DEFAULT 0"},
+		{"messages", "tx_power", "INTEGER NOT NULL DEFAULT 0"},
+		{"messages", "snippet", "TEXT NOT NULL DEFAULT ''"},
+		{"messages", "noise_floor", "REAL"},
+		{"messages", "dial", "INTEGER NOT NULL DEFAULT 0"},
+		{"messages", "band", "TEXT NOT NULL DEFAULT ''"},
+		{"messages", "submode", "TEXT NOT NULL DEFAULT ''"},
+		{"messages", "resends", "INTEGER NOT NULL DEFAULT 0"},
+		{"messages", "data_frames", "BOOLEAN NOT NULL DEFAULT FALSE"},
+		{"stations", "country", "TEXT NOT NULL DEFAULT ''"},
+		{"stations", "latitude", "REAL NOT NULL DEFAULT 0.0"},
+		{"stations", "longitude", "REAL NOT NULL DEFAULT 0.0"},
+		{"stations", "looked_up_at", "DATETIME"},
+		{"stations", "entity", "TEXT NOT NULL DEFAULT ''"},
+		{"stations", "state", "TEXT NOT NULL DEFAULT ''"},
+		{"qso_log", "lotw_uploaded", "DATETIME"},
+		{"qso_log", "eqsl_uploaded", "DATETIME"},
+		{"message_stats", "duplicates", "INTEGER NOT NULL DEFAULT 0"},
+		{"message_stats", "last_duplicate", "DATETIME"},
+	}
+
+	added := make(map[string]bool)
+	for _, c := range columns {
+		exists, err := ms.columnExists(c.table, c.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		alterSQL := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN "%s" %s`, c.table, c.column, ms.db.dialect.schema(c.definition))
+		if _, err := ms.db.Exec(alterSQL); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", c.table, c.column, err)
+		}
+		added[c.table+"."+c.column] = true
+		logger.Infof("Message store: added column %s.%s", c.table, c.column)
+	}
+
+	// Decodes stored with their offset had the dial added to it
+	if added["messages.dial"] {
+		if _, err := ms.db.Exec(`UPDATE messages SET dial = frequency - "offset" WHERE "offset" > 0 AND frequency > "offset"`); err != nil {
+			return fmt.Errorf("failed to fill in dial frequencies: %w", err)
+		}
+	}
+	if added["messages.band"] {
+		if err := ms.fillBands(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fillBands names the band of messages stored before bands were
+func (ms *MessageStore) fillBands() error {
+	rows, err := ms.db.Query("SELECT DISTINCT frequency FROM messages WHERE frequency > 0")
+	if err != nil {
+		return fmt.Errorf("failed to read message frequencies: %w", err)
+	}
+	var frequencies []int
+	for rows.Next() {
+		var frequency int
+		if err := rows.Scan(&frequency); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan message frequency: %w", err)
+		}
+		frequencies = append(frequencies, frequency)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, frequency := range frequencies {
+		band := protocol.Band(frequency)
+		if band == "" {
+			continue
+		}
+		if _, err := ms.db.Exec("UPDATE messages SET band = ? WHERE frequency = ?", band, frequency); err != nil {
+			return fmt.Errorf("failed to fill in message bands: %w", err)
+		}
+	}
+	return nil
+}
+
+// columnExists reports whether a table already has the named column
+func (ms *MessageStore) columnExists(table, column string) (bool, error) {
+	if ms.db.dialect == BackendPostgres {
+		var exists bool
+		err := ms.db.QueryRow(`
+			SELECT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?
+			)
+		`, table, column).Scan(&exists)
+		if err != nil {
+			return false, fmt.Errorf("failed to read table info for %s: %w", table, err)
+		}
+		return exists, nil
+	}
+
+	rows, err := ms.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to read table info for %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid          int
+			name         string
+			columnType   string
+			notNull      int
+			defaultValue sql.NullString
+			primaryKey   int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &primaryKey); err != nil {
+			return false, fmt.Errorf("failed to scan table info: %w", err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
 // createIndexes creates database indexes for performance
 func (ms *MessageStore) createIndexes() error {
 	indexes := []string{
@@ -129,9 +495,15 @@ func (ms *MessageStore) createIndexes() error {
 		"CREATE INDEX IF NOT EXISTS idx_messages_direction ON messages(direction)",
 		"CREATE INDEX IF NOT EXISTS idx_messages_is_read ON messages(is_read)",
 		"CREATE INDEX IF NOT EXISTS idx_messages_message_type ON messages(message_type)",
+		"CREATE INDEX IF NOT EXISTS idx_messages_status ON messages(status)",
 		"CREATE INDEX IF NOT EXISTS idx_conversations_callsign ON conversations(callsign)",
 		"CREATE INDEX IF NOT EXISTS idx_conversations_last_message_time ON conversations(last_message_time DESC)",
 		"CREATE INDEX IF NOT EXISTS idx_conversations_unread_count ON conversations(unread_count)",
+		"CREATE INDEX IF NOT EXISTS idx_stations_last_heard ON stations(last_heard DESC)",
+		"CREATE INDEX IF NOT EXISTS idx_qso_log_callsign ON qso_log(callsign, band)",
+		"CREATE INDEX IF NOT EXISTS idx_snr_history_callsign ON snr_history(callsign, heard)",
+		"CREATE INDEX IF NOT EXISTS idx_snr_history_heard ON snr_history(heard)",
+		"CREATE INDEX IF NOT EXISTS idx_telemetry_callsign ON telemetry(callsign, heard)",
 	}
 
 	for _, indexSQL := range indexes {
@@ -145,53 +517,93 @@ func (ms *MessageStore) createIndexes() error {
 
 // StoreMessage stores a message in the database
 func (ms *MessageStore) StoreMessage(msg protocol.Message, direction string, messageType string) error {
+	_, err := ms.storeMessage(msg, direction, messageType)
+	return err
+}
+
+// StoreReceived stores a received message and returns it with its ID. It
+// also reports whether the message is the first from its sender, which
+// starts a new conversation. A message already stored within DedupWindow is
+// counted in the stats and not stored again, returning ErrDuplicate.
+func (ms *MessageStore) StoreReceived(msg protocol.Message, messageType string) (protocol.Message, bool, error) {
+	duplicate, err := ms.isDuplicate(msg)
+	if err != nil {
+		return msg, false, err
+	}
+	if duplicate {
+		if err := ms.countDuplicate(); err != nil {
+			logger.Warnf("Failed to count duplicate: %v", err)
+		}
+		return msg, false, ErrDuplicate
+	}
+
+	var known int
+	if err := ms.db.QueryRow("SELECT COUNT(*) FROM conversations WHERE callsign = ?", msg.From).Scan(&known); err != nil {
+		return msg, false, fmt.Errorf("failed to look up conversation: %w", err)
+	}
+
+	id, err := ms.storeMessage(msg, "RX", messageType)
+	if err != nil {
+		return msg, false, err
+	}
+	msg.ID = id
+	return msg, known == 0, nil
+}
+
+// storeMessage stores a message and returns its ID
+func (ms *MessageStore) storeMessage(msg protocol.Message, direction string, messageType string) (int, error) {
 	tx, err := ms.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	if msg.Band == "" {
+		msg.Band = protocol.Band(msg.Frequency)
+	}
+
 	// Insert message
 	query := `
 		INSERT INTO messages (
 			timestamp, from_callsign, to_callsign, message_text,
-			snr, frequency, mode, direction, message_type
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			snr, frequency, "offset", mode, direction, message_type, channel, status, delivery, tx_power, snippet, noise_floor, dial, band, submode, data_frames
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := tx.Exec(query,
+	messageID, err := tx.insert(query,
 		msg.Timestamp, msg.From, msg.To, msg.Message,
-		msg.SNR, msg.Frequency, msg.Mode, direction, messageType,
+		msg.SNR, msg.Frequency, msg.Offset, msg.Mode, direction, messageType, msg.Channel, msg.Status, msg.Delivery, msg.Power, msg.Snippet, msg.NoiseFloor, msg.Dial, msg.Band, msg.Submode, msg.DataFrames,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to insert message: %w", err)
+		return 0, fmt.Errorf("failed to insert message: %w", err)
 	}
 
-	messageID, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("failed to get message ID: %w", err)
+	if msg.Raw != nil {
+		if err := ms.storeRawFrame(tx, messageID, msg.Raw); err != nil {
+			return 0, fmt.Errorf("failed to store raw frame: %w", err)
+		}
 	}
 
 	// Update conversation
 	if err := ms.updateConversation(tx, msg.From, messageID, msg.Timestamp, direction); err != nil {
-		return fmt.Errorf("failed to update conversation: %w", err)
+		return 0, fmt.Errorf("failed to update conversation: %w", err)
 	}
 
 	// Update stats
 	if err := ms.updateStats(tx, direction); err != nil {
-		return fmt.Errorf("failed to update stats: %w", err)
+		return 0, fmt.Errorf("failed to update stats: %w", err)
 	}
 
 	// Check if we need to cleanup old messages
 	if err := ms.cleanupOldMessages(tx); err != nil {
-		log.Printf("Warning: failed to cleanup old messages: %v", err)
+		logger.Warnf("Failed to cleanup old messages: %v", err)
 	}
 
-	return tx.Commit()
+	return int(messageID), tx.Commit()
 }
 
 // updateConversation updates the conversation record for a callsign
-func (ms *MessageStore) updateConversation(tx *sql.Tx, callsign string, messageID int64, timestamp time.Time, direction string) error {
+func (ms *MessageStore) updateConversation(tx *transaction, callsign string, messageID int64, timestamp time.Time, direction string) error {
 	// Insert or update conversation
 	query := `
 		INSERT INTO conversations (callsign, last_message_id, last_message_time, unread_count)
@@ -216,7 +628,7 @@ func (ms *MessageStore) updateConversation(tx *sql.Tx, callsign string, messageI
 }
 
 // updateStats updates message statistics
-func (ms *MessageStore) updateStats(tx *sql.Tx, direction string) error {
+func (ms *MessageStore) updateStats(tx *transaction, direction string) error {
 	query := `
 		UPDATE message_stats SET
 			total_messages = total_messages + 1,
@@ -246,7 +658,7 @@ func (ms *MessageStore) CleanupOldMessages() error {
 }
 
 // cleanupOldMessages removes messages beyond the maximum limit
-func (ms *MessageStore) cleanupOldMessages(tx *sql.Tx) error {
+func (ms *MessageStore) cleanupOldMessages(tx *transaction) error {
 	if ms.maxMessages <= 0 {
 		return nil // No limit
 	}
@@ -262,12 +674,13 @@ func (ms *MessageStore) cleanupOldMessages(tx *sql.Tx) error {
 		return nil // Within limit
 	}
 
-	// Delete oldest messages beyond limit
+	// Delete oldest messages beyond limit, keeping TX still waiting to go out
 	deleteCount := count - ms.maxMessages
 	query := `
 		DELETE FROM messages
 		WHERE id IN (
 			SELECT id FROM messages
+			WHERE status NOT IN ('queued', 'transmitting')
 			ORDER BY timestamp ASC
 			LIMIT ?
 		)
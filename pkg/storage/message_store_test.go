@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -497,4 +499,196 @@ func TestMessageStoreIntegration(t *testing.T) {
 			t.Errorf("Expected 2 conversations, got %d", len(conversations))
 		}
 	})
-}
\ No newline at end of file
+}
+func TestMigrateSchema(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "js8d-migrate-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	dbPath := filepath.Join(tempDir, "migrate.db")
+
+	// Simulate a database created before the channel column existed
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		from_callsign TEXT NOT NULL,
+		to_callsign TEXT NOT NULL DEFAULT '',
+		message_text TEXT NOT NULL,
+		snr REAL NOT NULL DEFAULT 0.0,
+		frequency INTEGER NOT NULL DEFAULT 0,
+		mode TEXT NOT NULL DEFAULT 'NORMAL',
+		direction TEXT NOT NULL CHECK (direction IN ('RX', 'TX')),
+		message_type TEXT NOT NULL DEFAULT 'MESSAGE',
+		is_read BOOLEAN NOT NULL DEFAULT FALSE,
+		grid_square TEXT DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create legacy table: %v", err)
+	}
+	db.Close()
+
+	store, err := NewMessageStore(dbPath, 100)
+	if err != nil {
+		t.Fatalf("Failed to open legacy database: %v", err)
+	}
+	defer store.Close()
+
+	exists, err := store.columnExists("messages", "channel")
+	if err != nil {
+		t.Fatalf("Failed to check column: %v", err)
+	}
+	if !exists {
+		t.Error("Expected channel column to be added by migration")
+	}
+}
+
+func TestMigrateDial(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "dial.db")
+
+	// A database whose decodes were stored with their offset but no dial
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		from_callsign TEXT NOT NULL,
+		to_callsign TEXT NOT NULL DEFAULT '',
+		message_text TEXT NOT NULL,
+		snr REAL NOT NULL DEFAULT 0.0,
+		frequency INTEGER NOT NULL DEFAULT 0,
+		"offset" INTEGER NOT NULL DEFAULT 0,
+		mode TEXT NOT NULL DEFAULT 'NORMAL',
+		direction TEXT NOT NULL CHECK (direction IN ('RX', 'TX')),
+		message_type TEXT NOT NULL DEFAULT 'MESSAGE',
+		is_read BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	INSERT INTO messages (from_callsign, message_text, frequency, "offset", direction)
+	VALUES ('N0ABC', 'HB', 14079500, 1500, 'RX'), ('K3DEP', 'HB', 14078000, 0, 'RX')`)
+	if err != nil {
+		t.Fatalf("Failed to create legacy table: %v", err)
+	}
+	db.Close()
+
+	store, err := NewMessageStore(dbPath, 100)
+	if err != nil {
+		t.Fatalf("Failed to open legacy database: %v", err)
+	}
+	defer store.Close()
+
+	messages, err := store.GetMessagesByCallsign("N0ABC", 10, 0)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("Expected N0ABC's message, got %d (%v)", len(messages), err)
+	}
+	if m := messages[0]; m.Dial != 14078000 || m.Offset != 1500 || m.Frequency != 14079500 {
+		t.Errorf("Expected the dial worked out from the offset, got %+v", m)
+	}
+	if m := messages[0]; m.Band != "20m" {
+		t.Errorf("Expected the band named from the frequency, got %q", m.Band)
+	}
+
+	// New messages are stored with their band
+	if err := store.StoreMessage(protocol.Message{Timestamp: time.Now(), From: "W1AW", Message: "HB", Frequency: 432065000}, "RX", "MESSAGE"); err != nil {
+		t.Fatalf("Failed to store message: %v", err)
+	}
+	messages, err = store.GetMessagesByCallsign("W1AW", 10, 0)
+	if err != nil || len(messages) != 1 || messages[0].Band != "70cm" {
+		t.Errorf("Expected a 70cm message, got %+v (%v)", messages, err)
+	}
+
+	messages, err = store.GetMessagesByCallsign("K3DEP", 10, 0)
+	if err != nil || len(messages) != 1 || messages[0].Dial != 0 {
+		t.Errorf("Expected no dial without an offset, got %+v (%v)", messages, err)
+	}
+}
+
+func TestStoreReceived(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	msg := protocol.Message{
+		Timestamp: time.Now(),
+		From:      "N0ABC",
+		To:        "K3DEP",
+		Message:   "K3DEP HELLO",
+		Frequency: 14078000,
+		Mode:      "JS8",
+	}
+
+	first, isNew, err := store.StoreReceived(msg, "MESSAGE")
+	if err != nil {
+		t.Fatalf("Failed to store message: %v", err)
+	}
+	if first.ID == 0 || !isNew {
+		t.Errorf("Expected an ID and a new conversation, got %d, %v", first.ID, isNew)
+	}
+
+	// The same text a cycle later is a message of its own
+	msg.Timestamp = msg.Timestamp.Add(15 * time.Second)
+	second, isNew, err := store.StoreReceived(msg, "MESSAGE")
+	if err != nil {
+		t.Fatalf("Failed to store message: %v", err)
+	}
+	if second.ID == first.ID || isNew {
+		t.Errorf("Expected a new ID in the same conversation, got %d, %v", second.ID, isNew)
+	}
+}
+
+func TestStoreReceivedDuplicates(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	msg := protocol.Message{
+		Timestamp: time.Now(),
+		From:      "N0ABC",
+		To:        "K3DEP",
+		Message:   "K3DEP HELLO",
+		Frequency: 14078000,
+		Mode:      "JS8",
+		Submode:   "normal",
+	}
+	if _, _, err := store.StoreReceived(msg, "MESSAGE"); err != nil {
+		t.Fatalf("Failed to store message: %v", err)
+	}
+
+	// A second decode pass or the other RX channel, in another time zone
+	dup := msg
+	dup.Timestamp = msg.Timestamp.Add(-1500 * time.Millisecond).UTC()
+	dup.Channel = "B"
+	if _, _, err := store.StoreReceived(dup, "MESSAGE"); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate, got %v", err)
+	}
+
+	// Another submode or another station is not a duplicate
+	others := []protocol.Message{msg, msg}
+	others[0].Submode = "fast"
+	others[1].From = "N0XYZ"
+	for _, other := range others {
+		if _, _, err := store.StoreReceived(other, "MESSAGE"); err != nil {
+			t.Errorf("Expected %s from %s stored, got %v", other.Submode, other.From, err)
+		}
+	}
+
+	count, _ := store.GetMessageCount()
+	if count != 3 {
+		t.Errorf("Expected 3 messages stored, got %d", count)
+	}
+	stats, err := store.GetMessageStats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats.Duplicates != 1 || stats.LastDuplicate.IsZero() {
+		t.Errorf("Expected 1 duplicate counted, got %d at %v", stats.Duplicates, stats.LastDuplicate)
+	}
+}
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Net is a directed net run from this station as net control
+type Net struct {
+	ID        int64        `json:"id"`
+	Group     string       `json:"group"` // The net's group, such as @ARESNET
+	Frequency int          `json:"frequency"`
+	Opened    time.Time    `json:"opened"`
+	Closed    *time.Time   `json:"closed,omitempty"` // nil while the net is open
+	Count     int          `json:"count"`            // Stations checked in
+	Checkins  []NetCheckin `json:"checkins,omitempty"`
+}
+
+// NetCheckin is a station that checked in to a net
+type NetCheckin struct {
+	Callsign string    `json:"callsign"`
+	Time     time.Time `json:"time"` // When it first checked in
+	Last     time.Time `json:"last"` // When it was last heard calling the net
+	SNR      float32   `json:"snr"`  // Of its latest message to the net
+	Grid     string    `json:"grid,omitempty"`
+	Message  string    `json:"message"` // Its first message to the net
+	Messages int       `json:"messages"`
+}
+
+// OpenNet stores a new net and sets its ID
+func (ms *MessageStore) OpenNet(net *Net) error {
+	net.Group = strings.ToUpper(net.Group)
+	id, err := ms.db.insert(`
+		INSERT INTO nets (net_group, frequency, opened_at) VALUES (?, ?, ?)
+	`, net.Group, net.Frequency, net.Opened)
+	if err != nil {
+		return fmt.Errorf("failed to open net: %w", err)
+	}
+	net.ID = id
+	return nil
+}
+
+// CloseNet records when a net closed
+func (ms *MessageStore) CloseNet(id int64, at time.Time) error {
+	if _, err := ms.db.Exec("UPDATE nets SET closed_at = ? WHERE id = ?", at, id); err != nil {
+		return fmt.Errorf("failed to close net: %w", err)
+	}
+	return nil
+}
+
+// CloseOpenNets closes the nets left open when js8d stopped, at the time
+// given, returning how many there were
+func (ms *MessageStore) CloseOpenNets(at time.Time) (int, error) {
+	result, err := ms.db.Exec("UPDATE nets SET closed_at = ? WHERE closed_at IS NULL", at)
+	if err != nil {
+		return 0, fmt.Errorf("failed to close open nets: %w", err)
+	}
+	closed, err := result.RowsAffected()
+	return int(closed), err
+}
+
+// RecordCheckin checks a station in to a net, or updates its check-in
+// when it already has, reporting whether this was its first
+func (ms *MessageStore) RecordCheckin(netID int64, checkin *NetCheckin) (bool, error) {
+	checkin.Callsign = strings.ToUpper(checkin.Callsign)
+	result, err := ms.db.Exec(`
+		UPDATE net_checkins SET last_at = ?, snr = ?, messages = messages + 1,
+			grid = CASE WHEN ? = '' THEN grid ELSE ? END
+		WHERE net_id = ? AND callsign = ?
+	`, checkin.Last, checkin.SNR, checkin.Grid, checkin.Grid, netID, checkin.Callsign)
+	if err != nil {
+		return false, fmt.Errorf("failed to update check-in: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err != nil || updated > 0 {
+		return false, err
+	}
+
+	_, err = ms.db.Exec(`
+		INSERT INTO net_checkins (net_id, callsign, first_at, last_at, snr, grid, message, messages)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 1)
+	`, netID, checkin.Callsign, checkin.Time, checkin.Last, checkin.SNR, checkin.Grid, checkin.Message)
+	if err != nil {
+		return false, fmt.Errorf("failed to record check-in: %w", err)
+	}
+	return true, nil
+}
+
+// netColumns are the columns queryNets reads
+const netColumns = `
+	n.id, n.net_group, n.frequency, n.opened_at, n.closed_at,
+	(SELECT COUNT(*) FROM net_checkins c WHERE c.net_id = n.id)
+`
+
+// GetNets returns the latest nets, newest first, without their check-ins
+func (ms *MessageStore) GetNets(limit int) ([]Net, error) {
+	return ms.queryNets(`SELECT `+netColumns+` FROM nets n ORDER BY n.id DESC LIMIT ?`, limit)
+}
+
+// GetNet returns a net with its check-ins in the order they came, or nil
+// when there is none
+func (ms *MessageStore) GetNet(id int64) (*Net, error) {
+	nets, err := ms.queryNets(`SELECT `+netColumns+` FROM nets n WHERE n.id = ?`, id)
+	if err != nil || len(nets) == 0 {
+		return nil, err
+	}
+	net := &nets[0]
+
+	rows, err := ms.db.Query(`
+		SELECT callsign, first_at, last_at, snr, grid, message, messages
+		FROM net_checkins WHERE net_id = ? ORDER BY first_at, callsign
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query check-ins: %w", err)
+	}
+	defer rows.Close()
+
+	net.Checkins = []NetCheckin{}
+	for rows.Next() {
+		var c NetCheckin
+		if err := rows.Scan(&c.Callsign, &c.Time, &c.Last, &c.SNR, &c.Grid, &c.Message, &c.Messages); err != nil {
+			return nil, fmt.Errorf("failed to scan check-in: %w", err)
+		}
+		net.Checkins = append(net.Checkins, c)
+	}
+	return net, rows.Err()
+}
+
+// queryNets reads the nets a query of netColumns returns
+func (ms *MessageStore) queryNets(query string, args ...interface{}) ([]Net, error) {
+	rows, err := ms.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nets: %w", err)
+	}
+	defer rows.Close()
+
+	nets := []Net{}
+	for rows.Next() {
+		var n Net
+		var closed sql.NullTime
+		if err := rows.Scan(&n.ID, &n.Group, &n.Frequency, &n.Opened, &closed, &n.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan net: %w", err)
+		}
+		if closed.Valid {
+			n.Closed = &closed.Time
+		}
+		nets = append(nets, n)
+	}
+	return nets, rows.Err()
+}
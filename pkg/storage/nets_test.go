@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNets(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	opened := time.Now().Add(-time.Hour).Truncate(time.Second)
+	net := &Net{Group: "@aresnet", Frequency: 7078000, Opened: opened}
+	if err := store.OpenNet(net); err != nil || net.ID == 0 {
+		t.Fatalf("Failed to open net: %v", err)
+	}
+
+	checkin := func(call, grid, message string, at time.Time) bool {
+		t.Helper()
+		first, err := store.RecordCheckin(net.ID, &NetCheckin{Callsign: call, Time: at, Last: at, SNR: -10, Grid: grid, Message: message})
+		if err != nil {
+			t.Fatalf("Failed to check %s in: %v", call, err)
+		}
+		return first
+	}
+	if !checkin("w1aw", "", "@ARESNET QNI", opened.Add(time.Minute)) || !checkin("N0ABC", "EM48", "@ARESNET QNI EM48", opened.Add(2*time.Minute)) {
+		t.Error("Expected each station's first check-in reported")
+	}
+	if checkin("W1AW", "FN31", "@ARESNET TRAFFIC", opened.Add(3*time.Minute)) {
+		t.Error("Expected a second message to update the check-in")
+	}
+
+	got, err := store.GetNet(net.ID)
+	if err != nil || got == nil || got.Group != "@ARESNET" || got.Closed != nil || got.Count != 2 || len(got.Checkins) != 2 {
+		t.Fatalf("Expected the open net with 2 check-ins, got %+v, %v", got, err)
+	}
+	w1aw := got.Checkins[0]
+	if w1aw.Callsign != "W1AW" || w1aw.Messages != 2 || w1aw.Message != "@ARESNET QNI" || w1aw.Grid != "FN31" || !w1aw.Last.Equal(opened.Add(3*time.Minute)) {
+		t.Errorf("Expected W1AW's check-in updated, got %+v", w1aw)
+	}
+
+	closed := opened.Add(time.Hour)
+	if err := store.CloseNet(net.ID, closed); err != nil {
+		t.Fatal(err)
+	}
+	left := &Net{Group: "@ARESNET", Opened: closed}
+	store.OpenNet(left)
+	if n, err := store.CloseOpenNets(closed.Add(time.Minute)); n != 1 || err != nil {
+		t.Errorf("Expected the net left open closed, got %d, %v", n, err)
+	}
+
+	nets, err := store.GetNets(10)
+	if err != nil || len(nets) != 2 || nets[0].ID != left.ID || nets[1].Closed == nil || !nets[1].Closed.Equal(closed) || nets[1].Checkins != nil {
+		t.Errorf("Expected both nets closed, newest first, without check-ins, got %+v, %v", nets, err)
+	}
+	if missing, err := store.GetNet(net.ID + 100); missing != nil || err != nil {
+		t.Errorf("Expected no net, got %+v, %v", missing, err)
+	}
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// SUnit is the dB in one S-unit of an S-meter
+const SUnit = 6.0
+
+// NoisePoint is the average noise floor on a band over one hour
+type NoisePoint struct {
+	Time       time.Time `json:"time"`
+	NoiseFloor float64   `json:"noise_floor"`
+}
+
+// BandNoise is the noise floor measured on a band over a range of hours
+type BandNoise struct {
+	Band     string       `json:"band"`
+	Samples  int          `json:"samples"`
+	Average  float64      `json:"average"`  // dB over every sample
+	Quietest float64      `json:"quietest"` // dB, the quietest hour
+	Loudest  float64      `json:"loudest"`  // dB, the loudest hour
+	Latest   NoisePoint   `json:"latest"`
+	SUnits   float64      `json:"s_units"` // How far the latest hour is over the quietest, in S-units
+	ByHour   [24]*float64 `json:"by_hour"` // Average by UTC hour of the day, nil for hours never measured
+	Points   []NoisePoint `json:"points"`  // Each hour, oldest first
+}
+
+// RecordBandNoise adds noise floor samples taken on a band to the hour they
+// fall in
+func (ms *MessageStore) RecordBandNoise(band string, at time.Time, total float64, samples int) error {
+	if samples == 0 {
+		return nil
+	}
+	_, err := ms.db.Exec(`
+		INSERT INTO band_noise (band, bucket, noise_total, noise_samples)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(band, bucket) DO UPDATE SET
+			noise_total = noise_total + excluded.noise_total,
+			noise_samples = noise_samples + excluded.noise_samples
+	`, band, at.Truncate(time.Hour).Unix(), total, samples)
+	if err != nil {
+		return fmt.Errorf("failed to record band noise: %w", err)
+	}
+	return nil
+}
+
+// PruneBandNoise removes the band noise of hours before a time and returns
+// how many were removed
+func (ms *MessageStore) PruneBandNoise(before time.Time) (int, error) {
+	result, err := ms.db.Exec("DELETE FROM band_noise WHERE bucket < ?", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune band noise: %w", err)
+	}
+	pruned, err := result.RowsAffected()
+	return int(pruned), err
+}
+
+// GetBandNoise returns the noise floor of each band measured between two
+// times, sorted by band name
+func (ms *MessageStore) GetBandNoise(from, to time.Time) ([]BandNoise, error) {
+	rows, err := ms.db.Query(`
+		SELECT band, bucket, noise_total, noise_samples
+		FROM band_noise
+		WHERE bucket >= ? AND bucket < ? AND noise_samples > 0
+		ORDER BY band, bucket
+	`, from.Truncate(time.Hour).Unix(), to.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query band noise: %w", err)
+	}
+	defer rows.Close()
+
+	type hourTotal struct {
+		total   float64
+		samples int
+	}
+	bands := map[string]*BandNoise{}
+	totals := map[string]float64{}
+	byHour := map[string]*[24]hourTotal{}
+	for rows.Next() {
+		var band string
+		var bucket int64
+		var total float64
+		var samples int
+		if err := rows.Scan(&band, &bucket, &total, &samples); err != nil {
+			return nil, fmt.Errorf("failed to scan band noise: %w", err)
+		}
+
+		point := NoisePoint{Time: time.Unix(bucket, 0).UTC(), NoiseFloor: total / float64(samples)}
+		b := bands[band]
+		if b == nil {
+			b = &BandNoise{Band: band, Quietest: point.NoiseFloor, Loudest: point.NoiseFloor}
+			bands[band] = b
+			byHour[band] = &[24]hourTotal{}
+		}
+		b.Points = append(b.Points, point)
+		b.Samples += samples
+		b.Quietest = math.Min(b.Quietest, point.NoiseFloor)
+		b.Loudest = math.Max(b.Loudest, point.NoiseFloor)
+		b.Latest = point
+		totals[band] += total
+
+		hour := &byHour[band][point.Time.Hour()]
+		hour.total += total
+		hour.samples += samples
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]BandNoise, 0, len(bands))
+	for band, b := range bands {
+		b.Average = totals[band] / float64(b.Samples)
+		b.SUnits = math.Round((b.Latest.NoiseFloor-b.Quietest)/SUnit*10) / 10
+		for i, hour := range byHour[band] {
+			if hour.samples > 0 {
+				average := hour.total / float64(hour.samples)
+				b.ByHour[i] = &average
+			}
+		}
+		result = append(result, *b)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Band < result[j].Band })
+	return result, nil
+}
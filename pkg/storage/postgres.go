@@ -0,0 +1,7 @@
+//go:build postgres
+
+package storage
+
+// Building with -tags postgres links the PostgreSQL driver for the postgres
+// storage backend. It is left out of default builds to keep them small.
+import _ "github.com/lib/pq"
@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Services QSOs are uploaded to for confirmation
+const (
+	QSLServiceLoTW = "lotw"
+	QSLServiceEQSL = "eqsl"
+)
+
+// ValidQSLService reports whether service is one QSOs are uploaded to
+func ValidQSLService(service string) bool {
+	return service == QSLServiceLoTW || service == QSLServiceEQSL
+}
+
+// QSO is a contact in the log: a station we have both sent to and heard
+// from on a band, from the first message of the exchange to the last
+type QSO struct {
+	ID        int64     `json:"id"`
+	Callsign  string    `json:"callsign"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Band      string    `json:"band,omitempty"`
+	Frequency int       `json:"frequency"` // In Hz
+	Mode      string    `json:"mode"`
+	Grid      string    `json:"grid,omitempty"`
+	SNR       float32   `json:"snr"` // Of the station's last message heard
+
+	// When the QSO went up for confirmation, if it has
+	LoTWUploaded *time.Time `json:"lotw_uploaded,omitempty"`
+	EQSLUploaded *time.Time `json:"eqsl_uploaded,omitempty"`
+
+	// From the station database when the log is read
+	Entity    string `json:"entity,omitempty"` // Primary prefix of the DXCC entity
+	State     string `json:"state,omitempty"`
+	QSLStatus string `json:"qsl_status,omitempty"`
+}
+
+// LogQSO adds a contact to the log, or extends the station's latest one on
+// the band when it ended no more than gap before this one starts. It
+// reports whether a new contact was logged.
+func (ms *MessageStore) LogQSO(qso *QSO, gap time.Duration) (bool, error) {
+	callsign := strings.ToUpper(qso.Callsign)
+	if qso.Mode == "" {
+		qso.Mode = "JS8"
+	}
+
+	var id int64
+	var end time.Time
+	err := ms.db.QueryRow(`
+		SELECT id, end_time FROM qso_log
+		WHERE callsign = ? AND band = ?
+		ORDER BY end_time DESC
+		LIMIT 1
+	`, callsign, qso.Band).Scan(&id, &end)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to find QSO: %w", err)
+	}
+
+	if err == nil && !qso.Start.After(end.Add(gap)) {
+		_, err = ms.db.Exec(`
+			UPDATE qso_log SET
+				end_time = CASE WHEN end_time < ? THEN ? ELSE end_time END,
+				grid = CASE WHEN ? != '' THEN ? ELSE grid END,
+				snr = CASE WHEN ? != 0.0 THEN ? ELSE snr END,
+				frequency = CASE WHEN frequency = 0 THEN ? ELSE frequency END
+			WHERE id = ?
+		`, qso.End, qso.End, qso.Grid, qso.Grid, qso.SNR, qso.SNR, qso.Frequency, id)
+		if err != nil {
+			return false, fmt.Errorf("failed to extend QSO: %w", err)
+		}
+		qso.ID = id
+		return false, nil
+	}
+
+	qso.ID, err = ms.db.insert(`
+		INSERT INTO qso_log (callsign, start_time, end_time, band, frequency, mode, grid, snr)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, callsign, qso.Start, qso.End, qso.Band, qso.Frequency, qso.Mode, qso.Grid, qso.SNR)
+	if err != nil {
+		return false, fmt.Errorf("failed to log QSO: %w", err)
+	}
+	return true, nil
+}
+
+// qsoColumns are read for each QSO, with the station's grid filling in for
+// one not heard during the QSO
+const qsoColumns = `
+	SELECT q.id, q.callsign, q.start_time, q.end_time, q.band, q.frequency, q.mode,
+		   CASE WHEN q.grid != '' THEN q.grid ELSE COALESCE(s.grid, '') END,
+		   q.snr, q.lotw_uploaded, q.eqsl_uploaded,
+		   COALESCE(s.entity, ''), COALESCE(s.state, ''), COALESCE(s.qsl_status, '')
+	FROM qso_log q
+	LEFT JOIN stations s ON s.callsign = q.callsign`
+
+// GetQSOs returns the logged contacts, newest first. An empty callsign
+// returns every station's, and a limit of 0 no limit.
+func (ms *MessageStore) GetQSOs(callsign string, limit int) ([]QSO, error) {
+	query := qsoColumns
+	var args []interface{}
+	if callsign != "" {
+		query += " WHERE q.callsign = ?"
+		args = append(args, strings.ToUpper(callsign))
+	}
+	query += " ORDER BY q.start_time DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	return ms.queryQSOs(query, args...)
+}
+
+// GetQSO returns one logged QSO, or nil when there is none with the ID
+func (ms *MessageStore) GetQSO(id int64) (*QSO, error) {
+	qsos, err := ms.queryQSOs(qsoColumns+" WHERE q.id = ?", id)
+	if err != nil || len(qsos) == 0 {
+		return nil, err
+	}
+	return &qsos[0], nil
+}
+
+// GetPendingQSOs returns the QSOs not yet uploaded to a service, oldest
+// first
+func (ms *MessageStore) GetPendingQSOs(service string) ([]QSO, error) {
+	if !ValidQSLService(service) {
+		return nil, fmt.Errorf("invalid QSL service %q", service)
+	}
+	return ms.queryQSOs(qsoColumns + " WHERE q." + service + "_uploaded IS NULL ORDER BY q.start_time")
+}
+
+// MarkUploaded records that QSOs went up to a service, returning how many
+// were in the log
+func (ms *MessageStore) MarkUploaded(service string, ids []int64, at time.Time) (int, error) {
+	if !ValidQSLService(service) {
+		return 0, fmt.Errorf("invalid QSL service %q", service)
+	}
+
+	tx, err := ms.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	marked := 0
+	for _, id := range ids {
+		result, err := tx.Exec("UPDATE qso_log SET "+service+"_uploaded = ? WHERE id = ?", at, id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to mark QSO %d uploaded: %w", id, err)
+		}
+		n, _ := result.RowsAffected()
+		marked += int(n)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit: %w", err)
+	}
+	return marked, nil
+}
+
+// queryQSOs reads the QSOs a query of qsoColumns returns
+func (ms *MessageStore) queryQSOs(query string, args ...interface{}) ([]QSO, error) {
+	rows, err := ms.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query QSOs: %w", err)
+	}
+	defer rows.Close()
+
+	qsos := []QSO{}
+	for rows.Next() {
+		var q QSO
+		var lotw, eqsl sql.NullTime
+		err := rows.Scan(&q.ID, &q.Callsign, &q.Start, &q.End, &q.Band, &q.Frequency, &q.Mode,
+			&q.Grid, &q.SNR, &lotw, &eqsl, &q.Entity, &q.State, &q.QSLStatus)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan QSO: %w", err)
+		}
+		if lotw.Valid {
+			q.LoTWUploaded = &lotw.Time
+		}
+		if eqsl.Valid {
+			q.EQSLUploaded = &eqsl.Time
+		}
+		qsos = append(qsos, q)
+	}
+
+	return qsos, rows.Err()
+}
+
+// LastDirected returns when the latest message in a direction from one
+// station to another was stored, or the zero time when there is none. TX
+// messages count once they have been sent.
+func (ms *MessageStore) LastDirected(direction, from, to string) (time.Time, error) {
+	var last time.Time
+	err := ms.db.QueryRow(`
+		SELECT timestamp FROM messages
+		WHERE direction = ? AND UPPER(from_callsign) = ? AND UPPER(to_callsign) = ?
+		  AND status NOT IN ('queued', 'transmitting', 'failed')
+		ORDER BY timestamp DESC
+		LIMIT 1
+	`, direction, strings.ToUpper(from), strings.ToUpper(to)).Scan(&last)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to find last message: %w", err)
+	}
+	return last, nil
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+func TestLogQSO(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	qso := &QSO{Callsign: "k1abc", Start: start, End: start, Band: "20m", Frequency: 14078000, SNR: -12}
+	if isNew, err := store.LogQSO(qso, 30*time.Minute); !isNew || err != nil {
+		t.Fatalf("Expected a new QSO, got %v, %v", isNew, err)
+	}
+
+	// A message within the gap extends it, and a grid heard later fills in
+	later := &QSO{Callsign: "K1ABC", Start: start.Add(10 * time.Minute), End: start.Add(10 * time.Minute), Band: "20m", Grid: "FN42"}
+	if isNew, err := store.LogQSO(later, 30*time.Minute); isNew || err != nil || later.ID != qso.ID {
+		t.Fatalf("Expected QSO %d extended, got %v, %v, %d", qso.ID, isNew, err, later.ID)
+	}
+
+	// Another band, and the same band after the gap, are new QSOs
+	other := &QSO{Callsign: "K1ABC", Start: start.Add(15 * time.Minute), End: start.Add(15 * time.Minute), Band: "40m"}
+	if isNew, _ := store.LogQSO(other, 30*time.Minute); !isNew {
+		t.Error("Expected a new QSO on 40m")
+	}
+	again := &QSO{Callsign: "K1ABC", Start: start.Add(time.Hour), End: start.Add(time.Hour), Band: "20m"}
+	if isNew, _ := store.LogQSO(again, 30*time.Minute); !isNew {
+		t.Error("Expected a new QSO after the gap")
+	}
+
+	if err := store.SetCallbookEntry("K1ABC", &CallbookEntry{State: "MA", Grid: "FN42"}); err != nil {
+		t.Fatalf("Failed to set callbook entry: %v", err)
+	}
+
+	qsos, err := store.GetQSOs("k1abc", 0)
+	if err != nil {
+		t.Fatalf("Failed to get QSOs: %v", err)
+	}
+	if len(qsos) != 3 {
+		t.Fatalf("Expected 3 QSOs, got %+v", qsos)
+	}
+	first := qsos[2]
+	if first.ID != qso.ID || !first.End.Equal(start.Add(10*time.Minute)) || first.Grid != "FN42" ||
+		first.SNR != -12 || first.Frequency != 14078000 || first.Mode != "JS8" || first.State != "MA" {
+		t.Errorf("Expected the first QSO extended to 10 minutes, got %+v", first)
+	}
+	// The station's grid fills in for one not heard during the QSO
+	if qsos[0].Grid != "FN42" {
+		t.Errorf("Expected the station's grid on the latest QSO, got %+v", qsos[0])
+	}
+
+	if qsos, _ := store.GetQSOs("", 1); len(qsos) != 1 || qsos[0].ID != again.ID {
+		t.Errorf("Expected only the latest QSO, got %+v", qsos)
+	}
+
+	if got, err := store.GetQSO(other.ID); err != nil || got == nil || got.Band != "40m" {
+		t.Errorf("Expected the 40m QSO, got %+v, %v", got, err)
+	}
+	if got, err := store.GetQSO(999); got != nil || err != nil {
+		t.Errorf("Expected no QSO, got %+v, %v", got, err)
+	}
+}
+
+func TestLastDirected(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	if last, err := store.LastDirected("TX", "N0CALL", "K1ABC"); !last.IsZero() || err != nil {
+		t.Errorf("Expected no message, got %v, %v", last, err)
+	}
+
+	sent := protocol.Message{Timestamp: time.Now().Truncate(time.Second), From: "N0CALL", To: "K1ABC", Message: "K1ABC -10", Status: protocol.MessageQueued}
+	if err := store.StoreMessage(sent, "TX", "DIRECTED"); err != nil {
+		t.Fatalf("Failed to store message: %v", err)
+	}
+	if last, _ := store.LastDirected("TX", "N0CALL", "K1ABC"); !last.IsZero() {
+		t.Errorf("Expected a queued message not to count, got %v", last)
+	}
+
+	heard := protocol.Message{Timestamp: sent.Timestamp, From: "K1ABC", To: "N0CALL", Message: "N0CALL FN42"}
+	if err := store.StoreMessage(heard, "RX", "DIRECTED"); err != nil {
+		t.Fatalf("Failed to store message: %v", err)
+	}
+	if last, err := store.LastDirected("RX", "k1abc", "n0call"); !last.Equal(sent.Timestamp) || err != nil {
+		t.Errorf("Expected %v, got %v, %v", sent.Timestamp, last, err)
+	}
+}
+
+func TestQSLUploads(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	var ids []int64
+	for i, call := range []string{"K1ABC", "DL1ABC"} {
+		qso := &QSO{Callsign: call, Start: start.Add(time.Duration(i) * time.Minute), End: start, Band: "20m"}
+		if _, err := store.LogQSO(qso, 0); err != nil {
+			t.Fatalf("Failed to log QSO: %v", err)
+		}
+		ids = append(ids, qso.ID)
+	}
+
+	pending, err := store.GetPendingQSOs(QSLServiceLoTW)
+	if err != nil || len(pending) != 2 || pending[0].Callsign != "K1ABC" {
+		t.Fatalf("Expected both QSOs pending, oldest first, got %+v, %v", pending, err)
+	}
+
+	if marked, err := store.MarkUploaded(QSLServiceLoTW, []int64{ids[0], 999}, start); marked != 1 || err != nil {
+		t.Errorf("Expected 1 QSO marked, got %d, %v", marked, err)
+	}
+	if pending, _ := store.GetPendingQSOs(QSLServiceLoTW); len(pending) != 1 || pending[0].Callsign != "DL1ABC" {
+		t.Errorf("Expected only DL1ABC pending for LoTW, got %+v", pending)
+	}
+	if pending, _ := store.GetPendingQSOs(QSLServiceEQSL); len(pending) != 2 {
+		t.Errorf("Expected both QSOs pending for eQSL, got %+v", pending)
+	}
+
+	qsos, _ := store.GetQSOs("K1ABC", 0)
+	if len(qsos) != 1 || qsos[0].LoTWUploaded == nil || !qsos[0].LoTWUploaded.Equal(start) || qsos[0].EQSLUploaded != nil {
+		t.Errorf("Expected K1ABC uploaded to LoTW only, got %+v", qsos)
+	}
+
+	if _, err := store.GetPendingQSOs("qrz"); err == nil {
+		t.Error("Expected an unknown service rejected")
+	}
+	if _, err := store.MarkUploaded("qrz", ids, start); err == nil {
+		t.Error("Expected an unknown service rejected")
+	}
+}
@@ -17,6 +17,8 @@ type MessageQuery struct {
 	Callsign   string
 	Direction  string // "RX", "TX", or "" for both
 	MessageType string
+	Channel    string // RX channel label, "" for all
+	Status     string // TX status such as "queued", "" for all
 	UnreadOnly bool
 }
 
@@ -28,6 +30,8 @@ type ConversationSummary struct {
 	LastMessageText string    `json:"last_message_text"`
 	UnreadCount     int       `json:"unread_count"`
 	TotalMessages   int       `json:"total_messages"`
+	Sessions        []Session `json:"sessions,omitempty"` // QSOs with the station, newest first
+	Station         *Station  `json:"station,omitempty"`  // The station database entry, if any
 }
 
 // MessageStats represents database statistics
@@ -36,6 +40,8 @@ type MessageStats struct {
 	TotalRX       int       `json:"total_rx"`
 	TotalTX       int       `json:"total_tx"`
 	LastCleanup   time.Time `json:"last_cleanup"`
+	Duplicates    int       `json:"duplicates"`     // Received messages dropped as duplicates
+	LastDuplicate time.Time `json:"last_duplicate"` // When the latest was dropped
 }
 
 // GetMessages retrieves messages based on query parameters
@@ -45,7 +51,7 @@ func (ms *MessageStore) GetMessages(query MessageQuery) ([]protocol.Message, err
 
 	sqlQuery := `
 		SELECT id, timestamp, from_callsign, to_callsign, message_text,
-			   snr, frequency, mode
+			   snr, frequency, dial, band, "offset", mode, submode, channel, status, delivery, resends, snippet, noise_floor
 		FROM messages
 		WHERE 1=1
 	`
@@ -75,6 +81,16 @@ func (ms *MessageStore) GetMessages(query MessageQuery) ([]protocol.Message, err
 		args = append(args, query.MessageType)
 	}
 
+	if query.Channel != "" {
+		conditions = append(conditions, "channel = ?")
+		args = append(args, query.Channel)
+	}
+
+	if query.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, query.Status)
+	}
+
 	if query.UnreadOnly {
 		conditions = append(conditions, "is_read = FALSE")
 	}
@@ -115,7 +131,17 @@ func (ms *MessageStore) GetMessages(query MessageQuery) ([]protocol.Message, err
 			&msg.Message,
 			&msg.SNR,
 			&msg.Frequency,
+			&msg.Dial,
+			&msg.Band,
+			&msg.Offset,
 			&msg.Mode,
+			&msg.Submode,
+			&msg.Channel,
+			&msg.Status,
+			&msg.Delivery,
+			&msg.Resends,
+			&msg.Snippet,
+			&msg.NoiseFloor,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
@@ -235,12 +261,12 @@ func (ms *MessageStore) MarkMessagesAsRead(callsign string) error {
 // GetMessageStats retrieves database statistics
 func (ms *MessageStore) GetMessageStats() (*MessageStats, error) {
 	var stats MessageStats
-	var lastCleanup sql.NullTime
+	var lastCleanup, lastDuplicate sql.NullTime
 
 	err := ms.db.QueryRow(`
-		SELECT total_messages, total_rx, total_tx, last_cleanup
+		SELECT total_messages, total_rx, total_tx, last_cleanup, duplicates, last_duplicate
 		FROM message_stats WHERE id = 1
-	`).Scan(&stats.TotalMessages, &stats.TotalRX, &stats.TotalTX, &lastCleanup)
+	`).Scan(&stats.TotalMessages, &stats.TotalRX, &stats.TotalTX, &lastCleanup, &stats.Duplicates, &lastDuplicate)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get message stats: %w", err)
@@ -249,6 +275,9 @@ func (ms *MessageStore) GetMessageStats() (*MessageStats, error) {
 	if lastCleanup.Valid {
 		stats.LastCleanup = lastCleanup.Time
 	}
+	if lastDuplicate.Valid {
+		stats.LastDuplicate = lastDuplicate.Time
+	}
 
 	return &stats, nil
 }
@@ -257,7 +286,7 @@ func (ms *MessageStore) GetMessageStats() (*MessageStats, error) {
 func (ms *MessageStore) SearchMessages(searchTerm string, limit int) ([]protocol.Message, error) {
 	query := `
 		SELECT id, timestamp, from_callsign, to_callsign, message_text,
-			   snr, frequency, mode
+			   snr, frequency, dial, band, "offset", mode, submode, channel, status, delivery, resends, snippet, noise_floor
 		FROM messages
 		WHERE message_text LIKE ?
 		ORDER BY timestamp DESC
@@ -286,7 +315,17 @@ func (ms *MessageStore) SearchMessages(searchTerm string, limit int) ([]protocol
 			&msg.Message,
 			&msg.SNR,
 			&msg.Frequency,
+			&msg.Dial,
+			&msg.Band,
+			&msg.Offset,
 			&msg.Mode,
+			&msg.Submode,
+			&msg.Channel,
+			&msg.Status,
+			&msg.Delivery,
+			&msg.Resends,
+			&msg.Snippet,
+			&msg.NoiseFloor,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan search result: %w", err)
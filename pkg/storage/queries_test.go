@@ -1,8 +1,6 @@
 package storage
 
 import (
-	"os"
-	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -11,23 +9,11 @@ import (
 )
 
 func setupTestStore(t *testing.T) (*MessageStore, func()) {
-	tempDir, err := os.MkdirTemp("", "js8d-queries-test")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-
-	dbPath := filepath.Join(tempDir, "queries_test.db")
-	store, err := NewMessageStore(dbPath, 1000)
+	store, err := NewMemoryStore(1000)
 	if err != nil {
 		t.Fatalf("Failed to create store: %v", err)
 	}
-
-	cleanup := func() {
-		store.Close()
-		os.RemoveAll(tempDir)
-	}
-
-	return store, cleanup
+	return store, func() { store.Close() }
 }
 
 func seedTestMessages(t *testing.T, store *MessageStore) {
@@ -629,4 +615,60 @@ func TestQueryIntegration(t *testing.T) {
 			t.Errorf("Expected 1 search result for '73', got %d", len(searchResults))
 		}
 	})
-}
\ No newline at end of file
+}
+
+func TestMessageChannel(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	for _, channel := range []string{"left", "right"} {
+		msg := protocol.Message{
+			Timestamp: time.Now(),
+			From:      "N0ABC",
+			To:        "K3DEP",
+			Message:   "K3DEP DE N0ABC " + channel,
+			Frequency: 14078000,
+			Mode:      "JS8",
+			Channel:   channel,
+		}
+		if err := store.StoreMessage(msg, "RX", "MESSAGE"); err != nil {
+			t.Fatalf("Failed to store message: %v", err)
+		}
+	}
+
+	messages, err := store.GetMessages(MessageQuery{Channel: "right"})
+	if err != nil {
+		t.Fatalf("Failed to get messages: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message on right channel, got %d", len(messages))
+	}
+	if messages[0].Channel != "right" {
+		t.Errorf("Expected channel right, got %q", messages[0].Channel)
+	}
+}
+
+func TestMessageNoiseFloor(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	floor := -92.5
+	now := time.Now()
+	for i, noise := range []*float64{&floor, nil} {
+		msg := protocol.Message{Timestamp: now.Add(time.Duration(i) * time.Second), From: "N0ABC", Message: "HB", NoiseFloor: noise}
+		if err := store.StoreMessage(msg, "RX", "HEARTBEAT"); err != nil {
+			t.Fatalf("Failed to store message: %v", err)
+		}
+	}
+
+	messages, err := store.GetRecentMessages(10)
+	if err != nil || len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d (%v)", len(messages), err)
+	}
+	if messages[0].NoiseFloor != nil {
+		t.Errorf("Expected no noise floor, got %v", *messages[0].NoiseFloor)
+	}
+	if messages[1].NoiseFloor == nil || *messages[1].NoiseFloor != floor {
+		t.Errorf("Expected the noise floor as stored, got %v", messages[1].NoiseFloor)
+	}
+}
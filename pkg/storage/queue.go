@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// QueueMessage stores an outbound message as queued and returns it with its
+// ID, which the transmit loop uses to record its progress
+func (ms *MessageStore) QueueMessage(msg protocol.Message, messageType string) (protocol.Message, error) {
+	msg.Status = protocol.MessageQueued
+	id, err := ms.storeMessage(msg, "TX", messageType)
+	if err != nil {
+		return msg, err
+	}
+	msg.ID = id
+	return msg, nil
+}
+
+// SetMessageStatus records a TX status change for a stored message
+func (ms *MessageStore) SetMessageStatus(id int, status string) error {
+	return ms.setMessageField(id, "status", status)
+}
+
+// SetMessageDelivery records whether a directed message was acknowledged
+func (ms *MessageStore) SetMessageDelivery(id int, delivery string) error {
+	return ms.setMessageField(id, "delivery", delivery)
+}
+
+// SetMessageResend records that a directed message is being resent for the
+// resends-th time, in submode at offset
+func (ms *MessageStore) SetMessageResend(id, resends int, submode string, offset, frequency int) error {
+	result, err := ms.db.Exec(
+		`UPDATE messages SET resends = ?, submode = ?, "offset" = ?, frequency = ? WHERE id = ?`,
+		resends, submode, offset, frequency, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record message resend: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("message %d not found", id)
+	}
+	return nil
+}
+
+// ExpireDeliveries marks messages still awaiting an ACK from an earlier run
+// undelivered, since nothing is waiting for it any more. Messages that
+// were never sent keep waiting, as they go out again at startup.
+func (ms *MessageStore) ExpireDeliveries() (int, error) {
+	result, err := ms.db.Exec(
+		"UPDATE messages SET delivery = ? WHERE delivery = ? AND status NOT IN (?, ?)",
+		protocol.DeliveryUndelivered, protocol.DeliveryAwaiting, protocol.MessageQueued, protocol.MessageTransmitting,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire deliveries: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// setMessageField updates one column of a stored message
+func (ms *MessageStore) setMessageField(id int, column, value string) error {
+	result, err := ms.db.Exec("UPDATE messages SET "+column+" = ? WHERE id = ?", value, id)
+	if err != nil {
+		return fmt.Errorf("failed to update message %s: %w", column, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("message %d not found", id)
+	}
+
+	return nil
+}
+
+// GetQueuedMessages returns the outbound messages not yet sent or failed,
+// oldest first, so the queue resumes in order after a restart
+func (ms *MessageStore) GetQueuedMessages() ([]protocol.Message, error) {
+	rows, err := ms.db.Query(`
+		SELECT id, timestamp, from_callsign, to_callsign, message_text,
+			   snr, frequency, dial, band, "offset", mode, submode, channel, status, delivery, resends, tx_power, data_frames
+		FROM messages
+		WHERE direction = 'TX' AND status IN (?, ?)
+		ORDER BY id ASC
+	`, protocol.MessageQueued, protocol.MessageTransmitting)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query queued messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []protocol.Message
+	for rows.Next() {
+		var msg protocol.Message
+		err := rows.Scan(
+			&msg.ID,
+			&msg.Timestamp,
+			&msg.From,
+			&msg.To,
+			&msg.Message,
+			&msg.SNR,
+			&msg.Frequency,
+			&msg.Dial,
+			&msg.Band,
+			&msg.Offset,
+			&msg.Mode,
+			&msg.Submode,
+			&msg.Channel,
+			&msg.Status,
+			&msg.Delivery,
+			&msg.Resends,
+			&msg.Power,
+			&msg.DataFrames,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
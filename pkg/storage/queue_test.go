@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+func TestMessageQueue(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	var queued []protocol.Message
+	for i, text := range []string{"N0ABC SNR?", "N0ABC HELLO"} {
+		msg, err := store.QueueMessage(protocol.Message{
+			Timestamp: time.Now(),
+			From:      "K3DEP",
+			To:        "N0ABC",
+			Message:   text,
+			Frequency: 14078000,
+			Mode:      "JS8",
+			Submode:   "fast",
+			Power:     10 * i,
+		}, "MESSAGE")
+		if err != nil {
+			t.Fatalf("Failed to queue message: %v", err)
+		}
+		if msg.ID == 0 || msg.Status != protocol.MessageQueued {
+			t.Fatalf("Expected an ID and queued status, got %+v", msg)
+		}
+		queued = append(queued, msg)
+	}
+
+	if err := store.SetMessageStatus(queued[0].ID, protocol.MessageSent); err != nil {
+		t.Fatalf("Failed to set status: %v", err)
+	}
+	if err := store.SetMessageStatus(9999, protocol.MessageSent); err == nil {
+		t.Error("Expected an error for an unknown message")
+	}
+
+	// Only the unsent message resumes after a restart
+	pending, err := store.GetQueuedMessages()
+	if err != nil {
+		t.Fatalf("Failed to get queued messages: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != queued[1].ID || pending[0].Message != "N0ABC HELLO" || pending[0].Power != 10 {
+		t.Errorf("Expected the second message still queued at 10%% power, got %+v", pending)
+	}
+	if len(pending) == 1 && pending[0].Submode != "fast" {
+		t.Errorf("Expected the fast submode kept, got %q", pending[0].Submode)
+	}
+
+	sent, err := store.GetMessages(MessageQuery{Status: protocol.MessageSent})
+	if err != nil {
+		t.Fatalf("Failed to get messages: %v", err)
+	}
+	if len(sent) != 1 || sent[0].ID != queued[0].ID {
+		t.Errorf("Expected the first message sent, got %+v", sent)
+	}
+}
+
+func TestMessageDelivery(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	queue := func(status string) protocol.Message {
+		t.Helper()
+		msg, err := store.QueueMessage(protocol.Message{
+			Timestamp: time.Now(),
+			From:      "K3DEP",
+			To:        "N0ABC",
+			Message:   "N0ABC HELLO",
+			Delivery:  protocol.DeliveryAwaiting,
+		}, "MESSAGE")
+		if err != nil {
+			t.Fatalf("Failed to queue message: %v", err)
+		}
+		if err := store.SetMessageStatus(msg.ID, status); err != nil {
+			t.Fatalf("Failed to set status: %v", err)
+		}
+		return msg
+	}
+	delivered := queue(protocol.MessageSent)
+	stale := queue(protocol.MessageSent)
+	unsent := queue(protocol.MessageQueued)
+
+	if err := store.SetMessageDelivery(delivered.ID, protocol.DeliveryDelivered); err != nil {
+		t.Fatalf("Failed to set delivery: %v", err)
+	}
+	if err := store.SetMessageResend(stale.ID, 2, "slow", 1600, 14079600); err != nil {
+		t.Fatalf("Failed to record a resend: %v", err)
+	}
+	if err := store.SetMessageResend(9999, 1, "slow", 1600, 14079600); err == nil {
+		t.Error("Expected an error for an unknown message")
+	}
+
+	// Only a sent message still awaiting its ACK expires at startup
+	expired, err := store.ExpireDeliveries()
+	if err != nil || expired != 1 {
+		t.Fatalf("Expected one delivery expired, got %d (%v)", expired, err)
+	}
+
+	messages, err := store.GetMessages(MessageQuery{Direction: "TX"})
+	if err != nil {
+		t.Fatalf("Failed to get messages: %v", err)
+	}
+	want := map[int]string{
+		delivered.ID: protocol.DeliveryDelivered,
+		stale.ID:     protocol.DeliveryUndelivered,
+		unsent.ID:    protocol.DeliveryAwaiting,
+	}
+	for _, msg := range messages {
+		if msg.Delivery != want[msg.ID] {
+			t.Errorf("Message %d: expected %q, got %q", msg.ID, want[msg.ID], msg.Delivery)
+		}
+		if msg.ID == stale.ID && (msg.Resends != 2 || msg.Submode != "slow" || msg.Offset != 1600 || msg.Frequency != 14079600) {
+			t.Errorf("Expected the second resend in slow at 1600 Hz, got %+v", msg)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// RawFrame is what a stored message was decoded from
+type RawFrame struct {
+	MessageID int
+	Timestamp time.Time
+	From      string
+	Message   string
+	protocol.RawFrame
+}
+
+// storeRawFrame stores the payload and tones of a decoded message
+func (ms *MessageStore) storeRawFrame(tx *transaction, messageID int64, raw *protocol.RawFrame) error {
+	var tones strings.Builder
+	for _, tone := range raw.Tones {
+		if tone < 0 || tone > 7 {
+			return fmt.Errorf("invalid tone %d", tone)
+		}
+		tones.WriteByte(byte('0' + tone))
+	}
+	_, err := tx.Exec("INSERT INTO raw_frames (message_id, payload, tones) VALUES (?, ?, ?)", messageID, raw.Payload, tones.String())
+	return err
+}
+
+// GetRawFrame returns what a message was decoded from, or nil when it was
+// stored without it
+func (ms *MessageStore) GetRawFrame(messageID int) (*RawFrame, error) {
+	var frame RawFrame
+	var tones string
+	err := ms.db.QueryRow(`
+		SELECT m.id, m.timestamp, m.from_callsign, m.message_text, r.payload, r.tones
+		FROM raw_frames r
+		JOIN messages m ON m.id = r.message_id
+		WHERE r.message_id = ?
+	`, messageID).Scan(&frame.MessageID, &frame.Timestamp, &frame.From, &frame.Message, &frame.Payload, &tones)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get raw frame: %w", err)
+	}
+
+	frame.Tones = make([]int, len(tones))
+	for i, digit := range []byte(tones) {
+		frame.Tones[i] = int(digit - '0')
+	}
+	return &frame, nil
+}
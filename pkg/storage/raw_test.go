@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+func TestRawFrames(t *testing.T) {
+	store, err := NewMemoryStore(1)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	raw := &protocol.RawFrame{
+		Payload: []byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0, 0x12, 0x34, 0x40},
+		Tones:   []int{4, 2, 5, 6, 1, 3, 0, 7},
+	}
+	msg := protocol.Message{Timestamp: time.Now(), From: "N0ABC", Message: "CQ-N0ABC-XX", Mode: "JS8", Raw: raw}
+	stored, _, err := store.StoreReceived(msg, "CQ")
+	if err != nil {
+		t.Fatalf("Failed to store message: %v", err)
+	}
+
+	frame, err := store.GetRawFrame(stored.ID)
+	if err != nil || frame == nil {
+		t.Fatalf("Expected the raw frame, got %v, %v", frame, err)
+	}
+	if frame.Message != "CQ-N0ABC-XX" || !bytes.Equal(frame.Payload, raw.Payload) || len(frame.Tones) != len(raw.Tones) {
+		t.Errorf("Expected the frame as stored, got %+v", frame)
+	}
+	for i, tone := range frame.Tones {
+		if tone != raw.Tones[i] {
+			t.Errorf("Expected tone %d of symbol %d, got %d", raw.Tones[i], i, tone)
+		}
+	}
+
+	// Messages stored without one have none
+	msg.Raw = nil
+	msg.Timestamp = msg.Timestamp.Add(15 * time.Second)
+	plain, _, err := store.StoreReceived(msg, "CQ")
+	if err != nil {
+		t.Fatalf("Failed to store message: %v", err)
+	}
+	if frame, err := store.GetRawFrame(plain.ID); err != nil || frame != nil {
+		t.Errorf("Expected no raw frame, got %v, %v", frame, err)
+	}
+
+	// The frame goes with its message when old messages are cleaned up
+	if frame, _ := store.GetRawFrame(stored.ID); frame != nil {
+		t.Error("Expected the raw frame removed with its message")
+	}
+}
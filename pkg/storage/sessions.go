@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// DefaultSessionGap is the silence after which messages with a station
+// start a new QSO
+const DefaultSessionGap = 30 * time.Minute
+
+// Session is one QSO with a station: messages in either direction without
+// a long gap, a band change, a CQ or a 73 between them
+type Session struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	Duration        int       `json:"duration"` // seconds from the first to the last message
+	Band            string    `json:"band,omitempty"`
+	Frequency       int       `json:"frequency"`
+	MessageCount    int       `json:"message_count"`
+	FirstMessageID  int       `json:"first_message_id"`
+	LastMessageID   int       `json:"last_message_id"`
+	Summary         string    `json:"summary"` // the message that opened the QSO
+	LastMessageText string    `json:"last_message_text"`
+}
+
+// GetSessions returns the QSOs with a station, newest first. Messages more
+// than gap apart belong to different QSOs.
+func (ms *MessageStore) GetSessions(callsign string, gap time.Duration) ([]Session, error) {
+	rows, err := ms.db.Query(`
+		SELECT id, timestamp, from_callsign, to_callsign, message_text,
+			   snr, frequency, dial, band, "offset", mode, submode, channel, status, delivery, resends, snippet, noise_floor
+		FROM messages
+		WHERE from_callsign = ? OR to_callsign = ?
+		ORDER BY timestamp ASC, id ASC
+	`, callsign, callsign)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []protocol.Message
+	for rows.Next() {
+		var msg protocol.Message
+		err := rows.Scan(
+			&msg.ID,
+			&msg.Timestamp,
+			&msg.From,
+			&msg.To,
+			&msg.Message,
+			&msg.SNR,
+			&msg.Frequency,
+			&msg.Dial,
+			&msg.Band,
+			&msg.Offset,
+			&msg.Mode,
+			&msg.Submode,
+			&msg.Channel,
+			&msg.Status,
+			&msg.Delivery,
+			&msg.Resends,
+			&msg.Snippet,
+			&msg.NoiseFloor,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return GroupSessions(messages, gap), nil
+}
+
+// GroupSessions splits messages with one station, oldest first, into QSOs
+// and returns them newest first. A QSO ends after a gap longer than gap or
+// a message signing off with 73, and a CQ or a move to another band starts
+// a new one.
+func GroupSessions(messages []protocol.Message, gap time.Duration) []Session {
+	if gap <= 0 {
+		gap = DefaultSessionGap
+	}
+
+	var sessions []Session
+	var current *Session
+	signedOff := false
+	for _, msg := range messages {
+		band := protocol.Band(msg.Frequency)
+		if current == nil || signedOff ||
+			msg.Timestamp.Sub(current.End) > gap ||
+			isCQ(msg.Message) ||
+			(band != "" && current.Band != "" && band != current.Band) {
+			sessions = append(sessions, Session{
+				Start:          msg.Timestamp,
+				Band:           band,
+				Frequency:      msg.Frequency,
+				FirstMessageID: msg.ID,
+				Summary:        msg.Message,
+			})
+			current = &sessions[len(sessions)-1]
+		}
+
+		current.End = msg.Timestamp
+		current.Duration = int(current.End.Sub(current.Start).Seconds())
+		current.MessageCount++
+		current.LastMessageID = msg.ID
+		current.LastMessageText = msg.Message
+		if current.Band == "" {
+			current.Band = band
+		}
+		signedOff = signsOff(msg.Message)
+	}
+
+	// Newest first, like the conversation list
+	for i, j := 0, len(sessions)-1; i < j; i, j = i+1, j-1 {
+		sessions[i], sessions[j] = sessions[j], sessions[i]
+	}
+	return sessions
+}
+
+// isCQ reports whether a message calls CQ, which opens a new QSO
+func isCQ(text string) bool {
+	for _, word := range strings.Fields(strings.ToUpper(text)) {
+		if word == "CQ" {
+			return true
+		}
+	}
+	return false
+}
+
+// signsOff reports whether a message ends a QSO with 73 or SK
+func signsOff(text string) bool {
+	for _, word := range strings.Fields(strings.ToUpper(text)) {
+		switch strings.Trim(word, ".!,") {
+		case "73", "SK":
+			return true
+		}
+	}
+	return false
+}
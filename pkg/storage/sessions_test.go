@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+func TestGroupSessions(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	messages := []protocol.Message{
+		// A QSO on 20m that signs off
+		{ID: 1, Timestamp: at(0), From: "N0ABC", Message: "CQ CQ N0ABC EM12", Frequency: 14078000},
+		{ID: 2, Timestamp: at(1), From: "K3DEP", To: "N0ABC", Message: "N0ABC SNR?", Frequency: 14078000},
+		{ID: 3, Timestamp: at(2), From: "N0ABC", To: "K3DEP", Message: "K3DEP SNR -10 73", Frequency: 14078000},
+		// Back five minutes later: the 73 closed the last one
+		{ID: 4, Timestamp: at(7), From: "N0ABC", To: "K3DEP", Message: "K3DEP HW CPY?", Frequency: 14078000},
+		// Same station an hour later on 40m
+		{ID: 5, Timestamp: at(70), From: "N0ABC", To: "K3DEP", Message: "K3DEP HELLO", Frequency: 7078000},
+		{ID: 6, Timestamp: at(75), From: "K3DEP", To: "N0ABC", Message: "N0ABC RR", Frequency: 7078000},
+	}
+
+	sessions := GroupSessions(messages, 30*time.Minute)
+	if len(sessions) != 3 {
+		t.Fatalf("Expected 3 sessions, got %d: %+v", len(sessions), sessions)
+	}
+
+	// Newest first
+	last, first := sessions[0], sessions[2]
+	if first.FirstMessageID != 1 || first.LastMessageID != 3 || first.MessageCount != 3 {
+		t.Errorf("Expected the first QSO to run from 1 to 3, got %+v", first)
+	}
+	if first.Band != "20m" || first.Duration != 120 || first.Summary != "CQ CQ N0ABC EM12" {
+		t.Errorf("Unexpected first QSO summary %+v", first)
+	}
+	if last.Band != "40m" || last.Duration != 300 || last.LastMessageText != "N0ABC RR" {
+		t.Errorf("Unexpected last QSO summary %+v", last)
+	}
+	if sessions[1].FirstMessageID != 4 || sessions[1].MessageCount != 1 {
+		t.Errorf("Expected the message after 73 in its own QSO, got %+v", sessions[1])
+	}
+
+	if got := GroupSessions(nil, 0); len(got) != 0 {
+		t.Errorf("Expected no sessions without messages, got %+v", got)
+	}
+}
+
+func TestGetSessions(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	seedTestMessages(t, store)
+
+	sessions, err := store.GetSessions("N0ABC", DefaultSessionGap)
+	if err != nil {
+		t.Fatalf("Failed to get sessions: %v", err)
+	}
+	if len(sessions) == 0 {
+		t.Fatal("Expected sessions with N0ABC")
+	}
+	for _, session := range sessions {
+		if session.MessageCount == 0 || session.End.Before(session.Start) {
+			t.Errorf("Unexpected session %+v", session)
+		}
+	}
+}
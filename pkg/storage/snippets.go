@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ClearSnippets drops the references to audio snippets that are gone, such
+// as those removed to stay within the snippet quota, and returns the number
+// of messages that had one
+func (ms *MessageStore) ClearSnippets(names []string) (int, error) {
+	if len(names) == 0 {
+		return 0, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		args[i] = name
+	}
+	result, err := ms.db.Exec("UPDATE messages SET snippet = '' WHERE snippet IN ("+placeholders+")", args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear snippets: %w", err)
+	}
+	cleared, err := result.RowsAffected()
+	return int(cleared), err
+}
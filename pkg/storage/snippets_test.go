@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+func TestSnippets(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	now := time.Now()
+	for i, snippet := range []string{"a.wav.gz", "b.wav.gz", ""} {
+		msg := protocol.Message{Timestamp: now.Add(time.Duration(i) * time.Minute), From: "N0ABC", Message: "HEARTBEAT", Snippet: snippet}
+		if _, _, err := store.StoreReceived(msg, "HEARTBEAT"); err != nil {
+			t.Fatalf("Failed to store message: %v", err)
+		}
+	}
+
+	messages, err := store.GetMessages(MessageQuery{Callsign: "N0ABC"})
+	if err != nil || len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d, %v", len(messages), err)
+	}
+	if messages[2].Snippet != "a.wav.gz" || messages[1].Snippet != "b.wav.gz" || messages[0].Snippet != "" {
+		t.Errorf("Expected the snippets as stored, got %q, %q, %q", messages[2].Snippet, messages[1].Snippet, messages[0].Snippet)
+	}
+
+	cleared, err := store.ClearSnippets([]string{"a.wav.gz", "gone.wav.gz"})
+	if err != nil || cleared != 1 {
+		t.Fatalf("ClearSnippets = %d, %v, want 1", cleared, err)
+	}
+	messages, _ = store.GetMessages(MessageQuery{Callsign: "N0ABC"})
+	if messages[2].Snippet != "" || messages[1].Snippet != "b.wav.gz" {
+		t.Errorf("Expected only the removed snippet cleared, got %q, %q", messages[2].Snippet, messages[1].Snippet)
+	}
+}
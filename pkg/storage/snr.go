@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// DefaultSNRHistoryRetention is how long SNR observations are kept
+const DefaultSNRHistoryRetention = 30 * 24 * time.Hour
+
+// SNRPoint is one decode of a station
+type SNRPoint struct {
+	Time      time.Time `json:"time"`
+	SNR       float32   `json:"snr"`
+	Frequency int       `json:"frequency,omitempty"` // RF frequency in Hz, offset included
+}
+
+// RecordSNR adds a decode of a station to its SNR history
+func (ms *MessageStore) RecordSNR(callsign string, heard time.Time, snr float32, frequency int) error {
+	_, err := ms.db.Exec(`
+		INSERT INTO snr_history (callsign, heard, snr, frequency)
+		VALUES (?, ?, ?, ?)
+	`, strings.ToUpper(callsign), heard.Unix(), snr, frequency)
+	if err != nil {
+		return fmt.Errorf("failed to record SNR: %w", err)
+	}
+	return nil
+}
+
+// GetSNRHistory returns the decodes of a station between two times, oldest
+// first
+func (ms *MessageStore) GetSNRHistory(callsign string, from, to time.Time) ([]SNRPoint, error) {
+	rows, err := ms.db.Query(`
+		SELECT heard, snr, frequency
+		FROM snr_history
+		WHERE callsign = ? AND heard >= ? AND heard < ?
+		ORDER BY heard
+	`, strings.ToUpper(callsign), from.Unix(), to.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SNR history: %w", err)
+	}
+	defer rows.Close()
+
+	points := []SNRPoint{}
+	for rows.Next() {
+		var seconds int64
+		var point SNRPoint
+		if err := rows.Scan(&seconds, &point.SNR, &point.Frequency); err != nil {
+			return nil, fmt.Errorf("failed to scan SNR history: %w", err)
+		}
+		point.Time = time.Unix(seconds, 0).UTC()
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+// PruneSNRHistory drops the decodes heard before a time, returning how many
+func (ms *MessageStore) PruneSNRHistory(before time.Time) (int, error) {
+	result, err := ms.db.Exec("DELETE FROM snr_history WHERE heard < ?", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune SNR history: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// SNRTrend fits a straight line through the points and returns its slope in
+// dB per hour, positive while a path is opening. It is nil for fewer than two
+// points or when they all fall in the same second.
+func SNRTrend(points []SNRPoint) *float64 {
+	if len(points) < 2 {
+		return nil
+	}
+
+	// Hours since the first point keep the sums small
+	start := points[0].Time
+	var sumX, sumY, sumXX, sumXY float64
+	for _, p := range points {
+		x := p.Time.Sub(start).Hours()
+		y := float64(p.SNR)
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumXY += x * y
+	}
+	n := float64(len(points))
+	denominator := n*sumXX - sumX*sumX
+	if math.Abs(denominator) < 1e-12 {
+		return nil
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	return &slope
+}
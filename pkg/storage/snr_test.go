@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSNRHistory(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	start := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+	for i, snr := range []float32{-20, -17, -14, -11} {
+		if err := store.RecordSNR("n0abc", start.Add(time.Duration(i)*time.Hour), snr, 14079500); err != nil {
+			t.Fatalf("Failed to record SNR: %v", err)
+		}
+	}
+	store.RecordSNR("K1XYZ", start, -5, 7079500)
+
+	points, err := store.GetSNRHistory("N0ABC", start, start.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to get SNR history: %v", err)
+	}
+	if len(points) != 3 {
+		t.Fatalf("Expected 3 points before the end of the range, got %d", len(points))
+	}
+	if points[0].SNR != -20 || !points[0].Time.Equal(start) || points[0].Frequency != 14079500 {
+		t.Errorf("Expected the first decode at -20 dB, got %+v", points[0])
+	}
+
+	// 3 dB more every hour
+	trend := SNRTrend(points)
+	if trend == nil || *trend < 2.99 || *trend > 3.01 {
+		t.Errorf("Expected a trend of 3 dB per hour, got %v", trend)
+	}
+	if SNRTrend(points[:1]) != nil {
+		t.Error("Expected no trend from one point")
+	}
+
+	pruned, err := store.PruneSNRHistory(start.Add(90 * time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+	if pruned != 3 {
+		t.Errorf("Expected 3 decodes pruned, got %d", pruned)
+	}
+}
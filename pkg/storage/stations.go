@@ -0,0 +1,340 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+// QSL statuses a station can be given
+const (
+	QSLNone      = ""
+	QSLSent      = "sent"
+	QSLReceived  = "received"
+	QSLConfirmed = "confirmed"
+)
+
+// Station is a station heard on the air or added by hand, with what the
+// operator has noted about it
+type Station struct {
+	Callsign   string    `json:"callsign"`
+	Grid       string    `json:"grid,omitempty"`
+	FirstHeard time.Time `json:"first_heard"`
+	LastHeard  time.Time `json:"last_heard"`
+	HeardCount int       `json:"heard_count"`
+	LastSNR    float32   `json:"last_snr"`
+	Name       string    `json:"name,omitempty"`
+	QTH        string    `json:"qth,omitempty"`
+	Notes      string    `json:"notes,omitempty"`
+	QSLStatus  string    `json:"qsl_status,omitempty"`
+
+	// Filled in by callbook lookups
+	Country   string  `json:"country,omitempty"`
+	State     string  `json:"state,omitempty"` // US state, two letters
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+
+	// Filled in from the grid and callsign by the engine
+	Path   *protocol.Path   `json:"path,omitempty"`
+	Entity *protocol.Entity `json:"entity,omitempty"`
+}
+
+// CallbookEntry is what an online callbook returned for a station
+type CallbookEntry struct {
+	Name      string
+	Country   string
+	State     string
+	Grid      string
+	Latitude  float64
+	Longitude float64
+}
+
+// StationUpdate holds the operator's edits to a station. Nil fields are
+// left as they are.
+type StationUpdate struct {
+	Name      *string `json:"name"`
+	QTH       *string `json:"qth"`
+	Notes     *string `json:"notes"`
+	QSLStatus *string `json:"qsl_status"`
+}
+
+// ValidQSLStatus reports whether status is one of the QSL statuses
+func ValidQSLStatus(status string) bool {
+	switch status {
+	case QSLNone, QSLSent, QSLReceived, QSLConfirmed:
+		return true
+	}
+	return false
+}
+
+// RecordHeard adds a decode to a station's history, creating the station the
+// first time it is heard. An empty grid keeps the one already known.
+func (ms *MessageStore) RecordHeard(callsign, grid string, heard time.Time, snr float32) error {
+	query := `
+		INSERT INTO stations (callsign, grid, first_heard, last_heard, heard_count, last_snr)
+		VALUES (?, ?, ?, ?, 1, ?)
+		ON CONFLICT(callsign) DO UPDATE SET
+			grid = CASE WHEN excluded.grid != '' THEN excluded.grid ELSE grid END,
+			first_heard = COALESCE(first_heard, excluded.first_heard),
+			last_heard = excluded.last_heard,
+			heard_count = heard_count + 1,
+			last_snr = excluded.last_snr,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	if _, err := ms.db.Exec(query, strings.ToUpper(callsign), strings.ToUpper(grid), heard, heard, snr); err != nil {
+		return fmt.Errorf("failed to record station: %w", err)
+	}
+	return nil
+}
+
+// NeedsLookup reports whether a station has not been looked up in the
+// callbook within ttl
+func (ms *MessageStore) NeedsLookup(callsign string, ttl time.Duration) (bool, error) {
+	var lookedUp sql.NullTime
+	err := ms.db.QueryRow("SELECT looked_up_at FROM stations WHERE callsign = ?", strings.ToUpper(callsign)).Scan(&lookedUp)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to check station lookup: %w", err)
+	}
+	return !lookedUp.Valid || time.Since(lookedUp.Time) > ttl, nil
+}
+
+// SetCallbookEntry records a callbook lookup of a station. Its name and grid
+// fill in only what the operator and the station itself have not, and a nil
+// entry records that the callbook does not know the station.
+func (ms *MessageStore) SetCallbookEntry(callsign string, entry *CallbookEntry) error {
+	if entry == nil {
+		entry = &CallbookEntry{}
+	}
+
+	query := `
+		INSERT INTO stations (callsign, name, grid, country, state, latitude, longitude, looked_up_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(callsign) DO UPDATE SET
+			name = CASE WHEN name = '' THEN excluded.name ELSE name END,
+			grid = CASE WHEN grid = '' THEN excluded.grid ELSE grid END,
+			country = excluded.country,
+			state = excluded.state,
+			latitude = excluded.latitude,
+			longitude = excluded.longitude,
+			looked_up_at = excluded.looked_up_at,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	_, err := ms.db.Exec(query, strings.ToUpper(callsign), entry.Name, strings.ToUpper(entry.Grid),
+		entry.Country, strings.ToUpper(entry.State), entry.Latitude, entry.Longitude, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record callbook entry: %w", err)
+	}
+	return nil
+}
+
+// UpdateStation applies the operator's edits to a station, adding it if it
+// has not been heard yet, and returns the result
+func (ms *MessageStore) UpdateStation(callsign string, update StationUpdate) (*Station, error) {
+	if update.QSLStatus != nil && !ValidQSLStatus(*update.QSLStatus) {
+		return nil, fmt.Errorf("invalid QSL status %q", *update.QSLStatus)
+	}
+	callsign = strings.ToUpper(callsign)
+
+	tx, err := ms.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT INTO stations (callsign) VALUES (?) ON CONFLICT DO NOTHING", callsign); err != nil {
+		return nil, fmt.Errorf("failed to add station: %w", err)
+	}
+
+	fields := []struct {
+		column string
+		value  *string
+	}{
+		{"name", update.Name},
+		{"qth", update.QTH},
+		{"notes", update.Notes},
+		{"qsl_status", update.QSLStatus},
+	}
+	for _, f := range fields {
+		if f.value == nil {
+			continue
+		}
+		query := "UPDATE stations SET " + f.column + " = ?, updated_at = CURRENT_TIMESTAMP WHERE callsign = ?"
+		if _, err := tx.Exec(query, strings.TrimSpace(*f.value), callsign); err != nil {
+			return nil, fmt.Errorf("failed to update station %s: %w", f.column, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ms.GetStation(callsign)
+}
+
+// GetStation returns a station, or nil if it is unknown
+func (ms *MessageStore) GetStation(callsign string) (*Station, error) {
+	row := ms.db.QueryRow(stationColumns+" WHERE callsign = ?", strings.ToUpper(callsign))
+	station, err := scanStation(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get station: %w", err)
+	}
+	return station, nil
+}
+
+// GetStations returns the stations whose callsign, name, QTH or notes
+// contain search, most recently heard first
+func (ms *MessageStore) GetStations(search string, limit int) ([]Station, error) {
+	query := stationColumns
+	args := []interface{}{}
+	if search != "" {
+		query += " WHERE callsign LIKE ? OR name LIKE ? OR qth LIKE ? OR notes LIKE ?"
+		pattern := "%" + search + "%"
+		args = append(args, pattern, pattern, pattern, pattern)
+	}
+	query += " ORDER BY last_heard IS NULL, last_heard DESC, callsign"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := ms.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stations: %w", err)
+	}
+	defer rows.Close()
+
+	var stations []Station
+	for rows.Next() {
+		station, err := scanStation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan station: %w", err)
+		}
+		stations = append(stations, *station)
+	}
+
+	return stations, rows.Err()
+}
+
+// GetStationGrids returns the known grid squares of the given stations
+func (ms *MessageStore) GetStationGrids(callsigns []string) (map[string]string, error) {
+	grids := make(map[string]string)
+	if len(callsigns) == 0 {
+		return grids, nil
+	}
+
+	placeholders := make([]string, len(callsigns))
+	args := make([]interface{}, len(callsigns))
+	for i, callsign := range callsigns {
+		placeholders[i] = "?"
+		args[i] = strings.ToUpper(callsign)
+	}
+
+	rows, err := ms.db.Query("SELECT callsign, grid FROM stations WHERE grid != '' AND callsign IN ("+
+		strings.Join(placeholders, ", ")+")", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query station grids: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var callsign, grid string
+		if err := rows.Scan(&callsign, &grid); err != nil {
+			return nil, fmt.Errorf("failed to scan station grid: %w", err)
+		}
+		grids[callsign] = grid
+	}
+
+	return grids, rows.Err()
+}
+
+// HeardStation is a station heard within a time window, with its last
+// decode in the window
+type HeardStation struct {
+	Callsign  string
+	Grid      string
+	Latitude  float64 // From the callbook, 0 when not looked up
+	Longitude float64
+	LastHeard time.Time
+	SNR       float32
+	Frequency int
+	Count     int  // Decodes in the window
+	Worked    bool // A message has been sent to the station
+}
+
+// GetHeardStations returns the stations decoded since a time, most recently
+// heard first
+func (ms *MessageStore) GetHeardStations(since time.Time) ([]HeardStation, error) {
+	rows, err := ms.db.Query(`
+		SELECT m.from_callsign, COALESCE(s.grid, ''), COALESCE(s.latitude, 0), COALESCE(s.longitude, 0),
+			   m.timestamp, m.snr, m.frequency, heard.count,
+			   EXISTS (SELECT 1 FROM messages tx WHERE tx.direction = 'TX' AND tx.to_callsign = m.from_callsign)
+		FROM (
+			SELECT from_callsign, MAX(id) AS last_id, COUNT(*) AS count
+			FROM messages
+			WHERE direction = 'RX' AND timestamp >= ?
+			GROUP BY from_callsign
+		) heard
+		JOIN messages m ON m.id = heard.last_id
+		LEFT JOIN stations s ON s.callsign = UPPER(m.from_callsign)
+		ORDER BY m.timestamp DESC
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query heard stations: %w", err)
+	}
+	defer rows.Close()
+
+	var stations []HeardStation
+	for rows.Next() {
+		var h HeardStation
+		err := rows.Scan(&h.Callsign, &h.Grid, &h.Latitude, &h.Longitude,
+			&h.LastHeard, &h.SNR, &h.Frequency, &h.Count, &h.Worked)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan heard station: %w", err)
+		}
+		stations = append(stations, h)
+	}
+
+	return stations, rows.Err()
+}
+
+// stationColumns selects a station in the order scanStation reads it
+const stationColumns = `
+	SELECT callsign, grid, first_heard, last_heard, heard_count, last_snr,
+		   name, qth, notes, qsl_status, country, state, latitude, longitude
+	FROM stations`
+
+// scanStation reads a row selected with stationColumns
+func scanStation(row interface{ Scan(...interface{}) error }) (*Station, error) {
+	var station Station
+	var firstHeard, lastHeard sql.NullTime
+	err := row.Scan(
+		&station.Callsign,
+		&station.Grid,
+		&firstHeard,
+		&lastHeard,
+		&station.HeardCount,
+		&station.LastSNR,
+		&station.Name,
+		&station.QTH,
+		&station.Notes,
+		&station.QSLStatus,
+		&station.Country,
+		&station.State,
+		&station.Latitude,
+		&station.Longitude,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	station.FirstHeard = firstHeard.Time
+	station.LastHeard = lastHeard.Time
+	return &station, nil
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/protocol"
+)
+
+func TestStations(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	first := time.Now().Add(-time.Hour)
+	if err := store.RecordHeard("n0abc", "FN20", first, -12); err != nil {
+		t.Fatalf("Failed to record station: %v", err)
+	}
+	// A decode without a grid keeps the one already known
+	if err := store.RecordHeard("N0ABC", "", first.Add(30*time.Minute), -5); err != nil {
+		t.Fatalf("Failed to record station: %v", err)
+	}
+
+	station, err := store.GetStation("N0ABC")
+	if err != nil || station == nil {
+		t.Fatalf("Expected N0ABC in the station database, got %v, %v", station, err)
+	}
+	if station.Grid != "FN20" || station.HeardCount != 2 || station.LastSNR != -5 {
+		t.Errorf("Unexpected station: %+v", station)
+	}
+	if !station.FirstHeard.Equal(first) || !station.LastHeard.After(first) {
+		t.Errorf("Expected heard from %v, got %v to %v", first, station.FirstHeard, station.LastHeard)
+	}
+
+	// The operator's notes survive later decodes
+	name, notes := "Bob", "Met at Dayton"
+	if _, err := store.UpdateStation("N0ABC", StationUpdate{Name: &name, Notes: &notes}); err != nil {
+		t.Fatalf("Failed to update station: %v", err)
+	}
+	if err := store.RecordHeard("N0ABC", "FN21", time.Now(), 0); err != nil {
+		t.Fatalf("Failed to record station: %v", err)
+	}
+	station, _ = store.GetStation("N0ABC")
+	if station.Name != "Bob" || station.Notes != "Met at Dayton" || station.Grid != "FN21" || station.HeardCount != 3 {
+		t.Errorf("Unexpected station after update: %+v", station)
+	}
+
+	// Stations can be added by hand before they are heard
+	qsl := QSLConfirmed
+	added, err := store.UpdateStation("W1AW", StationUpdate{QSLStatus: &qsl})
+	if err != nil || added.QSLStatus != QSLConfirmed || added.HeardCount != 0 {
+		t.Errorf("Expected W1AW added with a confirmed QSL, got %+v, %v", added, err)
+	}
+
+	invalid := "maybe"
+	if _, err := store.UpdateStation("W1AW", StationUpdate{QSLStatus: &invalid}); err == nil {
+		t.Error("Expected an error for an invalid QSL status")
+	}
+
+	if station, err := store.GetStation("K9XYZ"); station != nil || err != nil {
+		t.Errorf("Expected no station for K9XYZ, got %v, %v", station, err)
+	}
+
+	stations, err := store.GetStations("", 0)
+	if err != nil {
+		t.Fatalf("Failed to get stations: %v", err)
+	}
+	if len(stations) != 2 || stations[0].Callsign != "N0ABC" {
+		t.Errorf("Expected N0ABC then W1AW, got %+v", stations)
+	}
+
+	stations, _ = store.GetStations("dayton", 0)
+	if len(stations) != 1 || stations[0].Callsign != "N0ABC" {
+		t.Errorf("Expected the notes searched, got %+v", stations)
+	}
+
+	// Callbook lookups fill in what the operator has not
+	if needs, err := store.NeedsLookup("N0ABC", time.Hour); err != nil || !needs {
+		t.Errorf("Expected N0ABC to need a lookup, got %v, %v", needs, err)
+	}
+	err = store.SetCallbookEntry("N0ABC", &CallbookEntry{Name: "Robert Smith", Country: "United States", State: "co", Grid: "DM79", Latitude: 39.7})
+	if err != nil {
+		t.Fatalf("Failed to record callbook entry: %v", err)
+	}
+	station, _ = store.GetStation("N0ABC")
+	if station.Name != "Bob" || station.Grid != "FN21" || station.Country != "United States" || station.State != "CO" || station.Latitude != 39.7 {
+		t.Errorf("Unexpected station after lookup: %+v", station)
+	}
+	if needs, _ := store.NeedsLookup("N0ABC", time.Hour); needs {
+		t.Error("Expected no lookup within the TTL")
+	}
+
+	// Stations the callbook doesn't know aren't asked about again either
+	if err := store.SetCallbookEntry("W1AW", nil); err != nil {
+		t.Fatalf("Failed to record callbook miss: %v", err)
+	}
+	if needs, _ := store.NeedsLookup("W1AW", time.Hour); needs {
+		t.Error("Expected no lookup of a station the callbook doesn't know")
+	}
+
+	grids, err := store.GetStationGrids([]string{"n0abc", "W1AW", "K9XYZ"})
+	if err != nil {
+		t.Fatalf("Failed to get station grids: %v", err)
+	}
+	if len(grids) != 1 || grids["N0ABC"] != "FN21" {
+		t.Errorf("Expected only N0ABC's grid, got %v", grids)
+	}
+}
+
+func TestGetHeardStations(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	now := time.Now()
+	decodes := []struct {
+		from      string
+		age       time.Duration
+		snr       float32
+		frequency int
+	}{
+		{"N0ABC", 3 * time.Hour, -20, 7078000},
+		{"N0ABC", 10 * time.Minute, -5, 14078000},
+		{"DL1ABC", 20 * time.Minute, -15, 14078000},
+		{"W1AW", 48 * time.Hour, 0, 7078000},
+	}
+	for _, d := range decodes {
+		msg := protocol.Message{Timestamp: now.Add(-d.age), From: d.from, Message: "HB", SNR: d.snr, Frequency: d.frequency}
+		if err := store.StoreMessage(msg, "RX", "HEARTBEAT"); err != nil {
+			t.Fatalf("Failed to store message: %v", err)
+		}
+	}
+	reply := protocol.Message{Timestamp: now, From: "K3DEP", To: "N0ABC", Message: "N0ABC SNR?"}
+	if err := store.StoreMessage(reply, "TX", "DIRECTED"); err != nil {
+		t.Fatalf("Failed to store message: %v", err)
+	}
+	if err := store.RecordHeard("N0ABC", "FN20", now, -5); err != nil {
+		t.Fatalf("Failed to record station: %v", err)
+	}
+
+	heard, err := store.GetHeardStations(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to get heard stations: %v", err)
+	}
+	if len(heard) != 2 {
+		t.Fatalf("Expected 2 stations heard in the last day, got %+v", heard)
+	}
+	if heard[0].Callsign != "N0ABC" || heard[0].Grid != "FN20" || heard[0].Count != 2 ||
+		heard[0].SNR != -5 || heard[0].Frequency != 14078000 || !heard[0].Worked {
+		t.Errorf("Expected N0ABC's last decode on 20m, worked, got %+v", heard[0])
+	}
+	if heard[1].Callsign != "DL1ABC" || heard[1].Grid != "" || heard[1].Worked {
+		t.Errorf("Expected DL1ABC without a grid, got %+v", heard[1])
+	}
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultTelemetryRetention is how long telemetry from other stations is
+// kept
+const DefaultTelemetryRetention = 90 * 24 * time.Hour
+
+// Telemetry is one reading heard from a station. Fields it did not send
+// are nil.
+type Telemetry struct {
+	ID          int64     `json:"id"`
+	Callsign    string    `json:"callsign"`
+	Time        time.Time `json:"time"`
+	Frequency   int       `json:"frequency,omitempty"`
+	SNR         float32   `json:"snr"` // Of the last frame
+	Voltage     *float64  `json:"voltage,omitempty"`
+	Current     *float64  `json:"current,omitempty"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	Uptime      *int64    `json:"uptime,omitempty"` // Seconds
+}
+
+// SaveTelemetry stores a reading and sets its ID
+func (ms *MessageStore) SaveTelemetry(t *Telemetry) error {
+	t.Callsign = strings.ToUpper(t.Callsign)
+	id, err := ms.db.insert(`
+		INSERT INTO telemetry (callsign, heard, frequency, snr, voltage, current, temperature, uptime)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, t.Callsign, t.Time.Unix(), t.Frequency, t.SNR, t.Voltage, t.Current, t.Temperature, t.Uptime)
+	if err != nil {
+		return fmt.Errorf("failed to save telemetry: %w", err)
+	}
+	t.ID = id
+	return nil
+}
+
+// telemetryColumns are the columns queryTelemetry reads
+const telemetryColumns = `id, callsign, heard, frequency, snr, voltage, current, temperature, uptime`
+
+// GetTelemetry returns a station's readings between two times, oldest
+// first
+func (ms *MessageStore) GetTelemetry(callsign string, from, to time.Time) ([]Telemetry, error) {
+	return ms.queryTelemetry(`
+		SELECT `+telemetryColumns+` FROM telemetry
+		WHERE callsign = ? AND heard >= ? AND heard < ?
+		ORDER BY heard, id
+	`, strings.ToUpper(callsign), from.Unix(), to.Unix())
+}
+
+// GetTelemetryStations returns the latest reading of every station heard
+// sending telemetry, most recent first
+func (ms *MessageStore) GetTelemetryStations() ([]Telemetry, error) {
+	return ms.queryTelemetry(`
+		SELECT ` + telemetryColumns + ` FROM telemetry t
+		WHERE id = (SELECT MAX(id) FROM telemetry WHERE callsign = t.callsign)
+		ORDER BY heard DESC
+	`)
+}
+
+// PruneTelemetry drops the readings heard before a time, returning how many
+func (ms *MessageStore) PruneTelemetry(before time.Time) (int, error) {
+	result, err := ms.db.Exec("DELETE FROM telemetry WHERE heard < ?", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune telemetry: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// queryTelemetry reads the readings a query of telemetryColumns returns
+func (ms *MessageStore) queryTelemetry(query string, args ...interface{}) ([]Telemetry, error) {
+	rows, err := ms.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query telemetry: %w", err)
+	}
+	defer rows.Close()
+
+	readings := []Telemetry{}
+	for rows.Next() {
+		var t Telemetry
+		var heard int64
+		var voltage, current, temperature sql.NullFloat64
+		var uptime sql.NullInt64
+		if err := rows.Scan(&t.ID, &t.Callsign, &heard, &t.Frequency, &t.SNR, &voltage, &current, &temperature, &uptime); err != nil {
+			return nil, fmt.Errorf("failed to scan telemetry: %w", err)
+		}
+		t.Time = time.Unix(heard, 0).UTC()
+		if voltage.Valid {
+			t.Voltage = &voltage.Float64
+		}
+		if current.Valid {
+			t.Current = &current.Float64
+		}
+		if temperature.Valid {
+			t.Temperature = &temperature.Float64
+		}
+		if uptime.Valid {
+			t.Uptime = &uptime.Int64
+		}
+		readings = append(readings, t)
+	}
+	return readings, rows.Err()
+}
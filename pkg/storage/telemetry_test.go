@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTelemetry(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	start := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+	for i, voltage := range []float64{12.8, 12.6, 12.4} {
+		v := voltage
+		uptime := int64(i * 3600)
+		reading := &Telemetry{Callsign: "n0abc", Time: start.Add(time.Duration(i) * time.Hour), SNR: -12, Voltage: &v, Uptime: &uptime}
+		if err := store.SaveTelemetry(reading); err != nil {
+			t.Fatalf("Failed to save telemetry: %v", err)
+		}
+		if reading.ID == 0 {
+			t.Error("Expected the reading's ID to be set")
+		}
+	}
+	temperature := 21.5
+	store.SaveTelemetry(&Telemetry{Callsign: "K1XYZ", Time: start, Temperature: &temperature})
+
+	readings, err := store.GetTelemetry("N0ABC", start, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to get telemetry: %v", err)
+	}
+	if len(readings) != 2 {
+		t.Fatalf("Expected 2 readings before the end of the range, got %d", len(readings))
+	}
+	first := readings[0]
+	if !first.Time.Equal(start) || first.Voltage == nil || *first.Voltage != 12.8 || first.Uptime == nil || *first.Uptime != 0 {
+		t.Errorf("Expected the first reading at 12.8 V, got %+v", first)
+	}
+	if first.Current != nil || first.Temperature != nil {
+		t.Errorf("Expected fields not sent to be nil, got %+v", first)
+	}
+
+	stations, err := store.GetTelemetryStations()
+	if err != nil {
+		t.Fatalf("Failed to get telemetry stations: %v", err)
+	}
+	if len(stations) != 2 || stations[0].Callsign != "N0ABC" || *stations[0].Voltage != 12.4 {
+		t.Errorf("Expected N0ABC's latest reading first, got %+v", stations)
+	}
+	if stations[1].Callsign != "K1XYZ" || stations[1].Temperature == nil || *stations[1].Temperature != 21.5 {
+		t.Errorf("Expected K1XYZ's reading, got %+v", stations[1])
+	}
+
+	pruned, err := store.PruneTelemetry(start.Add(90 * time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to prune telemetry: %v", err)
+	}
+	if pruned != 3 {
+		t.Errorf("Expected 3 readings pruned, got %d", pruned)
+	}
+}
@@ -0,0 +1,205 @@
+// Package telemetry sends a node's sensor readings, such as its battery
+// voltage, temperature and uptime, as JS8 data frames. A reading is packed
+// into a few bytes with the sending station's callsign and split across the
+// same data frames forms use; a leading format byte forms never use tells
+// the two apart once the frames are put back together.
+package telemetry
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+)
+
+// Format is the first byte of a telemetry payload, following the form
+// payload formats 0 and 1
+const Format = 2
+
+// Field names, in the order they are packed
+const (
+	FieldVoltage     = "voltage"     // Battery volts, to 10 mV
+	FieldCurrent     = "current"     // Amps drawn, to 10 mA, negative while charging
+	FieldTemperature = "temperature" // Degrees Celsius, to 0.1
+	FieldUptime      = "uptime"      // Time since js8d started, to the minute
+)
+
+// Fields lists every field name
+var Fields = []string{FieldVoltage, FieldCurrent, FieldTemperature, FieldUptime}
+
+// fieldBits are the bits of the field mask, and fieldBytes the size of
+// each field, in Fields order
+var (
+	fieldBits  = []uint8{1, 2, 4, 8}
+	fieldBytes = []int{2, 2, 2, 3}
+)
+
+// callsignBytes holds a callsign packed into 50 bits
+const callsignBytes = 7
+
+// maxUptimeMinutes is the longest uptime 3 bytes hold
+const maxUptimeMinutes = 1<<24 - 1
+
+// Reading is one station's telemetry. Fields the station did not send are
+// nil.
+type Reading struct {
+	Callsign    string
+	Voltage     *float64
+	Current     *float64
+	Temperature *float64
+	Uptime      *time.Duration
+}
+
+// Empty reports whether a reading has no fields
+func (r Reading) Empty() bool {
+	return r.Voltage == nil && r.Current == nil && r.Temperature == nil && r.Uptime == nil
+}
+
+// ValidField reports whether name is one of Fields
+func ValidField(name string) bool {
+	for _, field := range Fields {
+		if name == field {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPayload reports whether an assembled data frame payload is telemetry
+// rather than a form
+func IsPayload(payload []byte) bool {
+	return len(payload) > 0 && payload[0] == Format
+}
+
+// Encode packs a reading: the format byte, a 16 bit checksum, the callsign,
+// a mask of the fields present and each of them in Fields order
+func Encode(r Reading) ([]byte, error) {
+	callsign := strings.ToUpper(strings.TrimSpace(r.Callsign))
+	packed := dsp.PackAlphaNumeric50(callsign)
+	if callsign == "" || dsp.UnpackAlphaNumeric50(packed) != callsign {
+		return nil, fmt.Errorf("callsign %q can't be packed", r.Callsign)
+	}
+	if r.Empty() {
+		return nil, fmt.Errorf("reading has no fields")
+	}
+
+	var mask uint8
+	var fields []byte
+	add := func(bit uint8, size int, value int64) {
+		mask |= bit
+		field := make([]byte, 4)
+		binary.BigEndian.PutUint32(field, uint32(value))
+		fields = append(fields, field[4-size:]...)
+	}
+	if r.Voltage != nil {
+		v := math.Round(*r.Voltage * 100)
+		if v < 0 || v > math.MaxUint16 {
+			return nil, fmt.Errorf("voltage %.2f V is out of range", *r.Voltage)
+		}
+		add(fieldBits[0], fieldBytes[0], int64(v))
+	}
+	if r.Current != nil {
+		v := math.Round(*r.Current * 100)
+		if v < math.MinInt16 || v > math.MaxInt16 {
+			return nil, fmt.Errorf("current %.2f A is out of range", *r.Current)
+		}
+		add(fieldBits[1], fieldBytes[1], int64(v))
+	}
+	if r.Temperature != nil {
+		v := math.Round(*r.Temperature * 10)
+		if v < math.MinInt16 || v > math.MaxInt16 {
+			return nil, fmt.Errorf("temperature %.1f C is out of range", *r.Temperature)
+		}
+		add(fieldBits[2], fieldBytes[2], int64(v))
+	}
+	if r.Uptime != nil {
+		minutes := int64(*r.Uptime / time.Minute)
+		if minutes < 0 {
+			minutes = 0
+		}
+		if minutes > maxUptimeMinutes {
+			minutes = maxUptimeMinutes
+		}
+		add(fieldBits[3], fieldBytes[3], minutes)
+	}
+
+	// The 50 bit callsign fills the low 7 bytes of a uint64
+	word := make([]byte, 8)
+	binary.BigEndian.PutUint64(word, packed)
+	body := append(word[8-callsignBytes:], mask)
+	body = append(body, fields...)
+
+	payload := dsp.PayloadHeader(Format, body, dsp.DataPayloadHeaderBytes)
+	return append(payload, body...), nil
+}
+
+// Decode unpacks a payload made by Encode. Zero padding after the payload
+// is ignored.
+func Decode(payload []byte) (Reading, error) {
+	if !IsPayload(payload) {
+		return Reading{}, fmt.Errorf("payload is not telemetry")
+	}
+	if len(payload) < dsp.DataPayloadHeaderBytes+callsignBytes+1 {
+		return Reading{}, fmt.Errorf("telemetry payload is too short")
+	}
+
+	body := payload[dsp.DataPayloadHeaderBytes:]
+	mask := body[callsignBytes]
+	size := callsignBytes + 1
+	for i, bit := range fieldBits {
+		if mask&bit != 0 {
+			size += fieldBytes[i]
+		}
+	}
+	if mask&^0x0f != 0 || len(body) < size {
+		return Reading{}, fmt.Errorf("telemetry payload is malformed")
+	}
+	body = body[:size]
+	if !dsp.PayloadChecks(payload[:dsp.DataPayloadHeaderBytes], body) {
+		return Reading{}, fmt.Errorf("telemetry payload fails its checksum")
+	}
+
+	packed := binary.BigEndian.Uint64(append([]byte{0}, body[:callsignBytes]...))
+	r := Reading{Callsign: dsp.UnpackAlphaNumeric50(packed)}
+
+	fields := body[callsignBytes+1:]
+	next := func(size int, signed bool) int64 {
+		field := make([]byte, 4)
+		copy(field[4-size:], fields[:size])
+		fields = fields[size:]
+		value := int64(binary.BigEndian.Uint32(field))
+		if signed && size == 2 {
+			value = int64(int16(value))
+		}
+		return value
+	}
+	if mask&fieldBits[0] != 0 {
+		v := float64(next(fieldBytes[0], false)) / 100
+		r.Voltage = &v
+	}
+	if mask&fieldBits[1] != 0 {
+		v := float64(next(fieldBytes[1], true)) / 100
+		r.Current = &v
+	}
+	if mask&fieldBits[2] != 0 {
+		v := float64(next(fieldBytes[2], true)) / 10
+		r.Temperature = &v
+	}
+	if mask&fieldBits[3] != 0 {
+		v := time.Duration(next(fieldBytes[3], false)) * time.Minute
+		r.Uptime = &v
+	}
+	return r, nil
+}
+
+// Frames encodes a reading and splits it into data frames under tag
+func Frames(r Reading, tag uint8) ([]string, error) {
+	payload, err := Encode(r)
+	if err != nil {
+		return nil, err
+	}
+	return dsp.PackDataFrames(tag, payload)
+}
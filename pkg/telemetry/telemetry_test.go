@@ -0,0 +1,103 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/dsp"
+	"github.com/dougsko/js8d/pkg/forms"
+)
+
+func TestTelemetryRoundTrip(t *testing.T) {
+	voltage, current, temperature := 12.64, -0.35, -4.2
+	uptime := 49*time.Hour + 17*time.Minute + 40*time.Second
+	reading := Reading{Callsign: "k1abc/p", Voltage: &voltage, Current: &current, Temperature: &temperature, Uptime: &uptime}
+
+	frames, err := Frames(reading, 12)
+	if err != nil {
+		t.Fatalf("Frames failed: %v", err)
+	}
+	if len(frames) != 4 {
+		t.Errorf("Expected 4 frames for every field, got %d", len(frames))
+	}
+
+	assembler := forms.NewAssembler(time.Minute)
+	var payload []byte
+	for _, frame := range frames {
+		_, _, p, err := assembler.Add(frame, time.Now())
+		if err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		payload = p
+	}
+	if !IsPayload(payload) {
+		t.Fatal("Expected the assembled payload to be telemetry")
+	}
+
+	got, err := Decode(payload)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if got.Callsign != "K1ABC/P" {
+		t.Errorf("Expected callsign K1ABC/P, got %q", got.Callsign)
+	}
+	if got.Voltage == nil || *got.Voltage != 12.64 {
+		t.Errorf("Expected 12.64 V, got %v", got.Voltage)
+	}
+	if got.Current == nil || *got.Current != -0.35 {
+		t.Errorf("Expected -0.35 A, got %v", got.Current)
+	}
+	if got.Temperature == nil || *got.Temperature != -4.2 {
+		t.Errorf("Expected -4.2 C, got %v", got.Temperature)
+	}
+	if got.Uptime == nil || *got.Uptime != 49*time.Hour+17*time.Minute {
+		t.Errorf("Expected uptime to the minute, got %v", got.Uptime)
+	}
+}
+
+func TestTelemetryFieldsLeftOut(t *testing.T) {
+	voltage := 3.7
+	payload, err := Encode(Reading{Callsign: "W1AW", Voltage: &voltage})
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if len(payload) != dsp.DataPayloadHeaderBytes+callsignBytes+1+2 {
+		t.Errorf("Expected %d bytes, got %d", dsp.DataPayloadHeaderBytes+callsignBytes+3, len(payload))
+	}
+
+	got, err := Decode(append(payload, 0, 0, 0))
+	if err != nil {
+		t.Fatalf("Decode with padding failed: %v", err)
+	}
+	if got.Voltage == nil || *got.Voltage != 3.7 || got.Current != nil || got.Temperature != nil || got.Uptime != nil {
+		t.Errorf("Expected only the voltage, got %+v", got)
+	}
+
+	payload[len(payload)-1] ^= 1
+	if _, err := Decode(payload); err == nil {
+		t.Error("Expected a corrupted payload to fail its checksum")
+	}
+}
+
+func TestTelemetryEncodeErrors(t *testing.T) {
+	voltage, hot := 12.0, 4000.0
+	tests := []struct {
+		name    string
+		reading Reading
+	}{
+		{"NoCallsign", Reading{Voltage: &voltage}},
+		{"NoFields", Reading{Callsign: "K1ABC"}},
+		{"OutOfRange", Reading{Callsign: "K1ABC", Temperature: &hot}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Encode(tt.reading); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+
+	if _, err := Decode([]byte{0, 1, 2, 3}); err == nil {
+		t.Error("Expected a form payload not to decode as telemetry")
+	}
+}
@@ -0,0 +1,176 @@
+// Package timesync measures how far the system clock is from true time.
+// JS8 frames only decode when both ends agree on the time to within about
+// two seconds, so a drifting clock silently stops a station being heard.
+package timesync
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Clock offset sources
+const (
+	SourceAuto   = "auto"   // chrony when it is running, otherwise NTP
+	SourceChrony = "chrony" // ask the local chronyd through chronyc
+	SourceNTP    = "ntp"    // query an NTP server directly
+	SourceGPS    = "gps"    // GPS time from gpsd, measured by whoever reads the fixes
+)
+
+// DefaultServer is the NTP server queried when none is configured
+const DefaultServer = "pool.ntp.org"
+
+// Seconds from the NTP epoch, 1900, to the Unix epoch
+const ntpEpochOffset = 2208988800
+
+// Result is one measurement of the system clock
+type Result struct {
+	Offset  time.Duration // how far the system clock is ahead of true time
+	Source  string        // chrony, ntp or gps
+	Server  string        // the reference the offset was measured against
+	Checked time.Time
+}
+
+// Checker measures the clock offset from a configured source
+type Checker struct {
+	Source  string // auto, chrony or ntp; empty is auto
+	Server  string // NTP server, host or host:port; empty uses DefaultServer
+	Chronyc string // chronyc command, empty finds it on the PATH
+}
+
+// Check measures the clock offset now
+func (c *Checker) Check(ctx context.Context) (*Result, error) {
+	switch strings.ToLower(c.Source) {
+	case SourceChrony:
+		return Chrony(ctx, c.Chronyc)
+	case SourceNTP:
+		return NTP(ctx, c.Server)
+	case SourceAuto, "":
+		result, err := Chrony(ctx, c.Chronyc)
+		if err == nil {
+			return result, nil
+		}
+		return NTP(ctx, c.Server)
+	default:
+		return nil, fmt.Errorf("unknown time source %q", c.Source)
+	}
+}
+
+// Chrony asks the local chronyd how far the system clock is from the NTP
+// time it is tracking
+func Chrony(ctx context.Context, chronyc string) (*Result, error) {
+	if chronyc == "" {
+		chronyc = "chronyc"
+	}
+	output, err := exec.CommandContext(ctx, chronyc, "-c", "tracking").Output()
+	if err != nil {
+		return nil, fmt.Errorf("chronyc tracking failed: %w", err)
+	}
+	offset, reference, err := ParseChronyTracking(string(output))
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Offset: offset, Source: SourceChrony, Server: reference, Checked: time.Now()}, nil
+}
+
+// ParseChronyTracking reads the output of chronyc -c tracking, returning how
+// far the system clock is ahead and the source chronyd is tracking
+func ParseChronyTracking(output string) (time.Duration, string, error) {
+	fields := strings.Split(strings.TrimSpace(output), ",")
+	if len(fields) < 5 {
+		return 0, "", errors.New("unexpected chronyc tracking output")
+	}
+	// The system time field is the correction still to apply, positive
+	// when the clock is slow
+	correction, err := strconv.ParseFloat(fields[4], 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("bad chronyc system time %q", fields[4])
+	}
+	stratum, _ := strconv.Atoi(fields[2])
+	if stratum == 0 {
+		return 0, "", errors.New("chronyd is not synchronised")
+	}
+	reference := fields[1]
+	if reference == "" {
+		reference = fields[0]
+	}
+	return seconds(-correction), reference, nil
+}
+
+// NTP queries an NTP server once and returns the system clock's offset from it
+func NTP(ctx context.Context, server string) (*Result, error) {
+	if server == "" {
+		server = DefaultServer
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", server, err)
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(5 * time.Second)
+	}
+	conn.SetDeadline(deadline)
+
+	// Client request: leap 0, version 4, mode 3
+	request := make([]byte, 48)
+	request[0] = 0x23
+	sent := time.Now()
+	binary.BigEndian.PutUint64(request[40:], toNTP(sent))
+	if _, err := conn.Write(request); err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", server, err)
+	}
+
+	response := make([]byte, 48)
+	n, err := conn.Read(response)
+	received := time.Now()
+	if err != nil {
+		return nil, fmt.Errorf("no answer from %s: %w", server, err)
+	}
+	if n < 48 || response[0]&0x07 != 4 {
+		return nil, fmt.Errorf("bad answer from %s", server)
+	}
+	if response[1] == 0 {
+		return nil, fmt.Errorf("%s refused the query", server)
+	}
+	if binary.BigEndian.Uint64(response[24:]) != binary.BigEndian.Uint64(request[40:]) {
+		return nil, fmt.Errorf("answer from %s does not match the query", server)
+	}
+
+	serverReceived := fromNTP(binary.BigEndian.Uint64(response[32:]))
+	serverSent := fromNTP(binary.BigEndian.Uint64(response[40:]))
+	ahead := (sent.Sub(serverReceived) + received.Sub(serverSent)) / 2
+	return &Result{Offset: ahead, Source: SourceNTP, Server: server, Checked: received}, nil
+}
+
+// toNTP converts a time to a 64-bit NTP timestamp
+func toNTP(t time.Time) uint64 {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / 1e9
+	return secs<<32 | frac
+}
+
+// fromNTP converts a 64-bit NTP timestamp to a time
+func fromNTP(stamp uint64) time.Time {
+	secs := int64(stamp>>32) - ntpEpochOffset
+	nanos := int64((stamp & 0xffffffff) * 1e9 >> 32)
+	return time.Unix(secs, nanos)
+}
+
+// seconds converts fractional seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
+}
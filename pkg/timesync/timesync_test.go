@@ -0,0 +1,124 @@
+package timesync
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeNTPServer answers NTP queries with a clock running behind by lag
+func fakeNTPServer(t *testing.T, lag time.Duration, stratum byte) string {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		request := make([]byte, 48)
+		for {
+			n, addr, err := conn.ReadFromUDP(request)
+			if err != nil {
+				return
+			}
+			if n < 48 {
+				continue
+			}
+			response := make([]byte, 48)
+			response[0] = 0x24 // version 4, server mode
+			response[1] = stratum
+			copy(response[24:32], request[40:48])
+			now := time.Now().Add(-lag)
+			binary.BigEndian.PutUint64(response[32:], toNTP(now))
+			binary.BigEndian.PutUint64(response[40:], toNTP(now))
+			conn.WriteToUDP(response, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestNTP(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	server := fakeNTPServer(t, 3*time.Second, 2)
+	result, err := NTP(ctx, server)
+	if err != nil {
+		t.Fatalf("NTP failed: %v", err)
+	}
+	if diff := result.Offset - 3*time.Second; diff < -100*time.Millisecond || diff > 100*time.Millisecond {
+		t.Errorf("Expected the clock 3s ahead, got %v", result.Offset)
+	}
+	if result.Source != SourceNTP || result.Server != server {
+		t.Errorf("Expected ntp from %s, got %s from %s", server, result.Source, result.Server)
+	}
+
+	// A kiss-of-death answer carries no time
+	if _, err := NTP(ctx, fakeNTPServer(t, 0, 0)); err == nil {
+		t.Errorf("Expected an error for stratum 0")
+	}
+}
+
+func TestNTPTimestamps(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 250000000, time.UTC)
+	if got := fromNTP(toNTP(now)); got.Sub(now).Abs() > time.Microsecond {
+		t.Errorf("Expected %v back, got %v", now, got)
+	}
+}
+
+func TestParseChronyTracking(t *testing.T) {
+	// chronyd correcting a clock 1.5 ms fast
+	output := "A29FC87B,162.159.200.123,3,1772366400.123456789,-0.001500000,-0.000012,0.000034,-12.345,-0.001,0.012,0.010,0.001,64.4,Normal\n"
+	offset, reference, err := ParseChronyTracking(output)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if offset != 1500*time.Microsecond {
+		t.Errorf("Expected 1.5ms ahead, got %v", offset)
+	}
+	if reference != "162.159.200.123" {
+		t.Errorf("Expected the reference address, got %q", reference)
+	}
+
+	if _, _, err := ParseChronyTracking("7F7F0101,,0,0.0,0.0,0.0,0.0,0.0,0.0,0.0,0.0,0.0,0.0,Not synchronised\n"); err == nil {
+		t.Errorf("Expected an error while chronyd is unsynchronised")
+	}
+	if _, _, err := ParseChronyTracking("506 Cannot talk to daemon"); err == nil {
+		t.Errorf("Expected an error for unexpected output")
+	}
+}
+
+func TestCheckerFallsBackToNTP(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	server := fakeNTPServer(t, -2*time.Second, 1)
+
+	checker := &Checker{Source: "AUTO", Server: server, Chronyc: filepath.Join(t.TempDir(), "missing")}
+	result, err := checker.Check(ctx)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if result.Source != SourceNTP || result.Offset > -time.Second {
+		t.Errorf("Expected an NTP result about 2s behind, got %+v", result)
+	}
+
+	// chrony is used when it answers
+	script := filepath.Join(t.TempDir(), "chronyc")
+	os.WriteFile(script, []byte("#!/bin/sh\necho 'A29FC87B,192.0.2.1,2,0,0.250,0,0,0,0,0,0,0,64,Normal'\n"), 0755)
+	checker.Chronyc = script
+	result, err = checker.Check(ctx)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if result.Source != SourceChrony || result.Offset != -250*time.Millisecond {
+		t.Errorf("Expected chrony 250ms behind, got %+v", result)
+	}
+
+	if _, err := (&Checker{Source: "gps"}).Check(ctx); err == nil {
+		t.Errorf("Expected an error for an unknown source")
+	}
+}
@@ -0,0 +1,235 @@
+// Package triggers calls webhooks when something happens at the station,
+// such as a directed message arriving, for home automation services like
+// IFTTT and Home Assistant
+package triggers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Event types a hook can fire on
+const (
+	Directed  = "directed"   // A message addressed to this station arrived
+	Heard     = "heard"      // A station was decoded
+	TXStarted = "tx_started" // A message went on the air
+	TXFailed  = "tx_failed"  // A message could not be sent
+	SWRAlarm  = "swr_alarm"  // The SWR went over the alarm level during TX
+	DBCorrupt = "db_corrupt" // The message database failed its integrity check
+)
+
+// Types lists every event type
+var Types = []string{Directed, Heard, TXStarted, TXFailed, SWRAlarm, DBCorrupt}
+
+// Agent identifies js8d to webhooks
+const Agent = "js8d"
+
+// Funcs are the functions templates may call besides the text/template
+// builtins. json quotes a value for use inside a JSON body.
+var Funcs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// Event is what happened, and the data templates are executed with
+type Event struct {
+	Type      string    `json:"event"`
+	Time      time.Time `json:"time"`
+	Station   string    `json:"station"` // This station's callsign
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	SNR       float32   `json:"snr,omitempty"`
+	Frequency int       `json:"frequency,omitempty"` // Hz
+	SWR       float32   `json:"swr,omitempty"`
+	Error     string    `json:"error,omitempty"` // Why a TX failed, or what the integrity check found
+}
+
+// Hook is a request made for events of one type
+type Hook struct {
+	Event       string
+	Callsigns   []string // Directed and heard: only for these senders, any when empty
+	URL         string
+	Method      string        // POST when empty
+	ContentType string        // application/json when empty
+	Template    string        // Body, the event as JSON when empty
+	Token       string        // Sent as a bearer token when set
+	Cooldown    time.Duration // Quiet time after firing for a sender
+}
+
+// hook is a Hook ready to fire
+type hook struct {
+	Hook
+	body      *template.Template
+	callsigns map[string]bool
+	last      map[string]time.Time // When the hook last fired, by sender
+}
+
+// Dispatcher makes the requests for events in the background, one at a time
+// in the order the events happened
+type Dispatcher struct {
+	hooks   []*hook
+	client  *http.Client
+	events  chan Event
+	onError func(url string, err error)
+}
+
+// New returns a dispatcher for the given hooks. onError, which may be nil,
+// is told about requests that failed.
+func New(hooks []Hook, onError func(url string, err error)) (*Dispatcher, error) {
+	d := &Dispatcher{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		events:  make(chan Event, 64),
+		onError: onError,
+	}
+	for i, h := range hooks {
+		body, err := Parse(h.Template)
+		if err != nil {
+			return nil, fmt.Errorf("trigger %d: %w", i+1, err)
+		}
+		callsigns := make(map[string]bool, len(h.Callsigns))
+		for _, call := range h.Callsigns {
+			callsigns[strings.ToUpper(call)] = true
+		}
+		d.hooks = append(d.hooks, &hook{Hook: h, body: body, callsigns: callsigns, last: make(map[string]time.Time)})
+	}
+	return d, nil
+}
+
+// Wants reports whether any hook fires on events of a type
+func (d *Dispatcher) Wants(eventType string) bool {
+	for _, h := range d.hooks {
+		if h.Event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse checks a body template, returning nil for an empty one
+func Parse(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("body").Funcs(Funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("bad template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Fire queues an event, returning false if the queue is full and it was
+// dropped
+func (d *Dispatcher) Fire(event Event) bool {
+	select {
+	case d.events <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run makes the requests for queued events until ctx is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		select {
+		case event := <-d.events:
+			for _, h := range d.matching(event) {
+				if err := d.send(ctx, h, event); err != nil && ctx.Err() == nil && d.onError != nil {
+					d.onError(h.URL, err)
+				}
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// matching returns the hooks an event fires, starting their cooldown. Only
+// Run calls it, so the cooldowns need no lock.
+func (d *Dispatcher) matching(event Event) []*hook {
+	from := strings.ToUpper(event.From)
+	var hooks []*hook
+	for _, h := range d.hooks {
+		if h.Event != event.Type {
+			continue
+		}
+		if len(h.callsigns) > 0 && !h.callsigns[from] {
+			continue
+		}
+		if h.Cooldown > 0 {
+			if last, ok := h.last[from]; ok && event.Time.Sub(last) < h.Cooldown {
+				continue
+			}
+			h.last[from] = event.Time
+		}
+		hooks = append(hooks, h)
+	}
+	return hooks
+}
+
+// render returns the body a hook sends for an event
+func (h *hook) render(event Event) ([]byte, error) {
+	if h.body == nil {
+		return json.Marshal(event)
+	}
+	var body bytes.Buffer
+	if err := h.body.Execute(&body, event); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+// send makes a hook's request for an event
+func (d *Dispatcher) send(ctx context.Context, h *hook, event Event) error {
+	body, err := h.render(event)
+	if err != nil {
+		return err
+	}
+
+	method := strings.ToUpper(h.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+	var reader io.Reader
+	if method != http.MethodGet {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, h.URL, reader)
+	if err != nil {
+		return err
+	}
+	if reader != nil {
+		contentType := h.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("User-Agent", Agent)
+	if h.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.Token)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
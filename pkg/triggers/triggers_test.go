@@ -0,0 +1,115 @@
+package triggers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type request struct {
+	method, path, contentType, auth string
+	body                            string
+}
+
+func TestDispatcher(t *testing.T) {
+	requests := make(chan request, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{r.Method, r.URL.Path, r.Header.Get("Content-Type"), r.Header.Get("Authorization"), string(body)}
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	errors := make(chan string, 10)
+	dispatcher, err := New([]Hook{
+		{Event: Directed, URL: server.URL + "/light", Token: "t0ken",
+			Template: `{"value1":{{json .From}},"value2":{{json .Message}}}`},
+		{Event: Heard, Callsigns: []string{"w1aw"}, URL: server.URL + "/heard", Method: "GET", Cooldown: time.Hour},
+		{Event: TXFailed, URL: server.URL + "/broken"},
+	}, func(url string, err error) { errors <- url })
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Run(ctx)
+
+	now := time.Now()
+	for _, event := range []Event{
+		{Type: Directed, Time: now, Station: "K3DEP", From: "DL1ABC", To: "K3DEP", Message: `K3DEP "QSL?"`},
+		{Type: Heard, Time: now, From: "N0ABC"},
+		{Type: Heard, Time: now, From: "W1AW"},
+		{Type: Heard, Time: now.Add(time.Minute), From: "W1AW"}, // Cooling down
+		{Type: TXFailed, Time: now, Error: "audio output failed"},
+	} {
+		if !dispatcher.Fire(event) {
+			t.Fatal("Event dropped")
+		}
+	}
+
+	receive := func() request {
+		select {
+		case r := <-requests:
+			return r
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for a request")
+			return request{}
+		}
+	}
+
+	light := receive()
+	if light.method != "POST" || light.path != "/light" || light.contentType != "application/json" || light.auth != "Bearer t0ken" {
+		t.Errorf("Unexpected request %+v", light)
+	}
+	var body map[string]string
+	if err := json.Unmarshal([]byte(light.body), &body); err != nil || body["value1"] != "DL1ABC" || body["value2"] != `K3DEP "QSL?"` {
+		t.Errorf("Unexpected body %s (%v)", light.body, err)
+	}
+
+	heard := receive()
+	if heard.method != "GET" || heard.path != "/heard" || heard.body != "" {
+		t.Errorf("Unexpected request %+v", heard)
+	}
+
+	broken := receive()
+	var event Event
+	if err := json.Unmarshal([]byte(broken.body), &event); err != nil || event.Type != TXFailed || event.Error != "audio output failed" {
+		t.Errorf("Unexpected body %s (%v)", broken.body, err)
+	}
+	select {
+	case url := <-errors:
+		if url != server.URL+"/broken" {
+			t.Errorf("Error reported for %s", url)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Failure not reported")
+	}
+
+	select {
+	case r := <-requests:
+		t.Errorf("Unexpected request %+v", r)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestParse(t *testing.T) {
+	if tmpl, err := Parse(""); tmpl != nil || err != nil {
+		t.Errorf("Parse(\"\") = %v, %v", tmpl, err)
+	}
+	if _, err := Parse("{{json .From}}"); err != nil {
+		t.Errorf("Parse failed: %v", err)
+	}
+	if _, err := Parse("{{.From"); err == nil {
+		t.Error("Expected an error for an unclosed action")
+	}
+	if _, err := Parse("{{upper .From}}"); err == nil {
+		t.Error("Expected an error for an unknown function")
+	}
+}
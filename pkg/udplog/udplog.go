@@ -0,0 +1,226 @@
+// Package udplog broadcasts logged QSOs as UDP datagrams, in the contact
+// XML of N1MM Logger+ or as ADIF, so loggers such as DXKeeper, Log4OM and
+// GridTracker pick them up as they would from a contest logger
+package udplog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/adif"
+)
+
+// Datagram formats
+const (
+	FormatN1MM = "n1mm" // N1MM Logger+ contactinfo XML, the default
+	FormatADIF = "adif" // One ADIF record
+)
+
+// Contact is a logged QSO as it is broadcast
+type Contact struct {
+	ID        int64 // Log ID, which keeps the N1MM ID stable across updates
+	Callsign  string
+	Start     time.Time
+	Band      string // e.g. 20m
+	Frequency int    // Hz
+	Mode      string
+	Grid      string
+	SNR       float32 // Of the station's last message heard
+
+	Station   string // Our callsign
+	Prefix    string // Primary prefix of the station's DXCC entity, when known
+	Continent string
+	CQZone    int
+
+	Record adif.Record // The QSO as ADIF, for the adif format
+}
+
+// Target is an address and the format datagrams sent there are in
+type Target struct {
+	Address string // host:port
+	Format  string // FormatN1MM or FormatADIF; empty is FormatN1MM
+}
+
+// Broadcaster sends contacts to its targets
+type Broadcaster struct {
+	targets []Target
+}
+
+// New returns a broadcaster for targets, checking their formats
+func New(targets []Target) (*Broadcaster, error) {
+	b := &Broadcaster{}
+	for _, t := range targets {
+		if t.Format == "" {
+			t.Format = FormatN1MM
+		}
+		if t.Format != FormatN1MM && t.Format != FormatADIF {
+			return nil, fmt.Errorf("unknown format %q for %s", t.Format, t.Address)
+		}
+		b.targets = append(b.targets, t)
+	}
+	return b, nil
+}
+
+// Send broadcasts a contact to every target. A replacement updates one
+// sent before; N1MM listeners replace it, ADIF ones would log it twice so
+// it is not sent to them.
+func (b *Broadcaster) Send(c Contact, replace bool) error {
+	var errs []error
+	for _, t := range b.targets {
+		if replace && t.Format == FormatADIF {
+			continue
+		}
+		data, err := Encode(t.Format, c, replace)
+		if err == nil {
+			err = send(t.Address, data)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", t.Address, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// send writes one datagram
+func send(address string, data []byte) error {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write(data)
+	return err
+}
+
+// Encode returns the datagram for a contact in a format
+func Encode(format string, c Contact, replace bool) ([]byte, error) {
+	switch format {
+	case FormatN1MM, "":
+		return encodeN1MM(c, replace)
+	case FormatADIF:
+		var b bytes.Buffer
+		err := adif.Write(&b, "js8d", nil, []adif.Record{c.Record})
+		return b.Bytes(), err
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// n1mmContact is the contactinfo, or contactreplace, element N1MM Logger+
+// sends. Fields js8d has no value for are sent empty, as N1MM does.
+type n1mmContact struct {
+	XMLName       xml.Name
+	App           string `xml:"app"`
+	ContestName   string `xml:"contestname"`
+	ContestNr     int    `xml:"contestnr"`
+	Timestamp     string `xml:"timestamp"`
+	MyCall        string `xml:"mycall"`
+	Band          string `xml:"band"`
+	RXFreq        int    `xml:"rxfreq"` // Tens of Hz
+	TXFreq        int    `xml:"txfreq"`
+	Operator      string `xml:"operator"`
+	Mode          string `xml:"mode"`
+	Call          string `xml:"call"`
+	CountryPrefix string `xml:"countryprefix"`
+	WPXPrefix     string `xml:"wpxprefix"`
+	StationPrefix string `xml:"stationprefix"`
+	Continent     string `xml:"continent"`
+	Snt           string `xml:"snt"`
+	SntNr         int    `xml:"sntnr"`
+	Rcv           string `xml:"rcv"`
+	RcvNr         int    `xml:"rcvnr"`
+	GridSquare    string `xml:"gridsquare"`
+	Exchange1     string `xml:"exchange1"`
+	Section       string `xml:"section"`
+	Comment       string `xml:"comment"`
+	QTH           string `xml:"qth"`
+	Name          string `xml:"name"`
+	Power         string `xml:"power"`
+	MiscText      string `xml:"misctext"`
+	Zone          int    `xml:"zone"`
+	Prec          string `xml:"prec"`
+	Ck            int    `xml:"ck"`
+	IsMultiplier1 int    `xml:"ismultiplier1"`
+	IsMultiplier2 int    `xml:"ismultiplier2"`
+	IsMultiplier3 int    `xml:"ismultiplier3"`
+	Points        int    `xml:"points"`
+	RadioNr       int    `xml:"radionr"`
+	Run1Run2      int    `xml:"run1run2"`
+	RoverLocation string `xml:"RoverLocation"`
+	Interfaced    int    `xml:"RadioInterfaced"`
+	NetworkedNr   int    `xml:"NetworkedCompNr"`
+	IsOriginal    string `xml:"IsOriginal"`
+	NetBiosName   string `xml:"NetBiosName"`
+	IsRunQSO      int    `xml:"IsRunQSO"`
+	StationName   string `xml:"StationName"`
+	ID            string `xml:"ID"`
+	IsClaimedQso  int    `xml:"IsClaimedQso"`
+}
+
+// encodeN1MM returns a contact as N1MM Logger+ contact XML
+func encodeN1MM(c Contact, replace bool) ([]byte, error) {
+	element := "contactinfo"
+	if replace {
+		element = "contactreplace"
+	}
+	contact := n1mmContact{
+		XMLName:       xml.Name{Local: element},
+		App:           "js8d",
+		ContestName:   "DX",
+		ContestNr:     1,
+		Timestamp:     c.Start.UTC().Format("2006-01-02 15:04:05"),
+		MyCall:        c.Station,
+		Band:          n1mmBand(c.Band),
+		RXFreq:        c.Frequency / 10,
+		TXFreq:        c.Frequency / 10,
+		Mode:          c.Mode,
+		Call:          c.Callsign,
+		CountryPrefix: c.Prefix,
+		StationPrefix: c.Station,
+		Continent:     c.Continent,
+		Rcv:           fmt.Sprintf("%+03d", int(c.SNR)),
+		GridSquare:    c.Grid,
+		Zone:          c.CQZone,
+		Points:        1,
+		RadioNr:       1,
+		Run1Run2:      1,
+		IsOriginal:    "True",
+		StationName:   "js8d",
+		ID:            contactID(c.Station, c.ID),
+		IsClaimedQso:  1,
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	enc := xml.NewEncoder(&b)
+	enc.Indent("", "\t")
+	if err := enc.Encode(contact); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// n1mmBands maps bands to the MHz N1MM names them by
+var n1mmBands = map[string]string{
+	"160m": "1.8", "80m": "3.5", "60m": "5", "40m": "7", "30m": "10", "20m": "14",
+	"17m": "18", "15m": "21", "12m": "24", "10m": "28", "6m": "50", "2m": "144",
+}
+
+// n1mmBand returns a band as N1MM names it
+func n1mmBand(band string) string {
+	return n1mmBands[strings.ToLower(band)]
+}
+
+// contactID returns the 32 hex digit ID N1MM gives a contact, the same
+// for every update of a logged QSO
+func contactID(station string, id int64) string {
+	sum := sha256.Sum256([]byte(strings.ToUpper(station) + "/" + strconv.FormatInt(id, 10)))
+	return hex.EncodeToString(sum[:16])
+}
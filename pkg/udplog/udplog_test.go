@@ -0,0 +1,110 @@
+package udplog
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dougsko/js8d/pkg/adif"
+)
+
+func testContact() Contact {
+	var record adif.Record
+	record.Add("CALL", "DL1ABC")
+	return Contact{
+		ID:        12,
+		Callsign:  "DL1ABC",
+		Start:     time.Date(2024, 1, 15, 11, 12, 30, 0, time.UTC),
+		Band:      "20m",
+		Frequency: 14079500,
+		Mode:      "JS8",
+		Grid:      "JO62",
+		SNR:       -8,
+		Station:   "K1ABC",
+		Prefix:    "DL",
+		Continent: "EU",
+		CQZone:    14,
+		Record:    record,
+	}
+}
+
+func TestEncodeN1MM(t *testing.T) {
+	data, err := Encode(FormatN1MM, testContact(), false)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	xml := string(data)
+	for _, want := range []string{
+		"<contactinfo>", "<timestamp>2024-01-15 11:12:30</timestamp>", "<mycall>K1ABC</mycall>",
+		"<band>14</band>", "<rxfreq>1407950</rxfreq>", "<mode>JS8</mode>", "<call>DL1ABC</call>",
+		"<countryprefix>DL</countryprefix>", "<rcv>-08</rcv>", "<gridsquare>JO62</gridsquare>", "<zone>14</zone>",
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("Expected %s in\n%s", want, xml)
+		}
+	}
+
+	replace, _ := Encode(FormatN1MM, testContact(), true)
+	if !strings.Contains(string(replace), "<contactreplace>") {
+		t.Errorf("Expected a contactreplace, got %s", replace)
+	}
+	id := func(data []byte) string {
+		s := string(data)
+		return s[strings.Index(s, "<ID>"):strings.Index(s, "</ID>")]
+	}
+	if id(data) != id(replace) || len(id(data)) != len("<ID>")+32 {
+		t.Errorf("Expected the same 32 digit ID for the update, got %s and %s", id(data), id(replace))
+	}
+}
+
+func TestSend(t *testing.T) {
+	listen := func() *net.UDPConn {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		return conn
+	}
+	n1mm, adifConn := listen(), listen()
+	defer n1mm.Close()
+	defer adifConn.Close()
+
+	b, err := New([]Target{
+		{Address: n1mm.LocalAddr().String()},
+		{Address: adifConn.LocalAddr().String(), Format: FormatADIF},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create broadcaster: %v", err)
+	}
+	if err := b.Send(testContact(), false); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	buf := make([]byte, 4096)
+	n, err := n1mm.Read(buf)
+	if err != nil || !strings.Contains(string(buf[:n]), "<contactinfo>") {
+		t.Errorf("Expected N1MM XML, got %q, %v", buf[:n], err)
+	}
+	n, err = adifConn.Read(buf)
+	if err != nil || !strings.Contains(string(buf[:n]), "<CALL:6>DL1ABC <EOR>") {
+		t.Errorf("Expected an ADIF record, got %q, %v", buf[:n], err)
+	}
+
+	// Updates go to N1MM listeners only
+	if err := b.Send(testContact(), true); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if n, err := n1mm.Read(buf); err != nil || !strings.Contains(string(buf[:n]), "<contactreplace>") {
+		t.Errorf("Expected a contactreplace, got %q, %v", buf[:n], err)
+	}
+	adifConn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if n, err := adifConn.Read(buf); err == nil {
+		t.Errorf("Expected no update for the ADIF listener, got %q", buf[:n])
+	}
+
+	if _, err := New([]Target{{Address: "127.0.0.1:1", Format: "json"}}); err == nil {
+		t.Error("Expected an unknown format rejected")
+	}
+}
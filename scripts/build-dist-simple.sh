@@ -109,7 +109,7 @@ build_platform() {
     log_info "Building for $GOOS/$GOARCH${GOARM:+ (ARM v$GOARM)}"
 
     # Create package structure
-    mkdir -p "$package_dir"/{bin,configs,docs,web,scripts}
+    mkdir -p "$package_dir"/{bin,configs,docs,scripts}
 
     # Build flags
     local ldflags="-X main.Version=$VERSION -X main.Build=$(date -u '+%Y-%m-%d_%H:%M:%S')"
@@ -134,7 +134,6 @@ build_platform() {
     cp "$PROJECT_DIR/README.md" "$PROJECT_DIR/LICENSE" "$package_dir/"
     cp -r "$PROJECT_DIR/configs"/* "$package_dir/configs/"
     cp -r "$PROJECT_DIR/docs"/* "$package_dir/docs/"
-    cp -r "$PROJECT_DIR/web"/* "$package_dir/web/"
     cp -r "$PROJECT_DIR/scripts"/* "$package_dir/scripts/"
 
     # Create installation instructions
@@ -93,7 +93,7 @@ create_package_structure() {
     log_info "Creating package structure for $platform..."
 
     # Create directory structure
-    mkdir -p "$package_dir"/{bin,configs,docs,web,scripts}
+    mkdir -p "$package_dir"/{bin,configs,docs,scripts}
 
     # Copy static files
     cp "$PROJECT_DIR/README.md" "$package_dir/"
@@ -105,9 +105,6 @@ create_package_structure() {
     # Copy documentation
     cp -r "$PROJECT_DIR/docs"/* "$package_dir/docs/"
 
-    # Copy web assets
-    cp -r "$PROJECT_DIR/web"/* "$package_dir/web/"
-
     # Copy scripts
     cp -r "$PROJECT_DIR/scripts"/* "$package_dir/scripts/"
 
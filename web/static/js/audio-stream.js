@@ -0,0 +1,118 @@
+// Plays the RX audio stream from /ws/audio-pcm so a remote operator can
+// listen to the band. Audio is mu-law at 8 kHz, about 64 kbit/s.
+class AudioListener {
+    constructor(button) {
+        this.button = button;
+        this.websocket = null;
+        this.context = null;
+        this.format = null;
+        this.nextTime = 0;
+
+        this.button.addEventListener('click', () => this.toggle());
+    }
+
+    toggle() {
+        if (this.websocket) {
+            this.stop();
+        } else {
+            this.start();
+        }
+    }
+
+    start() {
+        // Browsers only allow audio to start from a user gesture, which this is
+        this.context = new (window.AudioContext || window.webkitAudioContext)();
+        this.nextTime = 0;
+
+        const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+        this.websocket = new WebSocket(`${protocol}//${window.location.host}/ws/audio-pcm?encoding=mulaw`);
+        this.websocket.binaryType = 'arraybuffer';
+
+        this.websocket.onmessage = (event) => {
+            if (typeof event.data === 'string') {
+                const message = JSON.parse(event.data);
+                if (message.type === 'audio_format') {
+                    this.format = message;
+                }
+                return;
+            }
+            if (this.format) {
+                this.play(event.data);
+            }
+        };
+
+        this.websocket.onclose = () => {
+            if (this.websocket) {
+                console.log('Audio stream closed');
+                this.stop();
+            }
+        };
+
+        this.updateButton(true);
+    }
+
+    stop() {
+        const websocket = this.websocket;
+        this.websocket = null;
+        if (websocket) {
+            websocket.close();
+        }
+        if (this.context) {
+            this.context.close();
+            this.context = null;
+        }
+        this.format = null;
+        this.updateButton(false);
+    }
+
+    play(data) {
+        const samples = this.format.encoding === 'pcm16' ? this.decodePCM16(data) : this.decodeMuLaw(data);
+        const buffer = this.context.createBuffer(1, samples.length, this.format.sample_rate);
+        buffer.copyToChannel(samples, 0);
+
+        const source = this.context.createBufferSource();
+        source.buffer = buffer;
+        source.connect(this.context.destination);
+
+        // Keep a small jitter buffer, resyncing after an underrun or drift
+        const now = this.context.currentTime;
+        if (this.nextTime < now + 0.05 || this.nextTime > now + 1.0) {
+            this.nextTime = now + 0.25;
+        }
+        source.start(this.nextTime);
+        this.nextTime += buffer.duration;
+    }
+
+    decodeMuLaw(data) {
+        const bytes = new Uint8Array(data);
+        const samples = new Float32Array(bytes.length);
+        for (let i = 0; i < bytes.length; i++) {
+            const u = ~bytes[i] & 0xff;
+            const exponent = (u >> 4) & 0x07;
+            const magnitude = ((((u & 0x0f) << 3) + 0x84) << exponent) - 0x84;
+            samples[i] = (u & 0x80 ? -magnitude : magnitude) / 32768;
+        }
+        return samples;
+    }
+
+    decodePCM16(data) {
+        const pcm = new Int16Array(data);
+        const samples = new Float32Array(pcm.length);
+        for (let i = 0; i < pcm.length; i++) {
+            samples[i] = pcm[i] / 32768;
+        }
+        return samples;
+    }
+
+    updateButton(active) {
+        this.button.textContent = active ? 'Stop Listening' : 'Listen';
+        this.button.classList.toggle('active', active);
+    }
+}
+
+document.addEventListener('DOMContentLoaded', () => {
+    const button = document.getElementById('listen-toggle');
+    if (button) {
+        new AudioListener(button);
+    }
+});
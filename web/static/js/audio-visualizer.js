@@ -101,6 +101,10 @@ class AudioVisualizer {
             this.websocket.onmessage = (event) => {
                 try {
                     const data = JSON.parse(event.data);
+                    if (data.type === 'audio_alarm') {
+                        this.handleAudioAlarm(data.alarm);
+                        return;
+                    }
                     this.updateVisualization(data);
                 } catch (error) {
                     console.error('Error parsing WebSocket data:', error);
@@ -150,12 +154,12 @@ class AudioVisualizer {
             if (active) {
                 startBtn.disabled = true;
                 startBtn.textContent = 'Monitoring Active';
-                startBtn.style.background = '#4CAF50';
+                startBtn.style.background = 'var(--accent)';
                 stopBtn.disabled = false;
             } else {
                 startBtn.disabled = false;
                 startBtn.textContent = 'Start Monitoring';
-                startBtn.style.background = '#2196F3';
+                startBtn.style.background = 'var(--primary)';
                 stopBtn.disabled = true;
             }
         }
@@ -398,6 +402,15 @@ class AudioVisualizer {
         }
     }
 
+    handleAudioAlarm(alarm) {
+        // Alarm events arrive as soon as the condition changes
+        const clipRateEl = document.getElementById('audio-clip-rate-stat');
+        if (!clipRateEl || !alarm) return;
+
+        clipRateEl.textContent = alarm.message;
+        clipRateEl.style.color = alarm.active ? 'var(--danger)' : 'var(--accent)';
+    }
+
     updateAudioStats(data) {
         // Update audio statistics display
         const sampleRateEl = document.getElementById('audio-sample-rate-stat');
@@ -413,9 +426,13 @@ class AudioVisualizer {
             bufferSizeEl.textContent = `${data.spectrum.length * 2} samples`;
         }
 
-        if (clipRateEl && data.clipping !== undefined) {
+        if (clipRateEl && data.alarms && data.alarms.length > 0) {
+            // Sustained clipping or dead input takes priority over momentary clipping
+            clipRateEl.textContent = data.alarms.map(alarm => alarm.message).join('; ');
+            clipRateEl.style.color = 'var(--danger)';
+        } else if (clipRateEl && data.clipping !== undefined) {
             clipRateEl.textContent = data.clipping ? 'CLIPPING!' : 'OK';
-            clipRateEl.style.color = data.clipping ? '#f44336' : '#4CAF50';
+            clipRateEl.style.color = data.clipping ? 'var(--danger)' : 'var(--accent)';
         }
 
         if (peakHoldEl && data.peak !== undefined) {
@@ -442,7 +459,7 @@ class AudioVisualizer {
                 if (clipRateEl) {
                     const clipRate = stats.clip_rate_pct || 0;
                     clipRateEl.textContent = `${clipRate.toFixed(2)}%`;
-                    clipRateEl.style.color = clipRate > 1 ? '#f44336' : '#4CAF50';
+                    clipRateEl.style.color = clipRate > 1 ? 'var(--danger)' : 'var(--accent)';
                 }
                 if (peakHoldEl) peakHoldEl.textContent = `${(stats.peak_hold_db || -100).toFixed(1)} dB`;
             }
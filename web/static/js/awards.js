@@ -0,0 +1,91 @@
+// js8d Awards - progress toward the grid, DXCC and WAS awards from /api/v1/awards
+
+class JS8DAwards {
+    constructor() {
+        const element = document.getElementById('awards');
+        this.labels = element.dataset;
+        this.refreshInterval = 60000; // Reload every minute
+        this.bands = [];
+
+        document.getElementById('awards-band').addEventListener('change', () => this.load());
+        this.load();
+        setInterval(() => this.load(), this.refreshInterval);
+    }
+
+    async load() {
+        const params = new URLSearchParams();
+        const band = document.getElementById('awards-band').value;
+        if (band) {
+            params.set('band', band);
+        }
+
+        try {
+            const response = await fetch(`/api/v1/awards?${params}`);
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+            this.updateBands(data.bands || []);
+            this.draw(data.awards);
+        } catch (error) {
+            console.error('Failed to load awards:', error);
+            document.getElementById('awards-summary').textContent = `Failed to load awards: ${error.message}`;
+        }
+    }
+
+    // Offers the bands there are QSOs on, keeping the one selected
+    updateBands(bands) {
+        if (bands.join() === this.bands.join()) {
+            return;
+        }
+        this.bands = bands;
+
+        const select = document.getElementById('awards-band');
+        const selected = select.value;
+        while (select.options.length > 1) {
+            select.remove(1);
+        }
+        bands.forEach(band => select.add(new Option(band, band, false, band === selected)));
+    }
+
+    draw(progress) {
+        document.getElementById('awards-summary').textContent = `${this.labels.contacts}: ${progress.contacts}`;
+        this.drawAward('grids', progress.grids);
+        this.drawAward('dxcc', progress.dxcc);
+        this.drawAward('was', progress.was);
+    }
+
+    drawAward(id, award) {
+        const section = document.getElementById(`award-${id}`);
+        const percent = count => `${Math.min(100, count / award.target * 100)}%`;
+        section.querySelector('.award-bar .worked').style.width = percent(award.worked);
+        section.querySelector('.award-bar .confirmed').style.width = percent(award.confirmed);
+        section.querySelector('.award-counts').textContent =
+            `${award.name}: ${this.labels.worked} ${award.worked} · ${this.labels.confirmed} ${award.confirmed} / ${award.target}`;
+
+        const body = section.querySelector('tbody');
+        if (award.items.length === 0) {
+            body.innerHTML = `<tr><td colspan="5" class="empty">${this.escapeHtml(this.labels.none)}</td></tr>`;
+            return;
+        }
+        body.innerHTML = award.items.map(item => `
+            <tr>
+                <td>${this.escapeHtml(item.id)}</td>
+                <td>${this.escapeHtml(item.name || '')}</td>
+                <td>${item.contacts}</td>
+                <td><a href="/?station=${encodeURIComponent(item.callsign)}">${this.escapeHtml(item.callsign)}</a></td>
+                <td>${item.confirmed ? '✓' : ''}</td>
+            </tr>
+        `).join('');
+    }
+
+    escapeHtml(text) {
+        const div = document.createElement('div');
+        div.textContent = text;
+        return div.innerHTML;
+    }
+}
+
+document.addEventListener('DOMContentLoaded', () => {
+    window.js8dAwards = new JS8DAwards();
+});
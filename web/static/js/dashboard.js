@@ -0,0 +1,150 @@
+// js8d aggregator dashboard - status and messages of several js8d nodes
+
+class JS8Dashboard {
+    constructor() {
+        this.pollInterval = 5000;
+        this.filter = '';
+
+        this.init();
+    }
+
+    init() {
+        document.getElementById('node-filter').addEventListener('change', (e) => {
+            this.filter = e.target.value;
+            this.updateMessages();
+        });
+
+        document.getElementById('send-message').addEventListener('click', () => {
+            this.sendMessage();
+        });
+
+        document.getElementById('message-text').addEventListener('keypress', (e) => {
+            if (e.key === 'Enter') {
+                this.sendMessage();
+            }
+        });
+
+        this.refresh();
+        setInterval(() => this.refresh(), this.pollInterval);
+    }
+
+    refresh() {
+        this.updateNodes();
+        this.updateMessages();
+    }
+
+    async updateNodes() {
+        try {
+            const response = await fetch('/api/v1/nodes');
+            const data = await response.json();
+
+            let online = 0;
+            for (const node of data.nodes) {
+                if (node.online) {
+                    online++;
+                }
+                this.renderNode(node);
+            }
+            document.getElementById('nodes-online').textContent = `${online}/${data.count} nodes online`;
+        } catch (error) {
+            console.error('Failed to update nodes:', error);
+        }
+    }
+
+    renderNode(node) {
+        const card = document.querySelector(`.node-card[data-node="${node.name}"]`);
+        if (!card) {
+            return;
+        }
+        card.className = `node-card ${node.online ? 'online' : 'offline'}`;
+
+        const details = card.querySelector('.node-details');
+        if (!node.online) {
+            details.textContent = node.error || 'Waiting for first poll...';
+            return;
+        }
+
+        const status = node.status || {};
+        const freq = status.frequency ? `${(status.frequency / 1000).toFixed(1)} kHz` : '--';
+        details.innerHTML = `
+            <div>${this.escapeHtml(status.callsign || '')} ${this.escapeHtml(status.grid || '')}</div>
+            <div>${freq} ${this.escapeHtml(status.mode || '')}</div>
+            <div>Radio: ${status.connected ? 'connected' : 'disconnected'}${status.ptt ? ' - <span class="ptt-on">TX</span>' : ''}</div>
+            <div><a href="${this.escapeHtml(node.url)}" target="_blank" rel="noopener">Open</a></div>
+        `;
+    }
+
+    async updateMessages() {
+        try {
+            const params = new URLSearchParams({ limit: 100 });
+            if (this.filter) {
+                params.set('node', this.filter);
+            }
+            const response = await fetch(`/api/v1/messages?${params}`);
+            const data = await response.json();
+
+            const container = document.getElementById('messages');
+            container.innerHTML = '';
+            for (const msg of (data.messages || []).reverse()) {
+                container.appendChild(this.createMessage(msg));
+            }
+            container.scrollTop = container.scrollHeight;
+            document.getElementById('message-count').textContent = `${data.count} messages`;
+        } catch (error) {
+            console.error('Failed to update messages:', error);
+        }
+    }
+
+    createMessage(msg) {
+        const element = document.createElement('div');
+        element.className = 'message rx';
+
+        const timestamp = new Date(msg.timestamp).toLocaleTimeString();
+        const snrText = msg.snr ? ` (SNR: ${msg.snr.toFixed(1)}dB)` : '';
+
+        element.innerHTML = `
+            <div class="message-header">
+                <span class="node-tag">${this.escapeHtml(msg.node)}</span>
+                ${timestamp} - ${this.escapeHtml(msg.from)}${msg.to ? ' → ' + this.escapeHtml(msg.to) : ''}${snrText}
+            </div>
+            <div class="message-content">${this.escapeHtml(msg.message)}</div>
+        `;
+        return element;
+    }
+
+    async sendMessage() {
+        const node = document.getElementById('send-node').value;
+        const to = document.getElementById('to-callsign').value.trim();
+        const messageInput = document.getElementById('message-text');
+        const message = messageInput.value.trim();
+        if (!message) {
+            return;
+        }
+
+        try {
+            const response = await fetch(`/api/v1/nodes/${encodeURIComponent(node)}/messages`, {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ to, message })
+            });
+            if (response.ok) {
+                messageInput.value = '';
+            } else {
+                const error = await response.json();
+                alert(`Failed to send on ${node}: ${error.error}`);
+            }
+        } catch (error) {
+            alert(`Failed to send on ${node}: ${error.message}`);
+        }
+    }
+
+    escapeHtml(text) {
+        const div = document.createElement('div');
+        div.textContent = text;
+        return div.innerHTML;
+    }
+}
+
+document.addEventListener('DOMContentLoaded', () => {
+    window.dashboard = new JS8Dashboard();
+});
@@ -0,0 +1,142 @@
+// js8d Files - send small files to another js8d station and follow the
+// transfers, from /api/v1/files with progress from /ws/messages
+
+class JS8DFiles {
+    constructor() {
+        const element = document.getElementById('files');
+        this.labels = element.dataset;
+        this.refreshInterval = 30000; // Reload every 30 seconds
+        this.transfers = [];
+
+        const send = document.getElementById('file-send');
+        if (send) {
+            send.addEventListener('click', () => this.send());
+        }
+        document.getElementById('file-list').addEventListener('click', (event) => {
+            const id = event.target.dataset.cancel;
+            if (id) {
+                this.cancel(id);
+            }
+        });
+        this.load();
+        setInterval(() => this.load(), this.refreshInterval);
+        this.connectEvents();
+    }
+
+    // Progress arrives as file events between reloads
+    connectEvents() {
+        const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+        const socket = new WebSocket(`${protocol}//${window.location.host}/ws/messages`);
+
+        socket.onmessage = (event) => {
+            const data = JSON.parse(event.data);
+            if (data.type !== 'file') {
+                return;
+            }
+            const transfer = data.data.transfer;
+            const index = this.transfers.findIndex(t => t.id === transfer.id);
+            if (index >= 0) {
+                this.transfers[index] = transfer;
+            } else {
+                this.transfers.unshift(transfer);
+            }
+            this.draw();
+        };
+
+        socket.onclose = () => {
+            setTimeout(() => this.connectEvents(), this.refreshInterval);
+        };
+    }
+
+    async send() {
+        const input = document.getElementById('file-input');
+        if (input.files.length === 0) {
+            return;
+        }
+        const body = new FormData();
+        body.append('to', document.getElementById('file-to').value.trim());
+        body.append('file', input.files[0]);
+
+        try {
+            const data = await this.fetchJSON('/api/v1/files', {method: 'POST', body: body});
+            this.setStatus(`${this.labels.queued}: ${data.frames} ${this.labels.frames}`);
+            input.value = '';
+            this.load();
+        } catch (error) {
+            this.setStatus(error.message);
+        }
+    }
+
+    async cancel(id) {
+        try {
+            await this.fetchJSON(`/api/v1/files/${encodeURIComponent(id)}/cancel`, {method: 'POST'});
+            this.load();
+        } catch (error) {
+            this.setStatus(error.message);
+        }
+    }
+
+    async load() {
+        try {
+            const data = await this.fetchJSON('/api/v1/files');
+            this.transfers = data.transfers || [];
+            this.draw();
+        } catch (error) {
+            console.error('Failed to load file transfers:', error);
+        }
+    }
+
+    draw() {
+        const list = document.getElementById('file-list');
+        if (this.transfers.length === 0) {
+            list.innerHTML = `<div class="empty">${this.escapeHtml(this.labels.none)}</div>`;
+            return;
+        }
+        list.innerHTML = this.transfers.map(t => {
+            const status = this.labels['status' + t.status.charAt(0).toUpperCase() + t.status.slice(1)] || t.status;
+            const active = ['sending', 'polling', 'receiving'].includes(t.status);
+            return `
+                <div class="transfer">
+                    <h3>${t.direction === 'RX' ? '⬇' : '⬆'} ${this.escapeHtml(t.name || `#${t.transfer_id}`)}</h3>
+                    <div class="meta">
+                        ${new Date(t.started).toLocaleString()} ·
+                        ${t.from ? `${this.escapeHtml(this.labels.from)} ${this.escapeHtml(t.from)} ·` : ''}
+                        ${this.escapeHtml(this.labels.to)} ${this.escapeHtml(t.to)} ·
+                        ${t.done}/${t.frames} ${this.escapeHtml(this.labels.frames)}
+                        ${t.rounds ? `· ${t.rounds} ${this.escapeHtml(this.labels.rounds)}` : ''}
+                        · ${this.escapeHtml(status)}
+                    </div>
+                    <progress max="${t.frames || 1}" value="${t.done}"></progress>
+                    ${t.error ? `<div class="error">${this.escapeHtml(t.error)}</div>` : ''}
+                    <div class="actions">
+                        ${t.status === 'complete' ? `<a href="/api/v1/files/${t.id}">${this.escapeHtml(this.labels.download)}</a>` : ''}
+                        ${active ? `<button type="button" data-cancel="${t.id}">${this.escapeHtml(this.labels.cancel)}</button>` : ''}
+                    </div>
+                </div>
+            `;
+        }).join('');
+    }
+
+    async fetchJSON(url, options) {
+        const response = await fetch(url, options);
+        const data = await response.json();
+        if (!response.ok) {
+            throw new Error(data.error || `HTTP ${response.status}`);
+        }
+        return data;
+    }
+
+    setStatus(text) {
+        document.getElementById('file-status').textContent = text;
+    }
+
+    escapeHtml(text) {
+        const div = document.createElement('div');
+        div.textContent = text;
+        return div.innerHTML;
+    }
+}
+
+document.addEventListener('DOMContentLoaded', () => {
+    window.js8dFiles = new JS8DFiles();
+});
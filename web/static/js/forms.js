@@ -0,0 +1,140 @@
+// js8d Forms - send structured messages such as ICS-213 and read the ones
+// sent and received, from /api/v1/forms
+
+class JS8DForms {
+    constructor() {
+        const element = document.getElementById('forms');
+        this.labels = element.dataset;
+        this.refreshInterval = 30000; // Reload every 30 seconds
+        this.templates = [];
+
+        document.getElementById('form-template').addEventListener('change', () => this.drawFields());
+        document.getElementById('form-direction').addEventListener('change', () => this.load());
+        document.getElementById('form-send').addEventListener('click', () => this.send());
+        this.loadTemplates();
+        this.load();
+        setInterval(() => this.load(), this.refreshInterval);
+    }
+
+    async loadTemplates() {
+        try {
+            const data = await this.fetchJSON('/api/v1/forms/templates');
+            this.templates = data.templates || [];
+            const select = document.getElementById('form-template');
+            this.templates.forEach(template => select.add(new Option(template.name, template.code)));
+            this.drawFields();
+        } catch (error) {
+            console.error('Failed to load form templates:', error);
+            this.setStatus(`Failed to load form templates: ${error.message}`);
+        }
+    }
+
+    // Lays out an input for each field of the selected template
+    drawFields() {
+        const code = document.getElementById('form-template').value;
+        const template = this.templates.find(t => t.code === code);
+        const container = document.getElementById('form-fields');
+        container.innerHTML = '';
+        if (!template) {
+            return;
+        }
+        template.fields.forEach(field => {
+            const label = document.createElement('label');
+            label.htmlFor = `form-field-${field.name}`;
+            label.textContent = field.label;
+            const input = document.createElement(field.multiline ? 'textarea' : 'input');
+            input.id = `form-field-${field.name}`;
+            input.dataset.field = field.name;
+            container.append(label, input);
+        });
+    }
+
+    async send() {
+        const values = {};
+        document.querySelectorAll('#form-fields [data-field]').forEach(input => {
+            if (input.value.trim()) {
+                values[input.dataset.field] = input.value;
+            }
+        });
+
+        try {
+            const data = await this.fetchJSON('/api/v1/forms', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({
+                    template: document.getElementById('form-template').value,
+                    to: document.getElementById('form-to').value.trim(),
+                    values: values,
+                }),
+            });
+            this.setStatus(`${this.labels.queued}: ${data.frames} ${this.labels.frames}`);
+            document.querySelectorAll('#form-fields [data-field]').forEach(input => { input.value = ''; });
+            this.load();
+        } catch (error) {
+            this.setStatus(error.message);
+        }
+    }
+
+    async load() {
+        const params = new URLSearchParams();
+        const direction = document.getElementById('form-direction').value;
+        if (direction) {
+            params.set('direction', direction);
+        }
+
+        try {
+            const data = await this.fetchJSON(`/api/v1/forms?${params}`);
+            this.draw(data.forms || []);
+        } catch (error) {
+            console.error('Failed to load forms:', error);
+        }
+    }
+
+    draw(forms) {
+        const list = document.getElementById('form-list');
+        if (forms.length === 0) {
+            list.innerHTML = `<div class="empty">${this.escapeHtml(this.labels.none)}</div>`;
+            return;
+        }
+        list.innerHTML = forms.map(form => `
+            <div class="form-card">
+                <h3>${form.direction === 'RX' ? '⬇' : '⬆'} ${this.escapeHtml(form.name || form.template)}</h3>
+                <div class="meta">
+                    ${new Date(form.timestamp).toLocaleString()} ·
+                    ${this.escapeHtml(this.labels.from)} ${this.escapeHtml(form.from)}
+                    ${form.to ? `· ${this.escapeHtml(this.labels.to)} ${this.escapeHtml(form.to)}` : ''}
+                    · ${form.frames} ${this.escapeHtml(this.labels.frames)}
+                </div>
+                <dl>
+                    ${(form.values || []).filter(v => v.text).map(v => `
+                        <dt>${this.escapeHtml(v.label)}</dt>
+                        <dd>${this.escapeHtml(v.text)}</dd>
+                    `).join('')}
+                </dl>
+            </div>
+        `).join('');
+    }
+
+    async fetchJSON(url, options) {
+        const response = await fetch(url, options);
+        const data = await response.json();
+        if (!response.ok) {
+            throw new Error(data.error || `HTTP ${response.status}`);
+        }
+        return data;
+    }
+
+    setStatus(text) {
+        document.getElementById('form-status').textContent = text;
+    }
+
+    escapeHtml(text) {
+        const div = document.createElement('div');
+        div.textContent = text;
+        return div.innerHTML;
+    }
+}
+
+document.addEventListener('DOMContentLoaded', () => {
+    window.js8dForms = new JS8DForms();
+});
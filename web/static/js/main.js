@@ -4,19 +4,50 @@ class JS8DClient {
     constructor() {
         this.connected = false;
         this.messages = [];
+        this.stations = {}; // Station database lookups by callsign
+        this.station = new URLSearchParams(window.location.search).get('station'); // Conversation opened from the map
         this.pollInterval = 2000; // Poll every 2 seconds
         this.statusInterval = 10000; // Update status every 10 seconds
+        this.propagationInterval = 600000; // The daemon refreshes solar indices hourly at most
+        this.sensorGraphInterval = 60000; // Sensors are sampled once a minute by default
+        this.sensorGraphLoaded = 0;
+        this.noiseInterval = 300000; // Band noise is kept by the hour
 
         this.init();
     }
 
     init() {
         this.setupEventListeners();
+        if (this.station) {
+            this.openConversation();
+        }
         this.startPolling();
         this.updateStatus();
     }
 
     setupEventListeners() {
+        // Instance selector, only present when the daemon hosts several rigs
+        const instanceSelect = document.getElementById('instance-select');
+        if (instanceSelect) {
+            instanceSelect.addEventListener('change', () => {
+                this.selectInstance(instanceSelect.value);
+            });
+        }
+
+        // Profile selector, only present when the config file has profiles
+        const profileSelect = document.getElementById('profile-select');
+        if (profileSelect) {
+            this.loadProfiles(profileSelect);
+            profileSelect.addEventListener('change', () => {
+                this.selectProfile(profileSelect);
+            });
+        }
+
+        // Move the TX offset somewhere clear
+        document.getElementById('find-offset').addEventListener('click', () => {
+            this.findOffset();
+        });
+
         // Send message button
         document.getElementById('send-message').addEventListener('click', () => {
             this.sendMessage();
@@ -44,6 +75,26 @@ class JS8DClient {
             }
         });
 
+        // Message macros: F1-F12 insert one, Esc clears the message
+        this.macros = [];
+        this.loadMacros();
+        document.getElementById('save-macro').addEventListener('click', () => {
+            this.saveMacro();
+        });
+        document.addEventListener('keydown', (e) => {
+            const key = /^F([1-9]|1[0-2])$/.exec(e.key);
+            if (key && this.macros[key[1] - 1]) {
+                e.preventDefault();
+                this.insertMacro(this.macros[key[1] - 1].name);
+            } else if (e.key === 'Escape' && document.activeElement.id === 'message-text') {
+                document.getElementById('message-text').value = '';
+                this.previewMessage();
+            }
+        });
+        ['to-callsign', 'message-text'].forEach(id => {
+            document.getElementById(id).addEventListener('input', () => this.previewMessage());
+        });
+
         // Frequency change
         document.getElementById('frequency').addEventListener('change', (e) => {
             // Convert kHz to Hz for backend
@@ -112,6 +163,14 @@ class JS8DClient {
 
         this.spectrumWebSocket.onmessage = (event) => {
             const data = JSON.parse(event.data);
+            if (data.type === 'audio_alarm') {
+                this.addMessage({
+                    timestamp: data.alarm.timestamp,
+                    from: 'js8d',
+                    message: data.alarm.message,
+                }, 'system');
+                return;
+            }
             console.log('Spectrum data received:', {
                 type: data.type,
                 timestamp: data.timestamp,
@@ -291,13 +350,66 @@ class JS8DClient {
             await this.updateStatus();
         }, this.statusInterval);
 
+        // Solar indices change slowly
+        setInterval(() => this.updatePropagation(), this.propagationInterval);
+        setInterval(() => this.updateNoise(), this.noiseInterval);
+
         // Initial load
         this.loadMessages();
+        this.updatePropagation();
+        this.updateNoise();
+
+        // Pick up changes made by other clients without waiting for a poll
+        this.connectMessageEvents();
+    }
+
+    connectMessageEvents() {
+        const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+        const socket = new WebSocket(`${protocol}//${window.location.host}/ws/messages`);
+
+        socket.onmessage = (event) => {
+            const data = JSON.parse(event.data);
+            switch (data.type) {
+                case 'message':
+                    this.loadMessages();
+                    break;
+                case 'message_status':
+                    if (data.data.delivery) {
+                        this.updateDelivery(data.data);
+                    }
+                    break;
+                case 'channel_busy':
+                    this.showChannelBusy(data.data);
+                    break;
+            }
+        };
+
+        // Polling carries on while the socket is down; retry it later
+        socket.onclose = () => {
+            setTimeout(() => this.connectMessageEvents(), this.statusInterval);
+        };
+    }
+
+    // Show only the messages exchanged with one station, ready to reply
+    openConversation() {
+        this.station = this.station.toUpperCase();
+        document.getElementById('to-callsign').value = this.station;
+
+        const heading = document.querySelector('.messages-header h2');
+        heading.textContent = `Messages with ${this.station} `;
+        const all = document.createElement('a');
+        all.href = '/';
+        all.textContent = '(show all)';
+        all.className = 'show-all';
+        heading.appendChild(all);
     }
 
     async loadMessages() {
         try {
-            const response = await fetch('/api/v1/messages?limit=20');
+            const url = this.station
+                ? `/api/v1/messages/history?callsign=${encodeURIComponent(this.station)}&limit=20`
+                : '/api/v1/messages?limit=20';
+            const response = await fetch(url);
             if (!response.ok) {
                 throw new Error(`HTTP ${response.status}`);
             }
@@ -310,11 +422,15 @@ class JS8DClient {
                     !this.messages.find(existing => existing.id === msg.id)
                 );
 
-                // Add new messages to display
-                newMessages.forEach(msg => {
-                    this.addMessage(msg, 'rx');
+                // Add new messages to display, oldest first; only sent
+                // messages have a TX status
+                newMessages.reverse().forEach(msg => {
+                    this.addMessage(msg, msg.status ? 'tx' : 'rx');
                 });
 
+                // Sent messages change delivery state as ACKs come in
+                data.messages.forEach(msg => this.updateDelivery(msg));
+
                 // Update message list
                 this.messages = data.messages;
 
@@ -353,12 +469,91 @@ class JS8DClient {
         }
     }
 
+    async updatePropagation() {
+        try {
+            const response = await fetch('/api/v1/propagation');
+            if (!response.ok) {
+                throw new Error(`HTTP ${response.status}`);
+            }
+
+            const data = await response.json();
+            document.getElementById('propagation-item').style.display = data.enabled ? '' : 'none';
+            const conditions = data.conditions;
+            if (conditions) {
+                const element = document.getElementById('propagation-display');
+                element.textContent = `SFI ${conditions.sfi} A ${conditions.a_index} K ${conditions.k_index}`;
+                element.title = `Issued ${new Date(conditions.issued).toUTCString()}`;
+            }
+        } catch (error) {
+            console.error('Failed to get propagation:', error);
+        }
+    }
+
+    // Show the noise floor now and how the band the rig is on compares
+    // with its quietest hour of the last week, with a graph of the week
+    async updateNoise() {
+        try {
+            const response = await fetch('/api/v1/stats/noise?window=7d');
+            if (!response.ok) {
+                throw new Error(`HTTP ${response.status}`);
+            }
+
+            const data = await response.json();
+            const band = (data.bands || []).find(b => b.band === data.band);
+            document.getElementById('noise-item').style.display = data.noise_floor !== undefined || band ? '' : 'none';
+            const parts = [];
+            if (data.noise_floor !== undefined) {
+                parts.push(`${data.noise_floor.toFixed(1)} dB`);
+            }
+            if (band) {
+                parts.push(`${band.band} S+${band.s_units.toFixed(1)}`);
+            }
+            document.getElementById('noise-display').textContent = parts.length ? parts.join(' ') : '--';
+            this.drawNoiseGraph(band ? band.points : []);
+        } catch (error) {
+            console.error('Failed to get band noise:', error);
+        }
+    }
+
+    // Draw a band's hourly noise floor over time
+    drawNoiseGraph(points) {
+        const canvas = document.getElementById('noise-graph');
+        const ctx = canvas.getContext('2d');
+        ctx.clearRect(0, 0, canvas.width, canvas.height);
+        if (points.length < 2) {
+            return;
+        }
+
+        const levels = points.map(p => p.noise_floor);
+        const low = Math.min(...levels) - 1;
+        const high = Math.max(...levels) + 1;
+        const start = new Date(points[0].time).getTime();
+        const span = Math.max(new Date(points[points.length - 1].time).getTime() - start, 1);
+        const x = p => (new Date(p.time).getTime() - start) / span * canvas.width;
+        const y = v => canvas.height - (v - low) / (high - low) * canvas.height;
+
+        ctx.strokeStyle = getComputedStyle(document.documentElement).getPropertyValue('--accent') || 'green';
+        ctx.beginPath();
+        points.forEach((p, i) => {
+            if (i === 0) {
+                ctx.moveTo(x(p), y(p.noise_floor));
+            } else {
+                ctx.lineTo(x(p), y(p.noise_floor));
+            }
+        });
+        ctx.stroke();
+    }
+
     updateStatusFromData(data) {
         if (data.frequency) {
             // Convert Hz to kHz for display
             const freqKHz = (data.frequency / 1000).toFixed(1);
             document.getElementById('frequency').value = freqKHz;
         }
+        // TX here would be outside the band plan or license class
+        const frequency = document.getElementById('frequency');
+        frequency.title = data.band_plan ? data.band_plan.warning : '';
+        frequency.classList.toggle('out-of-band', !!data.band_plan);
         if (data.status) {
             document.getElementById('daemon-status').textContent = data.status;
         }
@@ -368,6 +563,114 @@ class JS8DClient {
         if (data.connected !== undefined) {
             // Update any connection indicators
         }
+        this.updateClock(data.clock);
+        this.updateSensors(data.sensors);
+        // The waterfall may be off while the node saves power
+        document.getElementById('power-item').style.display = data.low_power ? '' : 'none';
+    }
+
+    // Show the battery voltage and temperatures, flagged when the battery
+    // is low enough to stop TX, with a graph of the last day's voltage
+    updateSensors(sensors) {
+        document.getElementById('battery-item').style.display = sensors ? '' : 'none';
+        if (!sensors) {
+            return;
+        }
+        const element = document.getElementById('battery-display');
+        const latest = sensors.latest;
+        const parts = [];
+        if (latest && latest.voltage !== undefined) {
+            parts.push(`${latest.voltage.toFixed(2)} V`);
+        }
+        if (latest && latest.temperatures) {
+            Object.values(latest.temperatures).forEach(t => parts.push(`${t.toFixed(1)} °C`));
+        }
+        element.textContent = parts.length ? parts.join(' ') : '?';
+        element.title = sensors.low_battery
+            ? `Below ${sensors.min_voltage} V, TX stopped`
+            : (sensors.error || '');
+        element.className = sensors.low_battery ? 'battery-low' : '';
+
+        if (Date.now() - this.sensorGraphLoaded > this.sensorGraphInterval) {
+            this.sensorGraphLoaded = Date.now();
+            this.loadSensorGraph();
+        }
+    }
+
+    async loadSensorGraph() {
+        try {
+            const response = await fetch('/api/v1/sensors');
+            if (!response.ok) {
+                throw new Error(`HTTP ${response.status}`);
+            }
+
+            const data = await response.json();
+            const points = (data.history || []).filter(r => r.voltage !== undefined);
+            this.drawSensorGraph(points, data.sensors.min_voltage);
+        } catch (error) {
+            console.error('Failed to get sensor history:', error);
+        }
+    }
+
+    // Draw battery voltage over time, with the TX cutoff as a dashed line
+    drawSensorGraph(points, minVoltage) {
+        const canvas = document.getElementById('battery-graph');
+        const ctx = canvas.getContext('2d');
+        ctx.clearRect(0, 0, canvas.width, canvas.height);
+        if (points.length < 2) {
+            return;
+        }
+
+        const voltages = points.map(r => r.voltage);
+        if (minVoltage) {
+            voltages.push(minVoltage);
+        }
+        const low = Math.min(...voltages) - 0.1;
+        const high = Math.max(...voltages) + 0.1;
+        const start = new Date(points[0].time).getTime();
+        const span = Math.max(new Date(points[points.length - 1].time).getTime() - start, 1);
+        const x = r => (new Date(r.time).getTime() - start) / span * canvas.width;
+        const y = v => canvas.height - (v - low) / (high - low) * canvas.height;
+
+        if (minVoltage) {
+            ctx.strokeStyle = getComputedStyle(document.documentElement).getPropertyValue('--danger') || 'red';
+            ctx.setLineDash([3, 3]);
+            ctx.beginPath();
+            ctx.moveTo(0, y(minVoltage));
+            ctx.lineTo(canvas.width, y(minVoltage));
+            ctx.stroke();
+            ctx.setLineDash([]);
+        }
+
+        ctx.strokeStyle = getComputedStyle(document.documentElement).getPropertyValue('--accent') || 'green';
+        ctx.beginPath();
+        points.forEach((r, i) => {
+            if (i === 0) {
+                ctx.moveTo(x(r), y(r.voltage));
+            } else {
+                ctx.lineTo(x(r), y(r.voltage));
+            }
+        });
+        ctx.stroke();
+    }
+
+    // Show how far the system clock is off, flagged when it is out of
+    // tolerance or could not be checked
+    updateClock(clock) {
+        document.getElementById('clock-item').style.display = clock ? '' : 'none';
+        if (!clock) {
+            return;
+        }
+        const element = document.getElementById('clock-display');
+        if (clock.checked) {
+            const sign = clock.offset >= 0 ? '+' : '';
+            element.textContent = `${sign}${clock.offset.toFixed(2)} s`;
+            element.title = `${clock.server} (${clock.source})${clock.error ? ' - ' + clock.error : ''}`;
+        } else {
+            element.textContent = '?';
+            element.title = clock.error || '';
+        }
+        element.className = clock.synced ? 'clock-ok' : 'clock-off';
     }
 
     updateConnectionStatus() {
@@ -396,13 +699,19 @@ class JS8DClient {
         const messagesContainer = document.getElementById('messages');
         const messageElement = document.createElement('div');
         messageElement.className = `message ${type}`;
+        messageElement.dataset.id = msg.id;
 
         const timestamp = new Date(msg.timestamp).toLocaleTimeString();
-        const snrText = msg.snr ? ` (SNR: ${msg.snr.toFixed(1)}dB)` : '';
+        const noiseText = msg.noise_floor !== undefined ? `, noise ${msg.noise_floor.toFixed(1)}dB` : '';
+        const snrText = msg.snr ? ` (SNR: ${msg.snr.toFixed(1)}dB${noiseText})` : '';
+        const pathText = msg.path ? ` ${msg.path.distance} km ${msg.path.bearing}°` : '';
+        const frequencyText = msg.frequency ? ` ${(msg.frequency / 1000).toFixed(1)} kHz` : '';
 
         messageElement.innerHTML = `
             <div class="message-header">
-                ${timestamp} - ${msg.from}${msg.to ? ' → ' + msg.to : ''}${snrText}
+                ${timestamp} - ${msg.from}<span class="station-info"></span>${msg.to ? ' → ' + msg.to : ''}${snrText}${frequencyText}${pathText}
+                <span class="delivery"></span>
+                ${msg.snippet ? '<button class="play-snippet" title="Play the received audio">▶</button>' : ''}
             </div>
             <div class="message-content">${this.escapeHtml(msg.message)}</div>
         `;
@@ -420,6 +729,128 @@ class JS8DClient {
 
         messagesContainer.appendChild(messageElement);
         messagesContainer.scrollTop = messagesContainer.scrollHeight;
+        this.updateDelivery(msg);
+        const play = messageElement.querySelector('.play-snippet');
+        if (play) {
+            play.addEventListener('click', () => this.playSnippet(msg.snippet));
+        }
+        if (type !== 'system') {
+            this.showStation(messageElement, msg.from);
+        }
+    }
+
+    // Play back the audio a decode was made from, stopping any still playing
+    playSnippet(name) {
+        if (this.snippetAudio) {
+            this.snippetAudio.pause();
+        }
+        this.snippetAudio = new Audio(`/api/v1/snippets/${encodeURIComponent(name)}`);
+        this.snippetAudio.play().catch(error => {
+            console.error('Failed to play snippet:', error);
+        });
+    }
+
+    // Show the operator's name and QTH from the station database next to
+    // the callsign, with the notes and QSL status on hover
+    async showStation(messageElement, callsign) {
+        if (!callsign) {
+            return;
+        }
+        if (!(callsign in this.stations)) {
+            this.stations[callsign] = fetch(`/api/v1/stations/${encodeURIComponent(callsign)}`)
+                .then(response => response.ok ? response.json() : null)
+                .catch(() => null);
+        }
+
+        const station = await this.stations[callsign];
+        if (!station) {
+            return;
+        }
+
+        const details = [station.name, station.qth || station.country || station.grid].filter(Boolean);
+        const element = messageElement.querySelector('.station-info');
+        if (details.length > 0) {
+            element.textContent = ` (${details.join(', ')})`;
+        }
+        element.title = [station.notes, station.qsl_status ? `QSL ${station.qsl_status}` : '']
+            .filter(Boolean).join(' - ');
+    }
+
+    updateDelivery(msg) {
+        const element = document.querySelector(`#messages .message[data-id="${msg.id}"] .delivery`);
+        if (!element) {
+            return;
+        }
+
+        const labels = {
+            awaiting: 'awaiting ACK',
+            delivered: '✓ delivered',
+            undelivered: '✗ undelivered'
+        };
+        element.textContent = labels[msg.delivery] || '';
+        element.className = `delivery ${msg.delivery || ''}`;
+    }
+
+    async selectInstance(name) {
+        try {
+            // The daemon remembers the choice in a cookie used by every request
+            const response = await fetch('/api/v1/instances/select', {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                },
+                body: JSON.stringify({ name: name })
+            });
+            if (!response.ok) {
+                throw new Error(`HTTP ${response.status}`);
+            }
+            window.location.reload();
+        } catch (error) {
+            console.error('Failed to select instance:', error);
+        }
+    }
+
+    async loadProfiles(select) {
+        try {
+            const response = await fetch('/api/v1/profiles');
+            if (!response.ok) {
+                throw new Error(`HTTP ${response.status}`);
+            }
+            const data = await response.json();
+            select.value = data.active || '';
+            select.dataset.active = select.value;
+        } catch (error) {
+            console.error('Failed to load profiles:', error);
+        }
+    }
+
+    async selectProfile(select) {
+        try {
+            // Switching may reopen the sound card and radio, which takes a moment
+            select.disabled = true;
+            const response = await fetch('/api/v1/profiles/select', {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                },
+                body: JSON.stringify({ name: select.value })
+            });
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+            if (data.warning) {
+                alert(data.warning);
+            }
+            select.dataset.active = data.profile;
+            this.updateStatus();
+        } catch (error) {
+            console.error('Failed to select profile:', error);
+            alert(`Failed to switch profile: ${error.message}`);
+            select.value = select.dataset.active || '';
+        } finally {
+            select.disabled = false;
+        }
     }
 
     async sendMessage() {
@@ -452,6 +883,7 @@ class JS8DClient {
 
                 // Clear the message input
                 document.getElementById('message-text').value = '';
+                this.previewMessage();
 
                 // Add to display as transmitted message
                 if (data.message) {
@@ -473,6 +905,132 @@ class JS8DClient {
         }
     }
 
+    async loadMacros() {
+        try {
+            const response = await fetch('/api/v1/macros');
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+            this.macros = data.macros || [];
+            this.renderMacros();
+        } catch (error) {
+            console.error('Failed to load macros:', error);
+        }
+    }
+
+    renderMacros() {
+        const list = document.getElementById('macro-list');
+        list.replaceChildren(...this.macros.map((macro, i) => {
+            const button = document.createElement('button');
+            button.type = 'button';
+            button.className = 'macro-button';
+            button.title = `${macro.text}\nClick to insert, right-click to delete`;
+            if (i < 12) {
+                const key = document.createElement('span');
+                key.className = 'macro-key';
+                key.textContent = `F${i + 1}`;
+                button.appendChild(key);
+            }
+            button.appendChild(document.createTextNode(macro.name));
+            button.addEventListener('click', () => this.insertMacro(macro.name));
+            button.addEventListener('contextmenu', (e) => {
+                e.preventDefault();
+                this.deleteMacro(macro.name);
+            });
+            return button;
+        }));
+    }
+
+    // Put {NAME} at the cursor; the daemon fills it in when the message is sent
+    insertMacro(name) {
+        const input = document.getElementById('message-text');
+        const start = input.selectionStart ?? input.value.length;
+        const end = input.selectionEnd ?? input.value.length;
+        const token = `{${name}}`;
+        input.value = input.value.slice(0, start) + token + input.value.slice(end);
+        input.focus();
+        input.setSelectionRange(start + token.length, start + token.length);
+        this.previewMessage();
+    }
+
+    async saveMacro() {
+        const text = document.getElementById('message-text').value.trim();
+        if (!text) {
+            alert('Type the macro text in the message box first');
+            return;
+        }
+        const name = prompt('Macro name (letters, digits and underscores):');
+        if (!name) {
+            return;
+        }
+
+        try {
+            const response = await fetch(`/api/v1/macros/${encodeURIComponent(name.trim())}`, {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ text }),
+            });
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+            this.loadMacros();
+        } catch (error) {
+            console.error('Failed to save macro:', error);
+            alert(`Failed to save macro: ${error.message}`);
+        }
+    }
+
+    async deleteMacro(name) {
+        if (!confirm(`Delete macro ${name}?`)) {
+            return;
+        }
+
+        try {
+            const response = await fetch(`/api/v1/macros/${encodeURIComponent(name)}`, { method: 'DELETE' });
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+            this.loadMacros();
+        } catch (error) {
+            console.error('Failed to delete macro:', error);
+            alert(`Failed to delete macro: ${error.message}`);
+        }
+    }
+
+    // Show what a message with macros or tokens will send, a moment after
+    // typing stops
+    previewMessage() {
+        clearTimeout(this.previewTimer);
+        const preview = document.getElementById('message-preview');
+        const text = document.getElementById('message-text').value.trim();
+        if (!/\{\w+\}/.test(text)) {
+            preview.textContent = '';
+            preview.classList.remove('error');
+            return;
+        }
+
+        this.previewTimer = setTimeout(async () => {
+            try {
+                const response = await fetch('/api/v1/macros/expand', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({
+                        to: document.getElementById('to-callsign').value.trim().toUpperCase(),
+                        text,
+                    }),
+                });
+                const data = await response.json();
+                preview.textContent = response.ok ? `→ ${data.text}` : data.error;
+                preview.classList.toggle('error', !response.ok);
+            } catch (error) {
+                console.error('Failed to preview message:', error);
+            }
+        }, 300);
+    }
+
     async sendHeartbeat() {
         const callsign = document.querySelector('.callsign').textContent;
         const grid = document.querySelector('.grid').textContent.replace(/[()]/g, '');
@@ -589,7 +1147,7 @@ class JS8DClient {
 
             if (response.ok) {
                 button.textContent = '✅ PTT OFF';
-                button.style.background = '#4CAF50';
+                button.style.background = 'var(--accent)';
                 console.log('✅ PTT turned OFF successfully');
 
                 // Update status immediately
@@ -597,7 +1155,7 @@ class JS8DClient {
 
                 setTimeout(() => {
                     button.textContent = originalText;
-                    button.style.background = '#ff4444';
+                    button.style.background = 'var(--danger)';
                     button.disabled = false;
                 }, 3000);
             } else {
@@ -726,6 +1284,36 @@ class JS8DClient {
         }
     }
 
+    // Move the TX offset to the quietest slot of the last minute
+    async findOffset() {
+        try {
+            const response = await fetch('/api/v1/radio/find-offset', { method: 'POST' });
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+            document.getElementById('tx-progress-text').textContent = `TX offset ${data.offset} Hz`;
+        } catch (error) {
+            console.error('Failed to find a clear offset:', error);
+            alert(`Failed to find a clear offset: ${error.message}`);
+        }
+    }
+
+    // Say why a transmission is held back or moved, with what was heard
+    showChannelBusy(busy) {
+        const progressText = document.getElementById('tx-progress-text');
+        if (!progressText) return;
+
+        if (busy.moved_to) {
+            progressText.textContent = `Moved to ${busy.moved_to} Hz`;
+        } else if (busy.action === 'wait') {
+            progressText.textContent = 'Channel busy, waiting';
+        } else {
+            progressText.textContent = 'Channel busy';
+        }
+        progressText.title = busy.reason;
+    }
+
     drawVUMeter(ctx, rms, peak, clipping) {
         if (!ctx) return;
 
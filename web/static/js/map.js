@@ -0,0 +1,217 @@
+// js8d Map - heard stations from /api/v1/map drawn with Leaflet
+
+class JS8DMap {
+    constructor() {
+        const element = document.getElementById('station-map');
+        this.callsign = element.dataset.callsign;
+        this.home = this.gridToLatLng(element.dataset.grid);
+        this.refreshInterval = 60000; // Reload every minute
+        this.data = null;
+        this.fitted = false;
+
+        this.map = L.map(element, { worldCopyJump: true }).setView(this.home || [20, 0], 3);
+        L.tileLayer('https://{s}.tile.openstreetmap.org/{z}/{x}/{y}.png', {
+            maxZoom: 18,
+            attribution: '&copy; <a href="https://www.openstreetmap.org/copyright">OpenStreetMap</a> contributors'
+        }).addTo(this.map);
+
+        this.pathLayer = L.layerGroup().addTo(this.map);
+        this.stationLayer = L.layerGroup().addTo(this.map);
+
+        if (this.home) {
+            L.marker(this.home, { title: this.callsign })
+                .bindTooltip(`${this.callsign} (${element.dataset.grid})`)
+                .addTo(this.map);
+        }
+
+        this.init();
+    }
+
+    init() {
+        ['map-window', 'map-band'].forEach(id => {
+            document.getElementById(id).addEventListener('change', () => this.load());
+        });
+        document.getElementById('map-color').addEventListener('change', () => this.draw());
+
+        this.load();
+        setInterval(() => this.load(), this.refreshInterval);
+    }
+
+    async load() {
+        const params = new URLSearchParams({ window: document.getElementById('map-window').value });
+        const band = document.getElementById('map-band').value;
+        if (band) {
+            params.set('band', band);
+        }
+
+        try {
+            const response = await fetch(`/api/v1/map?${params}`);
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+            this.data = data;
+            this.draw();
+        } catch (error) {
+            console.error('Failed to load map:', error);
+            document.getElementById('map-summary').textContent = `Failed to load map: ${error.message}`;
+        }
+    }
+
+    draw() {
+        if (!this.data) {
+            return;
+        }
+
+        this.stationLayer.clearLayers();
+        this.pathLayer.clearLayers();
+
+        const colorBy = document.getElementById('map-color').value;
+        const bounds = this.home ? [this.home] : [];
+        this.data.features.forEach(feature => {
+            const props = feature.properties;
+            const [lon, lat] = feature.geometry.coordinates;
+            const position = [lat, lon];
+            bounds.push(position);
+
+            // Completed QSOs get the great-circle path from here
+            if (props.worked && this.home) {
+                L.polyline(this.greatCircle(this.home, position), {
+                    color: '#2196F3',
+                    weight: 1.5,
+                    opacity: 0.7
+                }).addTo(this.pathLayer);
+            }
+
+            L.circleMarker(position, {
+                radius: 7,
+                color: '#222',
+                weight: 1,
+                fillColor: colorBy === 'age' ? this.ageColor(props.last_heard) : this.snrColor(props.snr),
+                fillOpacity: 0.9
+            })
+                .bindTooltip(this.describe(props))
+                .on('click', () => {
+                    window.location.href = `/?station=${encodeURIComponent(props.callsign)}`;
+                })
+                .addTo(this.stationLayer);
+        });
+
+        // Frame the stations the first time there are any
+        if (!this.fitted && bounds.length > 1) {
+            this.map.fitBounds(bounds, { padding: [30, 30], maxZoom: 6 });
+            this.fitted = true;
+        }
+
+        const count = this.data.features.length;
+        let summary = `${count} station${count === 1 ? '' : 's'}`;
+        if (this.data.unplaced > 0) {
+            summary += `, ${this.data.unplaced} without a location`;
+        }
+        document.getElementById('map-summary').textContent = summary;
+        this.drawLegend(colorBy);
+    }
+
+    describe(props) {
+        const lines = [
+            `<strong>${this.escapeHtml(props.callsign)}</strong>${props.grid ? ' ' + this.escapeHtml(props.grid) : ''}`,
+            `SNR ${props.snr} dB${props.band ? ' on ' + props.band : ''}`,
+            `Last heard ${new Date(props.last_heard).toLocaleString()}`,
+            `${props.count} decode${props.count === 1 ? '' : 's'}`
+        ];
+        if (props.distance !== undefined) {
+            lines.push(`${props.distance} km at ${props.bearing}°`);
+        }
+        lines.push('<em>Click to open the conversation</em>');
+        return lines.join('<br>');
+    }
+
+    // Red at -24 dB, the JS8 normal mode floor, through to green at +10 dB
+    snrColor(snr) {
+        const fraction = Math.min(Math.max((snr + 24) / 34, 0), 1);
+        return `hsl(${Math.round(fraction * 120)}, 80%, 50%)`;
+    }
+
+    // Green when just heard, fading to grey at the end of the window
+    ageColor(lastHeard) {
+        const age = (Date.now() - new Date(lastHeard).getTime()) / 1000;
+        const fraction = Math.min(Math.max(age / this.data.window, 0), 1);
+        return `hsl(120, ${Math.round(80 * (1 - fraction))}%, ${Math.round(50 - 15 * fraction)}%)`;
+    }
+
+    drawLegend(colorBy) {
+        const stops = colorBy === 'age'
+            ? [['Just heard', this.ageColor(new Date())], ['Oldest', 'hsl(120, 0%, 35%)']]
+            : [['-24 dB', this.snrColor(-24)], ['-7 dB', this.snrColor(-7)], ['+10 dB', this.snrColor(10)]];
+
+        const legend = document.getElementById('map-legend');
+        legend.innerHTML = stops.map(([label, color]) =>
+            `<span><span class="legend-swatch" style="background: ${color}"></span>${label}</span>`
+        ).join('') + '<span><span class="legend-swatch" style="background: #2196F3"></span>Worked</span>';
+    }
+
+    // Centre of a 4 or 6 character Maidenhead grid square, or null
+    gridToLatLng(grid) {
+        if (!grid || !/^[A-R]{2}[0-9]{2}([A-X]{2})?$/i.test(grid)) {
+            return null;
+        }
+        grid = grid.toUpperCase();
+
+        let lon = (grid.charCodeAt(0) - 65) * 20 - 180 + Number(grid[2]) * 2;
+        let lat = (grid.charCodeAt(1) - 65) * 10 - 90 + Number(grid[3]);
+        if (grid.length === 6) {
+            lon += (grid.charCodeAt(4) - 65 + 0.5) / 12;
+            lat += (grid.charCodeAt(5) - 65 + 0.5) / 24;
+        } else {
+            lon += 1;
+            lat += 0.5;
+        }
+        return [lat, lon];
+    }
+
+    // Points along the great circle between two [lat, lng] positions, with
+    // longitudes kept continuous so Leaflet draws across the antimeridian
+    greatCircle(from, to, segments = 64) {
+        const toVector = ([lat, lng]) => {
+            const phi = lat * Math.PI / 180;
+            const lambda = lng * Math.PI / 180;
+            return [Math.cos(phi) * Math.cos(lambda), Math.cos(phi) * Math.sin(lambda), Math.sin(phi)];
+        };
+        const a = toVector(from);
+        const b = toVector(to);
+        const dot = Math.min(Math.max(a[0] * b[0] + a[1] * b[1] + a[2] * b[2], -1), 1);
+        const omega = Math.acos(dot);
+        if (omega < 1e-6) {
+            return [from, to];
+        }
+
+        const points = [];
+        let previous = null;
+        for (let i = 0; i <= segments; i++) {
+            const t = i / segments;
+            const wa = Math.sin((1 - t) * omega) / Math.sin(omega);
+            const wb = Math.sin(t * omega) / Math.sin(omega);
+            const [x, y, z] = [0, 1, 2].map(k => wa * a[k] + wb * b[k]);
+
+            const lat = Math.atan2(z, Math.hypot(x, y)) * 180 / Math.PI;
+            let lng = Math.atan2(y, x) * 180 / Math.PI;
+            if (previous !== null) {
+                while (lng - previous > 180) lng -= 360;
+                while (lng - previous < -180) lng += 360;
+            }
+            previous = lng;
+            points.push([lat, lng]);
+        }
+        return points;
+    }
+
+    escapeHtml(text) {
+        const div = document.createElement('div');
+        div.textContent = text;
+        return div.innerHTML;
+    }
+}
+
+document.addEventListener('DOMContentLoaded', () => {
+    window.js8dMap = new JS8DMap();
+});
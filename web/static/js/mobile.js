@@ -0,0 +1,243 @@
+// js8d compact view - message stream, quick reply, AUTO, scripted QSOs and
+// abort for phones
+
+class JS8DMobile {
+    constructor() {
+        this.stream = document.getElementById('messages');
+        this.callsign = this.stream.dataset.callsign;
+        this.statusInterval = 2000;
+        this.messageInterval = 5000; // Backs up the WebSocket
+        this.seen = new Set();
+        this.auto = false;
+        this.scripts = false;
+
+        this.init();
+    }
+
+    init() {
+        document.getElementById('reply-form').addEventListener('submit', (event) => {
+            event.preventDefault();
+            this.send();
+        });
+        document.getElementById('auto-toggle').addEventListener('click', () => this.toggleAuto());
+        document.getElementById('qso-toggle').addEventListener('click', () => this.toggleScripts());
+        document.getElementById('qso-next').addEventListener('click', () => this.qsoAction('next'));
+        document.getElementById('qso-stop').addEventListener('click', () => this.qsoAction('stop'));
+        document.getElementById('abort-tx').addEventListener('click', () => this.abort());
+
+        this.loadMessages();
+        this.updateStatus();
+        this.connectMessageEvents();
+        setInterval(() => this.loadMessages(), this.messageInterval);
+        setInterval(() => this.updateStatus(), this.statusInterval);
+    }
+
+    connectMessageEvents() {
+        const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+        const socket = new WebSocket(`${protocol}//${window.location.host}/ws/messages`);
+
+        socket.onmessage = (event) => {
+            if (JSON.parse(event.data).type === 'message') {
+                this.loadMessages();
+            }
+        };
+        socket.onclose = () => {
+            setTimeout(() => this.connectMessageEvents(), this.messageInterval);
+        };
+    }
+
+    async loadMessages() {
+        try {
+            const response = await fetch('/api/v1/messages?limit=50');
+            if (!response.ok) {
+                throw new Error(`HTTP ${response.status}`);
+            }
+
+            const data = await response.json();
+            // Newest first from the API; append oldest first
+            (data.messages || []).slice().reverse().forEach(msg => this.addMessage(msg));
+        } catch (error) {
+            console.error('Failed to load messages:', error);
+        }
+    }
+
+    addMessage(msg) {
+        if (this.seen.has(msg.id)) {
+            return;
+        }
+        this.seen.add(msg.id);
+
+        // Only follow new messages if the stream was already at the bottom
+        const atBottom = this.stream.scrollHeight - this.stream.scrollTop - this.stream.clientHeight < 40;
+
+        const element = document.createElement('div');
+        element.className = 'mobile-message';
+        if (msg.status) {
+            element.classList.add('tx');
+        } else if (msg.to === this.callsign) {
+            element.classList.add('directed');
+        }
+
+        const meta = document.createElement('div');
+        meta.className = 'meta';
+        const time = new Date(msg.timestamp).toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' });
+        meta.textContent = `${time} ${msg.from}${msg.to ? ' → ' + msg.to : ''}${msg.status ? '' : ` ${Math.round(msg.snr)} dB`}`;
+
+        const text = document.createElement('div');
+        text.className = 'text';
+        text.textContent = msg.message;
+
+        element.append(meta, text);
+
+        // Tap a received message to reply to its sender
+        if (!msg.status && msg.from !== this.callsign) {
+            element.addEventListener('click', () => {
+                document.getElementById('reply-to').value = msg.from;
+                document.getElementById('reply-text').focus();
+            });
+        }
+
+        this.stream.appendChild(element);
+        if (atBottom) {
+            this.stream.scrollTop = this.stream.scrollHeight;
+        }
+    }
+
+    async send() {
+        const to = document.getElementById('reply-to').value.trim().toUpperCase();
+        const input = document.getElementById('reply-text');
+        const message = input.value.trim();
+        if (!message) {
+            return;
+        }
+
+        try {
+            const response = await fetch('/api/v1/messages', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ to, message }),
+            });
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+
+            input.value = '';
+            if (data.message) {
+                this.addMessage(data.message);
+                this.stream.scrollTop = this.stream.scrollHeight;
+            }
+        } catch (error) {
+            alert(`Failed to send message: ${error.message}`);
+        }
+    }
+
+    async updateStatus() {
+        try {
+            const response = await fetch('/api/v1/status');
+            if (!response.ok) {
+                throw new Error(`HTTP ${response.status}`);
+            }
+
+            const data = await response.json();
+            if (data.frequency) {
+                document.getElementById('frequency').textContent = `${(data.frequency / 1000).toFixed(1)} kHz`;
+            }
+
+            const ptt = document.getElementById('ptt-indicator');
+            ptt.textContent = data.ptt ? 'TX' : 'RX';
+            ptt.className = data.ptt ? 'ptt-on' : 'ptt-off';
+            document.getElementById('abort-tx').classList.toggle('transmitting', data.ptt);
+
+            this.showAuto(data.auto);
+            this.showQSO(data.qso_script, data.qso);
+        } catch (error) {
+            console.error('Failed to get status:', error);
+        }
+    }
+
+    showAuto(enabled) {
+        this.auto = enabled;
+        const button = document.getElementById('auto-toggle');
+        button.textContent = enabled ? 'AUTO ON' : 'AUTO OFF';
+        button.classList.toggle('on', enabled);
+    }
+
+    async toggleAuto() {
+        try {
+            const response = await fetch('/api/v1/auto', {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ enabled: !this.auto }),
+            });
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+            this.showAuto(data.auto);
+        } catch (error) {
+            alert(`Failed to change AUTO: ${error.message}`);
+        }
+    }
+
+    // The script toggle, and the running QSO with buttons to skip ahead or
+    // take over
+    showQSO(enabled, qso) {
+        this.scripts = enabled;
+        const button = document.getElementById('qso-toggle');
+        button.textContent = enabled ? 'SCRIPT ON' : 'SCRIPT OFF';
+        button.classList.toggle('on', enabled);
+
+        document.getElementById('qso-status').hidden = !qso;
+        if (qso) {
+            document.getElementById('qso-progress').textContent = `QSO ${qso.call} ${qso.step}/${qso.steps}`;
+        }
+    }
+
+    async toggleScripts() {
+        try {
+            const response = await fetch('/api/v1/qso', {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ enabled: !this.scripts }),
+            });
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+            this.showQSO(data.enabled, data.qso);
+        } catch (error) {
+            alert(`Failed to change scripted QSOs: ${error.message}`);
+        }
+    }
+
+    async qsoAction(action) {
+        try {
+            const response = await fetch(`/api/v1/qso/${action}`, { method: 'POST' });
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+            this.showQSO(data.enabled, data.qso);
+        } catch (error) {
+            alert(`Failed to ${action} the QSO: ${error.message}`);
+        }
+    }
+
+    async abort() {
+        try {
+            const response = await fetch('/api/v1/abort', { method: 'POST' });
+            if (!response.ok) {
+                const data = await response.json();
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+            setTimeout(() => this.updateStatus(), 100);
+        } catch (error) {
+            alert(`Failed to abort: ${error.message}`);
+        }
+    }
+}
+
+document.addEventListener('DOMContentLoaded', () => {
+    window.js8dMobile = new JS8DMobile();
+});
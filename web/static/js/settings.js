@@ -25,6 +25,16 @@ class SettingsManager {
             this.reloadDaemon();
         });
 
+        // Search the radio models as the user types
+        document.getElementById('radio-model-search').addEventListener('input', (e) => {
+            clearTimeout(this.modelSearchTimeout);
+            this.modelSearchTimeout = setTimeout(() => this.loadRigModels(e.target.value), 250);
+        });
+
+        document.getElementById('detect-rig').addEventListener('click', () => {
+            this.detectRig();
+        });
+
         // Test buttons
         document.getElementById('test-cat').addEventListener('click', () => {
             this.testCAT();
@@ -55,10 +65,43 @@ class SettingsManager {
             this.cleanupMessages();
         });
 
+        // System buttons
+        document.getElementById('check-update').addEventListener('click', () => {
+            this.checkUpdate();
+        });
+
+        document.getElementById('run-selftest').addEventListener('click', () => {
+            this.runSelfTest();
+        });
+
+        document.getElementById('restart-daemon').addEventListener('click', () => {
+            this.restartDaemon();
+        });
+
+        document.getElementById('reboot-host').addEventListener('click', () => {
+            this.rebootHost();
+        });
+
+        document.getElementById('restore-backup').addEventListener('click', () => {
+            document.getElementById('restore-file').click();
+        });
+
+        document.getElementById('restore-file').addEventListener('change', (e) => {
+            this.restoreBackup(e.target);
+        });
+
         // Hamlib dependency handling
         document.getElementById('radio-use-hamlib').addEventListener('change', (e) => {
             this.handleHamlibChange(e.target.checked);
         });
+        document.getElementById('radio-backend').addEventListener('change', () => {
+            this.handleHamlibChange(this.getFormValue('radio-use-hamlib'));
+        });
+
+        // A sound card selected again brings back its remembered settings
+        document.getElementById('audio-input').addEventListener('change', (e) => {
+            this.recallAudioDevice(e.target.value);
+        });
 
         // Serial device changes no longer need to sync PTT port since they're the same
 
@@ -121,9 +164,11 @@ class SettingsManager {
 
             if (!saveResponse.ok) {
                 const error = await saveResponse.json();
+                this.showFieldErrors(error.fields);
                 this.showStatus(`Auto-save failed: ${error.error}`, 'error');
                 return;
             }
+            this.showFieldErrors([]);
 
             // Reload daemon configuration
             const reloadResponse = await fetch('/api/v1/config/reload', {
@@ -143,6 +188,7 @@ class SettingsManager {
 
                 // Update local config
                 this.config = configData;
+                await this.refreshAudioDevices();
             } else {
                 const error = await reloadResponse.json();
                 this.showStatus(`Auto-save succeeded, reload failed: ${error.error}`, 'error');
@@ -162,9 +208,11 @@ class SettingsManager {
             }
 
             this.config = await response.json();
+            this.audioDevices = this.config.audio?.devices || {};
             await this.loadSerialDevices();
             await this.loadAudioDevices();
             this.populateForm();
+            await this.loadRigModels();
             this.loadStorageStats();
             this.showStatus('Configuration loaded successfully', 'success');
 
@@ -250,6 +298,55 @@ class SettingsManager {
         }
     }
 
+    // Fills the radio model dropdown from Hamlib's model list, keeping the
+    // built-in options when it can't be had
+    async loadRigModels(search = '') {
+        try {
+            const response = await fetch(`/api/v1/radio/models?search=${encodeURIComponent(search)}`);
+            if (!response.ok) {
+                throw new Error(`HTTP ${response.status}`);
+            }
+            const data = await response.json();
+
+            const modelSelect = document.getElementById('radio-model');
+            const selected = modelSelect.value || this.config.radio?.model || '1';
+            const models = data.models || [];
+            // The dummy rig stands in when Hamlib is off
+            if (!models.some(model => String(model.id) === '1')) {
+                models.unshift({ id: 1, manufacturer: 'Hamlib', model: 'Dummy', status: '' });
+            }
+
+            modelSelect.innerHTML = '';
+            const groups = {};
+            models.forEach(model => {
+                if (!groups[model.manufacturer]) {
+                    groups[model.manufacturer] = document.createElement('optgroup');
+                    groups[model.manufacturer].label = model.manufacturer;
+                }
+                const option = document.createElement('option');
+                option.value = String(model.id);
+                option.textContent = `${model.manufacturer} ${model.model} (${model.id})`;
+                if (model.status && model.status !== 'Stable') {
+                    option.textContent += ` - ${model.status}`;
+                }
+                groups[model.manufacturer].appendChild(option);
+            });
+            Object.keys(groups).sort().forEach(name => modelSelect.appendChild(groups[name]));
+
+            // Keep the chosen model even when the search leaves it out
+            if (!modelSelect.querySelector(`option[value="${selected}"]`)) {
+                const option = document.createElement('option');
+                option.value = selected;
+                option.textContent = `Model ${selected}`;
+                modelSelect.insertBefore(option, modelSelect.firstChild);
+            }
+            modelSelect.value = selected;
+
+        } catch (error) {
+            console.error('Failed to load radio models:', error);
+        }
+    }
+
     async loadAudioDevices() {
         try {
             console.log('Loading audio devices...');
@@ -375,17 +472,23 @@ class SettingsManager {
         // Audio Configuration
         this.setFormValue('audio-input', this.config.audio?.input_device || 'default');
         this.setFormValue('audio-input-channels', this.config.audio?.input_channels || 'mono');
+        this.setFormValue('audio-input-channel', this.config.audio?.input_channel || 'left');
         this.setFormValue('audio-output', this.config.audio?.output_device || 'default');
         this.setFormValue('audio-output-channels', this.config.audio?.output_channels || 'mono');
+        this.setFormValue('audio-output-channel', this.config.audio?.output_channel || 'both');
         this.setFormValue('audio-notification-output', this.config.audio?.notification_device || 'Built-in Output');
         this.setFormValue('audio-sample-rate', this.config.audio?.sample_rate || 48000);
         this.setFormValue('audio-buffer-size', this.config.audio?.buffer_size || 1024);
+        this.setFormValue('audio-half-duplex', this.config.audio?.half_duplex || false);
+        this.setFormValue('audio-input-gain', this.config.audio?.input_gain || 0);
+        this.setFormValue('audio-output-gain', this.config.audio?.output_gain || 0);
         this.setFormValue('audio-save-directory', this.config.audio?.save_directory || '/Users/doug/Library/Application Support/JS8Call/save');
         this.setFormValue('audio-remember-power-tx', this.config.audio?.remember_power_tx || false);
         this.setFormValue('audio-remember-power-tune', this.config.audio?.remember_power_tune || false);
 
         // Radio Configuration
         this.setFormValue('radio-use-hamlib', this.config.radio?.use_hamlib || false);
+        this.setFormValue('radio-backend', this.config.radio?.backend || 'hamlib');
         this.setFormValue('radio-model', this.config.radio?.model || '1');
         this.setFormValue('radio-poll-interval', this.config.radio?.poll_interval || 1000);
 
@@ -412,6 +515,8 @@ class SettingsManager {
         this.setRadioValue('radio.split_operation', this.config.radio?.split_operation || 'rig');
         this.setFormValue('radio-ptt-command', this.config.radio?.ptt_command || '');
         this.setFormValue('radio-tx-delay', this.config.radio?.tx_delay || 0.2);
+        this.setFormValue('radio-tx-tail', this.config.radio?.tx_tail || 0);
+        this.setFormValue('radio-vox-leader', this.config.radio?.vox_leader || 0.1);
 
         // Web Configuration
         this.setFormValue('web-port', this.config.web?.port || 8080);
@@ -424,6 +529,10 @@ class SettingsManager {
         this.setFormValue('hardware-enable-gpio', this.config.hardware?.enable_gpio || false);
         this.setFormValue('hardware-ptt-gpio-pin', this.config.hardware?.ptt_gpio_pin || 18);
         this.setFormValue('hardware-status-led-pin', this.config.hardware?.status_led_pin || 19);
+        this.setFormValue('hardware-amp-keying', this.config.hardware?.amp_keying || false);
+        this.setFormValue('hardware-amp-gpio-pin', this.config.hardware?.amp_gpio_pin || 23);
+        this.setFormValue('hardware-amp-lead', this.config.hardware?.amp_lead || 0.05);
+        this.setFormValue('hardware-amp-tail', this.config.hardware?.amp_tail || 0.05);
         this.setFormValue('hardware-enable-oled', this.config.hardware?.enable_oled || false);
         this.setFormValue('hardware-oled-width', this.config.hardware?.oled_width || 128);
         this.setFormValue('hardware-oled-height', this.config.hardware?.oled_height || 64);
@@ -470,7 +579,11 @@ class SettingsManager {
             return element.checked;
         } else if (element.type === 'number') {
             const value = element.value;
-            return value === '' ? 0 : parseInt(value);
+            if (value === '') {
+                return 0;
+            }
+            // Fields in fractions of a second have a fractional step
+            return element.step.includes('.') ? parseFloat(value) : parseInt(value);
         } else if (element.tagName === 'SELECT') {
             // Check if this select contains numeric values
             const numericSelects = [
@@ -498,17 +611,23 @@ class SettingsManager {
             audio: {
                 input_device: this.getFormValue('audio-input'),
                 input_channels: this.getFormValue('audio-input-channels'),
+                input_channel: this.getFormValue('audio-input-channel'),
                 output_device: this.getFormValue('audio-output'),
                 output_channels: this.getFormValue('audio-output-channels'),
+                output_channel: this.getFormValue('audio-output-channel'),
                 notification_device: this.getFormValue('audio-notification-output'),
                 sample_rate: this.getFormValue('audio-sample-rate'),
                 buffer_size: this.getFormValue('audio-buffer-size'),
+                half_duplex: this.getFormValue('audio-half-duplex'),
+                input_gain: this.getFormValue('audio-input-gain'),
+                output_gain: this.getFormValue('audio-output-gain'),
                 save_directory: this.getFormValue('audio-save-directory'),
                 remember_power_tx: this.getFormValue('audio-remember-power-tx'),
                 remember_power_tune: this.getFormValue('audio-remember-power-tune')
             },
             radio: {
                 use_hamlib: this.getFormValue('radio-use-hamlib'),
+                backend: this.getFormValue('radio-backend'),
                 model: this.getFormValue('radio-model'),
                 poll_interval: this.getFormValue('radio-poll-interval'),
                 device: this.getFormValue('radio-device'),
@@ -525,6 +644,8 @@ class SettingsManager {
                 split_operation: this.getRadioValue('radio.split_operation'),
                 ptt_command: this.getFormValue('radio-ptt-command'),
                 tx_delay: this.getFormValue('radio-tx-delay'),
+                tx_tail: this.getFormValue('radio-tx-tail'),
+                vox_leader: this.getFormValue('radio-vox-leader'),
                 civ_address: this.getFormValue('radio-civ-address'),
                 civ_transceive: this.getFormValue('radio-civ-transceive')
             },
@@ -539,6 +660,10 @@ class SettingsManager {
                 enable_gpio: this.getFormValue('hardware-enable-gpio'),
                 ptt_gpio_pin: this.getFormValue('hardware-ptt-gpio-pin'),
                 status_led_pin: this.getFormValue('hardware-status-led-pin'),
+                amp_keying: this.getFormValue('hardware-amp-keying'),
+                amp_gpio_pin: this.getFormValue('hardware-amp-gpio-pin'),
+                amp_lead: this.getFormValue('hardware-amp-lead'),
+                amp_tail: this.getFormValue('hardware-amp-tail'),
                 enable_oled: this.getFormValue('hardware-enable-oled'),
                 oled_width: this.getFormValue('hardware-oled-width'),
                 oled_height: this.getFormValue('hardware-oled-height')
@@ -562,10 +687,16 @@ class SettingsManager {
 
             if (response.ok) {
                 const data = await response.json();
-                this.showStatus(`Configuration saved to ${data.path}`, 'success');
+                this.showFieldErrors([]);
+                if (data.warnings) {
+                    this.showStatus(`Saved with warnings: ${data.warnings.join('; ')}`, 'error');
+                } else {
+                    this.showStatus(`Configuration saved to ${data.path}`, 'success');
+                }
                 this.config = configData; // Update local config
             } else {
                 const error = await response.json();
+                this.showFieldErrors(error.fields);
                 this.showStatus(`Failed to save: ${error.error}`, 'error');
             }
 
@@ -616,6 +747,26 @@ class SettingsManager {
         }
     }
 
+    // Mark the inputs of settings the daemon rejected, e.g. radio.baud_rate
+    // is the radio-baud-rate input or the radio.baud_rate radio buttons
+    showFieldErrors(fields) {
+        document.querySelectorAll('.field-error').forEach(element => {
+            element.classList.remove('field-error');
+            element.removeAttribute('title');
+        });
+
+        (fields || []).forEach(field => {
+            const id = field.field.replace(/[._]/g, '-');
+            const elements = document.getElementById(id) ?
+                [document.getElementById(id)] :
+                document.querySelectorAll(`input[name="${field.field}"]`);
+            elements.forEach(element => {
+                element.classList.add('field-error');
+                element.title = field.message;
+            });
+        });
+    }
+
     showStatus(message, type) {
         const statusElement = document.getElementById('status-message');
         statusElement.textContent = message;
@@ -821,6 +972,47 @@ class SettingsManager {
         }
     }
 
+    // Finds a QRP Labs rig on USB and saves the settings it needs
+    async detectRig() {
+        try {
+            this.showStatus('Looking for a QRP Labs rig...', 'info');
+            const response = await fetch('/api/v1/radio/detect');
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+            if (!data.rigs || data.rigs.length === 0) {
+                this.showStatus('No QDX or QMX found; check the USB cable', 'error');
+                return;
+            }
+
+            const rig = data.rigs[0];
+            if (!confirm(`Found ${rig.name} on ${rig.serial_device}. Apply its recommended settings?`)) {
+                this.showStatus(`Found ${rig.name}`, 'info');
+                return;
+            }
+            const saved = await fetch('/api/v1/config', {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                },
+                body: JSON.stringify(rig.settings),
+            });
+            if (!saved.ok) {
+                const error = await saved.json();
+                this.showFieldErrors(error.fields);
+                this.showStatus(`Failed to save: ${error.error}`, 'error');
+                return;
+            }
+
+            await this.loadConfig();
+            this.showStatus(`${rig.name} set up; reload the daemon to use it`, 'success');
+        } catch (error) {
+            console.error('Failed to detect rig:', error);
+            this.showStatus(`Failed to detect rig: ${error.message}`, 'error');
+        }
+    }
+
     async retryRadioConnection() {
         const button = document.getElementById('retry-radio-connection');
         const originalText = button.textContent;
@@ -881,7 +1073,7 @@ class SettingsManager {
         }
 
         // Validate radio configuration
-        if (this.getFormValue('radio-use-hamlib')) {
+        if (this.getFormValue('radio-use-hamlib') || this.getFormValue('radio-backend') === 'native') {
             const device = this.getFormValue('radio-device');
             if (!device || device.trim() === '') {
                 this.showStatus('Serial device is required when using Hamlib', 'error');
@@ -914,6 +1106,24 @@ class SettingsManager {
             }
             document.getElementById('stat-last-cleanup').textContent = cleanupText;
 
+            // Latest integrity check and backup
+            let integrityText = 'Not run yet';
+            const integrity = stats.integrity;
+            if (integrity) {
+                const checked = new Date(integrity.checked).toLocaleString();
+                if (integrity.error) {
+                    integrityText = `Failed ${checked}: ${integrity.error}`;
+                } else if (!integrity.ok) {
+                    integrityText = `CORRUPT ${checked}: ${integrity.problems.join('; ')}`;
+                } else {
+                    integrityText = `OK ${checked}`;
+                    if (integrity.backed_up) {
+                        integrityText += `, backed up ${new Date(integrity.backed_up).toLocaleString()}`;
+                    }
+                }
+            }
+            document.getElementById('stat-integrity').textContent = integrityText;
+
         } catch (error) {
             console.error('Failed to load storage stats:', error);
             // Set error indicators
@@ -922,9 +1132,163 @@ class SettingsManager {
             document.getElementById('stat-total-rx').textContent = errorText;
             document.getElementById('stat-total-tx').textContent = errorText;
             document.getElementById('stat-last-cleanup').textContent = errorText;
+            document.getElementById('stat-integrity').textContent = errorText;
+        }
+    }
+
+    async checkUpdate() {
+        const button = document.getElementById('check-update');
+        const latest = document.getElementById('system-latest');
+        button.disabled = true;
+
+        try {
+            const response = await fetch('/api/v1/system/update');
+            const result = await response.json();
+            if (!response.ok) {
+                latest.textContent = 'Check failed';
+                this.showStatus(`Update check failed: ${result.error}`, 'error');
+                return;
+            }
+
+            const link = document.createElement('a');
+            link.href = result.latest.url;
+            link.target = '_blank';
+            link.rel = 'noopener';
+            link.textContent = result.latest.version;
+            latest.replaceChildren(link);
+            if (result.update_available) {
+                this.showStatus(`js8d ${result.latest.version} is available, this station runs ${result.current}`, 'info');
+            } else {
+                this.showStatus('js8d is up to date', 'success');
+            }
+        } catch (error) {
+            console.error('Update check failed:', error);
+            this.showStatus('Update check failed: Network error', 'error');
+        } finally {
+            button.disabled = false;
+        }
+    }
+
+    async runSelfTest() {
+        const button = document.getElementById('run-selftest');
+        button.disabled = true;
+        this.showStatus('Running the DSP self-test...', 'info');
+
+        try {
+            const response = await fetch('/api/v1/system/selftest', {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                },
+                body: JSON.stringify({ sweep: true })
+            });
+            const result = await response.json();
+            if (!response.ok) {
+                this.showStatus(`DSP self-test failed: ${result.error}`, 'error');
+                return;
+            }
+
+            const test = result.selftest;
+            if (!test.passed) {
+                this.showStatus(`DSP self-test failed: sent ${test.message}, decoded "${test.decoded}"`, 'error');
+            } else if (test.threshold !== undefined) {
+                this.showStatus(`DSP self-test passed on the ${test.decoder} decoder, decoding down to ${test.threshold} dB`, 'success');
+            } else {
+                this.showStatus(`DSP self-test passed on the ${test.decoder} decoder`, 'success');
+            }
+        } catch (error) {
+            console.error('DSP self-test failed:', error);
+            this.showStatus('DSP self-test failed: Network error', 'error');
+        } finally {
+            button.disabled = false;
+        }
+    }
+
+    async restartDaemon() {
+        if (!confirm('Restart js8d? Any transmission in progress finishes first.')) {
+            return;
+        }
+        const started = await this.daemonStarted();
+        if (await this.systemAction('/api/v1/system/restart', 'Restarting js8d...')) {
+            this.waitForDaemon(started, 'js8d restarted');
+        }
+    }
+
+    async rebootHost() {
+        if (!confirm('Reboot the computer js8d runs on? The station is off the air until it comes back.')) {
+            return;
+        }
+        const started = await this.daemonStarted();
+        if (await this.systemAction('/api/v1/system/reboot', 'Rebooting the host...')) {
+            this.waitForDaemon(started, 'Host rebooted, js8d is back');
         }
     }
 
+    // POSTs a restart or reboot, reporting whether the daemon accepted it
+    async restoreBackup(input) {
+        const file = input.files[0];
+        input.value = '';
+        if (!file || !confirm(`Replace this node's configuration and messages with ${file.name} and restart js8d?`)) {
+            return;
+        }
+        const started = await this.daemonStarted();
+        const body = new FormData();
+        body.append('backup', file);
+        try {
+            this.showStatus('Restoring backup...', 'info');
+            const response = await fetch('/api/v1/system/restore', { method: 'POST', body });
+            const result = await response.json();
+            if (!response.ok) {
+                this.showStatus(`Failed: ${result.error}`, 'error');
+                return;
+            }
+            this.showStatus(`Restored backup of ${result.manifest.callsign}, restarting js8d...`, 'info');
+            this.waitForDaemon(started, 'Backup restored, js8d restarted');
+        } catch (error) {
+            console.error('Restore failed:', error);
+            this.showStatus('Failed: Network error', 'error');
+        }
+    }
+
+    async systemAction(url, message) {
+        try {
+            const response = await fetch(url, { method: 'POST' });
+            const result = await response.json();
+            if (!response.ok) {
+                this.showStatus(`Failed: ${result.error}`, 'error');
+                return false;
+            }
+            this.showStatus(message, 'info');
+            return true;
+        } catch (error) {
+            console.error(`${url} failed:`, error);
+            this.showStatus('Failed: Network error', 'error');
+            return false;
+        }
+    }
+
+    // When the daemon started, or null if it can't be asked
+    async daemonStarted() {
+        try {
+            const response = await fetch('/api/v1/status');
+            return response.ok ? (await response.json()).started : null;
+        } catch (error) {
+            return null;
+        }
+    }
+
+    // Polls the status until a daemon started after `started` answers
+    waitForDaemon(started, message) {
+        const poll = setInterval(async () => {
+            const now = await this.daemonStarted();
+            if (now && now !== started) {
+                clearInterval(poll);
+                this.showStatus(message, 'success');
+                this.loadConfig();
+            }
+        }, 2000);
+    }
+
     async cleanupMessages() {
         if (!confirm('This will permanently delete old messages to free up space. Continue?')) {
             return;
@@ -963,12 +1327,44 @@ class SettingsManager {
     }
 
 
+    recallAudioDevice(device) {
+        const remembered = this.audioDevices?.[device];
+        if (!remembered) {
+            return;
+        }
+        this.setFormValue('audio-sample-rate', remembered.sample_rate || 48000);
+        this.setFormValue('audio-input-channels', remembered.input_channels || 'mono');
+        this.setFormValue('audio-output-channels', remembered.output_channels || 'mono');
+        if (remembered.input_channel) {
+            this.setFormValue('audio-input-channel', remembered.input_channel);
+        }
+        if (remembered.output_channel) {
+            this.setFormValue('audio-output-channel', remembered.output_channel);
+        }
+        this.setFormValue('audio-input-gain', remembered.input_gain || 0);
+        this.setFormValue('audio-output-gain', remembered.output_gain || 0);
+        this.showStatus(`Settings for ${device} recalled`, 'info');
+    }
+
+    async refreshAudioDevices() {
+        try {
+            const response = await fetch('/api/v1/config/audio');
+            if (response.ok) {
+                const audio = await response.json();
+                this.audioDevices = audio.devices || {};
+            }
+        } catch (error) {
+            console.error('Failed to refresh remembered audio devices:', error);
+        }
+    }
+
     handleHamlibChange(useHamlib) {
         const radioModel = document.getElementById('radio-model');
         const radioDevice = document.getElementById('radio-device');
         const testCatButton = document.getElementById('test-cat');
 
-        if (useHamlib) {
+        // The native backend controls the radio without Hamlib
+        if (useHamlib || this.getFormValue('radio-backend') === 'native') {
             // Enable real radio selection
             radioModel.disabled = false;
             radioDevice.disabled = false;
@@ -0,0 +1,58 @@
+// js8d themes - loaded in <head> so the saved theme applies before the page
+// paints. The choice is kept in local storage and in a cookie, which lets
+// the server render pages in it too.
+
+(function () {
+    const themes = ['dark', 'light', 'high-contrast', 'night'];
+    const storageKey = 'js8d-theme';
+    const cookieName = 'js8d_theme';
+
+    function saved() {
+        let theme = null;
+        try {
+            theme = localStorage.getItem(storageKey);
+        } catch (error) {
+            // Storage is off in some private browsing modes; the cookie remains
+        }
+        if (!theme) {
+            const match = document.cookie.match(new RegExp(`(?:^|; )${cookieName}=([^;]*)`));
+            theme = match ? decodeURIComponent(match[1]) : null;
+        }
+        return themes.includes(theme) ? theme : document.documentElement.dataset.theme || 'dark';
+    }
+
+    function apply(theme) {
+        document.documentElement.dataset.theme = theme;
+        document.querySelectorAll('.theme-select').forEach(select => {
+            select.value = theme;
+        });
+    }
+
+    function save(theme) {
+        try {
+            localStorage.setItem(storageKey, theme);
+        } catch (error) {
+            // See saved()
+        }
+        document.cookie = `${cookieName}=${encodeURIComponent(theme)}; path=/; max-age=${365 * 24 * 3600}; SameSite=Lax`;
+    }
+
+    apply(saved());
+
+    document.addEventListener('DOMContentLoaded', () => {
+        document.querySelectorAll('.theme-select').forEach(select => {
+            select.value = document.documentElement.dataset.theme;
+            select.addEventListener('change', () => {
+                apply(select.value);
+                save(select.value);
+            });
+        });
+    });
+
+    // Another tab changed the theme
+    window.addEventListener('storage', event => {
+        if (event.key === storageKey && themes.includes(event.newValue)) {
+            apply(event.newValue);
+        }
+    });
+})();
@@ -0,0 +1,22 @@
+// Package web holds the templates and static files of the web interface.
+// They are embedded in the binary, so js8d runs without them on disk.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+)
+
+//go:embed templates static
+var files embed.FS
+
+// Files returns the web interface files, with templates/ and static/ at its
+// root. Those in dir are used when it is set, so the interface can be worked
+// on without rebuilding js8d; otherwise the embedded copy is.
+func Files(dir string) fs.FS {
+	if dir != "" {
+		return os.DirFS(dir)
+	}
+	return files
+}
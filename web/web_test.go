@@ -0,0 +1,17 @@
+package web
+
+import (
+	"io/fs"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	for _, dir := range []string{"", "."} {
+		files := Files(dir)
+		for _, name := range []string{"templates/index.html", "templates/settings.html", "static/js/main.js", "static/css/main.css"} {
+			if _, err := fs.Stat(files, name); err != nil {
+				t.Errorf("Files(%q) is missing %s: %v", dir, name, err)
+			}
+		}
+	}
+}